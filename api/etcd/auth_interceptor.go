@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"strings"
 
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
+
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -70,8 +73,18 @@ func (s *Server) AuthInterceptor(
 		return nil, status.Errorf(codes.InvalidArgument, "failed to extract permission: %v", err)
 	}
 
-	// 如果需要权限检查（key 不为 nil）
-	if key != nil {
+	// 范围查询在存储引擎遍历时跳过没有读权限的键，而不是只检查起始 key；
+	// 单键查询和其他请求检查 key 的权限
+	if r, ok := req.(*pb.RangeRequest); ok && info.FullMethod == "/etcdserverpb.KV/Range" && len(r.RangeEnd) > 0 {
+		visible, err := s.authMgr.RangeReadFilter(tokenInfo.Username, r.Key, r.RangeEnd)
+		if err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "permission denied: %v", err)
+		}
+		if visible != nil {
+			ctx = kvstore.WithKeyFilter(ctx, visible)
+		}
+	} else if key != nil {
+		// 如果需要权限检查（key 不为 nil）
 		err = s.authMgr.CheckPermission(tokenInfo.Username, key, permType)
 		if err != nil {
 			return nil, status.Errorf(codes.PermissionDenied, "permission denied: %v", err)
@@ -80,6 +93,7 @@ func (s *Server) AuthInterceptor(
 
 	// 将用户信息注入 context
 	ctx = context.WithValue(ctx, "username", tokenInfo.Username)
+	ctx = admission.WithUser(ctx, tokenInfo.Username)
 
 	return handler(ctx, req)
 }
@@ -151,6 +165,17 @@ func extractPermissionFromRequest(method string, req interface{}) (key []byte, p
 		}
 		return nil, PermissionReadWrite, nil
 
+	case BatchWriteMethod:
+		// 批量写入与事务相同，简化为检查写权限
+		return []byte(""), PermissionWrite, nil
+
+	case KeyHistoryMethod:
+		r, ok := req.(*pb.RangeRequest)
+		if !ok {
+			return nil, PermissionRead, fmt.Errorf("invalid request type for KeyHistory")
+		}
+		return r.Key, PermissionRead, nil
+
 	case "/etcdserverpb.KV/Compact":
 		// Compact 需要特殊权限，通常只有管理员可以执行
 		return []byte(""), PermissionWrite, nil
@@ -17,7 +17,9 @@ package etcd
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sync/atomic"
@@ -50,6 +52,7 @@ type AuthManager struct {
 	tokenCleanupInterval time.Duration // Token 清理间隔
 	bcryptCost           int           // bcrypt 加密强度
 	enableAudit          bool          // 是否启用审计日志
+	failClosedRanges     bool          // 范围查询包含无读权限的键时拒绝，而不是跳过这些键
 }
 
 // NewAuthManager creates an Auth manager with concurrent-safe maps
@@ -75,6 +78,7 @@ func NewAuthManager(store kvstore.Store, cfg ...*config.AuthConfig) *AuthManager
 		tokenCleanupInterval: authCfg.TokenCleanupInterval,
 		bcryptCost:           authCfg.BcryptCost,
 		enableAudit:          authCfg.EnableAudit,
+		failClosedRanges:     authCfg.FailClosedRanges,
 	}
 
 	// Load authentication state from storage
@@ -88,15 +92,18 @@ func NewAuthManager(store kvstore.Store, cfg ...*config.AuthConfig) *AuthManager
 
 // loadState loads authentication state from storage
 func (am *AuthManager) loadState() error {
+	// 启动时还不一定有 leader，直接读取本地状态
+	ctx := kvstore.WithSerializable(context.Background())
+
 	// 1. Read /__auth/enabled
-	resp, err := am.store.Range(context.Background(), authEnabledKey, "", 1, 0)
+	resp, err := am.store.Range(ctx, authEnabledKey, "", 1, 0)
 	if err == nil && len(resp.Kvs) > 0 {
 		am.enabled.Store(string(resp.Kvs[0].Value) == "true")
 	}
 
 	// 2. Load all users
 	endKey := authUserPrefix + "\xff"
-	resp, err = am.store.Range(context.Background(), authUserPrefix, endKey, 0, 0)
+	resp, err = am.store.Range(ctx, authUserPrefix, endKey, 0, 0)
 	if err == nil {
 		for _, kv := range resp.Kvs {
 			var user UserInfo
@@ -108,7 +115,7 @@ func (am *AuthManager) loadState() error {
 
 	// 3. Load all roles
 	endKey = authRolePrefix + "\xff"
-	resp, err = am.store.Range(context.Background(), authRolePrefix, endKey, 0, 0)
+	resp, err = am.store.Range(ctx, authRolePrefix, endKey, 0, 0)
 	if err == nil {
 		for _, kv := range resp.Kvs {
 			var role RoleInfo
@@ -120,7 +127,7 @@ func (am *AuthManager) loadState() error {
 
 	// 4. Load valid tokens (skip expired ones)
 	endKey = authTokenPrefix + "\xff"
-	resp, err = am.store.Range(context.Background(), authTokenPrefix, endKey, 0, 0)
+	resp, err = am.store.Range(ctx, authTokenPrefix, endKey, 0, 0)
 	if err == nil {
 		now := time.Now().Unix()
 		for _, kv := range resp.Kvs {
@@ -300,6 +307,19 @@ func (am *AuthManager) CheckPermission(username string, key []byte, permType Per
 	return fmt.Errorf("permission denied")
 }
 
+// MySQLPasswordHash 返回用户的 mysql_native_password 校验值 SHA1(SHA1(password))
+// 启用该功能之前创建的用户没有校验值，需要修改一次密码后才能通过 MySQL 协议登录
+func (am *AuthManager) MySQLPasswordHash(username string) ([]byte, error) {
+	user, exists := am.users.Load(username)
+	if !exists {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+	if user.MySQLPasswordHash == "" {
+		return nil, fmt.Errorf("user %s has no MySQL credential, change the password to create one", username)
+	}
+	return hex.DecodeString(user.MySQLPasswordHash)
+}
+
 // keyInRange 检查 key 是否在 [start, end) 范围内
 func (am *AuthManager) keyInRange(key, start, end []byte) bool {
 	if len(end) == 0 {
@@ -328,10 +348,11 @@ func (am *AuthManager) AddUser(name, password string) error {
 
 	// 3. Create UserInfo
 	user := &UserInfo{
-		Name:         name,
-		PasswordHash: passwordHash,
-		Roles:        []string{},
-		CreatedAt:    time.Now().Unix(),
+		Name:              name,
+		PasswordHash:      passwordHash,
+		MySQLPasswordHash: mysqlNativeHash(password),
+		Roles:             []string{},
+		CreatedAt:         time.Now().Unix(),
 	}
 
 	// 4. Persist to storage
@@ -439,6 +460,7 @@ func (am *AuthManager) ChangePassword(name, newPassword string) error {
 
 	// 3. Update user info
 	user.PasswordHash = passwordHash
+	user.MySQLPasswordHash = mysqlNativeHash(newPassword)
 
 	// 4. Persist
 	key := authUserPrefix + name
@@ -738,6 +760,13 @@ func hashPassword(password string) (string, error) {
 	return string(bytes), err
 }
 
+// mysqlNativeHash 计算 mysql_native_password 校验值（与 MySQL authentication_string 相同，不含 '*' 前缀）
+func mysqlNativeHash(password string) string {
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+	return hex.EncodeToString(stage2[:])
+}
+
 // checkPasswordHash 验证密码
 func checkPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
@@ -0,0 +1,122 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// keyInterval 权限覆盖的 key 区间 [start, end)，end 为 nil 表示直到最后一个 key
+type keyInterval struct {
+	start, end []byte
+}
+
+// newKeyInterval 按 etcd 的约定把 key 和 rangeEnd 转换为区间：
+// rangeEnd 为空表示单个 key，"\x00" 表示 key 之后的所有键
+func newKeyInterval(key, rangeEnd []byte) keyInterval {
+	switch {
+	case len(rangeEnd) == 0:
+		return keyInterval{start: key, end: append(append([]byte{}, key...), 0)}
+	case bytes.Equal(rangeEnd, []byte{0}):
+		return keyInterval{start: key}
+	default:
+		return keyInterval{start: key, end: rangeEnd}
+	}
+}
+
+// RangeReadFilter 返回用户读取 [key, rangeEnd) 时用于 kvstore.WithKeyFilter 的过滤函数
+//
+// 读权限覆盖整个范围时（包括 root）返回 nil，不需要逐个检查 key。否则默认返回只保留
+// 有读权限的键的过滤函数，列表结果中没有权限的键被跳过，而不是让整个请求失败；
+// 开启 auth.fail_closed_ranges 时与 etcd 相同，返回错误拒绝整个请求
+func (am *AuthManager) RangeReadFilter(username string, key, rangeEnd []byte) (func(key []byte) bool, error) {
+	if username == "root" {
+		return nil, nil
+	}
+	intervals, err := am.readIntervals(username)
+	if err != nil {
+		return nil, err
+	}
+
+	want := newKeyInterval(key, rangeEnd)
+	i := sort.Search(len(intervals), func(i int) bool {
+		return bytes.Compare(intervals[i].start, want.start) > 0
+	}) - 1
+	if i >= 0 && intervals[i].contains(want) {
+		return nil, nil
+	}
+	if am.failClosedRanges {
+		return nil, fmt.Errorf("permission denied: no read permission on part of the range")
+	}
+
+	return func(key []byte) bool {
+		i := sort.Search(len(intervals), func(i int) bool {
+			return bytes.Compare(intervals[i].start, key) > 0
+		}) - 1
+		return i >= 0 && (intervals[i].end == nil || bytes.Compare(key, intervals[i].end) < 0)
+	}, nil
+}
+
+// contains 返回 other 是否完全在区间内
+func (iv keyInterval) contains(other keyInterval) bool {
+	if bytes.Compare(other.start, iv.start) < 0 {
+		return false
+	}
+	if iv.end == nil {
+		return true
+	}
+	return other.end != nil && bytes.Compare(other.end, iv.end) <= 0
+}
+
+// readIntervals 返回用户所有角色的读权限覆盖的区间，按 start 排序，重叠或相邻的区间已合并
+func (am *AuthManager) readIntervals(username string) ([]keyInterval, error) {
+	user, exists := am.users.Load(username)
+	if !exists {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+
+	var intervals []keyInterval
+	for _, roleName := range user.Roles {
+		role, exists := am.roles.Load(roleName)
+		if !exists {
+			continue
+		}
+		for _, perm := range role.Permissions {
+			if perm.Type == PermissionRead || perm.Type == PermissionReadWrite {
+				intervals = append(intervals, newKeyInterval(perm.Key, perm.RangeEnd))
+			}
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return bytes.Compare(intervals[i].start, intervals[j].start) < 0
+	})
+
+	merged := intervals[:0]
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.end == nil || bytes.Compare(iv.start, last.end) <= 0 {
+				if last.end != nil && (iv.end == nil || bytes.Compare(iv.end, last.end) > 0) {
+					last.end = iv.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, iv)
+	}
+	return merged, nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func grantRead(t *testing.T, am *AuthManager, role string, perms ...Permission) {
+	t.Helper()
+	if err := am.AddRole(role); err != nil {
+		t.Fatalf("AddRole: %v", err)
+	}
+	for _, perm := range perms {
+		if err := am.GrantPermission(role, perm); err != nil {
+			t.Fatalf("GrantPermission: %v", err)
+		}
+	}
+}
+
+func TestRangeReadFilter(t *testing.T) {
+	srv, cleanup := setupAuthTest(t)
+	defer cleanup()
+	am := srv.authMgr
+
+	if err := am.AddUser("user1", "pass"); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	grantRead(t, am, "role1",
+		Permission{Type: PermissionRead, Key: []byte("/a/"), RangeEnd: []byte("/a0")},
+		Permission{Type: PermissionReadWrite, Key: []byte("/a0"), RangeEnd: []byte("/a1")},
+		Permission{Type: PermissionRead, Key: []byte("/b/x")},
+		Permission{Type: PermissionWrite, Key: []byte("/c/"), RangeEnd: []byte("/c0")},
+	)
+	if err := am.GrantRole("user1", "role1"); err != nil {
+		t.Fatalf("GrantRole: %v", err)
+	}
+
+	// Ranges covered by the read permissions, adjacent ones merged, need no filter
+	for _, r := range [][2]string{{"/a/", "/a0"}, {"/a/", "/a1"}, {"/a/x", "/a0z"}, {"/b/x", ""}} {
+		visible, err := am.RangeReadFilter("user1", []byte(r[0]), []byte(r[1]))
+		if err != nil || visible != nil {
+			t.Fatalf("range %q: got filter %v, err %v; want covered", r, visible != nil, err)
+		}
+	}
+	if visible, err := am.RangeReadFilter("root", []byte("/"), []byte{0}); err != nil || visible != nil {
+		t.Fatalf("root: got filter %v, err %v; want covered", visible != nil, err)
+	}
+
+	visible, err := am.RangeReadFilter("user1", []byte("/"), []byte("/z"))
+	if err != nil || visible == nil {
+		t.Fatalf("partially readable range: got filter %v, err %v", visible != nil, err)
+	}
+	for key, want := range map[string]bool{
+		"/a/1": true, "/a0": true, "/a0/x": true, "/a1": false,
+		"/b/x": true, "/b/y": false, "/c/1": false, "/": false,
+	} {
+		if got := visible([]byte(key)); got != want {
+			t.Errorf("visible(%q) = %v, want %v", key, got, want)
+		}
+	}
+
+	if _, err := am.RangeReadFilter("nobody", []byte("/"), []byte("/z")); err == nil {
+		t.Fatal("unknown user should be rejected")
+	}
+
+	am.failClosedRanges = true
+	if _, err := am.RangeReadFilter("user1", []byte("/"), []byte("/z")); err == nil {
+		t.Fatal("fail-closed range should be rejected")
+	}
+	if visible, err := am.RangeReadFilter("user1", []byte("/a/"), []byte("/a1")); err != nil || visible != nil {
+		t.Fatalf("fail-closed covered range: got filter %v, err %v", visible != nil, err)
+	}
+}
+
+func TestRangeHidesUnreadableKeys(t *testing.T) {
+	srv, cleanup := setupAuthTest(t)
+	defer cleanup()
+	am := srv.authMgr
+
+	for _, user := range []string{"root", "user1"} {
+		if err := am.AddUser(user, "pass"); err != nil {
+			t.Fatalf("AddUser: %v", err)
+		}
+	}
+	grantRead(t, am, "role1", Permission{Type: PermissionRead, Key: []byte("/data/"), RangeEnd: []byte("/data0")})
+	if err := am.GrantRole("user1", "role1"); err != nil {
+		t.Fatalf("GrantRole: %v", err)
+	}
+	for _, key := range []string{"/data/1", "/data/2", "/other/1", "/secret"} {
+		if _, _, err := srv.store.PutWithLease(context.Background(), key, "v", 0); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+	if err := am.Enable(); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	token, err := am.Authenticate("user1", "pass")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	kv := &KVServer{server: srv}
+	info := &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.KV/Range"}
+	rangeAs := func(req *pb.RangeRequest) (*pb.RangeResponse, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("token", token))
+		resp, err := srv.AuthInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return kv.Range(ctx, req.(*pb.RangeRequest))
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.(*pb.RangeResponse), nil
+	}
+
+	// The start key is not readable, the readable keys are still listed
+	resp, err := rangeAs(&pb.RangeRequest{Key: []byte("/"), RangeEnd: []byte("0"), Limit: 1})
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Key) != "/data/1" || !resp.More || resp.Count != 2 {
+		t.Fatalf("got %d kvs, more %v, count %d; want /data/1, more, count 2", len(resp.Kvs), resp.More, resp.Count)
+	}
+
+	resp, err = rangeAs(&pb.RangeRequest{Key: []byte("/"), RangeEnd: []byte("0"), CountOnly: true})
+	if err != nil || resp.Count != 2 {
+		t.Fatalf("CountOnly: count %v, err %v; want 2", resp.GetCount(), err)
+	}
+
+	// A single key is still checked and denied
+	if _, err := rangeAs(&pb.RangeRequest{Key: []byte("/secret")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("single key: got %v, want PermissionDenied", err)
+	}
+
+	am.failClosedRanges = true
+	if _, err := rangeAs(&pb.RangeRequest{Key: []byte("/"), RangeEnd: []byte("0")}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("fail-closed: got %v, want PermissionDenied", err)
+	}
+	resp, err = rangeAs(&pb.RangeRequest{Key: []byte("/data/"), RangeEnd: []byte("/data0")})
+	if err != nil || len(resp.Kvs) != 2 {
+		t.Fatalf("fail-closed covered range: got %v, err %v", resp, err)
+	}
+}
@@ -16,10 +16,11 @@ package etcd
 
 // UserInfo 用户信息
 type UserInfo struct {
-	Name         string   `json:"name"`
-	PasswordHash string   `json:"password_hash"` // bcrypt hash
-	Roles        []string `json:"roles"`
-	CreatedAt    int64    `json:"created_at"`
+	Name              string   `json:"name"`
+	PasswordHash      string   `json:"password_hash"`                 // bcrypt hash
+	MySQLPasswordHash string   `json:"mysql_password_hash,omitempty"` // SHA1(SHA1(password))，MySQL 协议认证使用
+	Roles             []string `json:"roles"`
+	CreatedAt         int64    `json:"created_at"`
 }
 
 // RoleInfo 角色信息
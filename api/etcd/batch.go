@@ -0,0 +1,117 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+
+	"metaStore/internal/kvstore"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BatchWriteMethod 批量写入的 gRPC 方法（MetaStore 扩展服务，不属于 etcd API）
+//
+// 请求复用 etcd 的 TxnRequest：Success 中只能是 Put 和 DeleteRange，不能有 Compare
+// 和 Failure。所有操作作为一个 Raft 提案原子地应用，响应只带 header，不返回每个
+// 操作的结果，以免导入大量 key 时响应过大
+const BatchWriteMethod = "/metastore.Batch/Write"
+
+// defaultMaxBatchOps 未提供配置时一次批量写入的最大操作数
+const defaultMaxBatchOps = 10000
+
+// BatchServer 实现批量写入服务
+type BatchServer struct {
+	server *Server
+	maxOps int
+}
+
+// batchWriter 服务实现的接口，供 grpc.ServiceDesc 校验
+type batchWriter interface {
+	Write(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error)
+}
+
+var batchServiceDesc = grpc.ServiceDesc{
+	ServiceName: "metastore.Batch",
+	HandlerType: (*batchWriter)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Write",
+		Handler:    batchWriteHandler,
+	}},
+	Metadata: "metastore/batch",
+}
+
+func batchWriteHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(pb.TxnRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(batchWriter).Write(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: BatchWriteMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(batchWriter).Write(ctx, req.(*pb.TxnRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+// Write 把一批 Put/DeleteRange 作为一个事务提交
+func (b *BatchServer) Write(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	if len(req.Compare) > 0 || len(req.Failure) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "batch write does not accept compare or failure operations")
+	}
+	if len(req.Success) > b.maxOps {
+		return nil, status.Errorf(codes.InvalidArgument, "batch has %d operations, max %d", len(req.Success), b.maxOps)
+	}
+
+	ops := make([]kvstore.Op, len(req.Success))
+	for i, reqOp := range req.Success {
+		switch reqOp.Request.(type) {
+		case *pb.RequestOp_RequestPut, *pb.RequestOp_RequestDeleteRange:
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "batch operation %d is not a put or delete", i)
+		}
+		ops[i] = convertRequestOp(reqOp)
+	}
+	if len(ops) == 0 {
+		return &pb.TxnResponse{Header: b.server.getResponseHeader(), Succeeded: true}, nil
+	}
+
+	txnResp, err := b.server.store.Txn(ctx, nil, ops, nil)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	b.server.sendCommitIndex(ctx)
+
+	resp := &pb.TxnResponse{
+		Header:    b.server.getResponseHeader(),
+		Succeeded: true,
+	}
+	resp.Header.Revision = txnResp.Revision
+	return resp, nil
+}
+
+// BatchWrite 调用批量写入服务，cc 可以是 clientv3.Client.ActiveConnection()
+func BatchWrite(ctx context.Context, cc grpc.ClientConnInterface, ops []*pb.RequestOp, opts ...grpc.CallOption) (*pb.TxnResponse, error) {
+	resp := new(pb.TxnResponse)
+	if err := cc.Invoke(ctx, BatchWriteMethod, &pb.TxnRequest{Success: ops}, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// confChangeStore is a store whose raft configuration changes when the
+// conf changes proposed by the ClusterManager are applied
+type confChangeStore struct {
+	*memory.MemoryEtcd
+
+	mu      sync.Mutex
+	members []kvstore.MemberStatus
+}
+
+func (s *confChangeStore) Members() []kvstore.MemberStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.members)
+}
+
+func (s *confChangeStore) GetRaftStatus() kvstore.RaftStatus {
+	return kvstore.RaftStatus{NodeID: 1, LeaderID: 1, State: "leader"}
+}
+
+// apply applies a conf change the way raft does once it is committed
+func (s *confChangeStore) apply(cc raftpb.ConfChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch cc.Type {
+	case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
+		s.members = append(s.members, kvstore.MemberStatus{ID: cc.NodeID, IsLearner: cc.Type == raftpb.ConfChangeAddLearnerNode})
+	case raftpb.ConfChangeRemoveNode:
+		s.members = slices.DeleteFunc(s.members, func(m kvstore.MemberStatus) bool { return m.ID == cc.NodeID })
+	}
+}
+
+func TestMemberListReflectsMembership(t *testing.T) {
+	ctx := context.Background()
+	store := &confChangeStore{
+		MemoryEtcd: memory.NewMemoryEtcd(),
+		members:    []kvstore.MemberStatus{{ID: 1, IsLeader: true, PeerURL: "http://10.0.0.1:2380"}, {ID: 2, PeerURL: "http://10.0.0.2:2380"}},
+	}
+	confChangeC := make(chan raftpb.ConfChange, 1)
+	s := &Server{store: store, memberID: 1, clusterMgr: NewClusterManager(confChangeC)}
+	s.clusterMgr.InitialMembers([]*MemberInfo{{ID: 1}, {ID: 2}})
+	cluster := &ClusterServer{maintenance: &MaintenanceServer{server: s}}
+
+	list := func() []*pb.Member {
+		t.Helper()
+		resp, err := cluster.MemberList(ctx, &pb.MemberListRequest{})
+		if err != nil {
+			t.Fatalf("MemberList failed: %v", err)
+		}
+		return resp.Members
+	}
+	ids := func(members []*pb.Member) []uint64 {
+		var ids []uint64
+		for _, m := range members {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	if got := ids(list()); !slices.Equal(got, []uint64{1, 2}) {
+		t.Fatalf("Expected members [1 2], got %v", got)
+	}
+
+	added, err := cluster.MemberAdd(ctx, &pb.MemberAddRequest{PeerURLs: []string{"http://10.0.0.3:2380"}, IsLearner: true})
+	if err != nil {
+		t.Fatalf("MemberAdd failed: %v", err)
+	}
+	store.apply(<-confChangeC)
+	// leader 在前，其余按 ID 排序
+	want := []uint64{2, added.Member.ID}
+	slices.Sort(want)
+	members := list()
+	if got := ids(members); !slices.Equal(got, append([]uint64{1}, want...)) {
+		t.Fatalf("Expected the added member %d in %v", added.Member.ID, got)
+	}
+	for _, m := range members {
+		if m.ID != added.Member.ID {
+			continue
+		}
+		if !m.IsLearner || !slices.Equal(m.PeerURLs, []string{"http://10.0.0.3:2380"}) || m.Name != added.Member.Name {
+			t.Errorf("Expected the added learner with its peer URL from the registry, got %v", m)
+		}
+	}
+
+	if _, err := cluster.MemberRemove(ctx, &pb.MemberRemoveRequest{ID: 2}); err != nil {
+		t.Fatalf("MemberRemove failed: %v", err)
+	}
+	store.apply(<-confChangeC)
+	if got := ids(list()); !slices.Equal(got, []uint64{1, added.Member.ID}) {
+		t.Errorf("Expected members [1 %d] after removing 2, got %v", added.Member.ID, got)
+	}
+	records, err := s.loadMemberRegistry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[2] != nil || records[added.Member.ID] == nil {
+		t.Errorf("Expected the registry to drop member 2 and keep %d, got %v", added.Member.ID, records)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+	"metaStore/pkg/version"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// clusterVersionKey 集群版本的 key，值为所有成员二进制版本中最低的一个
+//
+// 每个成员在注册表中登记自己的二进制版本，leader 定期计算最低版本并经 raft 写入，
+// 所有成员读取后按集群版本开启功能
+const clusterVersionKey = kvstore.SystemKeyPrefix + "cluster/version"
+
+// clusterVersionInterval leader 重新计算、各成员重新读取集群版本的间隔
+const clusterVersionInterval = 5 * time.Second
+
+// ClusterVersionHeader 和 CapabilitiesHeader 在 Status 和 MemberList 的响应元数据中
+// 返回集群版本和已开启的功能（逗号分隔），客户端据此决定是否使用新的线上功能
+const (
+	ClusterVersionHeader = "x-metastore-cluster-version"
+	CapabilitiesHeader   = "x-metastore-capabilities"
+)
+
+// ClusterCapabilities 从 grpc.Header 收集的响应元数据中取出集群版本和已开启的功能，
+// 服务端没有返回时 ok 为 false
+func ClusterCapabilities(header metadata.MD) (clusterVersion string, caps []version.Capability, ok bool) {
+	versions := header.Get(ClusterVersionHeader)
+	if len(versions) == 0 {
+		return "", nil, false
+	}
+	for _, values := range header.Get(CapabilitiesHeader) {
+		for _, c := range strings.Split(values, ",") {
+			if c != "" {
+				caps = append(caps, version.Capability(c))
+			}
+		}
+	}
+	return versions[0], caps, true
+}
+
+// ClusterVersion 返回本节点已知的集群版本，读取到之前为 version.Baseline
+func (s *Server) ClusterVersion() string {
+	if v, ok := s.clusterVersion.Load().(string); ok {
+		return v
+	}
+	return version.Baseline
+}
+
+// ClusterSupports 集群中所有成员是否都支持功能 c
+func (s *Server) ClusterSupports(c version.Capability) bool {
+	return version.Supports(s.ClusterVersion(), c)
+}
+
+// setCapabilitiesHeader 在响应元数据中返回集群版本和已开启的功能
+func (s *Server) setCapabilitiesHeader(ctx context.Context) {
+	cv := s.ClusterVersion()
+	caps := version.Capabilities(cv)
+	names := make([]string, len(caps))
+	for i, c := range caps {
+		names[i] = string(c)
+	}
+	// 只在 gRPC handler 之外（例如测试中直接调用）失败
+	_ = grpc.SetHeader(ctx, metadata.Pairs(
+		ClusterVersionHeader, cv,
+		CapabilitiesHeader, strings.Join(names, ","),
+	))
+}
+
+// runClusterVersion 定期更新集群版本，直到服务停止
+func (s *Server) runClusterVersion(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.refreshClusterVersion()
+		select {
+		case <-s.stopRegister:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshClusterVersion leader 写入成员中最低的版本，所有成员读取集群版本
+func (s *Server) refreshClusterVersion() {
+	// 存储不暴露 raft 成员关系（例如 ephemeral）时只有本成员
+	if _, ok := kvstore.As[memberLister](s.store); !ok {
+		s.clusterVersion.Store(version.Version)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clusterVersionInterval)
+	defer cancel()
+
+	status := s.store.GetRaftStatus()
+	if status.LeaderID != 0 && status.LeaderID == status.NodeID {
+		if err := s.updateClusterVersion(ctx); err != nil {
+			log.Warn("Failed to update cluster version",
+				log.Err(err),
+				log.Component("server"))
+		}
+	}
+
+	resp, err := s.registryStore().Range(ctx, clusterVersionKey, "", 0, 0)
+	if err != nil || len(resp.Kvs) == 0 {
+		return
+	}
+	cv := string(resp.Kvs[0].Value)
+	if version.Validate(cv) != nil {
+		return
+	}
+	if old := s.ClusterVersion(); old != cv {
+		s.clusterVersion.Store(cv)
+		log.Info("Cluster version changed",
+			log.String("from", old),
+			log.String("to", cv),
+			log.Component("server"))
+	}
+}
+
+// updateClusterVersion 计算当前 raft 成员登记的最低版本并写入，没有变化时不写入
+//
+// 没有登记版本的成员按 version.Baseline 计算，因此旧版本的成员在集群中时，
+// 新功能保持关闭
+func (s *Server) updateClusterVersion(ctx context.Context) error {
+	ml, _ := kvstore.As[memberLister](s.store)
+	members := ml.Members()
+	if len(members) == 0 {
+		return nil
+	}
+	records, err := s.loadMemberRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	versions := make([]string, 0, len(members))
+	for _, st := range members {
+		v := version.Baseline
+		if rec := records[st.ID]; rec != nil && version.Validate(rec.Version) == nil {
+			v = rec.Version
+		}
+		versions = append(versions, v)
+	}
+	cv := version.Min(versions)
+
+	store := s.registryStore()
+	resp, err := store.Range(ctx, clusterVersionKey, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) > 0 && string(resp.Kvs[0].Value) == cv {
+		return nil
+	}
+	if len(resp.Kvs) > 0 && version.Compare(cv, string(resp.Kvs[0].Value)) < 0 {
+		log.Warn("Lowering cluster version, a member runs an older binary",
+			log.String("from", string(resp.Kvs[0].Value)),
+			log.String("to", cv),
+			log.Component("server"))
+	}
+	_, _, err = store.PutWithLease(ctx, clusterVersionKey, cv, 0)
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/pkg/version"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// twoMemberStore is a store whose raft configuration has members 1 and 2,
+// with member 1 as the leader
+type twoMemberStore struct {
+	*memory.MemoryEtcd
+}
+
+func (twoMemberStore) Members() []kvstore.MemberStatus {
+	return []kvstore.MemberStatus{{ID: 1, IsLeader: true}, {ID: 2}}
+}
+
+func (twoMemberStore) GetRaftStatus() kvstore.RaftStatus {
+	return kvstore.RaftStatus{NodeID: 1, LeaderID: 1, State: "leader"}
+}
+
+func TestClusterVersion(t *testing.T) {
+	ctx := context.Background()
+	s := &Server{store: twoMemberStore{memory.NewMemoryEtcd()}, memberID: 1}
+	register := func(id uint64, v string) {
+		if err := s.updateMemberRecord(ctx, id, func(rec *memberRecord) { rec.Version = v }); err != nil {
+			t.Fatalf("Failed to register member %d: %v", id, err)
+		}
+	}
+
+	// Member 2 still runs a binary that does not register its version
+	register(1, version.Version)
+	s.refreshClusterVersion()
+	if cv := s.ClusterVersion(); cv != version.Baseline {
+		t.Errorf("Expected cluster version %s during the upgrade, got %s", version.Baseline, cv)
+	}
+	if s.ClusterSupports(version.WatchResume) {
+		t.Error("Expected WatchResume to stay off until all members are upgraded")
+	}
+
+	register(2, version.Version)
+	s.refreshClusterVersion()
+	if cv := s.ClusterVersion(); cv != version.Version {
+		t.Errorf("Expected cluster version %s once all members are upgraded, got %s", version.Version, cv)
+	}
+	if !s.ClusterSupports(version.WatchResume) {
+		t.Error("Expected WatchResume once all members are upgraded")
+	}
+}
+
+func TestClusterVersionSingleMember(t *testing.T) {
+	s := &Server{store: memory.NewMemoryEtcd()}
+	s.refreshClusterVersion()
+	if cv := s.ClusterVersion(); cv != version.Version {
+		t.Errorf("Expected the binary version without raft membership, got %s", cv)
+	}
+}
+
+func TestClusterCapabilities(t *testing.T) {
+	if _, _, ok := ClusterCapabilities(metadata.MD{}); ok {
+		t.Error("Expected no capabilities without the header")
+	}
+	cv, caps, ok := ClusterCapabilities(metadata.Pairs(
+		ClusterVersionHeader, "2.1.0",
+		CapabilitiesHeader, "ProtobufProposals,WatchResume",
+	))
+	if !ok || cv != "2.1.0" || len(caps) != 2 || caps[1] != version.WatchResume {
+		t.Errorf("Unexpected capabilities %s %v %v", cv, caps, ok)
+	}
+}
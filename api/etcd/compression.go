@@ -0,0 +1,316 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC 压缩算法名称，与 grpc-encoding 头一致
+const (
+	compressorGzip     = "gzip"
+	compressorZstd     = "zstd"
+	compressorIdentity = "identity"
+)
+
+// 压缩算法在进程内全局注册，是否接受由每个 Server 的 grpc.compression 决定。
+// 服务端的响应使用请求的压缩算法，客户端通过 grpc.UseCompressor 选择
+func init() {
+	encoding.RegisterCompressor(&gzipCompressor{})
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// compressionHandler 检查请求的压缩算法是否启用，并按方法统计压缩前后的消息字节数
+//
+// 作为 grpc.StatsHandler 注册，从请求头得到客户端选择的压缩算法
+type compressionHandler struct {
+	enabled map[string]bool
+
+	mu       sync.RWMutex
+	counters map[compressionKey]*compressionCounter
+}
+
+type compressionKey struct {
+	method     string
+	compressor string
+	direction  string
+}
+
+type compressionCounter struct {
+	raw  atomic.Uint64
+	wire atomic.Uint64
+}
+
+// rpcCompression 一次 RPC 使用的压缩算法，由 TagRPC 放入 context
+type rpcCompression struct {
+	method     string
+	compressor string
+}
+
+type rpcCompressionKey struct{}
+
+// newCompressionHandler 创建只接受 names 中压缩算法的 handler，"none" 表示不接受压缩
+func newCompressionHandler(names []string) *compressionHandler {
+	h := &compressionHandler{
+		enabled:  make(map[string]bool),
+		counters: make(map[compressionKey]*compressionCounter),
+	}
+	for _, name := range names {
+		if name != "none" {
+			h.enabled[name] = true
+		}
+	}
+	return h
+}
+
+// check 请求使用了未启用的压缩算法时返回 Unimplemented
+func (h *compressionHandler) check(ctx context.Context) error {
+	rc, _ := ctx.Value(rpcCompressionKey{}).(*rpcCompression)
+	if rc == nil || rc.compressor == compressorIdentity || h.enabled[rc.compressor] {
+		return nil
+	}
+	return status.Errorf(codes.Unimplemented, "compressor %s is not enabled on this server", rc.compressor)
+}
+
+// TagRPC implements stats.Handler
+func (h *compressionHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcCompressionKey{}, &rpcCompression{
+		method:     info.FullMethodName,
+		compressor: compressorIdentity,
+	})
+}
+
+// HandleRPC implements stats.Handler
+func (h *compressionHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	rc, _ := ctx.Value(rpcCompressionKey{}).(*rpcCompression)
+	if rc == nil {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.InHeader:
+		// 请求头在消息之前处理，之后的读取不需要加锁
+		if s.Compression != "" {
+			rc.compressor = s.Compression
+		}
+	case *stats.InPayload:
+		h.add(rc, "in", s.Length, s.CompressedLength)
+	case *stats.OutPayload:
+		h.add(rc, "out", s.Length, s.CompressedLength)
+	}
+}
+
+// TagConn implements stats.Handler
+func (h *compressionHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn implements stats.Handler
+func (h *compressionHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func (h *compressionHandler) add(rc *rpcCompression, direction string, raw, wire int) {
+	key := compressionKey{method: rc.method, compressor: rc.compressor, direction: direction}
+	h.mu.RLock()
+	c := h.counters[key]
+	h.mu.RUnlock()
+	if c == nil {
+		h.mu.Lock()
+		if c = h.counters[key]; c == nil {
+			c = &compressionCounter{}
+			h.counters[key] = c
+		}
+		h.mu.Unlock()
+	}
+	c.raw.Add(uint64(raw))
+	c.wire.Add(uint64(wire))
+}
+
+// Stats 返回按方法、压缩算法和方向累计的字节数
+func (h *compressionHandler) Stats() []kvstore.CompressionStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	out := make([]kvstore.CompressionStats, 0, len(h.counters))
+	for key, c := range h.counters {
+		out = append(out, kvstore.CompressionStats{
+			Method:     key.method,
+			Compressor: key.compressor,
+			Direction:  key.direction,
+			RawBytes:   c.raw.Load(),
+			WireBytes:  c.wire.Load(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		if a.Compressor != b.Compressor {
+			return a.Compressor < b.Compressor
+		}
+		return a.Direction < b.Direction
+	})
+	return out
+}
+
+// CompressionInterceptor 拒绝使用未启用的压缩算法的请求
+func (s *Server) CompressionInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := s.compression.check(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// CompressionStreamInterceptor 拒绝使用未启用的压缩算法的流
+func (s *Server) CompressionStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := s.compression.check(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// gzipCompressor 基于标准库的 gzip，复用 writer 和 reader
+type gzipCompressor struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+type gzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+type gzipReader struct {
+	*gzip.Reader
+	pool *sync.Pool
+}
+
+func (c *gzipCompressor) Name() string { return compressorGzip }
+
+func (c *gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if z, ok := c.writers.Get().(*gzipWriter); ok {
+		z.Reset(w)
+		return z, nil
+	}
+	return &gzipWriter{Writer: gzip.NewWriter(w), pool: &c.writers}, nil
+}
+
+func (z *gzipWriter) Close() error {
+	defer z.pool.Put(z)
+	return z.Writer.Close()
+}
+
+func (c *gzipCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if z, ok := c.readers.Get().(*gzipReader); ok {
+		if err := z.Reset(r); err != nil {
+			c.readers.Put(z)
+			return nil, err
+		}
+		return z, nil
+	}
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipReader{Reader: gr, pool: &c.readers}, nil
+}
+
+func (z *gzipReader) Read(p []byte) (int, error) {
+	n, err := z.Reader.Read(p)
+	if err == io.EOF {
+		z.pool.Put(z)
+	}
+	return n, err
+}
+
+// zstdCompressor 基于 klauspost/compress 的 zstd，与 Raft 传输压缩使用同一实现
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (c *zstdCompressor) Name() string { return compressorZstd }
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if z, ok := c.encoders.Get().(*zstdWriter); ok {
+		z.Reset(w)
+		return z, nil
+	}
+	enc, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdWriter{Encoder: enc, pool: &c.encoders}, nil
+}
+
+func (z *zstdWriter) Close() error {
+	defer z.pool.Put(z)
+	return z.Encoder.Close()
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if z, ok := c.decoders.Get().(*zstdReader); ok {
+		if err := z.Reset(r); err != nil {
+			c.decoders.Put(z)
+			return nil, err
+		}
+		return z, nil
+	}
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{Decoder: dec, pool: &c.decoders}, nil
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.Decoder.Read(p)
+	if err == io.EOF {
+		z.pool.Put(z)
+	}
+	return n, err
+}
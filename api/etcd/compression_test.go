@@ -0,0 +1,127 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+func TestCompressorRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("metastore ", 1000))
+	for _, name := range []string{compressorGzip, compressorZstd} {
+		c := encoding.GetCompressor(name)
+		if c == nil {
+			t.Fatalf("Compressor %s is not registered", name)
+		}
+		// Twice, so the second round uses pooled writers and readers
+		for i := 0; i < 2; i++ {
+			var buf bytes.Buffer
+			w, err := c.Compress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			r, err := c.Decompress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%s round trip %d returned %d bytes, expected %d", name, i, len(got), len(data))
+			}
+		}
+	}
+}
+
+func TestGRPCCompression(t *testing.T) {
+	cfg := createAuthTestConfig()
+	cfg.Server.GRPC.Compression = []string{"gzip"}
+	srv, err := NewServer(ServerConfig{
+		Store:   memory.NewMemoryEtcd(),
+		Address: "127.0.0.1:0",
+		Config:  cfg,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(srv.Address(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	kv := pb.NewKVClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	value := []byte(strings.Repeat("a large, compressible value ", 1000))
+	if _, err := kv.Put(ctx, &pb.PutRequest{Key: []byte("k"), Value: value}, grpc.UseCompressor("gzip")); err != nil {
+		t.Fatalf("Compressed Put failed: %v", err)
+	}
+	resp, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte("k")}, grpc.UseCompressor("gzip"))
+	if err != nil {
+		t.Fatalf("Compressed Range failed: %v", err)
+	}
+	if len(resp.Kvs) != 1 || !bytes.Equal(resp.Kvs[0].Value, value) {
+		t.Fatal("Compressed Range returned the wrong value")
+	}
+
+	// zstd is registered but not enabled on this server
+	_, err = kv.Range(ctx, &pb.RangeRequest{Key: []byte("k")}, grpc.UseCompressor("zstd"))
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("Expected Unimplemented for a disabled compressor, got %v", err)
+	}
+	if _, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte("k")}); err != nil {
+		t.Fatalf("Uncompressed Range failed: %v", err)
+	}
+
+	var found bool
+	for _, s := range srv.CompressionStats() {
+		if s.Method != "/etcdserverpb.KV/Range" || s.Compressor != "gzip" || s.Direction != "out" {
+			continue
+		}
+		found = true
+		if s.RawBytes < uint64(len(value)) || s.WireBytes >= s.RawBytes/2 {
+			t.Errorf("Expected the Range response to shrink, got %d raw and %d wire bytes", s.RawBytes, s.WireBytes)
+		}
+	}
+	if !found {
+		t.Errorf("No gzip stats for Range responses in %+v", srv.CompressionStats())
+	}
+}
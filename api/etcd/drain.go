@@ -0,0 +1,213 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// errDraining ends streams evicted by a drain. Clients treat Unavailable as
+// retryable and open the stream again on another member
+var errDraining = status.Error(codes.Unavailable, "etcdserver: member is draining, reconnect to another member")
+
+// drainState tracks the drain of the gRPC server
+type drainState struct {
+	mu      sync.Mutex
+	status  reliability.DrainStatus
+	evict   chan struct{} // Closed when the drain starts, streams waiting for a message fail with errDraining
+	done    chan struct{} // Closed when the drain has finished
+	streams atomic.Int64  // Open client-streaming RPCs
+}
+
+func newDrainState() *drainState {
+	return &drainState{
+		status: reliability.DrainStatus{State: reliability.DrainServing},
+		evict:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// begin marks the drain as started, it returns false if it already was
+func (d *drainState) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.status.State != reliability.DrainServing {
+		return false
+	}
+	d.status.State = reliability.DrainDraining
+	d.status.StartedAt = time.Now()
+	return true
+}
+
+func (d *drainState) started() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status.State != reliability.DrainServing
+}
+
+func (d *drainState) finish(forced bool) {
+	d.mu.Lock()
+	d.status.State = reliability.DrainDrained
+	d.status.FinishedAt = time.Now()
+	d.status.Forced = forced
+	d.status.ReadyToShutdown = true
+	d.mu.Unlock()
+	close(d.done)
+}
+
+func (d *drainState) snapshot() reliability.DrainStatus {
+	d.mu.Lock()
+	st := d.status
+	d.mu.Unlock()
+	st.OpenStreams = d.streams.Load()
+	return st
+}
+
+// drainStream fails a pending RecvMsg once the drain starts, so long-lived
+// streams such as watches and lease keepalives end while the client waits for
+// its next message instead of holding the connection open
+type drainStream struct {
+	grpc.ServerStream
+	evict <-chan struct{}
+}
+
+func (ds *drainStream) RecvMsg(m interface{}) error {
+	select {
+	case <-ds.evict:
+		return errDraining
+	default:
+	}
+
+	// The receive outlives an eviction until the handler returns and the
+	// stream is cancelled, m is discarded by then
+	errC := make(chan error, 1)
+	go func() {
+		errC <- ds.ServerStream.RecvMsg(m)
+	}()
+	select {
+	case err := <-errC:
+		return err
+	case <-ds.evict:
+		return errDraining
+	}
+}
+
+// DrainStreamInterceptor tracks client-streaming RPCs so that a drain can evict them
+func (s *Server) DrainStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !info.IsClientStream {
+		return handler(srv, ss)
+	}
+	s.drain.streams.Add(1)
+	defer s.drain.streams.Add(-1)
+	return handler(srv, &drainStream{ServerStream: ss, evict: s.drain.evict})
+}
+
+// StartDrain starts draining the gRPC server in the background ahead of a
+// shutdown, a zero timeout uses reliability.drain_timeout. It returns false
+// if a drain was already started
+func (s *Server) StartDrain(timeout time.Duration) bool {
+	if timeout <= 0 {
+		timeout = s.drainTimeout
+	}
+	if !s.drain.begin() {
+		return false
+	}
+	reliability.SafeGo("grpc-drain", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		s.runDrain(ctx)
+	})
+	return true
+}
+
+// DrainStatus returns the progress of the drain
+func (s *Server) DrainStatus() reliability.DrainStatus {
+	return s.drain.snapshot()
+}
+
+// drainOnShutdown drains the gRPC server in the DrainConnections phase of the
+// shutdown, or waits for a drain started earlier
+func (s *Server) drainOnShutdown(ctx context.Context) {
+	if s.drain.begin() {
+		s.runDrain(ctx)
+		return
+	}
+	select {
+	case <-s.drain.done:
+	case <-ctx.Done():
+	}
+}
+
+// runDrain moves clients off this member without dropping them abruptly:
+//
+//  1. Health checks report NOT_SERVING and the listener is closed, so load
+//     balancers and clients stop picking this member.
+//  2. Every connection gets a GOAWAY, clients open new RPCs on other members
+//     while the RPCs in flight complete.
+//  3. Watch and lease keepalive streams are ended with Unavailable; clients
+//     resume watches from their last revision on another member.
+//
+// Whatever still runs when ctx is done is closed forcibly. Afterwards the
+// member is ready to shut down; Start keeps running until the shutdown.
+func (s *Server) runDrain(ctx context.Context) {
+	log.Info("Draining gRPC clients",
+		log.Int64("open_streams", s.drain.streams.Load()),
+		log.Component("server"))
+
+	if s.healthCheck {
+		s.healthMgr.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		s.healthMgr.GetServer().Shutdown()
+	}
+
+	// GracefulStop closes the listener, sends GOAWAY and waits for the RPCs in flight
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcSrv.GracefulStop()
+		close(stopped)
+	}()
+
+	s.drain.mu.Lock()
+	s.drain.status.EvictedStreams = s.drain.streams.Load()
+	s.drain.mu.Unlock()
+	close(s.drain.evict)
+
+	forced := false
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		forced = true
+		s.grpcSrv.Stop()
+		<-stopped
+	}
+	s.drain.finish(forced)
+
+	st := s.drain.snapshot()
+	log.Info("gRPC clients drained, member is ready to shut down",
+		log.Int64("evicted_streams", st.EvictedStreams),
+		log.Bool("forced", forced),
+		log.Duration("took", st.FinishedAt.Sub(st.StartedAt)),
+		log.Component("server"))
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/reliability"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// TestDrain 测试排空时 watch stream 以 Unavailable 结束，Start 在关闭之前不返回
+func TestDrain(t *testing.T) {
+	srv, err := NewServer(ServerConfig{
+		Store:   memory.NewMemoryEtcd(),
+		Address: "127.0.0.1:0",
+		Config:  createAuthTestConfig(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	startErr := make(chan error, 1)
+	go func() { startErr <- srv.Start() }()
+
+	conn, err := grpc.NewClient(srv.Address(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := pb.NewWatchClient(conn).Watch(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open watch stream: %v", err)
+	}
+	if err := stream.Send(&pb.WatchRequest{RequestUnion: &pb.WatchRequest_CreateRequest{
+		CreateRequest: &pb.WatchCreateRequest{Key: []byte("a")},
+	}}); err != nil {
+		t.Fatalf("Failed to create watch: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || !resp.Created {
+		t.Fatalf("Expected created response, got %v, %v", resp, err)
+	}
+
+	if !srv.StartDrain(5 * time.Second) {
+		t.Fatal("StartDrain should start a drain")
+	}
+	if srv.StartDrain(0) {
+		t.Fatal("StartDrain should not start a second drain")
+	}
+
+	// watch 被关闭，客户端可以在其他成员上重建
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, got %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.DrainStatus().State != reliability.DrainDrained {
+		if time.Now().After(deadline) {
+			t.Fatalf("Drain did not finish: %+v", srv.DrainStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	st := srv.DrainStatus()
+	if !st.ReadyToShutdown || st.Forced || st.EvictedStreams != 1 || st.OpenStreams != 0 {
+		t.Fatalf("Unexpected drain status: %+v", st)
+	}
+
+	// 排空后 Start 一直运行到关闭
+	select {
+	case err := <-startErr:
+		t.Fatalf("Start returned before shutdown: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	srv.Stop()
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Fatalf("Start returned error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Start did not return after shutdown")
+	}
+}
@@ -15,40 +15,111 @@
 package etcd
 
 import (
+	"context"
 	"errors"
 
+	"metaStore/internal/kvstore"
+	"metaStore/internal/mvcc"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/chunk"
+	"metaStore/pkg/hlc"
+	"metaStore/pkg/protect"
+	"metaStore/pkg/schema"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // 定义 etcd 兼容的错误类型
 var (
 	ErrKeyNotFound      = errors.New("key not found")
-	ErrCompacted        = errors.New("required revision has been compacted")
-	ErrFutureRev        = errors.New("required revision is a future revision")
-	ErrLeaseNotFound    = errors.New("lease not found")
-	ErrLeaseExpired     = errors.New("lease expired")
+	ErrCompacted        = mvcc.ErrCompacted
+	ErrFutureRev        = mvcc.ErrFutureRevision
+	ErrLeaseNotFound    = kvstore.ErrLeaseNotFound
+	ErrLeaseExpired     = kvstore.ErrLeaseExpired
 	ErrTooManyLeases    = errors.New("too many leases")
+	ErrTooManyWatches   = errors.New("too many watches")
 	ErrTxnConflict      = errors.New("transaction conflict")
 	ErrPermissionDenied = errors.New("permission denied")
 	ErrAuthFailed       = errors.New("authentication failed")
 	ErrInvalidArgument  = errors.New("invalid argument")
 	ErrWatchCanceled    = errors.New("watch canceled")
+
+	// ErrClusterIDMismatch 请求要求的 cluster ID 与本集群不一致
+	ErrClusterIDMismatch = errors.New("cluster ID mismatch")
+
+	// ErrReadReplica 只读副本不能被提升为投票成员
+	ErrReadReplica = errors.New("member is a read replica")
 )
 
 // errorCodeMap 将内部错误映射到 gRPC 状态码
 var errorCodeMap = map[error]codes.Code{
 	ErrKeyNotFound:      codes.NotFound,
-	ErrCompacted:        codes.OutOfRange,
-	ErrFutureRev:        codes.OutOfRange,
-	ErrLeaseNotFound:    codes.NotFound,
-	ErrLeaseExpired:     codes.NotFound,
 	ErrTooManyLeases:    codes.ResourceExhausted,
+	ErrTooManyWatches:   codes.ResourceExhausted,
 	ErrTxnConflict:      codes.FailedPrecondition,
 	ErrPermissionDenied: codes.PermissionDenied,
 	ErrAuthFailed:       codes.Unauthenticated,
 	ErrInvalidArgument:  codes.InvalidArgument,
 	ErrWatchCanceled:    codes.Canceled,
+
+	// 客户端连到了另一个集群，与 etcd 的 ErrClusterIdMismatch 相同
+	ErrClusterIDMismatch: codes.FailedPrecondition,
+	ErrReadReplica:       codes.FailedPrecondition,
+
+	// 写入的 value 不满足前缀上注册的 JSON Schema
+	schema.ErrInvalidValue:  codes.InvalidArgument,
+	schema.ErrInvalidSchema: codes.InvalidArgument,
+
+	// value 超过 chunking.max_value_size 或所在前缀的覆盖上限
+	chunk.ErrValueTooLarge: codes.InvalidArgument,
+
+	// 写入被准入 hook 拒绝
+	admission.ErrRejected: codes.PermissionDenied,
+
+	// 删除涉及 deny 模式的受保护前缀
+	protect.ErrProtected: codes.FailedPrecondition,
+
+	// 请求带的 HLC 超前本地时钟超过 server.hlc.max_offset
+	hlc.ErrClockOffset: codes.FailedPrecondition,
+
+	// 请求带了 read-after-write token，但存储无法等待 apply
+	errors.ErrUnsupported: codes.Unimplemented,
+
+	// 请求的 deadline 先于提交或 apply 到达
+	context.DeadlineExceeded: codes.DeadlineExceeded,
+	context.Canceled:         codes.Canceled,
+}
+
+// rpcErrorMap 将有 etcd 对应项的内部错误映射到 rpctypes 错误。
+// 返回的状态码和消息与 etcd 完全一致，clientv3 的 rpctypes.Error 才能把它们还原成
+// rpctypes.ErrCompacted、rpctypes.ErrNoLeader 等，重试逻辑据此区分可重试和永久失败
+var rpcErrorMap = []struct {
+	err error
+	rpc error
+}{
+	// 两个引擎的 MVCC 历史返回的 revision 越界
+	{mvcc.ErrCompacted, rpctypes.ErrGRPCCompacted},
+	{mvcc.ErrFutureRevision, rpctypes.ErrGRPCFutureRev},
+
+	// etcd 对过期的 lease 同样返回 lease not found
+	{kvstore.ErrLeaseNotFound, rpctypes.ErrGRPCLeaseNotFound},
+	{kvstore.ErrLeaseExpired, rpctypes.ErrGRPCLeaseNotFound},
+	{kvstore.ErrLeaseExists, rpctypes.ErrGRPCLeaseExist},
+
+	// 没有 leader 和 leader 变化可以重试，不是 leader 需要改连 leader
+	{kvstore.ErrNoLeader, rpctypes.ErrGRPCNoLeader},
+	{kvstore.ErrNotLeader, rpctypes.ErrGRPCNotLeader},
+	{kvstore.ErrLeaderChanged, rpctypes.ErrGRPCLeaderChanged},
+
+	// 提案超过 limits.max_request_size
+	{kvstore.ErrRequestTooLarge, rpctypes.ErrGRPCRequestTooLarge},
+
+	// 提案管道饱和
+	{kvstore.ErrTooManyRequests, rpctypes.ErrGRPCRequestTooManyRequests},
 }
 
 // toGRPCError 将内部错误转换为 gRPC 错误
@@ -62,6 +133,22 @@ func toGRPCError(err error) error {
 		return err
 	}
 
+	// 提案管道饱和：附带 RetryInfo，客户端按提示退避后重试
+	if retryAfter, ok := kvstore.RetryAfter(err); ok {
+		st := status.Convert(rpctypes.ErrGRPCRequestTooManyRequests)
+		if detailed, derr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); derr == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+
+	// 有 etcd 对应项的错误返回 rpctypes 错误，按顺序匹配
+	for _, m := range rpcErrorMap {
+		if errors.Is(err, m.err) {
+			return m.rpc
+		}
+	}
+
 	// 查找映射的错误码
 	for knownErr, code := range errorCodeMap {
 		if errors.Is(err, knownErr) {
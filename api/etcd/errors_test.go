@@ -0,0 +1,103 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/internal/mvcc"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestToGRPCErrorRPCTypes 验证存储层的类型化错误经 clientv3 的 rpctypes.Error 还原成 etcd 错误
+func TestToGRPCErrorRPCTypes(t *testing.T) {
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{mvcc.ErrCompacted, rpctypes.ErrCompacted},
+		{fmt.Errorf("%w: already compacted to revision 5 (requested: 3)", mvcc.ErrCompacted), rpctypes.ErrCompacted},
+		{fmt.Errorf("%w: cannot compact to future revision 9 (current: 5)", mvcc.ErrFutureRevision), rpctypes.ErrFutureRev},
+		{fmt.Errorf("%w: 7", kvstore.ErrLeaseNotFound), rpctypes.ErrLeaseNotFound},
+		{fmt.Errorf("%w: 7", kvstore.ErrLeaseExpired), rpctypes.ErrLeaseNotFound},
+		{fmt.Errorf("%w: 7", kvstore.ErrLeaseExists), rpctypes.ErrLeaseExist},
+		{kvstore.NotLeaderError(0), rpctypes.ErrNoLeader},
+		{kvstore.NotLeaderError(2), rpctypes.ErrNotLeader},
+		{kvstore.ErrLeaderChanged, rpctypes.ErrLeaderChanged},
+		{fmt.Errorf("put: %w", kvstore.ErrRequestTooLarge), rpctypes.ErrRequestTooLarge},
+		{kvstore.ErrTooManyRequests, rpctypes.ErrTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		got := rpctypes.Error(toGRPCError(tt.err))
+		if got != tt.want {
+			t.Errorf("rpctypes.Error(toGRPCError(%q)) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestToGRPCErrorRetryInfo 验证带重试提示的饱和错误既是 etcd 错误又附带 RetryInfo
+func TestToGRPCErrorRetryInfo(t *testing.T) {
+	err := toGRPCError(&kvstore.TooManyRequestsError{Reason: "propose queue full", RetryAfter: 2 * time.Second})
+
+	if got := rpctypes.Error(err); got != rpctypes.ErrTooManyRequests {
+		t.Fatalf("rpctypes.Error = %v, want ErrTooManyRequests", got)
+	}
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("code = %v, want ResourceExhausted", st.Code())
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok {
+			retry = ri
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() != 2*time.Second {
+		t.Fatalf("RetryInfo = %v, want 2s", retry)
+	}
+}
+
+// TestStoreErrorsAreTyped 验证存储返回的 lease 错误可以用 errors.Is 识别
+func TestStoreErrorsAreTyped(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+
+	if _, err := store.LeaseGrant(ctx, 1, 10); err != nil {
+		t.Fatalf("LeaseGrant: %v", err)
+	}
+	if _, err := store.LeaseGrant(ctx, 1, 10); !errors.Is(err, kvstore.ErrLeaseExists) {
+		t.Errorf("LeaseGrant twice = %v, want ErrLeaseExists", err)
+	}
+	if err := store.LeaseRevoke(ctx, 2); !errors.Is(err, kvstore.ErrLeaseNotFound) {
+		t.Errorf("LeaseRevoke unknown lease = %v, want ErrLeaseNotFound", err)
+	}
+	if _, _, err := store.PutWithLease(ctx, "a", "1", 2); !errors.Is(err, kvstore.ErrLeaseNotFound) {
+		t.Errorf("PutWithLease unknown lease = %v, want ErrLeaseNotFound", err)
+	}
+	if got := rpctypes.Error(toGRPCError(store.LeaseRevoke(ctx, 2))); got != rpctypes.ErrLeaseNotFound {
+		t.Errorf("LeaseRevoke unknown lease over gRPC = %v, want ErrLeaseNotFound", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// gRPC 健康检查服务中按节点状态更新的服务名，空服务名表示进程是否在服务
+//
+//	metastore.read  节点为 healthy-leader 或 healthy-follower 时为 SERVING
+//	metastore.write 节点为 healthy-leader 时为 SERVING
+//
+// 具体状态（no-leader、lagging、storage-degraded）和原因见 HTTP /health
+const (
+	HealthServiceRead  = "metastore.read"
+	HealthServiceWrite = "metastore.write"
+)
+
+// healthMonitorInterval 重新检查节点状态的间隔
+const healthMonitorInterval = time.Second
+
+// runHealthMonitor 定期检查节点状态并更新 gRPC 健康检查服务，直到服务停止
+func (s *Server) runHealthMonitor() {
+	ticker := time.NewTicker(healthMonitorInterval)
+	defer ticker.Stop()
+
+	var last kvstore.HealthMode
+	for {
+		h := kvstore.CheckHealth(s.store, s.healthMaxApplyLag)
+		s.healthMgr.SetServingStatus(HealthServiceRead, servingStatus(h.Healthy()))
+		s.healthMgr.SetServingStatus(HealthServiceWrite, servingStatus(h.Mode == kvstore.HealthLeader))
+		if h.Mode != last {
+			if last != "" {
+				log.Info("Node health changed",
+					log.String("from", string(last)),
+					log.String("to", string(h.Mode)),
+					log.String("reason", h.Reason),
+					log.Component("server"))
+			}
+			last = h.Mode
+		}
+
+		select {
+		case <-s.stopRegister:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func servingStatus(serving bool) healthpb.HealthCheckResponse_ServingStatus {
+	if serving {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
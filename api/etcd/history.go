@@ -0,0 +1,119 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+
+	"metaStore/internal/kvstore"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// KeyHistoryMethod 列出单个键历史版本的 gRPC 方法（MetaStore 扩展服务，不属于 etcd API）
+//
+// 请求复用 etcd 的 RangeRequest，只使用 Key、Limit 和 Serializable。响应复用
+// WatchResponse：Events 按 revision 从新到旧列出每次修改，删除是 DELETE 事件；
+// CompactRevision 是历史的边界，不大于它的修改可能已被压缩
+const KeyHistoryMethod = "/metastore.History/KeyHistory"
+
+// HistoryServer 实现键历史服务
+type HistoryServer struct {
+	server *Server
+}
+
+// keyHistorian 服务实现的接口，供 grpc.ServiceDesc 校验
+type keyHistorian interface {
+	KeyHistory(ctx context.Context, req *pb.RangeRequest) (*pb.WatchResponse, error)
+}
+
+var historyServiceDesc = grpc.ServiceDesc{
+	ServiceName: "metastore.History",
+	HandlerType: (*keyHistorian)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "KeyHistory",
+		Handler:    keyHistoryHandler,
+	}},
+	Metadata: "metastore/history",
+}
+
+func keyHistoryHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(pb.RangeRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(keyHistorian).KeyHistory(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: KeyHistoryMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(keyHistorian).KeyHistory(ctx, req.(*pb.RangeRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+// KeyHistory 返回 req.Key 的历史版本
+func (h *HistoryServer) KeyHistory(ctx context.Context, req *pb.RangeRequest) (*pb.WatchResponse, error) {
+	if len(req.Key) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	if len(req.RangeEnd) > 0 {
+		return nil, status.Error(codes.InvalidArgument, "key history does not accept a range")
+	}
+	historian, ok := kvstore.As[kvstore.KeyHistorian](h.server.store)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage engine does not keep key history")
+	}
+
+	if err := h.server.waitMinIndex(ctx); err != nil {
+		return nil, err
+	}
+	if req.Serializable {
+		ctx = kvstore.WithSerializable(ctx)
+	}
+
+	revs, compacted, err := historian.KeyHistory(ctx, string(req.Key), req.Limit)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	events := make([]*mvccpb.Event, len(revs))
+	for i, kv := range revs {
+		ev := &mvccpb.Event{Type: mvccpb.PUT, Kv: convertKVForResponse(kv)}
+		if kv.Version == 0 {
+			ev.Type = mvccpb.DELETE
+		}
+		events[i] = ev
+	}
+	return &pb.WatchResponse{
+		Header:          h.server.getResponseHeader(),
+		CompactRevision: compacted,
+		Events:          events,
+	}, nil
+}
+
+// KeyHistory 调用键历史服务，cc 可以是 clientv3.Client.ActiveConnection()
+// limit 大于 0 时最多返回 limit 个版本
+func KeyHistory(ctx context.Context, cc grpc.ClientConnInterface, key string, limit int64, opts ...grpc.CallOption) (*pb.WatchResponse, error) {
+	resp := new(pb.WatchResponse)
+	if err := cc.Invoke(ctx, KeyHistoryMethod, &pb.RangeRequest{Key: []byte(key), Limit: limit}, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// TestKeyHistory 测试键历史服务按 revision 从新到旧返回每次修改，删除是 DELETE 事件
+func TestKeyHistory(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv, err := NewServer(ServerConfig{
+		Store:   store,
+		Address: "127.0.0.1:0",
+		Config:  createAuthTestConfig(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(srv.Address(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	put := func(value string) int64 {
+		rev, _, err := store.PutWithLease(ctx, "config", value, 0)
+		if err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		return rev
+	}
+	first := put("v1")
+	second := put("v2")
+	_, _, deleted, err := store.DeleteRange(ctx, "config", "")
+	if err != nil {
+		t.Fatalf("DeleteRange failed: %v", err)
+	}
+	third := put("v3")
+
+	resp, err := KeyHistory(ctx, conn, "config", 0)
+	if err != nil {
+		t.Fatalf("KeyHistory failed: %v", err)
+	}
+	want := []struct {
+		typ   mvccpb.Event_EventType
+		rev   int64
+		value string
+	}{{mvccpb.PUT, third, "v3"}, {mvccpb.DELETE, deleted, ""}, {mvccpb.PUT, second, "v2"}, {mvccpb.PUT, first, "v1"}}
+	if len(resp.Events) != len(want) {
+		t.Fatalf("Expected %d revisions, got %v", len(want), resp.Events)
+	}
+	for i, w := range want {
+		ev := resp.Events[i]
+		if ev.Type != w.typ || ev.Kv.ModRevision != w.rev || string(ev.Kv.Value) != w.value {
+			t.Errorf("Event %d = %v at %d %q, want %v at %d %q", i, ev.Type, ev.Kv.ModRevision, ev.Kv.Value, w.typ, w.rev, w.value)
+		}
+	}
+	if resp.Header.Revision < third {
+		t.Errorf("Expected header revision at least %d, got %d", third, resp.Header.Revision)
+	}
+
+	// 压缩之后只保留边界之后的版本
+	if err := store.Compact(ctx, deleted); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	resp, err = KeyHistory(ctx, conn, "config", 0)
+	if err != nil {
+		t.Fatalf("KeyHistory after compaction failed: %v", err)
+	}
+	if resp.CompactRevision != deleted || len(resp.Events) != 1 || resp.Events[0].Kv.ModRevision != third {
+		t.Errorf("Expected revision %d after compaction at %d, got %v (compacted %d)", third, deleted, resp.Events, resp.CompactRevision)
+	}
+
+	if _, err := KeyHistory(ctx, conn, "", 0); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an empty key, got %v", err)
+	}
+}
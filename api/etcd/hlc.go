@@ -0,0 +1,85 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strconv"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/hlc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HLCHeader carries hybrid logical clock timestamps in gRPC metadata. Every
+// unary response returns the timestamp of the last operation applied on this
+// member; a request carrying one (e.g. read from another cluster) is merged
+// into the local clock first, so writes committed afterwards order after it.
+const HLCHeader = "x-metastore-hlc"
+
+// WithHLC returns a client context that sends ts with the request
+func WithHLC(ctx context.Context, ts uint64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, HLCHeader, strconv.FormatUint(ts, 10))
+}
+
+// HLC extracts the timestamp from a response header collected with grpc.Header;
+// ok is false when the server did not send one
+func HLC(header metadata.MD) (ts uint64, ok bool) {
+	values := header.Get(HLCHeader)
+	if len(values) == 0 {
+		return 0, false
+	}
+	parsed, err := hlc.Parse(values[0])
+	return uint64(parsed), err == nil
+}
+
+// HLCInterceptor merges the timestamp sent with a request into the local clock
+// and returns the timestamp of the last applied operation in the response header.
+// A timestamp further ahead of the local clock than the maximum offset fails the
+// request with FailedPrecondition
+func (s *Server) HLCInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	clock, ok := kvstore.As[kvstore.HybridClock](s.store)
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(HLCHeader); len(values) > 0 {
+			ts, err := hlc.Parse(values[0])
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %q", HLCHeader, values[0])
+			}
+			if err := clock.ObserveHLC(uint64(ts)); err != nil {
+				return nil, toGRPCError(err)
+			}
+		}
+	}
+
+	resp, err := handler(ctx, req)
+	if ts := clock.CurrentHLC(); ts != 0 {
+		// Fails only outside a gRPC handler, e.g. when called directly in tests
+		_ = grpc.SetHeader(ctx, metadata.Pairs(HLCHeader, strconv.FormatUint(ts, 10)))
+	}
+	return resp, err
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ClusterIDHeader and MemberIDHeader carry the cluster and member IDs in gRPC
+// metadata. Every response returns both; a request carrying ClusterIDHeader is
+// rejected with ErrClusterIDMismatch unless it matches the cluster it reached,
+// so a client pointed at the wrong cluster fails instead of silently using it.
+const (
+	ClusterIDHeader = "x-metastore-cluster-id"
+	MemberIDHeader  = "x-metastore-member-id"
+)
+
+// WithClusterID returns a client context whose requests only succeed on the
+// cluster with the given ID
+func WithClusterID(ctx context.Context, clusterID uint64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ClusterIDHeader, strconv.FormatUint(clusterID, 10))
+}
+
+// ClusterMember extracts the cluster and member IDs from a response header
+// collected with grpc.Header; ok is false when the server did not send them
+func ClusterMember(header metadata.MD) (clusterID, memberID uint64, ok bool) {
+	clusters, members := header.Get(ClusterIDHeader), header.Get(MemberIDHeader)
+	if len(clusters) == 0 || len(members) == 0 {
+		return 0, 0, false
+	}
+	clusterID, err := strconv.ParseUint(clusters[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	memberID, err = strconv.ParseUint(members[0], 10, 64)
+	return clusterID, memberID, err == nil
+}
+
+// verifyClusterID checks the cluster ID a request expects, if any
+func (s *Server) verifyClusterID(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	values := md.Get(ClusterIDHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	expected, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid %s: %q", ClusterIDHeader, values[0])
+	}
+	if expected != s.clusterID {
+		return toGRPCError(fmt.Errorf("%w: request expects cluster %d, member %d belongs to cluster %d",
+			ErrClusterIDMismatch, expected, s.memberID, s.clusterID))
+	}
+	return nil
+}
+
+// identityHeader is the response metadata naming this cluster and member
+func (s *Server) identityHeader() metadata.MD {
+	return metadata.Pairs(
+		ClusterIDHeader, strconv.FormatUint(s.clusterID, 10),
+		MemberIDHeader, strconv.FormatUint(s.memberID, 10),
+	)
+}
+
+// IdentityInterceptor rejects requests meant for another cluster and stamps the
+// cluster and member IDs on every response, both in the response metadata and
+// in the etcd ResponseHeader
+func (s *Server) IdentityInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	// Fails only outside a gRPC handler, e.g. when called directly in tests
+	_ = grpc.SetHeader(ctx, s.identityHeader())
+	if err := s.verifyClusterID(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	if r, ok := resp.(interface{ GetHeader() *pb.ResponseHeader }); ok {
+		if h := r.GetHeader(); h != nil {
+			h.ClusterId = s.clusterID
+			h.MemberId = s.memberID
+		}
+	}
+	return resp, err
+}
+
+// IdentityStreamInterceptor is IdentityInterceptor for streams (Watch,
+// LeaseKeepAlive, Snapshot). The IDs are checked once when the stream opens;
+// the responses it carries are built with getResponseHeader
+func (s *Server) IdentityStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	_ = ss.SetHeader(s.identityHeader())
+	if err := s.verifyClusterID(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// headerTransport records the response metadata set by a unary interceptor
+type headerTransport struct {
+	header metadata.MD
+}
+
+func (h *headerTransport) Method() string { return "/etcdserverpb.KV/Range" }
+func (h *headerTransport) SetHeader(md metadata.MD) error {
+	h.header = metadata.Join(h.header, md)
+	return nil
+}
+func (h *headerTransport) SendHeader(md metadata.MD) error { return h.SetHeader(md) }
+func (h *headerTransport) SetTrailer(md metadata.MD) error { return nil }
+
+// headerStream records the response metadata set by a stream interceptor
+type headerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (h *headerStream) Context() context.Context { return h.ctx }
+func (h *headerStream) SetHeader(md metadata.MD) error {
+	h.header = metadata.Join(h.header, md)
+	return nil
+}
+
+// identityCases are the cluster IDs a request may carry and whether the
+// cluster 100 accepts it; an empty ID sends no header
+var identityCases = []struct {
+	name      string
+	clusterID string
+	code      codes.Code
+}{
+	{"missing", "", codes.OK},
+	{"matching", "100", codes.OK},
+	{"mismatched", "200", codes.FailedPrecondition},
+	{"invalid", "abc", codes.InvalidArgument},
+}
+
+func identityContext(clusterID string) context.Context {
+	if clusterID == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(ClusterIDHeader, clusterID))
+}
+
+// checkIdentityHeader checks that the response metadata names cluster 100 and member 7
+func checkIdentityHeader(t *testing.T, header metadata.MD) {
+	t.Helper()
+	clusterID, memberID, ok := ClusterMember(header)
+	if !ok || clusterID != 100 || memberID != 7 {
+		t.Errorf("Expected cluster 100 and member 7 in the response header, got %v", header)
+	}
+}
+
+func TestIdentityInterceptor(t *testing.T) {
+	s := &Server{clusterID: 100, memberID: 7}
+	info := &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.KV/Range"}
+
+	for _, tc := range identityCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &headerTransport{}
+			ctx := grpc.NewContextWithServerTransportStream(identityContext(tc.clusterID), transport)
+			called := false
+			resp, err := s.IdentityInterceptor(ctx, &pb.RangeRequest{}, info,
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					called = true
+					return &pb.RangeResponse{Header: &pb.ResponseHeader{}}, nil
+				})
+
+			// 被拒绝的请求也带有集群和成员 ID，客户端可以看到自己连到了哪个集群
+			checkIdentityHeader(t, transport.header)
+			if status.Code(err) != tc.code {
+				t.Fatalf("Expected %v, got %v", tc.code, err)
+			}
+			if tc.code != codes.OK {
+				if called {
+					t.Error("The handler ran for a rejected request")
+				}
+				if tc.code == codes.FailedPrecondition && !strings.Contains(err.Error(), ErrClusterIDMismatch.Error()) {
+					t.Errorf("Expected %v, got %v", ErrClusterIDMismatch, err)
+				}
+				return
+			}
+			header := resp.(*pb.RangeResponse).Header
+			if header.ClusterId != 100 || header.MemberId != 7 {
+				t.Errorf("Expected cluster 100 and member 7 in the ResponseHeader, got %v", header)
+			}
+		})
+	}
+}
+
+func TestIdentityStreamInterceptor(t *testing.T) {
+	s := &Server{clusterID: 100, memberID: 7}
+	info := &grpc.StreamServerInfo{FullMethod: "/etcdserverpb.Watch/Watch"}
+
+	for _, tc := range identityCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &headerStream{ctx: identityContext(tc.clusterID)}
+			called := false
+			err := s.IdentityStreamInterceptor(nil, stream, info,
+				func(srv interface{}, ss grpc.ServerStream) error {
+					called = true
+					return nil
+				})
+
+			checkIdentityHeader(t, stream.header)
+			if status.Code(err) != tc.code {
+				t.Fatalf("Expected %v, got %v", tc.code, err)
+			}
+			if called != (tc.code == codes.OK) {
+				t.Errorf("Expected the handler to run: %v, ran: %v", tc.code == codes.OK, called)
+			}
+		})
+	}
+}
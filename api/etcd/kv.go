@@ -16,52 +16,139 @@ package etcd
 
 import (
 	"context"
+	"sort"
+
 	"metaStore/internal/kvstore"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	mvccpb "go.etcd.io/etcd/api/v3/mvccpb"
 )
 
+// defaultMaxRangeKeys 未提供配置时一次 Range 最多返回的键数
+const defaultMaxRangeKeys = 100000
+
 // KVServer 实现 etcd KV 服务
 type KVServer struct {
 	pb.UnimplementedKVServer
-	server *Server
+	server       *Server
+	maxRangeKeys int64 // 一次 Range 最多返回的键数，0 表示不限制
 }
 
 // Range 执行范围查询
+//
+// 结果以流式方式从 store 读取，边读边转换为 protobuf，不在内存中保留两份完整结果。
+// 返回的键数超过 maxRangeKeys 时截断并设置 more=true，客户端从最后一个 key 之后继续读取；
+// Count 始终是范围内的键总数
 func (s *KVServer) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
 	key := string(req.Key)
 	rangeEnd := string(req.RangeEnd)
-	limit := req.Limit
 	revision := req.Revision
 
-	// 从 store 查询
-	resp, err := s.server.store.Range(ctx, key, rangeEnd, limit, revision)
-	if err != nil {
-		return nil, toGRPCError(err)
+	limit := req.Limit
+	if s.maxRangeKeys > 0 && (limit <= 0 || limit > s.maxRangeKeys) {
+		limit = s.maxRangeKeys
+	}
+
+	// 带 read-after-write token 时先等本节点追上
+	if err := s.server.waitMinIndex(ctx); err != nil {
+		return nil, err
 	}
 
-	// 转换为 protobuf 格式
-	kvs := make([]*mvccpb.KeyValue, len(resp.Kvs))
-	for i, kv := range resp.Kvs {
-		kvs[i] = &mvccpb.KeyValue{
+	// serializable 读取直接访问本节点的状态，不经过 ReadIndex
+	if req.Serializable {
+		ctx = kvstore.WithSerializable(ctx)
+	}
+
+	// 按 key 排序由存储引擎按顺序或逆序流式读取；按其他字段排序时先收集范围内的全部键，
+	// 排序后再截断。与 etcd 相同，指定了非 key 的排序字段而未指定顺序时按升序
+	sortOrder := req.SortOrder
+	if req.SortTarget != pb.RangeRequest_KEY && sortOrder == pb.RangeRequest_NONE {
+		sortOrder = pb.RangeRequest_ASCEND
+	}
+	scan := kvstore.RangeFunc
+	if req.SortTarget == pb.RangeRequest_KEY && sortOrder == pb.RangeRequest_DESCEND {
+		scan = kvstore.ReverseRangeFunc
+	}
+	sortInMemory := req.SortTarget != pb.RangeRequest_KEY && !req.CountOnly
+	collectLimit := limit
+	if sortInMemory {
+		collectLimit = 0
+	}
+
+	// 从 store 流式查询并转换为 protobuf 格式
+	var kvs []*mvccpb.KeyValue
+	var count int64
+	rev, err := scan(ctx, s.server.store, key, rangeEnd, revision, func(kv *kvstore.KeyValue) bool {
+		count++
+		if req.CountOnly || (collectLimit > 0 && int64(len(kvs)) >= collectLimit) {
+			return true
+		}
+
+		pkv := &mvccpb.KeyValue{
 			Key:            kv.Key,
-			Value:          kv.Value,
 			CreateRevision: kv.CreateRevision,
 			ModRevision:    kv.ModRevision,
 			Version:        kv.Version,
 			Lease:          kv.Lease,
 		}
+		if !req.KeysOnly || req.SortTarget == pb.RangeRequest_VALUE {
+			pkv.Value = kv.Value
+		}
+		kvs = append(kvs, pkv)
+		return true
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	if sortInMemory {
+		sortKeyValues(kvs, req.SortTarget, sortOrder)
+		if limit > 0 && int64(len(kvs)) > limit {
+			kvs = kvs[:limit]
+		}
+		if req.KeysOnly {
+			for _, kv := range kvs {
+				kv.Value = nil
+			}
+		}
+	}
+
+	// eventual 模式下 serializable 读取的快照可能落后于最新状态，响应头报告读取对应的 revision
+	header := s.server.getResponseHeader()
+	if req.Serializable && rev > 0 {
+		header.Revision = rev
 	}
 
 	return &pb.RangeResponse{
-		Header: s.server.getResponseHeader(),
+		Header: header,
 		Kvs:    kvs,
-		More:   resp.More,
-		Count:  resp.Count,
+		More:   !req.CountOnly && count > int64(len(kvs)),
+		Count:  count,
 	}, nil
 }
 
+// sortKeyValues 按 key 以外的字段排序，相同时保持 key 顺序
+func sortKeyValues(kvs []*mvccpb.KeyValue, target pb.RangeRequest_SortTarget, order pb.RangeRequest_SortOrder) {
+	var less func(a, b *mvccpb.KeyValue) bool
+	switch target {
+	case pb.RangeRequest_VERSION:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.Version < b.Version }
+	case pb.RangeRequest_CREATE:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.CreateRevision < b.CreateRevision }
+	case pb.RangeRequest_MOD:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.ModRevision < b.ModRevision }
+	case pb.RangeRequest_VALUE:
+		less = func(a, b *mvccpb.KeyValue) bool { return string(a.Value) < string(b.Value) }
+	default:
+		return
+	}
+	if order == pb.RangeRequest_DESCEND {
+		asc := less
+		less = func(a, b *mvccpb.KeyValue) bool { return asc(b, a) }
+	}
+	sort.SliceStable(kvs, func(i, j int) bool { return less(kvs[i], kvs[j]) })
+}
+
 // Put 存储键值对
 func (s *KVServer) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
 	key := string(req.Key)
@@ -73,6 +160,7 @@ func (s *KVServer) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse
 	if err != nil {
 		return nil, toGRPCError(err)
 	}
+	s.server.sendCommitIndex(ctx)
 
 	resp := &pb.PutResponse{
 		Header: s.server.getResponseHeader(),
@@ -106,6 +194,7 @@ func (s *KVServer) DeleteRange(ctx context.Context, req *pb.DeleteRangeRequest)
 	if err != nil {
 		return nil, toGRPCError(err)
 	}
+	s.server.sendCommitIndex(ctx)
 
 	resp := &pb.DeleteRangeResponse{
 		Header:  s.server.getResponseHeader(),
@@ -153,11 +242,15 @@ func (s *KVServer) Txn(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse
 		elseOps[i] = convertRequestOp(reqOp)
 	}
 
-	// 执行事务
+	// 执行事务，事务中的读取同样遵守 read-after-write token
+	if err := s.server.waitMinIndex(ctx); err != nil {
+		return nil, err
+	}
 	txnResp, err := s.server.store.Txn(ctx, cmps, thenOps, elseOps)
 	if err != nil {
 		return nil, toGRPCError(err)
 	}
+	s.server.sendCommitIndex(ctx)
 
 	// 转换响应
 	resp := &pb.TxnResponse{
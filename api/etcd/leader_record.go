@@ -0,0 +1,109 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"metaStore/pkg/log"
+)
+
+// LeaderRecord leader 发布到 reliability.leader_key 的身份记录
+//
+// 前五个字段与 Kubernetes leader 选举记录（control-plane.alpha.kubernetes.io/leader
+// 注解）相同，外部控制器可以按 renewTime + leaseDurationSeconds 判断记录是否过期：
+// leader 失联后记录不再续期，直到新的 leader 写入自己的记录
+type LeaderRecord struct {
+	HolderIdentity       string    `json:"holderIdentity"`
+	LeaseDurationSeconds int       `json:"leaseDurationSeconds"`
+	AcquireTime          time.Time `json:"acquireTime"`
+	RenewTime            time.Time `json:"renewTime"`
+	LeaderTransitions    int       `json:"leaderTransitions"`
+
+	MemberID   string   `json:"memberID"` // 16 位十六进制的成员 ID
+	ClientURLs []string `json:"clientURLs,omitempty"`
+	Term       uint64   `json:"term"`
+}
+
+// leaderRecordLeases 记录的有效期是续期间隔的倍数，偶尔一次续期失败不会使记录过期
+const leaderRecordLeases = 3
+
+// runLeaderRecord 本节点是 leader 时定期发布 leader 记录，直到服务停止
+func (s *Server) runLeaderRecord(key string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.publishLeaderRecord(key, interval, time.Now()); err != nil {
+			log.Warn("Failed to publish leader record",
+				log.String("key", key),
+				log.Err(err),
+				log.Component("server"))
+		}
+		select {
+		case <-s.stopRegister:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishLeaderRecord 本节点是 leader 时写入或续期 leader 记录，其他节点不做任何事
+//
+// 记录中是本成员在同一 term 写入的记录时只更新 renewTime，否则视为新的 leader：
+// acquireTime 为当前时间，leaderTransitions 在已有记录的基础上加一
+func (s *Server) publishLeaderRecord(key string, interval time.Duration, now time.Time) error {
+	status := s.store.GetRaftStatus()
+	if status.LeaderID == 0 || status.LeaderID != status.NodeID {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+
+	store := s.registryStore()
+	resp, err := store.Range(ctx, key, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	rec := LeaderRecord{
+		HolderIdentity:       s.memberName(),
+		LeaseDurationSeconds: max(1, int(leaderRecordLeases*interval/time.Second)),
+		AcquireTime:          now,
+		RenewTime:            now,
+		MemberID:             fmt.Sprintf("%016x", s.memberID),
+		ClientURLs:           s.clientURLs,
+		Term:                 status.Term,
+	}
+	if len(resp.Kvs) > 0 {
+		var old LeaderRecord
+		if err := json.Unmarshal(resp.Kvs[0].Value, &old); err == nil {
+			if old.MemberID == rec.MemberID && old.Term == rec.Term {
+				rec.AcquireTime = old.AcquireTime
+				rec.LeaderTransitions = old.LeaderTransitions
+			} else {
+				rec.LeaderTransitions = old.LeaderTransitions + 1
+			}
+		}
+	}
+
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, _, err = store.PutWithLease(ctx, key, string(value), 0)
+	return err
+}
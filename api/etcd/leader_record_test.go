@@ -0,0 +1,101 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+)
+
+// leaderStore is a store whose raft status can be changed by the test
+type leaderStore struct {
+	*memory.MemoryEtcd
+	status kvstore.RaftStatus
+}
+
+func (s *leaderStore) GetRaftStatus() kvstore.RaftStatus { return s.status }
+
+func TestPublishLeaderRecord(t *testing.T) {
+	const key = "/metastore/leader"
+	store := &leaderStore{MemoryEtcd: memory.NewMemoryEtcd()}
+	s := &Server{store: store, memberID: 1, clientURLs: []string{"http://10.0.0.1:2379"}}
+	load := func() *LeaderRecord {
+		t.Helper()
+		resp, err := store.Range(context.Background(), key, "", 0, 0)
+		if err != nil {
+			t.Fatalf("Range failed: %v", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return nil
+		}
+		var rec LeaderRecord
+		if err := json.Unmarshal(resp.Kvs[0].Value, &rec); err != nil {
+			t.Fatalf("Failed to decode leader record: %v", err)
+		}
+		return &rec
+	}
+	publish := func(now time.Time) {
+		t.Helper()
+		if err := s.publishLeaderRecord(key, 5*time.Second, now); err != nil {
+			t.Fatalf("publishLeaderRecord failed: %v", err)
+		}
+	}
+
+	// Followers publish nothing
+	store.status = kvstore.RaftStatus{NodeID: 1, LeaderID: 2, Term: 3}
+	publish(time.Now())
+	if rec := load(); rec != nil {
+		t.Fatalf("Expected no record from a follower, got %+v", rec)
+	}
+
+	store.status.LeaderID = 1
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	publish(t0)
+	rec := load()
+	if rec == nil {
+		t.Fatal("Expected the leader to publish a record")
+	}
+	if rec.HolderIdentity != "node-1" || rec.MemberID != "0000000000000001" || rec.Term != 3 {
+		t.Errorf("Unexpected identity in %+v", rec)
+	}
+	if rec.LeaseDurationSeconds != 15 || !rec.AcquireTime.Equal(t0) || !rec.RenewTime.Equal(t0) || rec.LeaderTransitions != 0 {
+		t.Errorf("Unexpected lease in %+v", rec)
+	}
+	if len(rec.ClientURLs) != 1 || rec.ClientURLs[0] != "http://10.0.0.1:2379" {
+		t.Errorf("Expected the client URLs in the record, got %v", rec.ClientURLs)
+	}
+
+	// Renewing in the same term keeps the acquire time
+	t1 := t0.Add(5 * time.Second)
+	publish(t1)
+	rec = load()
+	if !rec.AcquireTime.Equal(t0) || !rec.RenewTime.Equal(t1) || rec.LeaderTransitions != 0 {
+		t.Errorf("Expected a renewal, got %+v", rec)
+	}
+
+	// Leadership won again in a later term counts as a transition
+	store.status.Term = 4
+	t2 := t1.Add(5 * time.Second)
+	publish(t2)
+	rec = load()
+	if !rec.AcquireTime.Equal(t2) || rec.LeaderTransitions != 1 || rec.Term != 4 {
+		t.Errorf("Expected a new acquisition, got %+v", rec)
+	}
+}
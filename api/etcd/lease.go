@@ -37,7 +37,7 @@ func (s *LeaseServer) LeaseGrant(ctx context.Context, req *pb.LeaseGrantRequest)
 	}
 
 	// 创建 lease
-	lease, err := s.server.leaseMgr.Grant(id, ttl)
+	lease, err := s.server.leaseMgr.GrantFor(clientAddress(ctx), id, ttl)
 	if err != nil {
 		return nil, toGRPCError(err)
 	}
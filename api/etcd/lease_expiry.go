@@ -0,0 +1,90 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryEntry lease 在调度队列中的到期时间
+type expiryEntry struct {
+	id       int64
+	deadline time.Time
+}
+
+// expiryQueue 按到期时间排序的最小堆，实现 heap.Interface
+// 续约不修改堆中已有的条目，而是压入新的条目。与 LeaseManager 记录的到期时间
+// 不一致的条目已经过时，出堆时丢弃，因此每次检查只需处理堆顶已到期的条目，
+// 而不是遍历全部 lease
+type expiryQueue []expiryEntry
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].deadline.Before(q[j].deadline) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x interface{}) { *q = append(*q, x.(expiryEntry)) }
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// schedule 把 lease 按当前到期时间加入队列（调用者需持有 lm.mu）
+func (lm *LeaseManager) schedule(id int64, deadline time.Time) {
+	heap.Push(&lm.expiry, expiryEntry{id: id, deadline: deadline})
+}
+
+// current 判断队列条目是否仍是 lease 当前的到期时间（调用者需持有 lm.mu）
+func (lm *LeaseManager) current(e expiryEntry) bool {
+	lease, ok := lm.leases[e.id]
+	return ok && lease.Deadline().Equal(e.deadline)
+}
+
+// popExpired 取出最多 limit 个已到期的 lease，limit <= 0 表示不限制（调用者需持有 lm.mu）
+func (lm *LeaseManager) popExpired(now time.Time, limit int) []int64 {
+	var ids []int64
+	for lm.expiry.Len() > 0 && (limit <= 0 || len(ids) < limit) {
+		e := lm.expiry[0]
+		if e.deadline.After(now) {
+			break
+		}
+		heap.Pop(&lm.expiry)
+		if lm.current(e) {
+			ids = append(ids, e.id)
+		}
+	}
+	return ids
+}
+
+// countExpired 统计留在队列中已到期的 lease 数，只访问到期的子树（调用者需持有 lm.mu）
+func (lm *LeaseManager) countExpired(now time.Time) int {
+	count := 0
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if i >= lm.expiry.Len() || lm.expiry[i].deadline.After(now) {
+			continue
+		}
+		if lm.current(lm.expiry[i]) {
+			count++
+		}
+		stack = append(stack, 2*i+1, 2*i+2)
+	}
+	return count
+}
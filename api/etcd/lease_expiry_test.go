@@ -0,0 +1,83 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+)
+
+// TestLeaseExpiryBatches 测试过期的 lease 按批撤销，续约后的 lease 不会被撤销
+func TestLeaseExpiryBatches(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	lm := NewLeaseManager(store, &config.LeaseConfig{CheckInterval: time.Hour, ExpiryBatchSize: 2}, nil)
+
+	ctx := context.Background()
+	for id := int64(1); id <= 5; id++ {
+		if _, err := lm.Grant(id, 60); err != nil {
+			t.Fatalf("Grant %d failed: %v", id, err)
+		}
+	}
+	if _, _, err := store.PutWithLease(ctx, "session", "v", 1); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// 把授予时间提前一小时使 lease 到期，lease 5 随后续约
+	lm.mu.Lock()
+	for id, lease := range lm.leases {
+		lease.GrantTime = lease.GrantTime.Add(-time.Hour)
+		lm.schedule(id, lease.Deadline())
+	}
+	lm.mu.Unlock()
+	if _, err := lm.Renew(5); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+
+	expect := func(scheduled, backlog int, revoked uint64) {
+		t.Helper()
+		stats := lm.ExpiryStats()
+		if stats.Scheduled != scheduled || stats.Backlog != backlog || stats.Revoked != revoked || stats.Failed != 0 {
+			t.Fatalf("Expected %d scheduled, backlog %d and %d revoked, got %+v", scheduled, backlog, revoked, stats)
+		}
+	}
+	lm.checkExpiredLeases()
+	expect(3, 2, 2)
+	lm.checkExpiredLeases()
+	expect(1, 0, 4)
+	lm.checkExpiredLeases()
+	expect(1, 0, 4)
+
+	resp, err := store.Range(ctx, "session", "", 0, 0)
+	if err != nil || len(resp.Kvs) != 0 {
+		t.Errorf("Expected the key of lease 1 to be deleted, got %v, %v", resp, err)
+	}
+	if _, err := lm.TimeToLive(5); err != nil {
+		t.Errorf("Expected the renewed lease to survive, got %v", err)
+	}
+}
+
+// TestLeaseExpiryJitter 测试检查间隔加上不超过 jitter 的随机延迟
+func TestLeaseExpiryJitter(t *testing.T) {
+	lm := NewLeaseManager(memory.NewMemoryEtcd(), &config.LeaseConfig{CheckInterval: time.Second, ExpiryJitter: 200 * time.Millisecond}, nil)
+	for i := 0; i < 100; i++ {
+		if d := lm.nextCheck(); d < time.Second || d >= 1200*time.Millisecond {
+			t.Fatalf("Expected the next check within [1s, 1.2s), got %v", d)
+		}
+	}
+}
@@ -16,6 +16,9 @@ package etcd
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"metaStore/internal/kvstore"
 	"metaStore/pkg/config"
 	"metaStore/pkg/log"
@@ -26,6 +29,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// leaseCountInterval 从 store 重新统计集群 lease 数的间隔，
+// 统计之间其他成员授予和撤销的 lease 在下次统计时计入
+const leaseCountInterval = 10 * time.Second
+
 // LeaseManager 管理所有的 lease
 type LeaseManager struct {
 	mu      sync.RWMutex
@@ -37,7 +44,25 @@ type LeaseManager struct {
 	// 配置
 	checkInterval time.Duration // Lease 过期检查间隔
 	defaultTTL    time.Duration // 默认 TTL
-	maxLeaseCount int           // 最大 Lease 数量限制（0 表示无限制）
+	maxLeaseCount int           // 集群的最大 Lease 数量（0 表示无限制）
+	maxPerClient  int           // 单个连接的最大 Lease 数量（0 表示无限制）
+
+	// 数量限制
+	clients        map[string]int   // 客户端连接地址 -> 经本成员授予、尚未撤销的 lease 数，由 mu 保护
+	owners         map[int64]string // leaseID -> 授予它的客户端连接地址，由 mu 保护
+	grantMu        sync.Mutex       // 集群的 lease 数接近上限时串行授予
+	clusterLeases  atomic.Int64     // 集群的 lease 数，定期从 store 统计，其间按本成员的授予和撤销增减
+	countedAt      atomic.Int64     // 上次从 store 统计的时间（UnixNano）
+	rejected       atomic.Uint64    // 因超过 maxLeaseCount 拒绝的 lease 数
+	rejectedClient atomic.Uint64    // 因超过 maxPerClient 拒绝的 lease 数
+
+	// 过期调度
+	expiry      expiryQueue   // 按到期时间排序的 lease，由 mu 保护
+	batchSize   int           // 每次检查最多撤销的 lease 数（0 表示无限制）
+	jitter      time.Duration // 每次检查额外的随机延迟上限
+	backlog     atomic.Int64  // 上次检查后仍在等待撤销的已到期 lease 数
+	revoked     atomic.Uint64 // 因过期撤销的 lease 总数
+	revokeFails atomic.Uint64 // 撤销失败的过期 lease 总数
 }
 
 // NewLeaseManager 创建新的 Lease 管理器
@@ -50,8 +75,10 @@ func NewLeaseManager(store kvstore.Store, leaseCfg *config.LeaseConfig, limitsCf
 	}
 
 	maxLeases := 0 // 默认无限制
+	maxPerClient := 0
 	if limitsCfg != nil {
 		maxLeases = limitsCfg.MaxLeaseCount
+		maxPerClient = limitsCfg.MaxLeasesPerConnection
 	}
 
 	return &LeaseManager{
@@ -61,6 +88,11 @@ func NewLeaseManager(store kvstore.Store, leaseCfg *config.LeaseConfig, limitsCf
 		checkInterval: leaseCfg.CheckInterval,
 		defaultTTL:    leaseCfg.DefaultTTL,
 		maxLeaseCount: maxLeases,
+		maxPerClient:  maxPerClient,
+		clients:       make(map[string]int),
+		owners:        make(map[int64]string),
+		batchSize:     leaseCfg.ExpiryBatchSize,
+		jitter:        leaseCfg.ExpiryJitter,
 	}
 }
 
@@ -77,40 +109,100 @@ func (lm *LeaseManager) Stop() {
 	close(lm.stopCh)
 }
 
-// Grant 创建一个新的 lease
+// Grant 创建一个新的 lease，不按客户端连接限制
 func (lm *LeaseManager) Grant(id int64, ttl int64) (*kvstore.Lease, error) {
+	return lm.GrantFor("", id, ttl)
+}
+
+// GrantFor 为客户端连接 client 创建一个新的 lease
+//
+// 集群的 lease 数达到 limits.max_lease_count，或 client 经本成员授予的 lease 数达到
+// limits.max_leases_per_connection 时返回 ErrTooManyLeases；client 为空时不按连接限制
+func (lm *LeaseManager) GrantFor(client string, id int64, ttl int64) (*kvstore.Lease, error) {
 	if lm.stopped.Load() {
 		return nil, ErrLeaseNotFound
 	}
 
-	// Check lease count limit
-	lm.mu.RLock()
-	currentCount := len(lm.leases)
-	lm.mu.RUnlock()
+	// 检查连接的上限并占位
+	lm.mu.Lock()
+	if client != "" && lm.maxPerClient > 0 && lm.clients[client] >= lm.maxPerClient {
+		lm.mu.Unlock()
+		lm.rejectedClient.Add(1)
+		return nil, fmt.Errorf("%w: this connection already holds limits.max_leases_per_connection (%d) leases", ErrTooManyLeases, lm.maxPerClient)
+	}
+	if client != "" {
+		lm.clients[client]++
+	}
+	lm.mu.Unlock()
+	release := func() {
+		lm.mu.Lock()
+		lm.releaseClient(client)
+		lm.mu.Unlock()
+	}
 
-	if lm.maxLeaseCount > 0 && currentCount >= lm.maxLeaseCount {
-		return nil, ErrTooManyLeases
+	// 接近上限时重新统计并串行授予，本成员授予的 lease 不会一起越过上限
+	if lm.maxLeaseCount > 0 {
+		count := lm.clusterLeases.Load()
+		if count >= int64(lm.maxLeaseCount)*9/10 {
+			lm.grantMu.Lock()
+			defer lm.grantMu.Unlock()
+			count = lm.refreshLeaseCount()
+		}
+		if count >= int64(lm.maxLeaseCount) {
+			release()
+			lm.rejected.Add(1)
+			return nil, fmt.Errorf("%w: the cluster already holds limits.max_lease_count (%d) leases", ErrTooManyLeases, lm.maxLeaseCount)
+		}
 	}
 
 	// 委托给 store
 	lease, err := lm.store.LeaseGrant(context.Background(), id, ttl)
 	if err != nil {
+		release()
 		return nil, err
 	}
+	lm.clusterLeases.Add(1)
 
 	lm.mu.Lock()
 	lm.leases[id] = lease
+	lm.schedule(id, lease.Deadline())
+	if client != "" {
+		lm.owners[id] = client
+	}
 	lm.mu.Unlock()
 
 	return lease, nil
 }
 
+// releaseClient 释放 client 的一个 lease 计数，调用方持有 mu
+func (lm *LeaseManager) releaseClient(client string) {
+	if client == "" {
+		return
+	}
+	if lm.clients[client]--; lm.clients[client] <= 0 {
+		delete(lm.clients, client)
+	}
+}
+
+// refreshLeaseCount 从 store 统计集群的 lease 数，失败时返回之前的估计
+func (lm *LeaseManager) refreshLeaseCount() int64 {
+	leases, err := lm.store.Leases(context.Background())
+	if err != nil {
+		return lm.clusterLeases.Load()
+	}
+	lm.clusterLeases.Store(int64(len(leases)))
+	lm.countedAt.Store(time.Now().UnixNano())
+	return int64(len(leases))
+}
+
 // Revoke 撤销一个 lease（删除所有关联的键）
 func (lm *LeaseManager) Revoke(id int64) error {
 	lm.mu.Lock()
 	_, ok := lm.leases[id]
 	if ok {
 		delete(lm.leases, id)
+		lm.releaseClient(lm.owners[id])
+		delete(lm.owners, id)
 	}
 	lm.mu.Unlock()
 
@@ -119,7 +211,28 @@ func (lm *LeaseManager) Revoke(id int64) error {
 	}
 
 	// 委托给 store（会删除所有关联的键）
-	return lm.store.LeaseRevoke(context.Background(), id)
+	if err := lm.store.LeaseRevoke(context.Background(), id); err != nil {
+		return err
+	}
+	lm.clusterLeases.Add(-1)
+	return nil
+}
+
+// Usage 返回集群的 lease 数量、上限和拒绝次数，连接的计数只包含经本成员授予的 lease
+func (lm *LeaseManager) Usage() kvstore.ResourceUsage {
+	lm.mu.RLock()
+	usage := kvstore.ResourceUsage{
+		Count:              int(lm.clusterLeases.Load()),
+		Limit:              lm.maxLeaseCount,
+		PerConnectionLimit: lm.maxPerClient,
+	}
+	for _, n := range lm.clients {
+		usage.MaxPerConnection = max(usage.MaxPerConnection, n)
+	}
+	lm.mu.RUnlock()
+	usage.Rejected = lm.rejected.Load()
+	usage.RejectedPerConnection = lm.rejectedClient.Load()
+	return usage
 }
 
 // Renew 续约一个 lease
@@ -140,6 +253,7 @@ func (lm *LeaseManager) Renew(id int64) (*kvstore.Lease, error) {
 
 	lm.mu.Lock()
 	lm.leases[id] = lease
+	lm.schedule(id, lease.Deadline())
 	lm.mu.Unlock()
 
 	return lease, nil
@@ -164,19 +278,43 @@ func (lm *LeaseManager) Leases() ([]*kvstore.Lease, error) {
 	return lm.store.Leases(context.Background())
 }
 
+// ExpiryStats 返回过期调度的当前状态
+func (lm *LeaseManager) ExpiryStats() kvstore.LeaseExpiryStats {
+	lm.mu.RLock()
+	scheduled := len(lm.leases)
+	lm.mu.RUnlock()
+	return kvstore.LeaseExpiryStats{
+		Scheduled: scheduled,
+		Backlog:   int(lm.backlog.Load()),
+		Revoked:   lm.revoked.Load(),
+		Failed:    lm.revokeFails.Load(),
+	}
+}
+
+// nextCheck 返回到下一次检查的间隔：检查间隔加上 0 到 jitter 的随机延迟
+func (lm *LeaseManager) nextCheck() time.Duration {
+	if lm.jitter <= 0 {
+		return lm.checkInterval
+	}
+	return lm.checkInterval + rand.N(lm.jitter)
+}
+
 // expiryChecker 定期检查并清理过期的 lease
 func (lm *LeaseManager) expiryChecker() {
-	ticker := time.NewTicker(lm.checkInterval) // 使用配置的检查间隔
-	defer ticker.Stop()
+	timer := time.NewTimer(lm.nextCheck())
+	defer timer.Stop()
 
 	log.Info("Lease expiry checker started",
 		zap.Duration("check_interval", lm.checkInterval),
+		zap.Int("batch_size", lm.batchSize),
+		zap.Duration("jitter", lm.jitter),
 		zap.String("component", "lease-manager"))
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			lm.checkExpiredLeases()
+			timer.Reset(lm.nextCheck())
 		case <-lm.stopCh:
 			log.Info("Lease expiry checker stopped", zap.String("component", "lease-manager"))
 			return
@@ -184,23 +322,42 @@ func (lm *LeaseManager) expiryChecker() {
 	}
 }
 
-// checkExpiredLeases 检查并清理过期的 lease
+// checkExpiredLeases 撤销到期的 lease，每次最多 batchSize 个，剩余的留到下一次检查
+// 同一批的撤销并发提交，由提案批处理合并成少量 Raft 日志条目
 func (lm *LeaseManager) checkExpiredLeases() {
-	lm.mu.RLock()
-	expiredIDs := make([]int64, 0)
-	for id, lease := range lm.leases {
-		if lease.IsExpired() {
-			expiredIDs = append(expiredIDs, id)
-		}
+	now := time.Now()
+	if lm.maxLeaseCount > 0 && now.Sub(time.Unix(0, lm.countedAt.Load())) >= leaseCountInterval {
+		lm.refreshLeaseCount()
 	}
-	lm.mu.RUnlock()
+	lm.mu.Lock()
+	expiredIDs := lm.popExpired(now, lm.batchSize)
+	lm.backlog.Store(int64(lm.countExpired(now)))
+	lm.mu.Unlock()
 
-	// 撤销过期的 lease
+	var wg sync.WaitGroup
 	for _, id := range expiredIDs {
-		if err := lm.Revoke(id); err != nil {
-			log.Error("Failed to revoke expired lease", zap.Int64("lease_id", id), zap.Error(err), zap.String("component", "lease-manager"))
-		} else {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			err := lm.Revoke(id)
+			if errors.Is(err, ErrLeaseNotFound) {
+				return // 已被客户端撤销
+			}
+			if err != nil {
+				lm.revokeFails.Add(1)
+				log.Error("Failed to revoke expired lease", zap.Int64("lease_id", id), zap.Error(err), zap.String("component", "lease-manager"))
+				return
+			}
+			lm.revoked.Add(1)
 			log.Info("Revoked expired lease", zap.Int64("lease_id", id), zap.String("component", "lease-manager"))
-		}
+		}(id)
+	}
+	wg.Wait()
+
+	if backlog := lm.backlog.Load(); backlog > 0 {
+		log.Warn("Expired leases left for the next check",
+			zap.Int64("backlog", backlog),
+			zap.Int("batch_size", lm.batchSize),
+			zap.String("component", "lease-manager"))
 	}
 }
@@ -19,6 +19,11 @@ import (
 	"fmt"
 	"hash/crc32"
 
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/backup"
+	"metaStore/pkg/encryption"
+	"metaStore/pkg/log"
+
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 )
 
@@ -26,7 +31,9 @@ import (
 type MaintenanceServer struct {
 	pb.UnimplementedMaintenanceServer
 	server            *Server
-	snapshotChunkSize int // 快照分块大小（字节）
+	snapshotChunkSize int                 // 快照分块大小（字节）
+	signSnapshots     bool                // 快照附带清单（SHA-256 摘要）
+	snapshotKeyring   *encryption.Keyring // 加密快照的 KEK，为 nil 表示不加密
 }
 
 // Alarm 告警管理
@@ -91,14 +98,16 @@ func (s *MaintenanceServer) Status(ctx context.Context, req *pb.StatusRequest) (
 
 	// 获取真实的 Raft 状态
 	raftStatus := s.server.store.GetRaftStatus()
+	s.server.setCapabilitiesHeader(ctx)
 
 	return &pb.StatusResponse{
-		Header:    s.server.getResponseHeader(),
-		Version:   "3.6.0-compatible", // MetaStore 版本
-		DbSize:    dbSize,
-		Leader:    raftStatus.LeaderID, // 真实的 Leader ID
-		RaftIndex: uint64(s.server.store.CurrentRevision()),
-		RaftTerm:  raftStatus.Term, // 真实的 Raft Term
+		Header:           s.server.getResponseHeader(),
+		Version:          "3.6.0-compatible", // MetaStore 版本
+		DbSize:           dbSize,
+		Leader:           raftStatus.LeaderID, // 真实的 Leader ID
+		RaftIndex:        raftStatus.Commit,   // 本节点的 commit index
+		RaftTerm:         raftStatus.Term,     // 真实的 Raft Term
+		RaftAppliedIndex: raftStatus.Applied,  // 本节点的 applied index，与 RaftIndex 的差即应用延迟
 	}, nil
 }
 
@@ -165,6 +174,18 @@ func (s *MaintenanceServer) Snapshot(req *pb.SnapshotRequest, stream pb.Maintena
 		return toGRPCError(err)
 	}
 
+	// 按配置附加清单并加密，恢复前用 metastorectl snapshot verify 校验
+	if s.signSnapshots || s.snapshotKeyring != nil {
+		snapshot, err = backup.Seal(snapshot, backup.Manifest{
+			ClusterID: s.server.clusterID,
+			MemberID:  s.server.memberID,
+			Revision:  s.server.store.CurrentRevision(),
+		}, s.snapshotKeyring)
+		if err != nil {
+			return toGRPCError(fmt.Errorf("failed to seal snapshot: %w", err))
+		}
+	}
+
 	// 分块发送快照数据（使用配置的块大小）
 	chunkSize := s.snapshotChunkSize
 	for i := 0; i < len(snapshot); i += chunkSize {
@@ -191,7 +212,7 @@ func (s *MaintenanceServer) MoveLeader(ctx context.Context, req *pb.MoveLeaderRe
 	// 检查当前节点是否是 leader
 	raftStatus := s.server.store.GetRaftStatus()
 	if raftStatus.LeaderID != s.server.memberID {
-		return nil, toGRPCError(fmt.Errorf("not leader, current leader: %d", raftStatus.LeaderID))
+		return nil, toGRPCError(kvstore.NotLeaderError(raftStatus.LeaderID))
 	}
 
 	// 验证目标节点ID
@@ -217,10 +238,17 @@ func (s *MaintenanceServer) Downgrade(ctx context.Context, req *pb.DowngradeRequ
 }
 
 // MemberList 列出所有集群成员
+//
+// 成员关系来自 raft 当前配置，URL 来自复制的成员注册表，leader 排在第一位；
+// 存储不暴露成员关系时退回到启动参数
 func (s *MaintenanceServer) MemberList(ctx context.Context, req *pb.MemberListRequest) (*pb.MemberListResponse, error) {
+	s.server.setCapabilitiesHeader(ctx)
+
 	var pbMembers []*pb.Member
 
-	if s.server.clusterMgr == nil {
+	if members, ok := s.server.liveMembers(ctx); ok {
+		pbMembers = members
+	} else if s.server.clusterMgr == nil {
 		// ClusterManager未初始化时，从clusterPeers构造成员列表
 		// 这允许在没有ConfChangeC的情况下也能返回集群成员信息
 		if len(s.server.clusterPeers) > 0 {
@@ -283,7 +311,18 @@ func (s *MaintenanceServer) MemberAdd(ctx context.Context, req *pb.MemberAddRequ
 		return nil, toGRPCError(err)
 	}
 
-	// 2. 返回新成员信息
+	// 2. 登记 peer URL，新成员启动后登记自己的 client URL
+	if err := s.server.updateMemberRecord(ctx, member.ID, func(rec *memberRecord) {
+		rec.Name = member.Name
+		rec.PeerURLs = req.PeerURLs
+	}); err != nil {
+		log.Warn("Failed to register added member",
+			log.MemberID(member.ID),
+			log.Err(err),
+			log.Component("maintenance"))
+	}
+
+	// 3. 返回新成员信息
 	return &pb.MemberAddResponse{
 		Header: s.server.getResponseHeader(),
 		Member: &pb.Member{
@@ -314,6 +353,13 @@ func (s *MaintenanceServer) MemberRemove(ctx context.Context, req *pb.MemberRemo
 		return nil, toGRPCError(err)
 	}
 
+	if err := s.server.deleteMemberRecord(ctx, req.ID); err != nil {
+		log.Warn("Failed to remove member from registry",
+			log.MemberID(req.ID),
+			log.Err(err),
+			log.Component("maintenance"))
+	}
+
 	// 3. 返回响应
 	return &pb.MemberRemoveResponse{
 		Header:  s.server.getResponseHeader(),
@@ -332,6 +378,15 @@ func (s *MaintenanceServer) MemberUpdate(ctx context.Context, req *pb.MemberUpda
 		return nil, toGRPCError(err)
 	}
 
+	if err := s.server.updateMemberRecord(ctx, req.ID, func(rec *memberRecord) {
+		rec.PeerURLs = req.PeerURLs
+	}); err != nil {
+		log.Warn("Failed to update member registry",
+			log.MemberID(req.ID),
+			log.Err(err),
+			log.Component("maintenance"))
+	}
+
 	// 2. 返回响应
 	return &pb.MemberUpdateResponse{
 		Header:  s.server.getResponseHeader(),
@@ -345,6 +400,13 @@ func (s *MaintenanceServer) MemberPromote(ctx context.Context, req *pb.MemberPro
 		return nil, toGRPCError(fmt.Errorf("cluster manager not initialized"))
 	}
 
+	// 只读副本必须保持 learner 身份，否则会参与投票
+	if records, err := s.server.loadMemberRegistry(ctx); err == nil {
+		if rec := records[req.ID]; rec != nil && rec.Role == memberRoleReplica {
+			return nil, toGRPCError(fmt.Errorf("%w, member %x cannot be promoted", ErrReadReplica, req.ID))
+		}
+	}
+
 	// 1. 调用 ClusterManager 提升成员
 	if err := s.server.clusterMgr.PromoteMember(req.ID); err != nil {
 		return nil, toGRPCError(err)
@@ -0,0 +1,76 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/backup"
+	"metaStore/pkg/encryption"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+)
+
+// snapshotStream collects the chunks sent by Maintenance.Snapshot
+type snapshotStream struct {
+	grpc.ServerStream
+	buf bytes.Buffer
+}
+
+func (s *snapshotStream) Send(resp *pb.SnapshotResponse) error {
+	s.buf.Write(resp.Blob)
+	return nil
+}
+
+func TestSnapshotEncrypted(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	if _, _, err := store.PutWithLease(context.Background(), "tenant/secret", "value", 0); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := encryption.NewKeyring("backup", map[string][]byte{"backup": bytes.Repeat([]byte{7}, encryption.KeySize)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &MaintenanceServer{
+		server:            &Server{store: store, clusterID: 1, memberID: 3},
+		snapshotChunkSize: 16,
+		snapshotKeyring:   keyring,
+	}
+
+	stream := &snapshotStream{}
+	if err := s.Snapshot(&pb.SnapshotRequest{}, stream); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	sealed := stream.buf.Bytes()
+	if bytes.Contains(sealed, []byte("tenant/secret")) {
+		t.Error("Expected the snapshot to be encrypted")
+	}
+
+	data, m, err := backup.Open(sealed, keyring)
+	if err != nil {
+		t.Fatalf("Failed to open snapshot: %v", err)
+	}
+	if m.MemberID != 3 || m.Revision != 1 || m.KeyID != "backup" {
+		t.Errorf("Unexpected manifest %+v", m)
+	}
+	want, _ := store.GetSnapshot()
+	if !bytes.Equal(data, want) {
+		t.Error("Decrypted snapshot differs from the store snapshot")
+	}
+}
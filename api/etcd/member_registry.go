@@ -0,0 +1,247 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/log"
+	"metaStore/pkg/version"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+// memberRegistryPrefix 成员注册表的 key 前缀，key 为前缀 + 16 位十六进制的成员 ID
+//
+// 注册表经 raft 复制到所有节点，每个节点启动后登记自己的 client URL，
+// MemberAdd/MemberUpdate 登记 peer URL
+const memberRegistryPrefix = kvstore.SystemKeyPrefix + "members/"
+
+// memberRegisterInterval 登记失败（例如还没有 leader）时的重试间隔
+const memberRegisterInterval = time.Second
+
+// memberRecord 注册表中的成员信息
+type memberRecord struct {
+	ID         uint64   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	PeerURLs   []string `json:"peer_urls,omitempty"`
+	ClientURLs []string `json:"client_urls,omitempty"`
+
+	Zone   string            `json:"zone,omitempty"`   // 故障域，leader 放置策略使用
+	Labels map[string]string `json:"labels,omitempty"` // 自定义标签
+	Role   string            `json:"role,omitempty"`   // 只读副本为 replica，其他成员为空
+
+	Version string `json:"version,omitempty"` // 成员的二进制版本，用于计算集群版本
+}
+
+// memberLister 由暴露 raft 成员关系的存储实现
+type memberLister interface {
+	Members() []kvstore.MemberStatus
+}
+
+func memberRegistryKey(id uint64) string {
+	return fmt.Sprintf("%s%016x", memberRegistryPrefix, id)
+}
+
+// registryStore 注册表是服务端内部数据，不经过准入 hook
+func (s *Server) registryStore() kvstore.Store {
+	return admission.Unwrap(s.store)
+}
+
+// loadMemberRegistry 读取注册表，无法解析的条目被忽略
+func (s *Server) loadMemberRegistry(ctx context.Context) (map[uint64]*memberRecord, error) {
+	start, end := kvstore.PrefixRange(memberRegistryPrefix)
+	resp, err := s.registryStore().Range(ctx, start, end, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[uint64]*memberRecord, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var rec memberRecord
+		if err := json.Unmarshal(kv.Value, &rec); err != nil || rec.ID == 0 {
+			continue
+		}
+		records[rec.ID] = &rec
+	}
+	return records, nil
+}
+
+// updateMemberRecord 修改成员的注册信息，没有变化时不写入
+func (s *Server) updateMemberRecord(ctx context.Context, id uint64, update func(rec *memberRecord)) error {
+	store := s.registryStore()
+	key := memberRegistryKey(id)
+	resp, err := store.Range(ctx, key, "", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	rec := &memberRecord{ID: id}
+	var old []byte
+	if len(resp.Kvs) > 0 {
+		old = resp.Kvs[0].Value
+		if err := json.Unmarshal(old, rec); err != nil {
+			rec = &memberRecord{ID: id}
+		}
+	}
+	update(rec)
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if string(value) == string(old) {
+		return nil
+	}
+	_, _, err = store.PutWithLease(ctx, key, string(value), 0)
+	return err
+}
+
+// deleteMemberRecord 删除被移除成员的注册信息
+func (s *Server) deleteMemberRecord(ctx context.Context, id uint64) error {
+	_, _, _, err := s.registryStore().DeleteRange(ctx, memberRegistryKey(id), "")
+	return err
+}
+
+// registerSelf 登记本节点的 client URL、所在 zone 和二进制版本，失败时重试直到成功或服务停止
+//
+// 配置了 raft.advertise_peer_urls 时登记这些 peer URL，否则在还没有登记时登记本节点在 peer 列表中的 URL
+func (s *Server) registerSelf(clientURLs []string) {
+	name := s.memberName()
+	role := ""
+	if s.replica {
+		role = memberRoleReplica
+	}
+	var peerURLs []string
+	if s.memberID >= 1 && s.memberID <= uint64(len(s.clusterPeers)) {
+		peerURLs = []string{s.clusterPeers[s.memberID-1]}
+	}
+
+	ticker := time.NewTicker(memberRegisterInterval)
+	defer ticker.Stop()
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*memberRegisterInterval)
+		err := s.updateMemberRecord(ctx, s.memberID, func(rec *memberRecord) {
+			rec.Name = name
+			rec.ClientURLs = clientURLs
+			rec.Zone = s.placement.Zone
+			rec.Labels = s.placement.Labels
+			rec.Role = role
+			rec.Version = version.Version
+			if len(s.advertisePeerURLs) > 0 {
+				rec.PeerURLs = s.advertisePeerURLs
+			} else if len(rec.PeerURLs) == 0 {
+				rec.PeerURLs = peerURLs
+			}
+		})
+		cancel()
+		if err == nil {
+			log.Info("Registered member client URLs",
+				log.MemberID(s.memberID),
+				zap.Strings("client_urls", clientURLs),
+				log.Component("server"))
+			return
+		}
+		if attempt%30 == 1 {
+			log.Warn("Failed to register member client URLs, retrying",
+				log.Err(err),
+				log.Component("server"))
+		}
+
+		select {
+		case <-s.stopRegister:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// advertiseClientURLs 返回本节点发布的 client URL
+func advertiseClientURLs(configured []string, listenAddr string) []string {
+	if len(configured) > 0 {
+		return configured
+	}
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return nil
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+		if hostname, err := os.Hostname(); err == nil && hostname != "" {
+			host = hostname
+		}
+	}
+	return []string{"http://" + net.JoinHostPort(host, port)}
+}
+
+// liveMembers 按 raft 当前配置列出成员，URL 来自注册表
+//
+// leader 排在第一位作为提示，其余按 ID 排序。存储不暴露成员关系时返回 false
+func (s *Server) liveMembers(ctx context.Context) ([]*pb.Member, bool) {
+	ml, ok := kvstore.As[memberLister](s.store)
+	if !ok {
+		return nil, false
+	}
+	statuses := ml.Members()
+	if len(statuses) == 0 {
+		return nil, false
+	}
+
+	records, err := s.loadMemberRegistry(ctx)
+	if err != nil {
+		// 读不到注册表时仍然返回成员关系
+		log.Warn("Failed to read member registry",
+			log.Err(err),
+			log.Component("server"))
+		records = nil
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].IsLeader != statuses[j].IsLeader {
+			return statuses[i].IsLeader
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	members := make([]*pb.Member, 0, len(statuses))
+	for _, st := range statuses {
+		m := &pb.Member{
+			ID:        st.ID,
+			Name:      fmt.Sprintf("node-%d", st.ID),
+			IsLearner: st.IsLearner,
+		}
+		if st.PeerURL != "" {
+			m.PeerURLs = []string{st.PeerURL}
+		}
+		if rec := records[st.ID]; rec != nil {
+			if rec.Name != "" {
+				m.Name = rec.Name
+			}
+			if len(rec.PeerURLs) > 0 {
+				m.PeerURLs = slices.Clone(rec.PeerURLs)
+			}
+			m.ClientURLs = slices.Clone(rec.ClientURLs)
+		}
+		members = append(members, m)
+	}
+	return members, true
+}
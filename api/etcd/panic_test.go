@@ -0,0 +1,63 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/pkg/reliability"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPanicRecoveryInterceptor(t *testing.T) {
+	s := &Server{panicRecovery: true}
+	before := reliability.PanicCounts()[reliability.PanicSourceGRPC]
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.KV/Range"}
+	_, err := s.PanicRecoveryInterceptor(context.Background(), &pb.RangeRequest{}, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			var m map[string]int
+			m["boom"] = 1
+			return nil, nil
+		})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal from a panicking handler, got %v", err)
+	}
+
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/etcdserverpb.Watch/Watch"}
+	err = s.PanicRecoveryStreamInterceptor(nil, &fakeServerStream{ctx: context.Background()}, streamInfo,
+		func(srv interface{}, ss grpc.ServerStream) error {
+			panic("boom")
+		})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal from a panicking stream handler, got %v", err)
+	}
+
+	if got := reliability.PanicCounts()[reliability.PanicSourceGRPC]; got != before+2 {
+		t.Errorf("Expected %d recovered gRPC panics, got %d", before+2, got)
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context { return f.ctx }
@@ -0,0 +1,98 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strconv"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ReadRevisionHeader pins the reads of a request at a revision, like WithRev
+// on every Get. A pinned request is read-only: writes carrying it are rejected.
+// Without the header, requests are pinned at server.read_revision when set
+const ReadRevisionHeader = "x-metastore-read-revision"
+
+// pinnedWriteMethods are the unary methods rejected while pinned. Txn is among
+// them even when it only reads, since its range operations have no revision
+var pinnedWriteMethods = map[string]bool{
+	"/etcdserverpb.KV/Put":            true,
+	"/etcdserverpb.KV/DeleteRange":    true,
+	"/etcdserverpb.KV/Txn":            true,
+	"/etcdserverpb.KV/Compact":        true,
+	"/etcdserverpb.Lease/LeaseGrant":  true,
+	"/etcdserverpb.Lease/LeaseRevoke": true,
+	BatchWriteMethod:                  true,
+}
+
+// WithReadRevision returns a client context whose reads are served at rev
+func WithReadRevision(ctx context.Context, rev int64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ReadRevisionHeader, strconv.FormatInt(rev, 10))
+}
+
+// ReadRevisionInterceptor pins every request of a client at rev, pass it with
+// grpc.WithUnaryInterceptor in clientv3.Config.DialOptions
+func ReadRevisionInterceptor(rev int64) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(WithReadRevision(ctx, rev), method, req, reply, cc, opts...)
+	}
+}
+
+// PinnedReadInterceptor serves Range requests without a revision at the pinned
+// revision and rejects writes. Explicit revisions are kept
+func (s *Server) PinnedReadInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	rev, err := s.pinnedRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rev == 0 {
+		return handler(ctx, req)
+	}
+
+	if pinnedWriteMethods[info.FullMethod] {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"reads are pinned at revision %d, writes are not allowed", rev)
+	}
+	if r, ok := req.(*pb.RangeRequest); ok && r.Revision == 0 {
+		r.Revision = rev
+	}
+	return handler(ctx, req)
+}
+
+// pinnedRevision returns the revision the request is pinned at, 0 for none
+func (s *Server) pinnedRevision(ctx context.Context) (int64, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ReadRevisionHeader); len(values) > 0 {
+			rev, err := strconv.ParseInt(values[0], 10, 64)
+			if err != nil || rev < 0 {
+				return 0, status.Errorf(codes.InvalidArgument, "invalid %s: %q", ReadRevisionHeader, values[0])
+			}
+			if rev > 0 {
+				return rev, nil
+			}
+		}
+	}
+	return s.readRevision, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestPinnedRead(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv, err := NewServer(ServerConfig{
+		Store:   store,
+		Address: "127.0.0.1:0",
+		Config:  createAuthTestConfig(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	first, _, err := store.PutWithLease(ctx, "config", "v1", 0)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, _, err := store.PutWithLease(ctx, "config", "v2", 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// The client interceptor pins every request of the connection
+	conn, err := grpc.NewClient(srv.Address(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(ReadRevisionInterceptor(first)))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	kv := pb.NewKVClient(conn)
+
+	resp, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte("config")})
+	if err != nil {
+		t.Fatalf("Pinned Range failed: %v", err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v1" {
+		t.Errorf("Expected v1 at revision %d, got %v", first, resp.Kvs)
+	}
+	if _, err := kv.Put(ctx, &pb.PutRequest{Key: []byte("config"), Value: []byte("v3")}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a pinned Put, got %v", err)
+	}
+
+	// server.read_revision pins requests without the header
+	plain, err := grpc.NewClient(srv.Address(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer plain.Close()
+	srv.readRevision = first
+	resp, err = pb.NewKVClient(plain).Range(ctx, &pb.RangeRequest{Key: []byte("config")})
+	if err != nil || len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v1" {
+		t.Errorf("Expected v1 with server.read_revision %d, got %v, %v", first, resp, err)
+	}
+	srv.readRevision = 0
+
+	bad := metadata.AppendToOutgoingContext(ctx, ReadRevisionHeader, "abc")
+	if _, err := pb.NewKVClient(plain).Range(bad, &pb.RangeRequest{Key: []byte("config")}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an invalid header, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+	"metaStore/pkg/placement"
+)
+
+// leadershipTransferrer 由可以转移 leader 的存储实现
+type leadershipTransferrer interface {
+	TransferLeadership(targetID uint64) error
+}
+
+// runPlacement 定期检查 leader 放置，直到服务停止
+func (s *Server) runPlacement(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopRegister:
+			return
+		case <-ticker.C:
+			s.checkPlacement()
+		}
+	}
+}
+
+// placementPolicy 返回集群中设置的放置策略，没有设置时使用配置文件中的值
+//
+// witness 不应用数据，读到的总是配置文件中的值
+func (s *Server) placementPolicy(ctx context.Context) placement.Policy {
+	policy, err := placement.Load(ctx, s.registryStore())
+	if err == nil {
+		return policy
+	}
+	if !errors.Is(err, placement.ErrNotSet) {
+		log.Warn("Failed to read leader placement policy, using configured policy",
+			log.Err(err),
+			log.Component("server"))
+	}
+	return placement.Policy{
+		PrimaryZone:      s.placement.PrimaryZone,
+		PreferredLeaders: s.placement.PreferredLeaders,
+	}
+}
+
+// checkPlacement 本节点是 leader 且有更合适的活跃成员时转移 leader
+//
+// 成员的 zone 来自注册表，没有登记的成员（例如 witness）不在任何 zone 中，
+// 只有在比当前 leader 更合适时才会成为转移目标
+func (s *Server) checkPlacement() {
+	ml, ok := kvstore.As[memberLister](s.store)
+	if !ok {
+		return
+	}
+	lt, ok := kvstore.As[leadershipTransferrer](s.store)
+	if !ok {
+		return
+	}
+	status := s.store.GetRaftStatus()
+	if status.LeaderID == 0 || status.LeaderID != status.NodeID {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	policy := s.placementPolicy(ctx)
+	// 读不到注册表时所有成员都视为没有登记
+	records, _ := s.loadMemberRegistry(ctx)
+
+	self := placement.Member{
+		ID:      status.NodeID,
+		Zone:    s.placement.Zone,
+		Witness: s.witness,
+	}
+	var members []placement.Member
+	for _, st := range ml.Members() {
+		m := placement.Member{
+			ID:      st.ID,
+			Learner: st.IsLearner,
+			Active:  st.RecentActive,
+			Match:   st.Match,
+		}
+		if rec := records[st.ID]; rec != nil {
+			m.Zone = rec.Zone
+		}
+		members = append(members, m)
+	}
+
+	target := policy.Transferee(self, members)
+	if target == 0 {
+		return
+	}
+	log.Info("Transferring leadership to follow the placement policy",
+		log.Uint64("target", target),
+		log.String("zone", s.placement.Zone),
+		log.String("primary_zone", policy.PrimaryZone),
+		log.Bool("witness", s.witness),
+		log.Component("server"))
+	if err := lt.TransferLeadership(target); err != nil {
+		log.Warn("Leadership transfer for placement failed",
+			log.Uint64("target", target),
+			log.Err(err),
+			log.Component("server"))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"strconv"
+
+	"metaStore/internal/kvstore"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Read-after-write tokens travel in gRPC metadata so the etcd protobuf messages
+// stay unchanged: writes return the token in the CommitIndexHeader response
+// header, and reads carrying it in MinIndexHeader wait until this member has
+// applied at least that raft index, which gives monotonic reads on followers.
+const (
+	CommitIndexHeader = "x-metastore-commit-index"
+	MinIndexHeader    = "x-metastore-min-index"
+)
+
+// WithMinIndex returns a client context that asks for reads at or after token
+func WithMinIndex(ctx context.Context, token uint64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MinIndexHeader, strconv.FormatUint(token, 10))
+}
+
+// CommitIndex extracts the token from the response header of a write, collected
+// with grpc.Header; ok is false when the server did not send one
+func CommitIndex(header metadata.MD) (token uint64, ok bool) {
+	values := header.Get(CommitIndexHeader)
+	if len(values) == 0 {
+		return 0, false
+	}
+	token, err := strconv.ParseUint(values[0], 10, 64)
+	return token, err == nil
+}
+
+// sendCommitIndex returns the read-after-write token of a completed write
+func (s *Server) sendCommitIndex(ctx context.Context) {
+	raw, ok := kvstore.As[kvstore.ReadAfterWriter](s.store)
+	if !ok {
+		return
+	}
+	token := strconv.FormatUint(raw.WriteIndex(), 10)
+	// Fails only outside a gRPC handler, e.g. when called directly in tests
+	_ = grpc.SetHeader(ctx, metadata.Pairs(CommitIndexHeader, token))
+}
+
+// waitMinIndex waits until this member has applied the token sent with a read
+func (s *Server) waitMinIndex(ctx context.Context) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	values := md.Get(MinIndexHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	token, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid %s: %q", MinIndexHeader, values[0])
+	}
+
+	raw, ok := kvstore.As[kvstore.ReadAfterWriter](s.store)
+	if !ok {
+		return toGRPCError(kvstore.ErrReadAfterWriteUnsupported)
+	}
+	if err := raw.WaitApplied(ctx, token); err != nil {
+		return toGRPCError(err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// memberRoleReplica is the role read replicas (raft.node_role: replica) record
+// in the member registry. They are raft learners that must never be promoted
+const memberRoleReplica = "replica"
+
+// memberName is the name a member registers, read replicas are named
+// replica-<id> so they stand out in MemberList next to IsLearner
+func (s *Server) memberName() string {
+	if s.replica {
+		return fmt.Sprintf("replica-%d", s.memberID)
+	}
+	return fmt.Sprintf("node-%d", s.memberID)
+}
+
+// ReplicaInterceptor makes a read replica read-only: Range requests are served
+// from the local state as serializable reads, without a ReadIndex round trip to
+// the leader, and writes are rejected like on pinned requests
+func (s *Server) ReplicaInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if !s.replica {
+		return handler(ctx, req)
+	}
+
+	if pinnedWriteMethods[info.FullMethod] {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"member %x is a read replica, writes are not allowed", s.memberID)
+	}
+	if r, ok := req.(*pb.RangeRequest); ok {
+		r.Serializable = true
+	}
+	return handler(ctx, req)
+}
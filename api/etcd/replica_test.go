@@ -0,0 +1,91 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestReadReplica(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	cfg := createAuthTestConfig()
+	cfg.Server.Raft.NodeRole = config.NodeRoleReplica
+	srv, err := NewServer(ServerConfig{
+		Store:    store,
+		Address:  "127.0.0.1:0",
+		MemberID: 4,
+		Config:   cfg,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	go srv.Start()
+	defer srv.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, _, err := store.PutWithLease(ctx, "report", "v1", 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	conn, err := grpc.NewClient(srv.Address(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	kv := pb.NewKVClient(conn)
+
+	// Linearizable reads are served from the local state
+	resp, err := kv.Range(ctx, &pb.RangeRequest{Key: []byte("report")})
+	if err != nil || len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v1" {
+		t.Errorf("Expected v1 from the replica, got %v, %v", resp, err)
+	}
+	if _, err := kv.Put(ctx, &pb.PutRequest{Key: []byte("report"), Value: []byte("v2")}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a Put on a replica, got %v", err)
+	}
+	if _, err := kv.Txn(ctx, &pb.TxnRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for a Txn on a replica, got %v", err)
+	}
+
+	// The replica registers itself under a distinct name and role
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		records, err := srv.loadMemberRegistry(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load member registry: %v", err)
+		}
+		if rec := records[4]; rec != nil {
+			if rec.Name != "replica-4" || rec.Role != memberRoleReplica {
+				t.Errorf("Expected replica-4 with role %q, got %+v", memberRoleReplica, rec)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Replica did not register itself")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
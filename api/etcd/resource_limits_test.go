@@ -0,0 +1,111 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerContext returns a context of a request from the client connection addr
+func peerContext(addr string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: net.TCPAddrFromAddrPort(netip.MustParseAddrPort(addr))})
+}
+
+func TestWatchLimits(t *testing.T) {
+	wm := NewWatchManager(memory.NewMemoryEtcd(), &config.LimitsConfig{MaxWatchCount: 3, MaxWatchesPerConnection: 2})
+	defer wm.Stop()
+
+	for i := 0; i < 2; i++ {
+		if _, err := wm.Create(peerContext("10.0.0.1:5000"), "a", "", 0, nil); err != nil {
+			t.Fatalf("Create %d failed: %v", i, err)
+		}
+	}
+	if _, err := wm.Create(peerContext("10.0.0.1:5000"), "a", "", 0, nil); !errors.Is(err, ErrTooManyWatches) {
+		t.Fatalf("Expected ErrTooManyWatches for the third watch on a connection, got %v", err)
+	}
+
+	id, err := wm.Create(peerContext("10.0.0.2:5000"), "b", "", 0, nil)
+	if err != nil {
+		t.Fatalf("Create on another connection failed: %v", err)
+	}
+	_, err = wm.Create(peerContext("10.0.0.3:5000"), "c", "", 0, nil)
+	if !errors.Is(err, ErrTooManyWatches) {
+		t.Fatalf("Expected ErrTooManyWatches above max_watch_count, got %v", err)
+	}
+	if st, _ := status.FromError(toGRPCError(err)); st.Code() != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, got %v", st.Code())
+	}
+
+	usage := wm.Usage()
+	if usage.Count != 3 || usage.MaxPerConnection != 2 || usage.Rejected != 1 || usage.RejectedPerConnection != 1 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	// Canceling a watch frees room for a new one
+	if err := wm.Cancel(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wm.Create(peerContext("10.0.0.3:5000"), "c", "", 0, nil); err != nil {
+		t.Fatalf("Create after cancel failed: %v", err)
+	}
+}
+
+func TestLeaseLimits(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	lm := NewLeaseManager(store, &config.LeaseConfig{CheckInterval: time.Hour},
+		&config.LimitsConfig{MaxLeaseCount: 3, MaxLeasesPerConnection: 1})
+
+	if _, err := lm.GrantFor("10.0.0.1:5000", 1, 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lm.GrantFor("10.0.0.1:5000", 2, 60); !errors.Is(err, ErrTooManyLeases) {
+		t.Fatalf("Expected ErrTooManyLeases for the second lease on a connection, got %v", err)
+	}
+
+	// A lease granted through another member counts against the cluster limit
+	if _, err := store.LeaseGrant(context.Background(), 100, 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lm.GrantFor("10.0.0.2:5000", 2, 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lm.GrantFor("10.0.0.3:5000", 3, 60); !errors.Is(err, ErrTooManyLeases) {
+		t.Fatalf("Expected ErrTooManyLeases above max_lease_count, got %v", err)
+	}
+	usage := lm.Usage()
+	if usage.Count != 3 || usage.MaxPerConnection != 1 || usage.Rejected != 1 || usage.RejectedPerConnection != 1 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	// Revoking releases both the cluster and the connection count
+	if err := lm.Revoke(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lm.GrantFor("10.0.0.1:5000", 4, 60); err != nil {
+		t.Fatalf("Grant after revoke failed: %v", err)
+	}
+}
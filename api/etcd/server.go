@@ -18,17 +18,25 @@ import (
 	"context"
 	"fmt"
 	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
 	"metaStore/pkg/config"
+	"metaStore/pkg/encryption"
 	"metaStore/pkg/log"
 	"metaStore/pkg/reliability"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/raft/v3/raftpb"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
@@ -39,6 +47,8 @@ type Server struct {
 	grpcSrv  *grpc.Server     // gRPC server
 	listener net.Listener     // Network listener
 
+	extraListeners []net.Listener // Further listen_client_urls served by the same gRPC server
+
 	// Management components
 	watchMgr   *WatchManager    // Watch manager
 	leaseMgr   *LeaseManager    // Lease manager
@@ -53,9 +63,33 @@ type Server struct {
 	dataValidator *reliability.DataValidator    // Data validator
 
 	// Configuration
-	clusterID    uint64   // Cluster ID
-	memberID     uint64   // Member ID
-	clusterPeers []string // Peer URLs of all cluster members
+	clusterID    uint64                 // Cluster ID
+	memberID     uint64                 // Member ID
+	clusterPeers []string               // Peer URLs of all cluster members
+	clientURLs   []string               // Client URLs this member registers for MemberList
+	placement    config.PlacementConfig // Member zone and leader placement policy
+	watchCfg     config.WatchConfig     // Scavenging of orphaned watches
+	compression  *compressionHandler    // Accepted gRPC compressors and payload sizes
+	witness      bool                   // Witness members never keep leadership
+	replica      bool                   // Read replicas serve serializable reads only
+	readRevision int64                  // Revision reads are pinned at (server.read_revision), 0 for none
+
+	healthCheck       bool   // Whether the gRPC health service is registered
+	panicRecovery     bool   // Whether handler panics are recovered (reliability.enable_panic_recovery)
+	healthMaxApplyLag uint64 // Apply lag beyond which the node is reported as lagging
+
+	advertisePeerURLs []string // Peer URLs registered for MemberList (raft.advertise_peer_urls), empty for the cluster peer list entry
+
+	leaderKey           string        // Key the leader publishes its identity under, empty for none
+	leaderRenewInterval time.Duration // How often the leader renews the record
+
+	stopRegister chan struct{} // Closed on shutdown to stop retrying the member registration
+
+	clusterVersion atomic.Value // Lowest binary version of all members (string), see cluster_version.go
+
+	drain        *drainState   // Drain of the gRPC server ahead of a shutdown
+	drainTimeout time.Duration // Default time allowed for a drain
+	closed       chan struct{} // Closed once the shutdown has stopped the gRPC server
 }
 
 // ServerConfig server configuration
@@ -126,11 +160,33 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 		cfg.ResourceLimits = &limits
 	}
 
-	// Create listener
-	listener, err := net.Listen("tcp", cfg.Address)
-	if err != nil {
-		return nil, fmt.Errorf("failed to listen on %s: %v", cfg.Address, err)
+	// Load snapshot encryption keys before anything is started
+	var snapshotKeyring *encryption.Keyring
+	if cfg.Config != nil {
+		keyring, err := encryption.LoadKeyring(&cfg.Config.Server.Maintenance.SnapshotEncryption)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load snapshot encryption keys: %w", err)
+		}
+		snapshotKeyring = keyring
+	}
+
+	// Create listeners, the first one is advertised when no client URLs are configured
+	addresses := []string{cfg.Address}
+	if cfg.Config != nil && len(cfg.Config.Server.Etcd.ListenClientURLs) > 0 {
+		addresses = cfg.Config.Server.Etcd.ListenAddresses()
+	}
+	listeners := make([]net.Listener, 0, len(addresses))
+	for _, addr := range addresses {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %v", addr, err)
+		}
+		listeners = append(listeners, ln)
 	}
+	listener := listeners[0]
 
 	// Initialize reliability components
 	var shutdownMgr *reliability.GracefulShutdown
@@ -160,11 +216,12 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 	}
 
 	// Create AuthManager (using configuration)
+	// 认证数据是服务端内部写入，不经过准入 hook
 	var authMgr *AuthManager
 	if cfg.Config != nil {
-		authMgr = NewAuthManager(cfg.Store, &cfg.Config.Server.Auth)
+		authMgr = NewAuthManager(admission.Unwrap(cfg.Store), &cfg.Config.Server.Auth)
 	} else {
-		authMgr = NewAuthManager(cfg.Store)
+		authMgr = NewAuthManager(admission.Unwrap(cfg.Store))
 	}
 
 	// Create Server instance (need to create first to use its methods)
@@ -182,15 +239,65 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 		clusterID:     cfg.ClusterID,
 		memberID:      cfg.MemberID,
 		clusterPeers:  cfg.ClusterPeers,
+		stopRegister:  make(chan struct{}),
+		drain:         newDrainState(),
+		drainTimeout:  5 * time.Second,
+		closed:        make(chan struct{}),
+	}
+	var advertised []string
+	if cfg.Config != nil {
+		advertised = cfg.Config.Server.Etcd.AdvertiseClientURLs
+	}
+	s.clientURLs = advertiseClientURLs(advertised, listener.Addr().String())
+	s.extraListeners = listeners[1:]
+	s.panicRecovery = cfg.Config == nil || cfg.Config.Server.Reliability.EnablePanicRecovery
+	if cfg.Config != nil {
+		s.placement = cfg.Config.Server.Raft.Placement
+		s.watchCfg = cfg.Config.Server.Watch
+		s.witness = cfg.Config.Server.Raft.IsWitness()
+		s.replica = cfg.Config.Server.Raft.IsReplica()
+		s.readRevision = cfg.Config.Server.ReadRevision
+		s.healthMaxApplyLag = cfg.Config.Server.Reliability.HealthMaxApplyLag
+		s.leaderKey = cfg.Config.Server.Reliability.LeaderKey
+		s.advertisePeerURLs = cfg.Config.Server.Raft.AdvertisePeerURLs
+		s.leaderRenewInterval = cfg.Config.Server.Reliability.LeaderRenewInterval
+		if cfg.Config.Server.Reliability.DrainTimeout > 0 {
+			s.drainTimeout = cfg.Config.Server.Reliability.DrainTimeout
+		}
 	}
 
+	// Raise the CORRUPT alarm when a checksum of the raft log or a snapshot fails
+	stopCorruptionAlarm := reliability.OnCorruption(func(source string, err error) {
+		s.alarmMgr.Activate(&pb.AlarmMember{MemberID: cfg.MemberID, Alarm: pb.AlarmType_CORRUPT})
+	})
+
+	// Compressors accepted from clients, gzip unless configured otherwise
+	compressors := []string{compressorGzip}
+	if cfg.Config != nil && len(cfg.Config.Server.GRPC.Compression) > 0 {
+		compressors = cfg.Config.Server.GRPC.Compression
+	}
+	s.compression = newCompressionHandler(compressors)
+
 	// Build gRPC server options
 	grpcOpts := []grpc.ServerOption{
+		grpc.StatsHandler(s.compression), // Request compressor and payload sizes
 		// Interceptor chain
 		grpc.ChainUnaryInterceptor(
 			s.PanicRecoveryInterceptor,   // Panic recovery (first layer)
+			s.CompressionInterceptor,     // Only enabled compressors
 			resourceMgr.LimitInterceptor, // Resource limits
+			s.IdentityInterceptor,        // Cluster and member IDs
 			s.AuthInterceptor,            // Authentication and authorization
+			s.PinnedReadInterceptor,      // Reads pinned at server.read_revision
+			s.ReplicaInterceptor,         // Read-only serving on read replicas
+			s.HLCInterceptor,             // Hybrid logical clock headers
+			s.OriginInterceptor,          // Frontend of proposals for batching
+		),
+		grpc.ChainStreamInterceptor(
+			s.PanicRecoveryStreamInterceptor, // Panic recovery (first layer)
+			s.CompressionStreamInterceptor,   // Only enabled compressors
+			s.IdentityStreamInterceptor,      // Cluster and member IDs
+			s.DrainStreamInterceptor,         // Eviction of long-lived streams on drain
 		),
 	}
 
@@ -262,18 +369,36 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 	}
 
 	// Register gRPC services
-	pb.RegisterKVServer(grpcSrv, &KVServer{server: s})
+	maxRangeKeys := int64(defaultMaxRangeKeys)
+	if cfg.Config != nil {
+		maxRangeKeys = cfg.Config.Server.Limits.MaxRangeKeys
+	}
+	pb.RegisterKVServer(grpcSrv, &KVServer{server: s, maxRangeKeys: maxRangeKeys})
 	pb.RegisterWatchServer(grpcSrv, &WatchServer{server: s})
 	pb.RegisterLeaseServer(grpcSrv, &LeaseServer{server: s})
 
+	// Register batch write service (MetaStore extension)
+	maxBatchOps := defaultMaxBatchOps
+	if cfg.Config != nil {
+		maxBatchOps = cfg.Config.Server.Limits.MaxBatchOps
+	}
+	grpcSrv.RegisterService(&batchServiceDesc, &BatchServer{server: s, maxOps: maxBatchOps})
+
+	// Register key history service (MetaStore extension)
+	grpcSrv.RegisterService(&historyServiceDesc, &HistoryServer{server: s})
+
 	// Create Maintenance server (using configuration)
 	snapshotChunkSize := 4 * 1024 * 1024 // Default 4MB
+	var signSnapshots bool
 	if cfg.Config != nil {
 		snapshotChunkSize = cfg.Config.Server.Maintenance.SnapshotChunkSize
+		signSnapshots = cfg.Config.Server.Maintenance.SignSnapshots
 	}
 	maintenanceServer := &MaintenanceServer{
 		server:            s,
 		snapshotChunkSize: snapshotChunkSize,
+		signSnapshots:     signSnapshots,
+		snapshotKeyring:   snapshotKeyring,
 	}
 	pb.RegisterMaintenanceServer(grpcSrv, maintenanceServer)
 	pb.RegisterAuthServer(grpcSrv, &AuthServer{server: s})
@@ -304,6 +429,7 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 
 		// Set initial status to SERVING
 		healthMgr.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		s.healthCheck = true
 	}
 
 	// Register graceful shutdown hooks
@@ -314,16 +440,27 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 		// Mark as unhealthy, stop accepting new requests
 		if cfg.EnableHealthCheck {
 			healthMgr.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+			// Also marks the read and write services NOT_SERVING and ignores later health monitor updates
+			healthMgr.GetServer().Shutdown()
 		}
 		return nil
 	})
 
+	// Transfer leadership before stopping so the cluster doesn't wait for an election timeout
+	if cfg.Config != nil && cfg.Config.Server.Raft.LeaderTransfer.TransferOnShutdown() {
+		transferTimeout := cfg.Config.Server.Raft.LeaderTransfer.Timeout
+		shutdownMgr.RegisterHook(reliability.PhaseStopAccepting, func(ctx context.Context) error {
+			s.moveLeaderOnShutdown(ctx, transferTimeout)
+			return nil
+		})
+	}
+
 	shutdownMgr.RegisterHook(reliability.PhaseDrainConnections, func(ctx context.Context) error {
 		log.Info("Shutdown phase: Drain existing connections",
 			log.Phase("DrainConnections"),
 			log.Component("server"))
-		// Wait for existing requests to complete (controlled by context timeout)
-		time.Sleep(2 * time.Second)
+		// Move clients to other members and wait for requests in flight (controlled by context timeout)
+		s.drainOnShutdown(ctx)
 		return nil
 	})
 
@@ -340,6 +477,8 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 			log.Phase("CloseResources"),
 			log.Component("server"))
 
+		close(s.stopRegister)
+
 		// Stop Lease manager
 		if s.leaseMgr != nil {
 			s.leaseMgr.Stop()
@@ -355,6 +494,8 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 			s.resourceMgr.Close()
 		}
 
+		stopCorruptionAlarm()
+
 		// Gracefully stop gRPC server
 		if s.grpcSrv != nil {
 			s.grpcSrv.GracefulStop()
@@ -364,13 +505,50 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 		if s.listener != nil {
 			s.listener.Close()
 		}
+		for _, ln := range s.extraListeners {
+			ln.Close()
+		}
 
+		close(s.closed)
 		return nil
 	})
 
 	return s, nil
 }
 
+// leaderMover is implemented by raft-backed stores
+type leaderMover interface {
+	MoveLeader(ctx context.Context) (uint64, error)
+}
+
+// moveLeaderOnShutdown hands leadership to the healthiest follower before the
+// node stops. It is best effort: on failure the cluster falls back to an election.
+func (s *Server) moveLeaderOnShutdown(ctx context.Context, timeout time.Duration) {
+	lm, ok := kvstore.As[leaderMover](s.store)
+	if !ok {
+		return
+	}
+	if status := s.store.GetRaftStatus(); status.LeaderID == 0 || status.LeaderID != status.NodeID {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	newLeader, err := lm.MoveLeader(ctx)
+	if err != nil {
+		log.Warn("Leadership transfer before shutdown failed, cluster will elect a new leader",
+			log.Err(err),
+			log.Component("server"))
+		return
+	}
+	log.Info("Transferred leadership before shutdown",
+		log.Uint64("new_leader", newLeader),
+		log.Duration("took", time.Since(start)),
+		log.Component("server"))
+}
+
 // Start starts the gRPC server
 func (s *Server) Start() error {
 	log.Info("Starting etcd-compatible gRPC server",
@@ -382,6 +560,42 @@ func (s *Server) Start() error {
 		s.leaseMgr.Start()
 	})
 
+	// Register client and peer URLs in the replicated member registry once the cluster accepts writes
+	reliability.SafeGo("member-registration", func() {
+		s.registerSelf(s.clientURLs)
+	})
+
+	// Report leader/follower/lagging state through the gRPC health service
+	if s.healthCheck {
+		reliability.SafeGo("health-monitor", s.runHealthMonitor)
+	}
+
+	// Track the cluster version that gates wire features during rolling upgrades
+	reliability.SafeGo("cluster-version", func() {
+		s.runClusterVersion(clusterVersionInterval)
+	})
+
+	// Publish the leader identity for external controllers
+	if s.leaderKey != "" && s.leaderRenewInterval > 0 {
+		reliability.SafeGo("leader-record", func() {
+			s.runLeaderRecord(s.leaderKey, s.leaderRenewInterval)
+		})
+	}
+
+	// Cancel watches left behind by streams that ended without cleaning up
+	if s.watchCfg.ScavengeInterval > 0 {
+		reliability.SafeGo("watch-scavenger", func() {
+			s.watchMgr.RunScavenger(s.watchCfg.ScavengeInterval, s.watchCfg.OrphanTimeout)
+		})
+	}
+
+	// Keep leadership in the preferred members and zone, and off witness members
+	if s.placement.CheckInterval > 0 {
+		reliability.SafeGo("leader-placement", func() {
+			s.runPlacement(s.placement.CheckInterval)
+		})
+	}
+
 	// Start graceful shutdown listener (waiting for signals in background)
 	reliability.SafeGo("shutdown-listener", func() {
 		s.shutdownMgr.Wait()
@@ -399,7 +613,21 @@ func (s *Server) Start() error {
 		log.Component("server"))
 
 	// Start gRPC service
-	return s.grpcSrv.Serve(s.listener)
+	for _, ln := range s.extraListeners {
+		reliability.SafeGo("grpc-listener", func() {
+			log.Info("Serving etcd gRPC on additional address",
+				log.String("address", ln.Addr().String()),
+				log.Component("server"))
+			s.grpcSrv.Serve(ln)
+		})
+	}
+	err := s.grpcSrv.Serve(s.listener)
+	if s.drain.started() {
+		// A drain stops the gRPC server ahead of the shutdown, keep running until the shutdown is done
+		<-s.closed
+		return nil
+	}
+	return err
 }
 
 // Stop stops the gRPC server (triggers graceful shutdown)
@@ -416,6 +644,36 @@ func (s *Server) WaitForShutdown() {
 		log.Component("server"))
 }
 
+// AuthManager returns the user database shared with the MySQL frontend
+func (s *Server) AuthManager() *AuthManager {
+	return s.authMgr
+}
+
+// LeaseExpiryStats returns the state of the lease expiry scheduler for metrics
+func (s *Server) LeaseExpiryStats() kvstore.LeaseExpiryStats {
+	return s.leaseMgr.ExpiryStats()
+}
+
+// WatchUsage returns the watch count and limits of this member for metrics
+func (s *Server) WatchUsage() kvstore.ResourceUsage {
+	return s.watchMgr.Usage()
+}
+
+// LeaseUsage returns the cluster's lease count and limits for metrics
+func (s *Server) LeaseUsage() kvstore.ResourceUsage {
+	return s.leaseMgr.Usage()
+}
+
+// WatchScavengeStats returns the number of orphaned watches canceled for metrics
+func (s *Server) WatchScavengeStats() kvstore.WatchScavengeStats {
+	return s.watchMgr.ScavengeStats()
+}
+
+// CompressionStats returns raw and compressed payload bytes per gRPC method for metrics
+func (s *Server) CompressionStats() []kvstore.CompressionStats {
+	return s.compression.Stats()
+}
+
 // Address returns the server listen address
 func (s *Server) Address() string {
 	if s.listener != nil {
@@ -434,20 +692,91 @@ func (s *Server) getResponseHeader() *pb.ResponseHeader {
 	}
 }
 
-// PanicRecoveryInterceptor panic recovery interceptor
+// PanicRecoveryInterceptor turns a panic of a handler into an Internal error.
+// The panic is logged with its stack and the method and client of the request
 func (s *Server) PanicRecoveryInterceptor(
 	ctx context.Context,
 	req interface{},
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (resp interface{}, err error) {
+	if !s.panicRecovery {
+		return handler(ctx, req)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fields := append(panicRequestFields(ctx, info.FullMethod), log.String("request_type", fmt.Sprintf("%T", req)))
+			reliability.ReportPanic(reliability.PanicSourceGRPC, info.FullMethod, r, fields...)
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// PanicRecoveryStreamInterceptor ends a stream whose handler panicked with an
+// Internal error, like PanicRecoveryInterceptor
+func (s *Server) PanicRecoveryStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	if !s.panicRecovery {
+		return handler(srv, ss)
+	}
 	defer func() {
 		if r := recover(); r != nil {
-			reliability.RecoverPanic(fmt.Sprintf("grpc-handler-%s", info.FullMethod))
-			err = fmt.Errorf("internal server error: panic recovered")
+			reliability.ReportPanic(reliability.PanicSourceGRPC, info.FullMethod, r, panicRequestFields(ss.Context(), info.FullMethod)...)
+			err = status.Error(codes.Internal, "internal server error")
 		}
 	}()
 
+	return handler(srv, ss)
+}
+
+// panicRequestFields describes the request a handler panicked on
+func panicRequestFields(ctx context.Context, method string) []zap.Field {
+	fields := []zap.Field{log.String("method", method)}
+	if addr := clientAddress(ctx); addr != "" {
+		fields = append(fields, log.String("client", addr))
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			fields = append(fields, log.String("user_agent", ua[0]))
+		}
+	}
+	return fields
+}
+
+// clientAddress returns the address of the client connection a request came
+// from, which identifies the connection for per-connection limits; empty when
+// unknown
+func clientAddress(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// PriorityHeader lets a client lower the propose queue priority of its writes:
+// bulk loads that send "low" are proposed after interactive writes
+const PriorityHeader = "x-metastore-priority"
+
+// OriginInterceptor tags requests with the etcd frontend, so their proposals use
+// the etcd queue of raft.batch.frontends, and applies PriorityHeader
+func (s *Server) OriginInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx = kvstore.WithOrigin(ctx, kvstore.OriginEtcd)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(PriorityHeader); len(values) > 0 && values[0] == kvstore.PriorityLow.String() {
+			ctx = kvstore.WithPriority(ctx, kvstore.PriorityLow)
+		}
+	}
 	return handler(ctx, req)
 }
 
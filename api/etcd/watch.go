@@ -31,6 +31,12 @@ type WatchServer struct {
 
 // Watch 创建 watch 流
 func (s *WatchServer) Watch(stream pb.Watch_WatchServer) error {
+	// stream 的 metadata 中的 value 过滤作用于其上创建的所有 watch
+	valueFilter, err := watchValueFilter(stream.Context())
+	if err != nil {
+		return err
+	}
+
 	// 跟踪这个stream创建的所有watchID，用于清理
 	streamWatches := make(map[int64]struct{})
 
@@ -51,7 +57,7 @@ func (s *WatchServer) Watch(stream pb.Watch_WatchServer) error {
 
 		// 处理创建 watch 请求
 		if createReq := req.GetCreateRequest(); createReq != nil {
-			watchID, err := s.handleCreateWatch(stream, createReq)
+			watchID, err := s.handleCreateWatch(stream, createReq, valueFilter)
 			if err != nil {
 				return err
 			}
@@ -73,7 +79,7 @@ func (s *WatchServer) Watch(stream pb.Watch_WatchServer) error {
 }
 
 // handleCreateWatch 处理创建 watch 请求，返回watchID和error
-func (s *WatchServer) handleCreateWatch(stream pb.Watch_WatchServer, req *pb.WatchCreateRequest) (int64, error) {
+func (s *WatchServer) handleCreateWatch(stream pb.Watch_WatchServer, req *pb.WatchCreateRequest, valueFilter func([]byte) bool) (int64, error) {
 	key := string(req.Key)
 	rangeEnd := string(req.RangeEnd)
 	startRevision := req.StartRevision
@@ -84,26 +90,31 @@ func (s *WatchServer) handleCreateWatch(stream pb.Watch_WatchServer, req *pb.Wat
 		ProgressNotify: req.ProgressNotify,
 		Filters:        convertFilters(req.Filters),
 		Fragment:       req.Fragment,
+		ValueFilter:    valueFilter,
 	}
 
 	// 创建 watch - 支持客户端指定 WatchId
+	// watch 的生命周期与 stream 绑定
+	ctx := stream.Context()
 	var watchID int64
+	var err error
 	if req.WatchId != 0 {
 		// Client specified watchID
-		watchID = s.server.watchMgr.CreateWithID(req.WatchId, key, rangeEnd, startRevision, opts)
+		watchID, err = s.server.watchMgr.CreateWithID(ctx, req.WatchId, key, rangeEnd, startRevision, opts)
 	} else {
 		// Server generates watchID
-		watchID = s.server.watchMgr.Create(key, rangeEnd, startRevision, opts)
+		watchID, err = s.server.watchMgr.Create(ctx, key, rangeEnd, startRevision, opts)
 	}
 
-	if watchID < 0 {
-		// 创建失败，发送错误响应
+	if err != nil {
+		// 创建失败，发送错误响应；超过 watch 数量上限时原因为 ErrTooManyWatches
+		log.Debug("Failed to create watch", zap.String("client", clientAddress(ctx)), zap.Error(err), zap.String("component", "etcdapi-watch"))
 		err := stream.Send(&pb.WatchResponse{
 			Header:  s.server.getResponseHeader(),
 			WatchId: -1,
 			Created: false,
 			Canceled: true,
-			CancelReason: "failed to create watch",
+			CancelReason: err.Error(),
 		})
 		return -1, err
 	}
@@ -160,49 +171,51 @@ func (s *WatchServer) handleCancelWatch(stream pb.Watch_WatchServer, req *pb.Wat
 
 // sendEvents 发送 watch 事件
 func (s *WatchServer) sendEvents(stream pb.Watch_WatchServer, watchID int64) {
-	eventCh, ok := s.server.watchMgr.GetEventChan(watchID)
+	eventCh, ok := s.server.watchMgr.Attach(watchID)
 	if !ok {
 		return
 	}
+	defer s.server.watchMgr.Detach(watchID)
+	done := stream.Context().Done()
 
-	for event := range eventCh {
-		// 转换事件类型
-		var eventType mvccpb.Event_EventType
-		switch event.Type {
-		case kvstore.EventTypePut:
-			eventType = mvccpb.PUT
-		case kvstore.EventTypeDelete:
-			eventType = mvccpb.DELETE
-		}
-
-		// 构造 watch 事件
-		watchEvent := &mvccpb.Event{
-			Type: eventType,
-		}
-
-		// 添加当前键值对
-		// For both PUT and DELETE events, Kv is properly populated
-		if event.Kv != nil {
-			watchEvent.Kv = &mvccpb.KeyValue{
-				Key:            event.Kv.Key,
-				Value:          event.Kv.Value,
-				CreateRevision: event.Kv.CreateRevision,
-				ModRevision:    event.Kv.ModRevision,
-				Version:        event.Kv.Version,
-				Lease:          event.Kv.Lease,
+	// next 为合并时多读到的下一个 revision 的事件
+	var next *kvstore.WatchEvent
+	for {
+		var event kvstore.WatchEvent
+		if next != nil {
+			event, next = *next, nil
+		} else {
+			select {
+			case ev, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				event = ev
+			case <-done:
+				// stream 已结束，Watch 返回时取消 watch
+				return
 			}
 		}
 
-		// 添加前一个键值对（如果有）
-		// Note: event.PrevKv may be nil if prevKV option was false
-		if event.PrevKv != nil {
-			watchEvent.PrevKv = &mvccpb.KeyValue{
-				Key:            event.PrevKv.Key,
-				Value:          event.PrevKv.Value,
-				CreateRevision: event.PrevKv.CreateRevision,
-				ModRevision:    event.PrevKv.ModRevision,
-				Version:        event.PrevKv.Version,
-				Lease:          event.PrevKv.Lease,
+		// 同一个 revision 中已到达的事件（例如同一个事务的修改）合并到一个响应，
+		// 减少客户端从 header revision 之后重连时漏掉事务后半部分的情况
+		events := []*mvccpb.Event{toMVCCEvent(event)}
+		closed := false
+	coalesce:
+		for {
+			select {
+			case ev, ok := <-eventCh:
+				if !ok {
+					closed = true
+					break coalesce
+				}
+				if ev.Revision != event.Revision {
+					next = &ev
+					break coalesce
+				}
+				events = append(events, toMVCCEvent(ev))
+			default:
+				break coalesce
 			}
 		}
 
@@ -210,7 +223,7 @@ func (s *WatchServer) sendEvents(stream pb.Watch_WatchServer, watchID int64) {
 		resp := &pb.WatchResponse{
 			Header:  s.server.getResponseHeader(),
 			WatchId: watchID,
-			Events:  []*mvccpb.Event{watchEvent},
+			Events:  events,
 		}
 
 		// 更新 header 中的 revision
@@ -221,5 +234,52 @@ func (s *WatchServer) sendEvents(stream pb.Watch_WatchServer, watchID int64) {
 			s.server.watchMgr.Cancel(watchID)
 			return
 		}
+		if closed {
+			return
+		}
+	}
+}
+
+// toMVCCEvent 把 store 的 watch 事件转换为 etcd 事件
+func toMVCCEvent(event kvstore.WatchEvent) *mvccpb.Event {
+	// 转换事件类型
+	var eventType mvccpb.Event_EventType
+	switch event.Type {
+	case kvstore.EventTypePut:
+		eventType = mvccpb.PUT
+	case kvstore.EventTypeDelete:
+		eventType = mvccpb.DELETE
+	}
+
+	// 构造 watch 事件
+	watchEvent := &mvccpb.Event{
+		Type: eventType,
+	}
+
+	// 添加当前键值对
+	// For both PUT and DELETE events, Kv is properly populated
+	if event.Kv != nil {
+		watchEvent.Kv = &mvccpb.KeyValue{
+			Key:            event.Kv.Key,
+			Value:          event.Kv.Value,
+			CreateRevision: event.Kv.CreateRevision,
+			ModRevision:    event.Kv.ModRevision,
+			Version:        event.Kv.Version,
+			Lease:          event.Kv.Lease,
+		}
+	}
+
+	// 添加前一个键值对（如果有）
+	// Note: event.PrevKv may be nil if prevKV option was false
+	if event.PrevKv != nil {
+		watchEvent.PrevKv = &mvccpb.KeyValue{
+			Key:            event.PrevKv.Key,
+			Value:          event.PrevKv.Value,
+			CreateRevision: event.PrevKv.CreateRevision,
+			ModRevision:    event.PrevKv.ModRevision,
+			Version:        event.PrevKv.Version,
+			Lease:          event.PrevKv.Lease,
+		}
 	}
+	return watchEvent
 }
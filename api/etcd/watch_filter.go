@@ -0,0 +1,78 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+
+	"metaStore/pkg/sqlindex"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Server-side value filters travel in the metadata of the watch stream, since
+// WatchCreateRequest has no field for them, and apply to every watch created on
+// that stream. clientv3 opens a separate stream per distinct outgoing metadata,
+// so watches created with and without a filter do not share one.
+const (
+	WatchValuePrefixHeader = "x-metastore-watch-value-prefix"
+	WatchJSONPathHeader    = "x-metastore-watch-json-path"
+	WatchJSONValueHeader   = "x-metastore-watch-json-value"
+)
+
+// WithWatchValueFilter returns a client context whose watches only receive
+// events with values matching f
+func WithWatchValueFilter(ctx context.Context, f sqlindex.ValueFilter) context.Context {
+	var kv []string
+	if f.Prefix != "" {
+		kv = append(kv, WatchValuePrefixHeader, f.Prefix)
+	}
+	if f.JSONPath != "" {
+		kv = append(kv, WatchJSONPathHeader, f.JSONPath, WatchJSONValueHeader, f.JSONValue)
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// watchValueFilter returns the value filter sent with a watch stream, nil when
+// there is none
+func watchValueFilter(ctx context.Context) (func([]byte) bool, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	f := sqlindex.ValueFilter{
+		Prefix:    first(WatchValuePrefixHeader),
+		JSONPath:  first(WatchJSONPathHeader),
+		JSONValue: first(WatchJSONValueHeader),
+	}
+	if err := f.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if f.Empty() {
+		return nil, nil
+	}
+	return f.Match, nil
+}
@@ -16,10 +16,12 @@ package etcd
 
 import (
 	"context"
+	"fmt"
 	"metaStore/internal/kvstore"
 	"metaStore/pkg/config"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // WatchManager 管理所有的 watch 订阅
@@ -27,14 +29,27 @@ type WatchManager struct {
 	mu            sync.RWMutex
 	store         kvstore.Store
 	watches       map[int64]*watchStream // watchID -> stream
+	clients       map[string]int         // 客户端连接地址 -> watch 数，由 mu 保护
 	nextID        atomic.Int64           // 下一个 watch ID
 	stopped       atomic.Bool            // 是否已停止
 	maxWatchCount int                    // 最大 Watch 数量限制（0 表示无限制）
+	maxPerClient  int                    // 单个连接的最大 Watch 数量（0 表示无限制）
+
+	rejected       atomic.Uint64 // 因超过 maxWatchCount 拒绝的 watch 数
+	rejectedClient atomic.Uint64 // 因超过 maxPerClient 拒绝的 watch 数
+
+	stopCh            chan struct{} // Stop 时关闭，停止清理
+	scavengedClosed   atomic.Uint64 // 因流已结束被清理的 watch 数
+	scavengedOrphaned atomic.Uint64 // 因长时间没有消费者被清理的 watch 数
 }
 
 // watchStream 表示一个 watch 流
 type watchStream struct {
 	watchID       int64
+	client        string          // 创建 watch 的客户端连接地址
+	done          <-chan struct{} // 创建 watch 的 gRPC 流结束时关闭
+	consumers     int             // 正在发送事件的消费者数，由 mu 保护
+	idleSince     time.Time       // 最后一个消费者退出（或创建）的时间，由 mu 保护
 	key           string
 	rangeEnd      string
 	startRevision int64
@@ -46,44 +61,68 @@ type watchStream struct {
 // 可选参数 cfg 用于设置 Watch 数量限制
 func NewWatchManager(store kvstore.Store, cfg ...*config.LimitsConfig) *WatchManager {
 	maxWatches := 0 // 默认无限制
+	maxPerClient := 0
 	if len(cfg) > 0 && cfg[0] != nil {
 		maxWatches = cfg[0].MaxWatchCount
+		maxPerClient = cfg[0].MaxWatchesPerConnection
 	}
 
 	return &WatchManager{
 		store:         store,
 		watches:       make(map[int64]*watchStream),
+		clients:       make(map[string]int),
 		maxWatchCount: maxWatches,
+		maxPerClient:  maxPerClient,
+		stopCh:        make(chan struct{}),
 	}
 }
 
-// Create 创建一个新的 watch
-func (wm *WatchManager) Create(key, rangeEnd string, startRevision int64, opts *kvstore.WatchOptions) int64 {
+// Create 创建一个新的 watch，ctx 为创建它的 gRPC 流的 context
+func (wm *WatchManager) Create(ctx context.Context, key, rangeEnd string, startRevision int64, opts *kvstore.WatchOptions) (int64, error) {
 	watchID := wm.nextID.Add(1)
-	return wm.CreateWithID(watchID, key, rangeEnd, startRevision, opts)
+	return wm.CreateWithID(ctx, watchID, key, rangeEnd, startRevision, opts)
 }
 
 // CreateWithID 使用指定的 watchID 创建 watch
-func (wm *WatchManager) CreateWithID(watchID int64, key, rangeEnd string, startRevision int64, opts *kvstore.WatchOptions) int64 {
+//
+// watch 随 ctx 所属的 gRPC 流结束，流结束后没有取消的 watch 由清理任务取消。
+// 本成员的 watch 数达到 limits.max_watch_count，或客户端连接的 watch 数达到
+// limits.max_watches_per_connection 时返回 ErrTooManyWatches；不知道客户端地址时不按连接限制
+func (wm *WatchManager) CreateWithID(ctx context.Context, watchID int64, key, rangeEnd string, startRevision int64, opts *kvstore.WatchOptions) (int64, error) {
 	if wm.stopped.Load() {
-		return -1
+		return -1, ErrWatchCanceled
 	}
 
-	// Check watch count limit
-	wm.mu.RLock()
-	currentCount := len(wm.watches)
-	wm.mu.RUnlock()
-
-	if wm.maxWatchCount > 0 && currentCount >= wm.maxWatchCount {
-		// Watch limit exceeded
-		return -1
+	client := clientAddress(ctx)
+	ws := &watchStream{
+		watchID:       watchID,
+		client:        client,
+		done:          ctx.Done(),
+		idleSince:     time.Now(),
+		key:           key,
+		rangeEnd:      rangeEnd,
+		startRevision: startRevision,
 	}
 
-	// Check if watchID already exists
+	// 检查上限并占位，并发创建的 watch 不会一起越过上限
 	wm.mu.Lock()
 	if _, exists := wm.watches[watchID]; exists {
 		wm.mu.Unlock()
-		return -1 // WatchID already in use
+		return -1, fmt.Errorf("%w: watch ID %d is already in use", ErrInvalidArgument, watchID)
+	}
+	if wm.maxWatchCount > 0 && len(wm.watches) >= wm.maxWatchCount {
+		wm.mu.Unlock()
+		wm.rejected.Add(1)
+		return -1, fmt.Errorf("%w: this member already serves limits.max_watch_count (%d) watches", ErrTooManyWatches, wm.maxWatchCount)
+	}
+	if client != "" && wm.maxPerClient > 0 && wm.clients[client] >= wm.maxPerClient {
+		wm.mu.Unlock()
+		wm.rejectedClient.Add(1)
+		return -1, fmt.Errorf("%w: this connection already has limits.max_watches_per_connection (%d) watches", ErrTooManyWatches, wm.maxPerClient)
+	}
+	wm.watches[watchID] = ws
+	if client != "" {
+		wm.clients[client]++
 	}
 	wm.mu.Unlock()
 
@@ -96,58 +135,97 @@ func (wm *WatchManager) CreateWithID(watchID int64, key, rangeEnd string, startR
 		WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error)
 	}
 
-	if wwo, ok := wm.store.(watchWithOptions); ok && opts != nil {
+	if wwo, ok := kvstore.As[watchWithOptions](wm.store); ok && opts != nil {
 		eventCh, err = wwo.WatchWithOptions(key, rangeEnd, startRevision, watchID, opts)
 	} else {
 		eventCh, err = wm.store.Watch(context.Background(), key, rangeEnd, startRevision, watchID)
 	}
 
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	if wm.watches[watchID] != ws {
+		// 创建期间被取消或管理器已停止
+		if err == nil {
+			wm.store.CancelWatch(watchID)
+		}
+		return -1, ErrWatchCanceled
+	}
 	if err != nil {
-		return -1
+		wm.remove(ws)
+		return -1, err
 	}
+	ws.eventCh = eventCh
+	return watchID, nil
+}
 
-	ws := &watchStream{
-		watchID:       watchID,
-		key:           key,
-		rangeEnd:      rangeEnd,
-		startRevision: startRevision,
-		eventCh:       eventCh,
+// remove 从 watches 中删除 ws 并释放其连接的计数，调用方持有 mu
+func (wm *WatchManager) remove(ws *watchStream) {
+	delete(wm.watches, ws.watchID)
+	if ws.client == "" {
+		return
+	}
+	if wm.clients[ws.client]--; wm.clients[ws.client] <= 0 {
+		delete(wm.clients, ws.client)
 	}
-
-	wm.mu.Lock()
-	wm.watches[watchID] = ws
-	wm.mu.Unlock()
-
-	return watchID
 }
 
 // Cancel 取消一个 watch
 func (wm *WatchManager) Cancel(watchID int64) error {
 	wm.mu.Lock()
-	_, ok := wm.watches[watchID]
+	ws, ok := wm.watches[watchID]
 	if !ok {
 		wm.mu.Unlock()
 		return ErrWatchCanceled
 	}
-	delete(wm.watches, watchID)
+	wm.remove(ws)
 	wm.mu.Unlock()
 
 	// 取消 store 中的 watch
 	return wm.store.CancelWatch(watchID)
 }
 
-// GetEventChan 获取 watch 的事件通道
-func (wm *WatchManager) GetEventChan(watchID int64) (<-chan kvstore.WatchEvent, bool) {
+// Usage 返回 watch 数量、上限和拒绝次数
+func (wm *WatchManager) Usage() kvstore.ResourceUsage {
 	wm.mu.RLock()
-	defer wm.mu.RUnlock()
+	usage := kvstore.ResourceUsage{
+		Count:              len(wm.watches),
+		Limit:              wm.maxWatchCount,
+		PerConnectionLimit: wm.maxPerClient,
+	}
+	for _, n := range wm.clients {
+		usage.MaxPerConnection = max(usage.MaxPerConnection, n)
+	}
+	wm.mu.RUnlock()
+	usage.Rejected = wm.rejected.Load()
+	usage.RejectedPerConnection = wm.rejectedClient.Load()
+	return usage
+}
+
+// Attach 登记一个发送 watch 事件的消费者并返回事件通道，消费者退出时调用 Detach
+func (wm *WatchManager) Attach(watchID int64) (<-chan kvstore.WatchEvent, bool) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
 
 	ws, ok := wm.watches[watchID]
 	if !ok {
 		return nil, false
 	}
+	ws.consumers++
 	return ws.eventCh, true
 }
 
+// Detach 注销 Attach 登记的消费者
+func (wm *WatchManager) Detach(watchID int64) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	if ws, ok := wm.watches[watchID]; ok && ws.consumers > 0 {
+		if ws.consumers--; ws.consumers == 0 {
+			ws.idleSince = time.Now()
+		}
+	}
+}
+
 // Stop 停止所有 watch
 func (wm *WatchManager) Stop() {
 	if !wm.stopped.CompareAndSwap(false, true) {
@@ -162,4 +240,6 @@ func (wm *WatchManager) Stop() {
 		wm.store.CancelWatch(watchID)
 	}
 	wm.watches = make(map[int64]*watchStream)
+	wm.clients = make(map[string]int)
+	close(wm.stopCh)
 }
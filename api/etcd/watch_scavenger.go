@@ -0,0 +1,98 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+)
+
+// RunScavenger 每隔 interval 清理一次孤立的 watch，直到 Stop
+//
+// 正常情况下 watch 随创建它的 gRPC 流一起取消；客户端消失而连接没有关闭时，
+// gRPC keepalive（grpc.keepalive_time / keepalive_timeout）探测到后关闭连接，流随之结束。
+// 清理任务兜底取消两类仍留在管理器中的 watch：
+//   - 创建它的流已结束
+//   - 超过 orphanTimeout 没有消费者发送它的事件
+func (wm *WatchManager) RunScavenger(interval, orphanTimeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wm.stopCh:
+			return
+		case now := <-ticker.C:
+			wm.Scavenge(now, orphanTimeout)
+		}
+	}
+}
+
+// Scavenge 取消流已结束的 watch 和 now 之前 orphanTimeout 以上没有消费者的 watch，
+// 返回两类各取消的个数
+func (wm *WatchManager) Scavenge(now time.Time, orphanTimeout time.Duration) (closed, orphaned int) {
+	var ids []int64
+	wm.mu.Lock()
+	for id, ws := range wm.watches {
+		switch {
+		case isClosed(ws.done):
+			closed++
+		case ws.consumers == 0 && orphanTimeout > 0 && now.Sub(ws.idleSince) >= orphanTimeout:
+			orphaned++
+		default:
+			continue
+		}
+		wm.remove(ws)
+		ids = append(ids, id)
+	}
+	wm.mu.Unlock()
+
+	for _, id := range ids {
+		wm.store.CancelWatch(id)
+	}
+	if len(ids) == 0 {
+		return 0, 0
+	}
+	wm.scavengedClosed.Add(uint64(closed))
+	wm.scavengedOrphaned.Add(uint64(orphaned))
+	log.Info("Scavenged orphaned watches",
+		log.Int("stream_closed", closed),
+		log.Int("orphaned", orphaned),
+		log.Duration("orphan_timeout", orphanTimeout),
+		log.Component("etcdapi-watch"))
+	return closed, orphaned
+}
+
+// ScavengeStats 返回清理任务取消的 watch 总数
+func (wm *WatchManager) ScavengeStats() kvstore.WatchScavengeStats {
+	return kvstore.WatchScavengeStats{
+		StreamClosed: wm.scavengedClosed.Load(),
+		Orphaned:     wm.scavengedOrphaned.Load(),
+	}
+}
+
+// isClosed done 是否已关闭，nil 表示永不结束
+func isClosed(done <-chan struct{}) bool {
+	if done == nil {
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
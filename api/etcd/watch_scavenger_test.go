@@ -0,0 +1,83 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+)
+
+func TestScavengeWatches(t *testing.T) {
+	wm := NewWatchManager(memory.NewMemoryEtcd())
+	defer wm.Stop()
+
+	// A watch whose stream ended without canceling it
+	streamCtx, endStream := context.WithCancel(context.Background())
+	closedID, err := wm.Create(streamCtx, "a", "", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endStream()
+
+	// A watch whose events nobody sends, and one with an active consumer
+	orphanID, err := wm.Create(context.Background(), "b", "", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activeID, err := wm.Create(context.Background(), "c", "", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := wm.Attach(activeID); !ok {
+		t.Fatal("Attach failed")
+	}
+
+	// Before the orphan timeout only the closed stream's watch is canceled
+	closed, orphaned := wm.Scavenge(time.Now(), time.Minute)
+	if closed != 1 || orphaned != 0 {
+		t.Fatalf("Expected 1 closed and 0 orphaned watches, got %d and %d", closed, orphaned)
+	}
+	if _, ok := wm.Attach(closedID); ok {
+		t.Error("Expected the watch of the ended stream to be canceled")
+	}
+
+	closed, orphaned = wm.Scavenge(time.Now().Add(2*time.Minute), time.Minute)
+	if closed != 0 || orphaned != 1 {
+		t.Fatalf("Expected 0 closed and 1 orphaned watches, got %d and %d", closed, orphaned)
+	}
+	if _, ok := wm.Attach(orphanID); ok {
+		t.Error("Expected the orphaned watch to be canceled")
+	}
+
+	// The consumer leaving starts its orphan timeout
+	wm.Detach(activeID)
+	if _, orphaned := wm.Scavenge(time.Now().Add(30*time.Second), time.Minute); orphaned != 0 {
+		t.Error("Expected the watch to survive until its orphan timeout")
+	}
+	if _, orphaned := wm.Scavenge(time.Now().Add(2*time.Minute), time.Minute); orphaned != 1 {
+		t.Error("Expected the watch to be canceled after its orphan timeout")
+	}
+
+	stats := wm.ScavengeStats()
+	if stats.StreamClosed != 1 || stats.Orphaned != 2 {
+		t.Errorf("Unexpected scavenge stats %+v", stats)
+	}
+	if usage := wm.Usage(); usage.Count != 0 {
+		t.Errorf("Expected no watches left, got %d", usage.Count)
+	}
+}
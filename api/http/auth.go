@@ -0,0 +1,145 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"metaStore/api/etcd"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// AuthLoginPath 用户名和密码换取 token
+//
+//	POST /auth/login  {"name":"alice","password":"secret"} -> {"token":"..."}
+//
+// etcd Auth 启用后，除 /auth/login、/openapi.json 和健康检查（/health、/readyz、/livez）外的请求
+// 都要在 Authorization 头中带上 token（可以加 "Bearer " 前缀），并与 gRPC 接口一样检查 key 权限：
+// KV 读写和 watch 按 key 检查，批量写入和 /admin 下的修改操作需要空 key 上的写权限。
+// /admin 下返回 key 或 value 的查询同样检查权限：回收站按 key 检查读权限，
+// raft 日志和用量报告覆盖整个 keyspace，需要空 key 上的写权限；其余查询只返回集群元数据
+const AuthLoginPath = "/auth/login"
+
+// UserStore etcd Auth 的用户数据库，启用认证后 HTTP 请求使用相同的用户、token 和权限
+type UserStore interface {
+	IsEnabled() bool
+	Authenticate(username, password string) (string, error)
+	ValidateToken(token string) (*etcd.TokenInfo, error)
+	CheckPermission(username string, key []byte, permType etcd.PermissionType) error
+}
+
+// loginRequest /auth/login 的请求体，字段与 etcd 的 AuthenticateRequest 一致
+type loginRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// handleLogin 处理登录
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authEnabled() {
+		http.Error(w, "authentication is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid login request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	token, err := s.users.Authenticate(req.Name, req.Password)
+	if err != nil {
+		// 不区分用户不存在和密码错误
+		log.Warn("HTTP login failed",
+			zap.String("username", req.Name),
+			zap.String("component", "http"))
+		http.Error(w, "authentication failed, invalid user ID or password", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+// authEnabled 是否需要认证
+func (s *Server) authEnabled() bool {
+	return s.users != nil && s.users.IsEnabled()
+}
+
+// withAuth 启用认证后校验 token，把用户名放入请求的 context，
+// 并检查批量写入和管理接口的权限；KV 和 watch 的 key 权限由各自的 handler 检查
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authEnabled() || r.URL.Path == AuthLoginPath || r.URL.Path == OpenAPIPath || isProbePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+		info, err := s.users.ValidateToken(token)
+		if err != nil {
+			http.Error(w, "invalid token: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		r = r.WithContext(admission.WithUser(r.Context(), info.Username))
+
+		// 与 gRPC 的 BatchWrite、Cluster 和 Maintenance 修改操作相同，检查空 key 上的写权限
+		if r.URL.Path == BatchPath || (strings.HasPrefix(r.URL.Path, "/admin/") && r.Method != http.MethodGet) {
+			if !s.authorize(w, r, "", etcd.PermissionWrite) {
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize 检查请求的用户对 key 的权限，没有权限时写出 403 并返回 false
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, key string, permType etcd.PermissionType) bool {
+	if !s.authEnabled() {
+		return true
+	}
+	username := admission.UserFromContext(r.Context())
+	if err := s.users.CheckPermission(username, []byte(key), permType); err != nil {
+		log.Warn("HTTP permission denied",
+			zap.String("username", username),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("component", "http"))
+		http.Error(w, "permission denied", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// canRead 返回请求的用户能否读取 key，用于在列表结果中跳过没有权限的条目
+func (s *Server) canRead(r *http.Request, key string) bool {
+	if !s.authEnabled() {
+		return true
+	}
+	return s.users.CheckPermission(admission.UserFromContext(r.Context()), []byte(key), etcd.PermissionRead) == nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+	"metaStore/pkg/protect"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAuth(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	users := etcd.NewAuthManager(store)
+	require.NoError(t, users.AddUser("root", "rootpw"))
+	require.NoError(t, users.AddUser("alice", "secret"))
+	require.NoError(t, users.AddRole("app"))
+	require.NoError(t, users.GrantPermission("app", etcd.Permission{
+		Type:     etcd.PermissionReadWrite,
+		Key:      []byte("app/"),
+		RangeEnd: []byte("app0"),
+	}))
+	require.NoError(t, users.GrantRole("alice", "app"))
+	require.NoError(t, users.Enable())
+
+	srv := httptest.NewServer(NewServer(Config{Store: store, Users: users}).httpServer.Handler)
+	defer srv.Close()
+
+	do := func(method, path, token, body string) int {
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	login := func(name, password string) string {
+		resp, err := http.Post(srv.URL+AuthLoginPath, "application/json",
+			strings.NewReader(`{"name":"`+name+`","password":"`+password+`"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct{ Token string }
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.NotEmpty(t, body.Token)
+		return body.Token
+	}
+
+	// 没有 token 或 token 无效时拒绝，/health 不需要认证
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/app/k", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/app/k", "bogus", ""))
+	assert.NotEqual(t, http.StatusUnauthorized, do(http.MethodGet, HealthPath, "", ""))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, AuthLoginPath, "", `{"name":"alice","password":"wrong"}`))
+
+	alice := login("alice", "secret")
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/app/k", alice, "v"))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/app/k", "Bearer "+alice, ""))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPut, "/other/k", alice, "v"))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, WatchPath+"?key=other/k", alice, ""))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, BatchPath, alice, `{"ops":[]}`))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/2", alice, "http://127.0.0.1:12379"))
+
+	root := login("root", "rootpw")
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/other/k", root, "v"))
+}
+
+func TestTrashAuth(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	protected := protect.Wrap(store, config.DeleteProtectionConfig{
+		Prefixes: []config.ProtectedPrefixConfig{
+			{Prefix: "app/", Mode: config.DeleteProtectionTrash, Retention: time.Hour},
+			{Prefix: "other/", Mode: config.DeleteProtectionTrash, Retention: time.Hour},
+		},
+		PurgeInterval: time.Hour,
+	})
+	defer protected.Close()
+	for _, k := range []string{"app/k", "other/k"} {
+		_, _, err := protected.PutWithLease(ctx, k, "secret-"+k, 0)
+		require.NoError(t, err)
+		_, _, _, err = protected.DeleteRange(ctx, k, "")
+		require.NoError(t, err)
+	}
+
+	users := etcd.NewAuthManager(store)
+	require.NoError(t, users.AddUser("root", "rootpw"))
+	require.NoError(t, users.AddUser("alice", "secret"))
+	require.NoError(t, users.AddRole("app"))
+	require.NoError(t, users.GrantPermission("app", etcd.Permission{
+		Type:     etcd.PermissionRead,
+		Key:      []byte("app/"),
+		RangeEnd: []byte("app0"),
+	}))
+	require.NoError(t, users.GrantRole("alice", "app"))
+	require.NoError(t, users.Enable())
+	alice, err := users.Authenticate("alice", "secret")
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(NewServer(Config{Store: protected, Users: users}).httpServer.Handler)
+	defer srv.Close()
+
+	get := func(path string) (int, []byte) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", alice)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, body
+	}
+
+	// 没有读权限时看不到已删除的 value
+	status, body := get(TrashPath + "?key=other/k")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.NotContains(t, string(body), "secret-other/k")
+
+	status, _ = get(TrashPath + "?key=app/k")
+	assert.Equal(t, http.StatusOK, status)
+
+	// 列表中跳过没有读权限的条目
+	status, body = get(TrashPath)
+	require.Equal(t, http.StatusOK, status)
+	var entries []protect.Entry
+	require.NoError(t, json.Unmarshal(body, &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "app/k", entries[0].Key)
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"metaStore/internal/kvstore"
+)
+
+// requestContext 返回写请求的 context
+//
+// ?timeout=5s 设置本次请求的超时，未设置时存储使用 limits.request_timeout。
+// context 标记来源为 HTTP 前端，提案使用 raft.batch.frontends 中 http 的队列
+func requestContext(r *http.Request) (context.Context, context.CancelFunc, error) {
+	parent := kvstore.WithOrigin(r.Context(), kvstore.OriginHTTP)
+	v := r.URL.Query().Get("timeout")
+	if v == "" {
+		ctx, cancel := context.WithCancel(parent)
+		return ctx, cancel, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout <= 0 {
+		return nil, nil, fmt.Errorf("invalid timeout %q", v)
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return ctx, cancel, nil
+}
+
+// writeTooManyRequests 提案管道饱和时返回 429 和 Retry-After（秒），err 不是背压错误时返回 false
+func writeTooManyRequests(w http.ResponseWriter, err error) bool {
+	retryAfter, ok := kvstore.RetryAfter(err)
+	if !ok {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+	return true
+}
+
+// writeLeaderChanged 等待 apply 期间 leader 变化时返回 503，客户端可以在新 leader 选出后重试
+func writeLeaderChanged(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, kvstore.ErrLeaderChanged) {
+		return false
+	}
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	return true
+}
+
+// writeTimeout 写请求在提案或等待 apply 阶段超时时返回 504，消息中带有超时的阶段。
+// 在 apply 阶段超时的写入已经提交给 Raft，之后仍可能生效
+func writeTimeout(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	http.Error(w, err.Error(), http.StatusGatewayTimeout)
+	return true
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// saturatedStore 拒绝所有写入，模拟提案管道饱和；删除模拟等待 apply 超时
+type saturatedStore struct {
+	*memory.MemoryEtcd
+	deadline time.Duration // 最近一次写入 context 剩余的时间
+}
+
+func (s *saturatedStore) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	if d, ok := ctx.Deadline(); ok {
+		s.deadline = time.Until(d)
+	}
+	return 0, nil, &kvstore.TooManyRequestsError{Reason: "test", RetryAfter: 1500 * time.Millisecond}
+}
+
+func (s *saturatedStore) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	return 0, nil, 0, &kvstore.StageError{Op: "DELETE", Stage: kvstore.StageApply, Err: context.DeadlineExceeded}
+}
+
+func TestTooManyRequests(t *testing.T) {
+	store := &saturatedStore{MemoryEtcd: memory.NewMemoryEtcd()}
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	put := func(query string) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, srv.URL+"/k"+query, strings.NewReader("v"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := put("?timeout=5s")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+	assert.InDelta(t, 5*time.Second, store.deadline, float64(time.Second))
+
+	resp = put("?timeout=soon")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL+"/k", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+	assert.Contains(t, string(body), "apply")
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/chunk"
+	"metaStore/pkg/log"
+	"metaStore/pkg/protect"
+	"metaStore/pkg/schema"
+
+	"go.uber.org/zap"
+)
+
+// BatchPath 批量写入接口路径
+//
+//	POST /batch {"ops":[{"type":"put","key":"k","value":"v"},{"type":"delete","key":"k"}]}
+//
+// 所有操作作为一个 Raft 提案原子地应用，要么全部生效要么全部不生效
+const BatchPath = "/batch"
+
+// defaultMaxBatchOps 未配置时一次批量写入的最大操作数
+const defaultMaxBatchOps = 10000
+
+// BatchOp 批量写入中的一个操作
+type BatchOp struct {
+	Type     string `json:"type"`                // "put" 或 "delete"
+	Key      string `json:"key"`                 // 按请求的 key 编码（KeyEncodingHeader）解码
+	Value    string `json:"value,omitempty"`     // put 的 value
+	Lease    int64  `json:"lease,omitempty"`     // put 绑定的 lease
+	RangeEnd string `json:"range_end,omitempty"` // delete 的范围结束 key，为空时只删除 key
+}
+
+// BatchRequest 批量写入请求
+type BatchRequest struct {
+	Ops []BatchOp `json:"ops"`
+}
+
+// BatchResponse 批量写入结果
+type BatchResponse struct {
+	Revision int64 `json:"revision"`
+	Ops      int   `json:"ops"`
+}
+
+// handleBatch 处理批量写入请求
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.replica {
+		http.Error(w, replicaWriteMessage, http.StatusForbidden)
+		return
+	}
+	if !observeHLC(w, r, s.store) {
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid batch: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Ops) > s.maxBatchOps {
+		http.Error(w, fmt.Sprintf("batch has %d operations, max %d", len(req.Ops), s.maxBatchOps), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	codec, ok := requestKeyCodec(w, r)
+	if !ok {
+		return
+	}
+
+	ops := make([]kvstore.Op, len(req.Ops))
+	for i, op := range req.Ops {
+		if op.Key == "" {
+			http.Error(w, fmt.Sprintf("operation %d: key is required", i), http.StatusBadRequest)
+			return
+		}
+		var err error
+		if op.Key, err = codec.Decode(op.Key); err == nil {
+			op.RangeEnd, err = codec.Decode(op.RangeEnd)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("operation %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		switch op.Type {
+		case "put":
+			ops[i] = kvstore.Op{Type: kvstore.OpPut, Key: []byte(op.Key), Value: []byte(op.Value), LeaseID: op.Lease}
+		case "delete":
+			ops[i] = kvstore.Op{Type: kvstore.OpDelete, Key: []byte(op.Key), RangeEnd: []byte(op.RangeEnd)}
+		default:
+			http.Error(w, fmt.Sprintf("operation %d: type must be put or delete", i), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel, err := requestContext(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	resp := BatchResponse{Ops: len(ops), Revision: s.store.CurrentRevision()}
+	if len(ops) > 0 {
+		txnResp, err := s.store.Txn(ctx, nil, ops, nil)
+		switch {
+		case writeTooManyRequests(w, err), writeLeaderChanged(w, err), writeTimeout(w, err):
+			return
+		case errors.Is(err, schema.ErrInvalidValue), errors.Is(err, schema.ErrInvalidSchema):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, chunk.ErrValueTooLarge), errors.Is(err, kvstore.ErrRequestTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case errors.Is(err, admission.ErrRejected), errors.Is(err, protect.ErrProtected):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Error("Failed to apply batch", zap.Int("ops", len(ops)), zap.Error(err), zap.String("component", "http"))
+			http.Error(w, "Failed on batch", http.StatusInternalServerError)
+			return
+		}
+		resp.Revision = txnResp.Revision
+	}
+
+	setCommitIndex(w, s.store)
+	setHLC(w, s.store)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store, MaxBatchOps: 3}).httpServer.Handler)
+	defer srv.Close()
+
+	post := func(body string) *http.Response {
+		resp, err := http.Post(srv.URL+BatchPath, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post(`{"ops":[{"type":"put","key":"a","value":"1"},{"type":"put","key":"b","value":"2"},{"type":"put","key":"c","value":"3"}]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out BatchResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	resp.Body.Close()
+	assert.Equal(t, 3, out.Ops)
+	assert.Equal(t, store.CurrentRevision(), out.Revision)
+	for _, k := range []string{"a", "b", "c"} {
+		_, ok := store.Lookup(k)
+		assert.True(t, ok, k)
+	}
+
+	resp = post(`{"ops":[{"type":"delete","key":"a","range_end":"c"},{"type":"put","key":"d","value":"4"}]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	r, err := store.Range(t.Context(), "a", "\x00", 0, 0)
+	require.NoError(t, err)
+	keys := []string{}
+	for _, kv := range r.Kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	assert.Equal(t, []string{"c", "d"}, keys)
+
+	// 非法的批次整体被拒绝
+	resp = post(`{"ops":[{"type":"put","key":"e","value":"5"},{"type":"get","key":"e"}]}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+	_, ok := store.Lookup("e")
+	assert.False(t, ok)
+
+	resp = post(`{"ops":[{"type":"put","key":"1"},{"type":"put","key":"2"},{"type":"put","key":"3"},{"type":"put","key":"4"}]}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + BatchPath)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"metaStore/pkg/reliability"
+)
+
+// DrainPath 滚动升级前排空本节点 gRPC 客户端的管理接口路径
+//
+//	GET  返回排空进度，ready_to_shutdown 为 true 后可以停止进程
+//	POST 在后台开始排空，?timeout= 指定等待客户端迁移的时间，默认 reliability.drain_timeout
+//
+// 排空后本节点不再接受 gRPC 连接，HTTP 和 MySQL 前端不受影响
+const DrainPath = "/admin/drain"
+
+// Drainer 排空 gRPC 客户端连接，由 etcd gRPC 服务器实现
+type Drainer interface {
+	StartDrain(timeout time.Duration) bool
+	DrainStatus() reliability.DrainStatus
+}
+
+// handleDrain 处理排空请求
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if s.drainer == nil {
+		http.Error(w, "the etcd gRPC server is not running on this node", http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var timeout time.Duration
+		if v := r.URL.Query().Get("timeout"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				http.Error(w, "invalid timeout: "+v, http.StatusBadRequest)
+				return
+			}
+			timeout = d
+		}
+		if !s.drainer.StartDrain(timeout) {
+			http.Error(w, "drain already started", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(s.drainer.DrainStatus())
+		return
+	default:
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.drainer.DrainStatus())
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"metaStore/pkg/encryption"
+)
+
+// EncryptionPath 静态加密的管理接口路径，只作用于收到请求的节点
+//
+//	GET  /admin/encryption        返回激活的 KEK 和最近一次重新加密的进度
+//	POST /admin/encryption/rotate 在后台用激活的 KEK 重新加密所有旧数据
+const EncryptionPath = "/admin/encryption"
+
+// KeyRotator 重新加密本节点存储的数据，由 RocksDB 存储实现
+type KeyRotator interface {
+	RotateKeys() error
+	RotationStatus() encryption.RotationStatus
+}
+
+// handleEncryption 处理静态加密管理请求
+func (s *Server) handleEncryption(w http.ResponseWriter, r *http.Request) {
+	if s.encryption == nil {
+		http.Error(w, "key rotation is not supported by this storage engine", http.StatusNotImplemented)
+		return
+	}
+
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, EncryptionPath), "/") {
+	case "":
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.encryption.RotationStatus())
+	case "rotate":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		switch err := s.encryption.RotateKeys(); {
+		case err == nil:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(s.encryption.RotationStatus())
+		case errors.Is(err, encryption.ErrRotationRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, encryption.ErrDisabled):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
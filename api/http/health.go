@@ -0,0 +1,52 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"metaStore/internal/kvstore"
+)
+
+// HealthPath 节点健康检查路径，返回 kvstore.NodeHealth
+//
+// mode 为 healthy-leader 或 healthy-follower 时返回 200，no-leader、lagging、
+// storage-degraded 时返回 503，负载均衡器可以据此把读请求从这些节点移走。
+// 带 ?leader 时只有 healthy-leader 返回 200，用于只把写请求路由到 leader
+const HealthPath = "/health"
+
+// handleHealth 处理健康检查请求
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodHead)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h := kvstore.CheckHealth(s.store, s.healthMaxApplyLag)
+	healthy := h.Healthy()
+	if r.URL.Query().Has("leader") {
+		healthy = h.Mode == kvstore.HealthLeader
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(h)
+}
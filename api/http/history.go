@@ -0,0 +1,134 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// HistoryPath 列出单个键历史版本的接口路径
+//
+//	GET /history?key=app/config           按 revision 从新到旧返回保留的全部版本
+//	GET /history?key=app/config&limit=10  最多返回 10 个版本
+//
+// 删除以 tombstone 为 true 的版本表示。compact_revision 是历史的边界，
+// 不大于它的修改可能已被压缩
+const HistoryPath = "/history"
+
+// KeyRevision 键的一个历史版本
+type KeyRevision struct {
+	Revision       int64  `json:"revision"`
+	Value          string `json:"value,omitempty"`
+	Tombstone      bool   `json:"tombstone,omitempty"` // 该 revision 删除了键
+	CreateRevision int64  `json:"create_revision,omitempty"`
+	Version        int64  `json:"version,omitempty"`
+	Lease          int64  `json:"lease,omitempty"`
+	HLC            uint64 `json:"hlc,omitempty"`
+}
+
+// KeyHistoryResponse 键历史查询结果
+type KeyHistoryResponse struct {
+	Key             string        `json:"key"`
+	CompactRevision int64         `json:"compact_revision"`
+	Revisions       []KeyRevision `json:"revisions"`
+}
+
+// handleHistory 处理键历史查询
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	historian, ok := kvstore.As[kvstore.KeyHistorian](s.store)
+	if !ok {
+		http.Error(w, "storage engine does not keep key history", http.StatusNotImplemented)
+		return
+	}
+
+	codec, ok := requestKeyCodec(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("key") == "" {
+		http.Error(w, "key is required", http.StatusBadRequest)
+		return
+	}
+	key, err := codec.Decode(q.Get("key"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var limit int64
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.ParseInt(v, 10, 64); err != nil || limit < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if !s.authorize(w, r, key, etcd.PermissionRead) {
+		return
+	}
+	if !waitMinIndex(w, r, s.store) {
+		return
+	}
+
+	ctx, cancel, err := requestContext(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	revs, compacted, err := historian.KeyHistory(ctx, key, limit)
+	switch {
+	case writeLeaderChanged(w, err), writeTimeout(w, err):
+		return
+	case err != nil:
+		log.Error("Failed to read key history", zap.String("key", key), zap.Error(err), zap.String("component", "http"))
+		http.Error(w, "Failed to read key history", http.StatusInternalServerError)
+		return
+	}
+
+	resp := KeyHistoryResponse{
+		Key:             codec.Encode([]byte(key)),
+		CompactRevision: compacted,
+		Revisions:       make([]KeyRevision, len(revs)),
+	}
+	for i, kv := range revs {
+		resp.Revisions[i] = KeyRevision{
+			Revision:       kv.ModRevision,
+			Value:          string(kv.Value),
+			Tombstone:      kv.Version == 0,
+			CreateRevision: kv.CreateRevision,
+			Version:        kv.Version,
+			Lease:          kv.Lease,
+			HLC:            kv.HLC,
+		}
+	}
+	setHLC(w, s.store)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
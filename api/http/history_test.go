@@ -0,0 +1,78 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx := t.Context()
+	first, _, err := store.PutWithLease(ctx, "app/config", "v1", 0)
+	require.NoError(t, err)
+	_, _, deleted, err := store.DeleteRange(ctx, "app/config", "")
+	require.NoError(t, err)
+	second, _, err := store.PutWithLease(ctx, "app/config", "v2", 0)
+	require.NoError(t, err)
+
+	get := func(query string) (*http.Response, KeyHistoryResponse) {
+		resp, err := http.Get(srv.URL + HistoryPath + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var out KeyHistoryResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		}
+		return resp, out
+	}
+
+	resp, out := get("?key=app/config")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "app/config", out.Key)
+	for i := range out.Revisions {
+		out.Revisions[i].HLC = 0
+	}
+	assert.Equal(t, []KeyRevision{
+		{Revision: second, Value: "v2", CreateRevision: second, Version: 1},
+		{Revision: deleted, Tombstone: true, CreateRevision: first},
+		{Revision: first, Value: "v1", CreateRevision: first, Version: 1},
+	}, out.Revisions)
+
+	resp, out = get("?key=app/config&limit=1")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, out.Revisions, 1)
+	assert.Equal(t, second, out.Revisions[0].Revision)
+
+	resp, out = get("?key=missing")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, out.Revisions)
+
+	resp, _ = get("")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = get("?key=app/config&limit=-1")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
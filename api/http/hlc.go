@@ -0,0 +1,65 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/hlc"
+)
+
+// HLCHeader KV 请求的响应中返回本节点最后应用的操作的 HLC；
+// 请求带上它（例如从另一个集群读到的 HLC）时先合并进本节点时钟，之后提交的写入排在它后面
+const HLCHeader = "X-MetaStore-HLC"
+
+// observeHLC 合并请求带的 HLC，失败时写出错误并返回 false
+// 超前本地时钟超过最大偏差时返回 412
+func observeHLC(w http.ResponseWriter, r *http.Request, store kvstore.Store) bool {
+	v := r.Header.Get(HLCHeader)
+	if v == "" {
+		return true
+	}
+	clock, ok := kvstore.As[kvstore.HybridClock](store)
+	if !ok {
+		return true
+	}
+	ts, err := hlc.Parse(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid %s %q", HLCHeader, v), http.StatusBadRequest)
+		return false
+	}
+	if err := clock.ObserveHLC(uint64(ts)); err != nil {
+		if errors.Is(err, hlc.ErrClockOffset) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return false
+	}
+	return true
+}
+
+// setHLC 在响应中返回本节点最后应用的操作的 HLC，必须在写出状态码之前调用
+func setHLC(w http.ResponseWriter, store kvstore.Store) {
+	if clock, ok := kvstore.As[kvstore.HybridClock](store); ok {
+		if ts := clock.CurrentHLC(); ts != 0 {
+			w.Header().Set(HLCHeader, strconv.FormatUint(ts, 10))
+		}
+	}
+}
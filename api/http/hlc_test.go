@@ -0,0 +1,79 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/hlc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHLCHeader(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{Store: memory.NewMemoryEtcd()}).httpServer.Handler)
+	defer srv.Close()
+
+	do := func(method, key string, ts hlc.Timestamp) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+"/"+key, strings.NewReader("v"))
+		require.NoError(t, err)
+		if ts != 0 {
+			req.Header.Set(HLCHeader, ts.String())
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	header := func(resp *http.Response) uint64 {
+		ts, err := strconv.ParseUint(resp.Header.Get(HLCHeader), 10, 64)
+		require.NoError(t, err)
+		return ts
+	}
+
+	resp := do(http.MethodPut, "k", 0)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	first := header(resp)
+	assert.NotZero(t, first)
+
+	// 另一个集群的 HLC 略微超前本地时钟，之后的写入排在它后面
+	remote := hlc.New(time.Now().Add(100*time.Millisecond), 3)
+	resp = do(http.MethodPut, "k", remote)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Greater(t, header(resp), uint64(remote))
+
+	resp = do(http.MethodGet, "k", 0)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Greater(t, header(resp), uint64(remote))
+
+	// 超过最大偏差的 HLC 被拒绝
+	resp = do(http.MethodPut, "k", hlc.New(time.Now().Add(time.Hour), 0))
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/k", nil)
+	require.NoError(t, err)
+	req.Header.Set(HLCHeader, "soon")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
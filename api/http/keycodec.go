@@ -0,0 +1,43 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"metaStore/pkg/keycodec"
+)
+
+// key 编码：请求在 KeyEncodingHeader 头或 keyEncoding 查询参数（浏览器 EventSource 无法设置请求头）
+// 中指定 raw、base64 或 hex 后，路径、watch 参数和批量写入中的 key 按该编码解码，watch 事件中的
+// key 按该编码返回。raw 模式下路径中的 key 按 URL 百分号转义解码，以 "/" 开头的 key 写作 %2F...
+const (
+	KeyEncodingHeader = "X-MetaStore-Key-Encoding"
+	KeyEncodingParam  = "keyEncoding"
+)
+
+// requestKeyCodec 返回请求指定的 key 编码，无效时写出 400 并返回 false
+func requestKeyCodec(w http.ResponseWriter, r *http.Request) (keycodec.Codec, bool) {
+	name := r.Header.Get(KeyEncodingHeader)
+	if name == "" {
+		name = r.URL.Query().Get(KeyEncodingParam)
+	}
+	codec, err := keycodec.Parse(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return keycodec.Raw, false
+	}
+	return codec, true
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 通过 etcd 写入的任意字节串 key 在 HTTP API 中可以按编码访问
+func TestKeyEncoding(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	do := func(method, path, encoding, body string) (int, string) {
+		req, err := http.NewRequestWithContext(ctx, method, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if encoding != "" {
+			req.Header.Set(KeyEncodingHeader, encoding)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	binary := "/bin\x00\xff/k?"
+	_, _, err := store.PutWithLease(ctx, binary, "v1", 0)
+	require.NoError(t, err)
+
+	code, body := do(http.MethodGet, "/"+hex.EncodeToString([]byte(binary)), "hex", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "v1", body)
+	code, body = do(http.MethodGet, "/"+base64.RawURLEncoding.EncodeToString([]byte(binary)), "base64", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "v1", body)
+
+	// raw 模式按百分号转义解码，查询参数不属于 key
+	code, _ = do(http.MethodPut, "/%2Fapp%2Fa%20b?timeout=5s", "", "v2")
+	require.Equal(t, http.StatusNoContent, code)
+	resp, err := store.Range(ctx, "/app/a b", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v2", string(resp.Kvs[0].Value))
+
+	// 指定编码时数字 key 不是集群操作
+	code, _ = do(http.MethodDelete, "/"+hex.EncodeToString([]byte("/app/a b")), "hex", "")
+	assert.Equal(t, http.StatusNoContent, code)
+	resp, err = store.Range(ctx, "/app/a b", "", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+
+	code, _ = do(http.MethodPost, BatchPath, "hex", `{"ops":[{"type":"put","key":"2f62","value":"v3"}]}`)
+	assert.Equal(t, http.StatusOK, code)
+	resp, err = store.Range(ctx, "/b", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+
+	code, _ = do(http.MethodGet, "/k", "base32", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodGet, "/zz", "hex", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPost, BatchPath, "hex", `{"ops":[{"type":"put","key":"/b","value":"v3"}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestWatchKeyEncoding(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	prefix := base64.StdEncoding.EncodeToString([]byte("\x00bin/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+WatchPath+"?keyEncoding=base64&prefix="+prefix, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, _, err = store.PutWithLease(ctx, "\x00bin/\xff", "1", 0)
+	require.NoError(t, err)
+
+	events := readEvents(t, bufio.NewReader(resp.Body), 1)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("\x00bin/\xff")), events[0].data.Kv.Key)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// MemberReplacePath 故障成员替换的管理接口路径
+//
+//	POST 启动替换，请求体为 kvstore.MemberReplaceRequest，
+//	     响应以 NDJSON 流式返回每次进度变化，最后一行的 phase 为 done 或 failed
+//	GET  返回最近一次替换的进度
+//
+// 客户端断开连接会中止替换；使用相同参数重新请求即可从中断处继续
+const MemberReplacePath = "/admin/members/replace"
+
+// memberReplacer 由支持成员替换的 store 实现
+type memberReplacer interface {
+	ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error
+}
+
+// replaceStatus 保存最近一次替换的进度，供 GET 查询
+type replaceStatus struct {
+	mu   sync.RWMutex
+	last *kvstore.MemberReplaceProgress
+}
+
+func (rs *replaceStatus) set(p kvstore.MemberReplaceProgress) {
+	rs.mu.Lock()
+	rs.last = &p
+	rs.mu.Unlock()
+}
+
+func (rs *replaceStatus) get() *kvstore.MemberReplaceProgress {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.last
+}
+
+// handleMemberReplace 处理故障成员替换请求
+func (s *Server) handleMemberReplace(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		last := s.replaceStatus.get()
+		if last == nil {
+			http.Error(w, "no member replacement has been run", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(last)
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mr, ok := kvstore.As[memberReplacer](s.store)
+	if !ok {
+		http.Error(w, "member replacement is not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	var req kvstore.MemberReplaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid member replace request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Info("Member replacement requested",
+		zap.Uint64("dead_id", req.DeadID),
+		zap.Uint64("new_id", req.NewID),
+		zap.String("peer_url", req.PeerURL),
+		zap.Bool("force", req.Force),
+		zap.String("component", "http"))
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	err := mr.ReplaceMember(r.Context(), req, func(p kvstore.MemberReplaceProgress) {
+		s.replaceStatus.set(p)
+		enc.Encode(p)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if err != nil {
+		log.Warn("Member replacement failed",
+			zap.Uint64("dead_id", req.DeadID),
+			zap.Uint64("new_id", req.NewID),
+			zap.Error(err),
+			zap.String("component", "http"))
+		return
+	}
+	log.Info("Member replacement completed",
+		zap.Uint64("dead_id", req.DeadID),
+		zap.Uint64("new_id", req.NewID),
+		zap.String("component", "http"))
+}
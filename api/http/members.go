@@ -0,0 +1,48 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"metaStore/internal/kvstore"
+)
+
+// MembersPath 列出集群成员的管理接口路径
+//
+//	GET 返回 []kvstore.MemberStatus：复制进度（仅 leader）、本节点与各成员的
+//	    最近联系时间、心跳 RTT、发送失败次数和失联状态（仅 leader 判断）
+const MembersPath = "/admin/members"
+
+// handleMembers 处理成员列表请求
+func (s *Server) handleMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ml, ok := kvstore.As[memberLister](s.store)
+	if !ok {
+		http.Error(w, "membership is not available for this storage engine", http.StatusNotImplemented)
+		return
+	}
+	members := ml.Members()
+	if members == nil {
+		members = []kvstore.MemberStatus{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
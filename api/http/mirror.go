@@ -0,0 +1,99 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"metaStore/pkg/log"
+	"metaStore/pkg/mirror"
+
+	"go.uber.org/zap"
+)
+
+// MirrorsPath 跨数据中心复制的管理接口路径
+//
+//	GET  /admin/mirrors              返回所有 mirror 的状态
+//	POST /admin/mirrors/{name}/start 启动 mirror
+//	POST /admin/mirrors/{name}/stop  停止 mirror，停止前保存 checkpoint
+const MirrorsPath = "/admin/mirrors"
+
+// MirrorController 管理 mirror 的启停，由 mirror.Manager 实现
+type MirrorController interface {
+	List() []mirror.Status
+	Start(name string) error
+	Stop(name string) error
+}
+
+// handleMirrors 处理 mirror 管理请求
+func (s *Server) handleMirrors(w http.ResponseWriter, r *http.Request) {
+	if s.mirrors == nil {
+		http.Error(w, "mirroring is not enabled on this server", http.StatusNotImplemented)
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, MirrorsPath), "/")
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.mirrors.List())
+		return
+	}
+
+	name, action, ok := strings.Cut(rest, "/")
+	if !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	switch action {
+	case "start":
+		err = s.mirrors.Start(name)
+	case "stop":
+		err = s.mirrors.Stop(name)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, mirror.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, mirror.ErrAlreadyRunning), errors.Is(err, mirror.ErrNotRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Error("Mirror admin request failed",
+			zap.String("mirror", name),
+			zap.String("action", action),
+			zap.Error(err),
+			zap.String("component", "http"))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// OpenAPIPath 返回描述 HTTP API 的 OpenAPI 3 文档，可以用 Swagger UI 浏览或生成其他语言的客户端。
+// 与健康检查相同，不需要认证
+//
+// 文档维护在 openapi.json 中，修改后运行 go generate ./api/http 重新生成 pkg/httpclient/api.gen.go
+// 和 clients/typescript/src/api.gen.ts，新增的接口还需要在两个客户端中添加导出的方法
+const OpenAPIPath = "/openapi.json"
+
+//go:generate go run metaStore/internal/openapigen/cmd/openapigen -spec openapi.json -go ../../pkg/httpclient/api.gen.go -ts ../../clients/typescript/src/api.gen.ts
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// handleOpenAPI 返回 OpenAPI 文档
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodHead)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
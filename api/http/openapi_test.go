@@ -0,0 +1,273 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/memory"
+	"metaStore/internal/openapigen"
+	"metaStore/pkg/httpclient"
+	"metaStore/pkg/keycodec"
+	"metaStore/pkg/version"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPIDoc openapi.json 中测试用到的部分
+type openAPIDoc struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// operationIDs 返回文档中所有操作的 operationId
+func (d *openAPIDoc) operationIDs(t *testing.T) []string {
+	var ids []string
+	for path, item := range d.Paths {
+		for method, raw := range item {
+			if method == "parameters" {
+				continue
+			}
+			var op struct {
+				OperationID string `json:"operationId"`
+			}
+			require.NoError(t, json.Unmarshal(raw, &op))
+			require.NotEmpty(t, op.OperationID, "%s %s", method, path)
+			ids = append(ids, op.OperationID)
+		}
+	}
+	return ids
+}
+
+func loadOpenAPI(t *testing.T) *openAPIDoc {
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	return &doc
+}
+
+// TestOpenAPISpec 检查文档覆盖所有注册的路径，并且 $ref 都能解析
+func TestOpenAPISpec(t *testing.T) {
+	doc := loadOpenAPI(t)
+	assert.Equal(t, version.Version, doc.Info.Version)
+
+	for _, path := range []string{
+		HealthPath, ReadyzPath, LivezPath, ReadyzPath + "/{check}", LivezPath + "/{check}",
+		AuthLoginPath, OpenAPIPath, MembersPath, RaftLogPath, MemberReplacePath, PlacementPath,
+		MirrorsPath, MirrorsPath + "/{name}/start", MirrorsPath + "/{name}/stop",
+		EncryptionPath, EncryptionPath + "/rotate", DrainPath,
+		SchemasPath, SchemasPath + "/{prefix}", SettingsPath, SettingsPath + "/{name}",
+		TrashPath, TrashPath + "/restore", UsagePath, UsagePath + "/scan",
+		WatchPath, BatchPath, HistoryPath, "/{key}",
+	} {
+		assert.Contains(t, doc.Paths, path)
+	}
+	assert.Len(t, doc.Paths, 29, "a path without a constant above was added to openapi.json")
+
+	var all interface{}
+	require.NoError(t, json.Unmarshal(openAPISpec, &all))
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				var target interface{} = all
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]interface{})
+					target = m[part]
+				}
+				assert.NotNil(t, target, "unresolved %s", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(all)
+}
+
+// TestOpenAPIClients 检查每个 operationId 在 Go 和 TypeScript 客户端中都有同名方法
+func TestOpenAPIClients(t *testing.T) {
+	ts, err := os.ReadFile("../../clients/typescript/src/index.ts")
+	require.NoError(t, err)
+
+	client := reflect.TypeOf(&httpclient.Client{})
+	for _, id := range loadOpenAPI(t).operationIDs(t) {
+		method := string(unicode.ToUpper(rune(id[0]))) + id[1:]
+		_, ok := client.MethodByName(method)
+		assert.True(t, ok, "httpclient.Client has no method %s for %s", method, id)
+		assert.Contains(t, string(ts), "  async "+id+"(", "the TypeScript client has no method %s", id)
+	}
+}
+
+// TestOpenAPIGenerated 检查客户端中生成的代码与 openapi.json 一致
+func TestOpenAPIGenerated(t *testing.T) {
+	doc, err := openapigen.Parse(openAPISpec)
+	require.NoError(t, err)
+	goSrc, err := doc.Go("httpclient")
+	require.NoError(t, err)
+	tsSrc, err := doc.TypeScript()
+	require.NoError(t, err)
+
+	for file, want := range map[string][]byte{
+		"../../pkg/httpclient/api.gen.go":         goSrc,
+		"../../clients/typescript/src/api.gen.ts": tsSrc,
+	} {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.True(t, string(want) == string(got), "%s is out of date, run go generate ./api/http", file)
+	}
+}
+
+// TestOpenAPIServe 文档与健康检查一样不需要认证
+func TestOpenAPIServe(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	users := etcd.NewAuthManager(store)
+	require.NoError(t, users.AddUser("root", "rootpw"))
+	require.NoError(t, users.Enable())
+	srv := httptest.NewServer(NewServer(Config{Store: store, Users: users}).httpServer.Handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + OpenAPIPath)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, openAPISpec, body)
+
+	resp, err = http.Post(srv.URL+OpenAPIPath, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+// TestOpenAPIGoClient 用 Go 客户端访问服务端，检查文档描述的请求和响应格式
+func TestOpenAPIGoClient(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+	ctx := t.Context()
+	c := httpclient.New(httpclient.Config{Endpoint: srv.URL, Timeout: 5 * time.Second})
+
+	// KV：以 "/" 开头的 key 和纯数字的 key 都按 key 处理
+	for _, key := range []string{"app/a b", "/abs", "42"} {
+		res, err := c.PutKey(ctx, key, []byte("v-"+key))
+		require.NoError(t, err, key)
+		assert.NotEmpty(t, res.HLC, key)
+		v, err := c.GetKey(ctx, key, 0)
+		require.NoError(t, err, key)
+		assert.Equal(t, "v-"+key, string(v))
+		_, err = c.DeleteKey(ctx, key)
+		require.NoError(t, err, key)
+		_, err = c.GetKey(ctx, key, 0)
+		assert.True(t, httpclient.IsNotFound(err), "%s: %v", key, err)
+	}
+
+	w, err := c.Watch(ctx, httpclient.WatchRequest{Prefix: "jobs/", FromRev: store.CurrentRevision() + 1, PrevKV: true})
+	require.NoError(t, err)
+	defer w.Close()
+
+	batch, err := c.Batch(ctx, []httpclient.BatchOp{
+		{Type: "put", Key: "jobs/1", Value: "queued"},
+		{Type: "put", Key: "jobs/2", Value: "queued"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, batch.Ops)
+	_, err = c.PutKey(ctx, "jobs/1", []byte("done"))
+	require.NoError(t, err)
+
+	var ids []string
+	for _, want := range []string{"jobs/1=queued", "jobs/2=queued", "jobs/1=done"} {
+		ev, err := w.Next()
+		require.NoError(t, err)
+		assert.Equal(t, want, ev.Kv.Key+"="+ev.Kv.Value)
+		ids = append(ids, ev.ID)
+	}
+	assert.Equal(t, ids[2], w.LastEventID())
+
+	// 从第一个事件之后继续，同一个 revision 中的第二个事件不会丢失
+	resumed, err := c.Watch(ctx, httpclient.WatchRequest{Prefix: "jobs/", LastEventID: ids[0]})
+	require.NoError(t, err)
+	defer resumed.Close()
+	ev, err := resumed.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "jobs/2", ev.Kv.Key)
+	assert.Equal(t, ids[1], ev.ID)
+
+	hist, err := c.KeyHistory(ctx, "jobs/1", 0)
+	require.NoError(t, err)
+	require.Len(t, hist.Revisions, 2)
+	assert.Equal(t, "done", hist.Revisions[0].Value)
+
+	// 二进制 key 通过 base64 编码传输
+	bin := httpclient.New(httpclient.Config{Endpoint: srv.URL, KeyEncoding: keycodec.Base64})
+	_, err = bin.PutKey(ctx, "bin/\x00\xff", []byte("x"))
+	require.NoError(t, err)
+	_, ok := store.Lookup("bin/\x00\xff")
+	assert.True(t, ok)
+	hist, err = bin.KeyHistory(ctx, "bin/\x00\xff", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "bin/\x00\xff", hist.Key)
+
+	// 管理接口
+	h, err := c.Health(ctx, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, h.Mode)
+
+	list, err := c.ListSettings(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, list)
+	setting, err := c.SetSetting(ctx, "compaction.retention", "100", 0)
+	require.NoError(t, err)
+	_, err = c.SetSetting(ctx, "compaction.retention", "200", 0)
+	assert.True(t, httpclient.IsConflict(err), "%v", err)
+	got, err := c.GetSetting(ctx, "compaction.retention")
+	require.NoError(t, err)
+	assert.Equal(t, *setting, *got)
+	require.NoError(t, c.ResetSetting(ctx, "compaction.retention", -1))
+
+	require.NoError(t, c.PutSchema(ctx, "cfg/", []byte(`{"type":"object"}`)))
+	doc, err := c.GetSchema(ctx, "cfg/")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"object"}`, string(doc))
+	schemas, err := c.ListSchemas(ctx)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.Equal(t, "cfg/", schemas[0].Prefix)
+	require.NoError(t, c.DeleteSchema(ctx, "cfg/"))
+
+	_, err = c.ListMirrors(ctx)
+	var apiErr *httpclient.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotImplemented, apiErr.StatusCode)
+}
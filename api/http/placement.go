@@ -0,0 +1,121 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+	"metaStore/pkg/placement"
+
+	"go.uber.org/zap"
+)
+
+// PlacementPath leader 放置策略的管理接口路径
+//
+//	GET    返回集群中设置的策略，没有设置时返回 404（各节点使用配置文件中的值）
+//	PUT    设置策略，请求体为 placement.Policy，例如
+//	       {"primary_zone": "az1", "preferred_leaders": [1, 2]}
+//	DELETE 删除策略，各节点恢复使用配置文件中的值
+//
+// leader 在下一次检查时按新策略转移
+const PlacementPath = "/admin/placement"
+
+// memberLister 由暴露 raft 成员关系的存储实现
+type memberLister interface {
+	Members() []kvstore.MemberStatus
+}
+
+// handlePlacement 处理 leader 放置策略管理请求
+func (s *Server) handlePlacement(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := placement.Load(r.Context(), s.store)
+		if errors.Is(err, placement.ErrNotSet) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			s.placementError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(policy)
+	case http.MethodPut:
+		var policy placement.Policy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			http.Error(w, "invalid placement policy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.validatePlacement(policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := placement.Save(r.Context(), s.store, policy); err != nil {
+			s.placementError(w, err)
+			return
+		}
+		log.Info("Leader placement policy updated",
+			zap.String("primary_zone", policy.PrimaryZone),
+			zap.Uint64s("preferred_leaders", policy.PreferredLeaders),
+			zap.String("component", "http"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(policy)
+	case http.MethodDelete:
+		if err := placement.Reset(r.Context(), s.store); err != nil {
+			s.placementError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodPut)
+		w.Header().Add("Allow", http.MethodDelete)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validatePlacement 首选 leader 必须是当前的投票成员
+func (s *Server) validatePlacement(policy placement.Policy) error {
+	ml, ok := kvstore.As[memberLister](s.store)
+	if !ok {
+		return nil
+	}
+	voters := make(map[uint64]bool)
+	for _, m := range ml.Members() {
+		voters[m.ID] = !m.IsLearner
+	}
+	for _, id := range policy.PreferredLeaders {
+		voter, ok := voters[id]
+		switch {
+		case !ok:
+			return fmt.Errorf("preferred leader %d is not a cluster member", id)
+		case !voter:
+			return fmt.Errorf("preferred leader %d is a learner", id)
+		}
+	}
+	return nil
+}
+
+func (s *Server) placementError(w http.ResponseWriter, err error) {
+	log.Error("Placement admin request failed",
+		zap.Error(err),
+		zap.String("component", "http"))
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/reliability"
+)
+
+// ReadyzPath 和 LivezPath Kubernetes 就绪和存活探针的路径，输出与 kube-apiserver 相同
+//
+// 所有检查通过时返回 200 和 ok，任一检查失败时返回 503 并逐项列出检查结果：
+//
+//	[+]ping ok
+//	[-]raft-leader failed: no leader elected
+//	readyz check failed
+//
+// 带 ?verbose 时通过也逐项列出；?exclude=<检查名> 跳过指定的检查，可以重复；
+// /readyz/<检查名> 只执行一项检查。与 /health 相同，探针不需要认证
+const (
+	ReadyzPath = "/readyz"
+	LivezPath  = "/livez"
+)
+
+// livezRaftTimeout raft 节点在该时间内没有返回状态时存活检查失败
+const livezRaftTimeout = 5 * time.Second
+
+// probeCheck 探针中的一项检查，返回 nil 表示通过
+type probeCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// readyzChecks 就绪检查：节点可以处理读写请求。失败的节点被移出 Service 的 endpoint，
+// 但不会被重启
+func (s *Server) readyzChecks() []probeCheck {
+	return []probeCheck{
+		{"ping", pingCheck},
+		{"storage", s.checkStorage},
+		{"raft-leader", s.checkRaftLeader},
+		{"raft-applied", s.checkRaftApplied},
+		{"drain", s.checkDrain},
+	}
+}
+
+// livezChecks 存活检查：进程需要重启才能恢复。没有 leader 或落后不在其中，
+// 重启不能解决这些问题，反而会让集群失去一个成员
+func (s *Server) livezChecks() []probeCheck {
+	return []probeCheck{
+		{"ping", pingCheck},
+		{"raft", s.checkRaftResponsive},
+	}
+}
+
+func pingCheck(context.Context) error { return nil }
+
+// checkStorage 存储引擎没有停止写入
+func (s *Server) checkStorage(context.Context) error {
+	if ss, ok := kvstore.As[kvstore.StorageStaller](s.store); ok {
+		if stalled, reason := ss.StorageStall(); stalled {
+			return fmt.Errorf("storage degraded: %s", reason)
+		}
+	}
+	return nil
+}
+
+// checkRaftLeader 集群有 leader，判断方式与 kvstore.CheckHealth 相同
+func (s *Server) checkRaftLeader(context.Context) error {
+	status := s.store.GetRaftStatus()
+	if status.State == "standalone" {
+		return nil
+	}
+	if status.LeaderID == 0 || status.State == "StateCandidate" || status.State == "StatePreCandidate" {
+		return fmt.Errorf("no leader elected")
+	}
+	return nil
+}
+
+// checkRaftApplied applied index 落后 commit index 不超过 reliability.health_max_apply_lag
+func (s *Server) checkRaftApplied(context.Context) error {
+	status := s.store.GetRaftStatus()
+	if s.healthMaxApplyLag == 0 || status.Commit <= status.Applied {
+		return nil
+	}
+	if lag := status.Commit - status.Applied; lag > s.healthMaxApplyLag {
+		return fmt.Errorf("applied index %d is %d entries behind commit index %d", status.Applied, lag, status.Commit)
+	}
+	return nil
+}
+
+// checkDrain 节点没有在排空，排空开始后就绪检查失败，负载均衡器不再转发新请求
+func (s *Server) checkDrain(context.Context) error {
+	if s.drainer != nil && s.drainer.DrainStatus().State != reliability.DrainServing {
+		return fmt.Errorf("member is draining")
+	}
+	return nil
+}
+
+// checkRaftResponsive raft 节点在 livezRaftTimeout 内返回状态。节点的事件循环卡住时
+// 读取状态会一直阻塞，此时检查失败，阻塞的 goroutine 随进程重启退出
+func (s *Server) checkRaftResponsive(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.store.GetRaftStatus()
+		close(done)
+	}()
+	timer := time.NewTimer(livezRaftTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("raft node did not report its status within %v", livezRaftTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isProbePath 路径是否是健康检查或探针，这些请求不需要认证
+func isProbePath(path string) bool {
+	if path == HealthPath || path == ReadyzPath || path == LivezPath {
+		return true
+	}
+	return strings.HasPrefix(path, ReadyzPath+"/") || strings.HasPrefix(path, LivezPath+"/")
+}
+
+// handleProbe 返回执行 checks 的探针 handler，name 为 readyz 或 livez
+func (s *Server) handleProbe(name string, checks func() []probeCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", http.MethodGet)
+			w.Header().Add("Allow", http.MethodHead)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		list := checks()
+		if only, ok := strings.CutPrefix(r.URL.Path, "/"+name+"/"); ok {
+			i := slices.IndexFunc(list, func(c probeCheck) bool { return c.name == only })
+			if i < 0 {
+				http.Error(w, fmt.Sprintf("%s check %q not found", name, only), http.StatusNotFound)
+				return
+			}
+			list = list[i : i+1]
+		}
+
+		excluded := r.URL.Query()["exclude"]
+		var out strings.Builder
+		failed := false
+		for _, c := range list {
+			if slices.Contains(excluded, c.name) {
+				fmt.Fprintf(&out, "[+]%s excluded: ok\n", c.name)
+				continue
+			}
+			if err := c.check(r.Context()); err != nil {
+				fmt.Fprintf(&out, "[-]%s failed: %v\n", c.name, err)
+				failed = true
+				continue
+			}
+			fmt.Fprintf(&out, "[+]%s ok\n", c.name)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-store")
+		switch {
+		case failed:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "%s%s check failed\n", out.String(), name)
+		case r.URL.Query().Has("verbose"):
+			fmt.Fprintf(w, "%s%s check passed\n", out.String(), name)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/pkg/reliability"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeStore 返回指定的 raft 状态
+type probeStore struct {
+	*memory.MemoryEtcd
+	status kvstore.RaftStatus
+}
+
+func (s *probeStore) GetRaftStatus() kvstore.RaftStatus { return s.status }
+
+// fakeDrainer 返回指定的排空状态
+type fakeDrainer struct{ state string }
+
+func (d *fakeDrainer) StartDrain(time.Duration) bool { return false }
+
+func (d *fakeDrainer) DrainStatus() reliability.DrainStatus {
+	return reliability.DrainStatus{State: d.state}
+}
+
+func TestReadyz(t *testing.T) {
+	store := &probeStore{
+		MemoryEtcd: memory.NewMemoryEtcd(),
+		status:     kvstore.RaftStatus{NodeID: 2, LeaderID: 1, State: "StateFollower", Applied: 10, Commit: 10},
+	}
+	drainer := &fakeDrainer{state: reliability.DrainServing}
+	srv := httptest.NewServer(NewServer(Config{Store: store, Drainer: drainer, HealthMaxApplyLag: 100}).httpServer.Handler)
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get(ReadyzPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body)
+
+	status, body = get(ReadyzPath + "?verbose")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[+]ping ok\n[+]storage ok\n[+]raft-leader ok\n[+]raft-applied ok\n[+]drain ok\nreadyz check passed\n", body)
+
+	// 落后超过阈值时未就绪，失败时总是逐项列出
+	store.status.Commit = 200
+	status, body = get(ReadyzPath)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, body, "[-]raft-applied failed: applied index 10 is 190 entries behind commit index 200\n")
+	assert.Contains(t, body, "[+]raft-leader ok\n")
+	assert.Contains(t, body, "readyz check failed\n")
+
+	status, _ = get(ReadyzPath + "?exclude=raft-applied")
+	assert.Equal(t, http.StatusOK, status)
+
+	// 单项检查
+	status, _ = get(ReadyzPath + "/raft-leader")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get(ReadyzPath + "/raft-applied")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	status, _ = get(ReadyzPath + "/bogus")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	store.status = kvstore.RaftStatus{NodeID: 2, State: "StatePreCandidate"}
+	status, body = get(ReadyzPath)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, body, "[-]raft-leader failed: no leader elected\n")
+
+	// 排空开始后未就绪
+	store.status = kvstore.RaftStatus{NodeID: 1, LeaderID: 1, State: "StateLeader"}
+	drainer.state = reliability.DrainDraining
+	status, body = get(ReadyzPath)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, body, "[-]drain failed: member is draining\n")
+
+	// 排空不影响存活检查
+	status, body = get(LivezPath + "?verbose")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[+]ping ok\n[+]raft ok\nlivez check passed\n", body)
+
+	resp, err := http.Post(srv.URL+ReadyzPath, "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestIsProbePath(t *testing.T) {
+	for _, path := range []string{HealthPath, ReadyzPath, ReadyzPath + "/ping", LivezPath, LivezPath + "/raft"} {
+		assert.True(t, isProbePath(path), path)
+	}
+	for _, path := range []string{HealthPath + "/x", "/readyzz", "/app/readyz"} {
+		assert.False(t, isProbePath(path), path)
+	}
+}
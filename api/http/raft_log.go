@@ -0,0 +1,95 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+)
+
+// RaftLogPath 解码本节点 raft 日志的管理接口路径，用于在线排查卡住的 apply
+//
+//	GET 返回 kvstore.RaftLog，?from= 指定起始索引，默认返回最后的条目；
+//	    ?limit= 指定条目数，默认 100，最多 1000；?redact=true 隐藏 value
+//
+// 只有 rocksdb 引擎支持。日志包含所有 key，启用认证后需要空 key 上的写权限
+const RaftLogPath = "/admin/raft/log"
+
+// defaultRaftLogLimit 未指定 limit 时返回的条目数
+const defaultRaftLogLimit = 100
+
+// raftLogReader 由能解码 raft 日志的存储实现
+type raftLogReader interface {
+	RaftLog(from uint64, limit int, redact bool) (*kvstore.RaftLog, error)
+}
+
+// handleRaftLog 处理 raft 日志查询请求
+func (s *Server) handleRaftLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	reader, ok := kvstore.As[raftLogReader](s.store)
+	if !ok {
+		http.Error(w, "the raft log is only available for the rocksdb storage engine", http.StatusNotImplemented)
+		return
+	}
+	if !s.authorize(w, r, "", etcd.PermissionWrite) {
+		return
+	}
+
+	q := r.URL.Query()
+	var from uint64
+	if v := q.Get("from"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from %q", v), http.StatusBadRequest)
+			return
+		}
+		from = n
+	}
+	limit := defaultRaftLogLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	redact := false
+	if v := q.Get("redact"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid redact %q", v), http.StatusBadRequest)
+			return
+		}
+		redact = b
+	}
+
+	l, err := reader.RaftLog(from, limit, redact)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l)
+}
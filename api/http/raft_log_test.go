@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// raftLogStore 记录 RaftLog 的参数
+type raftLogStore struct {
+	*memory.MemoryEtcd
+	from   uint64
+	limit  int
+	redact bool
+}
+
+func (s *raftLogStore) RaftLog(from uint64, limit int, redact bool) (*kvstore.RaftLog, error) {
+	s.from, s.limit, s.redact = from, limit, redact
+	return &kvstore.RaftLog{LastIndex: 7, Entries: []kvstore.RaftLogEntry{{Index: 7, Term: 2, Type: "EntryNormal"}}}, nil
+}
+
+func TestRaftLog(t *testing.T) {
+	store := &raftLogStore{MemoryEtcd: memory.NewMemoryEtcd()}
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + RaftLogPath)
+	require.NoError(t, err)
+	var l kvstore.RaftLog
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&l))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, uint64(7), l.LastIndex)
+	require.Len(t, l.Entries, 1)
+	assert.Equal(t, uint64(0), store.from)
+	assert.Equal(t, defaultRaftLogLimit, store.limit)
+	assert.False(t, store.redact)
+
+	resp, err = http.Get(srv.URL + RaftLogPath + "?from=5&limit=2&redact=true")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, uint64(5), store.from)
+	assert.Equal(t, 2, store.limit)
+	assert.True(t, store.redact)
+
+	resp, err = http.Get(srv.URL + RaftLogPath + "?limit=0")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// 不能解码日志的存储
+	plain := httptest.NewServer(NewServer(Config{Store: memory.NewMemoryEtcd()}).httpServer.Handler)
+	defer plain.Close()
+	resp, err = http.Get(plain.URL + RaftLogPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
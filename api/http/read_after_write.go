@@ -0,0 +1,63 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metaStore/internal/kvstore"
+)
+
+// read-after-write token：PUT/DELETE 成功后在 CommitIndexHeader 中返回，
+// GET 在 MinIndexHeader 中带上后，本节点先应用到该 raft index 再读取
+const (
+	CommitIndexHeader = "X-MetaStore-Commit-Index"
+	MinIndexHeader    = "X-MetaStore-Min-Index"
+)
+
+// setCommitIndex 在写请求的响应中返回 token，必须在写出状态码之前调用
+func setCommitIndex(w http.ResponseWriter, store kvstore.Store) {
+	if raw, ok := kvstore.As[kvstore.ReadAfterWriter](store); ok {
+		w.Header().Set(CommitIndexHeader, strconv.FormatUint(raw.WriteIndex(), 10))
+	}
+}
+
+// waitMinIndex 等待本节点应用到请求中的 token，失败时写出错误并返回 false
+func waitMinIndex(w http.ResponseWriter, r *http.Request, store kvstore.Store) bool {
+	v := r.Header.Get(MinIndexHeader)
+	if v == "" {
+		return true
+	}
+	token, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid %s %q", MinIndexHeader, v), http.StatusBadRequest)
+		return false
+	}
+
+	raw, ok := kvstore.As[kvstore.ReadAfterWriter](store)
+	if !ok {
+		http.Error(w, kvstore.ErrReadAfterWriteUnsupported.Error(), http.StatusNotImplemented)
+		return false
+	}
+	if err := raw.WaitApplied(r.Context(), token); err != nil {
+		if !writeTimeout(w, err) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 存储不支持 read-after-write token 时，带 token 的读取不能当作已满足
+func TestMinIndexUnsupported(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{Store: memory.NewMemoryEtcd()}).httpServer.Handler)
+	defer srv.Close()
+
+	get := func(token string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/k", nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set(MinIndexHeader, token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("").StatusCode)
+	assert.Equal(t, http.StatusNotImplemented, get("7").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, get("seven").StatusCode)
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+
+	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
+)
+
+// withRecovery 把 handler 的 panic 转为 500，并记录请求的方法、路径、客户端和堆栈。
+// http.ErrAbortHandler 是 handler 主动中断响应，继续交给 net/http 处理
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			reliability.ReportPanic(reliability.PanicSourceHTTP, r.Method+" "+r.URL.Path, rec,
+				log.String("method", r.Method),
+				log.String("path", r.URL.Path),
+				log.String("client", r.RemoteAddr))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
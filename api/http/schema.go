@@ -0,0 +1,105 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"metaStore/pkg/log"
+	"metaStore/pkg/schema"
+
+	"go.uber.org/zap"
+)
+
+// SchemasPath JSON Schema 注册表的管理接口路径，{prefix} 为被管辖的 key 前缀，可以包含 "/"
+//
+//	GET    /admin/schemas          返回所有注册的 schema
+//	GET    /admin/schemas/{prefix} 返回前缀上的 schema 文档
+//	PUT    /admin/schemas/{prefix} 注册或替换 schema，请求体为 schema 文档
+//	DELETE /admin/schemas/{prefix} 删除 schema
+const SchemasPath = "/admin/schemas"
+
+// handleSchemas 处理 schema 注册表管理请求
+func (s *Server) handleSchemas(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, SchemasPath)
+	if rest == "" || rest == "/" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		entries, err := schema.List(r.Context(), s.store)
+		if err != nil {
+			s.schemaError(w, "", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+		return
+	}
+	prefix := strings.TrimPrefix(rest, "/")
+
+	switch r.Method {
+	case http.MethodGet:
+		entry, err := schema.Get(r.Context(), s.store, prefix)
+		if err != nil {
+			s.schemaError(w, prefix, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(entry.Schema)
+	case http.MethodPut:
+		doc, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read schema", http.StatusBadRequest)
+			return
+		}
+		if _, err := schema.Put(r.Context(), s.store, prefix, doc); err != nil {
+			s.schemaError(w, prefix, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if err := schema.Delete(r.Context(), s.store, prefix); err != nil {
+			s.schemaError(w, prefix, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodPut)
+		w.Header().Add("Allow", http.MethodDelete)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) schemaError(w http.ResponseWriter, prefix string, err error) {
+	switch {
+	case errors.Is(err, schema.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, schema.ErrInvalidSchema):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Error("Schema admin request failed",
+			zap.String("prefix", prefix),
+			zap.Error(err),
+			zap.String("component", "http"))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -15,14 +15,22 @@
 package http
 
 import (
-	"context"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"metaStore/api/etcd"
 	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/chunk"
+	"metaStore/pkg/keycodec"
 	"metaStore/pkg/log"
+	"metaStore/pkg/protect"
+	"metaStore/pkg/schema"
+	"metaStore/pkg/settings"
+	"metaStore/pkg/version"
 
 	"go.etcd.io/raft/v3/raftpb"
 	"go.uber.org/zap"
@@ -33,13 +41,44 @@ type Server struct {
 	store       kvstore.Store
 	confChangeC chan<- raftpb.ConfChange
 	httpServer  *http.Server
+
+	mirrors       MirrorController
+	encryption    KeyRotator
+	drainer       Drainer
+	settings      *settings.Manager
+	usage         UsageReporter
+	users         UserStore
+	maxBatchOps   int
+	replaceStatus replaceStatus // 最近一次成员替换的进度
+
+	healthMaxApplyLag uint64
+	replica           bool
+	capabilities      func(version.Capability) bool
 }
 
+// replicaWriteMessage 只读副本拒绝写入时返回的错误
+const replicaWriteMessage = "member is a read replica, writes are not allowed"
+
 // Config HTTP API 配置
 type Config struct {
 	Store       kvstore.Store
+	Address     string // 监听地址，如 ":9121"，为空时监听 Port
 	Port        int
 	ConfChangeC chan<- raftpb.ConfChange
+	Mirrors     MirrorController  // 可选，为 nil 时 mirror 管理接口返回 501
+	Encryption  KeyRotator        // 可选，为 nil 时加密管理接口返回 501
+	Drainer     Drainer           // 可选，为 nil 时排空接口返回 501
+	Settings    *settings.Manager // 可选，修改集群设置后立即在本节点重新加载
+	Usage       UsageReporter     // 可选，为 nil 或未启用时用量接口返回 501
+	Users       UserStore         // 可选，etcd Auth 启用后 HTTP 请求需要 token
+	MaxBatchOps int               // 一次批量写入的最大操作数，0 表示默认 10000
+
+	HealthMaxApplyLag uint64 // applied index 落后 commit index 超过该值时 /health 报告 lagging、/readyz 失败，0 表示不检查
+	Replica           bool   // 只读副本（raft.node_role: replica）：读取本地状态，拒绝键值写入
+
+	// Capabilities 可选，报告集群中所有成员是否都支持某个功能，滚动升级期间新功能保持关闭；
+	// 为 nil 时开启本节点支持的所有功能
+	Capabilities func(version.Capability) bool
 }
 
 // NewServer 创建新的 HTTP API 服务器
@@ -47,19 +86,69 @@ func NewServer(cfg Config) *Server {
 	s := &Server{
 		store:       cfg.Store,
 		confChangeC: cfg.ConfChangeC,
+		mirrors:     cfg.Mirrors,
+		encryption:  cfg.Encryption,
+		drainer:     cfg.Drainer,
+		settings:    cfg.Settings,
+		usage:       cfg.Usage,
+		users:       cfg.Users,
+		maxBatchOps: cfg.MaxBatchOps,
+
+		healthMaxApplyLag: cfg.HealthMaxApplyLag,
+		replica:           cfg.Replica,
+		capabilities:      cfg.Capabilities,
+	}
+	if s.maxBatchOps <= 0 {
+		s.maxBatchOps = defaultMaxBatchOps
 	}
 
 	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, s.handleHealth)
+	mux.HandleFunc(ReadyzPath, s.handleProbe("readyz", s.readyzChecks))
+	mux.HandleFunc(ReadyzPath+"/", s.handleProbe("readyz", s.readyzChecks))
+	mux.HandleFunc(LivezPath, s.handleProbe("livez", s.livezChecks))
+	mux.HandleFunc(LivezPath+"/", s.handleProbe("livez", s.livezChecks))
+	mux.HandleFunc(AuthLoginPath, s.handleLogin)
+	mux.HandleFunc(OpenAPIPath, s.handleOpenAPI)
+	mux.HandleFunc(MembersPath, s.handleMembers)
+	mux.HandleFunc(RaftLogPath, s.handleRaftLog)
+	mux.HandleFunc(MemberReplacePath, s.handleMemberReplace)
+	mux.HandleFunc(PlacementPath, s.handlePlacement)
+	mux.HandleFunc(MirrorsPath, s.handleMirrors)
+	mux.HandleFunc(MirrorsPath+"/", s.handleMirrors)
+	mux.HandleFunc(EncryptionPath, s.handleEncryption)
+	mux.HandleFunc(EncryptionPath+"/", s.handleEncryption)
+	mux.HandleFunc(DrainPath, s.handleDrain)
+	mux.HandleFunc(SchemasPath, s.handleSchemas)
+	mux.HandleFunc(SchemasPath+"/", s.handleSchemas)
+	mux.HandleFunc(SettingsPath, s.handleSettings)
+	mux.HandleFunc(SettingsPath+"/", s.handleSettings)
+	mux.HandleFunc(TrashPath, s.handleTrash)
+	mux.HandleFunc(TrashPath+"/", s.handleTrash)
+	mux.HandleFunc(UsagePath, s.handleUsage)
+	mux.HandleFunc(UsagePath+"/", s.handleUsage)
+	mux.HandleFunc(WatchPath, s.handleWatch)
+	mux.HandleFunc(BatchPath, s.handleBatch)
+	mux.HandleFunc(HistoryPath, s.handleHistory)
 	mux.Handle("/", s)
 
+	addr := cfg.Address
+	if addr == "" {
+		addr = ":" + strconv.Itoa(cfg.Port)
+	}
 	s.httpServer = &http.Server{
-		Addr:    ":" + strconv.Itoa(cfg.Port),
-		Handler: mux,
+		Addr:    addr,
+		Handler: withRecovery(s.withAuth(mux)),
 	}
 
 	return s
 }
 
+// supports 集群是否已开启功能 c
+func (s *Server) supports(c version.Capability) bool {
+	return s.capabilities == nil || s.capabilities(c)
+}
+
 // Start 启动 HTTP 服务器
 func (s *Server) Start() error {
 	log.Info("Starting HTTP API server", zap.String("address", s.httpServer.Addr), zap.String("component", "http"))
@@ -79,18 +168,55 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		zap.String("uri", r.RequestURI),
 		zap.String("component", "http"))
 
-	// 去掉前导斜杠，使 key 与 etcd API 一致
-	key := strings.TrimPrefix(r.RequestURI, "/")
+	// 去掉前导斜杠，使 key 与 etcd API 一致；URL.Path 已解码百分号转义，查询参数不属于 key
+	key := strings.TrimPrefix(r.URL.Path, "/")
 	defer r.Body.Close()
 
+	codec, ok := requestKeyCodec(w, r)
+	if !ok {
+		return
+	}
+
 	// 检查是否是集群管理操作（以数字 ID 开头）
-	// 集群操作: POST /{nodeID} 添加节点, DELETE /{nodeID} 删除节点
+	// 集群操作: POST /{nodeID} 添加节点, DELETE /{nodeID} 删除节点；指定了 key 编码时总是 KV 操作
 	isClusterOp := false
-	if r.Method == http.MethodPost || r.Method == http.MethodDelete {
+	if codec == keycodec.Raw && (r.Method == http.MethodPost || r.Method == http.MethodDelete) {
 		// 尝试解析为 nodeID，如果成功则视为集群操作
 		_, err := strconv.ParseUint(key, 0, 64)
 		isClusterOp = (err == nil)
 	}
+	if !isClusterOp {
+		decoded, err := codec.Decode(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key = decoded
+	}
+
+	// 集群操作与 gRPC 的 MemberAdd/MemberRemove 相同，检查空 key 上的写权限
+	permKey, permType := key, etcd.PermissionWrite
+	if isClusterOp {
+		permKey = ""
+	} else if r.Method == http.MethodGet {
+		permType = etcd.PermissionRead
+	}
+	if !s.authorize(w, r, permKey, permType) {
+		return
+	}
+
+	if !observeHLC(w, r, s.store) {
+		return
+	}
+
+	// 只读副本只提供 serializable 读，不经过 leader
+	if s.replica && !isClusterOp {
+		if r.Method == http.MethodPut || r.Method == http.MethodDelete {
+			http.Error(w, replicaWriteMessage, http.StatusForbidden)
+			return
+		}
+		r = r.WithContext(kvstore.WithSerializable(r.Context()))
+	}
 
 	switch r.Method {
 	case http.MethodPut:
@@ -132,31 +258,70 @@ func (s *Server) handlePut(w http.ResponseWriter, r *http.Request, key string) {
 		zap.String("value", string(v)),
 		zap.String("component", "http"))
 
+	ctx, cancel, err := requestContext(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
 	// 使用同步的 PutWithLease 而不是异步的 Propose，确保写入后立即可读
-	ctx := context.Background()
 	_, _, err = s.store.PutWithLease(ctx, key, string(v), 0)
 	if err != nil {
+		if writeTooManyRequests(w, err) || writeLeaderChanged(w, err) || writeTimeout(w, err) {
+			return
+		}
+		if errors.Is(err, schema.ErrInvalidValue) || errors.Is(err, schema.ErrInvalidSchema) {
+			// 不满足前缀上注册的 JSON Schema，返回原因方便客户端修正
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, chunk.ErrValueTooLarge) || errors.Is(err, kvstore.ErrRequestTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, admission.ErrRejected) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
 		log.Error("Failed to put key-value", zap.Error(err), zap.String("component", "http"))
 		http.Error(w, "Failed on PUT", http.StatusInternalServerError)
 		return
 	}
 
+	setCommitIndex(w, s.store)
+	setHLC(w, s.store)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // handleGet 处理 GET 请求（查询键值）
+// 分段存储的大 value 逐段写出，不在内存中拼接
 func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, key string) {
 	log.Info("HTTP GET request",
 		zap.String("key", key),
 		zap.String("component", "http"))
 
-	if v, ok := s.store.Lookup(key); ok {
+	if !waitMinIndex(w, r, s.store) {
+		return
+	}
+	setHLC(w, s.store)
+
+	cw := &countingWriter{w: w}
+	found, err := chunk.StreamValue(r.Context(), s.store, key, cw)
+	switch {
+	case err != nil && cw.n == 0:
+		log.Error("Failed to get value", zap.String("key", key), zap.Error(err), zap.String("component", "http"))
+		http.Error(w, "Failed to GET", http.StatusInternalServerError)
+	case err != nil:
+		// 已经开始写出 value，只能中断响应
+		log.Error("Failed to stream value", zap.String("key", key), zap.Int64("written", cw.n), zap.Error(err), zap.String("component", "http"))
+		panic(http.ErrAbortHandler)
+	case found:
 		log.Info("HTTP GET found value",
 			zap.String("key", key),
-			zap.String("value", v),
+			zap.Int64("size", cw.n),
 			zap.String("component", "http"))
-		w.Write([]byte(v))
-	} else {
+	default:
 		log.Info("HTTP GET key not found",
 			zap.String("key", key),
 			zap.String("component", "http"))
@@ -164,6 +329,18 @@ func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, key string) {
 	}
 }
 
+// countingWriter 记录已写出的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // handleClusterAdd 处理 POST 请求（添加 Raft 节点）
 func (s *Server) handleClusterAdd(w http.ResponseWriter, r *http.Request, key string) {
 	url, err := io.ReadAll(r.Body)
@@ -214,8 +391,22 @@ func (s *Server) handleClusterDelete(w http.ResponseWriter, r *http.Request, key
 
 // handleKeyDelete 处理 DELETE 请求（删除 key-value 对）
 func (s *Server) handleKeyDelete(w http.ResponseWriter, r *http.Request, key string) {
+	ctx, cancel, err := requestContext(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
 	// 使用 DeleteRange 删除单个 key（rangeEnd 为空表示单键删除）
-	_, _, _, err := s.store.DeleteRange(context.Background(), key, "")
+	_, _, _, err = s.store.DeleteRange(ctx, key, "")
+	if writeTooManyRequests(w, err) || writeLeaderChanged(w, err) || writeTimeout(w, err) {
+		return
+	}
+	if errors.Is(err, admission.ErrRejected) || errors.Is(err, protect.ErrProtected) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		log.Error("Failed to delete key", zap.String("key", key), zap.Error(err), zap.String("component", "http"))
 		http.Error(w, "Failed on DELETE", http.StatusInternalServerError)
@@ -223,16 +414,23 @@ func (s *Server) handleKeyDelete(w http.ResponseWriter, r *http.Request, key str
 	}
 
 	// Optimistic-- no waiting for ack from raft
+	setCommitIndex(w, s.store)
+	setHLC(w, s.store)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // ServeHTTPKVAPI 启动 HTTP KV API（保持向后兼容）
 func ServeHTTPKVAPI(kv kvstore.Store, port int, confChangeC chan<- raftpb.ConfChange, errorC <-chan error) {
-	srv := NewServer(Config{
+	ServeHTTPAPI(Config{
 		Store:       kv,
 		Port:        port,
 		ConfChangeC: confChangeC,
-	})
+	}, errorC)
+}
+
+// ServeHTTPAPI 按配置启动 HTTP API，raft 出错时退出进程
+func ServeHTTPAPI(cfg Config, errorC <-chan error) {
+	srv := NewServer(cfg)
 
 	go func() {
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
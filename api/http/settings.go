@@ -0,0 +1,137 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"metaStore/pkg/log"
+	"metaStore/pkg/settings"
+
+	"go.uber.org/zap"
+)
+
+// SettingsPath 集群运行时设置的管理接口路径
+//
+//	GET    /admin/settings        返回所有设置的定义和集群中的值
+//	GET    /admin/settings/{name} 返回集群中设置的值
+//	PUT    /admin/settings/{name} 修改设置，请求体为值
+//	DELETE /admin/settings/{name} 删除设置，各节点恢复使用配置文件中的值
+//
+// PUT 和 DELETE 可以带 ?version=N，只有设置的当前版本等于 N 时才修改（0 表示尚未设置），
+// 版本不匹配时返回 409
+const SettingsPath = "/admin/settings"
+
+// handleSettings 处理集群设置管理请求
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, SettingsPath), "/")
+	if name == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		list, err := settings.List(r.Context(), s.store)
+		if err != nil {
+			s.settingsError(w, "", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings.Statuses(list))
+		return
+	}
+
+	version := int64(-1)
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid version %q", v), http.StatusBadRequest)
+			return
+		}
+		version = n
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		setting, err := settings.Get(r.Context(), s.store, name)
+		if err != nil {
+			s.settingsError(w, name, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(setting)
+	case http.MethodPut:
+		value, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read value", http.StatusBadRequest)
+			return
+		}
+		setting, err := settings.Set(r.Context(), s.store, name, strings.TrimSpace(string(value)), version)
+		if err != nil {
+			s.settingsError(w, name, err)
+			return
+		}
+		s.refreshSettings()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(setting)
+	case http.MethodDelete:
+		if err := settings.Reset(r.Context(), s.store, name, version); err != nil {
+			s.settingsError(w, name, err)
+			return
+		}
+		s.refreshSettings()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", http.MethodGet)
+		w.Header().Add("Allow", http.MethodPut)
+		w.Header().Add("Allow", http.MethodDelete)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// refreshSettings 修改后立即在本节点生效，其他节点在下一个刷新周期生效
+func (s *Server) refreshSettings() {
+	if s.settings == nil {
+		return
+	}
+	if err := s.settings.Refresh(); err != nil {
+		log.Warn("Failed to reload cluster settings",
+			zap.Error(err),
+			zap.String("component", "http"))
+	}
+}
+
+func (s *Server) settingsError(w http.ResponseWriter, name string, err error) {
+	switch {
+	case errors.Is(err, settings.ErrUnknown), errors.Is(err, settings.ErrNotSet):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, settings.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, settings.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Error("Settings admin request failed",
+			zap.String("name", name),
+			zap.Error(err),
+			zap.String("component", "http"))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+	"metaStore/pkg/protect"
+
+	"go.uber.org/zap"
+)
+
+// TrashPath 删除保护回收站的管理接口路径
+//
+//	GET    /admin/trash?prefix=P          列出原 key 以 P 开头的条目（不含 value）
+//	GET    /admin/trash?key=K             返回 K 的条目（含 value）
+//	POST   /admin/trash/restore?key=K     恢复 K，也可以用 ?prefix=P 恢复前缀下的所有条目
+//	DELETE /admin/trash?key=K             立即删除条目，也可以用 ?prefix=P
+//
+// 恢复时原 key 已经存在的条目被跳过并返回 409，带 &overwrite=true 时覆盖
+//
+// 启用认证后，查看 K 的条目需要 K 的读权限，列表中跳过没有读权限的条目；
+// 恢复和删除与其他管理修改操作相同，需要空 key 上的写权限
+const TrashPath = "/admin/trash"
+
+// TrashRestoreResponse 恢复的结果
+type TrashRestoreResponse struct {
+	Restored int    `json:"restored"`
+	Error    string `json:"error,omitempty"`
+}
+
+// handleTrash 处理回收站管理请求
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	key, rangeEnd, ok := trashRange(w, r)
+	if !ok {
+		return
+	}
+
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, TrashPath), "/") {
+	case "":
+		switch r.Method {
+		case http.MethodGet:
+			if rangeEnd == "" && !s.authorize(w, r, key, etcd.PermissionRead) {
+				return
+			}
+			entries, err := protect.List(r.Context(), s.store, key, rangeEnd)
+			if err != nil {
+				s.trashError(w, err)
+				return
+			}
+			if rangeEnd == "" {
+				if len(entries) == 0 {
+					http.Error(w, protect.ErrNotFound.Error(), http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(entries[0])
+				return
+			}
+			visible := entries[:0]
+			for _, e := range entries {
+				if !s.canRead(r, e.Key) {
+					continue
+				}
+				e.Value = nil
+				visible = append(visible, e)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(visible)
+		case http.MethodDelete:
+			if _, err := protect.Discard(r.Context(), s.store, key, rangeEnd); err != nil {
+				if errors.Is(err, protect.ErrNotFound) {
+					http.Error(w, err.Error(), http.StatusNotFound)
+					return
+				}
+				s.trashError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", http.MethodGet)
+			w.Header().Add("Allow", http.MethodDelete)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "restore":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		overwrite := r.URL.Query().Get("overwrite") == "true"
+		restored, err := protect.Restore(r.Context(), s.store, key, rangeEnd, overwrite)
+		resp := TrashRestoreResponse{Restored: restored}
+		status := http.StatusOK
+		switch {
+		case err == nil:
+		case errors.Is(err, protect.ErrNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, protect.ErrExists):
+			resp.Error = err.Error()
+			status = http.StatusConflict
+		default:
+			s.trashError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// trashRange 从 ?key= 或 ?prefix= 得到原 key 的范围
+func trashRange(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	q := r.URL.Query()
+	key, hasKey := q.Get("key"), q.Has("key")
+	prefix, hasPrefix := q.Get("prefix"), q.Has("prefix")
+	switch {
+	case hasKey && hasPrefix:
+		http.Error(w, "key and prefix are mutually exclusive", http.StatusBadRequest)
+		return "", "", false
+	case hasKey:
+		if key == "" {
+			http.Error(w, "key must not be empty", http.StatusBadRequest)
+			return "", "", false
+		}
+		return key, "", true
+	default:
+		// 没有参数时作用于整个回收站
+		key, rangeEnd := kvstore.PrefixRange(prefix)
+		return key, rangeEnd, true
+	}
+}
+
+func (s *Server) trashError(w http.ResponseWriter, err error) {
+	log.Error("Trash admin request failed",
+		zap.Error(err),
+		zap.String("component", "http"))
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
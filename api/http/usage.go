@@ -0,0 +1,89 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"metaStore/api/etcd"
+	"metaStore/pkg/usage"
+)
+
+// UsagePath 按前缀统计的用量的管理接口路径，只反映收到请求的节点
+//
+//	GET  /admin/usage      返回最近一次扫描的结果，可以带 ?limit=N 只返回字节数最大的 N 个前缀
+//	POST /admin/usage/scan 立即扫描一次并返回结果
+//
+// 报告包含整个 keyspace 的前缀，启用认证后查看也需要空 key 上的写权限
+const UsagePath = "/admin/usage"
+
+// UsageReporter 提供按前缀统计的用量，由 usage.Tracker 实现
+type UsageReporter interface {
+	Enabled() bool
+	Report() usage.Report
+	Scan(ctx context.Context) error
+}
+
+// handleUsage 处理用量查询请求
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if s.usage == nil || !s.usage.Enabled() {
+		http.Error(w, "usage accounting is disabled, set usage.enable in the configuration", http.StatusNotImplemented)
+		return
+	}
+
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, UsagePath), "/") {
+	case "":
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorize(w, r, "", etcd.PermissionWrite) {
+			return
+		}
+		report := s.usage.Report()
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", v), http.StatusBadRequest)
+				return
+			}
+			if n < len(report.Prefixes) {
+				report.Prefixes = report.Prefixes[:n]
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	case "scan":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := s.usage.Scan(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.usage.Report())
+	default:
+		http.NotFound(w, r)
+	}
+}
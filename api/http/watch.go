@@ -0,0 +1,298 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/keycodec"
+	"metaStore/pkg/log"
+	"metaStore/pkg/sqlindex"
+	"metaStore/pkg/version"
+
+	"go.uber.org/zap"
+)
+
+// WatchPath 以 Server-Sent Events 推送 watch 事件
+//
+//	GET /watch?prefix=app/&fromRev=100&prevKv=true
+//	GET /watch?key=app/config           只订阅单个 key
+//	GET /watch?prefix=jobs/&jsonPath=$.status&jsonValue=failed&valuePrefix={
+//	GET /watch?key=AGJpbg==&keyEncoding=base64  key 和事件中的 key 使用 base64 编码
+//
+// valuePrefix、jsonPath/jsonValue 在服务端按 value 过滤事件，条件同时满足才推送；
+// DELETE 事件按被删除的 value 匹配。
+// 每个事件的 SSE id 为 "revision.序号"，序号是该事件在流中同一 revision 的事件里的位置（从 1 开始），
+// id 在流中单调递增。浏览器 EventSource 断线重连时通过 Last-Event-ID 从该事件之后继续，
+// 同一个 revision 中已收到的事件不会重复、未收到的事件不会丢失；只有 revision 的 Last-Event-ID
+// 按该 revision 已全部收到处理，滚动升级期间（集群版本还不支持 WatchResume）只发送这种 id。
+// 同一个 key 的事件按 revision 递增的顺序推送。
+// 服务端关闭流（例如 watcher 过慢被取消）后客户端应重连；重连的 revision 已被压缩且不在
+// 事件记录中时，memory 引擎返回 500，rocksdb 引擎推送当前值
+const WatchPath = "/watch"
+
+// watchKeepAlive 空闲时发送注释行的间隔，避免代理断开长连接
+const watchKeepAlive = 15 * time.Second
+
+// httpWatchIDBase HTTP watch 使用的负数 ID 区间，与 mirror、CDC 的内部 watch 错开
+const httpWatchIDBase int64 = -3 << 40
+
+var httpWatchSeq atomic.Int64
+
+// watchEvent SSE 中的事件数据（JSON），key/value 以字符串返回，与 KV 接口一致
+type watchEvent struct {
+	Type     string   `json:"type"` // "PUT" 或 "DELETE"
+	Revision int64    `json:"revision"`
+	Kv       watchKV  `json:"kv"`
+	PrevKv   *watchKV `json:"prev_kv,omitempty"`
+}
+
+type watchKV struct {
+	Key            string `json:"key"`
+	Value          string `json:"value,omitempty"`
+	CreateRevision int64  `json:"create_revision,omitempty"`
+	ModRevision    int64  `json:"mod_revision"`
+	Version        int64  `json:"version,omitempty"`
+	Lease          int64  `json:"lease,omitempty"`
+	HLC            uint64 `json:"hlc,omitempty"` // 最后一次修改提交时的 HLC
+}
+
+// optionWatcher 支持 PrevKV 和 value 过滤的 store
+type optionWatcher interface {
+	WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error)
+}
+
+// handleWatch 处理 watch 订阅
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	codec, ok := requestKeyCodec(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	prefix, err := codec.Decode(q.Get("prefix"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, rangeEnd := kvstore.PrefixRange(prefix)
+	if k := q.Get("key"); k != "" {
+		if key, err = codec.Decode(k); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rangeEnd = ""
+	}
+	if !s.authorize(w, r, key, etcd.PermissionRead) {
+		return
+	}
+
+	var fromRev int64
+	if v := q.Get("fromRev"); v != "" {
+		rev, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || rev < 0 {
+			http.Error(w, "invalid fromRev", http.StatusBadRequest)
+			return
+		}
+		fromRev = rev
+	}
+	// EventSource 重连时从上一个已收到的事件之后继续
+	var skip int
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if rev, n, ok := parseWatchEventID(v); ok {
+			fromRev, skip = rev, n
+		}
+	}
+	prevKV := q.Get("prevKv") == "true"
+	filter := sqlindex.ValueFilter{
+		Prefix:    q.Get("valuePrefix"),
+		JSONPath:  q.Get("jsonPath"),
+		JSONValue: q.Get("jsonValue"),
+	}
+	if err := filter.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	watchID := httpWatchIDBase - httpWatchSeq.Add(1)
+	var events <-chan kvstore.WatchEvent
+	if ow, ok := kvstore.As[optionWatcher](s.store); ok {
+		opts := &kvstore.WatchOptions{PrevKV: prevKV}
+		if !filter.Empty() {
+			opts.ValueFilter = filter.Match
+		}
+		events, err = ow.WatchWithOptions(key, rangeEnd, fromRev, watchID, opts)
+	} else if filter.Empty() {
+		events, err = s.store.Watch(r.Context(), key, rangeEnd, fromRev, watchID)
+	} else {
+		http.Error(w, "value filters are not supported by the store", http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		log.Error("Failed to create watch", zap.String("key", key), zap.Error(err), zap.String("component", "http"))
+		http.Error(w, "Failed to watch", http.StatusInternalServerError)
+		return
+	}
+	defer s.store.CancelWatch(watchID)
+
+	log.Info("HTTP watch started",
+		zap.String("key", key),
+		zap.String("range_end", rangeEnd),
+		zap.Int64("from_rev", fromRev),
+		zap.String("component", "http"))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	resumable := s.supports(version.WatchResume)
+	if err := streamWatch(r.Context(), w, flusher, events, fromRev, skip, resumable, codec); err != nil {
+		log.Debug("HTTP watch ended", zap.String("key", key), zap.Error(err), zap.String("component", "http"))
+	}
+}
+
+// streamWatch 将事件写为 SSE，直到客户端断开或 watch 被关闭
+// 事件中的 key 按 codec 编码；skip 为 fromRev 中重连前已收到的事件数。
+// resumable 为 false 时（集群中还有不支持 WatchResume 的成员）SSE id 只有 revision
+func streamWatch(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, events <-chan kvstore.WatchEvent, fromRev int64, skip int, resumable bool, codec keycodec.Codec) error {
+	keepAlive := time.NewTicker(watchKeepAlive)
+	defer keepAlive.Stop()
+
+	// lastRev、seq 为上一个事件的 revision 和它在该 revision 中的序号
+	var lastRev int64
+	var seq int
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return err
+			}
+			flusher.Flush()
+
+		case ev, ok := <-events:
+			if !ok {
+				return fmt.Errorf("watch closed")
+			}
+			if ev.Kv == nil {
+				continue
+			}
+			rev := ev.Revision
+			if rev == 0 {
+				rev = ev.Kv.ModRevision
+			}
+			// store 从当前数据生成初始事件，跳过早于 fromRev 的部分
+			if fromRev > 0 && rev < fromRev {
+				continue
+			}
+			if rev != lastRev {
+				lastRev, seq = rev, 0
+			}
+			seq++
+			if rev == fromRev && seq <= skip {
+				continue
+			}
+
+			data, err := json.Marshal(toWatchEvent(ev, rev, codec))
+			if err != nil {
+				return err
+			}
+			name := "put"
+			if ev.Type == kvstore.EventTypeDelete {
+				name = "delete"
+			}
+			id := strconv.FormatInt(rev, 10)
+			if resumable {
+				id = watchEventID(rev, seq)
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, name, data); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// watchEventID 返回 revision 中第 seq 个事件的 SSE id
+func watchEventID(rev int64, seq int) string {
+	return strconv.FormatInt(rev, 10) + "." + strconv.Itoa(seq)
+}
+
+// parseWatchEventID 把 Last-Event-ID 解析为重连的起点：从 fromRev 开始，跳过其中前 skip 个事件。
+// 旧格式的 id 只有 revision，从下一个 revision 开始
+func parseWatchEventID(id string) (fromRev int64, skip int, ok bool) {
+	revPart, seqPart, hasSeq := strings.Cut(id, ".")
+	rev, err := strconv.ParseInt(revPart, 10, 64)
+	if err != nil || rev < 0 {
+		return 0, 0, false
+	}
+	if !hasSeq {
+		return rev + 1, 0, true
+	}
+	seq, err := strconv.Atoi(seqPart)
+	if err != nil || seq < 1 {
+		return 0, 0, false
+	}
+	return rev, seq, true
+}
+
+func toWatchEvent(ev kvstore.WatchEvent, rev int64, codec keycodec.Codec) watchEvent {
+	out := watchEvent{Type: "PUT", Revision: rev, Kv: toWatchKV(ev.Kv, codec)}
+	if ev.Type == kvstore.EventTypeDelete {
+		out.Type = "DELETE"
+		out.Kv = watchKV{Key: codec.Encode(ev.Kv.Key), ModRevision: rev, HLC: ev.Kv.HLC}
+	}
+	if ev.PrevKv != nil {
+		prev := toWatchKV(ev.PrevKv, codec)
+		out.PrevKv = &prev
+	}
+	return out
+}
+
+func toWatchKV(kv *kvstore.KeyValue, codec keycodec.Codec) watchKV {
+	return watchKV{
+		Key:            codec.Encode(kv.Key),
+		Value:          string(kv.Value),
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+		HLC:            kv.HLC,
+	}
+}
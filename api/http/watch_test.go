@@ -0,0 +1,254 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/version"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent 解析后的一个 SSE 事件
+type sseEvent struct {
+	id, name string
+	data     watchEvent
+}
+
+// readEvents 从 SSE 流中读取 n 个事件
+func readEvents(t *testing.T, r *bufio.Reader, n int) []sseEvent {
+	var out []sseEvent
+	var cur sseEvent
+	for len(out) < n {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if cur.id != "" {
+				out = append(out, cur)
+			}
+			cur = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			cur.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			cur.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &cur.data))
+		}
+	}
+	return out
+}
+
+func TestWatchStreamsEvents(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+WatchPath+"?prefix=app/&prevKv=true", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	_, _, err = store.PutWithLease(ctx, "app/a", "1", 0)
+	require.NoError(t, err)
+	_, _, err = store.PutWithLease(ctx, "other/x", "ignored", 0)
+	require.NoError(t, err)
+	_, _, err = store.PutWithLease(ctx, "app/a", "2", 0)
+	require.NoError(t, err)
+	_, _, _, err = store.DeleteRange(ctx, "app/a", "")
+	require.NoError(t, err)
+
+	events := readEvents(t, bufio.NewReader(resp.Body), 3)
+	assert.Equal(t, "put", events[0].name)
+	assert.Equal(t, "app/a", events[0].data.Kv.Key)
+	assert.Equal(t, "1", events[0].data.Kv.Value)
+
+	assert.Equal(t, "3.1", events[1].id)
+	assert.Equal(t, "2", events[1].data.Kv.Value)
+	require.NotNil(t, events[1].data.PrevKv)
+	assert.Equal(t, "1", events[1].data.PrevKv.Value)
+
+	assert.Equal(t, "delete", events[2].name)
+	assert.Equal(t, "DELETE", events[2].data.Type)
+	assert.Equal(t, int64(4), events[2].data.Revision)
+}
+
+// TestWatchLegacyEventIDs 集群版本不支持 WatchResume 时只发送 revision 作为 id
+func TestWatchLegacyEventIDs(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{
+		Store:        store,
+		Capabilities: func(c version.Capability) bool { return version.Supports(version.Baseline, c) },
+	}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+WatchPath+"?prefix=app/", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	_, _, err = store.PutWithLease(ctx, "app/a", "1", 0)
+	require.NoError(t, err)
+	events := readEvents(t, bufio.NewReader(resp.Body), 1)
+	assert.Equal(t, "1", events[0].id)
+}
+
+func TestWatchFiltersValues(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+WatchPath+"?prefix=jobs/&jsonPath=$.status&jsonValue=failed", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, kv := range [][2]string{
+		{"jobs/1", `{"status":"running"}`},
+		{"jobs/2", `{"status":"failed"}`},
+		{"jobs/3", "not json"},
+		{"jobs/1", `{"status":"failed"}`},
+	} {
+		_, _, err = store.PutWithLease(ctx, kv[0], kv[1], 0)
+		require.NoError(t, err)
+	}
+	// 删除事件按被删除的 value 匹配
+	_, _, _, err = store.DeleteRange(ctx, "jobs/3", "")
+	require.NoError(t, err)
+	_, _, _, err = store.DeleteRange(ctx, "jobs/2", "")
+	require.NoError(t, err)
+
+	events := readEvents(t, bufio.NewReader(resp.Body), 3)
+	assert.Equal(t, "jobs/2", events[0].data.Kv.Key)
+	assert.Equal(t, "put", events[0].name)
+	assert.Equal(t, "jobs/1", events[1].data.Kv.Key)
+	assert.Equal(t, "jobs/2", events[2].data.Kv.Key)
+	assert.Equal(t, "delete", events[2].name)
+}
+
+func TestWatchResumesFromLastEventID(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	srv := httptest.NewServer(NewServer(Config{Store: store}).httpServer.Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, key := range []string{"app/a", "app/b", "app/c"} {
+		_, _, err := store.PutWithLease(ctx, key, "1", 0)
+		require.NoError(t, err)
+	}
+	// 一次删除两个键，两个事件属于同一个 revision
+	_, _, delRev, err := store.DeleteRange(ctx, "app/b", "app/d")
+	require.NoError(t, err)
+	_, _, err = store.PutWithLease(ctx, "app/a", "2", 0)
+	require.NoError(t, err)
+
+	watch := func(lastEventID string, n int) []sseEvent {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+WatchPath+"?prefix=app/&fromRev=1", nil)
+		require.NoError(t, err)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return readEvents(t, bufio.NewReader(resp.Body), n)
+	}
+
+	// 同一个 revision 中的事件 id 按序号递增
+	all := watch("", 6)
+	var ids []string
+	for _, ev := range all {
+		ids = append(ids, ev.id)
+	}
+	rev := strconv.FormatInt(delRev, 10)
+	next := strconv.FormatInt(delRev+1, 10) + ".1"
+	assert.Equal(t, []string{"1.1", "2.1", "3.1", rev + ".1", rev + ".2", next}, ids)
+
+	// 收到第一个删除事件后重连，从第二个删除事件继续
+	events := watch(rev+".1", 2)
+	assert.Equal(t, rev+".2", events[0].id)
+	assert.Equal(t, all[4].data, events[0].data)
+	assert.Equal(t, next, events[1].id)
+
+	// 只有 revision 的 id 从下一个 revision 继续
+	events = watch(rev, 1)
+	assert.Equal(t, next, events[0].id)
+}
+
+func TestParseWatchEventID(t *testing.T) {
+	for _, tt := range []struct {
+		id      string
+		fromRev int64
+		skip    int
+		ok      bool
+	}{
+		{"7.2", 7, 2, true},
+		{"7", 8, 0, true},
+		{"7.0", 0, 0, false},
+		{"-1", 0, 0, false},
+		{"x.1", 0, 0, false},
+	} {
+		fromRev, skip, ok := parseWatchEventID(tt.id)
+		assert.Equal(t, tt.ok, ok, tt.id)
+		assert.Equal(t, tt.fromRev, fromRev, tt.id)
+		assert.Equal(t, tt.skip, skip, tt.id)
+	}
+}
+
+func TestWatchRejectsInvalidRequests(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{Store: memory.NewMemoryEtcd()}).httpServer.Handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + WatchPath + "?fromRev=abc")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + WatchPath + "?jsonPath=status&jsonValue=ready")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+WatchPath, "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -15,15 +15,80 @@
 package mysql
 
 import (
+	"context"
 	"crypto/sha1"
 	"fmt"
 	"sync"
 
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
 	"metaStore/pkg/log"
 
+	"github.com/go-mysql-org/go-mysql/mysql"
 	"go.uber.org/zap"
 )
 
+// UserStore is the etcd Auth user database. Once authentication is enabled there,
+// MySQL connections log in with the same users and their key permissions apply to
+// SQL statements; otherwise the static username/password from config is used.
+type UserStore interface {
+	IsEnabled() bool
+	MySQLPasswordHash(username string) ([]byte, error)
+	CheckPermission(username string, key []byte, permType etcd.PermissionType) error
+	RangeReadFilter(username string, key, rangeEnd []byte) (func(key []byte) bool, error)
+}
+
+// checkPermission enforces the key permissions of a user store connection
+func (h *MySQLHandler) checkPermission(command, key string, permType etcd.PermissionType) error {
+	if h.users == nil || !h.users.IsEnabled() {
+		return nil
+	}
+	if err := h.users.CheckPermission(h.user, []byte(key), permType); err != nil {
+		log.Warn("MySQL permission denied",
+			zap.String("username", h.user),
+			zap.String("command", command),
+			zap.String("key", key),
+			zap.String("component", "mysql"))
+		return mysql.NewError(mysql.ER_TABLEACCESS_DENIED_ERROR,
+			fmt.Sprintf("%s command denied to user '%s' for key '%s'", command, h.user, key))
+	}
+	return nil
+}
+
+// rangeContext applies the user's read permissions to a scan of [key, rangeEnd):
+// the store skips keys the user cannot read, so they do not count against the
+// scan limit. With auth.fail_closed_ranges a range that is not fully readable
+// is denied instead
+func (h *MySQLHandler) rangeContext(ctx context.Context, command, key, rangeEnd string) (context.Context, error) {
+	if h.users == nil || !h.users.IsEnabled() {
+		return ctx, nil
+	}
+	visible, err := h.users.RangeReadFilter(h.user, []byte(key), []byte(rangeEnd))
+	if err != nil {
+		log.Warn("MySQL permission denied",
+			zap.String("username", h.user),
+			zap.String("command", command),
+			zap.String("key", key),
+			zap.String("range_end", rangeEnd),
+			zap.String("component", "mysql"))
+		return nil, mysql.NewError(mysql.ER_TABLEACCESS_DENIED_ERROR,
+			fmt.Sprintf("%s command denied to user '%s' for keys from '%s'", command, h.user, key))
+	}
+	if visible != nil {
+		ctx = kvstore.WithKeyFilter(ctx, visible)
+	}
+	return ctx, nil
+}
+
+// canRead reports whether a range result row may be returned to the user; keys
+// outside the user's read permissions are silently skipped, like rows filtered by WHERE
+func (h *MySQLHandler) canRead(key []byte) bool {
+	if h.users == nil || !h.users.IsEnabled() {
+		return true
+	}
+	return h.users.CheckPermission(h.user, key, etcd.PermissionRead) == nil
+}
+
 // AuthProvider handles MySQL authentication
 type AuthProvider struct {
 	mu       sync.RWMutex
@@ -86,6 +151,21 @@ func (ap *AuthProvider) CheckAuth(username, password string) bool {
 	return true
 }
 
+// MySQLPasswordHash returns SHA1(SHA1(password)) of a static user, the verifier
+// the connection phase checks both authentication plugins against
+func (ap *AuthProvider) MySQLPasswordHash(username string) ([]byte, error) {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+
+	password, exists := ap.users[username]
+	if !exists {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+	return stage2[:], nil
+}
+
 // AddUser adds a new user
 func (ap *AuthProvider) AddUser(username, password string) error {
 	ap.mu.Lock()
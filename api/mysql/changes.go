@@ -0,0 +1,321 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/keycodec"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// changesTable is the virtual table of change events, for tools that can only
+// speak SQL. A SELECT on it long-polls: it returns the events after a revision,
+// waiting up to metastore_changes_timeout seconds for the first one
+//
+//	SELECT * FROM kv_changes WHERE key LIKE 'app/%' AND revision > 1200 LIMIT 100
+//
+// An empty result means the wait timed out. The next poll asks for revisions
+// above the largest one returned; the events of a revision are never split
+// between two results, so LIMIT may be exceeded by a large transaction. Without
+// a revision condition only events after the current revision are returned
+const changesTable = "kv_changes"
+
+// changesTimeoutVar is the session variable setting how many seconds a SELECT on
+// kv_changes waits for an event, 0 to return at once. Defaults to
+// mysql.changes_timeout
+//
+//	SET SESSION metastore_changes_timeout = 5
+const changesTimeoutVar = "metastore_changes_timeout"
+
+const (
+	// defaultChangesTimeout is the default of mysql.changes_timeout
+	defaultChangesTimeout = 30 * time.Second
+
+	// defaultChangesLimit is the number of events returned without LIMIT
+	defaultChangesLimit = 100
+
+	// changesSettle is how long a poll that has events waits for more, so the
+	// events of one commit are returned together
+	changesSettle = 10 * time.Millisecond
+
+	// changesWatchIDBase starts the negative watch IDs of polls, apart from the
+	// internal watches of mirror, CDC, usage and the HTTP API
+	changesWatchIDBase int64 = -4 << 40
+)
+
+var changesWatchSeq atomic.Int64
+
+// changesColumns are the columns of kv_changes, in display order
+var changesColumns = []string{"revision", "type", "key", "value", "create_revision", "mod_revision", "version", "lease"}
+
+var (
+	changesSelectRe = regexp.MustCompile("(?is)^SELECT\\s+(.+?)\\s+FROM\\s+`?" + changesTable + "`?" +
+		"(?:\\s+WHERE\\s+(.+?))?(?:\\s+LIMIT\\s+(\\d+))?\\s*;?$")
+	// changesCondRe matches the first condition of a WHERE clause and the AND after it
+	changesCondRe = regexp.MustCompile("(?is)^`?(\\w+)`?\\s*(>=|>|=|LIKE)\\s*('[^']*'|\"[^\"]*\"|\\d+)\\s*(?:AND\\s+|$)")
+
+	setChangesTimeoutRe    = regexp.MustCompile(`(?i)(?:@@(?:session\.)?|\b)metastore_changes_timeout\s*:?=\s*(?:'([^']*)'|"([^"]*)"|([-\w.]+))`)
+	selectChangesTimeoutRe = regexp.MustCompile(`(?i)@@(?:session\.)?metastore_changes_timeout`)
+)
+
+// changesQuery is a parsed SELECT on kv_changes
+type changesQuery struct {
+	columns  []int  // indexes into changesColumns
+	key      string // first key watched
+	rangeEnd string // end of the watched range, empty for a single key
+	exact    bool   // WHERE key = '...'
+	fromRev  int64  // first revision returned, 0 for the next one
+	limit    int
+}
+
+// isChangesSelect reports whether a SELECT targets kv_changes
+func isChangesSelect(query string) bool {
+	return changesSelectRe.MatchString(strings.TrimSpace(query))
+}
+
+// handleChangesSelect serves a long-polling SELECT on kv_changes
+func (h *MySQLHandler) handleChangesSelect(ctx context.Context, query string) (*mysql.Result, error) {
+	q, err := h.parseChangesQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if q.exact {
+		if err := h.checkPermission("SELECT", q.key, etcd.PermissionRead); err != nil {
+			return nil, err
+		}
+	}
+	if q.fromRev == 0 {
+		q.fromRev = h.store.CurrentRevision() + 1
+	}
+
+	events, err := h.pollChanges(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(q.columns))
+	for i, idx := range q.columns {
+		columns[i] = changesColumns[idx]
+	}
+	var rows [][]interface{}
+	for _, ev := range events {
+		typ := "PUT"
+		var value interface{} = ev.Kv.Value
+		if ev.Type == kvstore.EventTypeDelete {
+			typ, value = "DELETE", nil
+		}
+		all := []interface{}{ev.Revision, typ, h.encodeKey(ev.Kv.Key), value,
+			ev.Kv.CreateRevision, ev.Kv.ModRevision, ev.Kv.Version, ev.Kv.Lease}
+		row := make([]interface{}, len(q.columns))
+		for i, idx := range q.columns {
+			row[i] = all[idx]
+		}
+		rows = append(rows, row)
+	}
+
+	resultset, err := mysql.BuildSimpleResultset(columns, rows, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mysql.Result{
+		Status:       0,
+		AffectedRows: uint64(len(rows)),
+		Resultset:    resultset,
+	}, nil
+}
+
+// parseChangesQuery parses the columns, WHERE conditions and LIMIT of a SELECT
+// on kv_changes. The conditions are key = '<key>' or key LIKE '<prefix>%' and
+// revision > N or revision >= N, joined with AND
+func (h *MySQLHandler) parseChangesQuery(query string) (*changesQuery, error) {
+	m := changesSelectRe.FindStringSubmatch(strings.TrimSpace(query))
+	if m == nil {
+		return nil, mysql.NewError(ErrParseError, "invalid "+changesTable+" query")
+	}
+	q := &changesQuery{limit: defaultChangesLimit}
+
+	if sel := strings.TrimSpace(m[1]); sel == "*" {
+		for i := range changesColumns {
+			q.columns = append(q.columns, i)
+		}
+	} else {
+		for _, col := range strings.Split(sel, ",") {
+			col = strings.Trim(strings.TrimSpace(col), "`")
+			idx := columnIndex(changesColumns, col)
+			if idx < 0 {
+				return nil, mysql.NewError(mysql.ER_BAD_FIELD_ERROR,
+					fmt.Sprintf("Unknown column '%s' in 'field list'", col))
+			}
+			q.columns = append(q.columns, idx)
+		}
+	}
+
+	var prefix string
+	for where := strings.TrimSpace(m[2]); where != ""; {
+		c := changesCondRe.FindStringSubmatch(where)
+		if c == nil {
+			return nil, mysql.NewError(ErrNotSupported,
+				fmt.Sprintf("%s only supports key = '<key>', key LIKE '<prefix>%%' and revision > N joined with AND", changesTable))
+		}
+		where = where[len(c[0]):]
+		column, op, literal := strings.ToLower(c[1]), strings.ToUpper(c[2]), unquote(c[3])
+		switch {
+		case column == "key" && op == "=":
+			key, err := h.decodeKey(literal)
+			if err != nil {
+				return nil, err
+			}
+			q.key, q.exact = key, true
+		case column == "key" && op == "LIKE":
+			p, err := h.changesPrefix(literal)
+			if err != nil {
+				return nil, err
+			}
+			prefix = p
+		case column == "revision" && (op == ">" || op == ">="):
+			rev, err := strconv.ParseInt(literal, 10, 64)
+			if err != nil || rev < 0 {
+				return nil, mysql.NewError(ErrTruncatedWrongValue,
+					fmt.Sprintf("Incorrect revision value: '%s'", literal))
+			}
+			if op == ">" || rev == 0 {
+				rev++
+			}
+			q.fromRev = rev
+		default:
+			return nil, mysql.NewError(ErrNotSupported,
+				fmt.Sprintf("%s does not support the condition %s %s", changesTable, c[1], c[2]))
+		}
+	}
+	if !q.exact {
+		q.key, q.rangeEnd = kvstore.PrefixRange(prefix)
+	}
+
+	if m[3] != "" {
+		limit, err := strconv.Atoi(m[3])
+		if err != nil {
+			return nil, mysql.NewError(ErrParseError, "invalid LIMIT")
+		}
+		q.limit = limit
+	}
+	return q, nil
+}
+
+// changesPrefix returns the key prefix of a LIKE pattern, which must be a
+// prefix followed by %
+func (h *MySQLHandler) changesPrefix(pattern string) (string, error) {
+	if h.keyCodec != keycodec.Raw {
+		return h.decodeKeyPattern(pattern)
+	}
+	prefix, ok := strings.CutSuffix(pattern, "%")
+	if !ok || strings.Contains(prefix, "%") {
+		return "", mysql.NewError(ErrNotSupported,
+			fmt.Sprintf("LIKE on %s only supports '<prefix>%%'", changesTable))
+	}
+	return prefix, nil
+}
+
+// pollChanges returns the readable events from q.fromRev on. It waits up to the
+// session timeout for the first event, then only changesSettle for more
+func (h *MySQLHandler) pollChanges(ctx context.Context, q *changesQuery) ([]kvstore.WatchEvent, error) {
+	if q.limit == 0 {
+		return nil, nil
+	}
+	watchID := changesWatchIDBase - changesWatchSeq.Add(1)
+	events, err := h.store.Watch(ctx, q.key, q.rangeEnd, q.fromRev, watchID)
+	if err != nil {
+		return nil, NewReadError("watch changes", err)
+	}
+	defer h.store.CancelWatch(watchID)
+
+	var out []kvstore.WatchEvent
+	wait := h.changesTimeout
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case ev, ok := <-events:
+			timer.Stop()
+			if !ok {
+				if len(out) > 0 {
+					return out, nil
+				}
+				return nil, mysql.NewError(ErrQueryInterrupted, "watch on "+changesTable+" was closed")
+			}
+			if ev.Kv == nil {
+				continue
+			}
+			if ev.Revision == 0 {
+				ev.Revision = ev.Kv.ModRevision
+			}
+			// The store may start with the current data when the history since
+			// fromRev is gone, skip what is older
+			if ev.Revision < q.fromRev {
+				continue
+			}
+			// Stop at a revision boundary, the next poll picks up from there
+			if len(out) >= q.limit && ev.Revision != out[len(out)-1].Revision {
+				return out, nil
+			}
+			if !h.canRead(ev.Kv.Key) {
+				continue
+			}
+			out = append(out, ev)
+			wait = changesSettle
+
+		case <-timer.C:
+			// Replayed events are already buffered when the timeout is 0
+			if len(events) == 0 {
+				return out, nil
+			}
+
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, NewReadError("watch changes", ctx.Err())
+		}
+	}
+}
+
+// setChangesTimeout handles SET metastore_changes_timeout, in seconds
+func (h *MySQLHandler) setChangesTimeout(value string) error {
+	if strings.EqualFold(value, "DEFAULT") {
+		h.changesTimeout = h.defaultChangesTimeout
+		return nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return mysql.NewError(ErrWrongValueForVar,
+			fmt.Sprintf("Variable '%s' can't be set to the value of '%s'", changesTimeoutVar, value))
+	}
+	h.changesTimeout = time.Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// setDefaultChangesTimeout sets mysql.changes_timeout as the timeout and the
+// DEFAULT of the session
+func (h *MySQLHandler) setDefaultChangesTimeout(timeout time.Duration) {
+	h.defaultChangesTimeout = timeout
+	h.changesTimeout = timeout
+}
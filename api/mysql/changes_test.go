@@ -0,0 +1,186 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+)
+
+func execQuery(t *testing.T, h *MySQLHandler, query string) {
+	t.Helper()
+	if _, err := h.HandleQuery(query); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+func TestChangesSelect(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	for _, key := range []string{"app/a", "other", "app/b"} {
+		if _, _, err := store.PutWithLease(ctx, key, "v-"+key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, _, err := store.DeleteRange(ctx, "app/a", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	execQuery(t, h, "SET SESSION metastore_changes_timeout = 0")
+
+	columns, rows := queryRows(t, h, "SELECT * FROM kv_changes WHERE key LIKE 'app/%' AND revision > 0")
+	if len(columns) != len(changesColumns) || columns[0] != "revision" {
+		t.Fatalf("columns = %v", columns)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 events under app/, got %v", rows)
+	}
+	if got := fmt.Sprint(rows[0][:4], rows[1][:4]); got != "[1 PUT app/a v-app/a] [3 PUT app/b v-app/b]" {
+		t.Errorf("Unexpected put events %s", got)
+	}
+	if got := fmt.Sprint(rows[2][:3]); got != "[4 DELETE app/a]" {
+		t.Errorf("Unexpected delete event %s", got)
+	}
+
+	// revision > N returns the events after N
+	_, rows = queryRows(t, h, "SELECT revision, `key` FROM kv_changes WHERE revision > 2 LIMIT 10")
+	if got := fmt.Sprint(rows); got != "[[3 app/b] [4 app/a]]" {
+		t.Errorf("Expected the events after revision 2, got %s", got)
+	}
+	_, rows = queryRows(t, h, "SELECT revision FROM kv_changes WHERE key = 'other' AND revision >= 2")
+	if got := fmt.Sprint(rows); got != "[[2]]" {
+		t.Errorf("Expected the event of the exact key, got %s", got)
+	}
+
+	// Nothing newer: the poll returns empty once the timeout expires
+	_, rows = queryRows(t, h, "SELECT * FROM kv_changes WHERE revision > 4")
+	if len(rows) != 0 {
+		t.Errorf("Expected no events after the current revision, got %v", rows)
+	}
+}
+
+func TestChangesSelect_LongPoll(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	if _, _, err := store.PutWithLease(ctx, "app/a", "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	h.setDefaultChangesTimeout(5 * time.Second)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		store.PutWithLease(ctx, "other", "x", 0)
+		store.PutWithLease(ctx, "app/b", "2", 0)
+	}()
+	start := time.Now()
+	_, rows := queryRows(t, h, "SELECT revision, key, value FROM kv_changes WHERE key LIKE 'app/%'")
+	if got := fmt.Sprint(rows); got != "[[3 app/b 2]]" {
+		t.Fatalf("Expected the next event under app/, got %s", got)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("Poll returned after %v, expected it to return on the event", elapsed)
+	}
+
+	execQuery(t, h, "SET metastore_changes_timeout = 0.1")
+	start = time.Now()
+	_, rows = queryRows(t, h, "SELECT * FROM kv_changes WHERE revision > 3")
+	if len(rows) != 0 {
+		t.Fatalf("Expected an empty result on timeout, got %v", rows)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Poll returned after %v, expected it to wait for the timeout", elapsed)
+	}
+	_, rows = queryRows(t, h, "SELECT @@metastore_changes_timeout")
+	if rows[0][0] != "0.1" {
+		t.Errorf("@@metastore_changes_timeout = %v", rows[0][0])
+	}
+	execQuery(t, h, "SET metastore_changes_timeout = DEFAULT")
+	if h.changesTimeout != 5*time.Second {
+		t.Errorf("Expected DEFAULT to restore the server timeout, got %v", h.changesTimeout)
+	}
+}
+
+// eventStore replays fixed events to every watch
+type eventStore struct {
+	*memory.MemoryEtcd
+	events []kvstore.WatchEvent
+}
+
+func (s *eventStore) Watch(ctx context.Context, key, rangeEnd string, startRevision int64, watchID int64) (<-chan kvstore.WatchEvent, error) {
+	ch := make(chan kvstore.WatchEvent, len(s.events))
+	for _, ev := range s.events {
+		if ev.Revision >= startRevision {
+			ch <- ev
+		}
+	}
+	return ch, nil
+}
+
+func (s *eventStore) CancelWatch(watchID int64) error { return nil }
+
+// TestChangesSelect_Limit tests that LIMIT does not split the events of a revision
+func TestChangesSelect_Limit(t *testing.T) {
+	put := func(rev int64, key string) kvstore.WatchEvent {
+		return kvstore.WatchEvent{Type: kvstore.EventTypePut, Revision: rev,
+			Kv: &kvstore.KeyValue{Key: []byte(key), Value: []byte("v"), ModRevision: rev}}
+	}
+	// One transaction wrote a, b and c at revision 1
+	store := &eventStore{MemoryEtcd: memory.NewMemoryEtcd(),
+		events: []kvstore.WatchEvent{put(1, "a"), put(1, "b"), put(1, "c"), put(2, "d")}}
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	_, rows := queryRows(t, h, "SELECT revision, key FROM kv_changes WHERE revision > 0 LIMIT 2")
+	if got := fmt.Sprint(rows); got != "[[1 a] [1 b] [1 c]]" {
+		t.Errorf("Expected all events of revision 1, got %s", got)
+	}
+	_, rows = queryRows(t, h, "SELECT revision, key FROM kv_changes WHERE revision > 1 LIMIT 2")
+	if got := fmt.Sprint(rows); got != "[[2 d]]" {
+		t.Errorf("Expected the event of revision 2, got %s", got)
+	}
+	_, rows = queryRows(t, h, "SELECT key FROM kv_changes WHERE revision > 0 LIMIT 0")
+	if len(rows) != 0 {
+		t.Errorf("Expected no events with LIMIT 0, got %v", rows)
+	}
+}
+
+func TestChangesSelect_Errors(t *testing.T) {
+	h := NewMySQLHandler(memory.NewMemoryEtcd(), NewAuthProvider("root", ""))
+	for _, query := range []string{
+		"SELECT * FROM kv_changes WHERE value = 'x'",
+		"SELECT * FROM kv_changes WHERE revision < 10",
+		"SELECT * FROM kv_changes WHERE key LIKE '%suffix'",
+		"SELECT * FROM kv_changes WHERE key = 'a' OR key = 'b'",
+		"SELECT bogus FROM kv_changes",
+		"SET metastore_changes_timeout = -1",
+		"SET metastore_changes_timeout = 'soon'",
+	} {
+		if _, err := h.HandleQuery(query); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+	if !isChangesSelect("select * from `kv_changes` where key like 'a%' and revision > 5 limit 10;") {
+		t.Error("Expected a lower case query to be recognized")
+	}
+	if isChangesSelect("SELECT * FROM kv WHERE key = 'kv_changes'") {
+		t.Error("Expected a query on kv not to be recognized")
+	}
+}
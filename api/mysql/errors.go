@@ -15,37 +15,72 @@
 package mysql
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/chunk"
+	"metaStore/pkg/protect"
+	"metaStore/pkg/schema"
+
 	"github.com/go-mysql-org/go-mysql/mysql"
 )
 
 // MySQL standard error codes
 // These error codes are defined by MySQL protocol specification
 // Reference: https://dev.mysql.com/doc/refman/en/server-error-reference.html
+//
+// mysql.NewError fills in the SQLSTATE from go-mysql's MySQLState table
+// (23000 for duplicate entries, 40001 for deadlocks, 70100 for interrupted
+// queries) and falls back to HY000, which is also what MySQL sends for the
+// codes go-mysql does not define
 
 const (
 	// Authentication errors
-	ErrAccessDenied = mysql.ER_ACCESS_DENIED_ERROR // 1045
+	ErrAccessDenied         = mysql.ER_ACCESS_DENIED_ERROR          // 1045
+	ErrSpecificAccessDenied = mysql.ER_SPECIFIC_ACCESS_DENIED_ERROR // 1227
 
 	// Syntax errors
 	ErrSyntaxError = mysql.ER_SYNTAX_ERROR   // 1064
 	ErrParseError  = mysql.ER_PARSE_ERROR    // 1064
 
 	// Command errors
-	ErrUnknownCommand = mysql.ER_UNKNOWN_COM_ERROR // 1047
-	ErrNotSupported   = mysql.ER_NOT_SUPPORTED_YET // 1235
+	ErrUnknownCommand   = mysql.ER_UNKNOWN_COM_ERROR // 1047
+	ErrNotSupported     = mysql.ER_NOT_SUPPORTED_YET // 1235
+	ErrQueryInterrupted = mysql.ER_QUERY_INTERRUPTED // 1317
+
+	// Sessions pinned with metastore_read_revision are read-only
+	ErrReadOnly      = mysql.ER_OPTION_PREVENTS_STATEMENT      // 1290
+	ErrPinnedInTrans = mysql.ER_CANT_CHANGE_TX_CHARACTERISTICS // 1568
 
 	// Data errors
 	ErrKeyNotFound    = mysql.ER_KEY_NOT_FOUND     // 1032
 	ErrDuplicateKey   = mysql.ER_DUP_KEY           // 1022
+	ErrDuplicateEntry = mysql.ER_DUP_ENTRY         // 1062
 	ErrNoSuchTable    = mysql.ER_NO_SUCH_TABLE     // 1146
 	ErrNoSuchDatabase = mysql.ER_BAD_DB_ERROR      // 1049
+	ErrDataTooLong    = mysql.ER_DATA_TOO_LONG     // 1406
+
+	// Invalid metastore_key_encoding values and key literals that do not decode
+	ErrWrongValueForVar    = mysql.ER_WRONG_VALUE_FOR_VAR   // 1231
+	ErrTruncatedWrongValue = mysql.ER_TRUNCATED_WRONG_VALUE // 1292
+
+	// ErrNetPacketTooLarge is returned for writes above limits.max_request_size
+	ErrNetPacketTooLarge = mysql.ER_NET_PACKET_TOO_LARGE // 1153
+
+	// ErrCheckConstraintViolated is not defined by go-mysql (MySQL 8.0.16+)
+	ErrCheckConstraintViolated uint16 = 3819
+
+	// ErrQueryTimeout is not defined by go-mysql (MySQL 5.7.8+)
+	ErrQueryTimeout uint16 = 3024
 
 	// Transaction errors
-	ErrLockWaitTimeout    = mysql.ER_LOCK_WAIT_TIMEOUT    // 1205
-	ErrLockDeadlock       = mysql.ER_LOCK_DEADLOCK        // 1213
-	ErrRollbackOnly       = mysql.ER_UNKNOWN_ERROR        // 1105
+	ErrLockWaitTimeout       = mysql.ER_LOCK_WAIT_TIMEOUT        // 1205
+	ErrLockDeadlock          = mysql.ER_LOCK_DEADLOCK            // 1213
+	ErrRollbackOnly          = mysql.ER_UNKNOWN_ERROR            // 1105
+	ErrTooManyConcurrentTrxs = mysql.ER_TOO_MANY_CONCURRENT_TRXS // 1637
 
 	// Generic errors
 	ErrUnknownError   = mysql.ER_UNKNOWN_ERROR   // 1105
@@ -93,3 +128,68 @@ func NewInternalError(message string) error {
 	msg := fmt.Sprintf("Internal error: %s", message)
 	return mysql.NewError(ErrInternalError, msg)
 }
+
+// NewDuplicateEntryError creates the error for an INSERT of a key that exists
+func NewDuplicateEntryError(key string) error {
+	msg := fmt.Sprintf("Duplicate entry '%s' for key 'kv.PRIMARY'", key)
+	return mysql.NewError(ErrDuplicateEntry, msg)
+}
+
+// NewTxnConflictError creates the error for a transaction whose read set
+// changed before COMMIT, drivers and ORMs retry deadlocks
+func NewTxnConflictError() error {
+	return mysql.NewError(ErrLockDeadlock,
+		"transaction conflict: data was modified by another transaction")
+}
+
+// NewWriteError converts a store write failure into a MySQL error
+func NewWriteError(action string, err error) error {
+	msg := fmt.Sprintf("failed to %s: %v", action, err)
+	// Schema violations
+	if errors.Is(err, schema.ErrInvalidValue) || errors.Is(err, schema.ErrInvalidSchema) {
+		return mysql.NewError(ErrCheckConstraintViolated, msg)
+	}
+	// Value above chunking.max_value_size or the override of its prefix
+	if errors.Is(err, chunk.ErrValueTooLarge) {
+		return mysql.NewError(ErrDataTooLong, msg)
+	}
+	// Proposal above limits.max_request_size
+	if errors.Is(err, kvstore.ErrRequestTooLarge) {
+		return mysql.NewError(ErrNetPacketTooLarge, msg)
+	}
+	// Rejected by an admission hook
+	if errors.Is(err, admission.ErrRejected) {
+		return mysql.NewError(ErrSpecificAccessDenied, msg)
+	}
+	// Delete under a delete_protection prefix in deny mode
+	if errors.Is(err, protect.ErrProtected) {
+		return mysql.NewError(ErrSpecificAccessDenied, msg)
+	}
+	// Propose pipeline saturated, the message carries the retry-after hint
+	if errors.Is(err, kvstore.ErrTooManyRequests) {
+		return mysql.NewError(ErrTooManyConcurrentTrxs, msg)
+	}
+	return newStoreError(msg, err)
+}
+
+// NewReadError converts a store read failure into a MySQL error
+func NewReadError(action string, err error) error {
+	return newStoreError(fmt.Sprintf("failed to %s: %v", action, err), err)
+}
+
+// newStoreError maps the failures reads and writes share
+func newStoreError(msg string, err error) error {
+	// Leader changed or lost while waiting, drivers retry deadlocks
+	if errors.Is(err, kvstore.ErrLeaderChanged) || errors.Is(err, kvstore.ErrNoLeader) {
+		return mysql.NewError(ErrLockDeadlock, msg)
+	}
+	// Deadline, the message names the stage (a write that timed out waiting
+	// for apply may still take effect)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return mysql.NewError(ErrQueryTimeout, msg)
+	}
+	if errors.Is(err, context.Canceled) {
+		return mysql.NewError(ErrQueryInterrupted, msg)
+	}
+	return mysql.NewError(ErrUnknownError, msg)
+}
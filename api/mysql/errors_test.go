@@ -0,0 +1,153 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// expectError fails unless err is a MySQL error with the given code and SQLSTATE
+func expectError(t *testing.T, what string, err error, code uint16, state string) {
+	t.Helper()
+	var myErr *mysql.MyError
+	if !errors.As(err, &myErr) {
+		t.Fatalf("%s: error = %v, want MySQL error %d", what, err, code)
+	}
+	if myErr.Code != code || myErr.State != state {
+		t.Fatalf("%s: error %d (%s) %q, want %d (%s)", what, myErr.Code, myErr.State, myErr.Message, code, state)
+	}
+}
+
+func TestInsertDuplicateEntry(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	value := func(key string) string {
+		t.Helper()
+		resp, err := store.Range(ctx, key, "", 1, 0)
+		if err != nil {
+			t.Fatalf("Range(%s): %v", key, err)
+		}
+		if len(resp.Kvs) == 0 {
+			return ""
+		}
+		return string(resp.Kvs[0].Value)
+	}
+	exec := func(query string) error {
+		_, err := h.HandleQuery(query)
+		return err
+	}
+
+	if err := exec("INSERT INTO kv (key, value) VALUES ('a', '1')"); err != nil {
+		t.Fatalf("INSERT: %v", err)
+	}
+	err := exec("INSERT INTO kv (key, value) VALUES ('a', '2')")
+	expectError(t, "INSERT of an existing key", err, ErrDuplicateEntry, "23000")
+	if got := value("a"); got != "1" {
+		t.Fatalf("a = %q after the failed INSERT, want 1", got)
+	}
+
+	// A multi-row INSERT fails as a whole
+	err = exec("INSERT INTO kv (key, value) VALUES ('b', '1'), ('a', '3')")
+	expectError(t, "multi-row INSERT of an existing key", err, ErrDuplicateEntry, "23000")
+	if got := value("b"); got != "" {
+		t.Fatalf("b = %q after the failed INSERT, want absent", got)
+	}
+	err = exec("INSERT INTO kv (key, value) VALUES ('c', '1'), ('c', '2')")
+	expectError(t, "INSERT of the same key twice", err, ErrDuplicateEntry, "23000")
+
+	// REPLACE overwrites
+	if err := exec("REPLACE INTO kv (key, value) VALUES ('a', '4')"); err != nil {
+		t.Fatalf("REPLACE: %v", err)
+	}
+	if got := value("a"); got != "4" {
+		t.Fatalf("a = %q after REPLACE, want 4", got)
+	}
+
+	// A key that was deleted can be inserted again
+	if err := exec("DELETE FROM kv WHERE key = 'a'"); err != nil {
+		t.Fatalf("DELETE: %v", err)
+	}
+	if err := exec("INSERT INTO kv (key, value) VALUES ('a', '5')"); err != nil {
+		t.Fatalf("INSERT after DELETE: %v", err)
+	}
+}
+
+func TestTransactionDuplicateEntry(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	other := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	run := func(h *MySQLHandler, queries ...string) error {
+		t.Helper()
+		for i, q := range queries {
+			if _, err := h.HandleQuery(q); err != nil {
+				if i == len(queries)-1 {
+					return err
+				}
+				t.Fatalf("%s: %v", q, err)
+			}
+		}
+		return nil
+	}
+
+	// Another client inserts the key before COMMIT
+	if err := run(h, "BEGIN", "INSERT INTO kv (key, value) VALUES ('k', '1')"); err != nil {
+		t.Fatalf("INSERT in transaction: %v", err)
+	}
+	if err := run(other, "INSERT INTO kv (key, value) VALUES ('k', '2')"); err != nil {
+		t.Fatalf("concurrent INSERT: %v", err)
+	}
+	expectError(t, "COMMIT", run(h, "COMMIT"), ErrDuplicateEntry, "23000")
+
+	// DELETE then INSERT of the same key in one transaction succeeds
+	err := run(h, "BEGIN",
+		"DELETE FROM kv WHERE key = 'k'",
+		"INSERT INTO kv (key, value) VALUES ('k', '3')",
+		"COMMIT")
+	if err != nil {
+		t.Fatalf("DELETE and INSERT in transaction: %v", err)
+	}
+}
+
+func TestStoreErrorCodes(t *testing.T) {
+	tests := []struct {
+		err   error
+		code  uint16
+		state string
+	}{
+		{fmt.Errorf("apply: %w", context.DeadlineExceeded), ErrQueryTimeout, "HY000"},
+		{context.Canceled, ErrQueryInterrupted, "70100"},
+		{kvstore.ErrLeaderChanged, ErrLockDeadlock, "40001"},
+		{kvstore.ErrNoLeader, ErrLockDeadlock, "40001"},
+		{kvstore.ErrRequestTooLarge, ErrNetPacketTooLarge, "08S01"},
+		{errors.New("disk failure"), ErrUnknownError, "HY000"},
+	}
+	for _, tt := range tests {
+		expectError(t, fmt.Sprintf("NewWriteError(%v)", tt.err), NewWriteError("put", tt.err), tt.code, tt.state)
+	}
+
+	expectError(t, "NewReadError(deadline)", NewReadError("query", context.DeadlineExceeded), ErrQueryTimeout, "HY000")
+	expectError(t, "NewTxnConflictError", NewTxnConflictError(), ErrLockDeadlock, "40001")
+}
@@ -19,8 +19,11 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"metaStore/internal/kvstore"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/keycodec"
 	"metaStore/pkg/log"
 
 	"github.com/go-mysql-org/go-mysql/mysql"
@@ -34,6 +37,18 @@ type MySQLHandler struct {
 	authProvider *AuthProvider
 	user         string
 	password     string
+	users        UserStore // set when the connection authenticated against the user store
+	usage        UsageReporter
+	keyCodec     keycodec.Codec // key encoding of this session (SET metastore_key_encoding)
+	lockConfig   LockConfig     // row locks of SELECT ... FOR UPDATE
+	readRevision int64          // revision reads are pinned at (SET metastore_read_revision), 0 for the latest
+	defaultRev   int64          // server.read_revision, restored by SET metastore_read_revision = DEFAULT
+	replica      bool           // read replica (raft.node_role: replica): serializable reads, no writes
+
+	// How long a SELECT on kv_changes waits for an event (SET metastore_changes_timeout),
+	// and mysql.changes_timeout, restored by SET metastore_changes_timeout = DEFAULT
+	changesTimeout        time.Duration
+	defaultChangesTimeout time.Duration
 
 	// Transaction support (per-connection)
 	txMu         sync.Mutex
@@ -47,6 +62,8 @@ type Transaction struct {
 	startRev    int64             // Snapshot revision at BEGIN
 	operations  []TxOp            // Buffered operations
 	readSet     map[string]int64  // Key -> ModRevision for conflict detection
+	lockLease   int64             // Lease of the locks taken by SELECT ... FOR UPDATE
+	locks       map[string]bool   // Keys locked by SELECT ... FOR UPDATE
 }
 
 // TxOp represents a transaction operation
@@ -78,8 +95,15 @@ func (h *MySQLHandler) UseDB(dbName string) error {
 }
 
 // HandleQuery handles SQL query commands
-func (h *MySQLHandler) HandleQuery(query string) (*mysql.Result, error) {
-	ctx := context.Background()
+func (h *MySQLHandler) HandleQuery(query string) (result *mysql.Result, err error) {
+	defer h.recoverQuery(query, &err)
+
+	// Admission hooks see the connection's user, proposals use the mysql batch queue
+	ctx := admission.WithUser(kvstore.WithOrigin(context.Background(), kvstore.OriginMySQL), h.user)
+	if h.replica {
+		// A read replica serves its local state without asking the leader
+		ctx = kvstore.WithSerializable(ctx)
+	}
 	query = strings.TrimSpace(query)
 	queryUpper := strings.ToUpper(query)
 
@@ -88,12 +112,19 @@ func (h *MySQLHandler) HandleQuery(query string) (*mysql.Result, error) {
 		zap.String("query_upper", queryUpper),
 		zap.String("component", "mysql"))
 
+	// A session pinned at a revision or on a read replica is read-only
+	if h.readOnly() && isWriteStatement(queryUpper) {
+		return nil, h.readOnlyError()
+	}
+
 	// Parse and execute query
 	switch {
 	case strings.HasPrefix(queryUpper, "SELECT"):
 		return h.handleSelect(ctx, query)
 	case strings.HasPrefix(queryUpper, "INSERT"):
-		return h.handleInsert(ctx, query)
+		return h.handleInsert(ctx, query, false)
+	case strings.HasPrefix(queryUpper, "REPLACE"):
+		return h.handleInsert(ctx, query, true)
 	case strings.HasPrefix(queryUpper, "UPDATE"):
 		return h.handleUpdate(ctx, query)
 	case strings.HasPrefix(queryUpper, "DELETE"):
@@ -109,18 +140,25 @@ func (h *MySQLHandler) HandleQuery(query string) (*mysql.Result, error) {
 		return h.handleRollback(ctx)
 	case strings.HasPrefix(queryUpper, "SHOW DATABASES"):
 		return h.handleShowDatabases(ctx)
+	case strings.HasPrefix(queryUpper, "SHOW TABLES FROM INFORMATION_SCHEMA") ||
+		strings.HasPrefix(queryUpper, "SHOW TABLES IN INFORMATION_SCHEMA"):
+		return h.handleShowInfoSchemaTables(ctx)
 	case strings.HasPrefix(queryUpper, "SHOW TABLES"):
 		return h.handleShowTables(ctx)
+	case strings.HasPrefix(queryUpper, "CREATE INDEX") || strings.HasPrefix(queryUpper, "CREATE UNIQUE INDEX"):
+		return h.handleCreateIndex(ctx, query)
+	case strings.HasPrefix(queryUpper, "DROP INDEX"):
+		return h.handleDropIndex(ctx, query)
+	case showIndexRe.MatchString(query):
+		return h.handleShowIndex(ctx)
+	case showStatusRe.MatchString(query):
+		return h.handleShowStatus(ctx, query)
 	case strings.HasPrefix(queryUpper, "DESCRIBE") || strings.HasPrefix(queryUpper, "DESC"):
 		return h.handleDescribe(ctx, query)
 	case queryUpper == "PING" || strings.HasPrefix(queryUpper, "SELECT 1"):
 		return h.handlePing(ctx)
 	case strings.HasPrefix(queryUpper, "SET"):
-		// Accept SET commands for compatibility (usually SET autocommit, etc.)
-		return &mysql.Result{
-			Status:       0,
-			AffectedRows: 0,
-		}, nil
+		return h.handleSet(query)
 	default:
 		log.Warn("Unsupported SQL command",
 			zap.String("query", query),
@@ -262,15 +300,24 @@ func (h *MySQLHandler) createTransaction() *Transaction {
 		readSet:    make(map[string]int64),
 		startRev:   h.store.CurrentRevision(),
 	}
+	if h.readRevision != 0 {
+		tx.startRev = h.readRevision
+	}
 	h.transaction = tx
 	return tx
 }
 
 // removeTransaction removes the current transaction for this connection
+// and releases its locks
 func (h *MySQLHandler) removeTransaction() {
 	h.txMu.Lock()
-	defer h.txMu.Unlock()
+	tx := h.transaction
 	h.transaction = nil
+	h.txMu.Unlock()
+
+	if tx != nil {
+		h.releaseLocks(tx)
+	}
 }
 
 // Helper methods
@@ -331,11 +378,23 @@ func (h *MySQLHandler) handleCommit(ctx context.Context) (*mysql.Result, error)
 		})
 	}
 
-	// Build operations to apply
+	// Locks taken over after their lease expired no longer protect the reads
+	cmps = append(cmps, lockCompares(tx)...)
+
+	// Build operations to apply. An INSERT also requires its key to be absent,
+	// unless an earlier statement of this transaction already wrote the key
 	thenOps := make([]kvstore.Op, 0, len(tx.operations))
+	written := make(map[string]bool, len(tx.operations))
+	var insertKeys []string
 	for _, op := range tx.operations {
+		if op.OpType == "INSERT" && !written[op.Key] {
+			cmps = append(cmps, absentCompare(op.Key))
+			insertKeys = append(insertKeys, op.Key)
+		}
+		written[op.Key] = true
+
 		switch op.OpType {
-		case "PUT":
+		case "PUT", "INSERT":
 			thenOps = append(thenOps, kvstore.Op{
 				Type:  kvstore.OpPut,
 				Key:   []byte(op.Key),
@@ -356,18 +415,19 @@ func (h *MySQLHandler) handleCommit(ctx context.Context) (*mysql.Result, error)
 		log.Error("Transaction commit failed",
 			zap.Error(err),
 			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
-			fmt.Sprintf("transaction commit failed: %v", err))
+		return nil, NewWriteError("commit transaction", err)
 	}
 
 	// Check if transaction succeeded (all comparisons passed)
 	if !txnResp.Succeeded {
 		h.removeTransaction()
+		if dup := h.existingKey(ctx, insertKeys); dup != "" {
+			return nil, h.duplicateEntryError(dup)
+		}
 		log.Warn("Transaction conflict detected",
 			zap.Int("read_set_size", len(tx.readSet)),
 			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_LOCK_DEADLOCK,
-			"transaction conflict: data was modified by another transaction")
+		return nil, NewTxnConflictError()
 	}
 
 	// Success - clean up transaction
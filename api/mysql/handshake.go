@@ -0,0 +1,595 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"metaStore/pkg/log"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/packet"
+	"go.uber.org/zap"
+)
+
+// The user store only keeps password verifiers, while go-mysql needs the plaintext
+// password to check a client's scramble. We therefore run the connection phase
+// ourselves for every connection: upgrade to TLS when the client asks, verify the
+// mysql_native_password scramble or the caching_sha2_password exchange against
+// SHA1(SHA1(password)), and then let go-mysql complete its own handshake locally
+// (see authenticatedConn) before handing the connection over.
+
+// handshakeServerVersion matches the version announced by go-mysql's default server
+const handshakeServerVersion = "8.0.11"
+
+// handshakeCapability capabilities announced to clients, CLIENT_SSL is added by the handshaker
+const handshakeCapability = mysql.CLIENT_LONG_PASSWORD | mysql.CLIENT_LONG_FLAG |
+	mysql.CLIENT_CONNECT_WITH_DB | mysql.CLIENT_PROTOCOL_41 | mysql.CLIENT_TRANSACTIONS |
+	mysql.CLIENT_SECURE_CONNECTION | mysql.CLIENT_PLUGIN_AUTH |
+	mysql.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA | mysql.CLIENT_CONNECT_ATTRS
+
+// caching_sha2_password AuthMoreData packets
+const (
+	authMoreDataHeader       = 0x01
+	cachingSha2RequestPubKey = 0x02 // client: send me the RSA public key
+	cachingSha2FastAuthOK    = 0x03 // server: scramble matched the cached digest
+	cachingSha2FullAuth      = 0x04 // server: send the password (clear over TLS, RSA encrypted otherwise)
+)
+
+// errSecureTransportRequired is ER_SECURE_TRANSPORT_REQUIRED
+const errSecureTransportRequired = 3159
+
+// passwordVerifiers looks up SHA1(SHA1(password)) of a user, the verifier both
+// authentication plugins are checked against. Implemented by the static
+// AuthProvider and the etcd user store.
+type passwordVerifiers interface {
+	MySQLPasswordHash(username string) ([]byte, error)
+}
+
+// handshakeResponse is the part of the client's HandshakeResponse41 we keep
+type handshakeResponse struct {
+	capability uint32
+	charset    byte
+	user       string
+	authData   []byte
+	db         string
+	plugin     string
+
+	conn   net.Conn     // client connection, a *tls.Conn after an SSL request
+	pc     *packet.Conn // tracks the packet sequence of the connection phase on conn
+	secure bool         // the connection switched to TLS
+}
+
+// handshaker runs the connection phase of every connection
+type handshaker struct {
+	plugin        string          // auth plugin announced in the initial handshake
+	tlsConfig     *tls.Config     // certificate offered to clients sending an SSL request
+	requireSecure bool            // reject connections that stay in plain text
+	rsaKey        *rsa.PrivateKey // decrypts caching_sha2_password passwords sent without TLS
+	publicKey     []byte          // PEM of the public half of rsaKey, sent on request
+
+	// sha2Cache holds SHA256(SHA256(password)) of users that completed a full
+	// caching_sha2_password authentication, so their next logins take the fast path
+	sha2Cache sync.Map // username -> sha2CacheEntry
+}
+
+type sha2CacheEntry struct {
+	verifier []byte   // verifier at caching time; a password change invalidates the entry
+	digest   [32]byte // SHA256(SHA256(password))
+}
+
+// autoCertificate is the self-signed certificate used when no TLS certificate is
+// configured, generated once per process like MySQL's auto_generate_certs
+var autoCertificate = sync.OnceValues(generateCertificate)
+
+func newHandshaker(plugin string, tlsConfig *tls.Config, requireSecure bool) (*handshaker, error) {
+	switch plugin {
+	case "":
+		plugin = mysql.AUTH_CACHING_SHA2_PASSWORD
+	case mysql.AUTH_CACHING_SHA2_PASSWORD, mysql.AUTH_NATIVE_PASSWORD:
+	default:
+		return nil, fmt.Errorf("unsupported auth plugin %q", plugin)
+	}
+
+	if tlsConfig == nil {
+		cert, err := autoCertificate()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+	// caching_sha2_password needs an RSA key; certificates with other keys fall
+	// back to the key of the self-signed certificate
+	rsaKey, ok := tlsConfig.Certificates[0].PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		cert, err := autoCertificate()
+		if err != nil {
+			return nil, err
+		}
+		rsaKey = cert.PrivateKey.(*rsa.PrivateKey)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handshaker{
+		plugin:        plugin,
+		tlsConfig:     tlsConfig,
+		requireSecure: requireSecure,
+		rsaKey:        rsaKey,
+		publicKey:     pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+	}, nil
+}
+
+// authenticate runs the connection phase with the client and checks its credentials
+// against users. On failure an error packet has already been sent.
+func (h *handshaker) authenticate(conn net.Conn, connID uint32, users passwordVerifiers) (*handshakeResponse, error) {
+	pc := packet.NewConn(conn)
+	salt := mysql.RandomBuf(20)
+	if err := writeInitialHandshake(pc, connID, salt, handshakeCapability|mysql.CLIENT_SSL, h.plugin); err != nil {
+		return nil, err
+	}
+
+	data, sequence, err := readUnbuffered(conn)
+	if err != nil {
+		return nil, err
+	}
+	secure := false
+	if isSSLRequest(data) {
+		tlsConn := tls.Server(conn, h.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		// The handshake response follows the SSL request in the same sequence
+		conn, secure = tlsConn, true
+		if data, sequence, err = readUnbuffered(conn); err != nil {
+			return nil, err
+		}
+	}
+	pc = packet.NewConn(conn)
+	pc.Sequence = sequence + 1
+
+	resp, err := parseHandshakeResponse(data)
+	if err != nil {
+		_ = writeErrorPacket(pc, mysql.NewDefaultError(mysql.ER_HANDSHAKE_ERROR))
+		return nil, err
+	}
+	resp.conn, resp.pc, resp.secure = conn, pc, secure
+
+	if h.requireSecure && !secure {
+		_ = writeErrorPacket(pc, mysql.NewError(errSecureTransportRequired,
+			"Connections using insecure transport are prohibited while --require_secure_transport=ON."))
+		return nil, fmt.Errorf("insecure transport is not allowed")
+	}
+
+	// Clients may answer with either supported plugin, others are asked to switch
+	if resp.plugin != mysql.AUTH_NATIVE_PASSWORD && resp.plugin != mysql.AUTH_CACHING_SHA2_PASSWORD {
+		if err := writeAuthSwitchRequest(pc, h.plugin, salt); err != nil {
+			return nil, err
+		}
+		if resp.authData, err = pc.ReadPacket(); err != nil {
+			return nil, err
+		}
+		resp.plugin = h.plugin
+	}
+
+	verifier, err := users.MySQLPasswordHash(resp.user)
+	if err == nil {
+		if resp.plugin == mysql.AUTH_NATIVE_PASSWORD {
+			if !checkNativePassword(salt, resp.authData, verifier) {
+				err = fmt.Errorf("invalid password")
+			}
+		} else {
+			err = h.cachingSha2(resp, salt, verifier)
+		}
+	}
+	if err != nil {
+		log.Warn("MySQL authentication failed",
+			zap.String("username", resp.user),
+			zap.String("remote_addr", pc.RemoteAddr().String()),
+			zap.Error(err),
+			zap.String("component", "mysql"))
+		usingPassword := mysql.MySQLErrName[mysql.ER_YES]
+		if len(resp.authData) == 0 {
+			usingPassword = mysql.MySQLErrName[mysql.ER_NO]
+		}
+		_ = writeErrorPacket(pc, mysql.NewDefaultError(mysql.ER_ACCESS_DENIED_ERROR,
+			resp.user, hostOf(pc.RemoteAddr()), usingPassword))
+		return nil, err
+	}
+	return resp, nil
+}
+
+// cachingSha2 completes caching_sha2_password authentication. A user in the cache
+// is checked against the scramble (fast path); otherwise the client is asked for
+// its password, sent in clear over TLS or RSA encrypted without it (full path).
+func (h *handshaker) cachingSha2(resp *handshakeResponse, salt, verifier []byte) error {
+	// An empty password is sent as empty auth data and needs no exchange
+	if len(resp.authData) == 0 {
+		if !checkPlainPassword(nil, verifier) {
+			return fmt.Errorf("invalid password")
+		}
+		return nil
+	}
+
+	if v, ok := h.sha2Cache.Load(resp.user); ok {
+		entry := v.(sha2CacheEntry)
+		if bytes.Equal(entry.verifier, verifier) && checkSha2Scramble(salt, resp.authData, entry.digest) {
+			return writeAuthMoreData(resp.pc, cachingSha2FastAuthOK)
+		}
+	}
+
+	if err := writeAuthMoreData(resp.pc, cachingSha2FullAuth); err != nil {
+		return err
+	}
+	data, err := resp.pc.ReadPacket()
+	if err != nil {
+		return err
+	}
+	var password []byte
+	if resp.secure {
+		password = bytes.TrimSuffix(data, []byte{0})
+	} else {
+		if len(data) == 1 && data[0] == cachingSha2RequestPubKey {
+			if err := writeAuthMoreData(resp.pc, h.publicKey...); err != nil {
+				return err
+			}
+			if data, err = resp.pc.ReadPacket(); err != nil {
+				return err
+			}
+		}
+		plain, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, h.rsaKey, data, nil)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt password: %w", err)
+		}
+		for i := range plain {
+			plain[i] ^= salt[i%len(salt)]
+		}
+		password = bytes.TrimSuffix(plain, []byte{0})
+	}
+
+	if !checkPlainPassword(password, verifier) {
+		return fmt.Errorf("invalid password")
+	}
+	stage1 := sha256.Sum256(password)
+	h.sha2Cache.Store(resp.user, sha2CacheEntry{verifier: verifier, digest: sha256.Sum256(stage1[:])})
+	return nil
+}
+
+// checkPlainPassword checks a password received in full against the verifier
+func checkPlainPassword(password, verifier []byte) bool {
+	stage1 := sha1.Sum(password)
+	stage2 := sha1.Sum(stage1[:])
+	return bytes.Equal(stage2[:], verifier)
+}
+
+// checkSha2Scramble verifies a caching_sha2_password scramble against the cached
+// digest: SHA256(password) = scramble XOR SHA256(digest + salt), and
+// SHA256(SHA256(password)) must equal the digest
+func checkSha2Scramble(salt, scramble []byte, digest [32]byte) bool {
+	if len(scramble) != sha256.Size {
+		return false
+	}
+	h := sha256.New()
+	h.Write(digest[:])
+	h.Write(salt)
+	stage1 := h.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= scramble[i]
+	}
+	return sha256.Sum256(stage1) == digest
+}
+
+// checkNativePassword verifies a mysql_native_password scramble against the stored
+// verifier: SHA1(password) = scramble XOR SHA1(salt + verifier), and
+// SHA1(SHA1(password)) must equal the verifier
+func checkNativePassword(salt, scramble, verifier []byte) bool {
+	if len(scramble) == 0 {
+		empty := sha1.Sum(nil)
+		emptyVerifier := sha1.Sum(empty[:])
+		return bytes.Equal(verifier, emptyVerifier[:])
+	}
+	if len(scramble) != sha1.Size {
+		return false
+	}
+
+	h := sha1.New()
+	h.Write(salt)
+	h.Write(verifier)
+	stage1 := h.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= scramble[i]
+	}
+	candidate := sha1.Sum(stage1)
+	return bytes.Equal(candidate[:], verifier)
+}
+
+// writeInitialHandshake sends a Protocol::HandshakeV10 packet
+func writeInitialHandshake(pc *packet.Conn, connID uint32, salt []byte, capability uint32, plugin string) error {
+	data := make([]byte, 4, 128)
+	data = append(data, 10)
+	data = append(data, handshakeServerVersion...)
+	data = append(data, 0)
+	data = binary.LittleEndian.AppendUint32(data, connID)
+	data = append(data, salt[:8]...)
+	data = append(data, 0)
+	data = binary.LittleEndian.AppendUint16(data, uint16(capability&0xffff))
+	data = append(data, mysql.DEFAULT_COLLATION_ID)
+	data = binary.LittleEndian.AppendUint16(data, mysql.SERVER_STATUS_AUTOCOMMIT)
+	data = binary.LittleEndian.AppendUint16(data, uint16(capability>>16))
+	data = append(data, byte(len(salt)+1))
+	data = append(data, make([]byte, 10)...)
+	data = append(data, salt[8:]...)
+	data = append(data, 0)
+	data = append(data, plugin...)
+	data = append(data, 0)
+	return pc.WritePacket(data)
+}
+
+// writeAuthSwitchRequest asks the client to authenticate with another plugin
+func writeAuthSwitchRequest(pc *packet.Conn, plugin string, salt []byte) error {
+	data := make([]byte, 4, 4+1+len(plugin)+1+len(salt)+1)
+	data = append(data, mysql.EOF_HEADER)
+	data = append(data, plugin...)
+	data = append(data, 0)
+	data = append(data, salt...)
+	data = append(data, 0)
+	return pc.WritePacket(data)
+}
+
+// writeAuthMoreData sends a Protocol::AuthMoreData packet
+func writeAuthMoreData(pc *packet.Conn, payload ...byte) error {
+	data := make([]byte, 4, 4+1+len(payload))
+	data = append(data, authMoreDataHeader)
+	data = append(data, payload...)
+	return pc.WritePacket(data)
+}
+
+// readUnbuffered reads one packet straight from conn. packet.Conn reads ahead
+// through a buffer, which could swallow the TLS ClientHello a client sends right
+// after its SSL request.
+func readUnbuffered(conn net.Conn) (data []byte, sequence uint8, err error) {
+	var header [4]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return nil, 0, err
+	}
+	data = make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, 0, err
+	}
+	return data, header[3], nil
+}
+
+// isSSLRequest reports whether a client packet is a Protocol::SSLRequest, the
+// truncated handshake response sent before switching to TLS
+func isSSLRequest(data []byte) bool {
+	return len(data) == 4+4+1+23 && binary.LittleEndian.Uint32(data)&mysql.CLIENT_SSL != 0
+}
+
+// parseHandshakeResponse decodes a Protocol::HandshakeResponse41 packet
+func parseHandshakeResponse(data []byte) (resp *handshakeResponse, err error) {
+	defer func() {
+		if recover() != nil {
+			resp, err = nil, fmt.Errorf("malformed handshake response")
+		}
+	}()
+
+	resp = &handshakeResponse{capability: binary.LittleEndian.Uint32(data)}
+	if resp.capability&mysql.CLIENT_PROTOCOL_41 == 0 || resp.capability&mysql.CLIENT_SECURE_CONNECTION == 0 {
+		return nil, fmt.Errorf("CLIENT_PROTOCOL_41 and CLIENT_SECURE_CONNECTION are required")
+	}
+	pos := 4 + 4 // capability, max packet size
+	resp.charset = data[pos]
+	pos += 1 + 23
+
+	end := bytes.IndexByte(data[pos:], 0)
+	resp.user = string(data[pos : pos+end])
+	pos += end + 1
+
+	if resp.capability&mysql.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA != 0 {
+		auth, _, n, err := mysql.LengthEncodedString(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		resp.authData = auth
+		pos += n
+	} else {
+		n := int(data[pos])
+		resp.authData = data[pos+1 : pos+1+n]
+		pos += 1 + n
+	}
+
+	if resp.capability&mysql.CLIENT_CONNECT_WITH_DB != 0 && pos < len(data) {
+		end := bytes.IndexByte(data[pos:], 0)
+		resp.db = string(data[pos : pos+end])
+		pos += end + 1
+	}
+
+	resp.plugin = mysql.AUTH_NATIVE_PASSWORD
+	if resp.capability&mysql.CLIENT_PLUGIN_AUTH != 0 && pos < len(data) {
+		end := bytes.IndexByte(data[pos:], 0)
+		if end < 0 {
+			end = len(data) - pos
+		}
+		resp.plugin = string(data[pos : pos+end])
+	}
+	return resp, nil
+}
+
+// writeOKPacket completes the connection phase
+func writeOKPacket(pc *packet.Conn) error {
+	data := make([]byte, 4, 11)
+	data = append(data, mysql.OK_HEADER, 0, 0)
+	data = binary.LittleEndian.AppendUint16(data, mysql.SERVER_STATUS_AUTOCOMMIT)
+	data = append(data, 0, 0)
+	return pc.WritePacket(data)
+}
+
+func writeErrorPacket(pc *packet.Conn, e *mysql.MyError) error {
+	data := make([]byte, 4, 16+len(e.Message))
+	data = append(data, mysql.ERR_HEADER)
+	data = binary.LittleEndian.AppendUint16(data, e.Code)
+	data = append(data, '#')
+	data = append(data, e.State...)
+	data = append(data, e.Message...)
+	return pc.WritePacket(data)
+}
+
+func hostOf(addr net.Addr) string {
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+	return addr.String()
+}
+
+// authenticatedConn lets go-mysql run its handshake on a connection that was already
+// authenticated. Until handed over, everything go-mysql writes is dropped, and its
+// initial handshake is answered with a response for the same user signed with a
+// one-time password, so go-mysql's own check passes without the real password.
+type authenticatedConn struct {
+	net.Conn
+	resp     *handshakeResponse
+	password string
+
+	answered  bool
+	pending   []byte
+	handedOff bool
+}
+
+func newAuthenticatedConn(resp *handshakeResponse) *authenticatedConn {
+	return &authenticatedConn{
+		Conn:     resp.conn,
+		resp:     resp,
+		password: string(mysql.RandomBuf(20)),
+	}
+}
+
+// handOff switches the connection to pass-through once go-mysql finished its handshake
+func (c *authenticatedConn) handOff() {
+	c.handedOff = true
+}
+
+func (c *authenticatedConn) Read(b []byte) (int, error) {
+	if c.handedOff {
+		return c.Conn.Read(b)
+	}
+	if len(c.pending) == 0 {
+		return 0, fmt.Errorf("unexpected read during local handshake")
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *authenticatedConn) Write(b []byte) (int, error) {
+	if c.handedOff {
+		return c.Conn.Write(b)
+	}
+	// The first packet is go-mysql's initial handshake, which carries its salt
+	if !c.answered {
+		salt, err := handshakeSalt(b[4:])
+		if err != nil {
+			return 0, err
+		}
+		c.pending = c.localResponse(salt)
+		c.answered = true
+	}
+	return len(b), nil
+}
+
+// localResponse builds the HandshakeResponse41 packet (sequence 1) fed to go-mysql
+func (c *authenticatedConn) localResponse(salt []byte) []byte {
+	capability := c.resp.capability & handshakeCapability
+	capability &^= mysql.CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA | mysql.CLIENT_CONNECT_ATTRS | mysql.CLIENT_CONNECT_WITH_DB
+	capability |= mysql.CLIENT_PROTOCOL_41 | mysql.CLIENT_SECURE_CONNECTION | mysql.CLIENT_PLUGIN_AUTH
+	if c.resp.db != "" {
+		capability |= mysql.CLIENT_CONNECT_WITH_DB
+	}
+
+	scramble := mysql.CalcPassword(salt, []byte(c.password))
+
+	data := make([]byte, 4, 128)
+	data = binary.LittleEndian.AppendUint32(data, capability)
+	data = binary.LittleEndian.AppendUint32(data, uint32(mysql.MaxPayloadLen))
+	data = append(data, c.resp.charset)
+	data = append(data, make([]byte, 23)...)
+	data = append(data, c.resp.user...)
+	data = append(data, 0)
+	data = append(data, byte(len(scramble)))
+	data = append(data, scramble...)
+	if c.resp.db != "" {
+		data = append(data, c.resp.db...)
+		data = append(data, 0)
+	}
+	data = append(data, mysql.AUTH_NATIVE_PASSWORD...)
+	data = append(data, 0)
+
+	length := len(data) - 4
+	data[0], data[1], data[2], data[3] = byte(length), byte(length>>8), byte(length>>16), 1
+	return data
+}
+
+// generateCertificate creates a self-signed RSA certificate for TLS and for the
+// RSA exchange of caching_sha2_password
+func generateCertificate() (tls.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "MetaStore_Auto_Generated_Server_Certificate"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// handshakeSalt extracts the 20-byte scramble from a HandshakeV10 payload
+func handshakeSalt(payload []byte) ([]byte, error) {
+	end := bytes.IndexByte(payload[1:], 0)
+	if payload[0] != 10 || end < 0 {
+		return nil, fmt.Errorf("unexpected initial handshake")
+	}
+	pos := 1 + end + 1 + 4 // protocol version, server version, connection id
+	if len(payload) < pos+8+1+2+1+2+2+1+10+12 {
+		return nil, fmt.Errorf("short initial handshake")
+	}
+	salt := append([]byte{}, payload[pos:pos+8]...)
+	pos += 8 + 1 + 2 + 1 + 2 + 2 + 1 + 10
+	return append(salt, payload[pos:pos+12]...), nil
+}
@@ -0,0 +1,543 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"metaStore/api/etcd"
+	"metaStore/api/mysql/parser"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+	"metaStore/pkg/sqlindex"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+)
+
+// valueSelectLimit caps the rows returned by a filtered query without LIMIT
+const valueSelectLimit = 1000
+
+// showIndexRe matches SHOW INDEX|INDEXES|KEYS FROM|IN kv
+var showIndexRe = regexp.MustCompile("(?is)^SHOW\\s+(?:INDEX|INDEXES|KEYS)\\s+(?:FROM|IN)\\s+`?kv`?\\s*;?$")
+
+// handleCreateIndex handles CREATE INDEX on the value column or a JSON path within it.
+// The statement blocks until every node maintains the index and existing keys are backfilled.
+func (h *MySQLHandler) handleCreateIndex(ctx context.Context, query string) (*mysql.Result, error) {
+	plan, err := h.parseQuery(query)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_PARSE_ERROR, err.Error())
+	}
+	if plan.Type != parser.QueryTypeCreateIndex {
+		return nil, mysql.NewError(mysql.ER_PARSE_ERROR, "invalid CREATE INDEX syntax")
+	}
+	if err := h.checkIndexDDL("CREATE INDEX", plan.TableName); err != nil {
+		return nil, err
+	}
+
+	def, err := sqlindex.Create(ctx, h.store, sqlindex.Definition{
+		Name:     plan.IndexName,
+		JSONPath: plan.IndexJSONPath,
+	})
+	switch {
+	case errors.Is(err, sqlindex.ErrExists) && plan.IfExists:
+		return &mysql.Result{Status: 0}, nil
+	case errors.Is(err, sqlindex.ErrExists):
+		return nil, mysql.NewError(mysql.ER_DUP_KEYNAME,
+			fmt.Sprintf("Duplicate key name '%s'", plan.IndexName))
+	case err != nil:
+		log.Error("Failed to create secondary index",
+			zap.Error(err),
+			zap.String("index", plan.IndexName),
+			zap.String("component", "mysql"))
+		return nil, NewWriteError("create index", err)
+	}
+
+	log.Info("Secondary index created",
+		zap.String("index", def.Name),
+		zap.String("expression", def.Expression()),
+		zap.String("component", "mysql"))
+	return &mysql.Result{Status: 0}, nil
+}
+
+// handleDropIndex handles DROP INDEX name ON kv
+func (h *MySQLHandler) handleDropIndex(ctx context.Context, query string) (*mysql.Result, error) {
+	plan, err := h.parseQuery(query)
+	if err != nil {
+		return nil, mysql.NewError(mysql.ER_PARSE_ERROR, err.Error())
+	}
+	if plan.Type != parser.QueryTypeDropIndex {
+		return nil, mysql.NewError(mysql.ER_PARSE_ERROR, "invalid DROP INDEX syntax")
+	}
+	if err := h.checkIndexDDL("DROP INDEX", plan.TableName); err != nil {
+		return nil, err
+	}
+
+	err = sqlindex.Drop(ctx, h.store, plan.IndexName)
+	switch {
+	case errors.Is(err, sqlindex.ErrNotFound) && plan.IfExists:
+		return &mysql.Result{Status: 0}, nil
+	case errors.Is(err, sqlindex.ErrNotFound):
+		return nil, mysql.NewError(mysql.ER_CANT_DROP_FIELD_OR_KEY,
+			fmt.Sprintf("Can't DROP '%s'; check that column/key exists", plan.IndexName))
+	case err != nil:
+		return nil, NewWriteError("drop index", err)
+	}
+	return &mysql.Result{Status: 0}, nil
+}
+
+// checkIndexDDL validates the target table and that writes maintain indexes on this store
+func (h *MySQLHandler) checkIndexDDL(command, table string) error {
+	if table != "kv" {
+		return mysql.NewError(mysql.ER_NO_SUCH_TABLE,
+			fmt.Sprintf("Table 'metastore.%s' doesn't exist", table))
+	}
+	if _, ok := kvstore.As[sqlindex.Maintainer](h.store); !ok {
+		return mysql.NewError(mysql.ER_NOT_SUPPORTED_YET,
+			"secondary indexes are not maintained by this store")
+	}
+	// Index definitions live under the system prefix, which only root can normally write
+	return h.checkPermission(command, kvstore.SystemKeyPrefix+"sqlindex/", etcd.PermissionWrite)
+}
+
+// handleShowIndex handles SHOW INDEX|INDEXES|KEYS FROM kv
+func (h *MySQLHandler) handleShowIndex(ctx context.Context) (*mysql.Result, error) {
+	rows := [][]interface{}{
+		{"kv", int64(0), "PRIMARY", int64(1), "key", "BTREE", "", "YES", nil},
+	}
+
+	defs, err := sqlindex.List(ctx, h.store)
+	if err != nil {
+		return nil, NewReadError("list indexes", err)
+	}
+	for _, def := range defs {
+		var column, expression interface{} = "value", nil
+		if def.JSONPath != "" {
+			column, expression = nil, def.Expression()
+		}
+		// Comment carries the build state; write_only indexes are not used by queries yet
+		rows = append(rows, []interface{}{
+			"kv", int64(1), def.Name, int64(1), column, "BTREE", string(def.State), "YES", expression,
+		})
+	}
+
+	resultset, err := mysql.BuildSimpleResultset(
+		[]string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Index_type", "Comment", "Visible", "Expression"},
+		rows,
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mysql.Result{
+		Status:    0,
+		Resultset: resultset,
+	}, nil
+}
+
+// usesValue reports whether a WHERE clause filters on the value column
+func usesValue(cond *parser.WhereCondition) bool {
+	if cond == nil {
+		return false
+	}
+	if cond.Key == "value" || cond.JSONPath != "" {
+		return true
+	}
+	for _, child := range cond.Children {
+		if usesValue(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFiltered evaluates a WHERE clause the key lookups cannot serve. Candidate keys
+// come from a public secondary index when one covers the clause, otherwise all user
+// keys are scanned; every candidate is re-checked against the full clause.
+func (h *MySQLHandler) selectFiltered(ctx context.Context, plan *parser.QueryPlan, revision int64) (*kvstore.RangeResponse, error) {
+	var candidates []*kvstore.KeyValue
+
+	// Index entries reflect the latest revision, so snapshot reads always scan
+	keys, indexed := []string(nil), false
+	if revision == 0 {
+		var err error
+		if keys, indexed, err = h.indexCandidates(ctx, plan.Where); err != nil {
+			return nil, err
+		}
+	}
+
+	if indexed {
+		log.Debug("Using secondary index",
+			zap.Int("candidates", len(keys)),
+			zap.String("component", "mysql"))
+		if plan.OrderDesc {
+			sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+		}
+		for _, key := range keys {
+			resp, err := h.store.Range(ctx, key, "", 1, 0)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, resp.Kvs...)
+		}
+	} else {
+		rangeKeys := h.rangeUserKeys
+		if plan.OrderDesc {
+			rangeKeys = h.reverseRangeUserKeys
+		}
+		resp, err := rangeKeys(ctx, "", "\x00", 0, revision)
+		if err != nil {
+			return nil, err
+		}
+		candidates = resp.Kvs
+	}
+
+	offset, limit := plan.Offset, plan.Limit
+	if limit <= 0 {
+		limit = valueSelectLimit
+	}
+	var kvs []*kvstore.KeyValue
+	for _, kv := range candidates {
+		if !matchCondition(plan.Where, kv) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		kvs = append(kvs, kv)
+		if int64(len(kvs)) >= limit {
+			break
+		}
+	}
+	return &kvstore.RangeResponse{Kvs: kvs, Count: int64(len(kvs))}, nil
+}
+
+// indexCandidates returns the candidate keys for a WHERE clause from public indexes,
+// ok=false when no index covers it
+func (h *MySQLHandler) indexCandidates(ctx context.Context, cond *parser.WhereCondition) ([]string, bool, error) {
+	if _, ok := kvstore.As[sqlindex.Maintainer](h.store); !ok {
+		return nil, false, nil
+	}
+	defs, err := sqlindex.List(ctx, h.store)
+	if err != nil {
+		return nil, false, err
+	}
+	var public []*sqlindex.Definition
+	for _, def := range defs {
+		if def.State == sqlindex.StatePublic {
+			public = append(public, def)
+		}
+	}
+	if len(public) == 0 {
+		return nil, false, nil
+	}
+	return candidatesFor(ctx, h.store, public, cond)
+}
+
+func candidatesFor(ctx context.Context, store kvstore.Store, defs []*sqlindex.Definition, cond *parser.WhereCondition) ([]string, bool, error) {
+	switch cond.Type {
+	case parser.ConditionTypeAnd:
+		// Any indexed conjunct narrows the candidates
+		for _, child := range cond.Children {
+			keys, ok, err := candidatesFor(ctx, store, defs, child)
+			if err != nil || ok {
+				return keys, ok, err
+			}
+		}
+		return nil, false, nil
+
+	case parser.ConditionTypeOr:
+		// Every disjunct must be indexed
+		var all []string
+		for _, child := range cond.Children {
+			keys, ok, err := candidatesFor(ctx, store, defs, child)
+			if err != nil || !ok {
+				return nil, false, err
+			}
+			all = append(all, keys...)
+		}
+		return dedupKeys(all), true, nil
+	}
+
+	if cond.Key != "value" {
+		return nil, false, nil
+	}
+	var def *sqlindex.Definition
+	for _, d := range defs {
+		if d.JSONPath == cond.JSONPath {
+			def = d
+			break
+		}
+	}
+	if def == nil {
+		return nil, false, nil
+	}
+
+	switch {
+	case cond.Type == parser.ConditionTypeIn:
+		var all []string
+		for _, v := range cond.InValues {
+			term, ok := sqlindex.Term(v)
+			if !ok {
+				continue
+			}
+			keys, err := def.Equal(ctx, store, term)
+			if err != nil {
+				return nil, false, err
+			}
+			all = append(all, keys...)
+		}
+		return dedupKeys(all), true, nil
+
+	case cond.IsLike:
+		pattern, _ := cond.Value.(string)
+		return def.Like(ctx, store, pattern)
+
+	case cond.Operator == "eq":
+		term, ok := sqlindex.Term(cond.Value)
+		if !ok {
+			return nil, false, nil
+		}
+		keys, err := def.Equal(ctx, store, term)
+		return keys, err == nil, err
+	}
+	return nil, false, nil
+}
+
+func dedupKeys(keys []string) []string {
+	sort.Strings(keys)
+	out := keys[:0]
+	for i, k := range keys {
+		if i == 0 || k != keys[i-1] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// matchCondition evaluates a WHERE clause against a key-value pair
+func matchCondition(cond *parser.WhereCondition, kv *kvstore.KeyValue) bool {
+	switch cond.Type {
+	case parser.ConditionTypeAnd:
+		for _, child := range cond.Children {
+			if !matchCondition(child, kv) {
+				return false
+			}
+		}
+		return true
+	case parser.ConditionTypeOr:
+		for _, child := range cond.Children {
+			if matchCondition(child, kv) {
+				return true
+			}
+		}
+		return false
+	}
+
+	operand, ok := operandOf(cond, kv)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case cond.Type == parser.ConditionTypeIn:
+		for _, v := range cond.InValues {
+			if c, ok := compareTerm(operand, v); ok && c == 0 {
+				return true
+			}
+		}
+		return false
+	case cond.IsLike:
+		pattern, _ := cond.Value.(string)
+		return likeRegexp(pattern).MatchString(operand)
+	}
+
+	c, ok := compareTerm(operand, cond.Value)
+	if !ok {
+		return false
+	}
+	switch cond.Operator {
+	case "eq":
+		return c == 0
+	case "ne":
+		return c != 0
+	case "lt":
+		return c < 0
+	case "le":
+		return c <= 0
+	case "gt":
+		return c > 0
+	case "ge":
+		return c >= 0
+	}
+	return false
+}
+
+// operandOf returns the textual value of the condition's left side for a row
+func operandOf(cond *parser.WhereCondition, kv *kvstore.KeyValue) (string, bool) {
+	switch cond.Key {
+	case "key":
+		return string(kv.Key), cond.JSONPath == ""
+	case "value":
+		if cond.JSONPath == "" {
+			return string(kv.Value), true
+		}
+		v, ok := sqlindex.Extract(kv.Value, cond.JSONPath)
+		if !ok {
+			return "", false
+		}
+		return sqlindex.Term(v)
+	}
+	return "", false
+}
+
+// compareTerm compares a row value with a literal, numerically when both are numbers
+func compareTerm(operand string, literal interface{}) (int, bool) {
+	term, ok := sqlindex.Term(literal)
+	if !ok {
+		return 0, false
+	}
+	if a, err := strconv.ParseFloat(operand, 64); err == nil {
+		if b, err := strconv.ParseFloat(term, 64); err == nil {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return strings.Compare(operand, term), true
+}
+
+// likeRegexp converts a SQL LIKE pattern to a case-sensitive regexp, matching the
+// binary comparison used by the secondary indexes
+func likeRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// rangeUserKeys scans [key, rangeEnd) skipping the system prefix, so internal state
+// such as index entries and mirror checkpoints never shows up in table scans
+func (h *MySQLHandler) rangeUserKeys(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
+	sysStart, sysEnd := kvstore.PrefixRange(kvstore.SystemKeyPrefix)
+	result := &kvstore.RangeResponse{}
+
+	if key < sysStart {
+		end := rangeEnd
+		if end == "\x00" || end > sysStart {
+			end = sysStart
+		}
+		resp, err := h.store.Range(ctx, key, end, limit, revision)
+		if err != nil {
+			return nil, err
+		}
+		result.Kvs = append(result.Kvs, resp.Kvs...)
+		result.More = resp.More
+		result.Revision = resp.Revision
+	}
+
+	if limit > 0 && int64(len(result.Kvs)) >= limit {
+		result.Count = int64(len(result.Kvs))
+		return result, nil
+	}
+	start := key
+	if start < sysEnd {
+		start = sysEnd
+	}
+	if rangeEnd == "\x00" || start < rangeEnd {
+		rest := int64(0)
+		if limit > 0 {
+			rest = limit - int64(len(result.Kvs))
+		}
+		resp, err := h.store.Range(ctx, start, rangeEnd, rest, revision)
+		if err != nil {
+			return nil, err
+		}
+		result.Kvs = append(result.Kvs, resp.Kvs...)
+		result.More = resp.More
+		result.Revision = resp.Revision
+	}
+	result.Count = int64(len(result.Kvs))
+	return result, nil
+}
+
+// reverseRangeUserKeys is rangeUserKeys in descending key order (ORDER BY key DESC).
+// Keys are streamed from the end of the range, so only the returned keys are read
+func (h *MySQLHandler) reverseRangeUserKeys(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
+	sysStart, sysEnd := kvstore.PrefixRange(kvstore.SystemKeyPrefix)
+	result := &kvstore.RangeResponse{}
+	collect := func(kv *kvstore.KeyValue) bool {
+		if limit > 0 && int64(len(result.Kvs)) >= limit {
+			result.More = true
+			return false
+		}
+		result.Kvs = append(result.Kvs, kv)
+		return true
+	}
+
+	// Keys above the system prefix come first
+	start := key
+	if start < sysEnd {
+		start = sysEnd
+	}
+	if rangeEnd == "\x00" || start < rangeEnd {
+		rev, err := kvstore.ReverseRangeFunc(ctx, h.store, start, rangeEnd, revision, collect)
+		if err != nil {
+			return nil, err
+		}
+		result.Revision = rev
+	}
+
+	if key < sysStart && !result.More {
+		end := rangeEnd
+		if end == "\x00" || end > sysStart {
+			end = sysStart
+		}
+		rev, err := kvstore.ReverseRangeFunc(ctx, h.store, key, end, revision, collect)
+		if err != nil {
+			return nil, err
+		}
+		result.Revision = rev
+	}
+	result.Count = int64(len(result.Kvs))
+	return result, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/sqlindex"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+func TestSecondaryIndex(t *testing.T) {
+	defer func(d time.Duration) { sqlindex.RefreshInterval = d }(sqlindex.RefreshInterval)
+	sqlindex.RefreshInterval = 20 * time.Millisecond
+
+	base := memory.NewMemoryEtcd()
+	store := sqlindex.Wrap(base)
+	defer store.Close()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	exec := func(query string) {
+		t.Helper()
+		if _, err := h.HandleQuery(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	selectKeys := func(query string) []string {
+		t.Helper()
+		_, rows := queryRows(t, h, query)
+		keys := []string{}
+		for _, row := range rows {
+			keys = append(keys, row[0])
+		}
+		return keys
+	}
+	expect := func(query string, want ...string) {
+		t.Helper()
+		if got := selectKeys(query); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s = %v, want %v", query, got, want)
+		}
+	}
+
+	exec(`INSERT INTO kv (key, value) VALUES ('users/1', '{"name":"alice","age":30}')`)
+	exec(`INSERT INTO kv (key, value) VALUES ('users/2', '{"name":"bob","age":25}')`)
+	exec(`INSERT INTO kv (key, value) VALUES ('notes/1', 'hello world')`)
+
+	// Without an index value filters scan every key
+	expect("SELECT key FROM kv WHERE value LIKE '%llo wo%'", "notes/1")
+	expect("SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.age') > 26", "users/1")
+
+	exec("CREATE INDEX idx_value ON kv (value)")
+	exec("CREATE INDEX idx_name ON kv ((JSON_EXTRACT(value, '$.name')))")
+	_, rows := queryRows(t, h, "SHOW INDEX FROM kv")
+	if len(rows) != 3 || rows[1][2] != "idx_name" || rows[1][6] != "public" || rows[2][4] != "value" {
+		t.Fatalf("SHOW INDEX = %v", rows)
+	}
+
+	// Writes after CREATE INDEX are indexed too
+	exec(`UPDATE kv SET value = '{"name":"carol"}' WHERE key = 'users/2'`)
+	exec("BEGIN")
+	exec(`INSERT INTO kv (key, value) VALUES ('users/3', '{"name":"bob"}')`)
+	exec("COMMIT")
+
+	expect("SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.name') = 'alice'", "users/1")
+	expect("SELECT key FROM kv WHERE value->>'$.name' = 'bob'", "users/3")
+	expect("SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.name') IN ('bob', 'carol')", "users/2", "users/3")
+	expect("SELECT key FROM kv WHERE value LIKE '%llo wo%'", "notes/1")
+	expect("SELECT key FROM kv WHERE value = 'hello world' AND key LIKE 'notes/%'", "notes/1")
+
+	// A key written behind the wrapper's back has no index entries, so it is
+	// only visible to queries that scan instead of using an index
+	if _, _, err := base.PutWithLease(context.Background(), "users/9", `{"name":"alice","age":40}`, 0); err != nil {
+		t.Fatal(err)
+	}
+	expect("SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.name') = 'alice'", "users/1")
+	expect("SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.age') >= 30", "users/1", "users/9")
+
+	// Index entries live under the system prefix and never show up in table scans
+	for _, key := range selectKeys("SELECT key FROM kv") {
+		if strings.HasPrefix(key, "__metastore/") {
+			t.Fatalf("table scan returned internal key %q", key)
+		}
+	}
+
+	exec("DROP INDEX idx_name ON kv")
+	expect("SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.name') = 'alice'", "users/1", "users/9")
+	exec("DROP INDEX IF EXISTS idx_name ON kv")
+
+	tests := []struct {
+		query string
+		code  uint16
+	}{
+		{"CREATE INDEX idx_value ON kv (value)", mysql.ER_DUP_KEYNAME},
+		{"CREATE INDEX idx_key ON kv (key)", mysql.ER_PARSE_ERROR},
+		{"CREATE INDEX idx_other ON other (value)", mysql.ER_NO_SUCH_TABLE},
+		{"DROP INDEX idx_name ON kv", mysql.ER_CANT_DROP_FIELD_OR_KEY},
+	}
+	for _, tt := range tests {
+		_, err := h.HandleQuery(tt.query)
+		merr, ok := err.(*mysql.MyError)
+		if !ok || merr.Code != tt.code {
+			t.Errorf("%s: err = %v, want code %d", tt.query, err, tt.code)
+		}
+	}
+}
+
+func TestMultiRowInsert(t *testing.T) {
+	base := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(base, NewAuthProvider("root", ""))
+
+	result, err := h.HandleQuery(`INSERT INTO kv (key, value) VALUES ('a', '1'), ('b', '{"x":"(,)"}') , ("c", "3")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AffectedRows != 3 {
+		t.Fatalf("affected rows = %d, want 3", result.AffectedRows)
+	}
+
+	resp, err := base.Range(context.Background(), "a", "d", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 3 {
+		t.Fatalf("got %d keys, want 3", len(resp.Kvs))
+	}
+	if v := string(resp.Kvs[1].Value); v != `{"x":"(,)"}` {
+		t.Fatalf("b = %q", v)
+	}
+
+	if _, err := h.HandleQuery(`INSERT INTO kv (key, value) VALUES ('d', '4'), ('e'`); err == nil {
+		t.Fatal("expected syntax error")
+	}
+}
+
+func TestOrderByKeyDesc(t *testing.T) {
+	h := NewMySQLHandler(memory.NewMemoryEtcd(), NewAuthProvider("root", ""))
+	insert := `INSERT INTO kv (key, value) VALUES ('a/1', '{"n":1}'), ('a/2', '{"n":2}'), ('a/3', '{"n":1}'), ('b/1', 'x')`
+	if _, err := h.HandleQuery(insert); err != nil {
+		t.Fatalf("%s: %v", insert, err)
+	}
+
+	for query, want := range map[string][]string{
+		"SELECT key FROM kv ORDER BY key DESC":                                      {"b/1", "a/3", "a/2", "a/1"},
+		"SELECT key FROM kv WHERE key LIKE 'a/%' ORDER BY key DESC":                 {"a/3", "a/2", "a/1"},
+		"SELECT key FROM kv WHERE key LIKE 'a/%' ORDER BY key ASC":                  {"a/1", "a/2", "a/3"},
+		"SELECT key FROM kv WHERE JSON_EXTRACT(value, '$.n') = 1 ORDER BY key DESC": {"a/3", "a/1"},
+		"SELECT key FROM kv WHERE value LIKE '%n%' ORDER BY key DESC LIMIT 2":       {"a/3", "a/2"},
+	} {
+		_, rows := queryRows(t, h, query)
+		var got []string
+		for _, row := range rows {
+			got = append(got, row[0])
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s = %v, want %v", query, got, want)
+		}
+	}
+
+	if _, err := h.HandleQuery("SELECT key FROM kv ORDER BY value"); err == nil {
+		t.Fatalf("ORDER BY value should be rejected")
+	}
+}
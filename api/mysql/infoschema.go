@@ -0,0 +1,392 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/usage"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// infoSchemaDB is the virtual database holding the cluster state tables
+const infoSchemaDB = "information_schema"
+
+// infoSchemaTables lists the virtual tables and their columns, in display order
+var infoSchemaTables = []struct {
+	name    string
+	columns []string
+}{
+	{"metastore_members", []string{"id", "peer_url", "is_learner", "is_leader", "match_index", "progress", "recent_active",
+		"last_contact", "rtt_ms", "send_failures", "unreachable"}},
+	{"metastore_status", []string{"node_id", "leader_id", "term", "state", "applied_index", "commit_index", "revision"}},
+	{"metastore_leases", []string{"id", "ttl", "remaining", "granted_at", "key_count"}},
+	{"metastore_watches", []string{"id", "key", "range_end", "start_revision", "prev_kv", "pending"}},
+	{"metastore_usage", []string{"prefix", "keys", "bytes", "writes", "write_rate", "watches"}},
+	{"metastore_top_keys", []string{"key", "bytes", "mod_revision"}},
+	{"metastore_key_history", []string{"key", "revision", "value", "tombstone", "create_revision", "version", "lease",
+		"compact_revision"}},
+}
+
+// infoSchemaSelectRe matches SELECT <columns> FROM [information_schema.]metastore_<table>
+// with an optional single equality filter and LIMIT:
+//
+//	SELECT * FROM information_schema.metastore_members WHERE is_leader = 1
+//	SELECT id, remaining FROM metastore_leases LIMIT 10
+//	SELECT revision, value FROM metastore_key_history WHERE key = 'app/config'
+var infoSchemaSelectRe = regexp.MustCompile("(?is)^SELECT\\s+(.+?)\\s+FROM\\s+" +
+	"(?:`?information_schema`?\\.)?`?(metastore_\\w+)`?" +
+	"(?:\\s+WHERE\\s+`?(\\w+)`?\\s*=\\s*('[^']*'|\"[^\"]*\"|[^\\s;]+))?" +
+	"(?:\\s+LIMIT\\s+(\\d+))?\\s*;?$")
+
+// showStatusRe matches SHOW [GLOBAL|SESSION] STATUS [LIKE 'pattern']
+var showStatusRe = regexp.MustCompile(`(?is)^SHOW\s+(?:GLOBAL\s+|SESSION\s+)?STATUS(?:\s+LIKE\s+('[^']*'|"[^"]*"))?\s*;?$`)
+
+// memberLister is implemented by stores that expose raft membership
+type memberLister interface {
+	Members() []kvstore.MemberStatus
+}
+
+// watchLister is implemented by stores that expose their active watches
+type watchLister interface {
+	Watches() []kvstore.WatchInfo
+}
+
+// UsageReporter reports per-prefix usage, implemented by usage.Tracker
+type UsageReporter interface {
+	Enabled() bool
+	Report() usage.Report
+}
+
+// isInfoSchemaSelect reports whether a SELECT targets one of the virtual tables
+func isInfoSchemaSelect(query string) bool {
+	return infoSchemaSelectRe.MatchString(strings.TrimSpace(query))
+}
+
+// handleInfoSchemaSelect serves SELECT queries against the information_schema tables
+func (h *MySQLHandler) handleInfoSchemaSelect(ctx context.Context, query string) (*mysql.Result, error) {
+	m := infoSchemaSelectRe.FindStringSubmatch(strings.TrimSpace(query))
+	if m == nil {
+		return nil, mysql.NewError(mysql.ER_PARSE_ERROR, "invalid information_schema query")
+	}
+	table := strings.ToLower(m[2])
+
+	allColumns, rows, err := h.infoSchemaRows(ctx, table, m[3], unquote(m[4]))
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the WHERE filter by comparing the textual form of the column value
+	if m[3] != "" {
+		idx := columnIndex(allColumns, m[3])
+		if idx < 0 {
+			return nil, mysql.NewError(mysql.ER_BAD_FIELD_ERROR,
+				fmt.Sprintf("Unknown column '%s' in 'where clause'", m[3]))
+		}
+		want := unquote(m[4])
+		filtered := rows[:0]
+		for _, row := range rows {
+			if fmt.Sprint(row[idx]) == want {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	if m[5] != "" {
+		limit, err := strconv.Atoi(m[5])
+		if err == nil && limit < len(rows) {
+			rows = rows[:limit]
+		}
+	}
+
+	// Project the selected columns
+	columns := allColumns
+	var indexes []int
+	if sel := strings.TrimSpace(m[1]); sel != "*" {
+		columns = nil
+		for _, col := range strings.Split(sel, ",") {
+			col = strings.Trim(strings.TrimSpace(col), "`")
+			idx := columnIndex(allColumns, col)
+			if idx < 0 {
+				return nil, mysql.NewError(mysql.ER_BAD_FIELD_ERROR,
+					fmt.Sprintf("Unknown column '%s' in 'field list'", col))
+			}
+			columns = append(columns, allColumns[idx])
+			indexes = append(indexes, idx)
+		}
+		for i, row := range rows {
+			projected := make([]interface{}, len(indexes))
+			for j, idx := range indexes {
+				projected[j] = row[idx]
+			}
+			rows[i] = projected
+		}
+	}
+
+	resultset, err := mysql.BuildSimpleResultset(columns, rows, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mysql.Result{
+		Status:       0,
+		AffectedRows: uint64(len(rows)),
+		Resultset:    resultset,
+	}, nil
+}
+
+// infoSchemaRows returns the columns and all rows of a virtual table. The WHERE
+// column and value are only used by tables that cannot list all their rows
+func (h *MySQLHandler) infoSchemaRows(ctx context.Context, table, whereColumn, whereValue string) ([]string, [][]interface{}, error) {
+	var columns []string
+	for _, t := range infoSchemaTables {
+		if t.name == table {
+			columns = t.columns
+		}
+	}
+	if columns == nil {
+		return nil, nil, mysql.NewError(mysql.ER_NO_SUCH_TABLE,
+			fmt.Sprintf("Table '%s.%s' doesn't exist", infoSchemaDB, table))
+	}
+
+	var rows [][]interface{}
+	switch table {
+	case "metastore_members":
+		for _, m := range h.members() {
+			lastContact := ""
+			if !m.LastContact.IsZero() {
+				lastContact = m.LastContact.UTC().Format("2006-01-02 15:04:05.000")
+			}
+			rows = append(rows, []interface{}{
+				m.ID, m.PeerURL, boolInt(m.IsLearner), boolInt(m.IsLeader),
+				m.Match, m.Progress, boolInt(m.RecentActive),
+				lastContact, m.RTT.Seconds() * 1000, m.SendFailures, boolInt(m.Unreachable),
+			})
+		}
+
+	case "metastore_status":
+		st := h.store.GetRaftStatus()
+		rows = append(rows, []interface{}{
+			st.NodeID, st.LeaderID, st.Term, st.State,
+			st.Applied, st.Commit, h.store.CurrentRevision(),
+		})
+
+	case "metastore_leases":
+		leases, err := h.store.Leases(ctx)
+		if err != nil {
+			return nil, nil, NewReadError("list leases", err)
+		}
+		for _, l := range leases {
+			rows = append(rows, []interface{}{
+				l.ID, l.TTL, l.Remaining(),
+				l.GrantTime.UTC().Format("2006-01-02 15:04:05"), int64(len(l.Keys)),
+			})
+		}
+
+	case "metastore_watches":
+		if wl, ok := kvstore.As[watchLister](h.store); ok {
+			for _, w := range wl.Watches() {
+				rows = append(rows, []interface{}{
+					w.ID, w.Key, w.RangeEnd, w.StartRevision, boolInt(w.PrevKV), int64(w.Pending),
+				})
+			}
+		}
+
+	case "metastore_usage", "metastore_top_keys":
+		if h.usage == nil || !h.usage.Enabled() {
+			return nil, nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
+				"usage accounting is disabled, set usage.enable in the configuration")
+		}
+		report := h.usage.Report()
+		if table == "metastore_usage" {
+			for _, p := range report.Prefixes {
+				rows = append(rows, []interface{}{
+					p.Prefix, p.Keys, p.Bytes, p.Writes, p.WriteRate, p.Watches,
+				})
+			}
+		} else {
+			for _, k := range report.TopKeys {
+				rows = append(rows, []interface{}{k.Key, k.Bytes, k.ModRevision})
+			}
+		}
+
+	case "metastore_key_history":
+		if !strings.EqualFold(whereColumn, "key") {
+			return nil, nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
+				"metastore_key_history requires WHERE key = '<key>'")
+		}
+		historian, ok := kvstore.As[kvstore.KeyHistorian](h.store)
+		if !ok {
+			return nil, nil, mysql.NewError(mysql.ER_NOT_SUPPORTED_YET,
+				"storage engine does not keep key history")
+		}
+		if err := h.checkPermission("SELECT", whereValue, etcd.PermissionRead); err != nil {
+			return nil, nil, err
+		}
+		revs, compacted, err := historian.KeyHistory(ctx, whereValue, 0)
+		if err != nil {
+			return nil, nil, NewReadError("key history", err)
+		}
+		for _, kv := range revs {
+			rows = append(rows, []interface{}{
+				string(kv.Key), kv.ModRevision, string(kv.Value), boolInt(kv.Version == 0),
+				kv.CreateRevision, kv.Version, kv.Lease, compacted,
+			})
+		}
+	}
+	return columns, rows, nil
+}
+
+// members returns the raft membership, falling back to this node alone
+// when the store does not expose the member list
+func (h *MySQLHandler) members() []kvstore.MemberStatus {
+	if ml, ok := kvstore.As[memberLister](h.store); ok {
+		if members := ml.Members(); len(members) > 0 {
+			return members
+		}
+	}
+	st := h.store.GetRaftStatus()
+	return []kvstore.MemberStatus{{
+		ID:       st.NodeID,
+		IsLeader: st.LeaderID != 0 && st.NodeID == st.LeaderID,
+	}}
+}
+
+// handleShowStatus handles SHOW [GLOBAL|SESSION] STATUS [LIKE 'pattern']
+func (h *MySQLHandler) handleShowStatus(ctx context.Context, query string) (*mysql.Result, error) {
+	m := showStatusRe.FindStringSubmatch(strings.TrimSpace(query))
+	if m == nil {
+		return nil, mysql.NewError(mysql.ER_PARSE_ERROR, "invalid SHOW STATUS query")
+	}
+
+	st := h.store.GetRaftStatus()
+	leaseCount := 0
+	if leases, err := h.store.Leases(ctx); err == nil {
+		leaseCount = len(leases)
+	}
+	watchCount := 0
+	if wl, ok := kvstore.As[watchLister](h.store); ok {
+		watchCount = len(wl.Watches())
+	}
+
+	vars := [][]interface{}{
+		{"Metastore_node_id", strconv.FormatUint(st.NodeID, 10)},
+		{"Metastore_leader_id", strconv.FormatUint(st.LeaderID, 10)},
+		{"Metastore_term", strconv.FormatUint(st.Term, 10)},
+		{"Metastore_state", st.State},
+		{"Metastore_applied_index", strconv.FormatUint(st.Applied, 10)},
+		{"Metastore_commit_index", strconv.FormatUint(st.Commit, 10)},
+		{"Metastore_revision", strconv.FormatInt(h.store.CurrentRevision(), 10)},
+		{"Metastore_members", strconv.Itoa(len(h.members()))},
+		{"Metastore_leases", strconv.Itoa(leaseCount)},
+		{"Metastore_watches", strconv.Itoa(watchCount)},
+	}
+
+	if m[1] != "" {
+		pattern := likePattern(unquote(m[1]))
+		filtered := vars[:0]
+		for _, v := range vars {
+			if pattern.MatchString(v[0].(string)) {
+				filtered = append(filtered, v)
+			}
+		}
+		vars = filtered
+	}
+
+	resultset, err := mysql.BuildSimpleResultset(
+		[]string{"Variable_name", "Value"},
+		vars,
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mysql.Result{
+		Status:    0,
+		Resultset: resultset,
+	}, nil
+}
+
+// handleShowInfoSchemaTables lists the virtual tables of information_schema
+func (h *MySQLHandler) handleShowInfoSchemaTables(ctx context.Context) (*mysql.Result, error) {
+	var tables [][]interface{}
+	for _, t := range infoSchemaTables {
+		tables = append(tables, []interface{}{t.name})
+	}
+
+	resultset, err := mysql.BuildSimpleResultset(
+		[]string{"Tables_in_" + infoSchemaDB},
+		tables,
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mysql.Result{
+		Status:    0,
+		Resultset: resultset,
+	}, nil
+}
+
+// likePattern converts a SQL LIKE pattern to a case-insensitive regexp
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+func columnIndex(columns []string, name string) int {
+	for i, col := range columns {
+		if strings.EqualFold(col, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+func queryRows(t *testing.T, h *MySQLHandler, query string) ([]string, [][]string) {
+	t.Helper()
+	res, err := h.HandleQuery(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	if res.Resultset == nil {
+		t.Fatalf("%s: no result set", query)
+	}
+	var columns []string
+	for _, f := range res.Fields {
+		columns = append(columns, string(f.Name))
+	}
+	// BuildSimpleResultset only fills RowDatas, decode the length-encoded text values
+	var rows [][]string
+	for _, data := range res.RowDatas {
+		var row []string
+		for pos := 0; pos < len(data); {
+			v, _, n, err := mysql.LengthEncodedString(data[pos:])
+			if err != nil {
+				t.Fatalf("%s: %v", query, err)
+			}
+			row = append(row, string(v))
+			pos += n
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+func TestInfoSchemaTables(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	if _, _, err := store.PutWithLease(ctx, "a", "1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.LeaseGrant(ctx, 7, 60); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.PutWithLease(ctx, "b", "2", 7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.WatchWithOptions("app/", "app0", 0, 42, &kvstore.WatchOptions{PrevKV: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer store.CancelWatch(42)
+
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	columns, rows := queryRows(t, h, "SELECT * FROM information_schema.metastore_status")
+	if len(rows) != 1 || columns[0] != "node_id" || rows[0][1] != "1" || rows[0][6] != "2" {
+		t.Fatalf("status = %v %v", columns, rows)
+	}
+
+	_, rows = queryRows(t, h, "SELECT id, is_leader FROM metastore_members")
+	if len(rows) != 1 || rows[0][0] != "1" || rows[0][1] != "1" {
+		t.Fatalf("members = %v", rows)
+	}
+
+	_, rows = queryRows(t, h, "SELECT id, ttl, key_count FROM information_schema.metastore_leases WHERE id = 7")
+	if len(rows) != 1 || rows[0][0] != "7" || rows[0][1] != "60" || rows[0][2] != "1" {
+		t.Fatalf("leases = %v", rows)
+	}
+
+	_, rows = queryRows(t, h, "SELECT `id`, `key`, prev_kv FROM `information_schema`.`metastore_watches`;")
+	if len(rows) != 1 || rows[0][0] != "42" || rows[0][1] != "app/" || rows[0][2] != "1" {
+		t.Fatalf("watches = %v", rows)
+	}
+
+	_, rows = queryRows(t, h, "SELECT id FROM metastore_leases WHERE id = '8'")
+	if len(rows) != 0 {
+		t.Fatalf("filtered leases = %v", rows)
+	}
+
+	_, rows = queryRows(t, h, "SHOW GLOBAL STATUS LIKE 'metastore_%index'")
+	if len(rows) != 2 || rows[0][0] != "Metastore_applied_index" || rows[1][0] != "Metastore_commit_index" {
+		t.Fatalf("status vars = %v", rows)
+	}
+
+	_, rows = queryRows(t, h, "SHOW TABLES FROM information_schema")
+	if len(rows) != len(infoSchemaTables) {
+		t.Fatalf("tables = %v", rows)
+	}
+}
+
+func TestInfoSchemaKeyHistory(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	for _, v := range []string{"1", "2"} {
+		if _, _, err := store.PutWithLease(ctx, "app/config", v, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, _, err := store.DeleteRange(ctx, "app/config", ""); err != nil {
+		t.Fatal(err)
+	}
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	columns, rows := queryRows(t, h, "SELECT * FROM information_schema.metastore_key_history WHERE key = 'app/config'")
+	if len(columns) != 8 || columns[1] != "revision" || columns[3] != "tombstone" {
+		t.Fatalf("columns = %v", columns)
+	}
+	want := [][]string{{"3", "", "1"}, {"2", "2", "0"}, {"1", "1", "0"}}
+	if len(rows) != len(want) {
+		t.Fatalf("history = %v", rows)
+	}
+	for i, w := range want {
+		if rows[i][1] != w[0] || rows[i][2] != w[1] || rows[i][3] != w[2] {
+			t.Errorf("history row %d = %v, want revision %s value %q tombstone %s", i, rows[i], w[0], w[1], w[2])
+		}
+	}
+
+	_, rows = queryRows(t, h, "SELECT revision, value FROM metastore_key_history WHERE `key` = \"app/config\" LIMIT 1")
+	if len(rows) != 1 || rows[0][0] != "3" {
+		t.Fatalf("limited history = %v", rows)
+	}
+}
+
+func TestInfoSchemaErrors(t *testing.T) {
+	h := NewMySQLHandler(memory.NewMemoryEtcd(), NewAuthProvider("root", ""))
+
+	tests := []struct {
+		query string
+		code  uint16
+	}{
+		{"SELECT * FROM information_schema.metastore_nodes", mysql.ER_NO_SUCH_TABLE},
+		{"SELECT bogus FROM metastore_status", mysql.ER_BAD_FIELD_ERROR},
+		{"SELECT * FROM metastore_leases WHERE bogus = 1", mysql.ER_BAD_FIELD_ERROR},
+		{"SELECT * FROM metastore_usage", mysql.ER_UNKNOWN_ERROR},
+		{"SELECT * FROM metastore_key_history", mysql.ER_UNKNOWN_ERROR},
+	}
+	for _, tt := range tests {
+		_, err := h.HandleQuery(tt.query)
+		merr, ok := err.(*mysql.MyError)
+		if !ok || merr.Code != tt.code {
+			t.Errorf("%s: err = %v, want code %d", tt.query, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"metaStore/api/mysql/parser"
+	"metaStore/pkg/keycodec"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// keyEncodingVar is the session variable selecting how keys are written in
+// statements and returned in result sets: raw (default), base64 or hex.
+// Encoded keys let clients reach keys holding quotes or binary bytes
+//
+//	SET metastore_key_encoding = 'base64'
+//	SELECT value FROM kv WHERE key = 'L2FwcC9h'
+const keyEncodingVar = "metastore_key_encoding"
+
+var (
+	setKeyEncodingRe    = regexp.MustCompile(`(?i)(?:@@(?:session\.)?|\b)metastore_key_encoding\s*:?=\s*(?:'([^']*)'|"([^"]*)"|(\w+))`)
+	selectKeyEncodingRe = regexp.MustCompile(`(?i)@@(?:session\.)?metastore_key_encoding`)
+)
+
+// handleSet handles SET statements, only metastore_key_encoding,
+// metastore_read_revision and metastore_changes_timeout have an effect
+func (h *MySQLHandler) handleSet(query string) (*mysql.Result, error) {
+	if m := setReadRevisionRe.FindStringSubmatch(query); m != nil {
+		if err := h.setReadRevision(m[1] + m[2] + m[3]); err != nil {
+			return nil, err
+		}
+	}
+	if m := setChangesTimeoutRe.FindStringSubmatch(query); m != nil {
+		if err := h.setChangesTimeout(m[1] + m[2] + m[3]); err != nil {
+			return nil, err
+		}
+	}
+	if m := setKeyEncodingRe.FindStringSubmatch(query); m != nil {
+		name := m[1] + m[2] + m[3]
+		if strings.EqualFold(name, "DEFAULT") {
+			name = ""
+		}
+		codec, err := keycodec.Parse(name)
+		if err != nil {
+			return nil, mysql.NewError(ErrWrongValueForVar,
+				fmt.Sprintf("Variable '%s' can't be set to the value of '%s'", keyEncodingVar, name))
+		}
+		h.keyCodec = codec
+	}
+	// Other SET commands are accepted for compatibility (usually SET autocommit, etc.)
+	return &mysql.Result{
+		Status:       0,
+		AffectedRows: 0,
+	}, nil
+}
+
+// decodeKey decodes a key literal written in the session key encoding
+func (h *MySQLHandler) decodeKey(s string) (string, error) {
+	key, err := h.keyCodec.Decode(s)
+	if err != nil {
+		return "", mysql.NewError(ErrTruncatedWrongValue, err.Error())
+	}
+	return key, nil
+}
+
+// encodeKey returns a key for a result set in the session key encoding
+func (h *MySQLHandler) encodeKey(key []byte) interface{} {
+	if h.keyCodec == keycodec.Raw {
+		return key
+	}
+	return h.keyCodec.Encode(key)
+}
+
+// decodeKeyPattern decodes a LIKE pattern on the key column. With a key encoding
+// only an encoded prefix followed by % can be matched
+func (h *MySQLHandler) decodeKeyPattern(pattern string) (string, error) {
+	enc, ok := strings.CutSuffix(pattern, "%")
+	if !ok || strings.ContainsAny(enc, `%_\`) {
+		return "", mysql.NewError(ErrNotSupported,
+			fmt.Sprintf("with %s = %s, LIKE on key only supports '<encoded prefix>%%'", keyEncodingVar, h.keyCodec))
+	}
+	return h.decodeKey(enc)
+}
+
+// decodeWhereKey decodes the key literal of a simple WHERE clause
+func (h *MySQLHandler) decodeWhereKey(where *whereClause) error {
+	if h.keyCodec == keycodec.Raw {
+		return nil
+	}
+	var err error
+	if where.isLike {
+		where.likePrefix, err = h.decodeKeyPattern(where.likePattern)
+		where.likePattern = escapeLike(where.likePrefix) + "%"
+	} else {
+		where.key, err = h.decodeKey(where.key)
+	}
+	return err
+}
+
+// decodeKeyCondition decodes the key literals of a parsed WHERE clause in place
+func (h *MySQLHandler) decodeKeyCondition(cond *parser.WhereCondition) error {
+	if h.keyCodec == keycodec.Raw || cond == nil {
+		return nil
+	}
+	for _, child := range cond.Children {
+		if err := h.decodeKeyCondition(child); err != nil {
+			return err
+		}
+	}
+	if cond.Key != "key" || cond.JSONPath != "" {
+		return nil
+	}
+
+	if cond.IsLike {
+		pattern, _ := cond.Value.(string)
+		prefix, err := h.decodeKeyPattern(pattern)
+		if err != nil {
+			return err
+		}
+		cond.Prefix, cond.Value = prefix, escapeLike(prefix)+"%"
+		return nil
+	}
+	decode := func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		return h.decodeKey(s)
+	}
+	var err error
+	if cond.Value, err = decode(cond.Value); err != nil {
+		return err
+	}
+	for i, v := range cond.InValues {
+		if cond.InValues[i], err = decode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards of a literal prefix
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// hexLiteral parses the hexadecimal literals X'4142' and 0x4142, which carry
+// arbitrary bytes in any key encoding
+func hexLiteral(s string) (string, bool) {
+	var digits string
+	switch {
+	case len(s) >= 3 && (s[0] == 'x' || s[0] == 'X') && s[1] == '\'' && s[len(s)-1] == '\'':
+		digits = s[2 : len(s)-1]
+	case len(s) > 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X'):
+		digits = s[2:]
+	default:
+		return "", false
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// unescapeString resolves the backslash escapes and doubled quotes of a MySQL
+// string literal body quoted with quote
+func unescapeString(s string, quote byte) string {
+	if !strings.ContainsRune(s, '\\') && !strings.Contains(s, string([]byte{quote, quote})) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case '0':
+				b.WriteByte(0)
+			case 'b':
+				b.WriteByte('\b')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'Z':
+				b.WriteByte(0x1a)
+			case '%', '_':
+				// Kept escaped so LIKE patterns still see a literal wildcard
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			default:
+				b.WriteByte(s[i])
+			}
+		case c == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+			b.WriteByte(quote)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
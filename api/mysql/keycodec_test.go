@@ -0,0 +1,86 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"metaStore/internal/memory"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+func TestKeyEncoding(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+
+	exec := func(query string) {
+		t.Helper()
+		if _, err := h.HandleQuery(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	expect := func(query string, want ...[]string) {
+		t.Helper()
+		_, rows := queryRows(t, h, query)
+		if len(want) == 0 {
+			want = nil
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Fatalf("%s = %q, want %q", query, rows, want)
+		}
+	}
+	expectCode := func(query string, code uint16) {
+		t.Helper()
+		_, err := h.HandleQuery(query)
+		var myErr *mysql.MyError
+		if !errors.As(err, &myErr) || myErr.Code != code {
+			t.Fatalf("%s: got %v, want error %d", query, err, code)
+		}
+	}
+
+	// "/app/it's\x00\xff" holds a quote and bytes that are not valid UTF-8
+	const key = "/app/it's\x00\xff"
+	exec("SET metastore_key_encoding = 'base64'")
+	expect("SELECT @@metastore_key_encoding", []string{"base64"})
+	exec("INSERT INTO kv (key, value) VALUES ('L2FwcC9pdCdzAP8=', 'v1')")
+	resp, err := store.Range(context.Background(), key, "", 0, 0)
+	if err != nil || len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v1" {
+		t.Fatalf("stored key = %v, %v", resp, err)
+	}
+	expect("SELECT key, value FROM kv WHERE key = 'L2FwcC9pdCdzAP8='", []string{"L2FwcC9pdCdzAP8=", "v1"})
+	exec("UPDATE kv SET value = 'v2' WHERE key = 'L2FwcC9pdCdzAP8'")
+	expect("SELECT value FROM kv WHERE key IN ('L2FwcC9pdCdzAP8=', 'eA==')", []string{"v2"})
+	expectCode("SELECT value FROM kv WHERE key = '!!'", ErrTruncatedWrongValue)
+
+	// LIKE takes an encoded prefix, the decoded prefix is matched literally
+	exec("SET SESSION metastore_key_encoding = hex")
+	exec("INSERT INTO kv (key, value) VALUES ('2f6170705f', 'other')")
+	expect("SELECT key FROM kv WHERE key LIKE '2f6170702f%'", []string{"2f6170702f6974277300ff"})
+	expectCode("SELECT key FROM kv WHERE key LIKE '%2f'", ErrNotSupported)
+	exec("DELETE FROM kv WHERE key = '2F6170705F'")
+
+	expectCode("SET metastore_key_encoding = 'base32'", ErrWrongValueForVar)
+	expect("SELECT @@session.metastore_key_encoding", []string{"hex"})
+
+	// Raw keys accept MySQL string escapes and hex literals
+	exec("SET metastore_key_encoding = DEFAULT")
+	expect(`SELECT value FROM kv WHERE key = '/app/it\'s\0`+"\xff'", []string{"v2"})
+	expect("SELECT value FROM kv WHERE key = X'2f6170702f6974277300ff'", []string{"v2"})
+	expect("SELECT value FROM kv WHERE key = '/app/it''s'")
+}
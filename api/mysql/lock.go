@@ -0,0 +1,251 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"regexp"
+	"sort"
+	"time"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+)
+
+// lockPrefix holds the row locks of SELECT ... FOR UPDATE: one entry per
+// locked key, attached to the lease of the transaction holding it, so that
+// revoking the lease on COMMIT or ROLLBACK releases all of them at once
+const lockPrefix = kvstore.SystemKeyPrefix + "lock/"
+
+// Defaults of mysql.lock_ttl and mysql.lock_wait_timeout
+const (
+	defaultLockTTL         = 30 * time.Second
+	defaultLockWaitTimeout = 50 * time.Second // innodb_lock_wait_timeout
+)
+
+// LockConfig bounds the row locks of SELECT ... FOR UPDATE, zero values
+// select the defaults
+type LockConfig struct {
+	TTL         time.Duration // How long a transaction holds its locks before others may take them over
+	WaitTimeout time.Duration // How long a statement waits for a lock held by another transaction
+}
+
+// Polling interval while waiting for a lock held by another transaction
+const (
+	minLockBackoff = 10 * time.Millisecond
+	maxLockBackoff = 200 * time.Millisecond
+)
+
+var forUpdateRe = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE\s*;?\s*$`)
+
+// stripForUpdate removes a trailing FOR UPDATE clause from a SELECT
+func stripForUpdate(query string) (string, bool) {
+	loc := forUpdateRe.FindStringIndex(query)
+	if loc == nil {
+		return query, false
+	}
+	return query[:loc[0]], true
+}
+
+// NewLockWaitTimeoutError creates the error for a lock that was not granted in time
+func NewLockWaitTimeoutError() error {
+	return mysql.NewError(ErrLockWaitTimeout, "Lock wait timeout exceeded; try restarting transaction")
+}
+
+// lockKeys locks keys for tx, waiting up to the lock wait timeout for locks
+// held by other transactions. Locks are only checked by other FOR UPDATE
+// statements, plain writes do not wait for them
+func (h *MySQLHandler) lockKeys(ctx context.Context, tx *Transaction, keys []string) error {
+	for _, key := range keys {
+		if err := h.checkPermission("SELECT ... FOR UPDATE", key, etcd.PermissionWrite); err != nil {
+			return err
+		}
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	// A fixed order keeps statements locking the same keys from deadlocking
+	sort.Strings(keys)
+	deadline := time.Now().Add(h.lockWaitTimeout())
+	for _, key := range keys {
+		if tx.locks[key] {
+			continue
+		}
+		if tx.lockLease == 0 {
+			if err := h.grantLockLease(ctx, tx); err != nil {
+				return err
+			}
+		}
+		if err := h.acquireLock(ctx, tx.lockLease, key, deadline); err != nil {
+			return err
+		}
+		if tx.locks == nil {
+			tx.locks = make(map[string]bool)
+		}
+		tx.locks[key] = true
+	}
+	return nil
+}
+
+// grantLockLease grants the lease the locks of tx are attached to
+func (h *MySQLHandler) grantLockLease(ctx context.Context, tx *Transaction) error {
+	ttl := int64((h.lockTTL() + time.Second - 1) / time.Second)
+	id := rand.Int64N(1<<62) + 1
+	if _, err := h.store.LeaseGrant(ctx, id, ttl); err != nil {
+		return NewWriteError("lock", err)
+	}
+	tx.lockLease = id
+	return nil
+}
+
+// acquireLock takes the lock of key for lease. A lock whose lease expired
+// belongs to a transaction that neither committed nor rolled back in time
+// and is released
+func (h *MySQLHandler) acquireLock(ctx context.Context, lease int64, key string, deadline time.Time) error {
+	lk := []byte(lockPrefix + key)
+	lock := kvstore.Op{Type: kvstore.OpPut, Key: lk, LeaseID: lease}
+	backoff := minLockBackoff
+	for {
+		resp, err := h.store.Txn(ctx,
+			[]kvstore.Compare{absentCompare(string(lk))},
+			[]kvstore.Op{lock},
+			[]kvstore.Op{{Type: kvstore.OpRange, Key: lk}})
+		if err != nil {
+			return h.lockError(err)
+		}
+		if resp.Succeeded {
+			return nil
+		}
+
+		var holder *kvstore.KeyValue
+		if len(resp.Responses) > 0 && resp.Responses[0].RangeResp != nil && len(resp.Responses[0].RangeResp.Kvs) > 0 {
+			holder = resp.Responses[0].RangeResp.Kvs[0]
+		}
+		switch {
+		case holder == nil:
+			// Released between the compare and the read
+			continue
+		case holder.Lease == lease:
+			return nil
+		case h.lockExpired(ctx, holder.Lease):
+			// Revoking the lease releases all locks of the expired transaction
+			err := h.store.LeaseRevoke(ctx, holder.Lease)
+			if errors.Is(err, kvstore.ErrLeaseNotFound) {
+				// A lock left behind by a lease that is already gone
+				_, err = h.store.Txn(ctx, []kvstore.Compare{{
+					Target:      kvstore.CompareMod,
+					Result:      kvstore.CompareEqual,
+					Key:         lk,
+					TargetUnion: kvstore.CompareUnion{ModRevision: holder.ModRevision},
+				}}, []kvstore.Op{{Type: kvstore.OpDelete, Key: lk}}, nil)
+			}
+			if err != nil {
+				return NewWriteError("lock", err)
+			}
+			log.Info("Released expired locks",
+				zap.String("key", key),
+				zap.Int64("expired_lease", holder.Lease),
+				zap.String("component", "mysql"))
+			continue
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return NewLockWaitTimeoutError()
+		}
+		timer := time.NewTimer(min(backoff, wait))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return NewReadError("lock", ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxLockBackoff)
+	}
+}
+
+// lockExpired reports whether the lease of a lock has expired or is gone
+func (h *MySQLHandler) lockExpired(ctx context.Context, lease int64) bool {
+	l, err := h.store.LeaseTimeToLive(ctx, lease)
+	if err != nil {
+		return errors.Is(err, kvstore.ErrLeaseNotFound)
+	}
+	return l == nil || l.IsExpired()
+}
+
+// lockError converts a failure to take a lock. The lease of the transaction
+// itself expiring means its locks may already be held by others
+func (h *MySQLHandler) lockError(err error) error {
+	if errors.Is(err, kvstore.ErrLeaseNotFound) || errors.Is(err, kvstore.ErrLeaseExpired) {
+		return mysql.NewError(ErrLockDeadlock,
+			"transaction locks expired (mysql.lock_ttl), restart the transaction")
+	}
+	return NewWriteError("lock", err)
+}
+
+// lockCompares are the COMMIT conditions of a transaction holding locks:
+// none of them was taken over after its lease expired
+func lockCompares(tx *Transaction) []kvstore.Compare {
+	cmps := make([]kvstore.Compare, 0, len(tx.locks))
+	for key := range tx.locks {
+		cmps = append(cmps, kvstore.Compare{
+			Target:      kvstore.CompareLease,
+			Result:      kvstore.CompareEqual,
+			Key:         []byte(lockPrefix + key),
+			TargetUnion: kvstore.CompareUnion{Lease: tx.lockLease},
+		})
+	}
+	return cmps
+}
+
+// releaseLocks revokes the lease of tx, deleting all of its lock entries
+func (h *MySQLHandler) releaseLocks(tx *Transaction) {
+	if tx.lockLease == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.lockWaitTimeout())
+	defer cancel()
+	if err := h.store.LeaseRevoke(ctx, tx.lockLease); err != nil && !errors.Is(err, kvstore.ErrLeaseNotFound) {
+		// The locks are taken over once the lease expires
+		log.Warn("Failed to release transaction locks",
+			zap.Int64("lease", tx.lockLease),
+			zap.Int("locks", len(tx.locks)),
+			zap.Error(err),
+			zap.String("component", "mysql"))
+	}
+	tx.lockLease = 0
+	tx.locks = nil
+}
+
+func (h *MySQLHandler) lockTTL() time.Duration {
+	if h.lockConfig.TTL > 0 {
+		return h.lockConfig.TTL
+	}
+	return defaultLockTTL
+}
+
+func (h *MySQLHandler) lockWaitTimeout() time.Duration {
+	if h.lockConfig.WaitTimeout > 0 {
+		return h.lockConfig.WaitTimeout
+	}
+	return defaultLockWaitTimeout
+}
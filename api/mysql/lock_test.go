@@ -0,0 +1,140 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+)
+
+func TestSelectForUpdate(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	locks := LockConfig{TTL: time.Minute, WaitTimeout: 100 * time.Millisecond}
+	h1 := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	h1.lockConfig = locks
+	h2 := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	h2.lockConfig = locks
+
+	exec := func(h *MySQLHandler, query string) error {
+		t.Helper()
+		_, err := h.HandleQuery(query)
+		return err
+	}
+	mustExec := func(h *MySQLHandler, query string) {
+		t.Helper()
+		if err := exec(h, query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	mustExec(h1, "INSERT INTO kv (key, value) VALUES ('k', '1')")
+	mustExec(h1, "BEGIN")
+	mustExec(h1, "SELECT * FROM kv WHERE key = 'k' FOR UPDATE")
+
+	// A second locking read waits for the lock and times out
+	mustExec(h2, "BEGIN")
+	start := time.Now()
+	err := exec(h2, "SELECT * FROM kv WHERE key = 'k' FOR UPDATE")
+	expectError(t, "SELECT FOR UPDATE of a locked key", err, ErrLockWaitTimeout, "HY000")
+	if elapsed := time.Since(start); elapsed < locks.WaitTimeout {
+		t.Fatalf("lock wait returned after %v, want at least %v", elapsed, locks.WaitTimeout)
+	}
+
+	// Plain reads are not blocked, and the lock is re-entrant
+	mustExec(h2, "SELECT * FROM kv WHERE key = 'k'")
+	mustExec(h1, "SELECT * FROM kv WHERE key LIKE 'k%' FOR UPDATE")
+
+	// COMMIT releases the lock
+	mustExec(h1, "UPDATE kv SET value = '2' WHERE key = 'k'")
+	mustExec(h1, "COMMIT")
+	mustExec(h2, "SELECT * FROM kv WHERE key = 'k' FOR UPDATE")
+	resp, err := store.Range(context.Background(), lockPrefix+"k", "", 1, 0)
+	if err != nil || len(resp.Kvs) != 1 {
+		t.Fatalf("lock of k = %v, %v, want held by the second transaction", resp, err)
+	}
+
+	// ROLLBACK releases it too
+	mustExec(h2, "ROLLBACK")
+	resp, err = store.Range(context.Background(), lockPrefix+"k", "", 1, 0)
+	if err != nil || len(resp.Kvs) != 0 {
+		t.Fatalf("lock of k after ROLLBACK = %v, %v, want released", resp, err)
+	}
+
+	// Outside a transaction FOR UPDATE is a plain read
+	mustExec(h1, "SELECT * FROM kv WHERE key = 'k' FOR UPDATE")
+	if h1.getTransaction() != nil {
+		t.Fatal("autocommit SELECT FOR UPDATE started a transaction")
+	}
+}
+
+func TestSelectForUpdateExpiredLock(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	h.lockConfig = LockConfig{TTL: time.Minute, WaitTimeout: 5 * time.Second}
+
+	// A transaction that stopped without releasing its lock
+	if _, err := store.LeaseGrant(ctx, 42, 1); err != nil {
+		t.Fatalf("LeaseGrant: %v", err)
+	}
+	if _, _, err := store.PutWithLease(ctx, lockPrefix+"k", "", 42); err != nil {
+		t.Fatalf("PutWithLease: %v", err)
+	}
+	stale := &Transaction{lockLease: 42, locks: map[string]bool{"k": true}}
+
+	if _, err := h.HandleQuery("BEGIN"); err != nil {
+		t.Fatalf("BEGIN: %v", err)
+	}
+	if _, err := h.HandleQuery("SELECT * FROM kv WHERE key = 'k' FOR UPDATE"); err != nil {
+		t.Fatalf("SELECT FOR UPDATE of an expired lock: %v", err)
+	}
+
+	resp, err := store.Range(ctx, lockPrefix+"k", "", 1, 0)
+	if err != nil || len(resp.Kvs) != 1 || resp.Kvs[0].Lease == 42 {
+		t.Fatalf("lock of k = %v, %v, want taken over", resp, err)
+	}
+
+	// The transaction whose lock was taken over cannot commit
+	txnResp, err := store.Txn(ctx, lockCompares(stale), []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("k"), Value: []byte("x")}}, nil)
+	if err != nil || txnResp.Succeeded {
+		t.Fatalf("commit with a lost lock = %v, %v, want failed compare", txnResp, err)
+	}
+	if _, err := h.HandleQuery("COMMIT"); err != nil {
+		t.Fatalf("COMMIT: %v", err)
+	}
+}
+
+func TestStripForUpdate(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		ok    bool
+	}{
+		{"SELECT * FROM kv WHERE key = 'a' FOR UPDATE", "SELECT * FROM kv WHERE key = 'a'", true},
+		{"select * from kv for  update;", "select * from kv", true},
+		{"SELECT * FROM kv WHERE value = 'for update'", "SELECT * FROM kv WHERE value = 'for update'", false},
+		{"SELECT * FROM kv", "SELECT * FROM kv", false},
+	}
+	for _, tt := range tests {
+		got, ok := stripForUpdate(tt.query)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stripForUpdate(%q) = %q, %v, want %q, %v", tt.query, got, ok, tt.want, tt.ok)
+		}
+	}
+}
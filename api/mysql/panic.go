@@ -0,0 +1,37 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"metaStore/pkg/reliability"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+)
+
+// recoverQuery turns a panic while executing query into ER_INTERNAL_ERROR, so
+// the client gets an error and the connection stays usable. Defer it with the
+// named error result of the statement handler
+func (h *MySQLHandler) recoverQuery(query string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	reliability.ReportPanic(reliability.PanicSourceMySQL, "mysql-query", r,
+		zap.String("user", h.user),
+		zap.String("query", query),
+		zap.Bool("in_transaction", h.getTransaction() != nil))
+	*err = mysql.NewError(ErrInternalError, "internal server error")
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/reliability"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// panickingStore panics on every call, its embedded Store is nil
+type panickingStore struct {
+	kvstore.Store
+}
+
+func TestQueryPanicRecovery(t *testing.T) {
+	h := NewMySQLHandler(panickingStore{}, NewAuthProvider("root", ""))
+	before := reliability.PanicCounts()[reliability.PanicSourceMySQL]
+
+	_, err := h.HandleQuery("SELECT value FROM kv WHERE key = '/app/config'")
+	var myErr *mysql.MyError
+	if !errors.As(err, &myErr) || myErr.Code != ErrInternalError {
+		t.Fatalf("Expected error %d from a panicking query, got %v", ErrInternalError, err)
+	}
+	if got := reliability.PanicCounts()[reliability.PanicSourceMySQL]; got != before+1 {
+		t.Errorf("Expected %d recovered MySQL panics, got %d", before+1, got)
+	}
+
+	// The connection stays usable
+	if _, err := h.HandleQuery("SELECT 1"); err != nil {
+		t.Errorf("SELECT 1 after a recovered panic: %v", err)
+	}
+}
@@ -16,6 +16,7 @@ package parser
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/pingcap/tidb/pkg/parser"
@@ -40,7 +41,15 @@ func NewSQLParser() *SQLParser {
 func (p *SQLParser) Parse(sql string) (*QueryPlan, error) {
 	stmts, _, err := p.parser.Parse(sql, "", "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse SQL: %w", err)
+		// KEY is a reserved word, but clients commonly leave the kv table's key column unquoted
+		quoted := quoteKeyColumn(sql)
+		if quoted == sql {
+			return nil, fmt.Errorf("failed to parse SQL: %w", err)
+		}
+		var retryErr error
+		if stmts, _, retryErr = p.parser.Parse(quoted, "", ""); retryErr != nil {
+			return nil, fmt.Errorf("failed to parse SQL: %w", err)
+		}
 	}
 
 	if len(stmts) == 0 {
@@ -59,6 +68,15 @@ func (p *SQLParser) Parse(sql string) (*QueryPlan, error) {
 		return p.parseUpdateStmt(stmt)
 	case *ast.DeleteStmt:
 		return p.parseDeleteStmt(stmt)
+	case *ast.CreateIndexStmt:
+		return p.parseCreateIndexStmt(stmt)
+	case *ast.DropIndexStmt:
+		return &QueryPlan{
+			Type:      QueryTypeDropIndex,
+			TableName: stmt.Table.Name.L,
+			IndexName: stmt.IndexName,
+			IfExists:  stmt.IfExists,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unsupported statement type: %T", stmt)
 	}
@@ -108,6 +126,17 @@ func (p *SQLParser) parseSelectStmt(stmt *ast.SelectStmt) (*QueryPlan, error) {
 		plan.Where = whereExpr
 	}
 
+	// Parse ORDER BY, keys are unique so only the first column matters
+	if stmt.OrderBy != nil && len(stmt.OrderBy.Items) > 0 {
+		item := stmt.OrderBy.Items[0]
+		colName, ok := item.Expr.(*ast.ColumnNameExpr)
+		if !ok {
+			return nil, fmt.Errorf("unsupported ORDER BY expression")
+		}
+		plan.OrderBy = colName.Name.Name.L
+		plan.OrderDesc = item.Desc
+	}
+
 	// Parse LIMIT
 	if stmt.Limit != nil {
 		if stmt.Limit.Count != nil {
@@ -180,10 +209,10 @@ func (p *SQLParser) parseBinaryOp(expr *ast.BinaryOperationExpr) (*WhereConditio
 		}, nil
 
 	case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE:
-		// Simple comparison: key = 'value'
-		colName, ok := expr.L.(*ast.ColumnNameExpr)
-		if !ok {
-			return nil, fmt.Errorf("left side of comparison must be column name, got %T", expr.L)
+		// Simple comparison: key = 'value' or JSON_EXTRACT(value, '$.name') = 'x'
+		column, jsonPath, err := parseOperand(expr.L)
+		if err != nil {
+			return nil, fmt.Errorf("left side of comparison: %w", err)
 		}
 
 		// Extract value from right side
@@ -194,9 +223,10 @@ func (p *SQLParser) parseBinaryOp(expr *ast.BinaryOperationExpr) (*WhereConditio
 
 		return &WhereCondition{
 			Type:     ConditionTypeSimple,
-			Key:      colName.Name.Name.L,
+			Key:      column,
 			Value:    value,
 			Operator: expr.Op.String(),
+			JSONPath: jsonPath,
 		}, nil
 
 	default:
@@ -206,9 +236,9 @@ func (p *SQLParser) parseBinaryOp(expr *ast.BinaryOperationExpr) (*WhereConditio
 
 // parseLikeExpr parses LIKE expression
 func (p *SQLParser) parseLikeExpr(expr *ast.PatternLikeOrIlikeExpr) (*WhereCondition, error) {
-	colName, ok := expr.Expr.(*ast.ColumnNameExpr)
-	if !ok {
-		return nil, fmt.Errorf("LIKE left side must be column name, got %T", expr.Expr)
+	column, jsonPath, err := parseOperand(expr.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("LIKE left side: %w", err)
 	}
 
 	// Extract pattern value
@@ -218,19 +248,20 @@ func (p *SQLParser) parseLikeExpr(expr *ast.PatternLikeOrIlikeExpr) (*WhereCondi
 
 	return &WhereCondition{
 		Type:     ConditionTypeSimple,
-		Key:      colName.Name.Name.L,
+		Key:      column,
 		IsLike:   true,
 		Prefix:   prefix,
 		Value:    patternStr,
 		Operator: "LIKE",
+		JSONPath: jsonPath,
 	}, nil
 }
 
 // parseInExpr parses IN expression
 func (p *SQLParser) parseInExpr(expr *ast.PatternInExpr) (*WhereCondition, error) {
-	colName, ok := expr.Expr.(*ast.ColumnNameExpr)
-	if !ok {
-		return nil, fmt.Errorf("IN left side must be column name, got %T", expr.Expr)
+	column, jsonPath, err := parseOperand(expr.Expr)
+	if err != nil {
+		return nil, fmt.Errorf("IN left side: %w", err)
 	}
 
 	// Parse list of values
@@ -245,12 +276,79 @@ func (p *SQLParser) parseInExpr(expr *ast.PatternInExpr) (*WhereCondition, error
 
 	return &WhereCondition{
 		Type:     ConditionTypeIn,
-		Key:      colName.Name.Name.L,
+		Key:      column,
 		InValues: values,
 		Operator: "IN",
+		JSONPath: jsonPath,
 	}, nil
 }
 
+// parseOperand parses the left side of a condition: a column name, or
+// JSON_EXTRACT(column, 'path') optionally wrapped in JSON_UNQUOTE
+// (column->'path' and column->>'path' are rewritten to these by the TiDB parser)
+func parseOperand(expr ast.ExprNode) (column string, jsonPath string, err error) {
+	switch e := expr.(type) {
+	case *ast.ColumnNameExpr:
+		return e.Name.Name.L, "", nil
+	case *ast.FuncCallExpr:
+		switch e.FnName.L {
+		case "json_unquote":
+			if len(e.Args) == 1 {
+				if inner, ok := e.Args[0].(*ast.FuncCallExpr); ok && inner.FnName.L == "json_extract" {
+					return parseOperand(inner)
+				}
+			}
+		case "json_extract":
+			if len(e.Args) == 2 {
+				col, ok := e.Args[0].(*ast.ColumnNameExpr)
+				path, isString := extractValue(e.Args[1]).(string)
+				if ok && isString {
+					return col.Name.Name.L, path, nil
+				}
+			}
+		}
+		return "", "", fmt.Errorf("unsupported function %s", e.FnName.O)
+	default:
+		return "", "", fmt.Errorf("must be column name or JSON_EXTRACT, got %T", expr)
+	}
+}
+
+// parseCreateIndexStmt parses CREATE INDEX on the value column or on a JSON path within it:
+//
+//	CREATE INDEX idx_value ON kv (value)
+//	CREATE INDEX idx_name ON kv ((JSON_EXTRACT(value, '$.name')))
+func (p *SQLParser) parseCreateIndexStmt(stmt *ast.CreateIndexStmt) (*QueryPlan, error) {
+	if stmt.KeyType != ast.IndexKeyTypeNone {
+		return nil, fmt.Errorf("only plain secondary indexes are supported")
+	}
+	if len(stmt.IndexPartSpecifications) != 1 {
+		return nil, fmt.Errorf("index must have exactly one key part")
+	}
+
+	plan := &QueryPlan{
+		Type:      QueryTypeCreateIndex,
+		TableName: stmt.Table.Name.L,
+		IndexName: stmt.IndexName,
+		IfExists:  stmt.IfNotExists,
+	}
+
+	part := stmt.IndexPartSpecifications[0]
+	column := ""
+	if part.Column != nil {
+		column = part.Column.Name.L
+	} else {
+		col, jsonPath, err := parseOperand(part.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("index expression: %w", err)
+		}
+		column, plan.IndexJSONPath = col, jsonPath
+	}
+	if column != "value" {
+		return nil, fmt.Errorf("only the value column can be indexed, got %q", column)
+	}
+	return plan, nil
+}
+
 // parseInsertStmt parses INSERT statement (placeholder for future implementation)
 func (p *SQLParser) parseInsertStmt(stmt *ast.InsertStmt) (*QueryPlan, error) {
 	return &QueryPlan{Type: QueryTypeInsert}, fmt.Errorf("INSERT not yet implemented")
@@ -266,13 +364,59 @@ func (p *SQLParser) parseDeleteStmt(stmt *ast.DeleteStmt) (*QueryPlan, error) {
 	return &QueryPlan{Type: QueryTypeDelete}, fmt.Errorf("DELETE not yet implemented")
 }
 
+// quoteKeyColumn backquotes bare KEY words outside string literals and quoted identifiers
+func quoteKeyColumn(sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// Copy the quoted section verbatim, honoring backslash escapes in strings
+			j := i + 1
+			for ; j < len(sql) && sql[j] != c; j++ {
+				if sql[j] == '\\' && c != '`' {
+					j++
+				}
+			}
+			if j >= len(sql) {
+				j = len(sql) - 1
+			}
+			b.WriteString(sql[i : j+1])
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(sql) && isIdentChar(sql[j]) {
+				j++
+			}
+			if word := sql[i:j]; strings.EqualFold(word, "key") {
+				b.WriteString("`" + word + "`")
+			} else {
+				b.WriteString(word)
+			}
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
 // Helper functions for value extraction
 
 // extractValue extracts value from an expression node
 func extractValue(expr ast.ExprNode) interface{} {
 	switch e := expr.(type) {
 	case *test_driver.ValueExpr:
-		return e.GetValue()
+		v := e.GetValue()
+		// Hex literals (X'..', 0x..) come back as a named []byte type
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes())
+		}
+		return v
 	default:
 		return nil
 	}
@@ -276,3 +276,98 @@ func TestSQLParser_Limit(t *testing.T) {
 		})
 	}
 }
+
+func TestSQLParser_JSONExtract(t *testing.T) {
+	parser := NewSQLParser()
+
+	tests := []struct {
+		name     string
+		sql      string
+		wantPath string
+		wantLike bool
+	}{
+		{
+			name:     "JSON_EXTRACT equality",
+			sql:      "SELECT * FROM kv WHERE JSON_EXTRACT(value, '$.name') = 'x'",
+			wantPath: "$.name",
+		},
+		{
+			name:     "-> operator",
+			sql:      "SELECT * FROM kv WHERE value->'$.a.b' = 'x'",
+			wantPath: "$.a.b",
+		},
+		{
+			name:     "->> operator with LIKE",
+			sql:      "SELECT * FROM kv WHERE value->>'$.name' LIKE 'x%'",
+			wantPath: "$.name",
+			wantLike: true,
+		},
+		{
+			name:     "unquoted key column",
+			sql:      "SELECT key FROM kv WHERE value LIKE '%key%'",
+			wantLike: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := parser.Parse(tt.sql)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if plan.Where == nil || plan.Where.Key != "value" {
+				t.Fatalf("WHERE = %+v, want condition on value", plan.Where)
+			}
+			if plan.Where.JSONPath != tt.wantPath {
+				t.Errorf("JSONPath = %q, want %q", plan.Where.JSONPath, tt.wantPath)
+			}
+			if plan.Where.IsLike != tt.wantLike {
+				t.Errorf("IsLike = %v, want %v", plan.Where.IsLike, tt.wantLike)
+			}
+		})
+	}
+
+	// The pattern literal must not be rewritten by the KEY quoting retry
+	plan, err := parser.Parse("SELECT key FROM kv WHERE value LIKE '%key%'")
+	if err != nil || plan.Where.Value != "%key%" {
+		t.Errorf("pattern = %v, err = %v", plan.Where.Value, err)
+	}
+}
+
+func TestSQLParser_IndexDDL(t *testing.T) {
+	parser := NewSQLParser()
+
+	plan, err := parser.Parse("CREATE INDEX idx_name ON kv ((JSON_EXTRACT(value, '$.name')))")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if plan.Type != QueryTypeCreateIndex || plan.IndexName != "idx_name" || plan.TableName != "kv" || plan.IndexJSONPath != "$.name" {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	plan, err = parser.Parse("CREATE INDEX IF NOT EXISTS idx_value ON kv (value)")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if plan.IndexJSONPath != "" || !plan.IfExists {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	plan, err = parser.Parse("DROP INDEX idx_value ON kv")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if plan.Type != QueryTypeDropIndex || plan.IndexName != "idx_value" {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	for _, sql := range []string{
+		"CREATE INDEX idx ON kv (`key`)",
+		"CREATE UNIQUE INDEX idx ON kv (value)",
+		"CREATE INDEX idx ON kv (value, `key`)",
+	} {
+		if _, err := parser.Parse(sql); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
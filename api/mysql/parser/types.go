@@ -22,6 +22,13 @@ type QueryPlan struct {
 	Where     *WhereCondition // WHERE clause
 	Limit     int64
 	Offset    int64
+	OrderBy   string // ORDER BY column, empty when absent
+	OrderDesc bool   // ORDER BY ... DESC
+
+	// CREATE INDEX / DROP INDEX
+	IndexName     string
+	IndexJSONPath string // Empty for an index on the whole value
+	IfExists      bool   // IF NOT EXISTS for CREATE, IF EXISTS for DROP
 }
 
 // QueryType represents the type of SQL query
@@ -32,6 +39,8 @@ const (
 	QueryTypeInsert
 	QueryTypeUpdate
 	QueryTypeDelete
+	QueryTypeCreateIndex
+	QueryTypeDropIndex
 )
 
 // WhereCondition represents WHERE clause conditions
@@ -44,6 +53,7 @@ type WhereCondition struct {
 	Prefix   string              // For LIKE 'prefix%'
 	Children []*WhereCondition   // For AND/OR
 	InValues []interface{}       // For IN clause
+	JSONPath string              // For JSON_EXTRACT(value, '$.path') on the left side
 }
 
 // ConditionType represents the type of WHERE condition
@@ -67,6 +77,10 @@ func (qt QueryType) String() string {
 		return "UPDATE"
 	case QueryTypeDelete:
 		return "DELETE"
+	case QueryTypeCreateIndex:
+		return "CREATE INDEX"
+	case QueryTypeDropIndex:
+		return "DROP INDEX"
 	default:
 		return "UNKNOWN"
 	}
@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"strings"
 
+	"metaStore/api/etcd"
 	"metaStore/internal/kvstore"
+	"metaStore/pkg/keycodec"
 	"metaStore/pkg/log"
 	"metaStore/api/mysql/parser"
 
@@ -31,6 +33,7 @@ import (
 // - Multiple columns: SELECT key, value FROM kv
 // - LIKE queries: WHERE key LIKE 'prefix%'
 // - Exact match: WHERE key = 'exact'
+// - Value filters: WHERE value LIKE '%x%', WHERE JSON_EXTRACT(value, '$.name') = 'x'
 func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.Result, error) {
 	queryUpper := strings.ToUpper(query)
 
@@ -39,11 +42,25 @@ func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.R
 		return h.handleSystemSelect(ctx, query)
 	}
 
+	// Virtual tables exposing cluster state (information_schema.metastore_*)
+	if isInfoSchemaSelect(query) {
+		return h.handleInfoSchemaSelect(ctx, query)
+	}
+
+	// Long-polling change events (kv_changes)
+	if isChangesSelect(query) {
+		return h.handleChangesSelect(ctx, query)
+	}
+
 	// Handle constant SELECT queries (SELECT 1, SELECT 'hello', etc.)
 	// These don't have FROM clause and just return constant values
 	if !strings.Contains(queryUpper, " FROM ") {
 		return h.handleConstantSelect(ctx, query)
 	}
+	query, forUpdate := stripForUpdate(query)
+	if forUpdate && h.readOnly() {
+		return nil, h.readOnlyError()
+	}
 
 	// Try advanced SQL parser first for robust parsing
 	var columns []string
@@ -53,6 +70,12 @@ func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.R
 	if parseErr == nil && plan != nil {
 		// Successfully parsed with advanced parser
 		columns = plan.Columns
+		if plan.OrderBy != "" && plan.OrderBy != "key" {
+			return nil, mysql.NewError(ErrNotSupported, "ORDER BY is only supported on the key column")
+		}
+		if err := h.decodeKeyCondition(plan.Where); err != nil {
+			return nil, err
+		}
 		whereClause = h.convertWhereCondition(plan.Where)
 		log.Debug("Using advanced SQL parser",
 			zap.Strings("columns", columns),
@@ -61,6 +84,11 @@ func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.R
 		// Fallback to simple string parsing
 		columns = h.parseSelectColumns(query)
 		whereClause = h.parseWhereClause(query)
+		if whereClause != nil {
+			if err := h.decodeWhereKey(whereClause); err != nil {
+				return nil, err
+			}
+		}
 		log.Debug("Using simple parser (fallback)",
 			zap.Strings("columns", columns),
 			zap.String("component", "mysql"))
@@ -72,42 +100,117 @@ func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.R
 
 	// Determine revision to read from (snapshot isolation)
 	tx := h.getTransaction()
-	var readRevision int64 = 0 // 0 means latest
+	readRevision := h.readRevision // 0 means latest
 	if tx != nil && tx.active {
 		readRevision = tx.startRev // Read from transaction snapshot
+		if forUpdate {
+			// Locking reads see the latest committed rows, as in InnoDB
+			readRevision = 0
+		}
 		log.Debug("Reading from transaction snapshot",
 			zap.Int64("snapshot_rev", readRevision),
 			zap.String("component", "mysql"))
+	} else {
+		// Outside a transaction the locks would be released right away
+		forUpdate = false
 	}
 
-	var resp *kvstore.RangeResponse
-	var err error
+	// ORDER BY key DESC scans the range backwards instead of sorting it
+	rangeKeys := h.rangeUserKeys
+	if parseErr == nil && plan.OrderDesc {
+		rangeKeys = h.reverseRangeUserKeys
+	}
 
-	if whereClause == nil {
-		// No WHERE clause - return all keys (with limit)
-		resp, err = h.store.Range(ctx, "", "\x00", 100, readRevision)
-	} else if whereClause.isLike {
-		// LIKE query - use prefix matching
-		prefix := whereClause.likePrefix
-		endKey := h.getPrefixEndKey(prefix)
-		resp, err = h.store.Range(ctx, prefix, endKey, 1000, readRevision)
-	} else {
-		// Exact match query
-		resp, err = h.store.Range(ctx, whereClause.key, "", 1, readRevision)
+	filtered := parseErr == nil && plan.Where != nil && (whereClause == nil || usesValue(plan.Where))
+	exact := !filtered && whereClause != nil && !whereClause.isLike
+	if exact {
+		if err := h.checkPermission("SELECT", whereClause.key, etcd.PermissionRead); err != nil {
+			return nil, err
+		}
+	}
+
+	// Table and LIKE scans skip keys the user cannot read inside the store, so
+	// hidden keys do not use up the scan limit
+	scanCtx := ctx
+	if !filtered && !exact {
+		start, end := "", "\x00"
+		if whereClause != nil {
+			start, end = whereClause.likePrefix, h.getPrefixEndKey(whereClause.likePrefix)
+		}
+		var err error
+		if scanCtx, err = h.rangeContext(ctx, "SELECT", start, end); err != nil {
+			return nil, err
+		}
+	}
+
+	read := func() ([]*kvstore.KeyValue, error) {
+		var resp *kvstore.RangeResponse
+		var err error
+
+		if filtered {
+			// Value predicates and compound conditions - evaluated row by row, with
+			// candidates from a secondary index when one applies
+			resp, err = h.selectFiltered(ctx, plan, readRevision)
+		} else if whereClause == nil {
+			// No WHERE clause - return all keys (with limit)
+			resp, err = rangeKeys(scanCtx, "", "\x00", 100, readRevision)
+		} else if whereClause.isLike {
+			// LIKE query - use prefix matching
+			prefix := whereClause.likePrefix
+			endKey := h.getPrefixEndKey(prefix)
+			resp, err = rangeKeys(scanCtx, prefix, endKey, 1000, readRevision)
+		} else {
+			// Exact match query
+			resp, err = h.store.Range(ctx, whereClause.key, "", 1, readRevision)
+		}
+
+		if err != nil {
+			log.Error("Failed to query keys",
+				zap.Error(err),
+				zap.String("component", "mysql"))
+			return nil, NewReadError("query", err)
+		}
+
+		// Range queries only return keys the user may read
+		var kvs []*kvstore.KeyValue
+		for _, kv := range resp.Kvs {
+			if h.canRead(kv.Key) {
+				kvs = append(kvs, kv)
+			}
+		}
+		return kvs, nil
 	}
 
+	// SELECT ... FOR UPDATE locks the key of an exact match before reading it,
+	// even when it does not exist yet. Other queries lock the rows they found
+	// and read them again; rows inserted in between come back unlocked, since
+	// there are no gap locks
+	if forUpdate && exact {
+		if err := h.lockKeys(ctx, tx, []string{whereClause.key}); err != nil {
+			return nil, err
+		}
+	}
+	kvs, err := read()
 	if err != nil {
-		log.Error("Failed to query keys",
-			zap.Error(err),
-			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
-			fmt.Sprintf("failed to query: %v", err))
+		return nil, err
+	}
+	if forUpdate && !exact && len(kvs) > 0 {
+		keys := make([]string, len(kvs))
+		for i, kv := range kvs {
+			keys[i] = string(kv.Key)
+		}
+		if err := h.lockKeys(ctx, tx, keys); err != nil {
+			return nil, err
+		}
+		if kvs, err = read(); err != nil {
+			return nil, err
+		}
 	}
 
 	// Track reads in transaction for conflict detection
 	if tx != nil && tx.active {
 		tx.mu.Lock()
-		for _, kv := range resp.Kvs {
+		for _, kv := range kvs {
 			key := string(kv.Key)
 			// Record the ModRevision of each key read
 			tx.readSet[key] = kv.ModRevision
@@ -117,12 +220,12 @@ func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.R
 
 	// Build result set with selected columns
 	var rows [][]interface{}
-	for _, kv := range resp.Kvs {
+	for _, kv := range kvs {
 		row := make([]interface{}, len(columns))
 		for i, col := range columns {
 			switch col {
 			case "key":
-				row[i] = kv.Key
+				row[i] = h.encodeKey(kv.Key)
 			case "value":
 				row[i] = kv.Value
 			default:
@@ -150,20 +253,27 @@ func (h *MySQLHandler) handleSelect(ctx context.Context, query string) (*mysql.R
 
 // handleSelectAll handles SELECT * queries (range query)
 func (h *MySQLHandler) handleSelectAll(ctx context.Context) (*mysql.Result, error) {
+	ctx, err := h.rangeContext(ctx, "SELECT", "", "\x00")
+	if err != nil {
+		return nil, err
+	}
+
 	// Query all keys
-	resp, err := h.store.Range(ctx, "", "\x00", 100, 0) // Limit to 100 keys
+	resp, err := h.rangeUserKeys(ctx, "", "\x00", 100, 0) // Limit to 100 keys
 	if err != nil {
 		log.Error("Failed to query all keys",
 			zap.Error(err),
 			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
-			fmt.Sprintf("failed to query: %v", err))
+		return nil, NewReadError("query", err)
 	}
 
 	// Build result set
 	var rows [][]interface{}
 	for _, kv := range resp.Kvs {
-		rows = append(rows, []interface{}{kv.Key, kv.Value})
+		if !h.canRead(kv.Key) {
+			continue
+		}
+		rows = append(rows, []interface{}{h.encodeKey(kv.Key), kv.Value})
 	}
 
 	resultset, err := mysql.BuildSimpleResultset(
@@ -227,6 +337,15 @@ func (h *MySQLHandler) handleSystemSelect(ctx context.Context, query string) (*m
 	} else if strings.Contains(queryUpper, "@@TX_ISOLATION") || strings.Contains(queryUpper, "@@TRANSACTION_ISOLATION") {
 		columnName = "@@tx_isolation"
 		value = "REPEATABLE-READ"
+	} else if selectKeyEncodingRe.MatchString(query) {
+		columnName = "@@" + keyEncodingVar
+		value = h.keyCodec.String()
+	} else if selectReadRevisionRe.MatchString(query) {
+		columnName = "@@" + readRevisionVar
+		value = h.readRevision
+	} else if selectChangesTimeoutRe.MatchString(query) {
+		columnName = "@@" + changesTimeoutVar
+		value = h.changesTimeout.Seconds()
 	} else if strings.Contains(queryUpper, "$$") {
 		// Handle delimiter check query (SELECT $$)
 		columnName = "$$"
@@ -252,54 +371,134 @@ func (h *MySQLHandler) handleSystemSelect(ctx context.Context, query string) (*m
 	}, nil
 }
 
-// handleInsert handles INSERT queries
-func (h *MySQLHandler) handleInsert(ctx context.Context, query string) (*mysql.Result, error) {
+// handleInsert handles INSERT and REPLACE queries. INSERT fails with
+// ER_DUP_ENTRY when a key already exists, REPLACE overwrites it
+func (h *MySQLHandler) handleInsert(ctx context.Context, query string, replace bool) (*mysql.Result, error) {
 	// Parse INSERT query
-	// Simple parser for: INSERT INTO kv (key, value) VALUES ('k1', 'v1')
-	key, value, err := h.parseKeyValueFromInsert(query)
+	// Simple parser for: INSERT INTO kv (key, value) VALUES ('k1', 'v1'), ('k2', 'v2')
+	rows, err := h.parseRowsFromInsert(query)
 	if err != nil {
 		return nil, mysql.NewError(mysql.ER_SYNTAX_ERROR, err.Error())
 	}
+	for i := range rows {
+		if rows[i].Key, err = h.decodeKey(rows[i].Key); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, row := range rows {
+		if err := h.checkPermission("INSERT", row.Key, etcd.PermissionWrite); err != nil {
+			return nil, err
+		}
+	}
+
+	opType := "INSERT"
+	if replace {
+		opType = "PUT"
+	}
 
 	// Check if we're in a transaction
 	tx := h.getTransaction()
 	if tx != nil && tx.active {
-		// Buffer operation in transaction
+		// Buffer operation in transaction, COMMIT checks that inserted keys are absent
 		tx.mu.Lock()
-		tx.operations = append(tx.operations, TxOp{
-			OpType: "PUT",
-			Key:    key,
-			Value:  value,
-		})
+		for _, row := range rows {
+			tx.operations = append(tx.operations, TxOp{
+				OpType: opType,
+				Key:    row.Key,
+				Value:  row.Val,
+			})
+		}
 		tx.mu.Unlock()
 
 		log.Debug("Buffered INSERT in transaction",
-			zap.String("key", key),
+			zap.Int("rows", len(rows)),
 			zap.String("component", "mysql"))
 
 		return &mysql.Result{
 			Status:       0,
-			AffectedRows: 1,
+			AffectedRows: uint64(len(rows)),
 		}, nil
 	}
 
 	// Autocommit mode - execute immediately
-	_, _, err = h.store.PutWithLease(ctx, key, value, 0)
+	if replace && len(rows) == 1 {
+		_, _, err = h.store.PutWithLease(ctx, rows[0].Key, rows[0].Val, 0)
+	} else {
+		// A multi-row statement is applied atomically as one proposal, and an
+		// INSERT only if none of its keys exist
+		ops := make([]kvstore.Op, len(rows))
+		var cmps []kvstore.Compare
+		seen := make(map[string]bool, len(rows))
+		for i, row := range rows {
+			ops[i] = kvstore.Op{Type: kvstore.OpPut, Key: []byte(row.Key), Value: []byte(row.Val)}
+			if replace {
+				continue
+			}
+			if seen[row.Key] {
+				return nil, h.duplicateEntryError(row.Key)
+			}
+			seen[row.Key] = true
+			cmps = append(cmps, absentCompare(row.Key))
+		}
+		var resp *kvstore.TxnResponse
+		resp, err = h.store.Txn(ctx, cmps, ops, nil)
+		if err == nil && !resp.Succeeded {
+			keys := make([]string, len(rows))
+			for i, row := range rows {
+				keys[i] = row.Key
+			}
+			dup := h.existingKey(ctx, keys)
+			if dup == "" {
+				dup = keys[0]
+			}
+			return nil, h.duplicateEntryError(dup)
+		}
+	}
 	if err != nil {
 		log.Error("Failed to insert key-value",
 			zap.Error(err),
-			zap.String("key", key),
+			zap.Int("rows", len(rows)),
 			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
-			fmt.Sprintf("failed to insert: %v", err))
+		return nil, NewWriteError("insert", err)
 	}
 
 	return &mysql.Result{
 		Status:       0,
-		AffectedRows: 1,
+		AffectedRows: uint64(len(rows)),
 	}, nil
 }
 
+// absentCompare is the Txn condition of an INSERT: the key has never been
+// created, or was deleted since
+func absentCompare(key string) kvstore.Compare {
+	return kvstore.Compare{
+		Target:      kvstore.CompareCreate,
+		Result:      kvstore.CompareEqual,
+		Key:         []byte(key),
+		TargetUnion: kvstore.CompareUnion{CreateRevision: 0},
+	}
+}
+
+// existingKey returns the first of keys that exists, or "" if none does
+func (h *MySQLHandler) existingKey(ctx context.Context, keys []string) string {
+	for _, key := range keys {
+		resp, err := h.store.Range(ctx, key, "", 1, 0)
+		if err == nil && len(resp.Kvs) > 0 {
+			return key
+		}
+	}
+	return ""
+}
+
+// duplicateEntryError creates ER_DUP_ENTRY for key in the session's key encoding
+func (h *MySQLHandler) duplicateEntryError(key string) error {
+	if h.keyCodec != keycodec.Raw {
+		key = h.keyCodec.Encode([]byte(key))
+	}
+	return NewDuplicateEntryError(key)
+}
+
 // handleUpdate handles UPDATE queries
 func (h *MySQLHandler) handleUpdate(ctx context.Context, query string) (*mysql.Result, error) {
 	// Parse UPDATE query
@@ -308,6 +507,13 @@ func (h *MySQLHandler) handleUpdate(ctx context.Context, query string) (*mysql.R
 	if err != nil {
 		return nil, mysql.NewError(mysql.ER_SYNTAX_ERROR, err.Error())
 	}
+	if key, err = h.decodeKey(key); err != nil {
+		return nil, err
+	}
+
+	if err := h.checkPermission("UPDATE", key, etcd.PermissionWrite); err != nil {
+		return nil, err
+	}
 
 	// Check if we're in a transaction
 	tx := h.getTransaction()
@@ -338,8 +544,7 @@ func (h *MySQLHandler) handleUpdate(ctx context.Context, query string) (*mysql.R
 			zap.Error(err),
 			zap.String("key", key),
 			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
-			fmt.Sprintf("failed to update: %v", err))
+		return nil, NewWriteError("update", err)
 	}
 
 	return &mysql.Result{
@@ -356,6 +561,14 @@ func (h *MySQLHandler) handleDelete(ctx context.Context, query string) (*mysql.R
 	if key == "" {
 		return nil, mysql.NewError(mysql.ER_SYNTAX_ERROR, "invalid DELETE syntax")
 	}
+	key, err := h.decodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.checkPermission("DELETE", key, etcd.PermissionWrite); err != nil {
+		return nil, err
+	}
 
 	// Check if we're in a transaction
 	tx := h.getTransaction()
@@ -386,8 +599,7 @@ func (h *MySQLHandler) handleDelete(ctx context.Context, query string) (*mysql.R
 			zap.Error(err),
 			zap.String("key", key),
 			zap.String("component", "mysql"))
-		return nil, mysql.NewError(mysql.ER_UNKNOWN_ERROR,
-			fmt.Sprintf("failed to delete: %v", err))
+		return nil, NewWriteError("delete", err)
 	}
 
 	return &mysql.Result{
@@ -401,6 +613,7 @@ func (h *MySQLHandler) handleShowDatabases(ctx context.Context) (*mysql.Result,
 	// Return a virtual database list
 	databases := [][]interface{}{
 		{"metastore"},
+		{infoSchemaDB},
 	}
 
 	resultset, err := mysql.BuildSimpleResultset(
@@ -420,9 +633,10 @@ func (h *MySQLHandler) handleShowDatabases(ctx context.Context) (*mysql.Result,
 
 // handleShowTables handles SHOW TABLES command
 func (h *MySQLHandler) handleShowTables(ctx context.Context) (*mysql.Result, error) {
-	// Return a virtual table list (single "kv" table)
+	// Return the virtual tables: "kv" and the change events of "kv_changes"
 	tables := [][]interface{}{
 		{"kv"},
+		{changesTable},
 	}
 
 	resultset, err := mysql.BuildSimpleResultset(
@@ -447,6 +661,18 @@ func (h *MySQLHandler) handleDescribe(ctx context.Context, query string) (*mysql
 		{"key", "varchar(1024)", "NO", "PRI", nil, ""},
 		{"value", "blob", "YES", "", nil, ""},
 	}
+	if strings.Contains(strings.ToLower(query), changesTable) {
+		fields = [][]interface{}{
+			{"revision", "bigint", "NO", "PRI", nil, ""},
+			{"type", "varchar(6)", "NO", "", nil, ""},
+			{"key", "varchar(1024)", "NO", "PRI", nil, ""},
+			{"value", "blob", "YES", "", nil, ""},
+			{"create_revision", "bigint", "NO", "", nil, ""},
+			{"mod_revision", "bigint", "NO", "", nil, ""},
+			{"version", "bigint", "NO", "", nil, ""},
+			{"lease", "bigint", "NO", "", nil, ""},
+		}
+	}
 
 	resultset, err := mysql.BuildSimpleResultset(
 		[]string{"Field", "Type", "Null", "Key", "Default", "Extra"},
@@ -488,30 +714,69 @@ func (h *MySQLHandler) parseKeyFromSelect(query string) string {
 	return h.extractQuotedValue(valuePart)
 }
 
-func (h *MySQLHandler) parseKeyValueFromInsert(query string) (string, string, error) {
+// parseRowsFromInsert parses the (key, value) tuples of an INSERT statement,
+// a multi-row INSERT yields one pair per tuple
+func (h *MySQLHandler) parseRowsFromInsert(query string) ([]kvstore.KV, error) {
 	queryUpper := strings.ToUpper(query)
 	valuesIdx := strings.Index(queryUpper, "VALUES")
 	if valuesIdx == -1 {
-		return "", "", fmt.Errorf("invalid INSERT syntax: missing VALUES")
+		return nil, fmt.Errorf("invalid INSERT syntax: missing VALUES")
 	}
 
-	valuesPart := strings.TrimSpace(query[valuesIdx+6:])
-	// Extract values from (key, value) format
-	startIdx := strings.Index(valuesPart, "(")
-	endIdx := strings.Index(valuesPart, ")")
-	if startIdx == -1 || endIdx == -1 {
-		return "", "", fmt.Errorf("invalid INSERT syntax: missing parentheses")
-	}
+	rest := strings.TrimSpace(query[valuesIdx+6:])
+	var rows []kvstore.KV
+	for {
+		// Extract values from (key, value) format
+		if !strings.HasPrefix(rest, "(") {
+			return nil, fmt.Errorf("invalid INSERT syntax: missing parentheses")
+		}
 
-	values := strings.Split(valuesPart[startIdx+1:endIdx], ",")
-	if len(values) < 2 {
-		return "", "", fmt.Errorf("invalid INSERT syntax: expected (key, value)")
-	}
+		// Values may be JSON documents, so commas and parentheses inside quotes are literal
+		values, end, ok := splitTuple(rest[1:])
+		if !ok {
+			return nil, fmt.Errorf("invalid INSERT syntax: missing parentheses")
+		}
+		if len(values) < 2 {
+			return nil, fmt.Errorf("invalid INSERT syntax: expected (key, value)")
+		}
+		rows = append(rows, kvstore.KV{
+			Key: h.extractQuotedValue(strings.TrimSpace(values[0])),
+			Val: h.extractQuotedValue(strings.TrimSpace(values[1])),
+		})
 
-	key := h.extractQuotedValue(strings.TrimSpace(values[0]))
-	value := h.extractQuotedValue(strings.TrimSpace(values[1]))
+		rest = strings.TrimSpace(rest[1+end:])
+		if !strings.HasPrefix(rest, ",") {
+			return rows, nil
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+}
 
-	return key, value, nil
+// splitTuple splits "a, 'b,c')" at top-level commas up to the closing parenthesis,
+// keeping quoted strings intact, and returns the offset just past the parenthesis
+func splitTuple(s string) ([]string, int, bool) {
+	var parts []string
+	start := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		case c == ')':
+			return append(parts, s[start:i]), i + 1, true
+		}
+	}
+	return nil, 0, false
 }
 
 func (h *MySQLHandler) parseKeyValueFromUpdate(query string) (string, string, error) {
@@ -549,13 +814,16 @@ func (h *MySQLHandler) parseKeyFromDelete(query string) string {
 
 func (h *MySQLHandler) extractQuotedValue(s string) string {
 	s = strings.TrimSpace(s)
-	// Remove quotes (single or double)
+	// Remove quotes (single or double) and resolve escapes
 	if len(s) >= 2 {
 		if (s[0] == '\'' && s[len(s)-1] == '\'') ||
 			(s[0] == '"' && s[len(s)-1] == '"') {
-			return s[1 : len(s)-1]
+			return unescapeString(s[1:len(s)-1], s[0])
 		}
 	}
+	if v, ok := hexLiteral(s); ok {
+		return v
+	}
 	return s
 }
 
@@ -0,0 +1,92 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// readRevisionVar is the session variable pinning reads at a revision, like a
+// time-travel replica. A pinned session is read-only; DEFAULT (or 0) returns
+// to server.read_revision, which is the latest revision unless configured
+//
+//	SET SESSION metastore_read_revision = 1200
+//	SELECT value FROM kv WHERE key = '/app/config'
+const readRevisionVar = "metastore_read_revision"
+
+var (
+	setReadRevisionRe    = regexp.MustCompile(`(?i)(?:@@(?:session\.)?|\b)metastore_read_revision\s*:?=\s*(?:'([^']*)'|"([^"]*)"|(\w+))`)
+	selectReadRevisionRe = regexp.MustCompile(`(?i)@@(?:session\.)?metastore_read_revision`)
+)
+
+// writePrefixes are the statements rejected in a pinned session
+var writePrefixes = []string{"INSERT", "REPLACE", "UPDATE", "DELETE", "CREATE", "DROP"}
+
+// pinReads pins the session at rev and makes it the DEFAULT of the session
+func (h *MySQLHandler) pinReads(rev int64) {
+	h.defaultRev = rev
+	h.readRevision = rev
+}
+
+// setReadRevision handles SET metastore_read_revision
+func (h *MySQLHandler) setReadRevision(value string) error {
+	if h.getTransaction() != nil {
+		return mysql.NewError(ErrPinnedInTrans,
+			fmt.Sprintf("Variable '%s' cannot be changed inside a transaction", readRevisionVar))
+	}
+	if strings.EqualFold(value, "DEFAULT") {
+		h.readRevision = h.defaultRev
+		return nil
+	}
+	rev, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || rev < 0 || rev > h.store.CurrentRevision() {
+		return mysql.NewError(ErrWrongValueForVar,
+			fmt.Sprintf("Variable '%s' can't be set to the value of '%s'", readRevisionVar, value))
+	}
+	if rev == 0 {
+		rev = h.defaultRev
+	}
+	h.readRevision = rev
+	return nil
+}
+
+// isWriteStatement reports whether an upper-cased statement modifies data
+func isWriteStatement(queryUpper string) bool {
+	for _, prefix := range writePrefixes {
+		if strings.HasPrefix(queryUpper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnly reports whether the session rejects writes
+func (h *MySQLHandler) readOnly() bool {
+	return h.readRevision != 0 || h.replica
+}
+
+// readOnlyError is returned for statements a read-only session cannot execute
+func (h *MySQLHandler) readOnlyError() error {
+	if h.readRevision == 0 {
+		return mysql.NewError(ErrReadOnly, "The server is a read replica so it cannot execute this statement")
+	}
+	return mysql.NewError(ErrReadOnly,
+		fmt.Sprintf("The session is pinned at revision %d (%s) so it cannot execute this statement", h.readRevision, readRevisionVar))
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"metaStore/internal/memory"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+func TestReadRevision(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	ctx := context.Background()
+
+	first, _, err := store.PutWithLease(ctx, "/app/config", "v1", 0)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, _, err := store.PutWithLease(ctx, "/app/config", "v2", 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	exec := func(query string) {
+		t.Helper()
+		if _, err := h.HandleQuery(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	expect := func(query string, want ...[]string) {
+		t.Helper()
+		_, rows := queryRows(t, h, query)
+		if !reflect.DeepEqual(rows, want) {
+			t.Fatalf("%s = %q, want %q", query, rows, want)
+		}
+	}
+	expectCode := func(query string, code uint16) {
+		t.Helper()
+		_, err := h.HandleQuery(query)
+		var myErr *mysql.MyError
+		if !errors.As(err, &myErr) || myErr.Code != code {
+			t.Fatalf("%s: got %v, want error %d", query, err, code)
+		}
+	}
+
+	exec(fmt.Sprintf("SET SESSION metastore_read_revision = %d", first))
+	expect("SELECT @@metastore_read_revision", []string{fmt.Sprint(first)})
+	expect("SELECT value FROM kv WHERE key = '/app/config'", []string{"v1"})
+
+	// A pinned session is read-only, transactions read at the pinned revision
+	expectCode("INSERT INTO kv (key, value) VALUES ('/app/other', 'x')", ErrReadOnly)
+	expectCode("DELETE FROM kv WHERE key = '/app/config'", ErrReadOnly)
+	exec("BEGIN")
+	expect("SELECT value FROM kv WHERE key = '/app/config'", []string{"v1"})
+	expectCode("SELECT value FROM kv WHERE key = '/app/config' FOR UPDATE", ErrReadOnly)
+	expectCode("SET metastore_read_revision = DEFAULT", ErrPinnedInTrans)
+	exec("COMMIT")
+
+	expectCode("SET metastore_read_revision = 'abc'", ErrWrongValueForVar)
+	expectCode("SET metastore_read_revision = 1000", ErrWrongValueForVar)
+
+	exec("SET @@session.metastore_read_revision = DEFAULT")
+	expect("SELECT @@metastore_read_revision", []string{"0"})
+	expect("SELECT value FROM kv WHERE key = '/app/config'", []string{"v2"})
+	exec("INSERT INTO kv (key, value) VALUES ('/app/other', 'x')")
+
+	// server.read_revision is the default of every session
+	h.pinReads(first)
+	expectCode("UPDATE kv SET value = 'v3' WHERE key = '/app/config'", ErrReadOnly)
+	exec("SET metastore_read_revision = 0")
+	expect("SELECT @@metastore_read_revision", []string{fmt.Sprint(first)})
+}
+
+func TestReadReplicaSession(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	h.replica = true
+
+	if _, _, err := store.PutWithLease(context.Background(), "/app/config", "v1", 0); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	_, rows := queryRows(t, h, "SELECT value FROM kv WHERE key = '/app/config'")
+	if !reflect.DeepEqual(rows, [][]string{{"v1"}}) {
+		t.Fatalf("rows = %q, want v1", rows)
+	}
+
+	// Writes and row locks are rejected on a read replica
+	for _, query := range []string{
+		"INSERT INTO kv (key, value) VALUES ('/app/other', 'x')",
+		"SELECT value FROM kv WHERE key = '/app/config' FOR UPDATE",
+	} {
+		_, err := h.HandleQuery(query)
+		var myErr *mysql.MyError
+		if !errors.As(err, &myErr) || myErr.Code != ErrReadOnly {
+			t.Fatalf("%s: got %v, want error %d", query, err, ErrReadOnly)
+		}
+	}
+}
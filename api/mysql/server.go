@@ -16,6 +16,7 @@ package mysql
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"sync"
@@ -25,6 +26,7 @@ import (
 	"metaStore/internal/kvstore"
 	"metaStore/pkg/config"
 	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
 
 	"github.com/go-mysql-org/go-mysql/server"
 	"go.uber.org/zap"
@@ -40,6 +42,15 @@ type Server struct {
 	// Configuration
 	address      string
 	authProvider *AuthProvider
+	handshaker   *handshaker   // connection phase: TLS and authentication plugins
+	users        UserStore     // etcd Auth user database (optional)
+	usage        UsageReporter // per-prefix usage accounting (optional)
+	locks        LockConfig    // row locks of SELECT ... FOR UPDATE
+	readRevision int64         // revision sessions are pinned at, 0 for none
+	replica      bool          // read replica: sessions are read-only
+
+	// changesTimeout is how long a SELECT on kv_changes waits for an event by default
+	changesTimeout time.Duration
 
 	// Connection management
 	connections sync.Map       // Active connections
@@ -59,6 +70,28 @@ type ServerConfig struct {
 	Username  string         // Auth username (default: "root")
 	Password  string         // Auth password (default: "")
 	Config    *config.Config // Full configuration object (optional)
+	Users     UserStore      // etcd Auth user database, used once auth is enabled (optional)
+	Usage     UsageReporter  // Per-prefix usage served by information_schema.metastore_usage (optional)
+
+	// Transport security, overridden by Config when it is provided
+	TLS                    *tls.Config // Certificate offered to clients (optional, default self-signed)
+	AuthPlugin             string      // Auth plugin announced to clients (default caching_sha2_password)
+	RequireSecureTransport bool        // Reject connections that do not switch to TLS
+
+	// Row locks of SELECT ... FOR UPDATE, overridden by Config when it is provided
+	Locks LockConfig
+
+	// ReadRevision pins all sessions at a revision and makes them read-only,
+	// overridden by Config when it is provided
+	ReadRevision int64
+
+	// Replica serves serializable reads only and rejects writes, set from
+	// raft.node_role when Config is provided
+	Replica bool
+
+	// ChangesTimeout is how long a SELECT on kv_changes waits for an event by
+	// default (default 30s), overridden by Config when it is provided
+	ChangesTimeout time.Duration
 }
 
 // NewServer creates a new MySQL-compatible server
@@ -72,24 +105,64 @@ func NewServer(cfg ServerConfig) (*Server, error) {
 	if cfg.Username == "" {
 		cfg.Username = "root"
 	}
+	if cfg.Config != nil {
+		tlsConfig, err := cfg.Config.Server.TLS.Load()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig != nil {
+			cfg.TLS = tlsConfig
+		}
+		cfg.AuthPlugin = cfg.Config.Server.MySQL.AuthPlugin
+		cfg.RequireSecureTransport = cfg.Config.Server.MySQL.RequireSecureTransport
+		cfg.Locks = LockConfig{
+			TTL:         cfg.Config.Server.MySQL.LockTTL,
+			WaitTimeout: cfg.Config.Server.MySQL.LockWaitTimeout,
+		}
+		cfg.ReadRevision = cfg.Config.Server.ReadRevision
+		cfg.Replica = cfg.Config.Server.Raft.IsReplica()
+		cfg.ChangesTimeout = cfg.Config.Server.MySQL.ChangesTimeout
+	}
+	if cfg.ChangesTimeout == 0 {
+		cfg.ChangesTimeout = defaultChangesTimeout
+	}
+	handshaker, err := newHandshaker(cfg.AuthPlugin, cfg.TLS, cfg.RequireSecureTransport)
+	if err != nil {
+		return nil, err
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	s := &Server{
 		store:   cfg.Store,
 		address: cfg.Address,
+		users:   cfg.Users,
+		usage:   cfg.Usage,
+		locks:   cfg.Locks,
 		ctx:     ctx,
 		cancel:  cancel,
+
+		handshaker: handshaker,
 	}
+	s.readRevision = cfg.ReadRevision
+	s.replica = cfg.Replica
+	s.changesTimeout = cfg.ChangesTimeout
 
 	// Create auth provider
 	s.authProvider = NewAuthProvider(cfg.Username, cfg.Password)
 
 	// Create MySQL handler
 	s.handler = NewMySQLHandler(cfg.Store, s.authProvider)
+	s.handler.usage = cfg.Usage
+	s.handler.lockConfig = cfg.Locks
+	s.handler.pinReads(cfg.ReadRevision)
+	s.handler.replica = cfg.Replica
+	s.handler.setDefaultChangesTimeout(cfg.ChangesTimeout)
 
 	log.Info("MySQL server initialized",
 		zap.String("address", cfg.Address),
+		zap.String("auth_plugin", handshaker.plugin),
+		zap.Bool("require_secure_transport", cfg.RequireSecureTransport),
 		zap.String("component", "mysql"))
 
 	return s, nil
@@ -185,6 +258,15 @@ func (s *Server) handleConnection(conn net.Conn, connID uint64) {
 		conn.Close()
 		s.connections.Delete(connID)
 	}()
+	// A panic outside a query leaves the protocol state unknown, so the
+	// connection is closed but the server keeps running
+	defer func() {
+		if r := recover(); r != nil {
+			reliability.ReportPanic(reliability.PanicSourceMySQL, "mysql-connection", r,
+				zap.Uint64("conn_id", connID),
+				zap.String("remote_addr", conn.RemoteAddr().String()))
+		}
+	}()
 
 	log.Debug("New MySQL connection",
 		zap.Uint64("conn_id", connID),
@@ -193,14 +275,20 @@ func (s *Server) handleConnection(conn net.Conn, connID uint64) {
 
 	// Create a dedicated handler for this connection (enables per-connection transactions)
 	connHandler := NewMySQLHandler(s.store, s.authProvider)
-
-	// Create MySQL connection handler
-	mysqlConn, err := server.NewConn(
-		conn,
-		connHandler.user,
-		connHandler.password,
-		connHandler,
-	)
+	connHandler.usage = s.usage
+	connHandler.lockConfig = s.locks
+	connHandler.pinReads(s.readRevision)
+	connHandler.replica = s.replica
+	connHandler.setDefaultChangesTimeout(s.changesTimeout)
+
+	// Create MySQL connection handler; once etcd Auth is enabled clients log in with
+	// its users and their key permissions apply to the connection
+	var users passwordVerifiers = s.authProvider
+	if s.users != nil && s.users.IsEnabled() {
+		users = s.users
+		connHandler.users = s.users
+	}
+	mysqlConn, err := s.accept(conn, connID, connHandler, users)
 	if err != nil {
 		log.Error("Failed to create MySQL connection handler",
 			zap.Error(err),
@@ -254,3 +342,34 @@ func (s *Server) handleConnection(conn net.Conn, connID uint64) {
 		}
 	}
 }
+
+// accept runs the connection phase against users, binds the connection handler
+// to the authenticated user and hands the connection over to go-mysql
+func (s *Server) accept(conn net.Conn, connID uint64, connHandler *MySQLHandler, users passwordVerifiers) (*server.Conn, error) {
+	resp, err := s.handshaker.authenticate(conn, uint32(connID), users)
+	if err != nil {
+		return nil, err
+	}
+
+	// go-mysql completes its own handshake locally, then the connection is handed over
+	ac := newAuthenticatedConn(resp)
+	mysqlConn, err := server.NewConn(ac, resp.user, ac.password, connHandler)
+	if err != nil {
+		return nil, err
+	}
+	ac.handOff()
+
+	// resp.pc still tracks the client's packet sequence of the connection phase
+	if err := writeOKPacket(resp.pc); err != nil {
+		return nil, err
+	}
+
+	connHandler.user = resp.user
+	log.Debug("MySQL user authenticated",
+		zap.String("username", resp.user),
+		zap.String("plugin", resp.plugin),
+		zap.Bool("tls", resp.secure),
+		zap.Uint64("conn_id", connID),
+		zap.String("component", "mysql"))
+	return mysqlConn, nil
+}
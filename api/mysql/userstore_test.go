@@ -0,0 +1,250 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"metaStore/api/etcd"
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func TestUserStoreAuthentication(t *testing.T) {
+	store := memory.NewMemoryEtcd()
+	users := etcd.NewAuthManager(store)
+	for _, step := range []error{
+		users.AddUser("root", "rootpw"),
+		users.AddUser("alice", "secret"),
+		users.AddRole("app"),
+		users.GrantPermission("app", etcd.Permission{
+			Type:     etcd.PermissionReadWrite,
+			Key:      []byte("app/"),
+			RangeEnd: []byte("app0"),
+		}),
+		users.GrantRole("alice", "app"),
+		users.Enable(),
+	} {
+		if step != nil {
+			t.Fatal(step)
+		}
+	}
+	if _, _, err := store.PutWithLease(context.Background(), "other/secret", "x", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := NewServer(ServerConfig{Store: store, Address: "127.0.0.1:0", Users: users})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	addr := srv.listener.Addr().String()
+
+	open := func(user, password string) *sql.DB {
+		db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/metastore", user, password, addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+
+	// Wrong password and unknown users are rejected
+	for _, creds := range [][2]string{{"alice", "wrong"}, {"bob", "secret"}, {"alice", ""}} {
+		err := open(creds[0], creds[1]).Ping()
+		if err == nil || !strings.Contains(err.Error(), "1045") {
+			t.Fatalf("%s/%q: err = %v, want access denied", creds[0], creds[1], err)
+		}
+	}
+
+	alice := open("alice", "secret")
+	if err := alice.Ping(); err != nil {
+		t.Fatalf("alice ping: %v", err)
+	}
+	if _, err := alice.Exec("INSERT INTO kv (key, value) VALUES ('app/name', 'metastore')"); err != nil {
+		t.Fatalf("insert inside prefix: %v", err)
+	}
+	var value string
+	if err := alice.QueryRow("SELECT value FROM kv WHERE key = 'app/name'").Scan(&value); err != nil || value != "metastore" {
+		t.Fatalf("select inside prefix: %q, %v", value, err)
+	}
+
+	// Writes and point reads outside the granted prefix are denied
+	for _, q := range []string{
+		"INSERT INTO kv (key, value) VALUES ('other/x', '1')",
+		"DELETE FROM kv WHERE key = 'other/secret'",
+	} {
+		if _, err := alice.Exec(q); err == nil || !strings.Contains(err.Error(), "1142") {
+			t.Fatalf("%s: err = %v, want permission denied", q, err)
+		}
+	}
+	if err := alice.QueryRow("SELECT value FROM kv WHERE key = 'other/secret'").Scan(&value); err == nil || !strings.Contains(err.Error(), "1142") {
+		t.Fatalf("point read outside prefix: err = %v, want permission denied", err)
+	}
+
+	// Range reads only return permitted keys
+	rows, err := alice.Query("SELECT key FROM kv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if len(keys) != 1 || keys[0] != "app/name" {
+		t.Fatalf("range keys = %v, want [app/name]", keys)
+	}
+
+	// root keeps full access
+	root := open("root", "rootpw")
+	if err := root.QueryRow("SELECT value FROM kv WHERE key = 'other/secret'").Scan(&value); err != nil || value != "x" {
+		t.Fatalf("root read: %q, %v", value, err)
+	}
+}
+
+func TestRangeSkipsUnreadableKeys(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	users := etcd.NewAuthManager(store)
+	for _, step := range []error{
+		users.AddUser("root", "rootpw"),
+		users.AddUser("alice", "secret"),
+		users.AddRole("app"),
+		users.GrantPermission("app", etcd.Permission{
+			Type:     etcd.PermissionRead,
+			Key:      []byte("app/"),
+			RangeEnd: []byte("app0"),
+		}),
+		users.GrantRole("alice", "app"),
+		users.Enable(),
+	} {
+		if step != nil {
+			t.Fatal(step)
+		}
+	}
+	// More unreadable keys before app/ than a table scan returns
+	for i := 0; i < 120; i++ {
+		if _, _, err := store.PutWithLease(ctx, fmt.Sprintf("a/%03d", i), "x", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := store.PutWithLease(ctx, "app/name", "metastore", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewMySQLHandler(store, NewAuthProvider("root", ""))
+	h.users, h.user = users, "alice"
+	for _, q := range []string{"SELECT key FROM kv", "SELECT key FROM kv WHERE key LIKE 'a%'"} {
+		if _, rows := queryRows(t, h, q); fmt.Sprint(rows) != "[[app/name]]" {
+			t.Errorf("%s: rows = %v, want [[app/name]]", q, rows)
+		}
+	}
+
+	// Fail closed: a scan that is not fully readable is denied, a readable one is not
+	cfg := config.DefaultConfig(1, 1, ":2379").Server.Auth
+	cfg.FailClosedRanges = true
+	h.users = etcd.NewAuthManager(store, &cfg)
+	if _, err := h.HandleQuery("SELECT key FROM kv"); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("fail-closed scan: err = %v, want permission denied", err)
+	}
+	if _, rows := queryRows(t, h, "SELECT key FROM kv WHERE key LIKE 'app/%'"); fmt.Sprint(rows) != "[[app/name]]" {
+		t.Errorf("fail-closed readable scan: rows = %v", rows)
+	}
+}
+
+func TestCheckNativePassword(t *testing.T) {
+	salt := []byte("0123456789abcdefghij")
+	verifier := func(password string) []byte {
+		stage1 := sha1.Sum([]byte(password))
+		stage2 := sha1.Sum(stage1[:])
+		return stage2[:]
+	}
+
+	scramble := mysql.CalcPassword(salt, []byte("secret"))
+	if !checkNativePassword(salt, scramble, verifier("secret")) {
+		t.Fatal("valid scramble rejected")
+	}
+	if checkNativePassword(salt, scramble, verifier("other")) {
+		t.Fatal("scramble accepted for another password")
+	}
+	if checkNativePassword(salt, nil, verifier("secret")) {
+		t.Fatal("empty scramble accepted for a non-empty password")
+	}
+	if !checkNativePassword(salt, nil, verifier("")) {
+		t.Fatal("empty scramble rejected for an empty password")
+	}
+}
+
+func TestTLSAndCachingSha2(t *testing.T) {
+	for _, requireSecure := range []bool{false, true} {
+		srv, err := NewServer(ServerConfig{
+			Store:                  memory.NewMemoryEtcd(),
+			Address:                "127.0.0.1:0",
+			Password:               "pw",
+			RequireSecureTransport: requireSecure,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.Start(); err != nil {
+			t.Fatal(err)
+		}
+		addr := srv.listener.Addr().String()
+
+		// The second login of each kind takes the caching_sha2_password fast path
+		for _, c := range []struct {
+			password, tls string
+			want          string // error substring, empty for success
+		}{
+			{"pw", "skip-verify", ""},
+			{"pw", "skip-verify", ""},
+			{"wrong", "skip-verify", "1045"},
+			{"pw", "false", map[bool]string{false: "", true: "3159"}[requireSecure]},
+			{"pw", "false", map[bool]string{false: "", true: "3159"}[requireSecure]},
+			{"wrong", "false", map[bool]string{false: "1045", true: "3159"}[requireSecure]},
+		} {
+			db, err := sql.Open("mysql", fmt.Sprintf("root:%s@tcp(%s)/metastore?tls=%s", c.password, addr, c.tls))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = db.Ping()
+			db.Close()
+			if c.want == "" && err != nil {
+				t.Fatalf("require_secure_transport=%v, tls=%s: %v", requireSecure, c.tls, err)
+			}
+			if c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)) {
+				t.Fatalf("require_secure_transport=%v, tls=%s, password %q: err = %v, want %s",
+					requireSecure, c.tls, c.password, err, c.want)
+			}
+		}
+		srv.Stop()
+	}
+}
@@ -0,0 +1,585 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// TypeScript client for the MetaStore HTTP API, described by
+// api/http/openapi.json and served by every member at /openapi.json. The types
+// and the request of every operation are generated into api.gen.ts by
+// go generate ./api/http; MetaStoreClient adds key encoding and streams on
+// top. The api/http tests check that api.gen.ts is up to date and that each
+// operationId is a method of MetaStoreClient. Runs on browsers and Node.js 18+
+// (global fetch).
+
+import { operations } from "./api.gen.js";
+import type {
+  BatchOp,
+  BatchResponse,
+  DrainStatus,
+  KeyEncoding,
+  KeyHistoryResponse,
+  MemberReplaceProgress,
+  MemberReplaceRequest,
+  MemberStatus,
+  MirrorStatus,
+  NodeHealth,
+  Operation,
+  PlacementPolicy,
+  RaftLog,
+  RotationStatus,
+  SchemaEntry,
+  Setting,
+  SettingStatus,
+  TrashEntry,
+  TrashRestoreResponse,
+  UsageReport,
+  WatchEvent,
+} from "./api.gen.js";
+
+export type * from "./api.gen.js";
+
+export interface ClientOptions {
+  /** HTTP API address of a member, such as "http://127.0.0.1:9121". */
+  endpoint: string;
+  /** Token from /auth/login, set automatically by login(). */
+  token?: string;
+  /** Wire encoding of keys; base64 and hex carry keys that are not URL safe. */
+  keyEncoding?: KeyEncoding;
+  /** ?timeout= of writes as a Go duration such as "5s". */
+  timeout?: string;
+  /** Alternative fetch implementation. */
+  fetch?: typeof fetch;
+}
+
+export interface WriteResult {
+  /** Read-after-write token, pass it to getKey() on another member. */
+  commitIndex: number;
+  hlc: string;
+}
+
+/** A WatchEvent received by a WatchStream. */
+export interface StreamEvent extends WatchEvent {
+  /** SSE id, pass it as lastEventId to resume after this event. */
+  id: string;
+}
+
+export interface WatchRequest {
+  prefix?: string;
+  key?: string;
+  fromRev?: number;
+  prevKv?: boolean;
+  valuePrefix?: string;
+  jsonPath?: string;
+  jsonValue?: string;
+  lastEventId?: string;
+  signal?: AbortSignal;
+}
+
+export interface RaftLogRequest {
+  from?: number;
+  limit?: number;
+  redact?: boolean;
+}
+
+/** One trash entry with key, or every entry under prefix (the whole trash when empty). */
+export interface TrashRange {
+  key?: string;
+  prefix?: string;
+}
+
+/** A non-2xx response; the server returns the reason as plain text. */
+export class MetaStoreError extends Error {
+  constructor(
+    readonly status: number,
+    message: string,
+    /** Seconds, set on 429 and 503. */
+    readonly retryAfter?: number,
+  ) {
+    super(`${status}: ${message}`);
+    this.name = "MetaStoreError";
+  }
+
+  get notFound(): boolean {
+    return this.status === 404;
+  }
+
+  get conflict(): boolean {
+    return this.status === 409;
+  }
+}
+
+const utf8 = new TextEncoder();
+const utf8Decoder = new TextDecoder();
+
+function encodeKey(encoding: KeyEncoding, key: string): string {
+  const bytes = utf8.encode(key);
+  switch (encoding) {
+    case "base64":
+      return btoa(String.fromCharCode(...bytes));
+    case "hex":
+      return Array.from(bytes, (b) => b.toString(16).padStart(2, "0")).join("");
+    default:
+      return key;
+  }
+}
+
+function decodeKey(encoding: KeyEncoding, key: string): string {
+  switch (encoding) {
+    case "base64":
+      return utf8Decoder.decode(Uint8Array.from(atob(key), (c) => c.charCodeAt(0)));
+    case "hex":
+      return utf8Decoder.decode(Uint8Array.from(key.match(/../g) ?? [], (h) => parseInt(h, 16)));
+    default:
+      return key;
+  }
+}
+
+/** The ?prefix= of a trash range, not sent when it names a key. */
+function trashPrefix(range: TrashRange): string | undefined {
+  return range.key ? undefined : range.prefix;
+}
+
+/** The ?version= of a setting change, not sent when negative. */
+function settingVersion(version?: number): number | undefined {
+  return version === undefined || version < 0 ? undefined : version;
+}
+
+export class MetaStoreClient {
+  private readonly endpoint: string;
+  private readonly keyEncoding: KeyEncoding;
+  private readonly timeout?: string;
+  private readonly fetchImpl: typeof fetch;
+  private token?: string;
+
+  constructor(options: ClientOptions) {
+    this.endpoint = options.endpoint.replace(/\/+$/, "");
+    this.keyEncoding = options.keyEncoding ?? "raw";
+    this.timeout = options.timeout;
+    this.fetchImpl = options.fetch ?? globalThis.fetch.bind(globalThis);
+    this.token = options.token;
+  }
+
+  /** Sets the token sent with later requests. */
+  setToken(token: string | undefined): void {
+    this.token = token;
+  }
+
+  private async send<T>(op: Operation<T>, signal?: AbortSignal): Promise<Response> {
+    const params = new URLSearchParams();
+    for (const [name, value] of Object.entries(op.query)) {
+      for (const v of Array.isArray(value) ? value : value === undefined ? [] : [value]) {
+        params.append(name, v);
+      }
+    }
+    const query = params.toString();
+    const headers: Record<string, string> = {};
+    for (const [name, value] of Object.entries(op.headers)) {
+      if (value !== undefined) {
+        headers[name] = value;
+      }
+    }
+    if (op.contentType) {
+      headers["Content-Type"] = op.contentType;
+    }
+    if (!headers["X-MetaStore-Key-Encoding"] && this.keyEncoding !== "raw") {
+      headers["X-MetaStore-Key-Encoding"] = this.keyEncoding;
+    }
+    if (this.token) {
+      headers["Authorization"] = this.token;
+    }
+
+    const resp = await this.fetchImpl(this.endpoint + op.path + (query ? "?" + query : ""), {
+      method: op.method,
+      headers,
+      body: op.body,
+      signal,
+    });
+    if (!op.ok.includes(resp.status)) {
+      const retryAfter = resp.headers.get("Retry-After");
+      throw new MetaStoreError(
+        resp.status,
+        (await resp.text()).trim(),
+        retryAfter ? Number(retryAfter) : undefined,
+      );
+    }
+    return resp;
+  }
+
+  /** Sends an operation and reads its result the way the document describes it. */
+  private async call<T>(op: Operation<T>, signal?: AbortSignal): Promise<T> {
+    const resp = await this.send(op, signal);
+    switch (op.result) {
+      case "json":
+        return (await resp.json()) as T;
+      case "text":
+        return (await resp.text()) as T;
+      case "stream":
+        return resp as T;
+      default:
+        await resp.arrayBuffer();
+        return undefined as T;
+    }
+  }
+
+  private encode(key: string): string {
+    return encodeKey(this.keyEncoding, key);
+  }
+
+  private static writeResult(resp: Response): WriteResult {
+    return {
+      commitIndex: Number(resp.headers.get("X-MetaStore-Commit-Index") ?? 0),
+      hlc: resp.headers.get("X-MetaStore-HLC") ?? "",
+    };
+  }
+
+  /**
+   * Reads the value of a key, throwing a MetaStoreError with notFound when it
+   * does not exist. With minIndex, a commitIndex returned by a write on another
+   * member, the member applies up to it before reading.
+   */
+  async getKey(key: string, minIndex?: number): Promise<Uint8Array> {
+    const resp = await this.call(operations.getKey(this.encode(key), { minIndex: minIndex || undefined }));
+    return new Uint8Array(await resp.arrayBuffer());
+  }
+
+  async putKey(key: string, value: string | Uint8Array): Promise<WriteResult> {
+    const resp = await this.send(operations.putKey(this.encode(key), value, { timeout: this.timeout }));
+    return MetaStoreClient.writeResult(resp);
+  }
+
+  /** Deletes a key. A numeric raw key would name a member, so it is sent hex encoded. */
+  async deleteKey(key: string): Promise<WriteResult> {
+    let encoding = this.keyEncoding;
+    if (encoding === "raw" && /^(0x[0-9a-fA-F]+|\d+)$/.test(key)) {
+      encoding = "hex";
+    }
+    const resp = await this.send(
+      operations.deleteKey(encodeKey(encoding, key), { keyEncoding: encoding, timeout: this.timeout }),
+    );
+    return MetaStoreClient.writeResult(resp);
+  }
+
+  /** Proposes adding a member without waiting for the conf change to apply. */
+  async addMember(id: number, peerURL: string): Promise<void> {
+    await this.call(operations.addMember(String(id), peerURL, { keyEncoding: "raw" }));
+  }
+
+  /** Proposes removing a member, the deleteKey operation on a member ID. */
+  async removeMember(id: number): Promise<void> {
+    await this.call(operations.deleteKey(String(id), { keyEncoding: "raw" }));
+  }
+
+  /**
+   * Streams changes. Iterate the stream with for await; after the server
+   * closes it, watch again with lastEventId to continue without gaps.
+   */
+  async watch(req: WatchRequest = {}): Promise<WatchStream> {
+    const resp = await this.call(
+      operations.watch({
+        key: req.key ? this.encode(req.key) : undefined,
+        prefix: !req.key && req.prefix ? this.encode(req.prefix) : undefined,
+        fromRev: req.fromRev || undefined,
+        prevKv: req.prevKv,
+        valuePrefix: req.valuePrefix,
+        jsonPath: req.jsonPath,
+        jsonValue: req.jsonValue,
+        lastEventId: req.lastEventId,
+      }),
+      req.signal,
+    );
+    if (!resp.body) {
+      throw new Error("streaming responses are not supported");
+    }
+    return new WatchStream(resp.body, (key) => decodeKey(this.keyEncoding, key), req.lastEventId);
+  }
+
+  /** Applies puts and deletes atomically as one raft proposal. */
+  async batch(ops: BatchOp[]): Promise<BatchResponse> {
+    const wire = ops.map((op) => ({
+      ...op,
+      key: this.encode(op.key),
+      range_end: op.range_end ? this.encode(op.range_end) : undefined,
+    }));
+    return this.call(operations.batch({ ops: wire }, { timeout: this.timeout }));
+  }
+
+  /** Retained revisions of a key, newest first; limit 0 returns all. */
+  async keyHistory(key: string, limit = 0): Promise<KeyHistoryResponse> {
+    const out = await this.call(operations.keyHistory({ key: this.encode(key), limit: limit || undefined }));
+    out.key = decodeKey(this.keyEncoding, out.key);
+    return out;
+  }
+
+  /** Exchanges a user name and password for a token used by later requests. */
+  async login(name: string, password: string): Promise<string> {
+    const out = await this.call(operations.login({ name, password }));
+    this.token = out.token;
+    return out.token;
+  }
+
+  async openAPI(): Promise<unknown> {
+    return this.call(operations.openAPI());
+  }
+
+  /** Health of the member, also returned when it is unhealthy (503). */
+  async health(leader = false): Promise<NodeHealth> {
+    return this.call(operations.health({ leader }));
+  }
+
+  /** Runs the readiness checks, throwing a MetaStoreError listing them on failure. */
+  async readyz(verbose = false, exclude: string[] = []): Promise<string> {
+    return (await this.call(operations.readyz({ verbose, exclude }))).trim();
+  }
+
+  async readyzCheck(check: string): Promise<string> {
+    return (await this.call(operations.readyzCheck(check))).trim();
+  }
+
+  async livez(verbose = false, exclude: string[] = []): Promise<string> {
+    return (await this.call(operations.livez({ verbose, exclude }))).trim();
+  }
+
+  async livezCheck(check: string): Promise<string> {
+    return (await this.call(operations.livezCheck(check))).trim();
+  }
+
+  async listMembers(): Promise<MemberStatus[]> {
+    return this.call(operations.listMembers());
+  }
+
+  /**
+   * Replaces a failed member, calling report on every progress change.
+   * Aborting the signal aborts the replacement; the same request resumes it.
+   */
+  async replaceMember(
+    req: MemberReplaceRequest,
+    report?: (p: MemberReplaceProgress) => void,
+    signal?: AbortSignal,
+  ): Promise<MemberReplaceProgress> {
+    const resp = await this.call(operations.replaceMember(req), signal);
+    let last: MemberReplaceProgress | undefined;
+    for await (const line of lines(resp.body!)) {
+      if (line.trim() === "") {
+        continue;
+      }
+      last = JSON.parse(line) as MemberReplaceProgress;
+      report?.(last);
+    }
+    if (!last) {
+      throw new Error("member replacement ended without progress");
+    }
+    if (last.phase !== "done") {
+      throw new Error(`member replacement ${last.phase}: ${last.error ?? ""}`);
+    }
+    return last;
+  }
+
+  async memberReplaceStatus(): Promise<MemberReplaceProgress> {
+    return this.call(operations.memberReplaceStatus());
+  }
+
+  async raftLog(req: RaftLogRequest = {}): Promise<RaftLog> {
+    return this.call(
+      operations.raftLog({ from: req.from || undefined, limit: req.limit || undefined, redact: req.redact }),
+    );
+  }
+
+  async getPlacement(): Promise<PlacementPolicy> {
+    return this.call(operations.getPlacement());
+  }
+
+  async setPlacement(policy: PlacementPolicy): Promise<PlacementPolicy> {
+    return this.call(operations.setPlacement(policy));
+  }
+
+  async resetPlacement(): Promise<void> {
+    await this.call(operations.resetPlacement());
+  }
+
+  async drainStatus(): Promise<DrainStatus> {
+    return this.call(operations.drainStatus());
+  }
+
+  /** Starts draining the gRPC clients of the member, timeout as a Go duration. */
+  async startDrain(timeout?: string): Promise<DrainStatus> {
+    return this.call(operations.startDrain({ timeout }));
+  }
+
+  async listMirrors(): Promise<MirrorStatus[]> {
+    return this.call(operations.listMirrors());
+  }
+
+  async startMirror(name: string): Promise<void> {
+    await this.call(operations.startMirror(name));
+  }
+
+  async stopMirror(name: string): Promise<void> {
+    await this.call(operations.stopMirror(name));
+  }
+
+  async encryptionStatus(): Promise<RotationStatus> {
+    return this.call(operations.encryptionStatus());
+  }
+
+  async rotateKeys(): Promise<RotationStatus> {
+    return this.call(operations.rotateKeys());
+  }
+
+  async listSchemas(): Promise<SchemaEntry[]> {
+    return this.call(operations.listSchemas());
+  }
+
+  async getSchema(prefix: string): Promise<unknown> {
+    return this.call(operations.getSchema(prefix));
+  }
+
+  async putSchema(prefix: string, schema: unknown): Promise<void> {
+    await this.call(operations.putSchema(prefix, schema));
+  }
+
+  async deleteSchema(prefix: string): Promise<void> {
+    await this.call(operations.deleteSchema(prefix));
+  }
+
+  async listSettings(): Promise<SettingStatus[]> {
+    return this.call(operations.listSettings());
+  }
+
+  async getSetting(name: string): Promise<Setting> {
+    return this.call(operations.getSetting(name));
+  }
+
+  /**
+   * Changes a setting. With version, only when its current version matches
+   * (0 when it is not set), otherwise a MetaStoreError with conflict is thrown.
+   */
+  async setSetting(name: string, value: string, version?: number): Promise<Setting> {
+    return this.call(operations.setSetting(name, value, { version: settingVersion(version) }));
+  }
+
+  async resetSetting(name: string, version?: number): Promise<void> {
+    await this.call(operations.resetSetting(name, { version: settingVersion(version) }));
+  }
+
+  /** Trash entries without values under a prefix, or the entry of a key with its value. */
+  async listTrash(range: TrashRange = {}): Promise<TrashEntry[] | TrashEntry> {
+    return this.call(operations.listTrash({ key: range.key, prefix: trashPrefix(range) }));
+  }
+
+  async discardTrash(range: TrashRange): Promise<void> {
+    await this.call(operations.discardTrash({ key: range.key, prefix: trashPrefix(range) }));
+  }
+
+  /** Restores deleted keys; keys that exist again are skipped and reported in error unless overwrite. */
+  async restoreTrash(range: TrashRange, overwrite = false): Promise<TrashRestoreResponse> {
+    return this.call(operations.restoreTrash({ key: range.key, prefix: trashPrefix(range), overwrite }));
+  }
+
+  async usage(limit = 0): Promise<UsageReport> {
+    return this.call(operations.usage({ limit: limit || undefined }));
+  }
+
+  async scanUsage(): Promise<UsageReport> {
+    return this.call(operations.scanUsage());
+  }
+}
+
+/** Splits a response body into lines. */
+async function* lines(body: ReadableStream<Uint8Array>): AsyncGenerator<string> {
+  const reader = body.getReader();
+  const decoder = new TextDecoder();
+  let buffered = "";
+  try {
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) {
+        break;
+      }
+      buffered += decoder.decode(value, { stream: true });
+      let i: number;
+      while ((i = buffered.indexOf("\n")) >= 0) {
+        yield buffered.slice(0, i).replace(/\r$/, "");
+        buffered = buffered.slice(i + 1);
+      }
+    }
+    if (buffered !== "") {
+      yield buffered;
+    }
+  } finally {
+    reader.releaseLock();
+  }
+}
+
+/** Server-Sent Events of a watch. */
+export class WatchStream implements AsyncIterable<StreamEvent> {
+  private readonly lines: AsyncGenerator<string>;
+  private lastId?: string;
+
+  constructor(
+    private readonly body: ReadableStream<Uint8Array>,
+    private readonly decode: (key: string) => string,
+    lastEventId?: string,
+  ) {
+    this.lines = lines(body);
+    this.lastId = lastEventId;
+  }
+
+  /** The next event, or undefined once the server closes the stream. */
+  async next(): Promise<StreamEvent | undefined> {
+    let id = "";
+    let data = "";
+    for await (const line of this.lines) {
+      if (line === "") {
+        // A blank line ends an event; keepalive comments carry no data
+        if (data === "") {
+          continue;
+        }
+        const ev: StreamEvent = { ...(JSON.parse(data) as WatchEvent), id };
+        ev.kv.key = this.decode(ev.kv.key);
+        if (ev.prev_kv) {
+          ev.prev_kv.key = this.decode(ev.prev_kv.key);
+        }
+        this.lastId = id;
+        return ev;
+      }
+      const colon = line.indexOf(":");
+      const field = colon < 0 ? line : line.slice(0, colon);
+      const value = colon < 0 ? "" : line.slice(colon + 1).replace(/^ /, "");
+      if (field === "id") {
+        id = value;
+      } else if (field === "data") {
+        data = data === "" ? value : data + "\n" + value;
+      }
+    }
+    return undefined;
+  }
+
+  /** ID of the last event received, to resume with WatchRequest.lastEventId. */
+  get lastEventId(): string | undefined {
+    return this.lastId;
+  }
+
+  async close(): Promise<void> {
+    await this.lines.return(undefined);
+    await this.body.cancel().catch(() => undefined);
+  }
+
+  async *[Symbol.asyncIterator](): AsyncIterator<StreamEvent> {
+    for (;;) {
+      const ev = await this.next();
+      if (!ev) {
+        return;
+      }
+      yield ev;
+    }
+  }
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"metaStore/pkg/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateReport metastore config validate 输出的 JSON
+type validateReport struct {
+	File        string              `json:"file"`
+	Valid       bool                `json:"valid"`
+	Errors      int                 `json:"errors"`
+	Warnings    int                 `json:"warnings"`
+	Diagnostics []config.Diagnostic `json:"diagnostics"`
+}
+
+// runConfig 运行 metastore config 子命令，不启动成员，供 CI 检查模板生成的配置
+//
+//	metastore config validate --config metastore.yaml [--strict]
+//	metastore config print-defaults [--cluster-id 1 --member-id 1]
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: metastore config validate|print-defaults [flags]")
+	}
+	switch args[0] {
+	case "validate":
+		return validateConfig(args[1:])
+	case "print-defaults":
+		return printDefaults(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q, must be validate or print-defaults", args[0])
+	}
+}
+
+// validateConfig 以 JSON 输出配置文件的全部诊断，有错误（--strict 时包括警告）时返回错误
+func validateConfig(args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	configFile := fs.String("config", "", "path to the config file to validate")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	fs.Parse(args)
+	if *configFile == "" {
+		return errors.New("--config is required")
+	}
+
+	diags, err := config.CheckFile(*configFile)
+	if err != nil {
+		return err
+	}
+	report := validateReport{File: *configFile, Diagnostics: diags}
+	if report.Diagnostics == nil {
+		report.Diagnostics = []config.Diagnostic{}
+	}
+	for _, d := range diags {
+		if d.Severity == config.SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Valid = report.Errors == 0 && (!*strict || report.Warnings == 0)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if !report.Valid {
+		return fmt.Errorf("%s: %d errors, %d warnings", *configFile, report.Errors, report.Warnings)
+	}
+	return nil
+}
+
+// printDefaults 以 YAML 输出默认配置，可以作为模板的起点
+func printDefaults(args []string) error {
+	fs := flag.NewFlagSet("config print-defaults", flag.ExitOnError)
+	clusterID := fs.Uint64("cluster-id", 1, "cluster ID")
+	memberID := fs.Uint64("member-id", 1, "member ID")
+	etcdAddress := fs.String("grpc-addr", ":2379", "gRPC server address for etcd compatibility")
+	fs.Parse(args)
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(config.DefaultConfig(*clusterID, *memberID, *etcdAddress)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"metaStore/pkg/config"
+	"metaStore/pkg/datadir"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// lockDataDir 锁定成员的数据目录，目录已被其他进程使用或属于另一个成员时退出。
+// 锁在进程退出时释放
+func lockDataDir(dir string, cfg *config.Config) *datadir.Lock {
+	lock, err := datadir.Acquire(dir, datadir.Owner{
+		ClusterID: cfg.Server.ClusterID,
+		MemberID:  cfg.Server.MemberID,
+	})
+	if err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+	if cfg.Server.Raft.ForceNewCluster {
+		log.Warn("Forcing a new cluster: the other members are removed from the raft configuration",
+			zap.String("data_dir", dir),
+			zap.Uint64("member_id", cfg.Server.MemberID),
+			zap.String("component", "main"))
+	}
+	return lock
+}
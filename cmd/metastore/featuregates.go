@@ -0,0 +1,65 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"metaStore/pkg/config"
+	"metaStore/pkg/featuregate"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// loadFeatureGates 合并配置文件和 --feature-gates 中的功能开关，命令行优先。
+// 关闭的功能在这里从配置中去掉，后续组件按配置启动即可
+func loadFeatureGates(cfg *config.Config, flagValue string) (*featuregate.Gate, error) {
+	overrides, err := featuregate.ParseList(flagValue)
+	if err != nil {
+		return nil, fmt.Errorf("--feature-gates: %w", err)
+	}
+	if len(overrides) > 0 {
+		if cfg.Server.FeatureGates == nil {
+			cfg.Server.FeatureGates = map[string]bool{}
+		}
+		for name, on := range overrides {
+			cfg.Server.FeatureGates[name] = on
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	gate, err := featuregate.New(cfg.Server.FeatureGates)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ApplyFeatureGates(gate) {
+		log.Info("Lease read disabled by feature gate, reads use ReadIndex",
+			zap.String("gate", string(featuregate.LeaseRead)),
+			zap.String("component", "main"))
+	}
+
+	for _, st := range gate.Statuses() {
+		log.Info("Feature gate",
+			zap.String("name", string(st.Name)),
+			zap.String("stage", string(st.Stage)),
+			zap.Bool("enabled", st.Enabled),
+			zap.String("component", "main"))
+	}
+	return gate, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"metaStore/pkg/config"
+)
+
+// configFlag 直接映射到配置字段的命令行参数
+type configFlag struct {
+	name  string
+	field string // server: 下的字段路径
+	usage string
+}
+
+// configFlags 只设置配置字段的命令行参数，未指定时依次使用环境变量、配置文件和默认值，
+// 因此不需要配置文件也能设置各个前端的监听地址和数据目录
+var configFlags = []configFlag{
+	{"http-addr", "http.address", "HTTP API listen address"},
+	{"mysql-addr", "mysql.address", "MySQL protocol listen address"},
+	{"metrics-port", "monitoring.prometheus_port", "Prometheus metrics port"},
+	{"listen-client-urls", "etcd.listen_client_urls", "comma separated URLs the etcd gRPC server listens on"},
+	{"advertise-client-urls", "etcd.advertise_client_urls", "comma separated client URLs published in MemberList"},
+	{"listen-peer-urls", "raft.listen_peer_urls", "comma separated URLs the raft transport listens on"},
+	{"advertise-peer-urls", "raft.advertise_peer_urls", "comma separated URLs other members reach this member on"},
+	{"listen-metrics-urls", "monitoring.listen_metrics_urls", "comma separated URLs the metrics server listens on"},
+	{"data-dir", "storage.data_dir", "state machine directory"},
+	{"wal-dir", "storage.wal_dir", "raft log directory"},
+	{"snap-dir", "storage.snap_dir", "raft snapshot directory"},
+	{"tls-cert-file", "tls.cert_file", "PEM certificate chain for client connections"},
+	{"tls-key-file", "tls.key_file", "PEM private key of the certificate"},
+	{"tls-ca-file", "tls.ca_file", "CA bundle to verify client certificates"},
+	{"log-level", "log.level", "log level: debug, info, warn or error"},
+	{"log-encoding", "log.encoding", "log encoding: json or console"},
+}
+
+// flagFields 同时在加载配置前使用的参数覆盖的配置字段
+var flagFields = map[string]string{
+	"cluster-id": "cluster_id",
+	"member-id":  "member_id",
+	"grpc-addr":  "etcd.address",
+	"port":       "http.address",
+}
+
+// registerConfigFlags 在 fs 上注册 configFlags
+func registerConfigFlags(fs *flag.FlagSet) {
+	for _, f := range configFlags {
+		fs.String(f.name, "", fmt.Sprintf("%s, overrides server.%s", f.usage, f.field))
+	}
+}
+
+// applyFlags 把显式指定的参数写入配置并记录为覆盖，未指定的参数不改变配置
+func applyFlags(fs *flag.FlagSet, cfg *config.Config) error {
+	fields := make(map[string]string, len(flagFields)+len(configFlags))
+	for name, field := range flagFields {
+		fields[name] = field
+	}
+	for _, f := range configFlags {
+		fields[f.name] = f.field
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		field, ok := fields[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		value := f.Value.String()
+		if f.Name == "port" {
+			// --port 只指定端口，监听所有地址
+			value = ":" + value
+		}
+		err = cfg.SetField("--"+f.Name, field, value)
+	})
+	return err
+}
@@ -18,18 +18,36 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	// "metaStore/internal/batch" // 已禁用 BatchProposer
+	"metaStore/internal/kvstore"
 	"metaStore/internal/memory"
 	"metaStore/internal/raft"
 	"metaStore/internal/rocksdb"
+	"metaStore/pkg/admission"
+	"metaStore/pkg/cdc"
+	"metaStore/pkg/chaos"
+	"metaStore/pkg/chunk"
 	"metaStore/pkg/config"
+	"metaStore/pkg/datadir"
+	"metaStore/pkg/encryption"
+	"metaStore/pkg/featuregate"
+	"metaStore/pkg/history"
+	"metaStore/pkg/hlc"
 	"metaStore/api/etcd"
 	"metaStore/api/http"
 	"metaStore/pkg/log"
 	"metaStore/pkg/metrics"
+	"metaStore/pkg/mirror"
+	"metaStore/pkg/protect"
+	"metaStore/pkg/reliability"
 	"metaStore/api/mysql"
+	"metaStore/pkg/schema"
+	"metaStore/pkg/sqlindex"
+	"metaStore/pkg/usage"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.etcd.io/raft/v3/raftpb"
@@ -37,25 +55,59 @@ import (
 	// "time" // 已禁用 BatchProposer，不再需要
 )
 
-const (
-	// proposeChanBufferSize defines the buffer size for Raft proposal channel
-	// Larger buffer enables pipeline writes for better throughput (2-5x improvement)
-	// Value based on typical write burst patterns and memory constraints
-	proposeChanBufferSize = 10000
-)
-
 func main() {
+	// metastore proxy 以无状态代理运行，参数与服务端不同
+	if len(os.Args) > 1 && os.Args[1] == "proxy" {
+		if err := runProxy(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "proxy: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	// metastore recover 在成员停止时离线改写 raft 存储中的成员配置
+	if len(os.Args) > 1 && os.Args[1] == "recover" {
+		if err := runRecover(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "recover: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// metastore replay 把持久化的 raft 日志回放到全新的状态机，用于排查问题
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// metastore config 检查配置文件或输出默认配置，不启动成员
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 配置文件路径（可选）
 	configFile := flag.String("config", "", "path to config file (optional, uses defaults if not provided)")
 
 	// 命令行参数（用于覆盖配置文件或在无配置文件时使用）
 	cluster := flag.String("cluster", "http://127.0.0.1:9021", "comma separated cluster peers")
+	flag.StringVar(cluster, "peer-urls", *cluster, "comma separated cluster peers, same as --cluster")
 	clusterID := flag.Uint64("cluster-id", 1, "cluster ID")
 	memberID := flag.Int("member-id", 1, "node ID")
-	kvport := flag.Int("port", 9121, "http server port")
+	flag.Int("port", 9121, "http server port, overrides server.http.address")
 	grpcAddr := flag.String("grpc-addr", ":2379", "gRPC server address for etcd compatibility")
 	join := flag.Bool("join", false, "join an existing cluster")
 	storageEngine := flag.String("storage", "memory", "storage engine: memory or rocksdb")
+	ephemeral := flag.Bool("ephemeral", false, "run memory storage as a single node without raft and WAL (data is lost on restart)")
+	featureGates := flag.String("feature-gates", "", "comma separated Name=true|false pairs, overrides server.feature_gates in the config file")
+	forceNewCluster := flag.Bool("force-new-cluster", false, "restart this member as the only voter of its cluster, keeping its data (disaster recovery after losing the quorum)")
+	smokeTest := flag.Bool("smoke-test", false, "start the storage engine in a temporary directory, run put/get/txn/watch/lease/delete round-trips, print a JSON report and exit (non-zero on failure)")
+	registerConfigFlags(flag.CommandLine)
 
 	flag.Parse()
 
@@ -67,6 +119,21 @@ func main() {
 		os.Exit(-1)
 	}
 
+	// 优先级：命令行参数 > 环境变量 > 配置文件 > 默认值。显式指定的参数覆盖配置，
+	// 未指定 --member-id 时 raft 使用配置中的成员 ID
+	if err := applyFlags(flag.CommandLine, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(-1)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		os.Exit(-1)
+	}
+	*memberID = int(cfg.Server.MemberID)
+
+	// 强制单成员集群只能通过命令行参数开启，留在配置文件中会在每次重启时移除其他成员
+	cfg.Server.Raft.ForceNewCluster = *forceNewCluster
+
 	// 初始化日志系统（必须在其他组件之前初始化）
 	if err := log.InitFromConfig(&cfg.Server.Log); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
@@ -78,6 +145,25 @@ func main() {
 		zap.Strings("output_paths", cfg.Server.Log.OutputPaths),
 		zap.Strings("error_output_paths", cfg.Server.Log.ErrorOutputPaths),
 		zap.String("component", "main"))
+	for _, o := range cfg.Overrides {
+		log.Info("Config field overridden",
+			zap.String("field", o.Field),
+			zap.String("source", o.Source),
+			zap.String("value", o.Value),
+			zap.String("component", "config"))
+	}
+
+	// 只读副本以 learner 身份加入已有集群，作为初始成员启动会成为投票成员
+	if cfg.Server.Raft.IsReplica() && !*join {
+		log.Fatalf("raft.node_role replica requires --join: add the member with 'member add --learner' first")
+	}
+
+	// 其他成员通过 --cluster 中本节点的 URL 连接本节点，发布的 peer URL 必须包含它
+	if peers := strings.Split(*cluster, ","); *memberID >= 1 && *memberID <= len(peers) {
+		if err := cfg.Server.Raft.CheckAdvertisedPeer(peers[*memberID-1]); err != nil {
+			log.Fatalf("Invalid peer URLs: %v", err)
+		}
+	}
 
 	// 初始化全局性能配置
 	config.InitPerformanceConfig(cfg)
@@ -87,27 +173,84 @@ func main() {
 		zap.Bool("enable_lease_protobuf", config.GetEnableLeaseProtobuf()),
 		zap.String("component", "config"))
 
+	// 功能开关，必须在按配置创建 raft 节点和存储之前加载
+	gate, err := loadFeatureGates(cfg, *featureGates)
+	if err != nil {
+		log.Fatalf("Invalid feature gates: %v", err)
+		os.Exit(-1)
+		return
+	}
+
+	// raft 日志和快照的 CRC32C 校验，必须在打开存储之前设置；已有的校验在读取时总是验证
+	reliability.SetCRC(cfg.Server.Reliability.EnableCRC)
+
+	// 静态加密，必须在打开存储和加载快照之前设置 keyring
+	keyring, err := encryption.LoadKeyring(&cfg.Server.Encryption)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+		os.Exit(-1)
+		return
+	}
+	encryption.SetKeyring(keyring)
+	if keyring != nil {
+		log.Info("Encryption at rest enabled",
+			zap.String("active_key", keyring.ActiveKeyID()),
+			zap.Strings("keys", keyring.KeyIDs()),
+			zap.String("component", "encryption"))
+	}
+
+	// 本节点的混合逻辑时钟，存储引擎用它为提交的操作分配 HLC 时间戳
+	clock := hlc.NewClock(cfg.Server.HLC.MaxOffset)
+
+	// 冒烟测试在临时目录中启动存储引擎并完成一轮读写后退出，不打开数据目录，不监听端口
+	if *smokeTest {
+		if err := runSmokeTest(os.Stdout, cfg, *storageEngine, gate, clock); err != nil {
+			fmt.Fprintf(os.Stderr, "smoke-test: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 启动 Prometheus 指标服务器（如果启用）
+	var prometheusRegistry *prometheus.Registry
 	if cfg.Server.Monitoring.EnablePrometheus {
-		prometheusAddr := fmt.Sprintf(":%d", cfg.Server.Monitoring.PrometheusPort)
-		prometheusRegistry := prometheus.NewRegistry()
+		prometheusAddrs := cfg.Server.Monitoring.ListenAddresses()
+		prometheusRegistry = prometheus.NewRegistry()
 
 		// 注册默认的 Go 运行时指标
 		prometheusRegistry.MustRegister(prometheus.NewGoCollector())
 		prometheusRegistry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+		prometheusRegistry.MustRegister(metrics.NewFeatureGateCollector(gate))
+		prometheusRegistry.MustRegister(metrics.NewCorruptionCollector())
+		prometheusRegistry.MustRegister(metrics.NewPanicCollector())
+		prometheusRegistry.MustRegister(metrics.NewProposalCollector())
+		prometheusRegistry.MustRegister(metrics.NewHLCCollector(clock))
+
+		// 使用 zap 的全局 logger
+		metricsServer := metrics.NewMetricsServer(prometheusAddrs[0], prometheusRegistry, zap.L())
+
+		// 故障注入端点（仅用于测试）
+		if cfg.Server.Reliability.EnableFaultInjection {
+			chaos.Enable()
+			metricsServer.Handle("/debug/chaos", chaos.Handler())
+			log.Warn("Fault injection enabled, do not use in production",
+				zap.String("endpoint", "/debug/chaos"),
+				zap.String("component", "main"))
+		}
 
-		go func() {
-			// 使用 zap 的全局 logger
-			metricsServer := metrics.NewMetricsServer(prometheusAddr, prometheusRegistry, zap.L())
-			log.Info("Starting Prometheus metrics server",
-				zap.String("address", prometheusAddr),
-				zap.String("component", "metrics"))
-			if err := metricsServer.Start(); err != nil {
-				log.Error("Prometheus metrics server failed",
-					zap.Error(err),
+		// monitoring.listen_metrics_urls 中的每个地址由同一个服务器提供
+		for _, addr := range prometheusAddrs {
+			go func() {
+				log.Info("Starting Prometheus metrics server",
+					zap.String("address", addr),
 					zap.String("component", "metrics"))
-			}
-		}()
+				if err := metricsServer.ListenAndServe(addr); err != nil {
+					log.Error("Prometheus metrics server failed",
+						zap.Error(err),
+						zap.String("component", "metrics"))
+				}
+			}()
+		}
 	}
 
 	// 配置文件可以被命令行参数覆盖
@@ -126,8 +269,59 @@ func main() {
 			zap.String("component", "main"))
 	}
 
-	proposeC := make(chan string, proposeChanBufferSize)
-	defer close(proposeC)
+	if *ephemeral && *storageEngine != "memory" {
+		log.Fatalf("--ephemeral is only supported with --storage=memory")
+		os.Exit(-1)
+		return
+	}
+
+	// 操作历史记录（仅用于线性一致性测试）
+	var historyRecorder *history.Recorder
+	if cfg.Server.Reliability.HistoryFile != "" {
+		historyRecorder, err = history.NewRecorder(cfg.Server.Reliability.HistoryFile)
+		if err != nil {
+			log.Fatalf("Failed to open history file: %v", err)
+			os.Exit(-1)
+			return
+		}
+		defer historyRecorder.Close()
+		log.Warn("Recording operation history, do not use in production",
+			zap.String("history_file", cfg.Server.Reliability.HistoryFile),
+			zap.String("component", "main"))
+	}
+
+	// 提案管道背压：饱和时立即拒绝写入并返回重试提示，超过 limits.max_request_size 的提案不提交给 Raft
+	backpressure := kvstore.Backpressure{
+		QueueThreshold: cfg.Server.Limits.ProposeQueueThreshold,
+		MaxPending:     cfg.Server.Limits.MaxPendingProposals,
+		RetryAfter:     cfg.Server.Limits.RetryAfter,
+		Timeout:        cfg.Server.Limits.RequestTimeout,
+
+		MaxRequestBytes: cfg.Server.Limits.MaxRequestSize,
+	}
+
+	// 准入 hook
+	admissionChain, err := admission.NewChain(cfg.Server.Admission)
+	if err != nil {
+		log.Fatalf("Failed to configure admission hooks: %v", err)
+		os.Exit(-1)
+		return
+	}
+
+	// frontendStore 为每个前端包装存储：准入 hook 在最外层，未启用记录或没有 hook 时原样返回
+	frontendStore := func(kvs kvstore.Store, frontend string) kvstore.Store {
+		recorded := history.Wrap(kvs, historyRecorder, fmt.Sprintf("%d/%s", cfg.Server.MemberID, frontend))
+		return admission.Wrap(recorded, admissionChain, frontend)
+	}
+
+	// 提案队列：存储引擎按优先级入队，raft 节点（或批量提案器）从 proposeC 读取
+	proposeQueue := kvstore.NewProposeQueue(kvstore.ProposeQueueConfig{
+		Capacity:   cfg.Server.Limits.ProposeQueueSize,
+		Overflow:   kvstore.OverflowPolicy(cfg.Server.Limits.ProposeQueueOverflow),
+		RetryAfter: cfg.Server.Limits.RetryAfter,
+	})
+	defer proposeQueue.Close()
+	proposeC := proposeQueue.C()
 	confChangeC := make(chan raftpb.ConfChange)
 	defer close(confChangeC)
 
@@ -135,10 +329,21 @@ func main() {
 	case "rocksdb":
 		// RocksDB mode - persistent storage
 		log.Info("Starting with RocksDB persistent storage", zap.String("component", "main"))
-		dbPath := fmt.Sprintf("data/rocksdb/%d", cfg.Server.MemberID)
+		dbPath, walDir, snapDir := cfg.Server.Storage.Dirs("rocksdb", fmt.Sprintf("data/rocksdb/%d", cfg.Server.MemberID))
+
+		// 锁定数据目录，拒绝第二个进程或另一个成员打开同一个数据目录
+		dirLock := lockDataDir(dbPath, cfg)
+		defer dirLock.Release()
+
+		// 检查数据目录，WAL 或快照目录变化时先迁移文件
+		_, defaultWAL, defaultSnap := config.StorageConfig{DataDir: dbPath}.Dirs("rocksdb", dbPath)
+		if err := datadir.Prepare(datadir.Layout{Data: dbPath, WAL: walDir, Snap: snapDir},
+			datadir.Layout{Data: dbPath, WAL: defaultWAL, Snap: defaultSnap}, rocksdb.IsWALFile); err != nil {
+			log.Fatalf("Failed to prepare data directories: %v", err)
+		}
 
 		// 使用配置文件中的 RocksDB 配置
-		db, err := rocksdb.Open(dbPath, &cfg.Server.RocksDB)
+		db, err := rocksdb.OpenWithWALDir(dbPath, walDir, &cfg.Server.RocksDB)
 		if err != nil {
 			log.Fatalf("Failed to open RocksDB: %v", err)
 			os.Exit(-1)
@@ -157,29 +362,140 @@ func main() {
 
 		// Create RocksDB-backed KV store
 		var kvs *rocksdb.RocksDB
-		getSnapshot := func() ([]byte, error) { return kvs.GetSnapshot() }
+		getSnapshot := func() ([]byte, error) { return kvs.RaftSnapshot() }
 		commitC, errorC, snapshotterReady, raftNode := raft.NewNodeRocksDB(*memberID, strings.Split(*cluster, ","), *join, getSnapshot, proposeC, confChangeC, db, dbPath, cfg)
 
 		// 使用原始构造函数（不使用 BatchProposer）
-		kvs = rocksdb.NewRocksDB(db, <-snapshotterReady, proposeC, commitC, errorC)
+		kvs = rocksdb.NewRocksDB(db, <-snapshotterReady, nil, commitC, errorC)
+		kvs.SetProposeQueue(proposeQueue)
 		defer kvs.Close()
 
 		// 注入 raft 节点引用，用于获取状态信息
 		kvs.SetRaftNode(raftNode, cfg.Server.MemberID)
+		kvs.SetBackpressure(backpressure)
+		kvs.SetClock(clock)
+		kvs.SetWatchHistory(cfg.Server.MVCC.WatchHistory.MaxEvents, cfg.Server.MVCC.WatchHistory.Retention)
+		kvs.SetMissCache(cfg.Server.RocksDB.MissCacheSize)
+		kvs.SetReadCache(cfg.Server.RocksDB.ReadCacheSize)
+		kvs.SetSerializableReads(cfg.Server.RocksDB.SerializableReads)
+		kvs.SetEngineStatsInterval(cfg.Server.RocksDB.StatsInterval)
+		stopStallControl := kvs.StartStallControl(&cfg.Server.RocksDB)
+		defer stopStallControl()
+		// 开启后 raft 快照只引用本节点的 checkpoint，其他成员从 raft 端口下载 SST 文件
+		kvs.SetCheckpointBootstrap(strings.Split(*cluster, ",")[*memberID-1], cfg.Server.RocksDB.CheckpointBootstrap)
+
+		// 集群运行时设置覆盖配置文件中的背压参数，并控制自动压缩
+		clusterSettings, stopSettings := startClusterSettings(kvs, backpressure, kvs.SetBackpressure, gate)
+		defer stopSettings()
+
+		// Lease Read 指标（租约命中率 / ReadIndex 回退）、提案管道占用、复制进度、不存在 key 查询缓存、读缓存和 RocksDB 内部统计
+		if prometheusRegistry != nil {
+			prometheusRegistry.MustRegister(metrics.NewLeaseReadCollector(raftNode.ReadIndexManager))
+			prometheusRegistry.MustRegister(metrics.NewProposeQueueCollector(kvs.ProposeQueueStats))
+			prometheusRegistry.MustRegister(metrics.NewReplicationCollector(kvs.GetRaftStatus, kvs.Members))
+			if cfg.Server.RocksDB.MissCacheSize > 0 {
+				prometheusRegistry.MustRegister(metrics.NewMissCacheCollector(kvs.MissCacheStats))
+			}
+			if cfg.Server.RocksDB.ReadCacheSize > 0 {
+				prometheusRegistry.MustRegister(metrics.NewReadCacheCollector(kvs.ReadCacheStats))
+			}
+			prometheusRegistry.MustRegister(metrics.NewStorageEngineCollector(kvs.EngineStats))
+		}
+
+		// 超过 chunk_size 的 value 分段存储，上层包装和 CDC 看到的都是完整的 value
+		// 所有前端的写入在提案前检查 value 大小（max_value_size 及按前缀的覆盖上限）
+		chunked := chunk.Wrap(kvs, cfg.Server.Chunking)
+		if prometheusRegistry != nil {
+			prometheusRegistry.MustRegister(metrics.NewValueSizeCollector(chunked.ValueSizeStats))
+		}
+
+		// MySQL 二级索引，所有前端和 mirror 的写入都经过它维护索引条目
+		indexed := sqlindex.Wrap(chunked)
+		defer indexed.Close()
+
+		// 按前缀注册的 JSON Schema 校验，只作用于客户端写入，mirror 按原样复制远端数据
+		validated := schema.Wrap(indexed)
+		defer validated.Close()
+
+		// 受保护前缀上的删除失败或移入回收站，只作用于客户端写入
+		protected := protect.Wrap(validated, cfg.Server.DeleteProtection)
+		defer protected.Close()
+
+		// 跨数据中心复制（只在 leader 上运行）
+		mirrors := mirror.NewManager(indexed, cfg.Server.Mirror)
+		mirrors.StartConfigured()
+		defer mirrors.Close()
+
+		// 变更数据发布到 Kafka/NATS（只在 leader 上运行）
+		cdcManager := cdc.NewManager(chunked, cfg.Server.CDC)
+		cdcManager.Start()
+		defer cdcManager.Close()
+
+		// 按前缀统计用量，统计存储中实际的 key（包括分段和索引等内部 key）
+		usageTracker := usage.NewTracker(kvs, cfg.Server.Usage)
+		usageTracker.Start()
+		defer usageTracker.Close()
+		if prometheusRegistry != nil && usageTracker.Enabled() {
+			prometheusRegistry.MustRegister(metrics.NewUsageCollector(usageTracker.Report))
+		}
+
+		// Create etcd gRPC server first, the HTTP and MySQL frontends share its user database
+		log.Info("Starting etcd gRPC server",
+			zap.String("address", cfg.Server.Etcd.Address),
+			zap.Uint64("cluster_id", cfg.Server.ClusterID),
+			zap.Uint64("member_id", cfg.Server.MemberID),
+			zap.String("component", "main"))
+		etcdServer, err := etcd.NewServer(etcd.ServerConfig{
+			Store:        frontendStore(protected, "etcd"),
+			Address:      cfg.Server.Etcd.Address,
+			ClusterID:    cfg.Server.ClusterID,
+			MemberID:     cfg.Server.MemberID,
+			ClusterPeers: strings.Split(*cluster, ","),
+			ConfChangeC:  confChangeC,
+			Config:       cfg,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create etcd server: %v", err)
+			os.Exit(-1)
+			return
+		}
+		if prometheusRegistry != nil {
+			prometheusRegistry.MustRegister(metrics.NewLeaseExpiryCollector(etcdServer.LeaseExpiryStats))
+			prometheusRegistry.MustRegister(metrics.NewResourceLimitCollector(etcdServer.WatchUsage, etcdServer.LeaseUsage))
+			prometheusRegistry.MustRegister(metrics.NewWatchScavengeCollector(etcdServer.WatchScavengeStats))
+			prometheusRegistry.MustRegister(metrics.NewGRPCCompressionCollector(etcdServer.CompressionStats))
+		}
 
 		// Start HTTP API server
 		go func() {
-			log.Info("Starting HTTP API", zap.Int("port", *kvport), zap.String("component", "main"))
-			http.ServeHTTPKVAPI(kvs, *kvport, confChangeC, errorC)
+			log.Info("Starting HTTP API", zap.String("address", cfg.Server.HTTP.Address), zap.String("component", "main"))
+			http.ServeHTTPAPI(http.Config{
+				Store:       frontendStore(protected, "http"),
+				Address:     cfg.Server.HTTP.Address,
+				ConfChangeC: confChangeC,
+				Mirrors:     mirrors,
+				Settings:    clusterSettings,
+				Encryption:  kvs,
+				Drainer:     etcdServer,
+				Usage:       usageTracker,
+				Users:       etcdServer.AuthManager(),
+				MaxBatchOps: cfg.Server.Limits.MaxBatchOps,
+
+				HealthMaxApplyLag: cfg.Server.Reliability.HealthMaxApplyLag,
+				Replica:           cfg.Server.Raft.IsReplica(),
+				Capabilities:      etcdServer.ClusterSupports,
+			}, errorC)
 		}()
 
 		// Start MySQL protocol server
 		mysqlServer, err := mysql.NewServer(mysql.ServerConfig{
-			Store:    kvs,
+			Store:    frontendStore(protected, "mysql"),
 			Address:  cfg.Server.MySQL.Address,
 			Username: cfg.Server.MySQL.Username,
 			Password: cfg.Server.MySQL.Password,
 			Config:   cfg,
+			Users:    etcdServer.AuthManager(),
+			Usage:    usageTracker,
 		})
 		if err != nil {
 			log.Fatalf("Failed to create MySQL server: %v", err)
@@ -198,14 +514,114 @@ func main() {
 			}
 		}()
 
-		// Start etcd gRPC server
+		if err := etcdServer.Start(); err != nil {
+			log.Fatalf("etcd server failed: %v", err)
+			os.Exit(-1)
+			return
+		}
+
+	case "memory":
+		// Memory + WAL mode with etcd compatibility
+		var kvs *memory.Memory
+		var errorC <-chan error
+		if *ephemeral {
+			// Ephemeral mode - single node, no raft consensus and no WAL
+			log.Info("Starting with ephemeral memory storage (no raft, no WAL)", zap.String("component", "main"))
+			commitC, errC, ephemeralNode := raft.NewEphemeralNode(*memberID, proposeC, confChangeC)
+			errorC = errC
+			kvs = memory.NewMemory(nil, nil, commitC, errorC)
+			kvs.SetProposeQueue(proposeQueue)
+			kvs.SetRaftNode(ephemeralNode, cfg.Server.MemberID)
+		} else {
+			log.Info("Starting with memory + WAL storage and etcd gRPC support", zap.String("component", "main"))
+
+			// 检查数据目录，WAL 或快照目录变化时先迁移文件
+			dataDir, walDir, snapDir := cfg.Server.Storage.Dirs("memory", filepath.Join("data", "memory", strconv.Itoa(*memberID)))
+			dirLock := lockDataDir(dataDir, cfg)
+			defer dirLock.Release()
+			_, defaultWAL, defaultSnap := config.StorageConfig{DataDir: dataDir}.Dirs("memory", dataDir)
+			if err := datadir.Prepare(datadir.Layout{Data: dataDir, WAL: walDir, Snap: snapDir},
+				datadir.Layout{Data: dataDir, WAL: defaultWAL, Snap: defaultSnap}, nil); err != nil {
+				log.Fatalf("Failed to prepare data directories: %v", err)
+			}
+
+			getSnapshot := func() ([]byte, error) { return kvs.GetSnapshot() }
+			commitC, errC, snapshotterReady, raftNode := raft.NewNode(*memberID, strings.Split(*cluster, ","), *join, getSnapshot, proposeC, confChangeC, "memory", cfg)
+			errorC = errC
+
+			// 使用原始构造函数（不使用 BatchProposer）
+			kvs = memory.NewMemory(<-snapshotterReady, nil, commitC, errorC)
+			kvs.SetProposeQueue(proposeQueue)
+
+			// 注入 raft 节点引用，用于获取状态信息
+			kvs.SetRaftNode(raftNode, cfg.Server.MemberID)
+
+			// Lease Read 指标（租约命中率 / ReadIndex 回退）
+			if prometheusRegistry != nil {
+				prometheusRegistry.MustRegister(metrics.NewLeaseReadCollector(raftNode.ReadIndexManager))
+			}
+		}
+		kvs.SetBackpressure(backpressure)
+		kvs.SetBatchApply(gate.Enabled(featuregate.BatchApply))
+		// 合并应用时按分片并行执行一个 commit 中的写操作，worker 随进程退出
+		kvs.SetApplyPool(kvstore.NewApplyPool(cfg.Server.Limits.ApplyWorkers))
+		kvs.SetClock(clock)
+		kvs.SetWatchHistory(cfg.Server.MVCC.WatchHistory.MaxEvents, cfg.Server.MVCC.WatchHistory.Retention)
+
+		// 集群运行时设置覆盖配置文件中的背压参数，并控制自动压缩
+		clusterSettings, stopSettings := startClusterSettings(kvs, backpressure, kvs.SetBackpressure, gate)
+		defer stopSettings()
+
+		if prometheusRegistry != nil {
+			prometheusRegistry.MustRegister(metrics.NewProposeQueueCollector(kvs.ProposeQueueStats))
+			prometheusRegistry.MustRegister(metrics.NewReplicationCollector(kvs.GetRaftStatus, kvs.Members))
+		}
+
+		// 超过 chunk_size 的 value 分段存储，上层包装和 CDC 看到的都是完整的 value
+		// 所有前端的写入在提案前检查 value 大小（max_value_size 及按前缀的覆盖上限）
+		chunked := chunk.Wrap(kvs, cfg.Server.Chunking)
+		if prometheusRegistry != nil {
+			prometheusRegistry.MustRegister(metrics.NewValueSizeCollector(chunked.ValueSizeStats))
+		}
+
+		// MySQL 二级索引，所有前端和 mirror 的写入都经过它维护索引条目
+		indexed := sqlindex.Wrap(chunked)
+		defer indexed.Close()
+
+		// 按前缀注册的 JSON Schema 校验，只作用于客户端写入，mirror 按原样复制远端数据
+		validated := schema.Wrap(indexed)
+		defer validated.Close()
+
+		// 受保护前缀上的删除失败或移入回收站，只作用于客户端写入
+		protected := protect.Wrap(validated, cfg.Server.DeleteProtection)
+		defer protected.Close()
+
+		// 跨数据中心复制（只在 leader 上运行）
+		mirrors := mirror.NewManager(indexed, cfg.Server.Mirror)
+		mirrors.StartConfigured()
+		defer mirrors.Close()
+
+		// 变更数据发布到 Kafka/NATS（只在 leader 上运行）
+		cdcManager := cdc.NewManager(chunked, cfg.Server.CDC)
+		cdcManager.Start()
+		defer cdcManager.Close()
+
+		// 按前缀统计用量，统计存储中实际的 key（包括分段和索引等内部 key）
+		usageTracker := usage.NewTracker(kvs, cfg.Server.Usage)
+		usageTracker.Start()
+		defer usageTracker.Close()
+		if prometheusRegistry != nil && usageTracker.Enabled() {
+			prometheusRegistry.MustRegister(metrics.NewUsageCollector(usageTracker.Report))
+		}
+
+		// Create etcd gRPC server first, the HTTP and MySQL frontends share its user database
 		log.Info("Starting etcd gRPC server",
 			zap.String("address", cfg.Server.Etcd.Address),
 			zap.Uint64("cluster_id", cfg.Server.ClusterID),
 			zap.Uint64("member_id", cfg.Server.MemberID),
 			zap.String("component", "main"))
 		etcdServer, err := etcd.NewServer(etcd.ServerConfig{
-			Store:        kvs,
+			Store:        frontendStore(protected, "etcd"),
 			Address:      cfg.Server.Etcd.Address,
 			ClusterID:    cfg.Server.ClusterID,
 			MemberID:     cfg.Server.MemberID,
@@ -218,39 +634,42 @@ func main() {
 			os.Exit(-1)
 			return
 		}
-
-		if err := etcdServer.Start(); err != nil {
-			log.Fatalf("etcd server failed: %v", err)
-			os.Exit(-1)
-			return
+		if prometheusRegistry != nil {
+			prometheusRegistry.MustRegister(metrics.NewLeaseExpiryCollector(etcdServer.LeaseExpiryStats))
+			prometheusRegistry.MustRegister(metrics.NewResourceLimitCollector(etcdServer.WatchUsage, etcdServer.LeaseUsage))
+			prometheusRegistry.MustRegister(metrics.NewWatchScavengeCollector(etcdServer.WatchScavengeStats))
+			prometheusRegistry.MustRegister(metrics.NewGRPCCompressionCollector(etcdServer.CompressionStats))
 		}
 
-	case "memory":
-		// Memory + WAL mode with etcd compatibility
-		log.Info("Starting with memory + WAL storage and etcd gRPC support", zap.String("component", "main"))
-		var kvs *memory.Memory
-		getSnapshot := func() ([]byte, error) { return kvs.GetSnapshot() }
-		commitC, errorC, snapshotterReady, raftNode := raft.NewNode(*memberID, strings.Split(*cluster, ","), *join, getSnapshot, proposeC, confChangeC, "memory", cfg)
-
-		// 使用原始构造函数（不使用 BatchProposer）
-		kvs = memory.NewMemory(<-snapshotterReady, proposeC, commitC, errorC)
-
-		// 注入 raft 节点引用，用于获取状态信息
-		kvs.SetRaftNode(raftNode, cfg.Server.MemberID)
-
 		// Start HTTP API server
 		go func() {
-			log.Info("Starting HTTP API", zap.Int("port", *kvport), zap.String("component", "main"))
-			http.ServeHTTPKVAPI(kvs, *kvport, confChangeC, errorC)
+			log.Info("Starting HTTP API", zap.String("address", cfg.Server.HTTP.Address), zap.String("component", "main"))
+			http.ServeHTTPAPI(http.Config{
+				Store:       frontendStore(protected, "http"),
+				Address:     cfg.Server.HTTP.Address,
+				ConfChangeC: confChangeC,
+				Mirrors:     mirrors,
+				Settings:    clusterSettings,
+				Drainer:     etcdServer,
+				Usage:       usageTracker,
+				Users:       etcdServer.AuthManager(),
+				MaxBatchOps: cfg.Server.Limits.MaxBatchOps,
+
+				HealthMaxApplyLag: cfg.Server.Reliability.HealthMaxApplyLag,
+				Replica:           cfg.Server.Raft.IsReplica(),
+				Capabilities:      etcdServer.ClusterSupports,
+			}, errorC)
 		}()
 
 		// Start MySQL protocol server
 		mysqlServer, err := mysql.NewServer(mysql.ServerConfig{
-			Store:    kvs,
+			Store:    frontendStore(protected, "mysql"),
 			Address:  cfg.Server.MySQL.Address,
 			Username: cfg.Server.MySQL.Username,
 			Password: cfg.Server.MySQL.Password,
 			Config:   cfg,
+			Users:    etcdServer.AuthManager(),
+			Usage:    usageTracker,
 		})
 		if err != nil {
 			log.Fatalf("Failed to create MySQL server: %v", err)
@@ -269,27 +688,6 @@ func main() {
 			}
 		}()
 
-		// Start etcd gRPC server
-		log.Info("Starting etcd gRPC server",
-			zap.String("address", cfg.Server.Etcd.Address),
-			zap.Uint64("cluster_id", cfg.Server.ClusterID),
-			zap.Uint64("member_id", cfg.Server.MemberID),
-			zap.String("component", "main"))
-		etcdServer, err := etcd.NewServer(etcd.ServerConfig{
-			Store:        kvs,
-			Address:      cfg.Server.Etcd.Address,
-			ClusterID:    cfg.Server.ClusterID,
-			MemberID:     cfg.Server.MemberID,
-			ClusterPeers: strings.Split(*cluster, ","),
-			ConfChangeC:  confChangeC,
-			Config:       cfg,
-		})
-		if err != nil {
-			log.Fatalf("Failed to create etcd server: %v", err)
-			os.Exit(-1)
-			return
-		}
-
 		if err := etcdServer.Start(); err != nil {
 			log.Fatalf("etcd server failed: %v", err)
 			os.Exit(-1)
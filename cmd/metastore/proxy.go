@@ -0,0 +1,85 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+	"metaStore/pkg/proxy"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// runProxy 运行 metastore proxy 子命令：不参与 raft 的无状态 gRPC 代理
+func runProxy(args []string) error {
+	fs := flag.NewFlagSet("proxy", flag.ExitOnError)
+	endpoints := fs.String("endpoints", "127.0.0.1:2379", "comma separated gRPC endpoints of the cluster")
+	listenAddr := fs.String("listen-addr", ":23790", "gRPC address the proxy listens on")
+	dialTimeout := fs.Duration("dial-timeout", 5*time.Second, "dial timeout")
+	cacheSize := fs.Int("cache-size", 10000, "maximum number of cached serializable range responses, 0 disables the cache")
+	cacheTTL := fs.Duration("cache-ttl", time.Second, "how long a cached serializable range response is served")
+	logLevel := fs.String("log-level", "info", "log level")
+	fs.Parse(args)
+
+	logCfg := config.DefaultConfig(0, 0, "").Server.Log
+	logCfg.Level = *logLevel
+	if err := log.InitFromConfig(&logCfg); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	p, err := proxy.New(proxy.Config{
+		Endpoints:   strings.Split(*endpoints, ","),
+		DialTimeout: *dialTimeout,
+		CacheSize:   *cacheSize,
+		CacheTTL:    *cacheTTL,
+	})
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	lis, err := net.Listen("tcp", *listenAddr)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	p.Register(srv)
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigC
+		log.Info("Shutting down proxy", zap.String("component", "proxy"))
+		srv.GracefulStop()
+	}()
+
+	log.Info("Proxy started",
+		zap.String("listen_addr", *listenAddr),
+		zap.String("endpoints", *endpoints),
+		zap.Int("cache_size", *cacheSize),
+		zap.Duration("cache_ttl", *cacheTTL),
+		zap.String("component", "proxy"))
+	return srv.Serve(lis)
+}
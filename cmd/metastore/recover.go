@@ -0,0 +1,129 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"metaStore/internal/raft"
+	"metaStore/internal/rocksdb"
+	"metaStore/pkg/config"
+	"metaStore/pkg/datadir"
+	"metaStore/pkg/log"
+)
+
+// runRecover 运行 metastore recover 子命令：成员停止时离线改写 raft 存储中的成员配置，
+// 用于多数成员永久丢失、集群无法选出 leader 的情况
+//
+//	metastore recover --member-id 1 --storage rocksdb --force-new-cluster
+//	metastore recover --member-id 1 --storage rocksdb --unsafe-remove-member 4,5
+func runRecover(args []string) error {
+	fs := flag.NewFlagSet("recover", flag.ExitOnError)
+	configFile := fs.String("config", "", "path to the config file of the member (optional)")
+	clusterID := fs.Uint64("cluster-id", 1, "cluster ID")
+	memberID := fs.Int("member-id", 1, "ID of the stopped member whose raft storage is rewritten")
+	storageEngine := fs.String("storage", "memory", "storage engine of the member: memory or rocksdb")
+	forceNewCluster := fs.Bool("force-new-cluster", false, "remove every other member, leaving this member as a single-member cluster")
+	unsafeRemove := fs.String("unsafe-remove-member", "", "comma separated IDs of lost members to remove without a quorum; run it with the same IDs on every surviving member")
+	fs.Parse(args)
+
+	remove, err := parseMemberIDs(*unsafeRemove)
+	if err != nil {
+		return err
+	}
+	if *forceNewCluster == (len(remove) > 0) {
+		return errors.New("exactly one of --force-new-cluster and --unsafe-remove-member is required")
+	}
+
+	cfg, err := config.LoadConfigOrDefault(*configFile, *clusterID, uint64(*memberID), "")
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := log.InitFromConfig(&cfg.Server.Log); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	id := cfg.Server.MemberID
+	r := raft.Recovery{MemberID: id, ForceNewCluster: *forceNewCluster, RemoveMembers: remove}
+
+	var dataDir, walDir, snapDir string
+	var isWALFile func(string) bool
+	switch *storageEngine {
+	case "rocksdb":
+		dataDir, walDir, snapDir = cfg.Server.Storage.Dirs("rocksdb", fmt.Sprintf("data/rocksdb/%d", id))
+		isWALFile = rocksdb.IsWALFile
+	case "memory":
+		dataDir, walDir, snapDir = cfg.Server.Storage.Dirs("memory", filepath.Join("data", "memory", strconv.FormatUint(id, 10)))
+	default:
+		return fmt.Errorf("unknown storage engine %q", *storageEngine)
+	}
+
+	// 成员运行时不能改写它的存储
+	lock, err := datadir.Acquire(dataDir, datadir.Owner{ClusterID: cfg.Server.ClusterID, MemberID: id})
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// 与启动时一样先迁移目录，改写的是成员下次启动时读取的存储
+	_, defaultWAL, defaultSnap := config.StorageConfig{DataDir: dataDir}.Dirs(*storageEngine, dataDir)
+	if err := datadir.Prepare(datadir.Layout{Data: dataDir, WAL: walDir, Snap: snapDir},
+		datadir.Layout{Data: dataDir, WAL: defaultWAL, Snap: defaultSnap}, isWALFile); err != nil {
+		return fmt.Errorf("failed to prepare data directories: %w", err)
+	}
+
+	var members []uint64
+	if *storageEngine == "rocksdb" {
+		db, err := rocksdb.OpenWithWALDir(dataDir, walDir, &cfg.Server.RocksDB)
+		if err != nil {
+			return fmt.Errorf("failed to open RocksDB: %w", err)
+		}
+		defer db.Close()
+		members, err = raft.RecoverRocksDB(db, r)
+		if err != nil {
+			return err
+		}
+	} else {
+		members, err = raft.RecoverWAL(walDir, snapDir, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Rewrote the raft storage of member %d in %s, members are now %v\n", id, dataDir, members)
+	fmt.Println("Start the member without recover flags; add new members with --join")
+	return nil
+}
+
+// parseMemberIDs 解析逗号分隔的成员 ID
+func parseMemberIDs(s string) ([]uint64, error) {
+	var ids []uint64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("invalid member ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/internal/raft"
+	"metaStore/internal/rocksdb"
+	"metaStore/pkg/config"
+	"metaStore/pkg/featuregate"
+	"metaStore/pkg/log"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// runReplay 运行 metastore replay 子命令：把成员持久化的 raft 日志逐条回放到全新的状态机，
+// 打印每个条目解码后的操作和应用后的 revision，用于排查副本分歧、用客户的数据目录复现问题。
+// 只读取数据目录，不会修改它
+//
+//	metastore replay --storage rocksdb --member-id 1 --data-dir /backup/rocksdb/1 --until-index 1200
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configFile := fs.String("config", "", "path to the config file of the member (optional)")
+	clusterID := fs.Uint64("cluster-id", 1, "cluster ID")
+	memberID := fs.Int("member-id", 1, "ID of the member the raft log belongs to")
+	storageEngine := fs.String("storage", "memory", "storage engine of the member: memory or rocksdb")
+	dataDir := fs.String("data-dir", "", "data directory to replay, e.g. a copy of a stopped member's (default: the member's data directory)")
+	until := fs.Uint64("until-index", 0, "stop after applying this raft index (default: the commit index)")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfigOrDefault(*configFile, *clusterID, uint64(*memberID), "")
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := log.InitFromConfig(&cfg.Server.Log); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	gate, err := loadFeatureGates(cfg, "")
+	if err != nil {
+		return err
+	}
+	id := cfg.Server.MemberID
+
+	// --data-dir 指向的目录按默认布局查找 WAL 和快照
+	storage := cfg.Server.Storage
+	if *dataDir != "" {
+		storage = config.StorageConfig{DataDir: *dataDir}
+	}
+	var l *raft.ReplayLog
+	switch *storageEngine {
+	case "rocksdb":
+		dbDir, walDir, _ := storage.Dirs("rocksdb", fmt.Sprintf("data/rocksdb/%d", id))
+		db, err := rocksdb.OpenReadOnly(dbDir, walDir, &cfg.Server.RocksDB)
+		if err != nil {
+			return err
+		}
+		l, err = raft.ReadRocksDB(db, id, *until)
+		db.Close()
+		if err != nil {
+			return err
+		}
+	case "memory":
+		_, walDir, snapDir := storage.Dirs("memory", filepath.Join("data", "memory", strconv.FormatUint(id, 10)))
+		if l, err = raft.ReadWAL(walDir, snapDir, *until); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown storage engine %q", *storageEngine)
+	}
+
+	// 全新的状态机放在临时目录中，从回放的起点快照恢复
+	tmp, err := os.MkdirTemp("", "metastore-replay-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	snapshotter, err := l.Snapshotter(tmp)
+	if err != nil {
+		return err
+	}
+	commitC := make(chan *kvstore.Commit)
+	var kvs kvstore.Store
+	var decode func(string) ([]interface{}, error)
+	if *storageEngine == "rocksdb" {
+		db, err := rocksdb.Open(filepath.Join(tmp, "db"), &cfg.Server.RocksDB)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		r := rocksdb.NewRocksDB(db, snapshotter, nil, commitC, nil)
+		defer r.Close()
+		kvs = r
+		decode = func(data string) ([]interface{}, error) { return replayOps(rocksdb.DecodeProposal(data)) }
+	} else {
+		m := memory.NewMemory(snapshotter, nil, commitC, nil)
+		m.SetBatchApply(gate.Enabled(featuregate.BatchApply))
+		kvs = m
+		decode = func(data string) ([]interface{}, error) { return replayOps(memory.DecodeProposal(data)) }
+	}
+
+	var applied uint64
+	if l.Snapshot != nil {
+		applied = l.Snapshot.Metadata.Index
+		fmt.Printf("snapshot index=%d term=%d revision=%d\n", l.Snapshot.Metadata.Index, l.Snapshot.Metadata.Term, kvs.CurrentRevision())
+	}
+	stop := l.HardState.Commit
+	if *until != 0 && *until < stop {
+		stop = *until
+	}
+	for _, ent := range l.Entries {
+		if ent.Index > stop {
+			break
+		}
+		applied = ent.Index
+		switch ent.Type {
+		case raftpb.EntryConfChange:
+			var cc raftpb.ConfChange
+			if err := cc.Unmarshal(ent.Data); err != nil {
+				return fmt.Errorf("entry %d: decode conf change: %w", ent.Index, err)
+			}
+			fmt.Printf("index=%d term=%d conf-change=%s node=%d\n", ent.Index, ent.Term, cc.Type, cc.NodeID)
+			continue
+		case raftpb.EntryConfChangeV2:
+			fmt.Printf("index=%d term=%d conf-change-v2\n", ent.Index, ent.Term)
+			continue
+		}
+
+		// 与成员一样跳过无法解码的批量提案
+		proposals, err := raft.EntryProposals(ent, cfg.Server.Raft.Batch.Enable)
+		if err != nil {
+			fmt.Printf("index=%d term=%d skipped: %v\n", ent.Index, ent.Term, err)
+			continue
+		}
+		if len(proposals) == 0 {
+			fmt.Printf("index=%d term=%d empty\n", ent.Index, ent.Term)
+			continue
+		}
+		var ops []interface{}
+		for _, p := range proposals {
+			decoded, err := decode(p)
+			if err != nil {
+				// 成员应用这样的条目时会退出，回放停在这里
+				return fmt.Errorf("entry %d stops the member on apply: %w", ent.Index, err)
+			}
+			ops = append(ops, decoded...)
+		}
+
+		done := make(chan struct{})
+		commitC <- &kvstore.Commit{Data: proposals, ApplyDoneC: done, Index: ent.Index}
+		<-done
+		fmt.Printf("index=%d term=%d revision=%d\n", ent.Index, ent.Term, kvs.CurrentRevision())
+		for _, op := range ops {
+			b, err := json.Marshal(op)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("  %s\n", b)
+		}
+	}
+
+	fmt.Printf("Replayed up to index %d of member %d, revision %d\n", applied, id, kvs.CurrentRevision())
+	if *until > l.HardState.Commit {
+		fmt.Printf("Entries after the commit index %d are not committed and were not replayed\n", l.HardState.Commit)
+	}
+	return nil
+}
+
+// replayOps 把引擎解码出的操作转换为打印用的列表
+func replayOps[T any](ops []T, err error) ([]interface{}, error) {
+	out := make([]interface{}, len(ops))
+	for i, op := range ops {
+		out[i] = op
+	}
+	return out, err
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/featuregate"
+	"metaStore/pkg/log"
+	"metaStore/pkg/settings"
+
+	"go.uber.org/zap"
+)
+
+// compactionInterval 自动压缩检查的间隔
+const compactionInterval = time.Minute
+
+// startClusterSettings 加载集群运行时设置并应用到本节点，没有设置的项使用配置文件中的值。
+// 自动压缩只在功能开关 MVCCCompaction 打开时运行
+func startClusterSettings(store kvstore.Store, base kvstore.Backpressure, setBackpressure func(kvstore.Backpressure), gate *featuregate.Gate) (*settings.Manager, func()) {
+	mgr := settings.NewManager(store)
+	mgr.Subscribe(func(v settings.Values) {
+		setBackpressure(kvstore.Backpressure{
+			QueueThreshold: v.Float("limits.propose_queue_threshold", base.QueueThreshold),
+			MaxPending:     int(v.Int("limits.max_pending_proposals", int64(base.MaxPending))),
+			RetryAfter:     v.Duration("limits.retry_after", base.RetryAfter),
+			Timeout:        v.Duration("limits.request_timeout", base.Timeout),
+
+			// 受 raft.max_size_per_msg 约束，不能在运行时修改
+			MaxRequestBytes: base.MaxRequestBytes,
+		})
+	})
+
+	if !gate.Enabled(featuregate.MVCCCompaction) {
+		mgr.Start()
+		return mgr, mgr.Close
+	}
+
+	compactor := &retentionCompactor{store: store, stopC: make(chan struct{}), doneC: make(chan struct{})}
+	mgr.Subscribe(func(v settings.Values) {
+		compactor.retention.Store(v.Int("compaction.retention", 0))
+	})
+
+	mgr.Start()
+	go compactor.run()
+	return mgr, func() {
+		close(compactor.stopC)
+		<-compactor.doneC
+		mgr.Close()
+	}
+}
+
+// retentionCompactor 定期压缩本节点的历史版本，只保留最近 retention 个 revision
+type retentionCompactor struct {
+	store     kvstore.Store
+	retention atomic.Int64 // 0 表示不压缩
+	compacted int64        // 上一次压缩到的 revision
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+func (c *retentionCompactor) run() {
+	defer close(c.doneC)
+
+	ticker := time.NewTicker(compactionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.compact()
+		case <-c.stopC:
+			return
+		}
+	}
+}
+
+func (c *retentionCompactor) compact() {
+	retention := c.retention.Load()
+	if retention <= 0 {
+		return
+	}
+	rev := c.store.CurrentRevision() - retention
+	if rev <= c.compacted {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), compactionInterval)
+	defer cancel()
+	if err := c.store.Compact(ctx, rev); err != nil {
+		log.Warn("Auto compaction failed",
+			zap.Int64("revision", rev),
+			zap.Error(err),
+			zap.String("component", "settings"))
+		return
+	}
+	c.compacted = rev
+	log.Info("Auto compaction finished",
+		zap.Int64("revision", rev),
+		zap.Int64("retention", retention),
+		zap.String("component", "settings"))
+}
@@ -0,0 +1,331 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/internal/raft"
+	"metaStore/internal/rocksdb"
+	"metaStore/pkg/config"
+	"metaStore/pkg/featuregate"
+	"metaStore/pkg/hlc"
+
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/raft/v3/raftpb"
+	"go.uber.org/zap"
+)
+
+// smokeCheckTimeout 冒烟测试每项检查的超时时间
+const smokeCheckTimeout = 10 * time.Second
+
+// smokeKey 冒烟测试写入的键
+const smokeKey = "/metastore/smoke-test/key"
+
+// smokeCheck 冒烟测试中一项检查的结果
+type smokeCheck struct {
+	Name       string  `json:"name"`
+	Passed     bool    `json:"passed"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// smokeReport metastore --smoke-test 输出的 JSON
+type smokeReport struct {
+	Engine string       `json:"engine"`
+	Dir    string       `json:"dir"`
+	Passed bool         `json:"passed"`
+	Checks []smokeCheck `json:"checks"`
+}
+
+// smokeStep 冒烟测试的一项检查
+type smokeStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// smokeStore 冒烟测试打开的存储，close 停止单节点提交循环并关闭存储引擎
+type smokeStore struct {
+	kvstore.Store
+	close func()
+}
+
+// runSmokeTest 运行 metastore --smoke-test：在临时目录中按配置启动存储引擎，
+// 依次执行 put/get/txn/watch/lease/delete，RocksDB 还会关闭后重新打开检查数据是否保留。
+// 提交不经过 raft 和 WAL，也不监听任何端口，不影响已有的数据目录和正在运行的成员，
+// 可以在部署流水线或安装后的检查中执行。结果以 JSON 写入 out，任一检查失败时返回错误
+func runSmokeTest(out io.Writer, cfg *config.Config, engine string, gate *featuregate.Gate, clock *hlc.Clock) error {
+	if engine != "memory" && engine != "rocksdb" {
+		return fmt.Errorf("unknown storage engine %q, must be memory or rocksdb", engine)
+	}
+	dir, err := os.MkdirTemp("", "metastore-smoke-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var store *smokeStore
+	defer func() {
+		if store != nil {
+			store.close()
+		}
+	}()
+	open := func() error {
+		var err error
+		store, err = openSmokeStore(cfg, engine, dir, gate, clock)
+		return err
+	}
+
+	checks := []smokeStep{
+		{"open", func(context.Context) error { return open() }},
+		{"put", func(ctx context.Context) error {
+			if _, _, err := store.PutWithLease(ctx, smokeKey, "v1", 0); err != nil {
+				return err
+			}
+			return expectValue(ctx, store, smokeKey, "v1")
+		}},
+		{"get", func(ctx context.Context) error { return expectValue(ctx, store, smokeKey, "v1") }},
+		{"txn", func(ctx context.Context) error { return smokeTxn(ctx, store) }},
+		{"watch", func(ctx context.Context) error { return smokeWatch(ctx, store) }},
+		{"lease", func(ctx context.Context) error { return smokeLease(ctx, store) }},
+		{"delete", func(ctx context.Context) error {
+			deleted, _, _, err := store.DeleteRange(ctx, smokeKey, "")
+			if err != nil {
+				return err
+			}
+			if deleted != 1 {
+				return fmt.Errorf("deleted %d keys, expected 1", deleted)
+			}
+			return expectValue(ctx, store, smokeKey, "")
+		}},
+	}
+	if engine == "rocksdb" {
+		// 关闭后重新打开同一个目录，revision 应保持不变。重新打开后提交循环的
+		// index 从头开始，会被已持久化的 applied index 跳过，所以只读不写
+		checks = append(checks, smokeStep{"reopen", func(ctx context.Context) error {
+			rev := store.CurrentRevision()
+			store.close()
+			store = nil
+			if err := open(); err != nil {
+				return err
+			}
+			if got := store.CurrentRevision(); got != rev {
+				return fmt.Errorf("revision is %d after reopening, expected %d", got, rev)
+			}
+			return expectValue(ctx, store, smokeKey, "")
+		}})
+	}
+
+	return runSmokeChecks(out, smokeReport{Engine: engine, Dir: dir}, checks)
+}
+
+// runSmokeChecks 依次执行检查，遇到失败的检查即停止，把报告以 JSON 写入 out
+func runSmokeChecks(out io.Writer, report smokeReport, checks []smokeStep) error {
+	report.Passed = true
+	report.Checks = []smokeCheck{}
+	for _, c := range checks {
+		ctx, cancel := context.WithTimeout(context.Background(), smokeCheckTimeout)
+		start := time.Now()
+		err := c.run(ctx)
+		cancel()
+		result := smokeCheck{Name: c.name, Passed: err == nil, DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+		if err != nil {
+			result.Error = err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, result)
+		// 后面的检查依赖前面的结果，失败后不再继续
+		if err != nil {
+			break
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		last := report.Checks[len(report.Checks)-1]
+		return fmt.Errorf("%s check failed: %s", last.Name, last.Error)
+	}
+	return nil
+}
+
+// openSmokeStore 在 dir 中打开存储引擎，提交由单节点提交循环直接应用
+func openSmokeStore(cfg *config.Config, engine, dir string, gate *featuregate.Gate, clock *hlc.Clock) (*smokeStore, error) {
+	proposeQueue := kvstore.NewProposeQueue(kvstore.ProposeQueueConfig{
+		Capacity:   cfg.Server.Limits.ProposeQueueSize,
+		Overflow:   kvstore.OverflowPolicy(cfg.Server.Limits.ProposeQueueOverflow),
+		RetryAfter: cfg.Server.Limits.RetryAfter,
+	})
+	confChangeC := make(chan raftpb.ConfChange)
+	commitC, errorC, node := raft.NewEphemeralNode(int(cfg.Server.MemberID), proposeQueue.C(), confChangeC)
+
+	// stop 关闭提案队列并等待提交循环退出，之后存储不再应用提交
+	stop := func() {
+		proposeQueue.Close()
+		close(confChangeC)
+		for deadline := time.Now().Add(smokeCheckTimeout); !node.IsStopped() && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	switch engine {
+	case "rocksdb":
+		snapDir := filepath.Join(dir, "snap")
+		if err := os.MkdirAll(snapDir, 0750); err != nil {
+			stop()
+			return nil, err
+		}
+		db, err := rocksdb.Open(filepath.Join(dir, "rocksdb"), &cfg.Server.RocksDB)
+		if err != nil {
+			stop()
+			return nil, fmt.Errorf("failed to open RocksDB: %w", err)
+		}
+		kvs := rocksdb.NewRocksDB(db, snap.New(zap.L(), snapDir), nil, commitC, errorC)
+		kvs.SetProposeQueue(proposeQueue)
+		kvs.SetClock(clock)
+		return &smokeStore{Store: kvs, close: func() {
+			stop()
+			kvs.Close()
+			db.Close()
+		}}, nil
+
+	default:
+		kvs := memory.NewMemory(nil, nil, commitC, errorC)
+		kvs.SetProposeQueue(proposeQueue)
+		kvs.SetRaftNode(node, cfg.Server.MemberID)
+		kvs.SetBatchApply(gate.Enabled(featuregate.BatchApply))
+		kvs.SetClock(clock)
+		return &smokeStore{Store: kvs, close: stop}, nil
+	}
+}
+
+// expectValue 检查 key 的值，want 为空表示 key 不存在
+func expectValue(ctx context.Context, store kvstore.Store, key, want string) error {
+	resp, err := store.Range(ctx, key, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(resp.Kvs) == 0 && want == "":
+		return nil
+	case len(resp.Kvs) == 0:
+		return fmt.Errorf("key %s not found, expected %q", key, want)
+	case want == "":
+		return fmt.Errorf("key %s has value %q, expected it to be deleted", key, resp.Kvs[0].Value)
+	case string(resp.Kvs[0].Value) != want:
+		return fmt.Errorf("key %s has value %q, expected %q", key, resp.Kvs[0].Value, want)
+	}
+	return nil
+}
+
+// smokeTxn 值比较成功时执行 then 分支，失败时执行 else 分支
+func smokeTxn(ctx context.Context, store kvstore.Store) error {
+	txn := func(value string) (*kvstore.TxnResponse, error) {
+		cmps := []kvstore.Compare{{
+			Target:      kvstore.CompareValue,
+			Result:      kvstore.CompareEqual,
+			Key:         []byte(smokeKey),
+			TargetUnion: kvstore.CompareUnion{Value: []byte(value)},
+		}}
+		thenOps := []kvstore.Op{{Type: kvstore.OpPut, Key: []byte(smokeKey), Value: []byte("v2")}}
+		elseOps := []kvstore.Op{{Type: kvstore.OpRange, Key: []byte(smokeKey)}}
+		return store.Txn(ctx, cmps, thenOps, elseOps)
+	}
+
+	resp, err := txn("v1")
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("compare on the current value failed")
+	}
+	if err := expectValue(ctx, store, smokeKey, "v2"); err != nil {
+		return err
+	}
+
+	resp, err = txn("v1")
+	if err != nil {
+		return err
+	}
+	if resp.Succeeded {
+		return fmt.Errorf("compare on a stale value succeeded")
+	}
+	return expectValue(ctx, store, smokeKey, "v2")
+}
+
+// smokeWatch 写入后 watch 收到对应的事件
+func smokeWatch(ctx context.Context, store kvstore.Store) error {
+	const watchID = 1
+	events, err := store.Watch(ctx, smokeKey, "", 0, watchID)
+	if err != nil {
+		return err
+	}
+	defer store.CancelWatch(watchID)
+
+	rev, _, err := store.PutWithLease(ctx, smokeKey, "v3", 0)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return fmt.Errorf("watch closed before the event at revision %d", rev)
+			}
+			if ev.Type == kvstore.EventTypePut && ev.Kv != nil && string(ev.Kv.Value) == "v3" {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("no event for the put at revision %d: %w", rev, ctx.Err())
+		}
+	}
+}
+
+// smokeLease 撤销租约时删除绑定的 key
+func smokeLease(ctx context.Context, store kvstore.Store) error {
+	const leaseID = 1
+	const leasedKey = "/metastore/smoke-test/leased"
+	if _, err := store.LeaseGrant(ctx, leaseID, 60); err != nil {
+		return err
+	}
+	if _, _, err := store.PutWithLease(ctx, leasedKey, "leased", leaseID); err != nil {
+		return err
+	}
+	resp, err := store.Range(ctx, leasedKey, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) != 1 || resp.Kvs[0].Lease != leaseID {
+		return fmt.Errorf("key %s is not attached to lease %d", leasedKey, leaseID)
+	}
+	if _, err := store.LeaseTimeToLive(ctx, leaseID); err != nil {
+		return err
+	}
+	if err := store.LeaseRevoke(ctx, leaseID); err != nil {
+		return err
+	}
+	return expectValue(ctx, store, leasedKey, "")
+}
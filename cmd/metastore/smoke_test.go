@@ -0,0 +1,107 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+
+	"metaStore/pkg/config"
+	"metaStore/pkg/featuregate"
+	"metaStore/pkg/hlc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmokeTest(t *testing.T) {
+	cfg := config.DefaultConfig(1, 1, ":2379")
+	gate, err := featuregate.New(nil)
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, runSmokeTest(&out, cfg, "memory", gate, hlc.NewClock(cfg.Server.HLC.MaxOffset)))
+
+	var report smokeReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.True(t, report.Passed)
+	assert.Equal(t, "memory", report.Engine)
+	var names []string
+	for _, c := range report.Checks {
+		assert.True(t, c.Passed, c.Name)
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"open", "put", "get", "txn", "watch", "lease", "delete"}, names)
+	_, err = os.Stat(report.Dir)
+	assert.True(t, os.IsNotExist(err), "the temporary directory is removed")
+}
+
+func TestSmokeTestFailure(t *testing.T) {
+	ran := false
+	checks := []smokeStep{
+		{"open", func(context.Context) error { return nil }},
+		{"put", func(context.Context) error { return errors.New("no leader") }},
+		{"get", func(context.Context) error { ran = true; return nil }},
+	}
+	var out bytes.Buffer
+	err := runSmokeChecks(&out, smokeReport{Engine: "memory"}, checks)
+	require.Error(t, err)
+	assert.Equal(t, "put check failed: no leader", err.Error())
+	assert.False(t, ran, "checks after a failure are skipped")
+
+	// 报告中列出失败前的检查和失败的原因
+	var report smokeReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.False(t, report.Passed)
+	require.Len(t, report.Checks, 2)
+	assert.True(t, report.Checks[0].Passed)
+	assert.False(t, report.Checks[1].Passed)
+	assert.Equal(t, "no leader", report.Checks[1].Error)
+}
+
+// TestSmokeTestExitStatus 运行 metastore --smoke-test，成功时退出码为 0，失败时为 1
+func TestSmokeTestExitStatus(t *testing.T) {
+	if args := os.Getenv("METASTORE_SMOKE_TEST_ARGS"); args != "" {
+		os.Args = []string{"metastore", "--smoke-test", "--storage", args}
+		main()
+		return
+	}
+	run := func(engine string) (*exec.ExitError, string, string) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSmokeTestExitStatus$")
+		cmd.Env = append(os.Environ(), "METASTORE_SMOKE_TEST_ARGS="+engine)
+		cmd.Dir = t.TempDir()
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if err != nil {
+			require.ErrorAs(t, err, &exitErr)
+		}
+		return exitErr, stdout.String(), stderr.String()
+	}
+
+	exitErr, stdout, _ := run("memory")
+	require.Nil(t, exitErr)
+	assert.Contains(t, stdout, `"passed": true`)
+
+	exitErr, _, stderr := run("bogus")
+	require.NotNil(t, exitErr)
+	assert.Equal(t, 1, exitErr.ExitCode())
+	assert.Contains(t, stderr, `smoke-test: unknown storage engine "bogus"`)
+}
@@ -0,0 +1,510 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/metadata"
+)
+
+// maxImportTxnOps 每个导入事务的最大操作数，etcd 默认 --max-txn-ops 为 128
+const maxImportTxnOps = 128
+
+// clientFlags 连接集群的公共参数
+type clientFlags struct {
+	endpoints   *string
+	user        *string
+	password    *string
+	dialTimeout *time.Duration
+	parallel    *int
+	rate        *float64
+}
+
+func addClientFlags(fs *flag.FlagSet) *clientFlags {
+	return &clientFlags{
+		endpoints:   fs.String("endpoints", "127.0.0.1:2379", "comma separated gRPC endpoints of a MetaStore or etcd cluster"),
+		user:        fs.String("user", "", "username, if authentication is enabled"),
+		password:    fs.String("password", "", "password, if authentication is enabled"),
+		dialTimeout: fs.Duration("dial-timeout", 5*time.Second, "dial timeout"),
+		parallel:    fs.Int("parallel", 4, "number of concurrent requests"),
+		rate:        fs.Float64("rate", 0, "maximum keys per second, 0 for no limit"),
+	}
+}
+
+func (f *clientFlags) dial() (*clientv3.Client, error) {
+	if *f.parallel <= 0 {
+		return nil, fmt.Errorf("--parallel must be > 0")
+	}
+	return clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(*f.endpoints, ","),
+		DialTimeout: *f.dialTimeout,
+		Username:    *f.user,
+		Password:    *f.password,
+	})
+}
+
+// limiter 返回按 key 数限速的 limiter，burst 不小于一次请求的 key 数
+func (f *clientFlags) limiter(burst int) *rate.Limiter {
+	if *f.rate <= 0 {
+		return rate.NewLimiter(rate.Inf, burst)
+	}
+	return rate.NewLimiter(rate.Limit(*f.rate), max(burst, int(*f.rate)))
+}
+
+// dataExport 把前缀下的数据以一致的 revision 导出到文件或标准输出
+func dataExport(args []string) error {
+	fs := flag.NewFlagSet("data export", flag.ExitOnError)
+	cf := addClientFlags(fs)
+	prefix := fs.String("prefix", "", "only export keys under this prefix, empty exports all keys")
+	format := fs.String("format", formatJSONL, "output format: jsonl, etcd or consul")
+	output := fs.String("output", "-", "output file, - for stdout")
+	pageSize := fs.Int64("page-size", 1000, "keys fetched per request")
+	fs.Parse(args)
+
+	if *pageSize <= 0 {
+		return fmt.Errorf("--page-size must be > 0")
+	}
+	client, err := cf.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// 所有分区在同一个 revision 上读取，导出结果是一致的快照
+	head, err := client.Get(ctx, "/", clientv3.WithCountOnly())
+	if err != nil {
+		return err
+	}
+	rev := head.Header.Revision
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	buf := bufio.NewWriter(out)
+	w, err := newRecordWriter(buf, *format, head.Header)
+	if err != nil {
+		return err
+	}
+
+	limiter := cf.limiter(int(*pageSize))
+	var mu sync.Mutex
+	var exported atomic.Int64
+	g, gctx := newGroup(ctx)
+	for _, r := range splitRange(*prefix, *cf.parallel) {
+		g.Go(func() error {
+			key := r[0]
+			for {
+				resp, err := client.Get(gctx, key,
+					clientv3.WithRange(r[1]),
+					clientv3.WithRev(rev),
+					clientv3.WithLimit(*pageSize),
+					clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+				if err != nil {
+					return err
+				}
+				if len(resp.Kvs) == 0 {
+					return nil
+				}
+				if err := limiter.WaitN(gctx, len(resp.Kvs)); err != nil {
+					return err
+				}
+				mu.Lock()
+				for _, kv := range resp.Kvs {
+					// 内部 key（例如大 value 的分段）不导出，分段的 value 已由服务端组装
+					if kvstore.IsSystemKey(string(kv.Key)) {
+						continue
+					}
+					if err := w.write(kv); err != nil {
+						mu.Unlock()
+						return err
+					}
+					exported.Add(1)
+				}
+				mu.Unlock()
+				// 不能只依赖 More：部分存储引擎按 limit 截断后不设置 More
+				if !resp.More && int64(len(resp.Kvs)) < *pageSize {
+					return nil
+				}
+				key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+			}
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d keys at revision %d\n", exported.Load(), rev)
+	return nil
+}
+
+// dataImport 把导出的数据写入集群，同名 key 被覆盖，lease 不会被保留
+func dataImport(args []string) error {
+	fs := flag.NewFlagSet("data import", flag.ExitOnError)
+	cf := addClientFlags(fs)
+	format := fs.String("format", formatJSONL, "input format: jsonl, etcd or consul")
+	input := fs.String("input", "-", "input file, - for stdin")
+	batchSize := fs.Int("batch-size", 100, "keys written per transaction, at most 128")
+	fs.Parse(args)
+
+	if *batchSize <= 0 || *batchSize > maxImportTxnOps {
+		return fmt.Errorf("--batch-size must be between 1 and %d", maxImportTxnOps)
+	}
+	client, err := cf.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	in := os.Stdin
+	if *input != "-" {
+		if in, err = os.Open(*input); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// 导入的写入在 MetaStore 的提案队列中排在交互写入之后（etcd.PriorityHeader），etcd 忽略该 header
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-metastore-priority", "low")
+
+	limiter := cf.limiter(*batchSize)
+	var imported atomic.Int64
+	batches := make(chan []clientv3.Op, *cf.parallel)
+	g, gctx := newGroup(ctx)
+	for i := 0; i < *cf.parallel; i++ {
+		g.Go(func() error {
+			for ops := range batches {
+				if err := limiter.WaitN(gctx, len(ops)); err != nil {
+					return err
+				}
+				if _, err := client.Txn(gctx).Then(ops...).Commit(); err != nil {
+					return err
+				}
+				imported.Add(int64(len(ops)))
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		defer close(batches)
+		var ops []clientv3.Op
+		flush := func() error {
+			select {
+			case batches <- ops:
+				ops = nil
+				return nil
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+		}
+		err := readRecords(bufio.NewReader(in), *format, func(key, value []byte) error {
+			ops = append(ops, clientv3.OpPut(string(key), string(value)))
+			if len(ops) == *batchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(ops) > 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("%w (imported %d keys)", err, imported.Load())
+	}
+	fmt.Fprintf(os.Stderr, "imported %d keys\n", imported.Load())
+	return nil
+}
+
+// splitRange 按前缀之后第一个字节把前缀的 key 范围切分为最多 n 段
+func splitRange(prefix string, n int) [][2]string {
+	start, end := prefix, clientv3.GetPrefixRangeEnd(prefix)
+	if prefix == "" {
+		start, end = "\x00", "\x00"
+	}
+	n = min(max(n, 1), 256)
+	ranges := make([][2]string, 0, n)
+	from := start
+	for i := 1; i < n; i++ {
+		to := prefix + string([]byte{byte(i * 256 / n)})
+		ranges = append(ranges, [2]string{from, to})
+		from = to
+	}
+	return append(ranges, [2]string{from, end})
+}
+
+// group 并发执行任务，第一个错误取消其余任务
+type group struct {
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+func newGroup(ctx context.Context) (*group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &group{cancel: cancel}, ctx
+}
+
+func (g *group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+func (g *group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// 导入导出支持的格式
+const (
+	// formatJSONL 每行一个 jsonRecord，key 和 value 以 base64 编码
+	formatJSONL = "jsonl"
+	// formatEtcd 与 etcdctl get --prefix -w json 的输出相同
+	formatEtcd = "etcd"
+	// formatConsul 与 consul kv export / consul kv import 的格式相同
+	formatConsul = "consul"
+)
+
+// jsonRecord jsonl 格式中的一行
+type jsonRecord struct {
+	Key            []byte `json:"key"`
+	Value          []byte `json:"value"`
+	CreateRevision int64  `json:"create_revision,omitempty"`
+	ModRevision    int64  `json:"mod_revision,omitempty"`
+	Version        int64  `json:"version,omitempty"`
+	Lease          int64  `json:"lease,omitempty"`
+}
+
+// consulRecord consul kv export 中的一项，consul 的 key 不以 / 开头
+type consulRecord struct {
+	Key   string `json:"key"`
+	Flags uint64 `json:"flags"`
+	Value string `json:"value"` // base64
+}
+
+// recordWriter 按某种格式写出 key-value
+type recordWriter interface {
+	write(kv *mvccpb.KeyValue) error
+	close() error
+}
+
+func newRecordWriter(w io.Writer, format string, header *pb.ResponseHeader) (recordWriter, error) {
+	switch format {
+	case formatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case formatEtcd:
+		h, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		return &arrayWriter{w: w, open: `{"header":` + string(h) + `,"kvs":[`, closing: "]}\n", etcd: true}, nil
+	case formatConsul:
+		return &arrayWriter{w: w, open: "[", closing: "]\n"}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, must be one of: jsonl, etcd, consul", format)
+	}
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) write(kv *mvccpb.KeyValue) error {
+	return j.enc.Encode(jsonRecord{
+		Key:            kv.Key,
+		Value:          kv.Value,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+	})
+}
+
+func (j *jsonlWriter) close() error { return nil }
+
+// arrayWriter 逐项写出 JSON 数组，不需要把全部数据放在内存中
+type arrayWriter struct {
+	w             io.Writer
+	open, closing string
+	etcd          bool
+	n             int
+}
+
+func (a *arrayWriter) write(kv *mvccpb.KeyValue) error {
+	var item any = consulRecord{Key: string(kv.Key), Value: base64.StdEncoding.EncodeToString(kv.Value)}
+	if a.etcd {
+		item = kv
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if a.n == 0 {
+		sep = a.open
+	}
+	a.n++
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	_, err = a.w.Write(data)
+	return err
+}
+
+func (a *arrayWriter) close() error {
+	if a.n == 0 {
+		if _, err := io.WriteString(a.w, a.open); err != nil {
+			return err
+		}
+	}
+	closing := a.closing
+	if a.etcd {
+		closing = fmt.Sprintf(`],"count":%d}`+"\n", a.n)
+	}
+	_, err := io.WriteString(a.w, closing)
+	return err
+}
+
+// readRecords 逐条读取 key-value 并调用 fn，fn 返回错误时停止
+func readRecords(r io.Reader, format string, fn func(key, value []byte) error) error {
+	dec := json.NewDecoder(r)
+	switch format {
+	case formatJSONL:
+		for {
+			var rec jsonRecord
+			if err := dec.Decode(&rec); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("invalid jsonl record: %w", err)
+			}
+			if err := fn(rec.Key, rec.Value); err != nil {
+				return err
+			}
+		}
+	case formatEtcd:
+		// 跳到 "kvs" 数组，忽略 header 和 count
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok != "kvs" {
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return err
+				}
+				continue
+			}
+			err = readArray(dec, func() error {
+				var kv mvccpb.KeyValue
+				if err := dec.Decode(&kv); err != nil {
+					return fmt.Errorf("invalid etcd record: %w", err)
+				}
+				return fn(kv.Key, kv.Value)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatConsul:
+		return readArray(dec, func() error {
+			var rec consulRecord
+			if err := dec.Decode(&rec); err != nil {
+				return fmt.Errorf("invalid consul record: %w", err)
+			}
+			value, err := base64.StdEncoding.DecodeString(rec.Value)
+			if err != nil {
+				return fmt.Errorf("invalid consul value of %q: %w", rec.Key, err)
+			}
+			return fn([]byte(rec.Key), value)
+		})
+	default:
+		return fmt.Errorf("unknown format %q, must be one of: jsonl, etcd, consul", format)
+	}
+}
+
+// readArray 对 JSON 数组中的每一项调用 item，item 负责解码该项
+func readArray(dec *json.Decoder, item func() error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := item(); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("invalid input: expected %q, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordFormats(t *testing.T) {
+	kvs := []*mvccpb.KeyValue{
+		{Key: []byte("app/a"), Value: []byte("1"), CreateRevision: 2, ModRevision: 3, Version: 2},
+		{Key: []byte("app/b"), Value: []byte{0, 0xff, '\n'}},
+	}
+	for _, format := range []string{formatJSONL, formatEtcd, formatConsul} {
+		for _, n := range []int{0, len(kvs)} {
+			var buf bytes.Buffer
+			w, err := newRecordWriter(&buf, format, &pb.ResponseHeader{Revision: 9})
+			require.NoError(t, err)
+			for _, kv := range kvs[:n] {
+				require.NoError(t, w.write(kv))
+			}
+			require.NoError(t, w.close())
+			if format != formatJSONL {
+				assert.True(t, json.Valid(buf.Bytes()), "%s: %s", format, buf.String())
+			}
+
+			got := map[string]string{}
+			require.NoError(t, readRecords(&buf, format, func(key, value []byte) error {
+				got[string(key)] = string(value)
+				return nil
+			}), format)
+			want := map[string]string{}
+			for _, kv := range kvs[:n] {
+				want[string(kv.Key)] = string(kv.Value)
+			}
+			assert.Equal(t, want, got, format)
+		}
+	}
+
+	// etcdctl get -w json 的输出可以直接导入
+	etcdctl := `{"header":{"cluster_id":1,"revision":5},"kvs":[{"key":"Zm9v","create_revision":4,"mod_revision":5,"version":2,"value":"YmFy"}],"count":1}`
+	var keys []string
+	require.NoError(t, readRecords(bytes.NewBufferString(etcdctl), formatEtcd, func(key, value []byte) error {
+		keys = append(keys, string(key)+"="+string(value))
+		return nil
+	}))
+	assert.Equal(t, []string{"foo=bar"}, keys)
+
+	_, err := newRecordWriter(&bytes.Buffer{}, "etcd-snapshot", nil)
+	assert.Error(t, err)
+}
+
+func TestSplitRange(t *testing.T) {
+	assert.Equal(t, [][2]string{{"p/", "p0"}}, splitRange("p/", 1))
+	assert.Equal(t, [][2]string{{"p/", "p/\x80"}, {"p/\x80", "p0"}}, splitRange("p/", 2))
+
+	ranges := splitRange("", 4)
+	require.Len(t, ranges, 4)
+	assert.Equal(t, "\x00", ranges[0][0])
+	assert.Equal(t, "\x00", ranges[3][1])
+	for i := 1; i < len(ranges); i++ {
+		assert.Equal(t, ranges[i-1][1], ranges[i][0])
+	}
+	assert.Len(t, splitRange("x", 1000), 256)
+}
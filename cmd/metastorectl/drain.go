@@ -0,0 +1,113 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	httpapi "metaStore/api/http"
+	"metaStore/pkg/reliability"
+)
+
+// memberDrain 在节点上开始排空 gRPC 客户端，并轮询到可以停止进程
+func memberDrain(args []string) error {
+	fs := flag.NewFlagSet("member drain", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the node to drain")
+	timeout := fs.Duration("timeout", 0, "time allowed for clients to move away, 0 uses reliability.drain_timeout of the node")
+	interval := fs.Duration("interval", time.Second, "how often to poll the progress")
+	fs.Parse(args)
+
+	target := drainURL(*endpoint)
+	if *timeout > 0 {
+		target += "?timeout=" + url.QueryEscape(timeout.String())
+	}
+	resp, err := http.Post(target, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	msg, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// 已经在排空中时继续等待
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusConflict {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	for {
+		st, err := drainStatus(*endpoint)
+		if err != nil {
+			return err
+		}
+		printDrain(st)
+		if st.ReadyToShutdown {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// memberDrainStatus 打印节点的排空进度
+func memberDrainStatus(args []string) error {
+	fs := flag.NewFlagSet("member drain-status", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the node")
+	fs.Parse(args)
+
+	st, err := drainStatus(*endpoint)
+	if err != nil {
+		return err
+	}
+	printDrain(st)
+	return nil
+}
+
+func drainStatus(endpoint string) (reliability.DrainStatus, error) {
+	var st reliability.DrainStatus
+	resp, err := http.Get(drainURL(endpoint))
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return st, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	err = json.NewDecoder(resp.Body).Decode(&st)
+	return st, err
+}
+
+func printDrain(st reliability.DrainStatus) {
+	switch st.State {
+	case reliability.DrainDrained:
+		how := "all clients moved"
+		if st.Forced {
+			how = "remaining connections closed at the timeout"
+		}
+		fmt.Printf("[%s] %s, evicted streams: %d, ready to shut down\n", st.State, how, st.EvictedStreams)
+	case reliability.DrainDraining:
+		fmt.Printf("[%s] evicted streams: %d, open streams: %d\n", st.State, st.EvictedStreams, st.OpenStreams)
+	default:
+		fmt.Printf("[%s] open streams: %d\n", st.State, st.OpenStreams)
+	}
+}
+
+func drainURL(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/") + httpapi.DrainPath
+}
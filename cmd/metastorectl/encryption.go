@@ -0,0 +1,111 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	httpapi "metaStore/api/http"
+	"metaStore/pkg/encryption"
+)
+
+// encryptionRotateKey 在节点上启动重新加密，并轮询到完成
+func encryptionRotateKey(args []string) error {
+	fs := flag.NewFlagSet("encryption rotate-key", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the node")
+	interval := fs.Duration("interval", time.Second, "how often to poll the progress")
+	fs.Parse(args)
+
+	resp, err := http.Post(encryptionURL(*endpoint)+"/rotate", "application/json", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%s", resp.Status)
+	}
+
+	for {
+		st, err := encryptionStatus(*endpoint)
+		if err != nil {
+			return err
+		}
+		printRotation(st)
+		if !st.Running {
+			if st.Error != "" {
+				return fmt.Errorf("re-encryption failed: %s", st.Error)
+			}
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// encryptionShowStatus 打印节点的激活 KEK 和最近一次重新加密的进度
+func encryptionShowStatus(args []string) error {
+	fs := flag.NewFlagSet("encryption status", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the node")
+	fs.Parse(args)
+
+	st, err := encryptionStatus(*endpoint)
+	if err != nil {
+		return err
+	}
+	printRotation(st)
+	return nil
+}
+
+func encryptionStatus(endpoint string) (encryption.RotationStatus, error) {
+	var st encryption.RotationStatus
+	resp, err := http.Get(encryptionURL(endpoint))
+	if err != nil {
+		return st, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return st, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	err = json.NewDecoder(resp.Body).Decode(&st)
+	return st, err
+}
+
+func printRotation(st encryption.RotationStatus) {
+	if !st.Enabled {
+		fmt.Println("encryption: disabled")
+		return
+	}
+	state := "idle"
+	switch {
+	case st.Running:
+		state = "running"
+	case st.Error != "":
+		state = "failed"
+	case !st.FinishedAt.IsZero():
+		state = "done"
+	}
+	fmt.Printf("active key: %s  rotation: %s  scanned: %d  re-encrypted: %d\n",
+		st.ActiveKey, state, st.Scanned, st.Reencrypted)
+}
+
+func encryptionURL(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/") + httpapi.EncryptionPath
+}
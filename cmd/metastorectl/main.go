@@ -0,0 +1,253 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// metastorectl MetaStore 运维命令行工具
+//
+// 用法:
+//
+//	metastorectl member replace --endpoint http://127.0.0.1:9121 --dead 2 --new-id 4 --peer-url http://127.0.0.1:9024
+//	metastorectl member replace-status --endpoint http://127.0.0.1:9121
+//	metastorectl member drain --endpoint http://127.0.0.1:9121 --timeout 60s
+//	metastorectl raft log --endpoint http://127.0.0.1:9121 --from 1200 --limit 50 --redact
+//	metastorectl mirror list --endpoint http://127.0.0.1:9121
+//	metastorectl mirror start --endpoint http://127.0.0.1:9121 --name dc2
+//	metastorectl encryption rotate-key --endpoint http://127.0.0.1:9121
+//	metastorectl data export --endpoints 127.0.0.1:2379 --prefix /app/ --output app.jsonl
+//	metastorectl data import --endpoints 127.0.0.1:2379 --input app.jsonl
+//	metastorectl snapshot save --endpoints 127.0.0.1:2379 --output backup.db
+//	metastorectl snapshot verify --input backup.db --config metastore.yaml
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	httpapi "metaStore/api/http"
+	"metaStore/internal/kvstore"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] + " " + os.Args[2] {
+	case "member replace":
+		err = memberReplace(os.Args[3:])
+	case "member replace-status":
+		err = memberReplaceStatus(os.Args[3:])
+	case "member drain":
+		err = memberDrain(os.Args[3:])
+	case "member drain-status":
+		err = memberDrainStatus(os.Args[3:])
+	case "raft log":
+		err = raftLog(os.Args[3:])
+	case "mirror list":
+		err = mirrorList(os.Args[3:])
+	case "mirror start", "mirror stop":
+		err = mirrorAction(os.Args[2], os.Args[3:])
+	case "encryption rotate-key":
+		err = encryptionRotateKey(os.Args[3:])
+	case "encryption status":
+		err = encryptionShowStatus(os.Args[3:])
+	case "data export":
+		err = dataExport(os.Args[3:])
+	case "data import":
+		err = dataImport(os.Args[3:])
+	case "snapshot save":
+		err = snapshotSave(os.Args[3:])
+	case "snapshot verify":
+		err = snapshotVerify(os.Args[3:])
+	case "settings list":
+		err = settingsList(os.Args[3:])
+	case "settings set":
+		err = settingsSet(os.Args[3:])
+	case "settings reset":
+		err = settingsReset(os.Args[3:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  metastorectl member replace --endpoint URL --dead ID --new-id ID --peer-url URL [--catch-up-lag N] [--force] [--timeout D]
+      Replace a dead member: add the new node as a learner, wait for it to catch up,
+      promote it, then remove the dead member. Must be sent to the leader's HTTP API.
+      Start the new node with --member-id <new-id> --join before running this command.
+  metastorectl member replace-status --endpoint URL
+      Show the progress of the last member replacement on that node.
+  metastorectl member drain --endpoint URL [--timeout D] [--interval D]
+      Move the gRPC clients of that node to other members before stopping it: stop accepting
+      connections, end watch and keepalive streams so clients resume them elsewhere, and wait
+      until the node is ready to shut down. The HTTP and MySQL frontends keep serving.
+  metastorectl member drain-status --endpoint URL
+      Show the progress of the drain on that node.
+  metastorectl raft log --endpoint URL [--from N] [--limit N] [--redact]
+      Show decoded entries of that node's raft log without stopping it (rocksdb engine only).
+      Without --from the last entries are shown; entries committed but not yet applied are marked with *.
+  metastorectl mirror list --endpoint URL
+      Show configured mirrors and the last revision replicated to each remote cluster.
+  metastorectl mirror start|stop --endpoint URL --name NAME
+      Start or stop a configured mirror on that node. Mirrors replicate only while the node is leader.
+  metastorectl encryption rotate-key --endpoint URL [--interval D]
+      Re-encrypt every value stored on that node with the active key and wait until done.
+      Run it on each member after changing active_key, before removing the old key.
+  metastorectl encryption status --endpoint URL
+      Show the active key and the progress of the last re-encryption on that node.
+  metastorectl data export --endpoints HOSTS [--prefix P] [--format jsonl|etcd|consul] [--output FILE] [--parallel N] [--rate N]
+      Export keys under a prefix at a single revision through the gRPC API of a MetaStore or etcd cluster.
+      The etcd format matches "etcdctl get --prefix -w json", the consul format matches "consul kv export".
+  metastorectl data import --endpoints HOSTS [--format jsonl|etcd|consul] [--input FILE] [--batch-size N] [--parallel N] [--rate N]
+      Write exported keys into a MetaStore or etcd cluster in transactions. Existing keys are overwritten, leases are not kept.
+  metastorectl snapshot save --endpoints HOSTS --output FILE
+      Save a snapshot of one member through the Maintenance API. It is signed and encrypted as configured
+      under maintenance on that member, and its manifest is printed.
+  metastorectl snapshot verify --input FILE [--config FILE] [--output FILE]
+      Check a saved snapshot against its manifest before restoring it. Encrypted snapshots need a config file
+      with the maintenance.snapshot_encryption keys; --output writes the verified, decrypted snapshot.
+  metastorectl settings list --endpoint URL
+      Show the cluster-wide runtime settings and their current values.
+  metastorectl settings set --endpoint URL --name NAME --value VALUE [--version N]
+      Change a runtime setting on every node. With --version the change is only made if the setting's current version matches.
+  metastorectl settings reset --endpoint URL --name NAME [--version N]
+      Remove a runtime setting; every node goes back to the value in its config file.`)
+}
+
+// memberReplace 发起替换并打印服务端流式返回的进度
+func memberReplace(args []string) error {
+	fs := flag.NewFlagSet("member replace", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the leader")
+	deadID := fs.Uint64("dead", 0, "ID of the dead member to replace")
+	newID := fs.Uint64("new-id", 0, "ID of the new member")
+	peerURL := fs.String("peer-url", "", "raft peer URL of the new member")
+	catchUpLag := fs.Uint64("catch-up-lag", 0, "entries the learner may lag behind the leader commit before promotion")
+	force := fs.Bool("force", false, "replace the member even if the leader still sees it as active")
+	timeout := fs.Duration("timeout", 30*time.Minute, "maximum time for the whole replacement")
+	fs.Parse(args)
+
+	body, err := json.Marshal(kvstore.MemberReplaceRequest{
+		DeadID:     *deadID,
+		NewID:      *newID,
+		PeerURL:    *peerURL,
+		CatchUpLag: *catchUpLag,
+		Force:      *force,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C 断开连接即中止替换，之后使用相同参数重试可从中断处继续
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, replaceURL(*endpoint), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var last kvstore.MemberReplaceProgress
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			return fmt.Errorf("invalid progress from server: %w", err)
+		}
+		printProgress(last)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	switch last.Phase {
+	case kvstore.MemberReplaceDone:
+		return nil
+	case kvstore.MemberReplaceFailed:
+		return fmt.Errorf("replacement failed: %s", last.Error)
+	default:
+		return fmt.Errorf("connection closed during phase %q", last.Phase)
+	}
+}
+
+// memberReplaceStatus 查询最近一次替换的进度
+func memberReplaceStatus(args []string) error {
+	fs := flag.NewFlagSet("member replace-status", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the node that ran the replacement")
+	fs.Parse(args)
+
+	resp, err := http.Get(replaceURL(*endpoint))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var p kvstore.MemberReplaceProgress
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return err
+	}
+	printProgress(p)
+	return nil
+}
+
+func replaceURL(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/") + httpapi.MemberReplacePath
+}
+
+func printProgress(p kvstore.MemberReplaceProgress) {
+	switch p.Phase {
+	case kvstore.MemberReplaceCatchUp:
+		state := "replicating"
+		if p.Snapshotting {
+			state = "receiving snapshot"
+		}
+		fmt.Printf("[%s] member %d %s: match %d / commit %d\n", p.Phase, p.NewID, state, p.LearnerMatch, p.LeaderCommit)
+	case kvstore.MemberReplaceFailed:
+		fmt.Printf("[%s] %s\n", p.Phase, p.Error)
+	default:
+		fmt.Printf("[%s] dead member %d, new member %d\n", p.Phase, p.DeadID, p.NewID)
+	}
+}
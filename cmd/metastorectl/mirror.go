@@ -0,0 +1,95 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	httpapi "metaStore/api/http"
+	"metaStore/pkg/mirror"
+)
+
+// mirrorList 打印所有 mirror 的状态
+func mirrorList(args []string) error {
+	fs := flag.NewFlagSet("mirror list", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint")
+	fs.Parse(args)
+
+	resp, err := http.Get(mirrorsURL(*endpoint))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var statuses []mirror.Status
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tREVISION\tPREFIX\tDEST PREFIX\tENDPOINTS\tERROR")
+	for _, st := range statuses {
+		state := "stopped"
+		switch {
+		case st.Active:
+			state = "active"
+		case st.Running:
+			state = "standby"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%q\t%q\t%s\t%s\n",
+			st.Name, state, st.Revision, st.Prefix, st.DestPrefix, strings.Join(st.Endpoints, ","), st.Error)
+	}
+	return tw.Flush()
+}
+
+// mirrorAction 启动或停止指定 mirror
+func mirrorAction(action string, args []string) error {
+	fs := flag.NewFlagSet("mirror "+action, flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint")
+	name := fs.String("name", "", "name of the mirror in the server config")
+	fs.Parse(args)
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	target := mirrorsURL(*endpoint) + "/" + url.PathEscape(*name) + "/" + action
+	resp, err := http.Post(target, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	fmt.Printf("mirror %s: %s ok\n", *name, action)
+	return nil
+}
+
+func mirrorsURL(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/") + httpapi.MirrorsPath
+}
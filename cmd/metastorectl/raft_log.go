@@ -0,0 +1,110 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	httpapi "metaStore/api/http"
+	"metaStore/internal/kvstore"
+)
+
+// raftLog 打印节点 raft 日志中解码后的条目，用于在线排查卡住的 apply
+func raftLog(args []string) error {
+	fs := flag.NewFlagSet("raft log", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint of the node")
+	from := fs.Uint64("from", 0, "first raft index to show, 0 shows the last entries")
+	limit := fs.Int("limit", 0, "number of entries to show, 0 uses the server default")
+	redact := fs.Bool("redact", false, "leave values out, showing only their size")
+	fs.Parse(args)
+
+	q := url.Values{}
+	if *from > 0 {
+		q.Set("from", strconv.FormatUint(*from, 10))
+	}
+	if *limit > 0 {
+		q.Set("limit", strconv.Itoa(*limit))
+	}
+	if *redact {
+		q.Set("redact", "true")
+	}
+	target := strings.TrimSuffix(*endpoint, "/") + httpapi.RaftLogPath
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	resp, err := http.Get(target)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var l kvstore.RaftLog
+	if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return err
+	}
+
+	fmt.Printf("first=%d last=%d commit=%d applied=%d\n", l.FirstIndex, l.LastIndex, l.Commit, l.Applied)
+	for _, e := range l.Entries {
+		// 已提交但尚未交给状态机的条目标记为 pending
+		mark := " "
+		if e.Index > l.Applied && e.Index <= l.Commit {
+			mark = "*"
+		}
+		fmt.Printf("%s %d term=%d %s", mark, e.Index, e.Term, e.Type)
+		if e.ConfChange != "" {
+			fmt.Printf(" %s", e.ConfChange)
+		}
+		if e.Error != "" {
+			fmt.Printf(" error=%q", e.Error)
+		}
+		fmt.Println()
+		for _, op := range e.Ops {
+			fmt.Printf("      %s", op.Type)
+			if op.Key != "" {
+				fmt.Printf(" key=%q", op.Key)
+			}
+			if op.RangeEnd != "" {
+				fmt.Printf(" range_end=%q", op.RangeEnd)
+			}
+			if op.Value != "" {
+				fmt.Printf(" value=%q", op.Value)
+			}
+			if op.ValueSize > 0 {
+				fmt.Printf(" value_size=%d", op.ValueSize)
+			}
+			if op.LeaseID != 0 {
+				fmt.Printf(" lease=%d", op.LeaseID)
+			}
+			if op.TxnOps > 0 {
+				fmt.Printf(" txn_ops=%d", op.TxnOps)
+			}
+			if op.SeqNum != "" {
+				fmt.Printf(" seq=%s", op.SeqNum)
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
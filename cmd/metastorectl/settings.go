@@ -0,0 +1,139 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	httpapi "metaStore/api/http"
+	"metaStore/pkg/settings"
+)
+
+// settingsList 打印所有设置的定义和集群中的值
+func settingsList(args []string) error {
+	fs := flag.NewFlagSet("settings list", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint")
+	fs.Parse(args)
+
+	resp, err := http.Get(settingsURL(*endpoint, ""))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	var statuses []settings.Status
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tVALUE\tVERSION\tDESCRIPTION")
+	for _, st := range statuses {
+		value, version := st.Value, strconv.FormatInt(st.Version, 10)
+		if st.Version == 0 {
+			value, version = "(config file)", "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", st.Name, st.Kind, value, version, st.Description)
+	}
+	return tw.Flush()
+}
+
+// settingsSet 修改集群设置，--version 指定时只有当前版本匹配才修改
+func settingsSet(args []string) error {
+	fs := flag.NewFlagSet("settings set", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint")
+	name := fs.String("name", "", "name of the setting")
+	value := fs.String("value", "", "new value")
+	version := fs.Int64("version", -1, "only change the setting if its current version matches, 0 means not set")
+	fs.Parse(args)
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	resp, err := settingsRequest(http.MethodPut, *endpoint, *name, *version, strings.NewReader(*value))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	var s settings.Setting
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return err
+	}
+	fmt.Printf("%s = %s (version %d)\n", s.Name, s.Value, s.Version)
+	return nil
+}
+
+// settingsReset 删除集群设置，各节点恢复使用配置文件中的值
+func settingsReset(args []string) error {
+	fs := flag.NewFlagSet("settings reset", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "http://127.0.0.1:9121", "HTTP API endpoint")
+	name := fs.String("name", "", "name of the setting")
+	version := fs.Int64("version", -1, "only reset the setting if its current version matches")
+	fs.Parse(args)
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	resp, err := settingsRequest(http.MethodDelete, *endpoint, *name, *version, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+	fmt.Printf("%s: reset to config file value\n", *name)
+	return nil
+}
+
+func settingsRequest(method, endpoint, name string, version int64, body io.Reader) (*http.Response, error) {
+	target := settingsURL(endpoint, name)
+	if version >= 0 {
+		target += "?version=" + strconv.FormatInt(version, 10)
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+func settingsURL(endpoint, name string) string {
+	u := strings.TrimSuffix(endpoint, "/") + httpapi.SettingsPath
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	return u
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"metaStore/pkg/backup"
+	"metaStore/pkg/config"
+	"metaStore/pkg/encryption"
+)
+
+// snapshotSave 通过 Maintenance.Snapshot 把快照保存到文件
+//
+// 快照按服务端的 maintenance 配置签名和加密，原样写入文件，先写临时文件再改名，
+// 中断时不会留下不完整的快照
+func snapshotSave(args []string) error {
+	fs := flag.NewFlagSet("snapshot save", flag.ExitOnError)
+	cf := addClientFlags(fs)
+	output := fs.String("output", "", "file to write the snapshot to")
+	fs.Parse(args)
+
+	if *output == "" {
+		return fmt.Errorf("--output is required")
+	}
+	client, err := cf.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	rc, err := client.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	tmp := *output + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, rc)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, *output); err != nil {
+		return err
+	}
+
+	fmt.Printf("saved %d bytes to %s\n", n, *output)
+	data, err := os.ReadFile(*output)
+	if err != nil {
+		return err
+	}
+	m, err := backup.ReadManifest(data)
+	if errors.Is(err, backup.ErrNotSealed) {
+		fmt.Println("snapshot is not signed, set maintenance.sign_snapshots on the server to record a manifest")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return printManifest(m)
+}
+
+// snapshotVerify 按清单校验快照，可选地把解密后的快照写入文件以便恢复
+func snapshotVerify(args []string) error {
+	fs := flag.NewFlagSet("snapshot verify", flag.ExitOnError)
+	input := fs.String("input", "", "snapshot file written by snapshot save")
+	configFile := fs.String("config", "", "config file with maintenance.snapshot_encryption keys, required for encrypted snapshots")
+	output := fs.String("output", "", "write the verified, decrypted snapshot to this file")
+	fs.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("--input is required")
+	}
+	var keyring *encryption.Keyring
+	if *configFile != "" {
+		cfg, err := config.LoadConfig(*configFile)
+		if err != nil {
+			return err
+		}
+		if keyring, err = encryption.LoadKeyring(&cfg.Server.Maintenance.SnapshotEncryption); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	snapshot, m, err := backup.Open(data, keyring)
+	if err != nil {
+		return err
+	}
+	if err := printManifest(m); err != nil {
+		return err
+	}
+	fmt.Println("snapshot verified")
+	if *output != "" {
+		return os.WriteFile(*output, snapshot, 0600)
+	}
+	return nil
+}
+
+func printManifest(m *backup.Manifest) error {
+	out, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -20,12 +20,17 @@ import (
 	"sync"
 	"time"
 
+	"metaStore/internal/kvstore"
+
 	"go.uber.org/zap"
 )
 
 // ProposalBatcher 动态批量提案系统
 // 根据负载动态调整批量大小和超时时间，在低负载和高负载场景下取得平衡
 // 参考 TiKV、etcd 的批量优化策略
+//
+// BatchConfig.Origins 中的来源前端各有一个独立的自适应队列（子批量器），与默认队列
+// 共用输出通道，例如 MySQL 批量导入不会拉长 etcd 交互请求的批量等待时间
 type ProposalBatcher struct {
 	// 配置参数
 	minBatchSize  int           // 最小批量大小（低负载场景）
@@ -50,6 +55,10 @@ type ProposalBatcher struct {
 	currentBatchSize int           // 当前批量大小
 	currentTimeout   time.Duration // 当前超时时间
 
+	// 按来源前端的子批量器及其输入通道，其他来源的提案由本批量器处理
+	origins      map[string]*ProposalBatcher
+	originInputs map[string]chan string
+
 	logger *zap.Logger
 }
 
@@ -60,6 +69,9 @@ type BatchConfig struct {
 	MinTimeout    time.Duration // 最小超时时间（默认 5ms）
 	MaxTimeout    time.Duration // 最大超时时间（默认 20ms）
 	LoadThreshold float64       // 负载阈值（默认 0.7）
+
+	// Origins 按来源前端（kvstore.OriginEtcd 等）覆盖的批量参数，每个来源使用独立的队列
+	Origins map[string]BatchConfig
 }
 
 // DefaultBatchConfig 返回默认批量配置
@@ -101,6 +113,19 @@ func NewProposalBatcher(
 		logger:           logger,
 	}
 
+	for origin, originConfig := range config.Origins {
+		if batcher.origins == nil {
+			batcher.origins = make(map[string]*ProposalBatcher)
+			batcher.originInputs = make(map[string]chan string)
+		}
+		inputC := make(chan string, cap(batcher.proposeC))
+		sub := NewProposalBatcher(originConfig, inputC, logger.With(zap.String("origin", origin)))
+		sub.proposeC = batcher.proposeC
+		sub.stopC = batcher.stopC
+		batcher.origins[origin] = sub
+		batcher.originInputs[origin] = inputC
+	}
+
 	return batcher
 }
 
@@ -111,7 +136,24 @@ func (b *ProposalBatcher) ProposeC() <-chan []byte {
 
 // Start 启动批量提案器
 func (b *ProposalBatcher) Start(ctx context.Context) {
-	go b.run(ctx)
+	var wg sync.WaitGroup
+	for _, sub := range b.origins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub.run(ctx)
+		}()
+	}
+
+	go func() {
+		b.run(ctx)
+		// 子批量器刷新剩余提案后再关闭共用的输出通道
+		for _, inputC := range b.originInputs {
+			close(inputC)
+		}
+		wg.Wait()
+		close(b.proposeC) // batcher 拥有此通道，负责关闭
+	}()
 }
 
 // Stop 停止批量提案器
@@ -124,11 +166,8 @@ func (b *ProposalBatcher) run(ctx context.Context) {
 	ticker := time.NewTicker(b.currentTimeout)
 	defer ticker.Stop()
 
-	// 确保在退出时刷新剩余提案并关闭输出通道
-	defer func() {
-		b.flush()
-		close(b.proposeC) // batcher 拥有此通道，负责关闭
-	}()
+	// 确保在退出时刷新剩余提案
+	defer b.flush()
 
 	for {
 		select {
@@ -145,6 +184,19 @@ func (b *ProposalBatcher) run(ctx context.Context) {
 				return
 			}
 
+			// 有独立队列的来源交给对应的子批量器
+			origin, proposal := kvstore.SplitProposal(proposal)
+			if inputC, ok := b.originInputs[origin]; ok {
+				select {
+				case inputC <- proposal:
+				case <-ctx.Done():
+					return
+				case <-b.stopC:
+					return
+				}
+				continue
+			}
+
 			b.mu.Lock()
 			b.buffer = append(b.buffer, proposal)
 			bufferLen := len(b.buffer)
@@ -186,6 +238,7 @@ func (b *ProposalBatcher) flush() {
 	b.batchCount++
 	batchCount := b.batchCount
 	b.mu.Unlock()
+	kvstore.ObserveBatchSize(len(batch))
 
 	// 编码批量提案
 	batchData, err := EncodeBatch(batch)
@@ -318,6 +371,15 @@ func (b *ProposalBatcher) Stats() BatchStats {
 	}
 }
 
+// OriginStats 返回各来源前端独立队列的统计信息，Stats 只包含默认队列
+func (b *ProposalBatcher) OriginStats() map[string]BatchStats {
+	stats := make(map[string]BatchStats, len(b.origins))
+	for origin, sub := range b.origins {
+		stats[origin] = sub.Stats()
+	}
+	return stats
+}
+
 // BatchStats 批量提案器统计信息
 type BatchStats struct {
 	TotalProposals   int64         // 总提案数
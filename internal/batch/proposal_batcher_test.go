@@ -20,6 +20,8 @@ import (
 	"testing"
 	"time"
 
+	"metaStore/internal/kvstore"
+
 	"go.uber.org/zap"
 )
 
@@ -146,6 +148,67 @@ func TestProposalBatcher_MultipleProposals(t *testing.T) {
 	}
 }
 
+// TestProposalBatcher_Origins tests that proposals of a frontend with its own
+// parameters are batched in a separate queue
+func TestProposalBatcher_Origins(t *testing.T) {
+	inputC := make(chan string, 10)
+
+	config := DefaultBatchConfig()
+	mysqlConfig := config
+	mysqlConfig.MinBatchSize = 3 // Batch when 3 MySQL proposals accumulated
+	mysqlConfig.MinTimeout = 1 * time.Second
+	mysqlConfig.MaxTimeout = 2 * time.Second
+	config.Origins = map[string]BatchConfig{kvstore.OriginMySQL: mysqlConfig}
+	batcher := NewProposalBatcher(config, inputC, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	batcher.Start(ctx)
+	defer batcher.Stop()
+
+	proposeC := batcher.ProposeC()
+	receive := func() []string {
+		t.Helper()
+		select {
+		case data := <-proposeC:
+			proposals, err := DecodeBatch(data)
+			if err != nil {
+				t.Fatalf("DecodeBatch failed: %v", err)
+			}
+			return proposals
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for batched proposals")
+			return nil
+		}
+	}
+
+	// MySQL proposals wait for a full batch, the etcd one is sent at once
+	inputC <- kvstore.TagProposal(kvstore.OriginMySQL, "mysql-1")
+	inputC <- kvstore.TagProposal(kvstore.OriginMySQL, "mysql-2")
+	inputC <- kvstore.TagProposal(kvstore.OriginEtcd, "etcd-1")
+	if proposals := receive(); len(proposals) != 1 || proposals[0] != "etcd-1" {
+		t.Errorf("Expected [etcd-1], got %v", proposals)
+	}
+
+	// Tags are removed before proposals reach raft
+	inputC <- kvstore.TagProposal(kvstore.OriginMySQL, "mysql-3")
+	proposals := receive()
+	expected := []string{"mysql-1", "mysql-2", "mysql-3"}
+	if len(proposals) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, proposals)
+	}
+	for i, exp := range expected {
+		if proposals[i] != exp {
+			t.Errorf("Proposal %d mismatch: got %q, want %q", i, proposals[i], exp)
+		}
+	}
+
+	if stats := batcher.OriginStats()[kvstore.OriginMySQL]; stats.TotalProposals != 3 {
+		t.Errorf("Expected 3 MySQL proposals in stats, got %d", stats.TotalProposals)
+	}
+}
+
 // TestProposalBatcher_TimeoutTrigger tests that timeout triggers batch
 func TestProposalBatcher_TimeoutTrigger(t *testing.T) {
 	inputC := make(chan string, 10)
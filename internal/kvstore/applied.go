@@ -0,0 +1,109 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrReadAfterWriteUnsupported 请求带了 token，但存储不支持 read-after-write token
+var ErrReadAfterWriteUnsupported = fmt.Errorf("read-after-write token: %w", errors.ErrUnsupported)
+
+// ReadAfterWriter 支持 read-after-write token 的存储
+//
+// 写请求完成后返回 WriteIndex() 作为 token，客户端之后在任意节点读取时带上 token，
+// 该节点先等待状态机应用到 token 再读取，从而一定能读到这次写入（单调读），
+// 包括从 follower 读取
+type ReadAfterWriter interface {
+	// WriteIndex 返回一个不小于本节点已完成的所有写入的 raft index
+	WriteIndex() uint64
+
+	// WaitApplied 等待本节点的状态机应用到 index
+	WaitApplied(ctx context.Context, index uint64) error
+}
+
+// AppliedIndex 跟踪状态机应用到的 raft index，零值可用
+//
+// Begin 和 Advance 只在 apply goroutine 中按顺序调用
+type AppliedIndex struct {
+	applying atomic.Uint64 // 正在应用或最后应用的 commit 的 index
+
+	mu      sync.Mutex
+	applied uint64
+	changed chan struct{} // applied 推进时关闭并替换
+}
+
+// Begin 开始应用以 index 结尾的 commit
+//
+// 在 commit 应用过程中完成的写请求，其 raft index 不超过 index
+func (a *AppliedIndex) Begin(index uint64) {
+	if index > a.applying.Load() {
+		a.applying.Store(index)
+	}
+}
+
+// Advance 状态机已应用到 index，唤醒等待的读请求
+func (a *AppliedIndex) Advance(index uint64) {
+	a.Begin(index)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if index <= a.applied {
+		return
+	}
+	a.applied = index
+	if a.changed != nil {
+		close(a.changed)
+		a.changed = nil
+	}
+}
+
+// Applied 返回状态机已应用到的 index
+func (a *AppliedIndex) Applied() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.applied
+}
+
+// WriteIndex 返回一个不小于已完成的所有写入的 raft index
+func (a *AppliedIndex) WriteIndex() uint64 {
+	return a.applying.Load()
+}
+
+// Wait 等待状态机应用到 index，ctx 结束时返回 ctx.Err()
+func (a *AppliedIndex) Wait(ctx context.Context, index uint64) error {
+	for {
+		a.mu.Lock()
+		if index <= a.applied {
+			a.mu.Unlock()
+			return nil
+		}
+		if a.changed == nil {
+			a.changed = make(chan struct{})
+		}
+		changed := a.changed
+		a.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppliedIndex(t *testing.T) {
+	var a AppliedIndex
+
+	// 已应用的 index 立即返回
+	require.NoError(t, a.Wait(context.Background(), 0))
+
+	// 应用过程中完成的写入拿到的 token 覆盖整个 commit
+	a.Begin(5)
+	assert.Equal(t, uint64(5), a.WriteIndex())
+	assert.Equal(t, uint64(0), a.Applied())
+
+	done := make(chan error, 1)
+	go func() { done <- a.Wait(context.Background(), 5) }()
+
+	a.Advance(3)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned before index 5 was applied: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	a.Advance(5)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after index 5 was applied")
+	}
+
+	// 不会后退
+	a.Advance(4)
+	assert.Equal(t, uint64(5), a.Applied())
+	assert.Equal(t, uint64(5), a.WriteIndex())
+}
+
+func TestAppliedIndexWaitTimeout(t *testing.T) {
+	var a AppliedIndex
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, a.Wait(ctx, 1), context.DeadlineExceeded)
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// applyPoolQueueSize 每个 worker 排队的任务数，队列满时 Submit 阻塞 apply goroutine
+const applyPoolQueueSize = 256
+
+// ApplyPool 由固定数量 worker 组成的 apply 线程池
+//
+// 任务按哈希分给 worker，相同哈希（同一个 key 或同一个分片）的任务由同一个 worker
+// 按提交顺序执行，不同哈希的任务并行执行。apply goroutine 提交一个 commit 的写操作后
+// 调用 Wait，等全部完成再关闭 ApplyDoneC，因此 commit 之间仍然按顺序应用
+//
+// 只有内存引擎使用线程池。RocksDB 引擎把一个 commit 的写操作依次放进同一个 WriteBatch
+// （后面的操作读取前面写入的 key），再与 applied index 一起原子写入，没有可以并行的部分
+type ApplyPool struct {
+	queues  []chan func()
+	pending sync.WaitGroup // 已提交但尚未完成的任务
+	workers sync.WaitGroup
+}
+
+// NewApplyPool 创建有 workers 个 worker 的线程池，workers 小于 1 时按 1 处理
+func NewApplyPool(workers int) *ApplyPool {
+	workers = max(workers, 1)
+	p := &ApplyPool{queues: make([]chan func(), workers)}
+	for i := range p.queues {
+		p.queues[i] = make(chan func(), applyPoolQueueSize)
+		p.workers.Add(1)
+		go p.run(p.queues[i])
+	}
+	return p
+}
+
+func (p *ApplyPool) run(queue <-chan func()) {
+	defer p.workers.Done()
+	for task := range queue {
+		task()
+		p.pending.Done()
+	}
+}
+
+// Workers 返回 worker 数量
+func (p *ApplyPool) Workers() int {
+	return len(p.queues)
+}
+
+// Submit 把任务交给 hash 对应的 worker，worker 的队列满时阻塞
+func (p *ApplyPool) Submit(hash uint32, task func()) {
+	p.pending.Add(1)
+	p.queues[hash%uint32(len(p.queues))] <- task
+}
+
+// SubmitKey 把任务交给 key 对应的 worker，同一个 key 的任务按提交顺序执行
+func (p *ApplyPool) SubmitKey(key string, task func()) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	p.Submit(h.Sum32(), task)
+}
+
+// Wait 等待所有已提交的任务完成
+func (p *ApplyPool) Wait() {
+	p.pending.Wait()
+}
+
+// Close 等待排队的任务完成后停止所有 worker，之后不能再提交任务
+func (p *ApplyPool) Close() {
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.workers.Wait()
+}
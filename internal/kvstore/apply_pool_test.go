@@ -0,0 +1,81 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPoolKeyOrder(t *testing.T) {
+	pool := NewApplyPool(4)
+	defer pool.Close()
+
+	// 同一个 key 的任务按提交顺序执行
+	var mu sync.Mutex
+	order := make(map[string][]int)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i%10)
+		pool.SubmitKey(key, func() {
+			mu.Lock()
+			order[key] = append(order[key], i)
+			mu.Unlock()
+		})
+	}
+	pool.Wait()
+
+	assert.Len(t, order, 10)
+	for key, seq := range order {
+		assert.Len(t, seq, 100, key)
+		assert.IsIncreasing(t, seq, key)
+	}
+}
+
+func TestApplyPoolParallel(t *testing.T) {
+	pool := NewApplyPool(4)
+	defer pool.Close()
+	assert.Equal(t, 4, pool.Workers())
+
+	// 不同 worker 的任务并行执行：4 个任务互相等待，串行执行会死锁
+	var ready sync.WaitGroup
+	ready.Add(4)
+	var done atomic.Int32
+	for i := uint32(0); i < 4; i++ {
+		pool.Submit(i, func() {
+			ready.Done()
+			ready.Wait()
+			done.Add(1)
+		})
+	}
+	pool.Wait()
+	assert.Equal(t, int32(4), done.Load())
+}
+
+func TestApplyPoolMinWorkers(t *testing.T) {
+	pool := NewApplyPool(0)
+	defer pool.Close()
+	assert.Equal(t, 1, pool.Workers())
+
+	var n int
+	for i := 0; i < 10; i++ {
+		pool.SubmitKey(fmt.Sprint(i), func() { n++ })
+	}
+	pool.Wait()
+	assert.Equal(t, 10, n)
+}
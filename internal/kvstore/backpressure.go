@@ -0,0 +1,120 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTooManyRequests 提案管道饱和，请求没有提交给 Raft，可以安全重试
+var ErrTooManyRequests = errors.New("too many requests")
+
+// ErrRequestTooLarge 提案序列化后超过 limits.max_request_size，请求没有提交给 Raft。
+// 超过 raft.max_size_per_msg 的日志条目会让复制卡住，因此在提案之前拒绝
+var ErrRequestTooLarge = errors.New("request is too large")
+
+// TooManyRequestsError 带重试提示的 ErrTooManyRequests
+type TooManyRequestsError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return fmt.Sprintf("too many requests: %s, retry after %s", e.Reason, e.RetryAfter)
+}
+
+// Is 使 errors.Is(err, ErrTooManyRequests) 成立
+func (e *TooManyRequestsError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
+
+// RetryAfter 返回 err 携带的重试提示
+func RetryAfter(err error) (time.Duration, bool) {
+	var tmr *TooManyRequestsError
+	if errors.As(err, &tmr) {
+		return tmr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// Backpressure 写请求进入提案管道之前的饱和检测
+type Backpressure struct {
+	QueueThreshold float64       // 提案队列占用比例达到该值时拒绝新提案，0 表示不检查
+	MaxPending     int           // 已提案但尚未 apply 的请求数上限，0 表示不限制
+	RetryAfter     time.Duration // 拒绝时返回给客户端的重试提示
+	Timeout        time.Duration // 请求 context 没有 deadline 时使用的超时，提案和等待 apply 共用
+
+	MaxRequestBytes int64 // 单个提案序列化后的字节数上限，0 表示不限制
+}
+
+// DefaultBackpressure 未配置时使用的默认值
+var DefaultBackpressure = Backpressure{
+	QueueThreshold: 0.9,
+	MaxPending:     10000,
+	RetryAfter:     time.Second,
+	Timeout:        30 * time.Second,
+
+	MaxRequestBytes: 1572864, // 1.5MB，与 limits.max_request_size 的默认值相同
+}
+
+// ProposeQueueStats 提案管道的当前状态，用于导出指标
+type ProposeQueueStats struct {
+	Queued   int    // 提案队列中排队的提案数
+	Capacity int    // 提案队列容量
+	Pending  int    // 已提案但尚未 apply 的请求数
+	Rejected uint64 // 因饱和被拒绝的请求总数
+
+	// 以下只有使用 ProposeQueue 时才有
+	HighWater  int               // 排队提案数的历史最大值
+	ByPriority map[string]int    // 各优先级排队的提案数
+	Full       map[string]uint64 // 各优先级入队时遇到队列满的次数
+}
+
+// Check 在提案之前检查管道是否饱和
+func (b Backpressure) Check(queued, capacity, pending int) error {
+	if b.QueueThreshold > 0 && capacity > 0 && float64(queued) >= b.QueueThreshold*float64(capacity) {
+		return &TooManyRequestsError{
+			Reason:     fmt.Sprintf("propose queue is %d/%d full", queued, capacity),
+			RetryAfter: b.RetryAfter,
+		}
+	}
+	if b.MaxPending > 0 && pending >= b.MaxPending {
+		return &TooManyRequestsError{
+			Reason:     fmt.Sprintf("%d proposals are waiting to be applied", pending),
+			RetryAfter: b.RetryAfter,
+		}
+	}
+	return nil
+}
+
+// CheckSize 在提案之前检查序列化后的提案大小
+func (b Backpressure) CheckSize(size int) error {
+	if b.MaxRequestBytes > 0 && int64(size) > b.MaxRequestBytes {
+		return fmt.Errorf("%w: %d bytes (max %d bytes)", ErrRequestTooLarge, size, b.MaxRequestBytes)
+	}
+	return nil
+}
+
+// WithTimeout 返回写请求在提案和等待 apply 阶段使用的 context。ctx 已经带有 deadline
+// （例如 etcd 客户端的 gRPC deadline）时沿用它，否则加上默认超时
+func (b Backpressure) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || b.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, b.Timeout)
+}
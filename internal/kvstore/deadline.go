@@ -0,0 +1,55 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 请求等待的阶段
+const (
+	StagePropose = "propose" // 等待进入提案管道
+	StageApply   = "apply"   // 已提案，等待 Raft 提交并 apply
+
+	StageCatchUp = "catch-up" // 读请求等待本节点应用到 read-after-write token
+)
+
+// StageError 请求在某个阶段超时或被取消
+//
+// errors.Is(err, context.DeadlineExceeded) 和 errors.Is(err, context.Canceled) 仍然成立。
+// 在 apply 阶段失败的请求已经提交给 Raft，之后仍可能生效
+type StageError struct {
+	Op    string // PUT、DELETE、TXN、LEASE_GRANT、LEASE_REVOKE、COMPACT，等待 token 的读请求为 READ
+	Stage string // StagePropose、StageApply 或 StageCatchUp
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s: %v while waiting for %s", e.Op, e.Err, e.Stage)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Stage 返回 err 失败时所在的阶段，不是 StageError 时返回空字符串
+func Stage(err error) string {
+	var se *StageError
+	if errors.As(err, &se) {
+		return se.Stage
+	}
+	return ""
+}
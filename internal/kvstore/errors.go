@@ -0,0 +1,49 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 两个存储引擎共用的类型化错误。引擎用 %w 包装它们附带上下文，gRPC 层用
+// errors.Is 把它们映射到 etcd 的 rpctypes 错误，clientv3 据此区分可重试和永久失败。
+// revision 越界沿用 mvcc.ErrCompacted 和 mvcc.ErrFutureRevision
+var (
+	// ErrLeaseNotFound lease 不存在，与 etcd 的 ErrLeaseNotFound 相同
+	ErrLeaseNotFound = errors.New("lease not found")
+
+	// ErrLeaseExpired lease 已过期，etcd 对过期 lease 同样返回 ErrLeaseNotFound
+	ErrLeaseExpired = errors.New("lease expired")
+
+	// ErrLeaseExists 以已存在的 ID 授予 lease，与 etcd 的 ErrLeaseExist 相同
+	ErrLeaseExists = errors.New("lease already exists")
+
+	// ErrNoLeader 集群当前没有 leader，选举完成后可以重试
+	ErrNoLeader = errors.New("no leader")
+
+	// ErrNotLeader 操作只能在 leader 上执行，客户端应当改连 leader
+	ErrNotLeader = errors.New("not leader")
+)
+
+// NotLeaderError 返回本节点不是 leader 时的错误：leaderID 为 0 表示没有 leader，
+// 返回 ErrNoLeader，否则返回带当前 leader 的 ErrNotLeader
+func NotLeaderError(leaderID uint64) error {
+	if leaderID == 0 {
+		return ErrNoLeader
+	}
+	return fmt.Errorf("%w, current leader: %d", ErrNotLeader, leaderID)
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import "fmt"
+
+// HealthMode 节点的健康状态，负载均衡器据此把读请求从不健康的节点移走
+type HealthMode string
+
+const (
+	HealthLeader          HealthMode = "healthy-leader"   // leader，可以读写
+	HealthFollower        HealthMode = "healthy-follower" // follower，已知 leader 且 apply 跟得上
+	HealthNoLeader        HealthMode = "no-leader"        // 没有 leader，或者正在（预）选举
+	HealthLagging         HealthMode = "lagging"          // apply 落后 commit 太多，读到的数据可能较旧
+	HealthStorageDegraded HealthMode = "storage-degraded" // 存储引擎写停顿
+)
+
+// NodeHealth 节点健康检查结果
+type NodeHealth struct {
+	Mode     HealthMode `json:"mode"`
+	Reason   string     `json:"reason,omitempty"`
+	NodeID   uint64     `json:"node_id"`
+	LeaderID uint64     `json:"leader_id"`
+	Term     uint64     `json:"term"`
+	State    string     `json:"state"`
+	Applied  uint64     `json:"applied"`
+	Commit   uint64     `json:"commit"`
+	ApplyLag uint64     `json:"apply_lag"`
+}
+
+// Healthy 返回节点是否适合处理读请求
+func (h NodeHealth) Healthy() bool {
+	return h.Mode == HealthLeader || h.Mode == HealthFollower
+}
+
+// StorageStaller 由能检测写停顿的存储引擎实现
+type StorageStaller interface {
+	// StorageStall 返回存储引擎当前是否写停顿及原因
+	StorageStall() (stalled bool, reason string)
+}
+
+// CheckHealth 根据 Raft 状态和存储引擎状态判断节点的健康状态
+//
+// 多种问题同时存在时按 storage-degraded、no-leader、lagging 的顺序报告。
+// 启用 PreVote 和 CheckQuorum 时，与 leader 失联的 follower 在选举超时后进入
+// pre-candidate 状态，因此被报告为 no-leader，而不会一直使用过期的 leader
+func CheckHealth(store Store, maxApplyLag uint64) NodeHealth {
+	status := store.GetRaftStatus()
+	h := NodeHealth{
+		NodeID:   status.NodeID,
+		LeaderID: status.LeaderID,
+		Term:     status.Term,
+		State:    status.State,
+		Applied:  status.Applied,
+		Commit:   status.Commit,
+	}
+	if status.Commit > status.Applied {
+		h.ApplyLag = status.Commit - status.Applied
+	}
+
+	if ss, ok := As[StorageStaller](store); ok {
+		if stalled, reason := ss.StorageStall(); stalled {
+			h.Mode, h.Reason = HealthStorageDegraded, reason
+			return h
+		}
+	}
+
+	switch {
+	case status.State == "standalone":
+		h.Mode = HealthLeader
+	case status.LeaderID == 0 || status.State == "StateCandidate" || status.State == "StatePreCandidate":
+		h.Mode, h.Reason = HealthNoLeader, "no leader elected"
+	case maxApplyLag > 0 && h.ApplyLag > maxApplyLag:
+		h.Mode = HealthLagging
+		h.Reason = fmt.Sprintf("applied index %d is %d entries behind commit index %d", h.Applied, h.ApplyLag, h.Commit)
+	case status.LeaderID == status.NodeID:
+		h.Mode = HealthLeader
+	default:
+		h.Mode = HealthFollower
+	}
+	return h
+}
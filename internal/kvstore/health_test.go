@@ -0,0 +1,61 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// statusStore 返回固定 Raft 状态的存储
+type statusStore struct {
+	Store
+	status  RaftStatus
+	stalled bool
+}
+
+func (s *statusStore) GetRaftStatus() RaftStatus { return s.status }
+
+func (s *statusStore) StorageStall() (bool, string) { return s.stalled, "writes are stopped" }
+
+func TestCheckHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  RaftStatus
+		stalled bool
+		want    HealthMode
+	}{
+		{"leader", RaftStatus{NodeID: 1, LeaderID: 1, State: "StateLeader", Applied: 10, Commit: 10}, false, HealthLeader},
+		{"follower", RaftStatus{NodeID: 2, LeaderID: 1, State: "StateFollower", Applied: 95, Commit: 100}, false, HealthFollower},
+		{"standalone", RaftStatus{NodeID: 1, State: "standalone"}, false, HealthLeader},
+		{"no leader", RaftStatus{NodeID: 2, State: "StateFollower"}, false, HealthNoLeader},
+		{"pre-candidate", RaftStatus{NodeID: 2, LeaderID: 1, State: "StatePreCandidate"}, false, HealthNoLeader},
+		{"lagging", RaftStatus{NodeID: 2, LeaderID: 1, State: "StateFollower", Applied: 10, Commit: 200}, false, HealthLagging},
+		{"storage degraded", RaftStatus{NodeID: 1, LeaderID: 1, State: "StateLeader"}, true, HealthStorageDegraded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CheckHealth(&decorator{Store: &statusStore{status: tt.status, stalled: tt.stalled}}, 100)
+			assert.Equal(t, tt.want, h.Mode)
+			assert.Equal(t, tt.want == HealthLeader || tt.want == HealthFollower, h.Healthy())
+		})
+	}
+
+	// 0 disables the lag check
+	h := CheckHealth(&statusStore{status: RaftStatus{NodeID: 2, LeaderID: 1, Applied: 10, Commit: 200}}, 0)
+	assert.Equal(t, HealthFollower, h.Mode)
+	assert.Equal(t, uint64(190), h.ApplyLag)
+}
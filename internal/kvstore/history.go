@@ -0,0 +1,27 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import "context"
+
+// KeyHistorian 能列出单个键历史版本的存储
+//
+// KeyHistory 按 revision 从新到旧返回 key 仍保留的版本，删除以 Version 为 0、
+// 没有 value 的墓碑表示；limit 大于 0 时最多返回 limit 个版本。compacted 是历史的
+// 边界，不大于它的修改可能已被压缩而不在结果中。value 是引擎中保存的原始值，
+// 不经过装饰器（例如分段存储）的改写
+type KeyHistorian interface {
+	KeyHistory(ctx context.Context, key string, limit int64) (revs []*KeyValue, compacted int64, err error)
+}
@@ -0,0 +1,29 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+// HybridClock 为提交的操作分配 HLC 时间戳（pkg/hlc）的存储
+//
+// 每次修改的时间戳保存在 KeyValue.HLC 中，随 watch 事件和 CDC 发布。前端在响应头中
+// 返回 CurrentHLC()，客户端把它带给其它集群的请求，由 ObserveHLC 合并，从而在多个
+// 集群之间建立因果顺序
+type HybridClock interface {
+	// CurrentHLC 返回本节点最后应用的操作的 HLC，不小于本节点已完成的所有写入
+	CurrentHLC() uint64
+
+	// ObserveHLC 合并外部的 HLC，之后本节点提案的写入的 HLC 都大于它
+	// ts 超前本地物理时钟超过允许的最大偏差时返回 hlc.ErrClockOffset
+	ObserveHLC(ts uint64) error
+}
@@ -0,0 +1,38 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import "strings"
+
+// IsSystemKey 判断 key 是否属于内部子系统
+func IsSystemKey(key string) bool {
+	return strings.HasPrefix(key, SystemKeyPrefix)
+}
+
+// PrefixRange 返回前缀对应的 [key, rangeEnd)，空前缀表示全部 key
+func PrefixRange(prefix string) (string, string) {
+	if prefix == "" {
+		return "\x00", "\x00"
+	}
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return prefix, string(end[:i+1])
+		}
+	}
+	// 前缀全为 0xff，没有上界
+	return prefix, "\x00"
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixRange(t *testing.T) {
+	key, end := PrefixRange("app/")
+	assert.Equal(t, "app/", key)
+	assert.Equal(t, "app0", end)
+
+	key, end = PrefixRange("")
+	assert.Equal(t, "\x00", key)
+	assert.Equal(t, "\x00", end)
+
+	_, end = PrefixRange("a\xff")
+	assert.Equal(t, "b", end)
+
+	_, end = PrefixRange("\xff\xff")
+	assert.Equal(t, "\x00", end)
+}
+
+func TestIsSystemKey(t *testing.T) {
+	assert.True(t, IsSystemKey(SystemKeyPrefix+"mirror/dc2"))
+	assert.False(t, IsSystemKey("app/__metastore/x"))
+}
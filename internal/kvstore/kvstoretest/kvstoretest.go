@@ -0,0 +1,805 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kvstoretest 是 kvstore.Store 的一致性测试套件
+//
+// 每个存储引擎在自己的测试中调用 Run，保证 revision、删除事件、事务结果等语义
+// 在所有引擎上一致。新增引擎或修改引擎行为时，套件失败说明语义发生了偏离
+package kvstoretest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventTimeout 等待 watch 事件的超时
+const eventTimeout = 5 * time.Second
+
+// Factory 为每个子测试创建一个空存储，资源通过 t.Cleanup 释放
+type Factory func(t *testing.T) kvstore.Store
+
+// Run 对 newStore 创建的存储运行全部一致性测试
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s kvstore.Store)
+	}{
+		{"Put", testPut},
+		{"Range", testRange},
+		{"RangeFunc", testRangeFunc},
+		{"ReverseRangeFunc", testReverseRangeFunc},
+		{"KeyFilter", testKeyFilter},
+		{"DeleteRange", testDeleteRange},
+		{"Txn", testTxn},
+		{"TxnDeleteMissing", testTxnDeleteMissing},
+		{"Watch", testWatch},
+		{"WatchRange", testWatchRange},
+		{"WatchPrevKV", testWatchPrevKV},
+		{"WatchFilters", testWatchFilters},
+		{"WatchValueFilter", testWatchValueFilter},
+		{"WatchStartRevision", testWatchStartRevision},
+		{"WatchTxnAndLease", testWatchTxnAndLease},
+		{"CancelWatch", testCancelWatch},
+		{"WatchSlowConsumerOrder", testWatchSlowConsumerOrder},
+		{"WatchReconnect", testWatchReconnect},
+		{"Lease", testLease},
+		{"HLC", testHLC},
+		{"Compact", testCompact},
+		{"KeyHistory", testKeyHistory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// Loopback 模拟单节点 raft：proposeC 中的每个提案立即作为一次 commit 交给 commitC，
+// proposeC 关闭后关闭 commitC。用于在没有 raft 节点的情况下测试需要提案的引擎
+func Loopback(proposeC <-chan string, commitC chan<- *kvstore.Commit) {
+	var index uint64
+	for data := range proposeC {
+		index++
+		commitC <- &kvstore.Commit{Data: []string{data}, ApplyDoneC: make(chan struct{}), Index: index}
+	}
+	close(commitC)
+}
+
+func put(t *testing.T, s kvstore.Store, key, value string) int64 {
+	t.Helper()
+	rev, _, err := s.PutWithLease(context.Background(), key, value, 0)
+	require.NoError(t, err)
+	return rev
+}
+
+func get(t *testing.T, s kvstore.Store, key string) *kvstore.KeyValue {
+	t.Helper()
+	resp, err := s.Range(context.Background(), key, "", 0, 0)
+	require.NoError(t, err)
+	if len(resp.Kvs) == 0 {
+		return nil
+	}
+	return resp.Kvs[0]
+}
+
+func keys(kvs []*kvstore.KeyValue) []string {
+	out := make([]string, 0, len(kvs))
+	for _, kv := range kvs {
+		out = append(out, string(kv.Key))
+	}
+	return out
+}
+
+// testPut 每次写入 revision 加一，prevKv 是写入前的值，CreateRevision 保持不变
+func testPut(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	start := s.CurrentRevision()
+
+	rev, prev, err := s.PutWithLease(ctx, "a", "1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, start+1, rev)
+	assert.Nil(t, prev)
+
+	rev, prev, err = s.PutWithLease(ctx, "a", "2", 0)
+	require.NoError(t, err)
+	assert.Equal(t, start+2, rev)
+	assert.Equal(t, start+2, s.CurrentRevision())
+	require.NotNil(t, prev)
+	assert.Equal(t, "1", string(prev.Value))
+	assert.Equal(t, start+1, prev.ModRevision)
+
+	kv := get(t, s, "a")
+	require.NotNil(t, kv)
+	assert.Equal(t, "2", string(kv.Value))
+	assert.Equal(t, start+1, kv.CreateRevision)
+	assert.Equal(t, start+2, kv.ModRevision)
+	assert.Equal(t, int64(2), kv.Version)
+}
+
+// testRange Count 是范围内的键总数，不受 limit 影响；超出 limit 时 More 为 true
+func testRange(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	for _, k := range []string{"d", "b", "a", "c"} {
+		put(t, s, k, "v-"+k)
+	}
+
+	resp, err := s.Range(ctx, "a", "c", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys(resp.Kvs))
+	assert.Equal(t, int64(2), resp.Count)
+	assert.False(t, resp.More)
+	assert.Equal(t, s.CurrentRevision(), resp.Revision)
+
+	resp, err = s.Range(ctx, "a", "\x00", 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys(resp.Kvs))
+	assert.Equal(t, int64(4), resp.Count)
+	assert.True(t, resp.More)
+
+	resp, err = s.Range(ctx, "a", "\x00", 4, 0)
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 4)
+	assert.False(t, resp.More)
+
+	resp, err = s.Range(ctx, "missing", "", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+	assert.Equal(t, int64(0), resp.Count)
+}
+
+// testRangeFunc 流式读取与 Range 返回相同的键
+func testRangeFunc(t *testing.T, s kvstore.Store) {
+	for i := 0; i < 5; i++ {
+		put(t, s, fmt.Sprintf("k%d", i), "v")
+	}
+	var got []string
+	rev, err := kvstore.RangeFunc(context.Background(), s, "k1", "k4", 0, func(kv *kvstore.KeyValue) bool {
+		got = append(got, string(kv.Key))
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1", "k2", "k3"}, got)
+	assert.Equal(t, s.CurrentRevision(), rev)
+}
+
+// testKeyFilter 被过滤函数隐藏的键像不存在一样，不计入 count，也不占用 limit
+func testKeyFilter(t *testing.T, s kvstore.Store) {
+	for _, key := range []string{"f/1", "f/2", "f/3", "f/4"} {
+		put(t, s, key, "v")
+	}
+	ctx := kvstore.WithKeyFilter(context.Background(), func(key []byte) bool {
+		return string(key) != "f/1" && string(key) != "f/3"
+	})
+
+	resp, err := s.Range(ctx, "f/", "f0", 1, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "f/2", string(resp.Kvs[0].Key))
+	assert.True(t, resp.More)
+	assert.Equal(t, int64(2), resp.Count)
+
+	resp, err = s.Range(ctx, "f/1", "", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+	assert.Equal(t, int64(0), resp.Count)
+
+	collect := func(scan func(ctx context.Context, store kvstore.Store, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error)) []string {
+		var got []string
+		_, err := scan(ctx, s, "f/", "f0", 0, func(kv *kvstore.KeyValue) bool {
+			got = append(got, string(kv.Key))
+			return true
+		})
+		require.NoError(t, err)
+		return got
+	}
+	assert.Equal(t, []string{"f/2", "f/4"}, collect(kvstore.RangeFunc))
+	assert.Equal(t, []string{"f/4", "f/2"}, collect(kvstore.ReverseRangeFunc))
+
+	// 没有过滤函数时返回所有键
+	resp, err = s.Range(context.Background(), "f/", "f0", 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 4)
+}
+
+// testReverseRangeFunc 引擎原生支持降序流式读取，结果与升序相反，跨越引擎内部的分批边界
+func testReverseRangeFunc(t *testing.T, s kvstore.Store) {
+	_, ok := s.(kvstore.ReverseRangeStreamer)
+	require.True(t, ok, "engine should stream ranges in descending order")
+
+	const n = 300
+	for i := 0; i < n; i++ {
+		put(t, s, fmt.Sprintf("r/%03d", i), "v")
+	}
+	collect := func(key, rangeEnd string, max int) []string {
+		var got []string
+		rev, err := kvstore.ReverseRangeFunc(context.Background(), s, key, rangeEnd, 0, func(kv *kvstore.KeyValue) bool {
+			got = append(got, string(kv.Key))
+			return len(got) < max
+		})
+		require.NoError(t, err)
+		assert.Equal(t, s.CurrentRevision(), rev)
+		return got
+	}
+
+	got := collect("r/010", "r/290", n)
+	require.Len(t, got, 280)
+	assert.Equal(t, "r/289", got[0])
+	assert.Equal(t, "r/010", got[len(got)-1])
+	for i := 1; i < len(got); i++ {
+		assert.Greater(t, got[i-1], got[i])
+	}
+
+	// "\x00" 读到最后一个 key，fn 返回 false 时停止
+	assert.Equal(t, []string{"r/299", "r/298"}, collect("r/", "\x00", 2))
+	assert.Equal(t, []string{"r/005"}, collect("r/005", "", n))
+	assert.Empty(t, collect("r/100", "r/100", n))
+}
+
+// testDeleteRange 没有删除任何键时 revision 不变；一次范围删除只占用一个 revision
+func testDeleteRange(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	for _, k := range []string{"a", "b", "c"} {
+		put(t, s, k, "v-"+k)
+	}
+	before := s.CurrentRevision()
+
+	deleted, prevKvs, rev, err := s.DeleteRange(ctx, "missing", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+	assert.Empty(t, prevKvs)
+	assert.Equal(t, before, rev)
+	assert.Equal(t, before, s.CurrentRevision())
+
+	deleted, prevKvs, rev, err = s.DeleteRange(ctx, "a", "c")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	assert.Equal(t, []string{"a", "b"}, keys(prevKvs))
+	assert.Equal(t, "v-a", string(prevKvs[0].Value))
+	assert.Equal(t, before+1, rev)
+	assert.Equal(t, before+1, s.CurrentRevision())
+
+	assert.Nil(t, get(t, s, "a"))
+	assert.NotNil(t, get(t, s, "c"))
+}
+
+// testTxn 按比较结果执行 then 或 else 分支，响应与操作一一对应
+func testTxn(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	put(t, s, "k", "v1")
+
+	cmp := func(value string) []kvstore.Compare {
+		return []kvstore.Compare{{
+			Target:      kvstore.CompareValue,
+			Result:      kvstore.CompareEqual,
+			Key:         []byte("k"),
+			TargetUnion: kvstore.CompareUnion{Value: []byte(value)},
+		}}
+	}
+	thenOps := []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("k"), Value: []byte("v2")},
+		{Type: kvstore.OpRange, Key: []byte("k")},
+	}
+	elseOps := []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("else"), Value: []byte("1")},
+	}
+
+	resp, err := s.Txn(ctx, cmp("v1"), thenOps, elseOps)
+	require.NoError(t, err)
+	assert.True(t, resp.Succeeded)
+	require.Len(t, resp.Responses, 2)
+	require.Equal(t, kvstore.OpPut, resp.Responses[0].Type)
+	require.NotNil(t, resp.Responses[0].PutResp.PrevKv)
+	assert.Equal(t, "v1", string(resp.Responses[0].PutResp.PrevKv.Value))
+	require.Equal(t, kvstore.OpRange, resp.Responses[1].Type)
+	require.Len(t, resp.Responses[1].RangeResp.Kvs, 1)
+	assert.Equal(t, "v2", string(resp.Responses[1].RangeResp.Kvs[0].Value), "later ops see earlier writes")
+	assert.Equal(t, s.CurrentRevision(), resp.Revision)
+
+	resp, err = s.Txn(ctx, cmp("v1"), thenOps, elseOps)
+	require.NoError(t, err)
+	assert.False(t, resp.Succeeded)
+	require.Len(t, resp.Responses, 1)
+	assert.Equal(t, kvstore.OpPut, resp.Responses[0].Type)
+	assert.Equal(t, "v2", string(get(t, s, "k").Value))
+	assert.NotNil(t, get(t, s, "else"))
+}
+
+// testTxnDeleteMissing 事务中删除不存在的键不占用 revision，也不产生删除事件
+func testTxnDeleteMissing(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	put(t, s, "k", "v")
+	before := s.CurrentRevision()
+
+	resp, err := s.Txn(ctx, nil, []kvstore.Op{{Type: kvstore.OpDelete, Key: []byte("missing")}}, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, 1)
+	require.Equal(t, kvstore.OpDelete, resp.Responses[0].Type)
+	assert.Equal(t, int64(0), resp.Responses[0].DeleteResp.Deleted)
+	assert.Equal(t, before, resp.Revision)
+	assert.Equal(t, before, s.CurrentRevision())
+}
+
+// testWatch PUT 和 DELETE 事件按顺序到达，DELETE 事件的 Kv 只带 key 和删除时的 revision
+func testWatch(t *testing.T, s kvstore.Store) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const watchID = 1
+	events, err := s.Watch(ctx, "w", "", 0, watchID)
+	require.NoError(t, err)
+	defer s.CancelWatch(watchID)
+
+	putRev := put(t, s, "w", "1")
+	put(t, s, "other", "1")
+	_, _, delRev, err := s.DeleteRange(context.Background(), "w", "")
+	require.NoError(t, err)
+
+	next := func() kvstore.WatchEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(eventTimeout):
+			t.Fatal("timed out waiting for watch event")
+			return kvstore.WatchEvent{}
+		}
+	}
+
+	ev := next()
+	assert.Equal(t, kvstore.EventTypePut, ev.Type)
+	assert.Equal(t, "w", string(ev.Kv.Key))
+	assert.Equal(t, "1", string(ev.Kv.Value))
+	assert.Equal(t, putRev, ev.Revision)
+	assert.Equal(t, putRev, ev.Kv.ModRevision)
+
+	ev = next()
+	assert.Equal(t, kvstore.EventTypeDelete, ev.Type)
+	assert.Equal(t, "w", string(ev.Kv.Key))
+	assert.Empty(t, ev.Kv.Value)
+	assert.Equal(t, delRev, ev.Revision)
+	assert.Equal(t, delRev, ev.Kv.ModRevision)
+}
+
+// optionWatcher 支持 kvstore.WatchOptions 的 store，etcd 和 HTTP 的 watch 通过它创建
+type optionWatcher interface {
+	WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error)
+}
+
+// watchWithOptions 以 opts 创建 watch，测试结束时取消
+func watchWithOptions(t *testing.T, s kvstore.Store, key, rangeEnd string, startRevision, watchID int64, opts *kvstore.WatchOptions) <-chan kvstore.WatchEvent {
+	t.Helper()
+	ow, ok := kvstore.As[optionWatcher](s)
+	require.True(t, ok, "store does not support watch options")
+	events, err := ow.WatchWithOptions(key, rangeEnd, startRevision, watchID, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.CancelWatch(watchID) })
+	return events
+}
+
+// nextEvent 等待下一个 watch 事件
+func nextEvent(t *testing.T, events <-chan kvstore.WatchEvent) kvstore.WatchEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		require.True(t, ok, "watch channel closed")
+		return ev
+	case <-time.After(eventTimeout):
+		t.Fatal("timed out waiting for watch event")
+		return kvstore.WatchEvent{}
+	}
+}
+
+// noEvent 断言 watch 在短时间内没有收到事件
+func noEvent(t *testing.T, events <-chan kvstore.WatchEvent) {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected watch event %v on %q", ev.Type, ev.Kv.Key)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// eventKey 返回事件对应的 key
+func eventKey(ev kvstore.WatchEvent) string {
+	if ev.Kv != nil {
+		return string(ev.Kv.Key)
+	}
+	return string(ev.PrevKv.Key)
+}
+
+// testWatchRange 范围 watch 只收到 [key, rangeEnd) 内的事件，"\x00" 表示 key 之后的全部键
+func testWatchRange(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	prefix, err := s.Watch(ctx, "p/", "p0", 0, 10)
+	require.NoError(t, err)
+	defer s.CancelWatch(10)
+	all, err := s.Watch(ctx, "p/b", "\x00", 0, 11)
+	require.NoError(t, err)
+	defer s.CancelWatch(11)
+
+	put(t, s, "p/a", "1")
+	put(t, s, "other", "1")
+	put(t, s, "p/b", "1")
+	put(t, s, "q", "1")
+
+	assert.Equal(t, "p/a", eventKey(nextEvent(t, prefix)))
+	assert.Equal(t, "p/b", eventKey(nextEvent(t, prefix)))
+	noEvent(t, prefix)
+
+	assert.Equal(t, "p/b", eventKey(nextEvent(t, all)))
+	assert.Equal(t, "q", eventKey(nextEvent(t, all)))
+	noEvent(t, all)
+}
+
+// testWatchPrevKV 只有设置 PrevKV 时事件才带修改前的值，DELETE 事件的 PrevKv 是被删除的值
+func testWatchPrevKV(t *testing.T, s kvstore.Store) {
+	put(t, s, "k", "1")
+	with := watchWithOptions(t, s, "k", "", 0, 20, &kvstore.WatchOptions{PrevKV: true})
+	without := watchWithOptions(t, s, "k", "", 0, 21, &kvstore.WatchOptions{})
+
+	put(t, s, "k", "2")
+	_, _, _, err := s.DeleteRange(context.Background(), "k", "")
+	require.NoError(t, err)
+
+	ev := nextEvent(t, with)
+	assert.Equal(t, kvstore.EventTypePut, ev.Type)
+	require.NotNil(t, ev.PrevKv)
+	assert.Equal(t, "1", string(ev.PrevKv.Value))
+	ev = nextEvent(t, with)
+	assert.Equal(t, kvstore.EventTypeDelete, ev.Type)
+	require.NotNil(t, ev.PrevKv)
+	assert.Equal(t, "2", string(ev.PrevKv.Value))
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, nextEvent(t, without).PrevKv)
+	}
+}
+
+// testWatchFilters FilterNoPut 和 FilterNoDelete 分别丢弃 PUT 和 DELETE 事件
+func testWatchFilters(t *testing.T, s kvstore.Store) {
+	noPut := watchWithOptions(t, s, "f", "", 0, 30, &kvstore.WatchOptions{Filters: []kvstore.WatchFilterType{kvstore.FilterNoPut}})
+	noDelete := watchWithOptions(t, s, "f", "", 0, 31, &kvstore.WatchOptions{Filters: []kvstore.WatchFilterType{kvstore.FilterNoDelete}})
+
+	putRev := put(t, s, "f", "1")
+	_, _, delRev, err := s.DeleteRange(context.Background(), "f", "")
+	require.NoError(t, err)
+
+	ev := nextEvent(t, noPut)
+	assert.Equal(t, kvstore.EventTypeDelete, ev.Type)
+	assert.Equal(t, delRev, ev.Revision)
+	noEvent(t, noPut)
+
+	ev = nextEvent(t, noDelete)
+	assert.Equal(t, kvstore.EventTypePut, ev.Type)
+	assert.Equal(t, putRev, ev.Revision)
+	noEvent(t, noDelete)
+}
+
+// testWatchValueFilter ValueFilter 按写入的值过滤 PUT，按被删除的值过滤 DELETE
+func testWatchValueFilter(t *testing.T, s kvstore.Store) {
+	match := func(v []byte) bool { return string(v) == "yes" }
+	events := watchWithOptions(t, s, "v/", "v0", 0, 40, &kvstore.WatchOptions{ValueFilter: match})
+
+	put(t, s, "v/a", "no")
+	put(t, s, "v/b", "yes")
+	_, _, _, err := s.DeleteRange(context.Background(), "v/", "v0")
+	require.NoError(t, err)
+
+	ev := nextEvent(t, events)
+	assert.Equal(t, kvstore.EventTypePut, ev.Type)
+	assert.Equal(t, "v/b", eventKey(ev))
+	ev = nextEvent(t, events)
+	assert.Equal(t, kvstore.EventTypeDelete, ev.Type)
+	assert.Equal(t, "v/b", eventKey(ev))
+	noEvent(t, events)
+}
+
+// watchHistory 保留最近事件用于恢复 watch 的 store，默认容量由配置决定
+type watchHistory interface {
+	SetWatchHistory(maxEvents int, retention time.Duration)
+}
+
+// testWatchStartRevision 从旧 revision 开始的 watch 先按顺序回放之后的全部事件，再接收新事件，不重复
+func testWatchStartRevision(t *testing.T, s kvstore.Store) {
+	if h, ok := kvstore.As[watchHistory](s); ok {
+		h.SetWatchHistory(100, 0)
+	}
+	first := put(t, s, "r", "1")
+	second := put(t, s, "r", "2")
+	_, _, delRev, err := s.DeleteRange(context.Background(), "r", "")
+	require.NoError(t, err)
+
+	events := watchWithOptions(t, s, "r", "", first, 50, &kvstore.WatchOptions{PrevKV: true})
+	live := put(t, s, "r", "3")
+
+	ev := nextEvent(t, events)
+	assert.Equal(t, kvstore.EventTypePut, ev.Type)
+	assert.Equal(t, first, ev.Revision)
+	assert.Equal(t, "1", string(ev.Kv.Value))
+	ev = nextEvent(t, events)
+	assert.Equal(t, second, ev.Revision)
+	require.NotNil(t, ev.PrevKv)
+	assert.Equal(t, "1", string(ev.PrevKv.Value))
+	ev = nextEvent(t, events)
+	assert.Equal(t, kvstore.EventTypeDelete, ev.Type)
+	assert.Equal(t, delRev, ev.Revision)
+	ev = nextEvent(t, events)
+	assert.Equal(t, live, ev.Revision)
+	assert.Equal(t, "3", string(ev.Kv.Value))
+	noEvent(t, events)
+}
+
+// testWatchTxnAndLease 事务中的写入和撤销租约删除的键都产生 watch 事件
+func testWatchTxnAndLease(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	events, err := s.Watch(ctx, "t/", "t0", 0, 60)
+	require.NoError(t, err)
+	defer s.CancelWatch(60)
+
+	resp, err := s.Txn(ctx, nil, []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("t/a"), Value: []byte("1")},
+		{Type: kvstore.OpPut, Key: []byte("t/b"), Value: []byte("1")},
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, resp.Succeeded)
+
+	const leaseID = 600
+	_, err = s.LeaseGrant(ctx, leaseID, 60)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "t/leased", "v", leaseID)
+	require.NoError(t, err)
+	require.NoError(t, s.LeaseRevoke(ctx, leaseID))
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		ev := nextEvent(t, events)
+		got = append(got, fmt.Sprintf("%v %s", ev.Type, eventKey(ev)))
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf("%v t/a", kvstore.EventTypePut),
+		fmt.Sprintf("%v t/b", kvstore.EventTypePut),
+		fmt.Sprintf("%v t/leased", kvstore.EventTypePut),
+		fmt.Sprintf("%v t/leased", kvstore.EventTypeDelete),
+	}, got)
+}
+
+// testCancelWatch 取消后事件通道关闭，重复取消不报错，取消不存在的 watch 和重复使用 watch ID 报错
+func testCancelWatch(t *testing.T, s kvstore.Store) {
+	events, err := s.Watch(context.Background(), "x", "", 0, 70)
+	require.NoError(t, err)
+	_, err = s.Watch(context.Background(), "y", "", 0, 70)
+	assert.Error(t, err)
+
+	require.NoError(t, s.CancelWatch(70))
+	put(t, s, "x", "1")
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok, "event delivered after CancelWatch")
+	case <-time.After(eventTimeout):
+		t.Fatal("watch channel not closed after CancelWatch")
+	}
+	assert.Error(t, s.CancelWatch(70))
+
+	// 取消后 watch ID 可以再次使用
+	events, err = s.Watch(context.Background(), "x", "", 0, 70)
+	require.NoError(t, err)
+	defer s.CancelWatch(70)
+	put(t, s, "x", "2")
+	assert.Equal(t, "2", string(nextEvent(t, events).Kv.Value))
+}
+
+// testWatchSlowConsumerOrder 通道满时排队的事件仍按 revision 递增的顺序到达，不丢失也不重复
+func testWatchSlowConsumerOrder(t *testing.T, s kvstore.Store) {
+	events := watchWithOptions(t, s, "slow/", "slow0", 0, 75, nil)
+
+	// 不读取事件，写入超过通道容量的修改
+	const n = 300
+	for i := 0; i < n; i++ {
+		put(t, s, fmt.Sprintf("slow/%d", i%3), fmt.Sprint(i))
+	}
+
+	var last int64
+	for i := 0; i < n; i++ {
+		ev := nextEvent(t, events)
+		require.Greater(t, ev.Revision, last, "event %d out of order", i)
+		assert.Equal(t, fmt.Sprintf("slow/%d", i%3), eventKey(ev))
+		assert.Equal(t, fmt.Sprint(i), string(ev.Kv.Value))
+		last = ev.Revision
+	}
+	noEvent(t, events)
+}
+
+// testWatchReconnect 断线后从最后收到的事件继续：同一个 revision 中的事件在回放和实时推送中
+// 顺序相同，跳过已收到的部分后不重复也不丢失；压缩后仍在事件记录中的 revision 可以继续
+func testWatchReconnect(t *testing.T, s kvstore.Store) {
+	if h, ok := kvstore.As[watchHistory](s); ok {
+		h.SetWatchHistory(100, 0)
+	}
+	ctx := context.Background()
+	describe := func(ev kvstore.WatchEvent) string {
+		return fmt.Sprintf("%d %v %s=%s", ev.Revision, ev.Type, eventKey(ev), ev.Kv.Value)
+	}
+
+	live := watchWithOptions(t, s, "rc/", "rc0", 0, 80, nil)
+	first := put(t, s, "rc/a", "1")
+	put(t, s, "rc/b", "1")
+	put(t, s, "rc/c", "1")
+	_, _, delRev, err := s.DeleteRange(ctx, "rc/", "rc0")
+	require.NoError(t, err)
+	put(t, s, "rc/a", "2")
+
+	var all []string
+	for i := 0; i < 7; i++ {
+		all = append(all, describe(nextEvent(t, live)))
+	}
+	noEvent(t, live)
+
+	// resume 从 fromRev 重新 watch，跳过其中前 skip 个已收到的事件
+	resume := func(watchID, fromRev int64, skip, n int) []string {
+		t.Helper()
+		events := watchWithOptions(t, s, "rc/", "rc0", fromRev, watchID, nil)
+		var got []string
+		for len(got) < n {
+			ev := nextEvent(t, events)
+			if ev.Revision == fromRev && skip > 0 {
+				skip--
+				continue
+			}
+			got = append(got, describe(ev))
+		}
+		noEvent(t, events)
+		return got
+	}
+
+	// 收到范围删除的第一个事件后断线
+	assert.Equal(t, all[4:], resume(81, delRev, 1, 3))
+	// 收到整个范围删除后断线
+	assert.Equal(t, all[6:], resume(82, delRev+1, 0, 1))
+
+	// 压缩后从事件记录中继续
+	require.NoError(t, s.Compact(ctx, s.CurrentRevision()))
+	assert.Equal(t, all, resume(83, first, 0, 7))
+}
+
+// testLease 撤销租约时删除关联的键
+func testLease(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	const leaseID = 100
+
+	lease, err := s.LeaseGrant(ctx, leaseID, 60)
+	require.NoError(t, err)
+	assert.Equal(t, int64(leaseID), lease.ID)
+	assert.Equal(t, int64(60), lease.TTL)
+
+	_, _, err = s.PutWithLease(ctx, "leased", "v", leaseID)
+	require.NoError(t, err)
+	kv := get(t, s, "leased")
+	require.NotNil(t, kv)
+	assert.Equal(t, int64(leaseID), kv.Lease)
+
+	ttl, err := s.LeaseTimeToLive(ctx, leaseID)
+	require.NoError(t, err)
+	assert.Contains(t, ttl.Keys, "leased")
+
+	require.NoError(t, s.LeaseRevoke(ctx, leaseID))
+	assert.Nil(t, get(t, s, "leased"))
+	_, err = s.LeaseTimeToLive(ctx, leaseID)
+	assert.Error(t, err)
+}
+
+// testHLC 每次写入的 HLC 严格递增，删除事件带删除时的 HLC，CurrentHLC 不小于已完成的写入
+func testHLC(t *testing.T, s kvstore.Store) {
+	clock, ok := kvstore.As[kvstore.HybridClock](s)
+	if !ok {
+		t.Skip("store does not assign HLC timestamps")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const watchID = 2
+	events, err := s.Watch(ctx, "h", "", 0, watchID)
+	require.NoError(t, err)
+	defer s.CancelWatch(watchID)
+
+	put(t, s, "h", "1")
+	first := get(t, s, "h").HLC
+	require.NotZero(t, first)
+	put(t, s, "h", "2")
+	second := get(t, s, "h").HLC
+	assert.Greater(t, second, first)
+	assert.GreaterOrEqual(t, clock.CurrentHLC(), second)
+
+	_, _, _, err = s.DeleteRange(ctx, "h", "")
+	require.NoError(t, err)
+
+	var last kvstore.WatchEvent
+	for i := 0; i < 3; i++ {
+		select {
+		case last = <-events:
+		case <-time.After(eventTimeout):
+			t.Fatal("timed out waiting for watch event")
+		}
+	}
+	assert.Equal(t, kvstore.EventTypeDelete, last.Type)
+	assert.Greater(t, last.Kv.HLC, second)
+	assert.GreaterOrEqual(t, clock.CurrentHLC(), last.Kv.HLC)
+}
+
+// testCompact 压缩之后最新值仍然可读，不能重复压缩到同一个 revision，也不能压缩到未来的 revision
+func testCompact(t *testing.T, s kvstore.Store) {
+	ctx := context.Background()
+	put(t, s, "c", "1")
+	rev := put(t, s, "c", "2")
+	put(t, s, "c", "3")
+
+	require.NoError(t, s.Compact(ctx, rev))
+	assert.Error(t, s.Compact(ctx, rev))
+	assert.Error(t, s.Compact(ctx, s.CurrentRevision()+10))
+
+	kv := get(t, s, "c")
+	require.NotNil(t, kv)
+	assert.Equal(t, "3", string(kv.Value))
+	require.NoError(t, s.Compact(ctx, s.CurrentRevision()))
+}
+
+// testKeyHistory 单个键的历史从新到旧列出每次修改，删除以墓碑表示
+func testKeyHistory(t *testing.T, s kvstore.Store) {
+	historian, ok := kvstore.As[kvstore.KeyHistorian](s)
+	if !ok {
+		t.Skip("store does not keep key history")
+	}
+	if h, ok := kvstore.As[watchHistory](s); ok {
+		h.SetWatchHistory(100, 0)
+	}
+	ctx := context.Background()
+
+	first := put(t, s, "kh", "1")
+	put(t, s, "other", "x")
+	second := put(t, s, "kh", "2")
+	_, _, delRev, err := s.DeleteRange(ctx, "kh", "")
+	require.NoError(t, err)
+	third := put(t, s, "kh", "3")
+
+	revs, compacted, err := historian.KeyHistory(ctx, "kh", 0)
+	require.NoError(t, err)
+	assert.Less(t, compacted, first)
+	require.Len(t, revs, 4)
+	wantRevs := []int64{third, delRev, second, first}
+	wantValues := []string{"3", "", "2", "1"}
+	for i, kv := range revs {
+		assert.Equal(t, "kh", string(kv.Key))
+		assert.Equal(t, wantRevs[i], kv.ModRevision)
+		assert.Equal(t, wantValues[i], string(kv.Value))
+	}
+	assert.Zero(t, revs[1].Version, "deletion is a tombstone")
+	assert.Equal(t, int64(1), revs[0].Version)
+
+	revs, _, err = historian.KeyHistory(ctx, "kh", 2)
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+	assert.Equal(t, delRev, revs[1].ModRevision)
+
+	revs, _, err = historian.KeyHistory(ctx, "missing", 0)
+	require.NoError(t, err)
+	assert.Empty(t, revs)
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrLeaderChanged 请求等待 apply 期间 leader 发生变化，与 etcd 的 ErrLeaderChanged 相同
+//
+// leader 变化时转发给旧 leader 或在没有 leader 时提出的提案可能被丢弃，等待者立即
+// 收到该错误而不是等到超时。提案也可能已经提交并在之后生效，客户端可以重试，
+// 非幂等的写入重试前应当先确认结果
+var ErrLeaderChanged = errors.New("leader changed")
+
+// LeaderTracker 在 leader 变化时通知等待 apply 的请求
+type LeaderTracker struct {
+	mu      sync.Mutex
+	leader  uint64
+	changed chan struct{}
+}
+
+// NewLeaderTracker 创建 LeaderTracker
+func NewLeaderTracker() *LeaderTracker {
+	return &LeaderTracker{changed: make(chan struct{})}
+}
+
+// Changed 返回在下一次 leader 变化时关闭的 channel，提案之前获取
+func (t *LeaderTracker) Changed() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.changed
+}
+
+// Observe 记录 Raft 报告的 leader（0 表示没有 leader），与上次不同时通知所有等待者
+func (t *LeaderTracker) Observe(leader uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if leader == t.leader {
+		return
+	}
+	t.leader = leader
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// LeaderChangeNotifier 由能通知 leader 变化的 Raft 节点实现
+type LeaderChangeNotifier interface {
+	// LeaderChanged 返回在下一次 leader 变化时关闭的 channel
+	LeaderChanged() <-chan struct{}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestLeaderTracker(t *testing.T) {
+	tr := NewLeaderTracker()
+	ch := tr.Changed()
+	assert.False(t, closed(ch))
+
+	// 选出 leader
+	tr.Observe(1)
+	assert.True(t, closed(ch))
+
+	// leader 不变时不通知
+	ch = tr.Changed()
+	tr.Observe(1)
+	assert.False(t, closed(ch))
+
+	// 失去 leader 和选出新 leader 都会通知
+	tr.Observe(0)
+	assert.True(t, closed(ch))
+	ch = tr.Changed()
+	tr.Observe(2)
+	assert.True(t, closed(ch))
+	assert.False(t, closed(tr.Changed()))
+}
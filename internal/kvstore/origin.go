@@ -0,0 +1,66 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"strings"
+)
+
+// 写请求的来源前端。批量提案器按来源把提案放入不同的队列，各队列使用
+// raft.batch.frontends 中该前端的批量参数，例如 MySQL 批量导入使用更大的批量
+const (
+	OriginEtcd  = "etcd"
+	OriginHTTP  = "http"
+	OriginMySQL = "mysql"
+)
+
+type originKey struct{}
+
+// WithOrigin 标记写请求的来源前端
+func WithOrigin(ctx context.Context, origin string) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+// Origin 返回 ctx 中的来源前端，没有标记时返回空字符串
+func Origin(ctx context.Context) string {
+	origin, _ := ctx.Value(originKey{}).(string)
+	return origin
+}
+
+// originMarker 带来源的提案以 0 字节开头：protobuf、JSON 和 gob 编码的提案都不会以 0 开头
+const originMarker = "\x00"
+
+// TagProposal 在发送到 proposeC 的提案前加上来源，origin 为空时原样返回
+// 读取 proposeC 的一方在提交给 Raft 之前用 SplitProposal 去掉来源
+func TagProposal(origin, proposal string) string {
+	if origin == "" {
+		return proposal
+	}
+	return originMarker + origin + originMarker + proposal
+}
+
+// SplitProposal 拆分 TagProposal 生成的提案，返回来源和原始提案
+func SplitProposal(data string) (origin, proposal string) {
+	rest, ok := strings.CutPrefix(data, originMarker)
+	if !ok {
+		return "", data
+	}
+	origin, proposal, ok = strings.Cut(rest, originMarker)
+	if !ok {
+		return "", data
+	}
+	return origin, proposal
+}
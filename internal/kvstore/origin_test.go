@@ -0,0 +1,46 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrigin(t *testing.T) {
+	assert.Equal(t, "", Origin(context.Background()))
+	ctx := WithOrigin(context.Background(), OriginMySQL)
+	assert.Equal(t, OriginMySQL, Origin(ctx))
+
+	for _, tc := range []struct {
+		origin, proposal string
+	}{
+		{"", "PB:data"},
+		{OriginHTTP, "PB:data"},
+		{OriginEtcd, "\n\x04seq-1"},
+		{OriginMySQL, ""},
+	} {
+		origin, proposal := SplitProposal(TagProposal(tc.origin, tc.proposal))
+		assert.Equal(t, tc.origin, origin)
+		assert.Equal(t, tc.proposal, proposal)
+	}
+
+	// 不完整的标记按没有来源的提案处理
+	origin, proposal := SplitProposal("\x00mysql")
+	assert.Equal(t, "", origin)
+	assert.Equal(t, "\x00mysql", proposal)
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// 提案大小的分布，由存储引擎和批量提案器在热路径上记录，导出为 Prometheus 直方图，
+// 用于根据实际数据设置 MaxSizePerMsg 和 gRPC 消息大小上限
+var (
+	// proposalValueSizes 每个提案写入的 value 字节数，事务中每个 PUT 各记录一次
+	proposalValueSizes = newHistogram(exponentialBounds(64, 4, 10))
+	// txnKeyCounts 每个事务涉及的不同 key 数（比较和操作合计）
+	txnKeyCounts = newHistogram(exponentialBounds(1, 2, 10))
+	// batchSizes ProposalBatcher 每个批次包含的提案数
+	batchSizes = newHistogram(exponentialBounds(1, 2, 11))
+)
+
+// Histogram 固定桶的直方图，Observe 只做原子加法
+type Histogram struct {
+	bounds []float64
+	counts []atomic.Uint64 // counts[i] 是不大于 bounds[i] 的观测次数（非累计），最后一个是 +Inf
+	sum    atomic.Uint64
+}
+
+// HistogramSnapshot 直方图某一时刻的状态
+type HistogramSnapshot struct {
+	Count   uint64
+	Sum     uint64
+	Buckets map[float64]uint64 // 桶上界 -> 不大于上界的观测次数（累计，与 Prometheus 一致）
+}
+
+// ProposalStats 提案大小分布，用于导出指标
+type ProposalStats struct {
+	ValueSize HistogramSnapshot // 提案的 value 字节数
+	TxnKeys   HistogramSnapshot // 事务涉及的 key 数
+	BatchSize HistogramSnapshot // 批量提案器每批的提案数
+}
+
+// newHistogram 创建以 bounds（升序）为桶上界的直方图
+func newHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+}
+
+// exponentialBounds 返回 start 开始、每个是前一个 factor 倍的 count 个桶上界
+func exponentialBounds(start, factor float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v uint64) {
+	i := sort.SearchFloat64s(h.bounds, float64(v))
+	h.counts[i].Add(1)
+	h.sum.Add(v)
+}
+
+// Snapshot 返回当前的累计桶计数
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{
+		Sum:     h.sum.Load(),
+		Buckets: make(map[float64]uint64, len(h.bounds)),
+	}
+	for i := range h.counts {
+		snapshot.Count += h.counts[i].Load()
+		if i < len(h.bounds) {
+			snapshot.Buckets[h.bounds[i]] = snapshot.Count
+		}
+	}
+	return snapshot
+}
+
+// ObservePut 记录一次 PUT 提案的 value 大小
+func ObservePut(value string) {
+	proposalValueSizes.Observe(uint64(len(value)))
+}
+
+// ObserveTxn 记录一次事务提案涉及的 key 数，以及其中每个 PUT 的 value 大小
+func ObserveTxn(compares []Compare, thenOps, elseOps []Op) {
+	keys := make(map[string]struct{}, len(compares)+len(thenOps)+len(elseOps))
+	for _, cmp := range compares {
+		keys[string(cmp.Key)] = struct{}{}
+	}
+	for _, ops := range [][]Op{thenOps, elseOps} {
+		for _, op := range ops {
+			keys[string(op.Key)] = struct{}{}
+			if op.Type == OpPut {
+				proposalValueSizes.Observe(uint64(len(op.Value)))
+			}
+		}
+	}
+	txnKeyCounts.Observe(uint64(len(keys)))
+}
+
+// ObserveBatchSize 记录一个批量提案包含的提案数
+func ObserveBatchSize(n int) {
+	batchSizes.Observe(uint64(n))
+}
+
+// ProposalSizeStats 返回进程启动以来的提案大小分布
+func ProposalSizeStats() ProposalStats {
+	return ProposalStats{
+		ValueSize: proposalValueSizes.Snapshot(),
+		TxnKeys:   txnKeyCounts.Snapshot(),
+		BatchSize: batchSizes.Snapshot(),
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 4, 16})
+	for _, v := range []uint64{0, 1, 2, 4, 5, 100} {
+		h.Observe(v)
+	}
+
+	s := h.Snapshot()
+	assert.Equal(t, uint64(6), s.Count)
+	assert.Equal(t, uint64(112), s.Sum)
+	// 累计计数，大于最后一个上界的只计入 Count
+	assert.Equal(t, map[float64]uint64{1: 2, 4: 4, 16: 5}, s.Buckets)
+}
+
+func TestObserveTxn(t *testing.T) {
+	before := ProposalSizeStats()
+
+	ObserveTxn(
+		[]Compare{{Key: []byte("a")}, {Key: []byte("b")}},
+		[]Op{{Type: OpPut, Key: []byte("a"), Value: []byte(strings.Repeat("x", 100))}},
+		[]Op{{Type: OpDelete, Key: []byte("c")}},
+	)
+
+	after := ProposalSizeStats()
+	assert.Equal(t, before.TxnKeys.Count+1, after.TxnKeys.Count)
+	assert.Equal(t, before.TxnKeys.Sum+3, after.TxnKeys.Sum)
+	// 只有 PUT 记录 value 大小
+	assert.Equal(t, before.ValueSize.Count+1, after.ValueSize.Count)
+	assert.Equal(t, before.ValueSize.Sum+100, after.ValueSize.Sum)
+}
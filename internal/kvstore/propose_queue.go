@@ -0,0 +1,257 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrProposeQueueClosed 节点正在停止，提案队列不再接收提案
+var ErrProposeQueueClosed = errors.New("propose queue is closed")
+
+// Priority 提案在提案队列中的优先级，高优先级的提案先交给 Raft
+type Priority int
+
+const (
+	PriorityLow    Priority = iota // 批量导入等后台写入
+	PriorityNormal                 // 客户端写入（默认）
+	PriorityHigh                   // lease 撤销等不能被积压推迟的内部操作
+
+	priorityCount = int(PriorityHigh) + 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority 设置写请求在提案队列中的优先级
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityOf 返回 ctx 中设置的优先级，没有设置时返回 PriorityNormal
+func PriorityOf(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// OverflowPolicy 提案队列满时的处理方式
+type OverflowPolicy string
+
+const (
+	OverflowBlock  OverflowPolicy = "block"  // 等待队列出现空位，直到请求的 deadline
+	OverflowReject OverflowPolicy = "reject" // 立即返回 ErrTooManyRequests
+)
+
+// ProposeQueueConfig 提案队列配置
+type ProposeQueueConfig struct {
+	Capacity   int            // 排队提案数上限，所有优先级共用
+	Overflow   OverflowPolicy // 队列满时阻塞还是拒绝
+	RetryAfter time.Duration  // 拒绝时返回给客户端的重试提示
+}
+
+// ProposeQueue 存储引擎和 Raft 节点之间有界的提案队列
+//
+// 存储引擎按优先级入队，Raft 节点（启用批量提案时为批量提案器）从 C() 读取，
+// 同一优先级内先进先出。队列满时按 OverflowPolicy 阻塞或拒绝，每次遇到队列满
+// 都计入 Full，用于发现容量不足
+type ProposeQueue struct {
+	cfg ProposeQueueConfig
+
+	mu        sync.Mutex
+	queues    [priorityCount][]string
+	size      int
+	closed    bool
+	notFull   chan struct{} // 出队时关闭并替换，唤醒等待空位的提案
+	notEmpty  chan struct{} // 容量为 1，入队或关闭时唤醒出队 goroutine
+	full      [priorityCount]uint64
+	rejected  uint64
+	highWater int
+
+	out chan string
+}
+
+// NewProposeQueue 创建提案队列，Close 之前一直有一个 goroutine 把提案送到 C()
+func NewProposeQueue(cfg ProposeQueueConfig) *ProposeQueue {
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = 1
+	}
+	if cfg.Overflow == "" {
+		cfg.Overflow = OverflowBlock
+	}
+	q := &ProposeQueue{
+		cfg:      cfg,
+		notFull:  make(chan struct{}),
+		notEmpty: make(chan struct{}, 1),
+		out:      make(chan string),
+	}
+	go q.run()
+	return q
+}
+
+// C 返回出队的提案，Close 后排队的提案全部送出时关闭
+func (q *ProposeQueue) C() <-chan string {
+	return q.out
+}
+
+// Push 按优先级入队，队列满时按 OverflowPolicy 阻塞或返回 ErrTooManyRequests
+func (q *ProposeQueue) Push(ctx context.Context, p Priority, proposal string) error {
+	if p < PriorityLow || p > PriorityHigh {
+		p = PriorityNormal
+	}
+
+	q.mu.Lock()
+	counted := false
+	for !q.closed && q.size >= q.cfg.Capacity {
+		if !counted {
+			q.full[p]++
+			counted = true
+		}
+		if q.cfg.Overflow == OverflowReject {
+			q.rejected++
+			q.mu.Unlock()
+			return &TooManyRequestsError{
+				Reason:     fmt.Sprintf("propose queue is full (%d proposals)", q.cfg.Capacity),
+				RetryAfter: q.cfg.RetryAfter,
+			}
+		}
+		notFull := q.notFull
+		q.mu.Unlock()
+		select {
+		case <-notFull:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		q.mu.Lock()
+	}
+	if q.closed {
+		q.mu.Unlock()
+		return ErrProposeQueueClosed
+	}
+
+	q.queues[p] = append(q.queues[p], proposal)
+	q.size++
+	if q.size > q.highWater {
+		q.highWater = q.size
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close 停止接收提案，已排队的提案仍会送出，之后关闭 C()
+func (q *ProposeQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.notFull)
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+}
+
+// Len 返回排队的提案数
+func (q *ProposeQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Cap 返回队列容量
+func (q *ProposeQueue) Cap() int {
+	return q.cfg.Capacity
+}
+
+// Stats 返回队列的当前状态，Pending 由存储引擎填写
+func (q *ProposeQueue) Stats() ProposeQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := ProposeQueueStats{
+		Queued:     q.size,
+		Capacity:   q.cfg.Capacity,
+		Rejected:   q.rejected,
+		HighWater:  q.highWater,
+		ByPriority: make(map[string]int, priorityCount),
+		Full:       make(map[string]uint64, priorityCount),
+	}
+	for p := PriorityLow; p <= PriorityHigh; p++ {
+		stats.ByPriority[p.String()] = len(q.queues[p])
+		stats.Full[p.String()] = q.full[p]
+	}
+	return stats
+}
+
+// run 按优先级把提案送到 out
+func (q *ProposeQueue) run() {
+	defer close(q.out)
+	for {
+		proposal, ok := q.pop()
+		if !ok {
+			return
+		}
+		q.out <- proposal
+	}
+}
+
+// pop 取出优先级最高的提案，队列为空时等待，关闭且为空时返回 false
+func (q *ProposeQueue) pop() (string, bool) {
+	for {
+		q.mu.Lock()
+		for p := PriorityHigh; p >= PriorityLow; p-- {
+			if len(q.queues[p]) == 0 {
+				continue
+			}
+			proposal := q.queues[p][0]
+			q.queues[p][0] = ""
+			q.queues[p] = q.queues[p][1:]
+			q.size--
+			if !q.closed {
+				close(q.notFull)
+				q.notFull = make(chan struct{})
+			}
+			q.mu.Unlock()
+			return proposal, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return "", false
+		}
+		<-q.notEmpty
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposeQueue_Priority(t *testing.T) {
+	q := NewProposeQueue(ProposeQueueConfig{Capacity: 10})
+	ctx := context.Background()
+
+	// 出队 goroutine 先取走第一个提案并等待消费者，其余的按优先级排队
+	require.NoError(t, q.Push(ctx, PriorityNormal, "first"))
+	require.Eventually(t, func() bool { return q.Len() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, q.Push(ctx, PriorityLow, "import"))
+	require.NoError(t, q.Push(ctx, PriorityNormal, "put-1"))
+	require.NoError(t, q.Push(ctx, PriorityHigh, "revoke"))
+	require.NoError(t, q.Push(ctx, PriorityNormal, "put-2"))
+
+	stats := q.Stats()
+	assert.Equal(t, 4, stats.Queued)
+	assert.Equal(t, map[string]int{"low": 1, "normal": 2, "high": 1}, stats.ByPriority)
+
+	// Close 之后排队的提案仍会送出
+	q.Close()
+	assert.ErrorIs(t, q.Push(ctx, PriorityNormal, "late"), ErrProposeQueueClosed)
+	var got []string
+	for proposal := range q.C() {
+		got = append(got, proposal)
+	}
+	assert.Equal(t, []string{"first", "revoke", "put-1", "put-2", "import"}, got)
+}
+
+func TestProposeQueue_Overflow(t *testing.T) {
+	// 没有消费者：出队 goroutine 拿着一个提案，队列中还能放 Capacity 个
+	fill := func(q *ProposeQueue) {
+		require.NoError(t, q.Push(context.Background(), PriorityNormal, "in-flight"))
+		require.Eventually(t, func() bool { return q.Len() == 0 }, time.Second, time.Millisecond)
+		require.NoError(t, q.Push(context.Background(), PriorityNormal, "queued"))
+	}
+
+	reject := NewProposeQueue(ProposeQueueConfig{Capacity: 1, Overflow: OverflowReject, RetryAfter: time.Second})
+	defer reject.Close()
+	fill(reject)
+	err := reject.Push(context.Background(), PriorityHigh, "x")
+	require.ErrorIs(t, err, ErrTooManyRequests)
+	retryAfter, _ := RetryAfter(err)
+	assert.Equal(t, time.Second, retryAfter)
+	assert.Equal(t, uint64(1), reject.Stats().Full["high"])
+
+	block := NewProposeQueue(ProposeQueueConfig{Capacity: 1, Overflow: OverflowBlock})
+	fill(block)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, block.Push(ctx, PriorityNormal, "x"), context.DeadlineExceeded)
+
+	// 消费者取走提案后，阻塞的提案入队
+	done := make(chan error, 1)
+	go func() { done <- block.Push(context.Background(), PriorityLow, "waiting") }()
+	require.Eventually(t, func() bool { return block.Stats().Full["low"] == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "in-flight", <-block.C())
+	require.NoError(t, <-done)
+	stats := block.Stats()
+	assert.Equal(t, uint64(1), stats.Full["normal"])
+	assert.Equal(t, 1, stats.HighWater)
+	block.Close()
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+// RaftLog 本节点 raft 日志中一段条目的解码结果，用于在线排查卡住的 apply
+type RaftLog struct {
+	FirstIndex uint64         `json:"first_index"` // 存储中最早的条目，之前的已压缩进快照
+	LastIndex  uint64         `json:"last_index"`
+	Commit     uint64         `json:"commit"`
+	Applied    uint64         `json:"applied"` // 已交给状态机的最新条目
+	Entries    []RaftLogEntry `json:"entries"`
+}
+
+// RaftLogEntry 解码后的 raft 日志条目
+type RaftLogEntry struct {
+	Index      uint64      `json:"index"`
+	Term       uint64      `json:"term"`
+	Type       string      `json:"type"`                  // "EntryNormal"、"EntryConfChange" 等
+	Ops        []RaftLogOp `json:"ops,omitempty"`         // 普通条目中的操作，空条目没有操作
+	ConfChange string      `json:"conf_change,omitempty"` // 成员变更，如 "ConfChangeAddNode 4"
+	Error      string      `json:"error,omitempty"`       // 无法解码的原因，状态机应用这样的条目时会出错
+}
+
+// RaftLogOp 条目中的一个操作
+type RaftLogOp struct {
+	Type      string `json:"type"` // "PUT"、"DELETE"、"LEASE_GRANT"、"LEASE_REVOKE"、"TXN"、"COMPACT"
+	Key       string `json:"key,omitempty"`
+	RangeEnd  string `json:"range_end,omitempty"`
+	Value     string `json:"value,omitempty"` // 隐藏 value 时为空
+	ValueSize int    `json:"value_size,omitempty"`
+	LeaseID   int64  `json:"lease_id,omitempty"`
+	SeqNum    string `json:"seq_num,omitempty"` // 提案的序列号，与等待该提案的请求对应
+	TxnOps    int    `json:"txn_ops,omitempty"` // TXN 的比较和分支操作总数
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import "context"
+
+// RangeStreamer 支持流式范围查询的存储
+//
+// 参数含义与 Store.Range 相同。RangeFunc 按 key 顺序对范围内的每个键调用 fn，
+// 不在内存中物化完整结果；fn 返回 false 时停止。返回读取时的 revision
+type RangeStreamer interface {
+	RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *KeyValue) bool) (int64, error)
+}
+
+// RangeFunc 对 store 执行流式范围查询，store 不支持时退化为 Range
+//
+// 只检查最外层的 store，不沿 Unwrap 链查找：装饰器可能改写 Range 的结果
+// （例如拼接分段 value），越过它直接读取内层会得到错误的结果
+func RangeFunc(ctx context.Context, store Store, key, rangeEnd string, revision int64, fn func(kv *KeyValue) bool) (int64, error) {
+	if rs, ok := store.(RangeStreamer); ok {
+		return rs.RangeFunc(ctx, key, rangeEnd, revision, fn)
+	}
+
+	resp, err := store.Range(ctx, key, rangeEnd, 0, revision)
+	if err != nil {
+		return 0, err
+	}
+	for _, kv := range resp.Kvs {
+		if !fn(kv) {
+			break
+		}
+	}
+	return resp.Revision, nil
+}
+
+// ReverseRangeStreamer 支持按 key 降序流式范围查询的存储
+//
+// 参数含义与 RangeStreamer 相同，ReverseRangeFunc 从范围内最大的 key 开始逆序调用 fn
+type ReverseRangeStreamer interface {
+	ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *KeyValue) bool) (int64, error)
+}
+
+// ReverseRangeFunc 对 store 执行降序流式范围查询，store 不支持时退化为 Range 后逆序遍历
+//
+// 与 RangeFunc 一样只检查最外层的 store
+func ReverseRangeFunc(ctx context.Context, store Store, key, rangeEnd string, revision int64, fn func(kv *KeyValue) bool) (int64, error) {
+	if rs, ok := store.(ReverseRangeStreamer); ok {
+		return rs.ReverseRangeFunc(ctx, key, rangeEnd, revision, fn)
+	}
+
+	resp, err := store.Range(ctx, key, rangeEnd, 0, revision)
+	if err != nil {
+		return 0, err
+	}
+	for i := len(resp.Kvs) - 1; i >= 0; i-- {
+		if !fn(resp.Kvs[i]) {
+			break
+		}
+	}
+	return resp.Revision, nil
+}
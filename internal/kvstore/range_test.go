@@ -0,0 +1,94 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rangeStore 只实现 Range 的存储
+type rangeStore struct {
+	Store
+	kvs []*KeyValue
+}
+
+func (s *rangeStore) Range(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*RangeResponse, error) {
+	return &RangeResponse{Kvs: s.kvs, Count: int64(len(s.kvs)), Revision: 7}, nil
+}
+
+// streamStore 支持流式范围查询的存储
+type streamStore struct {
+	rangeStore
+}
+
+func (s *streamStore) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *KeyValue) bool) (int64, error) {
+	for _, kv := range s.kvs {
+		if !fn(kv) {
+			break
+		}
+	}
+	return 9, nil
+}
+
+func collectKeys(t *testing.T, store Store, max int) ([]string, int64) {
+	var keys []string
+	rev, err := RangeFunc(context.Background(), store, "a", "\x00", 0, func(kv *KeyValue) bool {
+		keys = append(keys, string(kv.Key))
+		return len(keys) < max
+	})
+	assert.NoError(t, err)
+	return keys, rev
+}
+
+func TestRangeFunc(t *testing.T) {
+	kvs := []*KeyValue{{Key: []byte("a")}, {Key: []byte("b")}, {Key: []byte("c")}}
+
+	// 不支持流式查询时退化为 Range
+	keys, rev := collectKeys(t, &rangeStore{kvs: kvs}, 10)
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+	assert.Equal(t, int64(7), rev)
+
+	keys, rev = collectKeys(t, &streamStore{rangeStore{kvs: kvs}}, 10)
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+	assert.Equal(t, int64(9), rev)
+
+	// fn 返回 false 时停止
+	keys, _ = collectKeys(t, &streamStore{rangeStore{kvs: kvs}}, 2)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	keys, _ = collectKeys(t, &rangeStore{kvs: kvs}, 1)
+	assert.Equal(t, []string{"a"}, keys)
+
+	// 装饰器不支持时走装饰器的 Range，不越过它读取内层
+	keys, rev = collectKeys(t, &decorator{Store: &streamStore{rangeStore{kvs: kvs}}}, 10)
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+	assert.Equal(t, int64(7), rev)
+}
+
+func TestReverseRangeFunc(t *testing.T) {
+	kvs := []*KeyValue{{Key: []byte("a")}, {Key: []byte("b")}, {Key: []byte("c")}}
+
+	// 不支持降序流式查询时退化为 Range 后逆序遍历
+	var keys []string
+	rev, err := ReverseRangeFunc(context.Background(), &streamStore{rangeStore{kvs: kvs}}, "a", "\x00", 0, func(kv *KeyValue) bool {
+		keys = append(keys, string(kv.Key))
+		return len(keys) < 2
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c", "b"}, keys)
+	assert.Equal(t, int64(7), rev)
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import "context"
+
+type serializableKey struct{}
+
+// WithSerializable 标记读请求为 serializable：直接读取本节点已应用的状态，不经过 ReadIndex，
+// 结果可能落后于 leader。对应 etcd RangeRequest 的 serializable 选项，也用于内部读取
+func WithSerializable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serializableKey{}, true)
+}
+
+// IsSerializable 返回 ctx 是否由 WithSerializable 标记
+func IsSerializable(ctx context.Context) bool {
+	v, _ := ctx.Value(serializableKey{}).(bool)
+	return v
+}
+
+type keyFilterKey struct{}
+
+// WithKeyFilter 限制读请求只能看到 visible 返回 true 的键，用于按权限过滤列表结果
+//
+// 存储引擎在遍历时检查 key，被隐藏的键像不存在一样跳过：不解码 value，不计入 Count，
+// 也不占用 limit。visible 不能保留传入的 key，引擎可能复用它的内存
+func WithKeyFilter(ctx context.Context, visible func(key []byte) bool) context.Context {
+	return context.WithValue(ctx, keyFilterKey{}, visible)
+}
+
+// KeyFilter 返回 WithKeyFilter 设置的过滤函数，没有设置时返回 nil
+func KeyFilter(ctx context.Context) func(key []byte) bool {
+	visible, _ := ctx.Value(keyFilterKey{}).(func(key []byte) bool)
+	return visible
+}
+
+// FilterKeys 就地删除 kvs 中 ctx 的过滤函数隐藏的键，供先物化结果的引擎使用
+func FilterKeys(ctx context.Context, kvs []*KeyValue) []*KeyValue {
+	visible := KeyFilter(ctx)
+	if visible == nil {
+		return kvs
+	}
+	n := 0
+	for _, kv := range kvs {
+		if visible(kv.Key) {
+			kvs[n] = kv
+			n++
+		}
+	}
+	return kvs[:n]
+}
+
+// MissCacheStats 不存在 key 查询缓存（negative lookup cache）的当前状态，用于导出指标
+type MissCacheStats struct {
+	Entries  int    // 缓存的不存在 key 数
+	Capacity int    // 缓存容量，0 表示未启用
+	Hits     uint64 // 由缓存直接返回不存在的查询总数
+	Misses   uint64 // 未命中缓存、读取存储引擎的查询总数
+}
+
+// ReadCacheStats 读缓存的当前状态，用于导出指标
+type ReadCacheStats struct {
+	Entries       int    // 缓存的 key 数
+	Capacity      int    // 缓存容量，0 表示未启用
+	Hits          uint64 // 由缓存直接返回的查询总数
+	Misses        uint64 // 未命中缓存、读取存储引擎的查询总数
+	Invalidations uint64 // 因写入或删除从缓存中删除的 key 总数
+}
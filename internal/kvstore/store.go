@@ -92,6 +92,7 @@ type Store interface {
 type Commit struct {
 	Data       []string
 	ApplyDoneC chan<- struct{}
+	Index      uint64 // raft index of the last entry in the batch, 0 if unknown
 }
 
 // KV represents a key-value pair
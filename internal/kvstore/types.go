@@ -24,6 +24,7 @@ type KeyValue struct {
 	ModRevision    int64  // 最后修改的 revision
 	Version        int64  // 该键的修改次数（从 1 开始）
 	Lease          int64  // 关联的 lease ID（0 表示无 lease）
+	HLC            uint64 // 最后一次修改提交时的 HLC 时间戳（pkg/hlc，0 表示没有）
 }
 
 // WatchEvent 表示一个 watch 事件
@@ -55,6 +56,22 @@ type WatchOptions struct {
 
 	// Fragment enables splitting large revisions into multiple responses
 	Fragment bool
+
+	// ValueFilter drops events whose value it rejects before they are queued
+	// DELETE events are matched against the deleted value
+	ValueFilter func(value []byte) bool
+}
+
+// MatchValue reports whether ev passes filter, a nil filter matches every event
+func MatchValue(ev WatchEvent, filter func(value []byte) bool) bool {
+	if filter == nil {
+		return true
+	}
+	kv := ev.Kv
+	if ev.Type == EventTypeDelete {
+		kv = ev.PrevKv
+	}
+	return kv != nil && filter(kv.Value)
 }
 
 // WatchFilterType represents watch filter types
@@ -200,6 +217,78 @@ func (l *Lease) Renew(ttl int64) int64 {
 	return l.TTL
 }
 
+// Deadline 返回租约的到期时间
+func (l *Lease) Deadline() time.Time {
+	return l.GrantTime.Add(time.Duration(l.TTL) * time.Second)
+}
+
+// LeaseExpiryStats lease 过期调度的当前状态，用于导出指标
+type LeaseExpiryStats struct {
+	Scheduled int    // 等待到期的 lease 数
+	Backlog   int    // 已到期、等待下一批撤销的 lease 数
+	Revoked   uint64 // 因过期撤销的 lease 总数
+	Failed    uint64 // 撤销失败的过期 lease 总数
+}
+
+// ResourceUsage watch 或 lease 的数量、上限和拒绝次数，用于导出指标，上限为 0 表示不限制
+type ResourceUsage struct {
+	Count                 int    // 当前数量
+	Limit                 int    // 数量上限
+	MaxPerConnection      int    // 单个客户端连接上最多的数量
+	PerConnectionLimit    int    // 每个客户端连接的上限
+	Rejected              uint64 // 因超过 Limit 拒绝的总数
+	RejectedPerConnection uint64 // 因超过 PerConnectionLimit 拒绝的总数
+}
+
+// WatchScavengeStats 清理任务取消的孤立 watch 总数，用于导出指标
+type WatchScavengeStats struct {
+	StreamClosed uint64 // 创建它的 gRPC 流已结束
+	Orphaned     uint64 // 长时间没有消费者
+}
+
+// StorageEngineStats 存储引擎（RocksDB）的内部统计，用于导出指标
+type StorageEngineStats struct {
+	ColumnFamilies     []ColumnFamilyStats // 每个列族的统计
+	BlockCacheUsage    uint64              // block cache 当前占用的字节数
+	BlockCacheCapacity uint64              // block cache 的容量
+
+	// 以下计数来自 RocksDB statistics，只在 rocksdb.statistics 开启时有值
+	Statistics       bool   // 是否开启了 statistics
+	BlockCacheHits   uint64 // block cache 命中次数
+	BlockCacheMisses uint64 // block cache 未命中次数
+	WriteStalls      uint64 // 因写停顿被延迟或阻塞的写入次数
+	WriteStallMicros uint64 // 写停顿的总时间（微秒）
+}
+
+// ColumnFamilyStats 一个列族的文件、压缩和 memtable 统计
+type ColumnFamilyStats struct {
+	Name                   string   // 列族名称，未拆分列族时为 default
+	LevelFiles             []uint64 // 每一层的 SST 文件数，下标为层号
+	PendingCompactionBytes uint64   // 估计还需要压缩的字节数
+	MemtableBytes          uint64   // 活跃和不可变 memtable 占用的字节数
+	ImmutableMemtables     uint64   // 等待刷盘的不可变 memtable 数
+}
+
+// CompressionStats 一个 gRPC 方法按压缩算法和方向累计的消息字节数，用于导出指标
+type CompressionStats struct {
+	Method     string // gRPC 方法全名
+	Compressor string // 客户端选择的压缩算法，未压缩为 identity
+	Direction  string // "in" 为请求，"out" 为响应
+	RawBytes   uint64 // 压缩前的消息字节数
+	WireBytes  uint64 // 压缩后实际传输的消息字节数
+}
+
+// ValueSizeStats 一个 value 大小上限及其拒绝的写入数，用于导出指标
+type ValueSizeStats struct {
+	Prefix   string // 覆盖上限的前缀，默认上限为空
+	Limit    int64  // 允许的最大 value 字节数
+	Rejected uint64 // 因 value 超过上限被拒绝的写入数
+}
+
+// SystemKeyPrefix 内部子系统（mirror、CDC 等）在 store 中持久化状态使用的 key 前缀
+// 这些 key 随 Raft 复制，但不会被 mirror 复制到远端，也不会发布到 CDC
+const SystemKeyPrefix = "__metastore/"
+
 // RaftStatus Raft 状态信息
 type RaftStatus struct {
 	NodeID   uint64 `json:"node_id"`   // 当前节点 ID
@@ -209,3 +298,64 @@ type RaftStatus struct {
 	Applied  uint64 `json:"applied"`   // 已应用的 index
 	Commit   uint64 `json:"commit"`    // 已提交的 index
 }
+
+// MemberStatus 集群成员状态
+// 复制进度（Match、Progress、RecentActive）只在 leader 上可见
+type MemberStatus struct {
+	ID           uint64 `json:"id"`
+	PeerURL      string `json:"peer_url"` // 启动参数中的 peer URL，运行期间加入的成员为空
+	IsLearner    bool   `json:"is_learner"`
+	IsLeader     bool   `json:"is_leader"`
+	Match        uint64 `json:"match"`         // leader 已知的复制位置
+	Progress     string `json:"progress"`      // "StateProbe"/"StateReplicate"/"StateSnapshot"，非 leader 上为空
+	RecentActive bool   `json:"recent_active"` // 最近一个选举周期内与 leader 有通信
+
+	// 本节点与该成员的通信情况，follower 只与 leader 通信
+	LastContact  time.Time     `json:"last_contact"`  // 最近一次收到该成员消息的时间，从未收到时为零值
+	RTT          time.Duration `json:"rtt"`           // leader 估计的心跳往返时间（平滑值）
+	SendFailures uint64        `json:"send_failures"` // 传输层报告发送失败的次数
+	Unreachable  bool          `json:"unreachable"`   // 超过 raft.peer_dead_timeout 没有联系，只在 leader 上判断
+}
+
+// WatchInfo 活跃 watch 的信息
+type WatchInfo struct {
+	ID            int64  `json:"id"`
+	Key           string `json:"key"`
+	RangeEnd      string `json:"range_end"`
+	StartRevision int64  `json:"start_revision"`
+	PrevKV        bool   `json:"prev_kv"`
+	Pending       int    `json:"pending"` // 缓冲中尚未被消费的事件数
+}
+
+// MemberReplaceRequest 替换故障成员的请求
+// 新节点先以 learner 身份加入，追上日志后提升为投票成员，最后移除故障成员
+type MemberReplaceRequest struct {
+	DeadID     uint64 `json:"dead_id"`      // 要替换的故障成员 ID
+	NewID      uint64 `json:"new_id"`       // 新成员 ID（新节点须以 --member-id 该值、--join 启动）
+	PeerURL    string `json:"peer_url"`     // 新成员的 raft peer URL
+	CatchUpLag uint64 `json:"catch_up_lag"` // learner 落后 leader commit 不超过该条数时视为追上 (0 表示完全追上)
+	Force      bool   `json:"force"`        // 故障成员仍处于活跃状态时也继续替换
+}
+
+// MemberReplacePhase 成员替换所处阶段
+type MemberReplacePhase string
+
+const (
+	MemberReplaceAddLearner MemberReplacePhase = "add_learner" // 添加 learner
+	MemberReplaceCatchUp    MemberReplacePhase = "catch_up"    // 等待 learner 通过快照/日志追上
+	MemberReplacePromote    MemberReplacePhase = "promote"     // 提升 learner 为投票成员
+	MemberReplaceRemoveDead MemberReplacePhase = "remove_dead" // 移除故障成员
+	MemberReplaceDone       MemberReplacePhase = "done"        // 替换完成
+	MemberReplaceFailed     MemberReplacePhase = "failed"      // 替换失败
+)
+
+// MemberReplaceProgress 成员替换进度
+type MemberReplaceProgress struct {
+	Phase        MemberReplacePhase `json:"phase"`
+	DeadID       uint64             `json:"dead_id"`
+	NewID        uint64             `json:"new_id"`
+	LearnerMatch uint64             `json:"learner_match"`   // learner 已复制的日志 index
+	LeaderCommit uint64             `json:"leader_commit"`   // leader 已提交的 index
+	Snapshotting bool               `json:"snapshotting"`    // leader 正在向 learner 发送快照
+	Error        string             `json:"error,omitempty"` // 失败原因（仅 failed 阶段）
+}
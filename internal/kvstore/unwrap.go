@@ -0,0 +1,40 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+// Wrapper 由包装另一个 Store 的装饰器实现
+//
+// 装饰器只实现自己改变了行为的方法，MoveLeader、WaitApplied 这类可选能力不再逐层转发，
+// 调用方用 As 沿 Unwrap 链找到真正提供能力的那一层。内层不支持时 As 返回 false，
+// 调用方可以走明确的 "不支持" 分支
+type Wrapper interface {
+	Unwrap() Store
+}
+
+// As 沿 Unwrap 链从外向内查找第一个实现 T 的 Store
+func As[T any](store Store) (T, bool) {
+	for store != nil {
+		if v, ok := store.(T); ok {
+			return v, true
+		}
+		w, ok := store.(Wrapper)
+		if !ok {
+			break
+		}
+		store = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
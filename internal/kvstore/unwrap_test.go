@@ -0,0 +1,57 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// innerStore 提供 ReadAfterWriter 能力的最内层存储
+type innerStore struct {
+	Store
+	AppliedIndex
+}
+
+func (s *innerStore) WaitApplied(ctx context.Context, index uint64) error {
+	return s.Wait(ctx, index)
+}
+
+// decorator 只改变部分方法的装饰器，不转发 ReadAfterWriter
+type decorator struct {
+	Store
+}
+
+func (d *decorator) Unwrap() Store { return d.Store }
+
+func TestAs(t *testing.T) {
+	inner := &innerStore{}
+	wrapped := &decorator{Store: &decorator{Store: inner}}
+
+	// 装饰器自身没有该能力时沿 Unwrap 链找到内层
+	_, ok := Store(wrapped).(ReadAfterWriter)
+	assert.False(t, ok)
+	raw, ok := As[ReadAfterWriter](wrapped)
+	assert.True(t, ok)
+	assert.Same(t, inner, raw)
+
+	// 链上没有任何一层提供能力
+	_, ok = As[ReadAfterWriter](&decorator{Store: &decorator{}})
+	assert.False(t, ok)
+	_, ok = As[ReadAfterWriter](nil)
+	assert.False(t, ok)
+}
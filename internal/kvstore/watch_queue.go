@@ -0,0 +1,123 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"sync"
+	"time"
+)
+
+// WatchSlowTimeout 慢 watcher 在这段时间内没有取走任何排队的事件时被取消
+const WatchSlowTimeout = 5 * time.Second
+
+// WatchQueue 按通知顺序向 watch 的通道投递事件
+//
+// 通道有空位且没有排队的事件时直接发送；通道满时事件进入队列，由一个 goroutine
+// 依次发送。队列不为空时后来的事件也排在队尾，不会越过先到的事件，因此同一个
+// key 的事件按 revision 递增的顺序到达。零值可以直接使用
+type WatchQueue struct {
+	// Timeout 慢 watcher 的超时时间，为 0 时使用 WatchSlowTimeout
+	Timeout time.Duration
+
+	mu       sync.Mutex
+	pending  []WatchEvent
+	draining bool
+	stopped  bool // watch 已取消或超时，不再投递
+}
+
+// Send 投递 ev。通道满时排队后立即返回；排队的事件超时发不出去时调用 onSlow，
+// 调用方在 onSlow 中取消 watch。cancel 关闭后丢弃排队的事件
+func (q *WatchQueue) Send(ch chan<- WatchEvent, cancel <-chan struct{}, ev WatchEvent, onSlow func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		return
+	}
+	if len(q.pending) == 0 && !q.draining {
+		select {
+		case ch <- ev:
+			return
+		case <-cancel:
+			return
+		default:
+		}
+	}
+	q.pending = append(q.pending, ev)
+	if !q.draining {
+		q.draining = true
+		go q.drain(ch, cancel, onSlow)
+	}
+}
+
+// Len 返回排队等待发送的事件数
+func (q *WatchQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+func (q *WatchQueue) drain(ch chan<- WatchEvent, cancel <-chan struct{}, onSlow func()) {
+	timeout := q.Timeout
+	if timeout <= 0 {
+		timeout = WatchSlowTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.draining = false
+			q.mu.Unlock()
+			return
+		}
+		ev := q.pending[0]
+		q.mu.Unlock()
+
+		select {
+		case <-cancel:
+			q.stop()
+			return
+		default:
+		}
+		select {
+		case ch <- ev:
+			q.mu.Lock()
+			q.pending[0] = WatchEvent{}
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+			// 超时从上一次成功发送算起
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(timeout)
+		case <-cancel:
+			q.stop()
+			return
+		case <-timer.C:
+			q.stop()
+			onSlow()
+			return
+		}
+	}
+}
+
+// stop 丢弃排队的事件，之后 Send 直接返回
+func (q *WatchQueue) stop() {
+	q.mu.Lock()
+	q.pending = nil
+	q.stopped = true
+	q.mu.Unlock()
+}
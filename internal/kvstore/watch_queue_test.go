@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchQueueOrder(t *testing.T) {
+	var q WatchQueue
+	ch := make(chan WatchEvent, 2)
+	cancel := make(chan struct{})
+	onSlow := func() { t.Error("watch cancelled as slow") }
+
+	// 通道满之后的事件排队，队列不为空时后来的事件不会越过它们
+	for rev := int64(1); rev <= 50; rev++ {
+		q.Send(ch, cancel, WatchEvent{Revision: rev}, onSlow)
+	}
+	assert.Equal(t, 48, q.Len())
+	for rev := int64(1); rev <= 50; rev++ {
+		select {
+		case ev := <-ch:
+			require.Equal(t, rev, ev.Revision)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for revision %d", rev)
+		}
+		if rev == 25 {
+			q.Send(ch, cancel, WatchEvent{Revision: 51}, onSlow)
+		}
+	}
+	assert.Equal(t, int64(51), (<-ch).Revision)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestWatchQueueSlowWatcher(t *testing.T) {
+	q := WatchQueue{Timeout: 50 * time.Millisecond}
+	ch := make(chan WatchEvent, 1)
+	slow := make(chan struct{})
+
+	q.Send(ch, nil, WatchEvent{Revision: 1}, nil)
+	q.Send(ch, nil, WatchEvent{Revision: 2}, func() { close(slow) })
+	select {
+	case <-slow:
+	case <-time.After(time.Second):
+		t.Fatal("slow watcher not reported")
+	}
+
+	// 超时之后不再投递
+	q.Send(ch, nil, WatchEvent{Revision: 3}, nil)
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, int64(1), (<-ch).Revision)
+	assert.Empty(t, ch)
+}
+
+func TestWatchQueueCancel(t *testing.T) {
+	var q WatchQueue
+	ch := make(chan WatchEvent)
+	cancel := make(chan struct{})
+
+	q.Send(ch, cancel, WatchEvent{Revision: 1}, func() { t.Error("watch cancelled as slow") })
+	assert.Equal(t, 1, q.Len())
+	close(cancel)
+	assert.Eventually(t, func() bool { return q.Len() == 0 }, time.Second, 10*time.Millisecond)
+}
@@ -67,12 +67,11 @@ func (rm *ReadIndexManager) RequestReadIndex(ctx context.Context, readIndex uint
 	rm.totalReadIndexReqs.Add(1)
 	rm.slowPathReads.Add(1)
 
-	// Check if already applied
-	rm.mu.RLock()
-	lastApplied := rm.lastApplied
-	rm.mu.RUnlock()
-
-	if readIndex <= lastApplied {
+	// Check if already applied and register under the same lock, otherwise a
+	// NotifyApplied between the check and the registration would be missed
+	rm.mu.Lock()
+	if readIndex <= rm.lastApplied {
+		rm.mu.Unlock()
 		// Already applied, can read immediately
 		return readIndex, nil
 	}
@@ -89,7 +88,6 @@ func (rm *ReadIndexManager) RequestReadIndex(ctx context.Context, readIndex uint
 	}
 
 	// Register request
-	rm.mu.Lock()
 	rm.pendingReads[requestID] = req
 	rm.mu.Unlock()
 
@@ -0,0 +1,151 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposeBackpressure(t *testing.T) {
+	// 没有 raft 节点消费 proposeC，也没有 commit，管道只会被填满
+	proposeC := make(chan string, 4)
+	m := NewMemory(nil, proposeC, make(chan *kvstore.Commit), make(chan error))
+	m.SetBackpressure(kvstore.Backpressure{QueueThreshold: 0.5, RetryAfter: 2 * time.Second, Timeout: time.Minute})
+
+	// 客户端的 deadline 优先于默认超时
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err := m.PutWithLease(ctx, "a", "1", 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	proposeC <- "queued"
+	_, _, err = m.PutWithLease(context.Background(), "b", "1", 0)
+	require.ErrorIs(t, err, kvstore.ErrTooManyRequests)
+	retryAfter, ok := kvstore.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, retryAfter)
+
+	stats := m.ProposeQueueStats()
+	assert.Equal(t, kvstore.ProposeQueueStats{Queued: 2, Capacity: 4, Pending: 0, Rejected: 1}, stats)
+
+	// 等待 apply 的提案过多
+	<-proposeC
+	<-proposeC
+	m.SetBackpressure(kvstore.Backpressure{MaxPending: 1, RetryAfter: time.Second})
+	m.pendingMu.Lock()
+	m.pendingOps["in-flight"] = make(chan struct{})
+	m.pendingMu.Unlock()
+	_, err = m.Txn(context.Background(), nil, []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("c"), Value: []byte("1")}}, nil)
+	assert.ErrorIs(t, err, kvstore.ErrTooManyRequests)
+	assert.Empty(t, proposeC)
+}
+
+func TestProposeDeadlineStage(t *testing.T) {
+	// 无缓冲且无人消费：请求停在提案阶段
+	m := NewMemory(nil, make(chan string), make(chan *kvstore.Commit), make(chan error))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err := m.PutWithLease(ctx, "a", "1", 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, kvstore.StagePropose, kvstore.Stage(err))
+
+	// 提案被接收但没有 commit：请求停在 apply 阶段，没有 deadline 时使用默认超时
+	proposeC := make(chan string, 4)
+	m = NewMemory(nil, proposeC, make(chan *kvstore.Commit), make(chan error))
+	m.SetBackpressure(kvstore.Backpressure{Timeout: 20 * time.Millisecond})
+	_, err = m.Txn(context.Background(), nil, []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("b"), Value: []byte("1")}}, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, kvstore.StageApply, kvstore.Stage(err))
+	assert.ErrorContains(t, err, "TXN")
+	<-proposeC
+
+	// 取消也会清理等待状态，之后的 apply 不会留下事务结果
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-proposeC
+		cancel()
+	}()
+	err = m.LeaseRevoke(ctx, 1)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, kvstore.StageApply, kvstore.Stage(err))
+
+	m.storeTxnResult("seq-1", &kvstore.TxnResponse{})
+	assert.Equal(t, 0, m.ProposeQueueStats().Pending)
+	assert.Empty(t, m.pendingTxnResults)
+}
+
+func TestProposeRequestTooLarge(t *testing.T) {
+	proposeC := make(chan string, 4)
+	m := NewMemory(nil, proposeC, make(chan *kvstore.Commit), make(chan error))
+	m.SetBackpressure(kvstore.Backpressure{MaxRequestBytes: 1024, Timeout: 20 * time.Millisecond})
+
+	// 超过上限的提案不进入 proposeC，也不计入背压拒绝
+	_, _, err := m.PutWithLease(context.Background(), "big", strings.Repeat("x", 2048), 0)
+	require.ErrorIs(t, err, kvstore.ErrRequestTooLarge)
+	assert.Empty(t, proposeC)
+	assert.Equal(t, kvstore.ProposeQueueStats{Capacity: 4}, m.ProposeQueueStats())
+
+	// 上限以内的提案照常提交
+	_, _, err = m.PutWithLease(context.Background(), "small", "1", 0)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, proposeC, 1)
+}
+
+// leaderChangeNode 能通知 leader 变化的 RaftNode
+type leaderChangeNode struct {
+	readIndexCounter
+	*kvstore.LeaderTracker
+}
+
+func (n *leaderChangeNode) LeaderChanged() <-chan struct{} { return n.Changed() }
+
+func TestProposeLeaderChanged(t *testing.T) {
+	proposeC := make(chan string, 4)
+	m := NewMemory(nil, proposeC, make(chan *kvstore.Commit), make(chan error))
+	m.SetBackpressure(kvstore.Backpressure{Timeout: time.Minute})
+	node := &leaderChangeNode{LeaderTracker: kvstore.NewLeaderTracker()}
+	node.Observe(1)
+	m.SetRaftNode(node, 1)
+
+	// leader 变化时等待 apply 的写入立即失败，而不是等到超时
+	go func() {
+		<-proposeC
+		node.Observe(2)
+	}()
+	_, _, err := m.PutWithLease(context.Background(), "a", "1", 0)
+	require.ErrorIs(t, err, kvstore.ErrLeaderChanged)
+	assert.Equal(t, kvstore.StageApply, kvstore.Stage(err))
+	assert.Equal(t, 0, m.ProposeQueueStats().Pending)
+
+	// 幂等的 lease 撤销自动重新提案，收到第二次提案后才取消
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-proposeC
+		node.Observe(0)
+		<-proposeC
+		cancel()
+	}()
+	err = m.LeaseRevoke(ctx, 1)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, proposeC)
+}
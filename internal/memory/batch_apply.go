@@ -92,7 +92,7 @@ func (m *Memory) applyBatch(ops []RaftOperation) {
 		case "TXN":
 			// 事务操作逐个执行（使用全局锁）
 			for _, op := range currentBatch {
-				txnResp, err := m.MemoryEtcd.applyTxnWithShardLocks(op.Compares, op.ThenOps, op.ElseOps)
+				txnResp, err := m.MemoryEtcd.applyTxnWithShardLocks(op.Compares, op.ThenOps, op.ElseOps, op.HLC)
 				if err != nil {
 					log.Error("Failed to apply TXN operation",
 						zap.Error(err),
@@ -100,15 +100,17 @@ func (m *Memory) applyBatch(ops []RaftOperation) {
 				}
 				// 保存事务结果
 				if op.SeqNum != "" && txnResp != nil {
-					m.pendingMu.Lock()
-					m.pendingTxnResults[op.SeqNum] = txnResp
-					m.pendingMu.Unlock()
+					m.storeTxnResult(op.SeqNum, txnResp)
 				}
 			}
 		case "LEASE_GRANT", "LEASE_REVOKE":
 			// Lease 操作（使用独立的 leaseMu）
 			for _, op := range currentBatch {
-				m.MemoryEtcd.applyLeaseOperationDirect(op.Type, op.LeaseID, op.TTL)
+				m.MemoryEtcd.applyLeaseOperationDirect(op.Type, op.LeaseID, op.TTL, op.HLC)
+			}
+		case "COMPACT":
+			for _, op := range currentBatch {
+				m.MemoryEtcd.applyCompact(op.Revision)
 			}
 		}
 
@@ -163,24 +165,41 @@ func (m *Memory) batchApplyPut(ops []RaftOperation) {
 	}
 
 	// 并行处理每个分片
+	m.applyShards(shardOps, m.batchApplyPutNoLock)
+}
+
+// applyShards 并行处理每个分片的操作，每个分片加锁一次，按顺序执行分片内的操作
+//
+// 设置了线程池时交给线程池（同一个分片总是由同一个 worker 执行），
+// 否则每个分片启动一个 goroutine。返回时所有操作都已完成
+func (m *Memory) applyShards(shardOps map[uint32][]RaftOperation, apply func(*shard, RaftOperation)) {
+	applyShard := func(shardIdx uint32, ops []RaftOperation) {
+		// ✅ 关键优化: 锁定分片一次
+		shard := &m.MemoryEtcd.kvData.shards[shardIdx]
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+
+		for _, op := range ops {
+			apply(shard, op)
+		}
+	}
+
+	if pool := m.applyPool.Load(); pool != nil {
+		for shardIdx, ops := range shardOps {
+			pool.Submit(shardIdx, func() { applyShard(shardIdx, ops) })
+		}
+		pool.Wait()
+		return
+	}
+
 	var wg sync.WaitGroup
 	for shardIdx, ops := range shardOps {
 		wg.Add(1)
-		go func(shardIdx uint32, ops []RaftOperation) {
+		go func() {
 			defer wg.Done()
-
-			// ✅ 关键优化: 锁定分片一次
-			shard := &m.MemoryEtcd.kvData.shards[shardIdx]
-			shard.mu.Lock()
-			defer shard.mu.Unlock()
-
-			// 批量执行 PUT 操作
-			for _, op := range ops {
-				m.batchApplyPutNoLock(shard, op)
-			}
-		}(shardIdx, ops)
+			applyShard(shardIdx, ops)
+		}()
 	}
-
 	wg.Wait()
 }
 
@@ -217,10 +236,12 @@ func (m *Memory) batchApplyPutNoLock(shard *shard, op RaftOperation) {
 		ModRevision:    newRevision,
 		Version:        version,
 		Lease:          op.LeaseID,
+		HLC:            op.HLC,
 	}
 
-	// 4. 写入分片 (已持有锁，直接操作 data)
-	shard.data[key] = kv
+	// 4. 写入分片 (已持有锁，直接操作 data)，并记录到 MVCC 历史
+	m.MemoryEtcd.kvData.setLocked(shard, key, kv)
+	m.MemoryEtcd.recordPut(kv)
 
 	// 5. 关联 lease
 	if op.LeaseID != 0 {
@@ -232,7 +253,7 @@ func (m *Memory) batchApplyPutNoLock(shard *shard, op RaftOperation) {
 	}
 
 	// 6. 通知 watchers
-	m.MemoryEtcd.notifyWatchers(key, kv, kvstore.EventTypePut)
+	m.MemoryEtcd.notifyPut(kv, prevKv)
 }
 
 // batchApplyDelete 批量应用 DELETE 操作
@@ -261,7 +282,7 @@ func (m *Memory) batchApplyDelete(ops []RaftOperation) {
 
 	// 范围删除（串行，锁定所有分片）
 	for _, op := range rangeOps {
-		_, _, _, err := m.MemoryEtcd.deleteDirect(op.Key, op.RangeEnd)
+		_, _, _, err := m.MemoryEtcd.deleteDirect(op.Key, op.RangeEnd, op.HLC)
 		if err != nil {
 			log.Error("Failed to apply DELETE range operation",
 				zap.Error(err),
@@ -282,25 +303,7 @@ func (m *Memory) batchApplyDeleteSingleKey(ops []RaftOperation) {
 	}
 
 	// 并行处理每个分片
-	var wg sync.WaitGroup
-	for shardIdx, ops := range shardOps {
-		wg.Add(1)
-		go func(shardIdx uint32, ops []RaftOperation) {
-			defer wg.Done()
-
-			// ✅ 关键优化: 锁定分片一次
-			shard := &m.MemoryEtcd.kvData.shards[shardIdx]
-			shard.mu.Lock()
-			defer shard.mu.Unlock()
-
-			// 批量执行 DELETE 操作
-			for _, op := range ops {
-				m.batchApplyDeleteNoLock(shard, op)
-			}
-		}(shardIdx, ops)
-	}
-
-	wg.Wait()
+	m.applyShards(shardOps, m.batchApplyDeleteNoLock)
 }
 
 // batchApplyDeleteNoLock 在持有分片锁的情况下执行 DELETE
@@ -316,10 +319,11 @@ func (m *Memory) batchApplyDeleteNoLock(shard *shard, op RaftOperation) {
 	}
 
 	// 生成新 revision
-	m.MemoryEtcd.revision.Add(1)
+	newRevision := m.MemoryEtcd.revision.Add(1)
 
 	// 删除键
-	delete(shard.data, key)
+	m.MemoryEtcd.kvData.deleteLocked(shard, key)
+	m.MemoryEtcd.recordDelete(key, newRevision, 0)
 
 	// 解除 lease 关联
 	if kv.Lease != 0 {
@@ -331,5 +335,5 @@ func (m *Memory) batchApplyDeleteNoLock(shard *shard, op RaftOperation) {
 	}
 
 	// 通知 watchers
-	m.MemoryEtcd.notifyWatchers(key, kv, kvstore.EventTypeDelete)
+	m.MemoryEtcd.notifyDelete(kv, newRevision, op.HLC)
 }
@@ -15,6 +15,8 @@
 package memory
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"metaStore/internal/kvstore"
 	"testing"
@@ -138,7 +140,7 @@ func TestBatchApplyMixed(t *testing.T) {
 
 	// 先写入一些数据供删除
 	for i := 50; i < 80; i++ {
-		m.MemoryEtcd.putDirect(fmt.Sprintf("key-%d", i), "old-value", 0)
+		m.MemoryEtcd.putDirect(fmt.Sprintf("key-%d", i), "old-value", 0, 0)
 	}
 
 	// 30 DELETE
@@ -383,3 +385,189 @@ func TestBatchApplyStressTest(t *testing.T) {
 		t.Errorf("Expected revision %d, got %d", numOps, finalRevision)
 	}
 }
+
+// TestBatchApplyDisabled 测试关闭功能开关 BatchApply 后 commit 中的操作逐个应用
+func TestBatchApplyDisabled(t *testing.T) {
+	commitC := make(chan *kvstore.Commit)
+	m := NewMemory(nil, make(chan string), commitC, make(chan error))
+	m.SetBatchApply(false)
+
+	var data []string
+	for i := 0; i < 10; i++ {
+		b, err := serializeOperation(RaftOperation{Type: "PUT", Key: fmt.Sprintf("key-%d", i), Value: fmt.Sprintf("value-%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, string(b))
+	}
+	b, err := serializeOperation(RaftOperation{Type: "DELETE", Key: "key-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, string(b))
+
+	applyDoneC := make(chan struct{})
+	commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC}
+	select {
+	case <-applyDoneC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("commit was not applied")
+	}
+
+	if _, exists := m.MemoryEtcd.kvData.Get("key-0"); exists {
+		t.Error("key-0 should be deleted")
+	}
+	kv, exists := m.MemoryEtcd.kvData.Get("key-9")
+	if !exists || string(kv.Value) != "value-9" {
+		t.Errorf("key-9: expected value-9, got %v", kv)
+	}
+	if rev := m.MemoryEtcd.revision.Load(); rev != 11 {
+		t.Errorf("Expected revision 11, got %d", rev)
+	}
+}
+
+// TestCommitAppliedIndex 测试 commit 应用后推进 read-after-write token
+func TestCommitAppliedIndex(t *testing.T) {
+	commitC := make(chan *kvstore.Commit)
+	m := NewMemory(nil, make(chan string), commitC, make(chan error))
+
+	b, err := serializeOperation(RaftOperation{Type: "PUT", Key: "key", Value: "value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyDoneC := make(chan struct{})
+	commitC <- &kvstore.Commit{Data: []string{string(b)}, ApplyDoneC: applyDoneC, Index: 7}
+	<-applyDoneC
+
+	if got := m.WriteIndex(); got != 7 {
+		t.Errorf("Expected write index 7, got %d", got)
+	}
+	if err := m.WaitApplied(context.Background(), 7); err != nil {
+		t.Errorf("WaitApplied(7) failed: %v", err)
+	}
+
+	// 尚未应用的 index 等到超时，错误中带有等待的阶段
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = m.WaitApplied(ctx, 8)
+	if !errors.Is(err, context.DeadlineExceeded) || kvstore.Stage(err) != kvstore.StageCatchUp {
+		t.Errorf("Expected deadline exceeded in stage %s, got %v", kvstore.StageCatchUp, err)
+	}
+}
+
+// TestBatchApplyWithPool 测试通过线程池合并应用 commit，同一个 key 的操作按顺序执行
+func TestBatchApplyWithPool(t *testing.T) {
+	commitC := make(chan *kvstore.Commit)
+	m := NewMemory(nil, make(chan string), commitC, make(chan error))
+	pool := kvstore.NewApplyPool(4)
+	defer pool.Close()
+	m.SetApplyPool(pool)
+
+	var data []string
+	for i := 0; i < 100; i++ {
+		b, err := serializeOperation(RaftOperation{Type: "PUT", Key: fmt.Sprintf("key-%d", i%10), Value: fmt.Sprintf("value-%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, string(b))
+	}
+	for i := 0; i < 5; i++ {
+		b, err := serializeOperation(RaftOperation{Type: "DELETE", Key: fmt.Sprintf("key-%d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, string(b))
+	}
+
+	applyDoneC := make(chan struct{})
+	commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC}
+	select {
+	case <-applyDoneC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("commit was not applied")
+	}
+
+	for i := 0; i < 10; i++ {
+		kv, exists := m.MemoryEtcd.kvData.Get(fmt.Sprintf("key-%d", i))
+		if i < 5 {
+			if exists {
+				t.Errorf("key-%d should be deleted", i)
+			}
+			continue
+		}
+		// 最后一次写入 key-i 的是第 90+i 个操作
+		if !exists || string(kv.Value) != fmt.Sprintf("value-%d", 90+i) || kv.Version != 10 {
+			t.Errorf("key-%d: expected value-%d at version 10, got %v", i, 90+i, kv)
+		}
+	}
+	if rev := m.MemoryEtcd.revision.Load(); rev != 105 {
+		t.Errorf("Expected revision 105, got %d", rev)
+	}
+}
+
+// TestBatchApplyWithPoolKeyOrder 测试线程池应用多个 commit 时，每个 key 的写入
+// （包括同一个 commit 中的删除后重建）按 commit 中的顺序生效
+func TestBatchApplyWithPoolKeyOrder(t *testing.T) {
+	commitC := make(chan *kvstore.Commit)
+	m := NewMemory(nil, make(chan string), commitC, make(chan error))
+	pool := kvstore.NewApplyPool(4)
+	defer pool.Close()
+	m.SetApplyPool(pool)
+
+	type state struct {
+		value       string
+		version     int64
+		modRevision int64
+	}
+	want := make(map[string]*state)
+	for c := 0; c < 5; c++ {
+		var ops []RaftOperation
+		for i := 0; i < 60; i++ {
+			ops = append(ops, RaftOperation{Type: "PUT", Key: fmt.Sprintf("key-%d", i%6), Value: fmt.Sprintf("value-%d-%d", c, i)})
+			if i == 30 {
+				ops = append(ops, RaftOperation{Type: "DELETE", Key: "key-0"})
+			}
+		}
+
+		data := make([]string, 0, len(ops))
+		for _, op := range ops {
+			b, err := serializeOperation(op)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, string(b))
+			switch s := want[op.Key]; {
+			case op.Type == "DELETE":
+				delete(want, op.Key)
+			case s == nil:
+				want[op.Key] = &state{value: op.Value, version: 1}
+			default:
+				s.value = op.Value
+				s.version++
+			}
+		}
+
+		applyDoneC := make(chan struct{})
+		commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC, Index: uint64(c + 1)}
+		select {
+		case <-applyDoneC:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("commit %d was not applied", c)
+		}
+
+		for key, s := range want {
+			kv, exists := m.MemoryEtcd.kvData.Get(key)
+			if !exists {
+				t.Fatalf("commit %d: %s is missing", c, key)
+			}
+			if string(kv.Value) != s.value || kv.Version != s.version {
+				t.Errorf("commit %d: %s expected %s at version %d, got %s at version %d",
+					c, key, s.value, s.version, kv.Value, kv.Version)
+			}
+			if kv.ModRevision <= s.modRevision {
+				t.Errorf("commit %d: %s mod revision %d did not advance past %d", c, key, kv.ModRevision, s.modRevision)
+			}
+			s.modRevision = kv.ModRevision
+		}
+	}
+}
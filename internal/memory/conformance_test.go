@@ -0,0 +1,44 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/kvstore/kvstoretest"
+)
+
+func TestConformance(t *testing.T) {
+	t.Run("Direct", func(t *testing.T) {
+		kvstoretest.Run(t, func(t *testing.T) kvstore.Store {
+			return NewMemoryEtcd()
+		})
+	})
+
+	t.Run("Raft", func(t *testing.T) {
+		kvstoretest.Run(t, func(t *testing.T) kvstore.Store {
+			proposeC := make(chan string, 16)
+			commitC := make(chan *kvstore.Commit, 16)
+			errorC := make(chan error)
+			go kvstoretest.Loopback(proposeC, commitC)
+			t.Cleanup(func() {
+				close(proposeC)
+				close(errorC)
+			})
+			return NewMemory(nil, proposeC, commitC, errorC)
+		})
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/mvcc"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// recordPut 把一次写入记录到 MVCC 历史
+//
+// kvData 只保存最新值，历史版本由 m.history 保存，
+// 用于按 revision 读取、Compact 以及从旧 revision 开始的 watch。
+func (m *MemoryEtcd) recordPut(kv *kvstore.KeyValue) {
+	m.history.PutAt(mvcc.Revision{Main: kv.ModRevision}, (*mvcc.KeyValue)(kv))
+}
+
+// recordDelete 把一次删除记录到 MVCC 历史
+//
+// 同一个 DeleteRange 删除的多个键共享 revision，用 sub 区分。
+func (m *MemoryEtcd) recordDelete(key string, revision, sub int64) {
+	m.history.DeleteAt(mvcc.Revision{Main: revision, Sub: sub}, []byte(key))
+}
+
+// rangeAt 从 MVCC 历史中读取指定 revision 的数据，ctx 的 key 过滤函数隐藏的键不计入 limit
+func (m *MemoryEtcd) rangeAt(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
+	visible := kvstore.KeyFilter(ctx)
+
+	var kvs []*mvcc.KeyValue
+
+	if rangeEnd == "" {
+		kv, err := m.history.Get([]byte(key), revision)
+		switch err {
+		case nil:
+			kvs = append(kvs, kv)
+		case mvcc.ErrKeyNotFound:
+		default:
+			return nil, err
+		}
+	} else {
+		// "\x00" 表示 key 之后的所有键，对应 mvcc 的 nil end
+		var end []byte
+		if rangeEnd != "\x00" {
+			end = []byte(rangeEnd)
+		}
+		// 多取一条用于判断 more
+		var err error
+		// 有过滤函数时无法预知需要读取多少条，读取完整范围
+		queryLimit := limit
+		if visible != nil {
+			queryLimit = 0
+		} else if limit > 0 {
+			queryLimit = limit + 1
+		}
+		kvs, _, err = m.history.Range([]byte(key), end, revision, queryLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if visible != nil {
+		n := 0
+		for _, kv := range kvs {
+			if visible(kv.Key) {
+				kvs[n] = kv
+				n++
+			}
+		}
+		kvs = kvs[:n]
+	}
+
+	more := false
+	if limit > 0 && int64(len(kvs)) > limit {
+		kvs = kvs[:limit]
+		more = true
+	}
+
+	result := make([]*kvstore.KeyValue, len(kvs))
+	for i, kv := range kvs {
+		result[i] = (*kvstore.KeyValue)(kv)
+	}
+
+	return &kvstore.RangeResponse{
+		Kvs:      result,
+		More:     more,
+		Count:    int64(len(result)),
+		Revision: m.revision.Load(),
+	}, nil
+}
+
+// KeyHistory 按 revision 从新到旧返回 key 在 MVCC 历史中保留的版本，删除以墓碑表示
+func (m *MemoryEtcd) KeyHistory(ctx context.Context, key string, limit int64) ([]*kvstore.KeyValue, int64, error) {
+	kvs, err := m.history.History([]byte(key), limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 读取版本之后再取压缩边界，期间发生的压缩只会让边界偏大，不会漏报丢失的版本
+	compacted := m.history.CompactedRevision()
+
+	result := make([]*kvstore.KeyValue, len(kvs))
+	for i, kv := range kvs {
+		result[i] = (*kvstore.KeyValue)(kv)
+	}
+	return result, compacted, nil
+}
+
+// historyEvents 返回 [key, rangeEnd) 内从 startRevision 开始发生的事件
+// MVCC 历史已被压缩时从事件日志中读取，两者都不完整时返回 mvcc.ErrCompacted
+func (m *MemoryEtcd) historyEvents(key, rangeEnd string, startRevision int64) ([]kvstore.WatchEvent, error) {
+	match := func(k []byte) bool {
+		return m.matchWatch(string(k), key, rangeEnd)
+	}
+	events, err := m.history.Events(startRevision, match)
+	if err == mvcc.ErrCompacted {
+		events, err = m.events.Events(startRevision, match)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]kvstore.WatchEvent, len(events))
+	for i, ev := range events {
+		result[i] = kvstore.WatchEvent{
+			Type:     kvstore.EventType(ev.Type),
+			Kv:       (*kvstore.KeyValue)(ev.Kv),
+			PrevKv:   (*kvstore.KeyValue)(ev.PrevKv),
+			Revision: ev.Kv.ModRevision,
+		}
+	}
+	return result, nil
+}
+
+// applyCompact 应用 Raft 提交的 COMPACT 操作
+//
+// 重新提案或并发的压缩可能已经覆盖了 revision，此时不做任何事。
+func (m *MemoryEtcd) applyCompact(revision int64) {
+	err := m.history.Compact(revision)
+	switch err {
+	case nil:
+		log.Info("Compacted history",
+			zap.Int64("revision", revision),
+			zap.String("component", "storage-memory"))
+	case mvcc.ErrCompacted:
+		log.Debug("Revision already compacted",
+			zap.Int64("revision", revision),
+			zap.String("component", "storage-memory"))
+	default:
+		log.Error("Failed to apply COMPACT operation",
+			zap.Error(err),
+			zap.Int64("revision", revision),
+			zap.String("component", "storage-memory"))
+	}
+}
+
+// restoreHistory 从快照恢复后重建 MVCC 历史
+//
+// 快照只包含每个键的最新值，早于快照 revision 的历史视为已压缩。
+func (m *MemoryEtcd) restoreHistory(kvData map[string]*kvstore.KeyValue, revision int64) {
+	kvs := make([]*mvcc.KeyValue, 0, len(kvData))
+	for _, kv := range kvData {
+		kvs = append(kvs, (*mvcc.KeyValue)(kv))
+	}
+	m.history.Restore(kvs, revision)
+	m.events.Reset(revision)
+}
+
+// SetWatchHistory 设置事件日志的容量和保留时间，与 MVCC 压缩无关
+// maxEvents 为 0 时不保留事件
+func (m *MemoryEtcd) SetWatchHistory(maxEvents int, retention time.Duration) {
+	m.events.SetLimits(maxEvents, retention)
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"metaStore/internal/kvstore"
+	"metaStore/internal/mvcc"
+	"testing"
+)
+
+// TestRangeAtRevision 测试按历史 revision 读取
+func TestRangeAtRevision(t *testing.T) {
+	m := NewMemoryEtcd()
+	ctx := context.Background()
+
+	m.PutWithLease(ctx, "a", "v1", 0)
+	m.PutWithLease(ctx, "a", "v2", 0)
+	m.DeleteRange(ctx, "a", "")
+
+	resp, err := m.Range(ctx, "a", "", 0, 1)
+	if err != nil {
+		t.Fatalf("Range at 1 failed: %v", err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v1" {
+		t.Errorf("Range at 1 = %v, want v1", resp.Kvs)
+	}
+
+	resp, err = m.Range(ctx, "a", "\x00", 0, 2)
+	if err != nil {
+		t.Fatalf("Range at 2 failed: %v", err)
+	}
+	if len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v2" {
+		t.Errorf("Range at 2 = %v, want v2", resp.Kvs)
+	}
+
+	resp, err = m.Range(ctx, "a", "", 0, 0)
+	if err != nil {
+		t.Fatalf("Range at current failed: %v", err)
+	}
+	if len(resp.Kvs) != 0 {
+		t.Errorf("Range at current = %v, want empty", resp.Kvs)
+	}
+
+	if _, err := m.Range(ctx, "a", "", 0, 10); !errors.Is(err, mvcc.ErrFutureRevision) {
+		t.Errorf("Range at future revision = %v, want ErrFutureRevision", err)
+	}
+}
+
+// TestCompactHistory 测试 Compact 之后旧 revision 不可读
+func TestCompactHistory(t *testing.T) {
+	m := NewMemoryEtcd()
+	ctx := context.Background()
+
+	m.PutWithLease(ctx, "a", "v1", 0)
+	m.PutWithLease(ctx, "b", "v1", 0)
+	m.PutWithLease(ctx, "b", "v2", 0)
+
+	if err := m.Compact(ctx, 2); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	if _, err := m.Range(ctx, "a", "", 0, 1); !errors.Is(err, mvcc.ErrCompacted) {
+		t.Errorf("Range at compacted revision = %v, want ErrCompacted", err)
+	}
+
+	// 压缩点仍然可读
+	resp, err := m.Range(ctx, "a", "\x00", 0, 2)
+	if err != nil {
+		t.Fatalf("Range at 2 failed: %v", err)
+	}
+	if len(resp.Kvs) != 2 {
+		t.Errorf("Range at 2 returned %d keys, want 2", len(resp.Kvs))
+	}
+
+	if err := m.Compact(ctx, 2); !errors.Is(err, mvcc.ErrCompacted) {
+		t.Errorf("Compact twice = %v, want ErrCompacted", err)
+	}
+}
+
+// TestCompactReplicated 测试 Compact 通过 Raft 提交，所有副本压缩到同一个 revision
+func TestCompactReplicated(t *testing.T) {
+	proposeC := make(chan string, 16)
+	leaderC := make(chan *kvstore.Commit)
+	followerC := make(chan *kvstore.Commit)
+	leader := NewMemory(nil, proposeC, leaderC, make(chan error))
+	follower := NewMemory(nil, make(chan string), followerC, make(chan error))
+
+	// 每个提案按相同顺序提交给两个副本
+	go func() {
+		for data := range proposeC {
+			for _, commitC := range []chan *kvstore.Commit{followerC, leaderC} {
+				applyDoneC := make(chan struct{})
+				commitC <- &kvstore.Commit{Data: []string{data}, ApplyDoneC: applyDoneC}
+				<-applyDoneC
+			}
+		}
+	}()
+	defer close(proposeC)
+
+	ctx := context.Background()
+	for _, v := range []string{"v1", "v2", "v3"} {
+		if _, _, err := leader.PutWithLease(ctx, "a", v, 0); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	if err := leader.Compact(ctx, 2); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	for name, m := range map[string]*Memory{"leader": leader, "follower": follower} {
+		if rev := m.MemoryEtcd.history.CompactedRevision(); rev != 2 {
+			t.Errorf("%s compacted revision = %d, want 2", name, rev)
+		}
+		if _, err := m.Range(ctx, "a", "", 0, 1); !errors.Is(err, mvcc.ErrCompacted) {
+			t.Errorf("%s Range at compacted revision = %v, want ErrCompacted", name, err)
+		}
+	}
+
+	// 必然失败的压缩不提案
+	if err := leader.Compact(ctx, 2); !errors.Is(err, mvcc.ErrCompacted) {
+		t.Errorf("Compact twice = %v, want ErrCompacted", err)
+	}
+	if err := leader.Compact(ctx, 10); !errors.Is(err, mvcc.ErrFutureRevision) {
+		t.Errorf("Compact future revision = %v, want ErrFutureRevision", err)
+	}
+}
+
+// TestWatchReplaysHistory 测试从旧 revision 开始的 watch 回放历史事件
+func TestWatchReplaysHistory(t *testing.T) {
+	m := NewMemoryEtcd()
+	ctx := context.Background()
+
+	m.PutWithLease(ctx, "a", "v1", 0)
+	m.PutWithLease(ctx, "a", "v2", 0)
+	m.DeleteRange(ctx, "a", "")
+
+	ch, err := m.WatchWithOptions("a", "", 2, 1, &kvstore.WatchOptions{PrevKV: true})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	m.PutWithLease(ctx, "a", "v3", 0)
+
+	want := []struct {
+		typ   kvstore.EventType
+		rev   int64
+		value string
+	}{
+		{kvstore.EventTypePut, 2, "v2"},
+		{kvstore.EventTypeDelete, 3, ""},
+		{kvstore.EventTypePut, 4, "v3"},
+	}
+	for i, w := range want {
+		ev := <-ch
+		if ev.Type != w.typ || ev.Revision != w.rev || string(ev.Kv.Value) != w.value {
+			t.Errorf("event %d = %v@%d %q, want %v@%d %q", i, ev.Type, ev.Revision, ev.Kv.Value, w.typ, w.rev, w.value)
+		}
+	}
+	select {
+	case ev := <-ch:
+		t.Errorf("unexpected event %v@%d", ev.Type, ev.Revision)
+	default:
+	}
+
+	m.Compact(ctx, 3)
+	if _, err := m.WatchWithOptions("a", "", 1, 2, nil); !errors.Is(err, mvcc.ErrCompacted) {
+		t.Errorf("Watch from compacted revision = %v, want ErrCompacted", err)
+	}
+}
+
+// TestWatchResumesFromEventLog 测试 MVCC 历史被压缩后从事件日志恢复 watch
+func TestWatchResumesFromEventLog(t *testing.T) {
+	m := NewMemoryEtcd()
+	m.SetWatchHistory(3, 0)
+	ctx := context.Background()
+
+	m.PutWithLease(ctx, "a", "v1", 0)
+	m.PutWithLease(ctx, "b", "v2", 0)
+	m.PutWithLease(ctx, "a", "v3", 0)
+	m.PutWithLease(ctx, "a", "v4", 0)
+	m.Compact(ctx, 3)
+
+	ch, err := m.WatchWithOptions("a", "", 2, 1, &kvstore.WatchOptions{PrevKV: true})
+	if err != nil {
+		t.Fatalf("Watch from revision kept by the event log failed: %v", err)
+	}
+	for _, want := range []string{"v3", "v4"} {
+		ev := <-ch
+		if string(ev.Kv.Value) != want {
+			t.Errorf("event value = %q, want %q", ev.Kv.Value, want)
+		}
+	}
+
+	// 日志只保留最近 3 个事件，revision 1 已经被丢弃
+	if _, err := m.WatchWithOptions("a", "", 1, 2, nil); !errors.Is(err, mvcc.ErrCompacted) {
+		t.Errorf("Watch from revision dropped by the event log = %v, want ErrCompacted", err)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"metaStore/pkg/hlc"
+)
+
+// SetClock 设置本节点的 HLC，未设置时使用默认最大偏差的时钟
+func (m *MemoryEtcd) SetClock(clock *hlc.Clock) {
+	m.clock.Store(clock)
+}
+
+// CurrentHLC 返回最后应用的操作的 HLC
+func (m *MemoryEtcd) CurrentHLC() uint64 {
+	return uint64(m.hlc.Last())
+}
+
+// ObserveHLC 把外部的 HLC 合并进本节点时钟
+func (m *MemoryEtcd) ObserveHLC(ts uint64) error {
+	return m.clock.Load().Update(hlc.Timestamp(ts))
+}
+
+// commitHLC 按提交顺序确定操作的 HLC 并合并进本节点时钟
+//
+// 其它节点提案的时间戳超前本地时钟过多时只计入偏差统计，已提交的操作仍使用它，
+// 保证所有副本的结果相同
+func (m *MemoryEtcd) commitHLC(proposed uint64) uint64 {
+	ts := m.hlc.Commit(hlc.Timestamp(proposed))
+	m.clock.Load().Update(ts)
+	return uint64(ts)
+}
+
+// nextHLC 不经过 Raft 的写入直接用本地时钟分配 HLC
+func (m *MemoryEtcd) nextHLC() uint64 {
+	return uint64(m.hlc.Commit(m.clock.Load().Now()))
+}
@@ -22,10 +22,12 @@ import (
 	"fmt"
 	"metaStore/internal/kvstore"
 	"metaStore/internal/lease"
+	"metaStore/internal/mvcc"
+	"metaStore/pkg/hlc"
 	"metaStore/pkg/log"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
 	"go.etcd.io/raft/v3/raftpb"
@@ -38,6 +40,10 @@ type RaftNode interface {
 	TransferLeadership(targetID uint64) error
 	LeaseManager() *lease.LeaseManager
 	ReadIndexManager() *lease.ReadIndexManager
+	ReadIndex(ctx context.Context) error
+	MoveLeader(ctx context.Context) (uint64, error)
+	ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error
+	Members() []kvstore.MemberStatus
 }
 
 // Memory 集成了 Raft 共识的 etcd 兼容存储
@@ -57,16 +63,33 @@ type Memory struct {
 	// Raft 节点引用（用于获取状态信息）
 	raftNode RaftNode
 	nodeID   uint64
+
+	// 提案管道背压
+	backpressure atomic.Pointer[kvstore.Backpressure]
+	rejected     atomic.Uint64
+
+	// 设置后提案按优先级进入该队列，不再使用 proposeC
+	queue *kvstore.ProposeQueue
+
+	// 功能开关 BatchApply，关闭时逐个应用 commit 中的操作
+	batchApply atomic.Bool
+
+	// 合并应用时按分片并行执行写操作的线程池，未设置时每个分片启动一个 goroutine
+	applyPool atomic.Pointer[kvstore.ApplyPool]
+
+	// 状态机应用到的 raft index，用于 read-after-write token
+	applied kvstore.AppliedIndex
 }
 
 // RaftOperation 表示通过 Raft 提交的操作
 type RaftOperation struct {
-	Type     string `json:"type"`      // "PUT", "DELETE", "LEASE_GRANT", "LEASE_REVOKE", "TXN"
+	Type     string `json:"type"`      // "PUT", "DELETE", "LEASE_GRANT", "LEASE_REVOKE", "TXN", "COMPACT"
 	Key      string `json:"key"`
 	Value    string `json:"value"`
 	LeaseID  int64  `json:"lease_id"`
 	RangeEnd string `json:"range_end"`
 	SeqNum   string `json:"seq_num"`   // 用于同步等待的序列号
+	HLC      uint64 `json:"hlc"`       // 提案节点分配的 HLC，apply 时按提交顺序调整
 
 	// Lease 操作
 	TTL int64 `json:"ttl"`
@@ -75,6 +98,9 @@ type RaftOperation struct {
 	Compares   []kvstore.Compare `json:"compares,omitempty"`
 	ThenOps    []kvstore.Op      `json:"then_ops,omitempty"`
 	ElseOps    []kvstore.Op      `json:"else_ops,omitempty"`
+
+	// Compact 操作
+	Revision int64 `json:"revision,omitempty"`
 }
 
 // NewMemory 创建集成 Raft 的 etcd 兼容存储
@@ -86,6 +112,8 @@ func NewMemory(snapshotter *snap.Snapshotter, proposeC chan<- string, commitC <-
 		pendingOps:        make(map[string]chan struct{}),
 		pendingTxnResults: make(map[string]*kvstore.TxnResponse),
 	}
+	m.SetBackpressure(kvstore.DefaultBackpressure)
+	m.batchApply.Store(true)
 
 	// 从快照恢复
 	snapshot, err := m.loadSnapshot()
@@ -100,6 +128,7 @@ func NewMemory(snapshotter *snap.Snapshotter, proposeC chan<- string, commitC <-
 		if err := m.recoverFromSnapshot(snapshot.Data); err != nil {
 			log.Fatal("Failed to recover from snapshot", zap.Error(err), zap.String("component", "storage-memory"))
 		}
+		m.applied.Advance(snapshot.Metadata.Index)
 	}
 
 	// 启动 commit 处理
@@ -108,19 +137,161 @@ func NewMemory(snapshotter *snap.Snapshotter, proposeC chan<- string, commitC <-
 	return m
 }
 
-func (m *Memory) propose(ctx context.Context, data string) error {
+func (m *Memory) propose(ctx context.Context, opType, data string) error {
+	// 超过单条消息上限的提案会卡住复制，提交之前拒绝
+	if err := m.backpressure.Load().CheckSize(len(data)); err != nil {
+		return err
+	}
+
+	// 管道饱和时立即拒绝，而不是让请求排队直到超时
+	m.pendingMu.RLock()
+	pending := len(m.pendingOps)
+	m.pendingMu.RUnlock()
+	queued, capacity := len(m.proposeC), cap(m.proposeC)
+	priority := kvstore.PriorityOf(ctx)
+	if opType == "LEASE_REVOKE" {
+		// lease 到期撤销不能排在积压的写入后面，否则 key 会比 TTL 存活得更久
+		priority = kvstore.PriorityHigh
+	}
+	if m.queue != nil {
+		queued, capacity = m.queue.Len(), m.queue.Cap()
+		if priority == kvstore.PriorityHigh {
+			// 高优先级的提案不受占用比例限制，队列中超过阈值的部分留给它们
+			queued = 0
+		}
+	}
+	if err := m.backpressure.Load().Check(queued, capacity, pending); err != nil {
+		m.rejected.Add(1)
+		return err
+	}
+
+	// 提案带上来源前端，批量提案器按来源分队列
+	proposal := kvstore.TagProposal(kvstore.Origin(ctx), data)
+	if m.queue != nil {
+		err := m.queue.Push(ctx, priority, proposal)
+		switch {
+		case errors.Is(err, kvstore.ErrTooManyRequests):
+			m.rejected.Add(1)
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return &kvstore.StageError{Op: opType, Stage: kvstore.StagePropose, Err: err}
+		}
+		return err
+	}
 
 	// 向后兼容：使用原始 proposeC
 	select {
-	case m.proposeC <- data:
+	case m.proposeC <- proposal:
 		return nil
-	case <-time.After(30 * time.Second):
-		return fmt.Errorf("timeout proposing operation")
 	case <-ctx.Done():
-		return ctx.Err()
+		return &kvstore.StageError{Op: opType, Stage: kvstore.StagePropose, Err: ctx.Err()}
 	}
 }
 
+// proposeAndWait 提案 op 并等待本节点 apply 完成
+//
+// ctx 的 deadline（没有时使用默认超时）覆盖提案和等待 apply 两个阶段。放弃等待时
+// 清理等待通道，apply 不会再为该请求保存事务结果。leader 变化时立即返回
+// ErrLeaderChanged，幂等的操作则在 deadline 之前重新提案
+func (m *Memory) proposeAndWait(ctx context.Context, op *RaftOperation) error {
+	ctx, cancel := m.backpressure.Load().WithTimeout(ctx)
+	defer cancel()
+
+	// 记录提案大小分布，重新提案不重复记录
+	switch op.Type {
+	case "PUT":
+		kvstore.ObservePut(op.Value)
+	case "TXN":
+		kvstore.ObserveTxn(op.Compares, op.ThenOps, op.ElseOps)
+	}
+
+	for {
+		err := m.proposeOnce(ctx, op)
+		if !errors.Is(err, kvstore.ErrLeaderChanged) || !idempotentOp(op.Type) {
+			return err
+		}
+		log.Debug("Leader changed, proposing again",
+			zap.String("op", op.Type),
+			zap.String("seq", op.SeqNum),
+			zap.String("component", "storage-memory"))
+	}
+}
+
+// idempotentOp 返回操作重复 apply 是否与 apply 一次效果相同，leader 变化后可以重新提案
+func idempotentOp(opType string) bool {
+	// 撤销已经不存在的 lease 不做任何事；重复授予会重置 lease 关联的 key
+	// 压缩到已经压缩过的 revision 同样不做任何事
+	return opType == "LEASE_REVOKE" || opType == "COMPACT"
+}
+
+// proposeOnce 使用新的序列号提案 op 并等待 apply
+func (m *Memory) proposeOnce(ctx context.Context, op *RaftOperation) error {
+	// 生成唯一序列号
+	m.mu.Lock()
+	m.seqNum++
+	op.SeqNum = fmt.Sprintf("seq-%d", m.seqNum)
+	m.mu.Unlock()
+	op.HLC = uint64(m.MemoryEtcd.clock.Load().Now())
+
+	// 序列化（使用 Protobuf 优化）
+	data, err := serializeOperation(*op)
+	if err != nil {
+		return err
+	}
+
+	// 创建等待通道
+	waitCh := make(chan struct{})
+	m.pendingMu.Lock()
+	m.pendingOps[op.SeqNum] = waitCh
+	m.pendingMu.Unlock()
+
+	// 提案之前获取，避免错过提案期间发生的 leader 变化
+	leaderChanged := m.leaderChanged()
+	if err := m.propose(ctx, op.Type, string(data)); err != nil {
+		m.cancelPending(op.SeqNum)
+		return fmt.Errorf("failed to propose %s operation: %w", op.Type, err)
+	}
+
+	// 等待 Raft 提交完成
+	select {
+	case <-waitCh:
+		return nil
+	case <-leaderChanged:
+		// 提案可能随旧 leader 一起丢失
+		select {
+		case <-waitCh:
+			return nil
+		default:
+		}
+		m.cancelPending(op.SeqNum)
+		return &kvstore.StageError{Op: op.Type, Stage: kvstore.StageApply, Err: kvstore.ErrLeaderChanged}
+	case <-ctx.Done():
+		// 同时完成时以 apply 结果为准
+		select {
+		case <-waitCh:
+			return nil
+		default:
+		}
+		m.cancelPending(op.SeqNum)
+		return &kvstore.StageError{Op: op.Type, Stage: kvstore.StageApply, Err: ctx.Err()}
+	}
+}
+
+// leaderChanged 返回在下一次 leader 变化时关闭的 channel，Raft 节点不支持时返回 nil
+func (m *Memory) leaderChanged() <-chan struct{} {
+	if n, ok := m.raftNode.(kvstore.LeaderChangeNotifier); ok {
+		return n.LeaderChanged()
+	}
+	return nil
+}
+
+// cancelPending 请求放弃等待时清理等待通道和已经保存的事务结果
+func (m *Memory) cancelPending(seqNum string) {
+	m.pendingMu.Lock()
+	delete(m.pendingOps, seqNum)
+	delete(m.pendingTxnResults, seqNum)
+	m.pendingMu.Unlock()
+}
+
 // readCommits 从 Raft commitC 读取并应用操作
 //
 // ✅ 性能优化 (Phase 2): 批量 Apply
@@ -148,10 +319,13 @@ func (m *Memory) readCommits(commitC <-chan *kvstore.Commit, errorC <-chan error
 				if err := m.recoverFromSnapshot(snapshot.Data); err != nil {
 					log.Fatal("Failed to recover from reloaded snapshot", zap.Error(err), zap.String("component", "storage-memory"))
 				}
+				m.applied.Advance(snapshot.Metadata.Index)
 			}
 			continue
 		}
 
+		m.applied.Begin(commit.Index)
+
 		// ✅ Phase 2 优化: 收集所有操作，批量应用
 		var allOps []RaftOperation
 
@@ -165,14 +339,20 @@ func (m *Memory) readCommits(commitC <-chan *kvstore.Commit, errorC <-chan error
 				continue
 			}
 
+			op.HLC = m.MemoryEtcd.commitHLC(op.HLC)
 			allOps = append(allOps, op)
 		}
 
 		// ✅ 批量应用所有操作 (Phase 2 核心优化)
-		if len(allOps) > 0 {
+		if m.batchApply.Load() {
 			m.applyBatch(allOps)
+		} else {
+			for _, op := range allOps {
+				m.applyOperation(op)
+			}
 		}
 
+		m.applied.Advance(commit.Index)
 		close(commit.ApplyDoneC)
 	}
 
@@ -200,7 +380,7 @@ func (m *Memory) applyOperation(op RaftOperation) {
 	switch op.Type {
 	case "PUT":
 		// ✅ 使用无锁版本 (ShardedMap 内部加锁)
-		_, _, err := m.MemoryEtcd.putDirect(op.Key, op.Value, op.LeaseID)
+		_, _, err := m.MemoryEtcd.putDirect(op.Key, op.Value, op.LeaseID, op.HLC)
 		if err != nil {
 			log.Error("Failed to apply PUT operation",
 				zap.Error(err),
@@ -210,7 +390,7 @@ func (m *Memory) applyOperation(op RaftOperation) {
 
 	case "DELETE":
 		// ✅ 使用无锁版本
-		_, _, _, err := m.MemoryEtcd.deleteDirect(op.Key, op.RangeEnd)
+		_, _, _, err := m.MemoryEtcd.deleteDirect(op.Key, op.RangeEnd, op.HLC)
 		if err != nil {
 			log.Error("Failed to apply DELETE operation",
 				zap.Error(err),
@@ -221,15 +401,15 @@ func (m *Memory) applyOperation(op RaftOperation) {
 
 	case "LEASE_GRANT":
 		// ✅ 使用独立的 lease 操作 (leaseMu 锁)
-		m.MemoryEtcd.applyLeaseOperationDirect("LEASE_GRANT", op.LeaseID, op.TTL)
+		m.MemoryEtcd.applyLeaseOperationDirect("LEASE_GRANT", op.LeaseID, op.TTL, op.HLC)
 
 	case "LEASE_REVOKE":
 		// ✅ 使用独立的 lease 操作
-		m.MemoryEtcd.applyLeaseOperationDirect("LEASE_REVOKE", op.LeaseID, 0)
+		m.MemoryEtcd.applyLeaseOperationDirect("LEASE_REVOKE", op.LeaseID, 0, op.HLC)
 
 	case "TXN":
 		// ✅ 使用细粒度分片锁 (只锁涉及的分片)
-		txnResp, err := m.MemoryEtcd.applyTxnWithShardLocks(op.Compares, op.ThenOps, op.ElseOps)
+		txnResp, err := m.MemoryEtcd.applyTxnWithShardLocks(op.Compares, op.ThenOps, op.ElseOps, op.HLC)
 		if err != nil {
 			log.Error("Failed to apply TXN operation",
 				zap.Error(err),
@@ -240,11 +420,12 @@ func (m *Memory) applyOperation(op RaftOperation) {
 		}
 		// 保存事务结果供客户端读取
 		if op.SeqNum != "" && txnResp != nil {
-			m.pendingMu.Lock()
-			m.pendingTxnResults[op.SeqNum] = txnResp
-			m.pendingMu.Unlock()
+			m.storeTxnResult(op.SeqNum, txnResp)
 		}
 
+	case "COMPACT":
+		m.MemoryEtcd.applyCompact(op.Revision)
+
 	default:
 		log.Warn("Unknown operation type",
 			zap.String("type", op.Type),
@@ -262,6 +443,16 @@ func (m *Memory) applyOperation(op RaftOperation) {
 	}
 }
 
+// storeTxnResult 保存事务结果供等待的客户端读取。只有本节点上仍在等待的请求才保存，
+// 其它节点提交的事务和已经超时的请求不会留下结果
+func (m *Memory) storeTxnResult(seqNum string, txnResp *kvstore.TxnResponse) {
+	m.pendingMu.Lock()
+	if _, waiting := m.pendingOps[seqNum]; waiting {
+		m.pendingTxnResults[seqNum] = txnResp
+	}
+	m.pendingMu.Unlock()
+}
+
 // applyLegacyOp 应用旧格式的操作（向后兼容）
 func (m *Memory) applyLegacyOp(data string) {
 	var dataKv kvstore.KV
@@ -273,69 +464,26 @@ func (m *Memory) applyLegacyOp(data string) {
 	}
 
 	// ✅ 使用无锁版本 (Phase 1 优化)
-	m.MemoryEtcd.putDirect(dataKv.Key, dataKv.Val, 0)
+	m.MemoryEtcd.putDirect(dataKv.Key, dataKv.Val, 0, 0)
 }
 
 // PutWithLease 存储键值对（通过 Raft）
 func (m *Memory) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
-	// 生成唯一序列号
-	m.mu.Lock()
-	m.seqNum++
-	seqNum := fmt.Sprintf("seq-%d", m.seqNum)
-	m.mu.Unlock()
-
-	// 创建等待通道
-	waitCh := make(chan struct{})
-	m.pendingMu.Lock()
-	m.pendingOps[seqNum] = waitCh
-	m.pendingMu.Unlock()
+	// 提交到 Raft 之前读取 prevKv，apply 之后读到的是本次写入的值
+	prevKv, _ := m.MemoryEtcd.kvData.Get(key)
 
 	op := RaftOperation{
 		Type:    "PUT",
 		Key:     key,
 		Value:   value,
 		LeaseID: leaseID,
-		SeqNum:  seqNum,
 	}
-
-	// 序列化并 propose（使用 Protobuf 优化）
-	data, err := serializeOperation(op)
-	if err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
+	if err := m.proposeAndWait(ctx, &op); err != nil {
 		return 0, nil, err
 	}
 
-	// 发送提案（使用 BatchProposer 如果可用）
-	if err := m.propose(ctx, string(data)); err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return 0, nil, fmt.Errorf("failed to propose PUT operation: %w", err)
-	}
-
-	// 等待 Raft 提交完成，带超时保护
-	select {
-	case <-waitCh:
-		// 成功完成
-	case <-time.After(30 * time.Second):
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return 0, nil, fmt.Errorf("timeout waiting for Raft commit (PUT)")
-	case <-ctx.Done():
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return 0, nil, ctx.Err()
-	}
-
-	// 读取当前 revision 和 prevKv（无需加锁，atomic + ShardedMap 内部加锁）
-	currentRevision := m.MemoryEtcd.revision.Load()
-	prevKv, _ := m.MemoryEtcd.kvData.Get(key)
-
-	return currentRevision, prevKv, nil
+	// 读取当前 revision（atomic，无需加锁）
+	return m.MemoryEtcd.revision.Load(), prevKv, nil
 }
 
 // DeleteRange 删除范围内的键（通过 Raft）
@@ -362,113 +510,29 @@ func (m *Memory) DeleteRange(ctx context.Context, key, rangeEnd string) (int64,
 		return 0, nil, m.MemoryEtcd.revision.Load(), nil
 	}
 
-	// 生成唯一序列号
-	m.mu.Lock()
-	m.seqNum++
-	seqNum := fmt.Sprintf("seq-%d", m.seqNum)
-	m.mu.Unlock()
-
-	// 创建等待通道
-	waitCh := make(chan struct{})
-	m.pendingMu.Lock()
-	m.pendingOps[seqNum] = waitCh
-	m.pendingMu.Unlock()
-
 	op := RaftOperation{
 		Type:     "DELETE",
 		Key:      key,
 		RangeEnd: rangeEnd,
-		SeqNum:   seqNum,
 	}
-
-	data, err := serializeOperation(op)
-	if err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
+	if err := m.proposeAndWait(ctx, &op); err != nil {
 		return 0, nil, 0, err
 	}
 
-	// 发送提案（使用 BatchProposer 如果可用）
-	if err := m.propose(ctx, string(data)); err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return 0, nil, 0, fmt.Errorf("failed to propose DELETE operation: %w", err)
-	}
-
-	// 等待 Raft 提交完成，带超时保护
-	select {
-	case <-waitCh:
-		// 成功完成
-	case <-time.After(30 * time.Second):
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return 0, nil, 0, fmt.Errorf("timeout waiting for Raft commit (DELETE)")
-	case <-ctx.Done():
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return 0, nil, 0, ctx.Err()
-	}
-
 	return deleted, prevKvs, m.MemoryEtcd.revision.Load(), nil
 }
 
 // LeaseGrant 创建租约（通过 Raft）
 func (m *Memory) LeaseGrant(ctx context.Context, id int64, ttl int64) (*kvstore.Lease, error) {
-	// 生成唯一序列号
-	m.mu.Lock()
-	m.seqNum++
-	seqNum := fmt.Sprintf("seq-%d", m.seqNum)
-	m.mu.Unlock()
-
-	// 创建等待通道
-	waitCh := make(chan struct{})
-	m.pendingMu.Lock()
-	m.pendingOps[seqNum] = waitCh
-	m.pendingMu.Unlock()
-
 	op := RaftOperation{
 		Type:    "LEASE_GRANT",
 		LeaseID: id,
 		TTL:     ttl,
-		SeqNum:  seqNum,
 	}
-
-	data, err := serializeOperation(op)
-	if err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
+	if err := m.proposeAndWait(ctx, &op); err != nil {
 		return nil, err
 	}
 
-	// 发送提案（使用 BatchProposer 如果可用）
-	if err := m.propose(ctx, string(data)); err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return nil, fmt.Errorf("failed to propose LEASE_GRANT operation: %w", err)
-	}
-
-	// 等待 Raft 提交完成，带超时保护
-	select {
-	case <-waitCh:
-		// 成功完成
-	case <-time.After(30 * time.Second):
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return nil, fmt.Errorf("timeout waiting for Raft commit (LEASE_GRANT)")
-	case <-ctx.Done():
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return nil, ctx.Err()
-	}
-
 	// 返回租约信息
 	lease := &kvstore.Lease{
 		ID:        id,
@@ -482,118 +546,48 @@ func (m *Memory) LeaseGrant(ctx context.Context, id int64, ttl int64) (*kvstore.
 
 // LeaseRevoke 撤销租约（通过 Raft）
 func (m *Memory) LeaseRevoke(ctx context.Context, id int64) error {
-	// 生成唯一序列号
-	m.mu.Lock()
-	m.seqNum++
-	seqNum := fmt.Sprintf("seq-%d", m.seqNum)
-	m.mu.Unlock()
-
-	// 创建等待通道
-	waitCh := make(chan struct{})
-	m.pendingMu.Lock()
-	m.pendingOps[seqNum] = waitCh
-	m.pendingMu.Unlock()
-
 	op := RaftOperation{
 		Type:    "LEASE_REVOKE",
 		LeaseID: id,
-		SeqNum:  seqNum,
 	}
+	return m.proposeAndWait(ctx, &op)
+}
 
-	data, err := serializeOperation(op)
-	if err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return err
+// Compact 压缩指定 revision 之前的历史数据（通过 Raft）
+//
+// 所有副本在 apply 时压缩到同一个 revision，watch 在任何节点上看到相同的压缩边界。
+// 提案前先校验 revision，必然失败的压缩不写入 Raft 日志。
+func (m *Memory) Compact(ctx context.Context, revision int64) error {
+	if revision > m.MemoryEtcd.revision.Load() {
+		return mvcc.ErrFutureRevision
 	}
-
-	// 发送提案（使用 BatchProposer 如果可用）
-	if err := m.propose(ctx, string(data)); err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return fmt.Errorf("failed to propose LEASE_REVOKE operation: %w", err)
+	if revision <= m.MemoryEtcd.history.CompactedRevision() {
+		return mvcc.ErrCompacted
 	}
 
-	// 等待 Raft 提交完成，带超时保护
-	select {
-	case <-waitCh:
-		// 成功完成
-	case <-time.After(30 * time.Second):
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return fmt.Errorf("timeout waiting for Raft commit (LEASE_REVOKE)")
-	case <-ctx.Done():
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return ctx.Err()
+	op := RaftOperation{
+		Type:     "COMPACT",
+		Revision: revision,
 	}
-
-	return nil
+	return m.proposeAndWait(ctx, &op)
 }
 
 // Txn 执行事务（通过 Raft）
 func (m *Memory) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
-	// 生成唯一序列号
-	m.mu.Lock()
-	m.seqNum++
-	seqNum := fmt.Sprintf("seq-%d", m.seqNum)
-	m.mu.Unlock()
-
-	// 创建等待通道
-	waitCh := make(chan struct{})
-	m.pendingMu.Lock()
-	m.pendingOps[seqNum] = waitCh
-	m.pendingMu.Unlock()
-
 	op := RaftOperation{
 		Type:     "TXN",
 		Compares: cmps,
 		ThenOps:  thenOps,
 		ElseOps:  elseOps,
-		SeqNum:   seqNum,
 	}
-
-	// 序列化并 propose（使用 Protobuf 优化）
-	data, err := serializeOperation(op)
-	if err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
+	if err := m.proposeAndWait(ctx, &op); err != nil {
 		return nil, err
 	}
 
-	// 发送提案（使用 BatchProposer 如果可用）
-	if err := m.propose(ctx, string(data)); err != nil {
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return nil, fmt.Errorf("failed to propose TXN operation: %w", err)
-	}
-
-	// 等待 Raft 提交完成，带超时保护
-	select {
-	case <-waitCh:
-		// 成功完成
-	case <-time.After(30 * time.Second):
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return nil, fmt.Errorf("timeout waiting for Raft commit (TXN)")
-	case <-ctx.Done():
-		m.pendingMu.Lock()
-		delete(m.pendingOps, seqNum)
-		m.pendingMu.Unlock()
-		return nil, ctx.Err()
-	}
-
 	// 读取事务结果
 	m.pendingMu.Lock()
-	txnResp := m.pendingTxnResults[seqNum]
-	delete(m.pendingTxnResults, seqNum) // 清理结果
+	txnResp := m.pendingTxnResults[op.SeqNum]
+	delete(m.pendingTxnResults, op.SeqNum) // 清理结果
 	m.pendingMu.Unlock()
 
 	if txnResp == nil {
@@ -631,11 +625,15 @@ func (m *Memory) GetSnapshot() ([]byte, error) {
 
 	// 使用 Protobuf 序列化（优化后）
 	revision := m.MemoryEtcd.revision.Load()
-	return serializeSnapshot(revision, kvData, leases)
+	return serializeSnapshot(revision, kvData, leases, m.MemoryEtcd.CurrentHLC())
 }
 
 // loadSnapshot 加载快照
 func (m *Memory) loadSnapshot() (*raftpb.Snapshot, error) {
+	// ephemeral 模式没有 snapshotter
+	if m.snapshotter == nil {
+		return nil, nil
+	}
 	snapshot, err := m.snapshotter.Load()
 	if errors.Is(err, snap.ErrNoSnapshot) {
 		return nil, nil
@@ -654,9 +652,11 @@ func (m *Memory) recoverFromSnapshot(snapshotData []byte) error {
 
 	// 使用 atomic 更新 revision
 	m.MemoryEtcd.revision.Store(snapshot.Revision)
+	m.MemoryEtcd.hlc.Restore(hlc.Timestamp(snapshot.HLC))
 
 	// 使用 ShardedMap.SetAll() 恢复数据（内部加锁）
 	m.MemoryEtcd.kvData.SetAll(snapshot.KVData)
+	m.MemoryEtcd.restoreHistory(snapshot.KVData, snapshot.Revision)
 
 	// 使用 leaseMu 恢复 leases
 	m.MemoryEtcd.leaseMu.Lock()
@@ -672,6 +672,60 @@ func (m *Memory) SetRaftNode(node RaftNode, nodeID uint64) {
 	m.nodeID = nodeID
 }
 
+// SetProposeQueue 设置提案队列，提案按优先级入队而不是发送到 proposeC，在开始处理请求之前调用
+func (m *Memory) SetProposeQueue(q *kvstore.ProposeQueue) {
+	m.queue = q
+}
+
+// SetBackpressure 设置提案管道的背压参数，可以在运行时调用
+func (m *Memory) SetBackpressure(b kvstore.Backpressure) {
+	m.backpressure.Store(&b)
+}
+
+// SetBatchApply 设置是否合并应用一次 commit 中的操作（功能开关 BatchApply）
+func (m *Memory) SetBatchApply(enabled bool) {
+	m.batchApply.Store(enabled)
+}
+
+// SetApplyPool 设置合并应用时并行执行写操作的线程池
+func (m *Memory) SetApplyPool(pool *kvstore.ApplyPool) {
+	m.applyPool.Store(pool)
+}
+
+// WriteIndex 返回 read-after-write token，不小于本节点已完成的所有写入的 raft index
+func (m *Memory) WriteIndex() uint64 {
+	return m.applied.WriteIndex()
+}
+
+// WaitApplied 等待本节点应用到 read-after-write token，没有 deadline 时使用背压的默认超时
+func (m *Memory) WaitApplied(ctx context.Context, index uint64) error {
+	ctx, cancel := m.backpressure.Load().WithTimeout(ctx)
+	defer cancel()
+	if err := m.applied.Wait(ctx, index); err != nil {
+		return &kvstore.StageError{Op: "READ", Stage: kvstore.StageCatchUp, Err: err}
+	}
+	return nil
+}
+
+// ProposeQueueStats 返回提案管道的当前状态
+func (m *Memory) ProposeQueueStats() kvstore.ProposeQueueStats {
+	m.pendingMu.RLock()
+	pending := len(m.pendingOps)
+	m.pendingMu.RUnlock()
+	if m.queue != nil {
+		stats := m.queue.Stats()
+		stats.Pending = pending
+		stats.Rejected = m.rejected.Load()
+		return stats
+	}
+	return kvstore.ProposeQueueStats{
+		Queued:   len(m.proposeC),
+		Capacity: cap(m.proposeC),
+		Pending:  pending,
+		Rejected: m.rejected.Load(),
+	}
+}
+
 // GetRaftStatus 获取 Raft 状态信息
 func (m *Memory) GetRaftStatus() kvstore.RaftStatus {
 	if m.raftNode == nil {
@@ -699,47 +753,95 @@ func (m *Memory) TransferLeadership(targetID uint64) error {
 	// 检查当前节点是否是 leader
 	status := m.raftNode.Status()
 	if status.LeaderID != m.nodeID {
-		return fmt.Errorf("not leader, current leader: %d", status.LeaderID)
+		return kvstore.NotLeaderError(status.LeaderID)
 	}
 
 	// 调用 Raft 节点的 TransferLeadership
 	return m.raftNode.TransferLeadership(targetID)
 }
 
-// Range 执行范围查询（带 Lease Read 优化）
+// MoveLeader 将 leader 转移给日志最新的活跃 follower（用于优雅关闭）
+// 返回新 leader 的 ID
+func (m *Memory) MoveLeader(ctx context.Context) (uint64, error) {
+	if m.raftNode == nil {
+		return 0, fmt.Errorf("raft node not available")
+	}
+	return m.raftNode.MoveLeader(ctx)
+}
+
+// ReplaceMember 用新的 learner 替换故障成员（只能在 leader 上执行）
+func (m *Memory) ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	if m.raftNode == nil {
+		return fmt.Errorf("raft node not available")
+	}
+	return m.raftNode.ReplaceMember(ctx, req, report)
+}
+
+// Members 返回集群成员状态，没有 Raft 节点时返回 nil
+func (m *Memory) Members() []kvstore.MemberStatus {
+	if m.raftNode == nil {
+		return nil
+	}
+	return m.raftNode.Members()
+}
+
+// Range 执行范围查询
+//
+// 默认是线性一致读:
+//   - Fast Path: 启用 Lease Read 且 Leader 有有效租约时直接读取（无需 Raft 共识）
+//   - Slow Path: 使用 ReadIndex 协议等待本地状态机追上 leader 的 commit index
 //
-// Lease Read 优化路径:
-//   - Fast Path: Leader 有有效租约时直接读取（无需 Raft 共识）
-//   - Slow Path: 使用 ReadIndex 协议确保线性一致性
-//   - 预期性能提升: 10-100x （取决于集群大小和网络延迟）
+// ctx 由 kvstore.WithSerializable 标记时直接读取本地状态，不保证线性一致
 func (m *Memory) Range(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
-	// 如果启用了 Lease Read 且 RaftNode 可用
-	if m.raftNode != nil {
-		leaseManager := m.raftNode.LeaseManager()
-		readIndexManager := m.raftNode.ReadIndexManager()
-
-		if leaseManager != nil && readIndexManager != nil {
-			// Fast Path: Leader 有有效租约
-			if leaseManager.IsLeader() && leaseManager.HasValidLease() {
-				// 记录快速路径读取
-				readIndexManager.RecordFastPathRead()
-
-				// 直接读取本地状态（已由租约保证线性一致性）
-				return m.MemoryEtcd.Range(ctx, key, rangeEnd, limit, revision)
-			}
+	if err := m.readBarrier(ctx); err != nil {
+		return nil, err
+	}
 
-			// Slow Path: 非 Leader 或租约失效，使用 ReadIndex 协议
-			// TODO: 实现完整的 ReadIndex 协议
-			// 1. Leader 记录当前 committedIndex 作为 readIndex
-			// 2. Leader 发送心跳确认仍是 Leader
-			// 3. 等待 appliedIndex >= readIndex
-			// 4. 执行读取
+	return m.MemoryEtcd.Range(ctx, key, rangeEnd, limit, revision)
+}
 
-			// 当前简化实现：直接读取（在完整实现前保持向后兼容）
-			return m.MemoryEtcd.Range(ctx, key, rangeEnd, limit, revision)
+// RangeFunc 流式范围查询，一致性语义与 Range 相同
+func (m *Memory) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	if err := m.readBarrier(ctx); err != nil {
+		return 0, err
+	}
+
+	return m.MemoryEtcd.RangeFunc(ctx, key, rangeEnd, revision, fn)
+}
+
+// ReverseRangeFunc 按 key 降序的流式范围查询，一致性语义与 Range 相同
+func (m *Memory) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	if err := m.readBarrier(ctx); err != nil {
+		return 0, err
+	}
+
+	return m.MemoryEtcd.ReverseRangeFunc(ctx, key, rangeEnd, revision, fn)
+}
+
+// KeyHistory 列出单个键的历史版本，一致性语义与 Range 相同
+func (m *Memory) KeyHistory(ctx context.Context, key string, limit int64) ([]*kvstore.KeyValue, int64, error) {
+	if err := m.readBarrier(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return m.MemoryEtcd.KeyHistory(ctx, key, limit)
+}
+
+// readBarrier 等待本地状态可以提供线性一致读，serializable 读取直接返回
+func (m *Memory) readBarrier(ctx context.Context) error {
+	if m.raftNode == nil || kvstore.IsSerializable(ctx) {
+		return nil
+	}
+
+	if lm := m.raftNode.LeaseManager(); lm != nil && lm.IsLeader() && lm.HasValidLease() {
+		// 记录快速路径读取
+		if rim := m.raftNode.ReadIndexManager(); rim != nil {
+			rim.RecordFastPathRead()
 		}
+		return nil
 	}
 
-	// 未启用 Lease Read 或 RaftNode 不可用，直接读取
-	return m.MemoryEtcd.Range(ctx, key, rangeEnd, limit, revision)
+	// 1. 通过 leader 获取当前 committedIndex 作为 readIndex（心跳确认领导权）
+	// 2. 等待本地 appliedIndex >= readIndex
+	return m.raftNode.ReadIndex(ctx)
 }
@@ -76,6 +76,8 @@ func raftOperationToProto(op RaftOperation) *raftpb.RaftOperation {
 		LeaseId:  op.LeaseID,
 		Ttl:      op.TTL,
 		SeqNum:   op.SeqNum,
+		Hlc:      op.HLC,
+		Revision: op.Revision,
 	}
 
 	// 转换 Compares
@@ -115,6 +117,8 @@ func protoToRaftOperation(pbOp *raftpb.RaftOperation) RaftOperation {
 		LeaseID:  pbOp.LeaseId,
 		TTL:      pbOp.Ttl,
 		SeqNum:   pbOp.SeqNum,
+		HLC:      pbOp.Hlc,
+		Revision: pbOp.Revision,
 	}
 
 	// 转换 Compares
@@ -0,0 +1,64 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/lease"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readIndexCounter 只记录 ReadIndex 调用次数的 RaftNode
+type readIndexCounter struct {
+	readIndexCalls int
+}
+
+func (n *readIndexCounter) Status() kvstore.RaftStatus                { return kvstore.RaftStatus{} }
+func (n *readIndexCounter) TransferLeadership(targetID uint64) error  { return nil }
+func (n *readIndexCounter) LeaseManager() *lease.LeaseManager         { return nil }
+func (n *readIndexCounter) ReadIndexManager() *lease.ReadIndexManager { return nil }
+func (n *readIndexCounter) MoveLeader(ctx context.Context) (uint64, error) {
+	return 0, nil
+}
+func (n *readIndexCounter) ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	return nil
+}
+func (n *readIndexCounter) Members() []kvstore.MemberStatus { return nil }
+
+func (n *readIndexCounter) ReadIndex(ctx context.Context) error {
+	n.readIndexCalls++
+	return nil
+}
+
+func TestRangeReadIndex(t *testing.T) {
+	m := NewMemory(nil, make(chan string, 1), make(chan *kvstore.Commit), make(chan error))
+	node := &readIndexCounter{}
+	m.SetRaftNode(node, 1)
+
+	// 没有租约时默认的线性一致读走 ReadIndex
+	_, err := m.Range(context.Background(), "a", "", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, node.readIndexCalls)
+
+	// serializable 读取直接访问本地状态
+	_, err = m.Range(kvstore.WithSerializable(context.Background()), "a", "", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, node.readIndexCalls)
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"metaStore/internal/kvstore"
+)
+
+// DecodeProposal 按 readCommits 的方式解码一条提案，供 metastore replay 打印。
+// 旧格式的 gob KV 解码为 PUT，无法解码的提案在 apply 时会使节点退出，这里返回错误
+func DecodeProposal(data string) ([]RaftOperation, error) {
+	if op, err := deserializeOperation([]byte(data)); err == nil {
+		return []RaftOperation{op}, nil
+	}
+	var kv kvstore.KV
+	if err := gob.NewDecoder(bytes.NewBufferString(data)).Decode(&kv); err != nil {
+		return nil, fmt.Errorf("undecodable proposal: %w", err)
+	}
+	return []RaftOperation{{Type: "PUT", Key: kv.Key, Value: kv.Val}}, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"metaStore/internal/kvstore"
+)
+
+// TestDecodeProposal 测试回放时按 readCommits 的方式解码提案
+func TestDecodeProposal(t *testing.T) {
+	data, err := json.Marshal(RaftOperation{Type: "PUT", Key: "k", Value: "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := DecodeProposal(string(data))
+	if err != nil || len(ops) != 1 || ops[0].Type != "PUT" || ops[0].Key != "k" {
+		t.Fatalf("DecodeProposal(json) = %+v, %v", ops, err)
+	}
+
+	// 旧格式的 gob KV 解码为 PUT
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(kvstore.KV{Key: "old", Val: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	ops, err = DecodeProposal(buf.String())
+	if err != nil || len(ops) != 1 || ops[0].Type != "PUT" || ops[0].Key != "old" {
+		t.Fatalf("DecodeProposal(gob) = %+v, %v", ops, err)
+	}
+
+	if _, err := DecodeProposal("garbage"); err == nil {
+		t.Fatal("DecodeProposal(garbage) should fail")
+	}
+}
@@ -16,10 +16,11 @@ package memory
 
 import (
 	"hash/fnv"
-	"sort"
 	"sync"
 
 	"metaStore/internal/kvstore"
+
+	"github.com/google/btree"
 )
 
 const (
@@ -27,12 +28,21 @@ const (
 	// Power of 2 for efficient modulo operation using bitwise AND
 	numShards = 512
 	shardMask = numShards - 1
+
+	// rangeBatch is the number of keys collected per index lock in range scans
+	rangeBatch = 256
 )
 
 // ShardedMap is a thread-safe sharded map for better concurrency
 // Each shard has its own lock, allowing parallel access to different shards
+// A sorted index of the keys serves ordered range scans in both directions
 type ShardedMap struct {
 	shards [numShards]shard
+
+	// index holds every key in the map, it is updated while holding the key's
+	// shard lock so it never disagrees with the shards for long
+	indexMu sync.RWMutex
+	index   *btree.BTreeG[string]
 }
 
 // shard represents a single shard with independent locking
@@ -43,7 +53,7 @@ type shard struct {
 
 // NewShardedMap creates a new sharded map
 func NewShardedMap() *ShardedMap {
-	sm := &ShardedMap{}
+	sm := &ShardedMap{index: newKeyIndex()}
 	for i := 0; i < numShards; i++ {
 		sm.shards[i].data = make(map[string]*kvstore.KeyValue)
 	}
@@ -78,6 +88,16 @@ func (sm *ShardedMap) Set(key string, kv *kvstore.KeyValue) {
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
 
+	sm.setLocked(shard, key, kv)
+}
+
+// setLocked stores a value, the caller holds the shard lock
+func (sm *ShardedMap) setLocked(shard *shard, key string, kv *kvstore.KeyValue) {
+	if _, exists := shard.data[key]; !exists {
+		sm.indexMu.Lock()
+		sm.index.ReplaceOrInsert(key)
+		sm.indexMu.Unlock()
+	}
 	shard.data[key] = kv
 }
 
@@ -89,91 +109,108 @@ func (sm *ShardedMap) Delete(key string) {
 	shard.mu.Lock()
 	defer shard.mu.Unlock()
 
-	delete(shard.data, key)
+	sm.deleteLocked(shard, key)
 }
 
-// Range iterates over keys in the specified range
-// For range queries, we need to scan all shards and combine results
-func (sm *ShardedMap) Range(startKey, endKey string, limit int64) []*kvstore.KeyValue {
-	// Collect from all shards
-	var allKvs []*kvstore.KeyValue
-
-	// We need to lock all shards for range query
-	// Lock them in order to prevent deadlock
-	for i := 0; i < numShards; i++ {
-		shard := &sm.shards[i]
-		shard.mu.RLock()
-	}
-
-	// Collect matching keys from all shards
-	for i := 0; i < numShards; i++ {
-		shard := &sm.shards[i]
-		for k, v := range shard.data {
-			if k >= startKey && (endKey == "\x00" || k < endKey) {
-				allKvs = append(allKvs, v)
-			}
-		}
+// deleteLocked removes a key, the caller holds the shard lock
+func (sm *ShardedMap) deleteLocked(shard *shard, key string) {
+	if _, exists := shard.data[key]; !exists {
+		return
 	}
+	delete(shard.data, key)
+	sm.indexMu.Lock()
+	sm.index.Delete(key)
+	sm.indexMu.Unlock()
+}
 
-	// Unlock all shards
-	for i := 0; i < numShards; i++ {
-		shard := &sm.shards[i]
-		shard.mu.RUnlock()
-	}
+// newKeyIndex creates the sorted key index
+func newKeyIndex() *btree.BTreeG[string] {
+	return btree.NewG(32, func(a, b string) bool { return a < b })
+}
 
-	// Sort by key
-	sort.Slice(allKvs, func(i, j int) bool {
-		return string(allKvs[i].Key) < string(allKvs[j].Key)
+// Range returns the keys in [startKey, endKey) in key order
+func (sm *ShardedMap) Range(startKey, endKey string, limit int64) []*kvstore.KeyValue {
+	var allKvs []*kvstore.KeyValue
+	sm.RangeFunc(startKey, endKey, limit, func(kv *kvstore.KeyValue) bool {
+		allKvs = append(allKvs, kv)
+		return true
 	})
-
-	// Apply limit
-	if limit > 0 && int64(len(allKvs)) > limit {
-		allKvs = allKvs[:limit]
-	}
-
 	return allKvs
 }
 
-// RangeFunc iterates over keys in the specified range with a callback function
-// This is more efficient for operations that don't need to collect all results
+// RangeFunc calls fn for the keys in [startKey, endKey) in key order until fn returns false
+// The sorted index is walked in batches, so the range is never materialized or sorted
 func (sm *ShardedMap) RangeFunc(startKey, endKey string, limit int64, fn func(*kvstore.KeyValue) bool) {
-	// Collect from all shards first
-	var allKvs []*kvstore.KeyValue
+	sm.rangeFunc(startKey, endKey, limit, false, fn)
+}
 
-	// Lock all shards
-	for i := 0; i < numShards; i++ {
-		sm.shards[i].mu.RLock()
-	}
+// ReverseRangeFunc is RangeFunc in descending key order
+func (sm *ShardedMap) ReverseRangeFunc(startKey, endKey string, limit int64, fn func(*kvstore.KeyValue) bool) {
+	sm.rangeFunc(startKey, endKey, limit, true, fn)
+}
 
-	// Collect matching keys
-	for i := 0; i < numShards; i++ {
-		for k, v := range sm.shards[i].data {
-			if k >= startKey && (endKey == "\x00" || k < endKey) {
-				allKvs = append(allKvs, v)
-			}
+// rangeFunc resolves the keys of scanKeys to values and applies limit
+func (sm *ShardedMap) rangeFunc(startKey, endKey string, limit int64, desc bool, fn func(*kvstore.KeyValue) bool) {
+	count := int64(0)
+	sm.scanKeys(startKey, endKey, desc, func(key string) bool {
+		// The key may have been deleted after the batch was collected
+		kv, ok := sm.Get(key)
+		if !ok {
+			return true
 		}
-	}
+		if limit > 0 && count >= limit {
+			return false
+		}
+		count++
+		return fn(kv)
+	})
+}
 
-	// Unlock all shards
-	for i := 0; i < numShards; i++ {
-		sm.shards[i].mu.RUnlock()
-	}
+// scanKeys walks the sorted index in [startKey, endKey), endKey "\x00" meaning no upper bound
+// Keys are collected rangeBatch at a time under the index lock and passed to fn after
+// releasing it, so fn may access the map and writers are not blocked for the whole scan
+func (sm *ShardedMap) scanKeys(startKey, endKey string, desc bool, fn func(key string) bool) {
+	lo, hi := startKey, endKey
+	bounded := endKey != "\x00"
+	batch := make([]string, 0, rangeBatch)
+	for {
+		batch = batch[:0]
+		collect := func(k string) bool {
+			if (desc && k < lo) || (!desc && bounded && k >= hi) {
+				return false
+			}
+			if desc && k == hi {
+				return true // hi is exclusive
+			}
+			batch = append(batch, k)
+			return len(batch) < rangeBatch
+		}
 
-	// Sort by key
-	sort.Slice(allKvs, func(i, j int) bool {
-		return string(allKvs[i].Key) < string(allKvs[j].Key)
-	})
+		sm.indexMu.RLock()
+		switch {
+		case !desc:
+			sm.index.AscendGreaterOrEqual(lo, collect)
+		case bounded:
+			sm.index.DescendLessOrEqual(hi, collect)
+		default:
+			sm.index.Descend(collect)
+		}
+		sm.indexMu.RUnlock()
 
-	// Apply limit and call function
-	count := int64(0)
-	for _, kv := range allKvs {
-		if limit > 0 && count >= limit {
-			break
+		for _, k := range batch {
+			if !fn(k) {
+				return
+			}
 		}
-		if !fn(kv) {
-			break
+		if len(batch) < rangeBatch {
+			return
+		}
+		// Continue after the last key of the batch
+		if desc {
+			hi, bounded = batch[len(batch)-1], true
+		} else {
+			lo = batch[len(batch)-1] + "\x00"
 		}
-		count++
 	}
 }
 
@@ -210,6 +247,9 @@ func (sm *ShardedMap) Clear() {
 	for i := 0; i < numShards; i++ {
 		sm.shards[i].data = make(map[string]*kvstore.KeyValue)
 	}
+	sm.indexMu.Lock()
+	sm.index = newKeyIndex()
+	sm.indexMu.Unlock()
 
 	// Unlock all shards
 	for i := 0; i < numShards; i++ {
@@ -253,11 +293,16 @@ func (sm *ShardedMap) SetAll(data map[string]*kvstore.KeyValue) {
 		sm.shards[i].data = make(map[string]*kvstore.KeyValue)
 	}
 
-	// Distribute new data to shards
+	// Distribute new data to shards and rebuild the index
+	index := newKeyIndex()
 	for k, v := range data {
 		shardIdx := sm.getShard(k)
 		sm.shards[shardIdx].data[k] = v
+		index.ReplaceOrInsert(k)
 	}
+	sm.indexMu.Lock()
+	sm.index = index
+	sm.indexMu.Unlock()
 
 	// Unlock all shards
 	for i := 0; i < numShards; i++ {
@@ -15,11 +15,14 @@
 package memory
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"metaStore/internal/common"
 	"metaStore/internal/kvstore"
 	"metaStore/pkg/config"
+	"metaStore/pkg/encryption"
+	"metaStore/pkg/reliability"
 	raftpb "metaStore/internal/proto"
 
 	"google.golang.org/protobuf/proto"
@@ -34,17 +37,40 @@ type SnapshotData struct {
 	Revision int64
 	KVData   map[string]*kvstore.KeyValue
 	Leases   map[int64]*kvstore.Lease
+	HLC      uint64 // 最后应用的操作的 HLC
 }
 
-// serializeSnapshot 序列化快照
+// encryptedSnapshotPrefix 加密快照的前缀，其后是整个快照的信封密文
+const encryptedSnapshotPrefix = "SNAP-ENC:"
+
+// serializeSnapshot 序列化快照，启用静态加密时用激活的 KEK 加密整个快照，
+// 启用 CRC 时在最外层加上校验
+func serializeSnapshot(revision int64, kvData map[string]*kvstore.KeyValue, leases map[int64]*kvstore.Lease, lastHLC uint64) ([]byte, error) {
+	data, err := encodeSnapshot(revision, kvData, leases, lastHLC)
+	if err != nil {
+		return nil, err
+	}
+	keyring := encryption.Current()
+	if keyring == nil {
+		return reliability.SealSnapshot(data), nil
+	}
+	sealed, err := keyring.Encrypt(data, []byte(encryptedSnapshotPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt snapshot failed: %w", err)
+	}
+	return reliability.SealSnapshot(append([]byte(encryptedSnapshotPrefix), sealed...)), nil
+}
+
+// encodeSnapshot 编码快照
 // 优先使用 Protobuf（2-3x 性能提升），回退到 JSON（向后兼容）
-func serializeSnapshot(revision int64, kvData map[string]*kvstore.KeyValue, leases map[int64]*kvstore.Lease) ([]byte, error) {
+func encodeSnapshot(revision int64, kvData map[string]*kvstore.KeyValue, leases map[int64]*kvstore.Lease, lastHLC uint64) ([]byte, error) {
 	if enableSnapshotProtobuf() {
 		// 使用 Protobuf 序列化
 		pbSnapshot := &raftpb.StoreSnapshot{
 			Revision: revision,
 			KvData:   make(map[string]*raftpb.KeyValueProto),
 			Leases:   make(map[int64]*raftpb.LeaseProto),
+			Hlc:      lastHLC,
 		}
 
 		// 转换 KV 数据
@@ -72,6 +98,7 @@ func serializeSnapshot(revision int64, kvData map[string]*kvstore.KeyValue, leas
 		Revision: revision,
 		KVData:   kvData,
 		Leases:   leases,
+		HLC:      lastHLC,
 	}
 	return json.Marshal(snapshot)
 }
@@ -79,6 +106,24 @@ func serializeSnapshot(revision int64, kvData map[string]*kvstore.KeyValue, leas
 // deserializeSnapshot 反序列化快照
 // 自动检测 Protobuf 或 JSON 格式
 func deserializeSnapshot(data []byte) (*SnapshotData, error) {
+	data, err := reliability.OpenSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 加密快照先解密，旧的明文快照仍可直接读取
+	if bytes.HasPrefix(data, []byte(encryptedSnapshotPrefix)) {
+		keyring := encryption.Current()
+		if keyring == nil {
+			return nil, encryption.ErrNoKeyring
+		}
+		plain, err := keyring.Decrypt(data[len(encryptedSnapshotPrefix):], []byte(encryptedSnapshotPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt snapshot failed: %w", err)
+		}
+		data = plain
+	}
+
 	// 检查是否为 Protobuf 格式（以 "SNAP-PB:" 前缀标识）
 	const pbPrefix = "SNAP-PB:"
 	if len(data) >= len(pbPrefix) && string(data[:len(pbPrefix)]) == pbPrefix {
@@ -93,6 +138,7 @@ func deserializeSnapshot(data []byte) (*SnapshotData, error) {
 			Revision: pbSnapshot.Revision,
 			KVData:   make(map[string]*kvstore.KeyValue),
 			Leases:   make(map[int64]*kvstore.Lease),
+			HLC:      pbSnapshot.Hlc,
 		}
 
 		// 转换 KV 数据
@@ -129,6 +175,7 @@ func keyValueToProto(kv *kvstore.KeyValue) *raftpb.KeyValueProto {
 		ModRevision:    kv.ModRevision,
 		Version:        kv.Version,
 		Lease:          kv.Lease,
+		Hlc:            kv.HLC,
 	}
 }
 
@@ -144,6 +191,7 @@ func protoToKeyValue(pbKv *raftpb.KeyValueProto) *kvstore.KeyValue {
 		ModRevision:    pbKv.ModRevision,
 		Version:        pbKv.Version,
 		Lease:          pbKv.Lease,
+		HLC:            pbKv.Hlc,
 	}
 }
 
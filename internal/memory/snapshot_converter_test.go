@@ -15,8 +15,12 @@
 package memory
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"metaStore/internal/kvstore"
+	"metaStore/pkg/encryption"
+	"metaStore/pkg/reliability"
 	"testing"
 	"time"
 )
@@ -33,6 +37,7 @@ func TestSnapshotProtobufSerialization(t *testing.T) {
 			ModRevision:    10,
 			Version:        5,
 			Lease:          123,
+			HLC:            0x18b_0000_0001,
 		},
 		"key2": {
 			Key:            []byte("key2"),
@@ -61,7 +66,7 @@ func TestSnapshotProtobufSerialization(t *testing.T) {
 	}
 
 	// 序列化
-	data, err := serializeSnapshot(revision, kvData, leases)
+	data, err := serializeSnapshot(revision, kvData, leases, 0x18b_0000_0002)
 	if err != nil {
 		t.Fatalf("serializeSnapshot failed: %v", err)
 	}
@@ -81,6 +86,9 @@ func TestSnapshotProtobufSerialization(t *testing.T) {
 	if snapshot.Revision != revision {
 		t.Errorf("Expected revision %d, got %d", revision, snapshot.Revision)
 	}
+	if snapshot.HLC != 0x18b_0000_0002 {
+		t.Errorf("Expected HLC %d, got %d", 0x18b_0000_0002, snapshot.HLC)
+	}
 
 	// 验证 KV 数据
 	if len(snapshot.KVData) != len(kvData) {
@@ -112,6 +120,9 @@ func TestSnapshotProtobufSerialization(t *testing.T) {
 		if actualKV.Lease != expectedKV.Lease {
 			t.Errorf("Lease mismatch for %s: expected %d, got %d", k, expectedKV.Lease, actualKV.Lease)
 		}
+		if actualKV.HLC != expectedKV.HLC {
+			t.Errorf("HLC mismatch for %s: expected %d, got %d", k, expectedKV.HLC, actualKV.HLC)
+		}
 	}
 
 	// 验证 Lease 数据
@@ -221,7 +232,7 @@ func TestSnapshotEmptyData(t *testing.T) {
 	leases := map[int64]*kvstore.Lease{}
 
 	// 序列化空快照
-	data, err := serializeSnapshot(revision, kvData, leases)
+	data, err := serializeSnapshot(revision, kvData, leases, 0)
 	if err != nil {
 		t.Fatalf("serializeSnapshot failed: %v", err)
 	}
@@ -247,6 +258,79 @@ func TestSnapshotEmptyData(t *testing.T) {
 	}
 }
 
+// TestSnapshotEncryption 测试启用静态加密后的快照
+func TestSnapshotEncryption(t *testing.T) {
+	keyring, err := encryption.NewKeyring("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, encryption.KeySize)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.SetKeyring(keyring)
+	defer encryption.SetKeyring(nil)
+
+	kvData := map[string]*kvstore.KeyValue{
+		"secret": {Key: []byte("secret"), Value: []byte("plaintext-value"), CreateRevision: 1, ModRevision: 1, Version: 1},
+	}
+	data, err := serializeSnapshot(1, kvData, map[int64]*kvstore.Lease{}, 0)
+	if err != nil {
+		t.Fatalf("serializeSnapshot failed: %v", err)
+	}
+	if bytes.Contains(data, []byte("plaintext-value")) {
+		t.Fatal("encrypted snapshot contains the plaintext value")
+	}
+
+	snapshot, err := deserializeSnapshot(data)
+	if err != nil {
+		t.Fatalf("deserializeSnapshot failed: %v", err)
+	}
+	if string(snapshot.KVData["secret"].Value) != "plaintext-value" {
+		t.Errorf("Expected value 'plaintext-value', got '%s'", snapshot.KVData["secret"].Value)
+	}
+
+	// 未配置 KEK 时无法读取加密快照
+	encryption.SetKeyring(nil)
+	if _, err := deserializeSnapshot(data); !errors.Is(err, encryption.ErrNoKeyring) {
+		t.Errorf("Expected ErrNoKeyring, got %v", err)
+	}
+}
+
+// TestSnapshotCRC 测试快照的 CRC 校验
+func TestSnapshotCRC(t *testing.T) {
+	kvData := map[string]*kvstore.KeyValue{
+		"key": {Key: []byte("key"), Value: []byte("value"), CreateRevision: 1, ModRevision: 1, Version: 1},
+	}
+
+	// 关闭 CRC 时写入的快照在打开 CRC 后仍然可以读取
+	legacy, err := serializeSnapshot(1, kvData, map[int64]*kvstore.Lease{}, 0)
+	if err != nil {
+		t.Fatalf("serializeSnapshot failed: %v", err)
+	}
+
+	reliability.SetCRC(true)
+	defer reliability.SetCRC(false)
+
+	if _, err := deserializeSnapshot(legacy); err != nil {
+		t.Fatalf("deserializeSnapshot of a snapshot without checksum failed: %v", err)
+	}
+
+	data, err := serializeSnapshot(1, kvData, map[int64]*kvstore.Lease{}, 0)
+	if err != nil {
+		t.Fatalf("serializeSnapshot failed: %v", err)
+	}
+	snapshot, err := deserializeSnapshot(data)
+	if err != nil {
+		t.Fatalf("deserializeSnapshot failed: %v", err)
+	}
+	if string(snapshot.KVData["key"].Value) != "value" {
+		t.Errorf("Expected value 'value', got '%s'", snapshot.KVData["key"].Value)
+	}
+
+	// 损坏最后一个字节
+	data[len(data)-1] ^= 0xff
+	if _, err := deserializeSnapshot(data); !errors.Is(err, reliability.ErrCorrupted) {
+		t.Errorf("Expected ErrCorrupted, got %v", err)
+	}
+}
+
 // BenchmarkSnapshotProtobuf 基准测试: Protobuf 序列化
 func BenchmarkSnapshotProtobuf(b *testing.B) {
 	// 准备大量测试数据（模拟真实场景）
@@ -277,7 +361,7 @@ func BenchmarkSnapshotProtobuf(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		data, err := serializeSnapshot(revision, kvData, leases)
+		data, err := serializeSnapshot(revision, kvData, leases, 0)
 		if err != nil {
 			b.Fatalf("serializeSnapshot failed: %v", err)
 		}
@@ -19,6 +19,8 @@ import (
 	"bytes"
 	"fmt"
 	"metaStore/internal/kvstore"
+	"metaStore/internal/mvcc"
+	"metaStore/pkg/hlc"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -27,6 +29,8 @@ import (
 // MemoryEtcd 支持 etcd 语义的内存存储
 type MemoryEtcd struct {
 	kvData       *ShardedMap                  // 分片 map，支持高并发访问
+	history      *mvcc.MemoryStore            // MVCC 历史版本，支持按 revision 读取和 Compact
+	events       *mvcc.EventLog               // 最近的事件，MVCC 历史被压缩后用于恢复 watch
 	revision     atomic.Int64                 // 全局 revision 计数器（无锁 atomic 操作）
 	leases       map[int64]*kvstore.Lease     // leaseID -> Lease
 	leaseMu      sync.RWMutex                 // 保护 leases map
@@ -34,6 +38,8 @@ type MemoryEtcd struct {
 	watchMu      sync.RWMutex                 // 保护 watches map
 	txnMu        sync.Mutex                   // 保护事务操作的原子性
 	nextWatchID  atomic.Int64
+	clock        atomic.Pointer[hlc.Clock]    // 本节点的 HLC
+	hlc          hlc.Sequencer                // 按提交顺序分配的 HLC
 }
 
 // watchSubscription 表示一个 watch 订阅
@@ -42,26 +48,32 @@ type watchSubscription struct {
 	key          string
 	rangeEnd     string
 	startRev     int64
+	replayedRev  int64 // 创建时从历史回放到的 revision
 	eventCh      chan kvstore.WatchEvent
 	cancel       chan struct{}
-	closed       atomic.Bool  // 防止重复关闭
-	closeOnce    sync.Once    // 确保只关闭一次
+	closed       atomic.Bool        // 防止重复关闭
+	closeOnce    sync.Once          // 确保只关闭一次
+	queue        kvstore.WatchQueue // 通道满时排队的事件
 
 	// Options
 	prevKV         bool
 	progressNotify bool
 	filters        []kvstore.WatchFilterType
 	fragment       bool
+	valueFilter    func(value []byte) bool
 }
 
 // NewMemoryEtcd 创建支持 etcd 语义的内存存储
 func NewMemoryEtcd() *MemoryEtcd {
 	m := &MemoryEtcd{
 		kvData:  NewShardedMap(),
+		history: mvcc.NewMemoryStore(),
+		events:  mvcc.NewEventLog(0, 0),
 		leases:  make(map[int64]*kvstore.Lease),
 		watches: make(map[int64]*watchSubscription),
 	}
 	m.revision.Store(0)
+	m.clock.Store(hlc.NewClock(hlc.DefaultMaxOffset))
 	return m
 }
 
@@ -72,6 +84,11 @@ func (m *MemoryEtcd) CurrentRevision() int64 {
 
 // Range 执行范围查询
 func (m *MemoryEtcd) Range(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
+	// 指定了历史 revision 时从 MVCC 历史读取
+	if revision > 0 && revision != m.revision.Load() {
+		return m.rangeAt(ctx, key, rangeEnd, limit, revision)
+	}
+
 	var kvs []*kvstore.KeyValue
 
 	// 如果 rangeEnd 为空，查询单个键
@@ -81,10 +98,13 @@ func (m *MemoryEtcd) Range(ctx context.Context, key, rangeEnd string, limit int6
 		}
 	} else {
 		// 范围查询 - ShardedMap 内部会处理锁和排序
-		kvs = m.kvData.Range(key, rangeEnd, limit)
+		// 不在这里截断，count 是范围内的键总数
+		kvs = m.kvData.Range(key, rangeEnd, 0)
 	}
+	// 没有读权限的键不计入 count 和 limit
+	kvs = kvstore.FilterKeys(ctx, kvs)
 
-	// 应用 limit（Range 已经处理了，这里是为了计算 more 和 count）
+	// 应用 limit，同时计算 more 和 count
 	more := false
 	count := int64(len(kvs))
 	if limit > 0 && int64(len(kvs)) > limit {
@@ -100,6 +120,67 @@ func (m *MemoryEtcd) Range(ctx context.Context, key, rangeEnd string, limit int6
 	}, nil
 }
 
+// RangeFunc 流式范围查询，按 key 顺序对每个键调用 fn，fn 返回 false 时停止
+//
+// 按有序索引分批读取，不物化完整结果，也不复制 value
+func (m *MemoryEtcd) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return m.rangeFunc(ctx, key, rangeEnd, revision, false, fn)
+}
+
+// ReverseRangeFunc 与 RangeFunc 相同，但按 key 降序调用 fn
+func (m *MemoryEtcd) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return m.rangeFunc(ctx, key, rangeEnd, revision, true, fn)
+}
+
+func (m *MemoryEtcd) rangeFunc(ctx context.Context, key, rangeEnd string, revision int64, desc bool, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	current := m.revision.Load()
+	if visible := kvstore.KeyFilter(ctx); visible != nil {
+		next := fn
+		fn = func(kv *kvstore.KeyValue) bool {
+			return !visible(kv.Key) || next(kv)
+		}
+	}
+
+	// 历史 revision 从 MVCC 历史读取
+	if revision > 0 && revision != current {
+		resp, err := m.rangeAt(ctx, key, rangeEnd, 0, revision)
+		if err != nil {
+			return 0, err
+		}
+		for i := range resp.Kvs {
+			kv := resp.Kvs[i]
+			if desc {
+				kv = resp.Kvs[len(resp.Kvs)-1-i]
+			}
+			if !fn(kv) {
+				break
+			}
+		}
+		return resp.Revision, nil
+	}
+
+	if rangeEnd == "" {
+		if kv, ok := m.kvData.Get(key); ok {
+			fn(kv)
+		}
+		return current, nil
+	}
+
+	var err error
+	visit := func(kv *kvstore.KeyValue) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		return fn(kv)
+	}
+	if desc {
+		m.kvData.ReverseRangeFunc(key, rangeEnd, 0, visit)
+	} else {
+		m.kvData.RangeFunc(key, rangeEnd, 0, visit)
+	}
+	return current, err
+}
+
 // PutWithLease 存储键值对，可选关联 lease
 func (m *MemoryEtcd) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
 	// 验证 lease（如果指定）
@@ -108,12 +189,12 @@ func (m *MemoryEtcd) PutWithLease(ctx context.Context, key, value string, leaseI
 		lease, ok := m.leases[leaseID]
 		if !ok {
 			m.leaseMu.RUnlock()
-			return 0, nil, fmt.Errorf("lease not found: %d", leaseID)
+			return 0, nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, leaseID)
 		}
 		// 过期检查
 		if lease.IsExpired() {
 			m.leaseMu.RUnlock()
-			return 0, nil, fmt.Errorf("lease expired: %d", leaseID)
+			return 0, nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseExpired, leaseID)
 		}
 		m.leaseMu.RUnlock()
 	}
@@ -123,6 +204,7 @@ func (m *MemoryEtcd) PutWithLease(ctx context.Context, key, value string, leaseI
 
 	// 递增 revision（atomic 操作，无需加锁）
 	newRevision := m.revision.Add(1)
+	ts := m.nextHLC()
 
 	// 创建或更新 KeyValue
 	var version int64 = 1
@@ -139,10 +221,12 @@ func (m *MemoryEtcd) PutWithLease(ctx context.Context, key, value string, leaseI
 		ModRevision:    newRevision,
 		Version:        version,
 		Lease:          leaseID,
+		HLC:            ts,
 	}
 
 	// 存储到 ShardedMap（内部加锁）
 	m.kvData.Set(key, kv)
+	m.recordPut(kv)
 
 	// 如果有 lease，关联 key
 	if leaseID != 0 {
@@ -198,16 +282,18 @@ func (m *MemoryEtcd) DeleteRange(ctx context.Context, key, rangeEnd string) (int
 
 	// 递增 revision（atomic 操作，无需加锁）
 	newRevision := m.revision.Add(1)
+	ts := m.nextHLC()
 
 	// Collect events to send after deletion
 	events := make([]kvstore.WatchEvent, 0, len(keysToDelete))
 
 	// 执行删除
-	for _, k := range keysToDelete {
+	for i, k := range keysToDelete {
 		prevKv, _ := m.kvData.Get(k)
 
 		// 从 ShardedMap 删除（内部加锁）
 		m.kvData.Delete(k)
+		m.recordDelete(k, newRevision, int64(i))
 		deleted++
 
 		// 从 lease 中移除 key
@@ -228,6 +314,7 @@ func (m *MemoryEtcd) DeleteRange(ctx context.Context, key, rangeEnd string) (int
 				ModRevision:    newRevision,
 				Version:        0,
 				Lease:          0,
+				HLC:            ts,
 			}
 			events = append(events, kvstore.WatchEvent{
 				Type:     kvstore.EventTypeDelete,
@@ -250,13 +337,19 @@ func (m *MemoryEtcd) DeleteRange(ctx context.Context, key, rangeEnd string) (int
 func (m *MemoryEtcd) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
 	// 使用 txnMu 保护事务的原子性
 	m.txnMu.Lock()
-	defer m.txnMu.Unlock()
+	resp, events, err := m.txnUnlocked(cmps, thenOps, elseOps, m.nextHLC())
+	m.txnMu.Unlock()
 
-	return m.txnUnlocked(cmps, thenOps, elseOps)
+	// 触发 watch 事件（无需持有锁）
+	for _, event := range events {
+		m.notifyWatches(event)
+	}
+	return resp, err
 }
 
-// txnUnlocked 执行事务（需要持有锁）
-func (m *MemoryEtcd) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+// txnUnlocked 执行事务（需要持有锁），事务中的写入都使用 HLC ts
+// 返回写入产生的 watch 事件，由调用方释放锁后发布
+func (m *MemoryEtcd) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op, ts uint64) (*kvstore.TxnResponse, []kvstore.WatchEvent, error) {
 	// 评估所有 compare 条件
 	succeeded := true
 	for _, cmp := range cmps {
@@ -276,33 +369,38 @@ func (m *MemoryEtcd) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, e
 
 	// 执行操作
 	responses := make([]kvstore.OpResponse, len(ops))
+	var events []kvstore.WatchEvent
 	for i, op := range ops {
 		switch op.Type {
 		case kvstore.OpRange:
 			resp, err := m.rangeUnlocked(string(op.Key), string(op.RangeEnd), op.Limit)
 			if err != nil {
-				return nil, err
+				return nil, events, err
 			}
 			responses[i] = kvstore.OpResponse{
 				Type:      kvstore.OpRange,
 				RangeResp: resp,
 			}
 		case kvstore.OpPut:
-			revision, prevKv, err := m.putUnlocked(string(op.Key), string(op.Value), op.LeaseID)
+			kv, prevKv, err := m.putUnlocked(string(op.Key), string(op.Value), op.LeaseID, ts)
 			if err != nil {
-				return nil, err
+				return nil, events, err
 			}
+			events = append(events, putEvent(kv, prevKv))
 			responses[i] = kvstore.OpResponse{
 				Type: kvstore.OpPut,
 				PutResp: &kvstore.PutResponse{
 					PrevKv:   prevKv,
-					Revision: revision,
+					Revision: kv.ModRevision,
 				},
 			}
 		case kvstore.OpDelete:
 			deleted, prevKvs, revision, err := m.deleteUnlocked(string(op.Key), string(op.RangeEnd))
 			if err != nil {
-				return nil, err
+				return nil, events, err
+			}
+			for _, prevKv := range prevKvs {
+				events = append(events, deleteEvent(prevKv, revision, ts))
 			}
 			responses[i] = kvstore.OpResponse{
 				Type: kvstore.OpDelete,
@@ -319,7 +417,7 @@ func (m *MemoryEtcd) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, e
 		Succeeded: succeeded,
 		Responses: responses,
 		Revision:  m.revision.Load(),
-	}, nil
+	}, events, nil
 }
 
 // evaluateCompare 评估比较条件（需要持有 txnMu）
@@ -401,8 +499,8 @@ func (m *MemoryEtcd) rangeUnlocked(key, rangeEnd string, limit int64) (*kvstore.
 			kvs = append(kvs, kv)
 		}
 	} else {
-		// 使用 ShardedMap.Range() 获取范围内的键值对（内部已排序）
-		kvs = m.kvData.Range(key, rangeEnd, limit)
+		// 使用 ShardedMap.Range() 获取范围内的键值对（内部已排序），count 是范围内的键总数
+		kvs = m.kvData.Range(key, rangeEnd, 0)
 	}
 
 	more := false
@@ -420,13 +518,17 @@ func (m *MemoryEtcd) rangeUnlocked(key, rangeEnd string, limit int64) (*kvstore.
 	}, nil
 }
 
-func (m *MemoryEtcd) putUnlocked(key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+func (m *MemoryEtcd) putUnlocked(key, value string, leaseID int64, ts uint64) (*kvstore.KeyValue, *kvstore.KeyValue, error) {
 	if leaseID != 0 {
 		m.leaseMu.RLock()
 		lease, ok := m.leases[leaseID]
-		if !ok || lease.IsExpired() {
+		if !ok {
 			m.leaseMu.RUnlock()
-			return 0, nil, fmt.Errorf("invalid lease")
+			return nil, nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, leaseID)
+		}
+		if lease.IsExpired() {
+			m.leaseMu.RUnlock()
+			return nil, nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseExpired, leaseID)
 		}
 		m.leaseMu.RUnlock()
 	}
@@ -448,9 +550,11 @@ func (m *MemoryEtcd) putUnlocked(key, value string, leaseID int64) (int64, *kvst
 		ModRevision:    newRevision,
 		Version:        version,
 		Lease:          leaseID,
+		HLC:            ts,
 	}
 
 	m.kvData.Set(key, kv)
+	m.recordPut(kv)
 
 	if leaseID != 0 {
 		m.leaseMu.Lock()
@@ -466,7 +570,7 @@ func (m *MemoryEtcd) putUnlocked(key, value string, leaseID int64) (int64, *kvst
 	// NOTE: putUnlocked does NOT notify watches - caller must do it after releasing lock
 	// This avoids deadlock when called from Txn which holds the lock
 
-	return newRevision, prevKv, nil
+	return kv, prevKv, nil
 }
 
 func (m *MemoryEtcd) deleteUnlocked(key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
@@ -495,9 +599,10 @@ func (m *MemoryEtcd) deleteUnlocked(key, rangeEnd string) (int64, []*kvstore.Key
 
 	newRevision := m.revision.Add(1)
 
-	for _, k := range keysToDelete {
+	for i, k := range keysToDelete {
 		prevKv, _ := m.kvData.Get(k)
 		m.kvData.Delete(k)
+		m.recordDelete(k, newRevision, int64(i))
 		deleted++
 
 		if prevKv != nil && prevKv.Lease != 0 {
@@ -541,18 +646,11 @@ func (m *MemoryEtcd) GetSnapshot() ([]byte, error) {
 }
 
 // Compact 压缩指定 revision 之前的历史数据
+//
+// 每个键保留 revision 时刻可见的版本，更早的版本从 MVCC 历史中删除。
+// 过期 Lease 的清理由 LeaseManager 定期处理。
 func (m *MemoryEtcd) Compact(ctx context.Context, revision int64) error {
-	// etcd 的 Compact 用于压缩历史版本，清理指定 revision 之前的数据
-	//
-	// 对于内存存储：
-	// 1. 当前不保留 MVCC 历史版本，每次更新直接覆盖
-	// 2. 过期 Lease 的清理由 LeaseManager 定期处理
-	// 3. 这里只需保持 API 兼容性
-	//
-	// 未来可扩展：实现 MVCC 历史版本管理和压缩
-	// 当前实现：no-op
-
-	return nil
+	return m.history.Compact(revision)
 }
 
 // GetRaftStatus returns Raft status information
@@ -39,12 +39,13 @@ import (
 //   - key: 键
 //   - value: 值
 //   - leaseID: 租约 ID (0 表示无租约)
+//   - ts: 写入的 HLC
 //
 // 返回：
 //   - revision: 当前 revision
 //   - prevKv: 之前的值 (如果存在)
 //   - error: 错误信息
-func (m *MemoryEtcd) putDirect(key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+func (m *MemoryEtcd) putDirect(key, value string, leaseID int64, ts uint64) (int64, *kvstore.KeyValue, error) {
 	// 1. 生成新的 revision (atomic 操作，无需加锁)
 	newRevision := m.revision.Add(1)
 
@@ -71,10 +72,12 @@ func (m *MemoryEtcd) putDirect(key, value string, leaseID int64) (int64, *kvstor
 		ModRevision:    newRevision,
 		Version:        version,
 		Lease:          leaseID,
+		HLC:            ts,
 	}
 
-	// 4. 写入 ShardedMap (内部加锁)
+	// 4. 写入 ShardedMap (内部加锁)，并记录到 MVCC 历史
 	m.kvData.Set(key, kv)
+	m.recordPut(kv)
 
 	// 5. 关联租约 (需要 leaseMu，因为 leases 不是 ShardedMap)
 	if leaseID != 0 {
@@ -86,7 +89,7 @@ func (m *MemoryEtcd) putDirect(key, value string, leaseID int64) (int64, *kvstor
 	}
 
 	// 6. 通知 watchers (watchMu 保护)
-	m.notifyWatchers(key, kv, kvstore.EventTypePut)
+	m.notifyPut(kv, prevKv)
 
 	return newRevision, prevKv, nil
 }
@@ -98,13 +101,14 @@ func (m *MemoryEtcd) putDirect(key, value string, leaseID int64) (int64, *kvstor
 // 参数：
 //   - key: 起始键
 //   - rangeEnd: 结束键 (空字符串表示单键删除)
+//   - ts: 删除的 HLC
 //
 // 返回：
 //   - deleted: 删除的键数量
 //   - prevKvs: 删除前的值列表
 //   - revision: 当前 revision
 //   - error: 错误信息
-func (m *MemoryEtcd) deleteDirect(key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+func (m *MemoryEtcd) deleteDirect(key, rangeEnd string, ts uint64) (int64, []*kvstore.KeyValue, int64, error) {
 	var deleted int64
 	var prevKvs []*kvstore.KeyValue
 
@@ -116,6 +120,7 @@ func (m *MemoryEtcd) deleteDirect(key, rangeEnd string) (int64, []*kvstore.KeyVa
 
 			// 删除键 (ShardedMap 内部加锁)
 			m.kvData.Delete(key)
+			m.recordDelete(key, newRevision, 0)
 
 			// 解除 lease 关联
 			if kv.Lease != 0 {
@@ -127,7 +132,7 @@ func (m *MemoryEtcd) deleteDirect(key, rangeEnd string) (int64, []*kvstore.KeyVa
 			}
 
 			// 通知 watchers
-			m.notifyWatchers(key, kv, kvstore.EventTypeDelete)
+			m.notifyDelete(kv, newRevision, ts)
 
 			deleted = 1
 			prevKvs = append(prevKvs, kv)
@@ -149,15 +154,16 @@ func (m *MemoryEtcd) deleteDirect(key, rangeEnd string) (int64, []*kvstore.KeyVa
 		return 0, nil, m.revision.Load(), nil
 	}
 
-	// 逐个删除键
-	for _, kv := range keysToDelete {
-		// 生成新 revision (每次删除都更新 revision)
-		m.revision.Add(1)
+	// 一次范围删除只占用一个 revision，与 DeleteRange 相同
+	newRevision := m.revision.Add(1)
 
+	// 逐个删除键
+	for i, kv := range keysToDelete {
 		keyStr := string(kv.Key)
 
 		// 删除键
 		m.kvData.Delete(keyStr)
+		m.recordDelete(keyStr, newRevision, int64(i))
 
 		// 解除 lease 关联
 		if kv.Lease != 0 {
@@ -169,13 +175,13 @@ func (m *MemoryEtcd) deleteDirect(key, rangeEnd string) (int64, []*kvstore.KeyVa
 		}
 
 		// 通知 watchers
-		m.notifyWatchers(keyStr, kv, kvstore.EventTypeDelete)
+		m.notifyDelete(kv, newRevision, ts)
 
 		deleted++
 		prevKvs = append(prevKvs, kv)
 	}
 
-	return deleted, prevKvs, m.revision.Load(), nil
+	return deleted, prevKvs, newRevision, nil
 }
 
 // applyTxnWithShardLocks 使用全局锁执行事务
@@ -196,17 +202,22 @@ func (m *MemoryEtcd) deleteDirect(key, rangeEnd string) (int64, []*kvstore.KeyVa
 //   - compares: 比较条件
 //   - thenOps: 成功时执行的操作
 //   - elseOps: 失败时执行的操作
+//   - ts: 事务的 HLC
 //
 // 返回：
 //   - *kvstore.TxnResponse: 事务响应
 //   - error: 错误信息
-func (m *MemoryEtcd) applyTxnWithShardLocks(compares []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+func (m *MemoryEtcd) applyTxnWithShardLocks(compares []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op, ts uint64) (*kvstore.TxnResponse, error) {
 	// 使用全局 txnMu 锁保证事务原子性
 	m.txnMu.Lock()
-	defer m.txnMu.Unlock()
-
 	// 执行事务逻辑
-	return m.txnUnlocked(compares, thenOps, elseOps)
+	resp, events, err := m.txnUnlocked(compares, thenOps, elseOps, ts)
+	m.txnMu.Unlock()
+
+	for _, event := range events {
+		m.notifyWatches(event)
+	}
+	return resp, err
 }
 
 // applyLeaseOperationDirect 直接执行 lease 操作，不使用全局锁
@@ -219,7 +230,8 @@ func (m *MemoryEtcd) applyTxnWithShardLocks(compares []kvstore.Compare, thenOps
 //   - opType: 操作类型 ("LEASE_GRANT" 或 "LEASE_REVOKE")
 //   - leaseID: 租约 ID
 //   - ttl: TTL (仅 GRANT 时使用)
-func (m *MemoryEtcd) applyLeaseOperationDirect(opType string, leaseID int64, ttl int64) {
+//   - ts: 操作的 HLC (REVOKE 删除关联的键时使用)
+func (m *MemoryEtcd) applyLeaseOperationDirect(opType string, leaseID int64, ttl int64, ts uint64) {
 	switch opType {
 	case "LEASE_GRANT":
 		m.leaseMu.Lock()
@@ -254,46 +266,42 @@ func (m *MemoryEtcd) applyLeaseOperationDirect(opType string, leaseID int64, ttl
 
 		// 删除关联的键 (不持有 leaseMu，避免死锁)
 		for _, key := range keysToDelete {
-			m.deleteDirect(key, "")
+			m.deleteDirect(key, "", ts)
 		}
 	}
 }
 
-// notifyWatchers 通知所有匹配的 watchers
-//
-// 并发安全性：使用 watchMu 保护 watches map
-//
-// 参数：
-//   - key: 键
-//   - kv: KeyValue
-//   - eventType: 事件类型
-func (m *MemoryEtcd) notifyWatchers(key string, kv *kvstore.KeyValue, eventType kvstore.EventType) {
-	m.watchMu.RLock()
-	defer m.watchMu.RUnlock()
-
-	for _, sub := range m.watches {
-		// 检查是否匹配
-		if m.watchMatches(sub, key) {
-			// 发送事件 (non-blocking)
-			select {
-			case sub.eventCh <- kvstore.WatchEvent{
-				Type: eventType,
-				Kv:   kv,
-			}:
-			default:
-				// 如果 channel 满了，跳过 (避免阻塞)
-			}
-		}
-	}
+// notifyPut 发布 PUT 事件，与 PutWithLease 发布的事件相同
+func (m *MemoryEtcd) notifyPut(kv, prevKv *kvstore.KeyValue) {
+	m.notifyWatches(putEvent(kv, prevKv))
 }
 
-// watchMatches 检查 key 是否匹配 watch 订阅
-func (m *MemoryEtcd) watchMatches(sub *watchSubscription, key string) bool {
-	if sub.rangeEnd == "" {
-		// 单键匹配
-		return key == sub.key
+// notifyDelete 发布 DELETE 事件，与 DeleteRange 发布的事件相同
+func (m *MemoryEtcd) notifyDelete(prevKv *kvstore.KeyValue, revision int64, ts uint64) {
+	m.notifyWatches(deleteEvent(prevKv, revision, ts))
+}
+
+// putEvent 返回写入 kv 的 PUT 事件
+func putEvent(kv, prevKv *kvstore.KeyValue) kvstore.WatchEvent {
+	return kvstore.WatchEvent{
+		Type:     kvstore.EventTypePut,
+		Kv:       kv,
+		PrevKv:   prevKv,
+		Revision: kv.ModRevision,
 	}
+}
 
-	// 范围匹配
-	return key >= sub.key && (sub.rangeEnd == "\x00" || key < sub.rangeEnd)
+// deleteEvent 返回删除 prevKv 的 DELETE 事件，Kv 只带 key 和删除时的 revision、HLC
+func deleteEvent(prevKv *kvstore.KeyValue, revision int64, ts uint64) kvstore.WatchEvent {
+	return kvstore.WatchEvent{
+		Type: kvstore.EventTypeDelete,
+		Kv: &kvstore.KeyValue{
+			Key:            prevKv.Key,
+			CreateRevision: prevKv.CreateRevision,
+			ModRevision:    revision,
+			HLC:            ts,
+		},
+		PrevKv:   prevKv,
+		Revision: revision,
+	}
 }
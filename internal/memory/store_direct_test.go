@@ -47,7 +47,7 @@ func TestPutDirectConcurrent(t *testing.T) {
 				key := fmt.Sprintf("key-%d-%d", id, j)
 				value := fmt.Sprintf("value-%d-%d", id, j)
 
-				_, _, err := m.putDirect(key, value, 0)
+				_, _, err := m.putDirect(key, value, 0, 0)
 				if err != nil {
 					t.Errorf("putDirect failed: %v", err)
 				}
@@ -105,7 +105,7 @@ func TestPutDirectSameKeyConcurrent(t *testing.T) {
 			<-startCh
 
 			value := fmt.Sprintf("value-%d", id)
-			m.putDirect(key, value, 0)
+			m.putDirect(key, value, 0, 0)
 		}(i)
 	}
 
@@ -143,7 +143,7 @@ func TestDeleteDirectConcurrent(t *testing.T) {
 	numKeys := 1000
 	for i := 0; i < numKeys; i++ {
 		key := fmt.Sprintf("key-%d", i)
-		m.putDirect(key, "value", 0)
+		m.putDirect(key, "value", 0, 0)
 	}
 
 	concurrency := 100
@@ -161,7 +161,7 @@ func TestDeleteDirectConcurrent(t *testing.T) {
 			// 每个 goroutine 删除一部分 key
 			for j := id * (numKeys / concurrency); j < (id+1)*(numKeys/concurrency); j++ {
 				key := fmt.Sprintf("key-%d", j)
-				m.deleteDirect(key, "")
+				m.deleteDirect(key, "", 0)
 			}
 		}(i)
 	}
@@ -181,8 +181,8 @@ func TestApplyTxnWithShardLocks(t *testing.T) {
 	m := NewMemoryEtcd()
 
 	// 写入初始数据
-	m.putDirect("key1", "value1", 0)
-	m.putDirect("key2", "value2", 0)
+	m.putDirect("key1", "value1", 0, 0)
+	m.putDirect("key2", "value2", 0, 0)
 
 	// 测试事务: if key1 == "value1" then put key2 = "updated"
 	compares := []kvstore.Compare{
@@ -206,7 +206,7 @@ func TestApplyTxnWithShardLocks(t *testing.T) {
 
 	elseOps := []kvstore.Op{}
 
-	resp, err := m.applyTxnWithShardLocks(compares, thenOps, elseOps)
+	resp, err := m.applyTxnWithShardLocks(compares, thenOps, elseOps, 0)
 	if err != nil {
 		t.Fatalf("Transaction failed: %v", err)
 	}
@@ -231,7 +231,7 @@ func TestConcurrentTransactions(t *testing.T) {
 	m := NewMemoryEtcd()
 
 	// 初始化计数器
-	m.putDirect("counter", "0", 0)
+	m.putDirect("counter", "0", 0, 0)
 
 	concurrency := 100
 	var wg sync.WaitGroup
@@ -275,7 +275,7 @@ func TestConcurrentTransactions(t *testing.T) {
 				},
 			}
 
-			resp, err := m.applyTxnWithShardLocks(compares, thenOps, []kvstore.Op{})
+			resp, err := m.applyTxnWithShardLocks(compares, thenOps, []kvstore.Op{}, 0)
 			if err == nil && resp.Succeeded {
 				successCount.Add(1)
 			}
@@ -311,7 +311,7 @@ func TestLeaseOperationsConcurrent(t *testing.T) {
 			<-startCh
 
 			leaseID := int64(id)
-			m.applyLeaseOperationDirect("LEASE_GRANT", leaseID, 60)
+			m.applyLeaseOperationDirect("LEASE_GRANT", leaseID, 60, 0)
 		}(i)
 	}
 
@@ -337,7 +337,7 @@ func TestLeaseOperationsConcurrent(t *testing.T) {
 			<-startCh2
 
 			leaseID := int64(id)
-			m.applyLeaseOperationDirect("LEASE_REVOKE", leaseID, 0)
+			m.applyLeaseOperationDirect("LEASE_REVOKE", leaseID, 0, 0)
 		}(i)
 	}
 
@@ -361,7 +361,7 @@ func BenchmarkPutDirectSequential(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		key := fmt.Sprintf("key-%d", i)
-		m.putDirect(key, "value", 0)
+		m.putDirect(key, "value", 0, 0)
 	}
 }
 
@@ -374,7 +374,7 @@ func BenchmarkPutDirectParallel(b *testing.B) {
 		i := 0
 		for pb.Next() {
 			key := fmt.Sprintf("key-%d", i)
-			m.putDirect(key, "value", 0)
+			m.putDirect(key, "value", 0, 0)
 			i++
 		}
 	})
@@ -385,7 +385,7 @@ func BenchmarkTxnWithShardLocks(b *testing.B) {
 	m := NewMemoryEtcd()
 
 	// 初始化数据
-	m.putDirect("key1", "value1", 0)
+	m.putDirect("key1", "value1", 0, 0)
 
 	compares := []kvstore.Compare{
 		{
@@ -408,7 +408,7 @@ func BenchmarkTxnWithShardLocks(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		m.applyTxnWithShardLocks(compares, thenOps, []kvstore.Op{})
+		m.applyTxnWithShardLocks(compares, thenOps, []kvstore.Op{}, 0)
 	}
 }
 
@@ -442,7 +442,7 @@ func TestRaceConditions(t *testing.T) {
 				default:
 					key := fmt.Sprintf("key-%d", id%1000)
 					value := fmt.Sprintf("value-%d", time.Now().UnixNano())
-					m.putDirect(key, value, 0)
+					m.putDirect(key, value, 0, 0)
 					totalOps.Add(1)
 				}
 			}
@@ -461,7 +461,7 @@ func TestRaceConditions(t *testing.T) {
 					return
 				default:
 					key := fmt.Sprintf("key-%d", id%1000)
-					m.deleteDirect(key, "")
+					m.deleteDirect(key, "", 0)
 					totalOps.Add(1)
 				}
 			}
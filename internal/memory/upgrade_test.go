@@ -0,0 +1,86 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+)
+
+// TestApplyPreviousReleaseFormats 滚动升级期间，新版本应用旧版本成员提交的每种提案格式
+func TestApplyPreviousReleaseFormats(t *testing.T) {
+	commitC := make(chan *kvstore.Commit)
+	errorC := make(chan error)
+	m := NewMemory(snap.New(nil, t.TempDir()), make(chan string), commitC, errorC)
+	defer close(errorC)
+	defer close(commitC)
+
+	// enable_protobuf 关闭的成员以 JSON 提案
+	jsonOp, err := json.Marshal(RaftOperation{Type: "PUT", Key: "json", Value: "v1", SeqNum: "old-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbOp, err := serializeOperation(RaftOperation{Type: "PUT", Key: "pb", Value: "v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 最早的版本以 gob 编码 KV
+	var gobKV bytes.Buffer
+	if err := gob.NewEncoder(&gobKV).Encode(kvstore.KV{Key: "gob", Val: "v3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, data := range []string{string(jsonOp), string(pbOp), gobKV.String()} {
+		applyDoneC := make(chan struct{})
+		commitC <- &kvstore.Commit{Data: []string{data}, ApplyDoneC: applyDoneC, Index: uint64(i + 1)}
+		<-applyDoneC
+	}
+
+	for key, value := range map[string]string{"json": "v1", "pb": "v2", "gob": "v3"} {
+		resp, err := m.Range(context.Background(), key, "", 0, 0)
+		if err != nil || len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != value {
+			t.Errorf("Range(%s) = %+v, %v, expected %s", key, resp, err, value)
+		}
+	}
+}
+
+// TestRecoverPreviousReleaseSnapshot 新版本从旧版本成员生成的 JSON 快照恢复
+func TestRecoverPreviousReleaseSnapshot(t *testing.T) {
+	config.SetEnableSnapshotProtobuf(false)
+	data, err := serializeSnapshot(7, map[string]*kvstore.KeyValue{
+		"k": {Key: []byte("k"), Value: []byte("v"), CreateRevision: 7, ModRevision: 7, Version: 1},
+	}, nil, 0)
+	config.SetEnableSnapshotProtobuf(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMemory(snap.New(nil, t.TempDir()), make(chan string), make(chan *kvstore.Commit), make(chan error))
+	if err := m.recoverFromSnapshot(data); err != nil {
+		t.Fatalf("Failed to recover from JSON snapshot: %v", err)
+	}
+	resp, err := m.Range(context.Background(), "k", "", 0, 0)
+	if err != nil || len(resp.Kvs) != 1 || string(resp.Kvs[0].Value) != "v" || m.CurrentRevision() != 7 {
+		t.Errorf("Unexpected state after recovery: %+v, %v, revision %d", resp, err, m.CurrentRevision())
+	}
+}
@@ -18,7 +18,9 @@ import (
 	"context"
 	"fmt"
 	"metaStore/internal/kvstore"
+	"metaStore/internal/mvcc"
 	"metaStore/pkg/log"
+	"sort"
 	"time"
 
 	"go.uber.org/zap"
@@ -39,17 +41,29 @@ func (m *MemoryEtcd) WatchWithOptions(key, rangeEnd string, startRevision int64,
 		return nil, fmt.Errorf("watch ID %d already exists", watchID)
 	}
 
-	// 创建事件通道（带缓冲以避免阻塞）
-	eventCh := make(chan kvstore.WatchEvent, 100)
+	// startRevision 之后已经发生的事件从 MVCC 历史中回放
+	var replay []kvstore.WatchEvent
+	if startRevision > 0 && startRevision <= m.revision.Load() {
+		var err error
+		replay, err = m.historyEvents(key, rangeEnd, startRevision)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建事件通道（带缓冲以避免阻塞），回放事件全部放入缓冲区
+	eventCh := make(chan kvstore.WatchEvent, 100+len(replay))
 
 	// Parse options
 	var prevKV, progressNotify, fragment bool
 	var filters []kvstore.WatchFilterType
+	var valueFilter func([]byte) bool
 	if opts != nil {
 		prevKV = opts.PrevKV
 		progressNotify = opts.ProgressNotify
 		filters = opts.Filters
 		fragment = opts.Fragment
+		valueFilter = opts.ValueFilter
 	}
 
 	// 创建订阅
@@ -64,51 +78,27 @@ func (m *MemoryEtcd) WatchWithOptions(key, rangeEnd string, startRevision int64,
 		progressNotify: progressNotify,
 		filters:        filters,
 		fragment:       fragment,
+		valueFilter:    valueFilter,
 	}
 
-	m.watches[watchID] = sub
-
-	// 如果 startRevision > 0，发送历史事件
-	// 注意：当前实现不保留完整历史，只能从当前数据生成初始快照
-	if startRevision > 0 && startRevision < m.revision.Load() {
-		// 异步发送当前所有匹配的键作为 PUT 事件
-		go m.sendHistoricalEvents(sub, key, rangeEnd)
+	// 持有 watchMu 时 notifyWatches 无法投递，回放事件一定排在实时事件之前；
+	// 已回放的 revision 不再由 notifyWatches 重复发送
+	for _, event := range replay {
+		if m.shouldFilter(event.Type, filters) || !kvstore.MatchValue(event, valueFilter) {
+			continue
+		}
+		if !prevKV {
+			event.PrevKv = nil
+		}
+		eventCh <- event
+	}
+	if n := len(replay); n > 0 {
+		sub.replayedRev = replay[n-1].Revision
 	}
 
-	return eventCh, nil
-}
-
-// sendHistoricalEvents 发送历史事件（从当前数据快照）
-func (m *MemoryEtcd) sendHistoricalEvents(sub *watchSubscription, key, rangeEnd string) {
-	// 使用 ShardedMap.GetAll() 获取所有数据（内部加锁）
-	allData := m.kvData.GetAll()
-
-	// 获取所有匹配的键
-	for k, kv := range allData {
-		if m.matchWatch(k, key, rangeEnd) {
-			event := kvstore.WatchEvent{
-				Type:     kvstore.EventTypePut,
-				Kv:       kv,
-				PrevKv:   nil, // 历史事件不返回 prevKv
-				Revision: kv.ModRevision,
-			}
+	m.watches[watchID] = sub
 
-			// 非阻塞发送
-			select {
-			case sub.eventCh <- event:
-				// 成功发送
-			case <-sub.cancel:
-				// Watch 已取消
-				return
-			default:
-				// Channel 满了，跳过此事件
-				log.Warn("Watch channel full, skipping historical event",
-				zap.Int64("watchID", sub.watchID),
-				zap.String("key", k),
-				zap.String("component", "watch"))
-			}
-		}
-	}
+	return eventCh, nil
 }
 
 // CancelWatch 取消一个 watch
@@ -139,8 +129,37 @@ func (m *MemoryEtcd) CancelWatch(watchID int64) error {
 	return nil
 }
 
+// Watches 返回当前活跃的 watch（按 ID 排序）
+func (m *MemoryEtcd) Watches() []kvstore.WatchInfo {
+	m.watchMu.RLock()
+	infos := make([]kvstore.WatchInfo, 0, len(m.watches))
+	for _, sub := range m.watches {
+		if sub.closed.Load() {
+			continue
+		}
+		infos = append(infos, kvstore.WatchInfo{
+			ID:            sub.watchID,
+			Key:           sub.key,
+			RangeEnd:      sub.rangeEnd,
+			StartRevision: sub.startRev,
+			PrevKV:        sub.prevKV,
+			Pending:       len(sub.eventCh) + sub.queue.Len(),
+		})
+	}
+	m.watchMu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
 // notifyWatches 通知所有匹配的 watch (high-performance lock-free version)
 func (m *MemoryEtcd) notifyWatches(event kvstore.WatchEvent) {
+	m.events.Append(event.Revision, mvcc.WatchEvent{
+		Type:   mvcc.EventType(event.Type),
+		Kv:     (*mvcc.KeyValue)(event.Kv),
+		PrevKv: (*mvcc.KeyValue)(event.PrevKv),
+	})
+
 	key := ""
 	if event.Kv != nil {
 		key = string(event.Kv.Key)
@@ -164,7 +183,11 @@ func (m *MemoryEtcd) notifyWatches(event kvstore.WatchEvent) {
 	// Send events outside of lock
 	for _, sub := range matchingSubs {
 		// Apply filters
-		if m.shouldFilter(event.Type, sub.filters) {
+		if m.shouldFilter(event.Type, sub.filters) || !kvstore.MatchValue(event, sub.valueFilter) {
+			continue
+		}
+		// Already delivered by history replay
+		if event.Revision <= sub.replayedRev {
 			continue
 		}
 
@@ -174,16 +197,8 @@ func (m *MemoryEtcd) notifyWatches(event kvstore.WatchEvent) {
 			eventToSend.PrevKv = nil
 		}
 
-		// Non-blocking send with slow client handling
-		select {
-		case sub.eventCh <- eventToSend:
-			// Success
-		case <-sub.cancel:
-			// Watch已取消
-		default:
-			// Channel满了，异步发送（慢客户端）
-			go m.slowSendEvent(sub, eventToSend)
-		}
+		// 按通知顺序投递，通道满时排队，不会因为慢客户端打乱顺序
+		sub.queue.Send(sub.eventCh, sub.cancel, eventToSend, func() { m.cancelSlowWatch(sub) })
 	}
 }
 
@@ -204,21 +219,10 @@ func (m *MemoryEtcd) shouldFilter(eventType kvstore.EventType, filters []kvstore
 	return false
 }
 
-// slowSendEvent handles slow clients with timeout
-func (m *MemoryEtcd) slowSendEvent(sub *watchSubscription, event kvstore.WatchEvent) {
-	timer := time.NewTimer(5 * time.Second)
-	defer timer.Stop()
-
-	select {
-	case sub.eventCh <- event:
-		// Successfully sent after retry
-	case <-sub.cancel:
-		// Watch cancelled
-	case <-timer.C:
-		// Timeout - force cancel this slow watch
-		log.Warn("Watch is too slow, force cancelling", zap.Int64("watch_id", sub.watchID), zap.String("component", "memory-watch"))
-		m.CancelWatch(sub.watchID)
-	}
+// cancelSlowWatch 取消超时未取走事件的慢 watch
+func (m *MemoryEtcd) cancelSlowWatch(sub *watchSubscription) {
+	log.Warn("Watch is too slow, force cancelling", zap.Int64("watch_id", sub.watchID), zap.String("component", "memory-watch"))
+	m.CancelWatch(sub.watchID)
 }
 
 // matchWatch 检查 key 是否匹配 watch 范围
@@ -238,7 +242,7 @@ func (m *MemoryEtcd) LeaseGrant(ctx context.Context, id int64, ttl int64) (*kvst
 
 	// 检查 lease 是否已存在
 	if _, ok := m.leases[id]; ok {
-		return nil, fmt.Errorf("lease already exists: %d", id)
+		return nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseExists, id)
 	}
 
 	lease := &kvstore.Lease{
@@ -259,7 +263,7 @@ func (m *MemoryEtcd) LeaseRevoke(ctx context.Context, id int64) error {
 	lease, ok := m.leases[id]
 	if !ok {
 		m.leaseMu.Unlock()
-		return fmt.Errorf("lease not found: %d", id)
+		return fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, id)
 	}
 
 	// Collect events to send after releasing lock
@@ -270,9 +274,11 @@ func (m *MemoryEtcd) LeaseRevoke(ctx context.Context, id int64) error {
 		if kv, exists := m.kvData.Get(key); exists {
 			// 递增 revision
 			newRevision := m.revision.Add(1)
+			ts := m.nextHLC()
 
 			// 删除键（ShardedMap 内部加锁）
 			m.kvData.Delete(key)
+			m.recordDelete(key, newRevision, 0)
 
 			// Prepare watch event
 			// For DELETE events, Kv contains the deleted key with ModRevision set to deletion revision
@@ -283,6 +289,7 @@ func (m *MemoryEtcd) LeaseRevoke(ctx context.Context, id int64) error {
 				ModRevision:    newRevision, // Set to deletion revision
 				Version:        0,           // Version is 0 for deleted key
 				Lease:          0,
+				HLC:            ts,
 			}
 			events = append(events, kvstore.WatchEvent{
 				Type:     kvstore.EventTypeDelete,
@@ -314,7 +321,7 @@ func (m *MemoryEtcd) LeaseRenew(ctx context.Context, id int64) (*kvstore.Lease,
 
 	lease, ok := m.leases[id]
 	if !ok {
-		return nil, fmt.Errorf("lease not found: %d", id)
+		return nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, id)
 	}
 
 	// 续约
@@ -329,7 +336,7 @@ func (m *MemoryEtcd) LeaseTimeToLive(ctx context.Context, id int64) (*kvstore.Le
 
 	lease, ok := m.leases[id]
 	if !ok {
-		return nil, fmt.Errorf("lease not found: %d", id)
+		return nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, id)
 	}
 
 	// 返回 lease 的副本
@@ -0,0 +1,223 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventLog keeps the most recent watch events so that watchers resuming after
+// a reconnect can catch up even when the MVCC history has been compacted.
+// It is bounded by its own event count and age, independently of compaction,
+// and always drops whole revisions, so the events it returns are complete.
+//
+// The log is held in memory: after a restart it starts empty and only covers
+// revisions applied since.
+type EventLog struct {
+	mu        sync.RWMutex
+	events    []loggedEvent // Ordered by revision
+	maxEvents int
+	retention time.Duration
+	compacted int64 // Events at or before this revision may be missing
+	now       func() time.Time
+}
+
+type loggedEvent struct {
+	ev      WatchEvent
+	rev     int64
+	at      time.Time
+	omitted *keySpan // Keys whose events at rev are missing, nil for an event
+}
+
+// keySpan is a range of keys [start, end), an empty end runs to the last key
+type keySpan struct {
+	start, end []byte
+}
+
+func (s *keySpan) contains(key []byte) bool {
+	return bytes.Compare(key, s.start) >= 0 && (len(s.end) == 0 || bytes.Compare(key, s.end) < 0)
+}
+
+// NewEventLog creates a log holding at most maxEvents events younger than
+// retention. A zero maxEvents disables the log, a zero retention keeps events
+// until the count limit is reached.
+func NewEventLog(maxEvents int, retention time.Duration) *EventLog {
+	return &EventLog{maxEvents: maxEvents, retention: retention, now: time.Now}
+}
+
+// SetLimits changes the bounds of the log and trims it accordingly.
+func (l *EventLog) SetLimits(maxEvents int, retention time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxEvents = maxEvents
+	l.retention = retention
+	l.trimLocked()
+}
+
+// Append records an event that happened at rev. Events normally arrive in
+// revision order; a late event is inserted at its position.
+func (l *EventLog) Append(rev int64, ev WatchEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rev <= l.compacted {
+		return
+	}
+	if l.maxEvents <= 0 {
+		l.compacted = rev
+		return
+	}
+
+	l.insertLocked(loggedEvent{ev: cloneEvent(ev), rev: rev, at: l.now()})
+}
+
+// Omit records that the events of the keys in [start, end) at rev are not in
+// the log, e.g. those of a range deleted without reading its keys. An empty
+// end runs to the last key. Watchers of an overlapping range cannot resume
+// from rev or earlier.
+func (l *EventLog) Omit(rev int64, start, end []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rev <= l.compacted {
+		return
+	}
+	if l.maxEvents <= 0 {
+		l.compacted = rev
+		return
+	}
+	span := &keySpan{start: bytes.Clone(start), end: bytes.Clone(end)}
+	l.insertLocked(loggedEvent{rev: rev, at: l.now(), omitted: span})
+}
+
+// insertLocked adds an entry at the position of its revision.
+func (l *EventLog) insertLocked(e loggedEvent) {
+	rev := e.rev
+	i := len(l.events)
+	for i > 0 && l.events[i-1].rev > rev {
+		i--
+	}
+	l.events = append(l.events, loggedEvent{})
+	copy(l.events[i+1:], l.events[i:])
+	l.events[i] = e
+	l.trimLocked()
+}
+
+// Events returns the events at or after fromRev whose key satisfies match.
+// ErrCompacted is returned when some of those events have been dropped; the
+// caller checks Omitted for events that were never logged.
+func (l *EventLog) Events(fromRev int64, match func(key []byte) bool) ([]WatchEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if fromRev <= l.compacted {
+		return nil, ErrCompacted
+	}
+	i := sort.Search(len(l.events), func(i int) bool { return l.events[i].rev >= fromRev })
+
+	var events []WatchEvent
+	for _, e := range l.events[i:] {
+		key := e.ev.PrevKv
+		if e.ev.Kv != nil {
+			key = e.ev.Kv
+		}
+		if key != nil && match(key.Key) {
+			events = append(events, cloneEvent(e.ev))
+		}
+	}
+	return events, nil
+}
+
+// Omitted reports whether events at or after fromRev were omitted for a range
+// of keys satisfying overlaps.
+func (l *EventLog) Omitted(fromRev int64, overlaps func(start, end []byte) bool) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	i := sort.Search(len(l.events), func(i int) bool { return l.events[i].rev >= fromRev })
+	for _, e := range l.events[i:] {
+		if e.omitted != nil && overlaps(e.omitted.start, e.omitted.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyEvents returns the logged events of key in revision order, together with
+// the revision at or before which events may be missing.
+func (l *EventLog) KeyEvents(key []byte) ([]WatchEvent, int64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var events []WatchEvent
+	compacted := l.compacted
+	for _, e := range l.events {
+		if e.omitted != nil {
+			// Earlier events of the key are no longer its latest ones
+			if e.omitted.contains(key) {
+				events = events[:0]
+				compacted = e.rev
+			}
+			continue
+		}
+		k := e.ev.PrevKv
+		if e.ev.Kv != nil {
+			k = e.ev.Kv
+		}
+		if k != nil && bytes.Equal(k.Key, key) {
+			events = append(events, cloneEvent(e.ev))
+		}
+	}
+	return events, compacted
+}
+
+// Reset drops all events and reports everything up to rev as missing, e.g.
+// after the state has been replaced by a snapshot.
+func (l *EventLog) Reset(rev int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.events)
+	l.events = l.events[:0]
+	l.compacted = rev
+}
+
+// trimLocked drops the oldest revisions until the log is within its bounds.
+func (l *EventLog) trimLocked() {
+	cutoff := l.now().Add(-l.retention)
+	n := 0
+	for n < len(l.events) {
+		e := l.events[n]
+		if len(l.events)-n <= l.maxEvents && (l.retention <= 0 || e.at.After(cutoff)) {
+			break
+		}
+		for n < len(l.events) && l.events[n].rev == e.rev {
+			n++
+		}
+		l.compacted = e.rev
+	}
+	if n > 0 {
+		clear(l.events[:n])
+		l.events = l.events[n:]
+	}
+}
+
+func cloneEvent(ev WatchEvent) WatchEvent {
+	ev.Kv = ev.Kv.Clone()
+	ev.PrevKv = ev.PrevKv.Clone()
+	return ev
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func putEvent(key string, rev int64) WatchEvent {
+	return WatchEvent{Type: EventTypePut, Kv: &KeyValue{Key: []byte(key), Value: []byte(fmt.Sprint(rev)), ModRevision: rev}}
+}
+
+func matchAll([]byte) bool { return true }
+
+func TestEventLogBoundedByCount(t *testing.T) {
+	l := NewEventLog(3, 0)
+	l.Append(1, putEvent("a", 1))
+	l.Append(2, putEvent("b", 2))
+	// Two keys deleted by one DeleteRange share revision 3
+	l.Append(3, WatchEvent{Type: EventTypeDelete, Kv: &KeyValue{Key: []byte("a"), ModRevision: 3}})
+	l.Append(3, WatchEvent{Type: EventTypeDelete, Kv: &KeyValue{Key: []byte("b"), ModRevision: 3}})
+
+	// Revision 1 is dropped to stay within 3 events
+	if _, err := l.Events(1, matchAll); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("Events(1) error = %v, want ErrCompacted", err)
+	}
+	events, err := l.Events(2, matchAll)
+	if err != nil {
+		t.Fatalf("Events(2) failed: %v", err)
+	}
+	if len(events) != 3 || events[1].Type != EventTypeDelete {
+		t.Fatalf("Events(2) = %+v, want put b and two deletes", events)
+	}
+
+	// A new revision evicts revision 2, never half of revision 3
+	l.Append(4, putEvent("c", 4))
+	events, err = l.Events(3, func(key []byte) bool { return string(key) != "c" })
+	if err != nil {
+		t.Fatalf("Events(3) failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Events(3) returned %d events, want 2", len(events))
+	}
+	if _, err := l.Events(2, matchAll); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("Events(2) error = %v, want ErrCompacted", err)
+	}
+}
+
+func TestEventLogRetention(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewEventLog(100, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Append(1, putEvent("a", 1))
+	now = now.Add(2 * time.Minute)
+	l.Append(2, putEvent("a", 2))
+
+	if _, err := l.Events(1, matchAll); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("Events(1) error = %v, want ErrCompacted", err)
+	}
+	events, err := l.Events(2, matchAll)
+	if err != nil || len(events) != 1 || string(events[0].Kv.Value) != "2" {
+		t.Fatalf("Events(2) = %+v, %v", events, err)
+	}
+
+	// Late events are kept in revision order
+	l.Append(4, putEvent("a", 4))
+	l.Append(3, putEvent("a", 3))
+	events, _ = l.Events(3, matchAll)
+	if len(events) != 2 || events[0].Kv.ModRevision != 3 {
+		t.Fatalf("Events(3) = %+v, want revisions 3 and 4", events)
+	}
+}
+
+func TestEventLogDisabledAndReset(t *testing.T) {
+	l := NewEventLog(0, 0)
+	l.Append(1, putEvent("a", 1))
+	if _, err := l.Events(1, matchAll); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("disabled log: Events(1) error = %v, want ErrCompacted", err)
+	}
+	events, err := l.Events(2, matchAll)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Events(2) = %+v, %v, want nothing", events, err)
+	}
+
+	l.SetLimits(10, 0)
+	l.Append(2, putEvent("a", 2))
+	l.Reset(5)
+	if _, err := l.Events(5, matchAll); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("after Reset: Events(5) error = %v, want ErrCompacted", err)
+	}
+	l.Append(6, putEvent("a", 6))
+	if events, _ := l.Events(6, matchAll); len(events) != 1 {
+		t.Fatalf("after Reset: Events(6) = %+v", events)
+	}
+}
+
+func TestEventLogKeyEvents(t *testing.T) {
+	l := NewEventLog(3, 0)
+	l.Append(1, putEvent("a", 1))
+	l.Append(2, putEvent("b", 2))
+	l.Append(3, putEvent("a", 3))
+	l.Append(4, WatchEvent{Type: EventTypeDelete, Kv: &KeyValue{Key: []byte("a"), ModRevision: 4}})
+
+	events, compacted := l.KeyEvents([]byte("a"))
+	if compacted != 1 {
+		t.Fatalf("KeyEvents compacted = %d, want 1", compacted)
+	}
+	if len(events) != 2 || events[0].Kv.ModRevision != 3 || events[1].Type != EventTypeDelete {
+		t.Fatalf("KeyEvents(a) = %+v, want PUT at 3 and DELETE at 4", events)
+	}
+}
+
+func TestEventLogOmit(t *testing.T) {
+	l := NewEventLog(10, 0)
+	l.Append(1, putEvent("a/1", 1))
+	l.Append(2, putEvent("b", 2))
+	l.Omit(3, []byte("a/"), []byte("a0"))
+	l.Append(4, putEvent("a/2", 4))
+
+	overlaps := func(key, rangeEnd string) func(start, end []byte) bool {
+		return func(start, end []byte) bool {
+			return (len(end) == 0 || key < string(end)) && string(start) < rangeEnd
+		}
+	}
+	if !l.Omitted(1, overlaps("a/", "a0")) || !l.Omitted(3, overlaps("a/1", "a/2")) {
+		t.Fatal("Omitted = false for a range overlapping the omitted deletes")
+	}
+	if l.Omitted(4, overlaps("a/", "a0")) || l.Omitted(1, overlaps("b", "c")) {
+		t.Fatal("Omitted = true after the omitted revision or for another range")
+	}
+
+	// The last known event of a/1 is older than its omitted delete
+	events, compacted := l.KeyEvents([]byte("a/1"))
+	if len(events) != 0 || compacted != 3 {
+		t.Fatalf("KeyEvents(a/1) = %+v, %d, want nothing before 3", events, compacted)
+	}
+	if events, compacted := l.KeyEvents([]byte("b")); len(events) != 1 || compacted != 0 {
+		t.Fatalf("KeyEvents(b) = %+v, %d, want PUT at 2", events, compacted)
+	}
+}
@@ -16,6 +16,7 @@ package mvcc
 
 import (
 	"bytes"
+	"encoding/binary"
 	"sync"
 
 	"github.com/google/btree"
@@ -259,43 +260,66 @@ func (idx *KeyIndex) Range(start, end []byte, atRev Revision, fn func(key []byte
 	})
 }
 
-// Compact removes all revisions before the given revision.
+// Compact removes revisions that are no longer visible at or after atRev.
+// For each key the latest revision <= atRev is kept so reads at atRev still
+// succeed; generations that ended at or before atRev are dropped entirely.
 // Returns the number of revisions removed.
 func (idx *KeyIndex) Compact(atRev Revision) int64 {
+	return idx.CompactFunc(atRev, nil)
+}
+
+// CompactFunc is like Compact but calls fn for every removed revision,
+// so callers can drop the matching values from their own storage.
+func (idx *KeyIndex) CompactFunc(atRev Revision, fn func(key []byte, rev Revision)) int64 {
 	idx.mu.Lock()
 	defer idx.mu.Unlock()
 
 	var removed int64
 	var keysToDelete []*KeyItem
 
+	drop := func(key []byte, revs []Revision) {
+		removed += int64(len(revs))
+		if fn != nil {
+			for _, r := range revs {
+				fn(key, r)
+			}
+		}
+	}
+
 	idx.tree.Ascend(func(item btree.Item) bool {
 		ki := item.(*KeyItem)
+		last := len(ki.Generations) - 1
 
-		// Compact each generation
 		newGens := make([]Generation, 0, len(ki.Generations))
 		for i := range ki.Generations {
 			gen := &ki.Generations[i]
 
-			// Find first revision >= atRev
-			keepFrom := 0
-			for j, r := range gen.Revisions {
-				if r.GreaterThanOrEqual(atRev) {
-					keepFrom = j
-					break
+			if gen.IsEmpty() {
+				// Only the trailing deletion marker carries information.
+				if i == last && len(newGens) > 0 {
+					newGens = append(newGens, *gen)
 				}
-				removed++
-				keepFrom = j + 1
+				continue
 			}
 
-			if keepFrom < len(gen.Revisions) {
-				// Keep remaining revisions
-				newGen := Generation{
-					Created:   gen.Created,
-					Revisions: make([]Revision, len(gen.Revisions)-keepFrom),
-				}
-				copy(newGen.Revisions, gen.Revisions[keepFrom:])
-				newGens = append(newGens, newGen)
+			// A closed generation ends with its tombstone.
+			if i < last && gen.LastRevision().LessThanOrEqual(atRev) {
+				drop(ki.Key, gen.Revisions)
+				continue
+			}
+
+			keepFrom := binarySearchRevision(gen.Revisions, atRev)
+			if keepFrom < 0 {
+				keepFrom = 0
+			}
+			drop(ki.Key, gen.Revisions[:keepFrom])
+
+			newGen := Generation{
+				Created:   gen.Created,
+				Revisions: make([]Revision, len(gen.Revisions)-keepFrom),
 			}
+			copy(newGen.Revisions, gen.Revisions[keepFrom:])
+			newGens = append(newGens, newGen)
 		}
 
 		if len(newGens) == 0 {
@@ -338,3 +362,88 @@ func (idx *KeyIndex) RevisionCount() int64 {
 	})
 	return count
 }
+
+// Ascend calls fn for every key in the index in key order.
+// fn must not modify the index.
+func (idx *KeyIndex) Ascend(fn func(ki *KeyItem) bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	idx.tree.Ascend(func(item btree.Item) bool {
+		return fn(item.(*KeyItem))
+	})
+}
+
+// Insert adds a fully built KeyItem, replacing any existing entry for its key.
+// It is used when loading a persisted index.
+func (idx *KeyIndex) Insert(ki *KeyItem) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.tree.ReplaceOrInsert(ki)
+}
+
+// Encode serializes the generations of ki.
+// Format: uvarint(#generations), then per generation the created revision,
+// uvarint(#revisions) and the revisions, each revision RevisionSize bytes.
+func (ki *KeyItem) Encode() []byte {
+	size := binary.MaxVarintLen64
+	for _, gen := range ki.Generations {
+		size += RevisionSize + binary.MaxVarintLen64 + len(gen.Revisions)*RevisionSize
+	}
+
+	buf := make([]byte, size)
+	n := binary.PutUvarint(buf, uint64(len(ki.Generations)))
+	for _, gen := range ki.Generations {
+		gen.Created.EncodeTo(buf[n:])
+		n += RevisionSize
+		n += binary.PutUvarint(buf[n:], uint64(len(gen.Revisions)))
+		for _, r := range gen.Revisions {
+			r.EncodeTo(buf[n:])
+			n += RevisionSize
+		}
+	}
+	return buf[:n]
+}
+
+// DecodeKeyItem is the inverse of KeyItem.Encode.
+func DecodeKeyItem(key, data []byte) (*KeyItem, error) {
+	ki := &KeyItem{Key: append([]byte{}, key...)}
+
+	numGens, n := binary.Uvarint(data)
+	if n <= 0 || numGens > uint64(len(data)) {
+		return nil, ErrInvalidData
+	}
+	data = data[n:]
+
+	ki.Generations = make([]Generation, 0, numGens)
+	for i := uint64(0); i < numGens; i++ {
+		if len(data) < RevisionSize {
+			return nil, ErrInvalidData
+		}
+		gen := Generation{Created: ParseRevision(data)}
+		data = data[RevisionSize:]
+
+		numRevs, n := binary.Uvarint(data)
+		if n <= 0 || numRevs > uint64(len(data)/RevisionSize) {
+			return nil, ErrInvalidData
+		}
+		data = data[n:]
+
+		if numRevs > 0 {
+			gen.Revisions = make([]Revision, numRevs)
+			for j := range gen.Revisions {
+				gen.Revisions[j] = ParseRevision(data)
+				data = data[RevisionSize:]
+			}
+			ki.Modified = gen.LastRevision()
+		} else {
+			ki.Modified = gen.Created
+		}
+		ki.Generations = append(ki.Generations, gen)
+	}
+
+	if len(data) != 0 || len(ki.Generations) == 0 {
+		return nil, ErrInvalidData
+	}
+	return ki, nil
+}
@@ -15,6 +15,7 @@
 package mvcc
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -231,6 +232,60 @@ func TestKeyIndexCompact(t *testing.T) {
 	}
 }
 
+func TestKeyIndexCompactKeepsVisibleRevision(t *testing.T) {
+	idx := NewKeyIndex()
+
+	// a is written at 1 and left alone; b is deleted at 3 and recreated at 5
+	idx.Put([]byte("a"), Revision{1, 0})
+	idx.Put([]byte("b"), Revision{2, 0})
+	idx.Delete([]byte("b"), Revision{3, 0})
+	idx.Put([]byte("c"), Revision{4, 0})
+	idx.Delete([]byte("c"), Revision{4, 1})
+	idx.Put([]byte("b"), Revision{5, 0})
+
+	var dropped []Revision
+	idx.CompactFunc(Revision{4, 1}, func(_ []byte, r Revision) {
+		dropped = append(dropped, r)
+	})
+
+	if rev := idx.GetRevision([]byte("a"), Revision{4, 0}); rev != (Revision{1, 0}) {
+		t.Errorf("GetRevision(a) = %v, want {1, 0}", rev)
+	}
+	if rev := idx.GetRevision([]byte("b"), Revision{4, 0}); !rev.IsZero() {
+		t.Errorf("GetRevision(b) at 4 = %v, want Zero", rev)
+	}
+	if rev := idx.GetRevision([]byte("b"), Revision{5, 0}); rev != (Revision{5, 0}) {
+		t.Errorf("GetRevision(b) at 5 = %v, want {5, 0}", rev)
+	}
+	if idx.Get([]byte("c")) != nil {
+		t.Error("key deleted before the compaction point should be removed")
+	}
+	if len(dropped) != 4 {
+		t.Errorf("dropped %v, want the closed generations of b and c", dropped)
+	}
+}
+
+func TestKeyItemEncodeDecode(t *testing.T) {
+	idx := NewKeyIndex()
+	idx.Put([]byte("a"), Revision{1, 0})
+	idx.Put([]byte("a"), Revision{2, 3})
+	idx.Delete([]byte("a"), Revision{4, 0})
+	idx.Put([]byte("a"), Revision{5, 0})
+
+	ki := idx.Get([]byte("a"))
+	got, err := DecodeKeyItem(ki.Key, ki.Encode())
+	if err != nil {
+		t.Fatalf("DecodeKeyItem failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, ki) {
+		t.Errorf("DecodeKeyItem = %+v, want %+v", got, ki)
+	}
+
+	if _, err := DecodeKeyItem(ki.Key, ki.Encode()[:5]); err != ErrInvalidData {
+		t.Errorf("truncated DecodeKeyItem = %v, want ErrInvalidData", err)
+	}
+}
+
 func TestKeyIndexLen(t *testing.T) {
 	idx := NewKeyIndex()
 
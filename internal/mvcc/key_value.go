@@ -40,6 +40,10 @@ type KeyValue struct {
 	// Lease is the ID of the lease attached to this key.
 	// 0 means no lease is attached.
 	Lease int64
+
+	// HLC is the hybrid logical clock timestamp of the last modification.
+	// 0 means the modification carried no timestamp.
+	HLC uint64
 }
 
 // Clone creates a deep copy of the KeyValue.
@@ -52,6 +56,7 @@ func (kv *KeyValue) Clone() *KeyValue {
 		ModRevision:    kv.ModRevision,
 		Version:        kv.Version,
 		Lease:          kv.Lease,
+		HLC:            kv.HLC,
 	}
 	if kv.Key != nil {
 		clone.Key = make([]byte, len(kv.Key))
@@ -120,11 +125,15 @@ func (t EventType) String() string {
 type KeyValueCodec struct{}
 
 // Encode serializes a KeyValue to bytes.
-// Format: [keyLen:4][valueLen:4][createRev:8][modRev:8][version:8][lease:8][key:keyLen][value:valueLen]
+// Format: [keyLen:4][valueLen:4][createRev:8][modRev:8][version:8][lease:8][key:keyLen][value:valueLen][hlc:8]
+// The hlc field is only present when non-zero, so records without a timestamp keep the original format.
 func (c *KeyValueCodec) Encode(kv *KeyValue) []byte {
 	keyLen := len(kv.Key)
 	valueLen := len(kv.Value)
 	size := 4 + 4 + 8 + 8 + 8 + 8 + keyLen + valueLen
+	if kv.HLC != 0 {
+		size += 8
+	}
 	buf := make([]byte, size)
 
 	offset := 0
@@ -143,6 +152,10 @@ func (c *KeyValueCodec) Encode(kv *KeyValue) []byte {
 	copy(buf[offset:], kv.Key)
 	offset += keyLen
 	copy(buf[offset:], kv.Value)
+	offset += valueLen
+	if kv.HLC != 0 {
+		binary.BigEndian.PutUint64(buf[offset:], kv.HLC)
+	}
 
 	return buf
 }
@@ -184,6 +197,11 @@ func (c *KeyValueCodec) Decode(data []byte) (*KeyValue, error) {
 		kv.Value = make([]byte, valueLen)
 		copy(kv.Value, data[offset:offset+valueLen])
 	}
+	offset += valueLen
+
+	if len(data) >= offset+8 {
+		kv.HLC = binary.BigEndian.Uint64(data[offset:])
+	}
 
 	return kv, nil
 }
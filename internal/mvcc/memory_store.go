@@ -133,18 +133,18 @@ func (s *MemoryStore) Get(key []byte, rev int64) (*KeyValue, error) {
 		return nil, ErrClosed
 	}
 
-	atRev := Revision{Main: rev}
+	atRev := ReadRevision(rev)
 	if rev == 0 {
-		atRev = s.revisionGen.Current()
+		atRev = ReadRevision(s.revisionGen.Current().Main)
 	}
 
 	// Check if revision is compacted
-	if atRev.LessThan(s.compactedRev) {
+	if atRev.Main < s.compactedRev.Main {
 		return nil, ErrCompacted
 	}
 
 	// Check if revision is in the future
-	if atRev.GreaterThan(s.revisionGen.Current()) {
+	if atRev.Main > s.revisionGen.Current().Main {
 		return nil, ErrFutureRevision
 	}
 
@@ -183,18 +183,18 @@ func (s *MemoryStore) Range(start, end []byte, rev int64, limit int64) ([]*KeyVa
 		return nil, 0, ErrClosed
 	}
 
-	atRev := Revision{Main: rev}
+	atRev := ReadRevision(rev)
 	if rev == 0 {
-		atRev = s.revisionGen.Current()
+		atRev = ReadRevision(s.revisionGen.Current().Main)
 	}
 
 	// Check if revision is compacted
-	if atRev.LessThan(s.compactedRev) {
+	if atRev.Main < s.compactedRev.Main {
 		return nil, 0, ErrCompacted
 	}
 
 	// Check if revision is in the future
-	if atRev.GreaterThan(s.revisionGen.Current()) {
+	if atRev.Main > s.revisionGen.Current().Main {
 		return nil, 0, ErrFutureRevision
 	}
 
@@ -399,30 +399,183 @@ func (s *MemoryStore) Compact(rev int64) error {
 		return ErrFutureRevision
 	}
 
-	// Compact key index
-	s.keyIndex.Compact(targetRev)
+	// Compact key index and drop the revisions it no longer references
+	s.keyIndex.CompactFunc(ReadRevision(rev), func(_ []byte, r Revision) {
+		s.revisionStore.Delete(&revisionItem{rev: r})
+	})
+
+	s.compactedRev = targetRev
+
+	return nil
+}
+
+// Close closes the store.
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.closed = true
+	return nil
+}
+
+// PutAt records kv at a revision chosen by the caller.
+// It lets an engine that assigns its own revisions keep history here;
+// kv must already carry its CreateRevision, ModRevision and Version.
+func (s *MemoryStore) PutAt(rev Revision, kv *KeyValue) error {
+	if len(kv.Key) == 0 {
+		return ErrEmptyKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.revisionStore.ReplaceOrInsert(&revisionItem{rev: rev, kv: kv.Clone()})
+	s.keyIndex.Put(kv.Key, rev)
+	s.revisionGen.Advance(rev)
+
+	return nil
+}
+
+// DeleteAt records a tombstone for key at a revision chosen by the caller.
+// Deleting a key that is not live only advances the current revision.
+func (s *MemoryStore) DeleteAt(rev Revision, key []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Remove old revisions from revision store
-	var toDelete []*revisionItem
-	s.revisionStore.Ascend(func(item btree.Item) bool {
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.revisionGen.Advance(rev)
+
+	ki := s.keyIndex.Get(key)
+	if ki == nil || ki.IsDeleted() {
+		return nil
+	}
+
+	var createRev int64
+	if item := s.revisionStore.Get(&revisionItem{rev: ki.CurrentGeneration().LastRevision()}); item != nil {
+		createRev = item.(*revisionItem).kv.CreateRevision
+	}
+
+	tombstone := &KeyValue{
+		Key:            append([]byte{}, key...),
+		CreateRevision: createRev,
+		ModRevision:    rev.Main,
+	}
+	s.revisionStore.ReplaceOrInsert(&revisionItem{rev: rev, kv: tombstone})
+	s.keyIndex.Delete(key, rev)
+
+	return nil
+}
+
+// Events returns the changes made at or after fromRev to keys accepted by
+// match, in revision order. Each event carries the previous value of its key
+// when that value is still retained.
+// Returns ErrCompacted if fromRev is older than the compacted revision.
+func (s *MemoryStore) Events(fromRev int64, match func(key []byte) bool) ([]WatchEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+	if fromRev < s.compactedRev.Main {
+		return nil, ErrCompacted
+	}
+
+	var events []WatchEvent
+	s.revisionStore.AscendGreaterOrEqual(&revisionItem{rev: Revision{Main: fromRev}}, func(item btree.Item) bool {
 		ri := item.(*revisionItem)
-		if ri.rev.LessThan(targetRev) {
-			toDelete = append(toDelete, ri)
+		if !match(ri.kv.Key) {
+			return true
 		}
+
+		ev := WatchEvent{Type: EventTypePut, Kv: ri.kv.Clone()}
+		if ri.kv.Version == 0 {
+			ev.Type = EventTypeDelete
+		}
+		if prev := s.prevValue(ri.kv.Key, ri.rev); prev != nil {
+			ev.PrevKv = prev.Clone()
+		}
+		events = append(events, ev)
 		return true
 	})
 
-	for _, ri := range toDelete {
-		s.revisionStore.Delete(ri)
+	return events, nil
+}
+
+// History returns the retained revisions of key, newest first. Deletions are
+// included as tombstones with Version 0. A positive limit caps the number of
+// revisions returned.
+func (s *MemoryStore) History(key []byte, limit int64) ([]*KeyValue, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKey
 	}
 
-	s.compactedRev = targetRev
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	return nil
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	ki := s.keyIndex.Get(key)
+	if ki == nil {
+		return nil, nil
+	}
+
+	var kvs []*KeyValue
+	for i := len(ki.Generations) - 1; i >= 0; i-- {
+		revs := ki.Generations[i].Revisions
+		for j := len(revs) - 1; j >= 0; j-- {
+			if limit > 0 && int64(len(kvs)) >= limit {
+				return kvs, nil
+			}
+			item := s.revisionStore.Get(&revisionItem{rev: revs[j]})
+			if item == nil {
+				continue
+			}
+			kvs = append(kvs, item.(*revisionItem).kv.Clone())
+		}
+	}
+	return kvs, nil
 }
 
-// Close closes the store.
-func (s *MemoryStore) Close() error {
+// prevValue returns the live value of key just before rev, if retained.
+func (s *MemoryStore) prevValue(key []byte, rev Revision) *KeyValue {
+	before := Revision{Main: rev.Main, Sub: rev.Sub - 1}
+	if rev.Sub == 0 {
+		before = ReadRevision(rev.Main - 1)
+	}
+
+	prevRev := s.keyIndex.GetRevision(key, before)
+	if prevRev.IsZero() {
+		return nil
+	}
+	item := s.revisionStore.Get(&revisionItem{rev: prevRev})
+	if item == nil || item.(*revisionItem).kv.Version == 0 {
+		return nil
+	}
+	return item.(*revisionItem).kv
+}
+
+// Restore replaces the store contents with kvs as of revision rev.
+// Only the latest value of each key is known, so everything before rev
+// is reported as compacted.
+func (s *MemoryStore) Restore(kvs []*KeyValue, rev int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -430,7 +583,22 @@ func (s *MemoryStore) Close() error {
 		return ErrClosed
 	}
 
-	s.closed = true
+	s.keyIndex = NewKeyIndex()
+	s.revisionStore = btree.New(32)
+
+	// Keys written by one transaction share a ModRevision.
+	subs := make(map[int64]int64, len(kvs))
+	for _, kv := range kvs {
+		r := Revision{Main: kv.ModRevision, Sub: subs[kv.ModRevision]}
+		subs[kv.ModRevision]++
+
+		s.revisionStore.ReplaceOrInsert(&revisionItem{rev: r, kv: kv.Clone()})
+		s.keyIndex.Put(kv.Key, r)
+	}
+
+	s.revisionGen = NewRevisionGenerator(Revision{Main: rev})
+	s.compactedRev = Revision{Main: rev}
+
 	return nil
 }
 
@@ -560,3 +560,165 @@ func TestMemoryStoreCompactedRevision(t *testing.T) {
 		t.Errorf("Compacted rev = %d, want 1", store.CompactedRevision())
 	}
 }
+
+func TestMemoryStoreCompactKeepsLatestValue(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	store.Put([]byte("a"), []byte("v1"), 0)
+	store.Put([]byte("b"), []byte("v1"), 0)
+	store.Put([]byte("b"), []byte("v2"), 0)
+
+	if err := store.Compact(3); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	// a was last written before the compaction point and must stay readable
+	kv, err := store.Get([]byte("a"), 3)
+	if err != nil {
+		t.Fatalf("Get(a) at compacted rev failed: %v", err)
+	}
+	if string(kv.Value) != "v1" {
+		t.Errorf("Value of a = %q, want v1", kv.Value)
+	}
+	if store.revisionStore.Len() != 2 {
+		t.Errorf("revisionStore holds %d revisions, want 2", store.revisionStore.Len())
+	}
+}
+
+func TestMemoryStorePutAtDeleteAt(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	store.PutAt(Revision{1, 0}, &KeyValue{Key: []byte("a"), Value: []byte("v1"), CreateRevision: 1, ModRevision: 1, Version: 1})
+	store.PutAt(Revision{2, 0}, &KeyValue{Key: []byte("b"), Value: []byte("v1"), CreateRevision: 2, ModRevision: 2, Version: 1})
+	store.DeleteAt(Revision{3, 0}, []byte("a"))
+	store.DeleteAt(Revision{3, 1}, []byte("b"))
+
+	if store.CurrentRevision() != 3 {
+		t.Errorf("CurrentRevision = %d, want 3", store.CurrentRevision())
+	}
+
+	kvs, _, err := store.Range([]byte("a"), nil, 2, 0)
+	if err != nil {
+		t.Fatalf("Range at 2 failed: %v", err)
+	}
+	if len(kvs) != 2 {
+		t.Errorf("Range at 2 returned %d keys, want 2", len(kvs))
+	}
+
+	// Both deletes share main revision 3
+	kvs, _, err = store.Range([]byte("a"), nil, 3, 0)
+	if err != nil {
+		t.Fatalf("Range at 3 failed: %v", err)
+	}
+	if len(kvs) != 0 {
+		t.Errorf("Range at 3 returned %d keys, want 0", len(kvs))
+	}
+}
+
+func TestMemoryStoreEvents(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	store.Put([]byte("a"), []byte("v1"), 0)
+	store.Put([]byte("b"), []byte("v1"), 0)
+	store.Put([]byte("a"), []byte("v2"), 0)
+	store.Delete([]byte("a"))
+
+	events, err := store.Events(2, func(key []byte) bool { return string(key) == "a" })
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+
+	if events[0].Type != EventTypePut || string(events[0].Kv.Value) != "v2" {
+		t.Errorf("events[0] = %v %q, want PUT v2", events[0].Type, events[0].Kv.Value)
+	}
+	if events[0].PrevKv == nil || string(events[0].PrevKv.Value) != "v1" {
+		t.Errorf("events[0].PrevKv = %v, want v1", events[0].PrevKv)
+	}
+	if events[1].Type != EventTypeDelete || events[1].Kv.ModRevision != 4 {
+		t.Errorf("events[1] = %v at %d, want DELETE at 4", events[1].Type, events[1].Kv.ModRevision)
+	}
+
+	store.Compact(3)
+	if _, err := store.Events(2, func([]byte) bool { return true }); err != ErrCompacted {
+		t.Errorf("Events before compacted rev = %v, want ErrCompacted", err)
+	}
+}
+
+func TestMemoryStoreHistory(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	store.Put([]byte("a"), []byte("v1"), 0)
+	store.Put([]byte("b"), []byte("v1"), 0)
+	store.Put([]byte("a"), []byte("v2"), 0)
+	store.Delete([]byte("a"))
+	store.Put([]byte("a"), []byte("v3"), 0)
+
+	kvs, err := store.History([]byte("a"), 0)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	want := []struct {
+		rev     int64
+		value   string
+		version int64
+	}{{5, "v3", 1}, {4, "", 0}, {3, "v2", 2}, {1, "v1", 1}}
+	if len(kvs) != len(want) {
+		t.Fatalf("got %d revisions, want %d", len(kvs), len(want))
+	}
+	for i, w := range want {
+		if kvs[i].ModRevision != w.rev || string(kvs[i].Value) != w.value || kvs[i].Version != w.version {
+			t.Errorf("kvs[%d] = rev %d %q version %d, want rev %d %q version %d",
+				i, kvs[i].ModRevision, kvs[i].Value, kvs[i].Version, w.rev, w.value, w.version)
+		}
+	}
+
+	if kvs, _ := store.History([]byte("a"), 2); len(kvs) != 2 || kvs[1].ModRevision != 4 {
+		t.Errorf("History with limit 2 = %v, want revisions 5 and 4", kvs)
+	}
+	if kvs, _ := store.History([]byte("c"), 0); len(kvs) != 0 {
+		t.Errorf("History of a missing key = %v, want none", kvs)
+	}
+
+	// Compaction drops the revisions superseded before it
+	store.Compact(4)
+	kvs, _ = store.History([]byte("a"), 0)
+	if len(kvs) != 1 || kvs[0].ModRevision != 5 {
+		t.Errorf("History after Compact(4) = %v, want revision 5", kvs)
+	}
+}
+
+func TestMemoryStoreRestore(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	store.Put([]byte("stale"), []byte("x"), 0)
+
+	err := store.Restore([]*KeyValue{
+		{Key: []byte("a"), Value: []byte("v1"), CreateRevision: 3, ModRevision: 7, Version: 2},
+		{Key: []byte("b"), Value: []byte("v1"), CreateRevision: 7, ModRevision: 7, Version: 1},
+	}, 9)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	if store.CurrentRevision() != 9 || store.CompactedRevision() != 9 {
+		t.Errorf("revisions = %d/%d, want 9/9", store.CurrentRevision(), store.CompactedRevision())
+	}
+	kvs, _, err := store.Range([]byte("a"), nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Range failed: %v", err)
+	}
+	if len(kvs) != 2 {
+		t.Errorf("Range returned %d keys, want 2", len(kvs))
+	}
+	if _, err := store.Get([]byte("a"), 8); err != ErrCompacted {
+		t.Errorf("Get before restore point = %v, want ErrCompacted", err)
+	}
+}
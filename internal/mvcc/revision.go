@@ -17,6 +17,7 @@ package mvcc
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 )
 
 const (
@@ -120,6 +121,12 @@ func NewRevision(main, sub int64) Revision {
 	return Revision{Main: main, Sub: sub}
 }
 
+// ReadRevision returns the upper bound used to read at main revision rev.
+// It includes every sub revision written by the same transaction.
+func ReadRevision(rev int64) Revision {
+	return Revision{Main: rev, Sub: math.MaxInt64}
+}
+
 // RevisionRange represents a range of revisions [Start, End).
 type RevisionRange struct {
 	Start Revision
@@ -161,6 +168,13 @@ func (g *RevisionGenerator) NextSub() Revision {
 	return g.current
 }
 
+// Advance moves the generator forward to rev if rev is newer.
+func (g *RevisionGenerator) Advance(rev Revision) {
+	if rev.GreaterThan(g.current) {
+		g.current = rev
+	}
+}
+
 // SetMain sets the main revision. Sub is reset to 0.
 func (g *RevisionGenerator) SetMain(main int64) {
 	g.current.Main = main
@@ -0,0 +1,195 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+package mvcc
+
+import (
+	"github.com/linxGnu/grocksdb"
+)
+
+// The key index is persisted so startup does not have to scan every MVCC key.
+//
+// A snapshot of the index is stored under indexPrefix, one entry per user key,
+// together with metaIndexRevision recording the current and compacted
+// revisions it reflects. Every write also appends to a revision log keyed by
+// revision, so changes made after the snapshot can be replayed in order.
+// The snapshot is rewritten on Compact, on Close and after a full rebuild.
+const (
+	// Format: indexPrefix + user_key -> KeyItem.Encode()
+	indexPrefix = "mvcc:index:"
+
+	// Format: revLogPrefix + revision_bytes -> flag byte + user_key
+	revLogPrefix = "mvcc:rev:"
+
+	metaIndexRevision = "mvcc:meta:index_revision"
+
+	revLogPut       byte = 0
+	revLogTombstone byte = 1
+)
+
+// makeRevLogKey creates the revision log key for rev.
+func makeRevLogKey(rev Revision) []byte {
+	result := make([]byte, len(revLogPrefix)+RevisionSize)
+	copy(result, revLogPrefix)
+	rev.EncodeTo(result[len(revLogPrefix):])
+	return result
+}
+
+// putRevision writes an encoded KeyValue for key at rev and logs the revision.
+func (s *RocksDBStore) putRevision(batch *grocksdb.WriteBatch, key []byte, rev Revision, encoded []byte, tombstone bool) {
+	batch.Put(s.makeStorageKey(key, rev), encoded)
+
+	flag := revLogPut
+	if tombstone {
+		flag = revLogTombstone
+	}
+	entry := make([]byte, 1+len(key))
+	entry[0] = flag
+	copy(entry[1:], key)
+	batch.Put(makeRevLogKey(rev), entry)
+}
+
+// deleteRevision removes a compacted revision and its log entry.
+func (s *RocksDBStore) deleteRevision(batch *grocksdb.WriteBatch, key []byte, rev Revision) {
+	batch.Delete(s.makeStorageKey(key, rev))
+	batch.Delete(makeRevLogKey(rev))
+}
+
+// saveIndex adds a snapshot of the key index to batch.
+// The caller must hold s.mu.
+func (s *RocksDBStore) saveIndex(batch *grocksdb.WriteBatch) {
+	prefix := []byte(indexPrefix)
+	batch.DeleteRange(prefix, prefixEnd(prefix))
+
+	s.keyIndex.Ascend(func(ki *KeyItem) bool {
+		key := make([]byte, len(indexPrefix)+len(ki.Key))
+		copy(key, indexPrefix)
+		copy(key[len(indexPrefix):], ki.Key)
+		batch.Put(key, ki.Encode())
+		return true
+	})
+
+	meta := make([]byte, 2*RevisionSize)
+	s.currentRev.EncodeTo(meta)
+	s.compactedRev.EncodeTo(meta[RevisionSize:])
+	batch.Put([]byte(metaIndexRevision), meta)
+}
+
+// SaveIndex persists a snapshot of the key index so the next startup
+// only needs to replay revisions written after it.
+func (s *RocksDBStore) SaveIndex() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+
+	s.saveIndex(batch)
+	return s.db.Write(s.wo, batch)
+}
+
+// loadKeyIndex restores the key index from its persisted snapshot and the
+// revision log, falling back to a full scan when the snapshot is missing or
+// does not match the stored revisions.
+func (s *RocksDBStore) loadKeyIndex() error {
+	ok, err := s.loadPersistedIndex()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	s.keyIndex = NewKeyIndex()
+	if err := s.rebuildKeyIndex(); err != nil {
+		return err
+	}
+
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+
+	s.saveIndex(batch)
+	return s.db.Write(s.wo, batch)
+}
+
+// loadPersistedIndex loads the index snapshot and replays the revision log.
+// It returns false if the snapshot is unusable.
+func (s *RocksDBStore) loadPersistedIndex() (bool, error) {
+	data, err := s.db.GetBytes(s.ro, []byte(metaIndexRevision))
+	if err != nil {
+		return false, err
+	}
+	if len(data) < 2*RevisionSize {
+		return false, nil
+	}
+
+	indexRev := ParseRevision(data)
+	indexCompacted := ParseRevision(data[RevisionSize:])
+	if indexRev.GreaterThan(s.currentRev) || indexCompacted != s.compactedRev {
+		return false, nil
+	}
+
+	it := s.db.NewIterator(s.ro)
+	defer it.Close()
+
+	prefix := []byte(indexPrefix)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		ki, err := DecodeKeyItem(it.Key().Data()[len(prefix):], it.Value().Data())
+		if err != nil {
+			return false, nil
+		}
+		s.keyIndex.Insert(ki)
+	}
+	if err := it.Err(); err != nil {
+		return false, err
+	}
+
+	// Replay the revisions written after the snapshot
+	logPrefix := []byte(revLogPrefix)
+	for it.Seek(makeRevLogKey(Revision{Main: indexRev.Main, Sub: indexRev.Sub + 1})); it.ValidForPrefix(logPrefix); it.Next() {
+		rev := ParseRevision(it.Key().Data()[len(logPrefix):])
+		entry := it.Value().Data()
+		if len(entry) < 2 {
+			return false, nil
+		}
+
+		key := append([]byte{}, entry[1:]...)
+		if entry[0] == revLogTombstone {
+			s.keyIndex.Delete(key, rev)
+		} else {
+			s.keyIndex.Put(key, rev)
+		}
+	}
+
+	return true, it.Err()
+}
+
+// prefixEnd returns the smallest key greater than every key with prefix.
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
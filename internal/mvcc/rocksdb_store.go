@@ -80,8 +80,8 @@ func NewRocksDBStore(db *grocksdb.DB) (*RocksDBStore, error) {
 		return nil, err
 	}
 
-	// Rebuild key index from stored data
-	if err := s.rebuildKeyIndex(); err != nil {
+	// Load the persisted key index, rebuilding it from stored data if needed
+	if err := s.loadKeyIndex(); err != nil {
 		wo.Destroy()
 		ro.Destroy()
 		return nil, err
@@ -132,11 +132,6 @@ func (s *RocksDBStore) rebuildKeyIndex() error {
 			continue
 		}
 
-		// Skip compacted revisions
-		if rev.LessThan(s.compactedRev) {
-			continue
-		}
-
 		// Decode value to check if it's a tombstone
 		kv, err := DefaultCodec.Decode(it.Value().Data())
 		if err != nil {
@@ -253,7 +248,7 @@ func (s *RocksDBStore) Put(key, value []byte, lease int64) (int64, error) {
 	batch := grocksdb.NewWriteBatch()
 	defer batch.Destroy()
 
-	batch.Put(s.makeStorageKey(key, rev), encoded)
+	s.putRevision(batch, key, rev, encoded, false)
 	s.saveCurrentRevision(batch)
 
 	if err := s.db.Write(s.wo, batch); err != nil {
@@ -279,16 +274,16 @@ func (s *RocksDBStore) Get(key []byte, rev int64) (*KeyValue, error) {
 		return nil, ErrClosed
 	}
 
-	atRev := Revision{Main: rev}
+	atRev := ReadRevision(rev)
 	if rev == 0 {
-		atRev = s.currentRev
+		atRev = ReadRevision(s.currentRev.Main)
 	}
 
 	// Check bounds
-	if atRev.LessThan(s.compactedRev) {
+	if atRev.Main < s.compactedRev.Main {
 		return nil, ErrCompacted
 	}
-	if atRev.GreaterThan(s.currentRev) {
+	if atRev.Main > s.currentRev.Main {
 		return nil, ErrFutureRevision
 	}
 
@@ -335,16 +330,16 @@ func (s *RocksDBStore) Range(start, end []byte, rev int64, limit int64) ([]*KeyV
 		return nil, 0, ErrClosed
 	}
 
-	atRev := Revision{Main: rev}
+	atRev := ReadRevision(rev)
 	if rev == 0 {
-		atRev = s.currentRev
+		atRev = ReadRevision(s.currentRev.Main)
 	}
 
 	// Check bounds
-	if atRev.LessThan(s.compactedRev) {
+	if atRev.Main < s.compactedRev.Main {
 		return nil, 0, ErrCompacted
 	}
-	if atRev.GreaterThan(s.currentRev) {
+	if atRev.Main > s.currentRev.Main {
 		return nil, 0, ErrFutureRevision
 	}
 
@@ -434,7 +429,7 @@ func (s *RocksDBStore) Delete(key []byte) (int64, int64, error) {
 	batch := grocksdb.NewWriteBatch()
 	defer batch.Destroy()
 
-	batch.Put(s.makeStorageKey(key, rev), encoded)
+	s.putRevision(batch, key, rev, encoded, true)
 	s.saveCurrentRevision(batch)
 
 	if err := s.db.Write(s.wo, batch); err != nil {
@@ -515,7 +510,7 @@ func (s *RocksDBStore) DeleteRange(start, end []byte) (int64, int64, error) {
 		}
 
 		encoded := DefaultCodec.Encode(tombstone)
-		batch.Put(s.makeStorageKey(key, rev), encoded)
+		s.putRevision(batch, key, rev, encoded, true)
 
 		// Update key index
 		s.keyIndex.Delete(key, rev)
@@ -576,36 +571,23 @@ func (s *RocksDBStore) Compact(rev int64) error {
 		return ErrFutureRevision
 	}
 
-	// Delete old revisions from RocksDB
+	// Compact key index and delete the revisions it no longer references
 	batch := grocksdb.NewWriteBatch()
 	defer batch.Destroy()
 
-	it := s.db.NewIterator(s.ro)
-	defer it.Close()
-
-	prefix := []byte(kvMVCCPrefix)
-	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-		_, keyRev, ok := s.parseStorageKey(it.Key().Data())
-		if !ok {
-			continue
-		}
-
-		if keyRev.LessThan(targetRev) {
-			batch.Delete(it.Key().Data())
-		}
-	}
+	s.keyIndex.CompactFunc(ReadRevision(rev), func(key []byte, r Revision) {
+		s.deleteRevision(batch, key, r)
+	})
 
-	// Update compacted revision
+	// Update compacted revision and persist the compacted key index
 	s.compactedRev = targetRev
 	batch.Put([]byte(metaCompactedRevision), s.compactedRev.Bytes())
+	s.saveIndex(batch)
 
 	if err := s.db.Write(s.wo, batch); err != nil {
 		return err
 	}
 
-	// Compact key index
-	s.keyIndex.Compact(targetRev)
-
 	// Trigger RocksDB physical compaction
 	startKey := []byte(kvMVCCPrefix)
 	endKey := append([]byte(kvMVCCPrefix), 0xFF)
@@ -623,6 +605,12 @@ func (s *RocksDBStore) Close() error {
 		return ErrClosed
 	}
 
+	// Persist the key index so the next startup does not need a full scan
+	batch := grocksdb.NewWriteBatch()
+	s.saveIndex(batch)
+	err := s.db.Write(s.wo, batch)
+	batch.Destroy()
+
 	s.closed = true
 
 	if s.wo != nil {
@@ -632,7 +620,7 @@ func (s *RocksDBStore) Close() error {
 		s.ro.Destroy()
 	}
 
-	return nil
+	return err
 }
 
 // Sync forces a sync to disk.
@@ -862,7 +850,7 @@ func (t *rocksDBTxn) executePut(op Op, rev Revision, batch *grocksdb.WriteBatch)
 	}
 
 	encoded := DefaultCodec.Encode(kv)
-	batch.Put(t.store.makeStorageKey(key, rev), encoded)
+	t.store.putRevision(batch, key, rev, encoded, false)
 	t.store.keyIndex.Put(key, rev)
 
 	return OpResponse{Type: OpTypePut}
@@ -939,7 +927,7 @@ func (t *rocksDBTxn) executeDelete(op Op, rev Revision, batch *grocksdb.WriteBat
 	}
 
 	encoded := DefaultCodec.Encode(tombstone)
-	batch.Put(t.store.makeStorageKey(op.Key, rev), encoded)
+	t.store.putRevision(batch, op.Key, rev, encoded, true)
 	t.store.keyIndex.Delete(op.Key, rev)
 
 	resp.Deleted = 1
@@ -987,7 +975,7 @@ func (t *rocksDBTxn) executeDeleteRange(op Op, baseRev Revision, batch *grocksdb
 		}
 
 		encoded := DefaultCodec.Encode(tombstone)
-		batch.Put(t.store.makeStorageKey(key, deleteRev), encoded)
+		t.store.putRevision(batch, key, deleteRev, encoded, true)
 		t.store.keyIndex.Delete(key, deleteRev)
 
 		resp.Deleted++
@@ -788,6 +788,106 @@ func TestRocksDBStorePersistence(t *testing.T) {
 	}
 }
 
+func TestRocksDBStoreIndexReplay(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	opts := grocksdb.NewDefaultOptions()
+	opts.SetCreateIfMissing(true)
+	defer opts.Destroy()
+
+	// First session: snapshot the index, then keep writing without Close
+	{
+		db, err := grocksdb.OpenDb(opts, tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to open RocksDB: %v", err)
+		}
+
+		store, err := NewRocksDBStore(db)
+		if err != nil {
+			db.Close()
+			t.Fatalf("NewRocksDBStore failed: %v", err)
+		}
+
+		store.Put([]byte("a"), []byte("v1"), 0)
+		store.Put([]byte("b"), []byte("v1"), 0)
+		if err := store.SaveIndex(); err != nil {
+			t.Fatalf("SaveIndex failed: %v", err)
+		}
+
+		store.Put([]byte("a"), []byte("v2"), 0)
+		store.Delete([]byte("b"))
+		store.Put([]byte("c"), []byte("v1"), 0)
+
+		db.Close()
+	}
+
+	db, err := grocksdb.OpenDb(opts, tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen RocksDB: %v", err)
+	}
+	defer db.Close()
+
+	store, err := NewRocksDBStore(db)
+	if err != nil {
+		t.Fatalf("NewRocksDBStore failed: %v", err)
+	}
+	defer store.Close()
+
+	kvs, _, err := store.Range([]byte("a"), nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Range failed: %v", err)
+	}
+	if len(kvs) != 2 || string(kvs[0].Value) != "v2" || string(kvs[1].Key) != "c" {
+		t.Errorf("Range after replay = %v, want a=v2 and c", kvs)
+	}
+
+	kv, err := store.Get([]byte("b"), 2)
+	if err != nil {
+		t.Fatalf("Get(b) at 2 failed: %v", err)
+	}
+	if string(kv.Value) != "v1" {
+		t.Errorf("b at 2 = %q, want v1", kv.Value)
+	}
+}
+
+func TestRocksDBStoreIndexFallbackScan(t *testing.T) {
+	db, _, cleanup := createTestRocksDB(t)
+	defer cleanup()
+
+	store, err := NewRocksDBStore(db)
+	if err != nil {
+		t.Fatalf("NewRocksDBStore failed: %v", err)
+	}
+	store.Put([]byte("a"), []byte("v1"), 0)
+	store.Put([]byte("b"), []byte("v1"), 0)
+	store.Compact(1)
+	store.Close()
+
+	// Without the snapshot metadata the index is rebuilt by scanning
+	wo := grocksdb.NewDefaultWriteOptions()
+	defer wo.Destroy()
+	if err := db.Delete(wo, []byte(metaIndexRevision)); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	store, err = NewRocksDBStore(db)
+	if err != nil {
+		t.Fatalf("NewRocksDBStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if store.keyIndex.Len() != 2 {
+		t.Errorf("rebuilt index has %d keys, want 2", store.keyIndex.Len())
+	}
+	kv, err := store.Get([]byte("a"), 1)
+	if err != nil {
+		t.Fatalf("Get(a) at compacted rev failed: %v", err)
+	}
+	if string(kv.Value) != "v1" {
+		t.Errorf("a at 1 = %q, want v1", kv.Value)
+	}
+}
+
 func TestRocksDBStoreDBSize(t *testing.T) {
 	db, _, cleanup := createTestRocksDB(t)
 	defer cleanup()
@@ -0,0 +1,69 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// openapigen 根据 OpenAPI 文档生成 HTTP API 客户端的代码，由 api/http 的 go:generate 调用：
+//
+//	go generate ./api/http
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"metaStore/internal/openapigen"
+)
+
+func main() {
+	spec := flag.String("spec", "openapi.json", "OpenAPI document")
+	goOut := flag.String("go", "", "output file of the Go client code")
+	goPkg := flag.String("package", "httpclient", "package of the Go client code")
+	tsOut := flag.String("ts", "", "output file of the TypeScript client code")
+	flag.Parse()
+
+	if err := run(*spec, *goOut, *goPkg, *tsOut); err != nil {
+		fmt.Fprintln(os.Stderr, "openapigen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(spec, goOut, goPkg, tsOut string) error {
+	data, err := os.ReadFile(spec)
+	if err != nil {
+		return err
+	}
+	doc, err := openapigen.Parse(data)
+	if err != nil {
+		return err
+	}
+	if goOut != "" {
+		src, err := doc.Go(goPkg)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(goOut, src, 0644); err != nil {
+			return err
+		}
+	}
+	if tsOut != "" {
+		src, err := doc.TypeScript()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(tsOut, src, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,476 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapigen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// license 生成文件开头的许可证声明，与手写的文件相同
+const license = `Copyright 2025 The axfor Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.`
+
+// goGen 生成 pkg/httpclient 的代码
+//
+// 生成的方法使用包中手写的 request、send、call、empty、text 和 jsonBody，
+// 它们负责认证、默认的 key 编码和错误响应
+type goGen struct {
+	d       *Document
+	b       bytes.Buffer
+	imports map[string]bool
+}
+
+// Go 返回包 pkg 的 Go 代码：每个 schema 的结构体，以及每个 operation 的参数类型和
+// Client 的未导出方法，方法名为 operationId
+func (d *Document) Go(pkg string) ([]byte, error) {
+	g := &goGen{d: d, imports: map[string]bool{}}
+	for _, s := range d.schemas {
+		if !isStruct(s.schema) {
+			continue // 字符串等类型直接使用 Go 的基本类型
+		}
+		if err := g.schema(s); err != nil {
+			return nil, err
+		}
+	}
+	for _, op := range d.operations {
+		if err := g.operation(op); err != nil {
+			return nil, fmt.Errorf("%s: %w", op.id, err)
+		}
+	}
+
+	var out bytes.Buffer
+	for _, line := range strings.Split(license, "\n") {
+		out.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	fmt.Fprintf(&out, "\n// %s\n\npackage %s\n\n", header, pkg)
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		out.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(g.b.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated Go code: %w", err)
+	}
+	return src, nil
+}
+
+// schema 生成 components.schemas 中一个 object 的结构体，非必需的字段带 omitempty
+func (g *goGen) schema(s namedSchema) error {
+	if desc := s.schema.str("description"); desc != "" {
+		fmt.Fprintf(&g.b, "// %s is %s\n", s.name, sentence(oneLine(desc)))
+	} else {
+		fmt.Fprintf(&g.b, "// %s is the %s schema\n", s.name, s.name)
+	}
+	fmt.Fprintf(&g.b, "type %s struct {\n", s.name)
+	required := s.schema.strings("required")
+	props := s.schema.get("properties")
+	for _, name := range props.keysOrNil() {
+		prop := props.get(name)
+		optional := !contains(required, name)
+		typ, err := g.typeOf(prop, optional)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", s.name, name, err)
+		}
+		tag := name
+		if optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&g.b, "\t%s %s `json:%q`", goName(name), typ, tag)
+		if doc := goFieldDoc(prop); doc != "" {
+			fmt.Fprintf(&g.b, " // %s", doc)
+		}
+		g.b.WriteString("\n")
+	}
+	g.b.WriteString("}\n\n")
+	return nil
+}
+
+// goFieldDoc 返回字段的行尾注释：描述和枚举值
+func goFieldDoc(prop *node) string {
+	doc := oneLine(prop.str("description"))
+	if enum := prop.strings("enum"); len(enum) > 0 {
+		values := "one of " + strings.Join(enum, ", ")
+		if doc == "" {
+			return strings.ToUpper(values[:1]) + values[1:]
+		}
+		doc += ", " + values
+	}
+	return doc
+}
+
+// typeOf 返回 schema 的 Go 类型，optional 的结构体使用指针。
+// x-go-type 指定标准库中的类型，如 time.Duration
+func (g *goGen) typeOf(s *node, optional bool) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	if t := s.str("x-go-type"); t != "" {
+		if pkg, _, ok := strings.Cut(t, "."); ok {
+			g.imports[pkg] = true
+		}
+		return t, nil
+	}
+	if name := refName(s); name != "" {
+		target := g.d.resolve(s)
+		if target == nil {
+			return "", fmt.Errorf("unresolved %s", s.str("$ref"))
+		}
+		if !isStruct(target) {
+			return g.typeOf(target, optional)
+		}
+		if optional {
+			return "*" + name, nil
+		}
+		return name, nil
+	}
+	if s.get("oneOf") != nil || s.get("anyOf") != nil {
+		g.imports["encoding/json"] = true
+		return "json.RawMessage", nil
+	}
+
+	switch s.str("type") {
+	case "string":
+		switch s.str("format") {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		case "binary":
+			g.imports["io"] = true
+			return "io.Reader", nil
+		}
+		return "string", nil
+	case "integer":
+		switch f := s.str("format"); f {
+		case "int32", "int64", "uint32", "uint64":
+			return f, nil
+		}
+		return "int", nil
+	case "number":
+		if s.str("format") == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		elem, err := g.typeOf(s.get("items"), false)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object":
+		if s.get("properties") == nil {
+			g.imports["encoding/json"] = true
+			return "json.RawMessage", nil
+		}
+		return "", fmt.Errorf("inline object schemas are not supported, move it to components.schemas")
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.str("type"))
+}
+
+// paramType 返回参数的 Go 类型。只看是否出现的参数为 bool，
+// 可选的整数为指针，这样 0 也可以发送
+func (g *goGen) paramType(p *param) (string, error) {
+	s := g.d.resolve(p.schema)
+	switch {
+	case p.allowEmpty, s.str("type") == "boolean":
+		return "bool", nil
+	case s.str("type") == "array":
+		if g.d.resolve(s.get("items")).str("type") != "string" {
+			return "", fmt.Errorf("parameter %s: only arrays of strings are supported", p.name)
+		}
+		return "[]string", nil
+	case s.str("type") == "integer":
+		typ, err := g.typeOf(s, false)
+		if err != nil || p.required {
+			return typ, err
+		}
+		return "*" + typ, nil
+	case s.str("type") == "string":
+		return "string", nil
+	}
+	return "", fmt.Errorf("parameter %s: unsupported type %q", p.name, s.str("type"))
+}
+
+// setParam 生成把参数写入查询参数 q 或请求头 h 的语句
+func (g *goGen) setParam(p *param) error {
+	target := "q"
+	if p.in == "header" {
+		target = "h"
+	}
+	field := "params." + p.goName
+	s := g.d.resolve(p.schema)
+	switch {
+	case p.allowEmpty:
+		fmt.Fprintf(&g.b, "\tif %s {\n\t\t%s.Set(%q, \"\")\n\t}\n", field, target, p.name)
+	case s.str("type") == "boolean":
+		fmt.Fprintf(&g.b, "\tif %s {\n\t\t%s.Set(%q, \"true\")\n\t}\n", field, target, p.name)
+	case s.str("type") == "array":
+		fmt.Fprintf(&g.b, "\tfor _, v := range %s {\n\t\t%s.Add(%q, v)\n\t}\n", field, target, p.name)
+	case s.str("type") == "integer":
+		g.imports["strconv"] = true
+		value := field
+		if !p.required {
+			value = "*" + field
+		}
+		switch s.str("format") {
+		case "int64":
+			value = "strconv.FormatInt(" + value + ", 10)"
+		case "int32":
+			value = "strconv.FormatInt(int64(" + value + "), 10)"
+		case "uint64":
+			value = "strconv.FormatUint(" + value + ", 10)"
+		case "uint32":
+			value = "strconv.FormatUint(uint64(" + value + "), 10)"
+		default:
+			value = "strconv.Itoa(" + value + ")"
+		}
+		if p.required {
+			fmt.Fprintf(&g.b, "\t%s.Set(%q, %s)\n", target, p.name, value)
+		} else {
+			fmt.Fprintf(&g.b, "\tif %s != nil {\n\t\t%s.Set(%q, %s)\n\t}\n", field, target, p.name, value)
+		}
+	default:
+		if p.required {
+			fmt.Fprintf(&g.b, "\t%s.Set(%q, %s)\n", target, p.name, field)
+		} else {
+			fmt.Fprintf(&g.b, "\tif %s != \"\" {\n\t\t%s.Set(%q, %s)\n\t}\n", field, target, p.name, field)
+		}
+	}
+	return nil
+}
+
+// operation 生成一个 operation 的参数类型和方法
+func (g *goGen) operation(op *operation) error {
+	name := unexported(op.id)
+	g.imports["context"] = true
+	g.imports["net/http"] = true
+
+	paramsType := name + "Params"
+	if len(op.params) > 0 {
+		fmt.Fprintf(&g.b, "// %s holds the query and header parameters of %s\n", paramsType, op.id)
+		fmt.Fprintf(&g.b, "type %s struct {\n", paramsType)
+		for _, p := range op.params {
+			typ, err := g.paramType(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&g.b, "\t%s %s // %s %s", p.goName, typ, p.in, p.name)
+			if p.description != "" {
+				fmt.Fprintf(&g.b, ": %s", oneLine(p.description))
+			}
+			g.b.WriteString("\n")
+		}
+		g.b.WriteString("}\n\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, p := range op.pathParams {
+		args = append(args, p.tsName+" string")
+	}
+	if len(op.params) > 0 {
+		args = append(args, "params "+paramsType)
+	}
+	var bodyType string
+	if op.body != nil {
+		var err error
+		if bodyType, err = g.bodyType(op.body); err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+		args = append(args, "body "+bodyType)
+	}
+	result, decoded, err := g.resultType(op)
+	if err != nil {
+		return err
+	}
+	zero := "nil"
+	if result == "string" {
+		zero = `""`
+	}
+
+	fmt.Fprintf(&g.b, "// %s sends %s %s", name, op.method, op.path)
+	if op.summary != "" {
+		fmt.Fprintf(&g.b, ": %s", oneLine(op.summary))
+	}
+	fmt.Fprintf(&g.b, "\nfunc (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), result)
+
+	var hasQuery, hasHeader bool
+	for _, p := range op.params {
+		hasQuery = hasQuery || p.in == "query"
+		hasHeader = hasHeader || p.in == "header"
+	}
+	if hasQuery {
+		g.imports["net/url"] = true
+		g.b.WriteString("\tq := url.Values{}\n")
+	}
+	if hasHeader || op.result != nil {
+		g.b.WriteString("\th := http.Header{}\n")
+	}
+	if op.result != nil {
+		fmt.Fprintf(&g.b, "\th.Set(\"Accept\", %q)\n", op.result.mediaType)
+	}
+	for _, p := range op.params {
+		if err := g.setParam(p); err != nil {
+			return err
+		}
+	}
+
+	fields := []string{"method: http.Method" + methodName(op.method), "path: " + g.pathExpr(op)}
+	if hasQuery {
+		fields = append(fields, "query: q")
+	}
+	if hasHeader || op.result != nil {
+		fields = append(fields, "header: h")
+	}
+	if op.body != nil {
+		switch {
+		case op.body.kind() == kindJSON && bodyType == "json.RawMessage":
+			g.imports["bytes"] = true
+			fields = append(fields, "body: bytes.NewReader(body)")
+		case op.body.kind() == kindJSON:
+			fmt.Fprintf(&g.b, "\tdata, err := jsonBody(body)\n\tif err != nil {\n\t\treturn %s, err\n\t}\n", zero)
+			fields = append(fields, "body: data")
+		case op.body.kind() == kindText:
+			g.imports["strings"] = true
+			fields = append(fields, "body: strings.NewReader(body)")
+		default:
+			fields = append(fields, "body: body")
+		}
+		fields = append(fields, fmt.Sprintf("bodyType: %q", op.body.mediaType))
+	}
+	fmt.Fprintf(&g.b, "\treq := request{%s}\n", strings.Join(fields, ", "))
+
+	codes := make([]string, len(op.ok))
+	for i, code := range op.ok {
+		codes[i] = statusName(code)
+	}
+	ok := strings.Join(codes, ", ")
+	switch {
+	case op.result == nil:
+		fmt.Fprintf(&g.b, "\treturn c.empty(ctx, req, %s)\n", ok)
+	case op.result.kind() == kindText:
+		fmt.Fprintf(&g.b, "\treturn c.text(ctx, req, %s)\n", ok)
+	case op.result.kind() == kindBinary:
+		fmt.Fprintf(&g.b, "\treturn c.send(ctx, req, %s)\n", ok)
+	default:
+		fmt.Fprintf(&g.b, "\tvar out %s\n\tif err := c.call(ctx, req, &out, %s); err != nil {\n\t\treturn nil, err\n\t}\n", decoded, ok)
+		if strings.HasPrefix(result, "*") {
+			g.b.WriteString("\treturn &out, nil\n")
+		} else {
+			g.b.WriteString("\treturn out, nil\n")
+		}
+	}
+	g.b.WriteString("}\n\n")
+	return nil
+}
+
+// bodyType 返回请求体参数的类型：JSON 为 schema 对应的类型，纯文本为 string，其他为 io.Reader
+func (g *goGen) bodyType(c *content) (string, error) {
+	switch c.kind() {
+	case kindJSON:
+		return g.typeOf(c.schema, false)
+	case kindText:
+		return "string", nil
+	}
+	g.imports["io"] = true
+	return "io.Reader", nil
+}
+
+// resultType 返回方法的结果类型和 JSON 响应解码的类型。
+// 没有响应体时返回响应头，纯文本返回 string，其他媒体类型返回响应由调用方读取和关闭
+func (g *goGen) resultType(op *operation) (result, decoded string, err error) {
+	switch {
+	case op.result == nil:
+		return "http.Header", "", nil
+	case op.result.kind() == kindText:
+		return "string", "", nil
+	case op.result.kind() == kindBinary:
+		return "*http.Response", "", nil
+	}
+	decoded, err = g.typeOf(op.result.schema, false)
+	if err != nil {
+		return "", "", fmt.Errorf("response: %w", err)
+	}
+	if isStruct(g.d.resolve(op.result.schema)) {
+		return "*" + decoded, decoded, nil
+	}
+	return decoded, decoded, nil
+}
+
+// pathExpr 返回请求路径的表达式，路径参数用 url.PathEscape 转义，其中的 "/" 也被转义
+func (g *goGen) pathExpr(op *operation) string {
+	var parts []string
+	for _, seg := range pathSegments(op.path) {
+		if !strings.HasPrefix(seg, "{") {
+			parts = append(parts, fmt.Sprintf("%q", seg))
+			continue
+		}
+		name := strings.Trim(seg, "{}")
+		for _, p := range op.pathParams {
+			if p.name == name {
+				name = p.tsName
+			}
+		}
+		g.imports["net/url"] = true
+		parts = append(parts, "url.PathEscape("+name+")")
+	}
+	return strings.Join(parts, " + ")
+}
+
+// methodName 返回 net/http 中方法常量的后缀，如 GET 为 Get
+func methodName(method string) string {
+	return method[:1] + strings.ToLower(method[1:])
+}
+
+// statusName 返回状态码在 net/http 中的常量
+func statusName(code int) string {
+	name := strings.NewReplacer(" ", "", "-", "", "'", "").Replace(http.StatusText(code))
+	if name == "" {
+		return fmt.Sprint(code)
+	}
+	return "http.Status" + name
+}
+
+// oneLine 把多行的描述合并为一行
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,510 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapigen 根据 api/http/openapi.json 生成 HTTP API 客户端的 Go 和 TypeScript 代码
+//
+// 生成的代码包括 components.schemas 中的每个类型，以及每个 operation 的请求：
+// 路径、查询参数、请求头、请求体、成功的状态码和响应的类型。key 编码、事件流等文档
+// 无法描述的部分仍由 pkg/httpclient 和 clients/typescript 手写，它们调用生成的代码。
+//
+// 修改文档后执行 go generate ./api/http 重新生成，api/http 的测试检查生成的文件是最新的
+package openapigen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// header 生成文件的第一行注释
+const header = "Code generated by openapigen from api/http/openapi.json. DO NOT EDIT."
+
+// Document 解析后的 OpenAPI 文档
+type Document struct {
+	root       *node
+	schemas    []namedSchema // components.schemas，按文档中的顺序
+	operations []*operation  // 按文档中的顺序
+}
+
+// namedSchema components.schemas 中的一个定义
+type namedSchema struct {
+	name   string
+	schema *node
+}
+
+// operation 一个接口
+type operation struct {
+	id      string
+	method  string // 大写，如 GET
+	path    string // 路径模板，如 /admin/mirrors/{name}/start
+	summary string
+
+	pathParams []*param
+	params     []*param // 查询参数和请求头
+	body       *content // 请求体，nil 表示没有
+	ok         []int    // 成功的状态码
+	result     *content // 成功响应的内容，nil 表示没有响应体
+}
+
+// param 一个参数
+type param struct {
+	name        string
+	in          string // path、query 或 header
+	description string
+	required    bool
+	allowEmpty  bool // 只看是否出现，值为空
+	schema      *node
+	goName      string
+	tsName      string
+}
+
+// content 请求体或响应的内容
+type content struct {
+	mediaType string
+	schema    *node
+}
+
+// contentKind 内容的编码方式
+type contentKind int
+
+const (
+	kindJSON   contentKind = iota // JSON，按 schema 编解码
+	kindText                      // 纯文本
+	kindBinary                    // 其他媒体类型，由调用方读写，如事件流
+)
+
+func (c *content) kind() contentKind {
+	switch {
+	case c.mediaType == "application/json" || strings.HasSuffix(c.mediaType, "+json"):
+		return kindJSON
+	case c.mediaType == "text/plain":
+		return kindText
+	}
+	return kindBinary
+}
+
+// httpMethods path item 中表示操作的 key
+var httpMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true,
+}
+
+// Parse 解析 OpenAPI 3 文档
+func Parse(data []byte) (*Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := parseNode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	d := &Document{root: root}
+
+	schemas := root.get("components").get("schemas")
+	for _, name := range schemas.keysOrNil() {
+		d.schemas = append(d.schemas, namedSchema{name: name, schema: schemas.get(name)})
+	}
+
+	paths := root.get("paths")
+	for _, path := range paths.keysOrNil() {
+		item := d.resolve(paths.get(path))
+		for _, method := range item.keysOrNil() {
+			if !httpMethods[method] {
+				continue
+			}
+			op, err := d.operation(path, method, item.get("parameters"), item.get(method))
+			if err != nil {
+				return nil, err
+			}
+			d.operations = append(d.operations, op)
+		}
+	}
+	return d, nil
+}
+
+// operation 解析 path item 中的一个操作，shared 为 path item 的公共参数
+func (d *Document) operation(path, method string, shared, n *node) (*operation, error) {
+	op := &operation{
+		id:      n.str("operationId"),
+		method:  strings.ToUpper(method),
+		path:    path,
+		summary: n.str("summary"),
+	}
+	if op.id == "" {
+		return nil, fmt.Errorf("%s %s has no operationId", op.method, path)
+	}
+
+	// 操作的参数覆盖同名同位置的公共参数
+	var params []*param
+	index := map[string]int{}
+	for _, list := range []*node{shared, n.get("parameters")} {
+		for _, item := range list.itemsOrNil() {
+			p := d.param(item)
+			key := p.in + "/" + p.name
+			if i, ok := index[key]; ok {
+				params[i] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	headerNames := map[string]bool{}
+	for _, p := range params {
+		if p.in == "header" {
+			headerNames[p.goName] = true
+		}
+	}
+	for _, p := range params {
+		switch p.in {
+		case "path":
+			op.pathParams = append(op.pathParams, p)
+		case "query":
+			// 与请求头同名的查询参数加上 Query 后缀，如 X-MetaStore-Key-Encoding 和 ?keyEncoding=
+			if headerNames[p.goName] {
+				p.goName += "Query"
+				p.tsName += "Query"
+			}
+			op.params = append(op.params, p)
+		case "header":
+			op.params = append(op.params, p)
+		default:
+			return nil, fmt.Errorf("%s: unsupported parameter %s in %s", op.id, p.name, p.in)
+		}
+	}
+	for _, p := range op.pathParams {
+		if !strings.Contains(path, "{"+p.name+"}") {
+			return nil, fmt.Errorf("%s: path parameter %s is not in %s", op.id, p.name, path)
+		}
+	}
+
+	if body := d.resolve(n.get("requestBody")); body != nil {
+		c, err := d.content(body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: request body: %w", op.id, err)
+		}
+		op.body = c
+	}
+
+	responses := n.get("responses")
+	var results []*content
+	for _, code := range responses.keysOrNil() {
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			continue // default 和 4XX 这样的范围都是错误
+		}
+		resp := d.resolve(responses.get(code))
+		c, err := d.content(resp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: response %d: %w", op.id, status, err)
+		}
+		// 2xx 以外带 JSON 响应体的状态也是结果，例如不健康时的 /health 和部分失败的恢复
+		if status < 200 || status > 299 {
+			if c == nil || c.kind() != kindJSON {
+				continue
+			}
+		}
+		op.ok = append(op.ok, status)
+		results = append(results, c)
+	}
+	if len(op.ok) == 0 {
+		return nil, fmt.Errorf("%s has no successful response", op.id)
+	}
+	op.result = results[0]
+	for _, c := range results[1:] {
+		if !d.sameContent(op.result, c) {
+			return nil, fmt.Errorf("%s: successful responses have different content", op.id)
+		}
+	}
+	return op, nil
+}
+
+// param 解析一个参数
+func (d *Document) param(n *node) *param {
+	n = d.resolve(n)
+	p := &param{
+		name:        n.str("name"),
+		in:          n.str("in"),
+		description: n.str("description"),
+		required:    n.flag("required"),
+		allowEmpty:  n.flag("allowEmptyValue"),
+		schema:      n.get("schema"),
+	}
+	name := p.name
+	if p.in == "header" {
+		name = strings.TrimPrefix(name, "X-MetaStore-")
+	}
+	p.goName = goName(name)
+	p.tsName = tsName(name)
+	return p
+}
+
+// content 返回请求体或响应的内容，没有内容时返回 nil
+func (d *Document) content(n *node) (*content, error) {
+	media := n.get("content")
+	if media == nil || len(media.keys) == 0 {
+		return nil, nil
+	}
+	if len(media.keys) > 1 {
+		return nil, fmt.Errorf("more than one media type")
+	}
+	mediaType := media.keys[0]
+	return &content{mediaType: mediaType, schema: media.get(mediaType).get("schema")}, nil
+}
+
+func (d *Document) sameContent(a, b *content) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.mediaType == b.mediaType && d.resolve(a.schema) == d.resolve(b.schema)
+}
+
+// resolve 解析 $ref，只支持文档内的引用
+func (d *Document) resolve(n *node) *node {
+	for n != nil {
+		ref := n.str("$ref")
+		if ref == "" {
+			return n
+		}
+		n = d.lookup(ref)
+	}
+	return nil
+}
+
+func (d *Document) lookup(ref string) *node {
+	target := d.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		target = target.get(part)
+	}
+	return target
+}
+
+// refName 返回 components.schemas 引用的名称，其他 schema 返回空
+func refName(n *node) string {
+	return strings.TrimPrefix(n.str("$ref"), "#/components/schemas/")
+}
+
+// isStruct 判断 schema 是否为有属性的 object，生成为结构体或 interface
+func isStruct(n *node) bool {
+	return n.str("type") == "object" && n.get("properties") != nil
+}
+
+// node 保留对象中 key 顺序的 JSON 值，生成的代码按文档中的顺序排列
+type node struct {
+	keys   []string
+	fields map[string]*node // 对象
+	items  []*node          // 数组
+	value  interface{}      // string、json.Number、bool 或 nil
+}
+
+func parseNode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &node{value: tok}, nil
+	}
+	n := &node{}
+	switch delim {
+	case '{':
+		n.fields = map[string]*node{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := tok.(string)
+			child, err := parseNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := n.fields[key]; !dup {
+				n.keys = append(n.keys, key)
+			}
+			n.fields[key] = child
+		}
+	case '[':
+		n.items = []*node{}
+		for dec.More() {
+			child, err := parseNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, child)
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *node) get(key string) *node {
+	if n == nil {
+		return nil
+	}
+	return n.fields[key]
+}
+
+func (n *node) str(key string) string {
+	if v := n.get(key); v != nil {
+		s, _ := v.value.(string)
+		return s
+	}
+	return ""
+}
+
+func (n *node) flag(key string) bool {
+	if v := n.get(key); v != nil {
+		b, _ := v.value.(bool)
+		return b
+	}
+	return false
+}
+
+// strings 返回字符串数组的值，如 enum 和 required
+func (n *node) strings(key string) []string {
+	var out []string
+	for _, item := range n.get(key).itemsOrNil() {
+		if s, ok := item.value.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (n *node) keysOrNil() []string {
+	if n == nil {
+		return nil
+	}
+	return n.keys
+}
+
+func (n *node) itemsOrNil() []*node {
+	if n == nil {
+		return nil
+	}
+	return n.items
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// initialisms 按 Go 的习惯全部大写的单词
+var initialisms = map[string]bool{
+	"api": true, "hlc": true, "http": true, "id": true, "json": true, "rtt": true, "ttl": true, "url": true,
+}
+
+// words 把名称拆分为小写单词，支持 prev_kv、prevKv、Last-Event-ID 和 JSONPath 这样的写法
+func words(name string) []string {
+	var out []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, strings.ToLower(string(cur)))
+			cur = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(cur) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+	return out
+}
+
+// goName 返回导出的 Go 名称，如 peer_url 为 PeerURL
+func goName(name string) string {
+	var b strings.Builder
+	for _, w := range words(name) {
+		if initialisms[w] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// tsName 返回 camelCase 的 TypeScript 名称，如 Last-Event-ID 为 lastEventId
+func tsName(name string) string {
+	var b strings.Builder
+	for i, w := range words(name) {
+		if i == 0 {
+			b.WriteString(w)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// unexported 把名称的首字母改为小写，生成的 Go 方法和参数类型不导出
+func unexported(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// sentence 把描述改为接在类型名后的小写开头，如 "The result of a batch" 为 "the result of a batch"。
+// 以缩写开头的描述保持不变
+func sentence(s string) string {
+	r := []rune(s)
+	if len(r) > 1 && unicode.IsUpper(r[0]) && unicode.IsUpper(r[1]) {
+		return s
+	}
+	return strings.ToLower(string(r[:1])) + string(r[1:])
+}
+
+// pathSegments 把路径模板拆分为文字和参数，参数以 { 开头
+func pathSegments(path string) []string {
+	var out []string
+	for path != "" {
+		i := strings.Index(path, "{")
+		if i < 0 {
+			out = append(out, path)
+			break
+		}
+		if i > 0 {
+			out = append(out, path[:i])
+		}
+		j := strings.Index(path[i:], "}")
+		if j < 0 {
+			out = append(out, path[i:])
+			break
+		}
+		out = append(out, path[i:i+j+1])
+		path = path[i+j+1:]
+	}
+	return out
+}
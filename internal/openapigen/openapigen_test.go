@@ -0,0 +1,103 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapigen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNames(t *testing.T) {
+	for _, tc := range []struct{ name, goName, tsName string }{
+		{"peer_url", "PeerURL", "peerUrl"},
+		{"prevKv", "PrevKv", "prevKv"},
+		{"Last-Event-ID", "LastEventID", "lastEventId"},
+		{"X-MetaStore-HLC", "XMetaStoreHLC", "xMetaStoreHlc"},
+		{"jsonPath", "JSONPath", "jsonPath"},
+		{"JSONPath", "JSONPath", "jsonPath"},
+	} {
+		assert.Equal(t, tc.goName, goName(tc.name), tc.name)
+		assert.Equal(t, tc.tsName, tsName(tc.name), tc.name)
+	}
+	assert.Equal(t, []string{"/admin/mirrors/", "{name}", "/start"}, pathSegments("/admin/mirrors/{name}/start"))
+}
+
+const testSpec = `{
+  "openapi": "3.0.3",
+  "paths": {
+    "/items/{name}": {
+      "parameters": [{"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {
+        "operationId": "getItem",
+        "parameters": [{"name": "X-MetaStore-Min-Index", "in": "header", "schema": {"type": "integer", "format": "uint64"}}],
+        "responses": {
+          "200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "404": {"description": "missing"}
+        }
+      },
+      "delete": {
+        "operationId": "deleteItem",
+        "responses": {"204": {"description": "deleted"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Item": {
+        "type": "object",
+        "description": "An item",
+        "required": ["name"],
+        "properties": {"name": {"type": "string"}, "size": {"type": "integer"}}
+      }
+    }
+  }
+}`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(testSpec))
+	require.NoError(t, err)
+	require.Len(t, doc.operations, 2)
+
+	get := doc.operations[0]
+	assert.Equal(t, "getItem", get.id)
+	assert.Equal(t, []int{200}, get.ok)
+	require.Len(t, get.pathParams, 1)
+	require.Len(t, get.params, 1)
+	assert.Equal(t, "MinIndex", get.params[0].goName)
+	assert.Equal(t, []int{204}, doc.operations[1].ok)
+	assert.Nil(t, doc.operations[1].result)
+
+	src, err := doc.Go("example")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "func (c *Client) getItem(ctx context.Context, name string, params getItemParams) (*Item, error) {")
+	assert.Contains(t, string(src), "Size int    `json:\"size,omitempty\"`")
+
+	ts, err := doc.TypeScript()
+	require.NoError(t, err)
+	assert.Contains(t, string(ts), "getItem(name: string, params: GetItemParams = {}): Operation<Item> {")
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, spec := range map[string]string{
+		"no operationId": `{"paths": {"/a": {"get": {"responses": {"204": {"description": "ok"}}}}}}`,
+		"no success":     `{"paths": {"/a": {"get": {"operationId": "a", "responses": {"404": {"description": "missing"}}}}}}`,
+		"path parameter": `{"paths": {"/a": {"get": {"operationId": "a", "parameters": [{"name": "b", "in": "path", "schema": {"type": "string"}}], "responses": {"204": {"description": "ok"}}}}}}`,
+	} {
+		_, err := Parse([]byte(spec))
+		assert.Error(t, err, name)
+	}
+}
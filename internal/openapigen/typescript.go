@@ -0,0 +1,390 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapigen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// operationType 生成的 TypeScript 代码中请求的类型，由 MetaStoreClient 发送
+const operationType = `/**
+ * The request of an operation, sent by MetaStoreClient. T is the type of its
+ * result: the decoded JSON body, the text, void, or the Response of a stream.
+ */
+export interface Operation<T> {
+  method: string;
+  path: string;
+  query: Record<string, string | string[] | undefined>;
+  headers: Record<string, string | undefined>;
+  body?: BodyInit;
+  contentType?: string;
+  /** Status codes of a successful response. */
+  ok: number[];
+  /** How the body of a successful response is read. */
+  result: "none" | "json" | "text" | "stream";
+  /** Never set, carries the result type. */
+  readonly type?: T;
+}
+`
+
+// tsGen 生成 clients/typescript 的代码
+type tsGen struct {
+	d *Document
+	b bytes.Buffer
+}
+
+// TypeScript 返回 TypeScript 代码：每个 schema 的 interface，每个 operation 的参数类型，
+// 以及 operations 中以 operationId 命名、构造请求的函数
+func (d *Document) TypeScript() ([]byte, error) {
+	g := &tsGen{d: d}
+	for _, line := range strings.Split(license, "\n") {
+		g.b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	fmt.Fprintf(&g.b, "\n// %s\n", header)
+
+	for _, s := range d.schemas {
+		if err := g.schema(s); err != nil {
+			return nil, err
+		}
+	}
+	g.b.WriteString("\n" + operationType)
+	for _, op := range d.operations {
+		if err := g.params(op); err != nil {
+			return nil, fmt.Errorf("%s: %w", op.id, err)
+		}
+	}
+	g.b.WriteString("\n/** Builds the request of each operation, named by operationId. */\nexport const operations = {\n")
+	for i, op := range d.operations {
+		if i > 0 {
+			g.b.WriteString("\n")
+		}
+		if err := g.operation(op); err != nil {
+			return nil, fmt.Errorf("%s: %w", op.id, err)
+		}
+	}
+	g.b.WriteString("};\n")
+	return g.b.Bytes(), nil
+}
+
+// schema 生成 components.schemas 中的一个定义：object 为 interface，枚举为联合类型，
+// 其他类型直接使用 TypeScript 的基本类型
+func (g *tsGen) schema(s namedSchema) error {
+	switch {
+	case isStruct(s.schema):
+	case len(s.schema.strings("enum")) > 0:
+		g.b.WriteString("\n")
+		g.doc("", s.schema.str("description"))
+		typ, err := g.typeOf(s.schema)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+		fmt.Fprintf(&g.b, "export type %s = %s;\n", s.name, typ)
+		return nil
+	default:
+		return nil
+	}
+
+	g.b.WriteString("\n")
+	g.doc("", s.schema.str("description"))
+	fmt.Fprintf(&g.b, "export interface %s {\n", s.name)
+	required := s.schema.strings("required")
+	props := s.schema.get("properties")
+	for _, name := range props.keysOrNil() {
+		prop := props.get(name)
+		typ, err := g.typeOf(prop)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", s.name, name, err)
+		}
+		g.doc("  ", prop.str("description"))
+		optional := "?"
+		if contains(required, name) {
+			optional = ""
+		}
+		fmt.Fprintf(&g.b, "  %s%s: %s;\n", tsKey(name), optional, typ)
+	}
+	g.b.WriteString("}\n")
+	return nil
+}
+
+// doc 生成 JSDoc 注释，desc 为空时不生成
+func (g *tsGen) doc(indent, desc string) {
+	if desc = oneLine(desc); desc != "" {
+		fmt.Fprintf(&g.b, "%s/** %s */\n", indent, strings.TrimSuffix(desc, ".")+".")
+	}
+}
+
+// typeOf 返回 schema 的 TypeScript 类型
+func (g *tsGen) typeOf(s *node) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	if name := refName(s); name != "" {
+		target := g.d.resolve(s)
+		if target == nil {
+			return "", fmt.Errorf("unresolved %s", s.str("$ref"))
+		}
+		if isStruct(target) || len(target.strings("enum")) > 0 {
+			return name, nil
+		}
+		return g.typeOf(target)
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alts := s.get(key); alts != nil {
+			var types []string
+			for _, alt := range alts.items {
+				typ, err := g.typeOf(alt)
+				if err != nil {
+					return "", err
+				}
+				types = append(types, typ)
+			}
+			return strings.Join(types, " | "), nil
+		}
+	}
+
+	switch s.str("type") {
+	case "string":
+		if enum := s.strings("enum"); len(enum) > 0 {
+			values := make([]string, len(enum))
+			for i, v := range enum {
+				values[i] = quote(v)
+			}
+			return strings.Join(values, " | "), nil
+		}
+		return "string", nil
+	case "integer", "number":
+		return "number", nil
+	case "boolean":
+		return "boolean", nil
+	case "array":
+		elem, err := g.typeOf(s.get("items"))
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(elem, " | ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]", nil
+	case "object":
+		if s.get("properties") == nil {
+			return "unknown", nil
+		}
+		return "", fmt.Errorf("inline object schemas are not supported, move it to components.schemas")
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.str("type"))
+}
+
+// paramsName 返回 operation 参数类型的名称，如 getKey 为 GetKeyParams
+func paramsName(op *operation) string {
+	return strings.ToUpper(op.id[:1]) + op.id[1:] + "Params"
+}
+
+// params 生成 operation 的查询参数和请求头的 interface
+func (g *tsGen) params(op *operation) error {
+	if len(op.params) == 0 {
+		return nil
+	}
+	fmt.Fprintf(&g.b, "\n/** Query and header parameters of %s. */\nexport interface %s {\n", op.id, paramsName(op))
+	for _, p := range op.params {
+		typ := "boolean"
+		if !p.allowEmpty {
+			var err error
+			if typ, err = g.typeOf(p.schema); err != nil {
+				return fmt.Errorf("parameter %s: %w", p.name, err)
+			}
+		}
+		doc := p.in + " " + p.name
+		if p.description != "" {
+			doc += ": " + p.description
+		}
+		g.doc("  ", doc)
+		optional := "?"
+		if p.required {
+			optional = ""
+		}
+		fmt.Fprintf(&g.b, "  %s%s: %s;\n", p.tsName, optional, typ)
+	}
+	g.b.WriteString("}\n")
+	return nil
+}
+
+// paramValue 返回参数在查询参数或请求头中的值，undefined 表示不发送
+func (g *tsGen) paramValue(p *param) string {
+	field := "params." + p.tsName
+	s := g.d.resolve(p.schema)
+	switch {
+	case p.allowEmpty:
+		return field + ` ? "" : undefined`
+	case s.str("type") == "boolean":
+		return field + ` ? "true" : undefined`
+	case s.str("type") == "array":
+		return field
+	case s.str("type") == "integer" || s.str("type") == "number":
+		if p.required {
+			return "String(" + field + ")"
+		}
+		return field + " === undefined ? undefined : String(" + field + ")"
+	case p.required:
+		return field
+	}
+	return field + " || undefined"
+}
+
+// operation 生成 operations 中构造一个请求的函数，参数依次为路径参数、请求体和
+// 查询参数与请求头，后者可选时可以省略
+func (g *tsGen) operation(op *operation) error {
+	var args []string
+	for _, p := range op.pathParams {
+		args = append(args, p.tsName+": string")
+	}
+	var bodyExpr string
+	if op.body != nil {
+		var typ string
+		switch op.body.kind() {
+		case kindJSON:
+			var err error
+			if typ, err = g.typeOf(op.body.schema); err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+			bodyExpr = "JSON.stringify(body)"
+			if typ == "unknown" {
+				bodyExpr = `typeof body === "string" ? body : JSON.stringify(body)`
+			}
+		case kindText:
+			typ, bodyExpr = "string", "body"
+		default:
+			typ, bodyExpr = "string | Uint8Array", "body"
+		}
+		args = append(args, "body: "+typ)
+	}
+	if len(op.params) > 0 {
+		arg := "params: " + paramsName(op)
+		required := false
+		for _, p := range op.params {
+			required = required || p.required
+		}
+		if !required {
+			arg += " = {}"
+		}
+		args = append(args, arg)
+	}
+	result, kind := "void", "none"
+	if op.result != nil {
+		switch op.result.kind() {
+		case kindJSON:
+			var err error
+			if result, err = g.typeOf(op.result.schema); err != nil {
+				return fmt.Errorf("response: %w", err)
+			}
+			kind = "json"
+		case kindText:
+			result, kind = "string", "text"
+		default:
+			result, kind = "Response", "stream"
+		}
+	}
+
+	summary := strings.TrimSuffix(oneLine(op.summary), ".")
+	if summary != "" {
+		summary += ". "
+	}
+	fmt.Fprintf(&g.b, "  /** %s%s %s */\n", summary, op.method, op.path)
+	fmt.Fprintf(&g.b, "  %s(%s): Operation<%s> {\n", op.id, strings.Join(args, ", "), result)
+	g.b.WriteString("    return {\n")
+	fmt.Fprintf(&g.b, "      method: %s,\n", quote(op.method))
+	fmt.Fprintf(&g.b, "      path: %s,\n", g.pathExpr(op))
+
+	var query, headers []string
+	if op.result != nil {
+		headers = append(headers, "Accept: "+quote(op.result.mediaType))
+	}
+	for _, p := range op.params {
+		entry := tsKey(p.name) + ": " + g.paramValue(p)
+		if p.in == "header" {
+			headers = append(headers, entry)
+		} else {
+			query = append(query, entry)
+		}
+	}
+	g.objectField("query", query)
+	g.objectField("headers", headers)
+	if op.body != nil {
+		fmt.Fprintf(&g.b, "      body: %s,\n", bodyExpr)
+		fmt.Fprintf(&g.b, "      contentType: %s,\n", quote(op.body.mediaType))
+	}
+	codes := make([]string, len(op.ok))
+	for i, code := range op.ok {
+		codes[i] = fmt.Sprint(code)
+	}
+	fmt.Fprintf(&g.b, "      ok: [%s],\n", strings.Join(codes, ", "))
+	fmt.Fprintf(&g.b, "      result: %s,\n", quote(kind))
+	g.b.WriteString("    };\n  },\n")
+	return nil
+}
+
+// objectField 生成请求中的对象字段，每个条目一行
+func (g *tsGen) objectField(name string, entries []string) {
+	if len(entries) == 0 {
+		fmt.Fprintf(&g.b, "      %s: {},\n", name)
+		return
+	}
+	fmt.Fprintf(&g.b, "      %s: {\n", name)
+	for _, e := range entries {
+		fmt.Fprintf(&g.b, "        %s,\n", e)
+	}
+	g.b.WriteString("      },\n")
+}
+
+// pathExpr 返回请求路径的表达式，路径参数用 encodeURIComponent 转义
+func (g *tsGen) pathExpr(op *operation) string {
+	var parts []string
+	for _, seg := range pathSegments(op.path) {
+		if !strings.HasPrefix(seg, "{") {
+			parts = append(parts, quote(seg))
+			continue
+		}
+		name := strings.Trim(seg, "{}")
+		for _, p := range op.pathParams {
+			if p.name == name {
+				name = p.tsName
+			}
+		}
+		parts = append(parts, "encodeURIComponent("+name+")")
+	}
+	return strings.Join(parts, " + ")
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsKey 返回对象字面量中的 key，不是标识符时加引号
+func tsKey(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return quote(name)
+}
+
+// quote 返回 TypeScript 字符串字面量
+func quote(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
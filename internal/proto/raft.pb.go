@@ -325,7 +325,7 @@ func (x *BatchOperation) GetOperations() []*RaftOperation {
 // This replaces the JSON-based RaftOperation struct for better performance
 type RaftOperation struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Operation type: PUT, DELETE, LEASE_GRANT, LEASE_REVOKE, TXN
+	// Operation type: PUT, DELETE, LEASE_GRANT, LEASE_REVOKE, TXN, COMPACT
 	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
 	// Key-value operation fields
 	Key      string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
@@ -336,9 +336,13 @@ type RaftOperation struct {
 	// Lease operation fields
 	Ttl int64 `protobuf:"varint,7,opt,name=ttl,proto3" json:"ttl,omitempty"`
 	// Transaction operation fields
-	Compares      []*Compare `protobuf:"bytes,8,rep,name=compares,proto3" json:"compares,omitempty"`
-	ThenOps       []*Op      `protobuf:"bytes,9,rep,name=then_ops,json=thenOps,proto3" json:"then_ops,omitempty"`
-	ElseOps       []*Op      `protobuf:"bytes,10,rep,name=else_ops,json=elseOps,proto3" json:"else_ops,omitempty"`
+	Compares []*Compare `protobuf:"bytes,8,rep,name=compares,proto3" json:"compares,omitempty"`
+	ThenOps  []*Op      `protobuf:"bytes,9,rep,name=then_ops,json=thenOps,proto3" json:"then_ops,omitempty"`
+	ElseOps  []*Op      `protobuf:"bytes,10,rep,name=else_ops,json=elseOps,proto3" json:"else_ops,omitempty"`
+	// Hybrid logical clock timestamp assigned by the proposing node (0 for older nodes)
+	Hlc uint64 `protobuf:"varint,11,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	// Compact operation fields
+	Revision      int64 `protobuf:"varint,12,opt,name=revision,proto3" json:"revision,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -443,6 +447,20 @@ func (x *RaftOperation) GetElseOps() []*Op {
 	return nil
 }
 
+func (x *RaftOperation) GetHlc() uint64 {
+	if x != nil {
+		return x.Hlc
+	}
+	return 0
+}
+
+func (x *RaftOperation) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
 // Compare represents a transaction comparison
 type Compare struct {
 	state  protoimpl.MessageState `protogen:"open.v1"`
@@ -684,6 +702,7 @@ type StoreSnapshot struct {
 	Revision      int64                     `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`                                                                                    // Current revision
 	KvData        map[string]*KeyValueProto `protobuf:"bytes,2,rep,name=kv_data,json=kvData,proto3" json:"kv_data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // All key-value pairs
 	Leases        map[int64]*LeaseProto     `protobuf:"bytes,3,rep,name=leases,proto3" json:"leases,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`              // All leases
+	Hlc           uint64                    `protobuf:"varint,4,opt,name=hlc,proto3" json:"hlc,omitempty"`                                                                                              // Last hybrid logical clock timestamp applied
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -739,6 +758,13 @@ func (x *StoreSnapshot) GetLeases() map[int64]*LeaseProto {
 	return nil
 }
 
+func (x *StoreSnapshot) GetHlc() uint64 {
+	if x != nil {
+		return x.Hlc
+	}
+	return 0
+}
+
 // KeyValueProto represents a key-value pair in Protobuf
 type KeyValueProto struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -748,6 +774,7 @@ type KeyValueProto struct {
 	ModRevision    int64                  `protobuf:"varint,4,opt,name=mod_revision,json=modRevision,proto3" json:"mod_revision,omitempty"`
 	Version        int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
 	Lease          int64                  `protobuf:"varint,6,opt,name=lease,proto3" json:"lease,omitempty"`
+	Hlc            uint64                 `protobuf:"varint,7,opt,name=hlc,proto3" json:"hlc,omitempty"` // Hybrid logical clock timestamp of the last modification
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -824,6 +851,13 @@ func (x *KeyValueProto) GetLease() int64 {
 	return 0
 }
 
+func (x *KeyValueProto) GetHlc() uint64 {
+	if x != nil {
+		return x.Hlc
+	}
+	return 0
+}
+
 // LeaseProto represents a lease in Protobuf
 type LeaseProto struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
@@ -905,7 +939,7 @@ const file_internal_proto_raft_proto_rawDesc = "" +
 	"\x0eBatchOperation\x125\n" +
 	"\n" +
 	"operations\x18\x01 \x03(\v2\x15.raftpb.RaftOperationR\n" +
-	"operations\"\xa9\x02\n" +
+	"operations\"\xd7\x02\n" +
 	"\rRaftOperation\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x10\n" +
 	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
@@ -919,7 +953,9 @@ const file_internal_proto_raft_proto_rawDesc = "" +
 	".raftpb.OpR\athenOps\x12%\n" +
 	"\belse_ops\x18\n" +
 	" \x03(\v2\n" +
-	".raftpb.OpR\aelseOps\"\xc0\x03\n" +
+	".raftpb.OpR\aelseOps\x12\x10\n" +
+	"\x03hlc\x18\v \x01(\x04R\x03hlc\x12\x1a\n" +
+	"\brevision\x18\f \x01(\x03R\brevision\"\xc0\x03\n" +
 	"\aCompare\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x125\n" +
 	"\x06result\x18\x02 \x01(\x0e2\x1d.raftpb.Compare.CompareResultR\x06result\x125\n" +
@@ -952,24 +988,26 @@ const file_internal_proto_raft_proto_rawDesc = "" +
 	"\x03PUT\x10\x00\x12\n" +
 	"\n" +
 	"\x06DELETE\x10\x01\x12\t\n" +
-	"\x05RANGE\x10\x02\"\xc3\x02\n" +
+	"\x05RANGE\x10\x02\"\xd5\x02\n" +
 	"\rStoreSnapshot\x12\x1a\n" +
 	"\brevision\x18\x01 \x01(\x03R\brevision\x12:\n" +
 	"\akv_data\x18\x02 \x03(\v2!.raftpb.StoreSnapshot.KvDataEntryR\x06kvData\x129\n" +
-	"\x06leases\x18\x03 \x03(\v2!.raftpb.StoreSnapshot.LeasesEntryR\x06leases\x1aP\n" +
+	"\x06leases\x18\x03 \x03(\v2!.raftpb.StoreSnapshot.LeasesEntryR\x06leases\x12\x10\n" +
+	"\x03hlc\x18\x04 \x01(\x04R\x03hlc\x1aP\n" +
 	"\vKvDataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12+\n" +
 	"\x05value\x18\x02 \x01(\v2\x15.raftpb.KeyValueProtoR\x05value:\x028\x01\x1aM\n" +
 	"\vLeasesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\x03R\x03key\x12(\n" +
-	"\x05value\x18\x02 \x01(\v2\x12.raftpb.LeaseProtoR\x05value:\x028\x01\"\xb3\x01\n" +
+	"\x05value\x18\x02 \x01(\v2\x12.raftpb.LeaseProtoR\x05value:\x028\x01\"\xc5\x01\n" +
 	"\rKeyValueProto\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\fR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\fR\x05value\x12'\n" +
 	"\x0fcreate_revision\x18\x03 \x01(\x03R\x0ecreateRevision\x12!\n" +
 	"\fmod_revision\x18\x04 \x01(\x03R\vmodRevision\x12\x18\n" +
 	"\aversion\x18\x05 \x01(\x03R\aversion\x12\x14\n" +
-	"\x05lease\x18\x06 \x01(\x03R\x05lease\"s\n" +
+	"\x05lease\x18\x06 \x01(\x03R\x05lease\x12\x10\n" +
+	"\x03hlc\x18\a \x01(\x04R\x03hlc\"s\n" +
 	"\n" +
 	"LeaseProto\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x10\n" +
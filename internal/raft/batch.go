@@ -0,0 +1,43 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"metaStore/internal/batch"
+	"metaStore/pkg/config"
+)
+
+// newBatchConfig 把 raft.batch 配置转换为批量提案器配置，
+// raft.batch.frontends 中的每个前端使用独立的队列
+func newBatchConfig(cfg config.RaftBatchConfig) batch.BatchConfig {
+	convert := func(c config.RaftBatchConfig) batch.BatchConfig {
+		return batch.BatchConfig{
+			MinBatchSize:  c.MinBatchSize,
+			MaxBatchSize:  c.MaxBatchSize,
+			MinTimeout:    c.MinTimeout,
+			MaxTimeout:    c.MaxTimeout,
+			LoadThreshold: c.LoadThreshold,
+		}
+	}
+
+	bc := convert(cfg)
+	for frontend := range cfg.Frontends {
+		if bc.Origins == nil {
+			bc.Origins = make(map[string]batch.BatchConfig)
+		}
+		bc.Origins[frontend] = convert(cfg.ForFrontend(frontend))
+	}
+	return bc
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestEphemeralNodeCommitsProposals(t *testing.T) {
+	proposeC := make(chan string, 4)
+	confChangeC := make(chan raftpb.ConfChange)
+	commitC, errorC, node := NewEphemeralNode(1, proposeC, confChangeC)
+
+	proposeC <- "a"
+	proposeC <- "b"
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case c := <-commitC:
+			require.NotNil(t, c)
+			got = append(got, c.Data...)
+			close(c.ApplyDoneC)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for commit")
+		}
+	}
+	require.Equal(t, []string{"a", "b"}, got)
+
+	require.Eventually(t, func() bool { return node.Status().Applied == 2 }, time.Second, 10*time.Millisecond)
+	status := node.Status()
+	require.Equal(t, uint64(1), status.LeaderID)
+	require.Error(t, node.TransferLeadership(2))
+
+	close(proposeC)
+	_, ok := <-errorC
+	require.False(t, ok)
+	require.True(t, node.IsStopped())
+}
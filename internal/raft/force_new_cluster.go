@@ -0,0 +1,115 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"fmt"
+	"sort"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// forceNewCluster 用于灾难恢复（与 etcd 的 --force-new-cluster 相同）：多数成员永久丢失时，
+// 让剩下的一个成员以原有数据单独组成集群
+//
+// 丢弃提交点之后未提交的日志，在提交点之后追加移除其他成员的 ConfChange 并直接视为已提交。
+// cs 是快照中的成员配置，ents 是快照之后的日志。返回需要追加的条目和新的 HardState，
+// 重启后 raft 应用这些 ConfChange，节点成为唯一的投票成员
+func forceNewCluster(id uint64, cs raftpb.ConfState, ents []raftpb.Entry, st raftpb.HardState) ([]raftpb.Entry, raftpb.HardState) {
+	members := committedMembers(cs, ents, st.Commit)
+
+	var changes []raftpb.ConfChange
+	if !members[id] {
+		changes = append(changes, raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: id})
+	}
+	for _, m := range sortedMembers(members) {
+		if m != id {
+			changes = append(changes, raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: m})
+		}
+	}
+	return appendConfChanges(changes, st)
+}
+
+// unsafeRemoveMembers 不经过多数派确认直接移除 remove 中的成员（与 etcd 的
+// unsafe remove 相同），所有存活的成员必须以相同的参数改写，否则它们的配置不一致
+func unsafeRemoveMembers(remove []uint64, cs raftpb.ConfState, ents []raftpb.Entry, st raftpb.HardState) ([]raftpb.Entry, raftpb.HardState, error) {
+	members := committedMembers(cs, ents, st.Commit)
+
+	var changes []raftpb.ConfChange
+	for _, m := range remove {
+		if !members[m] {
+			return nil, st, fmt.Errorf("member %d is not in the raft configuration %v", m, sortedMembers(members))
+		}
+		delete(members, m)
+		changes = append(changes, raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: m})
+	}
+	if len(members) == 0 {
+		return nil, st, fmt.Errorf("removing %v would leave no members", remove)
+	}
+	appended, st := appendConfChanges(changes, st)
+	return appended, st, nil
+}
+
+// committedMembers 返回快照配置 cs 经过 commit 之前的成员变更后的成员
+func committedMembers(cs raftpb.ConfState, ents []raftpb.Entry, commit uint64) map[uint64]bool {
+	members := make(map[uint64]bool)
+	for _, m := range cs.Voters {
+		members[m] = true
+	}
+	for _, m := range cs.Learners {
+		members[m] = true
+	}
+	for _, ent := range ents {
+		if ent.Index > commit {
+			break
+		}
+		if ent.Type != raftpb.EntryConfChange {
+			continue
+		}
+		var cc raftpb.ConfChange
+		if err := cc.Unmarshal(ent.Data); err != nil {
+			continue
+		}
+		switch cc.Type {
+		case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
+			members[cc.NodeID] = true
+		case raftpb.ConfChangeRemoveNode:
+			delete(members, cc.NodeID)
+		}
+	}
+	return members
+}
+
+func sortedMembers(members map[uint64]bool) []uint64 {
+	ids := make([]uint64, 0, len(members))
+	for m := range members {
+		ids = append(ids, m)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// appendConfChanges 把成员变更追加在提交点之后并直接视为已提交，提交点之后原有的日志被覆盖
+func appendConfChanges(changes []raftpb.ConfChange, st raftpb.HardState) ([]raftpb.Entry, raftpb.HardState) {
+	appended := make([]raftpb.Entry, 0, len(changes))
+	index := st.Commit
+	for _, cc := range changes {
+		index++
+		data, _ := cc.Marshal()
+		appended = append(appended, raftpb.Entry{Type: raftpb.EntryConfChange, Term: st.Term, Index: index, Data: data})
+	}
+	st.Commit = index
+	return appended, st
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func confChangeEntry(t *testing.T, index, term uint64, cc raftpb.ConfChange) raftpb.Entry {
+	t.Helper()
+	data, err := cc.Marshal()
+	require.NoError(t, err)
+	return raftpb.Entry{Type: raftpb.EntryConfChange, Index: index, Term: term, Data: data}
+}
+
+func TestForceNewClusterEntries(t *testing.T) {
+	cs := raftpb.ConfState{Voters: []uint64{1, 2, 3}}
+	ents := []raftpb.Entry{
+		{Type: raftpb.EntryNormal, Index: 6, Term: 2},
+		confChangeEntry(t, 7, 2, raftpb.ConfChange{Type: raftpb.ConfChangeAddLearnerNode, NodeID: 4}),
+		confChangeEntry(t, 8, 3, raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 3}),
+		// 未提交的成员变更被丢弃
+		confChangeEntry(t, 9, 3, raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 5}),
+	}
+	st := raftpb.HardState{Term: 3, Vote: 2, Commit: 8}
+
+	appended, newSt := forceNewCluster(1, cs, ents, st)
+	assert.Equal(t, raftpb.HardState{Term: 3, Vote: 2, Commit: 10}, newSt)
+	require.Len(t, appended, 2)
+	for i, want := range []uint64{2, 4} {
+		var cc raftpb.ConfChange
+		require.NoError(t, cc.Unmarshal(appended[i].Data))
+		assert.Equal(t, raftpb.ConfChangeRemoveNode, cc.Type)
+		assert.Equal(t, want, cc.NodeID)
+		assert.Equal(t, uint64(9+i), appended[i].Index)
+		assert.Equal(t, uint64(3), appended[i].Term)
+	}
+
+	// 不在配置中的成员先把自己加入
+	appended, _ = forceNewCluster(7, cs, nil, raftpb.HardState{Term: 1, Commit: 5})
+	var cc raftpb.ConfChange
+	require.NoError(t, cc.Unmarshal(appended[0].Data))
+	assert.Equal(t, raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 7}, cc)
+	assert.Len(t, appended, 4)
+}
+
+// TestForceNewClusterElectsAlone 测试三成员集群中剩下的成员改写日志后能够单独当选 leader
+func TestForceNewClusterElectsAlone(t *testing.T) {
+	storage := raft.NewMemoryStorage()
+	cs := raftpb.ConfState{Voters: []uint64{1, 2, 3}}
+	require.NoError(t, storage.ApplySnapshot(raftpb.Snapshot{Metadata: raftpb.SnapshotMetadata{Index: 5, Term: 2, ConfState: cs}}))
+	ents := []raftpb.Entry{{Index: 6, Term: 2}, {Index: 7, Term: 2}}
+
+	appended, st := forceNewCluster(1, cs, ents, raftpb.HardState{Term: 2, Commit: 6})
+	require.NoError(t, storage.Append(append(ents[:1], appended...)))
+	require.NoError(t, storage.SetHardState(st))
+
+	rn, err := raft.NewRawNode(&raft.Config{ID: 1, ElectionTick: 10, HeartbeatTick: 1, Storage: storage, MaxSizePerMsg: 1 << 20, MaxInflightMsgs: 256})
+	require.NoError(t, err)
+	drain := func() {
+		for rn.HasReady() {
+			rd := rn.Ready()
+			require.NoError(t, storage.Append(rd.Entries))
+			if !raft.IsEmptyHardState(rd.HardState) {
+				require.NoError(t, storage.SetHardState(rd.HardState))
+			}
+			for _, ent := range rd.CommittedEntries {
+				if ent.Type == raftpb.EntryConfChange {
+					var cc raftpb.ConfChange
+					require.NoError(t, cc.Unmarshal(ent.Data))
+					rn.ApplyConfChange(cc)
+				}
+			}
+			rn.Advance(rd)
+		}
+	}
+	drain()
+	require.NoError(t, rn.Campaign())
+	drain()
+
+	status := rn.Status()
+	assert.Equal(t, raft.StateLeader, status.RaftState)
+	assert.Equal(t, map[uint64]struct{}{1: {}}, status.Config.Voters.IDs())
+}
+
+func TestUnsafeRemoveMembers(t *testing.T) {
+	cs := raftpb.ConfState{Voters: []uint64{1, 2, 3, 4, 5}}
+	st := raftpb.HardState{Term: 4, Commit: 20}
+
+	// 五个成员中 4 和 5 永久丢失，剩下的三个成员以相同的参数改写
+	appended, newSt, err := unsafeRemoveMembers([]uint64{4, 5}, cs, nil, st)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(22), newSt.Commit)
+	assert.Equal(t, []uint64{1, 2, 3}, membersAfter(cs, nil, st.Commit, appended))
+
+	_, _, err = unsafeRemoveMembers([]uint64{6}, cs, nil, st)
+	assert.Error(t, err, "not a member")
+	_, _, err = unsafeRemoveMembers([]uint64{1}, raftpb.ConfState{Voters: []uint64{1}}, nil, st)
+	assert.Error(t, err, "no members left")
+
+	_, _, err = Recovery{MemberID: 1, RemoveMembers: []uint64{1}}.rewrite(cs, nil, st)
+	assert.Error(t, err, "a member cannot remove itself")
+	_, _, err = Recovery{MemberID: 1, ForceNewCluster: true, RemoveMembers: []uint64{2}}.rewrite(cs, nil, st)
+	assert.Error(t, err)
+	_, _, err = Recovery{MemberID: 1}.rewrite(cs, nil, st)
+	assert.Error(t, err)
+
+	appended, _, err = Recovery{MemberID: 3, ForceNewCluster: true}.rewrite(cs, nil, st)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3}, membersAfter(cs, nil, st.Commit, appended))
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/raft/v3"
+)
+
+var (
+	// ErrNotLeader is returned when a leader-only operation runs on a follower
+	ErrNotLeader = errors.New("raft: not leader")
+	// ErrNoTransferee is returned when no follower is fit to take over leadership
+	ErrNoTransferee = errors.New("raft: no active follower to transfer leadership to")
+)
+
+// leaderPollInterval 等待 leader 变更时轮询 raft 状态的间隔
+const leaderPollInterval = 20 * time.Millisecond
+
+// pickTransferee 选择最适合接任的 follower：最近活跃的投票成员中日志最新（Match 最大）者
+// 日志越新，转移时需要追赶的日志越少，新 leader 越快开始服务
+func pickTransferee(st raft.Status) uint64 {
+	var best, bestMatch uint64
+	for id, pr := range st.Progress {
+		if id == st.ID || pr.IsLearner || !pr.RecentActive {
+			continue
+		}
+		if best == 0 || pr.Match > bestMatch || (pr.Match == bestMatch && id < best) {
+			best, bestMatch = id, pr.Match
+		}
+	}
+	return best
+}
+
+// moveLeader 将 leader 转移给最健康的 follower，并等待新 leader 产生
+// 返回新 leader 的 ID
+func moveLeader(ctx context.Context, node raft.Node) (uint64, error) {
+	st := node.Status()
+	if st.RaftState != raft.StateLeader {
+		return 0, ErrNotLeader
+	}
+	target := pickTransferee(st)
+	if target == 0 {
+		return 0, ErrNoTransferee
+	}
+
+	node.TransferLeadership(ctx, st.ID, target)
+
+	ticker := time.NewTicker(leaderPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("leadership transfer to %d: %w", target, ctx.Err())
+		case <-ticker.C:
+			// 转移期间 leader 可能短暂为 0；其他节点当选也视为成功
+			if lead := node.Status().Lead; lead != 0 && lead != st.ID {
+				return lead, nil
+			}
+		}
+	}
+}
+
+// diskLatencyMonitor 跟踪 raft 日志持久化耗时
+// 连续 window 次写入超过 threshold 时触发 leader 转移，两次触发之间至少间隔 cooldown
+// 只在 serveChannels 所在的 goroutine 中调用
+type diskLatencyMonitor struct {
+	threshold time.Duration
+	window    int
+	cooldown  time.Duration
+
+	slow      int
+	lastFired time.Time
+}
+
+func newDiskLatencyMonitor(threshold time.Duration, window int, cooldown time.Duration) *diskLatencyMonitor {
+	if threshold <= 0 {
+		return nil
+	}
+	if window <= 0 {
+		window = 1
+	}
+	return &diskLatencyMonitor{threshold: threshold, window: window, cooldown: cooldown}
+}
+
+// observe 记录一次持久化耗时，返回是否应当转移 leader
+func (m *diskLatencyMonitor) observe(d time.Duration, now time.Time) bool {
+	if m == nil {
+		return false
+	}
+	if d < m.threshold {
+		m.slow = 0
+		return false
+	}
+
+	m.slow++
+	if m.slow < m.window {
+		return false
+	}
+	if !m.lastFired.IsZero() && now.Sub(m.lastFired) < m.cooldown {
+		return false
+	}
+	m.slow = 0
+	m.lastFired = now
+	return true
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/tracker"
+)
+
+func TestPickTransferee(t *testing.T) {
+	st := raft.Status{
+		BasicStatus: raft.BasicStatus{ID: 1},
+		Progress: map[uint64]tracker.Progress{
+			1: {Match: 100, RecentActive: true},
+			2: {Match: 90, RecentActive: true},
+			3: {Match: 99, RecentActive: false}, // 不活跃
+			4: {Match: 100, RecentActive: true, IsLearner: true},
+			5: {Match: 95, RecentActive: true},
+		},
+	}
+	assert.Equal(t, uint64(5), pickTransferee(st))
+
+	// 日志同样新时选择 ID 较小者，保证结果确定
+	st.Progress[2] = tracker.Progress{Match: 95, RecentActive: true}
+	assert.Equal(t, uint64(2), pickTransferee(st))
+
+	single := raft.Status{
+		BasicStatus: raft.BasicStatus{ID: 1},
+		Progress:    map[uint64]tracker.Progress{1: {Match: 10, RecentActive: true}},
+	}
+	assert.Equal(t, uint64(0), pickTransferee(single))
+}
+
+func TestDiskLatencyMonitor(t *testing.T) {
+	assert.Nil(t, newDiskLatencyMonitor(0, 3, time.Minute), "zero threshold disables the monitor")
+	var disabled *diskLatencyMonitor
+	assert.False(t, disabled.observe(time.Hour, time.Now()))
+
+	m := newDiskLatencyMonitor(10*time.Millisecond, 3, time.Minute)
+	now := time.Now()
+
+	assert.False(t, m.observe(20*time.Millisecond, now))
+	assert.False(t, m.observe(20*time.Millisecond, now))
+	assert.False(t, m.observe(time.Millisecond, now), "a fast write resets the window")
+	assert.False(t, m.observe(20*time.Millisecond, now))
+	assert.False(t, m.observe(20*time.Millisecond, now))
+	assert.True(t, m.observe(20*time.Millisecond, now))
+
+	// 冷却期内不重复触发
+	for i := 0; i < 3; i++ {
+		assert.False(t, m.observe(20*time.Millisecond, now.Add(time.Second)))
+	}
+	// 持续变慢时冷却期结束后立即再次触发
+	assert.True(t, m.observe(20*time.Millisecond, now.Add(2*time.Minute)))
+}
@@ -18,6 +18,8 @@ import (
 	"errors"
 	"net"
 	"time"
+
+	"metaStore/pkg/config"
 )
 
 // StoppableListener sets TCP keep-alive timeouts on accepted
@@ -35,6 +37,31 @@ func NewStoppableListener(addr string, stopc <-chan struct{}) (*StoppableListene
 	return &StoppableListener{ln.(*net.TCPListener), stopc}, nil
 }
 
+// listenPeers listens on raft.listen_peer_urls, by default on the host and port
+// of selfURL, the URL of this member in the peer list
+func listenPeers(cfg *config.Config, selfURL string, stopc <-chan struct{}) ([]*StoppableListener, error) {
+	var raftCfg config.RaftConfig
+	if cfg != nil {
+		raftCfg = cfg.Server.Raft
+	}
+	addrs, err := raftCfg.PeerListenAddresses(selfURL)
+	if err != nil {
+		return nil, err
+	}
+	lns := make([]*StoppableListener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := NewStoppableListener(addr, stopc)
+		if err != nil {
+			for _, l := range lns {
+				l.Close()
+			}
+			return nil, err
+		}
+		lns = append(lns, ln)
+	}
+	return lns, nil
+}
+
 func (ln StoppableListener) Accept() (c net.Conn, err error) {
 	connc := make(chan *net.TCPConn, 1)
 	errc := make(chan error, 1)
@@ -0,0 +1,233 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+	"go.etcd.io/raft/v3/tracker"
+)
+
+// ErrReplaceInProgress is returned when another member replacement is running
+var ErrReplaceInProgress = errors.New("raft: member replacement already in progress")
+
+const (
+	// replacePollInterval 轮询成员进度的间隔
+	replacePollInterval = 100 * time.Millisecond
+	// confChangeRetryInterval 配置变更未生效时重新提交的间隔
+	// 存在未应用的配置变更时 raft 会丢弃新的提案，重新提交是幂等的
+	confChangeRetryInterval = time.Second
+)
+
+// memberReplacer 在 leader 上编排故障成员替换：
+// 添加 learner -> 等待追上 -> 提升 -> 移除故障成员
+// 每一步执行前都会检查当前成员配置，因此中断后使用相同参数重试即可从断点继续
+// 零值可用，同一时间只允许一个替换流程
+type memberReplacer struct {
+	running atomic.Bool
+	node    raft.Node // 仅在 running 期间有效
+}
+
+// replace 执行替换流程，每次状态变化都会调用 report 汇报进度
+func (r *memberReplacer) replace(ctx context.Context, node raft.Node, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	if !r.running.CompareAndSwap(false, true) {
+		return ErrReplaceInProgress
+	}
+	defer r.running.Store(false)
+	r.node = node
+
+	if report == nil {
+		report = func(kvstore.MemberReplaceProgress) {}
+	}
+	progress := kvstore.MemberReplaceProgress{DeadID: req.DeadID, NewID: req.NewID}
+
+	err := r.run(ctx, req, &progress, report)
+	if err != nil {
+		progress.Phase = kvstore.MemberReplaceFailed
+		progress.Error = err.Error()
+	} else {
+		progress.Phase = kvstore.MemberReplaceDone
+	}
+	report(progress)
+	return err
+}
+
+func (r *memberReplacer) run(ctx context.Context, req kvstore.MemberReplaceRequest, progress *kvstore.MemberReplaceProgress, report func(kvstore.MemberReplaceProgress)) error {
+	st, err := r.leaderStatus()
+	if err != nil {
+		return err
+	}
+	if err := validateReplace(st, req); err != nil {
+		return err
+	}
+
+	// 1. 以 learner 身份加入，不影响 quorum
+	progress.Phase = kvstore.MemberReplaceAddLearner
+	report(*progress)
+	if _, exists := st.Progress[req.NewID]; !exists {
+		cc := raftpb.ConfChange{
+			Type:    raftpb.ConfChangeAddLearnerNode,
+			NodeID:  req.NewID,
+			Context: []byte(req.PeerURL),
+		}
+		if err := r.applyConfChange(ctx, cc, func(st raft.Status) bool {
+			_, ok := st.Progress[req.NewID]
+			return ok
+		}); err != nil {
+			return fmt.Errorf("add learner %d: %w", req.NewID, err)
+		}
+	}
+
+	// 2. 等待 learner 通过快照和日志追上 leader
+	progress.Phase = kvstore.MemberReplaceCatchUp
+	report(*progress)
+	if err := r.waitCatchUp(ctx, req, progress, report); err != nil {
+		return fmt.Errorf("wait for learner %d: %w", req.NewID, err)
+	}
+
+	// 3. 提升为投票成员
+	progress.Phase = kvstore.MemberReplacePromote
+	report(*progress)
+	cc := raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: req.NewID}
+	if err := r.applyConfChange(ctx, cc, func(st raft.Status) bool {
+		pr, ok := st.Progress[req.NewID]
+		return ok && !pr.IsLearner
+	}); err != nil {
+		return fmt.Errorf("promote learner %d: %w", req.NewID, err)
+	}
+
+	// 4. 移除故障成员
+	progress.Phase = kvstore.MemberReplaceRemoveDead
+	report(*progress)
+	cc = raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: req.DeadID}
+	if err := r.applyConfChange(ctx, cc, func(st raft.Status) bool {
+		_, ok := st.Progress[req.DeadID]
+		return !ok
+	}); err != nil {
+		return fmt.Errorf("remove member %d: %w", req.DeadID, err)
+	}
+	return nil
+}
+
+// validateReplace 检查替换请求在当前成员配置下是否可以执行
+func validateReplace(st raft.Status, req kvstore.MemberReplaceRequest) error {
+	if req.NewID == 0 || req.DeadID == 0 {
+		return fmt.Errorf("dead and new member IDs are required")
+	}
+	if req.NewID == req.DeadID {
+		return fmt.Errorf("new member ID must differ from the dead member ID")
+	}
+	if req.DeadID == st.ID {
+		return fmt.Errorf("member %d is the leader, transfer leadership before replacing it", req.DeadID)
+	}
+
+	dead, deadExists := st.Progress[req.DeadID]
+	newPr, newExists := st.Progress[req.NewID]
+	switch {
+	case newExists && !newPr.IsLearner && !deadExists:
+		// 已完成替换，重试时直接成功
+		return nil
+	case !deadExists:
+		return fmt.Errorf("member %d not found", req.DeadID)
+	case dead.RecentActive && !req.Force:
+		return fmt.Errorf("member %d is still active, use force to replace it anyway", req.DeadID)
+	case !newExists && req.PeerURL == "":
+		return fmt.Errorf("peer URL is required to add member %d", req.NewID)
+	}
+	return nil
+}
+
+// waitCatchUp 等待 learner 的 Match 追上 leader 的 commit index
+func (r *memberReplacer) waitCatchUp(ctx context.Context, req kvstore.MemberReplaceRequest, progress *kvstore.MemberReplaceProgress, report func(kvstore.MemberReplaceProgress)) error {
+	ticker := time.NewTicker(replacePollInterval)
+	defer ticker.Stop()
+	for {
+		st, err := r.leaderStatus()
+		if err != nil {
+			return err
+		}
+		pr, ok := st.Progress[req.NewID]
+		if !ok {
+			return fmt.Errorf("member %d was removed", req.NewID)
+		}
+		if !pr.IsLearner {
+			// 已被提升（例如重试之前的替换）
+			return nil
+		}
+
+		prev := *progress
+		progress.LearnerMatch = pr.Match
+		progress.LeaderCommit = st.Commit
+		progress.Snapshotting = pr.State == tracker.StateSnapshot
+		if *progress != prev {
+			report(*progress)
+		}
+		if pr.State == tracker.StateReplicate && pr.Match+req.CatchUpLag >= st.Commit {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyConfChange 提交配置变更并等待其在本节点生效（done 返回 true）
+func (r *memberReplacer) applyConfChange(ctx context.Context, cc raftpb.ConfChange, done func(raft.Status) bool) error {
+	ticker := time.NewTicker(replacePollInterval)
+	defer ticker.Stop()
+
+	var lastProposed time.Time
+	for {
+		st, err := r.leaderStatus()
+		if err != nil {
+			return err
+		}
+		if done(st) {
+			return nil
+		}
+		if time.Since(lastProposed) >= confChangeRetryInterval {
+			if err := r.node.ProposeConfChange(ctx, cc); err != nil {
+				return err
+			}
+			lastProposed = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaderStatus 返回 raft 状态，只有 leader 持有各成员的复制进度
+func (r *memberReplacer) leaderStatus() (raft.Status, error) {
+	st := r.node.Status()
+	if st.RaftState != raft.StateLeader {
+		return st, ErrNotLeader
+	}
+	return st, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+	"go.etcd.io/raft/v3/tracker"
+)
+
+// replaceTestNode 模拟 leader：配置变更立即生效，learner 每次查询状态时追赶一段日志
+type replaceTestNode struct {
+	raft.Node
+
+	mu       sync.Mutex
+	commit   uint64
+	progress map[uint64]tracker.Progress
+	proposed []raftpb.ConfChangeType
+}
+
+func newReplaceTestNode() *replaceTestNode {
+	return &replaceTestNode{
+		commit: 100,
+		progress: map[uint64]tracker.Progress{
+			1: {Match: 100, State: tracker.StateReplicate, RecentActive: true},
+			2: {Match: 40, State: tracker.StateProbe, RecentActive: false},
+			3: {Match: 100, State: tracker.StateReplicate, RecentActive: true},
+		},
+	}
+}
+
+func (n *replaceTestNode) Status() raft.Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	prs := make(map[uint64]tracker.Progress, len(n.progress))
+	for id, pr := range n.progress {
+		if pr.IsLearner && pr.Match < n.commit {
+			pr.Match += 40
+			pr.State = tracker.StateReplicate
+			n.progress[id] = pr
+		}
+		prs[id] = pr
+	}
+	st := raft.Status{Progress: prs}
+	st.ID = 1
+	st.Commit = n.commit
+	st.RaftState = raft.StateLeader
+	return st
+}
+
+func (n *replaceTestNode) ProposeConfChange(ctx context.Context, cc raftpb.ConfChangeI) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	c := cc.(raftpb.ConfChange)
+	n.proposed = append(n.proposed, c.Type)
+	switch c.Type {
+	case raftpb.ConfChangeAddLearnerNode:
+		n.progress[c.NodeID] = tracker.Progress{State: tracker.StateSnapshot, IsLearner: true}
+	case raftpb.ConfChangeAddNode:
+		pr := n.progress[c.NodeID]
+		pr.IsLearner = false
+		n.progress[c.NodeID] = pr
+	case raftpb.ConfChangeRemoveNode:
+		delete(n.progress, c.NodeID)
+	}
+	return nil
+}
+
+func TestMemberReplace(t *testing.T) {
+	node := newReplaceTestNode()
+	var r memberReplacer
+	var phases []kvstore.MemberReplacePhase
+	var maxMatch uint64
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req := kvstore.MemberReplaceRequest{DeadID: 2, NewID: 4, PeerURL: "http://127.0.0.1:9024"}
+	err := r.replace(ctx, node, req, func(p kvstore.MemberReplaceProgress) {
+		if len(phases) == 0 || phases[len(phases)-1] != p.Phase {
+			phases = append(phases, p.Phase)
+		}
+		if p.LearnerMatch > maxMatch {
+			maxMatch = p.LearnerMatch
+		}
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []kvstore.MemberReplacePhase{
+		kvstore.MemberReplaceAddLearner,
+		kvstore.MemberReplaceCatchUp,
+		kvstore.MemberReplacePromote,
+		kvstore.MemberReplaceRemoveDead,
+		kvstore.MemberReplaceDone,
+	}, phases)
+	assert.GreaterOrEqual(t, maxMatch, uint64(100), "catch-up progress is reported")
+	assert.Equal(t, []raftpb.ConfChangeType{
+		raftpb.ConfChangeAddLearnerNode,
+		raftpb.ConfChangeAddNode,
+		raftpb.ConfChangeRemoveNode,
+	}, node.proposed)
+
+	st := node.Status()
+	assert.NotContains(t, st.Progress, uint64(2))
+	assert.False(t, st.Progress[4].IsLearner)
+
+	// 重试已完成的替换不会再提交配置变更
+	require.NoError(t, r.replace(ctx, node, req, nil))
+	assert.Len(t, node.proposed, 3)
+}
+
+func TestValidateReplace(t *testing.T) {
+	st := newReplaceTestNode().Status()
+
+	tests := []struct {
+		name string
+		req  kvstore.MemberReplaceRequest
+		ok   bool
+	}{
+		{"ok", kvstore.MemberReplaceRequest{DeadID: 2, NewID: 4, PeerURL: "http://n4"}, true},
+		{"missing ids", kvstore.MemberReplaceRequest{NewID: 4, PeerURL: "http://n4"}, false},
+		{"same id", kvstore.MemberReplaceRequest{DeadID: 2, NewID: 2, PeerURL: "http://n4"}, false},
+		{"leader", kvstore.MemberReplaceRequest{DeadID: 1, NewID: 4, PeerURL: "http://n4"}, false},
+		{"unknown dead", kvstore.MemberReplaceRequest{DeadID: 9, NewID: 4, PeerURL: "http://n4"}, false},
+		{"active dead", kvstore.MemberReplaceRequest{DeadID: 3, NewID: 4, PeerURL: "http://n4"}, false},
+		{"active dead forced", kvstore.MemberReplaceRequest{DeadID: 3, NewID: 4, PeerURL: "http://n4", Force: true}, true},
+		{"missing peer url", kvstore.MemberReplaceRequest{DeadID: 2, NewID: 4}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReplace(st, tt.req)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestMemberReplaceRequiresLeader(t *testing.T) {
+	node := newReplaceTestNode()
+	var r memberReplacer
+
+	follower := &followerTestNode{replaceTestNode: node}
+	err := r.replace(context.Background(), follower, kvstore.MemberReplaceRequest{DeadID: 2, NewID: 4, PeerURL: "http://n4"}, nil)
+	assert.ErrorIs(t, err, ErrNotLeader)
+	assert.Empty(t, node.proposed)
+}
+
+type followerTestNode struct {
+	*replaceTestNode
+}
+
+func (n *followerTestNode) Status() raft.Status {
+	st := n.replaceTestNode.Status()
+	st.RaftState = raft.StateFollower
+	return st
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"sort"
+
+	"metaStore/internal/kvstore"
+
+	"go.etcd.io/raft/v3"
+)
+
+// memberStatus 根据 raft 状态列出当前配置中的成员（按 ID 排序）
+// 成员关系来自 raft 配置，每个节点上都可见；复制进度只有 leader 才有
+func memberStatus(st raft.Status, peers []string) []kvstore.MemberStatus {
+	learners := st.Config.Learners
+	ids := make([]uint64, 0, len(learners)+len(peers))
+	for id := range st.Config.Voters.IDs() {
+		ids = append(ids, id)
+	}
+	for id := range learners {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	members := make([]kvstore.MemberStatus, 0, len(ids))
+	for _, id := range ids {
+		m := kvstore.MemberStatus{ID: id, IsLeader: id == st.Lead}
+		_, m.IsLearner = learners[id]
+		// 静态 peers 列表的下标与节点 ID 对应（id = index + 1）
+		if id >= 1 && id <= uint64(len(peers)) {
+			m.PeerURL = peers[id-1]
+		}
+		if pr, ok := st.Progress[id]; ok {
+			m.Match = pr.Match
+			m.Progress = pr.State.String()
+			m.RecentActive = pr.RecentActive
+		}
+		members = append(members, m)
+	}
+	return members
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/lease"
+
+	"go.etcd.io/raft/v3/raftpb"
+	"go.uber.org/zap"
+)
+
+// ephemeralMaxBatch limits how many queued proposals are applied as one commit
+const ephemeralMaxBatch = 256
+
+// EphemeralNode is the node handle returned by NewEphemeralNode. It provides the
+// same surface the memory store expects from a raft node.
+type EphemeralNode interface {
+	TestableNode
+	TransferLeadership(targetID uint64) error
+	ReadIndex(ctx context.Context) error
+	MoveLeader(ctx context.Context) (uint64, error)
+	ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error
+	Members() []kvstore.MemberStatus
+}
+
+// ephemeralNode is a no-op consensus node for single-node, non-durable deployments.
+// Proposals are committed immediately in arrival order without WAL, snapshots or
+// peer transport, so all data is lost on restart. It is intended for local
+// development and CI where an etcd-compatible cache is enough.
+type ephemeralNode struct {
+	proposeC    <-chan string
+	confChangeC <-chan raftpb.ConfChange
+	commitC     chan<- *kvstore.Commit
+	errorC      chan<- error
+
+	id           int
+	appliedIndex atomic.Uint64
+	stopc        chan struct{}
+
+	logger *zap.Logger
+}
+
+// NewEphemeralNode starts a no-op consensus node that feeds every proposal straight
+// into the commit channel. It follows the same channel contract as NewNode so the
+// memory store can be used unchanged. To shutdown, close proposeC.
+func NewEphemeralNode(id int, proposeC <-chan string, confChangeC <-chan raftpb.ConfChange,
+) (<-chan *kvstore.Commit, <-chan error, EphemeralNode) {
+	commitC := make(chan *kvstore.Commit)
+	errorC := make(chan error)
+
+	en := &ephemeralNode{
+		proposeC:    proposeC,
+		confChangeC: confChangeC,
+		commitC:     commitC,
+		errorC:      errorC,
+		id:          id,
+		stopc:       make(chan struct{}),
+		logger:      newLogger(),
+	}
+
+	en.logger.Info("ephemeral node started, raft and WAL are bypassed",
+		zap.Int("id", id),
+		zap.String("component", "raft-ephemeral"))
+
+	go en.serveChannels()
+	return commitC, errorC, en
+}
+
+func (en *ephemeralNode) serveChannels() {
+	defer func() {
+		close(en.commitC)
+		close(en.errorC)
+		close(en.stopc)
+	}()
+
+	confChangeC := en.confChangeC
+	for {
+		select {
+		case prop, ok := <-en.proposeC:
+			if !ok {
+				return
+			}
+			en.commit(en.drain(prop))
+
+		case cc, ok := <-confChangeC:
+			if !ok {
+				confChangeC = nil
+				continue
+			}
+			// 单节点模式没有集群成员，忽略配置变更
+			en.logger.Warn("ignoring conf change in ephemeral mode",
+				zap.String("type", cc.Type.String()),
+				zap.Uint64("node_id", cc.NodeID),
+				zap.String("component", "raft-ephemeral"))
+		}
+	}
+}
+
+// drain collects proposals that are already queued so they are applied together
+func (en *ephemeralNode) drain(first string) []string {
+	_, first = kvstore.SplitProposal(first)
+	data := []string{first}
+	for len(data) < ephemeralMaxBatch {
+		select {
+		case prop, ok := <-en.proposeC:
+			if !ok {
+				return data
+			}
+			_, prop = kvstore.SplitProposal(prop)
+			data = append(data, prop)
+		default:
+			return data
+		}
+	}
+	return data
+}
+
+// commit publishes a batch and waits for the store to apply it, so the applied
+// index reported by Status never runs ahead of the state machine.
+func (en *ephemeralNode) commit(data []string) {
+	applyDoneC := make(chan struct{}, 1)
+	index := en.appliedIndex.Load() + uint64(len(data))
+	en.commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC, Index: index}
+	<-applyDoneC
+	en.appliedIndex.Add(uint64(len(data)))
+}
+
+// Status 返回单节点的固定 leader 状态
+func (en *ephemeralNode) Status() kvstore.RaftStatus {
+	applied := en.appliedIndex.Load()
+	return kvstore.RaftStatus{
+		NodeID:   uint64(en.id),
+		Term:     1,
+		LeaderID: uint64(en.id),
+		State:    "StateLeader",
+		Applied:  applied,
+		Commit:   applied,
+	}
+}
+
+// Members 单节点模式只有自身一个成员
+func (en *ephemeralNode) Members() []kvstore.MemberStatus {
+	return []kvstore.MemberStatus{{
+		ID:           uint64(en.id),
+		IsLeader:     true,
+		Match:        en.appliedIndex.Load(),
+		Progress:     "StateReplicate",
+		RecentActive: true,
+	}}
+}
+
+// TransferLeadership 单节点模式不支持 leader 转移
+func (en *ephemeralNode) TransferLeadership(targetID uint64) error {
+	return fmt.Errorf("leadership transfer is not supported in ephemeral mode")
+}
+
+// ReadIndex 单节点模式下提案同步应用，本地状态总是最新的
+func (en *ephemeralNode) ReadIndex(ctx context.Context) error {
+	if en.IsStopped() {
+		return ErrStopped
+	}
+	return nil
+}
+
+// MoveLeader 单节点模式没有可接任的节点
+func (en *ephemeralNode) MoveLeader(ctx context.Context) (uint64, error) {
+	return 0, ErrNoTransferee
+}
+
+// ReplaceMember 单节点模式没有成员可替换
+func (en *ephemeralNode) ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	return fmt.Errorf("member replacement is not supported in single-node mode")
+}
+
+// LeaseManager 返回 nil，单节点模式下读请求直接访问本地状态
+func (en *ephemeralNode) LeaseManager() *lease.LeaseManager {
+	return nil
+}
+
+// ReadIndexManager 返回 nil，单节点模式下读请求直接访问本地状态
+func (en *ephemeralNode) ReadIndexManager() *lease.ReadIndexManager {
+	return nil
+}
+
+// IsStopped 检查节点是否已停止（用于测试）
+func (en *ephemeralNode) IsStopped() bool {
+	select {
+	case <-en.stopc:
+		return true
+	default:
+		return false
+	}
+}
@@ -17,17 +17,17 @@ package raft
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	"metaStore/internal/batch"
 	"metaStore/internal/kvstore"
 	"metaStore/internal/lease"
+	"metaStore/pkg/chaos"
 	"metaStore/pkg/config"
 
 	"go.etcd.io/etcd/client/pkg/v3/fileutil"
@@ -70,6 +70,11 @@ type raftNode struct {
 	snapshotIndex uint64
 	appliedIndex  uint64
 
+	// lastApplyDoneC 最后一个交给状态机的 commit 的 ApplyDoneC。commitC 有缓冲，
+	// 快照前要等它关闭，否则 getSnapshot 可能缺少还在队列中的 commit
+	lastApplyDoneC  <-chan struct{}
+	lastCommitIndex uint64 // 最后一个交给状态机的 commit 的 index
+
 	// raft backing for the commit/error channel
 	node        raft.Node
 	raftStorage *raft.MemoryStorage
@@ -78,11 +83,12 @@ type raftNode struct {
 	snapshotter      *snap.Snapshotter
 	snapshotterReady chan *snap.Snapshotter // signals when snapshotter is ready
 
-	snapCount uint64
-	transport *rafthttp.Transport
-	stopc     chan struct{} // signals proposal channel closed
-	httpstopc chan struct{} // signals http server to shutdown
-	httpdonec chan struct{} // signals http server shutdown complete
+	snapCount   uint64
+	snapTrigger *snapshotTrigger // 按日志大小和时间触发快照
+	transport   *rafthttp.Transport
+	stopc       chan struct{} // signals proposal channel closed
+	httpstopc   chan struct{} // signals http server to shutdown
+	httpdonec   chan struct{} // signals http server shutdown complete
 
 	// 批量提案系统（可选）
 	batcher         *batch.ProposalBatcher // 批量提案器（如果启用）
@@ -91,7 +97,16 @@ type raftNode struct {
 	// Lease Read 系统（可选）
 	smartLeaseConfig *lease.SmartLeaseConfig // 智能配置管理器（支持动态扩缩容）
 	leaseManager     *lease.LeaseManager     // 租约管理器（如果启用）
-	readIndexManager *lease.ReadIndexManager // ReadIndex 管理器（如果启用）
+	readIndexManager *lease.ReadIndexManager // ReadIndex 管理器，与 Lease Read 无关，总是创建
+	appliedNotifier  *appliedNotifier        // 状态机应用完成后通知 ReadIndexManager
+	readIndexWaiters *readIndexWaiters       // 等待 ReadState 的线性一致读请求
+	leaderTracker    *kvstore.LeaderTracker  // leader 变化时通知等待 apply 的请求
+	peerHealth       *peerHealth             // 与各成员的通信情况，leader 上检测失联的成员
+
+	diskMonitor *diskLatencyMonitor // 磁盘延迟监控（超过阈值时转移 leader，可选）
+	replacer    memberReplacer      // 故障成员替换流程（仅 leader 执行）
+	codec       *messageCodec       // 节点间消息压缩与合并
+	snapStream  *snapshotStream     // 快照数据的流式收发
 
 	logger *zap.Logger
 	cfg    *config.Config // Raft configuration
@@ -110,17 +125,26 @@ func (rc *raftNode) isWitness() bool {
 // provided the proposal channel. All log entries are replayed over the
 // commit channel, followed by a nil message (to indicate the channel is
 // current), then new log entries. To shutdown, close proposeC and read errorC.
-// storageType: "memory" or "rocksdb" to separate data directories
+// storageType: "memory" or "rocksdb" to separate data directories under data/;
+// an absolute path is used as the data root directly (e.g. a test's t.TempDir())
 func NewNode(id int, peers []string, join bool, getSnapshot func() ([]byte, error), proposeC <-chan string,
 	confChangeC <-chan raftpb.ConfChange, storageType string, cfg *config.Config,
 ) (<-chan *kvstore.Commit, <-chan error, <-chan *snap.Snapshotter, *raftNode) {
-	commitC := make(chan *kvstore.Commit)
+	// 有界的 apply 队列：状态机落后时最多缓冲 apply_queue_size 个 commit，满了之后 raft 停止交付
+	commitC := make(chan *kvstore.Commit, cfg.Server.Limits.ApplyQueueSize)
 	errorC := make(chan error)
 
 	// Default to "memory" if not specified
 	if storageType == "" {
 		storageType = "memory"
 	}
+	dataDir := filepath.Join("data", storageType, strconv.Itoa(id))
+	if filepath.IsAbs(storageType) {
+		dataDir = filepath.Join(storageType, strconv.Itoa(id))
+	}
+
+	// WAL 和快照目录可以单独配置，默认在数据目录下
+	_, walDir, snapDir := cfg.Server.Storage.Dirs(storageType, dataDir)
 
 	rc := &raftNode{
 		proposeC:    proposeC,
@@ -130,14 +154,21 @@ func NewNode(id int, peers []string, join bool, getSnapshot func() ([]byte, erro
 		id:          id,
 		peers:       peers,
 		join:        join,
-		waldir:      fmt.Sprintf("data/%s/%d/wal", storageType, id),
-		snapdir:     fmt.Sprintf("data/%s/%d/snap", storageType, id),
+		waldir:      walDir,
+		snapdir:     snapDir,
 		getSnapshot: getSnapshot,
 		snapCount:   defaultSnapshotCount,
+		snapTrigger: newSnapshotTrigger(cfg.Server.Raft.SnapshotMaxLogBytes, cfg.Server.Raft.SnapshotInterval),
 		stopc:       make(chan struct{}),
 		httpstopc:   make(chan struct{}),
 		httpdonec:   make(chan struct{}),
 
+		readIndexWaiters: newReadIndexWaiters(),
+		leaderTracker:    kvstore.NewLeaderTracker(),
+		peerHealth:       newPeerHealth(cfg.Server.Raft.PeerDeadTimeout),
+		diskMonitor: newDiskLatencyMonitor(cfg.Server.Raft.LeaderTransfer.DiskLatencyThreshold,
+			cfg.Server.Raft.LeaderTransfer.DiskLatencyWindow, cfg.Server.Raft.LeaderTransfer.Cooldown),
+
 		logger: newLogger(),
 		cfg:    cfg, // Store config reference
 
@@ -215,27 +246,21 @@ func (rc *raftNode) publishEntries(ents []raftpb.Entry) (<-chan struct{}, bool)
 			}
 
 			// 如果启用了批量提案，需要解码批量提案
-			if rc.cfg.Server.Raft.Batch.Enable {
-				proposals, err := batch.DecodeBatch(ents[i].Data)
-				if err != nil {
-					rc.logger.Error("failed to decode batch proposal",
-						zap.Error(err),
-						zap.Uint64("index", ents[i].Index),
-						zap.String("component", "raft-memory"))
-					continue
-				}
-				data = append(data, proposals...)
-			} else {
-				// 不启用批量提案，直接使用字符串
-				s := string(ents[i].Data)
-				data = append(data, s)
+			proposals, err := EntryProposals(ents[i], rc.cfg.Server.Raft.Batch.Enable)
+			if err != nil {
+				rc.logger.Error("failed to decode batch proposal",
+					zap.Error(err),
+					zap.Uint64("index", ents[i].Index),
+					zap.String("component", "raft-memory"))
+				continue
 			}
+			data = append(data, proposals...)
 		case raftpb.EntryConfChange:
 			var cc raftpb.ConfChange
 			cc.Unmarshal(ents[i].Data)
 			rc.confState = *rc.node.ApplyConfChange(cc)
 			switch cc.Type {
-			case raftpb.ConfChangeAddNode:
+			case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
 				if len(cc.Context) > 0 {
 					rc.transport.AddPeer(types.ID(cc.NodeID), []string{string(cc.Context)})
 				}
@@ -252,20 +277,25 @@ func (rc *raftNode) publishEntries(ents []raftpb.Entry) (<-chan struct{}, bool)
 	var applyDoneC chan struct{}
 
 	if len(data) > 0 {
+		// commit 的 index 是这批条目的最后一个，其后没有数据的条目（空条目、配置变更）
+		// 也算作已应用，状态机应用完这个 commit 后 applied index 与 rc.appliedIndex 相同
+		index := ents[len(ents)-1].Index
 		applyDoneC = make(chan struct{}, 1)
 		select {
-		case rc.commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC}:
+		case rc.commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC, Index: index}:
 		case <-rc.stopc:
 			return nil, false
 		}
+		rc.lastApplyDoneC = applyDoneC
+		rc.lastCommitIndex = index
 	}
 
 	// after commit, update appliedIndex
 	rc.appliedIndex = ents[len(ents)-1].Index
 
-	// Lease Read: 通知 ReadIndexManager 应用进度
-	if rc.cfg.Server.Raft.LeaseRead.Enable && rc.readIndexManager != nil {
-		rc.readIndexManager.NotifyApplied(rc.appliedIndex)
+	// 状态机应用完成后通知 ReadIndexManager 应用进度，ReadIndex 读取依赖它
+	if rc.appliedNotifier != nil {
+		rc.appliedNotifier.notify(rc.appliedIndex, applyDoneC)
 	}
 
 	return applyDoneC, true
@@ -289,7 +319,7 @@ func (rc *raftNode) publishEntriesAsWitness(ents []raftpb.Entry) (<-chan struct{
 			rc.confState = *rc.node.ApplyConfChange(cc)
 
 			switch cc.Type {
-			case raftpb.ConfChangeAddNode:
+			case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
 				if len(cc.Context) > 0 {
 					rc.transport.AddPeer(types.ID(cc.NodeID), []string{string(cc.Context)})
 				}
@@ -368,6 +398,27 @@ func (rc *raftNode) replayWAL() *wal.WAL {
 	if err != nil {
 		log.Fatalf("store: failed to read WAL (%v)", err)
 	}
+	if rc.cfg.Server.Raft.ForceNewCluster {
+		var cs raftpb.ConfState
+		if snapshot != nil {
+			cs = snapshot.Metadata.ConfState
+		}
+		appended, newSt := forceNewCluster(uint64(rc.id), cs, ents, st)
+		if err := w.Save(newSt, appended); err != nil {
+			log.Fatalf("store: failed to force a new cluster (%v)", err)
+		}
+		rc.logger.Warn("forcing a new cluster with this member as the only voter",
+			zap.Uint64("commit", st.Commit),
+			zap.Int("conf_changes", len(appended)),
+			zap.String("component", "raft-memory"))
+		for i, ent := range ents {
+			if ent.Index > st.Commit {
+				ents = ents[:i]
+				break
+			}
+		}
+		ents, st = append(ents, appended...), newSt
+	}
 	rc.raftStorage = raft.NewMemoryStorage()
 	if snapshot != nil {
 		rc.raftStorage.ApplySnapshot(*snapshot)
@@ -433,6 +484,13 @@ func (rc *raftNode) startRaft() {
 		rc.node = raft.StartNode(c, rpeers)
 	}
 
+	// 节点间消息压缩与合并（须在 transport 启动前创建，Process 会用到）
+	codec, err := newMessageCodec(rc.cfg.Server.Raft.Transport)
+	if err != nil {
+		log.Fatalf("store: failed to create raft message codec (%v)", err)
+	}
+	rc.codec = codec
+
 	rc.transport = &rafthttp.Transport{
 		Logger:      rc.logger,
 		ID:          types.ID(rc.id),
@@ -441,7 +499,9 @@ func (rc *raftNode) startRaft() {
 		ServerStats: stats.NewServerStats("", ""),
 		LeaderStats: stats.NewLeaderStats(newLogger(), strconv.Itoa(rc.id)),
 		ErrorC:      make(chan error),
+		Snapshotter: rc.snapshotter,
 	}
+	rc.snapStream = newSnapshotStream(rc.transport, rc.snapshotter, rc.snapdir, rc.cfg.Server.Raft.Transport.StreamSnapshots())
 
 	rc.transport.Start()
 	for i := range rc.peers {
@@ -453,13 +513,7 @@ func (rc *raftNode) startRaft() {
 	// 初始化批量提案系统（如果启用）
 	// Witness nodes don't propose data, so batch system is not needed
 	if rc.cfg.Server.Raft.Batch.Enable && !rc.isWitness() {
-		batchConfig := batch.BatchConfig{
-			MinBatchSize:  rc.cfg.Server.Raft.Batch.MinBatchSize,
-			MaxBatchSize:  rc.cfg.Server.Raft.Batch.MaxBatchSize,
-			MinTimeout:    rc.cfg.Server.Raft.Batch.MinTimeout,
-			MaxTimeout:    rc.cfg.Server.Raft.Batch.MaxTimeout,
-			LoadThreshold: rc.cfg.Server.Raft.Batch.LoadThreshold,
-		}
+		batchConfig := newBatchConfig(rc.cfg.Server.Raft.Batch)
 		// batcher 拥有并管理输出通道，通过 ProposeC() 获取
 		rc.batcher = batch.NewProposalBatcher(batchConfig, rc.proposeC, rc.logger)
 		rc.batcher.Start(context.Background())
@@ -470,6 +524,7 @@ func (rc *raftNode) startRaft() {
 			zap.Duration("min_timeout", batchConfig.MinTimeout),
 			zap.Duration("max_timeout", batchConfig.MaxTimeout),
 			zap.Float64("load_threshold", batchConfig.LoadThreshold),
+			zap.Int("frontend_queues", len(batchConfig.Origins)),
 			zap.String("component", "raft-memory"))
 	} else if rc.isWitness() {
 		rc.logger.Info("batch proposal system skipped (witness node)",
@@ -527,8 +582,11 @@ func (rc *raftNode) startRaft() {
 			zap.Bool("currently_enabled", rc.smartLeaseConfig.IsEnabled()),
 			zap.String("component", "raft-memory"))
 	} else {
-		rc.logger.Info("lease read system disabled", zap.String("component", "raft-memory"))
+		// 没有租约时所有线性一致读都走 ReadIndex
+		rc.readIndexManager = lease.NewReadIndexManager(nil, rc.logger)
+		rc.logger.Info("lease read system disabled, reads use ReadIndex", zap.String("component", "raft-memory"))
 	}
+	rc.appliedNotifier = &appliedNotifier{rim: rc.readIndexManager, stopc: rc.stopc}
 
 	// Log witness node startup
 	if rc.isWitness() {
@@ -579,20 +637,30 @@ func (rc *raftNode) publishSnapshot(snapshotToSave raftpb.Snapshot) {
 
 	rc.confState = snapshotToSave.Metadata.ConfState
 	rc.snapshotIndex = snapshotToSave.Metadata.Index
+	rc.snapTrigger.reset(time.Now())
 	rc.appliedIndex = snapshotToSave.Metadata.Index
 }
 
 var snapshotCatchUpEntriesN uint64 = 10000
 
-func (rc *raftNode) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
-	if rc.appliedIndex-rc.snapshotIndex <= rc.snapCount {
+func (rc *raftNode) maybeTriggerSnapshot() {
+	reason, ok := rc.snapTrigger.due(rc.appliedIndex, rc.snapshotIndex, rc.snapCount, time.Now())
+	if !ok {
 		return
 	}
 
-	// wait until all committed entries are applied (or server is closed)
-	if applyDoneC != nil {
+	// 状态机只在有数据的 commit 上推进 applied index。最近的条目都没有数据时，
+	// 状态机的 applied index 小于 rc.appliedIndex，快照推迟到下一个有数据的 commit，
+	// 保证快照数据与快照元数据的 index 相同
+	if rc.lastCommitIndex != rc.appliedIndex {
+		return
+	}
+
+	// wait until all committed entries are applied (or server is closed),
+	// including commits of earlier Ready batches still queued in commitC
+	if rc.lastApplyDoneC != nil {
 		select {
-		case <-applyDoneC:
+		case <-rc.lastApplyDoneC:
 		case <-rc.stopc:
 			return
 		}
@@ -601,6 +669,8 @@ func (rc *raftNode) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
 	rc.logger.Info("start snapshot",
 		zap.Uint64("applied_index", rc.appliedIndex),
 		zap.Uint64("last_snapshot_index", rc.snapshotIndex),
+		zap.String("trigger", reason),
+		zap.Uint64("log_bytes", rc.snapTrigger.bytes),
 		zap.String("component", "raft-memory"))
 	data, err := rc.getSnapshot()
 	if err != nil {
@@ -614,10 +684,7 @@ func (rc *raftNode) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
 		panic(err)
 	}
 
-	compactIndex := uint64(1)
-	if rc.appliedIndex > snapshotCatchUpEntriesN {
-		compactIndex = rc.appliedIndex - snapshotCatchUpEntriesN
-	}
+	compactIndex := rc.snapTrigger.compactIndex(rc.appliedIndex, rc.snapshotIndex)
 	if err := rc.raftStorage.Compact(compactIndex); err != nil {
 		if !errors.Is(err, raft.ErrCompacted) {
 			panic(err)
@@ -627,6 +694,7 @@ func (rc *raftNode) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
 	}
 
 	rc.snapshotIndex = rc.appliedIndex
+	rc.snapTrigger.reset(time.Now())
 }
 
 func (rc *raftNode) serveChannels() {
@@ -679,6 +747,7 @@ func (rc *raftNode) serveChannels() {
 						rc.proposeC = nil
 					} else {
 						// blocks until accepted by raft state machine
+						_, prop = kvstore.SplitProposal(prop)
 						rc.node.Propose(context.TODO(), []byte(prop))
 					}
 
@@ -708,6 +777,7 @@ func (rc *raftNode) serveChannels() {
 		select {
 		case <-ticker.C:
 			rc.node.Tick()
+			rc.checkPeers()
 
 		// 单节点租约续期定时器触发
 		case <-leaseRenewTicker.C:
@@ -724,6 +794,11 @@ func (rc *raftNode) serveChannels() {
 
 		// store raft entries to wal, then publish over commit channel
 		case rd := <-rc.node.Ready():
+			// leader 变化时让等待 apply 的请求立即失败，而不是等到超时
+			if rd.SoftState != nil {
+				rc.leaderTracker.Observe(rd.SoftState.Lead)
+			}
+
 			// Lease Read: 处理角色变更
 			if rc.cfg.Server.Raft.LeaseRead.Enable && rc.leaseManager != nil {
 				if rd.SoftState != nil {
@@ -741,25 +816,38 @@ func (rc *raftNode) serveChannels() {
 			if !raft.IsEmptySnap(rd.Snapshot) {
 				rc.saveSnap(rd.Snapshot)
 			}
+			// Fault injection: a failed WAL fsync is unrecoverable, stop the node
+			if err := chaos.InjectError(chaos.WALSync); err != nil {
+				rc.writeError(err)
+				return
+			}
+			saveStart := time.Now()
 			rc.wal.Save(rd.HardState, rd.Entries)
+			if latency := time.Since(saveStart); len(rd.Entries) > 0 && rc.diskMonitor.observe(latency, time.Now()) {
+				go rc.moveLeaderOnSlowDisk(latency)
+			}
 			if !raft.IsEmptySnap(rd.Snapshot) {
 				rc.raftStorage.ApplySnapshot(rd.Snapshot)
 				rc.publishSnapshot(rd.Snapshot)
 			}
 			rc.raftStorage.Append(rd.Entries)
-			rc.transport.Send(rc.processMessages(rd.Messages))
+			rc.snapTrigger.append(rd.Entries)
+			rc.peerHealth.sent(rd.Messages, time.Now())
+			rc.transport.Send(chaos.FilterMessages(rc.snapStream.send(rc.codec.encode(rc.processMessages(rd.Messages))), rc.transport.Send))
+
+			// ReadIndex: 分发 leader 确认的 read index
+			rc.readIndexWaiters.notify(rd.ReadStates)
 
 			// Lease Read: 处理心跳响应以续约租约(多节点场景)
 			if rc.cfg.Server.Raft.LeaseRead.Enable && rc.leaseManager != nil && rc.leaseManager.IsLeader() {
 				rc.tryRenewLease()
 			}
 
-			applyDoneC, ok := rc.publishEntries(rc.entriesToApply(rd.CommittedEntries))
-			if !ok {
+			if _, ok := rc.publishEntries(rc.entriesToApply(rd.CommittedEntries)); !ok {
 				rc.stop()
 				return
 			}
-			rc.maybeTriggerSnapshot(applyDoneC)
+			rc.maybeTriggerSnapshot()
 			rc.node.Advance()
 
 		case err := <-rc.transport.ErrorC:
@@ -793,17 +881,16 @@ func (rc *raftNode) serveRaft() {
 		return
 	}
 
-	url, err := url.Parse(rc.peers[peerIndex])
-	if err != nil {
-		log.Fatalf("store: Failed parsing URL (%v)", err)
-	}
-
-	ln, err := NewStoppableListener(url.Host, rc.httpstopc)
+	lns, err := listenPeers(rc.cfg, rc.peers[peerIndex], rc.httpstopc)
 	if err != nil {
 		log.Fatalf("store: Failed to listen rafthttp (%v)", err)
 	}
 
-	err = (&http.Server{Handler: rc.transport.Handler()}).Serve(ln)
+	srv := &http.Server{Handler: rc.snapStream.handler(rc.transport.Handler())}
+	for _, ln := range lns[1:] {
+		go srv.Serve(ln)
+	}
+	err = srv.Serve(lns[0])
 	select {
 	case <-rc.httpstopc:
 	default:
@@ -813,10 +900,31 @@ func (rc *raftNode) serveRaft() {
 }
 
 func (rc *raftNode) Process(ctx context.Context, m raftpb.Message) error {
+	if !chaos.AllowReceive() {
+		return nil
+	}
+	if rc.peerHealth.received(m, time.Now()) {
+		rc.logger.Info("Peer is reachable again",
+			zap.Uint64("peer", m.From),
+			zap.String("component", "raft-memory"))
+	}
+	m, err := rc.snapStream.receive(m)
+	if err != nil {
+		return err
+	}
+	if m, err = rc.codec.decode(m); err != nil {
+		return err
+	}
+	if err := verifySnapshot(m); err != nil {
+		return err
+	}
 	return rc.node.Step(ctx, m)
 }
-func (rc *raftNode) IsIDRemoved(_ uint64) bool   { return false }
-func (rc *raftNode) ReportUnreachable(id uint64) { rc.node.ReportUnreachable(id) }
+func (rc *raftNode) IsIDRemoved(_ uint64) bool { return false }
+func (rc *raftNode) ReportUnreachable(id uint64) {
+	rc.peerHealth.sendFailed(id)
+	rc.node.ReportUnreachable(id)
+}
 func (rc *raftNode) ReportSnapshot(id uint64, status raft.SnapshotStatus) {
 	rc.node.ReportSnapshot(id, status)
 }
@@ -834,17 +942,89 @@ func (rc *raftNode) Status() kvstore.RaftStatus {
 	}
 }
 
+// Members 返回当前集群成员及其复制进度和通信情况
+func (rc *raftNode) Members() []kvstore.MemberStatus {
+	members := memberStatus(rc.node.Status(), rc.peers)
+	rc.peerHealth.fill(members)
+	return members
+}
+
+// checkPeers 在 leader 上检查成员是否失联，新失联的成员记录告警日志
+func (rc *raftNode) checkPeers() {
+	for _, id := range rc.peerHealth.check(rc.node.Status(), time.Now()) {
+		rc.logger.Warn("Peer is unreachable",
+			zap.Uint64("peer", id),
+			zap.Duration("timeout", rc.peerHealth.deadTimeout),
+			zap.String("component", "raft-memory"))
+	}
+}
+
 // TransferLeadership 将 leader 角色转移到指定节点
 func (rc *raftNode) TransferLeadership(targetID uint64) error {
 	rc.node.TransferLeadership(context.TODO(), 0, targetID)
 	return nil
 }
 
+// PauseTransport 暂停与所有 peer 的 raft 消息收发（用于测试中模拟网络分区）
+func (rc *raftNode) PauseTransport() {
+	rc.transport.Pause()
+}
+
+// ResumeTransport 恢复与所有 peer 的 raft 消息收发
+func (rc *raftNode) ResumeTransport() {
+	rc.transport.Resume()
+}
+
+// MoveLeader 将 leader 转移给日志最新的活跃 follower，并等待新 leader 产生
+func (rc *raftNode) MoveLeader(ctx context.Context) (uint64, error) {
+	return moveLeader(ctx, rc.node)
+}
+
+// ReplaceMember 用新的 learner 替换故障成员：添加 learner，等待追上后提升，再移除故障成员
+// 只能在 leader 上执行，report 在每次进度变化时被调用
+func (rc *raftNode) ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	return rc.replacer.replace(ctx, rc.node, req, report)
+}
+
+// moveLeaderOnSlowDisk 本地磁盘变慢时将 leader 转移出去，避免拖慢整个集群的写入
+func (rc *raftNode) moveLeaderOnSlowDisk(latency time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.cfg.Server.Raft.LeaderTransfer.Timeout)
+	defer cancel()
+
+	newLeader, err := moveLeader(ctx, rc.node)
+	switch {
+	case errors.Is(err, ErrNotLeader):
+		// follower 的磁盘慢不影响 leader 选择
+	case err != nil:
+		rc.logger.Warn("failed to transfer leadership away from slow disk",
+			zap.Error(err),
+			zap.Duration("latency", latency),
+			zap.String("component", "raft-memory"))
+	default:
+		rc.logger.Warn("transferred leadership away from slow disk",
+			zap.Uint64("new_leader", newLeader),
+			zap.Duration("latency", latency),
+			zap.String("component", "raft-memory"))
+	}
+}
+
+// ReadIndex 通过 ReadIndex 协议等待本地状态机追上 leader 的 commit index
+// 返回 nil 后本地读取满足线性一致性，超时由 lease_read.read_timeout 控制
+func (rc *raftNode) ReadIndex(ctx context.Context) error {
+	return linearizableRead(ctx, rc.node, rc.readIndexWaiters, rc.readIndexManager,
+		rc.cfg.Server.Raft.LeaseRead.ReadTimeout, rc.stopc)
+}
+
 // LeaseManager 返回租约管理器（用于测试）
 func (rc *raftNode) LeaseManager() *lease.LeaseManager {
 	return rc.leaseManager
 }
 
+// LeaderChanged 返回在下一次 leader 变化时关闭的 channel
+func (rc *raftNode) LeaderChanged() <-chan struct{} {
+	return rc.leaderTracker.Changed()
+}
+
 // ReadIndexManager 返回读索引管理器（用于测试）
 func (rc *raftNode) ReadIndexManager() *lease.ReadIndexManager {
 	return rc.readIndexManager
@@ -19,8 +19,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
-	"net/url"
 	"os"
 	"strconv"
 	"time"
@@ -29,6 +29,7 @@ import (
 	"metaStore/internal/kvstore"
 	"metaStore/internal/lease"
 	"metaStore/internal/rocksdb"
+	"metaStore/pkg/chaos"
 	"metaStore/pkg/config"
 
 	"github.com/linxGnu/grocksdb"
@@ -62,6 +63,11 @@ type raftNodeRocks struct {
 	snapshotIndex uint64
 	appliedIndex  uint64
 
+	// lastApplyDoneC 最后一个交给状态机的 commit 的 ApplyDoneC。commitC 有缓冲，
+	// 快照前要等它关闭，否则 getSnapshot 可能缺少还在队列中的 commit
+	lastApplyDoneC  <-chan struct{}
+	lastCommitIndex uint64 // 最后一个交给状态机的 commit 的 index
+
 	// raft backing for the commit/error channel
 	node        raft.Node
 	raftStorage *rocksdb.RocksDBStorage
@@ -70,11 +76,12 @@ type raftNodeRocks struct {
 	snapshotter      *snap.Snapshotter
 	snapshotterReady chan *snap.Snapshotter // signals when snapshotter is ready
 
-	snapCount uint64
-	transport *rafthttp.Transport
-	stopc     chan struct{} // signals proposal channel closed
-	httpstopc chan struct{} // signals http server to shutdown
-	httpdonec chan struct{} // signals http server shutdown complete
+	snapCount   uint64
+	snapTrigger *snapshotTrigger // 按日志大小和时间触发快照
+	transport   *rafthttp.Transport
+	stopc       chan struct{} // signals proposal channel closed
+	httpstopc   chan struct{} // signals http server to shutdown
+	httpdonec   chan struct{} // signals http server shutdown complete
 
 	// 批量提案系统（可选）
 	batcher         *batch.ProposalBatcher // 批量提案器（如果启用）
@@ -83,7 +90,16 @@ type raftNodeRocks struct {
 	// Lease Read 系统（可选）
 	smartLeaseConfig *lease.SmartLeaseConfig // 智能配置管理器（支持动态扩缩容）
 	leaseManager     *lease.LeaseManager     // 租约管理器（如果启用）
-	readIndexManager *lease.ReadIndexManager // ReadIndex 管理器（如果启用）
+	readIndexManager *lease.ReadIndexManager // ReadIndex 管理器，与 Lease Read 无关，总是创建
+	appliedNotifier  *appliedNotifier        // 状态机应用完成后通知 ReadIndexManager
+	readIndexWaiters *readIndexWaiters       // 等待 ReadState 的线性一致读请求
+	leaderTracker    *kvstore.LeaderTracker  // leader 变化时通知等待 apply 的请求
+	peerHealth       *peerHealth             // 与各成员的通信情况，leader 上检测失联的成员
+
+	diskMonitor *diskLatencyMonitor // 磁盘延迟监控（超过阈值时转移 leader，可选）
+	replacer    memberReplacer      // 故障成员替换流程（仅 leader 执行）
+	codec       *messageCodec       // 节点间消息压缩与合并
+	snapStream  *snapshotStream     // 快照数据的流式收发
 
 	logger *zap.Logger
 	cfg    *config.Config // Raft configuration
@@ -93,9 +109,13 @@ type raftNodeRocks struct {
 func NewNodeRocksDB(id int, peers []string, join bool, getSnapshot func() ([]byte, error),
 	proposeC <-chan string, confChangeC <-chan raftpb.ConfChange, rocksDB *grocksdb.DB, dataDir string, cfg *config.Config,
 ) (<-chan *kvstore.Commit, <-chan error, <-chan *snap.Snapshotter, *raftNodeRocks) {
-	commitC := make(chan *kvstore.Commit)
+	// 有界的 apply 队列：状态机落后时最多缓冲 apply_queue_size 个 commit，满了之后 raft 停止交付
+	commitC := make(chan *kvstore.Commit, cfg.Server.Limits.ApplyQueueSize)
 	errorC := make(chan error)
 
+	// 快照目录可以单独配置，RocksDB 的 WAL 目录在打开数据库时指定
+	_, _, snapDir := cfg.Server.Storage.Dirs("rocksdb", dataDir)
+
 	rc := &raftNodeRocks{
 		proposeC:    proposeC,
 		confChangeC: confChangeC,
@@ -105,13 +125,20 @@ func NewNodeRocksDB(id int, peers []string, join bool, getSnapshot func() ([]byt
 		peers:       peers,
 		join:        join,
 		dbdir:       dataDir,
-		snapdir:     fmt.Sprintf("%s/snap", dataDir),
+		snapdir:     snapDir,
 		getSnapshot: getSnapshot,
 		snapCount:   defaultSnapshotCount,
+		snapTrigger: newSnapshotTrigger(cfg.Server.Raft.SnapshotMaxLogBytes, cfg.Server.Raft.SnapshotInterval),
 		stopc:       make(chan struct{}),
 		httpstopc:   make(chan struct{}),
 		httpdonec:   make(chan struct{}),
-		rocksDB:     rocksDB,
+
+		readIndexWaiters: newReadIndexWaiters(),
+		leaderTracker:    kvstore.NewLeaderTracker(),
+		peerHealth:       newPeerHealth(cfg.Server.Raft.PeerDeadTimeout),
+		diskMonitor: newDiskLatencyMonitor(cfg.Server.Raft.LeaderTransfer.DiskLatencyThreshold,
+			cfg.Server.Raft.LeaderTransfer.DiskLatencyWindow, cfg.Server.Raft.LeaderTransfer.Cooldown),
+		rocksDB: rocksDB,
 
 		logger: newLogger(),
 		cfg:    cfg, // Store config reference
@@ -172,27 +199,21 @@ func (rc *raftNodeRocks) publishEntries(ents []raftpb.Entry) (<-chan struct{}, b
 			}
 
 			// 如果启用了批量提案，需要解码批量提案
-			if rc.cfg.Server.Raft.Batch.Enable {
-				proposals, err := batch.DecodeBatch(ents[i].Data)
-				if err != nil {
-					rc.logger.Error("failed to decode batch proposal",
-						zap.Error(err),
-						zap.Uint64("index", ents[i].Index),
-						zap.String("component", "raft-rocks"))
-					continue
-				}
-				data = append(data, proposals...)
-			} else {
-				// 不启用批量提案，直接使用字符串
-				s := string(ents[i].Data)
-				data = append(data, s)
+			proposals, err := EntryProposals(ents[i], rc.cfg.Server.Raft.Batch.Enable)
+			if err != nil {
+				rc.logger.Error("failed to decode batch proposal",
+					zap.Error(err),
+					zap.Uint64("index", ents[i].Index),
+					zap.String("component", "raft-rocks"))
+				continue
 			}
+			data = append(data, proposals...)
 		case raftpb.EntryConfChange:
 			var cc raftpb.ConfChange
 			cc.Unmarshal(ents[i].Data)
 			rc.confState = *rc.node.ApplyConfChange(cc)
 			switch cc.Type {
-			case raftpb.ConfChangeAddNode:
+			case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
 				if len(cc.Context) > 0 {
 					rc.transport.AddPeer(types.ID(cc.NodeID), []string{string(cc.Context)})
 				}
@@ -209,20 +230,25 @@ func (rc *raftNodeRocks) publishEntries(ents []raftpb.Entry) (<-chan struct{}, b
 	var applyDoneC chan struct{}
 
 	if len(data) > 0 {
+		// commit 的 index 是这批条目的最后一个，其后没有数据的条目（空条目、配置变更）
+		// 也算作已应用，状态机应用完这个 commit 后 applied index 与 rc.appliedIndex 相同
+		index := ents[len(ents)-1].Index
 		applyDoneC = make(chan struct{}, 1)
 		select {
-		case rc.commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC}:
+		case rc.commitC <- &kvstore.Commit{Data: data, ApplyDoneC: applyDoneC, Index: index}:
 		case <-rc.stopc:
 			return nil, false
 		}
+		rc.lastApplyDoneC = applyDoneC
+		rc.lastCommitIndex = index
 	}
 
 	// after commit, update appliedIndex
 	rc.appliedIndex = ents[len(ents)-1].Index
 
-	// Lease Read: 通知 ReadIndexManager 应用进度
-	if rc.cfg.Server.Raft.LeaseRead.Enable && rc.readIndexManager != nil {
-		rc.readIndexManager.NotifyApplied(rc.appliedIndex)
+	// 状态机应用完成后通知 ReadIndexManager 应用进度，ReadIndex 读取依赖它
+	if rc.appliedNotifier != nil {
+		rc.appliedNotifier.notify(rc.appliedIndex, applyDoneC)
 	}
 
 	return applyDoneC, true
@@ -246,7 +272,7 @@ func (rc *raftNodeRocks) publishEntriesAsWitness(ents []raftpb.Entry) (<-chan st
 			rc.confState = *rc.node.ApplyConfChange(cc)
 
 			switch cc.Type {
-			case raftpb.ConfChangeAddNode:
+			case raftpb.ConfChangeAddNode, raftpb.ConfChangeAddLearnerNode:
 				if len(cc.Context) > 0 {
 					rc.transport.AddPeer(types.ID(cc.NodeID), []string{string(cc.Context)})
 				}
@@ -294,18 +320,114 @@ func (rc *raftNodeRocks) initRocksDBStorage() error {
 	}
 	rc.raftStorage = rocksdbStorage
 
-	// Load snapshot and apply to RocksDB storage
+	// Validate the raft log before raft sees it, raft panics on an inconsistent log
 	snapshot := rc.loadSnapshot()
-	if snapshot != nil && !raft.IsEmptySnap(*snapshot) {
-		rc.logger.Info("applying snapshot to RocksDB storage",
-			zap.Uint64("term", snapshot.Metadata.Term),
-			zap.Uint64("index", snapshot.Metadata.Index),
-			zap.String("component", "raft-rocks"))
-		if err := rc.raftStorage.ApplySnapshot(*snapshot); err != nil {
-			return fmt.Errorf("failed to apply snapshot: %v", err)
+	if err := rc.checkRocksDBStorage(snapshot); err != nil {
+		return err
+	}
+
+	// Apply snapshot to RocksDB storage
+	if raft.IsEmptySnap(*snapshot) {
+		return nil
+	}
+	firstIndex, err := rc.raftStorage.FirstIndex()
+	if err != nil {
+		return err
+	}
+	if snapshot.Metadata.Index < firstIndex {
+		// Already applied before the restart (e.g. a snapshot received from the leader)
+		return nil
+	}
+	rc.logger.Info("applying snapshot to RocksDB storage",
+		zap.Uint64("term", snapshot.Metadata.Term),
+		zap.Uint64("index", snapshot.Metadata.Index),
+		zap.String("component", "raft-rocks"))
+	if err := rc.raftStorage.ApplySnapshot(*snapshot); err != nil {
+		return fmt.Errorf("failed to apply snapshot: %v", err)
+	}
+
+	return nil
+}
+
+// forceNewCluster 改写 RocksDB 中的 raft 日志，使本节点成为唯一的投票成员
+func (rc *raftNodeRocks) forceNewCluster() error {
+	hardState, confState, err := rc.raftStorage.InitialState()
+	if err != nil {
+		return err
+	}
+	first, err := rc.raftStorage.FirstIndex()
+	if err != nil {
+		return err
+	}
+	last, err := rc.raftStorage.LastIndex()
+	if err != nil {
+		return err
+	}
+	var ents []raftpb.Entry
+	if last >= first {
+		if ents, err = rc.raftStorage.Entries(first, last+1, math.MaxUint64); err != nil {
+			return err
+		}
+	}
+
+	appended, st := forceNewCluster(uint64(rc.id), confState, ents, hardState)
+	if err := rc.raftStorage.Append(appended); err != nil {
+		return err
+	}
+	if err := rc.raftStorage.SetHardState(st); err != nil {
+		return err
+	}
+	rc.logger.Warn("forcing a new cluster with this member as the only voter",
+		zap.Uint64("commit", hardState.Commit),
+		zap.Int("conf_changes", len(appended)),
+		zap.String("component", "raft-rocks"))
+	return nil
+}
+
+// checkRocksDBStorage runs the startup consistency check of the raft log and
+// makes sure the snapshot file is not older than the snapshot in the log
+func (rc *raftNodeRocks) checkRocksDBStorage(fileSnap *raftpb.Snapshot) error {
+	repair := rc.cfg.Server.Raft.StartupCheck != config.StartupCheckStrict
+	report, err := rc.raftStorage.Check(repair)
+	if err != nil {
+		var checkErr *rocksdb.CheckError
+		if errors.As(err, &checkErr) {
+			for _, problem := range checkErr.Problems {
+				rc.logger.Error("raft storage check failed",
+					zap.String("problem", problem),
+					zap.String("component", "raft-rocks"))
+			}
+			return fmt.Errorf("%w; remove %s and rejoin the cluster to rebuild this node from its peers", err, rc.dbdir)
 		}
+		return fmt.Errorf("failed to check RocksDB storage: %v", err)
+	}
+	for _, repaired := range report.Repairs {
+		rc.logger.Warn("repaired raft storage",
+			zap.String("repair", repaired),
+			zap.String("component", "raft-rocks"))
 	}
 
+	// Snapshots are stored in the raft log before they are written to the
+	// snapshot directory. After a crash in between, the state machine would
+	// recover from an older snapshot while the log entries after it may
+	// already be gone.
+	if !report.HasSnapshot || fileSnap.Metadata.Index >= report.SnapshotIndex {
+		return nil
+	}
+	fileIndex := fileSnap.Metadata.Index
+	if !repair {
+		return fmt.Errorf("snapshot at %d is applied to the raft log but the snapshot file is at %d (partially applied snapshot)", report.SnapshotIndex, fileIndex)
+	}
+	snap, err := rc.raftStorage.Snapshot()
+	if err != nil {
+		return err
+	}
+	if err := rc.saveSnap(snap); err != nil {
+		return fmt.Errorf("failed to save snapshot %d: %v", snap.Metadata.Index, err)
+	}
+	rc.logger.Warn("repaired raft storage",
+		zap.String("repair", fmt.Sprintf("saved snapshot file at %d from the raft log", snap.Metadata.Index)),
+		zap.String("component", "raft-rocks"))
 	return nil
 }
 
@@ -319,7 +441,7 @@ func (rc *raftNodeRocks) writeError(err error) {
 
 func (rc *raftNodeRocks) startRaft() {
 	if !fileutil.Exist(rc.snapdir) {
-		if err := os.Mkdir(rc.snapdir, 0o750); err != nil {
+		if err := os.MkdirAll(rc.snapdir, 0o750); err != nil {
 			log.Fatalf("store: cannot create dir for snapshot (%v)", err)
 		}
 	}
@@ -329,6 +451,11 @@ func (rc *raftNodeRocks) startRaft() {
 	if err := rc.initRocksDBStorage(); err != nil {
 		log.Fatalf("store: failed to initialize RocksDB storage (%v)", err)
 	}
+	if rc.cfg.Server.Raft.ForceNewCluster {
+		if err := rc.forceNewCluster(); err != nil {
+			log.Fatalf("store: failed to force a new cluster (%v)", err)
+		}
+	}
 
 	// Check if we're restarting an existing node
 	hardState, confState, err := rc.raftStorage.InitialState()
@@ -377,6 +504,13 @@ func (rc *raftNodeRocks) startRaft() {
 		rc.node = raft.StartNode(c, rpeers)
 	}
 
+	// 节点间消息压缩与合并（须在 transport 启动前创建，Process 会用到）
+	codec, err := newMessageCodec(rc.cfg.Server.Raft.Transport)
+	if err != nil {
+		log.Fatalf("store: failed to create raft message codec (%v)", err)
+	}
+	rc.codec = codec
+
 	rc.transport = &rafthttp.Transport{
 		Logger:      rc.logger,
 		ID:          types.ID(rc.id),
@@ -385,7 +519,9 @@ func (rc *raftNodeRocks) startRaft() {
 		ServerStats: stats.NewServerStats("", ""),
 		LeaderStats: stats.NewLeaderStats(newLogger(), strconv.Itoa(rc.id)),
 		ErrorC:      make(chan error),
+		Snapshotter: rc.snapshotter,
 	}
+	rc.snapStream = newSnapshotStream(rc.transport, rc.snapshotter, rc.snapdir, rc.cfg.Server.Raft.Transport.StreamSnapshots())
 
 	rc.transport.Start()
 	for i := range rc.peers {
@@ -397,13 +533,7 @@ func (rc *raftNodeRocks) startRaft() {
 	// 初始化批量提案系统（如果启用）
 	// Witness nodes don't propose data, so batch system is not needed
 	if rc.cfg.Server.Raft.Batch.Enable && !rc.isWitness() {
-		batchConfig := batch.BatchConfig{
-			MinBatchSize:  rc.cfg.Server.Raft.Batch.MinBatchSize,
-			MaxBatchSize:  rc.cfg.Server.Raft.Batch.MaxBatchSize,
-			MinTimeout:    rc.cfg.Server.Raft.Batch.MinTimeout,
-			MaxTimeout:    rc.cfg.Server.Raft.Batch.MaxTimeout,
-			LoadThreshold: rc.cfg.Server.Raft.Batch.LoadThreshold,
-		}
+		batchConfig := newBatchConfig(rc.cfg.Server.Raft.Batch)
 		// batcher 拥有并管理输出通道，通过 ProposeC() 获取
 		rc.batcher = batch.NewProposalBatcher(batchConfig, rc.proposeC, rc.logger)
 		rc.batcher.Start(context.Background())
@@ -414,6 +544,7 @@ func (rc *raftNodeRocks) startRaft() {
 			zap.Duration("min_timeout", batchConfig.MinTimeout),
 			zap.Duration("max_timeout", batchConfig.MaxTimeout),
 			zap.Float64("load_threshold", batchConfig.LoadThreshold),
+			zap.Int("frontend_queues", len(batchConfig.Origins)),
 			zap.String("component", "raft-rocks"))
 	} else if rc.isWitness() {
 		rc.logger.Info("batch proposal system skipped (witness node)",
@@ -470,8 +601,11 @@ func (rc *raftNodeRocks) startRaft() {
 			zap.Bool("currently_enabled", rc.smartLeaseConfig.IsEnabled()),
 			zap.String("component", "raft-rocks"))
 	} else {
-		rc.logger.Info("lease read system disabled", zap.String("component", "raft-rocks"))
+		// 没有租约时所有线性一致读都走 ReadIndex
+		rc.readIndexManager = lease.NewReadIndexManager(nil, rc.logger)
+		rc.logger.Info("lease read system disabled, reads use ReadIndex", zap.String("component", "raft-rocks"))
 	}
+	rc.appliedNotifier = &appliedNotifier{rim: rc.readIndexManager, stopc: rc.stopc}
 
 	// Create an initial snapshot if none exists (for new clusters)
 	// This prevents "need non-empty snapshot" panic when leader tries to sync followers
@@ -554,18 +688,28 @@ func (rc *raftNodeRocks) publishSnapshot(snapshotToSave raftpb.Snapshot) {
 
 	rc.confState = snapshotToSave.Metadata.ConfState
 	rc.snapshotIndex = snapshotToSave.Metadata.Index
+	rc.snapTrigger.reset(time.Now())
 	rc.appliedIndex = snapshotToSave.Metadata.Index
 }
 
-func (rc *raftNodeRocks) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
-	if rc.appliedIndex-rc.snapshotIndex <= rc.snapCount {
+func (rc *raftNodeRocks) maybeTriggerSnapshot() {
+	reason, ok := rc.snapTrigger.due(rc.appliedIndex, rc.snapshotIndex, rc.snapCount, time.Now())
+	if !ok {
+		return
+	}
+
+	// 状态机只在有数据的 commit 上推进 applied index。最近的条目都没有数据时，
+	// 状态机的 applied index 小于 rc.appliedIndex，快照推迟到下一个有数据的 commit，
+	// 保证快照数据与快照元数据的 index 相同
+	if rc.lastCommitIndex != rc.appliedIndex {
 		return
 	}
 
-	// wait until all committed entries are applied (or server is closed)
-	if applyDoneC != nil {
+	// wait until all committed entries are applied (or server is closed),
+	// including commits of earlier Ready batches still queued in commitC
+	if rc.lastApplyDoneC != nil {
 		select {
-		case <-applyDoneC:
+		case <-rc.lastApplyDoneC:
 		case <-rc.stopc:
 			return
 		}
@@ -574,6 +718,8 @@ func (rc *raftNodeRocks) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
 	rc.logger.Info("start snapshot",
 		zap.Uint64("applied_index", rc.appliedIndex),
 		zap.Uint64("last_snapshot_index", rc.snapshotIndex),
+		zap.String("trigger", reason),
+		zap.Uint64("log_bytes", rc.snapTrigger.bytes),
 		zap.String("component", "raft-rocks"))
 	data, err := rc.getSnapshot()
 	if err != nil {
@@ -592,10 +738,7 @@ func (rc *raftNodeRocks) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
 	}
 
 	// Compact RocksDB storage
-	compactIndex := uint64(1)
-	if rc.appliedIndex > snapshotCatchUpEntriesN {
-		compactIndex = rc.appliedIndex - snapshotCatchUpEntriesN
-	}
+	compactIndex := rc.snapTrigger.compactIndex(rc.appliedIndex, rc.snapshotIndex)
 	if err := rc.raftStorage.Compact(compactIndex); err != nil {
 		if !errors.Is(err, raft.ErrCompacted) {
 			panic(err)
@@ -605,6 +748,7 @@ func (rc *raftNodeRocks) maybeTriggerSnapshot(applyDoneC <-chan struct{}) {
 	}
 
 	rc.snapshotIndex = rc.appliedIndex
+	rc.snapTrigger.reset(time.Now())
 }
 
 func (rc *raftNodeRocks) serveChannels() {
@@ -655,6 +799,7 @@ func (rc *raftNodeRocks) serveChannels() {
 						rc.proposeC = nil
 					} else {
 						// blocks until accepted by raft state machine
+						_, prop = kvstore.SplitProposal(prop)
 						rc.node.Propose(context.TODO(), []byte(prop))
 					}
 
@@ -684,6 +829,7 @@ func (rc *raftNodeRocks) serveChannels() {
 		select {
 		case <-ticker.C:
 			rc.node.Tick()
+			rc.checkPeers()
 
 		// 单节点租约续期定时器触发
 		case <-leaseRenewTicker.C:
@@ -700,6 +846,11 @@ func (rc *raftNodeRocks) serveChannels() {
 
 		// store raft entries to RocksDB, then publish over commit channel
 		case rd := <-rc.node.Ready():
+			// leader 变化时让等待 apply 的请求立即失败，而不是等到超时
+			if rd.SoftState != nil {
+				rc.leaderTracker.Observe(rd.SoftState.Lead)
+			}
+
 			// Lease Read: 处理角色变更
 			if rc.cfg.Server.Raft.LeaseRead.Enable && rc.leaseManager != nil {
 				if rd.SoftState != nil {
@@ -730,15 +881,30 @@ func (rc *raftNodeRocks) serveChannels() {
 				rc.publishSnapshot(rd.Snapshot)
 			}
 
+			// Fault injection: a failed raft log fsync is unrecoverable, stop the node
+			if err := chaos.InjectError(chaos.WALSync); err != nil {
+				rc.writeError(err)
+				return
+			}
+
 			// Append entries to RocksDB
 			if len(rd.Entries) > 0 {
+				appendStart := time.Now()
 				if err := rc.raftStorage.Append(rd.Entries); err != nil {
 					log.Fatalf("failed to append entries: %v", err)
 				}
+				rc.snapTrigger.append(rd.Entries)
+				if latency := time.Since(appendStart); rc.diskMonitor.observe(latency, time.Now()) {
+					go rc.moveLeaderOnSlowDisk(latency)
+				}
 			}
 
 			// Send messages to peers
-			rc.transport.Send(rc.processMessages(rd.Messages))
+			rc.peerHealth.sent(rd.Messages, time.Now())
+			rc.transport.Send(chaos.FilterMessages(rc.snapStream.send(rc.codec.encode(rc.processMessages(rd.Messages))), rc.transport.Send))
+
+			// ReadIndex: 分发 leader 确认的 read index
+			rc.readIndexWaiters.notify(rd.ReadStates)
 
 			// Lease Read: 处理心跳响应以续约租约(多节点场景)
 			if rc.cfg.Server.Raft.LeaseRead.Enable && rc.leaseManager != nil && rc.leaseManager.IsLeader() {
@@ -746,14 +912,13 @@ func (rc *raftNodeRocks) serveChannels() {
 			}
 
 			// Apply committed entries
-			applyDoneC, ok := rc.publishEntries(rc.entriesToApply(rd.CommittedEntries))
-			if !ok {
+			if _, ok := rc.publishEntries(rc.entriesToApply(rd.CommittedEntries)); !ok {
 				rc.stop()
 				return
 			}
 
 			// Trigger snapshot if needed
-			rc.maybeTriggerSnapshot(applyDoneC)
+			rc.maybeTriggerSnapshot()
 
 			rc.node.Advance()
 
@@ -779,17 +944,19 @@ func (rc *raftNodeRocks) processMessages(ms []raftpb.Message) []raftpb.Message {
 }
 
 func (rc *raftNodeRocks) serveRaft() {
-	url, err := url.Parse(rc.peers[rc.id-1])
-	if err != nil {
-		log.Fatalf("store: Failed parsing URL (%v)", err)
-	}
-
-	ln, err := NewStoppableListener(url.Host, rc.httpstopc)
+	lns, err := listenPeers(rc.cfg, rc.peers[rc.id-1], rc.httpstopc)
 	if err != nil {
 		log.Fatalf("store: Failed to listen rafthttp (%v)", err)
 	}
 
-	err = (&http.Server{Handler: rc.transport.Handler()}).Serve(ln)
+	// 同一端口上还提供 checkpoint 文件的下载，供其他成员从 checkpoint 快照恢复
+	handler := rocksdb.CheckpointHandler(rc.rocksDB, rc.cfg.Server.RocksDB.CheckpointBootstrap.RateLimit,
+		rc.snapStream.handler(rc.transport.Handler()))
+	srv := &http.Server{Handler: handler}
+	for _, ln := range lns[1:] {
+		go srv.Serve(ln)
+	}
+	err = srv.Serve(lns[0])
 	select {
 	case <-rc.httpstopc:
 	default:
@@ -799,12 +966,33 @@ func (rc *raftNodeRocks) serveRaft() {
 }
 
 func (rc *raftNodeRocks) Process(ctx context.Context, m raftpb.Message) error {
+	if !chaos.AllowReceive() {
+		return nil
+	}
+	if rc.peerHealth.received(m, time.Now()) {
+		rc.logger.Info("Peer is reachable again",
+			zap.Uint64("peer", m.From),
+			zap.String("component", "raft-rocks"))
+	}
+	m, err := rc.snapStream.receive(m)
+	if err != nil {
+		return err
+	}
+	if m, err = rc.codec.decode(m); err != nil {
+		return err
+	}
+	if err := verifySnapshot(m); err != nil {
+		return err
+	}
 	return rc.node.Step(ctx, m)
 }
 
 func (rc *raftNodeRocks) IsIDRemoved(_ uint64) bool { return false }
 
-func (rc *raftNodeRocks) ReportUnreachable(id uint64) { rc.node.ReportUnreachable(id) }
+func (rc *raftNodeRocks) ReportUnreachable(id uint64) {
+	rc.peerHealth.sendFailed(id)
+	rc.node.ReportUnreachable(id)
+}
 
 func (rc *raftNodeRocks) ReportSnapshot(id uint64, status raft.SnapshotStatus) {
 	rc.node.ReportSnapshot(id, status)
@@ -823,17 +1011,89 @@ func (rc *raftNodeRocks) Status() kvstore.RaftStatus {
 	}
 }
 
+// Members 返回当前集群成员及其复制进度和通信情况
+func (rc *raftNodeRocks) Members() []kvstore.MemberStatus {
+	members := memberStatus(rc.node.Status(), rc.peers)
+	rc.peerHealth.fill(members)
+	return members
+}
+
+// checkPeers 在 leader 上检查成员是否失联，新失联的成员记录告警日志
+func (rc *raftNodeRocks) checkPeers() {
+	for _, id := range rc.peerHealth.check(rc.node.Status(), time.Now()) {
+		rc.logger.Warn("Peer is unreachable",
+			zap.Uint64("peer", id),
+			zap.Duration("timeout", rc.peerHealth.deadTimeout),
+			zap.String("component", "raft-rocks"))
+	}
+}
+
 // TransferLeadership 将 leader 角色转移到指定节点
 func (rc *raftNodeRocks) TransferLeadership(targetID uint64) error {
 	rc.node.TransferLeadership(context.TODO(), 0, targetID)
 	return nil
 }
 
+// PauseTransport 暂停与所有 peer 的 raft 消息收发（用于测试中模拟网络分区）
+func (rc *raftNodeRocks) PauseTransport() {
+	rc.transport.Pause()
+}
+
+// ResumeTransport 恢复与所有 peer 的 raft 消息收发
+func (rc *raftNodeRocks) ResumeTransport() {
+	rc.transport.Resume()
+}
+
+// MoveLeader 将 leader 转移给日志最新的活跃 follower，并等待新 leader 产生
+func (rc *raftNodeRocks) MoveLeader(ctx context.Context) (uint64, error) {
+	return moveLeader(ctx, rc.node)
+}
+
+// ReplaceMember 用新的 learner 替换故障成员：添加 learner，等待追上后提升，再移除故障成员
+// 只能在 leader 上执行，report 在每次进度变化时被调用
+func (rc *raftNodeRocks) ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	return rc.replacer.replace(ctx, rc.node, req, report)
+}
+
+// moveLeaderOnSlowDisk 本地磁盘变慢时将 leader 转移出去，避免拖慢整个集群的写入
+func (rc *raftNodeRocks) moveLeaderOnSlowDisk(latency time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.cfg.Server.Raft.LeaderTransfer.Timeout)
+	defer cancel()
+
+	newLeader, err := moveLeader(ctx, rc.node)
+	switch {
+	case errors.Is(err, ErrNotLeader):
+		// follower 的磁盘慢不影响 leader 选择
+	case err != nil:
+		rc.logger.Warn("failed to transfer leadership away from slow disk",
+			zap.Error(err),
+			zap.Duration("latency", latency),
+			zap.String("component", "raft-rocks"))
+	default:
+		rc.logger.Warn("transferred leadership away from slow disk",
+			zap.Uint64("new_leader", newLeader),
+			zap.Duration("latency", latency),
+			zap.String("component", "raft-rocks"))
+	}
+}
+
+// ReadIndex 通过 ReadIndex 协议等待本地状态机追上 leader 的 commit index
+// 返回 nil 后本地读取满足线性一致性，超时由 lease_read.read_timeout 控制
+func (rc *raftNodeRocks) ReadIndex(ctx context.Context) error {
+	return linearizableRead(ctx, rc.node, rc.readIndexWaiters, rc.readIndexManager,
+		rc.cfg.Server.Raft.LeaseRead.ReadTimeout, rc.stopc)
+}
+
 // LeaseManager 返回租约管理器（用于测试）
 func (rc *raftNodeRocks) LeaseManager() *lease.LeaseManager {
 	return rc.leaseManager
 }
 
+// LeaderChanged 返回在下一次 leader 变化时关闭的 channel
+func (rc *raftNodeRocks) LeaderChanged() <-chan struct{} {
+	return rc.leaderTracker.Changed()
+}
+
 // ReadIndexManager 返回读索引管理器（用于测试）
 func (rc *raftNodeRocks) ReadIndexManager() *lease.ReadIndexManager {
 	return rc.readIndexManager
@@ -0,0 +1,162 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// peerHealth 跟踪与每个成员的通信情况
+//
+// 收到成员的任何消息都算一次联系，leader 还用心跳往返估计 RTT。follower 只与
+// leader 通信，因此只在 leader 上判断成员是否失联：超过 deadTimeout 没有联系
+// （刚成为 leader 时从当选时刻开始计算）的成员被标记为不可达
+type peerHealth struct {
+	deadTimeout time.Duration
+
+	mu          sync.Mutex
+	peers       map[uint64]*peerState
+	leaderSince time.Time // 本节点成为 leader 的时间，不是 leader 时为零值
+}
+
+type peerState struct {
+	lastContact   time.Time     // 最近一次收到该成员消息的时间
+	heartbeatSent time.Time     // 最早一次尚未收到响应的心跳的发送时间
+	rtt           time.Duration // 心跳往返时间的平滑值
+	sendFailures  uint64        // 传输层报告发送失败的次数
+	unreachable   bool          // 已经告警失联
+}
+
+func newPeerHealth(deadTimeout time.Duration) *peerHealth {
+	return &peerHealth{deadTimeout: deadTimeout, peers: make(map[uint64]*peerState)}
+}
+
+// peer 返回成员的状态，调用方持有 mu
+func (h *peerHealth) peer(id uint64) *peerState {
+	p, ok := h.peers[id]
+	if !ok {
+		p = &peerState{}
+		h.peers[id] = p
+	}
+	return p
+}
+
+// sent 记录发出的心跳，用于估计 RTT
+func (h *peerHealth) sent(ms []raftpb.Message, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range ms {
+		if ms[i].Type != raftpb.MsgHeartbeat {
+			continue
+		}
+		if p := h.peer(ms[i].To); p.heartbeatSent.IsZero() {
+			p.heartbeatSent = now
+		}
+	}
+}
+
+// received 记录收到的消息，返回该成员是否从失联中恢复
+//
+// 心跳响应无法与具体的心跳对应，RTT 按最早一次未响应的心跳计算，
+// 心跳间隔小于实际 RTT 时只是一个估计
+func (h *peerHealth) received(m raftpb.Message, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := h.peer(m.From)
+	p.lastContact = now
+	if m.Type == raftpb.MsgHeartbeatResp && !p.heartbeatSent.IsZero() {
+		sample := now.Sub(p.heartbeatSent)
+		if p.rtt == 0 {
+			p.rtt = sample
+		} else {
+			p.rtt = (p.rtt*7 + sample) / 8
+		}
+		p.heartbeatSent = time.Time{}
+	}
+	recovered := p.unreachable
+	p.unreachable = false
+	return recovered
+}
+
+// sendFailed 记录传输层报告的发送失败
+func (h *peerHealth) sendFailed(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.peer(id).sendFailures++
+}
+
+// check 在 leader 上检查成员是否失联，返回新失联的成员
+func (h *peerHealth) check(st raft.Status, now time.Time) []uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st.RaftState != raft.StateLeader {
+		// 不再是 leader 时清除告警，由新 leader 判断
+		if !h.leaderSince.IsZero() {
+			h.leaderSince = time.Time{}
+			for _, p := range h.peers {
+				p.unreachable = false
+			}
+		}
+		return nil
+	}
+	if h.leaderSince.IsZero() {
+		h.leaderSince = now
+	}
+
+	var dead []uint64
+	for id := range st.Progress {
+		if id == st.ID {
+			continue
+		}
+		p := h.peer(id)
+		since := p.lastContact
+		if since.Before(h.leaderSince) {
+			since = h.leaderSince
+		}
+		if !p.unreachable && now.Sub(since) > h.deadTimeout {
+			p.unreachable = true
+			dead = append(dead, id)
+		}
+	}
+	// 已经移出集群的成员不再跟踪
+	for id := range h.peers {
+		if _, ok := st.Progress[id]; !ok {
+			delete(h.peers, id)
+		}
+	}
+	return dead
+}
+
+// fill 把与各成员的通信情况填入成员状态
+func (h *peerHealth) fill(members []kvstore.MemberStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range members {
+		p, ok := h.peers[members[i].ID]
+		if !ok {
+			continue
+		}
+		members[i].LastContact = p.lastContact
+		members[i].RTT = p.rtt
+		members[i].SendFailures = p.sendFailures
+		members[i].Unreachable = p.unreachable
+	}
+}
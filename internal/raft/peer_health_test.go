@@ -0,0 +1,66 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+	"go.etcd.io/raft/v3/tracker"
+)
+
+func TestPeerHealth(t *testing.T) {
+	now := time.Now()
+	h := newPeerHealth(10 * time.Second)
+	leader := raft.Status{
+		BasicStatus: raft.BasicStatus{ID: 1, SoftState: raft.SoftState{RaftState: raft.StateLeader}},
+		Progress:    map[uint64]tracker.Progress{1: {}, 2: {}, 3: {}},
+	}
+
+	// 心跳往返估计 RTT
+	sentAt := now.Add(5 * time.Second)
+	h.sent([]raftpb.Message{{Type: raftpb.MsgHeartbeat, To: 2}, {Type: raftpb.MsgApp, To: 3}}, sentAt)
+	assert.False(t, h.received(raftpb.Message{Type: raftpb.MsgHeartbeatResp, From: 2}, sentAt.Add(20*time.Millisecond)))
+	h.sendFailed(3)
+
+	// 刚成为 leader 时从当选时刻开始计算
+	assert.Empty(t, h.check(leader, now))
+	assert.Equal(t, []uint64{3}, h.check(leader, now.Add(11*time.Second)))
+	assert.Equal(t, []uint64{2}, h.check(leader, now.Add(16*time.Second)))
+	assert.Empty(t, h.check(leader, now.Add(20*time.Second)), "alarm only once")
+
+	members := []kvstore.MemberStatus{{ID: 1}, {ID: 2}, {ID: 3}}
+	h.fill(members)
+	assert.Equal(t, 20*time.Millisecond, members[1].RTT)
+	assert.Equal(t, sentAt.Add(20*time.Millisecond), members[1].LastContact)
+	assert.True(t, members[1].Unreachable)
+	assert.Equal(t, uint64(1), members[2].SendFailures)
+	assert.True(t, members[2].LastContact.IsZero())
+
+	// 恢复通信
+	assert.True(t, h.received(raftpb.Message{Type: raftpb.MsgAppResp, From: 3}, now.Add(21*time.Second)))
+	assert.False(t, h.received(raftpb.Message{Type: raftpb.MsgAppResp, From: 3}, now.Add(22*time.Second)))
+
+	// 不再是 leader 时清除告警
+	follower := raft.Status{BasicStatus: raft.BasicStatus{ID: 1, SoftState: raft.SoftState{RaftState: raft.StateFollower}}}
+	assert.Empty(t, h.check(follower, now.Add(time.Minute)))
+	h.fill(members)
+	assert.False(t, members[1].Unreachable)
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"fmt"
+	"math"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/rocksdb"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// MaxRaftLogEntries RaftLog 一次最多解码的条目数
+const MaxRaftLogEntries = 1000
+
+// RaftLog 从 RocksDB 存储读取并解码 from 开始的 limit 个条目，from 为 0 时返回最后 limit 个条目。
+// redact 隐藏 value，只保留长度。节点照常运行，读取不影响复制和 apply
+func (rc *raftNodeRocks) RaftLog(from uint64, limit int, redact bool) (*kvstore.RaftLog, error) {
+	if limit <= 0 || limit > MaxRaftLogEntries {
+		limit = MaxRaftLogEntries
+	}
+	first, err := rc.raftStorage.FirstIndex()
+	if err != nil {
+		return nil, err
+	}
+	last, err := rc.raftStorage.LastIndex()
+	if err != nil {
+		return nil, err
+	}
+	status := rc.node.Status()
+	l := &kvstore.RaftLog{
+		FirstIndex: first,
+		LastIndex:  last,
+		Commit:     status.Commit,
+		Applied:    status.Applied,
+		Entries:    []kvstore.RaftLogEntry{},
+	}
+
+	lo := from
+	if lo == 0 && last >= uint64(limit) {
+		lo = last - uint64(limit) + 1
+	}
+	if lo < first {
+		lo = first
+	}
+	hi := lo + uint64(limit) // 不含
+	if hi > last+1 {
+		hi = last + 1
+	}
+	if lo >= hi {
+		return l, nil
+	}
+	ents, err := rc.raftStorage.Entries(lo, hi, math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	for _, ent := range ents {
+		l.Entries = append(l.Entries, decodeLogEntry(ent, rc.cfg.Server.Raft.Batch.Enable, redact))
+	}
+	return l, nil
+}
+
+// decodeLogEntry 按 publishEntries 和状态机的方式解码条目
+func decodeLogEntry(ent raftpb.Entry, batched, redact bool) kvstore.RaftLogEntry {
+	e := kvstore.RaftLogEntry{Index: ent.Index, Term: ent.Term, Type: ent.Type.String()}
+	switch ent.Type {
+	case raftpb.EntryConfChange:
+		var cc raftpb.ConfChange
+		if err := cc.Unmarshal(ent.Data); err != nil {
+			e.Error = err.Error()
+		} else {
+			e.ConfChange = fmt.Sprintf("%s %d", cc.Type, cc.NodeID)
+		}
+		return e
+	case raftpb.EntryConfChangeV2:
+		var cc raftpb.ConfChangeV2
+		if err := cc.Unmarshal(ent.Data); err != nil {
+			e.Error = err.Error()
+		} else {
+			e.ConfChange = raftpb.ConfChangesToString(cc.Changes)
+		}
+		return e
+	}
+
+	proposals, err := EntryProposals(ent, batched)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	for _, p := range proposals {
+		ops, err := rocksdb.DecodeProposal(p)
+		if err != nil {
+			e.Error = err.Error()
+			return e
+		}
+		for _, op := range ops {
+			o := kvstore.RaftLogOp{
+				Type:      op.Type,
+				Key:       op.Key,
+				RangeEnd:  op.RangeEnd,
+				ValueSize: len(op.Value),
+				LeaseID:   op.LeaseID,
+				SeqNum:    op.SeqNum,
+				TxnOps:    len(op.Compares) + len(op.ThenOps) + len(op.ElseOps),
+			}
+			if !redact {
+				o.Value = op.Value
+			}
+			e.Ops = append(e.Ops, o)
+		}
+	}
+	return e
+}
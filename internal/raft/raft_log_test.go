@@ -0,0 +1,60 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+
+	"metaStore/internal/batch"
+	pb "metaStore/internal/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3/raftpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecodeLogEntry(t *testing.T) {
+	proposal, err := proto.Marshal(&pb.RaftOperation{Type: "PUT", Key: "k", Value: "secret", SeqNum: "1-3"})
+	require.NoError(t, err)
+	data, err := batch.EncodeBatch([]string{string(proposal)})
+	require.NoError(t, err)
+
+	e := decodeLogEntry(raftpb.Entry{Index: 9, Term: 2, Data: data}, true, false)
+	assert.Equal(t, "EntryNormal", e.Type)
+	assert.Empty(t, e.Error)
+	require.Len(t, e.Ops, 1)
+	assert.Equal(t, "k", e.Ops[0].Key)
+	assert.Equal(t, "secret", e.Ops[0].Value)
+	assert.Equal(t, 6, e.Ops[0].ValueSize)
+	assert.Equal(t, "1-3", e.Ops[0].SeqNum)
+
+	// 隐藏 value，只保留长度
+	e = decodeLogEntry(raftpb.Entry{Index: 9, Term: 2, Data: data}, true, true)
+	require.Len(t, e.Ops, 1)
+	assert.Empty(t, e.Ops[0].Value)
+	assert.Equal(t, 6, e.Ops[0].ValueSize)
+
+	cc := raftpb.ConfChange{Type: raftpb.ConfChangeAddLearnerNode, NodeID: 4}
+	ccData, err := cc.Marshal()
+	require.NoError(t, err)
+	e = decodeLogEntry(raftpb.Entry{Index: 10, Type: raftpb.EntryConfChange, Data: ccData}, true, false)
+	assert.Equal(t, "ConfChangeAddLearnerNode 4", e.ConfChange)
+
+	// 空条目没有操作
+	e = decodeLogEntry(raftpb.Entry{Index: 11}, true, false)
+	assert.Empty(t, e.Ops)
+	assert.Empty(t, e.Error)
+}
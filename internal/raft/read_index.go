@@ -0,0 +1,151 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"metaStore/internal/lease"
+
+	"go.etcd.io/raft/v3"
+)
+
+// ErrStopped is returned by reads issued after the raft node has stopped
+var ErrStopped = errors.New("raft: node stopped")
+
+// readIndexWaiters 跟踪等待 Raft ReadState 的读请求
+// 每个请求使用唯一的 request context，Ready 中返回的 ReadState 按 context 分发
+type readIndexWaiters struct {
+	mu      sync.Mutex
+	seq     uint64
+	waiters map[string]chan uint64
+}
+
+func newReadIndexWaiters() *readIndexWaiters {
+	return &readIndexWaiters{waiters: make(map[string]chan uint64)}
+}
+
+// register 分配唯一的请求上下文
+func (w *readIndexWaiters) register() ([]byte, <-chan uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+	rctx := make([]byte, 8)
+	binary.BigEndian.PutUint64(rctx, w.seq)
+	ch := make(chan uint64, 1)
+	w.waiters[string(rctx)] = ch
+	return rctx, ch
+}
+
+func (w *readIndexWaiters) cancel(rctx []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.waiters, string(rctx))
+}
+
+// notify 将 Ready 中的 ReadState 分发给对应的请求
+func (w *readIndexWaiters) notify(states []raft.ReadState) {
+	if len(states) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, rs := range states {
+		if ch, ok := w.waiters[string(rs.RequestCtx)]; ok {
+			ch <- rs.Index
+			delete(w.waiters, string(rs.RequestCtx))
+		}
+	}
+}
+
+// linearizableRead 执行 ReadIndex 协议：
+//  1. 通过 leader 确认当前 commit index 作为 readIndex（leader 需要心跳多数派确认）
+//  2. 等待本地状态机应用到 readIndex
+//
+// 返回 nil 后，本地读取满足线性一致性
+func linearizableRead(ctx context.Context, node raft.Node, w *readIndexWaiters, rim *lease.ReadIndexManager,
+	timeout time.Duration, stopc <-chan struct{}) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	rctx, ch := w.register()
+	defer w.cancel(rctx)
+
+	if err := node.ReadIndex(ctx, rctx); err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+
+	var index uint64
+	select {
+	case index = <-ch:
+	case <-ctx.Done():
+		return fmt.Errorf("read index timeout: %w", ctx.Err())
+	case <-stopc:
+		return ErrStopped
+	}
+
+	if rim == nil {
+		return nil
+	}
+	_, err := rim.RequestReadIndex(ctx, index)
+	return err
+}
+
+// appliedNotifier 在状态机真正应用完成后才推进 ReadIndexManager 的 applied index
+// commitC 是无缓冲通道，发送成功只表示 store 收到了提交，不代表已经应用
+// 只在 serveChannels 所在的 goroutine 中调用
+type appliedNotifier struct {
+	rim       *lease.ReadIndexManager
+	stopc     <-chan struct{}
+	lastDoneC <-chan struct{}
+}
+
+// notify 在 applyDoneC（没有数据时为上一批的 applyDoneC）关闭后通知 index
+// store 按顺序应用提交，因此最后一批完成即表示之前的提交都已应用
+func (n *appliedNotifier) notify(index uint64, applyDoneC <-chan struct{}) {
+	if applyDoneC != nil {
+		n.lastDoneC = applyDoneC
+	}
+	doneC := n.lastDoneC
+	if doneC == nil {
+		n.rim.NotifyApplied(index)
+		return
+	}
+
+	select {
+	case <-doneC:
+		n.rim.NotifyApplied(index)
+		return
+	default:
+	}
+
+	go func() {
+		select {
+		case <-doneC:
+			n.rim.NotifyApplied(index)
+		case <-n.stopc:
+		}
+	}()
+}
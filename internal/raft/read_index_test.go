@@ -0,0 +1,78 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/lease"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3"
+	"go.uber.org/zap"
+)
+
+func TestReadIndexWaitersNotify(t *testing.T) {
+	w := newReadIndexWaiters()
+	rctx1, ch1 := w.register()
+	rctx2, ch2 := w.register()
+	require.NotEqual(t, rctx1, rctx2)
+
+	w.notify([]raft.ReadState{{Index: 7, RequestCtx: rctx2}, {Index: 9, RequestCtx: []byte("unknown")}})
+	require.Equal(t, uint64(7), <-ch2)
+
+	select {
+	case <-ch1:
+		t.Fatal("unrelated waiter must not be notified")
+	default:
+	}
+
+	w.cancel(rctx1)
+	require.Empty(t, w.waiters)
+}
+
+func TestAppliedNotifierWaitsForApply(t *testing.T) {
+	rim := lease.NewReadIndexManager(nil, zap.NewNop())
+	stopc := make(chan struct{})
+	defer close(stopc)
+	n := &appliedNotifier{rim: rim, stopc: stopc}
+
+	// 第 5 条已发送给 store 但尚未应用，第 6 条是没有数据的配置变更
+	applyDoneC := make(chan struct{})
+	n.notify(5, applyDoneC)
+	n.notify(6, nil)
+
+	readDone := make(chan error, 1)
+	go func() {
+		_, err := rim.RequestReadIndex(context.Background(), 6)
+		readDone <- err
+	}()
+
+	select {
+	case <-readDone:
+		t.Fatal("read must wait until the state machine has applied the entries")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(applyDoneC)
+	select {
+	case err := <-readDone:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("read was not released after apply")
+	}
+}
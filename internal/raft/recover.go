@@ -0,0 +1,149 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"metaStore/internal/rocksdb"
+
+	"github.com/linxGnu/grocksdb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// Recovery 离线改写成员配置的方式，成员停止时由 metastore recover 执行
+type Recovery struct {
+	MemberID uint64 // 执行恢复的成员
+
+	// ForceNewCluster 移除其他全部成员，只留下 MemberID
+	ForceNewCluster bool
+	// RemoveMembers 移除的成员，存活的成员都要以相同的列表执行
+	RemoveMembers []uint64
+}
+
+// rewrite 计算需要追加的成员变更
+func (r Recovery) rewrite(cs raftpb.ConfState, ents []raftpb.Entry, st raftpb.HardState) ([]raftpb.Entry, raftpb.HardState, error) {
+	switch {
+	case r.ForceNewCluster && len(r.RemoveMembers) > 0:
+		return nil, st, errors.New("force new cluster and remove members are exclusive")
+	case r.ForceNewCluster:
+		appended, newSt := forceNewCluster(r.MemberID, cs, ents, st)
+		return appended, newSt, nil
+	case len(r.RemoveMembers) > 0:
+		for _, m := range r.RemoveMembers {
+			if m == r.MemberID {
+				return nil, st, fmt.Errorf("member %d cannot remove itself", m)
+			}
+		}
+		return unsafeRemoveMembers(r.RemoveMembers, cs, ents, st)
+	}
+	return nil, st, errors.New("nothing to recover")
+}
+
+// membersAfter 返回追加 appended 之后的成员，commit 是改写前的提交点
+func membersAfter(cs raftpb.ConfState, ents []raftpb.Entry, commit uint64, appended []raftpb.Entry) []uint64 {
+	log := make([]raftpb.Entry, 0, len(ents)+len(appended))
+	for _, ent := range ents {
+		if ent.Index <= commit {
+			log = append(log, ent)
+		}
+	}
+	return sortedMembers(committedMembers(cs, append(log, appended...), math.MaxUint64))
+}
+
+// RecoverWAL 离线改写 memory 引擎的 raft WAL，返回改写后的成员
+func RecoverWAL(walDir, snapDir string, r Recovery) ([]uint64, error) {
+	if !wal.Exist(walDir) {
+		return nil, fmt.Errorf("no raft WAL in %s", walDir)
+	}
+	logger := newLogger()
+	walSnaps, err := wal.ValidSnapshotEntries(logger, walDir)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	snapshot, err := snap.New(logger, snapDir).LoadNewestAvailable(walSnaps)
+	if err != nil && !errors.Is(err, snap.ErrNoSnapshot) {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	var walsnap walpb.Snapshot
+	var cs raftpb.ConfState
+	if snapshot != nil {
+		walsnap.Index, walsnap.Term = snapshot.Metadata.Index, snapshot.Metadata.Term
+		cs = snapshot.Metadata.ConfState
+	}
+
+	w, err := wal.Open(logger, walDir, walsnap)
+	if err != nil {
+		return nil, fmt.Errorf("open WAL: %w", err)
+	}
+	defer w.Close()
+	_, st, ents, err := w.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read WAL: %w", err)
+	}
+
+	appended, newSt, err := r.rewrite(cs, ents, st)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Save(newSt, appended); err != nil {
+		return nil, fmt.Errorf("write WAL: %w", err)
+	}
+	return membersAfter(cs, ents, st.Commit, appended), nil
+}
+
+// RecoverRocksDB 离线改写 RocksDB 中 memberID 的 raft 日志，返回改写后的成员
+func RecoverRocksDB(db *grocksdb.DB, r Recovery) ([]uint64, error) {
+	storage, err := rocksdb.NewRocksDBStorage(db, fmt.Sprintf("node_%d", r.MemberID))
+	if err != nil {
+		return nil, err
+	}
+	defer storage.Close()
+	st, cs, err := storage.InitialState()
+	if err != nil {
+		return nil, err
+	}
+	first, err := storage.FirstIndex()
+	if err != nil {
+		return nil, err
+	}
+	last, err := storage.LastIndex()
+	if err != nil {
+		return nil, err
+	}
+	var ents []raftpb.Entry
+	if last >= first {
+		if ents, err = storage.Entries(first, last+1, math.MaxUint64); err != nil {
+			return nil, err
+		}
+	}
+
+	appended, newSt, err := r.rewrite(cs, ents, st)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.Append(appended); err != nil {
+		return nil, err
+	}
+	if err := storage.SetHardState(newSt); err != nil {
+		return nil, err
+	}
+	return membersAfter(cs, ents, st.Commit, appended), nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"metaStore/internal/batch"
+	"metaStore/internal/rocksdb"
+
+	"github.com/linxGnu/grocksdb"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/etcd/server/v3/storage/wal/walpb"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// ReplayLog metastore replay 离线读取的 raft 日志
+type ReplayLog struct {
+	Snapshot  *raftpb.Snapshot // 回放的起点，nil 表示从空状态机开始
+	Entries   []raftpb.Entry   // 起点之后持久化的条目
+	HardState raftpb.HardState // HardState.Commit 之后的条目尚未提交
+}
+
+// Snapshotter 在空目录 dir 中保存回放的起点快照，全新的状态机从中恢复
+func (l *ReplayLog) Snapshotter(dir string) (*snap.Snapshotter, error) {
+	s := snap.New(newLogger(), dir)
+	if l.Snapshot != nil {
+		if err := s.SaveSnap(*l.Snapshot); err != nil {
+			return nil, fmt.Errorf("stage snapshot: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// EntryProposals 把普通条目解码为交给状态机的提案，batched 对应 raft.batch.enable
+func EntryProposals(ent raftpb.Entry, batched bool) ([]string, error) {
+	if ent.Type != raftpb.EntryNormal || len(ent.Data) == 0 {
+		return nil, nil
+	}
+	if batched {
+		return batch.DecodeBatch(ent.Data)
+	}
+	return []string{string(ent.Data)}, nil
+}
+
+// ReadWAL 只读地读取 memory 引擎的 WAL，起点是索引不超过 until 的最新快照，until 为 0 时不限
+func ReadWAL(walDir, snapDir string, until uint64) (*ReplayLog, error) {
+	if !wal.Exist(walDir) {
+		return nil, fmt.Errorf("no raft WAL in %s", walDir)
+	}
+	logger := newLogger()
+	walSnaps, err := wal.ValidSnapshotEntries(logger, walDir)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	candidates := make([]walpb.Snapshot, 0, len(walSnaps))
+	for _, s := range walSnaps {
+		if until == 0 || s.Index <= until {
+			candidates = append(candidates, s)
+		}
+	}
+	snapshot, err := snap.New(logger, snapDir).LoadNewestAvailable(candidates)
+	if err != nil && !errors.Is(err, snap.ErrNoSnapshot) {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+	var walsnap walpb.Snapshot
+	if snapshot != nil {
+		walsnap.Index, walsnap.Term = snapshot.Metadata.Index, snapshot.Metadata.Term
+	}
+
+	// 只读打开，不会截断或修复 WAL
+	w, err := wal.OpenForRead(logger, walDir, walsnap)
+	if err != nil {
+		return nil, fmt.Errorf("open WAL at index %d: %w", walsnap.Index, err)
+	}
+	defer w.Close()
+	_, st, ents, err := w.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read WAL: %w", err)
+	}
+	return &ReplayLog{Snapshot: snapshot, Entries: ents, HardState: st}, nil
+}
+
+// ReadRocksDB 读取 RocksDB 中 memberID 的 raft 日志。日志被压缩过时以存储中的快照为起点，
+// 快照晚于 until 时无法回放到 until
+func ReadRocksDB(db *grocksdb.DB, memberID, until uint64) (*ReplayLog, error) {
+	storage, err := rocksdb.NewRocksDBStorage(db, fmt.Sprintf("node_%d", memberID))
+	if err != nil {
+		return nil, err
+	}
+	defer storage.Close()
+	st, _, err := storage.InitialState()
+	if err != nil {
+		return nil, err
+	}
+	first, err := storage.FirstIndex()
+	if err != nil {
+		return nil, err
+	}
+	last, err := storage.LastIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &ReplayLog{HardState: st}
+	if first > 1 {
+		snapshot, err := storage.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		index := snapshot.Metadata.Index
+		if len(snapshot.Data) == 0 || index+1 < first {
+			return nil, fmt.Errorf("raft log before index %d is compacted and no snapshot covers it", first)
+		}
+		if until != 0 && index > until {
+			return nil, fmt.Errorf("raft log up to index %d is compacted into the snapshot, cannot replay until %d", index, until)
+		}
+		l.Snapshot = &snapshot
+		first = index + 1
+	}
+	if last >= first {
+		if l.Entries, err = storage.Entries(first, last+1, math.MaxUint64); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"testing"
+
+	"metaStore/internal/batch"
+	"metaStore/internal/rocksdb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestEntryProposals(t *testing.T) {
+	proposals, err := EntryProposals(raftpb.Entry{Data: []byte("put")}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"put"}, proposals)
+
+	data, err := batch.EncodeBatch([]string{"a", "b"})
+	require.NoError(t, err)
+	proposals, err = EntryProposals(raftpb.Entry{Data: data}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, proposals)
+
+	// 空条目和成员变更不交给状态机
+	proposals, err = EntryProposals(raftpb.Entry{}, true)
+	require.NoError(t, err)
+	assert.Empty(t, proposals)
+	proposals, err = EntryProposals(raftpb.Entry{Type: raftpb.EntryConfChange, Data: []byte{1}}, false)
+	require.NoError(t, err)
+	assert.Empty(t, proposals)
+}
+
+func TestReadRocksDB(t *testing.T) {
+	db, err := rocksdb.Open(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+	storage, err := rocksdb.NewRocksDBStorage(db, "node_1")
+	require.NoError(t, err)
+	var ents []raftpb.Entry
+	for i := uint64(1); i <= 6; i++ {
+		ents = append(ents, raftpb.Entry{Index: i, Term: 1, Data: []byte{byte(i)}})
+	}
+	require.NoError(t, storage.Append(ents))
+	require.NoError(t, storage.SetHardState(raftpb.HardState{Term: 1, Commit: 5}))
+
+	l, err := ReadRocksDB(db, 1, 0)
+	require.NoError(t, err)
+	assert.Nil(t, l.Snapshot)
+	assert.Len(t, l.Entries, 6)
+	assert.Equal(t, uint64(5), l.HardState.Commit)
+
+	// 压缩后从快照开始回放
+	_, err = storage.CreateSnapshot(4, &raftpb.ConfState{Voters: []uint64{1}}, []byte("state"))
+	require.NoError(t, err)
+	require.NoError(t, storage.Compact(3))
+	l, err = ReadRocksDB(db, 1, 0)
+	require.NoError(t, err)
+	require.NotNil(t, l.Snapshot)
+	assert.Equal(t, uint64(4), l.Snapshot.Metadata.Index)
+	require.Len(t, l.Entries, 2)
+	assert.Equal(t, uint64(5), l.Entries[0].Index)
+
+	_, err = ReadRocksDB(db, 1, 3)
+	assert.Error(t, err, "index 3 is inside the snapshot")
+}
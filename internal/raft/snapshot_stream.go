@@ -0,0 +1,194 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"metaStore/pkg/log"
+
+	"go.etcd.io/etcd/server/v3/etcdserver/api/rafthttp"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/raft/v3/raftpb"
+	"go.uber.org/zap"
+)
+
+// streamedSnapshotMarker 流式发送的 MsgSnap 中代替快照数据的标记
+//
+// 快照数据（经过 codec 压缩后）与消息一起 POST 到对端的 /raft/snapshot，
+// 对端 rafthttp 把数据写入快照目录下的 <index>.snap.db 后再调用 Process，
+// Process 用文件内容替换标记。pipeline 对单条消息有 512MB 的上限，
+// 流式发送的快照不受该限制，也不会长时间占用 pipeline
+var streamedSnapshotMarker = []byte("\x00msnapstream")
+
+// snapshotProgressInterval 快照收发进度的日志间隔
+const snapshotProgressInterval = 5 * time.Second
+
+// snapshotSender 由 rafthttp.Transport 实现
+type snapshotSender interface {
+	SendSnapshot(m snap.Message)
+}
+
+// snapshotStream 在 rafthttp 的快照通道上收发快照数据
+type snapshotStream struct {
+	transport   snapshotSender
+	snapshotter *snap.Snapshotter
+	enabled     bool // 发送端是否流式发送；接收端总是能处理流式快照
+}
+
+// newSnapshotStream 创建快照流，并删除上次运行中没有被安装的快照数据文件
+func newSnapshotStream(transport snapshotSender, snapshotter *snap.Snapshotter, snapdir string, enabled bool) *snapshotStream {
+	if leftovers, err := filepath.Glob(filepath.Join(snapdir, "*.snap.db")); err == nil {
+		for _, f := range leftovers {
+			os.Remove(f)
+		}
+	}
+	return &snapshotStream{transport: transport, snapshotter: snapshotter, enabled: enabled}
+}
+
+// send 把 MsgSnap 交给快照通道发送，返回其余的消息
+// 发送完成或失败由 rafthttp 通过 ReportSnapshot 报告给 raft
+func (s *snapshotStream) send(msgs []raftpb.Message) []raftpb.Message {
+	if !s.enabled {
+		return msgs
+	}
+	rest := msgs[:0]
+	for _, m := range msgs {
+		if m.Type != raftpb.MsgSnap || m.Snapshot == nil {
+			rest = append(rest, m)
+			continue
+		}
+		data := m.Snapshot.Data
+		snapshot := *m.Snapshot
+		snapshot.Data = streamedSnapshotMarker
+		m.Snapshot = &snapshot
+
+		body := &progressReader{r: bytes.NewReader(data), total: int64(len(data))}
+		msg := snap.NewMessage(m, body, int64(len(data)))
+		go body.report("Sending snapshot", m.To, snapshot.Metadata.Index, msg.CloseNotify())
+		s.transport.SendSnapshot(*msg)
+	}
+	return rest
+}
+
+// receive 用 rafthttp 保存的快照数据文件替换流式 MsgSnap 中的标记，文件读取后删除
+func (s *snapshotStream) receive(m raftpb.Message) (raftpb.Message, error) {
+	if m.Type != raftpb.MsgSnap || m.Snapshot == nil || !bytes.Equal(m.Snapshot.Data, streamedSnapshotMarker) {
+		return m, nil
+	}
+	index := m.Snapshot.Metadata.Index
+	path, err := s.snapshotter.DBFilePath(index)
+	if err != nil {
+		return m, fmt.Errorf("streamed snapshot %d from %x: %w", index, m.From, err)
+	}
+	data, err := os.ReadFile(path)
+	os.Remove(path)
+	if err != nil {
+		return m, fmt.Errorf("streamed snapshot %d from %x: %w", index, m.From, err)
+	}
+
+	snapshot := *m.Snapshot
+	snapshot.Data = data
+	m.Snapshot = &snapshot
+	return m, nil
+}
+
+// handler 记录接收快照的进度，其余请求直接交给 rafthttp
+func (s *snapshotStream) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != rafthttp.RaftSnapshotPrefix || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := &progressReader{r: r.Body, total: r.ContentLength}
+		done := make(chan bool, 1)
+		go body.report("Receiving snapshot", 0, 0, done)
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, r.Body}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		done <- sw.status < http.StatusBadRequest
+	})
+}
+
+// statusWriter 记录响应状态码
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// progressReader 统计已经读取的字节数
+type progressReader struct {
+	r     io.Reader
+	total int64
+	read  atomic.Int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read.Add(int64(n))
+	return n, err
+}
+
+func (p *progressReader) Close() error {
+	return nil
+}
+
+// report 定期记录进度，done 收到结果后记录总耗时
+func (p *progressReader) report(msg string, to, index uint64, done <-chan bool) {
+	start := time.Now()
+	ticker := time.NewTicker(snapshotProgressInterval)
+	defer ticker.Stop()
+
+	fields := func() []zap.Field {
+		fs := []zap.Field{
+			zap.Int64("bytes", p.read.Load()),
+			zap.Int64("total_bytes", p.total),
+			zap.Duration("elapsed", time.Since(start)),
+			zap.String("component", "raft"),
+		}
+		if to != 0 {
+			fs = append(fs, zap.String("to", fmt.Sprintf("%x", to)), zap.Uint64("index", index))
+		}
+		return fs
+	}
+	for {
+		select {
+		case ok := <-done:
+			if ok {
+				log.Info(msg+" finished", fields()...)
+			} else {
+				log.Warn(msg+" failed", fields()...)
+			}
+			return
+		case <-ticker.C:
+			log.Info(msg+" in progress", fields()...)
+		}
+	}
+}
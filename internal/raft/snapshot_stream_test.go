@@ -0,0 +1,97 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+type fakeSnapshotSender struct {
+	sent []snap.Message
+}
+
+func (f *fakeSnapshotSender) SendSnapshot(m snap.Message) {
+	f.sent = append(f.sent, m)
+}
+
+func TestSnapshotStreamRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	snapshotter := snap.New(newLogger(), dir)
+	sender := &fakeSnapshotSender{}
+	stream := newSnapshotStream(sender, snapshotter, dir, true)
+
+	data := bytes.Repeat([]byte("metastore-key=value;"), 1000)
+	snapshot := &raftpb.Snapshot{Data: data, Metadata: raftpb.SnapshotMetadata{Index: 7, Term: 2}}
+	msgs := []raftpb.Message{
+		{Type: raftpb.MsgApp, To: 2},
+		{Type: raftpb.MsgSnap, To: 3, Snapshot: snapshot},
+	}
+
+	rest := stream.send(msgs)
+	require.Len(t, rest, 1, "snapshot messages leave the regular message path")
+	assert.Equal(t, raftpb.MsgApp, rest[0].Type)
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, data, snapshot.Data, "the snapshot held by raft is not modified")
+
+	m := sender.sent[0]
+	assert.Equal(t, streamedSnapshotMarker, m.Snapshot.Data)
+	assert.Equal(t, int64(len(data)), m.TotalSize-int64(m.Message.Size()))
+
+	// 模拟 rafthttp 接收端：先保存数据再调用 Process
+	body, err := io.ReadAll(m.ReadCloser)
+	require.NoError(t, err)
+	_, err = snapshotter.SaveDBFrom(bytes.NewReader(body), m.Snapshot.Metadata.Index)
+	require.NoError(t, err)
+
+	got, err := stream.receive(m.Message)
+	require.NoError(t, err)
+	assert.Equal(t, data, got.Snapshot.Data)
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*.snap.db"))
+	assert.Empty(t, leftovers, "the data file is removed once the snapshot is loaded")
+
+	// 数据文件缺失时拒绝快照
+	_, err = stream.receive(m.Message)
+	assert.Error(t, err)
+}
+
+func TestSnapshotStreamDisabled(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0000000000000005.snap.db"), []byte("stale"), 0o600))
+
+	sender := &fakeSnapshotSender{}
+	stream := newSnapshotStream(sender, snap.New(newLogger(), dir), dir, false)
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*.snap.db"))
+	assert.Empty(t, leftovers, "data files of an interrupted transfer are removed at startup")
+
+	snapshot := &raftpb.Snapshot{Data: []byte("state"), Metadata: raftpb.SnapshotMetadata{Index: 1}}
+	rest := stream.send([]raftpb.Message{{Type: raftpb.MsgSnap, To: 2, Snapshot: snapshot}})
+	require.Len(t, rest, 1)
+	assert.Empty(t, sender.sent)
+
+	// 未流式发送的快照原样通过
+	got, err := stream.receive(rest[0])
+	require.NoError(t, err)
+	assert.Equal(t, []byte("state"), got.Snapshot.Data)
+}
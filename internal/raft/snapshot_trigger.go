@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"time"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// snapshotTrigger 决定何时创建快照并压缩 Raft 日志
+//
+// 除了距上次快照的条目数，还按追加的日志字节数和时间触发，
+// 条目少但单条很大的节点不会在压缩之前积累巨大的日志
+type snapshotTrigger struct {
+	maxBytes uint64        // 上次快照以来追加的日志字节数超过该值时触发，0 表示不按大小触发
+	interval time.Duration // 距上次快照超过该时间且有新条目时触发，0 表示不按时间触发
+
+	bytes uint64    // 上次快照以来追加的日志字节数
+	last  time.Time // 上次快照的时间
+}
+
+func newSnapshotTrigger(maxBytes uint64, interval time.Duration) *snapshotTrigger {
+	return &snapshotTrigger{maxBytes: maxBytes, interval: interval, last: time.Now()}
+}
+
+// append 记录追加到日志的条目
+func (t *snapshotTrigger) append(ents []raftpb.Entry) {
+	for i := range ents {
+		t.bytes += uint64(ents[i].Size())
+	}
+}
+
+// due 返回是否应当创建快照及触发原因，count 为按条目数触发的阈值
+func (t *snapshotTrigger) due(applied, snapshotIndex, count uint64, now time.Time) (string, bool) {
+	switch {
+	case applied <= snapshotIndex:
+		return "", false
+	case applied-snapshotIndex > count:
+		return "entries", true
+	case t.maxBytes > 0 && t.bytes > t.maxBytes:
+		return "bytes", true
+	case t.interval > 0 && now.Sub(t.last) >= t.interval:
+		return "interval", true
+	}
+	return "", false
+}
+
+// compactIndex 返回创建 applied 处的快照后日志可以压缩到的位置
+//
+// 保留 snapshotCatchUpEntriesN 条日志供稍慢的 follower 追赶，按大小触发时
+// 保留的日志不超过上次快照以来的部分，日志总大小不超过两个 maxBytes
+func (t *snapshotTrigger) compactIndex(applied, snapshotIndex uint64) uint64 {
+	compactIndex := uint64(1)
+	if applied > snapshotCatchUpEntriesN {
+		compactIndex = applied - snapshotCatchUpEntriesN
+	}
+	if t.maxBytes > 0 && snapshotIndex > compactIndex {
+		compactIndex = snapshotIndex
+	}
+	return compactIndex
+}
+
+// reset 在创建或安装快照后重新计数
+func (t *snapshotTrigger) reset(now time.Time) {
+	t.bytes = 0
+	t.last = now
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+	"go.etcd.io/etcd/server/v3/storage/wal"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestSnapshotTrigger(t *testing.T) {
+	now := time.Now()
+	tr := newSnapshotTrigger(1000, time.Minute)
+	tr.reset(now)
+
+	// 没有新条目时不触发
+	_, ok := tr.due(100, 100, 10000, now.Add(time.Hour))
+	assert.False(t, ok)
+
+	// 按条目数触发
+	reason, ok := tr.due(10200, 100, 10000, now)
+	assert.True(t, ok)
+	assert.Equal(t, "entries", reason)
+
+	// 按大小触发：条目少但单条很大
+	tr.append([]raftpb.Entry{{Index: 101, Data: make([]byte, 600)}})
+	_, ok = tr.due(101, 100, 10000, now)
+	assert.False(t, ok)
+	tr.append([]raftpb.Entry{{Index: 102, Data: make([]byte, 600)}})
+	reason, ok = tr.due(102, 100, 10000, now)
+	assert.True(t, ok)
+	assert.Equal(t, "bytes", reason)
+
+	// 按时间触发
+	tr.reset(now)
+	_, ok = tr.due(103, 102, 10000, now.Add(30*time.Second))
+	assert.False(t, ok)
+	reason, ok = tr.due(103, 102, 10000, now.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, "interval", reason)
+
+	// 按大小触发时只保留上次快照以来的日志
+	assert.Equal(t, uint64(102), tr.compactIndex(103, 102))
+	assert.Equal(t, uint64(20000), tr.compactIndex(30000, 102))
+	assert.Equal(t, uint64(1), newSnapshotTrigger(0, 0).compactIndex(103, 102))
+}
+
+// TestSnapshotWaitsForQueuedCommits 快照触发时 commitC 中还有排队的 commit，
+// 快照要等所有 commit 应用完，且状态机的 applied index 等于快照的 index
+func TestSnapshotWaitsForQueuedCommits(t *testing.T) {
+	dir := t.TempDir()
+	w, err := wal.Create(newLogger(), dir+"/wal", nil)
+	require.NoError(t, err)
+	defer w.Close()
+
+	commitC := make(chan *commit, 8)
+	var applied atomic.Uint64
+	var snapshotAt atomic.Uint64
+	rc := &raftNode{
+		commitC:     commitC,
+		stopc:       make(chan struct{}),
+		raftStorage: raft.NewMemoryStorage(),
+		wal:         w,
+		snapshotter: snap.New(newLogger(), t.TempDir()),
+		snapCount:   3,
+		snapTrigger: newSnapshotTrigger(0, 0),
+		getSnapshot: func() ([]byte, error) {
+			snapshotAt.Store(applied.Load())
+			return []byte("state"), nil
+		},
+		logger: newLogger(),
+		cfg:    config.DefaultConfig(1, 1, ":2379"),
+	}
+
+	// 三个 commit 排队等待应用，最后一个条目是空条目（如 leader 当选后的 no-op），
+	// 不交给状态机，此时状态机的 applied index 到不了 4，快照要推迟
+	ents := []raftpb.Entry{
+		{Term: 1, Index: 1, Data: []byte("a")},
+		{Term: 1, Index: 2, Data: []byte("b")},
+		{Term: 1, Index: 3, Data: []byte("c")},
+		{Term: 1, Index: 4},
+		{Term: 1, Index: 5, Data: []byte("d")},
+	}
+	require.NoError(t, rc.raftStorage.Append(ents))
+	for i := range ents[:4] {
+		_, ok := rc.publishEntries(ents[i : i+1])
+		require.True(t, ok)
+	}
+	require.Len(t, commitC, 3)
+	rc.maybeTriggerSnapshot()
+	assert.Equal(t, uint64(0), rc.snapshotIndex)
+
+	_, ok := rc.publishEntries(ents[4:])
+	require.True(t, ok)
+	require.Len(t, commitC, 4)
+
+	done := make(chan struct{})
+	go func() {
+		rc.maybeTriggerSnapshot()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("snapshot taken before the queued commits were applied")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for range 4 {
+		c := <-commitC
+		applied.Store(c.Index)
+		close(c.ApplyDoneC)
+	}
+	<-done
+	assert.Equal(t, uint64(5), snapshotAt.Load())
+	assert.Equal(t, uint64(5), rc.snapshotIndex)
+}
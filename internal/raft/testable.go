@@ -32,3 +32,19 @@ var _ TestableNode = (*raftNode)(nil)
 
 // Ensure raftNodeRocks implements TestableNode
 var _ TestableNode = (*raftNodeRocks)(nil)
+
+// Ensure ephemeralNode implements EphemeralNode
+var _ EphemeralNode = (*ephemeralNode)(nil)
+
+// PartitionableNode interface allows tests to isolate a node from its peers
+type PartitionableNode interface {
+	TestableNode
+	PauseTransport()
+	ResumeTransport()
+}
+
+// Ensure raft-backed nodes implement PartitionableNode
+var (
+	_ PartitionableNode = (*raftNode)(nil)
+	_ PartitionableNode = (*raftNodeRocks)(nil)
+)
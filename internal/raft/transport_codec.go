@@ -0,0 +1,263 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"bytes"
+	"fmt"
+
+	"metaStore/pkg/config"
+	"metaStore/pkg/reliability"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// 压缩消息通过 Message.Context 标记（MsgApp/MsgSnap 不使用该字段）
+// 标记后每个非空的 Entry.Data 和 Snapshot.Data 都带有 1 字节头：0 表示原始数据，1 表示已压缩
+var compressedMagic = []byte("\x00msz")
+
+const (
+	codecSnappy byte = 1
+	codecZstd   byte = 2
+
+	payloadRaw        byte = 0
+	payloadCompressed byte = 1
+)
+
+// messageCodec 在 rafthttp 之外对 peer 消息做压缩和合并
+// 发送端在 transport.Send 之前调用 encode，接收端在 Process 中调用 decode
+// decode 总是可用，与本节点是否开启压缩无关
+type messageCodec struct {
+	codec         byte // 0 表示不压缩
+	minSize       int
+	batch         bool
+	batchMaxBytes uint64
+
+	zenc *zstd.Encoder
+	zdec *zstd.Decoder
+}
+
+func newMessageCodec(cfg config.RaftTransportConfig) (*messageCodec, error) {
+	c := &messageCodec{
+		minSize:       cfg.CompressMinSize,
+		batch:         cfg.BatchMessages,
+		batchMaxBytes: cfg.BatchMaxBytes,
+	}
+
+	var err error
+	if c.zdec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Compression {
+	case "", config.CompressionNone:
+	case config.CompressionSnappy:
+		c.codec = codecSnappy
+	case config.CompressionZstd:
+		c.codec = codecZstd
+		if c.zenc, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown raft transport compression %q", cfg.Compression)
+	}
+	return c, nil
+}
+
+// encode 合并并压缩待发送的消息，不修改 raft 持有的日志条目和快照
+func (c *messageCodec) encode(msgs []raftpb.Message) []raftpb.Message {
+	if c.batch {
+		msgs = batchMessages(msgs, c.batchMaxBytes)
+	}
+	if c.codec == 0 {
+		return msgs
+	}
+	for i := range msgs {
+		c.compress(&msgs[i])
+	}
+	return msgs
+}
+
+// compress 压缩 MsgApp 的日志条目或 MsgSnap 的快照数据
+func (c *messageCodec) compress(m *raftpb.Message) {
+	if len(m.Context) != 0 {
+		return
+	}
+	switch m.Type {
+	case raftpb.MsgApp:
+		if !c.worthCompressing(m) {
+			return
+		}
+		ents := make([]raftpb.Entry, len(m.Entries))
+		copy(ents, m.Entries)
+		for i := range ents {
+			ents[i].Data = c.compressPayload(ents[i].Data)
+		}
+		m.Entries = ents
+	case raftpb.MsgSnap:
+		if m.Snapshot == nil || len(m.Snapshot.Data) == 0 {
+			return
+		}
+		snap := *m.Snapshot
+		snap.Data = c.compressPayload(snap.Data)
+		m.Snapshot = &snap
+	default:
+		return
+	}
+	m.Context = append(append([]byte{}, compressedMagic...), c.codec)
+}
+
+// worthCompressing 至少有一个条目达到压缩阈值时才压缩整条消息
+func (c *messageCodec) worthCompressing(m *raftpb.Message) bool {
+	for i := range m.Entries {
+		if len(m.Entries[i].Data) >= c.minSize && len(m.Entries[i].Data) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *messageCodec) compressPayload(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	if len(data) >= c.minSize {
+		var out []byte
+		switch c.codec {
+		case codecSnappy:
+			out = snappy.Encode(nil, data)
+		case codecZstd:
+			out = c.zenc.EncodeAll(data, nil)
+		}
+		// 不可压缩的数据保持原样，避免反而变大
+		if len(out) < len(data) {
+			return append([]byte{payloadCompressed}, out...)
+		}
+	}
+	return append([]byte{payloadRaw}, data...)
+}
+
+// decode 还原对端压缩过的消息，未压缩的消息原样返回
+func (c *messageCodec) decode(m raftpb.Message) (raftpb.Message, error) {
+	if len(m.Context) != len(compressedMagic)+1 || !bytes.HasPrefix(m.Context, compressedMagic) {
+		return m, nil
+	}
+	codec := m.Context[len(compressedMagic)]
+	m.Context = nil
+
+	var err error
+	switch m.Type {
+	case raftpb.MsgApp:
+		for i := range m.Entries {
+			if m.Entries[i].Data, err = c.decompressPayload(codec, m.Entries[i].Data); err != nil {
+				return m, fmt.Errorf("decompress entry %d: %w", m.Entries[i].Index, err)
+			}
+		}
+	case raftpb.MsgSnap:
+		if m.Snapshot != nil {
+			if m.Snapshot.Data, err = c.decompressPayload(codec, m.Snapshot.Data); err != nil {
+				return m, fmt.Errorf("decompress snapshot: %w", err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// verifySnapshot 在安装之前校验 MsgSnap 中快照数据的 CRC。Process 拒绝损坏的快照，
+// 发送方收到失败后会重新发送
+func verifySnapshot(m raftpb.Message) error {
+	if m.Type != raftpb.MsgSnap || m.Snapshot == nil {
+		return nil
+	}
+	if _, err := reliability.OpenSnapshot(m.Snapshot.Data); err != nil {
+		return fmt.Errorf("reject snapshot %d from %x: %w", m.Snapshot.Metadata.Index, m.From, err)
+	}
+	return nil
+}
+
+func (c *messageCodec) decompressPayload(codec byte, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	switch data[0] {
+	case payloadRaw:
+		return data[1:], nil
+	case payloadCompressed:
+	default:
+		return nil, fmt.Errorf("unknown payload header %d", data[0])
+	}
+
+	switch codec {
+	case codecSnappy:
+		return snappy.Decode(nil, data[1:])
+	case codecZstd:
+		return c.zdec.DecodeAll(data[1:], nil)
+	default:
+		return nil, fmt.Errorf("unknown codec %d", codec)
+	}
+}
+
+// batchMessages 合并发往同一 peer 的连续 MsgApp
+// 只有后一条消息紧接前一条的最后一个条目时才合并，合并后与依次处理两条消息等价
+// 每个 peer 的消息顺序保持不变
+func batchMessages(msgs []raftpb.Message, maxBytes uint64) []raftpb.Message {
+	if len(msgs) < 2 {
+		return msgs
+	}
+
+	out := make([]raftpb.Message, 0, len(msgs))
+	last := make(map[uint64]int) // peer -> 发往该 peer 的最后一条消息在 out 中的位置
+	for _, m := range msgs {
+		if i, ok := last[m.To]; ok && canMerge(&out[i], &m, maxBytes) {
+			merged := out[i]
+			ents := make([]raftpb.Entry, 0, len(merged.Entries)+len(m.Entries))
+			merged.Entries = append(append(ents, merged.Entries...), m.Entries...)
+			merged.Commit = m.Commit
+			out[i] = merged
+			continue
+		}
+		last[m.To] = len(out)
+		out = append(out, m)
+	}
+	return out
+}
+
+func canMerge(a, b *raftpb.Message, maxBytes uint64) bool {
+	if a.Type != raftpb.MsgApp || b.Type != raftpb.MsgApp {
+		return false
+	}
+	if a.From != b.From || a.Term != b.Term || len(a.Context) != 0 || len(b.Context) != 0 {
+		return false
+	}
+
+	lastIndex, lastTerm := a.Index, a.LogTerm
+	if n := len(a.Entries); n > 0 {
+		lastIndex, lastTerm = a.Entries[n-1].Index, a.Entries[n-1].Term
+	}
+	if b.Index != lastIndex || b.LogTerm != lastTerm {
+		return false
+	}
+	return entriesSize(a.Entries)+entriesSize(b.Entries) <= maxBytes
+}
+
+func entriesSize(ents []raftpb.Entry) uint64 {
+	var size uint64
+	for i := range ents {
+		size += uint64(ents[i].Size())
+	}
+	return size
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"bytes"
+	"testing"
+
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestMessageCodecRoundTrip(t *testing.T) {
+	big := bytes.Repeat([]byte("metastore-key=value;"), 100)
+	small := []byte("tiny")
+
+	for _, compression := range []string{config.CompressionSnappy, config.CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			sender, err := newMessageCodec(config.RaftTransportConfig{Compression: compression, CompressMinSize: 64})
+			require.NoError(t, err)
+			// 接收端未开启压缩也能解码
+			receiver, err := newMessageCodec(config.RaftTransportConfig{})
+			require.NoError(t, err)
+
+			ents := []raftpb.Entry{
+				{Index: 1, Term: 1, Data: big},
+				{Index: 2, Term: 1, Data: small},
+				{Index: 3, Term: 1},
+			}
+			snap := &raftpb.Snapshot{Data: big, Metadata: raftpb.SnapshotMetadata{Index: 3, Term: 1}}
+			msgs := []raftpb.Message{
+				{Type: raftpb.MsgApp, To: 2, Entries: ents},
+				{Type: raftpb.MsgSnap, To: 3, Snapshot: snap},
+				{Type: raftpb.MsgHeartbeat, To: 2, Context: []byte("read-index")},
+			}
+
+			out := sender.encode(msgs)
+			require.Len(t, out, 3)
+			assert.Less(t, len(out[0].Entries[0].Data), len(big), "large entries are compressed")
+			assert.Less(t, len(out[1].Snapshot.Data), len(big), "snapshot data is compressed")
+			assert.Equal(t, []byte("read-index"), out[2].Context, "other messages are untouched")
+
+			// raft 持有的日志条目和快照不能被修改
+			assert.Equal(t, big, ents[0].Data)
+			assert.Equal(t, big, snap.Data)
+
+			for i, m := range out {
+				got, err := receiver.decode(m)
+				require.NoError(t, err)
+				switch i {
+				case 0:
+					assert.Nil(t, got.Context)
+					assert.Equal(t, big, got.Entries[0].Data)
+					assert.Equal(t, small, got.Entries[1].Data)
+					assert.Empty(t, got.Entries[2].Data)
+				case 1:
+					assert.Equal(t, big, got.Snapshot.Data)
+				case 2:
+					assert.Equal(t, []byte("read-index"), got.Context)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageCodecSkipsSmallEntries(t *testing.T) {
+	c, err := newMessageCodec(config.RaftTransportConfig{Compression: config.CompressionZstd, CompressMinSize: 1024})
+	require.NoError(t, err)
+
+	m := raftpb.Message{Type: raftpb.MsgApp, Entries: []raftpb.Entry{{Index: 1, Data: []byte("small")}}}
+	out := c.encode([]raftpb.Message{m})
+	assert.Nil(t, out[0].Context)
+	assert.Equal(t, []byte("small"), out[0].Entries[0].Data)
+}
+
+func TestBatchMessages(t *testing.T) {
+	app := func(to, index, logTerm, commit uint64, ents ...uint64) raftpb.Message {
+		m := raftpb.Message{Type: raftpb.MsgApp, From: 1, To: to, Term: 2, Index: index, LogTerm: logTerm, Commit: commit}
+		for _, i := range ents {
+			m.Entries = append(m.Entries, raftpb.Entry{Index: i, Term: 2, Data: []byte("x")})
+		}
+		return m
+	}
+
+	msgs := []raftpb.Message{
+		app(2, 10, 2, 10, 11, 12),
+		app(3, 10, 2, 10, 11, 12),
+		app(2, 12, 2, 12, 13),                       // 紧接上一条，合并
+		{Type: raftpb.MsgHeartbeat, From: 1, To: 3}, // 打断发往 3 的连续 MsgApp
+		app(3, 12, 2, 12, 13),
+		app(2, 20, 2, 12, 21), // 不连续，不合并
+	}
+
+	out := batchMessages(msgs, 1<<20)
+	require.Len(t, out, 5)
+
+	assert.Equal(t, uint64(2), out[0].To)
+	assert.Len(t, out[0].Entries, 3)
+	assert.Equal(t, uint64(13), out[0].Entries[2].Index)
+	assert.Equal(t, uint64(12), out[0].Commit)
+
+	assert.Equal(t, uint64(3), out[1].To)
+	assert.Len(t, out[1].Entries, 2)
+	assert.Equal(t, raftpb.MsgHeartbeat, out[2].Type)
+	assert.Equal(t, uint64(3), out[3].To)
+	assert.Equal(t, uint64(20), out[4].Index)
+
+	// 原消息的条目切片不被修改
+	assert.Len(t, msgs[0].Entries, 2)
+
+	// 超过大小上限时不合并
+	assert.Len(t, batchMessages(msgs[:3], 1), 3)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"encoding/binary"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// appliedIndexKey holds the raft index of the last commit applied to the state
+// machine. It is written in the same batch as the mutations of the commit, so
+// after a crash the state machine and the index agree and entries replayed up
+// to the index are skipped instead of being applied twice
+const appliedIndexKey = "meta:applied_index"
+
+// putProgress adds the applied index, the current revision and the timestamp of
+// the last applied operation to batch (called under applyMu). index 0 means
+// unknown and is not recorded
+func (r *RocksDB) putProgress(batch *grocksdb.WriteBatch, index uint64) {
+	if index != 0 {
+		batch.Put([]byte(appliedIndexKey), binary.BigEndian.AppendUint64(nil, index))
+	}
+	batch.Put([]byte(revisionKey), binary.LittleEndian.AppendUint64(nil, uint64(r.CurrentRevision())))
+	r.putHLC(batch)
+}
+
+// saveProgress persists the progress of a commit whose operations were not
+// written in a batch of their own (called under applyMu)
+func (r *RocksDB) saveProgress(index uint64) error {
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+
+	r.putProgress(batch, index)
+	if err := r.writeBatch(batch); err != nil {
+		return err
+	}
+	r.durableIndex = max(r.durableIndex, index)
+	return nil
+}
+
+// loadAppliedIndex loads the raft index of the last applied commit from DB, 0
+// for stores written by older versions
+func (r *RocksDB) loadAppliedIndex() uint64 {
+	data, err := r.db.Get(r.ro, []byte(appliedIndexKey))
+	if err != nil {
+		return 0
+	}
+	defer data.Free()
+
+	if data.Size() != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data.Data())
+}
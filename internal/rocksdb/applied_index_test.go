@@ -0,0 +1,77 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+)
+
+func TestApplyCommit_SkipsApplied(t *testing.T) {
+	dir := t.TempDir()
+	snapDir := filepath.Join(dir, "snap")
+	require.NoError(t, os.MkdirAll(snapDir, 0755))
+
+	// open 打开同一个目录，模拟进程重启
+	open := func() (*RocksDB, chan *kvstore.Commit, func()) {
+		db, err := Open(filepath.Join(dir, "db"))
+		require.NoError(t, err)
+		commitC := make(chan *kvstore.Commit)
+		errorC := make(chan error)
+		store := NewRocksDB(db, snap.New(nil, snapDir), make(chan string), commitC, errorC)
+		return store, commitC, func() {
+			close(commitC)
+			close(errorC)
+			store.Close()
+			db.Close()
+		}
+	}
+	apply := func(commitC chan *kvstore.Commit, key string, index uint64) {
+		data, err := marshalRaftOperation(&RaftOperation{Type: "PUT", Key: key, Value: "v"})
+		require.NoError(t, err)
+		applyDoneC := make(chan struct{})
+		commitC <- &kvstore.Commit{Data: []string{string(data)}, ApplyDoneC: applyDoneC, Index: index}
+		<-applyDoneC
+	}
+
+	store, commitC, closeStore := open()
+	apply(commitC, "a", 5)
+	assert.Equal(t, int64(1), store.CurrentRevision())
+	assert.Equal(t, uint64(5), store.loadAppliedIndex())
+
+	apply(commitC, "b", 6)
+	assert.Equal(t, int64(2), store.CurrentRevision())
+	assert.Equal(t, uint64(6), store.loadAppliedIndex())
+	closeStore()
+
+	// 重启后回放已经应用的 entry 不会重复应用
+	store, commitC, closeStore = open()
+	defer closeStore()
+	assert.Equal(t, uint64(6), store.WriteIndex())
+	apply(commitC, "a", 5)
+	apply(commitC, "b", 6)
+	assert.Equal(t, int64(2), store.CurrentRevision())
+
+	apply(commitC, "c", 7)
+	assert.Equal(t, int64(3), store.CurrentRevision())
+	assert.Equal(t, uint64(7), store.loadAppliedIndex())
+}
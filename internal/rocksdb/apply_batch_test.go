@@ -0,0 +1,166 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/chaos"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+)
+
+// applyUnlocked applies one operation as a commit of its own, bypassing Raft
+func (r *RocksDB) applyUnlocked(op RaftOperation) error {
+	b := r.newApplyBatch()
+	defer b.Destroy()
+	r.applyOperation(b, &op)
+	return r.writeApplyBatch(b, 0)
+}
+
+func (r *RocksDB) putUnlocked(key, value string, leaseID int64) error {
+	return r.applyUnlocked(RaftOperation{Type: "PUT", Key: key, Value: value, LeaseID: leaseID})
+}
+
+func (r *RocksDB) deleteUnlocked(key, rangeEnd string) error {
+	return r.applyUnlocked(RaftOperation{Type: "DELETE", Key: key, RangeEnd: rangeEnd})
+}
+
+func (r *RocksDB) leaseGrantUnlocked(id, ttl int64) error {
+	return r.applyUnlocked(RaftOperation{Type: "LEASE_GRANT", LeaseID: id, TTL: ttl})
+}
+
+func (r *RocksDB) leaseRevokeUnlocked(id int64) error {
+	return r.applyUnlocked(RaftOperation{Type: "LEASE_REVOKE", LeaseID: id})
+}
+
+// 事务在同一个 commit 中读到前面操作的写入
+func TestApplyBatch_TxnSeesEarlierWrites(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	require.NoError(t, store.putUnlocked("a", "v1", 0))
+
+	b := store.newApplyBatch()
+	defer b.Destroy()
+	store.applyOperation(b, &RaftOperation{Type: "PUT", Key: "a", Value: "v2"})
+	store.applyOperation(b, &RaftOperation{Type: "DELETE", Key: "b/", RangeEnd: "b0"})
+	resp, err := store.txnUnlocked(b,
+		[]kvstore.Compare{{Key: []byte("a"), Target: kvstore.CompareValue, Result: kvstore.CompareEqual,
+			TargetUnion: kvstore.CompareUnion{Value: []byte("v2")}}},
+		[]kvstore.Op{
+			{Type: kvstore.OpPut, Key: []byte("b/1"), Value: []byte("v1")},
+			{Type: kvstore.OpRange, Key: []byte("a"), RangeEnd: []byte("c")},
+		}, nil)
+	require.NoError(t, err)
+	assert.True(t, resp.Succeeded)
+	require.Len(t, resp.Responses[1].RangeResp.Kvs, 2)
+	assert.Equal(t, "v2", string(resp.Responses[1].RangeResp.Kvs[0].Value))
+	assert.Equal(t, "b/1", string(resp.Responses[1].RangeResp.Kvs[1].Key))
+
+	// 写入前数据库中没有这个 commit 的任何修改
+	kv, err := store.getKeyValue("a")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(kv.Value))
+
+	require.NoError(t, store.writeApplyBatch(b, 0))
+	got, err := store.Range(kvstore.WithSerializable(context.Background()), "a", "c", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, got.Kvs, 2)
+	assert.Equal(t, int64(3), got.Revision)
+}
+
+// 写入中途崩溃后重放 TXN commit，事务只生效一次，且与 applied index 一致
+func TestApplyCommit_TxnCrashReplay(t *testing.T) {
+	dir := t.TempDir()
+	snapDir := filepath.Join(dir, "snap")
+	require.NoError(t, os.MkdirAll(snapDir, 0755))
+
+	open := func() (*RocksDB, chan *kvstore.Commit, func()) {
+		db, err := Open(filepath.Join(dir, "db"))
+		require.NoError(t, err)
+		commitC := make(chan *kvstore.Commit)
+		errorC := make(chan error)
+		store := NewRocksDB(db, snap.New(nil, snapDir), make(chan string), commitC, errorC)
+		return store, commitC, func() {
+			close(commitC)
+			close(errorC)
+			store.Close()
+			db.Close()
+		}
+	}
+	apply := func(commitC chan *kvstore.Commit, op *RaftOperation, index uint64) {
+		data, err := marshalRaftOperation(op)
+		require.NoError(t, err)
+		applyDoneC := make(chan struct{})
+		commitC <- &kvstore.Commit{Data: []string{string(data)}, ApplyDoneC: applyDoneC, Index: index}
+		<-applyDoneC
+	}
+	// 计数器自增：a 的版本为 1 时把 a 改成 2 并写入 b
+	txn := &RaftOperation{
+		Type: "TXN",
+		Compares: []kvstore.Compare{{Key: []byte("a"), Target: kvstore.CompareVersion,
+			Result: kvstore.CompareEqual, TargetUnion: kvstore.CompareUnion{Version: 1}}},
+		ThenOps: []kvstore.Op{
+			{Type: kvstore.OpPut, Key: []byte("a"), Value: []byte("2")},
+			{Type: kvstore.OpPut, Key: []byte("b"), Value: []byte("1")},
+		},
+	}
+
+	store, commitC, closeStore := open()
+	apply(commitC, &RaftOperation{Type: "PUT", Key: "a", Value: "1"}, 5)
+
+	// 写入 TXN commit 时崩溃：事务的写入和 applied index 都没有落盘。applyCommit
+	// 写入失败时停止进程，这里直接写入 commit 的 batch 模拟
+	batch := store.newApplyBatch()
+	store.applyOperation(batch, txn)
+	chaos.Reset()
+	chaos.Enable()
+	chaos.Set(chaos.RocksDBWrite, chaos.Rule{ErrorRate: 1, Count: 1})
+	err := store.writeApplyBatch(batch, 6)
+	chaos.Disable()
+	chaos.Reset()
+	batch.Destroy()
+	require.ErrorIs(t, err, chaos.ErrInjected)
+	assert.Equal(t, uint64(5), store.loadAppliedIndex())
+	missing, err := store.getKeyValue("b")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+	closeStore()
+
+	// 重启后从 applied index 之后重放
+	store, commitC, closeStore = open()
+	defer closeStore()
+	apply(commitC, &RaftOperation{Type: "PUT", Key: "a", Value: "1"}, 5)
+	apply(commitC, txn, 6)
+	assert.Equal(t, uint64(6), store.loadAppliedIndex())
+	assert.Equal(t, int64(3), store.CurrentRevision())
+
+	// 再次重放不会重复应用
+	apply(commitC, txn, 6)
+	assert.Equal(t, int64(3), store.CurrentRevision())
+	a, err := store.getKeyValue("a")
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(a.Value))
+	assert.Equal(t, int64(2), a.Version)
+	b, err := store.getKeyValue("b")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(b.Value))
+}
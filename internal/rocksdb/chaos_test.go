@@ -0,0 +1,53 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"testing"
+
+	"metaStore/pkg/chaos"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RocksDBWrite faults must cover every state machine write, not only puts
+func TestRocksDB_FaultInjectionCoversWrites(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	require.NoError(t, store.putUnlocked("key", "value", 0))
+	require.NoError(t, store.leaseGrantUnlocked(1, 60))
+
+	chaos.Reset()
+	chaos.Enable()
+	defer func() {
+		chaos.Disable()
+		chaos.Reset()
+	}()
+
+	writes := map[string]func() error{
+		"put":         func() error { return store.putUnlocked("key", "value2", 0) },
+		"delete":      func() error { return store.deleteUnlocked("key", "") },
+		"leaseGrant":  func() error { return store.leaseGrantUnlocked(2, 60) },
+		"leaseRevoke": func() error { return store.leaseRevokeUnlocked(1) },
+		"compact":     func() error { return store.applyUnlocked(RaftOperation{Type: "COMPACT", Revision: 1}) },
+	}
+	for name, write := range writes {
+		chaos.Set(chaos.RocksDBWrite, chaos.Rule{ErrorRate: 1, Count: 1})
+		assert.ErrorIs(t, write(), chaos.ErrInjected, name)
+	}
+	assert.Equal(t, int64(len(writes)), chaos.Hits()[chaos.RocksDBWrite])
+}
@@ -0,0 +1,610 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
+
+	"github.com/linxGnu/grocksdb"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// With checkpoint bootstrap a raft snapshot does not carry the state machine.
+// It carries a descriptor of a RocksDB checkpoint the sender created at the
+// snapshot index, and the receiver downloads the files of the checkpoint from
+// the peer URL of the sender, then ingests its state machine as SST files.
+// Checkpoints hard link the SST files of the DB, so creating one costs a
+// memtable flush instead of encoding every key, and large state machines are
+// transferred file by file, resumable and rate limited, instead of as one message.
+const (
+	// CheckpointPathPrefix is where the raft listener serves checkpoint files:
+	// <prefix><index>/<file>
+	CheckpointPathPrefix = "/raft/checkpoint/"
+
+	// checkpointsDir holds the checkpoints of this member, checkpointDownloadsDir
+	// the checkpoints being downloaded and the SST files built from them. Both
+	// are inside the DB directory, so checkpoints can hard link its files
+	checkpointsDir         = "checkpoints"
+	checkpointDownloadsDir = "checkpoint-downloads"
+
+	// checkpointSSTSize target size of the SST files ingested on recovery
+	checkpointSSTSize = 256 << 20
+
+	// checkpointFetchAttempts attempts per file before recovery fails, each
+	// resuming where the previous one stopped
+	checkpointFetchAttempts = 10
+
+	// checkpointIdleTimeout a download receiving nothing for this long is retried
+	checkpointIdleTimeout = 30 * time.Second
+)
+
+// checkpointSnapshotMagic prefixes snapshot data holding a checkpoint descriptor
+// instead of the state machine. Like compressedSnapshotMagic it starts with a
+// zero byte, which a gob stream never does
+var checkpointSnapshotMagic = []byte("\x00msckpt")
+
+// errCheckpointGone the sender no longer has the checkpoint
+var errCheckpointGone = errors.New("checkpoint no longer exists on the sender")
+
+// checkpointDescriptor the data of a raft snapshot referring to a checkpoint
+type checkpointDescriptor struct {
+	Index uint64           `json:"index"` // Applied index the checkpoint was created at
+	URL   string           `json:"url"`   // Peer URL of the member serving the files
+	Files []checkpointFile `json:"files"`
+}
+
+type checkpointFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// checkpointSource settings of the member creating checkpoints for snapshots
+type checkpointSource struct {
+	url       string
+	retention time.Duration
+}
+
+// SetCheckpointBootstrap configures raft snapshots taken by RaftSnapshot.
+// peerURL is the raft URL of this member, where receivers download checkpoint
+// files from. Receivers always understand checkpoint snapshots, whether or not
+// bootstrap is enabled on them
+func (r *RocksDB) SetCheckpointBootstrap(peerURL string, cfg config.CheckpointBootstrapConfig) {
+	if !cfg.Enable {
+		r.checkpoints.Store(nil)
+		return
+	}
+	r.checkpoints.Store(&checkpointSource{url: peerURL, retention: cfg.Retention})
+}
+
+// RaftSnapshot returns the data of a raft snapshot: the descriptor of a new
+// checkpoint with checkpoint bootstrap, otherwise the state machine as GetSnapshot
+func (r *RocksDB) RaftSnapshot() ([]byte, error) {
+	src := r.checkpoints.Load()
+	if src == nil {
+		return r.GetSnapshot()
+	}
+	desc, err := r.createCheckpoint(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	data, err := json.Marshal(desc)
+	if err != nil {
+		return nil, err
+	}
+	return reliability.SealSnapshot(append(append([]byte{}, checkpointSnapshotMagic...), data...)), nil
+}
+
+// createCheckpoint creates a checkpoint of the DB at the applied index, unless
+// one exists already, and removes the checkpoints past their retention
+func (r *RocksDB) createCheckpoint(src *checkpointSource) (*checkpointDescriptor, error) {
+	root := filepath.Join(r.db.Name(), checkpointsDir)
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+
+	// The checkpoint flushes the memtable, nothing is applied meanwhile so the
+	// state machine matches the applied index
+	r.applyMu.Lock()
+	index := r.applied.Applied()
+	dir := filepath.Join(root, strconv.FormatUint(index, 10))
+	var err error
+	if _, statErr := os.Stat(dir); errors.Is(statErr, os.ErrNotExist) {
+		err = writeCheckpoint(r.db, dir)
+	}
+	r.applyMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	pruneCheckpoints(root, filepath.Base(dir), src.retention)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	desc := &checkpointDescriptor{Index: index, URL: src.url}
+	var size int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode().IsRegular() {
+			desc.Files = append(desc.Files, checkpointFile{Name: e.Name(), Size: info.Size()})
+			size += info.Size()
+		}
+	}
+	log.Info("Created checkpoint for raft snapshot",
+		zap.Uint64("index", index),
+		zap.Int("files", len(desc.Files)),
+		zap.Int64("bytes", size),
+		zap.String("component", "storage-rocksdb"))
+	return desc, nil
+}
+
+// writeCheckpoint creates a checkpoint in dir through a temporary directory, so
+// dir only ever holds complete checkpoints
+func writeCheckpoint(db *grocksdb.DB, dir string) error {
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	cp, err := db.NewCheckpoint()
+	if err != nil {
+		return err
+	}
+	defer cp.Destroy()
+	if err := cp.CreateCheckpoint(tmp, 0); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// pruneCheckpoints removes the checkpoints created longer than retention ago
+// and leftovers of interrupted ones, always keeping the newest
+func pruneCheckpoints(root, newest string, retention time.Duration) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.Name() == newest {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if strings.HasSuffix(e.Name(), ".tmp") || time.Since(info.ModTime()) > retention {
+			if err := os.RemoveAll(filepath.Join(root, e.Name())); err != nil {
+				log.Warn("Failed to remove checkpoint", zap.String("checkpoint", e.Name()), zap.Error(err),
+					zap.String("component", "storage-rocksdb"))
+			}
+		}
+	}
+}
+
+// CheckpointHandler serves the checkpoint files of db under CheckpointPathPrefix
+// with Range support, so interrupted downloads resume, at most rateLimit bytes
+// per second over all downloads (0 means unlimited). Other requests go to next
+func CheckpointHandler(db *grocksdb.DB, rateLimit int64, next http.Handler) http.Handler {
+	var limiter *rate.Limiter
+	if rateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(rateLimit), int(min(rateLimit, 1<<20)))
+	}
+	root := filepath.Join(db.Name(), checkpointsDir)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, CheckpointPathPrefix) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		index, name, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, CheckpointPathPrefix), "/")
+		if _, err := strconv.ParseUint(index, 10, 64); err != nil || !ok || !validCheckpointFile(name) {
+			http.NotFound(w, req)
+			return
+		}
+		f, err := os.Open(filepath.Join(root, index, name))
+		if err != nil {
+			http.NotFound(w, req)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			http.NotFound(w, req)
+			return
+		}
+		http.ServeContent(&limitedResponseWriter{ResponseWriter: w, ctx: req.Context(), limiter: limiter},
+			req, name, info.ModTime(), f)
+	})
+}
+
+// validCheckpointFile reports whether name can be a file of a checkpoint
+// directory, rejecting anything that would leave it
+func validCheckpointFile(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".")
+}
+
+// limitedResponseWriter writes the response body at the rate of limiter
+type limitedResponseWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	limiter *rate.Limiter // nil for no limit
+}
+
+func (w *limitedResponseWriter) Write(b []byte) (int, error) {
+	if w.limiter == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	written := 0
+	for len(b) > 0 {
+		n := min(len(b), w.limiter.Burst())
+		if err := w.limiter.WaitN(w.ctx, n); err != nil {
+			return written, err
+		}
+		n, err := w.ResponseWriter.Write(b[:n])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// recoverFromCheckpoint replaces the state machine with the one in the
+// checkpoint described by data, see recoverFromSnapshot
+//
+// A member recovering from a snapshot it created itself reads its own
+// checkpoint, others download it first. The state machine range is then
+// deleted and the SST files built from the checkpoint are ingested. A crash in
+// between leaves no applied index, so the snapshot is recovered again on restart.
+func (r *RocksDB) recoverFromCheckpoint(data []byte, index uint64) error {
+	start := time.Now()
+
+	var desc checkpointDescriptor
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return fmt.Errorf("invalid checkpoint descriptor: %w", err)
+	}
+	// checkpoint 在其他 index 创建时导入后状态机与 applied index 不一致
+	if desc.Index != index {
+		return fmt.Errorf("checkpoint created at applied index %d does not match the snapshot index %d", desc.Index, index)
+	}
+	for _, f := range desc.Files {
+		if !validCheckpointFile(f.Name) {
+			return fmt.Errorf("invalid checkpoint file name %q", f.Name)
+		}
+	}
+
+	name := strconv.FormatUint(desc.Index, 10)
+	downloads := filepath.Join(r.db.Name(), checkpointDownloadsDir)
+	dir := filepath.Join(r.db.Name(), checkpointsDir, name)
+	downloaded := !checkpointComplete(dir, desc.Files)
+	if downloaded {
+		dir = filepath.Join(downloads, name)
+		if err := fetchCheckpoint(dir, &desc); err != nil {
+			return err
+		}
+	}
+
+	sstDir := filepath.Join(downloads, "sst-"+name)
+	keys, err := r.ingestCheckpoint(dir, sstDir, index)
+	os.RemoveAll(sstDir)
+	if err != nil {
+		return err
+	}
+	if downloaded {
+		// Also drops downloads of checkpoints that were never completed
+		os.RemoveAll(downloads)
+	}
+	r.resetRecoveredState(index)
+
+	var size int64
+	for _, f := range desc.Files {
+		size += f.Size
+	}
+	log.Info("Recovered state machine from checkpoint",
+		zap.Uint64("index", index),
+		zap.Uint64("checkpoint_index", desc.Index),
+		zap.String("source", desc.URL),
+		zap.Bool("downloaded", downloaded),
+		zap.Int64("bytes", size),
+		zap.Int("keys", keys),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("component", "storage-rocksdb"))
+	return nil
+}
+
+// checkpointComplete reports whether dir holds all files of a checkpoint
+func checkpointComplete(dir string, files []checkpointFile) bool {
+	for _, f := range files {
+		info, err := os.Stat(filepath.Join(dir, f.Name))
+		if err != nil || info.Size() != f.Size {
+			return false
+		}
+	}
+	return len(files) > 0
+}
+
+// fetchCheckpoint downloads the files of a checkpoint into dir. Files already
+// complete are skipped and partial ones resumed, also across restarts
+func fetchCheckpoint(dir string, desc *checkpointDescriptor) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	base := strings.TrimSuffix(desc.URL, "/") + CheckpointPathPrefix + strconv.FormatUint(desc.Index, 10) + "/"
+	for i, f := range desc.Files {
+		for attempt := 1; ; attempt++ {
+			err := fetchCheckpointFile(base+f.Name, filepath.Join(dir, f.Name), f.Size)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, errCheckpointGone) || attempt == checkpointFetchAttempts {
+				return fmt.Errorf("failed to download checkpoint %d from %s: %s: %w", desc.Index, desc.URL, f.Name, err)
+			}
+			log.Warn("Checkpoint download failed, resuming",
+				zap.String("file", f.Name),
+				zap.Int("attempt", attempt),
+				zap.Error(err),
+				zap.String("component", "storage-rocksdb"))
+			time.Sleep(min(time.Duration(attempt)*time.Second, 10*time.Second))
+		}
+		log.Debug("Downloaded checkpoint file",
+			zap.String("file", f.Name),
+			zap.Int64("bytes", f.Size),
+			zap.Int("done", i+1),
+			zap.Int("files", len(desc.Files)),
+			zap.String("component", "storage-rocksdb"))
+	}
+	return nil
+}
+
+// fetchCheckpointFile downloads url into path, continuing after the bytes the
+// file already has
+func fetchCheckpointFile(url, path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	have, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if have == size {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if have > 0 && have < size {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", have))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The whole file, the sender ignored the range or there was none
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		have = 0
+	case http.StatusNotFound:
+		return errCheckpointGone
+	default:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body := &idleTimeoutReader{r: resp.Body, timeout: checkpointIdleTimeout, timer: time.AfterFunc(checkpointIdleTimeout, cancel)}
+	defer body.timer.Stop()
+	n, err := io.Copy(f, body)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if have+n != size {
+		return fmt.Errorf("received %d of %d bytes", have+n, size)
+	}
+	return nil
+}
+
+// idleTimeoutReader cancels the request through timer when a read has not
+// returned anything for timeout, so a stalled connection fails and is resumed
+type idleTimeoutReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func (r *idleTimeoutReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// ingestCheckpoint replaces the state machine with the one in the checkpoint
+// in dir, building the SST files to ingest in sstDir. The applied index of the
+// checkpoint is replaced with index. It returns the number of keys ingested
+func (r *RocksDB) ingestCheckpoint(dir, sstDir string, index uint64) (int, error) {
+	opts := grocksdb.NewDefaultOptions()
+	defer opts.Destroy()
+	src, err := grocksdb.OpenDbForReadOnly(opts, dir, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	defer src.Close()
+
+	if err := os.RemoveAll(sstDir); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(sstDir, 0o750); err != nil {
+		return 0, err
+	}
+	w := &sstSplitter{dir: sstDir, opts: opts}
+	defer w.close()
+
+	ro := grocksdb.NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetFillCache(false)
+	ro.SetIterateUpperBound([]byte(stateMachineEnd))
+	it := src.NewIterator(ro)
+	defer it.Close()
+
+	// The state machine is at the snapshot index once the files are ingested
+	appliedIndex := binary.BigEndian.AppendUint64(nil, index)
+	keys, appliedWritten := 0, false
+	for it.Seek([]byte(stateMachineStart)); it.Valid(); it.Next() {
+		key := it.Key()
+		k := string(key.Data())
+		key.Free()
+		// The checkpoint also holds the raft storage of the sender
+		if !isStateMachineKey(k) {
+			continue
+		}
+		if !appliedWritten && k >= appliedIndexKey {
+			if err := w.add([]byte(appliedIndexKey), appliedIndex); err != nil {
+				return 0, err
+			}
+			appliedWritten = true
+			if k == appliedIndexKey {
+				continue
+			}
+		}
+		value := it.Value()
+		err := w.add([]byte(k), value.Data())
+		value.Free()
+		if err != nil {
+			return 0, err
+		}
+		keys++
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+	if !appliedWritten {
+		if err := w.add([]byte(appliedIndexKey), appliedIndex); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.finish(); err != nil {
+		return 0, err
+	}
+
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	wb.DeleteRange([]byte(stateMachineStart), []byte(stateMachineEnd))
+	if err := r.writeBatch(wb); err != nil {
+		return 0, err
+	}
+	// Ingested files get sequence numbers after the range deletion
+	ingestOpts := grocksdb.NewDefaultIngestExternalFileOptions()
+	defer ingestOpts.Destroy()
+	ingestOpts.SetMoveFiles(true)
+	if err := r.db.IngestExternalFile(w.files, ingestOpts); err != nil {
+		return 0, fmt.Errorf("failed to ingest checkpoint: %w", err)
+	}
+	return keys, nil
+}
+
+// sstSplitter writes sorted keys into SST files of about checkpointSSTSize
+type sstSplitter struct {
+	dir   string
+	opts  *grocksdb.Options
+	files []string
+
+	envOpts *grocksdb.EnvOptions
+	writer  *grocksdb.SSTFileWriter
+	written int
+}
+
+func (s *sstSplitter) add(key, value []byte) error {
+	if s.writer == nil {
+		if s.envOpts == nil {
+			s.envOpts = grocksdb.NewDefaultEnvOptions()
+		}
+		path := filepath.Join(s.dir, fmt.Sprintf("%06d.sst", len(s.files)))
+		s.writer = grocksdb.NewSSTFileWriter(s.envOpts, s.opts)
+		if err := s.writer.Open(path); err != nil {
+			return err
+		}
+		s.files = append(s.files, path)
+		s.written = 0
+	}
+	if err := s.writer.Add(key, value); err != nil {
+		return err
+	}
+	s.written += len(key) + len(value)
+	if s.written >= checkpointSSTSize {
+		return s.finish()
+	}
+	return nil
+}
+
+// finish completes the current file
+func (s *sstSplitter) finish() error {
+	if s.writer == nil {
+		return nil
+	}
+	err := s.writer.Finish()
+	s.writer.Destroy()
+	s.writer = nil
+	return err
+}
+
+func (s *sstSplitter) close() {
+	if s.writer != nil {
+		s.writer.Destroy()
+	}
+	if s.envOpts != nil {
+		s.envOpts.Destroy()
+	}
+}
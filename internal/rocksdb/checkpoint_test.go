@@ -0,0 +1,238 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointBootstrap(t *testing.T) {
+	leader, cleanupLeader := createTestStore(t, t.TempDir())
+	defer cleanupLeader()
+	follower, cleanupFollower := createTestStore(t, t.TempDir())
+	defer cleanupFollower()
+
+	ctx := context.Background()
+	_, _, err := leader.PutWithLease(ctx, "a", "v1", 0)
+	require.NoError(t, err)
+	_, _, err = leader.PutWithLease(ctx, "b", "v1", 0)
+	require.NoError(t, err)
+	// leader 的 raft 存储也在 checkpoint 中，不能被 follower 导入
+	require.NoError(t, leader.db.Put(leader.wo, []byte("node_1_hard_state"), []byte("leader")))
+
+	srv := httptest.NewServer(CheckpointHandler(leader.db, 0, http.NotFoundHandler()))
+	defer srv.Close()
+	leader.SetCheckpointBootstrap(srv.URL, config.CheckpointBootstrapConfig{Enable: true, Retention: time.Hour})
+
+	snapshot, err := leader.RaftSnapshot()
+	require.NoError(t, err)
+	index := leader.loadAppliedIndex()
+	assert.Less(t, len(snapshot), 1024, "the snapshot only describes the checkpoint")
+
+	require.NoError(t, follower.putUnlocked("a", "v2", 0))
+	require.NoError(t, follower.putUnlocked("extra", "v1", 0))
+	require.NoError(t, follower.db.Put(follower.wo, []byte("node_2_hard_state"), []byte("follower")))
+
+	// 快照元数据的 index 与 checkpoint 不同时拒绝
+	err = follower.recoverFromSnapshot(snapshot, index+1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match the snapshot index")
+
+	require.NoError(t, follower.recoverFromSnapshot(snapshot, index))
+
+	for key, want := range map[string]string{"a": "v1", "b": "v1"} {
+		kv, err := follower.getKeyValue(key)
+		require.NoError(t, err)
+		require.NotNil(t, kv, key)
+		assert.Equal(t, want, string(kv.Value), key)
+	}
+	kv, err := follower.getKeyValue("extra")
+	require.NoError(t, err)
+	assert.Nil(t, kv)
+	assert.Equal(t, int64(2), follower.CurrentRevision())
+	assert.Equal(t, index, follower.loadAppliedIndex())
+	assert.Equal(t, index, follower.durableIndex)
+
+	for key, want := range map[string]string{"node_1_hard_state": "", "node_2_hard_state": "follower"} {
+		data, err := follower.db.GetBytes(follower.ro, []byte(key))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data), key)
+	}
+	_, err = os.Stat(filepath.Join(follower.db.Name(), checkpointDownloadsDir))
+	assert.True(t, os.IsNotExist(err), "downloads are removed after recovery")
+
+	// leader 从自己的快照恢复时直接读取本地的 checkpoint
+	srv.Close()
+	require.NoError(t, leader.putUnlocked("a", "v3", 0))
+	require.NoError(t, leader.recoverFromSnapshot(snapshot, index))
+	kv, err = leader.getKeyValue("a")
+	require.NoError(t, err)
+	require.NotNil(t, kv)
+	assert.Equal(t, "v1", string(kv.Value))
+}
+
+func TestRecoverFromCheckpoint_IndexMismatch(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	// checkpoint 在快照 index 之后或之前创建都拒绝，不下载
+	err := store.recoverFromCheckpoint([]byte(`{"index":7,"url":"http://127.0.0.1:1"}`), 6)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "applied index 7 does not match the snapshot index 6")
+	err = store.recoverFromCheckpoint([]byte(`{"index":5,"url":"http://127.0.0.1:1"}`), 6)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "applied index 5 does not match the snapshot index 6")
+
+	err = store.recoverFromCheckpoint([]byte(`{"index":7,"files":[{"name":"../CURRENT","size":1}]}`), 7)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid checkpoint file name")
+}
+
+func TestCheckpointHandler(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	dir := filepath.Join(store.db.Name(), checkpointsDir, "7")
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000001.sst"), []byte("0123456789"), 0o640))
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	srv := httptest.NewServer(CheckpointHandler(store.db, 0, next))
+	defer srv.Close()
+
+	get := func(path, rangeHeader string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get(CheckpointPathPrefix+"7/000001.sst", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "0123456789", body)
+
+	// 中断的下载从已有的字节之后继续
+	status, body = get(CheckpointPathPrefix+"7/000001.sst", "bytes=4-")
+	assert.Equal(t, http.StatusPartialContent, status)
+	assert.Equal(t, "456789", body)
+
+	for _, path := range []string{
+		CheckpointPathPrefix + "8/000001.sst",
+		CheckpointPathPrefix + "x/000001.sst",
+		CheckpointPathPrefix + "7/",
+		CheckpointPathPrefix + "7/..%2f..%2fCURRENT",
+		CheckpointPathPrefix + "7/.hidden",
+	} {
+		status, _ = get(path, "")
+		assert.Equal(t, http.StatusNotFound, status, path)
+	}
+
+	status, _ = get("/raft", "")
+	assert.Equal(t, http.StatusTeapot, status)
+}
+
+func TestCheckpointHandler_RateLimit(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	dir := filepath.Join(store.db.Name(), checkpointsDir, "1")
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	data := bytes.Repeat([]byte("x"), 20000)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000001.sst"), data, 0o640))
+
+	srv := httptest.NewServer(CheckpointHandler(store.db, 10000, http.NotFoundHandler()))
+	defer srv.Close()
+
+	// 突发 10000 字节之后按每秒 10000 字节发送
+	start := time.Now()
+	resp, err := http.Get(srv.URL + CheckpointPathPrefix + "1/000001.sst")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, data, body)
+	assert.GreaterOrEqual(t, time.Since(start), 800*time.Millisecond)
+}
+
+func TestFetchCheckpointFile(t *testing.T) {
+	data := bytes.Repeat([]byte("checkpoint"), 1000)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/file" {
+			http.NotFound(w, r)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	// 已经下载了一部分的文件只请求剩余的字节
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, data[:4000], 0o640))
+	require.NoError(t, fetchCheckpointFile(srv.URL+"/file", path, int64(len(data))))
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.Equal(t, []string{"bytes=4000-"}, ranges)
+
+	// 完整的文件不再下载
+	require.NoError(t, fetchCheckpointFile(srv.URL+"/file", path, int64(len(data))))
+	assert.Len(t, ranges, 1)
+
+	err = fetchCheckpointFile(srv.URL+"/gone", filepath.Join(t.TempDir(), "gone"), 10)
+	assert.ErrorIs(t, err, errCheckpointGone)
+}
+
+func TestPruneCheckpoints(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"1", "2", "3", "4.tmp"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, name), 0o750))
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "1"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "3"), old, old))
+
+	pruneCheckpoints(root, "3", time.Hour)
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// 最新的 checkpoint 即使超过保留时间也保留
+	assert.Equal(t, []string{"2", "3"}, names)
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"metaStore/internal/kvstore"
+	"metaStore/internal/kvstore/kvstoretest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,7 +50,12 @@ func createTestStore(t *testing.T, tmpDir string) (*RocksDB, func()) {
 
 	store := NewRocksDB(db, snapshotter, proposeC, commitC, errorC)
 
+	// Compact is proposed through Raft
+	go kvstoretest.Loopback(proposeC, commitC)
+
 	cleanup := func() {
+		close(proposeC)
+		close(errorC)
 		store.Close()
 		db.Close()
 		os.RemoveAll(tmpDir)
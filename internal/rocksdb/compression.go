@@ -0,0 +1,135 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+
+	"metaStore/pkg/config"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedSnapshotMagic prefixes compressed snapshot data, followed by one codec byte
+// A gob stream never starts with a zero byte, so uncompressed snapshots stay readable
+var compressedSnapshotMagic = []byte("\x00msnapz")
+
+// valueCodec value compression settings, configured from RocksDBConfig in Open
+type valueCodec struct {
+	flag    byte // recordFlagSnappy, recordFlagZstd or 0 for no compression
+	minSize int
+}
+
+var (
+	currentCodec atomic.Pointer[valueCodec]
+
+	// EncodeAll/DecodeAll are safe for concurrent use
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// SetValueCompression sets the compression used for newly written values and snapshots
+// Reading always works whatever the setting, records carry their own compression flag
+func SetValueCompression(algorithm string, minSize int) error {
+	c := &valueCodec{minSize: minSize}
+	switch algorithm {
+	case "", config.CompressionNone:
+	case config.CompressionSnappy:
+		c.flag = recordFlagSnappy
+	case config.CompressionZstd:
+		c.flag = recordFlagZstd
+	default:
+		return fmt.Errorf("unknown value compression %q", algorithm)
+	}
+	currentCodec.Store(c)
+	return nil
+}
+
+// compress compresses data with the configured codec
+// Returns the data unchanged and a zero flag when it is below the threshold or does not shrink
+func compress(data []byte) ([]byte, byte) {
+	c := currentCodec.Load()
+	if c == nil || c.flag == 0 || len(data) == 0 || len(data) < c.minSize {
+		return data, 0
+	}
+	var out []byte
+	switch c.flag {
+	case recordFlagSnappy:
+		out = snappy.Encode(nil, data)
+	case recordFlagZstd:
+		out = zstdEncoder.EncodeAll(data, nil)
+	}
+	if len(out) >= len(data) {
+		return data, 0
+	}
+	return out, c.flag
+}
+
+// decompress reverses compress according to the record flags
+func decompress(data []byte, flags byte) ([]byte, error) {
+	switch {
+	case flags&recordFlagSnappy != 0:
+		out, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+		return out, nil
+	case flags&recordFlagZstd != 0:
+		out, err := zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress value: %w", err)
+		}
+		return out, nil
+	}
+	return data, nil
+}
+
+// compressSnapshot compresses snapshot data as a whole, small values are not compressed
+// individually so this still saves space when most values are below the threshold
+func compressSnapshot(data []byte) []byte {
+	c := currentCodec.Load()
+	if c == nil || c.flag == 0 {
+		return data
+	}
+	var out []byte
+	switch c.flag {
+	case recordFlagSnappy:
+		out = snappy.Encode(nil, data)
+	case recordFlagZstd:
+		out = zstdEncoder.EncodeAll(data, nil)
+	}
+	if len(compressedSnapshotMagic)+1+len(out) >= len(data) {
+		return data
+	}
+	result := make([]byte, 0, len(compressedSnapshotMagic)+1+len(out))
+	result = append(result, compressedSnapshotMagic...)
+	result = append(result, c.flag)
+	return append(result, out...)
+}
+
+// decompressSnapshot reverses compressSnapshot, uncompressed data is returned as is
+func decompressSnapshot(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, compressedSnapshotMagic) || len(data) <= len(compressedSnapshotMagic) {
+		return data, nil
+	}
+	flag := data[len(compressedSnapshotMagic)]
+	if flag != recordFlagSnappy && flag != recordFlagZstd {
+		return nil, fmt.Errorf("unknown snapshot compression %d", flag)
+	}
+	return decompress(data[len(compressedSnapshotMagic)+1:], flag)
+}
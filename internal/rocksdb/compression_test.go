@@ -0,0 +1,77 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCompression(t *testing.T) {
+	defer SetValueCompression(config.CompressionNone, 0)
+
+	large := bytes.Repeat([]byte("metastore "), 1000)
+	small := []byte("small value")
+
+	for _, algorithm := range []string{config.CompressionSnappy, config.CompressionZstd} {
+		t.Run(algorithm, func(t *testing.T) {
+			require.NoError(t, SetValueCompression(algorithm, 1024))
+
+			kv := &kvstore.KeyValue{Key: []byte("key"), Value: large, CreateRevision: 1, ModRevision: 2, Version: 1}
+			data, err := encodeKeyValue(kv)
+			require.NoError(t, err)
+			assert.Less(t, len(data), len(large))
+
+			decoded, err := decodeKeyValue(data)
+			require.NoError(t, err)
+			assert.Equal(t, kv, decoded)
+
+			// 低于阈值的值保持旧格式
+			data, err = encodeKeyValue(&kvstore.KeyValue{Key: []byte("key"), Value: small})
+			require.NoError(t, err)
+			assert.Len(t, data, 4+3+4+len(small)+8*4)
+
+			snapshot := append([]byte("gob"), large...)
+			compressed := compressSnapshot(snapshot)
+			assert.True(t, bytes.HasPrefix(compressed, compressedSnapshotMagic))
+			restored, err := decompressSnapshot(compressed)
+			require.NoError(t, err)
+			assert.Equal(t, snapshot, restored)
+		})
+	}
+
+	// 关闭压缩后仍能读取已压缩的记录和快照
+	require.NoError(t, SetValueCompression(config.CompressionZstd, 0))
+	data, err := encodeKeyValue(&kvstore.KeyValue{Key: []byte("key"), Value: large})
+	require.NoError(t, err)
+	snapshot := compressSnapshot(large)
+
+	require.NoError(t, SetValueCompression(config.CompressionNone, 0))
+	decoded, err := decodeKeyValue(data)
+	require.NoError(t, err)
+	assert.Equal(t, large, decoded.Value)
+	restored, err := decompressSnapshot(snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, large, restored)
+	assert.Equal(t, large, compressSnapshot(large))
+
+	assert.Error(t, SetValueCompression("lz4", 0))
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/kvstore/kvstoretest"
+
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+)
+
+func TestConformance(t *testing.T) {
+	kvstoretest.Run(t, func(t *testing.T) kvstore.Store {
+		dir := t.TempDir()
+		db, err := Open(filepath.Join(dir, "db"))
+		require.NoError(t, err)
+		snapDir := filepath.Join(dir, "snap")
+		require.NoError(t, os.MkdirAll(snapDir, 0755))
+
+		proposeC := make(chan string, 16)
+		commitC := make(chan *kvstore.Commit, 16)
+		errorC := make(chan error)
+		go kvstoretest.Loopback(proposeC, commitC)
+
+		store := NewRocksDB(db, snap.New(nil, snapDir), proposeC, commitC, errorC)
+		t.Cleanup(func() {
+			close(proposeC)
+			close(errorC)
+			store.Close()
+			db.Close()
+		})
+		return store
+	})
+}
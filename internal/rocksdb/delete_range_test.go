@@ -0,0 +1,150 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteRange_RangeTombstone(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	ctx := context.Background()
+
+	for i := 0; i < deleteRangeMinKeys+8; i++ {
+		_, _, err := store.PutWithLease(ctx, fmt.Sprintf("a/%03d", i), "v", 0)
+		require.NoError(t, err)
+	}
+	_, _, err := store.PutWithLease(ctx, "b", "v", 0)
+	require.NoError(t, err)
+
+	watchCh, err := store.WatchWithOptions("a/", "a0", 0, 1, &kvstore.WatchOptions{PrevKV: true})
+	require.NoError(t, err)
+
+	// 大范围删除只写一个 range tombstone
+	batch := store.newApplyBatch()
+	defer batch.Destroy()
+	store.applyMu.Lock()
+	events, err := store.prepareDeleteBatch(batch, "a/", "a0")
+	store.applyMu.Unlock()
+	require.NoError(t, err)
+	assert.Equal(t, 1, batch.Count())
+	assert.Len(t, events, deleteRangeMinKeys+8)
+
+	deleted, prevKvs, _, err := store.DeleteRange(ctx, "a/", "a0")
+	require.NoError(t, err)
+	assert.Equal(t, int64(deleteRangeMinKeys+8), deleted)
+	assert.Len(t, prevKvs, deleteRangeMinKeys+8)
+
+	// 每个 key 仍然有一个带 prevKv 的删除事件
+	for i := 0; i < deleteRangeMinKeys+8; i++ {
+		select {
+		case ev := <-watchCh:
+			assert.Equal(t, kvstore.EventTypeDelete, ev.Type)
+			assert.Equal(t, fmt.Sprintf("a/%03d", i), string(ev.Kv.Key))
+			require.NotNil(t, ev.PrevKv)
+			assert.Equal(t, "v", string(ev.PrevKv.Value))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("missing delete event %d", i)
+		}
+	}
+
+	resp, err := store.Range(ctx, "a/", "a0", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+	resp, err = store.Range(ctx, "b", "", 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 1)
+}
+
+// 没有 watch 覆盖的大范围删除不逐个读取 key，事件日志记录这段范围缺失
+func TestDeleteRange_Unwatched(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	store.SetWatchHistory(1000, 0)
+	store.SetReadCache(16)
+	ctx := context.Background()
+
+	for i := 0; i < deleteRangeMinKeys*4; i++ {
+		_, _, err := store.PutWithLease(ctx, fmt.Sprintf("a/%03d", i), "v", 0)
+		require.NoError(t, err)
+	}
+	_, _, err := store.PutWithLease(ctx, "b", "v", 0)
+	require.NoError(t, err)
+	_, ok := store.Lookup("a/001")
+	require.True(t, ok)
+	rev := store.CurrentRevision()
+
+	// 其他范围的 watch 不影响
+	_, err = store.WatchWithOptions("b", "", 0, 1, &kvstore.WatchOptions{PrevKV: true})
+	require.NoError(t, err)
+
+	batch := store.newApplyBatch()
+	defer batch.Destroy()
+	store.applyMu.Lock()
+	events, err := store.prepareDeleteBatch(batch, "a/", "a0")
+	require.NoError(t, err)
+	require.NoError(t, store.writeApplyBatch(batch, 0))
+	store.applyMu.Unlock()
+	assert.Empty(t, events)
+	assert.Equal(t, 1, batch.Count())
+	assert.Equal(t, rev+1, store.CurrentRevision())
+
+	resp, err := store.Range(ctx, "a/", "a0", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+	_, ok = store.Lookup("a/001")
+	assert.False(t, ok, "the read cache drops the deleted range")
+
+	// 跨过这次删除的 watch 不能从事件日志恢复
+	_, replayed := store.logEvents("a/", "a0", rev)
+	assert.False(t, replayed)
+	_, replayed = store.logEvents("b", "", rev)
+	assert.True(t, replayed)
+	kvs, compacted, err := store.KeyHistory(ctx, "a/001", 0)
+	require.NoError(t, err)
+	assert.Empty(t, kvs)
+	assert.Equal(t, rev+1, compacted)
+}
+
+func TestDeleteRange_ToEnd(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	ctx := context.Background()
+
+	for i := 0; i < deleteRangeMinKeys; i++ {
+		_, _, err := store.PutWithLease(ctx, fmt.Sprintf("k%03d", i), "v", 0)
+		require.NoError(t, err)
+	}
+	rev := store.CurrentRevision()
+
+	deleted, _, _, err := store.DeleteRange(ctx, "k", "\x00")
+	require.NoError(t, err)
+	assert.Equal(t, int64(deleteRangeMinKeys), deleted)
+
+	// 元数据不在 kv 前缀内，不受影响
+	assert.Equal(t, rev+1, store.CurrentRevision())
+	resp, err := store.Range(ctx, "", "\x00", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+}
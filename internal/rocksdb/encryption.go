@@ -0,0 +1,126 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"fmt"
+
+	"metaStore/pkg/encryption"
+	"metaStore/pkg/log"
+
+	"github.com/linxGnu/grocksdb"
+	"go.uber.org/zap"
+)
+
+// reencryptBatch number of records rewritten per write batch during key rotation
+const reencryptBatch = 256
+
+// RotateKeys starts re-encrypting, in the background, every record of this node that
+// is stored in plaintext or under a key other than the active one
+//
+// Records are rewritten locally without going through Raft, revisions are unchanged.
+// Each batch holds applyMu so a concurrent apply can never be overwritten with a stale value
+func (r *RocksDB) RotateKeys() error {
+	return r.rotation.Start(r.reencryptAll)
+}
+
+// RotationStatus returns the progress of the last key rotation on this node
+func (r *RocksDB) RotationStatus() encryption.RotationStatus {
+	return r.rotation.Status()
+}
+
+func (r *RocksDB) reencryptAll(progress func(scanned, reencrypted int64)) error {
+	keyring := encryption.Current()
+	if keyring == nil {
+		return encryption.ErrDisabled
+	}
+	active := keyring.ActiveKeyID()
+
+	log.Info("Re-encrypting stored values",
+		zap.String("active_key", active),
+		zap.String("component", "storage-rocksdb"))
+
+	cursor := []byte(kvPrefix)
+	for {
+		next, scanned, rewritten, err := r.reencryptBatch(cursor, active)
+		progress(scanned, rewritten)
+		if err != nil {
+			log.Error("Re-encryption failed", zap.Error(err), zap.String("component", "storage-rocksdb"))
+			return err
+		}
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+
+	log.Info("Re-encryption finished",
+		zap.String("active_key", active),
+		zap.String("component", "storage-rocksdb"))
+	return nil
+}
+
+// reencryptBatch rewrites up to reencryptBatch records starting at cursor and returns the
+// cursor of the next batch, nil when the scan is complete
+func (r *RocksDB) reencryptBatch(cursor []byte, active string) (next []byte, scanned, rewritten int64, err error) {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	it := r.db.NewIterator(r.ro)
+	defer it.Close()
+
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+
+	for it.Seek(cursor); it.ValidForPrefix([]byte(kvPrefix)); it.Next() {
+		if scanned == reencryptBatch {
+			next = append([]byte(nil), it.Key().Data()...)
+			break
+		}
+		scanned++
+
+		data := it.Value().Data()
+		keyID, err := recordKeyID(data)
+		if err != nil {
+			return nil, scanned, 0, fmt.Errorf("record %q: %w", it.Key().Data(), err)
+		}
+		if keyID == active {
+			continue
+		}
+		kv, err := decodeKeyValue(data)
+		if err != nil {
+			return nil, scanned, 0, fmt.Errorf("record %q: %w", it.Key().Data(), err)
+		}
+		if kv == nil {
+			continue
+		}
+		encoded, err := encodeKeyValue(kv)
+		if err != nil {
+			return nil, scanned, 0, err
+		}
+		wb.Put(it.Key().Data(), encoded)
+		rewritten++
+	}
+	if err := it.Err(); err != nil {
+		return nil, scanned, 0, err
+	}
+
+	if rewritten > 0 {
+		if err := r.writeBatch(wb); err != nil {
+			return nil, scanned, 0, err
+		}
+	}
+	return next, scanned, rewritten, nil
+}
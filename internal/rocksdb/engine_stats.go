@@ -0,0 +1,125 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// numLevels is the number of LSM levels reported per column family (RocksDB's default num_levels)
+const numLevels = 7
+
+// statisticsOptions maps databases opened with rocksdb.statistics to the
+// options holding their statistics object
+var statisticsOptions sync.Map // *grocksdb.DB -> *grocksdb.Options
+
+// engineStatsSampler caches the last sample, so frequent or concurrent scrapes
+// do not query RocksDB more than once per interval
+type engineStatsSampler struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	sampledAt time.Time
+	stats     kvstore.StorageEngineStats
+}
+
+// SetEngineStatsInterval sets how long a sample of the storage engine
+// statistics is reused, 0 queries RocksDB on every call
+func (r *RocksDB) SetEngineStatsInterval(interval time.Duration) {
+	r.engineStats.mu.Lock()
+	defer r.engineStats.mu.Unlock()
+	r.engineStats.interval = interval
+}
+
+// EngineStats returns RocksDB internal statistics for metrics: level file
+// counts, pending compaction bytes and memtable sizes per column family, block
+// cache usage and, with rocksdb.statistics, block cache hits and write stalls
+func (r *RocksDB) EngineStats() kvstore.StorageEngineStats {
+	s := &r.engineStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.sampledAt.IsZero() || now.Sub(s.sampledAt) >= s.interval {
+		s.stats = sampleEngineStats(r.db)
+		s.sampledAt = now
+	}
+	return s.stats
+}
+
+func sampleEngineStats(db *grocksdb.DB) kvstore.StorageEngineStats {
+	stats := kvstore.StorageEngineStats{
+		// Everything is stored in the default column family until families are split
+		ColumnFamilies: []kvstore.ColumnFamilyStats{
+			columnFamilyStats("default", db.GetProperty, db.GetIntProperty),
+		},
+	}
+	stats.BlockCacheUsage, _ = db.GetIntProperty("rocksdb.block-cache-usage")
+	stats.BlockCacheCapacity, _ = db.GetIntProperty("rocksdb.block-cache-capacity")
+
+	if opts, ok := statisticsOptions.Load(db); ok {
+		counts := parseStatistics(opts.(*grocksdb.Options).GetStatisticsString())
+		stats.Statistics = true
+		stats.BlockCacheHits = counts["rocksdb.block.cache.hit"]
+		stats.BlockCacheMisses = counts["rocksdb.block.cache.miss"]
+		stats.WriteStalls = counts["rocksdb.db.write.stall"]
+		stats.WriteStallMicros = counts["rocksdb.stall.micros"]
+	}
+	return stats
+}
+
+// columnFamilyStats reads the statistics of one column family through its
+// property getters (DB.GetPropertyCF and GetIntPropertyCF for a non-default family)
+func columnFamilyStats(name string, property func(string) string, intProperty func(string) (uint64, bool)) kvstore.ColumnFamilyStats {
+	cf := kvstore.ColumnFamilyStats{
+		Name:       name,
+		LevelFiles: make([]uint64, numLevels),
+	}
+	for level := range cf.LevelFiles {
+		// num-files-at-level is a string property only
+		cf.LevelFiles[level], _ = strconv.ParseUint(property(fmt.Sprintf("rocksdb.num-files-at-level%d", level)), 10, 64)
+	}
+	cf.PendingCompactionBytes, _ = intProperty("rocksdb.estimate-pending-compaction-bytes")
+	cf.MemtableBytes, _ = intProperty("rocksdb.cur-size-all-mem-tables")
+	cf.ImmutableMemtables, _ = intProperty("rocksdb.num-immutable-mem-table")
+	return cf
+}
+
+// parseStatistics extracts the COUNT of every ticker and histogram from the
+// output of Options.GetStatisticsString, which has lines like
+//
+//	rocksdb.block.cache.miss COUNT : 42
+//	rocksdb.db.write.stall P50 : 0.000000 P95 : 0.000000 ... COUNT : 3 SUM : 120
+func parseStatistics(s string) map[string]uint64 {
+	counts := make(map[string]uint64)
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		for i := 1; i+2 < len(fields); i++ {
+			if fields[i] == "COUNT" && fields[i+1] == ":" {
+				if n, err := strconv.ParseUint(fields[i+2], 10, 64); err == nil {
+					counts[fields[0]] = n
+				}
+				break
+			}
+		}
+	}
+	return counts
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatistics(t *testing.T) {
+	counts := parseStatistics(`rocksdb.block.cache.miss COUNT : 42
+rocksdb.block.cache.hit COUNT : 958
+rocksdb.stall.micros COUNT : 1500
+rocksdb.db.write.stall P50 : 0.000000 P95 : 250.000000 P99 : 500.000000 P100 : 700.000000 COUNT : 3 SUM : 1500
+rocksdb.malformed COUNT :
+`)
+	assert.Equal(t, map[string]uint64{
+		"rocksdb.block.cache.miss": 42,
+		"rocksdb.block.cache.hit":  958,
+		"rocksdb.stall.micros":     1500,
+		"rocksdb.db.write.stall":   3,
+	}, counts)
+}
+
+func TestColumnFamilyStats(t *testing.T) {
+	props := map[string]string{
+		"rocksdb.num-files-at-level0": "4",
+		"rocksdb.num-files-at-level1": "12",
+	}
+	intProps := map[string]uint64{
+		"rocksdb.estimate-pending-compaction-bytes": 1 << 20,
+		"rocksdb.cur-size-all-mem-tables":           64 << 20,
+		"rocksdb.num-immutable-mem-table":           1,
+	}
+	cf := columnFamilyStats("default",
+		func(name string) string { return props[name] },
+		func(name string) (uint64, bool) { v, ok := intProps[name]; return v, ok })
+
+	assert.Equal(t, "default", cf.Name)
+	assert.Equal(t, []uint64{4, 12, 0, 0, 0, 0, 0}, cf.LevelFiles)
+	assert.Equal(t, uint64(1<<20), cf.PendingCompactionBytes)
+	assert.Equal(t, uint64(64<<20), cf.MemtableBytes)
+	assert.Equal(t, uint64(1), cf.ImmutableMemtables)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"sync/atomic"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// readView is a RocksDB snapshot of the state machine after an applied commit,
+// shared by all eventual reads until the next commit replaces it
+type readView struct {
+	db       *grocksdb.DB
+	snap     *grocksdb.Snapshot
+	ro       *grocksdb.ReadOptions
+	revision int64 // Revision of the state machine in the snapshot
+
+	// Readers holding the view, plus one while it is the current view. The
+	// snapshot is released by whoever drops the last reference
+	refs atomic.Int64
+}
+
+func newReadView(db *grocksdb.DB, revision int64) *readView {
+	v := &readView{db: db, snap: db.NewSnapshot(), ro: grocksdb.NewDefaultReadOptions(), revision: revision}
+	opts := DefaultOptimizationConfig()
+	opts.ApplyReadOptions(v.ro)
+	v.ro.SetSnapshot(v.snap)
+	v.refs.Store(1)
+	return v
+}
+
+// acquire takes a reference, failing once the view has been released
+func (v *readView) acquire() bool {
+	for {
+		n := v.refs.Load()
+		if n == 0 {
+			return false
+		}
+		if v.refs.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+func (v *readView) release() {
+	if v.refs.Add(-1) == 0 {
+		v.ro.Destroy()
+		v.db.ReleaseSnapshot(v.snap)
+	}
+}
+
+// SetSerializableReads selects how serializable reads are served, see
+// config.RocksDBConfig.SerializableReads
+func (r *RocksDB) SetSerializableReads(mode string) {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+	r.eventualReads = mode == config.SerializableReadsEventual
+	r.refreshReadView()
+}
+
+// refreshReadView replaces the view of eventual reads with the state after the
+// commit just applied, or drops it when eventual reads are off. Called under applyMu
+func (r *RocksDB) refreshReadView() {
+	var next *readView
+	if r.eventualReads {
+		next = newReadView(r.db, r.cachedRevision.Load())
+	}
+	if old := r.readView.Swap(next); old != nil {
+		old.release()
+	}
+}
+
+// acquireReadView returns the view to serve a read from, nil when the read must
+// see the latest state: it is not serializable or eventual reads are off. The
+// caller releases the view
+//
+// Eventual reads take no lock and go through neither Raft nor the read caches.
+// A view only changes after a commit, so a read and the revision it reports
+// always match, but it can lag the latest applied state while a commit is applied.
+func (r *RocksDB) acquireReadView(ctx context.Context) *readView {
+	if !kvstore.IsSerializable(ctx) {
+		return nil
+	}
+	for {
+		v := r.readView.Load()
+		if v == nil {
+			return nil
+		}
+		if v.acquire() {
+			return v
+		}
+		// Replaced and released between the load and the acquire
+	}
+}
+
+// readOptions returns the read options reading from view, or the latest state when view is nil
+func (r *RocksDB) readOptions(view *readView) *grocksdb.ReadOptions {
+	if view != nil {
+		return view.ro
+	}
+	return r.ro
+}
+
+// closeReadView releases the current view when the store is closed
+func (r *RocksDB) closeReadView() {
+	if v := r.readView.Swap(nil); v != nil {
+		v.release()
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventualReads(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	store.SetSerializableReads(config.SerializableReadsEventual)
+
+	ctx := context.Background()
+	serializable := kvstore.WithSerializable(ctx)
+	_, _, err := store.PutWithLease(ctx, "a", "v1", 0)
+	require.NoError(t, err)
+
+	resp, err := store.Range(serializable, "a", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v1", string(resp.Kvs[0].Value))
+	assert.Equal(t, int64(1), resp.Revision)
+
+	// 不经过 apply 的写入不会刷新快照：eventual 读取仍然返回快照中的数据和对应的 revision
+	require.NoError(t, store.putUnlocked("a", "v2", 0))
+	require.NoError(t, store.putUnlocked("b", "v1", 0))
+
+	resp, err = store.Range(serializable, "a", "c", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v1", string(resp.Kvs[0].Value))
+	assert.Equal(t, int64(1), resp.Revision)
+
+	var keys []string
+	rev, err := store.RangeFunc(serializable, "a", "c", 0, func(kv *kvstore.KeyValue) bool {
+		keys = append(keys, string(kv.Key))
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+	assert.Equal(t, int64(1), rev)
+
+	// 线性一致读取看到最新的状态
+	resp, err = store.Range(ctx, "a", "c", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 2)
+	assert.Equal(t, "v2", string(resp.Kvs[0].Value))
+
+	// 下一次 apply 之后快照被替换
+	_, _, err = store.PutWithLease(ctx, "c", "v1", 0)
+	require.NoError(t, err)
+	resp, err = store.Range(serializable, "a", "d", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 3)
+	assert.Equal(t, "v2", string(resp.Kvs[0].Value))
+	assert.Equal(t, store.CurrentRevision(), resp.Revision)
+}
+
+func TestEventualReads_Local(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _, err := store.PutWithLease(ctx, "a", "v1", 0)
+	require.NoError(t, err)
+	assert.Nil(t, store.readView.Load(), "local mode keeps no snapshot")
+
+	store.SetSerializableReads(config.SerializableReadsEventual)
+	require.NotNil(t, store.readView.Load())
+	store.SetSerializableReads(config.SerializableReadsLocal)
+	assert.Nil(t, store.readView.Load())
+
+	require.NoError(t, store.putUnlocked("a", "v2", 0))
+	resp, err := store.Range(kvstore.WithSerializable(ctx), "a", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v2", string(resp.Kvs[0].Value))
+}
+
+func TestReadView_Release(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	store.SetSerializableReads(config.SerializableReadsEventual)
+
+	view := store.acquireReadView(kvstore.WithSerializable(context.Background()))
+	require.NotNil(t, view)
+	assert.Nil(t, store.acquireReadView(context.Background()), "linearizable reads do not use the snapshot")
+
+	// 被替换的快照在最后一个读取释放之前保持可用
+	store.applyMu.Lock()
+	store.refreshReadView()
+	store.applyMu.Unlock()
+	assert.Equal(t, int64(1), view.refs.Load())
+	assert.True(t, view.acquire())
+	view.release()
+
+	view.release()
+	assert.Equal(t, int64(0), view.refs.Load())
+	assert.False(t, view.acquire(), "a released view cannot be acquired again")
+}
@@ -0,0 +1,293 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/linxGnu/grocksdb"
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// CheckReport is the result of a startup consistency check of the raft storage
+type CheckReport struct {
+	FirstIndex uint64
+	LastIndex  uint64
+	HardState  raftpb.HardState
+
+	// HasSnapshot is false when no snapshot was ever stored
+	HasSnapshot   bool
+	SnapshotIndex uint64
+	SnapshotTerm  uint64
+
+	// Repairs lists what was fixed, Problems what was found but not fixed
+	Repairs  []string
+	Problems []string
+}
+
+// CheckError is returned when the raft storage is inconsistent and cannot be
+// (or was not allowed to be) repaired. Starting raft on such a storage would
+// panic or silently lose committed entries.
+type CheckError struct {
+	NodeID   string
+	Problems []string
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("raft storage of %s is inconsistent: %s", e.NodeID, strings.Join(e.Problems, "; "))
+}
+
+// Check validates the raft storage before raft is started:
+//
+//   - the log [firstIndex, lastIndex] is contiguous, every entry decodes and
+//     terms never decrease, starting from the snapshot term
+//   - a stored snapshot covers firstIndex-1, i.e. the log was not compacted
+//     past the snapshot, and the log reaches the snapshot index
+//   - the hard state term is not behind the snapshot or the last entry, and
+//     the commit index lies within [snapshot index, lastIndex]
+//
+// With repair set, problems that can be fixed without losing committed
+// entries are fixed: a dangling log tail after the commit index is
+// truncated, entries past lastIndex are deleted, a snapshot whose log
+// compaction did not complete is finished, and a commit index behind the
+// snapshot is raised to it. Anything else is returned as a *CheckError.
+func (s *RocksDBStorage) Check(repair bool) (*CheckReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &CheckReport{}
+	problem := func(format string, args ...any) {
+		r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+	}
+
+	hs, err := s.loadHardStateUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	snap, hasSnap, err := s.loadStoredSnapshotUnsafe()
+	if err != nil {
+		return nil, err
+	}
+	r.HasSnapshot = hasSnap
+	r.SnapshotIndex = s.firstIndex - 1
+	if hasSnap {
+		r.SnapshotIndex = snap.Metadata.Index
+		r.SnapshotTerm = snap.Metadata.Term
+	}
+
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	firstIndex, lastIndex := s.firstIndex, s.lastIndex
+
+	// Snapshot against the log boundaries
+	switch {
+	case !hasSnap && firstIndex > 1:
+		problem("log is compacted up to %d but no snapshot is stored", firstIndex-1)
+	case hasSnap && snap.Metadata.Index < firstIndex-1:
+		problem("log is compacted up to %d past the snapshot at %d, entries in between are lost", firstIndex-1, snap.Metadata.Index)
+	case hasSnap && snap.Metadata.Index > lastIndex:
+		// ApplySnapshot stored the snapshot but the log was not moved past it
+		if repair {
+			for i := firstIndex; i <= lastIndex; i++ {
+				wb.Delete(s.logKey(i))
+			}
+			firstIndex, lastIndex = snap.Metadata.Index+1, snap.Metadata.Index
+			r.Repairs = append(r.Repairs, fmt.Sprintf("finished applying snapshot at %d", snap.Metadata.Index))
+		} else {
+			problem("snapshot at %d is beyond the last log index %d (partially applied snapshot)", snap.Metadata.Index, lastIndex)
+		}
+	}
+	if firstIndex > lastIndex+1 {
+		problem("first index %d is beyond last index %d", firstIndex, lastIndex)
+	}
+
+	// Log continuity and term monotonicity
+	prevTerm := r.SnapshotTerm
+	if hasSnap && snap.Metadata.Index != firstIndex-1 {
+		prevTerm = 0
+	}
+	for i := firstIndex; i <= lastIndex; i++ {
+		ent, err := s.loadEntryUnsafe(i)
+		var bad string
+		switch {
+		case err != nil:
+			bad = err.Error()
+		case ent.Index != i:
+			bad = fmt.Sprintf("entry %d is stored with index %d", i, ent.Index)
+		case ent.Term < prevTerm:
+			bad = fmt.Sprintf("entry %d has term %d lower than the previous term %d", i, ent.Term, prevTerm)
+		}
+		if bad == "" {
+			prevTerm = ent.Term
+			continue
+		}
+
+		// Everything from i on is a dangling tail. It can only be dropped if
+		// none of it is committed, otherwise raft would need it to catch up.
+		if !repair || i <= hs.Commit {
+			problem("%s (committed index %d)", bad, hs.Commit)
+			break
+		}
+		for j := i; j <= lastIndex; j++ {
+			wb.Delete(s.logKey(j))
+		}
+		r.Repairs = append(r.Repairs, fmt.Sprintf("truncated log tail [%d, %d]: %s", i, lastIndex, bad))
+		lastIndex = i - 1
+		break
+	}
+
+	// Entries past lastIndex are not part of the log, but Append would not
+	// overwrite them if they were left behind. A truncated tail is already
+	// deleted in wb.
+	strayFrom := max(lastIndex, s.lastIndex)
+	if stray := s.strayEntriesUnsafe(strayFrom); stray > 0 {
+		if repair {
+			s.deleteLogFromWB(wb, strayFrom+1)
+			r.Repairs = append(r.Repairs, fmt.Sprintf("deleted %d entries past last index %d", stray, strayFrom))
+		} else {
+			problem("%d entries are stored past last index %d", stray, strayFrom)
+		}
+	}
+
+	// Hard state against the snapshot and the log
+	if !raft.IsEmptyHardState(hs) {
+		if hs.Term < r.SnapshotTerm {
+			problem("hard state term %d is behind the snapshot term %d", hs.Term, r.SnapshotTerm)
+		}
+		if hs.Term < prevTerm {
+			problem("hard state term %d is behind the last log term %d", hs.Term, prevTerm)
+		}
+		if hs.Commit > lastIndex {
+			problem("commit index %d is beyond last index %d, committed entries are lost", hs.Commit, lastIndex)
+		}
+		if hs.Commit < firstIndex-1 {
+			if repair {
+				r.Repairs = append(r.Repairs, fmt.Sprintf("raised commit index %d to the snapshot index %d", hs.Commit, firstIndex-1))
+				hs.Commit = firstIndex - 1
+				data, err := hs.Marshal()
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal hard state: %v", err)
+				}
+				wb.Put(s.prefixedKey(hardStateKey), data)
+			} else {
+				problem("commit index %d is behind the snapshot index %d", hs.Commit, firstIndex-1)
+			}
+		}
+	}
+
+	r.FirstIndex, r.LastIndex, r.HardState = firstIndex, lastIndex, hs
+	if len(r.Problems) > 0 {
+		return r, &CheckError{NodeID: s.nodeID, Problems: r.Problems}
+	}
+
+	if len(r.Repairs) > 0 {
+		if err := s.setFirstIndexWithWB(wb, firstIndex); err != nil {
+			return nil, err
+		}
+		if err := s.setLastIndexWithWB(wb, lastIndex); err != nil {
+			return nil, err
+		}
+		if err := s.db.Write(s.wo, wb); err != nil {
+			return nil, fmt.Errorf("failed to write repairs: %v", err)
+		}
+		s.firstIndex, s.lastIndex = firstIndex, lastIndex
+	}
+	return r, nil
+}
+
+// loadHardStateUnsafe loads the hard state without acquiring the lock
+func (s *RocksDBStorage) loadHardStateUnsafe() (raftpb.HardState, error) {
+	var hs raftpb.HardState
+	data, err := s.db.Get(s.ro, s.prefixedKey(hardStateKey))
+	if err != nil {
+		return hs, err
+	}
+	defer data.Free()
+	if data.Size() > 0 {
+		if err := hs.Unmarshal(data.Data()); err != nil {
+			return hs, fmt.Errorf("failed to unmarshal hard state: %v", err)
+		}
+	}
+	return hs, nil
+}
+
+// loadStoredSnapshotUnsafe loads the stored snapshot, unlike loadSnapshotUnsafe
+// it does not make one up when none is stored
+func (s *RocksDBStorage) loadStoredSnapshotUnsafe() (raftpb.Snapshot, bool, error) {
+	var snap raftpb.Snapshot
+	data, err := s.db.Get(s.ro, s.prefixedKey(snapshotKey))
+	if err != nil {
+		return snap, false, err
+	}
+	defer data.Free()
+	if data.Size() == 0 {
+		return snap, false, nil
+	}
+	if err := unmarshalSnapshot(data.Data(), &snap); err != nil {
+		return snap, false, fmt.Errorf("failed to unmarshal snapshot: %v", err)
+	}
+	return snap, true, nil
+}
+
+// loadEntryUnsafe loads the log entry at index without acquiring the lock
+func (s *RocksDBStorage) loadEntryUnsafe(index uint64) (raftpb.Entry, error) {
+	var ent raftpb.Entry
+	data, err := s.db.Get(s.ro, s.logKey(index))
+	if err != nil {
+		return ent, fmt.Errorf("failed to get entry %d: %v", index, err)
+	}
+	defer data.Free()
+	if data.Size() == 0 {
+		return ent, fmt.Errorf("entry %d is missing", index)
+	}
+	if err := unmarshalEntry(data.Data(), &ent); err != nil {
+		return ent, fmt.Errorf("entry %d is corrupted: %v", index, err)
+	}
+	return ent, nil
+}
+
+// strayEntriesUnsafe counts the log entries stored after lastIndex
+func (s *RocksDBStorage) strayEntriesUnsafe(lastIndex uint64) int {
+	prefix := s.logPrefix()
+	it := s.db.NewIterator(s.ro)
+	defer it.Close()
+
+	n := 0
+	for it.Seek(s.logKey(lastIndex + 1)); it.Valid(); it.Next() {
+		key := it.Key()
+		ok := bytes.HasPrefix(key.Data(), prefix)
+		key.Free()
+		if !ok {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// deleteLogFromWB deletes all log entries from index on using a provided WriteBatch
+func (s *RocksDBStorage) deleteLogFromWB(wb *grocksdb.WriteBatch, index uint64) {
+	end := s.logPrefix()
+	end[len(end)-1]++ // the prefix ends with "_", so this is the first key after all entries
+	wb.DeleteRange(s.logKey(index), end)
+}
+
+// logPrefix is the common prefix of all log entry keys
+func (s *RocksDBStorage) logPrefix() []byte {
+	return append(s.prefixedKey(raftLogPrefix), '_')
+}
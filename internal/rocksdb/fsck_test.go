@@ -0,0 +1,172 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func newCheckStorage(t *testing.T, entries ...raftpb.Entry) *RocksDBStorage {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	storage, err := NewRocksDBStorage(db, "test_node")
+	require.NoError(t, err)
+	t.Cleanup(storage.Close)
+	require.NoError(t, storage.Append(entries))
+	return storage
+}
+
+func TestCheck_Clean(t *testing.T) {
+	storage := newCheckStorage(t,
+		raftpb.Entry{Term: 1, Index: 1},
+		raftpb.Entry{Term: 1, Index: 2},
+		raftpb.Entry{Term: 2, Index: 3},
+	)
+	require.NoError(t, storage.SetHardState(raftpb.HardState{Term: 2, Commit: 3}))
+
+	report, err := storage.Check(true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Repairs)
+	assert.Equal(t, uint64(1), report.FirstIndex)
+	assert.Equal(t, uint64(3), report.LastIndex)
+	assert.False(t, report.HasSnapshot)
+}
+
+func TestCheck_DanglingTail(t *testing.T) {
+	storage := newCheckStorage(t,
+		raftpb.Entry{Term: 1, Index: 1},
+		raftpb.Entry{Term: 1, Index: 2},
+		raftpb.Entry{Term: 1, Index: 3},
+		raftpb.Entry{Term: 1, Index: 4},
+	)
+	require.NoError(t, storage.SetHardState(raftpb.HardState{Term: 1, Commit: 2}))
+	require.NoError(t, storage.db.Delete(storage.wo, storage.logKey(3)))
+
+	// strict 模式只报告问题
+	_, err := storage.Check(false)
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+	assert.Contains(t, checkErr.Problems[0], "entry 3 is missing")
+
+	report, err := storage.Check(true)
+	require.NoError(t, err)
+	require.Len(t, report.Repairs, 1)
+	assert.Equal(t, uint64(2), report.LastIndex)
+
+	lastIndex, err := storage.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), lastIndex)
+	assert.Zero(t, storage.strayEntriesUnsafe(lastIndex))
+
+	// 修复后再次检查没有问题
+	report, err = storage.Check(false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Repairs)
+}
+
+func TestCheck_MissingCommittedEntry(t *testing.T) {
+	storage := newCheckStorage(t,
+		raftpb.Entry{Term: 1, Index: 1},
+		raftpb.Entry{Term: 1, Index: 2},
+		raftpb.Entry{Term: 1, Index: 3},
+	)
+	require.NoError(t, storage.SetHardState(raftpb.HardState{Term: 1, Commit: 3}))
+	require.NoError(t, storage.db.Delete(storage.wo, storage.logKey(2)))
+
+	_, err := storage.Check(true)
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+	assert.Contains(t, checkErr.Error(), "entry 2 is missing")
+}
+
+func TestCheck_HardStateBehindSnapshot(t *testing.T) {
+	storage := newCheckStorage(t)
+	require.NoError(t, storage.ApplySnapshot(raftpb.Snapshot{
+		Data:     []byte("data"),
+		Metadata: raftpb.SnapshotMetadata{Index: 10, Term: 3, ConfState: raftpb.ConfState{Voters: []uint64{1}}},
+	}))
+	require.NoError(t, storage.SetHardState(raftpb.HardState{Term: 2, Commit: 10}))
+
+	_, err := storage.Check(true)
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+	assert.Contains(t, checkErr.Error(), "behind the snapshot term")
+}
+
+func TestCheck_CommitBehindSnapshot(t *testing.T) {
+	storage := newCheckStorage(t)
+	require.NoError(t, storage.ApplySnapshot(raftpb.Snapshot{
+		Data:     []byte("data"),
+		Metadata: raftpb.SnapshotMetadata{Index: 10, Term: 3, ConfState: raftpb.ConfState{Voters: []uint64{1}}},
+	}))
+	require.NoError(t, storage.SetHardState(raftpb.HardState{Term: 3, Commit: 5}))
+
+	report, err := storage.Check(true)
+	require.NoError(t, err)
+	require.Len(t, report.Repairs, 1)
+
+	hs, _, err := storage.InitialState()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), hs.Commit)
+}
+
+func TestCheck_PartiallyAppliedSnapshot(t *testing.T) {
+	storage := newCheckStorage(t,
+		raftpb.Entry{Term: 1, Index: 1},
+		raftpb.Entry{Term: 1, Index: 2},
+	)
+	// 快照已保存但日志边界没有更新
+	snap := raftpb.Snapshot{Metadata: raftpb.SnapshotMetadata{Index: 5, Term: 2}}
+	data, err := snap.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, storage.db.Put(storage.wo, storage.prefixedKey(snapshotKey), data))
+
+	_, err = storage.Check(false)
+	var checkErr *CheckError
+	require.ErrorAs(t, err, &checkErr)
+	assert.Contains(t, checkErr.Error(), "partially applied snapshot")
+
+	report, err := storage.Check(true)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), report.FirstIndex)
+	assert.Equal(t, uint64(5), report.LastIndex)
+
+	term, err := storage.Term(5)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), term)
+}
+
+func TestAppend_TruncatesConflictingTail(t *testing.T) {
+	storage := newCheckStorage(t,
+		raftpb.Entry{Term: 1, Index: 1},
+		raftpb.Entry{Term: 1, Index: 2},
+		raftpb.Entry{Term: 1, Index: 3},
+	)
+	require.NoError(t, storage.Append([]raftpb.Entry{{Term: 2, Index: 2}}))
+
+	lastIndex, err := storage.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), lastIndex)
+
+	report, err := storage.Check(false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Repairs)
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"encoding/binary"
+
+	"metaStore/pkg/hlc"
+
+	"github.com/linxGnu/grocksdb"
+)
+
+// hlcKey holds the hybrid logical clock timestamp of the last applied operation,
+// it travels with the other meta keys in snapshots
+const hlcKey = "meta:hlc"
+
+// SetClock sets the hybrid logical clock of this node, a clock with the default
+// maximum offset is used when it is never called
+func (r *RocksDB) SetClock(clock *hlc.Clock) {
+	r.clock.Store(clock)
+}
+
+// CurrentHLC returns the hybrid logical clock timestamp of the last applied operation
+func (r *RocksDB) CurrentHLC() uint64 {
+	return uint64(r.hlc.Last())
+}
+
+// ObserveHLC merges an external timestamp into the clock of this node
+func (r *RocksDB) ObserveHLC(ts uint64) error {
+	return r.clock.Load().Update(hlc.Timestamp(ts))
+}
+
+// commitHLC orders the proposed timestamp of a committed operation after all
+// previously committed ones and merges it into the local clock
+//
+// A timestamp too far ahead of the local clock is only counted in the skew
+// statistics, the committed operation keeps it so that all replicas agree
+func (r *RocksDB) commitHLC(proposed uint64) uint64 {
+	ts := r.hlc.Commit(hlc.Timestamp(proposed))
+	r.clock.Load().Update(ts)
+	return uint64(ts)
+}
+
+// putHLC adds the timestamp of the last applied operation to batch (called under applyMu)
+func (r *RocksDB) putHLC(batch *grocksdb.WriteBatch) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, r.CurrentHLC())
+	batch.Put([]byte(hlcKey), buf)
+}
+
+// loadHLC loads the timestamp of the last applied operation from DB
+func (r *RocksDB) loadHLC() hlc.Timestamp {
+	data, err := r.db.Get(r.ro, []byte(hlcKey))
+	if err != nil {
+		return 0
+	}
+	defer data.Free()
+
+	if data.Size() != 8 {
+		return 0
+	}
+	return hlc.Timestamp(binary.LittleEndian.Uint64(data.Data()))
+}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -28,7 +29,12 @@ import (
 	"metaStore/internal/common"
 	"metaStore/internal/kvstore"
 	"metaStore/internal/lease"
+	"metaStore/internal/mvcc"
+	"metaStore/pkg/chaos"
+	"metaStore/pkg/encryption"
+	"metaStore/pkg/hlc"
 	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
 
 	"github.com/linxGnu/grocksdb"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
@@ -41,6 +47,12 @@ const (
 	revisionKey = "meta:revision"
 	kvPrefix    = "kv:"
 	leasePrefix = "lease:"
+	metaPrefix  = "meta:"
+
+	// deleteRangeMinKeys is the number of keys from which a range delete is
+	// written as one DeleteRange instead of point deletes. When no watcher
+	// covers the range, no more keys than this are read
+	deleteRangeMinKeys = 32
 )
 
 // RaftNode Raft 节点接口，用于获取 Raft 状态
@@ -49,6 +61,11 @@ type RaftNode interface {
 	TransferLeadership(targetID uint64) error
 	LeaseManager() *lease.LeaseManager
 	ReadIndexManager() *lease.ReadIndexManager
+	ReadIndex(ctx context.Context) error
+	MoveLeader(ctx context.Context) (uint64, error)
+	ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error
+	Members() []kvstore.MemberStatus
+	RaftLog(from uint64, limit int, redact bool) (*kvstore.RaftLog, error)
 }
 
 // RocksDB integrates Raft consensus with etcd-compatible RocksDB storage
@@ -69,37 +86,86 @@ type RocksDB struct {
 	// Watch support
 	watchMu sync.RWMutex
 	watches map[int64]*watchSubscription
+	events  *mvcc.EventLog // Recent events for resuming watches, bounded independently of compaction
 
 	// Performance optimization: cached revision (atomic for lock-free access)
 	cachedRevision atomic.Int64
 
+	// applyMu serializes applying commits with background rewrites of stored records
+	applyMu  sync.Mutex
+	rotation encryption.Rotation // Re-encryption of records with the active key
+
 
 	// Raft 节点引用（用于获取状态信息）
 	raftNode RaftNode
 	nodeID   uint64
+
+	// Backpressure of the propose pipeline
+	backpressure atomic.Pointer[kvstore.Backpressure]
+	rejected     atomic.Uint64
+
+	// When set, proposals enter this queue by priority instead of proposeC
+	queue *kvstore.ProposeQueue
+
+	// Raft index applied to the state machine, for read-after-write tokens
+	applied kvstore.AppliedIndex
+	// Raft index persisted with the state machine, guarded by applyMu
+	durableIndex uint64
+
+	// Hybrid logical clock of this node and the timestamps of committed operations
+	clock    atomic.Pointer[hlc.Clock]
+	hlc      hlc.Sequencer
+	applyHLC uint64 // Timestamp of the operation being applied, guarded by applyMu
+
+	// Negative lookup cache, nil when disabled, and the keys put by the batch
+	// being applied, guarded by applyMu
+	missCache atomic.Pointer[missCache]
+	putKeys   []string
+
+	// Read cache, nil when disabled, and the keys and unread ranges deleted by
+	// the batch being applied, guarded by applyMu
+	readCache     atomic.Pointer[readCache]
+	deletedKeys   []string
+	deletedRanges []keyRange
+
+	// Storage engine statistics sampled for metrics
+	engineStats engineStatsSampler
+
+	// Write slowdown signalled by the stall controller, see stall_control.go
+	writeSlowdown atomic.Int32
+
+	// Raft snapshots refer to checkpoints when set, see checkpoint.go
+	checkpoints atomic.Pointer[checkpointSource]
+
+	// Serializable reads served from a shared snapshot, see eventual_read.go
+	eventualReads bool // Guarded by applyMu
+	readView      atomic.Pointer[readView]
 }
 
 // watchSubscription represents a watch subscription
 type watchSubscription struct {
-	watchID   int64
-	key       string
-	rangeEnd  string
-	startRev  int64
-	eventCh   chan kvstore.WatchEvent
-	cancel    chan struct{}
-	closed    atomic.Bool // 防止重复关闭
-	closeOnce sync.Once   // 确保只关闭一次
+	watchID     int64
+	key         string
+	rangeEnd    string
+	startRev    int64
+	replayedRev int64 // Last revision replayed from the event log on creation
+	eventCh     chan kvstore.WatchEvent
+	cancel      chan struct{}
+	closed      atomic.Bool        // 防止重复关闭
+	closeOnce   sync.Once          // 确保只关闭一次
+	queue       kvstore.WatchQueue // Events queued while eventCh is full
 
 	// Options
 	prevKV         bool
 	progressNotify bool
 	filters        []kvstore.WatchFilterType
 	fragment       bool
+	valueFilter    func(value []byte) bool
 }
 
 // RaftOperation represents an operation to be committed through Raft
 type RaftOperation struct {
-	Type     string `json:"type"` // "PUT", "DELETE", "LEASE_GRANT", "LEASE_REVOKE", "TXN"
+	Type     string `json:"type"` // "PUT", "DELETE", "LEASE_GRANT", "LEASE_REVOKE", "TXN", "COMPACT"
 	Key      string `json:"key"`
 	Value    string `json:"value"`
 	LeaseID  int64  `json:"lease_id"`
@@ -113,6 +179,12 @@ type RaftOperation struct {
 	Compares []kvstore.Compare `json:"compares,omitempty"`
 	ThenOps  []kvstore.Op      `json:"then_ops,omitempty"`
 	ElseOps  []kvstore.Op      `json:"else_ops,omitempty"`
+
+	// Hybrid logical clock timestamp, assigned by the proposer and ordered on apply
+	HLC uint64 `json:"hlc,omitempty"`
+
+	// Compact operations
+	Revision int64 `json:"revision,omitempty"`
 }
 
 // NewRocksDB creates a new RocksDB + Raft + etcd semantic storage
@@ -141,25 +213,38 @@ func NewRocksDB(
 		pendingOps:        make(map[string]chan struct{}),
 		pendingTxnResults: make(map[string]*kvstore.TxnResponse),
 		watches:           make(map[int64]*watchSubscription),
+		events:            mvcc.NewEventLog(0, 0),
 	}
+	r.SetBackpressure(kvstore.DefaultBackpressure)
+	r.SetClock(hlc.NewClock(hlc.DefaultMaxOffset))
 
 	// Recover from snapshot if exists
 	snapshot, err := r.loadSnapshot()
 	if err != nil {
 		log.Fatal("Failed to load snapshot", zap.Error(err), zap.String("component", "storage-rocksdb"))
 	}
-	if snapshot != nil {
+	r.durableIndex = r.loadAppliedIndex()
+	if snapshot != nil && snapshot.Metadata.Index <= r.durableIndex {
+		// The state machine already contains everything up to the snapshot
+		log.Info("State machine is ahead of the snapshot, skipping recovery",
+			zap.Uint64("snapshotIndex", snapshot.Metadata.Index),
+			zap.Uint64("appliedIndex", r.durableIndex),
+			zap.String("component", "storage-rocksdb"))
+	} else if snapshot != nil {
 		log.Info("Loading RocksDB snapshot",
 			zap.Uint64("term", snapshot.Metadata.Term),
 			zap.Uint64("index", snapshot.Metadata.Index),
 			zap.String("component", "storage-rocksdb"))
-		if err := r.recoverFromSnapshot(snapshot.Data); err != nil {
+		if err := r.recoverFromSnapshot(snapshot.Data, snapshot.Metadata.Index); err != nil {
 			log.Fatal("Failed to recover from snapshot", zap.Error(err), zap.String("component", "storage-rocksdb"))
 		}
 	}
+	r.applied.Advance(r.durableIndex)
 
 	// Initialize cached revision from DB
 	r.cachedRevision.Store(r.loadCurrentRevision())
+	r.events.Reset(r.cachedRevision.Load())
+	r.hlc.Restore(r.loadHLC())
 
 	// Start commit handler
 	go r.readCommits(commitC, errorC)
@@ -168,6 +253,7 @@ func NewRocksDB(
 }
 
 func (r *RocksDB) Close() {
+	r.closeReadView()
 	if r.wo != nil {
 		r.wo.Destroy()
 	}
@@ -176,94 +262,343 @@ func (r *RocksDB) Close() {
 	}
 }
 
-func (r *RocksDB) propose(ctx context.Context, data []byte) error {
+// writeBatch commits a state machine write batch, honoring fault injection
+// All state machine writes go through writeBatch, putKey or deleteKey so that
+// chaos.RocksDBWrite covers puts, deletes, leases and compaction alike.
+// Keys put into the batch leave the negative lookup cache once it is written,
+// keys put or deleted leave the read cache
+func (r *RocksDB) writeBatch(batch *grocksdb.WriteBatch) error {
+	defer r.forgetMisses()
+	defer r.forgetReads() // Runs first, forgetMisses resets putKeys
+	if err := chaos.InjectError(chaos.RocksDBWrite); err != nil {
+		return err
+	}
+	return r.db.Write(r.wo, batch)
+}
+
+// putKey writes a single state machine key, honoring fault injection
+func (r *RocksDB) putKey(key, value []byte) error {
+	if err := chaos.InjectError(chaos.RocksDBWrite); err != nil {
+		return err
+	}
+	return r.db.Put(r.wo, key, value)
+}
+
+// deleteKey deletes a single state machine key, honoring fault injection
+func (r *RocksDB) deleteKey(key []byte) error {
+	if err := chaos.InjectError(chaos.RocksDBWrite); err != nil {
+		return err
+	}
+	return r.db.Delete(r.wo, key)
+}
+
+func (r *RocksDB) propose(ctx context.Context, opType string, data []byte) error {
+	// An entry above the raft message size limit would wedge replication
+	if err := r.backpressure.Load().CheckSize(len(data)); err != nil {
+		return err
+	}
+
+	// Fail fast when the pipeline is saturated instead of queueing until timeout
+	r.pendingMu.RLock()
+	pending := len(r.pendingOps)
+	r.pendingMu.RUnlock()
+	queued, capacity := len(r.proposeC), cap(r.proposeC)
+	priority := kvstore.PriorityOf(ctx)
+	if opType == "LEASE_REVOKE" {
+		// Expiry must not wait behind a write backlog, or keys outlive their TTL
+		priority = kvstore.PriorityHigh
+	}
+	if r.queue != nil {
+		queued, capacity = r.queue.Len(), r.queue.Cap()
+		if priority == kvstore.PriorityHigh {
+			// High priority proposals skip the occupancy check, the room above the threshold is left to them
+			queued = 0
+		}
+	}
+	if err := r.backpressure.Load().Check(queued, capacity, pending); err != nil {
+		r.rejected.Add(1)
+		return err
+	}
+	if err := r.checkWriteSlowdown(priority); err != nil {
+		r.rejected.Add(1)
+		return err
+	}
+
+	// Proposals carry their frontend, the batcher queues them per origin
+	proposal := kvstore.TagProposal(kvstore.Origin(ctx), string(data))
+	if r.queue != nil {
+		err := r.queue.Push(ctx, priority, proposal)
+		switch {
+		case errors.Is(err, kvstore.ErrTooManyRequests):
+			r.rejected.Add(1)
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			return &kvstore.StageError{Op: opType, Stage: kvstore.StagePropose, Err: err}
+		}
+		return err
+	}
 
 	// 向后兼容：使用原始 proposeC
 	select {
-	case r.proposeC <- string(data):
+	case r.proposeC <- proposal:
+		return nil
+	case <-ctx.Done():
+		return &kvstore.StageError{Op: opType, Stage: kvstore.StagePropose, Err: ctx.Err()}
+	}
+}
+
+// proposeAndWait proposes op and waits until it is applied on this node.
+// The caller's deadline (or the default timeout when there is none) covers both
+// the propose and the apply stage; on cancellation the pending state is removed
+// so a late apply does not leave a transaction result behind. A leader change
+// fails the wait immediately with ErrLeaderChanged; idempotent operations are
+// proposed again until the deadline instead
+func (r *RocksDB) proposeAndWait(ctx context.Context, op *RaftOperation) error {
+	ctx, cancel := r.backpressure.Load().WithTimeout(ctx)
+	defer cancel()
+
+	// Record the proposal size distribution, once per write rather than per attempt
+	switch op.Type {
+	case "PUT":
+		kvstore.ObservePut(op.Value)
+	case "TXN":
+		kvstore.ObserveTxn(op.Compares, op.ThenOps, op.ElseOps)
+	}
+
+	for {
+		err := r.proposeOnce(ctx, op)
+		if !errors.Is(err, kvstore.ErrLeaderChanged) || !idempotentOp(op.Type) {
+			return err
+		}
+		log.Debug("Leader changed, proposing again",
+			zap.String("op", op.Type),
+			zap.String("seq", op.SeqNum),
+			zap.String("component", "storage-rocksdb"))
+	}
+}
+
+// idempotentOp reports whether applying the operation twice has the same
+// effect as applying it once, so it can be proposed again after a leader change
+func idempotentOp(opType string) bool {
+	// Revoking a lease that is already gone is a no-op; granting again would
+	// reset the attached keys. Compacting an already compacted revision is a
+	// no-op as well
+	return opType == "LEASE_REVOKE" || opType == "COMPACT"
+}
+
+// proposeOnce proposes op under a new sequence number and waits for the apply
+func (r *RocksDB) proposeOnce(ctx context.Context, op *RaftOperation) error {
+	// Generate sequence number (lock-free atomic operation)
+	op.SeqNum = fmt.Sprintf("seq-%d", r.seqNum.Add(1))
+	op.HLC = uint64(r.clock.Load().Now())
+
+	data, err := encodeProposal(op)
+	if err != nil {
+		return err
+	}
+
+	// Create wait channel
+	waitCh := make(chan struct{})
+	r.pendingMu.Lock()
+	r.pendingOps[op.SeqNum] = waitCh
+	r.pendingMu.Unlock()
+
+	// Taken before proposing so a change during the propose is not missed
+	leaderChanged := r.leaderChanged()
+	if err := r.propose(ctx, op.Type, data); err != nil {
+		r.cancelPending(op.SeqNum)
+		return err
+	}
+
+	// Wait for Raft commit
+	select {
+	case <-waitCh:
 		return nil
-	case <-time.After(30 * time.Second):
-		return fmt.Errorf("timeout proposing operation")
+	case <-leaderChanged:
+		// The proposal may have been dropped with the old leader
+		select {
+		case <-waitCh:
+			return nil
+		default:
+		}
+		r.cancelPending(op.SeqNum)
+		return &kvstore.StageError{Op: op.Type, Stage: kvstore.StageApply, Err: kvstore.ErrLeaderChanged}
 	case <-ctx.Done():
-		return ctx.Err()
+		// Prefer the apply result when both are ready
+		select {
+		case <-waitCh:
+			return nil
+		default:
+		}
+		r.cancelPending(op.SeqNum)
+		return &kvstore.StageError{Op: op.Type, Stage: kvstore.StageApply, Err: ctx.Err()}
+	}
+}
+
+// leaderChanged returns a channel closed on the next leader change, nil when
+// the raft node cannot report leader changes
+func (r *RocksDB) leaderChanged() <-chan struct{} {
+	if n, ok := r.raftNode.(kvstore.LeaderChangeNotifier); ok {
+		return n.LeaderChanged()
+	}
+	return nil
+}
+
+// cancelPending removes the wait channel and any stored transaction result of
+// a request that stopped waiting
+func (r *RocksDB) cancelPending(seqNum string) {
+	r.pendingMu.Lock()
+	delete(r.pendingOps, seqNum)
+	delete(r.pendingTxnResults, seqNum)
+	r.pendingMu.Unlock()
+}
+
+// storeTxnResult saves a transaction result for the client waiting on this
+// node. Transactions proposed by other nodes or by requests that already gave
+// up have no waiter and are not stored
+func (r *RocksDB) storeTxnResult(seqNum string, txnResp *kvstore.TxnResponse) {
+	r.pendingMu.Lock()
+	if _, waiting := r.pendingOps[seqNum]; waiting {
+		r.pendingTxnResults[seqNum] = txnResp
 	}
+	r.pendingMu.Unlock()
 }
 
 // readCommits reads from Raft commitC and applies operations
 func (r *RocksDB) readCommits(commitC <-chan *kvstore.Commit, errorC <-chan error) {
 	for commit := range commitC {
-		if commit == nil {
-			// Reload snapshot
-			snapshot, err := r.loadSnapshot()
-			if err != nil {
-				log.Fatal("Failed to reload snapshot", zap.Error(err), zap.String("component", "storage-rocksdb"))
-			}
-			if snapshot != nil {
-				log.Info("Reloading RocksDB snapshot",
-					zap.Uint64("term", snapshot.Metadata.Term),
-					zap.Uint64("index", snapshot.Metadata.Index),
-					zap.String("component", "storage-rocksdb"))
-				if err := r.recoverFromSnapshot(snapshot.Data); err != nil {
-					log.Fatal("Failed to recover from reloaded snapshot", zap.Error(err), zap.String("component", "storage-rocksdb"))
-				}
-			}
-			continue
+		r.applyMu.Lock()
+		r.applyCommit(commit)
+		if r.eventualReads {
+			r.refreshReadView()
 		}
+		r.applyMu.Unlock()
+	}
+
+	if err, ok := <-errorC; ok {
+		log.Fatal("Raft commit error", zap.Error(err), zap.String("component", "storage-rocksdb"))
+	}
+}
 
-		// Collect all operations from this commit for batch processing
-		var batchOps []*RaftOperation
-
-		for _, data := range commit.Data {
-			if ops, err := unmarshalRaftMessage([]byte(data)); err == nil && ops != nil {
-				// Try RaftMessage format (supports both single and batch operations)
-				// 支持旧的本地批量格式（向后兼容）
-				batchOps = append(batchOps, ops...)
-			} else if op, err := unmarshalRaftOperation([]byte(data)); err == nil && op != nil {
-				// Fallback to single operation format (backward compatibility)
-				batchOps = append(batchOps, op)
-			} else {
-				// Fallback to legacy gob format (for backward compatibility)
-				r.applyLegacyOp(data)
+// applyCommit applies a commit, or reloads the snapshot when commit is nil
+func (r *RocksDB) applyCommit(commit *kvstore.Commit) {
+	if commit == nil {
+		// Reload snapshot
+		snapshot, err := r.loadSnapshot()
+		if err != nil {
+			log.Fatal("Failed to reload snapshot", zap.Error(err), zap.String("component", "storage-rocksdb"))
+		}
+		if snapshot != nil {
+			log.Info("Reloading RocksDB snapshot",
+				zap.Uint64("term", snapshot.Metadata.Term),
+				zap.Uint64("index", snapshot.Metadata.Index),
+				zap.String("component", "storage-rocksdb"))
+			if err := r.recoverFromSnapshot(snapshot.Data, snapshot.Metadata.Index); err != nil {
+				log.Fatal("Failed to recover from reloaded snapshot", zap.Error(err), zap.String("component", "storage-rocksdb"))
 			}
+			r.applied.Advance(snapshot.Metadata.Index)
 		}
+		return
+	}
 
-		// Apply all operations in a single WriteBatch for maximum performance
-		if len(batchOps) > 0 {
-			r.applyOperationsBatch(batchOps)
-		}
+	// Entries replayed after a restart up to the persisted index are already in
+	// the state machine, applying them again would inflate the revision
+	if commit.Index != 0 && commit.Index <= r.durableIndex {
+		log.Debug("Skipping applied commit",
+			zap.Uint64("index", commit.Index),
+			zap.Uint64("appliedIndex", r.durableIndex),
+			zap.String("component", "storage-rocksdb"))
+		r.applied.Advance(commit.Index)
 		close(commit.ApplyDoneC)
+		return
 	}
 
-	if err, ok := <-errorC; ok {
-		log.Fatal("Raft commit error", zap.Error(err), zap.String("component", "storage-rocksdb"))
+	r.applied.Begin(commit.Index)
+
+	// Every write of the commit goes into one WriteBatch together with the
+	// applied index and the revision, so a crash cannot leave part of a commit,
+	// such as the writes of a transaction, applied without its index
+	b := r.newApplyBatch()
+	defer b.Destroy()
+
+	var ops []*RaftOperation
+	for _, data := range commit.Data {
+		if op, ok := unmarshalJSONOperation([]byte(data)); ok {
+			// Proposals of releases that encoded operations as JSON, checked
+			// first since protobuf may accept some of them as unknown fields
+			ops = append(ops, op)
+		} else if batchOps, err := unmarshalRaftMessage([]byte(data)); err == nil && batchOps != nil {
+			// Try RaftMessage format (supports both single and batch operations)
+			// 支持旧的本地批量格式（向后兼容）
+			ops = append(ops, batchOps...)
+		} else if op, err := unmarshalRaftOperation([]byte(data)); err == nil && op != nil {
+			// Fallback to single operation format (backward compatibility)
+			ops = append(ops, op)
+		} else {
+			// Fallback to legacy gob format (for backward compatibility)
+			r.applyLegacyOp(b, data)
+		}
+	}
+
+	// Timestamps are ordered by commit order, identically on every replica
+	for _, op := range ops {
+		op.HLC = r.commitHLC(op.HLC)
+	}
+
+	for _, op := range ops {
+		r.applyOperation(b, op)
+	}
+
+	// A commit that cannot be written must not be skipped: the next commit would
+	// record a later applied index and the entry would never be replayed. Stop
+	// the node, it replays the commit from the persisted applied index on restart
+	if err := r.writeApplyBatch(b, commit.Index); err != nil {
+		log.Fatal("Failed to write commit",
+			zap.Error(err),
+			zap.Uint64("index", commit.Index),
+			zap.Int("batch_size", len(ops)),
+			zap.String("component", "storage-rocksdb"))
+	}
+
+	// Notify waiting clients only once the commit is written
+	r.pendingMu.Lock()
+	for _, op := range ops {
+		if ch, exists := r.pendingOps[op.SeqNum]; op.SeqNum != "" && exists {
+			close(ch)
+			delete(r.pendingOps, op.SeqNum)
+		}
 	}
+	r.pendingMu.Unlock()
+	r.applied.Advance(commit.Index)
+	close(commit.ApplyDoneC)
 }
 
-// applyOperation applies an etcd operation
-func (r *RocksDB) applyOperation(op RaftOperation) {
+// applyOperation adds the writes of an etcd operation to the batch of its commit
+func (r *RocksDB) applyOperation(b *applyBatch, op *RaftOperation) {
+	r.applyHLC = op.HLC
 	switch op.Type {
 	case "PUT":
-		// Apply PUT
-		if err := r.putUnlocked(op.Key, op.Value, op.LeaseID); err != nil {
+		events, err := r.preparePutBatch(b, op.Key, op.Value, op.LeaseID)
+		if err != nil {
 			log.Error("Failed to apply PUT operation",
 				zap.Error(err),
 				zap.String("key", op.Key),
 				zap.String("component", "storage-rocksdb"))
 		}
+		b.events = append(b.events, events...)
 
 	case "DELETE":
-		// Apply DELETE
-		if err := r.deleteUnlocked(op.Key, op.RangeEnd); err != nil {
+		events, err := r.prepareDeleteBatch(b, op.Key, op.RangeEnd)
+		if err != nil {
 			log.Error("Failed to apply DELETE operation",
 				zap.Error(err),
 				zap.String("key", op.Key),
 				zap.String("rangeEnd", op.RangeEnd),
 				zap.String("component", "storage-rocksdb"))
 		}
+		b.events = append(b.events, events...)
 
 	case "LEASE_GRANT":
-		// Apply Lease Grant
-		if err := r.leaseGrantUnlocked(op.LeaseID, op.TTL); err != nil {
+		if err := r.prepareLeaseGrantBatch(b, op.LeaseID, op.TTL); err != nil {
 			log.Error("Failed to apply LEASE_GRANT operation",
 				zap.Error(err),
 				zap.Int64("leaseID", op.LeaseID),
@@ -272,17 +607,17 @@ func (r *RocksDB) applyOperation(op RaftOperation) {
 		}
 
 	case "LEASE_REVOKE":
-		// Apply Lease Revoke
-		if err := r.leaseRevokeUnlocked(op.LeaseID); err != nil {
+		events, err := r.prepareLeaseRevokeBatch(b, op.LeaseID)
+		if err != nil {
 			log.Error("Failed to apply LEASE_REVOKE operation",
 				zap.Error(err),
 				zap.Int64("leaseID", op.LeaseID),
 				zap.String("component", "storage-rocksdb"))
 		}
+		b.events = append(b.events, events...)
 
 	case "TXN":
-		// Apply Transaction
-		txnResp, err := r.txnUnlocked(op.Compares, op.ThenOps, op.ElseOps)
+		txnResp, err := r.txnUnlocked(b, op.Compares, op.ThenOps, op.ElseOps)
 		if err != nil {
 			log.Error("Failed to apply TXN operation",
 				zap.Error(err),
@@ -293,9 +628,15 @@ func (r *RocksDB) applyOperation(op RaftOperation) {
 		}
 		// Save transaction result for client to read
 		if op.SeqNum != "" && txnResp != nil {
-			r.pendingMu.Lock()
-			r.pendingTxnResults[op.SeqNum] = txnResp
-			r.pendingMu.Unlock()
+			r.storeTxnResult(op.SeqNum, txnResp)
+		}
+
+	case "COMPACT":
+		if err := r.applyCompact(b, op.Revision); err != nil {
+			log.Error("Failed to apply COMPACT operation",
+				zap.Error(err),
+				zap.Int64("revision", op.Revision),
+				zap.String("component", "storage-rocksdb"))
 		}
 
 	default:
@@ -303,124 +644,10 @@ func (r *RocksDB) applyOperation(op RaftOperation) {
 			zap.String("type", op.Type),
 			zap.String("component", "storage-rocksdb"))
 	}
-
-	// Notify waiting client
-	if op.SeqNum != "" {
-		r.pendingMu.Lock()
-		if ch, exists := r.pendingOps[op.SeqNum]; exists {
-			close(ch)
-			delete(r.pendingOps, op.SeqNum)
-		}
-		r.pendingMu.Unlock()
-	}
-}
-
-// applyOperationsBatch applies multiple operations using a single WriteBatch
-// This significantly reduces the number of fsync calls and improves throughput
-func (r *RocksDB) applyOperationsBatch(ops []*RaftOperation) {
-	if len(ops) == 0 {
-		return
-	}
-
-	// Create a single WriteBatch for all operations
-	batch := grocksdb.NewWriteBatch()
-	defer batch.Destroy()
-
-	// Track watch events to emit after batch write completes
-	var watchEvents []kvstore.WatchEvent
-
-	// Process each operation and add to batch
-	for _, op := range ops {
-		switch op.Type {
-		case "PUT":
-			events, err := r.preparePutBatch(batch, op.Key, op.Value, op.LeaseID)
-			if err != nil {
-				log.Error("Failed to prepare PUT in batch",
-					zap.Error(err),
-					zap.String("key", op.Key),
-					zap.String("component", "storage-rocksdb"))
-				continue
-			}
-			watchEvents = append(watchEvents, events...)
-
-		case "DELETE":
-			events, err := r.prepareDeleteBatch(batch, op.Key, op.RangeEnd)
-			if err != nil {
-				log.Error("Failed to prepare DELETE in batch",
-					zap.Error(err),
-					zap.String("key", op.Key),
-					zap.String("component", "storage-rocksdb"))
-				continue
-			}
-			watchEvents = append(watchEvents, events...)
-
-		case "LEASE_GRANT":
-			if err := r.prepareLeaseGrantBatch(batch, op.LeaseID, op.TTL); err != nil {
-				log.Error("Failed to prepare LEASE_GRANT in batch",
-					zap.Error(err),
-					zap.Int64("leaseID", op.LeaseID),
-					zap.String("component", "storage-rocksdb"))
-			}
-
-		case "LEASE_REVOKE":
-			if err := r.prepareLeaseRevokeBatch(batch, op.LeaseID); err != nil {
-				log.Error("Failed to prepare LEASE_REVOKE in batch",
-					zap.Error(err),
-					zap.Int64("leaseID", op.LeaseID),
-					zap.String("component", "storage-rocksdb"))
-			}
-
-		case "TXN":
-			// Transactions need special handling - apply individually for now
-			// TODO: Optimize transaction batching in future
-			txnResp, err := r.txnUnlocked(op.Compares, op.ThenOps, op.ElseOps)
-			if err != nil {
-				log.Error("Failed to apply TXN in batch",
-					zap.Error(err),
-					zap.String("component", "storage-rocksdb"))
-			}
-			if op.SeqNum != "" && txnResp != nil {
-				r.pendingMu.Lock()
-				r.pendingTxnResults[op.SeqNum] = txnResp
-				r.pendingMu.Unlock()
-			}
-		}
-	}
-
-	// Atomic write of all operations in one fsync
-	if err := r.db.Write(r.wo, batch); err != nil {
-		log.Error("Failed to write batch",
-			zap.Error(err),
-			zap.Int("batch_size", len(ops)),
-			zap.String("component", "storage-rocksdb"))
-		return
-	}
-
-	// Notify waiting clients AFTER successful batch write
-	// This ensures data is committed before clients read it
-	for _, op := range ops {
-		if op.SeqNum != "" {
-			r.pendingMu.Lock()
-			if ch, exists := r.pendingOps[op.SeqNum]; exists {
-				close(ch)
-				delete(r.pendingOps, op.SeqNum)
-			}
-			r.pendingMu.Unlock()
-		}
-	}
-
-	// Emit all watch events after successful write
-	for _, event := range watchEvents {
-		r.notifyWatches(event)
-	}
-
-	log.Debug("Applied operations batch",
-		zap.Int("batch_size", len(ops)),
-		zap.String("component", "storage-rocksdb"))
 }
 
 // applyLegacyOp applies legacy gob-encoded operation (for backward compatibility)
-func (r *RocksDB) applyLegacyOp(data string) {
+func (r *RocksDB) applyLegacyOp(b *applyBatch, data string) {
 	var dataKv kvstore.KV
 	dec := gob.NewDecoder(bytes.NewBufferString(data))
 	if err := dec.Decode(&dataKv); err != nil {
@@ -429,13 +656,16 @@ func (r *RocksDB) applyLegacyOp(data string) {
 			zap.String("component", "storage-rocksdb"))
 	}
 
-	// Convert to etcd operation
-	if err := r.putUnlocked(dataKv.Key, dataKv.Val, 0); err != nil {
+	// Convert to etcd operation, legacy operations carry no timestamp
+	r.applyHLC = 0
+	events, err := r.preparePutBatch(b, dataKv.Key, dataKv.Val, 0)
+	if err != nil {
 		log.Error("Failed to apply legacy PUT operation",
 			zap.Error(err),
 			zap.String("key", dataKv.Key),
 			zap.String("component", "storage-rocksdb"))
 	}
+	b.events = append(b.events, events...)
 }
 
 // loadCurrentRevision loads the current revision from DB (used during initialization)
@@ -449,7 +679,11 @@ func (r *RocksDB) loadCurrentRevision() int64 {
 	if data.Size() == 0 {
 		return 0
 	}
+	if data.Size() == 8 {
+		return int64(binary.LittleEndian.Uint64(data.Data()))
+	}
 
+	// Older versions stored the revision gob-encoded
 	var rev int64
 	buf := bytes.NewBuffer(data.Data())
 	if err := gob.NewDecoder(buf).Decode(&rev); err != nil {
@@ -464,41 +698,21 @@ func (r *RocksDB) CurrentRevision() int64 {
 }
 
 // incrementRevision increments and returns new revision
+// The revision is persisted together with the applied index once the commit is
+// written (putProgress), so a commit replayed after a crash starts from the
+// same revision again
 func (r *RocksDB) incrementRevision() (int64, error) {
 	// Atomically increment cached revision
-	rev := r.cachedRevision.Add(1)
-
-	// Persist to DB using binary encoding for efficiency
-	buf := make([]byte, 8)
-	binary.LittleEndian.PutUint64(buf, uint64(rev))
-
-	if err := r.db.Put(r.wo, []byte(revisionKey), buf); err != nil {
-		// Rollback cache on error
-		r.cachedRevision.Add(-1)
-		return 0, err
-	}
-
-	return rev, nil
+	return r.cachedRevision.Add(1), nil
 }
 
 // Range performs range query
 func (r *RocksDB) Range(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
-	// Lease Read 优化: 检查是否可以使用快速路径
-	if r.raftNode != nil {
-		leaseManager := r.raftNode.LeaseManager()
-		readIndexManager := r.raftNode.ReadIndexManager()
-
-		if leaseManager != nil && readIndexManager != nil {
-			// Fast Path: Leader 有有效租约
-			if leaseManager.IsLeader() && leaseManager.HasValidLease() {
-				// 记录快速路径读取
-				readIndexManager.RecordFastPathRead()
-				// 继续执行下面的本地读取逻辑（已由租约保证线性一致性）
-			}
-			// Slow Path: 非 Leader 或租约失效
-			// TODO: 实现 ReadIndex 协议或转发给 Leader
-			// 当前简化实现：直接读取（在完整实现前保持向后兼容）
-		}
+	view := r.acquireReadView(ctx)
+	if view != nil {
+		defer view.release()
+	} else if err := r.readBarrier(ctx); err != nil {
+		return nil, err
 	}
 
 	// Pre-allocate slice with estimated capacity
@@ -508,341 +722,259 @@ func (r *RocksDB) Range(ctx context.Context, key, rangeEnd string, limit int64,
 	}
 	kvs := make([]*kvstore.KeyValue, 0, estimatedCap)
 
-	// Single key query
-	if rangeEnd == "" {
-		kv, err := r.getKeyValue(key)
-		if err == nil && kv != nil {
-			kvs = append(kvs, kv)
-		}
-	} else {
-		// Range query
-		it := r.db.NewIterator(r.ro)
-		defer it.Close()
-
-		startKey := []byte(kvPrefix + key)
-		it.Seek(startKey)
-
-		for it.ValidForPrefix([]byte(kvPrefix)) {
-			k := string(it.Key().Data())
-			k = k[len(kvPrefix):] // Remove prefix
-
-			if k >= key && (rangeEnd == "\x00" || k < rangeEnd) {
-				// Use optimized binary decoding instead of gob
-				kv, err := decodeKeyValue(it.Value().Data())
-				if err == nil && kv != nil {
-					kvs = append(kvs, kv)
-				}
-
-				// Early exit if limit reached
-				if limit > 0 && int64(len(kvs)) >= limit {
-					break
-				}
-			}
-
-			if rangeEnd != "\x00" && k >= rangeEnd {
-				break
-			}
-
-			it.Next()
+	// Read one key past the limit to report whether more remain
+	more := false
+	if err := r.scan(ctx, view, key, rangeEnd, func(kv *kvstore.KeyValue) bool {
+		if limit > 0 && int64(len(kvs)) >= limit {
+			more = true
+			return false
 		}
-
-		// Sort by key
-		sort.Slice(kvs, func(i, j int) bool {
-			return string(kvs[i].Key) < string(kvs[j].Key)
-		})
+		kvs = append(kvs, kv)
+		return true
+	}); err != nil {
+		return nil, err
 	}
 
-	// Apply limit
-	more := false
+	// Count is the number of keys in range, the keys past the limit are
+	// counted without decoding them
 	count := int64(len(kvs))
-	if limit > 0 && int64(len(kvs)) > limit {
-		kvs = kvs[:limit]
-		more = true
+	if more {
+		n, err := r.countKeys(ctx, view, string(kvs[len(kvs)-1].Key)+"\x00", rangeEnd)
+		if err != nil {
+			return nil, err
+		}
+		count += n
 	}
 
+	rev := r.CurrentRevision()
+	if view != nil {
+		rev = view.revision
+	}
 	return &kvstore.RangeResponse{
 		Kvs:      kvs,
 		More:     more,
 		Count:    count,
-		Revision: r.CurrentRevision(),
+		Revision: rev,
 	}, nil
 }
 
-// PutWithLease stores key-value with optional lease
-func (r *RocksDB) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
-	// Check prevKv before submitting to Raft
-	prevKv, _ := r.getKeyValue(key)
-
-	// Generate sequence number (lock-free atomic operation)
-	seq := r.seqNum.Add(1)
-	seqNum := fmt.Sprintf("seq-%d", seq)
-
-	// Create wait channel
-	waitCh := make(chan struct{})
-	r.pendingMu.Lock()
-	r.pendingOps[seqNum] = waitCh
-	r.pendingMu.Unlock()
+// RangeFunc streams the keys in range to fn in key order without
+// materializing the result. Consistency is the same as Range.
+func (r *RocksDB) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	view := r.acquireReadView(ctx)
+	if view != nil {
+		defer view.release()
+	} else if err := r.readBarrier(ctx); err != nil {
+		return 0, err
+	}
 
-	// Cleanup function to remove pending operation on error/timeout
-	cleanup := func() {
-		r.pendingMu.Lock()
-		delete(r.pendingOps, seqNum)
-		r.pendingMu.Unlock()
+	rev := r.CurrentRevision()
+	if view != nil {
+		rev = view.revision
+	}
+	if err := r.scan(ctx, view, key, rangeEnd, fn); err != nil {
+		return 0, err
 	}
+	return rev, nil
+}
 
-	op := RaftOperation{
-		Type:    "PUT",
-		Key:     key,
-		Value:   value,
-		LeaseID: leaseID,
-		SeqNum:  seqNum,
+// ReverseRangeFunc is RangeFunc in descending key order, walking a reverse
+// iterator from the end of the range.
+func (r *RocksDB) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	view := r.acquireReadView(ctx)
+	if view != nil {
+		defer view.release()
+	} else if err := r.readBarrier(ctx); err != nil {
+		return 0, err
 	}
 
-	data, err := marshalRaftOperation(&op)
-	if err != nil {
-		cleanup()
-		return 0, nil, err
+	rev := r.CurrentRevision()
+	if view != nil {
+		rev = view.revision
+	}
+	if err := r.reverseScan(ctx, view, key, rangeEnd, fn); err != nil {
+		return 0, err
 	}
+	return rev, nil
+}
 
-	// Use BatchProposer for improved throughput (统一使用 propose 辅助方法)
-	if err := r.propose(ctx, data); err != nil {
-		cleanup()
-		return 0, nil, err
+// readBarrier makes the local state safe for a linearizable read.
+// Leader with a valid lease reads directly, otherwise the ReadIndex protocol
+// waits for the local appliedIndex to catch up with the leader's readIndex.
+// Serializable reads access local state directly.
+func (r *RocksDB) readBarrier(ctx context.Context) error {
+	if r.raftNode == nil || kvstore.IsSerializable(ctx) {
+		return nil
 	}
 
-	// Wait for Raft commit with timeout
-	select {
-	case <-waitCh:
-		// Raft commit completed
-		currentRevision := r.CurrentRevision()
-		return currentRevision, prevKv, nil
-	case <-ctx.Done():
-		cleanup()
-		return 0, nil, ctx.Err()
-	case <-time.After(30 * time.Second):
-		cleanup()
-		return 0, nil, fmt.Errorf("timeout waiting for Raft commit")
+	if lm := r.raftNode.LeaseManager(); lm != nil && lm.IsLeader() && lm.HasValidLease() {
+		if rim := r.raftNode.ReadIndexManager(); rim != nil {
+			rim.RecordFastPathRead()
+		}
+		return nil
 	}
+	return r.raftNode.ReadIndex(ctx)
 }
 
-// preparePutBatch prepares a PUT operation to be added to a WriteBatch
-// Returns watch events to be emitted after batch write succeeds
-func (r *RocksDB) preparePutBatch(batch *grocksdb.WriteBatch, key, value string, leaseID int64) ([]kvstore.WatchEvent, error) {
-	// Get previous KeyValue
-	prevKv, _ := r.getKeyValue(key)
+// scan calls fn for every key in range in key order until fn returns false.
+// It reads from view when set, otherwise the latest state. Keys hidden by the
+// key filter of ctx are skipped before their values are decoded.
+func (r *RocksDB) scan(ctx context.Context, view *readView, key, rangeEnd string, fn func(kv *kvstore.KeyValue) bool) error {
+	visible := kvstore.KeyFilter(ctx)
 
-	// Increment revision
-	newRevision, err := r.incrementRevision()
-	if err != nil {
-		return nil, err
-	}
-
-	// Create or update KeyValue
-	var version int64 = 1
-	var createRevision int64 = newRevision
-	if prevKv != nil {
-		version = prevKv.Version + 1
-		createRevision = prevKv.CreateRevision
+	// Single key query
+	if rangeEnd == "" {
+		if visible != nil && !visible([]byte(key)) {
+			return nil
+		}
+		var kv *kvstore.KeyValue
+		var err error
+		if view != nil {
+			kv, err = r.getKeyValueAt(view.ro, key)
+		} else {
+			kv, err = r.lookupKeyValue(key)
+		}
+		if err != nil {
+			return err
+		}
+		if kv != nil {
+			fn(kv)
+		}
+		return nil
 	}
 
-	kv := &kvstore.KeyValue{
-		Key:            []byte(key),
-		Value:          []byte(value),
-		CreateRevision: createRevision,
-		ModRevision:    newRevision,
-		Version:        version,
-		Lease:          leaseID,
-	}
+	it := r.db.NewIterator(r.readOptions(view))
+	defer it.Close()
 
-	// Serialize using optimized binary encoding
-	encodedKV, err := encodeKeyValue(kv)
-	if err != nil {
-		return nil, err
-	}
+	prefix := []byte(kvPrefix)
+	for it.Seek([]byte(kvPrefix + key)); it.ValidForPrefix(prefix); it.Next() {
+		k := string(it.Key().Data()[len(kvPrefix):])
+		if rangeEnd != "\x00" && k >= rangeEnd {
+			break
+		}
 
-	// Add to batch
-	dbKey := []byte(kvPrefix + key)
-	batch.Put(dbKey, encodedKV)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if visible != nil && !visible(it.Key().Data()[len(kvPrefix):]) {
+			continue
+		}
 
-	// Update lease's key tracking if leaseID is specified
-	if leaseID != 0 {
-		lease, err := r.getLease(leaseID)
+		// Use optimized binary decoding instead of gob
+		kv, err := decodeKeyValue(it.Value().Data())
 		if err != nil {
-			return nil, fmt.Errorf("failed to get lease %d: %v", leaseID, err)
+			// 不能静默跳过，例如缺少解密所需的 KEK
+			return fmt.Errorf("failed to decode key %q: %w", k, err)
 		}
-		if lease != nil {
-			// Add key to lease's key set
-			if lease.Keys == nil {
-				lease.Keys = make(map[string]bool)
-			}
-			lease.Keys[key] = true
-
-			// Save updated lease to batch - 使用 Protobuf（20x 性能提升）
-			leaseData, err := common.SerializeLease(lease)
-			if err != nil {
-				return nil, fmt.Errorf("failed to encode lease: %v", err)
-			}
-
-			leaseKey := []byte(fmt.Sprintf("%s%d", leasePrefix, leaseID))
-			batch.Put(leaseKey, leaseData)
+		if kv != nil && !fn(kv) {
+			break
 		}
 	}
 
-	// Prepare watch event (to be emitted after successful write)
-	event := kvstore.WatchEvent{
-		Type:     kvstore.EventTypePut,
-		Kv:       kv,
-		PrevKv:   prevKv,
-		Revision: newRevision,
-	}
-
-	return []kvstore.WatchEvent{event}, nil
+	return it.Err()
 }
 
-// prepareDeleteBatch prepares a DELETE operation to be added to a WriteBatch
-// Returns watch events to be emitted after batch write succeeds
-func (r *RocksDB) prepareDeleteBatch(batch *grocksdb.WriteBatch, key, rangeEnd string) ([]kvstore.WatchEvent, error) {
-	// Get revision for watch events
-	newRevision, err := r.incrementRevision()
-	if err != nil {
-		return nil, err
-	}
-
-	var events []kvstore.WatchEvent
-
+// reverseScan calls fn for every key in range in descending key order until fn returns false.
+func (r *RocksDB) reverseScan(ctx context.Context, view *readView, key, rangeEnd string, fn func(kv *kvstore.KeyValue) bool) error {
 	if rangeEnd == "" {
-		// Single key delete - get old value first for watch event
-		prevKv, _ := r.getKeyValue(key)
-
-		dbKey := []byte(kvPrefix + key)
-		batch.Delete(dbKey)
-
-		// Prepare watch event if key existed
-		if prevKv != nil {
-			deletedKv := &kvstore.KeyValue{
-				Key:            prevKv.Key,
-				Value:          nil,
-				CreateRevision: prevKv.CreateRevision,
-				ModRevision:    newRevision,
-				Version:        0,
-				Lease:          0,
-			}
-			events = append(events, kvstore.WatchEvent{
-				Type:     kvstore.EventTypeDelete,
-				Kv:       deletedKv,
-				PrevKv:   prevKv,
-				Revision: newRevision,
-			})
-		}
-
-		return events, nil
+		return r.scan(ctx, view, key, rangeEnd, fn)
 	}
+	visible := kvstore.KeyFilter(ctx)
 
-	// Range delete - collect old values first
-	it := r.db.NewIterator(r.ro)
+	it := r.db.NewIterator(r.readOptions(view))
 	defer it.Close()
 
-	startKey := []byte(kvPrefix + key)
-	endKey := []byte(kvPrefix + rangeEnd)
+	// Position on the last key before the exclusive upper bound, "\x00" reads to
+	// the end of the kv prefix
+	prefix := []byte(kvPrefix)
+	end := []byte(kvPrefix + rangeEnd)
+	if rangeEnd == "\x00" {
+		end = []byte(kvPrefix[:len(kvPrefix)-1] + string(kvPrefix[len(kvPrefix)-1]+1))
+	}
+	it.SeekForPrev(end)
+	if it.Valid() && bytes.Equal(it.Key().Data(), end) {
+		it.Prev()
+	}
 
-	var toDelete []string
-	for it.Seek(startKey); it.Valid(); it.Next() {
-		k := it.Key()
-		if bytes.Compare(k.Data(), endKey) >= 0 {
+	for ; it.ValidForPrefix(prefix); it.Prev() {
+		k := string(it.Key().Data()[len(kvPrefix):])
+		if k < key {
 			break
 		}
 
-		// Extract actual key (remove prefix)
-		actualKey := string(k.Data()[len(kvPrefix):])
-		toDelete = append(toDelete, actualKey)
-		k.Free()
-	}
-
-	// Delete all keys in range
-	for _, actualKey := range toDelete {
-		prevKv, _ := r.getKeyValue(actualKey)
-
-		dbKey := []byte(kvPrefix + actualKey)
-		batch.Delete(dbKey)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if visible != nil && !visible(it.Key().Data()[len(kvPrefix):]) {
+			continue
+		}
 
-		// Prepare watch event
-		if prevKv != nil {
-			deletedKv := &kvstore.KeyValue{
-				Key:            prevKv.Key,
-				Value:          nil,
-				CreateRevision: prevKv.CreateRevision,
-				ModRevision:    newRevision,
-				Version:        0,
-				Lease:          0,
-			}
-			events = append(events, kvstore.WatchEvent{
-				Type:     kvstore.EventTypeDelete,
-				Kv:       deletedKv,
-				PrevKv:   prevKv,
-				Revision: newRevision,
-			})
+		kv, err := decodeKeyValue(it.Value().Data())
+		if err != nil {
+			return fmt.Errorf("failed to decode key %q: %w", k, err)
+		}
+		if kv != nil && !fn(kv) {
+			break
 		}
 	}
 
-	return events, nil
+	return it.Err()
 }
 
-// prepareLeaseGrantBatch prepares a LEASE_GRANT operation to be added to a WriteBatch
-func (r *RocksDB) prepareLeaseGrantBatch(batch *grocksdb.WriteBatch, leaseID, ttl int64) error {
-	lease := &kvstore.Lease{
-		ID:        leaseID,
-		TTL:       ttl,
-		GrantTime: timeNow(), // Set GrantTime
-		Keys:      make(map[string]bool),
-	}
+// countKeys returns the number of keys in [key, rangeEnd) that the key filter
+// of ctx does not hide
+func (r *RocksDB) countKeys(ctx context.Context, view *readView, key, rangeEnd string) (int64, error) {
+	visible := kvstore.KeyFilter(ctx)
+	it := r.db.NewIterator(r.readOptions(view))
+	defer it.Close()
 
-	// 使用 Protobuf 序列化（20x 性能提升）
-	data, err := common.SerializeLease(lease)
-	if err != nil {
-		return fmt.Errorf("failed to encode lease: %v", err)
+	var n int64
+	prefix := []byte(kvPrefix)
+	end := []byte(kvPrefix + rangeEnd)
+	for it.Seek([]byte(kvPrefix + key)); it.ValidForPrefix(prefix); it.Next() {
+		if rangeEnd != "\x00" && bytes.Compare(it.Key().Data(), end) >= 0 {
+			break
+		}
+		if n%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+		if visible != nil && !visible(it.Key().Data()[len(kvPrefix):]) {
+			continue
+		}
+		n++
 	}
-
-	leaseKey := []byte(fmt.Sprintf("%s%d", leasePrefix, leaseID))
-	batch.Put(leaseKey, data)
-
-	return nil
+	return n, it.Err()
 }
 
-// prepareLeaseRevokeBatch prepares a LEASE_REVOKE operation to be added to a WriteBatch
-func (r *RocksDB) prepareLeaseRevokeBatch(batch *grocksdb.WriteBatch, leaseID int64) error {
-	// Get the lease to find associated keys
-	lease, err := r.getLease(leaseID)
-	if err != nil {
-		return fmt.Errorf("failed to get lease %d: %v", leaseID, err)
-	}
+// PutWithLease stores key-value with optional lease
+func (r *RocksDB) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	// Check prevKv before submitting to Raft
+	prevKv, _ := r.getKeyValue(key)
 
-	if lease == nil {
-		// Lease doesn't exist, nothing to revoke
-		return nil
+	op := RaftOperation{
+		Type:    "PUT",
+		Key:     key,
+		Value:   value,
+		LeaseID: leaseID,
 	}
-
-	// Delete all keys associated with this lease
-	for key := range lease.Keys {
-		dbKey := []byte(kvPrefix + key)
-		batch.Delete(dbKey)
+	if err := r.proposeAndWait(ctx, &op); err != nil {
+		return 0, nil, err
 	}
-
-	// Delete the lease itself
-	leaseKey := []byte(fmt.Sprintf("%s%d", leasePrefix, leaseID))
-	batch.Delete(leaseKey)
-
-	return nil
+	return r.CurrentRevision(), prevKv, nil
 }
 
-// putUnlocked applies put operation (called after Raft commit)
-func (r *RocksDB) putUnlocked(key, value string, leaseID int64) error {
+// preparePutBatch adds a PUT operation to the batch of its commit
+// Returns watch events to be emitted after batch write succeeds
+func (r *RocksDB) preparePutBatch(b *applyBatch, key, value string, leaseID int64) ([]kvstore.WatchEvent, error) {
 	// Get previous KeyValue
-	prevKv, _ := r.getKeyValue(key)
+	prevKv, _ := r.pendingKeyValue(b, key)
 
 	// Increment revision
 	newRevision, err := r.incrementRevision()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create or update KeyValue
@@ -860,26 +992,24 @@ func (r *RocksDB) putUnlocked(key, value string, leaseID int64) error {
 		ModRevision:    newRevision,
 		Version:        version,
 		Lease:          leaseID,
+		HLC:            r.applyHLC,
 	}
 
 	// Serialize using optimized binary encoding
 	encodedKV, err := encodeKeyValue(kv)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Use WriteBatch for atomic multi-key operations
-	batch := grocksdb.NewWriteBatch()
-	defer batch.Destroy()
-
-	dbKey := []byte(kvPrefix + key)
-	batch.Put(dbKey, encodedKV)
+	// Add to batch
+	b.putKeyValue(kv, encodedKV)
+	r.putKeys = append(r.putKeys, key)
 
 	// Update lease's key tracking if leaseID is specified
 	if leaseID != 0 {
-		lease, err := r.getLease(leaseID)
+		lease, err := r.pendingLease(b, leaseID)
 		if err != nil {
-			return fmt.Errorf("failed to get lease %d: %v", leaseID, err)
+			return nil, fmt.Errorf("failed to get lease %d: %v", leaseID, err)
 		}
 		if lease != nil {
 			// Add key to lease's key set
@@ -888,214 +1018,82 @@ func (r *RocksDB) putUnlocked(key, value string, leaseID int64) error {
 			}
 			lease.Keys[key] = true
 
-			// Save updated lease - 使用 Protobuf（20x 性能提升）
-			leaseData, err := common.SerializeLease(lease)
-			if err != nil {
-				return fmt.Errorf("failed to encode lease: %v", err)
+			// Save updated lease to batch
+			if err := b.putLease(lease); err != nil {
+				return nil, err
 			}
-
-			leaseKey := []byte(fmt.Sprintf("%s%d", leasePrefix, leaseID))
-			batch.Put(leaseKey, leaseData)
 		}
 	}
 
-	// Atomic commit of all operations
-	if err := r.db.Write(r.wo, batch); err != nil {
-		return err
-	}
-
-	// Trigger watch events
-	r.notifyWatches(kvstore.WatchEvent{
+	// Prepare watch event (to be emitted after successful write)
+	event := kvstore.WatchEvent{
 		Type:     kvstore.EventTypePut,
 		Kv:       kv,
 		PrevKv:   prevKv,
 		Revision: newRevision,
-	})
-
-	return nil
-}
-
-// DeleteRange deletes keys in range
-func (r *RocksDB) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
-	// Check what will be deleted (before Raft commit)
-	var deleted int64
-	var prevKvs []*kvstore.KeyValue
-
-	if rangeEnd == "" {
-		kv, err := r.getKeyValue(key)
-		if err == nil && kv != nil {
-			deleted = 1
-			prevKvs = append(prevKvs, kv)
-		}
-	} else {
-		// Range delete - scan first
-		it := r.db.NewIterator(r.ro)
-		defer it.Close()
-
-		startKey := []byte(kvPrefix + key)
-		it.Seek(startKey)
-
-		for it.ValidForPrefix([]byte(kvPrefix)) {
-			k := string(it.Key().Data())
-			k = k[len(kvPrefix):]
-
-			if k >= key && (rangeEnd == "\x00" || k < rangeEnd) {
-				var kv kvstore.KeyValue
-				if err := gob.NewDecoder(bytes.NewBuffer(it.Value().Data())).Decode(&kv); err == nil {
-					deleted++
-					prevKvs = append(prevKvs, &kv)
-				}
-			}
-
-			if rangeEnd != "\x00" && k >= rangeEnd {
-				break
-			}
-
-			it.Next()
-		}
 	}
 
-	if deleted == 0 {
-		return 0, nil, r.CurrentRevision(), nil
-	}
-
-	// Generate sequence number (lock-free atomic operation)
-	seq := r.seqNum.Add(1)
-	seqNum := fmt.Sprintf("seq-%d", seq)
-
-	// Create wait channel
-	waitCh := make(chan struct{})
-	r.pendingMu.Lock()
-	r.pendingOps[seqNum] = waitCh
-	r.pendingMu.Unlock()
-
-	// Cleanup function to remove pending operation on error/timeout
-	cleanup := func() {
-		r.pendingMu.Lock()
-		delete(r.pendingOps, seqNum)
-		r.pendingMu.Unlock()
-	}
-
-	op := RaftOperation{
-		Type:     "DELETE",
-		Key:      key,
-		RangeEnd: rangeEnd,
-		SeqNum:   seqNum,
-	}
-
-	data, err := marshalRaftOperation(&op)
-	if err != nil {
-		cleanup()
-		return 0, nil, 0, err
-	}
-
-	// Use BatchProposer for improved throughput (统一使用 propose 辅助方法)
-	if err := r.propose(ctx, data); err != nil {
-		cleanup()
-		return 0, nil, 0, err
-	}
+	return []kvstore.WatchEvent{event}, nil
+}
 
-	// Wait for Raft commit with timeout
-	select {
-	case <-waitCh:
-		// Raft commit completed
-		return deleted, prevKvs, r.CurrentRevision(), nil
-	case <-ctx.Done():
-		cleanup()
-		return 0, nil, 0, ctx.Err()
-	case <-time.After(30 * time.Second):
-		cleanup()
-		return 0, nil, 0, fmt.Errorf("timeout waiting for Raft commit")
+// prepareDeleteBatch adds a DELETE operation to the batch of its commit
+// Returns watch events to be emitted after batch write succeeds. The revision
+// only advances when the range holds at least one key
+//
+// A large range that no watcher covers is deleted without reading all of its
+// keys: it has no watch events, and the event log records the range as
+// omitted so that a watch cannot resume across the delete
+func (r *RocksDB) prepareDeleteBatch(b *applyBatch, key, rangeEnd string) ([]kvstore.WatchEvent, error) {
+	limit := 0
+	if rangeEnd != "" && !r.rangeWatched(key, rangeEnd) {
+		limit = deleteRangeMinKeys
+	}
+	keys, prevKvs := r.deleteTargets(b, key, rangeEnd, limit)
+	if len(keys) == 0 {
+		return nil, nil
 	}
-}
 
-// deleteUnlocked applies delete operation (called after Raft commit)
-func (r *RocksDB) deleteUnlocked(key, rangeEnd string) error {
-	// Get revision for watch events
+	// Get revision for watch events, one revision for the whole range
 	newRevision, err := r.incrementRevision()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if rangeEnd == "" {
-		// Single key delete - get old value first for watch event
-		prevKv, _ := r.getKeyValue(key)
-
-		dbKey := []byte(kvPrefix + key)
-		if err := r.db.Delete(r.wo, dbKey); err != nil {
-			return err
-		}
-
-		// Trigger watch event if key existed
-		if prevKv != nil {
-			// For DELETE events, Kv contains the deleted key with ModRevision set to deletion revision
-			deletedKv := &kvstore.KeyValue{
-				Key:            prevKv.Key,
-				Value:          nil, // Value is nil for deleted key
-				CreateRevision: prevKv.CreateRevision,
-				ModRevision:    newRevision, // Set to deletion revision
-				Version:        0,           // Version is 0 for deleted key
-				Lease:          0,
-			}
-			r.notifyWatches(kvstore.WatchEvent{
-				Type:     kvstore.EventTypeDelete,
-				Kv:       deletedKv,
-				PrevKv:   prevKv,
-				Revision: newRevision,
-			})
-		}
-
-		return nil
+	// Large ranges are removed with a single range tombstone instead of a
+	// point delete per key, the keys are only read for the watch events
+	rangeTombstone := rangeEnd != "" && len(keys) >= deleteRangeMinKeys
+	if rangeTombstone {
+		b.deleteRange(key, rangeEnd)
+	}
+	if limit > 0 && len(keys) >= limit {
+		kr := keyRange{start: key, end: rangeEnd}
+		b.omitted = append(b.omitted, omittedRange{keyRange: kr, revision: newRevision})
+		r.deletedRanges = append(r.deletedRanges, kr)
+		return nil, nil
 	}
 
-	// Range delete - collect old values first
-	it := r.db.NewIterator(r.ro)
-	defer it.Close()
-
-	wb := grocksdb.NewWriteBatch()
-	defer wb.Destroy()
-
-	var deletedKeys []*kvstore.KeyValue
-
-	startKey := []byte(kvPrefix + key)
-	it.Seek(startKey)
-
-	for it.ValidForPrefix([]byte(kvPrefix)) {
-		k := string(it.Key().Data())
-		k = k[len(kvPrefix):]
-
-		if k >= key && (rangeEnd == "\x00" || k < rangeEnd) {
-			// Get old value for watch event
-			var kv kvstore.KeyValue
-			if err := gob.NewDecoder(bytes.NewBuffer(it.Value().Data())).Decode(&kv); err == nil {
-				deletedKeys = append(deletedKeys, &kv)
-			}
-			wb.Delete(it.Key().Data())
+	events := make([]kvstore.WatchEvent, 0, len(keys))
+	for i, k := range keys {
+		if !rangeTombstone {
+			b.deleteKeyValue(k)
 		}
+		r.deletedKeys = append(r.deletedKeys, k)
 
-		if rangeEnd != "\x00" && k >= rangeEnd {
-			break
+		// Prepare watch event, undecodable values are deleted without one
+		prevKv := prevKvs[i]
+		if prevKv == nil {
+			continue
 		}
-
-		it.Next()
-	}
-
-	if err := r.db.Write(r.wo, wb); err != nil {
-		return err
-	}
-
-	// Trigger watch events for all deleted keys
-	for _, prevKv := range deletedKeys {
-		// For DELETE events, Kv contains the deleted key with ModRevision set to deletion revision
 		deletedKv := &kvstore.KeyValue{
 			Key:            prevKv.Key,
-			Value:          nil, // Value is nil for deleted key
+			Value:          nil,
 			CreateRevision: prevKv.CreateRevision,
-			ModRevision:    newRevision, // Set to deletion revision
-			Version:        0,           // Version is 0 for deleted key
+			ModRevision:    newRevision,
+			Version:        0,
 			Lease:          0,
+			HLC:            r.applyHLC,
 		}
-		r.notifyWatches(kvstore.WatchEvent{
+		events = append(events, kvstore.WatchEvent{
 			Type:     kvstore.EventTypeDelete,
 			Kv:       deletedKv,
 			PrevKv:   prevKv,
@@ -1103,157 +1101,161 @@ func (r *RocksDB) deleteUnlocked(key, rangeEnd string) error {
 		})
 	}
 
-	return nil
+	return events, nil
 }
 
-// LeaseGrant creates a lease
-func (r *RocksDB) LeaseGrant(ctx context.Context, id int64, ttl int64) (*kvstore.Lease, error) {
-	// Generate sequence number (lock-free atomic operation)
-	seq := r.seqNum.Add(1)
-	seqNum := fmt.Sprintf("seq-%d", seq)
-
-	// Create wait channel
-	waitCh := make(chan struct{})
-	r.pendingMu.Lock()
-	r.pendingOps[seqNum] = waitCh
-	r.pendingMu.Unlock()
-
-	// Cleanup function to remove pending operation on error/timeout
-	cleanup := func() {
-		r.pendingMu.Lock()
-		delete(r.pendingOps, seqNum)
-		r.pendingMu.Unlock()
+// deleteRangeBounds returns the database keys bounding a delete of [key, rangeEnd),
+// a rangeEnd of "\x00" runs to the end of the kv prefix
+func deleteRangeBounds(key, rangeEnd string) ([]byte, []byte) {
+	start := []byte(kvPrefix + key)
+	if rangeEnd == "\x00" {
+		end := []byte(kvPrefix)
+		end[len(end)-1]++
+		return start, end
 	}
+	return start, []byte(kvPrefix + rangeEnd)
+}
 
-	op := RaftOperation{
-		Type:    "LEASE_GRANT",
-		LeaseID: id,
-		TTL:     ttl,
-		SeqNum:  seqNum,
+// deleteTargets returns the keys a delete of [key, rangeEnd) removes and their
+// values as seen by the commit being applied, b is nil outside of a commit.
+// A value that cannot be decoded is returned as nil. A positive limit stops
+// reading the database after that many keys
+func (r *RocksDB) deleteTargets(b *applyBatch, key, rangeEnd string, limit int) ([]string, []*kvstore.KeyValue) {
+	if rangeEnd == "" {
+		kv, err := r.pendingKeyValue(b, key)
+		if err == nil && kv == nil {
+			return nil, nil
+		}
+		return []string{key}, []*kvstore.KeyValue{kv}
 	}
 
-	data, err := marshalRaftOperation(&op)
-	if err != nil {
-		cleanup()
-		return nil, err
-	}
+	it := r.db.NewIterator(r.ro)
+	defer it.Close()
 
-	// Use BatchProposer for improved throughput (统一使用 propose 辅助方法)
-	if err := r.propose(ctx, data); err != nil {
-		cleanup()
-		return nil, err
+	var keys []string
+	var prevKvs []*kvstore.KeyValue
+	prefix := []byte(kvPrefix)
+	for it.Seek([]byte(kvPrefix + key)); it.ValidForPrefix(prefix); it.Next() {
+		k := string(it.Key().Data()[len(kvPrefix):])
+		if rangeEnd != "\x00" && k >= rangeEnd {
+			break
+		}
+		if _, written := b.lookup(k); written {
+			continue
+		}
+		if limit > 0 && len(keys) >= limit {
+			break
+		}
+		kv, _ := decodeKeyValue(it.Value().Data())
+		keys = append(keys, k)
+		prevKvs = append(prevKvs, kv)
 	}
-
-	// Wait for Raft commit with timeout
-	select {
-	case <-waitCh:
-		// Raft commit completed
-		// Return lease info
-		return r.getLease(id)
-	case <-ctx.Done():
-		cleanup()
-		return nil, ctx.Err()
-	case <-time.After(30 * time.Second):
-		cleanup()
-		return nil, fmt.Errorf("timeout waiting for Raft commit")
+	if b != nil {
+		keys, prevKvs = b.mergePending(key, rangeEnd, keys, prevKvs)
 	}
+	return keys, prevKvs
 }
 
-// leaseGrantUnlocked applies lease grant (called after Raft commit)
-func (r *RocksDB) leaseGrantUnlocked(id int64, ttl int64) error {
-	lease := &kvstore.Lease{
-		ID:        id,
+// prepareLeaseGrantBatch adds a LEASE_GRANT operation to the batch of its commit
+func (r *RocksDB) prepareLeaseGrantBatch(b *applyBatch, leaseID, ttl int64) error {
+	return b.putLease(&kvstore.Lease{
+		ID:        leaseID,
 		TTL:       ttl,
-		GrantTime: timeNow(),
+		GrantTime: timeNow(), // Set GrantTime
 		Keys:      make(map[string]bool),
+	})
+}
+
+// prepareLeaseRevokeBatch adds a LEASE_REVOKE operation to the batch of its commit
+// Each associated key is deleted at its own revision, in key order so that every
+// replica assigns the same revisions, and the DELETE watch events are returned
+func (r *RocksDB) prepareLeaseRevokeBatch(b *applyBatch, leaseID int64) ([]kvstore.WatchEvent, error) {
+	// Get the lease to find associated keys
+	lease, err := r.pendingLease(b, leaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease %d: %v", leaseID, err)
+	}
+
+	if lease == nil {
+		// Lease doesn't exist, nothing to revoke
+		return nil, nil
 	}
 
-	// 使用 Protobuf 序列化（20x 性能提升）
-	data, err := common.SerializeLease(lease)
-	if err != nil {
-		return err
+	// Delete all keys associated with this lease
+	keys := make([]string, 0, len(lease.Keys))
+	for key := range lease.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var events []kvstore.WatchEvent
+	for _, key := range keys {
+		keyEvents, err := r.prepareDeleteBatch(b, key, "")
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, keyEvents...)
 	}
 
-	dbKey := []byte(fmt.Sprintf("%s%d", leasePrefix, id))
-	return r.db.Put(r.wo, dbKey, data)
-}
+	// Delete the lease itself
+	b.deleteLease(leaseID)
 
-// LeaseRevoke revokes a lease
-func (r *RocksDB) LeaseRevoke(ctx context.Context, id int64) error {
-	// Generate sequence number (lock-free atomic operation)
-	seq := r.seqNum.Add(1)
-	seqNum := fmt.Sprintf("seq-%d", seq)
+	return events, nil
+}
 
-	// Create wait channel
-	waitCh := make(chan struct{})
-	r.pendingMu.Lock()
-	r.pendingOps[seqNum] = waitCh
-	r.pendingMu.Unlock()
+// DeleteRange deletes keys in range
+func (r *RocksDB) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	// Check what will be deleted (before Raft commit)
+	prevKvs := r.deletedKeyValues(nil, key, rangeEnd)
+	deleted := int64(len(prevKvs))
 
-	// Cleanup function to remove pending operation on error/timeout
-	cleanup := func() {
-		r.pendingMu.Lock()
-		delete(r.pendingOps, seqNum)
-		r.pendingMu.Unlock()
+	if deleted == 0 {
+		return 0, nil, r.CurrentRevision(), nil
 	}
 
 	op := RaftOperation{
-		Type:    "LEASE_REVOKE",
-		LeaseID: id,
-		SeqNum:  seqNum,
-	}
-
-	data, err := marshalRaftOperation(&op)
-	if err != nil {
-		cleanup()
-		return err
+		Type:     "DELETE",
+		Key:      key,
+		RangeEnd: rangeEnd,
 	}
-
-	// Use BatchProposer for improved throughput (统一使用 propose 辅助方法)
-	if err := r.propose(ctx, data); err != nil {
-		cleanup()
-		return err
+	if err := r.proposeAndWait(ctx, &op); err != nil {
+		return 0, nil, 0, err
 	}
+	return deleted, prevKvs, r.CurrentRevision(), nil
+}
 
-	// Wait for Raft commit with timeout
-	select {
-	case <-waitCh:
-		// Raft commit completed
-		return nil
-	case <-ctx.Done():
-		cleanup()
-		return ctx.Err()
-	case <-time.After(30 * time.Second):
-		cleanup()
-		return fmt.Errorf("timeout waiting for Raft commit")
+// deletedKeyValues returns the decodable values a delete of [key, rangeEnd) removes,
+// as seen by the commit being applied when b is not nil
+func (r *RocksDB) deletedKeyValues(b *applyBatch, key, rangeEnd string) []*kvstore.KeyValue {
+	_, kvs := r.deleteTargets(b, key, rangeEnd, 0)
+	prevKvs := kvs[:0]
+	for _, kv := range kvs {
+		if kv != nil {
+			prevKvs = append(prevKvs, kv)
+		}
 	}
+	return prevKvs
 }
 
-// leaseRevokeUnlocked applies lease revoke (called after Raft commit)
-func (r *RocksDB) leaseRevokeUnlocked(id int64) error {
-	// Get lease to find associated keys
-	lease, err := r.getLease(id)
-	if err != nil {
-		return err
+// LeaseGrant creates a lease
+func (r *RocksDB) LeaseGrant(ctx context.Context, id int64, ttl int64) (*kvstore.Lease, error) {
+	op := RaftOperation{
+		Type:    "LEASE_GRANT",
+		LeaseID: id,
+		TTL:     ttl,
 	}
-	if lease == nil {
-		return nil // Already deleted
+	if err := r.proposeAndWait(ctx, &op); err != nil {
+		return nil, err
 	}
+	return r.getLease(id)
+}
 
-	// Delete all keys associated with this lease
-	for key := range lease.Keys {
-		if err := r.deleteUnlocked(key, ""); err != nil {
-			log.Error("Failed to delete key during lease revoke",
-				zap.Error(err),
-				zap.String("key", key),
-				zap.Int64("leaseID", id),
-				zap.String("component", "storage-rocksdb"))
-		}
+// LeaseRevoke revokes a lease
+func (r *RocksDB) LeaseRevoke(ctx context.Context, id int64) error {
+	op := RaftOperation{
+		Type:    "LEASE_REVOKE",
+		LeaseID: id,
 	}
-
-	// Delete lease
-	dbKey := []byte(fmt.Sprintf("%s%d", leasePrefix, id))
-	return r.db.Delete(r.wo, dbKey)
+	return r.proposeAndWait(ctx, &op)
 }
 
 // Watch creates a watch and returns an event channel
@@ -1263,6 +1265,10 @@ func (r *RocksDB) Watch(ctx context.Context, key, rangeEnd string, startRevision
 
 // WatchWithOptions creates a watch with options
 func (r *RocksDB) WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error) {
+	// A commit checks for watchers before deleting an unwatched range without
+	// its events, so a watch is registered between commits
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
 	r.watchMu.Lock()
 	defer r.watchMu.Unlock()
 
@@ -1271,17 +1277,27 @@ func (r *RocksDB) WatchWithOptions(key, rangeEnd string, startRevision int64, wa
 		return nil, fmt.Errorf("watch ID %d already exists", watchID)
 	}
 
-	// Create event channel (buffered to avoid blocking)
-	eventCh := make(chan kvstore.WatchEvent, 100)
+	// Events after startRevision are replayed from the event log when it still
+	// holds all of them, otherwise from a snapshot of the current data below
+	var replay []kvstore.WatchEvent
+	replayed := false
+	if startRevision > 0 && startRevision <= r.CurrentRevision() {
+		replay, replayed = r.logEvents(key, rangeEnd, startRevision)
+	}
+
+	// Create event channel (buffered to avoid blocking), replayed events all fit
+	eventCh := make(chan kvstore.WatchEvent, 100+len(replay))
 
 	// Parse options
 	var prevKV, progressNotify, fragment bool
 	var filters []kvstore.WatchFilterType
+	var valueFilter func([]byte) bool
 	if opts != nil {
 		prevKV = opts.PrevKV
 		progressNotify = opts.ProgressNotify
 		filters = opts.Filters
 		fragment = opts.Fragment
+		valueFilter = opts.ValueFilter
 	}
 
 	// Create subscription
@@ -1296,13 +1312,29 @@ func (r *RocksDB) WatchWithOptions(key, rangeEnd string, startRevision int64, wa
 		progressNotify: progressNotify,
 		filters:        filters,
 		fragment:       fragment,
+		valueFilter:    valueFilter,
+	}
+
+	// Holding watchMu keeps notifyWatches from delivering, so replayed events
+	// come before live ones, and revisions already replayed are skipped there
+	for _, event := range replay {
+		if r.shouldFilter(event.Type, filters) || !kvstore.MatchValue(event, valueFilter) {
+			continue
+		}
+		if !prevKV {
+			event.PrevKv = nil
+		}
+		eventCh <- event
+	}
+	if n := len(replay); n > 0 {
+		sub.replayedRev = replay[n-1].Revision
 	}
 
 	r.watches[watchID] = sub
 
 	// 如果 startRevision > 0，发送历史事件
 	// 注意：当前实现不保留完整历史，只能从当前数据生成初始快照
-	if startRevision > 0 && startRevision < r.CurrentRevision() {
+	if !replayed && startRevision > 0 && startRevision < r.CurrentRevision() {
 		// 异步发送当前所有匹配的键作为 PUT 事件
 		go r.sendHistoricalEvents(sub, key, rangeEnd)
 	}
@@ -1310,10 +1342,77 @@ func (r *RocksDB) WatchWithOptions(key, rangeEnd string, startRevision int64, wa
 	return eventCh, nil
 }
 
+// logEvents returns the events in [key, rangeEnd) from startRevision on, ok is
+// false when the event log no longer holds all of them
+func (r *RocksDB) logEvents(key, rangeEnd string, startRevision int64) ([]kvstore.WatchEvent, bool) {
+	events, err := r.events.Events(startRevision, func(k []byte) bool {
+		return r.matchWatch(string(k), key, rangeEnd)
+	})
+	if err != nil {
+		return nil, false
+	}
+	watched := keyRange{start: key, end: rangeEnd}
+	if r.events.Omitted(startRevision, func(start, end []byte) bool {
+		omitted := keyRange{start: string(start), end: string(end)}
+		if len(end) == 0 {
+			omitted.end = "\x00"
+		}
+		return omitted.overlaps(watched)
+	}) {
+		return nil, false
+	}
+	result := make([]kvstore.WatchEvent, len(events))
+	for i, ev := range events {
+		result[i] = kvstore.WatchEvent{
+			Type:     kvstore.EventType(ev.Type),
+			Kv:       (*kvstore.KeyValue)(ev.Kv),
+			PrevKv:   (*kvstore.KeyValue)(ev.PrevKv),
+			Revision: ev.Kv.ModRevision,
+		}
+	}
+	return result, true
+}
+
+// KeyHistory returns the revisions of key newest first, deletions as
+// tombstones. RocksDB keeps only the latest value of each key, older revisions
+// come from the watch event log, so the history reaches back as far as the log
+// does (server.mvcc.watch_history); compacted is where the log starts
+func (r *RocksDB) KeyHistory(ctx context.Context, key string, limit int64) ([]*kvstore.KeyValue, int64, error) {
+	if err := r.readBarrier(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	// Read the value before the log, any change made in between is logged
+	current, err := r.getKeyValue(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	events, compacted := r.events.KeyEvents([]byte(key))
+
+	var kvs []*kvstore.KeyValue
+	for i := len(events) - 1; i >= 0; i-- {
+		kvs = append(kvs, (*kvstore.KeyValue)(events[i].Kv))
+	}
+	// A value older than the log is the oldest revision known
+	if current != nil && current.ModRevision <= compacted {
+		kvs = append(kvs, current)
+	}
+	if limit > 0 && int64(len(kvs)) > limit {
+		kvs = kvs[:limit]
+	}
+	return kvs, compacted, nil
+}
+
+// SetWatchHistory sets the size and retention of the event log used to resume
+// watches, independently of compaction; a zero maxEvents disables it
+func (r *RocksDB) SetWatchHistory(maxEvents int, retention time.Duration) {
+	r.events.SetLimits(maxEvents, retention)
+}
+
 // sendHistoricalEvents 发送历史事件（从当前数据快照）
 func (r *RocksDB) sendHistoricalEvents(sub *watchSubscription, key, rangeEnd string) {
 	// 使用 Range 查询获取所有匹配的键
-	resp, err := r.Range(context.Background(), key, rangeEnd, 0, 0)
+	resp, err := r.Range(kvstore.WithSerializable(context.Background()), key, rangeEnd, 0, 0)
 	if err != nil {
 		log.Error("Failed to get historical events for watch",
 			zap.Error(err),
@@ -1332,6 +1431,9 @@ func (r *RocksDB) sendHistoricalEvents(sub *watchSubscription, key, rangeEnd str
 			PrevKv:   nil, // 历史事件不返回 prevKv
 			Revision: kv.ModRevision,
 		}
+		if !kvstore.MatchValue(event, sub.valueFilter) {
+			continue
+		}
 
 		// 非阻塞发送
 		select {
@@ -1378,17 +1480,39 @@ func (r *RocksDB) CancelWatch(watchID int64) error {
 	return nil
 }
 
+// Watches 返回当前活跃的 watch（按 ID 排序）
+func (r *RocksDB) Watches() []kvstore.WatchInfo {
+	r.watchMu.RLock()
+	infos := make([]kvstore.WatchInfo, 0, len(r.watches))
+	for _, sub := range r.watches {
+		if sub.closed.Load() {
+			continue
+		}
+		infos = append(infos, kvstore.WatchInfo{
+			ID:            sub.watchID,
+			Key:           sub.key,
+			RangeEnd:      sub.rangeEnd,
+			StartRevision: sub.startRev,
+			PrevKV:        sub.prevKV,
+			Pending:       len(sub.eventCh) + sub.queue.Len(),
+		})
+	}
+	r.watchMu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
 // Compact compresses historical data before specified revision
-// Lightweight implementation that:
-// 1. Records compacted revision for client query validation
-// 2. Triggers RocksDB physical compaction (SST file merging)
-// 3. Cleans up expired lease metadata
+// The compaction is proposed through Raft as a COMPACT operation, so every
+// replica compacts at the same revision and watchers see the same boundary
+// on any node. Requests that are bound to fail are rejected before proposing
 func (r *RocksDB) Compact(ctx context.Context, revision int64) error {
 	currentRev := r.CurrentRevision()
 
 	// Validation: cannot compact future revisions
 	if revision > currentRev {
-		return fmt.Errorf("cannot compact to future revision %d (current: %d)", revision, currentRev)
+		return fmt.Errorf("%w: cannot compact to future revision %d (current: %d)", mvcc.ErrFutureRevision, revision, currentRev)
 	}
 
 	// Validation: cannot compact to revision 0 or negative
@@ -1396,13 +1520,45 @@ func (r *RocksDB) Compact(ctx context.Context, revision int64) error {
 		return fmt.Errorf("invalid compact revision: %d", revision)
 	}
 
+	r.mu.Lock()
+	compactedRev := r.getCompactedRevisionUnlocked()
+	r.mu.Unlock()
+	if revision <= compactedRev {
+		return fmt.Errorf("%w: already compacted to revision %d (requested: %d)", mvcc.ErrCompacted, compactedRev, revision)
+	}
+
+	op := RaftOperation{
+		Type:     "COMPACT",
+		Revision: revision,
+	}
+	return r.proposeAndWait(ctx, &op)
+}
+
+// applyCompact applies a committed COMPACT operation on this replica
+// Lightweight implementation that:
+// 1. Records compacted revision for client query validation
+// 2. Cleans up expired lease metadata
+// 3. Triggers RocksDB physical compaction (SST file merging) once the batch is written
+//
+// A compaction proposed again after a leader change, or overtaken by a later
+// one, finds the revision already compacted and does nothing
+func (r *RocksDB) applyCompact(b *applyBatch, revision int64) error {
+	currentRev := r.CurrentRevision()
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// Get current compacted revision
-	compactedRev := r.getCompactedRevisionUnlocked()
+	compactedRev := b.compacted
+	if compactedRev == 0 {
+		compactedRev = r.getCompactedRevisionUnlocked()
+	}
 	if revision <= compactedRev {
-		return fmt.Errorf("already compacted to revision %d (requested: %d)", compactedRev, revision)
+		log.Debug("Revision already compacted",
+			zap.Int64("revision", revision),
+			zap.Int64("lastCompacted", compactedRev),
+			zap.String("component", "storage-rocksdb"))
+		return nil
 	}
 
 	log.Info("Starting compact operation",
@@ -1411,29 +1567,17 @@ func (r *RocksDB) Compact(ctx context.Context, revision int64) error {
 		zap.Int64("lastCompacted", compactedRev),
 		zap.String("component", "storage-rocksdb"))
 
-	startTime := time.Now()
-
 	// 1. Record compacted revision
-	if err := r.setCompactedRevisionUnlocked(revision); err != nil {
-		return fmt.Errorf("failed to record compacted revision: %w", err)
-	}
-
-	// 2. Trigger RocksDB physical compaction (SST file merging)
-	// This reclaims space from deleted keys and reduces read amplification
-	startKey := []byte(kvPrefix)
-	endKey := []byte(kvPrefix + "\xff")
-
-	// CompactRange is asynchronous but we can wait for it
-	r.db.CompactRange(grocksdb.Range{Start: startKey, Limit: endKey})
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(revision))
+	b.Put([]byte("meta:compacted_revision"), value)
+	b.compacted = revision
 
-	// 3. Optional: Clean up expired leases (best effort)
+	// 2. Optional: Clean up expired leases (best effort)
 	// This doesn't affect correctness but helps reclaim space
-	cleanedLeases := r.cleanupExpiredLeasesUnlocked()
-
-	duration := time.Since(startTime)
-	log.Info("Compact operation completed",
+	cleanedLeases := r.cleanupExpiredLeasesUnlocked(b)
+	log.Debug("Cleaned up expired leases",
 		zap.Int64("revision", revision),
-		zap.Duration("duration", duration),
 		zap.Int("cleanedLeases", cleanedLeases),
 		zap.String("component", "storage-rocksdb"))
 
@@ -1457,18 +1601,9 @@ func (r *RocksDB) getCompactedRevisionUnlocked() int64 {
 	return int64(binary.BigEndian.Uint64(data))
 }
 
-// setCompactedRevisionUnlocked writes the compacted revision to DB (caller must hold lock)
-func (r *RocksDB) setCompactedRevisionUnlocked(revision int64) error {
-	key := []byte("meta:compacted_revision")
-	value := make([]byte, 8)
-	binary.BigEndian.PutUint64(value, uint64(revision))
-
-	return r.db.Put(r.wo, key, value)
-}
-
-// cleanupExpiredLeasesUnlocked removes expired leases (caller must hold lock)
-// Returns number of cleaned leases
-func (r *RocksDB) cleanupExpiredLeasesUnlocked() int {
+// cleanupExpiredLeasesUnlocked adds the removal of expired leases to the batch
+// (caller must hold lock). Returns number of cleaned leases
+func (r *RocksDB) cleanupExpiredLeasesUnlocked(b *applyBatch) int {
 	cleaned := 0
 	now := time.Now()
 
@@ -1492,12 +1627,8 @@ func (r *RocksDB) cleanupExpiredLeasesUnlocked() int {
 		if elapsed > time.Duration(lease.TTL)*time.Second {
 			// Delete expired lease metadata
 			// Note: Associated keys are already deleted by LeaseManager
-			if err := r.db.Delete(r.wo, it.Key().Data()); err != nil {
-				log.Warn("Failed to delete expired lease",
-					zap.Error(err),
-					zap.Int64("leaseID", lease.ID),
-					zap.String("component", "storage-rocksdb"))
-			} else {
+			if _, written := b.leases[lease.ID]; !written {
+				b.deleteLease(lease.ID)
 				cleaned++
 			}
 		}
@@ -1514,7 +1645,7 @@ func (r *RocksDB) LeaseRenew(ctx context.Context, id int64) (*kvstore.Lease, err
 		return nil, err
 	}
 	if lease == nil {
-		return nil, fmt.Errorf("lease not found: %d", id)
+		return nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, id)
 	}
 
 	// Update grant time
@@ -1527,7 +1658,7 @@ func (r *RocksDB) LeaseRenew(ctx context.Context, id int64) (*kvstore.Lease, err
 	}
 
 	dbKey := []byte(fmt.Sprintf("%s%d", leasePrefix, id))
-	if err := r.db.Put(r.wo, dbKey, data); err != nil {
+	if err := r.putKey(dbKey, data); err != nil {
 		return nil, err
 	}
 
@@ -1536,7 +1667,14 @@ func (r *RocksDB) LeaseRenew(ctx context.Context, id int64) (*kvstore.Lease, err
 
 // LeaseTimeToLive gets remaining time of a lease
 func (r *RocksDB) LeaseTimeToLive(ctx context.Context, id int64) (*kvstore.Lease, error) {
-	return r.getLease(id)
+	lease, err := r.getLease(id)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil {
+		return nil, fmt.Errorf("%w: %d", kvstore.ErrLeaseNotFound, id)
+	}
+	return lease, nil
 }
 
 // Leases returns all leases
@@ -1569,7 +1707,7 @@ func (r *RocksDB) Propose(k string, v string) {
 
 // Lookup looks up a key (for backward compatibility)
 func (r *RocksDB) Lookup(key string) (string, bool) {
-	kv, err := r.getKeyValue(key)
+	kv, err := r.lookupKeyValue(key)
 	if err != nil || kv == nil {
 		return "", false
 	}
@@ -1578,74 +1716,38 @@ func (r *RocksDB) Lookup(key string) (string, bool) {
 
 // Txn executes a transaction (through Raft)
 func (r *RocksDB) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
-	// Generate sequence number (lock-free atomic operation)
-	seq := r.seqNum.Add(1)
-	seqNum := fmt.Sprintf("seq-%d", seq)
-
-	// Create wait channel
-	waitCh := make(chan struct{})
-	r.pendingMu.Lock()
-	r.pendingOps[seqNum] = waitCh
-	r.pendingMu.Unlock()
-
-	// Cleanup function to remove pending operation on error/timeout
-	cleanup := func() {
-		r.pendingMu.Lock()
-		delete(r.pendingOps, seqNum)
-		delete(r.pendingTxnResults, seqNum)
-		r.pendingMu.Unlock()
-	}
-
 	op := RaftOperation{
 		Type:     "TXN",
 		Compares: cmps,
 		ThenOps:  thenOps,
 		ElseOps:  elseOps,
-		SeqNum:   seqNum,
-	}
-
-	// Serialize and propose
-	data, err := marshalRaftOperation(&op)
-	if err != nil {
-		cleanup()
-		return nil, err
 	}
-
-	// Use BatchProposer for improved throughput (统一使用 propose 辅助方法)
-	if err := r.propose(ctx, data); err != nil {
-		cleanup()
+	if err := r.proposeAndWait(ctx, &op); err != nil {
 		return nil, err
 	}
 
-	// Wait for Raft commit with timeout
-	select {
-	case <-waitCh:
-		// Raft commit completed
-		// Read transaction result
-		r.pendingMu.Lock()
-		txnResp := r.pendingTxnResults[seqNum]
-		delete(r.pendingTxnResults, seqNum) // Clean up result
-		r.pendingMu.Unlock()
-
-		if txnResp == nil {
-			return nil, fmt.Errorf("transaction result not found")
-		}
+	// Read transaction result
+	r.pendingMu.Lock()
+	txnResp := r.pendingTxnResults[op.SeqNum]
+	delete(r.pendingTxnResults, op.SeqNum) // Clean up result
+	r.pendingMu.Unlock()
 
-		return txnResp, nil
-	case <-ctx.Done():
-		cleanup()
-		return nil, ctx.Err()
-	case <-time.After(30 * time.Second):
-		cleanup()
-		return nil, fmt.Errorf("timeout waiting for Raft commit")
+	if txnResp == nil {
+		return nil, fmt.Errorf("transaction result not found")
 	}
+	return txnResp, nil
 }
 
 // Helper functions
 
 func (r *RocksDB) getKeyValue(key string) (*kvstore.KeyValue, error) {
+	return r.getKeyValueAt(r.ro, key)
+}
+
+// getKeyValueAt reads a key with the given read options, which may pin a snapshot
+func (r *RocksDB) getKeyValueAt(ro *grocksdb.ReadOptions, key string) (*kvstore.KeyValue, error) {
 	dbKey := []byte(kvPrefix + key)
-	data, err := r.db.Get(r.ro, dbKey)
+	data, err := r.db.Get(ro, dbKey)
 	if err != nil {
 		return nil, err
 	}
@@ -1687,14 +1789,37 @@ func (r *RocksDB) getLease(id int64) (*kvstore.Lease, error) {
 
 // Snapshot support
 
+// GetSnapshot serializes the state machine as of the last applied raft index
+//
+// Applying is paused only while a RocksDB snapshot is pinned together with the
+// applied index; the scan then reads the pinned snapshot while commits keep
+// being applied. The index travels with the data so the restore can verify it
 func (r *RocksDB) GetSnapshot() ([]byte, error) {
-	// Create snapshot of all data
+	r.applyMu.Lock()
+	dbSnap := r.db.NewSnapshot()
+	index := r.applied.Applied()
+	r.applyMu.Unlock()
+	defer r.db.ReleaseSnapshot(dbSnap)
+
+	ro := grocksdb.NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetSnapshot(dbSnap)
+	ro.SetFillCache(false) // a full scan must not evict the working set of readers
+
+	// Create snapshot of the state machine, the raft storage sharing the DB is not included
 	snapshot := make(map[string][]byte)
+	snapshot[snapshotIndexKey] = binary.BigEndian.AppendUint64(nil, index)
 
-	it := r.db.NewIterator(r.ro)
+	it := r.db.NewIterator(ro)
 	defer it.Close()
 
-	for it.SeekToFirst(); it.Valid(); it.Next() {
+	for it.Seek([]byte(stateMachineStart)); it.Valid(); it.Next() {
+		if string(it.Key().Data()) >= stateMachineEnd {
+			break
+		}
+		if !isStateMachineKey(string(it.Key().Data())) {
+			continue
+		}
 		key := make([]byte, len(it.Key().Data()))
 		copy(key, it.Key().Data())
 
@@ -1709,7 +1834,7 @@ func (r *RocksDB) GetSnapshot() ([]byte, error) {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return reliability.SealSnapshot(compressSnapshot(buf.Bytes())), nil
 }
 
 func (r *RocksDB) loadSnapshot() (*raftpb.Snapshot, error) {
@@ -1723,31 +1848,6 @@ func (r *RocksDB) loadSnapshot() (*raftpb.Snapshot, error) {
 	return snapshot, nil
 }
 
-func (r *RocksDB) recoverFromSnapshot(snapshot []byte) error {
-	var snapshotData map[string][]byte
-	if err := gob.NewDecoder(bytes.NewBuffer(snapshot)).Decode(&snapshotData); err != nil {
-		return err
-	}
-
-	// Clear existing data
-	it := r.db.NewIterator(r.ro)
-	defer it.Close()
-
-	wb := grocksdb.NewWriteBatch()
-	defer wb.Destroy()
-
-	for it.SeekToFirst(); it.Valid(); it.Next() {
-		wb.Delete(it.Key().Data())
-	}
-
-	// Restore from snapshot
-	for k, v := range snapshotData {
-		wb.Put([]byte(k), v)
-	}
-
-	return r.db.Write(r.wo, wb)
-}
-
 // timeNow returns current timestamp
 func timeNow() time.Time {
 	return time.Now()
@@ -1755,6 +1855,12 @@ func timeNow() time.Time {
 
 // notifyWatches notifies all matching watches (high-performance lock-free version)
 func (r *RocksDB) notifyWatches(event kvstore.WatchEvent) {
+	r.events.Append(event.Revision, mvcc.WatchEvent{
+		Type:   mvcc.EventType(event.Type),
+		Kv:     (*mvcc.KeyValue)(event.Kv),
+		PrevKv: (*mvcc.KeyValue)(event.PrevKv),
+	})
+
 	key := ""
 	if event.Kv != nil {
 		key = string(event.Kv.Key)
@@ -1778,7 +1884,11 @@ func (r *RocksDB) notifyWatches(event kvstore.WatchEvent) {
 	// Send events outside of lock
 	for _, sub := range matchingSubs {
 		// Apply filters
-		if r.shouldFilter(event.Type, sub.filters) {
+		if r.shouldFilter(event.Type, sub.filters) || !kvstore.MatchValue(event, sub.valueFilter) {
+			continue
+		}
+		// Already delivered by the replay from the event log
+		if event.Revision <= sub.replayedRev {
 			continue
 		}
 
@@ -1788,16 +1898,8 @@ func (r *RocksDB) notifyWatches(event kvstore.WatchEvent) {
 			eventToSend.PrevKv = nil
 		}
 
-		// Non-blocking send with slow client handling
-		select {
-		case sub.eventCh <- eventToSend:
-			// Success
-		case <-sub.cancel:
-			// Watch已取消
-		default:
-			// Channel满了，异步发送（慢客户端）
-			go r.slowSendEvent(sub, eventToSend)
-		}
+		// Delivered in notification order, queued while the channel is full
+		sub.queue.Send(sub.eventCh, sub.cancel, eventToSend, func() { r.cancelSlowWatch(sub) })
 	}
 }
 
@@ -1818,23 +1920,25 @@ func (r *RocksDB) shouldFilter(eventType kvstore.EventType, filters []kvstore.Wa
 	return false
 }
 
-// slowSendEvent handles slow clients with timeout
-func (r *RocksDB) slowSendEvent(sub *watchSubscription, event kvstore.WatchEvent) {
-	timer := time.NewTimer(5 * time.Second)
-	defer timer.Stop()
+// cancelSlowWatch cancels a watch that has not taken its queued events in time
+func (r *RocksDB) cancelSlowWatch(sub *watchSubscription) {
+	log.Warn("Watch is too slow, force cancelling",
+		zap.Int64("watchID", sub.watchID),
+		zap.String("component", "storage-rocksdb"))
+	r.CancelWatch(sub.watchID)
+}
 
-	select {
-	case sub.eventCh <- event:
-		// Successfully sent after retry
-	case <-sub.cancel:
-		// Watch cancelled
-	case <-timer.C:
-		// Timeout - force cancel this slow watch
-		log.Warn("Watch is too slow, force cancelling",
-			zap.Int64("watchID", sub.watchID),
-			zap.String("component", "storage-rocksdb"))
-		r.CancelWatch(sub.watchID)
+// rangeWatched returns whether a watch covers any key of [key, rangeEnd)
+func (r *RocksDB) rangeWatched(key, rangeEnd string) bool {
+	kr := keyRange{start: key, end: rangeEnd}
+	r.watchMu.RLock()
+	defer r.watchMu.RUnlock()
+	for _, sub := range r.watches {
+		if !sub.closed.Load() && kr.overlaps(keyRange{start: sub.key, end: sub.rangeEnd}) {
+			return true
+		}
 	}
+	return false
 }
 
 // matchWatch checks if key matches watch range
@@ -1848,11 +1952,12 @@ func (r *RocksDB) matchWatch(key, watchKey, rangeEnd string) bool {
 }
 
 // txnUnlocked executes a transaction (called after Raft commit, must be called without external locks)
-func (r *RocksDB) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+// Its writes go to the batch of the commit, reads see the writes made before them
+func (r *RocksDB) txnUnlocked(b *applyBatch, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
 	// Evaluate all compare conditions
 	succeeded := true
 	for _, cmp := range cmps {
-		if !r.evaluateCompare(cmp) {
+		if !r.evaluateCompare(b, cmp) {
 			succeeded = false
 			break
 		}
@@ -1871,7 +1976,7 @@ func (r *RocksDB) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, else
 	for i, op := range ops {
 		switch op.Type {
 		case kvstore.OpRange:
-			resp, err := r.Range(context.Background(), string(op.Key), string(op.RangeEnd), op.Limit, 0)
+			resp, err := r.pendingRange(b, string(op.Key), string(op.RangeEnd), op.Limit)
 			if err != nil {
 				return nil, err
 			}
@@ -1880,14 +1985,15 @@ func (r *RocksDB) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, else
 				RangeResp: resp,
 			}
 		case kvstore.OpPut:
-			// For txn operations, we need to call the unlocked version
 			// Get previous value first
-			prevKv, _ := r.getKeyValue(string(op.Key))
+			prevKv, _ := r.pendingKeyValue(b, string(op.Key))
 
 			// Apply put
-			if err := r.putUnlocked(string(op.Key), string(op.Value), op.LeaseID); err != nil {
+			events, err := r.preparePutBatch(b, string(op.Key), string(op.Value), op.LeaseID)
+			if err != nil {
 				return nil, err
 			}
+			b.events = append(b.events, events...)
 
 			responses[i] = kvstore.OpResponse{
 				Type: kvstore.OpPut,
@@ -1898,49 +2004,17 @@ func (r *RocksDB) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, else
 			}
 		case kvstore.OpDelete:
 			// Get previous values first
-			var deleted int64
-			var prevKvs []*kvstore.KeyValue
-
 			key := string(op.Key)
 			rangeEnd := string(op.RangeEnd)
-
-			if rangeEnd == "" {
-				if kv, err := r.getKeyValue(key); err == nil && kv != nil {
-					deleted = 1
-					prevKvs = append(prevKvs, kv)
-				}
-			} else {
-				// Range delete - scan first
-				it := r.db.NewIterator(r.ro)
-				defer it.Close()
-
-				startKey := []byte(kvPrefix + key)
-				it.Seek(startKey)
-
-				for it.ValidForPrefix([]byte(kvPrefix)) {
-					k := string(it.Key().Data())
-					k = k[len(kvPrefix):]
-
-					if k >= key && (rangeEnd == "\x00" || k < rangeEnd) {
-						var kv kvstore.KeyValue
-						if err := gob.NewDecoder(bytes.NewBuffer(it.Value().Data())).Decode(&kv); err == nil {
-							deleted++
-							prevKvs = append(prevKvs, &kv)
-						}
-					}
-
-					if rangeEnd != "\x00" && k >= rangeEnd {
-						break
-					}
-
-					it.Next()
-				}
-			}
+			prevKvs := r.deletedKeyValues(b, key, rangeEnd)
+			deleted := int64(len(prevKvs))
 
 			// Apply delete
-			if err := r.deleteUnlocked(key, rangeEnd); err != nil {
+			events, err := r.prepareDeleteBatch(b, key, rangeEnd)
+			if err != nil {
 				return nil, err
 			}
+			b.events = append(b.events, events...)
 
 			responses[i] = kvstore.OpResponse{
 				Type: kvstore.OpDelete,
@@ -1961,8 +2035,8 @@ func (r *RocksDB) txnUnlocked(cmps []kvstore.Compare, thenOps []kvstore.Op, else
 }
 
 // evaluateCompare evaluates a compare condition
-func (r *RocksDB) evaluateCompare(cmp kvstore.Compare) bool {
-	kv, _ := r.getKeyValue(string(cmp.Key))
+func (r *RocksDB) evaluateCompare(b *applyBatch, cmp kvstore.Compare) bool {
+	kv, _ := r.pendingKeyValue(b, string(cmp.Key))
 	exists := (kv != nil)
 
 	switch cmp.Target {
@@ -2037,6 +2111,54 @@ func (r *RocksDB) SetRaftNode(node RaftNode, nodeID uint64) {
 	r.nodeID = nodeID
 }
 
+// SetProposeQueue sets the propose queue; proposals enter it by priority instead
+// of proposeC. Call before serving requests
+func (r *RocksDB) SetProposeQueue(q *kvstore.ProposeQueue) {
+	r.queue = q
+}
+
+// SetBackpressure sets the backpressure parameters of the propose pipeline, safe to call at runtime
+func (r *RocksDB) SetBackpressure(b kvstore.Backpressure) {
+	r.backpressure.Store(&b)
+}
+
+
+// WriteIndex returns a read-after-write token, a raft index covering every write
+// completed on this node
+func (r *RocksDB) WriteIndex() uint64 {
+	return r.applied.WriteIndex()
+}
+
+// WaitApplied waits until this node has applied a read-after-write token, using the
+// default backpressure timeout when ctx has no deadline
+func (r *RocksDB) WaitApplied(ctx context.Context, index uint64) error {
+	ctx, cancel := r.backpressure.Load().WithTimeout(ctx)
+	defer cancel()
+	if err := r.applied.Wait(ctx, index); err != nil {
+		return &kvstore.StageError{Op: "READ", Stage: kvstore.StageCatchUp, Err: err}
+	}
+	return nil
+}
+
+// ProposeQueueStats returns the current state of the propose pipeline
+func (r *RocksDB) ProposeQueueStats() kvstore.ProposeQueueStats {
+	r.pendingMu.RLock()
+	pending := len(r.pendingOps)
+	r.pendingMu.RUnlock()
+	if r.queue != nil {
+		stats := r.queue.Stats()
+		stats.Pending = pending
+		stats.Rejected = r.rejected.Load()
+		return stats
+	}
+	return kvstore.ProposeQueueStats{
+		Queued:   len(r.proposeC),
+		Capacity: cap(r.proposeC),
+		Pending:  pending,
+		Rejected: r.rejected.Load(),
+	}
+}
+
 // GetRaftStatus 获取 Raft 状态信息
 func (r *RocksDB) GetRaftStatus() kvstore.RaftStatus {
 	if r.raftNode == nil {
@@ -2064,9 +2186,42 @@ func (r *RocksDB) TransferLeadership(targetID uint64) error {
 	// 检查当前节点是否是 leader
 	status := r.raftNode.Status()
 	if status.LeaderID != r.nodeID {
-		return fmt.Errorf("not leader, current leader: %d", status.LeaderID)
+		return kvstore.NotLeaderError(status.LeaderID)
 	}
 
 	// 调用 Raft 节点的 TransferLeadership
 	return r.raftNode.TransferLeadership(targetID)
 }
+
+// MoveLeader 将 leader 转移给日志最新的活跃 follower（用于优雅关闭）
+// 返回新 leader 的 ID
+func (r *RocksDB) MoveLeader(ctx context.Context) (uint64, error) {
+	if r.raftNode == nil {
+		return 0, fmt.Errorf("raft node not available")
+	}
+	return r.raftNode.MoveLeader(ctx)
+}
+
+// ReplaceMember 用新的 learner 替换故障成员（只能在 leader 上执行）
+func (r *RocksDB) ReplaceMember(ctx context.Context, req kvstore.MemberReplaceRequest, report func(kvstore.MemberReplaceProgress)) error {
+	if r.raftNode == nil {
+		return fmt.Errorf("raft node not available")
+	}
+	return r.raftNode.ReplaceMember(ctx, req, report)
+}
+
+// Members 返回集群成员状态，没有 Raft 节点时返回 nil
+func (r *RocksDB) Members() []kvstore.MemberStatus {
+	if r.raftNode == nil {
+		return nil
+	}
+	return r.raftNode.Members()
+}
+
+// RaftLog 解码 from 开始的 limit 个 raft 日志条目，from 为 0 时返回最后 limit 个，redact 隐藏 value
+func (r *RocksDB) RaftLog(from uint64, limit int, redact bool) (*kvstore.RaftLog, error) {
+	if r.raftNode == nil {
+		return nil, fmt.Errorf("raft node not available")
+	}
+	return r.raftNode.RaftLog(from, limit, redact)
+}
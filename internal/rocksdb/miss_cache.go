@@ -0,0 +1,170 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"metaStore/internal/kvstore"
+)
+
+// missCache is an LRU of keys recently looked up and found absent, so repeated
+// single-key reads of missing keys (common in service discovery) skip RocksDB.
+//
+// Entries are dropped once a put to the key is visible in the DB and before the
+// put is acknowledged. A lookup that raced with a put must not record a stale
+// miss, so every invalidation bumps gen and add rejects results read under an
+// older generation.
+type missCache struct {
+	mu   sync.Mutex
+	size int
+	lru  *list.List // Most recently used first, values are keys
+	keys map[string]*list.Element
+	gen  uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newMissCache(size int) *missCache {
+	return &missCache{
+		size: size,
+		lru:  list.New(),
+		keys: make(map[string]*list.Element),
+	}
+}
+
+// lookup reports whether key is known to be absent, along with the current
+// generation to pass to add after reading the DB
+func (c *missCache) lookup(key string) (bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.keys[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.hits.Add(1)
+		return true, c.gen
+	}
+	c.misses.Add(1)
+	return false, c.gen
+}
+
+// add records key as absent, unless an invalidation happened since gen was returned by lookup
+func (c *missCache) add(key string, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	if elem, ok := c.keys[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.keys[key] = c.lru.PushFront(key)
+	for c.lru.Len() > c.size {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// invalidate drops keys that were just written
+func (c *missCache) invalidate(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, key := range keys {
+		if elem, ok := c.keys[key]; ok {
+			c.removeLocked(elem)
+		}
+	}
+}
+
+// purge drops all entries, used when the state machine is replaced by a snapshot
+func (c *missCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.lru.Init()
+	c.keys = make(map[string]*list.Element)
+}
+
+func (c *missCache) removeLocked(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.keys, elem.Value.(string))
+}
+
+func (c *missCache) stats() kvstore.MissCacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	return kvstore.MissCacheStats{
+		Entries:  entries,
+		Capacity: c.size,
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+	}
+}
+
+// SetMissCache enables the negative lookup cache holding up to size absent keys,
+// size 0 disables it. Call it before serving reads
+func (r *RocksDB) SetMissCache(size int) {
+	if size <= 0 {
+		r.missCache.Store(nil)
+		return
+	}
+	r.missCache.Store(newMissCache(size))
+}
+
+// MissCacheStats returns the statistics of the negative lookup cache, all zero when disabled
+func (r *RocksDB) MissCacheStats() kvstore.MissCacheStats {
+	if c := r.missCache.Load(); c != nil {
+		return c.stats()
+	}
+	return kvstore.MissCacheStats{}
+}
+
+// lookupKeyValue reads a single key for clients, answering repeated lookups of
+// absent keys from the negative lookup cache and of present keys from the read cache
+func (r *RocksDB) lookupKeyValue(key string) (*kvstore.KeyValue, error) {
+	c := r.missCache.Load()
+	if c == nil {
+		return r.readKeyValue(key)
+	}
+	absent, gen := c.lookup(key)
+	if absent {
+		return nil, nil
+	}
+	kv, err := r.readKeyValue(key)
+	if err == nil && kv == nil {
+		c.add(key, gen)
+	}
+	return kv, err
+}
+
+// forgetMisses drops the keys put by the batch just written from the negative
+// lookup cache (called under applyMu)
+func (r *RocksDB) forgetMisses() {
+	if len(r.putKeys) == 0 {
+		return
+	}
+	if c := r.missCache.Load(); c != nil {
+		c.invalidate(r.putKeys)
+	}
+	r.putKeys = r.putKeys[:0]
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissCache(t *testing.T) {
+	c := newMissCache(2)
+
+	absent, gen := c.lookup("a")
+	assert.False(t, absent)
+	c.add("a", gen)
+	absent, _ = c.lookup("a")
+	assert.True(t, absent)
+
+	// 读取期间发生过失效的结果不写入缓存
+	_, gen = c.lookup("b")
+	c.invalidate([]string{"x"})
+	c.add("b", gen)
+	absent, _ = c.lookup("b")
+	assert.False(t, absent)
+
+	// 超出容量时淘汰最久未使用的 key
+	_, gen = c.lookup("c")
+	c.add("c", gen)
+	_, gen = c.lookup("d")
+	c.add("d", gen)
+	absent, _ = c.lookup("a")
+	assert.False(t, absent)
+
+	c.invalidate([]string{"c"})
+	absent, _ = c.lookup("c")
+	assert.False(t, absent)
+
+	assert.Equal(t, kvstore.MissCacheStats{Entries: 1, Capacity: 2, Hits: 1, Misses: 7}, c.stats())
+}
+
+func TestRocksDB_MissCache(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	store.SetMissCache(16)
+
+	_, ok := store.Lookup("svc/a")
+	assert.False(t, ok)
+	_, ok = store.Lookup("svc/a")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), store.MissCacheStats().Hits)
+
+	// 写入后缓存的 miss 立即失效
+	require.NoError(t, store.putUnlocked("svc/a", "v1", 0))
+	value, ok := store.Lookup("svc/a")
+	assert.True(t, ok)
+	assert.Equal(t, "v1", value)
+
+	// 批量 apply 的写入同样使缓存失效
+	resp, err := store.Range(context.Background(), "svc/b", "", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Kvs)
+	assert.Equal(t, 1, store.MissCacheStats().Entries)
+
+	batch := store.newApplyBatch()
+	defer batch.Destroy()
+	_, err = store.preparePutBatch(batch, "svc/b", "v1", 0)
+	require.NoError(t, err)
+	require.NoError(t, store.writeApplyBatch(batch, 0))
+	resp, err = store.Range(context.Background(), "svc/b", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v1", string(resp.Kvs[0].Value))
+
+	// 恢复快照后清空缓存
+	snapshot, err := store.GetSnapshot()
+	require.NoError(t, err)
+	_, ok = store.Lookup("svc/c")
+	assert.False(t, ok)
+	assert.Equal(t, 1, store.MissCacheStats().Entries)
+	require.NoError(t, store.recoverFromSnapshot(snapshot, 0))
+	assert.Equal(t, 0, store.MissCacheStats().Entries)
+}
@@ -17,9 +17,11 @@ package rocksdb
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"sync"
 
 	"metaStore/internal/kvstore"
+	"metaStore/pkg/encryption"
 )
 
 // Object pools for performance optimization
@@ -76,12 +78,40 @@ func putKVSlice(slice *[]*kvstore.KeyValue) {
 }
 
 // Binary encoding for KeyValue (faster than gob)
-// Format: [keyLen(4)][key][valueLen(4)][value][createRev(8)][modRev(8)][version(8)][lease(8)]
+// Format: [keyLen(4)][key][valueLen(4)][value][createRev(8)][modRev(8)][version(8)][lease(8)][flags(1)][hlc(8)]
+// The flags byte is only present when non-zero, so plain uncompressed records keep the original format.
+// The hlc field follows the flags byte when recordFlagHLC is set
+
+// Record flags
+const (
+	// recordFlagEncrypted the value field holds an encryption envelope bound to the key
+	recordFlagEncrypted byte = 1 << 0
+	// recordFlagSnappy the value was compressed with snappy before encryption
+	recordFlagSnappy byte = 1 << 1
+	// recordFlagZstd the value was compressed with zstd before encryption
+	recordFlagZstd byte = 1 << 2
+	// recordFlagHLC the record ends with the hybrid logical clock timestamp of its last modification
+	recordFlagHLC byte = 1 << 3
+)
 
 // encodeKeyValue encodes a KeyValue to binary format
+// Large values are compressed first, then encrypted with the active key when encryption is enabled
 func encodeKeyValue(kv *kvstore.KeyValue) ([]byte, error) {
+	value, flags := compress(kv.Value)
+	if keyring := encryption.Current(); keyring != nil {
+		sealed, err := keyring.Encrypt(value, kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt value: %w", err)
+		}
+		value = sealed
+		flags |= recordFlagEncrypted
+	}
+	if kv.HLC != 0 {
+		flags |= recordFlagHLC
+	}
+
 	// Calculate total size
-	size := 4 + len(kv.Key) + 4 + len(kv.Value) + 8*4
+	size := 4 + len(kv.Key) + 4 + len(value) + 8*4 + 1 + 8
 
 	buf := getBuffer()
 	defer putBuffer(buf)
@@ -92,14 +122,20 @@ func encodeKeyValue(kv *kvstore.KeyValue) ([]byte, error) {
 	buf.Write(kv.Key)
 
 	// Write value length and value
-	binary.Write(buf, binary.LittleEndian, uint32(len(kv.Value)))
-	buf.Write(kv.Value)
+	binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+	buf.Write(value)
 
 	// Write fixed-size fields
 	binary.Write(buf, binary.LittleEndian, kv.CreateRevision)
 	binary.Write(buf, binary.LittleEndian, kv.ModRevision)
 	binary.Write(buf, binary.LittleEndian, kv.Version)
 	binary.Write(buf, binary.LittleEndian, kv.Lease)
+	if flags != 0 {
+		buf.WriteByte(flags)
+	}
+	if flags&recordFlagHLC != 0 {
+		binary.Write(buf, binary.LittleEndian, kv.HLC)
+	}
 
 	// Return a copy since we're reusing the buffer
 	result := make([]byte, buf.Len())
@@ -138,6 +174,52 @@ func decodeKeyValue(data []byte) (*kvstore.KeyValue, error) {
 	kv.Version = int64(binary.LittleEndian.Uint64(data[offset:]))
 	offset += 8
 	kv.Lease = int64(binary.LittleEndian.Uint64(data[offset:]))
+	offset += 8
+
+	if offset >= len(data) {
+		return kv, nil
+	}
+	flags := data[offset]
+	offset++
+	if flags&recordFlagHLC != 0 {
+		if offset+8 > len(data) {
+			return nil, fmt.Errorf("truncated record: missing hlc")
+		}
+		kv.HLC = binary.LittleEndian.Uint64(data[offset:])
+	}
+	if flags&recordFlagEncrypted != 0 {
+		keyring := encryption.Current()
+		if keyring == nil {
+			return nil, encryption.ErrNoKeyring
+		}
+		value, err := keyring.Decrypt(kv.Value, kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		kv.Value = value
+	}
+	value, err := decompress(kv.Value, flags)
+	if err != nil {
+		return nil, err
+	}
+	kv.Value = value
 
 	return kv, nil
 }
+
+// recordKeyID returns the id of the key that encrypted a record, "" for plain records
+func recordKeyID(data []byte) (string, error) {
+	if len(data) < 8 {
+		return "", nil
+	}
+	keyLen := int(binary.LittleEndian.Uint32(data))
+	offset := 4 + keyLen
+	valueLen := int(binary.LittleEndian.Uint32(data[offset:]))
+	offset += 4
+	value := data[offset : offset+valueLen]
+	offset += valueLen + 8*4
+	if offset >= len(data) || data[offset]&recordFlagEncrypted == 0 {
+		return "", nil
+	}
+	return encryption.KeyID(value)
+}
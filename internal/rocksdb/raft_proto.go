@@ -15,8 +15,12 @@
 package rocksdb
 
 import (
+	"encoding/json"
+
 	"metaStore/internal/kvstore"
 	pb "metaStore/internal/proto"
+	"metaStore/pkg/config"
+
 	"google.golang.org/protobuf/proto"
 )
 
@@ -30,6 +34,8 @@ func toProto(op *RaftOperation) *pb.RaftOperation {
 		RangeEnd: op.RangeEnd,
 		SeqNum:   op.SeqNum,
 		Ttl:      op.TTL,
+		Hlc:      op.HLC,
+		Revision: op.Revision,
 	}
 
 	// Convert Compares
@@ -69,6 +75,8 @@ func fromProto(pbOp *pb.RaftOperation) *RaftOperation {
 		RangeEnd: pbOp.RangeEnd,
 		SeqNum:   pbOp.SeqNum,
 		TTL:      pbOp.Ttl,
+		HLC:      pbOp.Hlc,
+		Revision: pbOp.Revision,
 	}
 
 	// Convert Compares
@@ -178,6 +186,30 @@ func marshalRaftOperation(op *RaftOperation) ([]byte, error) {
 	return proto.Marshal(pbOp)
 }
 
+// encodeProposal encodes op for proposing: protobuf, or JSON while
+// performance.enable_protobuf is off, e.g. to keep proposals readable by
+// members that are rolled back during an upgrade
+func encodeProposal(op *RaftOperation) ([]byte, error) {
+	if !config.GetEnableProtobuf() {
+		return json.Marshal(op)
+	}
+	return marshalRaftOperation(op)
+}
+
+// unmarshalJSONOperation decodes a RaftOperation encoded as JSON, the format of
+// releases before protobuf proposals. Protobuf proposals start with a field tag
+// and never with '{', so the two cannot be mistaken for each other
+func unmarshalJSONOperation(data []byte) (*RaftOperation, bool) {
+	if len(data) == 0 || data[0] != '{' {
+		return nil, false
+	}
+	var op RaftOperation
+	if err := json.Unmarshal(data, &op); err != nil || op.Type == "" {
+		return nil, false
+	}
+	return &op, true
+}
+
 // unmarshalRaftOperation unmarshals RaftOperation from protobuf
 func unmarshalRaftOperation(data []byte) (*RaftOperation, error) {
 	pbOp := &pb.RaftOperation{}
@@ -18,10 +18,13 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"metaStore/pkg/config"
 	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
 
 	"github.com/linxGnu/grocksdb"
 	"go.etcd.io/raft/v3"
@@ -195,9 +198,9 @@ func (s *RocksDBStorage) Entries(lo, hi, maxSize uint64) ([]raftpb.Entry, error)
 		}
 
 		var ent raftpb.Entry
-		if err := ent.Unmarshal(data.Data()); err != nil {
+		if err := unmarshalEntry(data.Data(), &ent); err != nil {
 			data.Free()
-			return nil, fmt.Errorf("failed to unmarshal entry %d: %v", i, err)
+			return nil, fmt.Errorf("failed to unmarshal entry %d: %w", i, err)
 		}
 		data.Free()
 
@@ -259,7 +262,7 @@ func (s *RocksDBStorage) Term(index uint64) (uint64, error) {
 	}
 
 	var ent raftpb.Entry
-	if err := ent.Unmarshal(data.Data()); err != nil {
+	if err := unmarshalEntry(data.Data(), &ent); err != nil {
 		return 0, fmt.Errorf("failed to unmarshal entry %d: %v", index, err)
 	}
 
@@ -302,8 +305,8 @@ func (s *RocksDBStorage) loadSnapshotUnsafe() (raftpb.Snapshot, error) {
 	defer snapData.Free()
 
 	if snapData.Size() > 0 {
-		if err := snapshot.Unmarshal(snapData.Data()); err != nil {
-			return snapshot, fmt.Errorf("failed to unmarshal snapshot: %v", err)
+		if err := unmarshalSnapshot(snapData.Data(), &snapshot); err != nil {
+			return snapshot, fmt.Errorf("failed to unmarshal snapshot: %w", err)
 		}
 	} else {
 		// No stored snapshot - create a valid empty snapshot
@@ -347,15 +350,16 @@ func (s *RocksDBStorage) Append(entries []raftpb.Entry) error {
 	// Store all new entries
 	for _, ent := range entries {
 		key := s.logKey(ent.Index)
-		data, err := ent.Marshal()
+		data, err := marshalEntry(ent)
 		if err != nil {
 			return fmt.Errorf("failed to marshal entry %d: %v", ent.Index, err)
 		}
 		wb.Put(key, data)
 	}
 
-	// Update lastIndex if needed
-	if last > s.lastIndex {
+	// Update lastIndex if needed. After truncating conflicting entries the log
+	// ends at last even if it was longer before
+	if last > s.lastIndex || first <= s.lastIndex {
 		if err := s.setLastIndexWithWB(wb, last); err != nil {
 			return err
 		}
@@ -435,7 +439,7 @@ func (s *RocksDBStorage) CreateSnapshot(index uint64, cs *raftpb.ConfState, data
 		}
 
 		var ent raftpb.Entry
-		if err := ent.Unmarshal(entData.Data()); err != nil {
+		if err := unmarshalEntry(entData.Data(), &ent); err != nil {
 			return raftpb.Snapshot{}, fmt.Errorf("failed to unmarshal entry %d: %v", index, err)
 		}
 		term = ent.Term
@@ -451,7 +455,7 @@ func (s *RocksDBStorage) CreateSnapshot(index uint64, cs *raftpb.ConfState, data
 	}
 
 	// Save the snapshot
-	snapData, err := snapshot.Marshal()
+	snapData, err := marshalSnapshot(snapshot)
 	if err != nil {
 		return raftpb.Snapshot{}, fmt.Errorf("failed to marshal snapshot: %v", err)
 	}
@@ -484,7 +488,7 @@ func (s *RocksDBStorage) ApplySnapshot(snap raftpb.Snapshot) error {
 	defer wb.Destroy()
 
 	// Save snapshot metadata
-	snapData, err := snap.Marshal()
+	snapData, err := marshalSnapshot(snap)
 	if err != nil {
 		return fmt.Errorf("failed to marshal snapshot: %v", err)
 	}
@@ -634,6 +638,43 @@ func (s *RocksDBStorage) setLastIndexWithWB(wb *grocksdb.WriteBatch, index uint6
 	return nil
 }
 
+// raftCRCMagic marks log entries and snapshots stored with a CRC32C checksum
+// (reliability.enable_crc). A marshalled Entry or Snapshot never starts with
+// this byte, so data written without a checksum is still readable.
+var raftCRCMagic = []byte{0xff}
+
+func marshalEntry(ent raftpb.Entry) ([]byte, error) {
+	data, err := ent.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return reliability.SealCRC(raftCRCMagic, data), nil
+}
+
+func unmarshalEntry(data []byte, ent *raftpb.Entry) error {
+	payload, err := reliability.OpenCRC(reliability.SourceRaftLog, raftCRCMagic, data)
+	if err != nil {
+		return err
+	}
+	return ent.Unmarshal(payload)
+}
+
+func marshalSnapshot(snap raftpb.Snapshot) ([]byte, error) {
+	data, err := snap.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return reliability.SealCRC(raftCRCMagic, data), nil
+}
+
+func unmarshalSnapshot(data []byte, snap *raftpb.Snapshot) error {
+	payload, err := reliability.OpenCRC(reliability.SourceSnapshot, raftCRCMagic, data)
+	if err != nil {
+		return err
+	}
+	return snap.Unmarshal(payload)
+}
+
 func binaryReadUint64BigEndian(b []byte) (uint64, error) {
 	if len(b) < 8 {
 		return 0, fmt.Errorf("buffer too small to read uint64")
@@ -643,6 +684,12 @@ func binaryReadUint64BigEndian(b []byte) (uint64, error) {
 
 // OpenRocksDB opens a RocksDB database with optimal settings for raft storage
 func Open(path string, cfg ...*config.RocksDBConfig) (*grocksdb.DB, error) {
+	return OpenWithWALDir(path, "", cfg...)
+}
+
+// OpenWithWALDir opens the database like Open, keeping the RocksDB WAL in walDir
+// (e.g. on a faster disk than the SST files). Empty or equal to path keeps the WAL with the data
+func OpenWithWALDir(path, walDir string, cfg ...*config.RocksDBConfig) (*grocksdb.DB, error) {
 	// 使用配置或默认值
 	var rocksCfg *config.RocksDBConfig
 	if len(cfg) > 0 && cfg[0] != nil {
@@ -652,6 +699,9 @@ func Open(path string, cfg ...*config.RocksDBConfig) (*grocksdb.DB, error) {
 		defaultCfg := config.DefaultConfig(1, 1, ":2379")
 		rocksCfg = &defaultCfg.Server.RocksDB
 	}
+	if err := SetValueCompression(rocksCfg.ValueCompression, rocksCfg.ValueCompressMinSize); err != nil {
+		return nil, err
+	}
 
 	bbto := grocksdb.NewDefaultBlockBasedTableOptions()
 	bbto.SetBlockCache(grocksdb.NewLRUCache(rocksCfg.BlockCacheSize)) // 使用配置的 Block Cache
@@ -667,9 +717,18 @@ func Open(path string, cfg ...*config.RocksDBConfig) (*grocksdb.DB, error) {
 
 	// Write settings for durability (WAL is enabled by default in RocksDB)
 	opts.SetManualWALFlush(false)
+	if walDir != "" && filepath.Clean(walDir) != filepath.Clean(path) {
+		opts.SetWalDir(walDir)
+	}
 
 	// Performance settings - 使用配置文件的值
-	opts.SetMaxBackgroundJobs(rocksCfg.MaxBackgroundJobs)
+	// With stall control the job limit leaves room for boosting; the controller
+	// keeps the compaction thread pool at max_background_jobs until then
+	if rocksCfg.StallControl.Enable && rocksCfg.StallControl.BoostBackgroundJobs > rocksCfg.MaxBackgroundJobs {
+		opts.SetMaxBackgroundJobs(rocksCfg.StallControl.BoostBackgroundJobs)
+	} else {
+		opts.SetMaxBackgroundJobs(rocksCfg.MaxBackgroundJobs)
+	}
 	opts.SetMaxOpenFiles(rocksCfg.MaxOpenFiles)
 	opts.SetWriteBufferSize(rocksCfg.WriteBufferSize)
 	opts.SetMaxWriteBufferNumber(rocksCfg.MaxWriteBufferNumber)
@@ -688,11 +747,44 @@ func Open(path string, cfg ...*config.RocksDBConfig) (*grocksdb.DB, error) {
 	// Compression
 	opts.SetCompression(grocksdb.SnappyCompression)
 
+	// Tickers and histograms (block cache hits, write stalls) for metrics
+	if rocksCfg.Statistics {
+		opts.EnableStatistics()
+	}
+
 	db, err := grocksdb.OpenDb(opts, path)
 	if err != nil {
 		opts.Destroy()
 		return nil, fmt.Errorf("failed to open RocksDB at %s: %v", path, err)
 	}
+	if rocksCfg.Statistics {
+		statisticsOptions.Store(db, opts)
+	}
+
+	return db, nil
+}
+
+// OpenReadOnly opens an existing database without writing to it, for offline
+// tools inspecting the data directory of a member
+func OpenReadOnly(path, walDir string, cfg *config.RocksDBConfig) (*grocksdb.DB, error) {
+	if err := SetValueCompression(cfg.ValueCompression, cfg.ValueCompressMinSize); err != nil {
+		return nil, err
+	}
 
+	opts := grocksdb.NewDefaultOptions()
+	if walDir != "" && filepath.Clean(walDir) != filepath.Clean(path) {
+		opts.SetWalDir(walDir)
+	}
+	db, err := grocksdb.OpenDbForReadOnly(opts, path, false)
+	if err != nil {
+		opts.Destroy()
+		return nil, fmt.Errorf("failed to open RocksDB at %s read-only: %v", path, err)
+	}
 	return db, nil
 }
+
+// IsWALFile reports whether name is a RocksDB WAL file (e.g. 000012.log), used
+// to move the WAL alone when its directory changes
+func IsWALFile(name string) bool {
+	return strings.HasSuffix(name, ".log")
+}
@@ -18,6 +18,9 @@ import (
 	"os"
 	"testing"
 
+	"metaStore/pkg/reliability"
+
+	"github.com/linxGnu/grocksdb"
 	"github.com/stretchr/testify/require"
 	"go.etcd.io/raft/v3"
 	"go.etcd.io/raft/v3/raftpb"
@@ -363,3 +366,88 @@ func TestRocksDBStorage_Persistence(t *testing.T) {
 		require.Equal(t, uint64(2), hardState.Commit)
 	}
 }
+
+// flipStoredByte reopens the database and flips the last byte of a stored value,
+// simulating bit rot in a persisted raft entry or snapshot
+func flipStoredByte(t *testing.T, dir string, key []byte) {
+	db, err := Open(dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ro := grocksdb.NewDefaultReadOptions()
+	defer ro.Destroy()
+	wo := grocksdb.NewDefaultWriteOptions()
+	defer wo.Destroy()
+
+	value, err := db.GetBytes(ro, key)
+	require.NoError(t, err)
+	require.NotEmpty(t, value)
+	value[len(value)-1] ^= 0xff
+	require.NoError(t, db.Put(wo, key, value))
+}
+
+func TestRocksDBStorage_DetectsCorruption(t *testing.T) {
+	reliability.SetCRC(true)
+	defer reliability.SetCRC(false)
+
+	var reported []string
+	defer reliability.OnCorruption(func(source string, err error) {
+		require.ErrorIs(t, err, reliability.ErrCorrupted)
+		reported = append(reported, source)
+	})()
+
+	// Open a storage, run fn against it and close everything again
+	session := func(dir string, fn func(storage *RocksDBStorage)) {
+		db, err := Open(dir)
+		require.NoError(t, err)
+		defer db.Close()
+		storage, err := NewRocksDBStorage(db, "test_node")
+		require.NoError(t, err)
+		defer storage.Close()
+		fn(storage)
+	}
+
+	t.Run("Entry", func(t *testing.T) {
+		dir := t.TempDir()
+		var key []byte
+		session(dir, func(storage *RocksDBStorage) {
+			require.NoError(t, storage.Append([]raftpb.Entry{
+				{Term: 1, Index: 1, Data: []byte("entry1")},
+				{Term: 1, Index: 2, Data: []byte("entry2")},
+			}))
+			key = storage.logKey(2)
+		})
+
+		flipStoredByte(t, dir, key)
+
+		before := reliability.CorruptionCounts()[reliability.SourceRaftLog]
+		session(dir, func(storage *RocksDBStorage) {
+			_, err := storage.Entries(1, 3, 1<<20)
+			require.ErrorIs(t, err, reliability.ErrCorrupted)
+		})
+		require.Equal(t, before+1, reliability.CorruptionCounts()[reliability.SourceRaftLog])
+		require.Contains(t, reported, reliability.SourceRaftLog)
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		var key []byte
+		session(dir, func(storage *RocksDBStorage) {
+			require.NoError(t, storage.ApplySnapshot(raftpb.Snapshot{
+				Data:     []byte("state"),
+				Metadata: raftpb.SnapshotMetadata{Index: 10, Term: 1, ConfState: raftpb.ConfState{Voters: []uint64{1}}},
+			}))
+			key = storage.prefixedKey(snapshotKey)
+		})
+
+		flipStoredByte(t, dir, key)
+
+		before := reliability.CorruptionCounts()[reliability.SourceSnapshot]
+		session(dir, func(storage *RocksDBStorage) {
+			_, err := storage.Snapshot()
+			require.ErrorIs(t, err, reliability.ErrCorrupted)
+		})
+		require.Equal(t, before+1, reliability.CorruptionCounts()[reliability.SourceSnapshot])
+		require.Contains(t, reported, reliability.SourceSnapshot)
+	})
+}
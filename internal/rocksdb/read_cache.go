@@ -0,0 +1,199 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"metaStore/internal/kvstore"
+)
+
+// readCache is an LRU of recently read key-values, so read-heavy workloads
+// answer repeated single-key reads without a RocksDB lookup and decode.
+//
+// Entries are tagged with their ModRevision and dropped in the apply path once
+// a put or delete of the key is visible in the DB, before the write is
+// acknowledged, so the cache is never behind the state machine. As in the
+// negative lookup cache, every invalidation bumps gen and add rejects values
+// read under an older generation.
+type readCache struct {
+	mu   sync.Mutex
+	size int
+	lru  *list.List // Most recently used first, values are *kvstore.KeyValue
+	keys map[string]*list.Element
+	gen  uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+func newReadCache(size int) *readCache {
+	return &readCache{
+		size: size,
+		lru:  list.New(),
+		keys: make(map[string]*list.Element),
+	}
+}
+
+// lookup returns a copy of the cached value of key, or nil along with the
+// current generation to pass to add after reading the DB
+func (c *readCache) lookup(key string) (*kvstore.KeyValue, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.keys[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.hits.Add(1)
+		kv := *elem.Value.(*kvstore.KeyValue)
+		return &kv, c.gen
+	}
+	c.misses.Add(1)
+	return nil, c.gen
+}
+
+// add caches a copy of kv, unless an invalidation happened since gen was
+// returned by lookup or a newer revision of the key is already cached
+func (c *readCache) add(kv *kvstore.KeyValue, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	entry := *kv
+	if elem, ok := c.keys[string(kv.Key)]; ok {
+		if elem.Value.(*kvstore.KeyValue).ModRevision < kv.ModRevision {
+			elem.Value = &entry
+		}
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.keys[string(kv.Key)] = c.lru.PushFront(&entry)
+	for c.lru.Len() > c.size {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// invalidate drops keys that were just written or deleted
+func (c *readCache) invalidate(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, key := range keys {
+		if elem, ok := c.keys[key]; ok {
+			c.removeLocked(elem)
+			c.invalidations.Add(1)
+		}
+	}
+}
+
+// invalidateRanges drops the keys in ranges that were just deleted
+func (c *readCache) invalidateRanges(ranges []keyRange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for key, elem := range c.keys {
+		for _, kr := range ranges {
+			if kr.contains(key) {
+				c.removeLocked(elem)
+				c.invalidations.Add(1)
+				break
+			}
+		}
+	}
+}
+
+// purge drops all entries, used when the state machine is replaced by a snapshot
+func (c *readCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.lru.Init()
+	c.keys = make(map[string]*list.Element)
+}
+
+func (c *readCache) removeLocked(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.keys, string(elem.Value.(*kvstore.KeyValue).Key))
+}
+
+func (c *readCache) stats() kvstore.ReadCacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	return kvstore.ReadCacheStats{
+		Entries:       entries,
+		Capacity:      c.size,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+}
+
+// SetReadCache enables the read cache holding up to size key-values, size 0
+// disables it. Call it before serving reads
+func (r *RocksDB) SetReadCache(size int) {
+	if size <= 0 {
+		r.readCache.Store(nil)
+		return
+	}
+	r.readCache.Store(newReadCache(size))
+}
+
+// ReadCacheStats returns the statistics of the read cache, all zero when disabled
+func (r *RocksDB) ReadCacheStats() kvstore.ReadCacheStats {
+	if c := r.readCache.Load(); c != nil {
+		return c.stats()
+	}
+	return kvstore.ReadCacheStats{}
+}
+
+// readKeyValue reads a single present key, answering repeated reads from the read cache
+func (r *RocksDB) readKeyValue(key string) (*kvstore.KeyValue, error) {
+	c := r.readCache.Load()
+	if c == nil {
+		return r.getKeyValue(key)
+	}
+	kv, gen := c.lookup(key)
+	if kv != nil {
+		return kv, nil
+	}
+	kv, err := r.getKeyValue(key)
+	if err == nil && kv != nil {
+		c.add(kv, gen)
+	}
+	return kv, err
+}
+
+// forgetReads drops the keys put or deleted by the batch just written from the
+// read cache (called under applyMu, before forgetMisses resets putKeys)
+func (r *RocksDB) forgetReads() {
+	if c := r.readCache.Load(); c != nil {
+		if len(r.putKeys)+len(r.deletedKeys) > 0 {
+			c.invalidate(append(r.deletedKeys, r.putKeys...))
+		}
+		if len(r.deletedRanges) > 0 {
+			c.invalidateRanges(r.deletedRanges)
+		}
+	}
+	r.deletedKeys = r.deletedKeys[:0]
+	r.deletedRanges = r.deletedRanges[:0]
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCache(t *testing.T) {
+	c := newReadCache(2)
+	kv := func(key string, rev int64) *kvstore.KeyValue {
+		return &kvstore.KeyValue{Key: []byte(key), Value: []byte("v"), ModRevision: rev}
+	}
+
+	cached, gen := c.lookup("a")
+	assert.Nil(t, cached)
+	c.add(kv("a", 2), gen)
+	cached, gen = c.lookup("a")
+	require.NotNil(t, cached)
+	assert.Equal(t, int64(2), cached.ModRevision)
+
+	// 不用较旧的 revision 覆盖缓存
+	c.add(kv("a", 1), gen)
+	cached, _ = c.lookup("a")
+	assert.Equal(t, int64(2), cached.ModRevision)
+
+	// 返回的是副本
+	cached.Value = nil
+	cached, _ = c.lookup("a")
+	assert.Equal(t, "v", string(cached.Value))
+
+	// 读取期间发生过失效的结果不写入缓存
+	_, gen = c.lookup("b")
+	c.invalidate([]string{"x"})
+	c.add(kv("b", 3), gen)
+	cached, _ = c.lookup("b")
+	assert.Nil(t, cached)
+
+	// 超出容量时淘汰最久未使用的 key
+	_, gen = c.lookup("c")
+	c.add(kv("c", 4), gen)
+	_, gen = c.lookup("d")
+	c.add(kv("d", 5), gen)
+	cached, _ = c.lookup("a")
+	assert.Nil(t, cached)
+
+	c.invalidate([]string{"c"})
+	cached, _ = c.lookup("c")
+	assert.Nil(t, cached)
+
+	assert.Equal(t, kvstore.ReadCacheStats{Entries: 1, Capacity: 2, Hits: 3, Misses: 7, Invalidations: 1}, c.stats())
+}
+
+func TestRocksDB_ReadCache(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+	store.SetReadCache(16)
+	ctx := context.Background()
+
+	_, _, err := store.PutWithLease(ctx, "svc/a", "v1", 0)
+	require.NoError(t, err)
+	value, ok := store.Lookup("svc/a")
+	require.True(t, ok)
+	assert.Equal(t, "v1", value)
+	value, ok = store.Lookup("svc/a")
+	require.True(t, ok)
+	assert.Equal(t, "v1", value)
+	assert.Equal(t, uint64(1), store.ReadCacheStats().Hits)
+
+	// 写入后缓存的值立即失效
+	_, _, err = store.PutWithLease(ctx, "svc/a", "v2", 0)
+	require.NoError(t, err)
+	resp, err := store.Range(ctx, "svc/a", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v2", string(resp.Kvs[0].Value))
+	assert.Equal(t, uint64(1), store.ReadCacheStats().Invalidations)
+
+	// 删除同样使缓存失效
+	_, _, _, err = store.DeleteRange(ctx, "svc/", "svc0")
+	require.NoError(t, err)
+	_, ok = store.Lookup("svc/a")
+	assert.False(t, ok)
+
+	// 恢复快照后清空缓存
+	_, _, err = store.PutWithLease(ctx, "svc/b", "v1", 0)
+	require.NoError(t, err)
+	snapshot, err := store.GetSnapshot()
+	require.NoError(t, err)
+	_, ok = store.Lookup("svc/b")
+	assert.True(t, ok)
+	assert.Equal(t, 1, store.ReadCacheStats().Entries)
+	require.NoError(t, store.recoverFromSnapshot(snapshot, store.loadAppliedIndex()))
+	assert.Equal(t, 0, store.ReadCacheStats().Entries)
+}
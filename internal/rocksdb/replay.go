@@ -0,0 +1,43 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"metaStore/internal/kvstore"
+)
+
+// DecodeProposal decodes a proposal the way applyCommit does, for metastore
+// replay to print. Legacy gob KVs decode to a PUT; a proposal nothing decodes
+// makes the member exit on apply and is returned as an error here
+func DecodeProposal(data string) ([]*RaftOperation, error) {
+	if op, ok := unmarshalJSONOperation([]byte(data)); ok {
+		return []*RaftOperation{op}, nil
+	}
+	if ops, err := unmarshalRaftMessage([]byte(data)); err == nil && ops != nil {
+		return ops, nil
+	}
+	if op, err := unmarshalRaftOperation([]byte(data)); err == nil && op != nil {
+		return []*RaftOperation{op}, nil
+	}
+	var kv kvstore.KV
+	if err := gob.NewDecoder(bytes.NewBufferString(data)).Decode(&kv); err != nil {
+		return nil, fmt.Errorf("undecodable proposal: %w", err)
+	}
+	return []*RaftOperation{{Type: "PUT", Key: kv.Key, Value: kv.Val}}, nil
+}
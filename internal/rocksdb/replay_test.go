@@ -0,0 +1,42 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeProposal(t *testing.T) {
+	data, err := marshalRaftOperation(&RaftOperation{Type: "PUT", Key: "k", Value: "v"})
+	require.NoError(t, err)
+	ops, err := DecodeProposal(string(data))
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "PUT", ops[0].Type)
+	assert.Equal(t, "k", ops[0].Key)
+
+	data, err = marshalBatchOperations([]*RaftOperation{
+		{Type: "PUT", Key: "a", Value: "1"},
+		{Type: "DELETE", Key: "b"},
+	})
+	require.NoError(t, err)
+	ops, err = DecodeProposal(string(data))
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	assert.Equal(t, "DELETE", ops[1].Type)
+}
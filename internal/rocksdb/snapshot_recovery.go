@@ -0,0 +1,268 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/pkg/log"
+	"metaStore/pkg/reliability"
+
+	"github.com/linxGnu/grocksdb"
+	"go.uber.org/zap"
+)
+
+// The RocksDB of a node holds both the state machine (kv:, lease:, meta:) and the
+// raft storage of the node (<nodeID>_...). Snapshots only carry and only replace
+// the state machine.
+const (
+	// stateMachineStart and stateMachineEnd bound the key range of the state machine:
+	// "kv:" < "lease:" < "meta:" < "meta;"
+	stateMachineStart = kvPrefix
+	stateMachineEnd   = "meta;"
+
+	// minRecoveryRange minimum number of snapshot keys compared by one recovery worker
+	minRecoveryRange = 4096
+
+	// snapshotIndexKey holds the raft index the snapshot data was taken at. It
+	// only exists inside snapshots and is never written to the DB
+	snapshotIndexKey = "meta:snapshot_index"
+)
+
+// isStateMachineKey reports whether key belongs to the state machine
+func isStateMachineKey(key string) bool {
+	return strings.HasPrefix(key, kvPrefix) ||
+		strings.HasPrefix(key, leasePrefix) ||
+		strings.HasPrefix(key, metaPrefix)
+}
+
+// recoveryRange a key range [lower, upper) of the state machine and the sorted
+// snapshot keys inside it
+type recoveryRange struct {
+	lower, upper string
+	keys         []string
+}
+
+// snapshotChange a write needed to move the current state to the snapshot
+type snapshotChange struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// recoverFromSnapshot replaces the state machine with the snapshot
+//
+// The snapshot is compared with the current state in parallel across key ranges,
+// reading from a RocksDB snapshot, so reads keep serving the old state meanwhile.
+// Only the differences are then written in a single batch, which atomically
+// switches to the new state. Keys outside the state machine, such as the raft
+// storage sharing the DB, are never touched.
+//
+// Callers hold applyMu, so nothing else writes to the state machine between the
+// comparison and the switch.
+//
+// index is the raft index of the snapshot and the data must be taken at exactly
+// that applied index: data taken later holds entries that would be applied again
+// on top of it, data taken earlier lacks entries that the log behind the snapshot
+// no longer has. Both are rejected; snapshots of older versions carry no index
+// and are not checked.
+//
+// Snapshots referring to a checkpoint are handed to recoverFromCheckpoint.
+func (r *RocksDB) recoverFromSnapshot(snapshot []byte, index uint64) error {
+	start := time.Now()
+
+	snapshot, err := reliability.OpenSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(snapshot, checkpointSnapshotMagic) {
+		return r.recoverFromCheckpoint(snapshot[len(checkpointSnapshotMagic):], index)
+	}
+	snapshot, err = decompressSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	var snapshotData map[string][]byte
+	if err := gob.NewDecoder(bytes.NewBuffer(snapshot)).Decode(&snapshotData); err != nil {
+		return err
+	}
+	if data, ok := snapshotData[snapshotIndexKey]; ok {
+		if len(data) != 8 {
+			return fmt.Errorf("invalid snapshot index of %d bytes", len(data))
+		}
+		if taken := binary.BigEndian.Uint64(data); taken != index {
+			return fmt.Errorf("snapshot data taken at applied index %d does not match the snapshot index %d", taken, index)
+		}
+		delete(snapshotData, snapshotIndexKey)
+	}
+	// The state machine is at the snapshot index once the batch below is written
+	snapshotData[appliedIndexKey] = binary.BigEndian.AppendUint64(nil, index)
+
+	// Snapshots of older versions also contain the raft storage of the sender
+	keys := make([]string, 0, len(snapshotData))
+	for k := range snapshotData {
+		if isStateMachineKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	ranges := splitRecoveryRanges(keys, runtime.GOMAXPROCS(0))
+
+	dbSnap := r.db.NewSnapshot()
+	defer r.db.ReleaseSnapshot(dbSnap)
+
+	changes := make([][]snapshotChange, len(ranges))
+	errs := make([]error, len(ranges))
+	var wg sync.WaitGroup
+	for i, rg := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			changes[i], errs[i] = r.diffRange(dbSnap, rg, snapshotData)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to compare snapshot with the current state: %w", err)
+	}
+
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+
+	puts, deletes := 0, 0
+	for _, rangeChanges := range changes {
+		for _, c := range rangeChanges {
+			if c.delete {
+				wb.Delete(c.key)
+				deletes++
+			} else {
+				wb.Put(c.key, c.value)
+				puts++
+			}
+		}
+	}
+	if wb.Count() > 0 {
+		if err := r.writeBatch(wb); err != nil {
+			return err
+		}
+	}
+	r.resetRecoveredState(index)
+
+	log.Info("Recovered state machine from snapshot",
+		zap.Uint64("index", index),
+		zap.Int("keys", len(keys)),
+		zap.Int("ranges", len(ranges)),
+		zap.Int("puts", puts),
+		zap.Int("deletes", deletes),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("component", "storage-rocksdb"))
+	return nil
+}
+
+// resetRecoveredState drops everything derived from the state machine before
+// it was replaced with a snapshot taken at index
+func (r *RocksDB) resetRecoveredState(index uint64) {
+	r.durableIndex = index
+	if c := r.missCache.Load(); c != nil {
+		c.purge()
+	}
+	if c := r.readCache.Load(); c != nil {
+		c.purge()
+	}
+	r.cachedRevision.Store(r.loadCurrentRevision())
+	// Events before the snapshot were never applied here
+	r.events.Reset(r.cachedRevision.Load())
+	r.hlc.Restore(r.loadHLC())
+}
+
+// splitRecoveryRanges splits the state machine into at most workers ranges holding
+// about the same number of snapshot keys, at least minRecoveryRange each
+func splitRecoveryRanges(keys []string, workers int) []recoveryRange {
+	parts := min(workers, (len(keys)+minRecoveryRange-1)/minRecoveryRange)
+	parts = max(parts, 1)
+
+	ranges := make([]recoveryRange, parts)
+	lower := stateMachineStart
+	for i := range parts {
+		from, to := i*len(keys)/parts, (i+1)*len(keys)/parts
+		upper := stateMachineEnd
+		if i < parts-1 {
+			upper = keys[to]
+		}
+		ranges[i] = recoveryRange{lower: lower, upper: upper, keys: keys[from:to]}
+		lower = upper
+	}
+	return ranges
+}
+
+// diffRange merges the stored keys of rg with its snapshot keys and returns the
+// writes that turn the stored range into the snapshot range
+func (r *RocksDB) diffRange(dbSnap *grocksdb.Snapshot, rg recoveryRange, data map[string][]byte) ([]snapshotChange, error) {
+	ro := grocksdb.NewDefaultReadOptions()
+	defer ro.Destroy()
+	ro.SetSnapshot(dbSnap)
+	ro.SetFillCache(false) // a full scan must not evict the working set of readers
+	ro.SetIterateUpperBound([]byte(rg.upper))
+
+	it := r.db.NewIterator(ro)
+	defer it.Close()
+
+	var changes []snapshotChange
+	put := func(k string) {
+		changes = append(changes, snapshotChange{key: []byte(k), value: data[k]})
+	}
+
+	i := 0
+	for it.Seek([]byte(rg.lower)); it.Valid(); it.Next() {
+		key := it.Key()
+		k := string(key.Data())
+		key.Free()
+
+		// Snapshot keys sorting before the stored key are missing
+		for ; i < len(rg.keys) && rg.keys[i] < k; i++ {
+			put(rg.keys[i])
+		}
+		if i < len(rg.keys) && rg.keys[i] == k {
+			value := it.Value()
+			same := bytes.Equal(value.Data(), data[k])
+			value.Free()
+			if !same {
+				put(k)
+			}
+			i++
+			continue
+		}
+		if isStateMachineKey(k) {
+			changes = append(changes, snapshotChange{key: []byte(k), delete: true})
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	for ; i < len(rg.keys); i++ {
+		put(rg.keys[i])
+	}
+	return changes, nil
+}
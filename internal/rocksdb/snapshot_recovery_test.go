@@ -0,0 +1,130 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRecoveryRanges(t *testing.T) {
+	ranges := splitRecoveryRanges(nil, 8)
+	require.Len(t, ranges, 1)
+	assert.Equal(t, stateMachineStart, ranges[0].lower)
+	assert.Equal(t, stateMachineEnd, ranges[0].upper)
+
+	keys := make([]string, 3*minRecoveryRange)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("kv:%08d", i)
+	}
+	ranges = splitRecoveryRanges(keys, 8)
+	require.Len(t, ranges, 3)
+
+	// 范围首尾相接，覆盖整个状态机，并且每个 key 都落在所属范围内
+	total := 0
+	for i, rg := range ranges {
+		if i > 0 {
+			assert.Equal(t, ranges[i-1].upper, rg.lower)
+		}
+		for _, k := range rg.keys {
+			assert.True(t, k >= rg.lower && k < rg.upper, "key %s outside [%s, %s)", k, rg.lower, rg.upper)
+		}
+		total += len(rg.keys)
+	}
+	assert.Equal(t, stateMachineStart, ranges[0].lower)
+	assert.Equal(t, stateMachineEnd, ranges[2].upper)
+	assert.Equal(t, len(keys), total)
+}
+
+func TestRecoverFromSnapshot_Incremental(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	require.NoError(t, store.putUnlocked("keep", "v1", 0))
+	require.NoError(t, store.putUnlocked("change", "v1", 0))
+	require.NoError(t, store.putUnlocked("remove", "v1", 0))
+	snapshot, err := store.GetSnapshot()
+	require.NoError(t, err)
+
+	require.NoError(t, store.putUnlocked("change", "v2", 0))
+	require.NoError(t, store.deleteUnlocked("remove", ""))
+	require.NoError(t, store.putUnlocked("extra", "v1", 0))
+	revision := store.CurrentRevision()
+
+	// raft 存储和状态机共用一个 DB，恢复快照时不能被删除
+	raftKey := []byte("node_1_hard_state")
+	require.NoError(t, store.db.Put(store.wo, raftKey, []byte("hs")))
+
+	require.NoError(t, store.recoverFromSnapshot(snapshot, 0))
+
+	for key, want := range map[string]string{"keep": "v1", "change": "v1", "remove": "v1"} {
+		kv, err := store.getKeyValue(key)
+		require.NoError(t, err)
+		require.NotNil(t, kv, key)
+		assert.Equal(t, want, string(kv.Value), key)
+	}
+	kv, err := store.getKeyValue("extra")
+	require.NoError(t, err)
+	assert.Nil(t, kv)
+	assert.Less(t, store.CurrentRevision(), revision)
+
+	data, err := store.db.Get(store.ro, raftKey)
+	require.NoError(t, err)
+	defer data.Free()
+	assert.Equal(t, "hs", string(data.Data()))
+}
+
+func TestGetSnapshot_AppliedIndex(t *testing.T) {
+	store, cleanup := createTestStore(t, t.TempDir())
+	defer cleanup()
+
+	ctx := context.Background()
+	_, _, err := store.PutWithLease(ctx, "a", "v1", 0)
+	require.NoError(t, err)
+	_, _, err = store.PutWithLease(ctx, "b", "v1", 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), store.applied.Applied())
+
+	snapshot, err := store.GetSnapshot()
+	require.NoError(t, err)
+
+	// 快照数据不能晚于快照的 raft index，否则其后的 entry 会被重复应用
+	err = store.recoverFromSnapshot(snapshot, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "applied index 2")
+
+	// 也不能早于快照的 raft index，否则缺少的 entry 已经随日志压缩丢失
+	err = store.recoverFromSnapshot(snapshot, 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match the snapshot index 3")
+
+	_, _, err = store.PutWithLease(ctx, "a", "v2", 0)
+	require.NoError(t, err)
+	require.NoError(t, store.recoverFromSnapshot(snapshot, 2))
+	kv, err := store.getKeyValue("a")
+	require.NoError(t, err)
+	require.NotNil(t, kv)
+	assert.Equal(t, "v1", string(kv.Value))
+
+	// 快照索引只存在于快照中
+	data, err := store.db.Get(store.ro, []byte(snapshotIndexKey))
+	require.NoError(t, err)
+	defer data.Free()
+	assert.Zero(t, data.Size())
+}
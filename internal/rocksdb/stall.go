@@ -0,0 +1,30 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import "fmt"
+
+// StorageStall reports whether RocksDB is stopping or throttling writes, which
+// happens when compaction falls behind (too many L0 files, pending compaction
+// bytes or unflushed memtables). Health checks report the node as degraded.
+func (r *RocksDB) StorageStall() (bool, string) {
+	if stopped, ok := r.db.GetIntProperty("rocksdb.is-write-stopped"); ok && stopped != 0 {
+		return true, "rocksdb writes are stopped"
+	}
+	if rate, ok := r.db.GetIntProperty("rocksdb.actual-delayed-write-rate"); ok && rate != 0 {
+		return true, fmt.Sprintf("rocksdb writes are delayed to %d bytes/s", rate)
+	}
+	return false, ""
+}
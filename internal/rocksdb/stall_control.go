@@ -0,0 +1,181 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"strconv"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+
+	"github.com/linxGnu/grocksdb"
+	"go.uber.org/zap"
+)
+
+// Write slowdown signalled to the propose path by the stall controller
+const (
+	slowdownNone    int32 = iota
+	slowdownDelayed       // RocksDB delays writes, low priority proposals are rejected
+	slowdownStopped       // RocksDB stopped writes, only high priority proposals pass
+)
+
+// stallEngine is the part of RocksDB the stall controller reads and tunes
+type stallEngine interface {
+	GetProperty(name string) string
+	GetIntProperty(name string) (uint64, bool)
+	SetOptions(keys, values []string) error
+	SetBackgroundThreads(n int)
+}
+
+// dbStallEngine tunes a database through its mutable options and the
+// compaction (low priority) thread pool of the default environment it runs in
+type dbStallEngine struct {
+	*grocksdb.DB
+	env *grocksdb.Env
+}
+
+func (e dbStallEngine) SetBackgroundThreads(n int) {
+	e.env.SetBackgroundThreads(n)
+}
+
+// stallController watches RocksDB stall indicators and, while compaction falls
+// behind, gives compaction more threads and raises level0_slowdown_writes_trigger
+// so bursts are absorbed instead of delaying the writes of the apply loop. The
+// settings are restored once there has been no pressure for calm_period
+type stallController struct {
+	engine stallEngine
+	cfg    config.StallControlConfig
+	signal func(level int32)
+
+	normalThreads  int // Compaction threads for max_background_jobs
+	boostThreads   int // Compaction threads for boost_background_jobs
+	normalSlowdown int // level0_slowdown_writes_trigger
+
+	boosted   bool
+	calmSince time.Time
+}
+
+func newStallController(engine stallEngine, rocksCfg *config.RocksDBConfig, signal func(int32)) *stallController {
+	return &stallController{
+		engine:         engine,
+		cfg:            rocksCfg.StallControl,
+		signal:         signal,
+		normalThreads:  compactionThreads(rocksCfg.MaxBackgroundJobs),
+		boostThreads:   compactionThreads(rocksCfg.StallControl.BoostBackgroundJobs),
+		normalSlowdown: rocksCfg.Level0SlowdownWritesTrigger,
+	}
+}
+
+// compactionThreads is the share of background jobs RocksDB gives to
+// compactions, the rest (a quarter, at least one) runs flushes
+func compactionThreads(jobs int) int {
+	return max(1, jobs-max(1, jobs/4))
+}
+
+// check reads the stall indicators once and adjusts the settings
+func (c *stallController) check(now time.Time) {
+	level := slowdownNone
+	if stopped, ok := c.engine.GetIntProperty("rocksdb.is-write-stopped"); ok && stopped != 0 {
+		level = slowdownStopped
+	} else if rate, ok := c.engine.GetIntProperty("rocksdb.actual-delayed-write-rate"); ok && rate != 0 {
+		level = slowdownDelayed
+	}
+	c.signal(level)
+
+	l0, _ := strconv.ParseUint(c.engine.GetProperty("rocksdb.num-files-at-level0"), 10, 64)
+	pressure := level != slowdownNone || float64(l0) >= c.cfg.Level0Ratio*float64(c.normalSlowdown)
+
+	switch {
+	case pressure:
+		c.calmSince = time.Time{}
+		if !c.boosted {
+			c.apply(true, l0)
+		}
+	case c.boosted && c.calmSince.IsZero():
+		c.calmSince = now
+	case c.boosted && now.Sub(c.calmSince) >= c.cfg.CalmPeriod:
+		c.apply(false, l0)
+	}
+}
+
+// apply switches between the boosted and the normal settings
+func (c *stallController) apply(boost bool, l0 uint64) {
+	threads, slowdown, msg := c.normalThreads, c.normalSlowdown, "Compaction caught up, restoring RocksDB settings"
+	if boost {
+		threads, slowdown, msg = c.boostThreads, c.cfg.BoostLevel0SlowdownTrigger, "Compaction is falling behind, boosting RocksDB compaction"
+	}
+	c.engine.SetBackgroundThreads(threads)
+	if err := c.engine.SetOptions([]string{"level0_slowdown_writes_trigger"}, []string{strconv.Itoa(slowdown)}); err != nil {
+		log.Warn("Failed to set level0_slowdown_writes_trigger", zap.Error(err), zap.String("component", "storage-rocksdb"))
+	}
+	c.boosted = boost
+	log.Info(msg,
+		zap.Uint64("level0_files", l0),
+		zap.Int("compaction_threads", threads),
+		zap.Int("level0_slowdown_writes_trigger", slowdown),
+		zap.String("component", "storage-rocksdb"))
+}
+
+// StartStallControl starts the write stall controller configured by
+// rocksdb.stall_control and returns the function stopping it. The database
+// must have been opened with the same configuration
+func (r *RocksDB) StartStallControl(rocksCfg *config.RocksDBConfig) (stop func()) {
+	if !rocksCfg.StallControl.Enable {
+		return func() {}
+	}
+	env := grocksdb.NewDefaultEnv()
+	c := newStallController(dbStallEngine{DB: r.db, env: env}, rocksCfg, r.writeSlowdown.Store)
+	// The database was opened with room for boost_background_jobs
+	c.engine.SetBackgroundThreads(c.normalThreads)
+
+	stopC, doneC := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(doneC)
+		defer env.Destroy()
+		ticker := time.NewTicker(rocksCfg.StallControl.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopC:
+				return
+			case now := <-ticker.C:
+				c.check(now)
+			}
+		}
+	}()
+	return func() {
+		close(stopC)
+		<-doneC
+		r.writeSlowdown.Store(slowdownNone)
+	}
+}
+
+// checkWriteSlowdown rejects proposals of the given priority while the stall
+// controller reports that RocksDB delays or stops writes, so clients back off
+// instead of queueing behind a stalled apply loop until Raft times out
+func (r *RocksDB) checkWriteSlowdown(priority kvstore.Priority) error {
+	var reason string
+	switch level := r.writeSlowdown.Load(); {
+	case level == slowdownStopped && priority < kvstore.PriorityHigh:
+		reason = "storage engine stopped writes while compaction catches up"
+	case level == slowdownDelayed && priority == kvstore.PriorityLow:
+		reason = "storage engine is delaying writes while compaction catches up"
+	default:
+		return nil
+	}
+	return &kvstore.TooManyRequestsError{Reason: reason, RetryAfter: r.backpressure.Load().RetryAfter}
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStallEngine records the settings applied by the stall controller
+type fakeStallEngine struct {
+	l0       int
+	delayed  uint64
+	stopped  uint64
+	threads  int
+	slowdown string
+}
+
+func (e *fakeStallEngine) GetProperty(name string) string {
+	if name == "rocksdb.num-files-at-level0" {
+		return strconv.Itoa(e.l0)
+	}
+	return ""
+}
+
+func (e *fakeStallEngine) GetIntProperty(name string) (uint64, bool) {
+	switch name {
+	case "rocksdb.is-write-stopped":
+		return e.stopped, true
+	case "rocksdb.actual-delayed-write-rate":
+		return e.delayed, true
+	}
+	return 0, false
+}
+
+func (e *fakeStallEngine) SetOptions(keys, values []string) error {
+	if len(keys) != 1 || keys[0] != "level0_slowdown_writes_trigger" {
+		return errors.New("unexpected option")
+	}
+	e.slowdown = values[0]
+	return nil
+}
+
+func (e *fakeStallEngine) SetBackgroundThreads(n int) {
+	e.threads = n
+}
+
+func TestStallController(t *testing.T) {
+	rocksCfg := &config.RocksDBConfig{
+		MaxBackgroundJobs:           4,
+		Level0SlowdownWritesTrigger: 20,
+		Level0StopWritesTrigger:     36,
+		StallControl: config.StallControlConfig{
+			Enable:                     true,
+			Level0Ratio:                0.75,
+			BoostBackgroundJobs:        8,
+			BoostLevel0SlowdownTrigger: 28,
+			CalmPeriod:                 30 * time.Second,
+		},
+	}
+	engine := &fakeStallEngine{}
+	var signalled int32
+	c := newStallController(engine, rocksCfg, func(level int32) { signalled = level })
+	now := time.Now()
+
+	// Below 75% of the slowdown trigger nothing changes
+	engine.l0 = 14
+	c.check(now)
+	assert.False(t, c.boosted)
+	assert.Equal(t, 0, engine.threads)
+
+	// L0 files piling up boost compaction before RocksDB delays writes
+	engine.l0 = 15
+	c.check(now)
+	require.True(t, c.boosted)
+	assert.Equal(t, 6, engine.threads)
+	assert.Equal(t, "28", engine.slowdown)
+	assert.Equal(t, slowdownNone, signalled)
+
+	engine.delayed = 1 << 20
+	c.check(now.Add(time.Second))
+	assert.Equal(t, slowdownDelayed, signalled)
+	engine.stopped = 1
+	c.check(now.Add(2 * time.Second))
+	assert.Equal(t, slowdownStopped, signalled)
+
+	// Settings are restored after calm_period without pressure
+	engine.l0, engine.delayed, engine.stopped = 2, 0, 0
+	c.check(now.Add(3 * time.Second))
+	assert.Equal(t, slowdownNone, signalled)
+	assert.True(t, c.boosted)
+	c.check(now.Add(20 * time.Second))
+	assert.True(t, c.boosted)
+	c.check(now.Add(33 * time.Second))
+	assert.False(t, c.boosted)
+	assert.Equal(t, 3, engine.threads)
+	assert.Equal(t, "20", engine.slowdown)
+}
+
+func TestCheckWriteSlowdown(t *testing.T) {
+	r := &RocksDB{}
+	r.SetBackpressure(kvstore.DefaultBackpressure)
+
+	for _, p := range []kvstore.Priority{kvstore.PriorityLow, kvstore.PriorityNormal, kvstore.PriorityHigh} {
+		assert.NoError(t, r.checkWriteSlowdown(p))
+	}
+
+	r.writeSlowdown.Store(slowdownDelayed)
+	err := r.checkWriteSlowdown(kvstore.PriorityLow)
+	assert.ErrorIs(t, err, kvstore.ErrTooManyRequests)
+	retry, ok := kvstore.RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, kvstore.DefaultBackpressure.RetryAfter, retry)
+	assert.NoError(t, r.checkWriteSlowdown(kvstore.PriorityNormal))
+
+	r.writeSlowdown.Store(slowdownStopped)
+	assert.ErrorIs(t, r.checkWriteSlowdown(kvstore.PriorityNormal), kvstore.ErrTooManyRequests)
+	assert.NoError(t, r.checkWriteSlowdown(kvstore.PriorityHigh))
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rocksdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
+)
+
+// TestApplyPreviousReleaseFormats 滚动升级期间，新版本应用旧版本成员提交的每种提案格式
+func TestApplyPreviousReleaseFormats(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(filepath.Join(dir, "db"))
+	require.NoError(t, err)
+	defer db.Close()
+	commitC := make(chan *kvstore.Commit)
+	errorC := make(chan error)
+	store := NewRocksDB(db, snap.New(nil, dir), make(chan string), commitC, errorC)
+	defer func() {
+		close(commitC)
+		close(errorC)
+		store.Close()
+	}()
+
+	jsonOp, err := json.Marshal(&RaftOperation{Type: "PUT", Key: "json", Value: "v|1", SeqNum: "old-1"})
+	require.NoError(t, err)
+	pbOp, err := marshalRaftOperation(&RaftOperation{Type: "PUT", Key: "pb", Value: "v2"})
+	require.NoError(t, err)
+	batch, err := marshalBatchOperations([]*RaftOperation{
+		{Type: "PUT", Key: "batch-a", Value: "v3"},
+		{Type: "PUT", Key: "batch-b", Value: "v4"},
+	})
+	require.NoError(t, err)
+	var gobKV bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&gobKV).Encode(kvstore.KV{Key: "gob", Val: "v5"}))
+
+	for i, data := range []string{string(jsonOp), string(pbOp), string(batch), gobKV.String()} {
+		applyDoneC := make(chan struct{})
+		commitC <- &kvstore.Commit{Data: []string{data}, ApplyDoneC: applyDoneC, Index: uint64(i + 1)}
+		<-applyDoneC
+	}
+
+	expected := map[string]string{"json": "v|1", "pb": "v2", "batch-a": "v3", "batch-b": "v4", "gob": "v5"}
+	for key, value := range expected {
+		resp, err := store.Range(context.Background(), key, "", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, resp.Kvs, 1, key)
+		assert.Equal(t, value, string(resp.Kvs[0].Value), key)
+	}
+	assert.Equal(t, int64(5), store.CurrentRevision())
+}
+
+// TestEncodeProposalJSON 关闭 performance.enable_protobuf 时提案以 JSON 编码，仍可解码
+func TestEncodeProposalJSON(t *testing.T) {
+	config.SetEnableProtobuf(false)
+	defer config.SetEnableProtobuf(true)
+
+	op := &RaftOperation{
+		Type:     "TXN",
+		Compares: []kvstore.Compare{{Target: kvstore.CompareValue, Key: []byte("k"), TargetUnion: kvstore.CompareUnion{Value: []byte("v")}}},
+		ThenOps:  []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("k"), Value: []byte("v2")}},
+	}
+	data, err := encodeProposal(op)
+	require.NoError(t, err)
+	assert.Equal(t, byte('{'), data[0])
+
+	ops, err := DecodeProposal(string(data))
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, op.Compares, ops[0].Compares)
+	assert.Equal(t, op.ThenOps, ops[0].ThenOps)
+}
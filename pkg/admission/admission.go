@@ -0,0 +1,184 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admission 在写入提案之前执行可插拔的检查 hook
+//
+// hook 在启动时按名称注册（与 database/sql 驱动相同），配置 admission.hooks 选择
+// 启用哪些 hook 以及它们作用的前端。etcd、HTTP 和 MySQL 前端的 put 和 delete（包括
+// 事务和批量写入中的操作）在进入 Raft 之前依次经过这些 hook，hook 可以拒绝请求，
+// 也可以改写 key、value 和 lease
+//
+// 自定义 hook 在自己的构建中注册：
+//
+//	func init() {
+//		admission.Register("audit-tag", func(params map[string]string) (admission.Hook, error) {
+//			return admission.HookFunc(func(ctx context.Context, req *admission.Request) error {
+//				...
+//			}), nil
+//		})
+//	}
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+)
+
+// ErrRejected 请求被 hook 拒绝，前端据此返回权限类错误而不是服务端错误
+var ErrRejected = errors.New("rejected by admission hook")
+
+// Request 写请求中的一个操作，hook 可以修改 Key、RangeEnd、Value 和 LeaseID
+type Request struct {
+	Frontend string         // "etcd", "http" 或 "mysql"
+	User     string         // 认证的用户，未启用认证时为空
+	Type     kvstore.OpType // kvstore.OpPut 或 kvstore.OpDelete
+	Key      string
+	RangeEnd string // 删除的范围结束 key，为空时只删除 Key
+	Value    []byte // put 的 value
+	LeaseID  int64  // put 绑定的 lease
+}
+
+// Hook 检查一个操作，返回错误即拒绝整个请求
+type Hook interface {
+	Admit(ctx context.Context, req *Request) error
+}
+
+// HookFunc 把函数适配为 Hook
+type HookFunc func(ctx context.Context, req *Request) error
+
+// Admit 调用 f
+func (f HookFunc) Admit(ctx context.Context, req *Request) error {
+	return f(ctx, req)
+}
+
+// Factory 根据配置的参数创建 hook
+type Factory func(params map[string]string) (Hook, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register 注册一个 hook，名称重复时 panic。应在 init 中调用
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("admission: Register factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("admission: Register called twice for hook " + name)
+	}
+	registry[name] = factory
+}
+
+// Hooks 返回已注册的 hook 名称
+func Hooks() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rejectf 返回拒绝请求的错误，hook 用它说明拒绝原因
+func Rejectf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrRejected, fmt.Sprintf(format, args...))
+}
+
+// boundHook 一个配置好的 hook
+type boundHook struct {
+	name      string
+	frontends []string
+	hook      Hook
+}
+
+// Chain 按配置顺序执行的 hook
+type Chain struct {
+	hooks []boundHook
+}
+
+// NewChain 按配置创建 hook，未注册的名称或参数错误返回错误
+func NewChain(cfg config.AdmissionConfig) (*Chain, error) {
+	c := &Chain{}
+	for _, hc := range cfg.Hooks {
+		registryMu.RLock()
+		factory, ok := registry[hc.Name]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("admission hook %q is not registered (registered: %v)", hc.Name, Hooks())
+		}
+		hook, err := factory(hc.Params)
+		if err != nil {
+			return nil, fmt.Errorf("admission hook %q: %w", hc.Name, err)
+		}
+		c.hooks = append(c.hooks, boundHook{name: hc.Name, frontends: hc.Frontends, hook: hook})
+	}
+	return c, nil
+}
+
+// Use 在链尾追加一个 hook，frontends 为空时作用于所有前端
+func (c *Chain) Use(name string, hook Hook, frontends ...string) {
+	c.hooks = append(c.hooks, boundHook{name: name, frontends: frontends, hook: hook})
+}
+
+// forFrontend 返回作用于该前端的 hook
+func (c *Chain) forFrontend(frontend string) []boundHook {
+	if c == nil {
+		return nil
+	}
+	var hooks []boundHook
+	for _, h := range c.hooks {
+		if len(h.frontends) == 0 || slices.Contains(h.frontends, frontend) {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+// admit 依次执行 hook，错误统一包装为 ErrRejected 并带上 hook 名称
+func admit(ctx context.Context, hooks []boundHook, req *Request) error {
+	for _, h := range hooks {
+		if err := h.hook.Admit(ctx, req); err != nil {
+			if errors.Is(err, ErrRejected) {
+				return fmt.Errorf("%s: %w", h.name, err)
+			}
+			return fmt.Errorf("%s: %w: %v", h.name, ErrRejected, err)
+		}
+	}
+	return nil
+}
+
+type userKey struct{}
+
+// WithUser 返回带有认证用户的 context，前端在调用存储之前设置
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext 返回 WithUser 设置的用户
+func UserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChain(t *testing.T) {
+	_, err := NewChain(config.AdmissionConfig{Hooks: []config.AdmissionHookConfig{{Name: "missing"}}})
+	assert.ErrorContains(t, err, "not registered")
+
+	_, err = NewChain(config.AdmissionConfig{Hooks: []config.AdmissionHookConfig{{Name: "key-pattern"}}})
+	assert.ErrorContains(t, err, "pattern is required")
+
+	_, err = NewChain(config.AdmissionConfig{Hooks: []config.AdmissionHookConfig{{
+		Name:   "size-limit",
+		Params: map[string]string{"max_value_size": "-1"},
+	}}})
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { Register("key-pattern", newKeyPattern) })
+	assert.Contains(t, Hooks(), "tenant-prefix")
+}
+
+func TestStoreBuiltinHooks(t *testing.T) {
+	ctx := context.Background()
+	chain, err := NewChain(config.AdmissionConfig{Hooks: []config.AdmissionHookConfig{
+		{Name: "key-pattern", Params: map[string]string{"prefix": "/app/", "pattern": `^/app/[a-z]+$`}},
+		{Name: "size-limit", Frontends: []string{"http"}, Params: map[string]string{"max_value_size": "4"}},
+	}})
+	require.NoError(t, err)
+	base := memory.NewMemoryEtcd()
+	httpStore := Wrap(base, chain, "http")
+	etcdStore := Wrap(base, chain, "etcd")
+
+	_, _, err = httpStore.PutWithLease(ctx, "/app/ok", "1", 0)
+	assert.NoError(t, err)
+	_, _, err = httpStore.PutWithLease(ctx, "/app/Bad", "1", 0)
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.ErrorContains(t, err, "key-pattern")
+	_, _, err = httpStore.PutWithLease(ctx, "/other/Any", "1", 0)
+	assert.NoError(t, err)
+
+	// size-limit 只作用于 http
+	_, _, err = httpStore.PutWithLease(ctx, "/app/big", "12345", 0)
+	assert.ErrorIs(t, err, ErrRejected)
+	_, _, err = etcdStore.PutWithLease(ctx, "/app/big", "12345", 0)
+	assert.NoError(t, err)
+
+	// 事务中任一操作被拒绝，整个事务不执行
+	ops := []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("/app/a"), Value: []byte("1")},
+		{Type: kvstore.OpPut, Key: []byte("/app/B"), Value: []byte("1")},
+	}
+	_, err = etcdStore.Txn(ctx, nil, ops, nil)
+	assert.ErrorIs(t, err, ErrRejected)
+	_, ok := base.Lookup("/app/a")
+	assert.False(t, ok)
+
+	// 内部 key 不经过 hook
+	_, _, err = httpStore.PutWithLease(ctx, kvstore.SystemKeyPrefix+"x", "123456", 0)
+	assert.NoError(t, err)
+
+	assert.Same(t, base, Wrap(base, chain, "mysql").(*admittingStore).Store)
+	assert.Equal(t, kvstore.Store(base), Unwrap(httpStore))
+	assert.Equal(t, kvstore.Store(base), Wrap(base, &Chain{}, "http"))
+}
+
+func TestStoreMutatingHook(t *testing.T) {
+	ctx := context.Background()
+	chain := &Chain{}
+	chain.Use("tag", HookFunc(func(ctx context.Context, req *Request) error {
+		if req.Type == kvstore.OpPut {
+			req.Value = append([]byte(req.Frontend+":"), req.Value...)
+		}
+		return nil
+	}))
+	chain.Use("deny", HookFunc(func(ctx context.Context, req *Request) error {
+		if req.Key == "locked" {
+			return errors.New("locked")
+		}
+		return nil
+	}), "mysql")
+	base := memory.NewMemoryEtcd()
+	s := Wrap(base, chain, "mysql")
+
+	_, _, err := s.PutWithLease(ctx, "k", "v", 0)
+	require.NoError(t, err)
+	v, _ := base.Lookup("k")
+	assert.Equal(t, "mysql:v", v)
+
+	ops := []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("t"), Value: []byte("v")},
+		{Type: kvstore.OpRange, Key: []byte("t")},
+	}
+	_, err = s.Txn(ctx, nil, ops, nil)
+	require.NoError(t, err)
+	v, _ = base.Lookup("t")
+	assert.Equal(t, "mysql:v", v)
+	assert.Equal(t, []byte("v"), ops[0].Value, "caller's ops must not be modified")
+
+	// 非 ErrRejected 的错误也包装为 ErrRejected
+	_, _, _, err = s.DeleteRange(ctx, "locked", "")
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.ErrorContains(t, err, "deny")
+}
+
+func TestTenantPrefix(t *testing.T) {
+	ctx := context.Background()
+	chain, err := NewChain(config.AdmissionConfig{Hooks: []config.AdmissionHookConfig{{Name: "tenant-prefix"}}})
+	require.NoError(t, err)
+	s := Wrap(memory.NewMemoryEtcd(), chain, "etcd")
+
+	alice := WithUser(ctx, "alice")
+	_, _, err = s.PutWithLease(alice, "/tenants/alice/x", "1", 0)
+	assert.NoError(t, err)
+	_, _, err = s.PutWithLease(alice, "/tenants/bob/x", "1", 0)
+	assert.ErrorIs(t, err, ErrRejected)
+	_, _, _, err = s.DeleteRange(alice, "/tenants/alice/", "/tenants/alice0")
+	assert.NoError(t, err)
+	_, _, _, err = s.DeleteRange(alice, "/tenants/alice/", "\x00")
+	assert.ErrorIs(t, err, ErrRejected)
+
+	_, _, err = s.PutWithLease(ctx, "/tenants/alice/x", "1", 0)
+	assert.ErrorIs(t, err, ErrRejected, "unauthenticated")
+	_, _, err = s.PutWithLease(WithUser(ctx, "root"), "/anything", "1", 0)
+	assert.NoError(t, err)
+}
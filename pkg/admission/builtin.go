@@ -0,0 +1,134 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"metaStore/internal/kvstore"
+)
+
+func init() {
+	Register("key-pattern", newKeyPattern)
+	Register("size-limit", newSizeLimit)
+	Register("tenant-prefix", newTenantPrefix)
+}
+
+// newKeyPattern 写入 prefix 下的 key 必须匹配 pattern
+//
+// 参数：pattern（必填，正则表达式），prefix（可选，只检查该前缀下的 key）
+func newKeyPattern(params map[string]string) (Hook, error) {
+	if params["pattern"] == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	re, err := regexp.Compile(params["pattern"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	prefix := params["prefix"]
+	return HookFunc(func(ctx context.Context, req *Request) error {
+		if req.Type != kvstore.OpPut || !strings.HasPrefix(req.Key, prefix) {
+			return nil
+		}
+		if !re.MatchString(req.Key) {
+			return Rejectf("key %q does not match %s", req.Key, re)
+		}
+		return nil
+	}), nil
+}
+
+// newSizeLimit 限制 prefix 下 key 和 value 的大小
+//
+// 参数：max_key_size、max_value_size（字节，0 或不设置为不限制），prefix（可选）
+func newSizeLimit(params map[string]string) (Hook, error) {
+	maxKey, err := intParam(params, "max_key_size")
+	if err != nil {
+		return nil, err
+	}
+	maxValue, err := intParam(params, "max_value_size")
+	if err != nil {
+		return nil, err
+	}
+	prefix := params["prefix"]
+	return HookFunc(func(ctx context.Context, req *Request) error {
+		if req.Type != kvstore.OpPut || !strings.HasPrefix(req.Key, prefix) {
+			return nil
+		}
+		if maxKey > 0 && len(req.Key) > maxKey {
+			return Rejectf("key of %d bytes exceeds %d", len(req.Key), maxKey)
+		}
+		if maxValue > 0 && len(req.Value) > maxValue {
+			return Rejectf("value of key %q has %d bytes, exceeds %d", req.Key, len(req.Value), maxValue)
+		}
+		return nil
+	}), nil
+}
+
+// newTenantPrefix 认证用户只能写 prefix + 用户名 + "/" 下的 key
+//
+// 参数：prefix（默认 "/tenants/"），exempt（不受限制的用户，逗号分隔，默认 "root"）。
+// 未认证的请求（例如 HTTP API）被拒绝，除非 hook 不作用于该前端
+func newTenantPrefix(params map[string]string) (Hook, error) {
+	prefix, ok := params["prefix"]
+	if !ok {
+		prefix = "/tenants/"
+	}
+	exempt, ok := params["exempt"]
+	if !ok {
+		exempt = "root"
+	}
+	exemptUsers := map[string]bool{}
+	for _, u := range strings.Split(exempt, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			exemptUsers[u] = true
+		}
+	}
+	return HookFunc(func(ctx context.Context, req *Request) error {
+		if exemptUsers[req.User] {
+			return nil
+		}
+		if req.User == "" {
+			return Rejectf("writes through %s must be authenticated", req.Frontend)
+		}
+		own := prefix + req.User + "/"
+		if !strings.HasPrefix(req.Key, own) {
+			return Rejectf("user %q may only write keys under %q", req.User, own)
+		}
+		// 范围删除不能超出自己的前缀
+		if req.Type == kvstore.OpDelete && req.RangeEnd != "" {
+			_, end := kvstore.PrefixRange(own)
+			if req.RangeEnd == "\x00" || req.RangeEnd > end {
+				return Rejectf("user %q may only delete keys under %q", req.User, own)
+			}
+		}
+		return nil
+	}), nil
+}
+
+func intParam(params map[string]string, name string) (int, error) {
+	v, ok := params[name]
+	if !ok || v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
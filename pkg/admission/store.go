@@ -0,0 +1,137 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+
+	"metaStore/internal/kvstore"
+)
+
+// admittingStore 在写入到达底层存储之前执行前端的 hook
+type admittingStore struct {
+	kvstore.Store
+	frontend string
+	hooks    []boundHook
+}
+
+// Wrap 返回执行 chain 中作用于 frontend 的 hook 的存储，没有这样的 hook 时原样返回
+func Wrap(store kvstore.Store, chain *Chain, frontend string) kvstore.Store {
+	hooks := chain.forFrontend(frontend)
+	if len(hooks) == 0 {
+		return store
+	}
+	return &admittingStore{Store: store, frontend: frontend, hooks: hooks}
+}
+
+// Unwrap 返回 Wrap 包装前的存储，供前端自身的内部写入（例如 etcd 认证数据）绕过 hook
+func Unwrap(store kvstore.Store) kvstore.Store {
+	if a, ok := store.(*admittingStore); ok {
+		return a.Store
+	}
+	return store
+}
+
+// Unwrap 实现 kvstore.Wrapper，供调用方查找底层存储的其他能力
+func (s *admittingStore) Unwrap() kvstore.Store {
+	return s.Store
+}
+
+// RangeFunc 读取不经过 hook，转发给底层存储以保留流式读取
+func (s *admittingStore) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.RangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// ReverseRangeFunc 同样转发给底层存储
+func (s *admittingStore) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.ReverseRangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// admit 执行 hook。内部 key（schema、索引定义等）由 MetaStore 自己管理，不经过 hook
+func (s *admittingStore) admit(ctx context.Context, req *Request) error {
+	if kvstore.IsSystemKey(req.Key) {
+		return nil
+	}
+	return admit(ctx, s.hooks, req)
+}
+
+func (s *admittingStore) request(ctx context.Context, op kvstore.OpType, key, rangeEnd string, value []byte, leaseID int64) *Request {
+	return &Request{
+		Frontend: s.frontend,
+		User:     UserFromContext(ctx),
+		Type:     op,
+		Key:      key,
+		RangeEnd: rangeEnd,
+		Value:    value,
+		LeaseID:  leaseID,
+	}
+}
+
+func (s *admittingStore) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	req := s.request(ctx, kvstore.OpPut, key, "", []byte(value), leaseID)
+	if err := s.admit(ctx, req); err != nil {
+		return 0, nil, err
+	}
+	return s.Store.PutWithLease(ctx, req.Key, string(req.Value), req.LeaseID)
+}
+
+func (s *admittingStore) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	req := s.request(ctx, kvstore.OpDelete, key, rangeEnd, nil, 0)
+	if err := s.admit(ctx, req); err != nil {
+		return 0, nil, 0, err
+	}
+	return s.Store.DeleteRange(ctx, req.Key, req.RangeEnd)
+}
+
+// Txn 检查两个分支中的所有 PUT 和 DELETE，任一被拒绝则整个事务被拒绝
+func (s *admittingStore) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+	var err error
+	if thenOps, err = s.admitOps(ctx, thenOps); err != nil {
+		return nil, err
+	}
+	if elseOps, err = s.admitOps(ctx, elseOps); err != nil {
+		return nil, err
+	}
+	return s.Store.Txn(ctx, cmps, thenOps, elseOps)
+}
+
+// admitOps 返回 hook 改写后的操作，不修改调用方的 ops
+func (s *admittingStore) admitOps(ctx context.Context, ops []kvstore.Op) ([]kvstore.Op, error) {
+	var admitted []kvstore.Op
+	for i, op := range ops {
+		if op.Type != kvstore.OpPut && op.Type != kvstore.OpDelete {
+			continue
+		}
+		req := s.request(ctx, op.Type, string(op.Key), string(op.RangeEnd), op.Value, op.LeaseID)
+		if err := s.admit(ctx, req); err != nil {
+			return nil, err
+		}
+		if admitted == nil {
+			admitted = append([]kvstore.Op(nil), ops...)
+		}
+		if req.Key != string(op.Key) {
+			admitted[i].Key = []byte(req.Key)
+		}
+		if req.RangeEnd != string(op.RangeEnd) {
+			admitted[i].RangeEnd = []byte(req.RangeEnd)
+		}
+		admitted[i].Value = req.Value
+		admitted[i].LeaseID = req.LeaseID
+	}
+	if admitted == nil {
+		return ops, nil
+	}
+	return admitted, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backup 为导出的快照（etcdctl snapshot save、metastorectl snapshot save）
+// 加上清单，可选地加密
+//
+// 清单记录快照明文的 SHA-256 摘要和长度，恢复前校验，发现截断或损坏。
+// 加密使用 pkg/encryption 的 AES-256-GCM 信封加密，清单作为附加认证数据，
+// 因此修改清单或密文都会导致解密失败；只签名不加密时，能改写文件的人也能改写清单，
+// 防篡改需要同时启用加密
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"metaStore/pkg/encryption"
+)
+
+// FormatVersion 当前的清单格式版本
+const FormatVersion = 1
+
+// Cipher 清单中记录的加密算法
+const Cipher = "AES-256-GCM"
+
+// magic 带清单的快照的前缀
+var magic = []byte("MSBK1\n")
+
+var (
+	// ErrNotSealed 数据不是带清单的快照
+	ErrNotSealed = errors.New("backup: snapshot has no manifest")
+	// ErrDigestMismatch 快照与清单中的摘要不一致
+	ErrDigestMismatch = errors.New("backup: snapshot digest does not match the manifest")
+	// ErrNoKey 快照已加密，但没有提供密钥
+	ErrNoKey = errors.New("backup: snapshot is encrypted but no key is configured")
+)
+
+// Manifest 快照清单
+type Manifest struct {
+	Format    int       `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+	ClusterID uint64    `json:"cluster_id,omitempty"`
+	MemberID  uint64    `json:"member_id,omitempty"`
+	Revision  int64     `json:"revision"`
+	Size      int64     `json:"size"`             // 快照明文的字节数
+	SHA256    string    `json:"sha256"`           // 快照明文的 SHA-256（hex）
+	Cipher    string    `json:"cipher,omitempty"` // 加密算法，未加密时为空
+	KeyID     string    `json:"key_id,omitempty"` // 加密使用的 KEK ID
+}
+
+// Encrypted 快照是否已加密
+func (m *Manifest) Encrypted() bool {
+	return m.Cipher != ""
+}
+
+// Seal 计算 snapshot 的摘要填入清单，keyring 不为 nil 时加密快照
+//
+// 格式：magic(6) | manifestLen(4) | manifest JSON | 快照明文或 encryption 密文
+func Seal(snapshot []byte, m Manifest, keyring *encryption.Keyring) ([]byte, error) {
+	sum := sha256.Sum256(snapshot)
+	m.Format = FormatVersion
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+	m.Size = int64(len(snapshot))
+	m.SHA256 = hex.EncodeToString(sum[:])
+	m.Cipher, m.KeyID = "", ""
+	if keyring != nil {
+		m.Cipher, m.KeyID = Cipher, keyring.ActiveKeyID()
+	}
+	header, err := json.Marshal(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := snapshot
+	if keyring != nil {
+		if payload, err = keyring.Encrypt(snapshot, header); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]byte, 0, len(magic)+4+len(header)+len(payload))
+	out = append(out, magic...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(header)))
+	out = append(out, header...)
+	return append(out, payload...), nil
+}
+
+// IsSealed 判断 data 是否是 Seal 生成的快照
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// ReadManifest 只解析清单，不解密也不校验快照
+func ReadManifest(data []byte) (*Manifest, error) {
+	m, _, _, err := parse(data)
+	return m, err
+}
+
+// Open 解密快照并按清单校验，返回快照明文和清单
+//
+// 快照已加密时 keyring 必须包含加密使用的 KEK
+func Open(data []byte, keyring *encryption.Keyring) ([]byte, *Manifest, error) {
+	m, header, payload, err := parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot := payload
+	if m.Encrypted() {
+		if m.Cipher != Cipher {
+			return nil, nil, fmt.Errorf("backup: unsupported cipher %q", m.Cipher)
+		}
+		if keyring == nil {
+			return nil, nil, ErrNoKey
+		}
+		if snapshot, err = keyring.Decrypt(payload, header); err != nil {
+			return nil, nil, fmt.Errorf("backup: snapshot or manifest was modified, or the key is wrong: %w", err)
+		}
+	}
+
+	sum := sha256.Sum256(snapshot)
+	if int64(len(snapshot)) != m.Size || hex.EncodeToString(sum[:]) != m.SHA256 {
+		return nil, nil, fmt.Errorf("%w: expected %d bytes with sha256 %s, got %d bytes with sha256 %x",
+			ErrDigestMismatch, m.Size, m.SHA256, len(snapshot), sum)
+	}
+	return snapshot, m, nil
+}
+
+// parse 拆分清单和快照
+func parse(data []byte) (*Manifest, []byte, []byte, error) {
+	if !IsSealed(data) {
+		return nil, nil, nil, ErrNotSealed
+	}
+	rest := data[len(magic):]
+	if len(rest) < 4 {
+		return nil, nil, nil, fmt.Errorf("backup: manifest is truncated")
+	}
+	n := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if uint64(len(rest)) < uint64(n) {
+		return nil, nil, nil, fmt.Errorf("backup: manifest is truncated")
+	}
+	header, payload := rest[:n], rest[n:]
+
+	var m Manifest
+	if err := json.Unmarshal(header, &m); err != nil {
+		return nil, nil, nil, fmt.Errorf("backup: invalid manifest: %w", err)
+	}
+	if m.Format != FormatVersion {
+		return nil, nil, nil, fmt.Errorf("backup: unsupported manifest format %d", m.Format)
+	}
+	return &m, header, payload, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"bytes"
+	"testing"
+
+	"metaStore/pkg/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeyring(t *testing.T, id string, b byte) *encryption.Keyring {
+	k, err := encryption.NewKeyring(id, map[string][]byte{id: bytes.Repeat([]byte{b}, encryption.KeySize)})
+	require.NoError(t, err)
+	return k
+}
+
+func TestSealOpenSigned(t *testing.T) {
+	snapshot := []byte("snapshot data")
+	sealed, err := Seal(snapshot, Manifest{MemberID: 2, Revision: 42}, nil)
+	require.NoError(t, err)
+	assert.True(t, IsSealed(sealed))
+	assert.True(t, bytes.Contains(sealed, snapshot), "signed snapshots are not encrypted")
+
+	data, m, err := Open(sealed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, data)
+	assert.Equal(t, uint64(2), m.MemberID)
+	assert.Equal(t, int64(42), m.Revision)
+	assert.Equal(t, int64(len(snapshot)), m.Size)
+	assert.False(t, m.Encrypted())
+
+	// 快照被修改或截断
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 0xff
+	_, _, err = Open(tampered, nil)
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+	_, _, err = Open(sealed[:len(sealed)-1], nil)
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	_, _, err = Open(snapshot, nil)
+	assert.ErrorIs(t, err, ErrNotSealed)
+}
+
+func TestSealOpenEncrypted(t *testing.T) {
+	keyring := testKeyring(t, "backup-1", 1)
+	snapshot := []byte("tenant secrets")
+	sealed, err := Seal(snapshot, Manifest{Revision: 7}, keyring)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(sealed, snapshot))
+
+	m, err := ReadManifest(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, Cipher, m.Cipher)
+	assert.Equal(t, "backup-1", m.KeyID)
+
+	data, _, err := Open(sealed, keyring)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, data)
+
+	_, _, err = Open(sealed, nil)
+	assert.ErrorIs(t, err, ErrNoKey)
+	_, _, err = Open(sealed, testKeyring(t, "backup-1", 2))
+	assert.Error(t, err, "wrong key")
+
+	// 清单由 GCM 认证，改写其中的摘要或版本号都会解密失败
+	tampered := bytes.Replace(sealed, []byte(`"revision":7`), []byte(`"revision":8`), 1)
+	require.NotEqual(t, sealed, tampered)
+	_, _, err = Open(tampered, keyring)
+	assert.Error(t, err)
+}
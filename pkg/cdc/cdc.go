@@ -0,0 +1,495 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cdc 将 key 的变更（Change Data Capture）发布到 Kafka 或 NATS JetStream
+//
+// leader 订阅本地 watch 流，按前缀路由到 topic/subject，消息被确认后才推进游标，
+// 游标（已发布的 revision）定期写入本地 store 并随 Raft 复制，因此投递语义为 at-least-once：
+// 进程崩溃或 leader 切换后会从游标处重新发布，消费者需要按 (key, mod_revision) 去重
+//
+// 本地 watch 不保留历史，重新订阅时先对路由前缀做一次全量扫描，
+// 发布游标之后修改过的 key；同一节点上能识别期间被删除的 key，
+// 但 leader 切换或重启期间发生的删除不会被发布
+package cdc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errNotLeader    = errors.New("cdc: not leader")
+	errWatchClosed  = errors.New("cdc: local watch closed")
+	leaderPollDelay = time.Second
+)
+
+const (
+	// retryMin/retryMax 会话失败后重连的退避区间
+	retryMin = time.Second
+	retryMax = 30 * time.Second
+
+	// watchIDBase 内部 watch 使用负数 ID，与 mirror（-1<<40 起）错开
+	watchIDBase int64 = -2 << 40
+)
+
+// Event 发布到消息系统的变更事件（JSON），字段与 etcd 的 mvccpb.Event 对应
+type Event struct {
+	Type     string    `json:"type"`              // "PUT" 或 "DELETE"
+	Revision int64     `json:"revision"`          // 产生该事件的 revision
+	Kv       KeyValue  `json:"kv"`                // DELETE 时只有 key、mod_revision 和 hlc
+	PrevKv   *KeyValue `json:"prev_kv,omitempty"` // 修改前的值（store 支持时）
+}
+
+// KeyValue 事件中的键值，value 以 base64 编码
+type KeyValue struct {
+	Key            string `json:"key"`
+	Value          []byte `json:"value,omitempty"`
+	CreateRevision int64  `json:"create_revision,omitempty"`
+	ModRevision    int64  `json:"mod_revision"`
+	Version        int64  `json:"version,omitempty"`
+	Lease          int64  `json:"lease,omitempty"`
+	HLC            uint64 `json:"hlc,omitempty"` // 提交时的 HLC，可用于合并多个集群的事件
+}
+
+// Event types
+const (
+	EventPut    = "PUT"
+	EventDelete = "DELETE"
+)
+
+// optionWatcher 支持 PrevKV 的 store
+type optionWatcher interface {
+	WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error)
+}
+
+// Manager 运行配置中的所有 CDC sink
+// 每个节点都创建 Manager，但只有 leader 上的 sink 会发布事件
+type Manager struct {
+	sinks  []*sink
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager 根据配置创建 sink，调用 Start 后开始运行
+func NewManager(store kvstore.Store, cfg config.CDCConfig) *Manager {
+	return newManager(store, cfg, dialPublisher)
+}
+
+func newManager(store kvstore.Store, cfg config.CDCConfig, dial func(config.CDCSinkConfig) (publisher, error)) *Manager {
+	mgr := &Manager{}
+	for i, sc := range cfg.Sinks {
+		routes := slices.Clone(sc.Routes)
+		// 最长前缀优先匹配
+		sort.SliceStable(routes, func(a, b int) bool { return len(routes[a].Prefix) > len(routes[b].Prefix) })
+		mgr.sinks = append(mgr.sinks, &sink{
+			cfg:            sc,
+			routes:         routes,
+			store:          store,
+			dial:           dial,
+			cursorInterval: cfg.CursorInterval,
+			watchID:        watchIDBase - int64(i),
+		})
+	}
+	return mgr
+}
+
+// Start 启动所有 sink
+func (mgr *Manager) Start() {
+	if len(mgr.sinks) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr.cancel = cancel
+	for _, s := range mgr.sinks {
+		mgr.wg.Add(1)
+		go func() {
+			defer mgr.wg.Done()
+			s.loop(ctx)
+		}()
+		log.Info("CDC sink started",
+			zap.String("sink", s.cfg.Name),
+			zap.String("type", s.cfg.Type),
+			zap.Strings("endpoints", s.cfg.Endpoints),
+			zap.String("component", "cdc"))
+	}
+}
+
+// Close 停止所有 sink，已确认的游标会在停止前持久化
+func (mgr *Manager) Close() {
+	if mgr.cancel == nil {
+		return
+	}
+	mgr.cancel()
+	mgr.wg.Wait()
+}
+
+// sink 单个 CDC 发布目标
+type sink struct {
+	cfg            config.CDCSinkConfig
+	routes         []config.CDCRoute // 按前缀长度降序
+	store          kvstore.Store
+	dial           func(config.CDCSinkConfig) (publisher, error)
+	cursorInterval time.Duration
+	watchID        int64
+
+	// known 上一次会话结束时路由范围内存在的 key，用于在重新订阅时识别期间的删除
+	// 进程启动后的第一次会话为 nil
+	known map[string]struct{}
+}
+
+// cursorKey 保存已发布 revision 的本地 key
+func (s *sink) cursorKey() string {
+	return kvstore.SystemKeyPrefix + "cdc/" + s.cfg.Name
+}
+
+// loop 只在本节点是 leader 时运行发布会话，会话失败后退避重试
+func (s *sink) loop(ctx context.Context) {
+	backoff := retryMin
+	for {
+		delay := leaderPollDelay
+		if s.isLeader() {
+			err := s.session(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case errors.Is(err, errNotLeader):
+				backoff = retryMin
+			case err != nil:
+				log.Warn("CDC session failed",
+					zap.String("sink", s.cfg.Name),
+					zap.Error(err),
+					zap.Duration("retry_in", backoff),
+					zap.String("component", "cdc"))
+				delay = backoff
+				backoff = min(backoff*2, retryMax)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// session 连接消息系统、补发游标之后的变更并持续发布 watch 事件，直到失去 leader 或出错
+func (s *sink) session(ctx context.Context) error {
+	pub, err := s.dial(s.cfg)
+	if err != nil {
+		return fmt.Errorf("connect %s: %w", s.cfg.Type, err)
+	}
+	defer pub.close()
+
+	cursor, err := s.loadCursor(ctx)
+	if err != nil {
+		return fmt.Errorf("load cursor: %w", err)
+	}
+
+	// 先注册 watch 再扫描，扫描之后的变更都会出现在事件流中
+	events, err := s.watch()
+	if err != nil {
+		return fmt.Errorf("watch local store: %w", err)
+	}
+	defer s.store.CancelWatch(s.watchID)
+
+	snapRev, err := s.resync(ctx, pub, cursor)
+	if err != nil {
+		return fmt.Errorf("resync: %w", err)
+	}
+
+	saved, published := cursor, max(cursor, snapRev)
+	persist := func(ctx context.Context) {
+		if published <= saved {
+			return
+		}
+		if err := s.saveCursor(ctx, published); err != nil {
+			log.Warn("Failed to save CDC cursor",
+				zap.String("sink", s.cfg.Name),
+				zap.Int64("revision", published),
+				zap.Error(err),
+				zap.String("component", "cdc"))
+			return
+		}
+		saved = published
+	}
+	// 停止或退出时用独立的 context 保存游标
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), s.cfg.PublishTimeout)
+		persist(stopCtx)
+		cancel()
+	}()
+
+	cursorTicker := time.NewTicker(s.cursorInterval)
+	defer cursorTicker.Stop()
+	leaderTicker := time.NewTicker(leaderPollDelay)
+	defer leaderTicker.Stop()
+
+	batch := make([]kvstore.WatchEvent, 0, s.cfg.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case ev, ok := <-events:
+			if !ok {
+				return errWatchClosed
+			}
+			// 取出已到达的事件，一起发布
+			batch = append(batch[:0], ev)
+		drain:
+			for len(batch) < s.cfg.BatchSize {
+				select {
+				case ev, ok := <-events:
+					if !ok {
+						break drain
+					}
+					batch = append(batch, ev)
+				default:
+					break drain
+				}
+			}
+
+			var msgs []message
+			var rev int64
+			n := 0 // batch[:n] 为需要发布的事件
+			for _, ev := range batch {
+				r := eventRevision(ev)
+				if ev.Kv == nil || (r != 0 && r <= snapRev) {
+					continue
+				}
+				key := string(ev.Kv.Key)
+				if kvstore.IsSystemKey(key) {
+					continue
+				}
+				route, ok := s.route(key)
+				if !ok {
+					continue
+				}
+				msg, err := newMessage(route, ev, r)
+				if err != nil {
+					return err
+				}
+				msgs = append(msgs, msg)
+				rev = max(rev, r)
+				batch[n] = ev
+				n++
+			}
+			if len(msgs) == 0 {
+				continue
+			}
+			if err := s.publish(ctx, pub, msgs); err != nil {
+				return err
+			}
+			published = max(published, rev)
+			for _, ev := range batch[:n] {
+				s.track(ev)
+			}
+
+		case <-cursorTicker.C:
+			persist(ctx)
+
+		case <-leaderTicker.C:
+			if !s.isLeader() {
+				return errNotLeader
+			}
+		}
+	}
+}
+
+// resync 发布游标之后修改过的 key 以及上一次会话之后被删除的 key，返回扫描对应的 revision
+func (s *sink) resync(ctx context.Context, pub publisher, cursor int64) (int64, error) {
+	// 使用扫描前的 revision，保守地把扫描期间的变更交给事件流重复发布
+	snapRev := s.store.CurrentRevision()
+
+	var kvs []*kvstore.KeyValue
+	present := make(map[string]struct{})
+	for _, r := range s.routes {
+		key, rangeEnd := kvstore.PrefixRange(r.Prefix)
+		resp, err := s.store.Range(ctx, key, rangeEnd, 0, 0)
+		if err != nil {
+			return 0, err
+		}
+		for _, kv := range resp.Kvs {
+			k := string(kv.Key)
+			if _, dup := present[k]; dup || kvstore.IsSystemKey(k) {
+				continue
+			}
+			present[k] = struct{}{}
+			if kv.ModRevision > cursor {
+				kvs = append(kvs, kv)
+			}
+		}
+	}
+	// 按修改顺序发布
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].ModRevision < kvs[j].ModRevision })
+
+	var msgs []message
+	for k := range s.known {
+		if _, ok := present[k]; ok {
+			continue
+		}
+		route, _ := s.route(k)
+		ev := kvstore.WatchEvent{Type: kvstore.EventTypeDelete, Kv: &kvstore.KeyValue{Key: []byte(k), ModRevision: snapRev}, Revision: snapRev}
+		msg, err := newMessage(route, ev, snapRev)
+		if err != nil {
+			return 0, err
+		}
+		msgs = append(msgs, msg)
+	}
+	for _, kv := range kvs {
+		route, _ := s.route(string(kv.Key))
+		msg, err := newMessage(route, kvstore.WatchEvent{Type: kvstore.EventTypePut, Kv: kv}, kv.ModRevision)
+		if err != nil {
+			return 0, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	for len(msgs) > 0 {
+		n := min(len(msgs), s.cfg.BatchSize)
+		if err := s.publish(ctx, pub, msgs[:n]); err != nil {
+			return 0, err
+		}
+		msgs = msgs[n:]
+	}
+	s.known = present
+	return snapRev, nil
+}
+
+func (s *sink) publish(ctx context.Context, pub publisher, msgs []message) error {
+	pubCtx, cancel := context.WithTimeout(ctx, s.cfg.PublishTimeout)
+	defer cancel()
+	if err := pub.publish(pubCtx, msgs); err != nil {
+		return fmt.Errorf("publish to %s: %w", s.cfg.Type, err)
+	}
+	return nil
+}
+
+func (s *sink) watch() (<-chan kvstore.WatchEvent, error) {
+	// 只有一个路由时只订阅该前缀，否则订阅全部 key 再按路由过滤
+	key, rangeEnd := kvstore.PrefixRange("")
+	if len(s.routes) == 1 {
+		key, rangeEnd = kvstore.PrefixRange(s.routes[0].Prefix)
+	}
+	if ow, ok := kvstore.As[optionWatcher](s.store); ok {
+		return ow.WatchWithOptions(key, rangeEnd, 0, s.watchID, &kvstore.WatchOptions{PrevKV: true})
+	}
+	return s.store.Watch(context.Background(), key, rangeEnd, 0, s.watchID)
+}
+
+// route 返回 key 匹配的最长前缀路由
+func (s *sink) route(key string) (config.CDCRoute, bool) {
+	for _, r := range s.routes {
+		if strings.HasPrefix(key, r.Prefix) {
+			return r, true
+		}
+	}
+	return config.CDCRoute{}, false
+}
+
+// track 根据已确认的事件更新 known
+func (s *sink) track(ev kvstore.WatchEvent) {
+	if s.known == nil {
+		return
+	}
+	if ev.Type == kvstore.EventTypeDelete {
+		delete(s.known, string(ev.Kv.Key))
+	} else {
+		s.known[string(ev.Kv.Key)] = struct{}{}
+	}
+}
+
+func (s *sink) loadCursor(ctx context.Context) (int64, error) {
+	resp, err := s.store.Range(ctx, s.cursorKey(), "", 1, 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+}
+
+func (s *sink) saveCursor(ctx context.Context, rev int64) error {
+	_, _, err := s.store.PutWithLease(ctx, s.cursorKey(), strconv.FormatInt(rev, 10), 0)
+	return err
+}
+
+func (s *sink) isLeader() bool {
+	st := s.store.GetRaftStatus()
+	return st.LeaderID != 0 && st.LeaderID == st.NodeID
+}
+
+// newMessage 将 watch 事件编码为消息
+func newMessage(route config.CDCRoute, ev kvstore.WatchEvent, rev int64) (message, error) {
+	e := Event{Type: EventPut, Revision: rev, Kv: toKeyValue(ev.Kv)}
+	if ev.Type == kvstore.EventTypeDelete {
+		e.Type = EventDelete
+		e.Kv = KeyValue{Key: string(ev.Kv.Key), ModRevision: rev, HLC: ev.Kv.HLC}
+	}
+	if ev.PrevKv != nil {
+		prev := toKeyValue(ev.PrevKv)
+		e.PrevKv = &prev
+	}
+
+	value, err := json.Marshal(e)
+	if err != nil {
+		return message{}, fmt.Errorf("encode event: %w", err)
+	}
+	return message{
+		topic: route.Topic,
+		key:   ev.Kv.Key,
+		id:    hex.EncodeToString(ev.Kv.Key) + "@" + strconv.FormatInt(rev, 10),
+		value: value,
+	}, nil
+}
+
+func toKeyValue(kv *kvstore.KeyValue) KeyValue {
+	return KeyValue{
+		Key:            string(kv.Key),
+		Value:          kv.Value,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Version:        kv.Version,
+		Lease:          kv.Lease,
+		HLC:            kv.HLC,
+	}
+}
+
+// eventRevision 返回事件对应的 revision，未知时返回 0
+func eventRevision(ev kvstore.WatchEvent) int64 {
+	if ev.Revision != 0 {
+		return ev.Revision
+	}
+	if ev.Kv != nil {
+		return ev.Kv.ModRevision
+	}
+	return 0
+}
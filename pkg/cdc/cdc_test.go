@@ -0,0 +1,205 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePublisher 记录已确认的消息，fail 为 true 时拒绝发布
+type fakePublisher struct {
+	mu   sync.Mutex
+	msgs []message
+	fail bool
+}
+
+func (p *fakePublisher) publish(ctx context.Context, msgs []message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		return errors.New("broker unavailable")
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *fakePublisher) close() error { return nil }
+
+func (p *fakePublisher) setFail(fail bool) {
+	p.mu.Lock()
+	p.fail = fail
+	p.mu.Unlock()
+}
+
+// received 返回已确认的事件，格式为 topic:TYPE:key
+func (p *fakePublisher) received(t *testing.T) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []string
+	for _, m := range p.msgs {
+		var ev Event
+		require.NoError(t, json.Unmarshal(m.value, &ev))
+		out = append(out, m.topic+":"+ev.Type+":"+ev.Kv.Key)
+	}
+	return out
+}
+
+func (p *fakePublisher) events(t *testing.T) []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []Event
+	for _, m := range p.msgs {
+		var ev Event
+		require.NoError(t, json.Unmarshal(m.value, &ev))
+		out = append(out, ev)
+	}
+	return out
+}
+
+func TestCDCPublishesRoutedEvents(t *testing.T) {
+	oldDelay := leaderPollDelay
+	leaderPollDelay = 10 * time.Millisecond
+	defer func() { leaderPollDelay = oldDelay }()
+
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	put := func(k, v string) {
+		_, _, err := store.PutWithLease(ctx, k, v, 0)
+		require.NoError(t, err)
+	}
+	put("app/a", "1")
+	put("other/x", "ignored")
+
+	pub := &fakePublisher{}
+	cfg := config.CDCConfig{
+		CursorInterval: 10 * time.Millisecond,
+		Sinks: []config.CDCSinkConfig{{
+			Name: "events",
+			Type: config.CDCSinkKafka,
+			Routes: []config.CDCRoute{
+				{Prefix: "app/", Topic: "app"},
+				{Prefix: "app/users/", Topic: "users"},
+			},
+			BatchSize:      10,
+			PublishTimeout: time.Second,
+		}},
+	}
+	mgr := newManager(store, cfg, func(config.CDCSinkConfig) (publisher, error) { return pub, nil })
+	mgr.Start()
+	defer mgr.Close()
+
+	receivedEventually := func(want ...string) {
+		require.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual(want, pub.received(t))
+		}, 5*time.Second, 10*time.Millisecond, "got %v", pub.received(t))
+	}
+
+	// 已有的 key 作为初始快照发布
+	receivedEventually("app:PUT:app/a")
+
+	put("app/users/u1", "alice")
+	put("app/a", "2")
+	_, _, _, err := store.DeleteRange(ctx, "app/users/u1", "")
+	require.NoError(t, err)
+	put("other/y", "ignored")
+	receivedEventually(
+		"app:PUT:app/a",
+		"users:PUT:app/users/u1",
+		"app:PUT:app/a",
+		"users:DELETE:app/users/u1",
+	)
+
+	evs := pub.events(t)
+	assert.Equal(t, []byte("2"), evs[2].Kv.Value)
+	require.NotNil(t, evs[2].PrevKv)
+	assert.Equal(t, []byte("1"), evs[2].PrevKv.Value)
+	assert.Equal(t, evs[3].Revision, evs[3].Kv.ModRevision)
+
+	// 游标推进到最后一个已确认的 revision
+	require.Eventually(t, func() bool {
+		resp, err := store.Range(ctx, "__metastore/cdc/events", "", 1, 0)
+		if err != nil || len(resp.Kvs) == 0 {
+			return false
+		}
+		rev, _ := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+		return rev == evs[3].Revision
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCDCRetriesUnacknowledgedEvents(t *testing.T) {
+	oldDelay := leaderPollDelay
+	leaderPollDelay = 10 * time.Millisecond
+	defer func() { leaderPollDelay = oldDelay }()
+
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	pub := &fakePublisher{}
+	s := newManager(store, config.CDCConfig{
+		CursorInterval: time.Hour,
+		Sinks: []config.CDCSinkConfig{{
+			Name:           "events",
+			Type:           config.CDCSinkNATS,
+			Routes:         []config.CDCRoute{{Prefix: "", Topic: "all"}},
+			BatchSize:      10,
+			PublishTimeout: time.Second,
+		}},
+	}, func(config.CDCSinkConfig) (publisher, error) { return pub, nil }).sinks[0]
+
+	_, _, err := store.PutWithLease(ctx, "a", "1", 0)
+	require.NoError(t, err)
+	_, _, err = store.PutWithLease(ctx, "b", "1", 0)
+	require.NoError(t, err)
+
+	// 第一次会话发布快照后被停止，游标在退出时保存
+	sessionCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- s.session(sessionCtx) }()
+	require.Eventually(t, func() bool { return len(pub.received(t)) == 2 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	// broker 不可用时的变更不会推进游标
+	pub.setFail(true)
+	sessionCtx, cancel = context.WithCancel(ctx)
+	go func() { done <- s.session(sessionCtx) }()
+	time.Sleep(50 * time.Millisecond)
+	_, _, err = store.PutWithLease(ctx, "c", "1", 0)
+	require.NoError(t, err)
+	_, _, _, err = store.DeleteRange(ctx, "a", "")
+	require.NoError(t, err)
+	require.Error(t, <-done)
+	cancel()
+
+	// broker 恢复后补发游标之后的 PUT，以及本节点观察到的删除
+	pub.setFail(false)
+	sessionCtx, cancel = context.WithCancel(ctx)
+	go func() { done <- s.session(sessionCtx) }()
+	require.Eventually(t, func() bool { return len(pub.received(t)) == 4 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, []string{"all:PUT:a", "all:PUT:b", "all:DELETE:a", "all:PUT:c"}, pub.received(t))
+}
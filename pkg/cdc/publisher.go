@@ -0,0 +1,133 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"metaStore/pkg/config"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// message 发布到消息系统的一条事件
+type message struct {
+	topic string
+	key   []byte // Kafka 分区键，同一个 key 的事件保持顺序
+	id    string // 去重 ID（key + revision），NATS JetStream 据此丢弃重复消息
+	value []byte // JSON 编码的 Event
+}
+
+// publisher 消息系统客户端
+type publisher interface {
+	// publish 按顺序发布一组消息，全部被确认后才返回 nil
+	publish(ctx context.Context, msgs []message) error
+	close() error
+}
+
+func dialPublisher(cfg config.CDCSinkConfig) (publisher, error) {
+	switch cfg.Type {
+	case config.CDCSinkKafka:
+		return newKafkaPublisher(cfg), nil
+	case config.CDCSinkNATS:
+		return newNATSPublisher(cfg)
+	default:
+		return nil, fmt.Errorf("unknown cdc sink type %q", cfg.Type)
+	}
+}
+
+// kafkaPublisher 同步写入 Kafka，等待所有副本确认
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafkaPublisher(cfg config.CDCSinkConfig) *kafkaPublisher {
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Endpoints...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: cfg.PublishTimeout,
+	}}
+}
+
+func (p *kafkaPublisher) publish(ctx context.Context, msgs []message) error {
+	kmsgs := make([]kafka.Message, len(msgs))
+	for i, m := range msgs {
+		kmsgs[i] = kafka.Message{Topic: m.topic, Key: m.key, Value: m.value}
+	}
+	return p.writer.WriteMessages(ctx, kmsgs...)
+}
+
+func (p *kafkaPublisher) close() error {
+	return p.writer.Close()
+}
+
+// natsPublisher 通过 JetStream 发布，等待 stream 确认
+// subject 需要被某个 JetStream stream 捕获，否则发布会失败
+type natsPublisher struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+}
+
+func newNATSPublisher(cfg config.CDCSinkConfig) (*natsPublisher, error) {
+	conn, err := nats.Connect(strings.Join(cfg.Endpoints, ","),
+		nats.Name("metastore-cdc-"+cfg.Name),
+		nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &natsPublisher{conn: conn, js: js}, nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, msgs []message) error {
+	futures := make([]nats.PubAckFuture, 0, len(msgs))
+	for _, m := range msgs {
+		msg := nats.NewMsg(m.topic)
+		msg.Data = m.value
+		msg.Header.Set(nats.MsgIdHdr, m.id)
+		f, err := p.js.PublishMsgAsync(msg)
+		if err != nil {
+			return err
+		}
+		futures = append(futures, f)
+	}
+
+	for _, f := range futures {
+		select {
+		case <-f.Ok():
+		case err := <-f.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *natsPublisher) close() error {
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos provides process-wide fault injection hooks for raft transport
+// and storage. It is disabled by default; when disabled every hook is a single
+// atomic load, so the hooks can stay in production code paths.
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// Point identifies a location where faults can be injected
+type Point string
+
+const (
+	// RaftSend applies to raft messages sent to peers (drop/delay/duplicate)
+	RaftSend Point = "raft.send"
+	// RaftReceive applies to raft messages received from peers (drop/delay)
+	RaftReceive Point = "raft.receive"
+	// WALSync applies to raft log persistence (error = failed fsync, node stops)
+	WALSync Point = "wal.sync"
+	// RocksDBWrite applies to state machine writes in RocksDB (error = failed write, node stops when applying a commit)
+	RocksDBWrite Point = "rocksdb.write"
+)
+
+// Points lists all supported injection points
+var Points = []Point{RaftSend, RaftReceive, WALSync, RocksDBWrite}
+
+// ErrInjected is returned by hooks when a fault is injected
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Rule describes the faults applied at a point
+// Rates are probabilities in [0, 1] evaluated independently for each event
+type Rule struct {
+	DropRate      float64       `json:"drop_rate"`
+	DuplicateRate float64       `json:"duplicate_rate"`
+	ErrorRate     float64       `json:"error_rate"`
+	Delay         time.Duration `json:"delay"`
+	// Count limits how many events the rule affects, 0 means unlimited
+	Count int64 `json:"count"`
+}
+
+// Action is the outcome of evaluating a point
+type Action struct {
+	Drop      bool
+	Duplicate bool
+	Delay     time.Duration
+	Err       error
+}
+
+// injector holds the active rules
+type injector struct {
+	enabled atomic.Bool
+
+	mu    sync.Mutex
+	rules map[Point]*Rule
+	rnd   *rand.Rand
+	hits  map[Point]int64
+}
+
+var global = &injector{
+	rules: make(map[Point]*Rule),
+	hits:  make(map[Point]int64),
+	rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+}
+
+// Enable turns on fault injection process-wide
+func Enable() {
+	global.enabled.Store(true)
+}
+
+// Disable turns off fault injection; configured rules are kept
+func Disable() {
+	global.enabled.Store(false)
+}
+
+// Enabled reports whether fault injection is on
+func Enabled() bool {
+	return global.enabled.Load()
+}
+
+// Seed makes fault decisions reproducible
+func Seed(seed int64) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.rnd = rand.New(rand.NewSource(seed))
+}
+
+// Set installs or replaces the rule for a point
+func Set(p Point, r Rule) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	rule := r
+	global.rules[p] = &rule
+}
+
+// Clear removes the rule for a point
+func Clear(p Point) {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	delete(global.rules, p)
+}
+
+// Reset removes all rules and hit counters
+func Reset() {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	global.rules = make(map[Point]*Rule)
+	global.hits = make(map[Point]int64)
+}
+
+// Rules returns a copy of the active rules
+func Rules() map[Point]Rule {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	rules := make(map[Point]Rule, len(global.rules))
+	for p, r := range global.rules {
+		rules[p] = *r
+	}
+	return rules
+}
+
+// Hits returns how many events each point has affected
+func Hits() map[Point]int64 {
+	global.mu.Lock()
+	defer global.mu.Unlock()
+	hits := make(map[Point]int64, len(global.hits))
+	for p, n := range global.hits {
+		hits[p] = n
+	}
+	return hits
+}
+
+// Eval decides which faults apply to one event at the given point
+func Eval(p Point) Action {
+	if !global.enabled.Load() {
+		return Action{}
+	}
+
+	global.mu.Lock()
+	defer global.mu.Unlock()
+
+	rule, ok := global.rules[p]
+	if !ok {
+		return Action{}
+	}
+
+	var a Action
+	a.Drop = rule.DropRate > 0 && global.rnd.Float64() < rule.DropRate
+	a.Duplicate = rule.DuplicateRate > 0 && global.rnd.Float64() < rule.DuplicateRate
+	if rule.ErrorRate > 0 && global.rnd.Float64() < rule.ErrorRate {
+		a.Err = ErrInjected
+	}
+	a.Delay = rule.Delay
+
+	if a == (Action{}) {
+		return a
+	}
+
+	global.hits[p]++
+	if rule.Count > 0 {
+		rule.Count--
+		if rule.Count == 0 {
+			delete(global.rules, p)
+		}
+	}
+	return a
+}
+
+// InjectError applies delay and error faults for a synchronous operation
+func InjectError(p Point) error {
+	if !global.enabled.Load() {
+		return nil
+	}
+	a := Eval(p)
+	if a.Delay > 0 {
+		time.Sleep(a.Delay)
+	}
+	if a.Drop {
+		return ErrInjected
+	}
+	return a.Err
+}
+
+// AllowReceive applies faults to an inbound raft message and reports whether
+// it should be processed
+func AllowReceive() bool {
+	if !global.enabled.Load() {
+		return true
+	}
+	a := Eval(RaftReceive)
+	if a.Delay > 0 {
+		time.Sleep(a.Delay)
+	}
+	return !a.Drop && a.Err == nil
+}
+
+// FilterMessages applies send faults to outbound raft messages. Dropped
+// messages are removed, duplicated ones are repeated, and delayed ones are
+// handed to deliver asynchronously after the delay.
+func FilterMessages(msgs []raftpb.Message, deliver func([]raftpb.Message)) []raftpb.Message {
+	if !global.enabled.Load() || len(msgs) == 0 {
+		return msgs
+	}
+
+	out := make([]raftpb.Message, 0, len(msgs))
+	for _, m := range msgs {
+		a := Eval(RaftSend)
+		if a.Drop || a.Err != nil {
+			continue
+		}
+		batch := []raftpb.Message{m}
+		if a.Duplicate {
+			batch = append(batch, m)
+		}
+		if a.Delay > 0 {
+			time.AfterFunc(a.Delay, func() { deliver(batch) })
+			continue
+		}
+		out = append(out, batch...)
+	}
+	return out
+}
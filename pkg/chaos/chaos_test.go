@@ -0,0 +1,69 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+func TestDisabledIsNoop(t *testing.T) {
+	Reset()
+	Disable()
+	Set(WALSync, Rule{ErrorRate: 1})
+	defer Reset()
+
+	assert.NoError(t, InjectError(WALSync))
+	assert.True(t, AllowReceive())
+}
+
+func TestFilterMessages(t *testing.T) {
+	Reset()
+	Enable()
+	defer func() {
+		Disable()
+		Reset()
+	}()
+
+	msgs := []raftpb.Message{{Type: raftpb.MsgApp}, {Type: raftpb.MsgHeartbeat}}
+	noDeliver := func([]raftpb.Message) { t.Fatal("unexpected delayed delivery") }
+
+	Set(RaftSend, Rule{DropRate: 1})
+	assert.Empty(t, FilterMessages(msgs, noDeliver))
+
+	Set(RaftSend, Rule{DuplicateRate: 1})
+	assert.Len(t, FilterMessages(msgs, noDeliver), 4)
+
+	Clear(RaftSend)
+	assert.Equal(t, msgs, FilterMessages(msgs, noDeliver))
+}
+
+func TestRuleCount(t *testing.T) {
+	Reset()
+	Enable()
+	defer func() {
+		Disable()
+		Reset()
+	}()
+
+	Set(RocksDBWrite, Rule{ErrorRate: 1, Count: 2})
+	assert.ErrorIs(t, InjectError(RocksDBWrite), ErrInjected)
+	assert.ErrorIs(t, InjectError(RocksDBWrite), ErrInjected)
+	assert.NoError(t, InjectError(RocksDBWrite))
+	assert.Equal(t, int64(2), Hits()[RocksDBWrite])
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ruleRequest is the JSON form of a rule accepted by the admin endpoint
+// Delay uses Go duration syntax, e.g. "50ms"
+type ruleRequest struct {
+	Point         Point   `json:"point"`
+	DropRate      float64 `json:"drop_rate"`
+	DuplicateRate float64 `json:"duplicate_rate"`
+	ErrorRate     float64 `json:"error_rate"`
+	Delay         string  `json:"delay"`
+	Count         int64   `json:"count"`
+}
+
+// status is returned by GET
+type status struct {
+	Enabled bool            `json:"enabled"`
+	Rules   map[Point]Rule  `json:"rules"`
+	Hits    map[Point]int64 `json:"hits"`
+}
+
+// Handler returns the admin endpoint for fault injection:
+//
+//	GET     list rules and hit counters
+//	PUT     install a rule (JSON body, see ruleRequest)
+//	DELETE  remove the rule given by ?point=, or all rules when omitted
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeStatus(w)
+
+		case http.MethodPut, http.MethodPost:
+			var req ruleRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, fmt.Sprintf("invalid rule: %v", err), http.StatusBadRequest)
+				return
+			}
+			rule, err := req.toRule()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Set(req.Point, rule)
+			writeStatus(w)
+
+		case http.MethodDelete:
+			if p := r.URL.Query().Get("point"); p != "" {
+				Clear(Point(p))
+			} else {
+				Reset()
+			}
+			writeStatus(w)
+
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (req ruleRequest) toRule() (Rule, error) {
+	if !validPoint(req.Point) {
+		return Rule{}, fmt.Errorf("unknown point %q", req.Point)
+	}
+	for _, rate := range []float64{req.DropRate, req.DuplicateRate, req.ErrorRate} {
+		if rate < 0 || rate > 1 {
+			return Rule{}, fmt.Errorf("rates must be between 0.0 and 1.0")
+		}
+	}
+
+	rule := Rule{
+		DropRate:      req.DropRate,
+		DuplicateRate: req.DuplicateRate,
+		ErrorRate:     req.ErrorRate,
+		Count:         req.Count,
+	}
+	if req.Delay != "" {
+		d, err := time.ParseDuration(req.Delay)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid delay: %v", err)
+		}
+		rule.Delay = d
+	}
+	return rule, nil
+}
+
+func validPoint(p Point) bool {
+	for _, known := range Points {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+func writeStatus(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status{
+		Enabled: Enabled(),
+		Rules:   Rules(),
+		Hits:    Hits(),
+	})
+}
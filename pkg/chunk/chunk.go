@@ -0,0 +1,133 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chunk 实现大 value 的分段存储
+//
+// 超过 chunk_size 的 value 被切分为若干段，每段作为一个独立的 Raft 提案写入
+// __metastore/chunk/<id>/<序号>，全部写完后在原 key 下写入 manifest。读取时按
+// manifest 依次读出各段并拼接，对上层协议透明。每次写入使用新的 id，段写入后不再
+// 修改，被覆盖或删除的 value 的段在 manifest 替换后回收
+package chunk
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"metaStore/internal/kvstore"
+)
+
+// segmentPrefix 段的 key 前缀：<segmentPrefix><id>/<序号>
+const segmentPrefix = kvstore.SystemKeyPrefix + "chunk/"
+
+// manifestMagic 标记 manifest value，后接 JSON 编码的 Manifest
+// 以该前缀开头的普通 value 也会被分段存储，因此不会被误认为 manifest
+var manifestMagic = []byte("\x00mschunk\x00")
+
+var (
+	// ErrValueTooLarge value 超过 max_value_size
+	ErrValueTooLarge = errors.New("chunk: value is too large")
+	// ErrCorrupted manifest 引用的段缺失或长度不符
+	ErrCorrupted = errors.New("chunk: chunked value is corrupted")
+)
+
+// ValueTooLargeError value 超过所在前缀的大小上限，errors.Is 匹配 ErrValueTooLarge
+type ValueTooLargeError struct {
+	Key    string
+	Size   int64
+	Limit  int64
+	Prefix string // 生效的覆盖前缀，默认上限时为空
+}
+
+func (e *ValueTooLargeError) Error() string {
+	if e.Prefix == "" {
+		return fmt.Sprintf("%v: key %q has %d bytes (max %d bytes)", ErrValueTooLarge, e.Key, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("%v: key %q has %d bytes (max %d bytes under %q)", ErrValueTooLarge, e.Key, e.Size, e.Limit, e.Prefix)
+}
+
+func (e *ValueTooLargeError) Is(target error) bool {
+	return target == ErrValueTooLarge
+}
+
+// Manifest 描述一个分段存储的 value
+type Manifest struct {
+	ID     string `json:"id"`
+	Size   int64  `json:"size"`
+	Chunks int    `json:"chunks"`
+}
+
+// Streamer 按段把 value 写出而不在内存中拼接完整的 value
+type Streamer interface {
+	StreamValue(ctx context.Context, key string, w io.Writer) (bool, error)
+}
+
+// StreamValue 把 key 的 value 写入 w，store 不支持分段读取时退化为普通读取
+// 返回 false 表示 key 不存在
+func StreamValue(ctx context.Context, store kvstore.Store, key string, w io.Writer) (bool, error) {
+	if s, ok := kvstore.As[Streamer](store); ok {
+		return s.StreamValue(ctx, key, w)
+	}
+	resp, err := store.Range(ctx, key, "", 0, 0)
+	if err != nil {
+		return false, err
+	}
+	if len(resp.Kvs) == 0 {
+		return false, nil
+	}
+	_, err = w.Write(resp.Kvs[0].Value)
+	return true, err
+}
+
+// IsManifest 判断 value 是否为 manifest
+func IsManifest(value []byte) bool {
+	return bytes.HasPrefix(value, manifestMagic)
+}
+
+func parseManifest(value []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(value[len(manifestMagic):], &m); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	if m.ID == "" || m.Chunks <= 0 || m.Size < 0 {
+		return nil, fmt.Errorf("%w: invalid manifest", ErrCorrupted)
+	}
+	return &m, nil
+}
+
+func (m *Manifest) encode() []byte {
+	data, _ := json.Marshal(m)
+	return append(append([]byte(nil), manifestMagic...), data...)
+}
+
+// segmentKey 返回第 i 段的 key
+func (m *Manifest) segmentKey(i int) string {
+	return fmt.Sprintf("%s%s/%08d", segmentPrefix, m.ID, i)
+}
+
+// segmentRange 返回所有段所在的 key 范围
+func (m *Manifest) segmentRange() (string, string) {
+	return kvstore.PrefixRange(segmentPrefix + m.ID + "/")
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
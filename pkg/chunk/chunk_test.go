@@ -0,0 +1,218 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore() (*Store, kvstore.Store) {
+	base := memory.NewMemoryEtcd()
+	return Wrap(base, config.ChunkingConfig{ChunkSize: 8, MaxValueSize: 64}), base
+}
+
+// segmentCount 返回底层存储中的段数
+func segmentCount(t *testing.T, base kvstore.Store) int {
+	start, end := kvstore.PrefixRange(segmentPrefix)
+	resp, err := base.Range(context.Background(), start, end, 0, 0)
+	require.NoError(t, err)
+	return len(resp.Kvs)
+}
+
+func TestStoreChunksLargeValues(t *testing.T) {
+	ctx := context.Background()
+	s, base := newTestStore()
+
+	large := strings.Repeat("0123456789", 3)
+	_, _, err := s.PutWithLease(ctx, "blob", large, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, segmentCount(t, base))
+
+	// 底层只保存 manifest
+	raw, ok := base.Lookup("blob")
+	require.True(t, ok)
+	assert.True(t, IsManifest([]byte(raw)))
+
+	v, ok := s.Lookup("blob")
+	require.True(t, ok)
+	assert.Equal(t, large, v)
+
+	_, _, err = s.PutWithLease(ctx, "small", "tiny", 0)
+	require.NoError(t, err)
+	resp, err := s.Range(ctx, "", "\x00", 0, 0)
+	require.NoError(t, err)
+	values := map[string]string{}
+	for _, kv := range resp.Kvs {
+		if !kvstore.IsSystemKey(string(kv.Key)) {
+			values[string(kv.Key)] = string(kv.Value)
+		}
+	}
+	assert.Equal(t, map[string]string{"blob": large, "small": "tiny"}, values)
+
+	var buf bytes.Buffer
+	found, err := s.StreamValue(ctx, "blob", &buf)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, large, buf.String())
+	found, err = StreamValue(ctx, base, "missing", &buf)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// 覆盖后旧段被回收，prevKv 是完整的旧 value
+	_, prev, err := s.PutWithLease(ctx, "blob", "replaced", 0)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(prev.Value))
+	assert.Equal(t, 0, segmentCount(t, base))
+
+	// 与 manifest 前缀相同的小 value 也分段存储，读出的仍是原值
+	forged := string(manifestMagic) + "{}"
+	_, _, err = s.PutWithLease(ctx, "forged", forged, 0)
+	require.NoError(t, err)
+	v, _ = s.Lookup("forged")
+	assert.Equal(t, forged, v)
+
+	_, prevKvs, _, err := s.DeleteRange(ctx, "forged", "")
+	require.NoError(t, err)
+	require.Len(t, prevKvs, 1)
+	assert.Equal(t, forged, string(prevKvs[0].Value))
+	assert.Equal(t, 0, segmentCount(t, base))
+
+	_, _, err = s.PutWithLease(ctx, "huge", strings.Repeat("x", 65), 0)
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+	assert.Equal(t, 0, segmentCount(t, base))
+}
+
+func TestStoreTxn(t *testing.T) {
+	ctx := context.Background()
+	s, base := newTestStore()
+	large := strings.Repeat("abcdefgh", 4)
+
+	put := []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("blob"), Value: []byte(large)}}
+	get := []kvstore.Op{{Type: kvstore.OpRange, Key: []byte("blob")}}
+	missing := []kvstore.Compare{{
+		Target: kvstore.CompareVersion,
+		Result: kvstore.CompareEqual,
+		Key:    []byte("blob"),
+	}}
+
+	resp, err := s.Txn(ctx, missing, put, get)
+	require.NoError(t, err)
+	assert.True(t, resp.Succeeded)
+	assert.Equal(t, 4, segmentCount(t, base))
+	assert.Equal(t, []byte(large), put[0].Value, "caller's ops must not be modified")
+
+	// 比较失败，then 分支预写的段被回收，else 分支读出完整的 value
+	resp, err = s.Txn(ctx, missing, put, get)
+	require.NoError(t, err)
+	assert.False(t, resp.Succeeded)
+	assert.Equal(t, 4, segmentCount(t, base))
+	require.Len(t, resp.Responses, 1)
+	assert.Equal(t, large, string(resp.Responses[0].RangeResp.Kvs[0].Value))
+}
+
+func TestStoreValueSizeOverrides(t *testing.T) {
+	ctx := context.Background()
+	base := memory.NewMemoryEtcd()
+	s := Wrap(base, config.ChunkingConfig{
+		ChunkSize:    8,
+		MaxValueSize: 16,
+		MaxValueSizeOverrides: []config.ValueSizeOverride{
+			{Prefix: "blobs/", MaxValueSize: 64},
+			{Prefix: "blobs/big/", MaxValueSize: 128},
+		},
+	})
+
+	_, _, err := s.PutWithLease(ctx, "small", strings.Repeat("x", 17), 0)
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+	var tooLarge *ValueTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, ValueTooLargeError{Key: "small", Size: 17, Limit: 16}, *tooLarge)
+
+	// 覆盖前缀下的 value 可以超过默认上限，最长匹配的前缀生效
+	_, _, err = s.PutWithLease(ctx, "blobs/a", strings.Repeat("x", 64), 0)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "blobs/b", strings.Repeat("x", 65), 0)
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, "blobs/", tooLarge.Prefix)
+	_, _, err = s.PutWithLease(ctx, "blobs/big/a", strings.Repeat("x", 128), 0)
+	require.NoError(t, err)
+	segments := segmentCount(t, base)
+
+	// 未执行的分支超过上限也拒绝整个事务，不写入任何段
+	then := []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("blobs/c"), Value: []byte(strings.Repeat("x", 32))}}
+	els := []kvstore.Op{{Type: kvstore.OpPut, Key: []byte("other"), Value: []byte(strings.Repeat("x", 32))}}
+	_, err = s.Txn(ctx, nil, then, els)
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+	assert.Equal(t, segments, segmentCount(t, base))
+
+	assert.Equal(t, []kvstore.ValueSizeStats{
+		{Prefix: "blobs/big/", Limit: 128},
+		{Prefix: "blobs/", Limit: 64, Rejected: 1},
+		{Limit: 16, Rejected: 2},
+	}, s.ValueSizeStats())
+}
+
+func TestStoreWatch(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore()
+	large := strings.Repeat("w", 20)
+
+	events, err := s.Watch(ctx, "blob", "", 0, 1)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "blob", large, 0)
+	require.NoError(t, err)
+
+	select {
+	case ev := <-events:
+		assert.Equal(t, large, string(ev.Kv.Value))
+	case <-time.After(time.Second):
+		t.Fatal("no watch event")
+	}
+	require.NoError(t, s.CancelWatch(1))
+}
+
+func TestStoreWatchValueFilter(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore()
+
+	// 过滤作用于拼接后的 value，而不是底层的 manifest
+	events, err := s.WatchWithOptions("blob", "\x00", 0, 1, &kvstore.WatchOptions{
+		ValueFilter: func(value []byte) bool { return bytes.HasPrefix(value, []byte("keep")) },
+	})
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "blob/1", strings.Repeat("d", 20), 0)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "blob/2", "keep"+strings.Repeat("k", 20), 0)
+	require.NoError(t, err)
+
+	select {
+	case ev := <-events:
+		assert.Equal(t, "blob/2", string(ev.Kv.Key))
+	case <-time.After(time.Second):
+		t.Fatal("no watch event")
+	}
+	require.NoError(t, s.CancelWatch(1))
+}
@@ -0,0 +1,538 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// reclaimTimeout 回收旧段的超时，回收与请求的 ctx 无关，客户端断开后仍会完成
+const reclaimTimeout = 10 * time.Second
+
+// Store 把大 value 分段写入底层存储，读取和 watch 时透明地拼接
+//
+// 段先于 manifest 写入，manifest 写入成功前读者看不到新 value；写入失败时已写的段
+// 被回收。段与 value 绑定同一个 lease，lease 过期时一起删除
+type Store struct {
+	kvstore.Store
+
+	chunkSize int
+	limits    []*valueLimit // 覆盖前缀按长度降序排列，最后是默认上限
+
+	mu      sync.Mutex
+	watches map[int64]chan struct{} // watchID -> 取消时关闭，结束转发 goroutine
+}
+
+// valueLimit 一个 value 大小上限，以及它拒绝的写入数
+type valueLimit struct {
+	prefix   string
+	max      int64
+	rejected atomic.Uint64
+}
+
+// Wrap 返回分段存储大 value 的存储
+func Wrap(store kvstore.Store, cfg config.ChunkingConfig) *Store {
+	limits := make([]*valueLimit, 0, len(cfg.MaxValueSizeOverrides)+1)
+	for _, o := range cfg.MaxValueSizeOverrides {
+		limits = append(limits, &valueLimit{prefix: o.Prefix, max: o.MaxValueSize})
+	}
+	sort.SliceStable(limits, func(i, j int) bool { return len(limits[i].prefix) > len(limits[j].prefix) })
+	limits = append(limits, &valueLimit{max: cfg.MaxValueSize})
+	return &Store{
+		Store:     store,
+		chunkSize: cfg.ChunkSize,
+		limits:    limits,
+		watches:   make(map[int64]chan struct{}),
+	}
+}
+
+// Unwrap 实现 kvstore.Wrapper，供调用方查找底层存储的其他能力
+func (s *Store) Unwrap() kvstore.Store {
+	return s.Store
+}
+
+// checkValueSize 在提案之前检查 value 是否超过 key 所在前缀的上限（最长匹配的覆盖前缀，
+// 否则为 max_value_size），分段存储的 value 按完整大小计算
+func (s *Store) checkValueSize(key string, size int) error {
+	for _, l := range s.limits {
+		if !strings.HasPrefix(key, l.prefix) {
+			continue
+		}
+		if int64(size) <= l.max {
+			return nil
+		}
+		l.rejected.Add(1)
+		return &ValueTooLargeError{Key: key, Size: int64(size), Limit: l.max, Prefix: l.prefix}
+	}
+	return nil
+}
+
+// checkOps 检查事务两个分支中所有 PUT 的 value 大小
+func (s *Store) checkOps(branches ...[]kvstore.Op) error {
+	for _, ops := range branches {
+		for _, op := range ops {
+			if op.Type != kvstore.OpPut {
+				continue
+			}
+			if err := s.checkValueSize(string(op.Key), len(op.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValueSizeStats 返回每个 value 大小上限及其拒绝的写入数
+func (s *Store) ValueSizeStats() []kvstore.ValueSizeStats {
+	stats := make([]kvstore.ValueSizeStats, len(s.limits))
+	for i, l := range s.limits {
+		stats[i] = kvstore.ValueSizeStats{Prefix: l.prefix, Limit: l.max, Rejected: l.rejected.Load()}
+	}
+	return stats
+}
+
+// needsChunking 判断 value 是否需要分段存储
+func (s *Store) needsChunking(value []byte) bool {
+	return len(value) > s.chunkSize || IsManifest(value)
+}
+
+// writeSegments 逐段写入 value，返回指向这些段的 manifest
+func (s *Store) writeSegments(ctx context.Context, value []byte, leaseID int64) (*Manifest, error) {
+	m := &Manifest{
+		ID:     newID(),
+		Size:   int64(len(value)),
+		Chunks: (len(value) + s.chunkSize - 1) / s.chunkSize,
+	}
+	for i := 0; i < m.Chunks; i++ {
+		end := (i + 1) * s.chunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+		if _, _, err := s.Store.PutWithLease(ctx, m.segmentKey(i), string(value[i*s.chunkSize:end]), leaseID); err != nil {
+			s.reclaim(m)
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// reclaim 删除 manifest 的所有段
+func (s *Store) reclaim(m *Manifest) {
+	ctx, cancel := context.WithTimeout(context.Background(), reclaimTimeout)
+	defer cancel()
+	start, end := m.segmentRange()
+	if _, _, _, err := s.Store.DeleteRange(ctx, start, end); err != nil {
+		log.Warn("Failed to reclaim value segments",
+			zap.String("id", m.ID),
+			zap.Error(err),
+			zap.String("component", "chunk"))
+	}
+}
+
+// release 把被覆盖或删除的 value 拼接后返回给调用方，并回收它们的段
+func (s *Store) release(ctx context.Context, kvs []*kvstore.KeyValue) {
+	for i, kv := range kvs {
+		if kv == nil || !IsManifest(kv.Value) {
+			continue
+		}
+		m, err := parseManifest(kv.Value)
+		if err != nil {
+			continue
+		}
+		if kvs[i], err = s.resolve(ctx, kv); err != nil {
+			log.Warn("Failed to read replaced chunked value",
+				zap.String("key", string(kv.Key)),
+				zap.Error(err),
+				zap.String("component", "chunk"))
+			c := *kv
+			c.Value = nil
+			kvs[i] = &c
+		}
+		s.reclaim(m)
+	}
+}
+
+// resolve 返回 value 已拼接完整的 kv 副本，不修改底层存储持有的 kv
+func (s *Store) resolve(ctx context.Context, kv *kvstore.KeyValue) (*kvstore.KeyValue, error) {
+	if kv == nil || !IsManifest(kv.Value) {
+		return kv, nil
+	}
+	m, err := parseManifest(kv.Value)
+	if err != nil {
+		return nil, err
+	}
+	start, end := m.segmentRange()
+	resp, err := s.Store.Range(ctx, start, end, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) != m.Chunks {
+		return nil, fmt.Errorf("%w: key %q has %d of %d segments", ErrCorrupted, kv.Key, len(resp.Kvs), m.Chunks)
+	}
+	value := make([]byte, 0, m.Size)
+	for _, seg := range resp.Kvs {
+		value = append(value, seg.Value...)
+	}
+	if int64(len(value)) != m.Size {
+		return nil, fmt.Errorf("%w: key %q has %d of %d bytes", ErrCorrupted, kv.Key, len(value), m.Size)
+	}
+	c := *kv
+	c.Value = value
+	return &c, nil
+}
+
+// resolveAll 原地替换 kvs 中的 manifest
+func (s *Store) resolveAll(ctx context.Context, kvs []*kvstore.KeyValue) error {
+	for i := range kvs {
+		kv, err := s.resolve(ctx, kvs[i])
+		if err != nil {
+			return err
+		}
+		kvs[i] = kv
+	}
+	return nil
+}
+
+// Lookup 返回拼接后的 value
+func (s *Store) Lookup(key string) (string, bool) {
+	v, ok := s.Store.Lookup(key)
+	if !ok || !IsManifest([]byte(v)) {
+		return v, ok
+	}
+	kv, err := s.resolve(context.Background(), &kvstore.KeyValue{Key: []byte(key), Value: []byte(v)})
+	if err != nil {
+		log.Error("Failed to read chunked value",
+			zap.String("key", key),
+			zap.Error(err),
+			zap.String("component", "chunk"))
+		return "", false
+	}
+	return string(kv.Value), true
+}
+
+// Range 拼接结果中的分段 value
+// 读取期间 value 被覆盖时旧段可能已被回收，此时重新读取一次
+func (s *Store) Range(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := s.Store.Range(ctx, key, rangeEnd, limit, revision)
+		if err != nil {
+			return nil, err
+		}
+		err = s.resolveAll(ctx, resp.Kvs)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt > 0 || !errors.Is(err, ErrCorrupted) {
+			return nil, err
+		}
+	}
+}
+
+// RangeFunc 流式读取范围并逐个拼接分段 value
+// 与 Range 一样，某个 key 的旧段在读取期间被回收时重新读取该 key 一次
+func (s *Store) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return s.rangeFunc(ctx, key, rangeEnd, revision, false, fn)
+}
+
+// ReverseRangeFunc 与 RangeFunc 相同，但按 key 降序读取
+func (s *Store) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return s.rangeFunc(ctx, key, rangeEnd, revision, true, fn)
+}
+
+func (s *Store) rangeFunc(ctx context.Context, key, rangeEnd string, revision int64, desc bool, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	scan := kvstore.RangeFunc
+	if desc {
+		scan = kvstore.ReverseRangeFunc
+	}
+	var resolveErr error
+	rev, err := scan(ctx, s.Store, key, rangeEnd, revision, func(kv *kvstore.KeyValue) bool {
+		resolved, err := s.resolve(ctx, kv)
+		if errors.Is(err, ErrCorrupted) {
+			var resp *kvstore.RangeResponse
+			if resp, err = s.Range(ctx, string(kv.Key), "", 0, revision); err == nil {
+				if len(resp.Kvs) == 0 {
+					return true // 已被删除
+				}
+				resolved = resp.Kvs[0]
+			}
+		}
+		if err != nil {
+			resolveErr = err
+			return false
+		}
+		return fn(resolved)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rev, resolveErr
+}
+
+// StreamValue 逐段把 key 的 value 写入 w，不在内存中拼接完整的 value
+func (s *Store) StreamValue(ctx context.Context, key string, w io.Writer) (bool, error) {
+	resp, err := s.Store.Range(ctx, key, "", 0, 0)
+	if err != nil {
+		return false, err
+	}
+	if len(resp.Kvs) == 0 {
+		return false, nil
+	}
+	value := resp.Kvs[0].Value
+	if !IsManifest(value) {
+		_, err = w.Write(value)
+		return true, err
+	}
+	m, err := parseManifest(value)
+	if err != nil {
+		return true, err
+	}
+	// 分段在 manifest 之前写入，读到 manifest 后本地一定已经有全部分段
+	segCtx := kvstore.WithSerializable(ctx)
+	var written int64
+	for i := 0; i < m.Chunks; i++ {
+		seg, err := s.Store.Range(segCtx, m.segmentKey(i), "", 0, 0)
+		if err != nil {
+			return true, err
+		}
+		if len(seg.Kvs) == 0 {
+			return true, fmt.Errorf("%w: key %q is missing segment %d", ErrCorrupted, key, i)
+		}
+		n, err := w.Write(seg.Kvs[0].Value)
+		written += int64(n)
+		if err != nil {
+			return true, err
+		}
+	}
+	if written != m.Size {
+		return true, fmt.Errorf("%w: key %q has %d of %d bytes", ErrCorrupted, key, written, m.Size)
+	}
+	return true, nil
+}
+
+func (s *Store) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	if err := s.checkValueSize(key, len(value)); err != nil {
+		return 0, nil, err
+	}
+	var m *Manifest
+	if s.needsChunking([]byte(value)) {
+		var err error
+		if m, err = s.writeSegments(ctx, []byte(value), leaseID); err != nil {
+			return 0, nil, err
+		}
+		value = string(m.encode())
+	}
+	rev, prevKv, err := s.Store.PutWithLease(ctx, key, value, leaseID)
+	if err != nil {
+		if m != nil {
+			s.reclaim(m)
+		}
+		return rev, prevKv, err
+	}
+	prev := []*kvstore.KeyValue{prevKv}
+	s.release(ctx, prev)
+	return rev, prev[0], nil
+}
+
+func (s *Store) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	deleted, prevKvs, rev, err := s.Store.DeleteRange(ctx, key, rangeEnd)
+	if err == nil {
+		s.release(ctx, prevKvs)
+	}
+	return deleted, prevKvs, rev, err
+}
+
+// prepareOps 为 ops 中需要分段的 PUT 预先写入段，返回替换为 manifest 后的 ops
+func (s *Store) prepareOps(ctx context.Context, ops []kvstore.Op) ([]kvstore.Op, []*Manifest, error) {
+	var prepared []kvstore.Op
+	var manifests []*Manifest
+	for i, op := range ops {
+		if op.Type != kvstore.OpPut || !s.needsChunking(op.Value) {
+			continue
+		}
+		m, err := s.writeSegments(ctx, op.Value, op.LeaseID)
+		if err != nil {
+			for _, m := range manifests {
+				s.reclaim(m)
+			}
+			return nil, nil, err
+		}
+		manifests = append(manifests, m)
+		if prepared == nil {
+			prepared = append([]kvstore.Op(nil), ops...)
+		}
+		prepared[i].Value = m.encode()
+	}
+	if prepared == nil {
+		return ops, nil, nil
+	}
+	return prepared, manifests, nil
+}
+
+// Txn 在提交事务之前写入两个分支中大 value 的段，未执行分支的段在提交后回收
+// 任一分支的 value 超过上限时整个事务被拒绝，不写入任何段
+func (s *Store) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+	if err := s.checkOps(thenOps, elseOps); err != nil {
+		return nil, err
+	}
+	thenOps, thenManifests, err := s.prepareOps(ctx, thenOps)
+	if err != nil {
+		return nil, err
+	}
+	elseOps, elseManifests, err := s.prepareOps(ctx, elseOps)
+	if err != nil {
+		for _, m := range thenManifests {
+			s.reclaim(m)
+		}
+		return nil, err
+	}
+
+	resp, err := s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	unused := elseManifests
+	switch {
+	case err != nil:
+		unused = append(thenManifests, elseManifests...)
+	case !resp.Succeeded:
+		unused = thenManifests
+	}
+	for _, m := range unused {
+		s.reclaim(m)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range resp.Responses {
+		switch {
+		case r.RangeResp != nil:
+			if err := s.resolveAll(ctx, r.RangeResp.Kvs); err != nil {
+				return nil, err
+			}
+		case r.PutResp != nil:
+			prev := []*kvstore.KeyValue{r.PutResp.PrevKv}
+			s.release(ctx, prev)
+			r.PutResp.PrevKv = prev[0]
+		case r.DeleteResp != nil:
+			s.release(ctx, r.DeleteResp.PrevKvs)
+		}
+	}
+	return resp, nil
+}
+
+func (s *Store) Watch(ctx context.Context, key, rangeEnd string, startRevision int64, watchID int64) (<-chan kvstore.WatchEvent, error) {
+	ch, err := s.Store.Watch(ctx, key, rangeEnd, startRevision, watchID)
+	if err != nil {
+		return nil, err
+	}
+	return s.forward(watchID, ch, nil), nil
+}
+
+// WatchWithOptions keeps watch options working through the wrapper
+// 底层存储看到的是 manifest，value 过滤放行 manifest，拼接后再过滤一次
+func (s *Store) WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error) {
+	type watchWithOptions interface {
+		WatchWithOptions(key, rangeEnd string, startRevision int64, watchID int64, opts *kvstore.WatchOptions) (<-chan kvstore.WatchEvent, error)
+	}
+	var valueFilter func([]byte) bool
+	if opts != nil && opts.ValueFilter != nil {
+		valueFilter = opts.ValueFilter
+		o := *opts
+		o.ValueFilter = func(value []byte) bool { return IsManifest(value) || valueFilter(value) }
+		opts = &o
+	}
+
+	var ch <-chan kvstore.WatchEvent
+	var err error
+	if wwo, ok := kvstore.As[watchWithOptions](s.Store); ok {
+		ch, err = wwo.WatchWithOptions(key, rangeEnd, startRevision, watchID, opts)
+	} else {
+		ch, err = s.Store.Watch(context.Background(), key, rangeEnd, startRevision, watchID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.forward(watchID, ch, valueFilter), nil
+}
+
+// forward 拼接事件中的分段 value 后转发，丢弃不满足 valueFilter 的事件
+// 删除事件的 PrevKv 对应的段通常已被回收，此时 PrevKv 不带 value
+func (s *Store) forward(watchID int64, src <-chan kvstore.WatchEvent, valueFilter func([]byte) bool) <-chan kvstore.WatchEvent {
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.watches[watchID] = done
+	s.mu.Unlock()
+
+	out := make(chan kvstore.WatchEvent, cap(src))
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			if s.watches[watchID] == done {
+				delete(s.watches, watchID)
+			}
+			s.mu.Unlock()
+			close(out)
+		}()
+		for ev := range src {
+			ev.Kv = s.resolveEvent(ev.Kv)
+			ev.PrevKv = s.resolveEvent(ev.PrevKv)
+			if !kvstore.MatchValue(ev, valueFilter) {
+				continue
+			}
+			select {
+			case out <- ev:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (s *Store) resolveEvent(kv *kvstore.KeyValue) *kvstore.KeyValue {
+	resolved, err := s.resolve(context.Background(), kv)
+	if err != nil {
+		log.Debug("Chunked value in watch event is no longer available",
+			zap.String("key", string(kv.Key)),
+			zap.Error(err),
+			zap.String("component", "chunk"))
+		c := *kv
+		c.Value = nil
+		return &c
+	}
+	return resolved
+}
+
+func (s *Store) CancelWatch(watchID int64) error {
+	s.mu.Lock()
+	if done, ok := s.watches[watchID]; ok {
+		close(done)
+		delete(s.watches, watchID)
+	}
+	s.mu.Unlock()
+	return s.Store.CancelWatch(watchID)
+}
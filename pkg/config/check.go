@@ -0,0 +1,148 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity of a configuration diagnostic
+type Severity string
+
+const (
+	// SeverityError the member refuses to start or a feature cannot work
+	SeverityError Severity = "error"
+	// SeverityWarning the member starts, but the settings work against each other
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is one finding about a configuration, as printed by
+// `metastore config validate`
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Field    string   `json:"field,omitempty"` // Path below server:, e.g. raft.election_tick
+	Message  string   `json:"message"`
+}
+
+// minElectionHeartbeatRatio is the smallest election_tick / heartbeat_tick ratio
+// that tolerates a few delayed heartbeats, raft recommends 10
+const minElectionHeartbeatRatio = 5
+
+// Check runs Validate and the cross-field sanity checks, and returns every
+// finding. Validate stops at the first error, so at most one error comes from it
+func (c *Config) Check() []Diagnostic {
+	var diags []Diagnostic
+	if err := c.Validate(); err != nil {
+		diags = append(diags, Diagnostic{Severity: SeverityError, Field: errorField(err.Error()), Message: err.Error()})
+	}
+
+	add := func(severity Severity, field, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{Severity: severity, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	raft := c.Server.Raft
+	electionTimeout := time.Duration(raft.ElectionTick) * raft.TickInterval
+
+	// Election vs heartbeat: followers start an election after election_tick
+	// ticks without a heartbeat
+	if raft.HeartbeatTick > 0 && raft.ElectionTick > raft.HeartbeatTick &&
+		raft.ElectionTick < minElectionHeartbeatRatio*raft.HeartbeatTick {
+		add(SeverityWarning, "raft.election_tick",
+			"raft.election_tick (%d) is less than %dx raft.heartbeat_tick (%d), a few delayed heartbeats trigger an election (10x recommended)",
+			raft.ElectionTick, minElectionHeartbeatRatio, raft.HeartbeatTick)
+	}
+
+	// Clock drift: the leader serves lease reads for election timeout minus
+	// clock_drift after each heartbeat round
+	if raft.LeaseRead.Enable && electionTimeout > 0 &&
+		raft.LeaseRead.ClockDrift < electionTimeout && raft.LeaseRead.ClockDrift*2 > electionTimeout {
+		add(SeverityWarning, "raft.lease_read.clock_drift",
+			"raft.lease_read.clock_drift (%s) is more than half the election timeout (%s), most lease reads fall back to ReadIndex",
+			raft.LeaseRead.ClockDrift, electionTimeout)
+	}
+	if raft.LeaseRead.Enable && c.Server.HLC.MaxOffset > 0 && c.Server.HLC.MaxOffset < raft.LeaseRead.ClockDrift {
+		add(SeverityWarning, "hlc.max_offset",
+			"hlc.max_offset (%s) is smaller than raft.lease_read.clock_drift (%s), peers within the tolerated drift are reported as skewed",
+			c.Server.HLC.MaxOffset, raft.LeaseRead.ClockDrift)
+	}
+
+	// gRPC vs raft message sizes
+	grpc, limits := c.Server.GRPC, c.Server.Limits
+	if grpc.MaxRecvMsgSize > 0 && int64(grpc.MaxRecvMsgSize) < limits.MaxRequestSize {
+		add(SeverityWarning, "grpc.max_recv_msg_size",
+			"grpc.max_recv_msg_size (%d) is smaller than limits.max_request_size (%d), gRPC rejects requests the limit allows",
+			grpc.MaxRecvMsgSize, limits.MaxRequestSize)
+	}
+	if grpc.MaxSendMsgSize > 0 && int64(grpc.MaxSendMsgSize) < limits.MaxRequestSize {
+		add(SeverityWarning, "grpc.max_send_msg_size",
+			"grpc.max_send_msg_size (%d) is smaller than limits.max_request_size (%d), values that can be written cannot be read back over gRPC",
+			grpc.MaxSendMsgSize, limits.MaxRequestSize)
+	}
+	if grpc.MaxSendMsgSize > 0 && grpc.MaxSendMsgSize < c.Server.Maintenance.SnapshotChunkSize {
+		add(SeverityError, "maintenance.snapshot_chunk_size",
+			"maintenance.snapshot_chunk_size (%d) is larger than grpc.max_send_msg_size (%d), Maintenance.Snapshot cannot send its chunks",
+			c.Server.Maintenance.SnapshotChunkSize, grpc.MaxSendMsgSize)
+	}
+	return diags
+}
+
+// CheckFile parses the config file at path like LoadConfig, without environment
+// overrides, and returns the diagnostics of Check. Fields the config does not
+// know are reported as warnings. The error is only set when the file cannot be read
+func CheckFile(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return []Diagnostic{{Severity: SeverityError, Message: fmt.Sprintf("failed to parse config: %v", err)}}, nil
+	}
+	var diags []Diagnostic
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var strict Config
+	var typeErr *yaml.TypeError
+	if err := dec.Decode(&strict); errors.As(err, &typeErr) {
+		for _, msg := range typeErr.Errors {
+			diags = append(diags, Diagnostic{Severity: SeverityWarning, Message: msg})
+		}
+	}
+
+	cfg.SetDefaults()
+	return append(diags, cfg.Check()...), nil
+}
+
+// errorField returns the config path a Validate error starts with, if any
+func errorField(msg string) string {
+	field, _, _ := strings.Cut(msg, " ")
+	field = strings.TrimSuffix(field, ":")
+	if !strings.ContainsAny(field, "._") {
+		return ""
+	}
+	for _, r := range field {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' && r != '.' {
+			return ""
+		}
+	}
+	return field
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestCheckDefaults tests that the defaults produce no diagnostics
+func TestCheckDefaults(t *testing.T) {
+	if diags := DefaultConfig(1, 1, ":2379").Check(); len(diags) != 0 {
+		t.Errorf("Expected no diagnostics for the defaults, got %+v", diags)
+	}
+}
+
+// TestCheckCrossField tests the sanity checks across fields
+func TestCheckCrossField(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(c *Config)
+		field    string
+		severity Severity
+	}{
+		{"ElectionHeartbeat", func(c *Config) { c.Server.Raft.HeartbeatTick = 3 }, "raft.election_tick", SeverityWarning},
+		{"ClockDrift", func(c *Config) {
+			c.Server.Raft.LeaseRead.ClockDrift = 600 * time.Millisecond
+			c.Server.HLC.MaxOffset = time.Second
+		}, "raft.lease_read.clock_drift", SeverityWarning},
+		{"HLCOffset", func(c *Config) { c.Server.HLC.MaxOffset = 50 * time.Millisecond }, "hlc.max_offset", SeverityWarning},
+		{"GRPCRecv", func(c *Config) { c.Server.GRPC.MaxRecvMsgSize = 1 << 20 }, "grpc.max_recv_msg_size", SeverityWarning},
+		{"SnapshotChunk", func(c *Config) { c.Server.Maintenance.SnapshotChunkSize = 8 << 20 }, "maintenance.snapshot_chunk_size", SeverityError},
+		{"Validate", func(c *Config) { c.Server.Raft.ElectionTick = 1 }, "raft.election_tick", SeverityError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig(1, 1, ":2379")
+			tt.modify(cfg)
+			diags := cfg.Check()
+			if len(diags) != 1 {
+				t.Fatalf("Expected 1 diagnostic, got %+v", diags)
+			}
+			if diags[0].Field != tt.field || diags[0].Severity != tt.severity {
+				t.Errorf("Expected %s on %s, got %+v", tt.severity, tt.field, diags[0])
+			}
+		})
+	}
+}
+
+// TestCheckFile tests that unknown fields are warnings and parse errors are reported
+func TestCheckFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	diags, err := CheckFile(write("unknown.yaml", "server:\n  cluster_id: 1\n  member_id: 1\n  etcd:\n    address: \":2379\"\n  raft:\n    election_tik: 20\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning {
+		t.Errorf("Expected one warning for the unknown field, got %+v", diags)
+	}
+
+	diags, err = CheckFile(write("invalid.yaml", "server:\n  raft: [\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Severity != SeverityError {
+		t.Errorf("Expected one parse error, got %+v", diags)
+	}
+
+	if _, err := CheckFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestDefaultsRoundTrip tests that the printed defaults load back unchanged
+func TestDefaultsRoundTrip(t *testing.T) {
+	out, err := yaml.Marshal(DefaultConfig(1, 1, ":2379"))
+	if err != nil {
+		t.Fatalf("Failed to marshal defaults: %v", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(out, &cfg); err != nil {
+		t.Fatalf("Failed to parse printed defaults: %v", err)
+	}
+	cfg.SetDefaults()
+	if diags := cfg.Check(); len(diags) != 0 {
+		t.Errorf("Expected no diagnostics for the printed defaults, got %+v", diags)
+	}
+	again, err := yaml.Marshal(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(out) {
+		t.Error("Printed defaults changed after loading them back")
+	}
+}
@@ -15,17 +15,25 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"os"
-	"strconv"
+	"path/filepath"
+	"runtime"
 	"time"
 
+	"metaStore/pkg/featuregate"
+
 	"gopkg.in/yaml.v3"
 )
 
 // Config unified configuration structure
 type Config struct {
 	Server ServerConfig `yaml:"server"`
+
+	// Overrides lists the fields set from the environment or flags, logged at startup
+	Overrides []Override `yaml:"-"`
 }
 
 // ServerConfig server configuration
@@ -38,11 +46,19 @@ type ServerConfig struct {
 	Etcd  EtcdConfig  `yaml:"etcd"`  // etcd gRPC protocol configuration
 	HTTP  HTTPConfig  `yaml:"http"`  // HTTP REST API configuration
 	MySQL MySQLConfig `yaml:"mysql"` // MySQL protocol configuration
+	TLS   TLSConfig   `yaml:"tls"`   // Server certificate for client connections
+
+	// ReadRevision pins the etcd and MySQL frontends of this member at a
+	// revision, e.g. to reproduce an incident on a spare member: reads without
+	// an explicit revision are served at it and client writes are rejected,
+	// while raft keeps replicating. 0 serves the latest revision
+	ReadRevision int64 `yaml:"read_revision"`
 
 	// Sub-configurations
 	GRPC        GRPCConfig        `yaml:"grpc"`
 	Limits      LimitsConfig      `yaml:"limits"`
 	Lease       LeaseConfig       `yaml:"lease"`
+	Watch       WatchConfig       `yaml:"watch"`
 	Auth        AuthConfig        `yaml:"auth"`
 	Maintenance MaintenanceConfig `yaml:"maintenance"`
 	Reliability ReliabilityConfig `yaml:"reliability"`
@@ -51,12 +67,66 @@ type ServerConfig struct {
 	Performance PerformanceConfig `yaml:"performance"`
 	Raft        RaftConfig        `yaml:"raft"`
 	RocksDB     RocksDBConfig     `yaml:"rocksdb"`
-	MVCC        MVCCConfig        `yaml:"mvcc"` // MVCC configuration
+	MVCC        MVCCConfig        `yaml:"mvcc"`       // MVCC configuration
+	Mirror      MirrorConfig      `yaml:"mirror"`     // Cross-datacenter asynchronous replication
+	CDC         CDCConfig         `yaml:"cdc"`        // Change data capture to Kafka/NATS
+	Encryption  EncryptionConfig  `yaml:"encryption"` // Encryption of values at rest
+	Chunking    ChunkingConfig    `yaml:"chunking"`   // Storage of large values as segments
+	Admission   AdmissionConfig   `yaml:"admission"`  // Hooks that inspect writes before they are proposed
+	Usage       UsageConfig       `yaml:"usage"`      // Per-prefix usage accounting
+	HLC         HLCConfig         `yaml:"hlc"`        // Hybrid logical clock timestamps of committed operations
+
+	// DeleteProtection guards critical prefixes against accidental deletes
+	DeleteProtection DeleteProtectionConfig `yaml:"delete_protection"`
+
+	// Storage places the raft log, snapshots and state machine in separate directories
+	Storage StorageConfig `yaml:"storage"`
+
+	// FeatureGates enables or disables experimental features by name, see pkg/featuregate
+	FeatureGates map[string]bool `yaml:"feature_gates"`
+}
+
+// StorageConfig data directory layout
+// Putting the raft log on a fast disk (NVMe) and the state machine on a larger one
+// keeps commit latency low. When a directory changes, its files are moved on startup
+type StorageConfig struct {
+	DataDir string `yaml:"data_dir"` // State machine directory, default data/<engine>/<member_id>
+	WALDir  string `yaml:"wal_dir"`  // Raft log directory: the RocksDB WAL (rocksdb engine) or the raft WAL (memory engine), default inside data_dir
+	SnapDir string `yaml:"snap_dir"` // Raft snapshot directory, default <data_dir>/snap
+}
+
+// Dirs returns the data, raft log and snapshot directories, defaultData is used when data_dir is unset
+// With the rocksdb engine the raft log lives in the RocksDB WAL, which defaults to the data directory itself
+func (s StorageConfig) Dirs(engine, defaultData string) (data, wal, snap string) {
+	data = defaultData
+	if s.DataDir != "" {
+		data = s.DataDir
+	}
+	wal = filepath.Join(data, "wal")
+	if engine == "rocksdb" {
+		wal = data
+	}
+	if s.WALDir != "" {
+		wal = s.WALDir
+	}
+	snap = filepath.Join(data, "snap")
+	if s.SnapDir != "" {
+		snap = s.SnapDir
+	}
+	return filepath.Clean(data), filepath.Clean(wal), filepath.Clean(snap)
 }
 
 // EtcdConfig etcd gRPC protocol configuration
 type EtcdConfig struct {
 	Address string `yaml:"address"` // Listen address for etcd gRPC, default ":2379"
+
+	// ListenClientURLs are the URLs the gRPC server listens on, e.g. http://0.0.0.0:2379.
+	// They replace address when set
+	ListenClientURLs []string `yaml:"listen_client_urls"`
+
+	// AdvertiseClientURLs are the URLs this member publishes in MemberList for clients.
+	// Empty derives one URL from the listen address, using the hostname when no host is given
+	AdvertiseClientURLs []string `yaml:"advertise_client_urls"`
 }
 
 // HTTPConfig HTTP REST API configuration
@@ -69,6 +139,71 @@ type MySQLConfig struct {
 	Address  string `yaml:"address"`  // Listen address for MySQL protocol, default ":3306"
 	Username string `yaml:"username"` // Authentication username, default "root"
 	Password string `yaml:"password"` // Authentication password, default ""
+
+	// AuthPlugin is announced in the initial handshake: "caching_sha2_password"
+	// (default, what MySQL 8 clients use) or "mysql_native_password". Clients may
+	// answer with either plugin
+	AuthPlugin string `yaml:"auth_plugin"`
+
+	// RequireSecureTransport rejects connections that did not switch to TLS
+	RequireSecureTransport bool `yaml:"require_secure_transport"`
+
+	// LockTTL is how long a transaction holds the row locks of SELECT ... FOR
+	// UPDATE; locks of a transaction that neither commits nor rolls back within
+	// it may be taken over by others. Default 30s
+	LockTTL time.Duration `yaml:"lock_ttl"`
+
+	// LockWaitTimeout is how long a statement waits for a row lock held by
+	// another transaction before failing with error 1205. Default 50s
+	LockWaitTimeout time.Duration `yaml:"lock_wait_timeout"`
+
+	// ChangesTimeout is how long a SELECT on kv_changes waits for a change event
+	// before returning an empty result; sessions change it with SET
+	// metastore_changes_timeout. Default 30s
+	ChangesTimeout time.Duration `yaml:"changes_timeout"`
+}
+
+// MySQL authentication plugins
+const (
+	MySQLNativePassword      = "mysql_native_password"
+	MySQLCachingSha2Password = "caching_sha2_password"
+)
+
+// TLSConfig server certificate for client connections. The MySQL frontend
+// offers TLS with it; without one it uses a self-signed certificate
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"` // PEM certificate chain
+	KeyFile  string `yaml:"key_file"`  // PEM private key of the certificate
+	CAFile   string `yaml:"ca_file"`   // Optional CA bundle; client certificates are verified against it when sent
+}
+
+// Enabled reports whether a certificate is configured
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// Load builds the server side tls.Config, nil when no certificate is configured
+func (t TLSConfig) Load() (*tls.Config, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	conf := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		conf.ClientCAs = x509.NewCertPool()
+		if !conf.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return conf, nil
 }
 
 // GRPCConfig gRPC configuration
@@ -93,44 +228,120 @@ type GRPCConfig struct {
 	EnableRateLimit       bool          `yaml:"enable_rate_limit"`         // Whether to enable rate limiting, default false
 	RateLimitQPS          int           `yaml:"rate_limit_qps"`            // Requests per second limit, default 0 (no limit)
 	RateLimitBurst        int           `yaml:"rate_limit_burst"`          // Burst request token bucket size, default 0 (no limit)
+
+	// Compression of requests and responses, negotiated per RPC by the client
+	Compression []string `yaml:"compression"` // Compressors accepted from clients, "gzip" and/or "zstd", default ["gzip"], ["none"] disables compression
 }
 
 // LimitsConfig resource limits configuration
 type LimitsConfig struct {
 	MaxConnections int   `yaml:"max_connections"`  // Default 1000
-	MaxWatchCount  int   `yaml:"max_watch_count"`  // Default 10000
-	MaxLeaseCount  int   `yaml:"max_lease_count"`  // Default 10000
-	MaxRequestSize int64 `yaml:"max_request_size"` // Default 1.5MB
+	MaxWatchCount  int   `yaml:"max_watch_count"`  // Watches served by one member, default 10000
+	MaxLeaseCount  int   `yaml:"max_lease_count"`  // Leases in the cluster, default 10000
+	MaxRequestSize int64 `yaml:"max_request_size"` // Largest serialized proposal, larger writes fail with ErrRequestTooLarge, default 1.5MB
 	MaxMemoryMB    int64 `yaml:"max_memory_mb"`    // Max memory usage (MB), default 8192 (8GB), 0 means no limit
 	MaxRequests    int64 `yaml:"max_requests"`     // Max concurrent requests, default 5000
+	MaxBatchOps    int   `yaml:"max_batch_ops"`    // Max mutations in one batch write, default 10000
+	MaxRangeKeys   int64 `yaml:"max_range_keys"`   // Max keys returned by one range, more=true beyond it, default 100000
+
+	// Per-connection limits, a connection is identified by the client address (ip:port).
+	// Leases count while they are alive, even after the connection that granted them closed
+	MaxWatchesPerConnection int `yaml:"max_watches_per_connection"` // Default 0 (no limit)
+	MaxLeasesPerConnection  int `yaml:"max_leases_per_connection"`  // Leases granted through one member, default 0 (no limit)
+
+	// Backpressure: writes are rejected with a retry-after hint instead of queueing
+	// until they time out when the propose pipeline is saturated
+	ProposeQueueThreshold float64       `yaml:"propose_queue_threshold"` // Reject writes when the propose queue is this full (0-1], default 0.9
+	MaxPendingProposals   int           `yaml:"max_pending_proposals"`   // Reject writes when this many proposals wait to be applied, default 10000
+	RetryAfter            time.Duration `yaml:"retry_after"`             // Retry hint returned with rejected writes, default 1s
+	RequestTimeout        time.Duration `yaml:"request_timeout"`         // Write timeout when the client sets no deadline, default 30s
+
+	// Propose queue between the storage engine and raft, shared by all priorities
+	ProposeQueueSize     int    `yaml:"propose_queue_size"`     // Queued proposals before the queue is full, default 10000
+	ProposeQueueOverflow string `yaml:"propose_queue_overflow"` // "block" (default) waits for room until the write deadline, "reject" fails with a retry-after hint
+
+	// Apply queue between raft and the state machine; raft stops handing over
+	// commits while it is full, and the writes waiting on them count as pending proposals
+	ApplyQueueSize int `yaml:"apply_queue_size"` // Committed batches waiting to be applied, default 64
+	ApplyWorkers   int `yaml:"apply_workers"`    // Workers applying the writes of a commit in parallel by key (memory engine), default number of CPUs
+}
+
+// Propose queue overflow policies
+const (
+	ProposeOverflowBlock  = "block"
+	ProposeOverflowReject = "reject"
+)
+
+// WatchConfig watch lifecycle configuration
+// Watches end with the gRPC stream that created them. A scavenger cancels the
+// watches left behind: those whose stream has ended, and those that had no
+// consumer sending their events for OrphanTimeout
+type WatchConfig struct {
+	ScavengeInterval time.Duration `yaml:"scavenge_interval"` // Default 1m
+	OrphanTimeout    time.Duration `yaml:"orphan_timeout"`    // Default 5m
 }
 
 // LeaseConfig lease configuration
 type LeaseConfig struct {
 	CheckInterval time.Duration `yaml:"check_interval"` // Default 1s
 	DefaultTTL    time.Duration `yaml:"default_ttl"`    // Default 60s
+
+	// Expired leases are revoked in batches of at most ExpiryBatchSize per
+	// check, each check is delayed by a random amount up to ExpiryJitter so
+	// the revokes of many nodes and leases do not arrive at Raft together
+	ExpiryBatchSize int           `yaml:"expiry_batch_size"` // Default 1000
+	ExpiryJitter    time.Duration `yaml:"expiry_jitter"`     // Default check_interval/5
 }
 
 // AuthConfig authentication configuration
+// Range requests and SQL scans skip keys the user cannot read instead of
+// failing; with FailClosedRanges they fail unless the user's read permissions
+// cover the whole range, like etcd
 type AuthConfig struct {
 	TokenTTL             time.Duration `yaml:"token_ttl"`              // Default 24h
 	TokenCleanupInterval time.Duration `yaml:"token_cleanup_interval"` // Default 5m
 	BcryptCost           int           `yaml:"bcrypt_cost"`            // Default 10
 	EnableAudit          bool          `yaml:"enable_audit"`           // Default false
+	FailClosedRanges     bool          `yaml:"fail_closed_ranges"`     // Default false
 }
 
 // MaintenanceConfig maintenance configuration
+// Snapshots streamed by Maintenance.Snapshot (etcdctl or metastorectl snapshot save)
+// carry a manifest with their SHA-256 digest when SignSnapshots is set or snapshot
+// encryption is enabled, the digest is verified on restore
 type MaintenanceConfig struct {
-	SnapshotChunkSize int `yaml:"snapshot_chunk_size"` // Default 4MB
+	SnapshotChunkSize  int              `yaml:"snapshot_chunk_size"` // Default 4MB
+	SignSnapshots      bool             `yaml:"sign_snapshots"`      // Default false
+	SnapshotEncryption EncryptionConfig `yaml:"snapshot_encryption"` // AES-256-GCM, keys are separate from encryption at rest
 }
 
 // ReliabilityConfig reliability configuration
 type ReliabilityConfig struct {
 	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout"`      // Default 30s
-	DrainTimeout        time.Duration `yaml:"drain_timeout"`         // Default 5s
+	DrainTimeout        time.Duration `yaml:"drain_timeout"`         // Time to move gRPC clients away on shutdown and /admin/drain, default 5s
 	EnableCRC           bool          `yaml:"enable_crc"`            // Default false
 	EnableHealthCheck   bool          `yaml:"enable_health_check"`   // Default true
 	EnablePanicRecovery bool          `yaml:"enable_panic_recovery"` // Default true
+
+	// HealthMaxApplyLag reports a node as lagging in /health, /readyz and the gRPC health
+	// service once its applied index trails the commit index by more than this
+	HealthMaxApplyLag uint64 `yaml:"health_max_apply_lag"` // Default 1000 entries
+
+	// LeaderKey makes the leader publish its identity under this key as a
+	// Kubernetes-style leader election record, renewed every LeaderRenewInterval,
+	// so external controllers can get or watch the current leader. Empty disables
+	// publishing, default ""
+	LeaderKey           string        `yaml:"leader_key"`
+	LeaderRenewInterval time.Duration `yaml:"leader_renew_interval"` // Default 5s
+
+	// EnableFaultInjection exposes the /debug/chaos endpoint on the metrics server
+	// Test-only: never enable in production, default false
+	EnableFaultInjection bool `yaml:"enable_fault_injection"`
+
+	// HistoryFile records every client operation served by the frontends as a
+	// Jepsen-style history (JSON lines) for linearizability checking
+	// Test-only, empty disables recording, default ""
+	HistoryFile string `yaml:"history_file"`
 }
 
 // LogConfig log configuration
@@ -145,6 +356,7 @@ type LogConfig struct {
 type MonitoringConfig struct {
 	EnablePrometheus     bool          `yaml:"enable_prometheus"`      // Default true
 	PrometheusPort       int           `yaml:"prometheus_port"`        // Default 9090
+	ListenMetricsURLs    []string      `yaml:"listen_metrics_urls"`    // URLs the metrics server listens on, replace prometheus_port when set
 	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold"` // Default 100ms
 }
 
@@ -166,14 +378,27 @@ const (
 	// Witness nodes do NOT store data, they only help form quorum for leader election
 	// This enables 2-node HA by adding a lightweight 3rd node for voting
 	NodeRoleWitness NodeRole = "witness"
+
+	// NodeRoleReplica is a read-only replica for analytics traffic
+	// Replicas join as Raft learners: they apply the log but never vote or become leader,
+	// and serve serializable reads only, so heavy scans do not slow down the quorum
+	NodeRoleReplica NodeRole = "replica"
 )
 
 // RaftConfig Raft consensus configuration
 type RaftConfig struct {
 	// Node role configuration (for 2-node HA support)
-	NodeRole NodeRole      `yaml:"node_role"` // Node role: "data" (default) or "witness"
+	NodeRole NodeRole      `yaml:"node_role"` // Node role: "data" (default), "witness" or "replica"
 	Witness  WitnessConfig `yaml:"witness"`   // Witness node specific configuration
 
+	// The transport listens on ListenPeerURLs, by default on the host and port of
+	// this member's URL in the cluster peer list (--cluster). AdvertisePeerURLs
+	// are the URLs other members reach this member on and are published in
+	// MemberList; its peer list URL must be one of them. Set both when the
+	// listen port differs from the advertised one, behind NAT or a container port mapping
+	ListenPeerURLs    []string `yaml:"listen_peer_urls"`
+	AdvertisePeerURLs []string `yaml:"advertise_peer_urls"`
+
 	// Tick configuration (affects Raft processing speed)
 	TickInterval  time.Duration `yaml:"tick_interval"`   // Raft tick interval, default 100ms
 	ElectionTick  int           `yaml:"election_tick"`   // Election timeout tick count, default 10 (= 1s)
@@ -195,6 +420,31 @@ type RaftConfig struct {
 
 	// Lease Read configuration (read performance optimization, reference: etcd/TiKV)
 	LeaseRead LeaseReadConfig `yaml:"lease_read"` // Lease Read configuration
+
+	// Leadership transfer configuration (reduces unavailability on restart and slow disks)
+	LeaderTransfer LeaderTransferConfig `yaml:"leader_transfer"` // Automatic leadership transfer configuration
+
+	// Failure-domain aware leader placement (multi-AZ deployments)
+	Placement PlacementConfig `yaml:"placement"` // Member zone and preferred leader placement
+
+	// Peer transport configuration (reduces WAN bandwidth for geo-distributed clusters)
+	Transport RaftTransportConfig `yaml:"transport"` // Peer message compression and batching
+
+	// Member liveness: the leader alarms when it has not heard from a member for this long
+	PeerDeadTimeout time.Duration `yaml:"peer_dead_timeout"` // Default 30s
+
+	// Raft log compaction triggers, in addition to the fixed 10000-entry count
+	SnapshotMaxLogBytes uint64        `yaml:"snapshot_max_log_bytes"` // Snapshot and compact once this many log bytes were appended since the last snapshot, 0 disables (default)
+	SnapshotInterval    time.Duration `yaml:"snapshot_interval"`      // Snapshot when this long has passed since the last snapshot and entries were applied, 0 disables (default)
+
+	// Startup consistency check of the RocksDB raft log
+	StartupCheck string `yaml:"startup_check"` // "repair" (default) fixes what is safe to fix, "strict" refuses to start on any problem
+
+	// ForceNewCluster restarts the member as the only voter of its cluster,
+	// like etcd's --force-new-cluster, for recovery after losing the quorum.
+	// Only set by the --force-new-cluster flag: left in a config file it would
+	// drop the other members on every restart
+	ForceNewCluster bool `yaml:"-"`
 }
 
 // WitnessConfig configuration for witness nodes
@@ -215,6 +465,11 @@ func (r *RaftConfig) IsWitness() bool {
 	return r.NodeRole == NodeRoleWitness
 }
 
+// IsReplica returns true if this node is configured as a read-only replica
+func (r *RaftConfig) IsReplica() bool {
+	return r.NodeRole == NodeRoleReplica
+}
+
 // IsDataNode returns true if this node is configured as a full data node
 func (r *RaftConfig) IsDataNode() bool {
 	return r.NodeRole == NodeRoleData || r.NodeRole == ""
@@ -231,6 +486,44 @@ type RaftBatchConfig struct {
 	MinTimeout    time.Duration `yaml:"min_timeout"`     // Minimum timeout (low load), default 5ms
 	MaxTimeout    time.Duration `yaml:"max_timeout"`     // Maximum timeout (high load), default 20ms
 	LoadThreshold float64       `yaml:"load_threshold"`  // Load threshold (0.0-1.0), default 0.7
+
+	// Per-frontend overrides keyed by "etcd", "http" or "mysql". Each listed frontend gets
+	// its own adaptive queue, e.g. larger batches for MySQL bulk loads
+	Frontends map[string]RaftBatchOverride `yaml:"frontends"`
+}
+
+// RaftBatchOverride batch parameters of one frontend, unset fields inherit raft.batch
+type RaftBatchOverride struct {
+	MinBatchSize  int           `yaml:"min_batch_size"`
+	MaxBatchSize  int           `yaml:"max_batch_size"`
+	MinTimeout    time.Duration `yaml:"min_timeout"`
+	MaxTimeout    time.Duration `yaml:"max_timeout"`
+	LoadThreshold float64       `yaml:"load_threshold"`
+}
+
+// ForFrontend returns the batch parameters used for proposals of frontend
+func (c RaftBatchConfig) ForFrontend(frontend string) RaftBatchConfig {
+	o, ok := c.Frontends[frontend]
+	if !ok {
+		return c
+	}
+	if o.MinBatchSize != 0 {
+		c.MinBatchSize = o.MinBatchSize
+	}
+	if o.MaxBatchSize != 0 {
+		c.MaxBatchSize = o.MaxBatchSize
+	}
+	if o.MinTimeout != 0 {
+		c.MinTimeout = o.MinTimeout
+	}
+	if o.MaxTimeout != 0 {
+		c.MaxTimeout = o.MaxTimeout
+	}
+	if o.LoadThreshold != 0 {
+		c.LoadThreshold = o.LoadThreshold
+	}
+	c.Frontends = nil
+	return c
 }
 
 // LeaseReadConfig Lease Read configuration
@@ -244,6 +537,75 @@ type LeaseReadConfig struct {
 	ReadTimeout time.Duration `yaml:"read_timeout"` // Read timeout, default 5s
 }
 
+// LeaderTransferConfig automatic leadership transfer configuration
+// The leader hands over to the most up-to-date active follower instead of leaving
+// the cluster leaderless until an election timeout fires
+type LeaderTransferConfig struct {
+	OnShutdown           *bool         `yaml:"on_shutdown"`            // Transfer leadership before graceful shutdown, default true (nil means unset)
+	Timeout              time.Duration `yaml:"timeout"`                // Max time to wait for the new leader, default 5s
+	DiskLatencyThreshold time.Duration `yaml:"disk_latency_threshold"` // Transfer away when persisting the raft log is slower than this, 0 disables (default)
+	DiskLatencyWindow    int           `yaml:"disk_latency_window"`    // Consecutive slow writes before transferring, default 5
+	Cooldown             time.Duration `yaml:"cooldown"`               // Minimum interval between disk latency transfers, default 30s
+}
+
+// TransferOnShutdown reports whether leadership is handed over before graceful shutdown
+// An unset on_shutdown keeps the default (true); "on_shutdown: false" disables it
+func (c LeaderTransferConfig) TransferOnShutdown() bool {
+	return c.OnShutdown == nil || *c.OnShutdown
+}
+
+// PlacementConfig failure-domain aware leader placement
+// The leader periodically checks whether a better placed active member exists and hands
+// leadership over to it: preferred leaders first, then members in the primary zone.
+// Witness and learner members never receive leadership
+type PlacementConfig struct {
+	Zone             string            `yaml:"zone"`              // Failure domain of this member (e.g. availability zone), recorded in the member registry
+	Labels           map[string]string `yaml:"labels"`            // Free-form member labels recorded in the member registry
+	PrimaryZone      string            `yaml:"primary_zone"`      // Zone the leader should stay in, empty means any zone
+	PreferredLeaders []uint64          `yaml:"preferred_leaders"` // Member IDs preferred as leader, earlier entries first
+	CheckInterval    time.Duration     `yaml:"check_interval"`    // How often the leader checks its placement, default 10s
+}
+
+// Raft peer transport compression codecs
+const (
+	CompressionNone   = "none"
+	CompressionSnappy = "snappy"
+	CompressionZstd   = "zstd"
+)
+
+// Serving modes of serializable reads on the RocksDB engine
+const (
+	SerializableReadsLocal    = "local"
+	SerializableReadsEventual = "eventual"
+)
+
+// Raft startup consistency check modes
+const (
+	StartupCheckRepair = "repair"
+	StartupCheckStrict = "strict"
+)
+
+// RaftTransportConfig peer transport configuration
+// Compression applies to log entries in append messages and to snapshot data;
+// every node can decode compressed messages regardless of its own setting,
+// so compression can be turned on node by node after a rolling upgrade
+type RaftTransportConfig struct {
+	Compression     string `yaml:"compression"`       // "none" (default), "snappy" or "zstd"
+	CompressMinSize int    `yaml:"compress_min_size"` // Entries smaller than this are sent as is, default 256 bytes
+	BatchMessages   bool   `yaml:"batch_messages"`    // Merge consecutive append messages to the same peer, default false
+	BatchMaxBytes   uint64 `yaml:"batch_max_bytes"`   // Maximum entry bytes of a merged message, default max_size_per_msg
+
+	// SnapshotStream sends snapshot data over the streaming snapshot endpoint instead of
+	// embedding it in the raft message, default true (nil means unset)
+	SnapshotStream *bool `yaml:"snapshot_stream"`
+}
+
+// StreamSnapshots reports whether snapshots are streamed separately from their message
+// Disable it while upgrading a cluster whose older members cannot receive streamed snapshots
+func (c RaftTransportConfig) StreamSnapshots() bool {
+	return c.SnapshotStream == nil || *c.SnapshotStream
+}
+
 // RocksDBConfig RocksDB performance configuration
 type RocksDBConfig struct {
 	// Block Cache configuration (affects read performance)
@@ -268,6 +630,196 @@ type RocksDBConfig struct {
 	MaxOpenFiles  int    `yaml:"max_open_files"`   // Default 10000
 	UseFsync      bool   `yaml:"use_fsync"`        // Default false (use fdatasync)
 	BytesPerSync  uint64 `yaml:"bytes_per_sync"`   // Default 1MB
+
+	// Value compression, applied per value in the record codec and to snapshot data
+	ValueCompression     string `yaml:"value_compression"`       // "none" (default), "snappy" or "zstd"
+	ValueCompressMinSize int    `yaml:"value_compress_min_size"` // Values smaller than this are stored as is, default 4KB
+
+	// Negative lookup cache: LRU of keys recently read and found absent, dropped when the key is put
+	MissCacheSize int `yaml:"miss_cache_size"` // Maximum number of cached absent keys, 0 (default) disables it
+
+	// Read cache: LRU of recently read key-values, dropped in the apply path when the key is written or deleted
+	ReadCacheSize int `yaml:"read_cache_size"` // Maximum number of cached key-values, 0 (default) disables it
+
+	// How serializable reads are served: "local" (default) reads the latest applied state,
+	// "eventual" reads a snapshot shared by all readers and replaced after every applied
+	// commit, without locks or the read caches, and reports the revision of that snapshot
+	SerializableReads string `yaml:"serializable_reads"`
+
+	// Internal statistics exported as metrics
+	StatsInterval time.Duration `yaml:"stats_interval"` // How long sampled properties are reused between scrapes, default 15s
+	Statistics    bool          `yaml:"statistics"`     // Collect tickers for block cache hits and write stalls, costs some CPU, default false
+
+	// Write stall mitigation while compaction falls behind
+	StallControl StallControlConfig `yaml:"stall_control"`
+
+	// Follower bootstrap from RocksDB checkpoints instead of state machine data in raft snapshots
+	CheckpointBootstrap CheckpointBootstrapConfig `yaml:"checkpoint_bootstrap"`
+}
+
+// StallControlConfig adjusts RocksDB while compaction falls behind during write
+// bursts, so write stalls do not block the apply loop long enough to time out Raft
+type StallControlConfig struct {
+	Enable                     bool          `yaml:"enable"`                        // Default false
+	CheckInterval              time.Duration `yaml:"check_interval"`                // How often stall indicators are read, default 1s
+	Level0Ratio                float64       `yaml:"level0_ratio"`                  // Pressure once L0 files reach this fraction of level0_slowdown_writes_trigger, default 0.75
+	BoostBackgroundJobs        int           `yaml:"boost_background_jobs"`         // Background jobs under pressure, default twice max_background_jobs
+	BoostLevel0SlowdownTrigger int           `yaml:"boost_level0_slowdown_trigger"` // level0_slowdown_writes_trigger under pressure, default halfway to level0_stop_writes_trigger
+	CalmPeriod                 time.Duration `yaml:"calm_period"`                   // Time without pressure before the settings are restored, default 30s
+}
+
+// CheckpointBootstrapConfig makes raft snapshots refer to a RocksDB checkpoint of
+// the sender instead of carrying the state machine. Receivers download the SST
+// files of the checkpoint from the peer URL of the sender and ingest them.
+// Only enable it once every member runs a version that understands such snapshots
+type CheckpointBootstrapConfig struct {
+	Enable    bool          `yaml:"enable"`     // Default false
+	RateLimit int64         `yaml:"rate_limit"` // Bytes per second served to downloading members, 0 (default) means unlimited
+	Retention time.Duration `yaml:"retention"`  // How long older checkpoints stay available to members still downloading them, default 1h
+}
+
+// MirrorConfig cross-datacenter asynchronous replication configuration
+// Each mirror tails the local watch stream and replays changes under its prefix
+// to a remote MetaStore/etcd cluster; only the leader runs mirrors
+type MirrorConfig struct {
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval"` // How often the last mirrored revision is persisted, default 1s
+	Mirrors            []MirrorSpec  `yaml:"mirrors"`             // Configured mirrors
+}
+
+// MirrorSpec a single mirror to a remote cluster
+type MirrorSpec struct {
+	Name        string        `yaml:"name"`         // Unique name, also identifies the checkpoint
+	Endpoints   []string      `yaml:"endpoints"`    // Remote cluster client endpoints
+	Username    string        `yaml:"username"`     // Remote auth user (optional)
+	Password    string        `yaml:"password"`     // Remote auth password (optional)
+	DialTimeout time.Duration `yaml:"dial_timeout"` // Remote dial timeout, default 5s
+	Prefix      string        `yaml:"prefix"`       // Only mirror keys under this prefix, "" mirrors all keys
+	DestPrefix  string        `yaml:"dest_prefix"`  // Replace prefix with this on the remote, "" keeps keys unchanged
+	AutoStart   bool          `yaml:"auto_start"`   // Start the mirror on boot, default false (start via admin API)
+}
+
+// CDC sink types
+const (
+	CDCSinkKafka = "kafka"
+	CDCSinkNATS  = "nats"
+)
+
+// CDCConfig change data capture configuration
+// Each sink publishes PUT/DELETE events of the routed prefixes to Kafka or NATS JetStream
+// with at-least-once delivery; only the leader publishes
+type CDCConfig struct {
+	CursorInterval time.Duration   `yaml:"cursor_interval"` // How often the last published revision is persisted, default 1s
+	Sinks          []CDCSinkConfig `yaml:"sinks"`           // Configured sinks
+}
+
+// CDCSinkConfig a single CDC sink
+type CDCSinkConfig struct {
+	Name           string        `yaml:"name"`            // Unique name, also identifies the cursor
+	Type           string        `yaml:"type"`            // "kafka" or "nats"
+	Endpoints      []string      `yaml:"endpoints"`       // Kafka brokers or NATS server URLs
+	Routes         []CDCRoute    `yaml:"routes"`          // Prefix to topic/subject routes
+	BatchSize      int           `yaml:"batch_size"`      // Max events per publish, default 100
+	PublishTimeout time.Duration `yaml:"publish_timeout"` // Timeout for a publish to be acknowledged, default 10s
+}
+
+// CDCRoute routes keys under Prefix to Topic (Kafka topic or NATS subject)
+// When several routes match a key, the longest prefix wins
+type CDCRoute struct {
+	Prefix string `yaml:"prefix"` // "" matches all keys
+	Topic  string `yaml:"topic"`
+}
+
+// EncryptionConfig encryption of values at rest
+// Values are encrypted with AES-256-GCM envelope encryption before they are written to
+// RocksDB or to snapshots. All members must be configured with the same keys because
+// snapshots are exchanged between members
+type EncryptionConfig struct {
+	Enabled   bool            `yaml:"enabled"`    // Default false
+	ActiveKey string          `yaml:"active_key"` // Key used for new writes, default the last key in Keys
+	Keys      []EncryptionKey `yaml:"keys"`       // Key encryption keys, keep retired keys until no data uses them
+}
+
+// EncryptionKey a key encryption key (KEK), exactly one source must be set
+// The material is 32 bytes, either raw or base64/hex encoded
+type EncryptionKey struct {
+	ID      string   `yaml:"id"`      // Recorded in every ciphertext, must not change
+	File    string   `yaml:"file"`    // Read the key from a file
+	Env     string   `yaml:"env"`     // Read the key from an environment variable
+	Command []string `yaml:"command"` // Run a command (e.g. a KMS client) and read the key from its stdout
+}
+
+// ChunkingConfig large value configuration
+// Values larger than ChunkSize are stored as segment keys plus a manifest under the
+// original key, so no single Raft proposal or gRPC message has to carry the whole value
+type ChunkingConfig struct {
+	ChunkSize    int   `yaml:"chunk_size"`     // Segment size, larger values are chunked, default 1MB
+	MaxValueSize int64 `yaml:"max_value_size"` // Largest value accepted, default 1.5MB like etcd
+
+	// Limits for keys under known blob prefixes, the longest matching prefix applies
+	MaxValueSizeOverrides []ValueSizeOverride `yaml:"max_value_size_overrides"`
+}
+
+// ValueSizeOverride replaces max_value_size for the keys under a prefix
+type ValueSizeOverride struct {
+	Prefix       string `yaml:"prefix"`
+	MaxValueSize int64  `yaml:"max_value_size"`
+}
+
+// UsageConfig per-prefix usage accounting
+// Key counts and sizes come from a periodic scan of the local keyspace, writes are
+// counted from a local watch and watches are sampled at each scan. A prefix is the
+// part of a key up to and including its PrefixDepth-th "/"
+type UsageConfig struct {
+	Enable       bool          `yaml:"enable"`        // Default false
+	PrefixDepth  int           `yaml:"prefix_depth"`  // "/" separators included in a prefix, default 2
+	ScanInterval time.Duration `yaml:"scan_interval"` // Interval between keyspace scans, default 5m
+	MaxPrefixes  int           `yaml:"max_prefixes"`  // Largest prefixes reported, the rest are folded into one row, default 1000
+	TopKeys      int           `yaml:"top_keys"`      // Largest keys reported, default 20
+}
+
+// HLCConfig hybrid logical clock
+// Every committed operation carries an HLC timestamp, returned in KV response headers
+// and in watch/CDC events. Timestamps received from peers or clients further ahead of
+// the local physical clock than MaxOffset are counted in the skew metrics, and are
+// rejected when they come with a client request
+type HLCConfig struct {
+	MaxOffset time.Duration `yaml:"max_offset"` // Largest accepted clock offset, default 500ms
+}
+
+// Delete protection modes
+const (
+	DeleteProtectionDeny  = "deny"  // Deletes under the prefix fail
+	DeleteProtectionTrash = "trash" // Deleted values are moved to the trash and can be restored
+)
+
+// DeleteProtectionConfig delete protection for prefixes
+// Applies to deletes from the etcd, HTTP and MySQL frontends; lease expiry,
+// mirrors and internal writers are not affected
+type DeleteProtectionConfig struct {
+	Prefixes      []ProtectedPrefixConfig `yaml:"prefixes"`
+	PurgeInterval time.Duration           `yaml:"purge_interval"` // Interval between removals of expired trash entries, default 1m
+}
+
+// ProtectedPrefixConfig a single protected prefix
+type ProtectedPrefixConfig struct {
+	Prefix    string        `yaml:"prefix"`
+	Mode      string        `yaml:"mode"`      // "deny" or "trash", default "trash"
+	Retention time.Duration `yaml:"retention"` // How long trashed values can be restored, default 168h (7 days)
+}
+
+// AdmissionConfig admission hooks
+// Hooks are registered by name at startup (built-in or linked in by a custom build) and
+// can reject or rewrite puts and deletes from the etcd, HTTP and MySQL frontends before
+// they are proposed. Internal writers such as mirrors and index maintenance are not hooked
+type AdmissionConfig struct {
+	Hooks []AdmissionHookConfig `yaml:"hooks"` // Applied in order, the first rejection wins
+}
+
+// AdmissionHookConfig a single configured hook
+type AdmissionHookConfig struct {
+	Name      string            `yaml:"name"`      // Registered hook name
+	Frontends []string          `yaml:"frontends"` // "etcd", "http" and/or "mysql", default all
+	Params    map[string]string `yaml:"params"`    // Hook specific parameters
 }
 
 // MVCCConfig MVCC (Multi-Version Concurrency Control) configuration
@@ -281,6 +833,9 @@ type MVCCConfig struct {
 
 	// Compaction performance configuration
 	Compaction MVCCCompactionConfig `yaml:"compaction"`
+
+	// Event log for resuming watches, retained independently of compaction
+	WatchHistory MVCCWatchHistoryConfig `yaml:"watch_history"`
 }
 
 // MVCCRetentionConfig version retention configuration
@@ -319,6 +874,18 @@ type MVCCCompactionConfig struct {
 	BatchInterval time.Duration `yaml:"batch_interval"`
 }
 
+// MVCCWatchHistoryConfig event log used to resume watches
+// Watchers resuming from a revision that compaction already removed are replayed
+// from this log when it still holds every event since that revision. The log is
+// kept in memory and starts empty after a restart
+type MVCCWatchHistoryConfig struct {
+	// MaxEvents is the number of most recent events retained (default 10000, negative disables the log)
+	MaxEvents int `yaml:"max_events"`
+
+	// Retention is the maximum age of retained events (optional, 0 means only use MaxEvents)
+	Retention time.Duration `yaml:"retention"`
+}
+
 // DefaultConfig returns a configuration with recommended default values
 // Use this function to get production-ready defaults when no config file is provided
 func DefaultConfig(clusterID, memberID uint64, etcdAddress string) *Config {
@@ -354,7 +921,9 @@ func LoadConfig(path string) (*Config, error) {
 	cfg.SetDefaults()
 
 	// Override from environment variables
-	cfg.OverrideFromEnv()
+	if err := cfg.OverrideFromEnv(); err != nil {
+		return nil, err
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -383,7 +952,9 @@ func LoadConfigOrDefault(path string, clusterID, memberID uint64, etcdAddress st
 	cfg := DefaultConfig(clusterID, memberID, etcdAddress)
 
 	// Override from environment variables
-	cfg.OverrideFromEnv()
+	if err := cfg.OverrideFromEnv(); err != nil {
+		return nil, err
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -408,6 +979,18 @@ func (c *Config) SetDefaults() {
 	if c.Server.MySQL.Username == "" {
 		c.Server.MySQL.Username = "root"
 	}
+	if c.Server.MySQL.AuthPlugin == "" {
+		c.Server.MySQL.AuthPlugin = MySQLCachingSha2Password
+	}
+	if c.Server.MySQL.LockTTL == 0 {
+		c.Server.MySQL.LockTTL = 30 * time.Second
+	}
+	if c.Server.MySQL.LockWaitTimeout == 0 {
+		c.Server.MySQL.LockWaitTimeout = 50 * time.Second // innodb_lock_wait_timeout
+	}
+	if c.Server.MySQL.ChangesTimeout == 0 {
+		c.Server.MySQL.ChangesTimeout = 30 * time.Second
+	}
 
 	// gRPC defaults (based on industry best practices: etcd, gRPC official, TiKV)
 	if c.Server.GRPC.MaxRecvMsgSize == 0 {
@@ -440,6 +1023,9 @@ func (c *Config) SetDefaults() {
 	if c.Server.GRPC.MaxConnectionAgeGrace == 0 {
 		c.Server.GRPC.MaxConnectionAgeGrace = 10 * time.Second // Fast cleanup
 	}
+	if len(c.Server.GRPC.Compression) == 0 {
+		c.Server.GRPC.Compression = []string{"gzip"}
+	}
 
 	// Limits defaults
 	if c.Server.Limits.MaxConnections == 0 {
@@ -460,6 +1046,44 @@ func (c *Config) SetDefaults() {
 	if c.Server.Limits.MaxRequests == 0 {
 		c.Server.Limits.MaxRequests = 5000
 	}
+	if c.Server.Limits.MaxBatchOps == 0 {
+		c.Server.Limits.MaxBatchOps = 10000
+	}
+	if c.Server.Limits.MaxRangeKeys == 0 {
+		c.Server.Limits.MaxRangeKeys = 100000
+	}
+	if c.Server.Limits.ProposeQueueThreshold == 0 {
+		c.Server.Limits.ProposeQueueThreshold = 0.9
+	}
+	if c.Server.Limits.MaxPendingProposals == 0 {
+		c.Server.Limits.MaxPendingProposals = 10000
+	}
+	if c.Server.Limits.RetryAfter == 0 {
+		c.Server.Limits.RetryAfter = time.Second
+	}
+	if c.Server.Limits.ProposeQueueSize == 0 {
+		c.Server.Limits.ProposeQueueSize = 10000
+	}
+	if c.Server.Limits.ProposeQueueOverflow == "" {
+		c.Server.Limits.ProposeQueueOverflow = ProposeOverflowBlock
+	}
+	if c.Server.Limits.ApplyQueueSize == 0 {
+		c.Server.Limits.ApplyQueueSize = 64
+	}
+	if c.Server.Limits.ApplyWorkers == 0 {
+		c.Server.Limits.ApplyWorkers = runtime.NumCPU()
+	}
+	if c.Server.Limits.RequestTimeout == 0 {
+		c.Server.Limits.RequestTimeout = 30 * time.Second
+	}
+
+	// Watch defaults
+	if c.Server.Watch.ScavengeInterval == 0 {
+		c.Server.Watch.ScavengeInterval = time.Minute
+	}
+	if c.Server.Watch.OrphanTimeout == 0 {
+		c.Server.Watch.OrphanTimeout = 5 * time.Minute
+	}
 
 	// Lease defaults
 	if c.Server.Lease.CheckInterval == 0 {
@@ -468,6 +1092,12 @@ func (c *Config) SetDefaults() {
 	if c.Server.Lease.DefaultTTL == 0 {
 		c.Server.Lease.DefaultTTL = 60 * time.Second
 	}
+	if c.Server.Lease.ExpiryBatchSize == 0 {
+		c.Server.Lease.ExpiryBatchSize = 1000
+	}
+	if c.Server.Lease.ExpiryJitter == 0 {
+		c.Server.Lease.ExpiryJitter = c.Server.Lease.CheckInterval / 5
+	}
 
 	// Auth defaults
 	if c.Server.Auth.TokenTTL == 0 {
@@ -484,6 +1114,9 @@ func (c *Config) SetDefaults() {
 	if c.Server.Maintenance.SnapshotChunkSize == 0 {
 		c.Server.Maintenance.SnapshotChunkSize = 4 * 1024 * 1024 // 4MB
 	}
+	if e := &c.Server.Maintenance.SnapshotEncryption; e.ActiveKey == "" && len(e.Keys) > 0 {
+		e.ActiveKey = e.Keys[len(e.Keys)-1].ID
+	}
 
 	// Reliability defaults
 	if c.Server.Reliability.ShutdownTimeout == 0 {
@@ -499,6 +1132,12 @@ func (c *Config) SetDefaults() {
 	if !c.Server.Reliability.EnablePanicRecovery {
 		c.Server.Reliability.EnablePanicRecovery = true
 	}
+	if c.Server.Reliability.HealthMaxApplyLag == 0 {
+		c.Server.Reliability.HealthMaxApplyLag = 1000
+	}
+	if c.Server.Reliability.LeaderRenewInterval == 0 {
+		c.Server.Reliability.LeaderRenewInterval = 5 * time.Second
+	}
 
 	// Log defaults
 	if c.Server.Log.Level == "" {
@@ -604,6 +1243,96 @@ func (c *Config) SetDefaults() {
 		c.Server.Raft.LeaseRead.ReadTimeout = 5 * time.Second // Read timeout 5 seconds
 	}
 
+	// Leadership transfer defaults
+	// Transfer on shutdown enabled by default, disk latency transfer disabled by default
+	// OnShutdown is a pointer so an explicit "on_shutdown: false" survives defaulting
+	if c.Server.Raft.LeaderTransfer.OnShutdown == nil {
+		onShutdown := true
+		c.Server.Raft.LeaderTransfer.OnShutdown = &onShutdown
+	}
+	if c.Server.Raft.LeaderTransfer.Timeout == 0 {
+		c.Server.Raft.LeaderTransfer.Timeout = 5 * time.Second
+	}
+	if c.Server.Raft.LeaderTransfer.DiskLatencyWindow == 0 {
+		c.Server.Raft.LeaderTransfer.DiskLatencyWindow = 5
+	}
+	if c.Server.Raft.LeaderTransfer.Cooldown == 0 {
+		c.Server.Raft.LeaderTransfer.Cooldown = 30 * time.Second
+	}
+
+	// Leader placement defaults
+	if c.Server.Raft.Placement.CheckInterval == 0 {
+		c.Server.Raft.Placement.CheckInterval = 10 * time.Second
+	}
+
+	// Member liveness defaults
+	if c.Server.Raft.PeerDeadTimeout == 0 {
+		c.Server.Raft.PeerDeadTimeout = 30 * time.Second
+	}
+
+	// Peer transport defaults
+	// Compression and batching are disabled by default, mainly useful across datacenters
+	if c.Server.Raft.Transport.Compression == "" {
+		c.Server.Raft.Transport.Compression = CompressionNone
+	}
+	if c.Server.Raft.Transport.CompressMinSize == 0 {
+		c.Server.Raft.Transport.CompressMinSize = 256
+	}
+	if c.Server.Raft.Transport.BatchMaxBytes == 0 {
+		c.Server.Raft.Transport.BatchMaxBytes = c.Server.Raft.MaxSizePerMsg
+	}
+
+	// Startup check of the raft log repairs what is safe to repair by default
+	if c.Server.Raft.StartupCheck == "" {
+		c.Server.Raft.StartupCheck = StartupCheckRepair
+	}
+
+	// Chunking defaults
+	if c.Server.Chunking.ChunkSize == 0 {
+		c.Server.Chunking.ChunkSize = 1048576 // 1MB
+	}
+	if c.Server.Chunking.MaxValueSize == 0 {
+		c.Server.Chunking.MaxValueSize = 1572864 // 1.5MB, etcd's default request size limit
+	}
+
+	// Usage accounting defaults
+	if c.Server.Usage.PrefixDepth == 0 {
+		c.Server.Usage.PrefixDepth = 2
+	}
+	if c.Server.Usage.ScanInterval == 0 {
+		c.Server.Usage.ScanInterval = 5 * time.Minute
+	}
+	if c.Server.Usage.MaxPrefixes == 0 {
+		c.Server.Usage.MaxPrefixes = 1000
+	}
+	if c.Server.Usage.TopKeys == 0 {
+		c.Server.Usage.TopKeys = 20
+	}
+
+	// Hybrid logical clock defaults
+	if c.Server.HLC.MaxOffset == 0 {
+		c.Server.HLC.MaxOffset = 500 * time.Millisecond
+	}
+
+	// Delete protection defaults
+	if c.Server.DeleteProtection.PurgeInterval == 0 {
+		c.Server.DeleteProtection.PurgeInterval = time.Minute
+	}
+	for i := range c.Server.DeleteProtection.Prefixes {
+		p := &c.Server.DeleteProtection.Prefixes[i]
+		if p.Mode == "" {
+			p.Mode = DeleteProtectionTrash
+		}
+		if p.Retention == 0 {
+			p.Retention = 7 * 24 * time.Hour
+		}
+	}
+
+	// Encryption defaults
+	if c.Server.Encryption.ActiveKey == "" && len(c.Server.Encryption.Keys) > 0 {
+		c.Server.Encryption.ActiveKey = c.Server.Encryption.Keys[len(c.Server.Encryption.Keys)-1].ID
+	}
+
 	// RocksDB defaults (based on RocksDB official recommendations)
 	if c.Server.RocksDB.BlockCacheSize == 0 {
 		c.Server.RocksDB.BlockCacheSize = 268435456 // 256MB
@@ -641,6 +1370,36 @@ func (c *Config) SetDefaults() {
 	if c.Server.RocksDB.BytesPerSync == 0 {
 		c.Server.RocksDB.BytesPerSync = 1048576 // 1MB
 	}
+	if c.Server.RocksDB.ValueCompression == "" {
+		c.Server.RocksDB.ValueCompression = CompressionNone
+	}
+	if c.Server.RocksDB.ValueCompressMinSize == 0 {
+		c.Server.RocksDB.ValueCompressMinSize = 4096 // 4KB
+	}
+	if c.Server.RocksDB.SerializableReads == "" {
+		c.Server.RocksDB.SerializableReads = SerializableReadsLocal
+	}
+	if c.Server.RocksDB.StatsInterval == 0 {
+		c.Server.RocksDB.StatsInterval = 15 * time.Second
+	}
+	if c.Server.RocksDB.StallControl.CheckInterval == 0 {
+		c.Server.RocksDB.StallControl.CheckInterval = time.Second
+	}
+	if c.Server.RocksDB.StallControl.Level0Ratio == 0 {
+		c.Server.RocksDB.StallControl.Level0Ratio = 0.75
+	}
+	if c.Server.RocksDB.StallControl.BoostBackgroundJobs == 0 {
+		c.Server.RocksDB.StallControl.BoostBackgroundJobs = 2 * c.Server.RocksDB.MaxBackgroundJobs
+	}
+	if c.Server.RocksDB.StallControl.BoostLevel0SlowdownTrigger == 0 {
+		c.Server.RocksDB.StallControl.BoostLevel0SlowdownTrigger = (c.Server.RocksDB.Level0SlowdownWritesTrigger + c.Server.RocksDB.Level0StopWritesTrigger) / 2
+	}
+	if c.Server.RocksDB.StallControl.CalmPeriod == 0 {
+		c.Server.RocksDB.StallControl.CalmPeriod = 30 * time.Second
+	}
+	if c.Server.RocksDB.CheckpointBootstrap.Retention == 0 {
+		c.Server.RocksDB.CheckpointBootstrap.Retention = time.Hour
+	}
 	// UseFsync defaults to false (no need to set)
 
 	// MVCC defaults (compatible with etcd)
@@ -666,32 +1425,46 @@ func (c *Config) SetDefaults() {
 	if c.Server.MVCC.Compaction.BatchInterval == 0 {
 		c.Server.MVCC.Compaction.BatchInterval = 10 * time.Millisecond
 	}
-}
 
-// OverrideFromEnv overrides configuration from environment variables
-func (c *Config) OverrideFromEnv() {
-	// Cluster configuration
-	if clusterID := os.Getenv("METASTORE_CLUSTER_ID"); clusterID != "" {
-		if id, err := strconv.ParseUint(clusterID, 10, 64); err == nil {
-			c.Server.ClusterID = id
-		}
+	// Watch history defaults
+	if c.Server.MVCC.WatchHistory.MaxEvents == 0 {
+		c.Server.MVCC.WatchHistory.MaxEvents = 10000
 	}
-	if memberID := os.Getenv("METASTORE_MEMBER_ID"); memberID != "" {
-		if id, err := strconv.ParseUint(memberID, 10, 64); err == nil {
-			c.Server.MemberID = id
-		}
+	// Retention defaults to 0 (only use MaxEvents)
+
+	// Mirror defaults
+	if c.Server.Mirror.CheckpointInterval == 0 {
+		c.Server.Mirror.CheckpointInterval = time.Second
 	}
-	if etcdAddr := os.Getenv("METASTORE_ETCD_ADDRESS"); etcdAddr != "" {
-		c.Server.Etcd.Address = etcdAddr
+	for i := range c.Server.Mirror.Mirrors {
+		if c.Server.Mirror.Mirrors[i].DialTimeout == 0 {
+			c.Server.Mirror.Mirrors[i].DialTimeout = 5 * time.Second
+		}
 	}
 
-	// Log configuration
-	if logLevel := os.Getenv("METASTORE_LOG_LEVEL"); logLevel != "" {
-		c.Server.Log.Level = logLevel
+	// CDC defaults
+	if c.Server.CDC.CursorInterval == 0 {
+		c.Server.CDC.CursorInterval = time.Second
+	}
+	for i := range c.Server.CDC.Sinks {
+		if c.Server.CDC.Sinks[i].BatchSize == 0 {
+			c.Server.CDC.Sinks[i].BatchSize = 100
+		}
+		if c.Server.CDC.Sinks[i].PublishTimeout == 0 {
+			c.Server.CDC.Sinks[i].PublishTimeout = 10 * time.Second
+		}
 	}
-	if logEncoding := os.Getenv("METASTORE_LOG_ENCODING"); logEncoding != "" {
-		c.Server.Log.Encoding = logEncoding
+}
+
+// ApplyFeatureGates removes configuration for features that are gated off, so
+// components can start from the config alone. It reports whether lease reads
+// were turned off; linearizable reads then use ReadIndex.
+func (c *Config) ApplyFeatureGates(gate *featuregate.Gate) (leaseReadDisabled bool) {
+	if !gate.Enabled(featuregate.LeaseRead) && c.Server.Raft.LeaseRead.Enable {
+		c.Server.Raft.LeaseRead.Enable = false
+		leaseReadDisabled = true
 	}
+	return leaseReadDisabled
 }
 
 // Validate validates the configuration
@@ -708,6 +1481,49 @@ func (c *Config) Validate() error {
 	if c.Server.Etcd.Address == "" {
 		return fmt.Errorf("etcd.address is required")
 	}
+	for _, check := range []struct {
+		field     string
+		urls      []string
+		advertise bool
+	}{
+		{"etcd.listen_client_urls", c.Server.Etcd.ListenClientURLs, false},
+		{"etcd.advertise_client_urls", c.Server.Etcd.AdvertiseClientURLs, true},
+		{"raft.listen_peer_urls", c.Server.Raft.ListenPeerURLs, false},
+		{"raft.advertise_peer_urls", c.Server.Raft.AdvertisePeerURLs, true},
+		{"monitoring.listen_metrics_urls", c.Server.Monitoring.ListenMetricsURLs, false},
+	} {
+		validate := validateListenURLs
+		if check.advertise {
+			validate = validateAdvertiseURLs
+		}
+		if err := validate(check.field, check.urls); err != nil {
+			return err
+		}
+	}
+
+	switch c.Server.MySQL.AuthPlugin {
+	case MySQLNativePassword, MySQLCachingSha2Password:
+	default:
+		return fmt.Errorf("mysql.auth_plugin must be one of: %s, %s", MySQLCachingSha2Password, MySQLNativePassword)
+	}
+	if c.Server.MySQL.LockTTL < time.Second {
+		return fmt.Errorf("mysql.lock_ttl must be at least 1s")
+	}
+	if c.Server.MySQL.LockWaitTimeout < 0 {
+		return fmt.Errorf("mysql.lock_wait_timeout must not be negative")
+	}
+	if c.Server.MySQL.ChangesTimeout < 0 {
+		return fmt.Errorf("mysql.changes_timeout must not be negative")
+	}
+	if c.Server.ReadRevision < 0 {
+		return fmt.Errorf("read_revision must not be negative")
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		return fmt.Errorf("tls.cert_file and tls.key_file must be set together")
+	}
+	if c.Server.TLS.CAFile != "" && !c.Server.TLS.Enabled() {
+		return fmt.Errorf("tls.ca_file requires tls.cert_file")
+	}
 
 	// Validate gRPC configuration
 	if c.Server.GRPC.MaxRecvMsgSize < 0 {
@@ -716,6 +1532,17 @@ func (c *Config) Validate() error {
 	if c.Server.GRPC.MaxSendMsgSize < 0 {
 		return fmt.Errorf("grpc.max_send_msg_size must be >= 0")
 	}
+	for _, name := range c.Server.GRPC.Compression {
+		switch name {
+		case "gzip", "zstd":
+		case "none":
+			if len(c.Server.GRPC.Compression) > 1 {
+				return fmt.Errorf("grpc.compression: none cannot be combined with other compressors")
+			}
+		default:
+			return fmt.Errorf("grpc.compression: unknown compressor %q, must be gzip, zstd or none", name)
+		}
+	}
 
 	// Validate resource limits
 	if c.Server.Limits.MaxConnections <= 0 {
@@ -727,11 +1554,66 @@ func (c *Config) Validate() error {
 	if c.Server.Limits.MaxLeaseCount <= 0 {
 		return fmt.Errorf("limits.max_lease_count must be > 0")
 	}
+	if c.Server.Limits.MaxWatchesPerConnection < 0 || c.Server.Limits.MaxLeasesPerConnection < 0 {
+		return fmt.Errorf("limits.max_watches_per_connection and limits.max_leases_per_connection must be >= 0")
+	}
+	if c.Server.Limits.MaxBatchOps <= 0 {
+		return fmt.Errorf("limits.max_batch_ops must be > 0")
+	}
+	if c.Server.Limits.MaxRangeKeys <= 0 {
+		return fmt.Errorf("limits.max_range_keys must be > 0")
+	}
+	if c.Server.Limits.ProposeQueueThreshold <= 0 || c.Server.Limits.ProposeQueueThreshold > 1 {
+		return fmt.Errorf("limits.propose_queue_threshold must be in (0, 1]")
+	}
+	if c.Server.Limits.MaxPendingProposals <= 0 {
+		return fmt.Errorf("limits.max_pending_proposals must be > 0")
+	}
+	if c.Server.Limits.RetryAfter <= 0 {
+		return fmt.Errorf("limits.retry_after must be > 0")
+	}
+	if c.Server.Limits.ProposeQueueSize <= 0 {
+		return fmt.Errorf("limits.propose_queue_size must be > 0")
+	}
+	switch c.Server.Limits.ProposeQueueOverflow {
+	case ProposeOverflowBlock, ProposeOverflowReject:
+	default:
+		return fmt.Errorf("limits.propose_queue_overflow must be one of: block, reject")
+	}
+	if c.Server.Limits.ApplyQueueSize <= 0 {
+		return fmt.Errorf("limits.apply_queue_size must be > 0")
+	}
+	if c.Server.Limits.ApplyWorkers <= 0 {
+		return fmt.Errorf("limits.apply_workers must be > 0")
+	}
+	if c.Server.Limits.RequestTimeout <= 0 {
+		return fmt.Errorf("limits.request_timeout must be > 0")
+	}
+
+	// The /debug/chaos endpoint is served by the metrics server; without it the
+	// faults would be enabled but impossible to configure
+	if c.Server.Reliability.EnableFaultInjection && !c.Server.Monitoring.EnablePrometheus {
+		return fmt.Errorf("reliability.enable_fault_injection requires monitoring.enable_prometheus")
+	}
+	if c.Server.Reliability.LeaderRenewInterval < 0 {
+		return fmt.Errorf("reliability.leader_renew_interval must be >= 0")
+	}
+
+	// Validate Watch configuration
+	if c.Server.Watch.ScavengeInterval <= 0 || c.Server.Watch.OrphanTimeout <= 0 {
+		return fmt.Errorf("watch.scavenge_interval and watch.orphan_timeout must be > 0")
+	}
 
 	// Validate Lease configuration
 	if c.Server.Lease.CheckInterval <= 0 {
 		return fmt.Errorf("lease.check_interval must be > 0")
 	}
+	if c.Server.Lease.ExpiryBatchSize < 0 {
+		return fmt.Errorf("lease.expiry_batch_size must not be negative")
+	}
+	if c.Server.Lease.ExpiryJitter < 0 {
+		return fmt.Errorf("lease.expiry_jitter must not be negative")
+	}
 
 	// Validate Auth configuration
 	if c.Server.Auth.TokenTTL <= 0 {
@@ -745,6 +1627,9 @@ func (c *Config) Validate() error {
 	if c.Server.Maintenance.SnapshotChunkSize <= 0 {
 		return fmt.Errorf("maintenance.snapshot_chunk_size must be > 0")
 	}
+	if err := validateEncryption("maintenance.snapshot_encryption", &c.Server.Maintenance.SnapshotEncryption); err != nil {
+		return err
+	}
 
 	// Validate log level
 	validLogLevels := map[string]bool{
@@ -764,8 +1649,18 @@ func (c *Config) Validate() error {
 	// Validate node role
 	if c.Server.Raft.NodeRole != "" &&
 		c.Server.Raft.NodeRole != NodeRoleData &&
-		c.Server.Raft.NodeRole != NodeRoleWitness {
-		return fmt.Errorf("raft.node_role must be either 'data' or 'witness'")
+		c.Server.Raft.NodeRole != NodeRoleWitness &&
+		c.Server.Raft.NodeRole != NodeRoleReplica {
+		return fmt.Errorf("raft.node_role must be one of 'data', 'witness' or 'replica'")
+	}
+
+	// Validate feature gates
+	gate, err := featuregate.New(c.Server.FeatureGates)
+	if err != nil {
+		return fmt.Errorf("feature_gates: %w", err)
+	}
+	if c.Server.Raft.IsWitness() && !gate.Enabled(featuregate.WitnessMode) {
+		return fmt.Errorf("raft.node_role witness requires feature gate %s", featuregate.WitnessMode)
 	}
 
 	// Witness node specific validation
@@ -797,26 +1692,18 @@ func (c *Config) Validate() error {
 
 	// Validate batch proposal configuration
 	if c.Server.Raft.Batch.Enable {
-		if c.Server.Raft.Batch.MinBatchSize <= 0 {
-			return fmt.Errorf("raft.batch.min_batch_size must be > 0")
+		if err := validateRaftBatch("raft.batch", c.Server.Raft.Batch); err != nil {
+			return err
 		}
-		if c.Server.Raft.Batch.MaxBatchSize <= 0 {
-			return fmt.Errorf("raft.batch.max_batch_size must be > 0")
-		}
-		if c.Server.Raft.Batch.MinBatchSize > c.Server.Raft.Batch.MaxBatchSize {
-			return fmt.Errorf("raft.batch.min_batch_size must be <= max_batch_size")
-		}
-		if c.Server.Raft.Batch.MinTimeout <= 0 {
-			return fmt.Errorf("raft.batch.min_timeout must be > 0")
-		}
-		if c.Server.Raft.Batch.MaxTimeout <= 0 {
-			return fmt.Errorf("raft.batch.max_timeout must be > 0")
-		}
-		if c.Server.Raft.Batch.MinTimeout > c.Server.Raft.Batch.MaxTimeout {
-			return fmt.Errorf("raft.batch.min_timeout must be <= max_timeout")
-		}
-		if c.Server.Raft.Batch.LoadThreshold < 0 || c.Server.Raft.Batch.LoadThreshold > 1 {
-			return fmt.Errorf("raft.batch.load_threshold must be between 0.0 and 1.0")
+		for name := range c.Server.Raft.Batch.Frontends {
+			switch name {
+			case "etcd", "http", "mysql":
+			default:
+				return fmt.Errorf("raft.batch.frontends: unknown frontend %q, must be etcd, http or mysql", name)
+			}
+			if err := validateRaftBatch("raft.batch.frontends."+name, c.Server.Raft.Batch.ForFrontend(name)); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -835,6 +1722,107 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate leadership transfer configuration
+	if c.Server.Raft.LeaderTransfer.Timeout <= 0 {
+		return fmt.Errorf("raft.leader_transfer.timeout must be > 0")
+	}
+	if c.Server.Raft.LeaderTransfer.DiskLatencyThreshold < 0 {
+		return fmt.Errorf("raft.leader_transfer.disk_latency_threshold must be >= 0")
+	}
+	if c.Server.Raft.LeaderTransfer.DiskLatencyWindow <= 0 {
+		return fmt.Errorf("raft.leader_transfer.disk_latency_window must be > 0")
+	}
+
+	// Validate leader placement configuration
+	if c.Server.Raft.Placement.CheckInterval <= 0 {
+		return fmt.Errorf("raft.placement.check_interval must be > 0")
+	}
+	for _, id := range c.Server.Raft.Placement.PreferredLeaders {
+		if id == 0 {
+			return fmt.Errorf("raft.placement.preferred_leaders must not contain 0")
+		}
+	}
+
+	// Validate data directory layout
+	if dirs := c.Server.Storage; dirs.SnapDir != "" {
+		if dirs.WALDir != "" && filepath.Clean(dirs.WALDir) == filepath.Clean(dirs.SnapDir) {
+			return fmt.Errorf("storage.wal_dir and storage.snap_dir must be different directories")
+		}
+		if dirs.DataDir != "" && filepath.Clean(dirs.DataDir) == filepath.Clean(dirs.SnapDir) {
+			return fmt.Errorf("storage.data_dir and storage.snap_dir must be different directories")
+		}
+	}
+
+	// Validate member liveness configuration
+	if c.Server.Raft.PeerDeadTimeout < 0 {
+		return fmt.Errorf("raft.peer_dead_timeout must be > 0")
+	}
+
+	// Validate log compaction triggers
+	if c.Server.Raft.SnapshotInterval < 0 {
+		return fmt.Errorf("raft.snapshot_interval must be >= 0")
+	}
+
+	// Validate peer transport configuration
+	switch c.Server.Raft.Transport.Compression {
+	case CompressionNone, CompressionSnappy, CompressionZstd:
+	default:
+		return fmt.Errorf("raft.transport.compression must be one of: none, snappy, zstd")
+	}
+	if c.Server.Raft.Transport.CompressMinSize < 0 {
+		return fmt.Errorf("raft.transport.compress_min_size must be >= 0")
+	}
+
+	switch c.Server.Raft.StartupCheck {
+	case StartupCheckRepair, StartupCheckStrict:
+	default:
+		return fmt.Errorf("raft.startup_check must be one of: repair, strict")
+	}
+
+	// Validate value compression
+	switch c.Server.RocksDB.ValueCompression {
+	case CompressionNone, CompressionSnappy, CompressionZstd:
+	default:
+		return fmt.Errorf("rocksdb.value_compression must be one of: none, snappy, zstd")
+	}
+	if c.Server.RocksDB.ValueCompressMinSize < 0 {
+		return fmt.Errorf("rocksdb.value_compress_min_size must be >= 0")
+	}
+	if c.Server.RocksDB.MissCacheSize < 0 {
+		return fmt.Errorf("rocksdb.miss_cache_size must be >= 0")
+	}
+	if c.Server.RocksDB.ReadCacheSize < 0 {
+		return fmt.Errorf("rocksdb.read_cache_size must be >= 0")
+	}
+	switch c.Server.RocksDB.SerializableReads {
+	case SerializableReadsLocal, SerializableReadsEventual:
+	default:
+		return fmt.Errorf("rocksdb.serializable_reads must be one of: local, eventual")
+	}
+	if c.Server.RocksDB.StatsInterval < 0 {
+		return fmt.Errorf("rocksdb.stats_interval must be >= 0")
+	}
+	if sc := c.Server.RocksDB.StallControl; sc.Enable {
+		if sc.CheckInterval <= 0 || sc.CalmPeriod < 0 {
+			return fmt.Errorf("rocksdb.stall_control.check_interval must be > 0 and calm_period >= 0")
+		}
+		if sc.Level0Ratio <= 0 || sc.Level0Ratio > 1 {
+			return fmt.Errorf("rocksdb.stall_control.level0_ratio must be in (0, 1]")
+		}
+		if sc.BoostBackgroundJobs < c.Server.RocksDB.MaxBackgroundJobs {
+			return fmt.Errorf("rocksdb.stall_control.boost_background_jobs (%d) must be >= rocksdb.max_background_jobs (%d)",
+				sc.BoostBackgroundJobs, c.Server.RocksDB.MaxBackgroundJobs)
+		}
+		if sc.BoostLevel0SlowdownTrigger < c.Server.RocksDB.Level0SlowdownWritesTrigger ||
+			sc.BoostLevel0SlowdownTrigger >= c.Server.RocksDB.Level0StopWritesTrigger {
+			return fmt.Errorf("rocksdb.stall_control.boost_level0_slowdown_trigger (%d) must be in [level0_slowdown_writes_trigger, level0_stop_writes_trigger)",
+				sc.BoostLevel0SlowdownTrigger)
+		}
+	}
+	if cb := c.Server.RocksDB.CheckpointBootstrap; cb.RateLimit < 0 || cb.Retention < 0 {
+		return fmt.Errorf("rocksdb.checkpoint_bootstrap.rate_limit and retention must be >= 0")
+	}
+
 	// Validate MVCC configuration
 	if c.Server.MVCC.Retention.MaxRevisions <= 0 {
 		return fmt.Errorf("mvcc.retention.max_revisions must be > 0")
@@ -868,6 +1856,203 @@ func (c *Config) Validate() error {
 	if c.Server.MVCC.Compaction.BatchInterval < 0 {
 		return fmt.Errorf("mvcc.compaction.batch_interval must be >= 0")
 	}
+	if c.Server.MVCC.WatchHistory.Retention < 0 {
+		return fmt.Errorf("mvcc.watch_history.retention must be >= 0")
+	}
+
+	// Validate mirror configuration
+	if c.Server.Mirror.CheckpointInterval <= 0 {
+		return fmt.Errorf("mirror.checkpoint_interval must be > 0")
+	}
+	mirrorNames := make(map[string]bool, len(c.Server.Mirror.Mirrors))
+	for _, m := range c.Server.Mirror.Mirrors {
+		if m.Name == "" {
+			return fmt.Errorf("mirror.mirrors[].name is required")
+		}
+		if mirrorNames[m.Name] {
+			return fmt.Errorf("mirror %q is defined more than once", m.Name)
+		}
+		mirrorNames[m.Name] = true
+		if len(m.Endpoints) == 0 {
+			return fmt.Errorf("mirror %q: endpoints are required", m.Name)
+		}
+	}
+
+	// Validate encryption configuration
+	if err := validateEncryption("encryption", &c.Server.Encryption); err != nil {
+		return err
+	}
+
+	// Validate chunking configuration
+	if c.Server.Chunking.ChunkSize <= 0 {
+		return fmt.Errorf("chunking.chunk_size must be > 0")
+	}
+	if c.Server.Chunking.MaxValueSize < int64(c.Server.Chunking.ChunkSize) {
+		return fmt.Errorf("chunking.max_value_size must be >= chunking.chunk_size")
+	}
+	overridden := make(map[string]bool)
+	for _, o := range c.Server.Chunking.MaxValueSizeOverrides {
+		if o.Prefix == "" || o.MaxValueSize <= 0 {
+			return fmt.Errorf("chunking.max_value_size_overrides need a prefix and a max_value_size > 0")
+		}
+		if overridden[o.Prefix] {
+			return fmt.Errorf("chunking.max_value_size_overrides: duplicate prefix %q", o.Prefix)
+		}
+		overridden[o.Prefix] = true
+	}
+
+	// Validate the proposal size limit: every proposal must fit in one raft message,
+	// and a chunked value writes one segment per proposal
+	if c.Server.Limits.MaxRequestSize <= 0 {
+		return fmt.Errorf("limits.max_request_size must be > 0")
+	}
+	if uint64(c.Server.Limits.MaxRequestSize) > c.Server.Raft.MaxSizePerMsg {
+		return fmt.Errorf("limits.max_request_size must be <= raft.max_size_per_msg")
+	}
+	if int64(c.Server.Chunking.ChunkSize) >= c.Server.Limits.MaxRequestSize {
+		return fmt.Errorf("chunking.chunk_size must be < limits.max_request_size")
+	}
+
+	// Validate usage accounting configuration
+	if c.Server.Usage.PrefixDepth <= 0 {
+		return fmt.Errorf("usage.prefix_depth must be > 0")
+	}
+	if c.Server.Usage.ScanInterval <= 0 {
+		return fmt.Errorf("usage.scan_interval must be > 0")
+	}
+	if c.Server.Usage.MaxPrefixes <= 0 {
+		return fmt.Errorf("usage.max_prefixes must be > 0")
+	}
+	if c.Server.Usage.TopKeys < 0 {
+		return fmt.Errorf("usage.top_keys must be >= 0")
+	}
+
+	// Validate hybrid logical clock configuration
+	if c.Server.HLC.MaxOffset <= 0 {
+		return fmt.Errorf("hlc.max_offset must be > 0")
+	}
 
+	// Validate delete protection configuration
+	if c.Server.DeleteProtection.PurgeInterval <= 0 {
+		return fmt.Errorf("delete_protection.purge_interval must be > 0")
+	}
+	for _, p := range c.Server.DeleteProtection.Prefixes {
+		if p.Prefix == "" {
+			return fmt.Errorf("delete_protection.prefixes[].prefix is required")
+		}
+		if p.Mode != DeleteProtectionDeny && p.Mode != DeleteProtectionTrash {
+			return fmt.Errorf("delete_protection prefix %q: mode must be deny or trash", p.Prefix)
+		}
+		if p.Retention <= 0 {
+			return fmt.Errorf("delete_protection prefix %q: retention must be > 0", p.Prefix)
+		}
+	}
+
+	// Validate admission configuration
+	for _, h := range c.Server.Admission.Hooks {
+		if h.Name == "" {
+			return fmt.Errorf("admission.hooks[].name is required")
+		}
+		for _, f := range h.Frontends {
+			if f != "etcd" && f != "http" && f != "mysql" {
+				return fmt.Errorf("admission hook %q: frontends must be etcd, http or mysql", h.Name)
+			}
+		}
+	}
+
+	// Validate CDC configuration
+	if c.Server.CDC.CursorInterval <= 0 {
+		return fmt.Errorf("cdc.cursor_interval must be > 0")
+	}
+	sinkNames := make(map[string]bool, len(c.Server.CDC.Sinks))
+	for _, s := range c.Server.CDC.Sinks {
+		if s.Name == "" {
+			return fmt.Errorf("cdc.sinks[].name is required")
+		}
+		if sinkNames[s.Name] {
+			return fmt.Errorf("cdc sink %q is defined more than once", s.Name)
+		}
+		sinkNames[s.Name] = true
+		if s.Type != CDCSinkKafka && s.Type != CDCSinkNATS {
+			return fmt.Errorf("cdc sink %q: type must be %q or %q", s.Name, CDCSinkKafka, CDCSinkNATS)
+		}
+		if len(s.Endpoints) == 0 {
+			return fmt.Errorf("cdc sink %q: endpoints are required", s.Name)
+		}
+		if len(s.Routes) == 0 {
+			return fmt.Errorf("cdc sink %q: at least one route is required", s.Name)
+		}
+		for _, r := range s.Routes {
+			if r.Topic == "" {
+				return fmt.Errorf("cdc sink %q: route for prefix %q has no topic", s.Name, r.Prefix)
+			}
+		}
+		if s.BatchSize <= 0 {
+			return fmt.Errorf("cdc sink %q: batch_size must be > 0", s.Name)
+		}
+		if s.PublishTimeout <= 0 {
+			return fmt.Errorf("cdc sink %q: publish_timeout must be > 0", s.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateEncryption validates an encryption key set, prefix names it in errors
+func validateEncryption(prefix string, e *EncryptionConfig) error {
+	if !e.Enabled {
+		return nil
+	}
+	if len(e.Keys) == 0 {
+		return fmt.Errorf("%s.keys is required when encryption is enabled", prefix)
+	}
+	keyIDs := make(map[string]bool, len(e.Keys))
+	for _, k := range e.Keys {
+		if k.ID == "" || len(k.ID) > 255 {
+			return fmt.Errorf("%s.keys[].id is required and must be at most 255 bytes", prefix)
+		}
+		if keyIDs[k.ID] {
+			return fmt.Errorf("%s key %q is defined more than once", prefix, k.ID)
+		}
+		keyIDs[k.ID] = true
+		sources := 0
+		for _, set := range []bool{k.File != "", k.Env != "", len(k.Command) > 0} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("%s key %q: exactly one of file, env or command must be set", prefix, k.ID)
+		}
+	}
+	if !keyIDs[e.ActiveKey] {
+		return fmt.Errorf("%s.active_key %q is not one of %s.keys", prefix, e.ActiveKey, prefix)
+	}
+	return nil
+}
+
+// validateRaftBatch validates batch proposal parameters, prefix names them in errors
+func validateRaftBatch(prefix string, b RaftBatchConfig) error {
+	if b.MinBatchSize <= 0 {
+		return fmt.Errorf("%s.min_batch_size must be > 0", prefix)
+	}
+	if b.MaxBatchSize <= 0 {
+		return fmt.Errorf("%s.max_batch_size must be > 0", prefix)
+	}
+	if b.MinBatchSize > b.MaxBatchSize {
+		return fmt.Errorf("%s.min_batch_size must be <= max_batch_size", prefix)
+	}
+	if b.MinTimeout <= 0 {
+		return fmt.Errorf("%s.min_timeout must be > 0", prefix)
+	}
+	if b.MaxTimeout <= 0 {
+		return fmt.Errorf("%s.max_timeout must be > 0", prefix)
+	}
+	if b.MinTimeout > b.MaxTimeout {
+		return fmt.Errorf("%s.min_timeout must be <= max_timeout", prefix)
+	}
+	if b.LoadThreshold < 0 || b.LoadThreshold > 1 {
+		return fmt.Errorf("%s.load_threshold must be between 0.0 and 1.0", prefix)
+	}
 	return nil
 }
@@ -0,0 +1,182 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables that override config fields. The
+// rest of the name is the field's path below server: in upper case, with dots
+// replaced by underscores: raft.lease_read.clock_drift is
+// METASTORE_RAFT_LEASE_READ_CLOCK_DRIFT.
+//
+// Values are parsed as YAML, so durations are written as in the config file
+// (5s), lists as [a, b] or a, b, and maps as {LeaseRead: false}. String fields
+// take the value as is. Maps are merged into the configured map, other values
+// replace it. Precedence is flags > environment > config file > defaults.
+const EnvPrefix = "METASTORE_"
+
+// legacyEnvNames are environment variables that predate the generated names,
+// they apply unless the generated name is set as well
+var legacyEnvNames = map[string]string{
+	"METASTORE_HISTORY_FILE": "reliability.history_file",
+}
+
+// Override is a config field set from an environment variable or a flag
+type Override struct {
+	Source string // METASTORE_* variable or command line flag
+	Field  string // Path below server:, e.g. raft.election_tick
+	Value  string // Value as given, redacted for secrets
+}
+
+// envField is a config field that can be overridden from the environment
+type envField struct {
+	path  string
+	value reflect.Value
+}
+
+// EnvName returns the environment variable that overrides the field at path
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// EnvNames maps the environment variables of all config fields to the field
+// paths they override
+func EnvNames() map[string]string {
+	var c Config
+	names := make(map[string]string)
+	for _, f := range c.envFields() {
+		names[EnvName(f.path)] = f.path
+	}
+	return names
+}
+
+// OverrideFromEnv sets config fields from METASTORE_* environment variables and
+// records them in Overrides
+func (c *Config) OverrideFromEnv() error {
+	fields := c.envFields()
+	byPath := make(map[string]envField, len(fields))
+	for _, f := range fields {
+		byPath[f.path] = f
+	}
+
+	legacy := make([]string, 0, len(legacyEnvNames))
+	for env := range legacyEnvNames {
+		legacy = append(legacy, env)
+	}
+	sort.Strings(legacy)
+	for _, env := range legacy {
+		path := legacyEnvNames[env]
+		if _, set := os.LookupEnv(EnvName(path)); set {
+			continue
+		}
+		if value, ok := os.LookupEnv(env); ok && value != "" {
+			if err := c.setEnvField(env, byPath[path], value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range fields {
+		env := EnvName(f.path)
+		if value, ok := os.LookupEnv(env); ok && value != "" {
+			if err := c.setEnvField(env, f, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetField sets the field at path from value, parsed like an environment
+// variable, and records source, e.g. a command line flag, as the override
+func (c *Config) SetField(source, path, value string) error {
+	for _, f := range c.envFields() {
+		if f.path == path {
+			return c.setEnvField(source, f, value)
+		}
+	}
+	return fmt.Errorf("%s: unknown config field %s", source, path)
+}
+
+// RecordOverride records that source, e.g. a flag, set the field at path to value
+func (c *Config) RecordOverride(source, path, value string) {
+	c.Overrides = append(c.Overrides, Override{Source: source, Field: path, Value: redact(path, value)})
+}
+
+func (c *Config) setEnvField(source string, f envField, value string) error {
+	if f.value.Kind() == reflect.String {
+		f.value.SetString(value)
+	} else {
+		doc := value
+		if f.value.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+			doc = "[" + value + "]"
+		}
+		if err := yaml.Unmarshal([]byte(doc), f.value.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: invalid value for %s: %w", source, f.path, err)
+		}
+	}
+	c.RecordOverride(source, f.path, value)
+	return nil
+}
+
+// envFields lists the fields of c.Server that can be overridden, in declaration
+// order. Structs are descended into; every other field, including maps and
+// lists of structs, is set as a whole
+func (c *Config) envFields() []envField {
+	var fields []envField
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(sf.Name)
+			}
+			path := prefix + name
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct {
+				walk(fv, path+".")
+				continue
+			}
+			fields = append(fields, envField{path: path, value: fv})
+		}
+	}
+	walk(reflect.ValueOf(&c.Server).Elem(), "")
+	return fields
+}
+
+// redact hides the values of secret fields in logs
+func redact(path, value string) string {
+	name := path[strings.LastIndex(path, ".")+1:]
+	if strings.Contains(name, "password") || strings.Contains(name, "secret") {
+		return "******"
+	}
+	return value
+}
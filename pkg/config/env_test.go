@@ -0,0 +1,133 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestOverrideFromEnv tests that nested fields of every kind can be set from the environment
+func TestOverrideFromEnv(t *testing.T) {
+	t.Setenv("METASTORE_MEMBER_ID", "3")
+	t.Setenv("METASTORE_RAFT_LEASE_READ_CLOCK_DRIFT", "250ms")
+	t.Setenv("METASTORE_RAFT_LEADER_TRANSFER_ON_SHUTDOWN", "false")
+	t.Setenv("METASTORE_RAFT_PLACEMENT_PREFERRED_LEADERS", "1, 2")
+	t.Setenv("METASTORE_MYSQL_PASSWORD", "p#ss: word")
+	t.Setenv("METASTORE_FEATURE_GATES", "{MVCCCompaction: true}")
+	t.Setenv("METASTORE_HISTORY_FILE", "/tmp/history.jsonl")
+
+	cfg := DefaultConfig(1, 1, ":2379")
+	cfg.Server.FeatureGates = map[string]bool{"LeaseRead": false}
+	if err := cfg.OverrideFromEnv(); err != nil {
+		t.Fatalf("OverrideFromEnv failed: %v", err)
+	}
+
+	if cfg.Server.MemberID != 3 {
+		t.Errorf("Expected member_id 3, got %d", cfg.Server.MemberID)
+	}
+	if cfg.Server.Raft.LeaseRead.ClockDrift != 250*time.Millisecond {
+		t.Errorf("Expected clock_drift 250ms, got %s", cfg.Server.Raft.LeaseRead.ClockDrift)
+	}
+	if cfg.Server.Raft.LeaderTransfer.TransferOnShutdown() {
+		t.Error("Expected on_shutdown false")
+	}
+	if got := cfg.Server.Raft.Placement.PreferredLeaders; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("Expected preferred_leaders [1 2], got %v", got)
+	}
+	if cfg.Server.MySQL.Password != "p#ss: word" {
+		t.Errorf("Expected the password as is, got %q", cfg.Server.MySQL.Password)
+	}
+	if gates := cfg.Server.FeatureGates; len(gates) != 2 || !gates["MVCCCompaction"] || gates["LeaseRead"] {
+		t.Errorf("Expected feature gates merged into the configured ones, got %v", gates)
+	}
+	if cfg.Server.Reliability.HistoryFile != "/tmp/history.jsonl" {
+		t.Errorf("Expected history_file from METASTORE_HISTORY_FILE, got %q", cfg.Server.Reliability.HistoryFile)
+	}
+
+	overrides := make(map[string]Override)
+	for _, o := range cfg.Overrides {
+		overrides[o.Field] = o
+	}
+	if len(overrides) != 7 {
+		t.Errorf("Expected 7 overrides, got %+v", cfg.Overrides)
+	}
+	if o := overrides["mysql.password"]; o.Source != "METASTORE_MYSQL_PASSWORD" || o.Value != "******" {
+		t.Errorf("Expected a redacted password override, got %+v", o)
+	}
+	if o := overrides["reliability.history_file"]; o.Source != "METASTORE_HISTORY_FILE" {
+		t.Errorf("Expected the legacy variable as the source, got %+v", o)
+	}
+}
+
+// TestOverrideFromEnvInvalid tests that values that do not parse fail loading
+func TestOverrideFromEnvInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  cluster_id: 1\n  member_id: 1\n  etcd:\n    address: \":2379\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("METASTORE_RAFT_ELECTION_TICK", "ten")
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("Expected an error for an invalid raft.election_tick")
+	}
+}
+
+// TestEnvNamesUnique tests that no two config fields map to the same variable
+func TestEnvNamesUnique(t *testing.T) {
+	var c Config
+	fields := c.envFields()
+	names := EnvNames()
+	if len(names) != len(fields) {
+		seen := make(map[string]string)
+		for _, f := range fields {
+			if other, ok := seen[EnvName(f.path)]; ok {
+				t.Errorf("%s and %s both map to %s", other, f.path, EnvName(f.path))
+			}
+			seen[EnvName(f.path)] = f.path
+		}
+	}
+	if names["METASTORE_ETCD_ADDRESS"] != "etcd.address" {
+		t.Errorf("Expected METASTORE_ETCD_ADDRESS for etcd.address, got %v", names["METASTORE_ETCD_ADDRESS"])
+	}
+}
+
+// TestSetField tests setting fields by path, as the command line flags do
+func TestSetField(t *testing.T) {
+	cfg := DefaultConfig(1, 1, ":2379")
+	if err := cfg.SetField("--mysql-addr", "mysql.address", ":13306"); err != nil {
+		t.Fatalf("SetField failed: %v", err)
+	}
+	if err := cfg.SetField("--metrics-port", "monitoring.prometheus_port", "19090"); err != nil {
+		t.Fatalf("SetField failed: %v", err)
+	}
+	if cfg.Server.MySQL.Address != ":13306" {
+		t.Errorf("Expected mysql.address :13306, got %q", cfg.Server.MySQL.Address)
+	}
+	if cfg.Server.Monitoring.PrometheusPort != 19090 {
+		t.Errorf("Expected prometheus_port 19090, got %d", cfg.Server.Monitoring.PrometheusPort)
+	}
+	if len(cfg.Overrides) != 2 || cfg.Overrides[1].Source != "--metrics-port" {
+		t.Errorf("Expected the flags recorded as overrides, got %+v", cfg.Overrides)
+	}
+
+	if err := cfg.SetField("--metrics-port", "monitoring.prometheus_port", "metrics"); err == nil {
+		t.Error("Expected an error for an invalid port")
+	}
+	if err := cfg.SetField("--unknown", "mysql.adress", ":13306"); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
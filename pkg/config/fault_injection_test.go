@@ -0,0 +1,31 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+// TestFaultInjectionRequiresMetrics tests that /debug/chaos cannot be enabled without the metrics server serving it
+func TestFaultInjectionRequiresMetrics(t *testing.T) {
+	cfg := DefaultConfig(1, 1, ":2379")
+	cfg.Server.Reliability.EnableFaultInjection = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected fault injection with metrics server to pass, got %v", err)
+	}
+
+	cfg.Server.Monitoring.EnablePrometheus = false
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected fault injection without metrics server to fail validation")
+	}
+}
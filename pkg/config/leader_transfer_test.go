@@ -0,0 +1,49 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestLeaderTransferOnShutdown tests that an explicit on_shutdown survives SetDefaults
+func TestLeaderTransferOnShutdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		yamlData string
+		want     bool
+	}{
+		{"Unset", "server:\n  raft:\n    leader_transfer:\n      timeout: 3s\n", true},
+		{"ExplicitTrue", "server:\n  raft:\n    leader_transfer:\n      on_shutdown: true\n", true},
+		{"ExplicitFalse", "server:\n  raft:\n    leader_transfer:\n      on_shutdown: false\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			if err := yaml.Unmarshal([]byte(tt.yamlData), &cfg); err != nil {
+				t.Fatalf("Failed to parse YAML: %v", err)
+			}
+
+			cfg.SetDefaults()
+			cfg.SetDefaults() // defaulting twice must not flip an explicit value
+
+			if got := cfg.Server.Raft.LeaderTransfer.TransferOnShutdown(); got != tt.want {
+				t.Errorf("Expected TransferOnShutdown()=%v, got %v", tt.want, got)
+			}
+		})
+	}
+}
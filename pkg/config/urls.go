@@ -0,0 +1,123 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+)
+
+// ListenAddresses returns the addresses the etcd gRPC server listens on: the
+// hosts of listen_client_urls, or address when none are set
+func (c *EtcdConfig) ListenAddresses() []string {
+	if len(c.ListenClientURLs) == 0 {
+		return []string{c.Address}
+	}
+	return urlHosts(c.ListenClientURLs)
+}
+
+// ListenAddresses returns the addresses the metrics server listens on: the
+// hosts of listen_metrics_urls, or prometheus_port on all interfaces
+func (c *MonitoringConfig) ListenAddresses() []string {
+	if len(c.ListenMetricsURLs) == 0 {
+		return []string{fmt.Sprintf(":%d", c.PrometheusPort)}
+	}
+	return urlHosts(c.ListenMetricsURLs)
+}
+
+// PeerListenAddresses returns the addresses the raft transport listens on: the
+// hosts of listen_peer_urls, or the host of selfURL, this member's URL in the
+// cluster peer list
+func (c *RaftConfig) PeerListenAddresses(selfURL string) ([]string, error) {
+	if len(c.ListenPeerURLs) > 0 {
+		return urlHosts(c.ListenPeerURLs), nil
+	}
+	u, err := url.Parse(selfURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer URL %q: %w", selfURL, err)
+	}
+	return []string{u.Host}, nil
+}
+
+// CheckAdvertisedPeer checks that selfURL, this member's URL in the cluster peer
+// list, is one of advertise_peer_urls. The other members reach this member on
+// its peer list entry, so advertising anything else would be a lie
+func (c *RaftConfig) CheckAdvertisedPeer(selfURL string) error {
+	if len(c.AdvertisePeerURLs) == 0 || slices.Contains(c.AdvertisePeerURLs, selfURL) {
+		return nil
+	}
+	return fmt.Errorf("raft.advertise_peer_urls %v must include %s, the URL of this member in the cluster peer list", c.AdvertisePeerURLs, selfURL)
+}
+
+// urlHosts returns the host:port of validated URLs
+func urlHosts(urls []string) []string {
+	hosts := make([]string, 0, len(urls))
+	for _, raw := range urls {
+		u, _ := url.Parse(raw)
+		hosts = append(hosts, u.Host)
+	}
+	return hosts
+}
+
+// parseMemberURL parses a client, peer or metrics URL: http or https with a
+// port and no path
+func parseMemberURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("URL %q must use http or https", raw)
+	}
+	if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" {
+		return nil, fmt.Errorf("URL %q must include a port", raw)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("URL %q must not have a path", raw)
+	}
+	return u, nil
+}
+
+// validateListenURLs checks URLs to listen on. The host must be an IP address,
+// localhost or empty for all interfaces, so the socket binds what was configured
+func validateListenURLs(field string, urls []string) error {
+	for _, raw := range urls {
+		u, err := parseMemberURL(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if host := u.Hostname(); host != "" && host != "localhost" && net.ParseIP(host) == nil {
+			return fmt.Errorf("%s: URL %q must listen on an IP address, not a host name", field, raw)
+		}
+	}
+	return nil
+}
+
+// validateAdvertiseURLs checks URLs published to clients or members. The host
+// must be one they can connect to, not a wildcard address
+func validateAdvertiseURLs(field string, urls []string) error {
+	for _, raw := range urls {
+		u, err := parseMemberURL(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if ip := net.ParseIP(u.Hostname()); u.Hostname() == "" || (ip != nil && ip.IsUnspecified()) {
+			return fmt.Errorf("%s: URL %q must name a reachable host, not a wildcard address", field, raw)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestListenAddresses tests the addresses listened on with and without listen URLs
+func TestListenAddresses(t *testing.T) {
+	cfg := DefaultConfig(1, 1, ":2379")
+	if got := cfg.Server.Etcd.ListenAddresses(); !slices.Equal(got, []string{":2379"}) {
+		t.Errorf("Expected etcd.address without listen URLs, got %v", got)
+	}
+	if got := cfg.Server.Monitoring.ListenAddresses(); !slices.Equal(got, []string{":9090"}) {
+		t.Errorf("Expected prometheus_port without listen URLs, got %v", got)
+	}
+	got, err := cfg.Server.Raft.PeerListenAddresses("http://node-1.example.com:12380")
+	if err != nil || !slices.Equal(got, []string{"node-1.example.com:12380"}) {
+		t.Errorf("Expected the peer list URL without listen URLs, got %v, %v", got, err)
+	}
+
+	// Behind a port mapping the member listens on other ports than it advertises
+	if err := cfg.SetField("--listen-client-urls", "etcd.listen_client_urls", "http://0.0.0.0:2379,http://127.0.0.1:22379"); err != nil {
+		t.Fatalf("SetField failed: %v", err)
+	}
+	cfg.Server.Etcd.AdvertiseClientURLs = []string{"https://node-1.example.com:32379"}
+	cfg.Server.Raft.ListenPeerURLs = []string{"http://0.0.0.0:2380"}
+	cfg.Server.Raft.AdvertisePeerURLs = []string{"http://node-1.example.com:32380"}
+	cfg.Server.Monitoring.ListenMetricsURLs = []string{"http://localhost:9091"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if got := cfg.Server.Etcd.ListenAddresses(); !slices.Equal(got, []string{"0.0.0.0:2379", "127.0.0.1:22379"}) {
+		t.Errorf("Expected the listen_client_urls hosts, got %v", got)
+	}
+	if got := cfg.Server.Monitoring.ListenAddresses(); !slices.Equal(got, []string{"localhost:9091"}) {
+		t.Errorf("Expected the listen_metrics_urls hosts, got %v", got)
+	}
+	got, err = cfg.Server.Raft.PeerListenAddresses("http://node-1.example.com:32380")
+	if err != nil || !slices.Equal(got, []string{"0.0.0.0:2380"}) {
+		t.Errorf("Expected the listen_peer_urls hosts, got %v, %v", got, err)
+	}
+
+	if err := cfg.Server.Raft.CheckAdvertisedPeer("http://node-1.example.com:32380"); err != nil {
+		t.Errorf("Expected the peer list URL to be advertised, got %v", err)
+	}
+	if err := cfg.Server.Raft.CheckAdvertisedPeer("http://10.0.0.1:2380"); err == nil {
+		t.Error("Expected an error when the peer list URL is not advertised")
+	}
+}
+
+// TestValidateURLs tests the checks of listen and advertise URLs
+func TestValidateURLs(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		err    string
+	}{
+		{"no port", func(c *Config) { c.Server.Etcd.ListenClientURLs = []string{"http://0.0.0.0"} }, "must include a port"},
+		{"scheme", func(c *Config) { c.Server.Raft.ListenPeerURLs = []string{"tcp://0.0.0.0:2380"} }, "must use http or https"},
+		{"path", func(c *Config) { c.Server.Monitoring.ListenMetricsURLs = []string{"http://0.0.0.0:9090/metrics"} }, "must not have a path"},
+		{"listen host name", func(c *Config) { c.Server.Etcd.ListenClientURLs = []string{"http://node-1:2379"} }, "must listen on an IP address"},
+		{"advertise wildcard", func(c *Config) { c.Server.Etcd.AdvertiseClientURLs = []string{"http://0.0.0.0:2379"} }, "etcd.advertise_client_urls"},
+		{"advertise no host", func(c *Config) { c.Server.Raft.AdvertisePeerURLs = []string{"http://:2380"} }, "raft.advertise_peer_urls"},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig(1, 1, ":2379")
+		tt.modify(cfg)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.err, err)
+		}
+	}
+}
@@ -79,6 +79,7 @@ func TestWitnessConfigValidation(t *testing.T) {
 		cfg := DefaultConfig(1, 1, ":2379")
 		cfg.Server.Raft.NodeRole = NodeRoleWitness
 		cfg.SetDefaults()
+		cfg.Server.FeatureGates = map[string]bool{"WitnessMode": true}
 
 		// Force enable LeaseRead (this should fail validation)
 		cfg.Server.Raft.LeaseRead.Enable = true
@@ -93,6 +94,7 @@ func TestWitnessConfigValidation(t *testing.T) {
 		cfg := DefaultConfig(1, 1, ":2379")
 		cfg.Server.Raft.NodeRole = NodeRoleWitness
 		cfg.SetDefaults()
+		cfg.Server.FeatureGates = map[string]bool{"WitnessMode": true}
 
 		err := cfg.Validate()
 		if err != nil {
@@ -100,6 +102,18 @@ func TestWitnessConfigValidation(t *testing.T) {
 		}
 	})
 
+	t.Run("WitnessWithoutFeatureGateShouldFail", func(t *testing.T) {
+		cfg := DefaultConfig(1, 1, ":2379")
+		cfg.Server.Raft.NodeRole = NodeRoleWitness
+		cfg.SetDefaults()
+		// WitnessMode 是 alpha 功能，默认关闭
+
+		err := cfg.Validate()
+		if err == nil {
+			t.Error("Expected validation error for witness with WitnessMode gate disabled")
+		}
+	})
+
 	t.Run("InvalidNodeRoleShouldFail", func(t *testing.T) {
 		cfg := DefaultConfig(1, 1, ":2379")
 		cfg.Server.Raft.NodeRole = "invalid"
@@ -0,0 +1,221 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package datadir 管理节点的数据目录布局
+//
+// Raft 日志、快照和状态机可以放在不同的磁盘上。每次启动时检查目录是否可写，
+// 并把使用的布局记录在数据目录的 layout.json 中；配置的目录与上次启动不同时，
+// 先把原目录中的文件移动到新目录，再打开存储
+package datadir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"metaStore/pkg/log"
+)
+
+// layoutFile 数据目录中记录上次启动使用的布局的文件
+const layoutFile = "layout.json"
+
+// Layout 节点的数据目录
+type Layout struct {
+	Data string `json:"data"` // 状态机
+	WAL  string `json:"wal"`  // Raft 日志
+	Snap string `json:"snap"` // Raft 快照
+}
+
+// Prepare 检查并创建 l 中的目录，目录与上次启动不同时迁移文件
+//
+// 第一次记录布局时，认为上次启动使用的是 defaults（没有单独配置目录时的布局）。
+// isWAL 判断原 WAL 目录中的文件是否属于 Raft 日志，RocksDB 的 WAL 默认与数据文件
+// 在同一目录，只移动 WAL 文件；为 nil 时移动所有文件
+func Prepare(l Layout, defaults Layout, isWAL func(name string) bool) error {
+	if err := os.MkdirAll(l.Data, 0o750); err != nil {
+		return fmt.Errorf("datadir: create data dir: %w", err)
+	}
+
+	prev, err := readLayout(l.Data)
+	if errors.Is(err, os.ErrNotExist) {
+		prev = defaults
+	} else if err != nil {
+		return err
+	}
+
+	if err := migrate("wal", prev.WAL, l.WAL, isWAL); err != nil {
+		return err
+	}
+	if err := migrate("snap", prev.Snap, l.Snap, nil); err != nil {
+		return err
+	}
+
+	for _, dir := range []string{l.WAL, l.Snap} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("datadir: create %s: %w", dir, err)
+		}
+	}
+	for _, dir := range []string{l.Data, l.WAL, l.Snap} {
+		if err := checkWritable(dir); err != nil {
+			return err
+		}
+	}
+	return writeLayout(l)
+}
+
+func readLayout(dir string) (Layout, error) {
+	data, err := os.ReadFile(filepath.Join(dir, layoutFile))
+	if err != nil {
+		return Layout{}, err
+	}
+	var l Layout
+	if err := json.Unmarshal(data, &l); err != nil {
+		return Layout{}, fmt.Errorf("datadir: invalid %s: %w", layoutFile, err)
+	}
+	return l, nil
+}
+
+// writeLayout 原子地写入 layout.json
+func writeLayout(l Layout) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.Data, layoutFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("datadir: write %s: %w", layoutFile, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("datadir: write %s: %w", layoutFile, err)
+	}
+	return nil
+}
+
+// checkWritable 在目录中创建并删除一个文件，启动时尽早发现权限和只读挂载问题
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("datadir: %s is not writable: %w", dir, err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// migrate 把 from 中的文件移动到 to，to 中已有同名文件时拒绝迁移
+func migrate(kind, from, to string, match func(name string) bool) error {
+	if from == "" || filepath.Clean(from) == filepath.Clean(to) {
+		return nil
+	}
+	entries, err := os.ReadDir(from)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("datadir: read previous %s dir: %w", kind, err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.Name() == layoutFile || e.Name() == lockName || (match != nil && !match(e.Name())) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(to, e.Name())); err == nil {
+			return fmt.Errorf("datadir: cannot move %s dir from %s to %s: %s exists in both", kind, from, to, e.Name())
+		}
+		names = append(names, e.Name())
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	log.Info("Moving files to the new directory",
+		log.String("kind", kind),
+		log.String("from", from),
+		log.String("to", to),
+		log.Int("files", len(names)),
+		log.Component("datadir"))
+	if err := os.MkdirAll(to, 0o750); err != nil {
+		return fmt.Errorf("datadir: create %s: %w", to, err)
+	}
+	for _, name := range names {
+		if err := move(filepath.Join(from, name), filepath.Join(to, name)); err != nil {
+			return fmt.Errorf("datadir: move %s to %s: %w", name, to, err)
+		}
+	}
+	if err := syncDir(to); err != nil {
+		return err
+	}
+	// 原目录只在移空后删除，数据目录本身不受影响
+	if match == nil {
+		os.Remove(from)
+	}
+	return nil
+}
+
+// move 重命名文件，跨文件系统时复制后删除
+func move(from, to string) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot copy %s across file systems", info.Mode().Type())
+	}
+	if err := copyFile(from, to, info.Mode().Perm()); err != nil {
+		os.Remove(to)
+		return err
+	}
+	return os.Remove(from)
+}
+
+func copyFile(from, to string, perm os.FileMode) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
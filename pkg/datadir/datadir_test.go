@@ -0,0 +1,86 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0o640))
+	}
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestPrepare_Migrate(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(root, "data")
+	defaults := Layout{Data: data, WAL: data, Snap: filepath.Join(data, "snap")}
+	isWAL := func(name string) bool { return strings.HasSuffix(name, ".log") }
+
+	// 升级前的节点：WAL 与数据文件在同一目录，还没有 layout.json
+	writeFiles(t, data, "000012.log", "000013.sst", "CURRENT")
+	writeFiles(t, defaults.Snap, "0000000000000002-0000000000002711.snap")
+
+	// WAL 和快照移到单独的目录
+	moved := Layout{Data: data, WAL: filepath.Join(root, "nvme", "wal"), Snap: filepath.Join(root, "bulk", "snap")}
+	require.NoError(t, Prepare(moved, defaults, isWAL))
+	assert.Equal(t, []string{"000012.log"}, listFiles(t, moved.WAL))
+	assert.Equal(t, []string{"0000000000000002-0000000000002711.snap"}, listFiles(t, moved.Snap))
+	assert.Equal(t, []string{"000013.sst", "CURRENT", layoutFile}, listFiles(t, data))
+
+	// 布局没有变化时不移动任何文件
+	require.NoError(t, Prepare(moved, defaults, isWAL))
+	assert.Equal(t, []string{"000012.log"}, listFiles(t, moved.WAL))
+
+	// 改回默认布局
+	require.NoError(t, Prepare(defaults, defaults, isWAL))
+	assert.Equal(t, []string{"000012.log", "000013.sst", "CURRENT", layoutFile, "snap"}, listFiles(t, data))
+	assert.Equal(t, []string{"0000000000000002-0000000000002711.snap"}, listFiles(t, defaults.Snap))
+}
+
+func TestPrepare_Conflict(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(root, "data")
+	defaults := Layout{Data: data, WAL: filepath.Join(data, "wal"), Snap: filepath.Join(data, "snap")}
+	writeFiles(t, defaults.WAL, "0000000000000000-0000000000000000.wal")
+
+	// 新目录中已有同名文件时拒绝迁移，原文件保持不动
+	moved := Layout{Data: data, WAL: filepath.Join(root, "wal"), Snap: defaults.Snap}
+	writeFiles(t, moved.WAL, "0000000000000000-0000000000000000.wal")
+	err := Prepare(moved, defaults, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exists in both")
+	assert.Equal(t, []string{"0000000000000000-0000000000000000.wal"}, listFiles(t, defaults.WAL))
+}
@@ -0,0 +1,144 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datadir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockName 数据目录中记录持有者的成员锁文件
+const lockName = "member.lock"
+
+var (
+	// ErrLocked 数据目录正被另一个进程使用
+	ErrLocked = errors.New("datadir: data dir is in use")
+	// ErrOtherMember 数据目录属于另一个成员
+	ErrOtherMember = errors.New("datadir: data dir belongs to another member")
+)
+
+// Owner 使用数据目录的成员
+type Owner struct {
+	ClusterID uint64    `json:"cluster_id"`
+	MemberID  uint64    `json:"member_id"`
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Started   time.Time `json:"started"`
+}
+
+func (o Owner) String() string {
+	return fmt.Sprintf("member %d of cluster %d (pid %d on %s, started %s)",
+		o.MemberID, o.ClusterID, o.PID, o.Host, o.Started.Format(time.RFC3339))
+}
+
+// Lock 数据目录上的成员锁，进程退出时由操作系统释放
+type Lock struct {
+	f *os.File
+}
+
+// Acquire 锁定数据目录，防止两个进程同时打开同一个数据目录破坏 raft 状态
+//
+// 目录已被锁定时返回 ErrLocked，错误信息中包含持有锁的成员和进程。锁文件记录
+// 上次使用目录的成员，集群或成员 ID 不同时返回 ErrOtherMember：两个成员共用
+// 一份 raft 日志同样会破坏集群。owner 的 PID、Host 和 Started 为空时自动填写
+func Acquire(dir string, owner Owner) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("datadir: create data dir: %w", err)
+	}
+	path := filepath.Join(dir, lockName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("datadir: open %s: %w", lockName, err)
+	}
+
+	locked := tryLock(f)
+	prev, hasPrev := readOwner(f)
+	if errors.Is(locked, errWouldBlock) {
+		f.Close()
+		holder := "another process"
+		if hasPrev {
+			holder = prev.String()
+		}
+		return nil, fmt.Errorf("%w: %s is locked by %s; stop it or give this member its own data dir", ErrLocked, dir, holder)
+	} else if locked != nil {
+		f.Close()
+		return nil, fmt.Errorf("datadir: lock %s: %w", path, locked)
+	}
+	if hasPrev && (prev.ClusterID != owner.ClusterID || prev.MemberID != owner.MemberID) {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s was last used by member %d of cluster %d, not member %d of cluster %d; check --member-id and --cluster-id",
+			ErrOtherMember, dir, prev.MemberID, prev.ClusterID, owner.MemberID, owner.ClusterID)
+	}
+
+	if owner.PID == 0 {
+		owner.PID = os.Getpid()
+	}
+	if owner.Host == "" {
+		owner.Host, _ = os.Hostname()
+	}
+	if owner.Started.IsZero() {
+		owner.Started = time.Now()
+	}
+	if err := writeOwner(f, owner); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("datadir: write %s: %w", lockName, err)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release 释放锁，锁文件保留，下次启动时用于检查成员身份
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// readOwner 读取锁文件中记录的成员，文件为空或无法解析时返回 false
+func readOwner(f *os.File) (Owner, bool) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return Owner{}, false
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || len(data) == 0 {
+		return Owner{}, false
+	}
+	var o Owner
+	if err := json.Unmarshal(data, &o); err != nil {
+		return Owner{}, false
+	}
+	return o, true
+}
+
+func writeOwner(f *os.File, o Owner) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
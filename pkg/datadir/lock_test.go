@@ -0,0 +1,57 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	member := Owner{ClusterID: 1, MemberID: 2}
+
+	lock, err := Acquire(dir, member)
+	require.NoError(t, err)
+
+	// 同一个数据目录不能被第二个进程打开，错误中包含持有者
+	_, err = Acquire(dir, member)
+	require.ErrorIs(t, err, ErrLocked)
+	assert.Contains(t, err.Error(), "member 2 of cluster 1")
+	assert.Contains(t, err.Error(), "pid")
+
+	require.NoError(t, lock.Release())
+	lock, err = Acquire(dir, member)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+
+	// 锁文件在释放后保留，另一个成员不能使用这个数据目录
+	_, err = Acquire(dir, Owner{ClusterID: 1, MemberID: 3})
+	require.ErrorIs(t, err, ErrOtherMember)
+	_, err = Acquire(dir, Owner{ClusterID: 7, MemberID: 2})
+	require.ErrorIs(t, err, ErrOtherMember)
+
+	// 无法解析的锁文件被覆盖
+	require.NoError(t, os.WriteFile(filepath.Join(dir, lockName), []byte("garbage"), 0o640))
+	lock, err = Acquire(dir, Owner{ClusterID: 1, MemberID: 3})
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
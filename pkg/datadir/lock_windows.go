@@ -0,0 +1,31 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package datadir
+
+import (
+	"errors"
+	"os"
+)
+
+// errWouldBlock 文件已被其他进程锁定
+var errWouldBlock = errors.New("file is locked")
+
+// tryLock Windows 上尚未实现文件锁，只检查成员身份
+func tryLock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption 实现 value 的静态加密（AES-256-GCM 信封加密）
+//
+// 每次加密生成随机的数据密钥（DEK）加密数据，再用密钥加密密钥（KEK）加密 DEK，
+// 两者一起保存在密文头部。KEK 来自配置（文件、环境变量或调用 KMS 的外部命令），
+// 以 ID 区分，密文记录所用 KEK 的 ID，因此轮换 KEK 后旧数据仍可解密，
+// 新写入总是使用当前激活的 KEK
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+const (
+	// KeySize KEK 和 DEK 的长度（AES-256）
+	KeySize = 32
+
+	nonceSize      = 12
+	wrappedDEKSize = KeySize + 16 // DEK 密文 + GCM tag
+)
+
+// magic 密文前缀，用于识别加密数据
+var magic = []byte("MSE1")
+
+var (
+	// ErrUnknownKey 密文使用的 KEK 不在 keyring 中
+	ErrUnknownKey = errors.New("encryption: unknown key")
+	// ErrNotEncrypted 数据不是本包生成的密文
+	ErrNotEncrypted = errors.New("encryption: data is not encrypted")
+	// ErrNoKeyring 遇到密文但未配置加密
+	ErrNoKeyring = errors.New("encryption: encrypted data found but encryption is not configured")
+)
+
+// Keyring 一组 KEK 及当前激活的 KEK
+type Keyring struct {
+	keks   map[string]cipher.AEAD
+	active string
+}
+
+// NewKeyring 用 ID 到 KEK 的映射创建 keyring，新数据使用 active 对应的 KEK 加密
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("encryption: active key %q is not configured", active)
+	}
+	k := &Keyring{keks: make(map[string]cipher.AEAD, len(keys)), active: active}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("encryption: key id must be 1-255 bytes")
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("encryption: key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keks[id] = aead
+	}
+	return k, nil
+}
+
+// ActiveKeyID 返回当前激活的 KEK ID
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// KeyIDs 返回所有 KEK ID
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keks))
+	for id := range k.keks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt 用激活的 KEK 加密 plaintext，aad 为附加认证数据（例如 key 名），
+// 解密时必须提供相同的 aad，防止密文被挪到其他 key 下
+//
+// 格式：magic(4) | idLen(1) | id | nonce(12) | wrapped DEK(48) | nonce(12) | ciphertext
+func (k *Keyring) Encrypt(plaintext, aad []byte) ([]byte, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	dekAEAD, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	id := k.active
+	out := make([]byte, 0, len(magic)+1+len(id)+2*nonceSize+wrappedDEKSize+len(plaintext)+16)
+	out = append(out, magic...)
+	out = append(out, byte(len(id)))
+	out = append(out, id...)
+
+	// 用 KEK 加密 DEK，KEK ID 作为附加数据
+	kekNonce, err := randomNonce()
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, kekNonce...)
+	out = k.keks[id].Seal(out, kekNonce, dek, []byte(id))
+
+	dataNonce, err := randomNonce()
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, dataNonce...)
+	return dekAEAD.Seal(out, dataNonce, plaintext, aad), nil
+}
+
+// Decrypt 解密 Encrypt 生成的密文
+func (k *Keyring) Decrypt(data, aad []byte) ([]byte, error) {
+	id, rest, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	kek, ok := k.keks[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	if len(rest) < 2*nonceSize+wrappedDEKSize+16 {
+		return nil, fmt.Errorf("encryption: ciphertext is truncated")
+	}
+
+	kekNonce, rest := rest[:nonceSize], rest[nonceSize:]
+	wrapped, rest := rest[:wrappedDEKSize], rest[wrappedDEKSize:]
+	dek, err := kek.Open(nil, kekNonce, wrapped, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: failed to unwrap data key with key %q: %w", id, err)
+	}
+	dekAEAD, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	dataNonce, ciphertext := rest[:nonceSize], rest[nonceSize:]
+	plaintext, err := dekAEAD.Open(nil, dataNonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: failed to decrypt: %w", err)
+	}
+	if plaintext == nil {
+		plaintext = []byte{}
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted 判断 data 是否是本包生成的密文
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// KeyID 返回密文使用的 KEK ID
+func KeyID(data []byte) (string, error) {
+	id, _, err := parseHeader(data)
+	return id, err
+}
+
+func parseHeader(data []byte) (string, []byte, error) {
+	if !IsEncrypted(data) || len(data) < len(magic)+1 {
+		return "", nil, ErrNotEncrypted
+	}
+	rest := data[len(magic):]
+	n := int(rest[0])
+	if len(rest) < 1+n {
+		return "", nil, fmt.Errorf("encryption: ciphertext header is truncated")
+	}
+	return string(rest[1 : 1+n]), rest[1+n:], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomNonce() ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	_, err := rand.Read(nonce)
+	return nonce, err
+}
+
+// current 进程内生效的 keyring，为 nil 表示不加密
+var current atomic.Pointer[Keyring]
+
+// SetKeyring 设置存储引擎使用的 keyring，必须在打开存储之前调用；nil 表示不加密
+func SetKeyring(k *Keyring) {
+	current.Store(k)
+}
+
+// Current 返回存储引擎使用的 keyring，未启用加密时返回 nil
+func Current() *Keyring {
+	return current.Load()
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k1, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+
+	sealed, err := k1.Encrypt([]byte("secret value"), []byte("key/a"))
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.NotContains(t, string(sealed), "secret value")
+	id, err := KeyID(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", id)
+
+	plain, err := k1.Decrypt(sealed, []byte("key/a"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret value", string(plain))
+
+	// 密文与 key 名绑定，挪到其他 key 下无法解密
+	_, err = k1.Decrypt(sealed, []byte("key/b"))
+	assert.Error(t, err)
+
+	// 每次加密使用新的 DEK 和 nonce
+	again, err := k1.Encrypt([]byte("secret value"), []byte("key/a"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	empty, err := k1.Encrypt(nil, nil)
+	require.NoError(t, err)
+	plain, err = k1.Decrypt(empty, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, plain)
+	assert.Empty(t, plain)
+
+	// 轮换后旧密文仍可解密，新密文使用新 KEK
+	k2, err := NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)})
+	require.NoError(t, err)
+	plain, err = k2.Decrypt(sealed, []byte("key/a"))
+	require.NoError(t, err)
+	assert.Equal(t, "secret value", string(plain))
+	rotated, err := k2.Encrypt(plain, []byte("key/a"))
+	require.NoError(t, err)
+	id, _ = KeyID(rotated)
+	assert.Equal(t, "k2", id)
+
+	// 删除旧 KEK 后无法解密旧密文
+	only2, err := NewKeyring("k2", map[string][]byte{"k2": testKey(2)})
+	require.NoError(t, err)
+	_, err = only2.Decrypt(sealed, []byte("key/a"))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// KEK 相同 ID 不同内容时无法解开 DEK
+	wrong, err := NewKeyring("k1", map[string][]byte{"k1": testKey(9)})
+	require.NoError(t, err)
+	_, err = wrong.Decrypt(sealed, []byte("key/a"))
+	assert.Error(t, err)
+
+	_, err = k1.Decrypt([]byte("plain"), nil)
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+	_, err = k1.Decrypt(sealed[:len(sealed)-20], []byte("key/a"))
+	assert.Error(t, err)
+}
+
+func TestNewKeyringRejects(t *testing.T) {
+	_, err := NewKeyring("missing", map[string][]byte{"k1": testKey(1)})
+	assert.Error(t, err)
+	_, err = NewKeyring("k1", map[string][]byte{"k1": []byte("short")})
+	assert.Error(t, err)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	rawFile := filepath.Join(dir, "raw.key")
+	require.NoError(t, os.WriteFile(rawFile, testKey(1), 0600))
+	t.Setenv("TEST_METASTORE_KEK", base64.StdEncoding.EncodeToString(testKey(2)))
+
+	k, err := LoadKeyring(&config.EncryptionConfig{
+		Enabled:   true,
+		ActiveKey: "cmd",
+		Keys: []config.EncryptionKey{
+			{ID: "file", File: rawFile},
+			{ID: "env", Env: "TEST_METASTORE_KEK"},
+			{ID: "cmd", Command: []string{"echo", hex.EncodeToString(testKey(3))}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cmd", k.ActiveKeyID())
+	assert.Equal(t, []string{"cmd", "env", "file"}, k.KeyIDs())
+
+	k, err = LoadKeyring(&config.EncryptionConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, k)
+
+	_, err = LoadKeyring(&config.EncryptionConfig{
+		Enabled:   true,
+		ActiveKey: "bad",
+		Keys:      []config.EncryptionKey{{ID: "bad", Env: "TEST_METASTORE_KEK_UNSET"}},
+	})
+	assert.Error(t, err)
+	_, err = LoadKeyring(&config.EncryptionConfig{
+		Enabled:   true,
+		ActiveKey: "bad",
+		Keys:      []config.EncryptionKey{{ID: "bad", Command: []string{"false"}}},
+	})
+	assert.Error(t, err)
+}
+
+func TestRotation(t *testing.T) {
+	var r Rotation
+	assert.ErrorIs(t, r.Start(func(func(int64, int64)) error { return nil }), ErrDisabled)
+	assert.False(t, r.Status().Enabled)
+
+	k, err := NewKeyring("k1", map[string][]byte{"k1": testKey(1)})
+	require.NoError(t, err)
+	SetKeyring(k)
+	defer SetKeyring(nil)
+
+	release := make(chan struct{})
+	require.NoError(t, r.Start(func(progress func(int64, int64)) error {
+		progress(10, 3)
+		<-release
+		progress(5, 1)
+		return nil
+	}))
+	assert.ErrorIs(t, r.Start(func(func(int64, int64)) error { return nil }), ErrRotationRunning)
+	close(release)
+
+	require.Eventually(t, func() bool { return !r.Status().Running }, time.Second, 5*time.Millisecond)
+	st := r.Status()
+	assert.True(t, st.Enabled)
+	assert.Equal(t, "k1", st.ActiveKey)
+	assert.EqualValues(t, 15, st.Scanned)
+	assert.EqualValues(t, 4, st.Reencrypted)
+	assert.Empty(t, st.Error)
+	assert.False(t, st.FinishedAt.IsZero())
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"metaStore/pkg/config"
+)
+
+// commandTimeout 获取 KEK 的外部命令的超时时间
+const commandTimeout = 30 * time.Second
+
+// LoadKeyring 按配置加载 KEK，未启用加密时返回 nil
+func LoadKeyring(cfg *config.EncryptionConfig) (*Keyring, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	keys := make(map[string][]byte, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		material, err := readKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: key %q: %w", k.ID, err)
+		}
+		keys[k.ID] = material
+	}
+	return NewKeyring(cfg.ActiveKey, keys)
+}
+
+// readKey 读取 KEK，支持原始 32 字节、base64 或 hex 编码
+func readKey(k config.EncryptionKey) ([]byte, error) {
+	var raw []byte
+	switch {
+	case k.File != "":
+		data, err := os.ReadFile(k.File)
+		if err != nil {
+			return nil, err
+		}
+		raw = data
+	case k.Env != "":
+		value, ok := os.LookupEnv(k.Env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", k.Env)
+		}
+		raw = []byte(value)
+	case len(k.Command) > 0:
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, k.Command[0], k.Command[1:]...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("command %q failed: %v: %s", k.Command[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		raw = out
+	default:
+		return nil, fmt.Errorf("no key source configured")
+	}
+	return decodeKey(raw)
+}
+
+func decodeKey(raw []byte) ([]byte, error) {
+	if len(raw) == KeySize {
+		return raw, nil
+	}
+	text := string(bytes.TrimSpace(raw))
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key must be %d bytes, raw or base64/hex encoded", KeySize)
+}
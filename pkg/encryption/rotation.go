@@ -0,0 +1,101 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRotationRunning 已有重新加密任务在运行
+	ErrRotationRunning = errors.New("encryption: key rotation is already running")
+	// ErrDisabled 未启用加密
+	ErrDisabled = errors.New("encryption: encryption is not enabled")
+)
+
+// RotationStatus 重新加密任务的进度
+type RotationStatus struct {
+	Enabled     bool      `json:"enabled"`
+	ActiveKey   string    `json:"active_key,omitempty"`
+	Running     bool      `json:"running"`
+	Scanned     int64     `json:"scanned"`     // 已检查的记录数
+	Reencrypted int64     `json:"reencrypted"` // 用激活 KEK 重新加密的记录数
+	StartedAt   time.Time `json:"started_at,omitempty"`
+	FinishedAt  time.Time `json:"finished_at,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Rotation 记录本节点最近一次重新加密任务，零值可用
+//
+// 轮换时先在所有节点的配置中加入新 KEK 并设为 active_key，此后新写入使用新 KEK，
+// 旧数据在下次写入时重新加密；重新加密任务把剩余的旧数据一次性改用新 KEK，
+// 完成后才能从配置中删除旧 KEK
+type Rotation struct {
+	mu     sync.Mutex
+	status RotationStatus
+}
+
+// Start 在后台运行 run，progress 用于报告进度
+func (r *Rotation) Start(run func(progress func(scanned, reencrypted int64)) error) error {
+	k := Current()
+	if k == nil {
+		return ErrDisabled
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.Running {
+		return ErrRotationRunning
+	}
+	r.status = RotationStatus{
+		Enabled:   true,
+		ActiveKey: k.ActiveKeyID(),
+		Running:   true,
+		StartedAt: time.Now(),
+	}
+
+	go func() {
+		err := run(func(scanned, reencrypted int64) {
+			r.mu.Lock()
+			r.status.Scanned += scanned
+			r.status.Reencrypted += reencrypted
+			r.mu.Unlock()
+		})
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.status.Running = false
+		r.status.FinishedAt = time.Now()
+		if err != nil {
+			r.status.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// Status 返回最近一次任务的进度
+func (r *Rotation) Status() RotationStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	if k := Current(); k != nil {
+		status.Enabled = true
+		if !status.Running {
+			status.ActiveKey = k.ActiveKeyID()
+		}
+	}
+	return status
+}
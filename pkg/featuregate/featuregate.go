@@ -0,0 +1,148 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package featuregate 实现实验性功能的开关
+//
+// 每个功能有一个成熟度阶段和默认值。Alpha 默认关闭，Beta 默认打开，GA 总是打开且不能
+// 关闭。运维可以在配置文件的 server.feature_gates 或命令行 --feature-gates 中按环境
+// 打开或关闭功能，代码通过 Gate.Enabled 选择路径，两条路径都可以单独测试
+package featuregate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Feature 功能名称
+type Feature string
+
+const (
+	// LeaseRead leader 在租约有效期内直接提供线性一致读，不经过 ReadIndex
+	LeaseRead Feature = "LeaseRead"
+	// BatchApply 把一次 commit 中的操作合并应用，关闭时逐个应用
+	// 只作用于内存引擎，RocksDB 引擎总是把一次 commit 连同 applied index 写入同一个 WriteBatch
+	BatchApply Feature = "BatchApply"
+	// WitnessMode 允许以 witness 角色（只投票不存数据）启动节点
+	WitnessMode Feature = "WitnessMode"
+	// MVCCCompaction 按集群设置 compaction.retention 定期压缩历史版本
+	MVCCCompaction Feature = "MVCCCompaction"
+)
+
+// Stage 功能的成熟度
+type Stage string
+
+const (
+	Alpha Stage = "alpha"
+	Beta  Stage = "beta"
+	GA    Stage = "ga"
+)
+
+// Spec 功能的默认值和成熟度
+type Spec struct {
+	Default     bool
+	Stage       Stage
+	Description string
+}
+
+// known 所有功能。新功能以 Alpha 加入，稳定后提升阶段
+var known = map[Feature]Spec{
+	LeaseRead:      {Default: true, Stage: Beta, Description: "serve linearizable reads from the leader lease"},
+	BatchApply:     {Default: true, Stage: Beta, Description: "apply the operations of a commit as one batch"},
+	WitnessMode:    {Default: false, Stage: Alpha, Description: "allow starting nodes with node_role witness"},
+	MVCCCompaction: {Default: false, Stage: Alpha, Description: "periodically compact history per the compaction.retention setting"},
+}
+
+// Status 一个功能的当前状态，用于指标和日志
+type Status struct {
+	Name    Feature
+	Stage   Stage
+	Default bool
+	Enabled bool
+}
+
+// Gate 一组功能开关，创建后不再修改，可以并发读取
+type Gate struct {
+	enabled map[Feature]bool
+}
+
+// New 返回在默认值上应用 overrides 的开关。未知的功能和关闭 GA 功能返回错误
+func New(overrides map[string]bool) (*Gate, error) {
+	g := &Gate{enabled: make(map[Feature]bool, len(known))}
+	for f, spec := range known {
+		g.enabled[f] = spec.Default
+	}
+	for name, on := range overrides {
+		f := Feature(name)
+		spec, ok := known[f]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature gate %q, known gates: %s", name, strings.Join(names(), ", "))
+		}
+		if spec.Stage == GA && !on {
+			return nil, fmt.Errorf("feature gate %s is GA and cannot be disabled", name)
+		}
+		g.enabled[f] = on
+	}
+	return g, nil
+}
+
+// Enabled 返回功能是否打开。nil Gate 使用默认值
+func (g *Gate) Enabled(f Feature) bool {
+	if g == nil {
+		return known[f].Default
+	}
+	return g.enabled[f]
+}
+
+// Statuses 返回所有功能的状态，按名称排序
+func (g *Gate) Statuses() []Status {
+	statuses := make([]Status, 0, len(known))
+	for _, name := range names() {
+		f := Feature(name)
+		spec := known[f]
+		statuses = append(statuses, Status{Name: f, Stage: spec.Stage, Default: spec.Default, Enabled: g.Enabled(f)})
+	}
+	return statuses
+}
+
+// ParseList 解析 "LeaseRead=false,MVCCCompaction=true" 形式的列表
+func ParseList(s string) (map[string]bool, error) {
+	gates := map[string]bool{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid feature gate %q, expected Name=true|false", item)
+		}
+		on, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for feature gate %s: %q", name, value)
+		}
+		gates[strings.TrimSpace(name)] = on
+	}
+	return gates, nil
+}
+
+func names() []string {
+	list := make([]string, 0, len(known))
+	for f := range known {
+		list = append(list, string(f))
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featuregate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	g, err := New(nil)
+	require.NoError(t, err)
+	assert.True(t, g.Enabled(LeaseRead))
+	assert.False(t, g.Enabled(MVCCCompaction))
+
+	g, err = New(map[string]bool{"LeaseRead": false, "MVCCCompaction": true})
+	require.NoError(t, err)
+	assert.False(t, g.Enabled(LeaseRead))
+	assert.True(t, g.Enabled(MVCCCompaction))
+	assert.True(t, g.Enabled(BatchApply))
+
+	_, err = New(map[string]bool{"NoSuchFeature": true})
+	assert.ErrorContains(t, err, "unknown feature gate")
+
+	// nil Gate 使用默认值
+	var nilGate *Gate
+	assert.False(t, nilGate.Enabled(WitnessMode))
+	assert.False(t, nilGate.Enabled(MVCCCompaction))
+}
+
+func TestGADisable(t *testing.T) {
+	known["TestGA"] = Spec{Default: true, Stage: GA}
+	defer delete(known, "TestGA")
+
+	_, err := New(map[string]bool{"TestGA": false})
+	assert.ErrorContains(t, err, "cannot be disabled")
+	_, err = New(map[string]bool{"TestGA": true})
+	assert.NoError(t, err)
+}
+
+func TestStatuses(t *testing.T) {
+	g, err := New(map[string]bool{"BatchApply": false})
+	require.NoError(t, err)
+	statuses := g.Statuses()
+	require.Len(t, statuses, len(known))
+	assert.Equal(t, BatchApply, statuses[0].Name)
+	assert.False(t, statuses[0].Enabled)
+	assert.True(t, statuses[0].Default)
+	assert.Equal(t, Beta, statuses[0].Stage)
+}
+
+func TestParseList(t *testing.T) {
+	gates, err := ParseList(" LeaseRead=false, MVCCCompaction=true ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"LeaseRead": false, "MVCCCompaction": true}, gates)
+
+	gates, err = ParseList("")
+	require.NoError(t, err)
+	assert.Empty(t, gates)
+
+	_, err = ParseList("LeaseRead")
+	assert.Error(t, err)
+	_, err = ParseList("LeaseRead=maybe")
+	assert.Error(t, err)
+}
@@ -0,0 +1,248 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// pending is the return time of operations whose outcome is unknown
+const pending = math.MaxInt64
+
+// operation is an invoke paired with its completion
+type operation struct {
+	f     Func
+	in    interface{}
+	out   interface{}
+	call  int64
+	ret   int64
+	index int64
+}
+
+// register is the sequential model of a single key
+type register struct {
+	set bool
+	val string
+}
+
+// CheckLinearizable verifies that the operations on every key can be ordered
+// into a sequential register history consistent with real time. Failed
+// operations are ignored, and indeterminate writes may or may not take effect.
+// It returns nil when the history is linearizable.
+func CheckLinearizable(events []Event) error {
+	byKey, err := pairOps(events)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !linearizable(byKey[key]) {
+			return fmt.Errorf("history of key %q is not linearizable (%d operations)", key, len(byKey[key]))
+		}
+	}
+	return nil
+}
+
+// pairOps matches completions to invocations and groups operations by key
+func pairOps(events []Event) (map[string][]*operation, error) {
+	type procID struct {
+		node    string
+		process int64
+	}
+	open := make(map[procID]*operation)
+	keyOf := make(map[*operation]string)
+
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time < sorted[j].Time })
+
+	for _, ev := range sorted {
+		id := procID{ev.Node, ev.Process}
+		if ev.Type == TypeInvoke {
+			if _, busy := open[id]; busy {
+				return nil, fmt.Errorf("process %s/%d invoked twice without completing", ev.Node, ev.Process)
+			}
+			op := &operation{f: ev.F, in: ev.Value, call: ev.Time, ret: pending, index: ev.Index}
+			open[id] = op
+			keyOf[op] = ev.Key
+			continue
+		}
+
+		op, ok := open[id]
+		if !ok {
+			return nil, fmt.Errorf("event %d completes process %s/%d which has no invocation", ev.Index, ev.Node, ev.Process)
+		}
+		delete(open, id)
+
+		switch ev.Type {
+		case TypeOk:
+			op.ret = ev.Time
+			op.out = ev.Value
+		case TypeFail:
+			delete(keyOf, op)
+		case TypeInfo:
+			// 结果未知，可能生效也可能未生效
+		default:
+			return nil, fmt.Errorf("event %d has unknown type %q", ev.Index, ev.Type)
+		}
+	}
+
+	byKey := make(map[string][]*operation)
+	for op, key := range keyOf {
+		// 未完成的读对状态没有影响
+		if op.f == FuncRead && op.ret == pending {
+			continue
+		}
+		byKey[key] = append(byKey[key], op)
+	}
+	for _, ops := range byKey {
+		sort.Slice(ops, func(i, j int) bool { return ops[i].call < ops[j].call })
+	}
+	return byKey, nil
+}
+
+// step applies op to the register and reports whether the observed output is
+// consistent with the current state
+func (r register) step(op *operation) (register, bool) {
+	switch op.f {
+	case FuncRead:
+		v, ok := asString(op.out)
+		if ok != r.set || v != r.val {
+			return r, false
+		}
+		return r, true
+	case FuncWrite:
+		v, _ := asString(op.in)
+		return register{set: true, val: v}, true
+	case FuncDelete:
+		return register{}, true
+	case FuncCAS:
+		expect, next, ok := asPair(op.in)
+		if !ok || !r.set || r.val != expect {
+			return r, false
+		}
+		return register{set: true, val: next}, true
+	default:
+		return r, false
+	}
+}
+
+// linearizable searches for a valid order with memoized depth-first search
+// (Wing & Gong with the Lowe cache). At each step only operations invoked
+// before the earliest outstanding return may be linearized next.
+func linearizable(ops []*operation) bool {
+	n := len(ops)
+	done := make([]uint64, (n+63)/64)
+	remaining := 0
+	for _, op := range ops {
+		if op.ret != pending {
+			remaining++
+		}
+	}
+	seen := make(map[string]struct{})
+
+	var search func(state register, remaining int) bool
+	search = func(state register, remaining int) bool {
+		if remaining == 0 {
+			return true
+		}
+		k := cacheKey(done, state)
+		if _, ok := seen[k]; ok {
+			return false
+		}
+		seen[k] = struct{}{}
+
+		minRet := int64(pending)
+		for i, op := range ops {
+			if done[i/64]&(1<<(i%64)) == 0 && op.ret < minRet {
+				minRet = op.ret
+			}
+		}
+
+		for i, op := range ops {
+			if op.call > minRet {
+				break
+			}
+			if done[i/64]&(1<<(i%64)) != 0 {
+				continue
+			}
+			next, ok := state.step(op)
+			if !ok {
+				continue
+			}
+			done[i/64] |= 1 << (i % 64)
+			left := remaining
+			if op.ret != pending {
+				left--
+			}
+			if search(next, left) {
+				return true
+			}
+			done[i/64] &^= 1 << (i % 64)
+		}
+		return false
+	}
+
+	return search(register{}, remaining)
+}
+
+func cacheKey(done []uint64, state register) string {
+	var b strings.Builder
+	var buf [8]byte
+	for _, w := range done {
+		binary.LittleEndian.PutUint64(buf[:], w)
+		b.Write(buf[:])
+	}
+	if state.set {
+		b.WriteByte(1)
+		b.WriteString(state.val)
+	} else {
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func asString(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	return s, ok
+}
+
+// asPair decodes a cas value, which is []string when recorded in-process and
+// []interface{} after a round trip through JSON
+func asPair(v interface{}) (string, string, bool) {
+	switch p := v.(type) {
+	case []string:
+		if len(p) == 2 {
+			return p[0], p[1], true
+		}
+	case []interface{}:
+		if len(p) == 2 {
+			a, ok1 := asString(p[0])
+			b, ok2 := asString(p[1])
+			return a, b, ok1 && ok2
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history records Jepsen-style operation histories and checks them for
+// linearizability. Histories are written as JSON lines using the field names of
+// Jepsen histories (type, f, value, process, time), so they can be converted for
+// porcupine or elle with a trivial script.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Type is the event type of a history entry
+type Type string
+
+const (
+	// TypeInvoke marks the start of an operation
+	TypeInvoke Type = "invoke"
+	// TypeOk means the operation definitely took effect
+	TypeOk Type = "ok"
+	// TypeFail means the operation definitely did not take effect
+	TypeFail Type = "fail"
+	// TypeInfo means the outcome is unknown (e.g. a timed out write)
+	TypeInfo Type = "info"
+)
+
+// Func is the operation kind of a history entry
+type Func string
+
+const (
+	FuncRead   Func = "read"   // value: nil on invoke, string or nil (not found) on ok
+	FuncWrite  Func = "write"  // value: the written string
+	FuncDelete Func = "delete" // value: nil
+	FuncCAS    Func = "cas"    // value: [expected, new]
+)
+
+// Event is one line of a history file
+type Event struct {
+	Index   int64       `json:"index"`
+	Type    Type        `json:"type"`
+	F       Func        `json:"f"`
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value"`
+	Process int64       `json:"process"`
+	Node    string      `json:"node,omitempty"` // frontend that served the operation
+	Time    int64       `json:"time"`           // unix nanoseconds
+	Error   string      `json:"error,omitempty"`
+}
+
+// Recorder appends events to a history file. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	index int64
+
+	process atomic.Int64
+}
+
+// NewRecorder creates (or truncates) the history file at path
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	return &Recorder{
+		file: f,
+		w:    w,
+		enc:  json.NewEncoder(w),
+	}, nil
+}
+
+// Op is an operation that has been invoked but not yet completed
+type Op struct {
+	r   *Recorder
+	inv Event
+}
+
+// Invoke records the start of an operation. Every invocation gets its own
+// process id, so a process never has more than one outstanding operation.
+func (r *Recorder) Invoke(node string, f Func, key string, value interface{}) *Op {
+	inv := Event{
+		Type:    TypeInvoke,
+		F:       f,
+		Key:     key,
+		Value:   value,
+		Process: r.process.Add(1),
+		Node:    node,
+	}
+	r.append(&inv)
+	return &Op{r: r, inv: inv}
+}
+
+// Ok records a successful completion with the observed value
+func (op *Op) Ok(value interface{}) {
+	op.complete(TypeOk, value, nil)
+}
+
+// Fail records that the operation did not take effect
+func (op *Op) Fail(err error) {
+	op.complete(TypeFail, op.inv.Value, err)
+}
+
+// Info records that the outcome of the operation is unknown
+func (op *Op) Info(err error) {
+	op.complete(TypeInfo, op.inv.Value, err)
+}
+
+// Complete records ok when err is nil. Failed reads have no effect and are
+// recorded as fail; any other error is indeterminate and recorded as info.
+func (op *Op) Complete(value interface{}, err error) {
+	switch {
+	case err == nil:
+		op.Ok(value)
+	case op.inv.F == FuncRead:
+		op.Fail(err)
+	default:
+		op.Info(err)
+	}
+}
+
+func (op *Op) complete(typ Type, value interface{}, err error) {
+	ev := op.inv
+	ev.Type = typ
+	ev.Value = value
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	op.r.append(&ev)
+}
+
+// append stamps and writes one event. Each line is flushed immediately so the
+// history survives a crash of the recording process.
+func (r *Recorder) append(ev *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return
+	}
+	r.index++
+	ev.Index = r.index
+	ev.Time = time.Now().UnixNano()
+	if err := r.enc.Encode(ev); err == nil {
+		r.w.Flush()
+	}
+}
+
+// Close flushes and closes the history file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
+
+// ReadFile loads a history written by a Recorder
+func ReadFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var ev Event
+		if err := dec.Decode(&ev); err != nil {
+			return nil, fmt.Errorf("failed to decode history event %d: %w", len(events)+1, err)
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ev builds an event at a logical time
+func ev(t int64, typ Type, process int64, f Func, value interface{}) Event {
+	return Event{Index: t, Type: typ, F: f, Key: "k", Value: value, Process: process, Time: t}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	rec, err := NewRecorder(path)
+	require.NoError(t, err)
+
+	rec.Invoke("etcd", FuncWrite, "k", "1").Complete("1", nil)
+	rec.Invoke("etcd", FuncCAS, "k", []string{"1", "2"}).Ok([]string{"1", "2"})
+	rec.Invoke("http", FuncRead, "k", nil).Complete(nil, errors.New("timeout"))
+	rec.Invoke("http", FuncWrite, "k", "3").Complete(nil, errors.New("timeout"))
+	require.NoError(t, rec.Close())
+
+	events, err := ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 8)
+
+	assert.Equal(t, TypeInvoke, events[0].Type)
+	assert.Equal(t, TypeOk, events[1].Type)
+	assert.Equal(t, TypeFail, events[5].Type, "failed reads are definite")
+	assert.Equal(t, TypeInfo, events[7].Type, "failed writes are indeterminate")
+	assert.Equal(t, "timeout", events[7].Error)
+	for i, e := range events {
+		assert.Equal(t, int64(i+1), e.Index)
+	}
+
+	assert.NoError(t, CheckLinearizable(events))
+}
+
+func TestCheckLinearizable(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		ok     bool
+	}{
+		{
+			name: "concurrent read may see either value",
+			events: []Event{
+				ev(1, TypeInvoke, 1, FuncWrite, "a"),
+				ev(2, TypeOk, 1, FuncWrite, "a"),
+				ev(3, TypeInvoke, 2, FuncWrite, "b"),
+				ev(4, TypeInvoke, 3, FuncRead, nil),
+				ev(5, TypeOk, 3, FuncRead, "a"),
+				ev(6, TypeOk, 2, FuncWrite, "b"),
+			},
+			ok: true,
+		},
+		{
+			name: "stale read after completed write",
+			events: []Event{
+				ev(1, TypeInvoke, 1, FuncWrite, "a"),
+				ev(2, TypeOk, 1, FuncWrite, "a"),
+				ev(3, TypeInvoke, 2, FuncWrite, "b"),
+				ev(4, TypeOk, 2, FuncWrite, "b"),
+				ev(5, TypeInvoke, 3, FuncRead, nil),
+				ev(6, TypeOk, 3, FuncRead, "a"),
+			},
+			ok: false,
+		},
+		{
+			name: "indeterminate write may take effect later",
+			events: []Event{
+				ev(1, TypeInvoke, 1, FuncWrite, "a"),
+				ev(2, TypeInfo, 1, FuncWrite, "a"),
+				ev(3, TypeInvoke, 2, FuncRead, nil),
+				ev(4, TypeOk, 2, FuncRead, nil),
+				ev(5, TypeInvoke, 3, FuncRead, nil),
+				ev(6, TypeOk, 3, FuncRead, "a"),
+			},
+			ok: true,
+		},
+		{
+			name: "failed write never takes effect",
+			events: []Event{
+				ev(1, TypeInvoke, 1, FuncWrite, "a"),
+				ev(2, TypeFail, 1, FuncWrite, "a"),
+				ev(3, TypeInvoke, 2, FuncRead, nil),
+				ev(4, TypeOk, 2, FuncRead, "a"),
+			},
+			ok: false,
+		},
+		{
+			name: "cas and delete",
+			events: []Event{
+				ev(1, TypeInvoke, 1, FuncWrite, "a"),
+				ev(2, TypeOk, 1, FuncWrite, "a"),
+				ev(3, TypeInvoke, 2, FuncCAS, []interface{}{"a", "b"}),
+				ev(4, TypeOk, 2, FuncCAS, []interface{}{"a", "b"}),
+				ev(5, TypeInvoke, 3, FuncCAS, []interface{}{"a", "c"}),
+				ev(6, TypeOk, 3, FuncCAS, []interface{}{"a", "c"}),
+			},
+			ok: false,
+		},
+		{
+			name: "read of deleted key",
+			events: []Event{
+				ev(1, TypeInvoke, 1, FuncWrite, "a"),
+				ev(2, TypeOk, 1, FuncWrite, "a"),
+				ev(3, TypeInvoke, 2, FuncDelete, nil),
+				ev(4, TypeOk, 2, FuncDelete, nil),
+				ev(5, TypeInvoke, 3, FuncRead, nil),
+				ev(6, TypeOk, 3, FuncRead, nil),
+			},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckLinearizable(tt.events)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckLinearizableMalformed(t *testing.T) {
+	err := CheckLinearizable([]Event{ev(1, TypeOk, 1, FuncWrite, "a")})
+	assert.Error(t, err)
+}
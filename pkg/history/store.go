@@ -0,0 +1,120 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"bytes"
+	"context"
+
+	"metaStore/internal/kvstore"
+)
+
+// recordingStore records single-key register operations served by a frontend.
+// Range scans, multi-key deletes and general transactions are passed through
+// unrecorded, so a history is only checkable when clients restrict themselves
+// to get/put/delete and compare-and-swap on single keys.
+type recordingStore struct {
+	kvstore.Store
+	rec  *Recorder
+	node string
+}
+
+// Wrap returns a store that records operations into rec under the given
+// frontend name. A nil recorder returns the store unchanged.
+func Wrap(store kvstore.Store, rec *Recorder, node string) kvstore.Store {
+	if rec == nil {
+		return store
+	}
+	return &recordingStore{Store: store, rec: rec, node: node}
+}
+
+// Unwrap returns the wrapped store so callers can reach capabilities beyond kvstore.Store.
+func (s *recordingStore) Unwrap() kvstore.Store {
+	return s.Store
+}
+
+// RangeFunc passes range scans through unrecorded, keeping them streamed.
+func (s *recordingStore) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.RangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// ReverseRangeFunc passes descending range scans through the same way.
+func (s *recordingStore) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.ReverseRangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+func (s *recordingStore) Range(ctx context.Context, key, rangeEnd string, limit int64, revision int64) (*kvstore.RangeResponse, error) {
+	if rangeEnd != "" || revision != 0 {
+		return s.Store.Range(ctx, key, rangeEnd, limit, revision)
+	}
+
+	op := s.rec.Invoke(s.node, FuncRead, key, nil)
+	resp, err := s.Store.Range(ctx, key, rangeEnd, limit, revision)
+	var value interface{}
+	if err == nil && len(resp.Kvs) > 0 {
+		value = string(resp.Kvs[0].Value)
+	}
+	op.Complete(value, err)
+	return resp, err
+}
+
+func (s *recordingStore) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	op := s.rec.Invoke(s.node, FuncWrite, key, value)
+	rev, prevKv, err := s.Store.PutWithLease(ctx, key, value, leaseID)
+	op.Complete(value, err)
+	return rev, prevKv, err
+}
+
+func (s *recordingStore) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	if rangeEnd != "" {
+		return s.Store.DeleteRange(ctx, key, rangeEnd)
+	}
+
+	op := s.rec.Invoke(s.node, FuncDelete, key, nil)
+	deleted, prevKvs, rev, err := s.Store.DeleteRange(ctx, key, rangeEnd)
+	op.Complete(nil, err)
+	return deleted, prevKvs, rev, err
+}
+
+func (s *recordingStore) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+	key, value, ok := casOf(cmps, thenOps, elseOps)
+	if !ok {
+		return s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	}
+
+	op := s.rec.Invoke(s.node, FuncCAS, key, value)
+	resp, err := s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	if err == nil && !resp.Succeeded {
+		op.Fail(nil)
+	} else {
+		op.Complete(value, err)
+	}
+	return resp, err
+}
+
+// casOf recognizes "if value(k) == old then put(k, new)" transactions
+func casOf(cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (string, []string, bool) {
+	if len(cmps) != 1 || len(thenOps) != 1 || len(elseOps) != 0 {
+		return "", nil, false
+	}
+	cmp, put := cmps[0], thenOps[0]
+	if cmp.Target != kvstore.CompareValue || cmp.Result != kvstore.CompareEqual {
+		return "", nil, false
+	}
+	if put.Type != kvstore.OpPut || !bytes.Equal(cmp.Key, put.Key) {
+		return "", nil, false
+	}
+	return string(cmp.Key), []string{string(cmp.TargetUnion.Value), string(put.Value)}, true
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hlc 实现混合逻辑时钟 (Hybrid Logical Clock)
+//
+// 每个提交的操作带一个 HLC 时间戳：提案节点用本地时钟生成，apply 时按提交顺序调整为
+// 严格递增，所有副本得到相同的值。revision 只在一个集群内有序，HLC 接近物理时间且
+// 满足因果关系，消费 CDC/watch 的外部系统可以用它合并多个集群的事件。
+// 客户端把从一个集群读到的 HLC 带给另一个集群时，后者之后提交的写入一定排在它后面
+package hlc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// logicalBits 逻辑计数占用的低位数
+const logicalBits = 16
+
+// DefaultMaxOffset 默认允许的最大时钟偏差
+const DefaultMaxOffset = 500 * time.Millisecond
+
+// ErrClockOffset 收到的时间戳超前本地物理时钟超过允许的最大偏差
+var ErrClockOffset = errors.New("hlc: clock offset exceeds the maximum")
+
+// Timestamp HLC 时间戳，高 48 位是 Unix 毫秒，低 16 位是逻辑计数
+//
+// 按数值比较即按因果顺序比较，0 表示没有时间戳
+type Timestamp uint64
+
+// New 由物理时间和逻辑计数构造时间戳
+func New(wall time.Time, logical uint16) Timestamp {
+	return Timestamp(uint64(wall.UnixMilli())<<logicalBits | uint64(logical))
+}
+
+// WallTime 返回时间戳的物理时间部分
+func (t Timestamp) WallTime() time.Time {
+	return time.UnixMilli(int64(t >> logicalBits))
+}
+
+// Logical 返回时间戳的逻辑计数部分
+func (t Timestamp) Logical() uint16 {
+	return uint16(t)
+}
+
+// Next 返回紧接 t 之后的时间戳，逻辑计数溢出时进位到物理时间
+func (t Timestamp) Next() Timestamp {
+	return t + 1
+}
+
+// String 格式为 "<Unix 毫秒>.<逻辑计数>"
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%d.%d", uint64(t>>logicalBits), t.Logical())
+}
+
+// Parse 解析 String 的输出，也接受 uint64 形式的原始值
+func Parse(s string) (Timestamp, error) {
+	wall, logical, ok := strings.Cut(s, ".")
+	if !ok {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("hlc: invalid timestamp %q", s)
+		}
+		return Timestamp(v), nil
+	}
+	w, err := strconv.ParseUint(wall, 10, 64-logicalBits)
+	if err != nil {
+		return 0, fmt.Errorf("hlc: invalid timestamp %q", s)
+	}
+	l, err := strconv.ParseUint(logical, 10, logicalBits)
+	if err != nil {
+		return 0, fmt.Errorf("hlc: invalid timestamp %q", s)
+	}
+	return Timestamp(w<<logicalBits | l), nil
+}
+
+// Stats 时钟偏差统计
+type Stats struct {
+	MaxOffset time.Duration // 允许的最大偏差，0 表示不限制
+	LastSkew  time.Duration // 最近一次收到的时间戳超前本地物理时钟的量，落后时为负
+	MaxSkew   time.Duration // 启动以来收到的时间戳超前本地物理时钟的最大量
+	Rejected  uint64        // 因超过最大偏差而没有合并的时间戳数
+}
+
+// Clock 本节点的 HLC，并发安全
+type Clock struct {
+	physical  func() time.Time
+	maxOffset time.Duration
+
+	mu   sync.Mutex
+	last Timestamp
+
+	lastSkew atomic.Int64
+	maxSkew  atomic.Int64
+	rejected atomic.Uint64
+}
+
+// NewClock 创建时钟，maxOffset 为 0 时不限制偏差
+func NewClock(maxOffset time.Duration) *Clock {
+	return &Clock{physical: time.Now, maxOffset: maxOffset}
+}
+
+// Now 返回一个大于之前所有 Now 和已合并时间戳的新时间戳
+func (c *Clock) Now() Timestamp {
+	wall := New(c.physical(), 0)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if wall > c.last {
+		c.last = wall
+	} else {
+		c.last = c.last.Next()
+	}
+	return c.last
+}
+
+// Update 合并收到的时间戳，之后 Now 返回的时间戳都大于它
+//
+// 超前本地物理时钟超过最大偏差的时间戳不合并，返回 ErrClockOffset，
+// 避免一个时钟错误的节点或客户端把整个集群的 HLC 推向未来
+func (c *Clock) Update(remote Timestamp) error {
+	if remote == 0 {
+		return nil
+	}
+	now := c.physical()
+	skew := remote.WallTime().Sub(now.Truncate(time.Millisecond))
+	c.lastSkew.Store(int64(skew))
+	for {
+		old := c.maxSkew.Load()
+		if int64(skew) <= old || c.maxSkew.CompareAndSwap(old, int64(skew)) {
+			break
+		}
+	}
+	if c.maxOffset > 0 && skew > c.maxOffset {
+		c.rejected.Add(1)
+		return fmt.Errorf("%w: %s is %v ahead of the local clock (max %v)", ErrClockOffset, remote, skew, c.maxOffset)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if remote > c.last {
+		c.last = remote
+	}
+	return nil
+}
+
+// MaxOffset 返回允许的最大偏差
+func (c *Clock) MaxOffset() time.Duration {
+	return c.maxOffset
+}
+
+// Stats 返回偏差统计
+func (c *Clock) Stats() Stats {
+	return Stats{
+		MaxOffset: c.maxOffset,
+		LastSkew:  time.Duration(c.lastSkew.Load()),
+		MaxSkew:   time.Duration(c.maxSkew.Load()),
+		Rejected:  c.rejected.Load(),
+	}
+}
+
+// Sequencer 为状态机按提交顺序分配 HLC，零值可用
+//
+// 提案节点在提案时生成时间戳，并发的提案提交顺序可能与时间戳顺序不同，Commit 把
+// 时间戳调整为严格递增。所有副本以相同顺序调用 Commit，得到相同的结果
+type Sequencer struct {
+	last atomic.Uint64
+}
+
+// Commit 返回提交的操作的时间戳：不小于 proposed，且大于之前提交的操作
+// proposed 为 0（旧版本节点的提案）时返回 0，不影响之后的时间戳
+func (s *Sequencer) Commit(proposed Timestamp) Timestamp {
+	if proposed == 0 {
+		return 0
+	}
+	for {
+		last := Timestamp(s.last.Load())
+		ts := proposed
+		if ts <= last {
+			ts = last.Next()
+		}
+		if s.last.CompareAndSwap(uint64(last), uint64(ts)) {
+			return ts
+		}
+	}
+}
+
+// Last 返回最后提交的时间戳
+func (s *Sequencer) Last() Timestamp {
+	return Timestamp(s.last.Load())
+}
+
+// Restore 从快照恢复最后提交的时间戳
+func (s *Sequencer) Restore(last Timestamp) {
+	s.last.Store(uint64(last))
+}
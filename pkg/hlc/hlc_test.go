@@ -0,0 +1,107 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hlc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClock(maxOffset time.Duration, now *time.Time) *Clock {
+	c := NewClock(maxOffset)
+	c.physical = func() time.Time { return *now }
+	return c
+}
+
+func TestTimestampString(t *testing.T) {
+	wall := time.UnixMilli(1700000000123)
+	ts := New(wall, 7)
+	assert.Equal(t, wall, ts.WallTime())
+	assert.Equal(t, uint16(7), ts.Logical())
+	assert.Equal(t, "1700000000123.7", ts.String())
+
+	parsed, err := Parse(ts.String())
+	require.NoError(t, err)
+	assert.Equal(t, ts, parsed)
+
+	parsed, err = Parse("123456789")
+	require.NoError(t, err)
+	assert.Equal(t, Timestamp(123456789), parsed)
+
+	for _, s := range []string{"", "abc", "1.70000", "1.x"} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestClockNow(t *testing.T) {
+	now := time.UnixMilli(1000)
+	c := newTestClock(0, &now)
+
+	a := c.Now()
+	assert.Equal(t, New(now, 0), a)
+	b := c.Now()
+	assert.Equal(t, New(now, 1), b, "same millisecond advances the logical counter")
+
+	// 物理时钟回拨时仍然单调递增
+	now = time.UnixMilli(900)
+	assert.Greater(t, c.Now(), b)
+
+	now = time.UnixMilli(2000)
+	assert.Equal(t, New(now, 0), c.Now())
+}
+
+func TestClockUpdate(t *testing.T) {
+	now := time.UnixMilli(10_000)
+	c := newTestClock(500*time.Millisecond, &now)
+
+	remote := New(time.UnixMilli(10_300), 4)
+	require.NoError(t, c.Update(remote))
+	assert.Greater(t, c.Now(), remote, "timestamps after a merge follow it")
+	assert.Equal(t, 300*time.Millisecond, c.Stats().LastSkew)
+
+	// 超过最大偏差的时间戳不合并
+	far := New(time.UnixMilli(20_000), 0)
+	err := c.Update(far)
+	assert.True(t, errors.Is(err, ErrClockOffset))
+	assert.Less(t, c.Now(), far)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Rejected)
+	assert.Equal(t, 10*time.Second, stats.MaxSkew)
+	assert.Equal(t, 500*time.Millisecond, stats.MaxOffset)
+
+	// 落后的时间戳只记录偏差
+	require.NoError(t, c.Update(New(time.UnixMilli(9_000), 0)))
+	assert.Equal(t, -time.Second, c.Stats().LastSkew)
+}
+
+func TestSequencer(t *testing.T) {
+	var s Sequencer
+	assert.Equal(t, Timestamp(0), s.Commit(0), "proposals without a timestamp stay unstamped")
+
+	assert.Equal(t, Timestamp(100), s.Commit(100))
+	assert.Equal(t, Timestamp(101), s.Commit(50), "an older proposal committed later still orders after")
+	assert.Equal(t, Timestamp(101), s.Last())
+	assert.Equal(t, Timestamp(200), s.Commit(200))
+
+	var replica Sequencer
+	replica.Restore(101)
+	assert.Equal(t, Timestamp(200), replica.Commit(200), "a restored replica assigns the same timestamps")
+}
@@ -0,0 +1,250 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ListMembers 返回集群成员，复制进度只在 leader 上有值
+func (c *Client) ListMembers(ctx context.Context) ([]MemberStatus, error) {
+	return c.listMembers(ctx)
+}
+
+// ReplaceMember 替换故障成员，每次进度变化时调用 report（可以为 nil），返回最后的进度。
+// 替换失败时同时返回进度和错误；取消 ctx 会中止替换，用相同的请求重新调用即可从中断处继续
+func (c *Client) ReplaceMember(ctx context.Context, req MemberReplaceRequest, report func(MemberReplaceProgress)) (*MemberReplaceProgress, error) {
+	resp, err := c.replaceMember(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var last *MemberReplaceProgress
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var p MemberReplaceProgress
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return last, err
+		}
+		last = &p
+		if report != nil {
+			report(p)
+		}
+	}
+	switch {
+	case last == nil:
+		return nil, errors.New("member replacement ended without progress")
+	case last.Phase == "failed":
+		return last, fmt.Errorf("member replacement failed: %s", last.Error)
+	case last.Phase != "done":
+		return last, fmt.Errorf("member replacement stopped in phase %s", last.Phase)
+	}
+	return last, nil
+}
+
+// MemberReplaceStatus 返回最近一次替换的进度，没有执行过替换时返回 404 错误
+func (c *Client) MemberReplaceStatus(ctx context.Context) (*MemberReplaceProgress, error) {
+	return c.memberReplaceStatus(ctx)
+}
+
+// RaftLog 解码本节点的 raft 日志，只有 rocksdb 引擎支持
+func (c *Client) RaftLog(ctx context.Context, req RaftLogRequest) (*RaftLog, error) {
+	params := raftLogParams{Redact: req.Redact}
+	if req.From > 0 {
+		params.From = &req.From
+	}
+	if req.Limit > 0 {
+		params.Limit = &req.Limit
+	}
+	return c.raftLog(ctx, params)
+}
+
+// GetPlacement 返回集群中设置的 leader 放置策略，没有设置时返回 404 错误
+func (c *Client) GetPlacement(ctx context.Context) (*PlacementPolicy, error) {
+	return c.getPlacement(ctx)
+}
+
+// SetPlacement 设置 leader 放置策略
+func (c *Client) SetPlacement(ctx context.Context, policy PlacementPolicy) (*PlacementPolicy, error) {
+	return c.setPlacement(ctx, policy)
+}
+
+// ResetPlacement 删除放置策略，各节点恢复使用配置文件中的值
+func (c *Client) ResetPlacement(ctx context.Context) error {
+	_, err := c.resetPlacement(ctx)
+	return err
+}
+
+// DrainStatus 返回本节点 gRPC 客户端的排空进度
+func (c *Client) DrainStatus(ctx context.Context) (*DrainStatus, error) {
+	return c.drainStatus(ctx)
+}
+
+// StartDrain 在后台开始排空，timeout 为 0 时使用节点的 reliability.drain_timeout。
+// 已经在排空中时返回 409 错误
+func (c *Client) StartDrain(ctx context.Context, timeout time.Duration) (*DrainStatus, error) {
+	var params startDrainParams
+	if timeout > 0 {
+		params.Timeout = timeout.String()
+	}
+	return c.startDrain(ctx, params)
+}
+
+// ListMirrors 返回所有 mirror 的状态
+func (c *Client) ListMirrors(ctx context.Context) ([]MirrorStatus, error) {
+	return c.listMirrors(ctx)
+}
+
+// StartMirror 启动 mirror
+func (c *Client) StartMirror(ctx context.Context, name string) error {
+	_, err := c.startMirror(ctx, name)
+	return err
+}
+
+// StopMirror 停止 mirror，停止前保存 checkpoint
+func (c *Client) StopMirror(ctx context.Context, name string) error {
+	_, err := c.stopMirror(ctx, name)
+	return err
+}
+
+// EncryptionStatus 返回本节点激活的 KEK 和最近一次重新加密的进度
+func (c *Client) EncryptionStatus(ctx context.Context) (*RotationStatus, error) {
+	return c.encryptionStatus(ctx)
+}
+
+// RotateKeys 在后台用激活的 KEK 重新加密本节点的旧数据
+func (c *Client) RotateKeys(ctx context.Context) (*RotationStatus, error) {
+	return c.rotateKeys(ctx)
+}
+
+// ListSchemas 返回所有注册的 JSON Schema
+func (c *Client) ListSchemas(ctx context.Context) ([]SchemaEntry, error) {
+	return c.listSchemas(ctx)
+}
+
+// GetSchema 返回前缀上的 schema 文档
+func (c *Client) GetSchema(ctx context.Context, prefix string) (json.RawMessage, error) {
+	return c.getSchema(ctx, prefix)
+}
+
+// PutSchema 在前缀上注册或替换 schema
+func (c *Client) PutSchema(ctx context.Context, prefix string, doc []byte) error {
+	_, err := c.putSchema(ctx, prefix, doc)
+	return err
+}
+
+// DeleteSchema 删除前缀上的 schema
+func (c *Client) DeleteSchema(ctx context.Context, prefix string) error {
+	_, err := c.deleteSchema(ctx, prefix)
+	return err
+}
+
+// ListSettings 返回所有设置的定义和集群中的值
+func (c *Client) ListSettings(ctx context.Context) ([]SettingStatus, error) {
+	return c.listSettings(ctx)
+}
+
+// GetSetting 返回集群中设置的值，没有设置时返回 404 错误
+func (c *Client) GetSetting(ctx context.Context, name string) (*Setting, error) {
+	return c.getSetting(ctx, name)
+}
+
+// SetSetting 修改设置。version 不小于 0 时只有设置的当前版本等于它才修改（0 表示尚未设置），
+// 否则返回 409 错误（见 IsConflict）；version 为 -1 时无条件修改
+func (c *Client) SetSetting(ctx context.Context, name, value string, version int64) (*Setting, error) {
+	return c.setSetting(ctx, name, setSettingParams{Version: settingVersion(version)}, value)
+}
+
+// ResetSetting 删除设置，各节点恢复使用配置文件中的值；version 的含义与 SetSetting 相同
+func (c *Client) ResetSetting(ctx context.Context, name string, version int64) error {
+	_, err := c.resetSetting(ctx, name, resetSettingParams{Version: settingVersion(version)})
+	return err
+}
+
+// settingVersion 返回 ?version=，version 小于 0 时不发送
+func settingVersion(version int64) *int64 {
+	if version < 0 {
+		return nil
+	}
+	return &version
+}
+
+// ListTrash 列出原 key 以 prefix 开头的回收站条目（不含 value），prefix 为空时列出全部
+func (c *Client) ListTrash(ctx context.Context, prefix string) ([]TrashEntry, error) {
+	raw, err := c.listTrash(ctx, listTrashParams{Prefix: prefix})
+	if err != nil {
+		return nil, err
+	}
+	var out []TrashEntry
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTrashEntry 返回 key 的回收站条目（含 value），与 ListTrash 是同一个接口
+func (c *Client) GetTrashEntry(ctx context.Context, key string) (*TrashEntry, error) {
+	raw, err := c.listTrash(ctx, listTrashParams{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	var out TrashEntry
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DiscardTrash 立即删除回收站条目
+func (c *Client) DiscardTrash(ctx context.Context, r TrashRange) error {
+	_, err := c.discardTrash(ctx, discardTrashParams{Key: r.Key, Prefix: r.prefix()})
+	return err
+}
+
+// RestoreTrash 恢复被删除的 key。原 key 已经存在的条目被跳过，此时同时返回结果和 409 错误；
+// overwrite 为 true 时覆盖
+func (c *Client) RestoreTrash(ctx context.Context, r TrashRange, overwrite bool) (*TrashRestoreResponse, error) {
+	out, err := c.restoreTrash(ctx, restoreTrashParams{Key: r.Key, Prefix: r.prefix(), Overwrite: overwrite})
+	if err != nil {
+		return nil, err
+	}
+	if out.Error != "" {
+		return out, &Error{StatusCode: http.StatusConflict, Message: out.Error}
+	}
+	return out, nil
+}
+
+// Usage 返回本节点最近一次用量扫描的结果，limit 大于 0 时只返回字节数最大的 limit 个前缀
+func (c *Client) Usage(ctx context.Context, limit int) (*UsageReport, error) {
+	var params usageParams
+	if limit > 0 {
+		params.Limit = &limit
+	}
+	return c.usage(ctx, params)
+}
+
+// ScanUsage 立即扫描一次并返回结果
+func (c *Client) ScanUsage(ctx context.Context) (*UsageReport, error) {
+	return c.scanUsage(ctx)
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpclient MetaStore HTTP API 的 Go 客户端
+//
+// 接口由 api/http/openapi.json 描述，服务端在 /openapi.json 提供同一份文档。
+// api.gen.go 由文档生成（go generate ./api/http），包括 schema 对应的类型和每个
+// operationId 同名的未导出方法，负责路径、参数、状态码和响应的解码；
+// Client 导出的方法（首字母大写）在其上处理 key 编码、事件流等文档之外的部分。
+// api/http 的测试检查生成的代码是最新的，且每个 operationId 都有导出的方法
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/pkg/keycodec"
+)
+
+// 与 api/http 相同的请求和响应头
+const (
+	keyEncodingHeader = "X-MetaStore-Key-Encoding"
+	hlcHeader         = "X-MetaStore-HLC"
+	commitIndexHeader = "X-MetaStore-Commit-Index"
+	minIndexHeader    = "X-MetaStore-Min-Index"
+)
+
+// Config 客户端配置
+type Config struct {
+	Endpoint    string         // 成员的 HTTP API 地址，如 "http://127.0.0.1:9121"
+	Token       string         // 可选，/auth/login 返回的 token，Login 成功后自动设置
+	KeyEncoding keycodec.Codec // key 在请求中的编码，raw 以外的编码可以传输任意字节的 key
+	HTTPClient  *http.Client   // 可选，为 nil 时使用 http.DefaultClient
+	Timeout     time.Duration  // 可选，写请求的 ?timeout=，0 表示使用服务端的 limits.request_timeout
+}
+
+// Client MetaStore HTTP API 客户端，可以并发使用
+type Client struct {
+	endpoint string
+	codec    keycodec.Codec
+	hc       *http.Client
+	timeout  time.Duration
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Error 服务端返回的错误状态
+type Error struct {
+	StatusCode int
+	Message    string        // 响应体，服务端以纯文本返回错误原因
+	RetryAfter time.Duration // 429 和 503 的 Retry-After
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound 判断 err 是否为 404
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsConflict 判断 err 是否为 409，例如设置的版本不匹配
+func IsConflict(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+func statusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// New 创建客户端
+func New(cfg Config) *Client {
+	c := &Client{
+		endpoint: strings.TrimRight(cfg.Endpoint, "/"),
+		codec:    cfg.KeyEncoding,
+		hc:       cfg.HTTPClient,
+		timeout:  cfg.Timeout,
+		token:    cfg.Token,
+	}
+	if c.hc == nil {
+		c.hc = http.DefaultClient
+	}
+	return c
+}
+
+// SetToken 设置之后请求使用的 token
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+}
+
+// request 一个 API 请求，由生成的方法构造
+type request struct {
+	method   string
+	path     string // 已转义的路径
+	query    url.Values
+	header   http.Header
+	body     io.Reader
+	bodyType string
+}
+
+// send 发送请求，状态码不在 ok 中时返回 *Error 并关闭响应体
+func (c *Client) send(ctx context.Context, req request, ok ...int) (*http.Response, error) {
+	u := c.endpoint + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	hr, err := http.NewRequestWithContext(ctx, req.method, u, req.body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.header {
+		hr.Header[k] = v
+	}
+	if req.bodyType != "" {
+		hr.Header.Set("Content-Type", req.bodyType)
+	}
+	if hr.Header.Get(keyEncodingHeader) == "" && c.codec != keycodec.Raw {
+		hr.Header.Set(keyEncodingHeader, c.codec.String())
+	}
+	c.mu.RLock()
+	token := c.token
+	c.mu.RUnlock()
+	if token != "" {
+		hr.Header.Set("Authorization", token)
+	}
+
+	resp, err := c.hc.Do(hr)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	return nil, responseError(resp)
+}
+
+// responseError 把错误响应转换为 *Error
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	e := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			e.RetryAfter = time.Duration(n) * time.Second
+		}
+	}
+	return e
+}
+
+// call 发送请求并把 JSON 响应解码到 out
+func (c *Client) call(ctx context.Context, req request, out interface{}, ok ...int) error {
+	resp, err := c.send(ctx, req, ok...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// empty 发送没有响应体的请求，返回响应头
+func (c *Client) empty(ctx context.Context, req request, ok ...int) (http.Header, error) {
+	resp, err := c.send(ctx, req, ok...)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+// text 发送请求并返回纯文本的响应体
+func (c *Client) text(ctx context.Context, req request, ok ...int) (string, error) {
+	resp, err := c.send(ctx, req, ok...)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	return string(out), err
+}
+
+// jsonBody 编码请求体
+func jsonBody(v interface{}) (io.Reader, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// writeTimeout 返回写请求的 ?timeout=，为空时使用服务端的 limits.request_timeout
+func (c *Client) writeTimeout() string {
+	if c.timeout <= 0 {
+		return ""
+	}
+	return c.timeout.String()
+}
+
+// writeResult 从写请求的响应头读取 token 和 HLC
+func writeResult(header http.Header) WriteResult {
+	idx, _ := strconv.ParseUint(header.Get(commitIndexHeader), 10, 64)
+	return WriteResult{CommitIndex: idx, HLC: header.Get(hlcHeader)}
+}
+
+// GetKey 读取 key 的值，key 不存在时返回 404 错误（见 IsNotFound）。
+// minIndex 为其他节点上写入返回的 CommitIndex 时，本节点先应用到该位置再读取，0 表示不等待
+func (c *Client) GetKey(ctx context.Context, key string, minIndex uint64) ([]byte, error) {
+	var params getKeyParams
+	if minIndex > 0 {
+		params.MinIndex = &minIndex
+	}
+	resp, err := c.getKey(ctx, c.codec.Encode([]byte(key)), params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// PutKey 写入 key
+func (c *Client) PutKey(ctx context.Context, key string, value []byte) (WriteResult, error) {
+	header, err := c.putKey(ctx, c.codec.Encode([]byte(key)), putKeyParams{Timeout: c.writeTimeout()}, bytes.NewReader(value))
+	if err != nil {
+		return WriteResult{}, err
+	}
+	return writeResult(header), nil
+}
+
+// DeleteKey 删除 key。raw 编码下纯数字的路径表示成员 ID，这样的 key 改用 hex 编码发送
+func (c *Client) DeleteKey(ctx context.Context, key string) (WriteResult, error) {
+	codec := c.codec
+	if _, err := strconv.ParseUint(key, 0, 64); err == nil && codec == keycodec.Raw {
+		codec = keycodec.Hex
+	}
+	header, err := c.deleteKey(ctx, codec.Encode([]byte(key)), deleteKeyParams{
+		KeyEncoding: codec.String(),
+		Timeout:     c.writeTimeout(),
+	})
+	if err != nil {
+		return WriteResult{}, err
+	}
+	return writeResult(header), nil
+}
+
+// AddMember 提议添加成员，不等待成员变更被应用
+func (c *Client) AddMember(ctx context.Context, id uint64, peerURL string) error {
+	_, err := c.addMember(ctx, strconv.FormatUint(id, 10), addMemberParams{KeyEncoding: keycodec.Raw.String()}, peerURL)
+	return err
+}
+
+// RemoveMember 提议删除成员，不等待成员变更被应用。它是对成员 ID 的 deleteKey
+func (c *Client) RemoveMember(ctx context.Context, id uint64) error {
+	_, err := c.deleteKey(ctx, strconv.FormatUint(id, 10), deleteKeyParams{KeyEncoding: keycodec.Raw.String()})
+	return err
+}
+
+// Batch 原子地应用一组 put 和 delete，操作中的 key 和 RangeEnd 按客户端的 key 编码发送
+func (c *Client) Batch(ctx context.Context, ops []BatchOp) (*BatchResponse, error) {
+	wire := make([]BatchOp, len(ops))
+	for i, op := range ops {
+		op.Key = c.codec.Encode([]byte(op.Key))
+		if op.RangeEnd != "" {
+			op.RangeEnd = c.codec.Encode([]byte(op.RangeEnd))
+		}
+		wire[i] = op
+	}
+	return c.batch(ctx, batchParams{Timeout: c.writeTimeout()}, BatchRequest{Ops: wire})
+}
+
+// KeyHistory 按 revision 从新到旧返回 key 保留的版本，limit 为 0 时返回全部
+func (c *Client) KeyHistory(ctx context.Context, key string, limit int64) (*KeyHistoryResponse, error) {
+	params := keyHistoryParams{Key: c.codec.Encode([]byte(key))}
+	if limit > 0 {
+		params.Limit = &limit
+	}
+	out, err := c.keyHistory(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if out.Key, err = c.codec.Decode(out.Key); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Login 用用户名和密码换取 token，成功后之后的请求自动带上它
+func (c *Client) Login(ctx context.Context, name, password string) (string, error) {
+	out, err := c.login(ctx, LoginRequest{Name: name, Password: password})
+	if err != nil {
+		return "", err
+	}
+	c.SetToken(out.Token)
+	return out.Token, nil
+}
+
+// OpenAPI 返回服务端的 OpenAPI 文档
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	return c.openAPI(ctx)
+}
+
+// Health 返回节点的健康状态。节点不健康（服务端返回 503）时也返回结果，由 NodeHealth.Healthy 判断；
+// leader 为 true 时只有 healthy-leader 视为健康
+func (c *Client) Health(ctx context.Context, leader bool) (*NodeHealth, error) {
+	return c.health(ctx, healthParams{Leader: leader})
+}
+
+// Readyz 执行就绪检查，任一检查失败时返回 503 错误，错误消息逐项列出检查结果
+func (c *Client) Readyz(ctx context.Context, verbose bool, exclude ...string) (string, error) {
+	return trimmed(c.readyz(ctx, readyzParams{Verbose: verbose, Exclude: exclude}))
+}
+
+// ReadyzCheck 只执行一项就绪检查
+func (c *Client) ReadyzCheck(ctx context.Context, check string) (string, error) {
+	return trimmed(c.readyzCheck(ctx, check))
+}
+
+// Livez 执行存活检查，任一检查失败时返回 503 错误
+func (c *Client) Livez(ctx context.Context, verbose bool, exclude ...string) (string, error) {
+	return trimmed(c.livez(ctx, livezParams{Verbose: verbose, Exclude: exclude}))
+}
+
+// LivezCheck 只执行一项存活检查
+func (c *Client) LivezCheck(ctx context.Context, check string) (string, error) {
+	return trimmed(c.livezCheck(ctx, check))
+}
+
+// trimmed 去掉检查结果末尾的换行
+func trimmed(out string, err error) (string, error) {
+	return strings.TrimSpace(out), err
+}
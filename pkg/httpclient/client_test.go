@@ -0,0 +1,78 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"metaStore/pkg/keycodec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestError 非 2xx 响应转换为 *Error，并带上 Retry-After
+func TestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		w.Header().Set("Retry-After", "3")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := New(Config{Endpoint: srv.URL + "/", Token: "secret"})
+	_, err := c.GetKey(t.Context(), "a", 0)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr), "%v", err)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, "too many requests", apiErr.Message)
+	assert.Equal(t, 3*time.Second, apiErr.RetryAfter)
+	assert.False(t, IsNotFound(err))
+}
+
+// TestWatchStream 解析 SSE：跳过 keepalive 注释，按编码还原 key
+func TestWatchStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "hex", r.Header.Get("X-MetaStore-Key-Encoding"))
+		assert.Equal(t, keycodec.Hex.Encode([]byte("k/")), r.URL.Query().Get("prefix"))
+		assert.Equal(t, "7.0", r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, ": keepalive\n\n")
+		io.WriteString(w, "id: 8.0\r\nevent: put\r\ndata: {\"type\":\"PUT\",\"revision\":8,\"kv\":{\"key\":\""+
+			keycodec.Hex.Encode([]byte("k/1"))+"\",\"value\":\"v\",\"mod_revision\":8}}\r\n\r\n")
+	}))
+	defer srv.Close()
+
+	c := New(Config{Endpoint: srv.URL, KeyEncoding: keycodec.Hex})
+	w, err := c.Watch(t.Context(), WatchRequest{Prefix: "k/", LastEventID: "7.0"})
+	require.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, "7.0", w.LastEventID())
+
+	ev, err := w.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "8.0", ev.ID)
+	assert.Equal(t, "k/1", ev.Kv.Key)
+	assert.Equal(t, "v", ev.Kv.Value)
+	assert.Equal(t, "8.0", w.LastEventID())
+
+	_, err = w.Next()
+	assert.Equal(t, io.EOF, err)
+}
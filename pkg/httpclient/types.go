@@ -0,0 +1,55 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+// api/http/openapi.json 中 components.schemas 的定义生成在 api.gen.go，这里只有客户端自己的类型
+
+// WriteResult 写请求成功后的响应头
+type WriteResult struct {
+	CommitIndex uint64 // read-after-write token，作为 GetKey 的 minIndex 在其他节点上读到这次写入
+	HLC         string // 本节点最后应用的操作的 HLC
+}
+
+// StreamEvent Watcher 收到的事件
+type StreamEvent struct {
+	ID string // SSE id，重连时作为 WatchRequest.LastEventID
+	WatchEvent
+}
+
+// Healthy 返回节点是否适合处理读请求
+func (h *NodeHealth) Healthy() bool {
+	return h.Mode == "healthy-leader" || h.Mode == "healthy-follower"
+}
+
+// RaftLogRequest raft 日志查询参数，零值返回最后 100 个条目
+type RaftLogRequest struct {
+	From   uint64
+	Limit  int
+	Redact bool
+}
+
+// TrashRange 回收站操作的范围：Key 指定一个条目，否则为 Prefix 下的所有条目（为空时整个回收站）
+type TrashRange struct {
+	Key    string
+	Prefix string
+}
+
+// prefix 返回 ?prefix=，指定了 Key 时不发送
+func (r TrashRange) prefix() string {
+	if r.Key != "" {
+		return ""
+	}
+	return r.Prefix
+}
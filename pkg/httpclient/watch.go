@@ -0,0 +1,135 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WatchRequest /watch 的参数。Key 非空时只订阅单个 key，否则订阅 Prefix 下的所有 key
+type WatchRequest struct {
+	Prefix      string
+	Key         string
+	FromRev     int64 // 从该 revision 开始，0 表示只推送之后的修改
+	PrevKV      bool
+	ValuePrefix string // 只推送 value 以此开头的事件
+	JSONPath    string // 只推送 value 中该 JSON 路径的值等于 JSONValue 的事件
+	JSONValue   string
+	LastEventID string // 上一个流中最后收到的 StreamEvent.ID，从它之后继续，优先于 FromRev
+}
+
+// WatchStream 服务端推送的事件流
+type WatchStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	codec  func(string) (string, error)
+	lastID string
+}
+
+// Watch 订阅修改。流被服务端关闭（Next 返回 io.EOF）后，以 LastEventID 重新订阅即可
+// 不重复、不遗漏地继续
+func (c *Client) Watch(ctx context.Context, req WatchRequest) (*WatchStream, error) {
+	params := watchParams{
+		PrevKv:      req.PrevKV,
+		ValuePrefix: req.ValuePrefix,
+		JSONPath:    req.JSONPath,
+		JSONValue:   req.JSONValue,
+		LastEventID: req.LastEventID,
+	}
+	if req.Key != "" {
+		params.Key = c.codec.Encode([]byte(req.Key))
+	} else if req.Prefix != "" {
+		params.Prefix = c.codec.Encode([]byte(req.Prefix))
+	}
+	if req.FromRev > 0 {
+		params.FromRev = &req.FromRev
+	}
+
+	resp, err := c.watch(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &WatchStream{body: resp.Body, reader: bufio.NewReader(resp.Body), codec: c.codec.Decode, lastID: req.LastEventID}, nil
+}
+
+// Next 返回下一个事件，阻塞直到收到事件、流结束（io.EOF）或 Watch 的 context 被取消
+func (s *WatchStream) Next() (*StreamEvent, error) {
+	var id, data string
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// 空行结束一个事件，keepalive 注释没有数据
+			if data == "" {
+				continue
+			}
+			ev := StreamEvent{ID: id}
+			if err := json.Unmarshal([]byte(data), &ev.WatchEvent); err != nil {
+				return nil, fmt.Errorf("invalid watch event %q: %w", data, err)
+			}
+			if err := s.decodeKeys(&ev.WatchEvent); err != nil {
+				return nil, err
+			}
+			s.lastID = id
+			return &ev, nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			if data != "" {
+				data += "\n"
+			}
+			data += value
+		}
+	}
+}
+
+// decodeKeys 按客户端的 key 编码还原事件中的 key
+func (s *WatchStream) decodeKeys(ev *WatchEvent) error {
+	var err error
+	if ev.Kv.Key, err = s.codec(ev.Kv.Key); err != nil {
+		return err
+	}
+	if ev.PrevKv != nil {
+		ev.PrevKv.Key, err = s.codec(ev.PrevKv.Key)
+	}
+	return err
+}
+
+// LastEventID 返回最后收到的事件的 ID，重新订阅时作为 WatchRequest.LastEventID
+func (s *WatchStream) LastEventID() string {
+	return s.lastID
+}
+
+// Close 关闭事件流
+func (s *WatchStream) Close() error {
+	return s.body.Close()
+}
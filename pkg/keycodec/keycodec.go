@@ -0,0 +1,94 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keycodec 实现 HTTP 和 MySQL 前端的 key 编码
+//
+// etcd 的 key 是任意字节串，可能包含 "/"、引号或非 UTF-8 字节，无法直接放进 URL 路径或
+// SQL 字符串。客户端选择 base64 或 hex 编码后，请求中的 key 按该编码解码，响应中的 key
+// 按该编码返回，存储中的 key 不变
+package keycodec
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Codec key 编码方式，零值 Raw 表示不编码
+type Codec int
+
+const (
+	Raw    Codec = iota // key 原样传输
+	Base64              // 标准 base64，解码时也接受 URL 安全字母表和省略的填充
+	Hex                 // 十六进制，大小写均可
+)
+
+// Parse 解析编码名称（raw、base64、hex，大小写不敏感），空串为 Raw
+func Parse(name string) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "raw":
+		return Raw, nil
+	case "base64":
+		return Base64, nil
+	case "hex":
+		return Hex, nil
+	}
+	return Raw, fmt.Errorf("unknown key encoding %q, expected raw, base64 or hex", name)
+}
+
+// String 返回编码名称
+func (c Codec) String() string {
+	switch c {
+	case Base64:
+		return "base64"
+	case Hex:
+		return "hex"
+	}
+	return "raw"
+}
+
+// Encode 编码 key
+func (c Codec) Encode(key []byte) string {
+	switch c {
+	case Base64:
+		return base64.StdEncoding.EncodeToString(key)
+	case Hex:
+		return hex.EncodeToString(key)
+	}
+	return string(key)
+}
+
+// Decode 解码请求中的 key
+func (c Codec) Decode(s string) (string, error) {
+	switch c {
+	case Base64:
+		enc := base64.RawStdEncoding
+		if strings.ContainsAny(s, "-_") {
+			enc = base64.RawURLEncoding
+		}
+		b, err := enc.DecodeString(strings.TrimRight(s, "="))
+		if err != nil {
+			return "", fmt.Errorf("invalid base64 key %q: %w", s, err)
+		}
+		return string(b), nil
+	case Hex:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid hex key %q: %w", s, err)
+		}
+		return string(b), nil
+	}
+	return s, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keycodec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	key := []byte("/tenants/a\x00\xff'\"?")
+
+	for _, name := range []string{"raw", "BASE64", "hex"} {
+		c, err := Parse(name)
+		require.NoError(t, err)
+		decoded, err := c.Decode(c.Encode(key))
+		require.NoError(t, err)
+		assert.Equal(t, string(key), decoded, name)
+	}
+
+	c, err := Parse("")
+	require.NoError(t, err)
+	assert.Equal(t, Raw, c)
+	_, err = Parse("base32")
+	assert.Error(t, err)
+
+	// base64 也接受 URL 安全字母表和省略的填充，便于放进 URL 路径
+	for _, s := range []string{"+/8=", "+/8", "-_8"} {
+		decoded, err := Base64.Decode(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, "\xfb\xff", decoded, s)
+	}
+	decoded, err := Hex.Decode("2F41")
+	require.NoError(t, err)
+	assert.Equal(t, "/A", decoded)
+
+	_, err = Base64.Decode("!!")
+	assert.Error(t, err)
+	_, err = Hex.Decode("abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/pkg/reliability"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CorruptionCollector exports the number of checksum failures of the raft log and snapshots
+type CorruptionCollector struct {
+	corrupted *prometheus.Desc
+}
+
+// NewCorruptionCollector creates a collector reading the counters of pkg/reliability
+func NewCorruptionCollector() *CorruptionCollector {
+	return &CorruptionCollector{
+		corrupted: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "storage", "corruption_detected_total"),
+			"Total number of CRC32C checksum failures, by source (raft_log or snapshot)",
+			[]string{"source"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *CorruptionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.corrupted
+}
+
+// Collect implements prometheus.Collector
+func (c *CorruptionCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := reliability.CorruptionCounts()
+	for _, source := range []string{reliability.SourceRaftLog, reliability.SourceSnapshot} {
+		ch <- prometheus.MustNewConstMetric(c.corrupted, prometheus.CounterValue, float64(counts[source]), source)
+	}
+}
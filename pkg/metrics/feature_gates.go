@@ -0,0 +1,55 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/pkg/featuregate"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FeatureGateCollector exports whether each feature gate is enabled on this node
+type FeatureGateCollector struct {
+	gate    *featuregate.Gate
+	enabled *prometheus.Desc
+}
+
+// NewFeatureGateCollector creates a collector for the given gate
+func NewFeatureGateCollector(gate *featuregate.Gate) *FeatureGateCollector {
+	return &FeatureGateCollector{
+		gate: gate,
+		enabled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "feature_enabled"),
+			"Whether a feature gate is enabled (1) or disabled (0) on this node",
+			[]string{"name", "stage"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *FeatureGateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.enabled
+}
+
+// Collect implements prometheus.Collector
+func (c *FeatureGateCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, st := range c.gate.Statuses() {
+		value := 0.0
+		if st.Enabled {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.enabled, prometheus.GaugeValue, value, string(st.Name), string(st.Stage))
+	}
+}
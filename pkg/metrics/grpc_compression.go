@@ -0,0 +1,63 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// GRPCCompressionCollector exports gRPC message bytes before and after
+// compression per method, compressor and direction, so the bandwidth saved by
+// grpc.compression can be compared with its CPU cost
+type GRPCCompressionCollector struct {
+	stats func() []kvstore.CompressionStats
+
+	raw  *prometheus.Desc
+	wire *prometheus.Desc
+}
+
+// NewGRPCCompressionCollector creates a collector for the given stats getter
+func NewGRPCCompressionCollector(stats func() []kvstore.CompressionStats) *GRPCCompressionCollector {
+	labels := []string{"method", "compressor", "direction"}
+	return &GRPCCompressionCollector{
+		stats: stats,
+		raw: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "grpc", "payload_raw_bytes_total"),
+			"Total gRPC message bytes before compression",
+			labels, nil,
+		),
+		wire: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "grpc", "payload_wire_bytes_total"),
+			"Total gRPC message bytes sent or received after compression",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *GRPCCompressionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.raw
+	ch <- c.wire
+}
+
+// Collect implements prometheus.Collector
+func (c *GRPCCompressionCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.stats() {
+		ch <- prometheus.MustNewConstMetric(c.raw, prometheus.CounterValue, float64(s.RawBytes), s.Method, s.Compressor, s.Direction)
+		ch <- prometheus.MustNewConstMetric(c.wire, prometheus.CounterValue, float64(s.WireBytes), s.Method, s.Compressor, s.Direction)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/pkg/hlc"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HLCCollector exports the clock skew observed by the hybrid logical clock of this node
+type HLCCollector struct {
+	clock *hlc.Clock
+
+	maxOffset *prometheus.Desc
+	lastSkew  *prometheus.Desc
+	maxSkew   *prometheus.Desc
+	rejected  *prometheus.Desc
+}
+
+// NewHLCCollector creates a collector reading the statistics of clock
+func NewHLCCollector(clock *hlc.Clock) *HLCCollector {
+	return &HLCCollector{
+		clock: clock,
+		maxOffset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "hlc", "max_offset_seconds"),
+			"Maximum clock offset accepted from remote timestamps (server.hlc.max_offset), 0 when unbounded",
+			nil, nil,
+		),
+		lastSkew: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "hlc", "skew_seconds"),
+			"How far the last observed remote timestamp was ahead of the local physical clock, negative when behind",
+			nil, nil,
+		),
+		maxSkew: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "hlc", "max_skew_seconds"),
+			"Largest lead of an observed remote timestamp over the local physical clock since start",
+			nil, nil,
+		),
+		rejected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "hlc", "offset_rejections_total"),
+			"Total number of remote timestamps not merged because they exceeded the maximum offset",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *HLCCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOffset
+	ch <- c.lastSkew
+	ch <- c.maxSkew
+	ch <- c.rejected
+}
+
+// Collect implements prometheus.Collector
+func (c *HLCCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.clock.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOffset, prometheus.GaugeValue, stats.MaxOffset.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.lastSkew, prometheus.GaugeValue, stats.LastSkew.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxSkew, prometheus.GaugeValue, stats.MaxSkew.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(stats.Rejected))
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LeaseExpiryCollector exports the lease expiry scheduler, which revokes
+// expired leases in bounded batches; a growing backlog means leases expire
+// faster than lease.expiry_batch_size per check revokes them
+type LeaseExpiryCollector struct {
+	stats func() kvstore.LeaseExpiryStats
+
+	scheduled *prometheus.Desc
+	backlog   *prometheus.Desc
+	revoked   *prometheus.Desc
+	failed    *prometheus.Desc
+}
+
+// NewLeaseExpiryCollector creates a collector for the given stats getter
+func NewLeaseExpiryCollector(stats func() kvstore.LeaseExpiryStats) *LeaseExpiryCollector {
+	return &LeaseExpiryCollector{
+		stats: stats,
+		scheduled: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lease_expiry", "scheduled_leases"),
+			"Current number of leases waiting for their deadline",
+			nil, nil,
+		),
+		backlog: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lease_expiry", "backlog"),
+			"Number of expired leases left for the next check after the last batch",
+			nil, nil,
+		),
+		revoked: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lease_expiry", "revoked_total"),
+			"Total number of leases revoked because they expired",
+			nil, nil,
+		),
+		failed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lease_expiry", "failures_total"),
+			"Total number of expired leases whose revoke failed",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *LeaseExpiryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.scheduled
+	ch <- c.backlog
+	ch <- c.revoked
+	ch <- c.failed
+}
+
+// Collect implements prometheus.Collector
+func (c *LeaseExpiryCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.scheduled, prometheus.GaugeValue, float64(stats.Scheduled))
+	ch <- prometheus.MustNewConstMetric(c.backlog, prometheus.GaugeValue, float64(stats.Backlog))
+	ch <- prometheus.MustNewConstMetric(c.revoked, prometheus.CounterValue, float64(stats.Revoked))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+}
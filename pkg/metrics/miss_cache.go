@@ -0,0 +1,76 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MissCacheCollector exports the negative lookup cache of a store, which answers
+// single-key reads of recently missing keys without reaching the storage engine
+type MissCacheCollector struct {
+	stats func() kvstore.MissCacheStats
+
+	entries  *prometheus.Desc
+	capacity *prometheus.Desc
+	hits     *prometheus.Desc
+	misses   *prometheus.Desc
+}
+
+// NewMissCacheCollector creates a collector for the given stats getter
+func NewMissCacheCollector(stats func() kvstore.MissCacheStats) *MissCacheCollector {
+	return &MissCacheCollector{
+		stats: stats,
+		entries: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "miss_cache", "entries"),
+			"Current number of absent keys held by the negative lookup cache",
+			nil, nil,
+		),
+		capacity: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "miss_cache", "capacity"),
+			"Maximum number of absent keys held by the negative lookup cache (rocksdb.miss_cache_size)",
+			nil, nil,
+		),
+		hits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "miss_cache", "hits_total"),
+			"Total number of single-key reads answered as absent by the negative lookup cache",
+			nil, nil,
+		),
+		misses: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "miss_cache", "misses_total"),
+			"Total number of single-key reads not found in the negative lookup cache and read from the storage engine",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *MissCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.capacity
+	ch <- c.hits
+	ch <- c.misses
+}
+
+// Collect implements prometheus.Collector
+func (c *MissCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+}
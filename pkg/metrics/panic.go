@@ -0,0 +1,59 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/pkg/reliability"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// panicSources are exported even before their first panic
+var panicSources = []string{
+	reliability.PanicSourceGRPC,
+	reliability.PanicSourceHTTP,
+	reliability.PanicSourceMySQL,
+	reliability.PanicSourceGoroutine,
+}
+
+// PanicCollector exports the panics recovered by the gRPC, HTTP and MySQL
+// frontends and by background goroutines
+type PanicCollector struct {
+	recovered *prometheus.Desc
+}
+
+// NewPanicCollector creates a collector reading the counters of pkg/reliability
+func NewPanicCollector() *PanicCollector {
+	return &PanicCollector{
+		recovered: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "recovered_panics_total"),
+			"Total number of recovered panics, by source (grpc, http, mysql or goroutine)",
+			[]string{"source"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *PanicCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.recovered
+}
+
+// Collect implements prometheus.Collector
+func (c *PanicCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := reliability.PanicCounts()
+	for _, source := range panicSources {
+		ch <- prometheus.MustNewConstMetric(c.recovered, prometheus.CounterValue, float64(counts[source]), source)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProposalCollector exports the size distribution of Raft proposals, for sizing
+// raft.max_size_per_msg and the gRPC message limits from observed traffic
+type ProposalCollector struct {
+	valueSize *prometheus.Desc
+	txnKeys   *prometheus.Desc
+	batchSize *prometheus.Desc
+}
+
+// NewProposalCollector creates a collector reading the histograms of internal/kvstore
+func NewProposalCollector() *ProposalCollector {
+	return &ProposalCollector{
+		valueSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "proposal_value_size_bytes"),
+			"Size of the values written by proposals, one observation per put including puts inside transactions",
+			nil, nil,
+		),
+		txnKeys: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "txn_keys"),
+			"Number of distinct keys compared or written by a proposed transaction",
+			nil, nil,
+		),
+		batchSize: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "proposal_batch_size"),
+			"Number of proposals in each batch sent to Raft by the proposal batcher",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *ProposalCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.valueSize
+	ch <- c.txnKeys
+	ch <- c.batchSize
+}
+
+// Collect implements prometheus.Collector
+func (c *ProposalCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := kvstore.ProposalSizeStats()
+	ch <- constHistogram(c.valueSize, stats.ValueSize)
+	ch <- constHistogram(c.txnKeys, stats.TxnKeys)
+	ch <- constHistogram(c.batchSize, stats.BatchSize)
+}
+
+func constHistogram(desc *prometheus.Desc, h kvstore.HistogramSnapshot) prometheus.Metric {
+	return prometheus.MustNewConstHistogram(desc, h.Count, float64(h.Sum), h.Buckets)
+}
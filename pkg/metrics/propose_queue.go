@@ -0,0 +1,117 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProposeQueueCollector exports the occupancy of the propose pipeline of a store
+// The state is read from the store on every scrape
+type ProposeQueueCollector struct {
+	stats func() kvstore.ProposeQueueStats
+
+	queued    *prometheus.Desc
+	capacity  *prometheus.Desc
+	occupancy *prometheus.Desc
+	pending   *prometheus.Desc
+	rejected  *prometheus.Desc
+
+	highWater  *prometheus.Desc
+	byPriority *prometheus.Desc
+	full       *prometheus.Desc
+}
+
+// NewProposeQueueCollector creates a collector for the given stats getter
+func NewProposeQueueCollector(stats func() kvstore.ProposeQueueStats) *ProposeQueueCollector {
+	return &ProposeQueueCollector{
+		stats: stats,
+		queued: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "propose_queue_length"),
+			"Current number of proposals waiting in the propose queue",
+			nil, nil,
+		),
+		capacity: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "propose_queue_capacity"),
+			"Capacity of the propose queue",
+			nil, nil,
+		),
+		occupancy: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "propose_queue_occupancy"),
+			"Fraction of the propose queue in use; writes are rejected above limits.propose_queue_threshold",
+			nil, nil,
+		),
+		pending: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "pending_proposals"),
+			"Current number of proposed writes waiting to be applied",
+			nil, nil,
+		),
+		rejected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "backpressure_rejections_total"),
+			"Total number of writes rejected because the propose pipeline was saturated",
+			nil, nil,
+		),
+		highWater: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "propose_queue_high_water"),
+			"Highest number of proposals queued at once since the start",
+			nil, nil,
+		),
+		byPriority: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "propose_queue_priority_length"),
+			"Current number of queued proposals by priority",
+			[]string{"priority"}, nil,
+		),
+		full: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "propose_queue_full_total"),
+			"Proposals that found the propose queue full and blocked or were rejected per limits.propose_queue_overflow",
+			[]string{"priority"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *ProposeQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.queued
+	ch <- c.capacity
+	ch <- c.occupancy
+	ch <- c.pending
+	ch <- c.rejected
+	ch <- c.highWater
+	ch <- c.byPriority
+	ch <- c.full
+}
+
+// Collect implements prometheus.Collector
+func (c *ProposeQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	occupancy := 0.0
+	if stats.Capacity > 0 {
+		occupancy = float64(stats.Queued) / float64(stats.Capacity)
+	}
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(stats.Queued))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(c.occupancy, prometheus.GaugeValue, occupancy)
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(stats.Rejected))
+	ch <- prometheus.MustNewConstMetric(c.highWater, prometheus.GaugeValue, float64(stats.HighWater))
+	for priority, queued := range stats.ByPriority {
+		ch <- prometheus.MustNewConstMetric(c.byPriority, prometheus.GaugeValue, float64(queued), priority)
+	}
+	for priority, full := range stats.Full {
+		ch <- prometheus.MustNewConstMetric(c.full, prometheus.CounterValue, float64(full), priority)
+	}
+}
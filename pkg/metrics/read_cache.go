@@ -0,0 +1,85 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReadCacheCollector exports the read cache of a store, which answers single-key
+// reads of recently read keys without reaching the storage engine
+type ReadCacheCollector struct {
+	stats func() kvstore.ReadCacheStats
+
+	entries  *prometheus.Desc
+	capacity *prometheus.Desc
+	hits     *prometheus.Desc
+	misses   *prometheus.Desc
+
+	invalidations *prometheus.Desc
+}
+
+// NewReadCacheCollector creates a collector for the given stats getter
+func NewReadCacheCollector(stats func() kvstore.ReadCacheStats) *ReadCacheCollector {
+	return &ReadCacheCollector{
+		stats: stats,
+		entries: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "read_cache", "entries"),
+			"Current number of key-values held by the read cache",
+			nil, nil,
+		),
+		capacity: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "read_cache", "capacity"),
+			"Maximum number of key-values held by the read cache (rocksdb.read_cache_size)",
+			nil, nil,
+		),
+		hits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "read_cache", "hits_total"),
+			"Total number of single-key reads answered by the read cache",
+			nil, nil,
+		),
+		misses: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "read_cache", "misses_total"),
+			"Total number of single-key reads not found in the read cache and read from the storage engine",
+			nil, nil,
+		),
+		invalidations: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "read_cache", "invalidations_total"),
+			"Total number of cached key-values dropped because the key was put or deleted",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *ReadCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.capacity
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.invalidations
+}
+
+// Collect implements prometheus.Collector
+func (c *ReadCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.invalidations, prometheus.CounterValue, float64(stats.Invalidations))
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ReplicationCollector exports the commit and applied indices of this member,
+// the liveness of the members it talks to and, on the leader, how far each
+// member's log lags behind the commit index
+// The state is read from the store on every scrape
+type ReplicationCollector struct {
+	status  func() kvstore.RaftStatus
+	members func() []kvstore.MemberStatus
+
+	commit  *prometheus.Desc
+	applied *prometheus.Desc
+	match   *prometheus.Desc
+	lag     *prometheus.Desc
+
+	lastContact  *prometheus.Desc
+	rtt          *prometheus.Desc
+	sendFailures *prometheus.Desc
+	unreachable  *prometheus.Desc
+}
+
+// NewReplicationCollector creates a collector for the given status getters
+func NewReplicationCollector(status func() kvstore.RaftStatus, members func() []kvstore.MemberStatus) *ReplicationCollector {
+	return &ReplicationCollector{
+		status:  status,
+		members: members,
+		commit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "commit_index"),
+			"Raft commit index known to this member",
+			nil, nil,
+		),
+		applied: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "applied_index"),
+			"Raft index applied by this member",
+			nil, nil,
+		),
+		match: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "member_match_index"),
+			"Highest log index known to be replicated to a member, only exported by the leader",
+			[]string{"member"}, nil,
+		),
+		lag: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "member_lag_entries"),
+			"Number of committed entries a member has not replicated yet, only exported by the leader",
+			[]string{"member"}, nil,
+		),
+		lastContact: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "member_last_contact_seconds"),
+			"Seconds since this member last received a message from a member; followers only hear from the leader",
+			[]string{"member"}, nil,
+		),
+		rtt: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "member_rtt_seconds"),
+			"Smoothed heartbeat round-trip time to a member, only exported by the leader",
+			[]string{"member"}, nil,
+		),
+		sendFailures: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "member_send_failures_total"),
+			"Messages to a member the transport failed to deliver",
+			[]string{"member"}, nil,
+		),
+		unreachable: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "raft", "member_unreachable"),
+			"1 when the leader has not heard from a member for raft.peer_dead_timeout, only exported by the leader",
+			[]string{"member"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *ReplicationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.commit
+	ch <- c.applied
+	ch <- c.match
+	ch <- c.lag
+	ch <- c.lastContact
+	ch <- c.rtt
+	ch <- c.sendFailures
+	ch <- c.unreachable
+}
+
+// Collect implements prometheus.Collector
+func (c *ReplicationCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.status()
+	ch <- prometheus.MustNewConstMetric(c.commit, prometheus.GaugeValue, float64(st.Commit))
+	ch <- prometheus.MustNewConstMetric(c.applied, prometheus.GaugeValue, float64(st.Applied))
+
+	members := c.members()
+	now := time.Now()
+	for _, m := range members {
+		if m.ID == st.NodeID {
+			continue
+		}
+		member := strconv.FormatUint(m.ID, 10)
+		if !m.LastContact.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.lastContact, prometheus.GaugeValue, now.Sub(m.LastContact).Seconds(), member)
+		}
+		ch <- prometheus.MustNewConstMetric(c.sendFailures, prometheus.CounterValue, float64(m.SendFailures), member)
+	}
+
+	if st.LeaderID == 0 || st.LeaderID != st.NodeID {
+		return
+	}
+	for _, m := range members {
+		if m.Progress == "" {
+			continue
+		}
+		member := strconv.FormatUint(m.ID, 10)
+		if m.ID != st.NodeID {
+			unreachable := 0.0
+			if m.Unreachable {
+				unreachable = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.rtt, prometheus.GaugeValue, m.RTT.Seconds(), member)
+			ch <- prometheus.MustNewConstMetric(c.unreachable, prometheus.GaugeValue, unreachable, member)
+		}
+		lag := uint64(0)
+		if st.Commit > m.Match {
+			lag = st.Commit - m.Match
+		}
+		ch <- prometheus.MustNewConstMetric(c.match, prometheus.GaugeValue, float64(m.Match), member)
+		ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, float64(lag), member)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ResourceLimitCollector exports watch and lease counts next to
+// limits.max_watch_count, max_lease_count and their per-connection limits,
+// so utilization can be alerted on before requests are rejected. Watches are
+// counted on this member, leases in the whole cluster
+type ResourceLimitCollector struct {
+	watches func() kvstore.ResourceUsage
+	leases  func() kvstore.ResourceUsage
+
+	count              *prometheus.Desc
+	limit              *prometheus.Desc
+	maxPerConnection   *prometheus.Desc
+	perConnectionLimit *prometheus.Desc
+	rejected           *prometheus.Desc
+}
+
+// NewResourceLimitCollector creates a collector for the given usage getters
+func NewResourceLimitCollector(watches, leases func() kvstore.ResourceUsage) *ResourceLimitCollector {
+	labels := []string{"resource"}
+	return &ResourceLimitCollector{
+		watches: watches,
+		leases:  leases,
+		count: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "resource", "count"),
+			"Current number of watches on this member or leases in the cluster",
+			labels, nil,
+		),
+		limit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "resource", "limit"),
+			"Configured limit on the number of watches or leases, 0 means no limit",
+			labels, nil,
+		),
+		maxPerConnection: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "resource", "max_per_connection"),
+			"Largest number of watches or leases held by a single client connection",
+			labels, nil,
+		),
+		perConnectionLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "resource", "per_connection_limit"),
+			"Configured limit per client connection, 0 means no limit",
+			labels, nil,
+		),
+		rejected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "resource", "rejected_total"),
+			"Total number of watch creations or lease grants rejected by a limit",
+			[]string{"resource", "scope"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *ResourceLimitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+	ch <- c.limit
+	ch <- c.maxPerConnection
+	ch <- c.perConnectionLimit
+	ch <- c.rejected
+}
+
+// Collect implements prometheus.Collector
+func (c *ResourceLimitCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(ch, "watch", "member", c.watches())
+	c.collect(ch, "lease", "cluster", c.leases())
+}
+
+func (c *ResourceLimitCollector) collect(ch chan<- prometheus.Metric, resource, scope string, usage kvstore.ResourceUsage) {
+	ch <- prometheus.MustNewConstMetric(c.count, prometheus.GaugeValue, float64(usage.Count), resource)
+	ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(usage.Limit), resource)
+	ch <- prometheus.MustNewConstMetric(c.maxPerConnection, prometheus.GaugeValue, float64(usage.MaxPerConnection), resource)
+	ch <- prometheus.MustNewConstMetric(c.perConnectionLimit, prometheus.GaugeValue, float64(usage.PerConnectionLimit), resource)
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(usage.Rejected), resource, scope)
+	ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(usage.RejectedPerConnection), resource, "connection")
+}
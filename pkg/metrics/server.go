@@ -17,6 +17,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 // Provides /metrics endpoint for Prometheus scraping and /health endpoint for health checks
 type MetricsServer struct {
 	server   *http.Server
+	mux      *http.ServeMux
 	registry *prometheus.Registry
 	logger   *zap.Logger
 }
@@ -89,11 +91,18 @@ func NewMetricsServer(addr string, registry *prometheus.Registry, logger *zap.Lo
 
 	return &MetricsServer{
 		server:   server,
+		mux:      mux,
 		registry: registry,
 		logger:   logger,
 	}
 }
 
+// Handle registers an additional handler (e.g. debug endpoints) on the metrics server
+// Must be called before Start
+func (ms *MetricsServer) Handle(pattern string, handler http.Handler) {
+	ms.mux.Handle(pattern, handler)
+}
+
 // Start starts the metrics server
 // This method blocks until the server is shut down
 func (ms *MetricsServer) Start() error {
@@ -109,6 +118,28 @@ func (ms *MetricsServer) Start() error {
 	return nil
 }
 
+// ListenAndServe serves on addr, which may differ from the address the server
+// was created with, so one server can listen on several addresses
+// This method blocks until the server is shut down
+func (ms *MetricsServer) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		ms.logger.Error("metrics server failed",
+			zap.Error(err))
+		return err
+	}
+	ms.logger.Info("starting metrics server",
+		zap.String("addr", addr))
+
+	if err := ms.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		ms.logger.Error("metrics server failed",
+			zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 // Shutdown gracefully shuts down the metrics server
 // ctx: Context with timeout for shutdown (recommended: 5-10 seconds)
 func (ms *MetricsServer) Shutdown(ctx context.Context) error {
@@ -0,0 +1,135 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strconv"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StorageEngineCollector exports RocksDB internal statistics, sampled at most
+// once per rocksdb.stats_interval. Per column family metrics carry a cf label.
+// Block cache hits and write stalls are only exported with rocksdb.statistics
+type StorageEngineCollector struct {
+	stats func() kvstore.StorageEngineStats
+
+	levelFiles             *prometheus.Desc
+	pendingCompactionBytes *prometheus.Desc
+	memtableBytes          *prometheus.Desc
+	immutableMemtables     *prometheus.Desc
+	blockCacheUsage        *prometheus.Desc
+	blockCacheCapacity     *prometheus.Desc
+	blockCacheHits         *prometheus.Desc
+	blockCacheMisses       *prometheus.Desc
+	writeStalls            *prometheus.Desc
+	writeStallSeconds      *prometheus.Desc
+}
+
+// NewStorageEngineCollector creates a collector for the given stats getter
+func NewStorageEngineCollector(stats func() kvstore.StorageEngineStats) *StorageEngineCollector {
+	cf := []string{"cf"}
+	return &StorageEngineCollector{
+		stats: stats,
+		levelFiles: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "level_files"),
+			"Number of SST files in each LSM level",
+			[]string{"cf", "level"}, nil,
+		),
+		pendingCompactionBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "pending_compaction_bytes"),
+			"Estimated bytes compaction has to rewrite to bring all levels under their target size",
+			cf, nil,
+		),
+		memtableBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "memtable_bytes"),
+			"Bytes used by the active and immutable memtables",
+			cf, nil,
+		),
+		immutableMemtables: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "immutable_memtables"),
+			"Number of immutable memtables waiting to be flushed",
+			cf, nil,
+		),
+		blockCacheUsage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "block_cache_usage_bytes"),
+			"Bytes currently held by the block cache",
+			nil, nil,
+		),
+		blockCacheCapacity: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "block_cache_capacity_bytes"),
+			"Capacity of the block cache (rocksdb.block_cache_size)",
+			nil, nil,
+		),
+		blockCacheHits: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "block_cache_hits_total"),
+			"Total number of block reads served by the block cache",
+			nil, nil,
+		),
+		blockCacheMisses: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "block_cache_misses_total"),
+			"Total number of block reads that missed the block cache",
+			nil, nil,
+		),
+		writeStalls: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "write_stalls_total"),
+			"Total number of writes delayed or stopped because compaction fell behind",
+			nil, nil,
+		),
+		writeStallSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rocksdb", "write_stall_seconds_total"),
+			"Total time writes spent stalled",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *StorageEngineCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.levelFiles
+	ch <- c.pendingCompactionBytes
+	ch <- c.memtableBytes
+	ch <- c.immutableMemtables
+	ch <- c.blockCacheUsage
+	ch <- c.blockCacheCapacity
+	ch <- c.blockCacheHits
+	ch <- c.blockCacheMisses
+	ch <- c.writeStalls
+	ch <- c.writeStallSeconds
+}
+
+// Collect implements prometheus.Collector
+func (c *StorageEngineCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	for _, cf := range stats.ColumnFamilies {
+		for level, files := range cf.LevelFiles {
+			ch <- prometheus.MustNewConstMetric(c.levelFiles, prometheus.GaugeValue, float64(files), cf.Name, strconv.Itoa(level))
+		}
+		ch <- prometheus.MustNewConstMetric(c.pendingCompactionBytes, prometheus.GaugeValue, float64(cf.PendingCompactionBytes), cf.Name)
+		ch <- prometheus.MustNewConstMetric(c.memtableBytes, prometheus.GaugeValue, float64(cf.MemtableBytes), cf.Name)
+		ch <- prometheus.MustNewConstMetric(c.immutableMemtables, prometheus.GaugeValue, float64(cf.ImmutableMemtables), cf.Name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.blockCacheUsage, prometheus.GaugeValue, float64(stats.BlockCacheUsage))
+	ch <- prometheus.MustNewConstMetric(c.blockCacheCapacity, prometheus.GaugeValue, float64(stats.BlockCacheCapacity))
+	if !stats.Statistics {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.blockCacheHits, prometheus.CounterValue, float64(stats.BlockCacheHits))
+	ch <- prometheus.MustNewConstMetric(c.blockCacheMisses, prometheus.CounterValue, float64(stats.BlockCacheMisses))
+	ch <- prometheus.MustNewConstMetric(c.writeStalls, prometheus.CounterValue, float64(stats.WriteStalls))
+	ch <- prometheus.MustNewConstMetric(c.writeStallSeconds, prometheus.CounterValue, float64(stats.WriteStallMicros)/1e6)
+}
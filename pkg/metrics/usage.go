@@ -0,0 +1,115 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/pkg/usage"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// UsageCollector exports the per-prefix usage of the last keyspace scan
+// The number of prefixes is bounded by usage.max_prefixes
+type UsageCollector struct {
+	report func() usage.Report
+
+	keys      *prometheus.Desc
+	bytes     *prometheus.Desc
+	writes    *prometheus.Desc
+	writeRate *prometheus.Desc
+	watches   *prometheus.Desc
+	topKey    *prometheus.Desc
+	scanned   *prometheus.Desc
+	duration  *prometheus.Desc
+}
+
+// NewUsageCollector creates a collector for the given report getter
+func NewUsageCollector(report func() usage.Report) *UsageCollector {
+	return &UsageCollector{
+		report: report,
+		keys: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "prefix_keys"),
+			"Number of keys under the prefix at the last scan",
+			[]string{"prefix"}, nil,
+		),
+		bytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "prefix_bytes"),
+			"Total size of the keys and values under the prefix at the last scan",
+			[]string{"prefix"}, nil,
+		),
+		writes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "prefix_writes_total"),
+			"Total number of puts and deletes under the prefix",
+			[]string{"prefix"}, nil,
+		),
+		writeRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "prefix_write_rate"),
+			"Writes per second under the prefix over the last scan interval",
+			[]string{"prefix"}, nil,
+		),
+		watches: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "prefix_watches"),
+			"Number of client watches on this node starting under the prefix at the last scan",
+			[]string{"prefix"}, nil,
+		),
+		topKey: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "top_key_bytes"),
+			"Size of the largest keys at the last scan",
+			[]string{"key"}, nil,
+		),
+		scanned: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "last_scan_timestamp_seconds"),
+			"Unix time of the last completed keyspace scan",
+			nil, nil,
+		),
+		duration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "usage", "last_scan_duration_seconds"),
+			"Duration of the last completed keyspace scan",
+			nil, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *UsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.keys
+	ch <- c.bytes
+	ch <- c.writes
+	ch <- c.writeRate
+	ch <- c.watches
+	ch <- c.topKey
+	ch <- c.scanned
+	ch <- c.duration
+}
+
+// Collect implements prometheus.Collector
+func (c *UsageCollector) Collect(ch chan<- prometheus.Metric) {
+	r := c.report()
+	if r.ScannedAt.IsZero() {
+		return
+	}
+	for _, p := range r.Prefixes {
+		ch <- prometheus.MustNewConstMetric(c.keys, prometheus.GaugeValue, float64(p.Keys), p.Prefix)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(p.Bytes), p.Prefix)
+		ch <- prometheus.MustNewConstMetric(c.writes, prometheus.CounterValue, float64(p.Writes), p.Prefix)
+		ch <- prometheus.MustNewConstMetric(c.writeRate, prometheus.GaugeValue, p.WriteRate, p.Prefix)
+		ch <- prometheus.MustNewConstMetric(c.watches, prometheus.GaugeValue, float64(p.Watches), p.Prefix)
+	}
+	for _, k := range r.TopKeys {
+		ch <- prometheus.MustNewConstMetric(c.topKey, prometheus.GaugeValue, float64(k.Bytes), k.Key)
+	}
+	ch <- prometheus.MustNewConstMetric(c.scanned, prometheus.GaugeValue, float64(r.ScannedAt.UnixNano())/1e9)
+	ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, r.Duration.Seconds())
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ValueSizeCollector exports the value size limits, chunking.max_value_size
+// and its per-prefix overrides, with the writes each of them rejected. The
+// prefix label is empty for the default limit
+type ValueSizeCollector struct {
+	stats func() []kvstore.ValueSizeStats
+
+	limit    *prometheus.Desc
+	rejected *prometheus.Desc
+}
+
+// NewValueSizeCollector creates a collector for the given stats getter
+func NewValueSizeCollector(stats func() []kvstore.ValueSizeStats) *ValueSizeCollector {
+	labels := []string{"prefix"}
+	return &ValueSizeCollector{
+		stats: stats,
+		limit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "value_size", "limit_bytes"),
+			"Largest value accepted for keys under the prefix",
+			labels, nil,
+		),
+		rejected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "value_size", "rejections_total"),
+			"Total number of writes rejected because a value exceeded the limit of its prefix",
+			labels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *ValueSizeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.limit
+	ch <- c.rejected
+}
+
+// Collect implements prometheus.Collector
+func (c *ValueSizeCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.stats() {
+		ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(s.Limit), s.Prefix)
+		ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(s.Rejected), s.Prefix)
+	}
+}
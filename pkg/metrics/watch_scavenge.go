@@ -0,0 +1,53 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"metaStore/internal/kvstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WatchScavengeCollector exports the watches canceled by the watch scavenger;
+// a growing count means streams end without their watches being canceled
+type WatchScavengeCollector struct {
+	stats func() kvstore.WatchScavengeStats
+
+	scavenged *prometheus.Desc
+}
+
+// NewWatchScavengeCollector creates a collector for the given stats getter
+func NewWatchScavengeCollector(stats func() kvstore.WatchScavengeStats) *WatchScavengeCollector {
+	return &WatchScavengeCollector{
+		stats: stats,
+		scavenged: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "watch", "scavenged_total"),
+			"Total number of orphaned watches canceled by the scavenger, by reason",
+			[]string{"reason"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *WatchScavengeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.scavenged
+}
+
+// Collect implements prometheus.Collector
+func (c *WatchScavengeCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.scavenged, prometheus.CounterValue, float64(stats.StreamClosed), "stream_closed")
+	ch <- prometheus.MustNewConstMetric(c.scavenged, prometheus.CounterValue, float64(stats.Orphaned), "orphaned")
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"errors"
+	"fmt"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotFound is returned for a mirror name that is not configured
+	ErrNotFound = errors.New("mirror: not found")
+	// ErrAlreadyRunning is returned when starting a running mirror
+	ErrAlreadyRunning = errors.New("mirror: already running")
+	// ErrNotRunning is returned when stopping a stopped mirror
+	ErrNotRunning = errors.New("mirror: not running")
+)
+
+// watchIDBase 内部 watch 使用负数 ID，避免与客户端 watch 冲突
+const watchIDBase int64 = -1 << 40
+
+// Manager 管理配置中的所有 mirror
+// 每个节点都创建 Manager，但只有 leader 上的 mirror 会真正复制
+type Manager struct {
+	mirrors map[string]*mirror
+	names   []string // 保持配置顺序
+}
+
+// NewManager 根据配置创建 mirror，不会自动启动
+func NewManager(store kvstore.Store, cfg config.MirrorConfig) *Manager {
+	return newManager(store, cfg, dialRemote)
+}
+
+func newManager(store kvstore.Store, cfg config.MirrorConfig, dial func(config.MirrorSpec) (remote, error)) *Manager {
+	mgr := &Manager{mirrors: make(map[string]*mirror, len(cfg.Mirrors))}
+	for i, spec := range cfg.Mirrors {
+		mgr.mirrors[spec.Name] = &mirror{
+			spec:               spec,
+			store:              store,
+			dial:               dial,
+			checkpointInterval: cfg.CheckpointInterval,
+			watchID:            watchIDBase - int64(i),
+		}
+		mgr.names = append(mgr.names, spec.Name)
+	}
+	return mgr
+}
+
+// StartConfigured 启动配置了 auto_start 的 mirror
+func (mgr *Manager) StartConfigured() {
+	for _, name := range mgr.names {
+		m := mgr.mirrors[name]
+		if !m.spec.AutoStart {
+			continue
+		}
+		if err := m.start(); err != nil {
+			log.Warn("Failed to start mirror", zap.String("mirror", name), zap.Error(err), zap.String("component", "mirror"))
+			continue
+		}
+		log.Info("Mirror started",
+			zap.String("mirror", name),
+			zap.Strings("endpoints", m.spec.Endpoints),
+			zap.String("prefix", m.spec.Prefix),
+			zap.String("component", "mirror"))
+	}
+}
+
+// Start 启动指定 mirror
+func (mgr *Manager) Start(name string) error {
+	m, ok := mgr.mirrors[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err := m.start(); err != nil {
+		return err
+	}
+	log.Info("Mirror started", zap.String("mirror", name), zap.String("component", "mirror"))
+	return nil
+}
+
+// Stop 停止指定 mirror，已复制的 revision 会在停止前持久化
+func (mgr *Manager) Stop(name string) error {
+	m, ok := mgr.mirrors[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err := m.stop(); err != nil {
+		return err
+	}
+	log.Info("Mirror stopped", zap.String("mirror", name), zap.String("component", "mirror"))
+	return nil
+}
+
+// List 返回所有 mirror 的状态
+func (mgr *Manager) List() []Status {
+	statuses := make([]Status, 0, len(mgr.names))
+	for _, name := range mgr.names {
+		statuses = append(statuses, mgr.mirrors[name].status())
+	}
+	return statuses
+}
+
+// Close 停止所有运行中的 mirror
+func (mgr *Manager) Close() {
+	for _, name := range mgr.names {
+		mgr.mirrors[name].stop()
+	}
+}
@@ -0,0 +1,398 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mirror 实现跨数据中心异步复制
+//
+// leader 订阅本地 watch 流，将前缀下的变更按 revision 顺序重放到远端 MetaStore/etcd 集群，
+// 并定期把已复制的 revision 写入本地 store（随 Raft 复制），leader 切换后新 leader 从断点继续
+//
+// 每次（重新）连接时先做一次全量对齐：写入 checkpoint 之后修改过的 key，删除远端存在而本地已不存在的 key，
+// 因此 mirror 停止期间的删除也能同步到远端。远端的目标前缀由 mirror 独占
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errNotLeader    = errors.New("mirror: not leader")
+	errWatchClosed  = errors.New("mirror: local watch closed")
+	leaderPollDelay = time.Second
+)
+
+const (
+	// applyTimeout 单次远端写入的超时时间
+	applyTimeout = 30 * time.Second
+	// retryMin/retryMax 会话失败后重连的退避区间
+	retryMin = time.Second
+	retryMax = 30 * time.Second
+)
+
+// Status 单个 mirror 的运行状态
+type Status struct {
+	Name       string   `json:"name"`
+	Endpoints  []string `json:"endpoints"`
+	Prefix     string   `json:"prefix"`
+	DestPrefix string   `json:"dest_prefix"`
+	Running    bool     `json:"running"`         // 已启动（本节点成为 leader 时才会真正复制）
+	Active     bool     `json:"active"`          // 本节点是 leader 且正在复制
+	Revision   int64    `json:"revision"`        // 已复制到远端的本地 revision
+	Error      string   `json:"error,omitempty"` // 最近一次会话失败的原因
+}
+
+// mirror 单个远端复制任务
+type mirror struct {
+	spec               config.MirrorSpec
+	store              kvstore.Store
+	dial               func(config.MirrorSpec) (remote, error)
+	checkpointInterval time.Duration
+	watchID            int64
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+	active   bool
+	revision int64
+	lastErr  string
+}
+
+// checkpointKey 保存已复制 revision 的本地 key
+func (m *mirror) checkpointKey() string {
+	return kvstore.SystemKeyPrefix + "mirror/" + m.spec.Name
+}
+
+func (m *mirror) start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return ErrAlreadyRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	m.lastErr = ""
+	go m.loop(ctx, m.done)
+	return nil
+}
+
+func (m *mirror) stop() error {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return ErrNotRunning
+	}
+	cancel()
+	<-done
+	return nil
+}
+
+func (m *mirror) status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Status{
+		Name:       m.spec.Name,
+		Endpoints:  m.spec.Endpoints,
+		Prefix:     m.spec.Prefix,
+		DestPrefix: m.spec.DestPrefix,
+		Running:    m.cancel != nil,
+		Active:     m.active,
+		Revision:   m.revision,
+		Error:      m.lastErr,
+	}
+}
+
+// loop 只在本节点是 leader 时运行复制会话，会话失败后退避重试
+func (m *mirror) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	backoff := retryMin
+	for {
+		delay := leaderPollDelay
+		if m.isLeader() {
+			err := m.session(ctx)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case errors.Is(err, errNotLeader):
+				backoff = retryMin
+			case err != nil:
+				m.setError(err)
+				log.Warn("Mirror session failed",
+					zap.String("mirror", m.spec.Name),
+					zap.Error(err),
+					zap.Duration("retry_in", backoff),
+					zap.String("component", "mirror"))
+				delay = backoff
+				backoff = min(backoff*2, retryMax)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// session 连接远端、对齐数据并持续重放 watch 事件，直到失去 leader 或出错
+func (m *mirror) session(ctx context.Context) error {
+	rem, err := m.dial(m.spec)
+	if err != nil {
+		return fmt.Errorf("connect remote: %w", err)
+	}
+	defer rem.close()
+
+	checkpoint, err := m.loadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("load checkpoint: %w", err)
+	}
+
+	// 先注册 watch 再读取快照，快照之后的变更都会出现在事件流中
+	key, rangeEnd := kvstore.PrefixRange(m.spec.Prefix)
+	events, err := m.store.Watch(ctx, key, rangeEnd, 0, m.watchID)
+	if err != nil {
+		return fmt.Errorf("watch local store: %w", err)
+	}
+	defer m.store.CancelWatch(m.watchID)
+
+	snapRev, err := m.sync(ctx, rem, checkpoint)
+	if err != nil {
+		return fmt.Errorf("initial sync: %w", err)
+	}
+	m.setActive(true, snapRev)
+	defer m.setActive(false, 0)
+
+	saved := checkpoint
+	checkpointTicker := time.NewTicker(m.checkpointInterval)
+	defer checkpointTicker.Stop()
+	leaderTicker := time.NewTicker(leaderPollDelay)
+	defer leaderTicker.Stop()
+
+	var (
+		pending    []op
+		pendingRev int64
+		mirrored   = snapRev
+	)
+	flush := func(ctx context.Context) error {
+		if len(pending) == 0 {
+			return nil
+		}
+		applyCtx, cancel := context.WithTimeout(ctx, applyTimeout)
+		defer cancel()
+		if err := rem.apply(applyCtx, pending); err != nil {
+			return fmt.Errorf("apply to remote: %w", err)
+		}
+		pending = pending[:0]
+		if pendingRev > mirrored {
+			mirrored = pendingRev
+			m.setActive(true, mirrored)
+		}
+		return nil
+	}
+	persist := func(ctx context.Context) {
+		if mirrored <= saved {
+			return
+		}
+		if err := m.saveCheckpoint(ctx, mirrored); err != nil {
+			log.Warn("Failed to save mirror checkpoint",
+				zap.String("mirror", m.spec.Name),
+				zap.Int64("revision", mirrored),
+				zap.Error(err),
+				zap.String("component", "mirror"))
+			return
+		}
+		saved = mirrored
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 停止时用独立的 context 写完已收到的变更并保存 checkpoint
+			stopCtx, cancel := context.WithTimeout(context.Background(), applyTimeout)
+			if flush(stopCtx) == nil {
+				persist(stopCtx)
+			}
+			cancel()
+			return ctx.Err()
+
+		case ev, ok := <-events:
+			if !ok {
+				if err := flush(ctx); err != nil {
+					return err
+				}
+				persist(ctx)
+				return errWatchClosed
+			}
+			rev := eventRevision(ev)
+			if ev.Kv == nil || kvstore.IsSystemKey(string(ev.Kv.Key)) || (rev != 0 && rev <= snapRev) {
+				continue
+			}
+			// 同一 revision（同一事务）的变更一起提交
+			if rev != pendingRev {
+				if err := flush(ctx); err != nil {
+					return err
+				}
+			}
+			pending = append(pending, m.toOp(ev))
+			pendingRev = max(pendingRev, rev)
+			if len(events) == 0 {
+				if err := flush(ctx); err != nil {
+					return err
+				}
+			}
+
+		case <-checkpointTicker.C:
+			persist(ctx)
+
+		case <-leaderTicker.C:
+			if !m.isLeader() {
+				if err := flush(ctx); err != nil {
+					return err
+				}
+				persist(ctx)
+				return errNotLeader
+			}
+		}
+	}
+}
+
+// sync 将本地快照与远端对齐，返回快照对应的 revision
+func (m *mirror) sync(ctx context.Context, rem remote, checkpoint int64) (int64, error) {
+	// 使用读取前的 revision，保守地把读取期间的变更交给事件流重放
+	snapRev := m.store.CurrentRevision()
+	key, rangeEnd := kvstore.PrefixRange(m.spec.Prefix)
+	resp, err := m.store.Range(ctx, key, rangeEnd, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	local := make(map[string]bool, len(resp.Kvs))
+	var ops []op
+	for _, kv := range resp.Kvs {
+		if kvstore.IsSystemKey(string(kv.Key)) {
+			continue
+		}
+		dest := m.destKey(string(kv.Key))
+		local[dest] = true
+		if kv.ModRevision > checkpoint {
+			ops = append(ops, op{key: dest, value: kv.Value})
+		}
+	}
+
+	remoteKeys, err := rem.keys(ctx, m.destListPrefix())
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range remoteKeys {
+		if !local[k] && !kvstore.IsSystemKey(k) {
+			ops = append(ops, op{key: k, delete: true})
+		}
+	}
+
+	applyCtx, cancel := context.WithTimeout(ctx, applyTimeout)
+	defer cancel()
+	if err := rem.apply(applyCtx, ops); err != nil {
+		return 0, err
+	}
+	return snapRev, nil
+}
+
+func (m *mirror) toOp(ev kvstore.WatchEvent) op {
+	dest := m.destKey(string(ev.Kv.Key))
+	if ev.Type == kvstore.EventTypeDelete {
+		return op{key: dest, delete: true}
+	}
+	return op{key: dest, value: ev.Kv.Value}
+}
+
+// destKey 将本地 key 改写为远端 key
+func (m *mirror) destKey(key string) string {
+	if m.spec.DestPrefix == "" {
+		return key
+	}
+	return m.spec.DestPrefix + strings.TrimPrefix(key, m.spec.Prefix)
+}
+
+// destListPrefix 远端由本 mirror 管理的前缀
+func (m *mirror) destListPrefix() string {
+	if m.spec.DestPrefix != "" {
+		return m.spec.DestPrefix
+	}
+	return m.spec.Prefix
+}
+
+func (m *mirror) loadCheckpoint(ctx context.Context) (int64, error) {
+	resp, err := m.store.Range(ctx, m.checkpointKey(), "", 1, 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+}
+
+func (m *mirror) saveCheckpoint(ctx context.Context, rev int64) error {
+	_, _, err := m.store.PutWithLease(ctx, m.checkpointKey(), strconv.FormatInt(rev, 10), 0)
+	return err
+}
+
+func (m *mirror) isLeader() bool {
+	st := m.store.GetRaftStatus()
+	return st.LeaderID != 0 && st.LeaderID == st.NodeID
+}
+
+func (m *mirror) setActive(active bool, rev int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active = active
+	if active {
+		m.revision = rev
+		m.lastErr = ""
+	}
+}
+
+func (m *mirror) setError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastErr = err.Error()
+}
+
+// eventRevision 返回事件对应的 revision，未知时返回 0
+func eventRevision(ev kvstore.WatchEvent) int64 {
+	if ev.Revision != 0 {
+		return ev.Revision
+	}
+	if ev.Kv != nil {
+		return ev.Kv.ModRevision
+	}
+	return 0
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRemote 记录写入的内存远端
+type fakeRemote struct {
+	mu   sync.Mutex
+	data map[string]string
+	puts []string // 所有写入过的 key，按顺序
+}
+
+func newFakeRemote(data map[string]string) *fakeRemote {
+	return &fakeRemote{data: data}
+}
+
+func (r *fakeRemote) apply(ctx context.Context, ops []op) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range ops {
+		if o.delete {
+			delete(r.data, o.key)
+		} else {
+			r.data[o.key] = string(o.value)
+			r.puts = append(r.puts, o.key)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRemote) keys(ctx context.Context, prefix string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []string
+	for k := range r.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeRemote) close() error { return nil }
+
+func (r *fakeRemote) snapshot() (map[string]string, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return maps.Clone(r.data), append([]string(nil), r.puts...)
+}
+
+func TestMirrorReplicatesWithCheckpoint(t *testing.T) {
+	oldDelay := leaderPollDelay
+	leaderPollDelay = 10 * time.Millisecond
+	defer func() { leaderPollDelay = oldDelay }()
+
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	put := func(k, v string) {
+		_, _, err := store.PutWithLease(ctx, k, v, 0)
+		require.NoError(t, err)
+	}
+	del := func(k string) {
+		_, _, _, err := store.DeleteRange(ctx, k, "")
+		require.NoError(t, err)
+	}
+	put("app/a", "1")
+	put("app/b", "2")
+	put("other/x", "ignored")
+
+	rem := newFakeRemote(map[string]string{"dc1/app/stale": "old", "unrelated": "keep"})
+	cfg := config.MirrorConfig{
+		CheckpointInterval: 20 * time.Millisecond,
+		Mirrors:            []config.MirrorSpec{{Name: "dc2", Prefix: "app/", DestPrefix: "dc1/app/"}},
+	}
+	mgr := newManager(store, cfg, func(config.MirrorSpec) (remote, error) { return rem, nil })
+	defer mgr.Close()
+
+	remoteEquals := func(want map[string]string) {
+		require.Eventually(t, func() bool {
+			got, _ := rem.snapshot()
+			return maps.Equal(got, want)
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// 初始对齐：改写前缀，删除远端多余的 key，不触碰前缀外的 key
+	require.NoError(t, mgr.Start("dc2"))
+	assert.ErrorIs(t, mgr.Start("dc2"), ErrAlreadyRunning)
+	remoteEquals(map[string]string{"dc1/app/a": "1", "dc1/app/b": "2", "unrelated": "keep"})
+
+	// 实时重放
+	put("app/c", "3")
+	del("app/a")
+	put("other/y", "ignored")
+	remoteEquals(map[string]string{"dc1/app/b": "2", "dc1/app/c": "3", "unrelated": "keep"})
+
+	status := mgr.List()
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Running)
+
+	// 停止时保存 checkpoint
+	require.NoError(t, mgr.Stop("dc2"))
+	assert.ErrorIs(t, mgr.Stop("dc2"), ErrNotRunning)
+	resp, err := store.Range(ctx, "__metastore/mirror/dc2", "", 1, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	checkpoint, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, checkpoint, int64(5))
+
+	// 停止期间的修改在重新启动后同步，checkpoint 之前的 key 不再重复写入
+	del("app/b")
+	put("app/d", "4")
+	_, before := rem.snapshot()
+	require.NoError(t, mgr.Start("dc2"))
+	remoteEquals(map[string]string{"dc1/app/c": "3", "dc1/app/d": "4", "unrelated": "keep"})
+	_, after := rem.snapshot()
+	assert.Equal(t, []string{"dc1/app/d"}, after[len(before):])
+
+	assert.ErrorIs(t, mgr.Start("missing"), ErrNotFound)
+}
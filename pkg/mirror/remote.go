@@ -0,0 +1,104 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"context"
+
+	"metaStore/pkg/config"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// maxTxnOps 单个远端事务的最大操作数（etcd 默认 --max-txn-ops 为 128）
+const maxTxnOps = 128
+
+// op 重放到远端的一次写入
+type op struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+// remote 远端集群
+type remote interface {
+	// apply 按顺序写入一组操作，同一本地 revision 的操作在同一事务中提交
+	apply(ctx context.Context, ops []op) error
+	// keys 列出远端前缀下的所有 key
+	keys(ctx context.Context, prefix string) ([]string, error)
+	close() error
+}
+
+// etcdRemote 通过 etcd v3 客户端访问远端 MetaStore/etcd 集群
+type etcdRemote struct {
+	client *clientv3.Client
+}
+
+func dialRemote(spec config.MirrorSpec) (remote, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   spec.Endpoints,
+		DialTimeout: spec.DialTimeout,
+		Username:    spec.Username,
+		Password:    spec.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &etcdRemote{client: client}, nil
+}
+
+func (r *etcdRemote) apply(ctx context.Context, ops []op) error {
+	for len(ops) > 0 {
+		n := min(len(ops), maxTxnOps)
+		txnOps := make([]clientv3.Op, 0, n)
+		for _, o := range ops[:n] {
+			if o.delete {
+				txnOps = append(txnOps, clientv3.OpDelete(o.key))
+			} else {
+				txnOps = append(txnOps, clientv3.OpPut(o.key, string(o.value)))
+			}
+		}
+		if _, err := r.client.Txn(ctx).Then(txnOps...).Commit(); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+func (r *etcdRemote) keys(ctx context.Context, prefix string) ([]string, error) {
+	opts := []clientv3.OpOption{clientv3.WithKeysOnly()}
+	key := prefix
+	if prefix == "" {
+		key = "\x00"
+		opts = append(opts, clientv3.WithFromKey())
+	} else {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	resp, err := r.client.Get(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		keys = append(keys, string(kv.Key))
+	}
+	return keys, nil
+}
+
+func (r *etcdRemote) close() error {
+	return r.client.Close()
+}
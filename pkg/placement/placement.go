@@ -0,0 +1,129 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package placement 实现感知故障域的 leader 放置策略
+//
+// 每个成员在配置中声明所在的 zone 和标签，并登记到成员注册表。leader 定期按策略检查
+// 自己是否合适：优先留在首选 leader（preferred leaders）上，其次留在主 zone（primary
+// zone）中，witness 节点不保存数据，不应成为 leader。存在更合适的活跃成员时转移 leader，
+// 适用于各可用区之间延迟不对称的多 AZ 部署
+//
+// 策略可以在运行时通过管理接口修改，保存在 __metastore/placement 下并随 Raft 复制，
+// 没有设置时使用配置文件 raft.placement 中的值
+package placement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"metaStore/internal/kvstore"
+)
+
+// policyKey 集群中设置的放置策略
+const policyKey = kvstore.SystemKeyPrefix + "placement"
+
+// ErrNotSet 集群中没有设置放置策略，使用配置文件中的值
+var ErrNotSet = errors.New("placement: policy not set")
+
+// Policy leader 放置策略
+type Policy struct {
+	PrimaryZone      string   `json:"primary_zone,omitempty"`      // leader 优先留在这个 zone 中
+	PreferredLeaders []uint64 `json:"preferred_leaders,omitempty"` // 首选 leader 的成员 ID，排在前面的优先
+}
+
+// IsZero 返回策略是否为空，空策略不会主动转移 leader（witness 除外）
+func (p Policy) IsZero() bool {
+	return p.PrimaryZone == "" && len(p.PreferredLeaders) == 0
+}
+
+// Member leader 选择时考虑的成员信息
+type Member struct {
+	ID      uint64
+	Zone    string
+	Witness bool   // witness 不保存数据，不能成为 leader
+	Learner bool   // learner 不参与投票，不能成为 leader
+	Active  bool   // 最近一个选举周期内与 leader 有通信
+	Match   uint64 // leader 已知的复制位置
+}
+
+// rank 成员作为 leader 的合适程度，越大越合适，负数表示不能成为 leader
+func (p Policy) rank(m Member) int {
+	switch {
+	case m.Witness || m.Learner:
+		return -1
+	case slices.Contains(p.PreferredLeaders, m.ID):
+		return 3 + len(p.PreferredLeaders) - slices.Index(p.PreferredLeaders, m.ID)
+	case p.PrimaryZone == "" || m.Zone == p.PrimaryZone:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Transferee 返回 leader self 应当转移到的成员，0 表示留在本节点
+//
+// 只有存在比本节点更合适的活跃成员时才转移，同样合适的成员中选择日志最新的，
+// 日志越新转移越快
+func (p Policy) Transferee(self Member, members []Member) uint64 {
+	var best Member
+	bestRank := p.rank(self)
+	for _, m := range members {
+		if m.ID == self.ID || !m.Active {
+			continue
+		}
+		r := p.rank(m)
+		if r < 0 {
+			continue
+		}
+		if r > bestRank || (best.ID != 0 && r == bestRank && (m.Match > best.Match || (m.Match == best.Match && m.ID < best.ID))) {
+			best, bestRank = m, r
+		}
+	}
+	return best.ID
+}
+
+// Load 返回集群中设置的放置策略，没有设置时返回 ErrNotSet
+func Load(ctx context.Context, store kvstore.Store) (Policy, error) {
+	resp, err := store.Range(ctx, policyKey, "", 0, 0)
+	if err != nil {
+		return Policy{}, err
+	}
+	if len(resp.Kvs) == 0 {
+		return Policy{}, ErrNotSet
+	}
+	var p Policy
+	if err := json.Unmarshal(resp.Kvs[0].Value, &p); err != nil {
+		return Policy{}, fmt.Errorf("placement: invalid policy: %w", err)
+	}
+	return p, nil
+}
+
+// Save 在集群中设置放置策略，各节点的 leader 检查随即使用新策略
+func Save(ctx context.Context, store kvstore.Store, p Policy) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, _, err = store.PutWithLease(ctx, policyKey, string(value), 0)
+	return err
+}
+
+// Reset 删除集群中的放置策略，恢复使用配置文件中的值
+func Reset(ctx context.Context, store kvstore.Store) error {
+	_, _, _, err := store.DeleteRange(ctx, policyKey, "")
+	return err
+}
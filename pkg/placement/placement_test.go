@@ -0,0 +1,88 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"context"
+	"testing"
+
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Transferee(t *testing.T) {
+	members := []Member{
+		{ID: 1, Zone: "az1", Active: true, Match: 100},
+		{ID: 2, Zone: "az2", Active: true, Match: 100},
+		{ID: 3, Zone: "az2", Active: true, Match: 90},
+		{ID: 4, Witness: true, Active: true, Match: 100},
+		{ID: 5, Zone: "az2", Learner: true, Active: true, Match: 100},
+	}
+	self := func(id uint64) Member {
+		for _, m := range members {
+			if m.ID == id {
+				return m
+			}
+		}
+		return Member{ID: id}
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		self   uint64
+		want   uint64
+	}{
+		{"empty policy keeps the leader", Policy{}, 1, 0},
+		{"already in primary zone", Policy{PrimaryZone: "az2"}, 3, 0},
+		{"move into primary zone, most up-to-date first", Policy{PrimaryZone: "az2"}, 1, 2},
+		{"no candidate in primary zone", Policy{PrimaryZone: "az3"}, 1, 0},
+		{"preferred leader", Policy{PreferredLeaders: []uint64{3}}, 1, 3},
+		{"earlier preferred leader wins", Policy{PreferredLeaders: []uint64{3, 2}}, 2, 3},
+		{"preferred beats primary zone", Policy{PrimaryZone: "az2", PreferredLeaders: []uint64{1}}, 2, 1},
+		{"witness and learner are never chosen", Policy{PreferredLeaders: []uint64{4, 5}}, 1, 0},
+		{"witness leader hands over", Policy{}, 4, 1},
+		{"witness leader prefers primary zone", Policy{PrimaryZone: "az2"}, 4, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.Transferee(self(tt.self), members))
+		})
+	}
+
+	// Inactive members are skipped
+	inactive := []Member{{ID: 2, Zone: "az2", Match: 100}, {ID: 3, Zone: "az2", Active: true, Match: 90}}
+	assert.Equal(t, uint64(3), Policy{PrimaryZone: "az2"}.Transferee(self(1), inactive))
+}
+
+func TestPolicy_Store(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+
+	_, err := Load(ctx, store)
+	require.ErrorIs(t, err, ErrNotSet)
+
+	want := Policy{PrimaryZone: "az1", PreferredLeaders: []uint64{2, 1}}
+	require.NoError(t, Save(ctx, store, want))
+	got, err := Load(ctx, store)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	require.NoError(t, Reset(ctx, store))
+	_, err = Load(ctx, store)
+	require.ErrorIs(t, err, ErrNotSet)
+}
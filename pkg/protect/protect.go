@@ -0,0 +1,231 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protect 为配置的前缀提供删除保护
+//
+// deny 模式的前缀下有 key 会被删除时整个请求失败；trash 模式下被删除的 value
+// 与删除本身在同一个事务中写入回收站（__metastore/trash/），保留期内可以恢复，
+// 过期的条目由 leader 定期清理。
+//
+// 与 sqlindex 一样，删除前先读出受保护范围内的 key：预读与事务之间被修改的 key
+// 在回收站中保存的是预读时的 value，期间新建的 key 会被删除但不进入回收站
+package protect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+)
+
+// ErrProtected 删除涉及 deny 模式的前缀
+var ErrProtected = errors.New("protect: delete protected prefix")
+
+// rule 单个受保护的前缀
+type rule struct {
+	prefix    string
+	start     string // 前缀对应的区间，end 为空表示没有上界
+	end       string
+	mode      string
+	retention time.Duration
+}
+
+// Store 拦截受保护前缀上的删除
+type Store struct {
+	kvstore.Store
+	rules []rule // 按前缀长度降序，嵌套的前缀以最长的为准
+	now   func() time.Time
+
+	purgeInterval time.Duration
+	stopC         chan struct{}
+	doneC         chan struct{}
+}
+
+// Wrap 返回提供删除保护的存储，并在 leader 上定期清理过期的回收站条目
+func Wrap(store kvstore.Store, cfg config.DeleteProtectionConfig) *Store {
+	s := &Store{
+		Store:         store,
+		now:           time.Now,
+		purgeInterval: cfg.PurgeInterval,
+		stopC:         make(chan struct{}),
+		doneC:         make(chan struct{}),
+	}
+	for _, p := range cfg.Prefixes {
+		start, end := bounds(kvstore.PrefixRange(p.Prefix))
+		s.rules = append(s.rules, rule{prefix: p.Prefix, start: start, end: end, mode: p.Mode, retention: p.Retention})
+	}
+	sort.SliceStable(s.rules, func(i, j int) bool { return len(s.rules[i].prefix) > len(s.rules[j].prefix) })
+	if s.purgeInterval <= 0 {
+		s.purgeInterval = time.Minute
+	}
+
+	go s.purgeLoop()
+	return s
+}
+
+// Unwrap 实现 kvstore.Wrapper，供调用方查找底层存储的其他能力
+func (s *Store) Unwrap() kvstore.Store {
+	return s.Store
+}
+
+// RangeFunc 读取不受影响，转发给底层存储以保留流式读取
+func (s *Store) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.RangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// ReverseRangeFunc 同样转发给底层存储
+func (s *Store) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.ReverseRangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// Close 停止清理回收站
+func (s *Store) Close() {
+	close(s.stopC)
+	<-s.doneC
+}
+
+func (s *Store) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	op := kvstore.Op{Type: kvstore.OpDelete, Key: []byte(key), RangeEnd: []byte(rangeEnd)}
+	trashOps, err := s.protect(ctx, []kvstore.Op{op})
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if len(trashOps) == 0 {
+		return s.Store.DeleteRange(ctx, key, rangeEnd)
+	}
+
+	resp, err := s.Store.Txn(ctx, nil, append([]kvstore.Op{op}, trashOps...), nil)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	del := resp.Responses[0].DeleteResp
+	return del.Deleted, del.PrevKvs, del.Revision, nil
+}
+
+// Txn 检查两个分支中的删除，任一分支涉及 deny 前缀则整个事务被拒绝
+func (s *Store) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+	thenTrash, err := s.protect(ctx, thenOps)
+	if err != nil {
+		return nil, err
+	}
+	elseTrash, err := s.protect(ctx, elseOps)
+	if err != nil {
+		return nil, err
+	}
+	if len(thenTrash) == 0 && len(elseTrash) == 0 {
+		return s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	}
+
+	resp, err := s.Store.Txn(ctx, cmps,
+		append(thenOps[:len(thenOps):len(thenOps)], thenTrash...),
+		append(elseOps[:len(elseOps):len(elseOps)], elseTrash...))
+	if err != nil {
+		return nil, err
+	}
+	// 去掉回收站操作的响应，调用方看到的响应与自己的操作一一对应
+	n := len(elseOps)
+	if resp.Succeeded {
+		n = len(thenOps)
+	}
+	if len(resp.Responses) > n {
+		resp.Responses = resp.Responses[:n]
+	}
+	return resp, nil
+}
+
+// protect 检查 ops 中的删除，返回把受保护的 value 写入回收站的操作
+func (s *Store) protect(ctx context.Context, ops []kvstore.Op) ([]kvstore.Op, error) {
+	if len(s.rules) == 0 {
+		return nil, nil
+	}
+
+	var trashOps []kvstore.Op
+	seen := make(map[string]struct{})
+	for _, op := range ops {
+		if op.Type != kvstore.OpDelete {
+			continue
+		}
+		key, rangeEnd := string(op.Key), string(op.RangeEnd)
+		if rangeEnd == "" && kvstore.IsSystemKey(key) {
+			continue
+		}
+
+		for _, r := range s.rules {
+			start, end, ok := intersect(key, rangeEnd, r)
+			if !ok {
+				continue
+			}
+			resp, err := s.Store.Range(ctx, start, end, 0, 0)
+			if err != nil {
+				return nil, fmt.Errorf("protect: read %q: %w", start, err)
+			}
+			for _, kv := range resp.Kvs {
+				k := string(kv.Key)
+				if _, dup := seen[k]; dup || s.ruleFor(k) != r.prefix {
+					continue
+				}
+				seen[k] = struct{}{}
+				if r.mode == config.DeleteProtectionDeny {
+					return nil, fmt.Errorf("%w: %q is under %q", ErrProtected, k, r.prefix)
+				}
+				entry, err := json.Marshal(newEntry(kv, s.now(), r.retention))
+				if err != nil {
+					return nil, err
+				}
+				trashOps = append(trashOps, kvstore.Op{Type: kvstore.OpPut, Key: []byte(trashKey(k)), Value: entry})
+			}
+		}
+	}
+	return trashOps, nil
+}
+
+// ruleFor 返回 key 所属的最长受保护前缀
+func (s *Store) ruleFor(key string) string {
+	for _, r := range s.rules {
+		if key >= r.start && (r.end == "" || key < r.end) {
+			return r.prefix
+		}
+	}
+	return ""
+}
+
+// bounds 把 etcd 语义的 [key, rangeEnd) 转换为半开区间，end 为空表示没有上界
+func bounds(key, rangeEnd string) (string, string) {
+	switch rangeEnd {
+	case "":
+		return key, key + "\x00"
+	case "\x00":
+		return key, ""
+	default:
+		return key, rangeEnd
+	}
+}
+
+// intersect 返回删除范围与受保护前缀的交集，以 etcd 语义的 [key, rangeEnd) 表示
+func intersect(key, rangeEnd string, r rule) (string, string, bool) {
+	start, end := bounds(key, rangeEnd)
+	start = max(start, r.start)
+	if end == "" || (r.end != "" && r.end < end) {
+		end = r.end
+	}
+	if end == "" {
+		return start, "\x00", true
+	}
+	return start, end, start < end
+}
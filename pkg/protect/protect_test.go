@@ -0,0 +1,128 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protect
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProtectedStore 返回按 prefixes 保护的包装存储
+func newProtectedStore(t *testing.T, prefixes ...config.ProtectedPrefixConfig) (*Store, *memory.MemoryEtcd) {
+	base := memory.NewMemoryEtcd()
+	s := Wrap(base, config.DeleteProtectionConfig{Prefixes: prefixes, PurgeInterval: time.Hour})
+	t.Cleanup(s.Close)
+	return s, base
+}
+
+func TestDenyRejectsDelete(t *testing.T) {
+	ctx := context.Background()
+	s, base := newProtectedStore(t, config.ProtectedPrefixConfig{Prefix: "registry/", Mode: config.DeleteProtectionDeny})
+
+	_, _, err := s.PutWithLease(ctx, "registry/a", "1", 0)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "tmp/a", "1", 0)
+	require.NoError(t, err)
+
+	// 范围删除覆盖受保护的前缀
+	_, _, _, err = s.DeleteRange(ctx, "", "\x00")
+	assert.ErrorIs(t, err, ErrProtected)
+	_, err = s.Txn(ctx, nil, nil, []kvstore.Op{{Type: kvstore.OpDelete, Key: []byte("registry/a")}})
+	assert.ErrorIs(t, err, ErrProtected)
+
+	resp, err := base.Range(ctx, "registry/a", "", 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 1)
+
+	deleted, _, _, err := s.DeleteRange(ctx, "tmp/a", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	// 前缀下没有 key 时删除不受影响
+	_, _, _, err = s.DeleteRange(ctx, "registry/missing", "")
+	assert.NoError(t, err)
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	ctx := context.Background()
+	s, base := newProtectedStore(t, config.ProtectedPrefixConfig{Prefix: "app/", Mode: config.DeleteProtectionTrash, Retention: time.Hour})
+
+	for _, k := range []string{"app/a", "app/b", "other"} {
+		_, _, err := s.PutWithLease(ctx, k, "v-"+k, 0)
+		require.NoError(t, err)
+	}
+
+	deleted, prevKvs, _, err := s.DeleteRange(ctx, "", "\x00")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.Len(t, prevKvs, 3)
+
+	// 只有受保护前缀下的 key 进入回收站
+	entries, err := List(ctx, base, "", "\x00")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "app/a", entries[0].Key)
+	assert.Equal(t, []byte("v-app/a"), entries[0].Value)
+
+	// 原 key 已经被删除
+	resp, err := s.Range(ctx, "app/", "app0", 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, resp.Kvs, 0)
+
+	_, _, err = s.PutWithLease(ctx, "app/b", "new", 0)
+	require.NoError(t, err)
+
+	n, err := Restore(ctx, base, "app/", "app0", false)
+	assert.ErrorIs(t, err, ErrExists)
+	assert.Equal(t, 1, n)
+
+	resp, err = base.Range(ctx, "app/a", "", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, []byte("v-app/a"), resp.Kvs[0].Value)
+
+	// 覆盖恢复
+	n, err = Restore(ctx, base, "app/b", "", true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	resp, err = base.Range(ctx, "app/b", "", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v-app/b"), resp.Kvs[0].Value)
+
+	_, err = Restore(ctx, base, "app/b", "", true)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 过期的条目被清理
+	_, _, _, err = s.DeleteRange(ctx, "app/a", "")
+	require.NoError(t, err)
+	n, err = Purge(ctx, base, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	n, err = Purge(ctx, base, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	entries, err = List(ctx, base, "", "\x00")
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
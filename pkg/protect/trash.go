@@ -0,0 +1,226 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// trashPrefix 回收站条目的 key 前缀，条目 key 为 trashPrefix + 原 key
+const trashPrefix = kvstore.SystemKeyPrefix + "trash/"
+
+var (
+	// ErrNotFound 回收站中没有该 key
+	ErrNotFound = errors.New("protect: key not in trash")
+
+	// ErrExists 恢复的 key 已经存在，需要指定覆盖
+	ErrExists = errors.New("protect: key already exists")
+)
+
+// Entry 回收站中的一个被删除的 value
+// 同一个 key 多次被删除时只保留最近一次的 value
+type Entry struct {
+	Key         string    `json:"key"`
+	Value       []byte    `json:"value,omitempty"`
+	Size        int       `json:"size"`
+	ModRevision int64     `json:"mod_revision"` // 被删除的 value 的修改 revision
+	DeletedAt   time.Time `json:"deleted_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	trashRevision int64 // 条目自身的修改 revision，用于恢复和清理时检测并发修改
+}
+
+func newEntry(kv *kvstore.KeyValue, now time.Time, retention time.Duration) *Entry {
+	return &Entry{
+		Key:         string(kv.Key),
+		Value:       kv.Value,
+		Size:        len(kv.Value),
+		ModRevision: kv.ModRevision,
+		DeletedAt:   now.UTC(),
+		ExpiresAt:   now.Add(retention).UTC(),
+	}
+}
+
+func trashKey(key string) string {
+	return trashPrefix + key
+}
+
+// List 返回回收站中原 key 落在 [key, rangeEnd) 内的条目，按 key 排序
+func List(ctx context.Context, store kvstore.Store, key, rangeEnd string) ([]*Entry, error) {
+	start, end := trashKey(key), trashKey(rangeEnd)
+	switch rangeEnd {
+	case "":
+		end = ""
+	case "\x00":
+		_, end = kvstore.PrefixRange(trashPrefix)
+	}
+	resp, err := store.Range(ctx, start, end, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var e Entry
+		if err := json.Unmarshal(kv.Value, &e); err != nil {
+			return nil, fmt.Errorf("protect: decode trash entry %q: %w", kv.Key, err)
+		}
+		e.trashRevision = kv.ModRevision
+		entries = append(entries, &e)
+	}
+	return entries, nil
+}
+
+// Restore 把回收站中 [key, rangeEnd) 内的条目写回原 key 并移出回收站，返回恢复的个数
+//
+// 每个条目单独恢复。overwrite 为 false 时跳过已经存在的 key，最后返回 ErrExists；
+// 恢复的 key 不关联 lease
+func Restore(ctx context.Context, store kvstore.Store, key, rangeEnd string, overwrite bool) (int, error) {
+	entries, err := List(ctx, store, key, rangeEnd)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, ErrNotFound
+	}
+
+	restored := 0
+	var conflicts []string
+	for _, e := range entries {
+		cmps := []kvstore.Compare{{
+			Target:      kvstore.CompareMod,
+			Result:      kvstore.CompareEqual,
+			Key:         []byte(trashKey(e.Key)),
+			TargetUnion: kvstore.CompareUnion{ModRevision: e.trashRevision},
+		}}
+		if !overwrite {
+			cmps = append(cmps, kvstore.Compare{
+				Target: kvstore.CompareVersion,
+				Result: kvstore.CompareEqual,
+				Key:    []byte(e.Key),
+			})
+		}
+		ops := []kvstore.Op{
+			{Type: kvstore.OpPut, Key: []byte(e.Key), Value: e.Value},
+			{Type: kvstore.OpDelete, Key: []byte(trashKey(e.Key))},
+		}
+		resp, err := store.Txn(ctx, cmps, ops, nil)
+		if err != nil {
+			return restored, err
+		}
+		if !resp.Succeeded {
+			// 条目被并发恢复、清理或替换时同样跳过
+			conflicts = append(conflicts, e.Key)
+			continue
+		}
+		restored++
+	}
+
+	if len(conflicts) > 0 {
+		return restored, fmt.Errorf("%w: %s", ErrExists, strings.Join(conflicts, ", "))
+	}
+	return restored, nil
+}
+
+// Purge 删除 now 之前过期的回收站条目，返回删除的个数
+func Purge(ctx context.Context, store kvstore.Store, now time.Time) (int, error) {
+	entries, err := List(ctx, store, "", "\x00")
+	if err != nil {
+		return 0, err
+	}
+	expired := entries[:0]
+	for _, e := range entries {
+		if !e.ExpiresAt.After(now) {
+			expired = append(expired, e)
+		}
+	}
+	return remove(ctx, store, expired)
+}
+
+// Discard 立即删除回收站中 [key, rangeEnd) 内的条目，返回删除的个数
+func Discard(ctx context.Context, store kvstore.Store, key, rangeEnd string) (int, error) {
+	entries, err := List(ctx, store, key, rangeEnd)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, ErrNotFound
+	}
+	return remove(ctx, store, entries)
+}
+
+// remove 删除条目，读取之后被重新写入的条目保留
+func remove(ctx context.Context, store kvstore.Store, entries []*Entry) (int, error) {
+	removed := 0
+	for _, e := range entries {
+		cmps := []kvstore.Compare{{
+			Target:      kvstore.CompareMod,
+			Result:      kvstore.CompareEqual,
+			Key:         []byte(trashKey(e.Key)),
+			TargetUnion: kvstore.CompareUnion{ModRevision: e.trashRevision},
+		}}
+		ops := []kvstore.Op{{Type: kvstore.OpDelete, Key: []byte(trashKey(e.Key))}}
+		resp, err := store.Txn(ctx, cmps, ops, nil)
+		if err != nil {
+			return removed, err
+		}
+		if resp.Succeeded {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// purgeLoop 在 leader 上定期清理过期的回收站条目
+func (s *Store) purgeLoop() {
+	defer close(s.doneC)
+
+	ticker := time.NewTicker(s.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopC:
+			return
+		case <-ticker.C:
+			st := s.Store.GetRaftStatus()
+			if st.LeaderID == 0 || st.LeaderID != st.NodeID {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), s.purgeInterval)
+			n, err := Purge(ctx, s.Store, s.now())
+			cancel()
+			if err != nil {
+				log.Warn("Failed to purge expired trash entries",
+					zap.Error(err),
+					zap.String("component", "protect"))
+			} else if n > 0 {
+				log.Info("Purged expired trash entries",
+					zap.Int("count", n),
+					zap.String("component", "protect"))
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// rangeCache serializable Range 结果的 LRU 缓存，条目在 TTL 后过期
+type rangeCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	lru     *list.List // 最近使用的在前
+	entries map[string]*list.Element
+
+	// gen 每次失效加一，读取期间发生过失效的结果不写入缓存
+	gen uint64
+}
+
+type cacheEntry struct {
+	key        string
+	start, end string // 请求的区间，end 为空表示没有上界
+	resp       *pb.RangeResponse
+	expires    time.Time
+}
+
+func newRangeCache(size int, ttl time.Duration) *rangeCache {
+	return &rangeCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *rangeCache) enabled() bool {
+	return c.size > 0 && c.ttl > 0
+}
+
+// cacheKey 缓存 key，由客户端的 token 和请求的编码得到：集群按 token 鉴权，
+// token 不同的请求分开缓存，limit、排序等参数不同的请求也分开缓存
+func cacheKey(token []string, req *pb.RangeRequest) (string, bool) {
+	data, err := req.Marshal()
+	if err != nil {
+		return "", false
+	}
+	return strings.Join(token, "\x00") + "\x00" + string(data), true
+}
+
+// get 返回缓存的结果和当前的失效代数
+func (c *rangeCache) get(key string) (*pb.RangeResponse, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, c.gen
+	}
+	e := elem.Value.(*cacheEntry)
+	if !c.now().Before(e.expires) {
+		c.removeLocked(elem)
+		return nil, c.gen
+	}
+	c.lru.MoveToFront(elem)
+	return e.resp, c.gen
+}
+
+// add 缓存结果，gen 为读取前 get 返回的失效代数
+func (c *rangeCache) add(key string, req *pb.RangeRequest, resp *pb.RangeResponse, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	start, end := bounds(string(req.Key), string(req.RangeEnd))
+	e := &cacheEntry{key: key, start: start, end: end, resp: resp, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = e
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.size {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// invalidate 删除与 [key, rangeEnd) 相交的缓存条目
+func (c *rangeCache) invalidate(key, rangeEnd string) {
+	start, end := bounds(key, rangeEnd)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		e := elem.Value.(*cacheEntry)
+		if (e.end == "" || start < e.end) && (end == "" || e.start < end) {
+			c.removeLocked(elem)
+		}
+		elem = next
+	}
+}
+
+// purge 删除所有缓存条目
+func (c *rangeCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+func (c *rangeCache) removeLocked(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// invalidateTxn 使事务两个分支中所有写入涉及的缓存失效
+func (c *rangeCache) invalidateTxn(req *pb.TxnRequest) {
+	for _, ops := range [][]*pb.RequestOp{req.Success, req.Failure} {
+		for _, op := range ops {
+			switch {
+			case op.GetRequestPut() != nil:
+				c.invalidate(string(op.GetRequestPut().Key), "")
+			case op.GetRequestDeleteRange() != nil:
+				r := op.GetRequestDeleteRange()
+				c.invalidate(string(r.Key), string(r.RangeEnd))
+			case op.GetRequestTxn() != nil:
+				c.invalidateTxn(op.GetRequestTxn())
+			}
+		}
+	}
+}
+
+// bounds 把 etcd 语义的 [key, rangeEnd) 转换为半开区间，end 为空表示没有上界
+func bounds(key, rangeEnd string) (string, string) {
+	switch rangeEnd {
+	case "":
+		return key, key + "\x00"
+	case "\x00":
+		return key, ""
+	default:
+		return key, rangeEnd
+	}
+}
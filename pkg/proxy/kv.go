@@ -0,0 +1,91 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// kvProxy 转发 KV 请求，缓存 serializable 的 Range
+type kvProxy struct {
+	kv    pb.KVClient
+	cache *rangeCache
+}
+
+func (p *kvProxy) Range(ctx context.Context, req *pb.RangeRequest) (*pb.RangeResponse, error) {
+	md := forwarded(ctx)
+	// 带有 x-metastore-* 元数据的请求（例如 read-after-write）不走缓存
+	extra := len(md)
+	if _, ok := md[tokenKey]; ok {
+		extra--
+	}
+	if !req.Serializable || extra > 0 || !p.cache.enabled() {
+		var header metadata.MD
+		resp, err := p.kv.Range(outgoing(ctx, md), req, grpc.Header(&header))
+		relayHeader(ctx, header)
+		return resp, err
+	}
+
+	key, ok := cacheKey(md[tokenKey], req)
+	if !ok {
+		return p.kv.Range(outgoing(ctx, md), req)
+	}
+	resp, gen := p.cache.get(key)
+	if resp != nil {
+		return resp, nil
+	}
+	resp, err := p.kv.Range(outgoing(ctx, md), req)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.add(key, req, resp, gen)
+	return resp, nil
+}
+
+func (p *kvProxy) Put(ctx context.Context, req *pb.PutRequest) (*pb.PutResponse, error) {
+	var header metadata.MD
+	resp, err := p.kv.Put(outgoing(ctx, forwarded(ctx)), req, grpc.Header(&header))
+	// 请求失败时写入也可能已经生效，同样使缓存失效
+	p.cache.invalidate(string(req.Key), "")
+	relayHeader(ctx, header)
+	return resp, err
+}
+
+func (p *kvProxy) DeleteRange(ctx context.Context, req *pb.DeleteRangeRequest) (*pb.DeleteRangeResponse, error) {
+	var header metadata.MD
+	resp, err := p.kv.DeleteRange(outgoing(ctx, forwarded(ctx)), req, grpc.Header(&header))
+	p.cache.invalidate(string(req.Key), string(req.RangeEnd))
+	relayHeader(ctx, header)
+	return resp, err
+}
+
+func (p *kvProxy) Txn(ctx context.Context, req *pb.TxnRequest) (*pb.TxnResponse, error) {
+	var header metadata.MD
+	resp, err := p.kv.Txn(outgoing(ctx, forwarded(ctx)), req, grpc.Header(&header))
+	p.cache.invalidateTxn(req)
+	relayHeader(ctx, header)
+	return resp, err
+}
+
+func (p *kvProxy) Compact(ctx context.Context, req *pb.CompactionRequest) (*pb.CompactionResponse, error) {
+	resp, err := p.kv.Compact(outgoing(ctx, forwarded(ctx)), req)
+	// 缓存中可能有已经被压缩的历史 revision 的结果
+	p.cache.purge()
+	return resp, err
+}
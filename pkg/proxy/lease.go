@@ -0,0 +1,93 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+// leaseProxy 转发 Lease 请求
+type leaseProxy struct {
+	lease pb.LeaseClient
+	cache *rangeCache
+}
+
+func (p *leaseProxy) LeaseGrant(ctx context.Context, req *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	return p.lease.LeaseGrant(outgoing(ctx, forwarded(ctx)), req)
+}
+
+func (p *leaseProxy) LeaseRevoke(ctx context.Context, req *pb.LeaseRevokeRequest) (*pb.LeaseRevokeResponse, error) {
+	resp, err := p.lease.LeaseRevoke(outgoing(ctx, forwarded(ctx)), req)
+	// 代理不知道 lease 关联了哪些 key
+	p.cache.purge()
+	return resp, err
+}
+
+func (p *leaseProxy) LeaseTimeToLive(ctx context.Context, req *pb.LeaseTimeToLiveRequest) (*pb.LeaseTimeToLiveResponse, error) {
+	return p.lease.LeaseTimeToLive(outgoing(ctx, forwarded(ctx)), req)
+}
+
+func (p *leaseProxy) LeaseLeases(ctx context.Context, req *pb.LeaseLeasesRequest) (*pb.LeaseLeasesResponse, error) {
+	return p.lease.LeaseLeases(outgoing(ctx, forwarded(ctx)), req)
+}
+
+// LeaseKeepAlive 为每个客户端流打开一个上游流，双向转发
+func (p *leaseProxy) LeaseKeepAlive(srv pb.Lease_LeaseKeepAliveServer) error {
+	ctx, cancel := context.WithCancel(srv.Context())
+	defer cancel()
+
+	upstream, err := p.lease.LeaseKeepAlive(outgoing(ctx, forwarded(ctx)))
+	if err != nil {
+		return err
+	}
+
+	errC := make(chan error, 2)
+	go func() {
+		for {
+			resp, err := upstream.Recv()
+			if err != nil {
+				errC <- err
+				return
+			}
+			if err := srv.Send(resp); err != nil {
+				errC <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			req, err := srv.Recv()
+			if err != nil {
+				errC <- err
+				return
+			}
+			if err := upstream.Send(req); err != nil {
+				errC <- err
+				return
+			}
+		}
+	}()
+
+	err = <-errC
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
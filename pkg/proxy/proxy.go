@@ -0,0 +1,153 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxy 实现无状态的 gRPC 代理，类似 etcd grpc-proxy
+//
+// 代理把 KV、Watch 和 Lease 请求转发给集群，自身不参与 raft，可以随意增减实例来
+// 扩展读和 watch 的扇出：
+//   - 从当前 revision 开始、范围相同的 watch 合并为一个上游 watch
+//   - serializable 的 Range 结果在代理内缓存一段时间，经过本代理的写入会使相关的
+//     缓存失效，其他途径的写入最多在 TTL 内不可见
+//
+// 代理自身没有身份：客户端的 token 转发给集群，由集群认证和鉴权，Authenticate
+// 也转发给集群以便客户端经代理获取 token。缓存的 Range 结果只返回给 token 相同的
+// 请求，token 不同的 watch 不合并。x-metastore-* 元数据（watch 过滤、
+// read-after-write 等）原样转发
+package proxy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataPrefix 转发给集群和回传给客户端的元数据前缀
+const metadataPrefix = "x-metastore-"
+
+// tokenKey 客户端认证 token 的元数据 key，原样转发给集群
+const tokenKey = "token"
+
+// Config 代理配置
+type Config struct {
+	Endpoints   []string
+	DialTimeout time.Duration
+
+	// CacheSize serializable Range 缓存的最大条目数，0 表示不缓存
+	CacheSize int
+	// CacheTTL 缓存条目的有效期
+	CacheTTL time.Duration
+}
+
+// Proxy 无状态代理
+type Proxy struct {
+	client *clientv3.Client
+
+	kv    *kvProxy
+	watch *watchProxy
+	lease *leaseProxy
+	auth  *authProxy
+}
+
+// New 连接集群并创建代理
+func New(cfg Config) (*Proxy, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, errors.New("proxy: no endpoints")
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		DialTimeout: cfg.DialTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conn := client.ActiveConnection()
+	p := newProxy(pb.NewKVClient(conn), client.Watcher, pb.NewLeaseClient(conn), cfg)
+	p.auth = &authProxy{auth: pb.NewAuthClient(conn)}
+	p.client = client
+	return p, nil
+}
+
+func newProxy(kv pb.KVClient, watcher clientv3.Watcher, lease pb.LeaseClient, cfg Config) *Proxy {
+	cache := newRangeCache(cfg.CacheSize, cfg.CacheTTL)
+	return &Proxy{
+		kv:    &kvProxy{kv: kv, cache: cache},
+		watch: newWatchProxy(watcher, kv),
+		lease: &leaseProxy{lease: lease, cache: cache},
+	}
+}
+
+// Register 在 gRPC 服务器上注册 KV、Watch 和 Lease 服务，以及只转发 Authenticate 的 Auth 服务
+func (p *Proxy) Register(s *grpc.Server) {
+	pb.RegisterKVServer(s, p.kv)
+	pb.RegisterWatchServer(s, p.watch)
+	pb.RegisterLeaseServer(s, p.lease)
+	if p.auth != nil {
+		pb.RegisterAuthServer(s, p.auth)
+	}
+}
+
+// Close 关闭所有上游 watch 和到集群的连接
+func (p *Proxy) Close() error {
+	p.watch.close()
+	if p.client == nil {
+		return nil
+	}
+	return p.client.Close()
+}
+
+// forwarded 返回客户端请求中需要转发的元数据：x-metastore-* 和 token
+func forwarded(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var out metadata.MD
+	for k, v := range md {
+		if strings.HasPrefix(k, metadataPrefix) || k == tokenKey {
+			if out == nil {
+				out = metadata.MD{}
+			}
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// outgoing 返回转发了元数据的上游请求 context
+func outgoing(ctx context.Context, md metadata.MD) context.Context {
+	if len(md) == 0 {
+		return ctx
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// relayHeader 把上游响应头中的 x-metastore-* 元数据回传给客户端
+func relayHeader(ctx context.Context, header metadata.MD) {
+	var out metadata.MD
+	for k, v := range header {
+		if strings.HasPrefix(k, metadataPrefix) {
+			if out == nil {
+				out = metadata.MD{}
+			}
+			out[k] = v
+		}
+	}
+	if out != nil {
+		_ = grpc.SetHeader(ctx, out)
+	}
+}
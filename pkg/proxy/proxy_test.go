@@ -0,0 +1,255 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeKV 记录 Range 次数的上游 KV
+type fakeKV struct {
+	pb.KVClient
+	mu     sync.Mutex
+	ranges int
+
+	// readers 不为空时像开启了认证的集群一样，只允许这些 token 读取
+	readers map[string]bool
+}
+
+func (f *fakeKV) Range(ctx context.Context, req *pb.RangeRequest, _ ...grpc.CallOption) (*pb.RangeResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readers != nil {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if tokens := md["token"]; len(tokens) == 0 || !f.readers[tokens[0]] {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+	}
+	f.ranges++
+	return &pb.RangeResponse{
+		Header: &pb.ResponseHeader{Revision: int64(f.ranges)},
+		Kvs:    []*mvccpb.KeyValue{{Key: req.Key, Value: []byte("v")}},
+		Count:  1,
+	}, nil
+}
+
+func (f *fakeKV) Put(ctx context.Context, req *pb.PutRequest, _ ...grpc.CallOption) (*pb.PutResponse, error) {
+	return &pb.PutResponse{Header: &pb.ResponseHeader{}}, nil
+}
+
+func (f *fakeKV) rangeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ranges
+}
+
+// fakeWatcher 记录上游 watch，由测试推送事件
+type fakeWatcher struct {
+	clientv3.Watcher
+	mu      sync.Mutex
+	watches []chan clientv3.WatchResponse
+}
+
+func (f *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse, 16)
+	ch <- clientv3.WatchResponse{Created: true, Header: pb.ResponseHeader{Revision: 10}}
+	f.mu.Lock()
+	f.watches = append(f.watches, ch)
+	f.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+func (f *fakeWatcher) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.watches)
+}
+
+func startProxy(t *testing.T, kv *fakeKV, w *fakeWatcher) *clientv3.Client {
+	p := newProxy(kv, w, nil, Config{CacheSize: 10, CacheTTL: time.Minute})
+	srv := grpc.NewServer()
+	p.Register(srv)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(lis)
+	t.Cleanup(func() {
+		srv.Stop()
+		p.Close()
+	})
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{lis.Addr().String()}, DialTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSerializableRangeCache(t *testing.T) {
+	kv := &fakeKV{}
+	client := startProxy(t, kv, &fakeWatcher{})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Get(ctx, "a", clientv3.WithSerializable())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, kv.rangeCount())
+
+	// 线性一致读不走缓存
+	_, err := client.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, kv.rangeCount())
+
+	// 不相关的写入不影响缓存，相关的写入使缓存失效
+	_, err = client.Put(ctx, "b", "x")
+	require.NoError(t, err)
+	_, err = client.Get(ctx, "a", clientv3.WithSerializable())
+	require.NoError(t, err)
+	assert.Equal(t, 2, kv.rangeCount())
+
+	_, err = client.Put(ctx, "a", "x")
+	require.NoError(t, err)
+	_, err = client.Get(ctx, "a", clientv3.WithSerializable())
+	require.NoError(t, err)
+	assert.Equal(t, 3, kv.rangeCount())
+}
+
+func TestClientTokenForwarded(t *testing.T) {
+	kv := &fakeKV{readers: map[string]bool{"alice": true}}
+	w := &fakeWatcher{}
+	client := startProxy(t, kv, w)
+	ctx := context.Background()
+	alice := metadata.AppendToOutgoingContext(ctx, "token", "alice")
+	bob := metadata.AppendToOutgoingContext(ctx, "token", "bob")
+
+	_, err := client.Get(alice, "a", clientv3.WithSerializable())
+	require.NoError(t, err)
+	assert.Equal(t, 1, kv.rangeCount())
+
+	// 没有权限的客户端被集群拒绝，缓存的结果不返回给其他身份
+	_, err = client.Get(bob, "a", clientv3.WithSerializable())
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.Get(ctx, "a", clientv3.WithSerializable())
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.Get(alice, "a", clientv3.WithSerializable())
+	require.NoError(t, err)
+	assert.Equal(t, 1, kv.rangeCount())
+
+	// token 不同的 watch 不合并
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, token := range []string{"alice", "bob"} {
+		c := metadata.AppendToOutgoingContext(wctx, "token", token)
+		require.True(t, (<-client.Watch(c, "/a/", clientv3.WithPrefix(), clientv3.WithCreatedNotify())).Created)
+	}
+	assert.Equal(t, 2, w.count())
+}
+
+func TestRangeCacheInvalidate(t *testing.T) {
+	c := newRangeCache(2, time.Minute)
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+
+	prefix := &pb.RangeRequest{Key: []byte("/a/"), RangeEnd: []byte("/a0")}
+	all := &pb.RangeRequest{Key: []byte{0}, RangeEnd: []byte{0}}
+	resp := &pb.RangeResponse{}
+
+	_, gen := c.get("prefix")
+	c.add("prefix", prefix, resp, gen)
+	c.add("all", all, resp, gen)
+	c.invalidate("/b", "")
+	got, _ := c.get("prefix")
+	assert.NotNil(t, got)
+	got, gen = c.get("all")
+	assert.Nil(t, got, "the whole keyspace intersects any write")
+
+	// 读取期间发生过失效的结果不写入缓存
+	c.invalidate("/a/x", "")
+	c.add("all", all, resp, gen)
+	got, _ = c.get("all")
+	assert.Nil(t, got)
+	got, _ = c.get("prefix")
+	assert.Nil(t, got)
+
+	// 过期
+	_, gen = c.get("prefix")
+	c.add("prefix", prefix, resp, gen)
+	now = now.Add(2 * time.Minute)
+	got, _ = c.get("prefix")
+	assert.Nil(t, got)
+}
+
+func TestWatchCoalescing(t *testing.T) {
+	w := &fakeWatcher{}
+	client := startProxy(t, &fakeKV{}, w)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wch1 := client.Watch(ctx, "/a/", clientv3.WithPrefix(), clientv3.WithCreatedNotify())
+	wch2 := client.Watch(clientv3.WithRequireLeader(ctx), "/a/", clientv3.WithPrefix(), clientv3.WithPrevKV(), clientv3.WithCreatedNotify())
+	for _, wch := range []clientv3.WatchChan{wch1, wch2} {
+		resp := <-wch
+		require.True(t, resp.Created)
+		assert.Equal(t, int64(10), resp.Header.Revision)
+	}
+	assert.Equal(t, 1, w.count(), "watches on the same range share one upstream watch")
+
+	// 指定起始 revision 的 watch 单独使用上游 watch
+	wch3 := client.Watch(ctx, "/a/", clientv3.WithPrefix(), clientv3.WithRev(5), clientv3.WithCreatedNotify())
+	require.True(t, (<-wch3).Created)
+	assert.Equal(t, 2, w.count())
+
+	w.mu.Lock()
+	w.watches[0] <- clientv3.WatchResponse{
+		Header: pb.ResponseHeader{Revision: 11},
+		Events: []*clientv3.Event{{
+			Type:   mvccpb.PUT,
+			Kv:     &mvccpb.KeyValue{Key: []byte("/a/1"), Value: []byte("new"), ModRevision: 11},
+			PrevKv: &mvccpb.KeyValue{Key: []byte("/a/1"), Value: []byte("old")},
+		}},
+	}
+	w.mu.Unlock()
+
+	resp := <-wch1
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, "new", string(resp.Events[0].Kv.Value))
+	assert.Nil(t, resp.Events[0].PrevKv, "prevKv is stripped for watchers that did not ask for it")
+
+	resp = <-wch2
+	require.Len(t, resp.Events, 1)
+	require.NotNil(t, resp.Events[0].PrevKv)
+	assert.Equal(t, "old", string(resp.Events[0].PrevKv.Value))
+}
@@ -0,0 +1,509 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"metaStore/pkg/log"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// watchSendBuffer 每个客户端流待发送响应的缓冲，满了说明客户端跟不上
+const watchSendBuffer = 1024
+
+// errSlowWatcher 客户端流跟不上时关闭流；Unavailable 使客户端从最后的 revision 重连，
+// 重连后的 watch 带有起始 revision，不再与其他 watch 合并
+var errSlowWatcher = status.Error(codes.Unavailable, "proxy: watch stream is too slow")
+
+// watchProxy 转发 Watch 请求，合并相同的 watch
+type watchProxy struct {
+	watcher clientv3.Watcher
+	kv      pb.KVClient
+
+	mu     sync.Mutex
+	groups map[string]*watchGroup // 可以合并的上游 watch，按元数据和范围索引
+	all    map[*watchGroup]struct{}
+}
+
+func newWatchProxy(watcher clientv3.Watcher, kv pb.KVClient) *watchProxy {
+	return &watchProxy{
+		watcher: watcher,
+		kv:      kv,
+		groups:  make(map[string]*watchGroup),
+		all:     make(map[*watchGroup]struct{}),
+	}
+}
+
+// watchGroup 一个上游 watch 及共享它的客户端 watch
+type watchGroup struct {
+	wp     *watchProxy
+	key    string // groups 中的 key，不合并的 watch 为空
+	cancel context.CancelFunc
+	refs   int // 加入中和已加入的客户端 watch 数，由 wp.mu 保护
+
+	ready chan struct{} // 上游 watch 创建完成或失败后关闭
+
+	mu      sync.Mutex
+	members map[*watcher]struct{}
+	rev     int64 // 已经分发到的 revision
+	// createdRev 上游 watch 创建时的 revision
+	createdRev int64
+	closed     bool
+	failure    *pb.WatchResponse // 上游 watch 失败时发给客户端的取消响应
+}
+
+// watcher 一个客户端 watch
+type watcher struct {
+	stream   *watchStream
+	id       int64
+	group    *watchGroup
+	prevKV   bool
+	progress bool
+	noPut    bool
+	noDelete bool
+}
+
+// watchStream 一个客户端 Watch 流
+type watchStream struct {
+	wp    *watchProxy
+	srv   pb.Watch_WatchServer
+	ctx   context.Context
+	abort context.CancelCauseFunc
+	md    metadata.MD
+	sendC chan *pb.WatchResponse
+
+	mu       sync.Mutex
+	watchers map[int64]*watcher
+	nextID   int64
+}
+
+func (wp *watchProxy) Watch(srv pb.Watch_WatchServer) error {
+	ctx, abort := context.WithCancelCause(srv.Context())
+	defer abort(nil)
+
+	ws := &watchStream{
+		wp:       wp,
+		srv:      srv,
+		ctx:      ctx,
+		abort:    abort,
+		md:       forwarded(srv.Context()),
+		sendC:    make(chan *pb.WatchResponse, watchSendBuffer),
+		watchers: make(map[int64]*watcher),
+	}
+	defer ws.close()
+
+	go ws.sendLoop()
+	go ws.recvLoop()
+
+	<-ctx.Done()
+	err := context.Cause(ctx)
+	if errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func (ws *watchStream) sendLoop() {
+	for {
+		select {
+		case resp := <-ws.sendC:
+			if err := ws.srv.Send(resp); err != nil {
+				ws.abort(err)
+				return
+			}
+		case <-ws.ctx.Done():
+			return
+		}
+	}
+}
+
+func (ws *watchStream) recvLoop() {
+	for {
+		req, err := ws.srv.Recv()
+		if err != nil {
+			ws.abort(err)
+			return
+		}
+		switch {
+		case req.GetCreateRequest() != nil:
+			ws.create(req.GetCreateRequest())
+		case req.GetCancelRequest() != nil:
+			ws.cancel(req.GetCancelRequest().WatchId)
+		case req.GetProgressRequest() != nil:
+			ws.progress()
+		}
+	}
+}
+
+// send 把响应放入发送缓冲，缓冲已满时关闭整个流
+func (ws *watchStream) send(resp *pb.WatchResponse) {
+	select {
+	case ws.sendC <- resp:
+	case <-ws.ctx.Done():
+	default:
+		ws.abort(errSlowWatcher)
+	}
+}
+
+func (ws *watchStream) create(cr *pb.WatchCreateRequest) {
+	ws.mu.Lock()
+	id := cr.WatchId
+	if id == clientv3.AutoWatchID {
+		for ws.watchers[ws.nextID] != nil {
+			ws.nextID++
+		}
+		id = ws.nextID
+		ws.nextID++
+	} else if ws.watchers[id] != nil {
+		ws.mu.Unlock()
+		ws.send(&pb.WatchResponse{
+			WatchId:      id,
+			Created:      true,
+			Canceled:     true,
+			CancelReason: fmt.Sprintf("watch id %d already in use", id),
+		})
+		return
+	}
+	w := &watcher{stream: ws, id: id, prevKV: cr.PrevKv, progress: cr.ProgressNotify}
+	for _, f := range cr.Filters {
+		switch f {
+		case pb.WatchCreateRequest_NOPUT:
+			w.noPut = true
+		case pb.WatchCreateRequest_NODELETE:
+			w.noDelete = true
+		}
+	}
+	ws.watchers[id] = w
+	ws.mu.Unlock()
+
+	// 同一个流上的创建必须按顺序响应，等待上游 watch 创建完成
+	g := ws.wp.join(ws.md, cr)
+	select {
+	case <-g.ready:
+	case <-ws.ctx.Done():
+		ws.wp.leave(g)
+		return
+	}
+
+	// 加锁顺序：ws.mu、g.mu、wp.mu
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.watchers[id] != w {
+		// 流已经关闭
+		ws.wp.leave(g)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		resp := *g.failure
+		resp.WatchId = id
+		resp.Created = true
+		ws.send(&resp)
+		delete(ws.watchers, id)
+		ws.wp.leave(g)
+		return
+	}
+	w.group = g
+	ws.send(&pb.WatchResponse{Header: &pb.ResponseHeader{Revision: max(g.rev, g.createdRev)}, WatchId: id, Created: true})
+	g.members[w] = struct{}{}
+}
+
+func (ws *watchStream) cancel(id int64) {
+	ws.mu.Lock()
+	w := ws.watchers[id]
+	delete(ws.watchers, id)
+	ws.mu.Unlock()
+	if w == nil || w.group == nil {
+		return
+	}
+
+	g := w.group
+	g.mu.Lock()
+	_, member := g.members[w]
+	delete(g.members, w)
+	rev := g.rev
+	g.mu.Unlock()
+	if member {
+		// 上游 watch 失败时由 fail 离开
+		ws.wp.leave(g)
+	}
+	ws.send(&pb.WatchResponse{Header: &pb.ResponseHeader{Revision: rev}, WatchId: id, Canceled: true})
+}
+
+// forget 从流中去掉 watch，id 已经被新的 watch 使用时保留
+func (ws *watchStream) forget(w *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.watchers[w.id] == w {
+		delete(ws.watchers, w.id)
+	}
+}
+
+// progress 响应客户端的进度请求
+//
+// 合并的上游 watch 进度不同，返回流上所有 watch 都已经分发到的最小 revision；
+// 流上没有 watch 时返回集群当前的 revision
+func (ws *watchStream) progress() {
+	ws.mu.Lock()
+	var rev int64
+	found := false
+	for _, w := range ws.watchers {
+		if w.group == nil {
+			continue
+		}
+		w.group.mu.Lock()
+		if !found || w.group.rev < rev {
+			rev = w.group.rev
+		}
+		found = true
+		w.group.mu.Unlock()
+	}
+	ws.mu.Unlock()
+
+	if !found {
+		resp, err := ws.wp.kv.Range(outgoing(ws.ctx, ws.md), &pb.RangeRequest{Key: []byte{0}, Serializable: true, CountOnly: true})
+		if err != nil {
+			log.Warn("Failed to get revision for watch progress",
+				zap.Error(err),
+				zap.String("component", "proxy"))
+			return
+		}
+		rev = resp.Header.Revision
+	}
+	ws.send(&pb.WatchResponse{Header: &pb.ResponseHeader{Revision: rev}, WatchId: clientv3.InvalidWatchID})
+}
+
+// close 流结束时离开所有 watch
+func (ws *watchStream) close() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	for _, w := range ws.watchers {
+		if w.group == nil {
+			continue
+		}
+		w.group.mu.Lock()
+		_, member := w.group.members[w]
+		delete(w.group.members, w)
+		w.group.mu.Unlock()
+		if member {
+			ws.wp.leave(w.group)
+		}
+	}
+	ws.watchers = make(map[int64]*watcher)
+}
+
+// join 返回客户端 watch 所属的上游 watch，必要时创建
+//
+// 从当前 revision 开始的 watch 按元数据和范围合并；指定了起始 revision 的 watch
+// 需要历史事件，单独使用一个上游 watch
+func (wp *watchProxy) join(md metadata.MD, cr *pb.WatchCreateRequest) *watchGroup {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	var key string
+	if cr.StartRevision == 0 {
+		key = groupKey(md, cr.Key, cr.RangeEnd)
+		if g := wp.groups[key]; g != nil {
+			g.refs++
+			return g
+		}
+	}
+
+	ctx, cancel := context.WithCancel(outgoing(context.Background(), md))
+	g := &watchGroup{
+		wp:      wp,
+		key:     key,
+		cancel:  cancel,
+		refs:    1,
+		ready:   make(chan struct{}),
+		members: make(map[*watcher]struct{}),
+	}
+	if key != "" {
+		wp.groups[key] = g
+	}
+	wp.all[g] = struct{}{}
+
+	opts := []clientv3.OpOption{clientv3.WithPrevKV(), clientv3.WithProgressNotify(), clientv3.WithCreatedNotify()}
+	if len(cr.RangeEnd) > 0 {
+		opts = append(opts, clientv3.WithRange(string(cr.RangeEnd)))
+	}
+	if cr.StartRevision > 0 {
+		opts = append(opts, clientv3.WithRev(cr.StartRevision))
+	}
+	go g.run(wp.watcher.Watch(ctx, string(cr.Key), opts...), cr.StartRevision)
+	return g
+}
+
+// leave 客户端 watch 离开上游 watch，最后一个离开时取消上游 watch
+func (wp *watchProxy) leave(g *watchGroup) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	g.refs--
+	if g.refs > 0 {
+		return
+	}
+	wp.removeLocked(g)
+	g.cancel()
+}
+
+func (wp *watchProxy) removeLocked(g *watchGroup) {
+	if g.key != "" && wp.groups[g.key] == g {
+		delete(wp.groups, g.key)
+	}
+	delete(wp.all, g)
+}
+
+// close 取消所有上游 watch
+func (wp *watchProxy) close() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	for g := range wp.all {
+		g.cancel()
+	}
+}
+
+// groupKey 合并 watch 的 key，元数据不同（例如 watch 过滤条件不同）的 watch 不合并
+func groupKey(md metadata.MD, key, rangeEnd []byte) string {
+	names := make([]string, 0, len(md))
+	for k := range md {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, k := range names {
+		fmt.Fprintf(&b, "%q=%q;", k, md[k])
+	}
+	fmt.Fprintf(&b, "%q:%q", key, rangeEnd)
+	return b.String()
+}
+
+// run 把上游 watch 的响应分发给所有成员
+func (g *watchGroup) run(wch clientv3.WatchChan, startRev int64) {
+	created := false
+	for resp := range wch {
+		if resp.Created && !created {
+			created = true
+			g.mu.Lock()
+			g.createdRev = resp.Header.Revision
+			g.rev = resp.Header.Revision
+			if startRev > 0 {
+				// 历史事件还没有分发
+				g.rev = startRev - 1
+			}
+			g.mu.Unlock()
+			close(g.ready)
+			continue
+		}
+		if err := resp.Err(); err != nil {
+			g.fail(&resp, err)
+			return
+		}
+		g.dispatch(&resp)
+	}
+	g.fail(nil, status.Error(codes.Unavailable, "proxy: upstream watch closed"))
+}
+
+// dispatch 按每个成员的选项过滤事件后发送
+func (g *watchGroup) dispatch(resp *clientv3.WatchResponse) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.rev = max(g.rev, resp.Header.Revision)
+
+	progress := resp.IsProgressNotify()
+	for w := range g.members {
+		header := resp.Header
+		out := &pb.WatchResponse{Header: &header, WatchId: w.id}
+		if progress {
+			if !w.progress {
+				continue
+			}
+			w.stream.send(out)
+			continue
+		}
+		for _, ev := range resp.Events {
+			if (ev.Type == mvccpb.PUT && w.noPut) || (ev.Type == mvccpb.DELETE && w.noDelete) {
+				continue
+			}
+			e := (*mvccpb.Event)(ev)
+			if !w.prevKV && e.PrevKv != nil {
+				e = &mvccpb.Event{Type: e.Type, Kv: e.Kv}
+			}
+			out.Events = append(out.Events, e)
+		}
+		if len(out.Events) > 0 {
+			w.stream.send(out)
+		}
+	}
+}
+
+// fail 上游 watch 失败（例如起始 revision 已被压缩），取消所有成员
+func (g *watchGroup) fail(resp *clientv3.WatchResponse, err error) {
+	failure := &pb.WatchResponse{Canceled: true, CancelReason: err.Error()}
+	if resp != nil {
+		header := resp.Header
+		failure.Header = &header
+		failure.CompactRevision = resp.CompactRevision
+	}
+
+	g.wp.mu.Lock()
+	g.wp.removeLocked(g)
+	g.wp.mu.Unlock()
+
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return
+	}
+	g.closed = true
+	g.failure = failure
+	members := g.members
+	g.members = make(map[*watcher]struct{})
+	for w := range members {
+		out := *failure
+		out.WatchId = w.id
+		w.stream.send(&out)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-g.ready:
+	default:
+		close(g.ready)
+	}
+	for w := range members {
+		w.stream.forget(w)
+		g.wp.leave(g)
+	}
+	g.cancel()
+}
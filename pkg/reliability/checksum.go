@@ -0,0 +1,153 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reliability
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"metaStore/pkg/log"
+)
+
+// 持久化数据的 CRC32C 校验
+//
+// 校验过的数据格式为 magic + 4 字节大端 CRC32C + 数据。magic 由调用方选择，必须不会是
+// 未校验数据的开头，这样打开 reliability.enable_crc 之前写入的数据仍然可以读取。
+// 写入时只在 enable_crc 打开时添加校验，读取时只要有 magic 就校验
+
+// ErrCorrupted 数据校验失败
+var ErrCorrupted = errors.New("data corrupted")
+
+// 校验数据的来源，用于告警和指标
+const (
+	SourceRaftLog  = "raft_log"
+	SourceSnapshot = "snapshot"
+)
+
+var (
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+	crcEnabled atomic.Bool
+
+	corruptionMu        sync.Mutex
+	corruptionCounts    = map[string]uint64{}
+	corruptionListeners = map[int]func(source string, err error){}
+	nextListener        int
+)
+
+// SetCRC 设置写入时是否添加校验（reliability.enable_crc）
+func SetCRC(enabled bool) {
+	crcEnabled.Store(enabled)
+}
+
+// CRCEnabled 返回写入时是否添加校验
+func CRCEnabled() bool {
+	return crcEnabled.Load()
+}
+
+// Checksum 计算 CRC32C
+func Checksum(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoli)
+}
+
+// SealCRC 在 enable_crc 打开时给数据加上 magic 和校验和，否则原样返回
+func SealCRC(magic, data []byte) []byte {
+	if !crcEnabled.Load() {
+		return data
+	}
+	out := make([]byte, 0, len(magic)+4+len(data))
+	out = append(out, magic...)
+	out = binary.BigEndian.AppendUint32(out, Checksum(data))
+	return append(out, data...)
+}
+
+// OpenCRC 校验并去掉 SealCRC 添加的头，没有 magic 的数据原样返回。
+// 校验失败时返回 ErrCorrupted，并通过 ReportCorruption 上报
+func OpenCRC(source string, magic, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, magic) {
+		return data, nil
+	}
+	if len(data) < len(magic)+4 {
+		err := fmt.Errorf("%w: %s checksum is truncated", ErrCorrupted, source)
+		ReportCorruption(source, err)
+		return nil, err
+	}
+	want := binary.BigEndian.Uint32(data[len(magic):])
+	payload := data[len(magic)+4:]
+	if got := Checksum(payload); got != want {
+		err := fmt.Errorf("%w: %s checksum mismatch, expected %08x, got %08x", ErrCorrupted, source, want, got)
+		ReportCorruption(source, err)
+		return nil, err
+	}
+	return payload, nil
+}
+
+// snapshotCRCMagic 带校验的状态机快照的前缀，在快照编码（加密、压缩）的最外层
+var snapshotCRCMagic = []byte("SNAP-CRC:")
+
+// SealSnapshot 给状态机快照加上校验，两种存储引擎的快照编码都使用它，
+// 接收方可以在安装快照之前校验
+func SealSnapshot(data []byte) []byte {
+	return SealCRC(snapshotCRCMagic, data)
+}
+
+// OpenSnapshot 校验并去掉 SealSnapshot 添加的头
+func OpenSnapshot(data []byte) ([]byte, error) {
+	return OpenCRC(SourceSnapshot, snapshotCRCMagic, data)
+}
+
+// OnCorruption 注册数据损坏的回调，例如触发 CORRUPT 告警，返回的函数取消注册
+func OnCorruption(fn func(source string, err error)) func() {
+	corruptionMu.Lock()
+	defer corruptionMu.Unlock()
+	id := nextListener
+	nextListener++
+	corruptionListeners[id] = fn
+	return func() {
+		corruptionMu.Lock()
+		defer corruptionMu.Unlock()
+		delete(corruptionListeners, id)
+	}
+}
+
+// ReportCorruption 记录一次数据损坏并通知回调
+func ReportCorruption(source string, err error) {
+	log.Error("Data corruption detected",
+		log.String("source", source),
+		log.Err(err),
+		log.Component("reliability"))
+
+	corruptionMu.Lock()
+	corruptionCounts[source]++
+	listeners := slices.Collect(maps.Values(corruptionListeners))
+	corruptionMu.Unlock()
+
+	for _, fn := range listeners {
+		fn(source, err)
+	}
+}
+
+// CorruptionCounts 返回各来源检测到的数据损坏次数
+func CorruptionCounts() map[string]uint64 {
+	corruptionMu.Lock()
+	defer corruptionMu.Unlock()
+	return maps.Clone(corruptionCounts)
+}
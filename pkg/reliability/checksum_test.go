@@ -0,0 +1,86 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reliability
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpenCRC(t *testing.T) {
+	magic := []byte{0xff}
+	data := []byte("payload")
+
+	// 关闭时原样写入
+	SetCRC(false)
+	if sealed := SealCRC(magic, data); !bytes.Equal(sealed, data) {
+		t.Fatalf("expected data unchanged with CRC disabled, got %x", sealed)
+	}
+
+	SetCRC(true)
+	defer SetCRC(false)
+
+	sealed := SealCRC(magic, data)
+	if len(sealed) != len(magic)+4+len(data) {
+		t.Fatalf("unexpected sealed length %d", len(sealed))
+	}
+	opened, err := OpenCRC(SourceRaftLog, magic, sealed)
+	if err != nil {
+		t.Fatalf("OpenCRC failed: %v", err)
+	}
+	if !bytes.Equal(opened, data) {
+		t.Errorf("expected %q, got %q", data, opened)
+	}
+
+	// 没有 magic 的旧数据原样返回
+	opened, err = OpenCRC(SourceRaftLog, magic, data)
+	if err != nil || !bytes.Equal(opened, data) {
+		t.Errorf("expected legacy data unchanged, got %q, %v", opened, err)
+	}
+}
+
+func TestOpenCRCCorrupted(t *testing.T) {
+	SetCRC(true)
+	defer SetCRC(false)
+
+	var reported []string
+	stop := OnCorruption(func(source string, err error) {
+		reported = append(reported, source)
+	})
+
+	before := CorruptionCounts()[SourceSnapshot]
+	sealed := SealSnapshot([]byte("snapshot"))
+	sealed[len(sealed)-1] ^= 0x01
+	if _, err := OpenSnapshot(sealed); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("expected ErrCorrupted, got %v", err)
+	}
+	if _, err := OpenSnapshot(snapshotCRCMagic); !errors.Is(err, ErrCorrupted) {
+		t.Fatalf("expected ErrCorrupted for truncated checksum, got %v", err)
+	}
+	if got := CorruptionCounts()[SourceSnapshot] - before; got != 2 {
+		t.Errorf("expected 2 corruptions counted, got %d", got)
+	}
+	if len(reported) != 2 || reported[0] != SourceSnapshot {
+		t.Errorf("expected 2 snapshot reports, got %v", reported)
+	}
+
+	// 取消注册后不再通知
+	stop()
+	_, _ = OpenSnapshot(sealed)
+	if len(reported) != 2 {
+		t.Errorf("listener called after unregister: %v", reported)
+	}
+}
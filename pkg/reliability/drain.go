@@ -0,0 +1,35 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reliability
+
+import "time"
+
+// 排空状态
+const (
+	DrainServing  = "serving"  // 未开始排空
+	DrainDraining = "draining" // 已停止接受连接，正在等待现有请求和 stream 结束
+	DrainDrained  = "drained"  // gRPC 服务已停止，可以安全关闭进程
+)
+
+// DrainStatus gRPC 服务排空的进度，用于滚动升级前迁移客户端连接
+type DrainStatus struct {
+	State           string    `json:"state"`                 // serving、draining 或 drained
+	StartedAt       time.Time `json:"started_at,omitempty"`  // 开始排空的时间
+	FinishedAt      time.Time `json:"finished_at,omitempty"` // 排空完成的时间
+	OpenStreams     int64     `json:"open_streams"`          // 仍然打开的客户端流式 RPC（watch、lease keepalive）
+	EvictedStreams  int64     `json:"evicted_streams"`       // 排空开始时被关闭、由客户端在其他成员上重建的 stream 数
+	Forced          bool      `json:"forced"`                // 超时后强制关闭了剩余的连接
+	ReadyToShutdown bool      `json:"ready_to_shutdown"`     // 排空完成，可以停止进程
+}
@@ -16,9 +16,21 @@ package reliability
 
 import (
 	"fmt"
+	"maps"
 	"metaStore/pkg/log"
 	"runtime/debug"
+	"sync"
 	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// panic 的来源，用于日志和指标
+const (
+	PanicSourceGoroutine = "goroutine"
+	PanicSourceGRPC      = "grpc"
+	PanicSourceHTTP      = "http"
+	PanicSourceMySQL     = "mysql"
 )
 
 var (
@@ -26,27 +38,46 @@ var (
 	PanicCounter int64
 	// PanicHandler 全局 panic 处理器
 	PanicHandler func(goroutineName string, panicValue interface{}, stack []byte)
+
+	panicMu     sync.Mutex
+	panicCounts = make(map[string]uint64)
 )
 
+// ReportPanic 记录一次恢复的 panic：计数、带堆栈的错误日志并调用 PanicHandler。
+// 必须在 recover 所在的 defer 中调用，堆栈才包含 panic 的位置；
+// fields 描述 panic 时处理的请求，例如方法和客户端地址
+func ReportPanic(source, name string, r interface{}, fields ...zap.Field) {
+	atomic.AddInt64(&PanicCounter, 1)
+	panicMu.Lock()
+	panicCounts[source]++
+	panicMu.Unlock()
+
+	stack := debug.Stack()
+	fields = append(fields,
+		log.String("source", source),
+		log.String("panic_value", fmt.Sprintf("%v", r)),
+		log.String("stack", string(stack)),
+		log.Component("panic-recovery"))
+	log.Error("Panic recovered", fields...)
+
+	// 调用自定义处理器（如果有）
+	if PanicHandler != nil {
+		PanicHandler(name, r, stack)
+	}
+}
+
+// PanicCounts 返回各来源恢复的 panic 次数
+func PanicCounts() map[string]uint64 {
+	panicMu.Lock()
+	defer panicMu.Unlock()
+	return maps.Clone(panicCounts)
+}
+
 // RecoverPanic 恢复 panic 的通用函数
 // 应在所有 goroutine 开头使用 defer RecoverPanic("goroutine-name")
 func RecoverPanic(goroutineName string) {
 	if r := recover(); r != nil {
-		atomic.AddInt64(&PanicCounter, 1)
-
-		stack := debug.Stack()
-
-		// 记录 panic 信息
-		log.Error("Panic recovered",
-			log.Goroutine(goroutineName),
-			log.String("panic_value", fmt.Sprintf("%v", r)),
-			log.String("stack", string(stack)),
-			log.Component("panic-recovery"))
-
-		// 调用自定义处理器（如果有）
-		if PanicHandler != nil {
-			PanicHandler(goroutineName, r, stack)
-		}
+		ReportPanic(PanicSourceGoroutine, goroutineName, r, log.Goroutine(goroutineName))
 	}
 }
 
@@ -67,19 +98,9 @@ func SafeGoWithRestart(name string, fn func(), maxRestarts int) {
 	worker = func() {
 		defer func() {
 			if r := recover(); r != nil {
-				atomic.AddInt64(&PanicCounter, 1)
-				stack := debug.Stack()
-
-				log.Error("Panic recovered in auto-restart goroutine",
+				ReportPanic(PanicSourceGoroutine, name, r,
 					log.Goroutine(name),
-					log.Int("restart_count", restartCount),
-					log.String("panic_value", fmt.Sprintf("%v", r)),
-					log.String("stack", string(stack)),
-					log.Component("panic-recovery"))
-
-				if PanicHandler != nil {
-					PanicHandler(name, r, stack)
-				}
+					log.Int("restart_count", restartCount))
 
 				// 检查是否应该重启
 				restartCount++
@@ -118,18 +139,7 @@ func ResetPanicCount() {
 func PanicMiddleware(handler func() error) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			atomic.AddInt64(&PanicCounter, 1)
-			stack := debug.Stack()
-
-			log.Error("Panic recovered in handler",
-				log.String("panic_value", fmt.Sprintf("%v", r)),
-				log.String("stack", string(stack)),
-				log.Component("panic-middleware"))
-
-			if PanicHandler != nil {
-				PanicHandler("grpc-handler", r, stack)
-			}
-
+			ReportPanic(PanicSourceGRPC, "grpc-handler", r)
 			err = fmt.Errorf("internal server error: panic recovered")
 		}
 	}()
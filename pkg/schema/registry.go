@@ -0,0 +1,130 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema 实现按 key 前缀注册的 JSON Schema 校验
+//
+// schema 文档保存在 __metastore/schema/<prefix> 下，随 Raft 复制。一个 key 由匹配的
+// 最长前缀对应的 schema 管辖，写入该 key 的 value 必须是满足 schema 的 JSON。
+// 校验由 Store 包装在提交 Raft 提案之前完成，不合法的写入不会进入日志。
+// 没有注册任何 schema 时写入直接透传
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"metaStore/internal/kvstore"
+)
+
+// registryPrefix schema 文档的 key 前缀：<registryPrefix><被管辖的 key 前缀>
+const registryPrefix = kvstore.SystemKeyPrefix + "schema/"
+
+var (
+	// ErrInvalidValue 写入的 value 不满足所在前缀的 schema
+	ErrInvalidValue = errors.New("schema: value does not match schema")
+	// ErrInvalidSchema schema 文档无法编译，或前缀不合法
+	ErrInvalidSchema = errors.New("schema: invalid schema")
+	// ErrNotFound 前缀没有注册 schema
+	ErrNotFound = errors.New("schema: schema not found")
+
+	// RefreshInterval 各节点重新加载 schema 的间隔，注册后最多经过一个周期在所有节点生效
+	RefreshInterval = time.Second
+)
+
+// Entry 注册在某个前缀上的 schema
+type Entry struct {
+	Prefix   string          `json:"prefix"`
+	Schema   json.RawMessage `json:"schema"`
+	Revision int64           `json:"revision"`
+}
+
+// registryKey 返回 prefix 对应的 schema key
+func registryKey(prefix string) string {
+	return registryPrefix + prefix
+}
+
+// checkPrefix 校验被管辖的前缀，系统前缀下的 key 不受 schema 约束
+func checkPrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("%w: prefix must not be empty", ErrInvalidSchema)
+	}
+	if kvstore.IsSystemKey(prefix) {
+		return fmt.Errorf("%w: prefix %q is reserved", ErrInvalidSchema, prefix)
+	}
+	return nil
+}
+
+// List 返回所有注册的 schema，按前缀排序
+func List(ctx context.Context, store kvstore.Store) ([]Entry, error) {
+	start, end := kvstore.PrefixRange(registryPrefix)
+	resp, err := store.Range(ctx, start, end, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		entries = append(entries, Entry{
+			Prefix:   strings.TrimPrefix(string(kv.Key), registryPrefix),
+			Schema:   json.RawMessage(kv.Value),
+			Revision: kv.ModRevision,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Prefix < entries[j].Prefix })
+	return entries, nil
+}
+
+// Get 返回 prefix 上注册的 schema
+func Get(ctx context.Context, store kvstore.Store, prefix string) (*Entry, error) {
+	resp, err := store.Range(ctx, registryKey(prefix), "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+	kv := resp.Kvs[0]
+	return &Entry{Prefix: prefix, Schema: json.RawMessage(kv.Value), Revision: kv.ModRevision}, nil
+}
+
+// Put 在 prefix 上注册或替换 schema，已有的 key 不会被重新校验
+func Put(ctx context.Context, store kvstore.Store, prefix string, doc []byte) (*Entry, error) {
+	if err := checkPrefix(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := Compile(doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	rev, _, err := store.PutWithLease(ctx, registryKey(prefix), string(doc), 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{Prefix: prefix, Schema: json.RawMessage(doc), Revision: rev}, nil
+}
+
+// Delete 删除 prefix 上的 schema
+func Delete(ctx context.Context, store kvstore.Store, prefix string) error {
+	deleted, _, _, err := store.DeleteRange(ctx, registryKey(prefix), "")
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
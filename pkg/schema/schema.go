@@ -0,0 +1,619 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// unsupportedKeywords 尚未实现的 JSON Schema 校验关键字，编译时拒绝，避免规则被静默忽略
+var unsupportedKeywords = []string{
+	"additionalItems", "contains", "dependencies", "dependentRequired", "dependentSchemas",
+	"else", "if", "maxContains", "minContains", "patternProperties", "prefixItems",
+	"propertyNames", "then", "unevaluatedItems", "unevaluatedProperties",
+}
+
+// Schema 编译后的 JSON Schema（draft-07 的常用子集）
+//
+// 支持 type、enum、const、properties、required、additionalProperties、min/maxProperties、
+// items、min/maxItems、uniqueItems、min/maxLength、pattern、minimum、maximum、
+// exclusiveMinimum、exclusiveMaximum、multipleOf、allOf、anyOf、oneOf、not 以及文档内的 $ref。
+// format、title、description 等注解关键字被忽略
+type Schema struct {
+	always *bool // true/false schema
+
+	types    []string
+	enum     []interface{}
+	constVal *interface{}
+
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	minProperties        *int
+	maxProperties        *int
+
+	items       *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+
+	ref string // 通过 compiler.refs 延迟解析，支持递归引用
+	c   *compiler
+}
+
+// compiler 保存根文档以解析 $ref
+type compiler struct {
+	root interface{}
+	refs map[string]*Schema
+}
+
+// Compile 解析并编译 JSON Schema 文档
+func Compile(doc []byte) (*Schema, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	c := &compiler{root: root, refs: make(map[string]*Schema)}
+	s, err := c.compile(root, "#")
+	if err != nil {
+		return nil, err
+	}
+	// 确认所有引用都能解析
+	for ref := range c.refs {
+		if _, err := c.resolve(ref); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func (c *compiler) compile(node interface{}, path string) (*Schema, error) {
+	if b, ok := node.(bool); ok {
+		return &Schema{always: &b}, nil
+	}
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", path)
+	}
+	for _, kw := range unsupportedKeywords {
+		if _, ok := m[kw]; ok {
+			return nil, fmt.Errorf("%s: unsupported keyword %q", path, kw)
+		}
+	}
+
+	s := &Schema{c: c}
+	if ref, ok := m["$ref"]; ok {
+		r, ok := ref.(string)
+		if !ok || !strings.HasPrefix(r, "#") {
+			return nil, fmt.Errorf("%s: only local $ref (#/...) is supported", path)
+		}
+		s.ref = r
+		c.refs[r] = nil
+		return s, nil
+	}
+
+	var err error
+	if t, ok := m["type"]; ok {
+		switch t := t.(type) {
+		case string:
+			s.types = []string{t}
+		case []interface{}:
+			for _, v := range t {
+				name, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s/type: must be a string or array of strings", path)
+				}
+				s.types = append(s.types, name)
+			}
+		default:
+			return nil, fmt.Errorf("%s/type: must be a string or array of strings", path)
+		}
+		for _, name := range s.types {
+			switch name {
+			case "null", "boolean", "object", "array", "number", "integer", "string":
+			default:
+				return nil, fmt.Errorf("%s/type: unknown type %q", path, name)
+			}
+		}
+	}
+	if e, ok := m["enum"]; ok {
+		if s.enum, ok = e.([]interface{}); !ok {
+			return nil, fmt.Errorf("%s/enum: must be an array", path)
+		}
+	}
+	if v, ok := m["const"]; ok {
+		s.constVal = &v
+	}
+
+	if p, ok := m["properties"]; ok {
+		props, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/properties: must be an object", path)
+		}
+		s.properties = make(map[string]*Schema, len(props))
+		for name, sub := range props {
+			if s.properties[name], err = c.compile(sub, path+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r, ok := m["required"]; ok {
+		list, ok := r.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s/required: must be an array of strings", path)
+		}
+		for _, v := range list {
+			name, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/required: must be an array of strings", path)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if a, ok := m["additionalProperties"]; ok {
+		if s.additionalProperties, err = c.compile(a, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if i, ok := m["items"]; ok {
+		if s.items, err = c.compile(i, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	if u, ok := m["uniqueItems"]; ok {
+		if s.uniqueItems, ok = u.(bool); !ok {
+			return nil, fmt.Errorf("%s/uniqueItems: must be a boolean", path)
+		}
+	}
+	if p, ok := m["pattern"]; ok {
+		str, ok := p.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/pattern: must be a string", path)
+		}
+		if s.pattern, err = regexp.Compile(str); err != nil {
+			return nil, fmt.Errorf("%s/pattern: %w", path, err)
+		}
+	}
+
+	for kw, dst := range map[string]**int{
+		"minProperties": &s.minProperties, "maxProperties": &s.maxProperties,
+		"minItems": &s.minItems, "maxItems": &s.maxItems,
+		"minLength": &s.minLength, "maxLength": &s.maxLength,
+	} {
+		if v, ok := m[kw]; ok {
+			n, ok := intOf(v)
+			if !ok || n < 0 {
+				return nil, fmt.Errorf("%s/%s: must be a non-negative integer", path, kw)
+			}
+			*dst = &n
+		}
+	}
+	for kw, dst := range map[string]**float64{
+		"minimum": &s.minimum, "maximum": &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMinimum, "exclusiveMaximum": &s.exclusiveMaximum,
+		"multipleOf": &s.multipleOf,
+	} {
+		if v, ok := m[kw]; ok {
+			f, ok := floatOf(v)
+			if !ok {
+				return nil, fmt.Errorf("%s/%s: must be a number", path, kw)
+			}
+			*dst = &f
+		}
+	}
+	if s.multipleOf != nil && *s.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s/multipleOf: must be greater than 0", path)
+	}
+
+	for kw, dst := range map[string]*[]*Schema{"allOf": &s.allOf, "anyOf": &s.anyOf, "oneOf": &s.oneOf} {
+		if v, ok := m[kw]; ok {
+			list, ok := v.([]interface{})
+			if !ok || len(list) == 0 {
+				return nil, fmt.Errorf("%s/%s: must be a non-empty array", path, kw)
+			}
+			for i, sub := range list {
+				compiled, err := c.compile(sub, fmt.Sprintf("%s/%s/%d", path, kw, i))
+				if err != nil {
+					return nil, err
+				}
+				*dst = append(*dst, compiled)
+			}
+		}
+	}
+	if n, ok := m["not"]; ok {
+		if s.not, err = c.compile(n, path+"/not"); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// resolve 按 JSON Pointer 解析文档内引用，结果缓存以支持递归 schema
+func (c *compiler) resolve(ref string) (*Schema, error) {
+	if s := c.refs[ref]; s != nil {
+		return s, nil
+	}
+	node := c.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			switch n := node.(type) {
+			case map[string]interface{}:
+				var ok bool
+				if node, ok = n[token]; !ok {
+					return nil, fmt.Errorf("unresolvable $ref %q", ref)
+				}
+			case []interface{}:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(n) {
+					return nil, fmt.Errorf("unresolvable $ref %q", ref)
+				}
+				node = n[i]
+			default:
+				return nil, fmt.Errorf("unresolvable $ref %q", ref)
+			}
+		}
+	}
+	// 先占位，递归引用自身时直接返回
+	s := &Schema{c: c}
+	c.refs[ref] = s
+	compiled, err := c.compile(node, ref)
+	if err != nil {
+		delete(c.refs, ref)
+		return nil, err
+	}
+	*s = *compiled
+	return s, nil
+}
+
+// Validate 校验 value 是否是满足 schema 的 JSON 文档
+func (s *Schema) Validate(value []byte) error {
+	doc, err := decode(value)
+	if err != nil {
+		return fmt.Errorf("value is not valid JSON: %v", err)
+	}
+	return s.validate(doc, "")
+}
+
+func (s *Schema) validate(v interface{}, path string) error {
+	if s.ref != "" {
+		target, err := s.c.resolve(s.ref)
+		if err != nil {
+			return err
+		}
+		return target.validate(v, path)
+	}
+	if s.always != nil {
+		if !*s.always {
+			return fmt.Errorf("%s: not allowed", pointer(path))
+		}
+		return nil
+	}
+
+	if len(s.types) > 0 {
+		matched := false
+		for _, t := range s.types {
+			if hasType(v, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", pointer(path), strings.Join(s.types, " or "), typeOf(v))
+		}
+	}
+	if s.enum != nil {
+		found := false
+		for _, e := range s.enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed values", pointer(path))
+		}
+	}
+	if s.constVal != nil && !equal(v, *s.constVal) {
+		return fmt.Errorf("%s: value does not match const", pointer(path))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if err := s.validateObject(v, path); err != nil {
+			return err
+		}
+	case []interface{}:
+		if err := s.validateArray(v, path); err != nil {
+			return err
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.minLength != nil && n < *s.minLength {
+			return fmt.Errorf("%s: length %d is less than minLength %d", pointer(path), n, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			return fmt.Errorf("%s: length %d exceeds maxLength %d", pointer(path), n, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Errorf("%s: does not match pattern %q", pointer(path), s.pattern.String())
+		}
+	case json.Number:
+		if err := s.validateNumber(v, path); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range s.allOf {
+		if err := sub.validate(v, path); err != nil {
+			return err
+		}
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.validate(v, path) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: does not match any schema in anyOf", pointer(path))
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(v, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d schemas in oneOf, want exactly 1", pointer(path), matched)
+		}
+	}
+	if s.not != nil && s.not.validate(v, path) == nil {
+		return fmt.Errorf("%s: must not match the schema in not", pointer(path))
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(obj map[string]interface{}, path string) error {
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", pointer(path), name)
+		}
+	}
+	if s.minProperties != nil && len(obj) < *s.minProperties {
+		return fmt.Errorf("%s: has %d properties, minProperties is %d", pointer(path), len(obj), *s.minProperties)
+	}
+	if s.maxProperties != nil && len(obj) > *s.maxProperties {
+		return fmt.Errorf("%s: has %d properties, maxProperties is %d", pointer(path), len(obj), *s.maxProperties)
+	}
+
+	// 按属性名排序，保证错误信息稳定
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		child := path + "/" + escapePointer(name)
+		if sub, ok := s.properties[name]; ok {
+			if err := sub.validate(obj[name], child); err != nil {
+				return err
+			}
+		} else if s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				return fmt.Errorf("%s: additional property %q is not allowed", pointer(path), name)
+			}
+			if err := s.additionalProperties.validate(obj[name], child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(arr []interface{}, path string) error {
+	if s.minItems != nil && len(arr) < *s.minItems {
+		return fmt.Errorf("%s: has %d items, minItems is %d", pointer(path), len(arr), *s.minItems)
+	}
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		return fmt.Errorf("%s: has %d items, maxItems is %d", pointer(path), len(arr), *s.maxItems)
+	}
+	if s.uniqueItems {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if equal(arr[i], arr[j]) {
+					return fmt.Errorf("%s: items %d and %d are equal", pointer(path), i, j)
+				}
+			}
+		}
+	}
+	if s.items != nil {
+		for i, item := range arr {
+			if err := s.items.validate(item, path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateNumber(n json.Number, path string) error {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return fmt.Errorf("%s: invalid number %s", pointer(path), n)
+	}
+	if s.minimum != nil && f < *s.minimum {
+		return fmt.Errorf("%s: %s is less than minimum %v", pointer(path), n, *s.minimum)
+	}
+	if s.maximum != nil && f > *s.maximum {
+		return fmt.Errorf("%s: %s exceeds maximum %v", pointer(path), n, *s.maximum)
+	}
+	if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+		return fmt.Errorf("%s: %s must be greater than %v", pointer(path), n, *s.exclusiveMinimum)
+	}
+	if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+		return fmt.Errorf("%s: %s must be less than %v", pointer(path), n, *s.exclusiveMaximum)
+	}
+	if s.multipleOf != nil {
+		q := f / *s.multipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			return fmt.Errorf("%s: %s is not a multiple of %v", pointer(path), n, *s.multipleOf)
+		}
+	}
+	return nil
+}
+
+func hasType(v interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := strconv.ParseFloat(string(n), 64)
+		return err == nil && f == math.Trunc(f)
+	default:
+		return typeOf(v) == t
+	}
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// equal 按 JSON 语义比较，数字按数值比较（1 与 1.0 相等）
+func equal(a, b interface{}) bool {
+	if na, ok := a.(json.Number); ok {
+		nb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, errA := strconv.ParseFloat(string(na), 64)
+		fb, errB := strconv.ParseFloat(string(nb), 64)
+		return errA == nil && errB == nil && fa == fb
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if bv, ok := b[k]; !ok || !equal(v, bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func intOf(v interface{}) (int, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(string(n))
+	return i, err == nil
+}
+
+func floatOf(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	return f, err == nil
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// pointer 返回错误信息中的位置，根用 "/" 表示
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
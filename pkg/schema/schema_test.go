@@ -0,0 +1,180 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	RefreshInterval = 20 * time.Millisecond
+	m.Run()
+}
+
+const serviceSchema = `{
+	"type": "object",
+	"required": ["name", "port"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "pattern": "^[a-z-]+$"},
+		"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+		"mode": {"enum": ["active", "standby"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+		"weight": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.5},
+		"backup": {"$ref": "#"}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Compile([]byte(serviceSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{`{"name":"api","port":8080}`, true},
+		{`{"name":"api","port":8080.0,"mode":"standby","tags":["a","b"],"weight":1.5}`, true},
+		{`{"name":"api","port":80,"backup":{"name":"api-b","port":81}}`, true},
+		{`{"name":"api"}`, false},
+		{`{"name":"API","port":80}`, false},
+		{`{"name":"api","port":0}`, false},
+		{`{"name":"api","port":80.5}`, false},
+		{`{"name":"api","port":80,"extra":1}`, false},
+		{`{"name":"api","port":80,"mode":"down"}`, false},
+		{`{"name":"api","port":80,"tags":["a","a"]}`, false},
+		{`{"name":"api","port":80,"weight":0}`, false},
+		{`{"name":"api","port":80,"weight":1.2}`, false},
+		{`{"name":"api","port":80,"backup":{"name":"api-b"}}`, false},
+		{`["api"]`, false},
+		{`not json`, false},
+		{`{"name":"api","port":80} {}`, false},
+	}
+	for _, tt := range tests {
+		err := s.Validate([]byte(tt.value))
+		if tt.ok {
+			assert.NoError(t, err, tt.value)
+		} else {
+			assert.Error(t, err, tt.value)
+		}
+	}
+
+	err = s.Validate([]byte(`{"name":"api","port":80,"backup":{"name":"api-b","port":"x"}}`))
+	assert.EqualError(t, err, "/backup/port: expected integer, got string")
+
+	combinators, err := Compile([]byte(`{"oneOf":[{"type":"string"},{"type":"integer"}],"not":{"const":"off"}}`))
+	require.NoError(t, err)
+	assert.NoError(t, combinators.Validate([]byte(`"on"`)))
+	assert.NoError(t, combinators.Validate([]byte(`3`)))
+	assert.Error(t, combinators.Validate([]byte(`"off"`)))
+	assert.Error(t, combinators.Validate([]byte(`true`)))
+}
+
+func TestCompileRejects(t *testing.T) {
+	for _, doc := range []string{
+		`not json`,
+		`[]`,
+		`{"type":"text"}`,
+		`{"pattern":"("}`,
+		`{"minLength":-1}`,
+		`{"multipleOf":0}`,
+		`{"$ref":"http://example.com/schema.json"}`,
+		`{"$ref":"#/definitions/missing"}`,
+		`{"if":{"type":"string"},"then":{"minLength":1}}`,
+		`{"anyOf":[]}`,
+	} {
+		_, err := Compile([]byte(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestStoreValidatesWrites(t *testing.T) {
+	ctx := context.Background()
+	base := memory.NewMemoryEtcd()
+	s := Wrap(base)
+	defer s.Close()
+
+	// 没有 schema 时写入透传
+	_, _, err := s.PutWithLease(ctx, "services/raw", "plain text", 0)
+	require.NoError(t, err)
+
+	_, err = Put(ctx, s, "services/", []byte(serviceSchema))
+	require.NoError(t, err)
+	_, err = Put(ctx, s, "services/legacy/", []byte(`true`))
+	require.NoError(t, err)
+	_, err = Put(ctx, s, "bad/", []byte(`{"type":"text"}`))
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+	_, err = Put(ctx, s, "__metastore/x", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	// 绕过 Put 直接写 schema key 同样被校验
+	_, _, err = s.PutWithLease(ctx, registryKey("other/"), `{"type":1}`, 0)
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+
+	// 本节点注册后立即生效
+	_, _, err = s.PutWithLease(ctx, "services/api", `{"name":"api","port":8080}`, 0)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "services/web", `{"name":"web"}`, 0)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	_, ok := base.Lookup("services/web")
+	assert.False(t, ok, "rejected value must not reach the store")
+
+	// 最长前缀优先
+	_, _, err = s.PutWithLease(ctx, "services/legacy/x", `"anything"`, 0)
+	assert.NoError(t, err)
+
+	// 事务中任一分支的非法 PUT 都会拒绝整个事务
+	_, err = s.Txn(ctx, nil, []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("services/db"), Value: []byte(`{"name":"db","port":5432}`)},
+	}, []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("services/db"), Value: []byte(`{}`)},
+	})
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	_, ok = base.Lookup("services/db")
+	assert.False(t, ok)
+
+	entries, err := List(ctx, s)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "services/", entries[0].Prefix)
+
+	require.NoError(t, Delete(ctx, s, "services/"))
+	assert.ErrorIs(t, Delete(ctx, s, "services/"), ErrNotFound)
+	_, _, err = s.PutWithLease(ctx, "services/web", `{"name":"web"}`, 0)
+	assert.NoError(t, err)
+}
+
+func TestStoreLoadsRemoteSchemas(t *testing.T) {
+	ctx := context.Background()
+	base := memory.NewMemoryEtcd()
+	s := Wrap(base)
+	defer s.Close()
+
+	// 其他节点注册的 schema 在刷新周期后生效
+	_, err := Put(ctx, base, "cfg/", []byte(`{"type":"object"}`))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, _, err := s.PutWithLease(ctx, "cfg/a", `"str"`, 0)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+}
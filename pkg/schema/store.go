@@ -0,0 +1,217 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// rule 一个前缀及其编译后的 schema
+type rule struct {
+	prefix string
+	schema *Schema
+}
+
+// Store 在写入进入 Raft 之前按前缀校验 value
+//
+// 写入 schema key 本身时校验 schema 文档能否编译。本节点注册或删除 schema 后立即
+// 重新加载，其他节点在下一个刷新周期生效
+type Store struct {
+	kvstore.Store
+
+	mu    sync.RWMutex
+	rules []rule // 按前缀长度降序，第一个匹配的即最长前缀
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+// Wrap 返回校验 value 的存储，并在后台定期加载 schema
+func Wrap(store kvstore.Store) *Store {
+	s := &Store{
+		Store: store,
+		stopC: make(chan struct{}),
+		doneC: make(chan struct{}),
+	}
+	go s.refreshLoop()
+	return s
+}
+
+// Unwrap 实现 kvstore.Wrapper，供调用方查找底层存储的其他能力
+func (s *Store) Unwrap() kvstore.Store {
+	return s.Store
+}
+
+// RangeFunc 读取不经过校验，转发给底层存储以保留流式读取
+func (s *Store) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.RangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// ReverseRangeFunc 同样转发给底层存储
+func (s *Store) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.ReverseRangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// Close 停止加载 schema
+func (s *Store) Close() {
+	close(s.stopC)
+	<-s.doneC
+}
+
+func (s *Store) refreshLoop() {
+	defer close(s.doneC)
+
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		s.reload()
+		select {
+		case <-ticker.C:
+		case <-s.stopC:
+			return
+		}
+	}
+}
+
+func (s *Store) reload() {
+	if err := s.refresh(); err != nil {
+		log.Warn("Failed to load value schemas",
+			zap.Error(err),
+			zap.String("component", "schema"))
+	}
+}
+
+// refresh 重新加载 schema，失败时保留上一次的结果。无法编译的 schema（例如绕过校验
+// 直接写入底层存储）会被跳过并记录日志
+func (s *Store) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), RefreshInterval)
+	defer cancel()
+	entries, err := List(ctx, s.Store)
+	if err != nil {
+		return err
+	}
+	rules := make([]rule, 0, len(entries))
+	for _, e := range entries {
+		compiled, err := Compile(e.Schema)
+		if err != nil {
+			log.Warn("Skipping invalid value schema",
+				zap.String("prefix", e.Prefix),
+				zap.Error(err),
+				zap.String("component", "schema"))
+			continue
+		}
+		rules = append(rules, rule{prefix: e.Prefix, schema: compiled})
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return len(rules[i].prefix) > len(rules[j].prefix) })
+
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+	return nil
+}
+
+// validate 校验写入 key 的 value
+func (s *Store) validate(key string, value []byte) error {
+	if strings.HasPrefix(key, registryPrefix) {
+		if err := checkPrefix(strings.TrimPrefix(key, registryPrefix)); err != nil {
+			return err
+		}
+		if _, err := Compile(value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+		return nil
+	}
+	if kvstore.IsSystemKey(key) {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.rules {
+		if strings.HasPrefix(key, r.prefix) {
+			if err := r.schema.Validate(value); err != nil {
+				return fmt.Errorf("%w for key %q (prefix %q): %v", ErrInvalidValue, key, r.prefix, err)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// touchesRegistry 判断写入是否修改了 schema
+func touchesRegistry(key, rangeEnd string) bool {
+	if strings.HasPrefix(key, registryPrefix) {
+		return true
+	}
+	if rangeEnd == "" {
+		return false
+	}
+	start, end := kvstore.PrefixRange(registryPrefix)
+	return key < end && (rangeEnd == "\x00" || rangeEnd > start)
+}
+
+func (s *Store) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	if err := s.validate(key, []byte(value)); err != nil {
+		return 0, nil, err
+	}
+	rev, prev, err := s.Store.PutWithLease(ctx, key, value, leaseID)
+	if err == nil && touchesRegistry(key, "") {
+		s.reload()
+	}
+	return rev, prev, err
+}
+
+func (s *Store) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	deleted, prevKvs, rev, err := s.Store.DeleteRange(ctx, key, rangeEnd)
+	if err == nil && deleted > 0 && touchesRegistry(key, rangeEnd) {
+		s.reload()
+	}
+	return deleted, prevKvs, rev, err
+}
+
+// Txn 校验两个分支中的所有 PUT，任一不合法则整个事务被拒绝
+func (s *Store) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+	touched := false
+	for _, ops := range [][]kvstore.Op{thenOps, elseOps} {
+		for _, op := range ops {
+			if op.Type != kvstore.OpPut && op.Type != kvstore.OpDelete {
+				continue
+			}
+			if touchesRegistry(string(op.Key), string(op.RangeEnd)) {
+				touched = true
+			}
+			if op.Type == kvstore.OpPut {
+				if err := s.validate(string(op.Key), op.Value); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	resp, err := s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	if err == nil && touched {
+		s.reload()
+	}
+	return resp, err
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// Values 集群中已设置的值，name -> value。没有设置的项由调用方使用配置文件中的值
+type Values map[string]string
+
+// Int 返回整数设置，没有设置时返回 def
+func (v Values) Int(name string, def int64) int64 {
+	if s, ok := v[name]; ok {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Float 返回浮点数设置，没有设置时返回 def
+func (v Values) Float(name string, def float64) float64 {
+	if s, ok := v[name]; ok {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// Duration 返回时长设置，没有设置时返回 def
+func (v Values) Duration(name string, def time.Duration) time.Duration {
+	if s, ok := v[name]; ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// Bool 返回布尔设置，没有设置时返回 def
+func (v Values) Bool(name string, def bool) bool {
+	if s, ok := v[name]; ok {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// Manager 在本节点上加载集群设置，值变化时通知订阅者
+//
+// 本节点通过管理接口修改设置后可以调用 Refresh 立即生效，其他节点在下一个刷新周期生效
+type Manager struct {
+	store kvstore.Store
+
+	refreshMu sync.Mutex // 串行化 Refresh，保证订阅者按顺序收到变化
+	mu        sync.Mutex
+	values    Values
+	versions  map[string]int64
+	subs      []func(Values)
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+// NewManager 创建设置管理器，调用 Start 之后开始加载
+func NewManager(store kvstore.Store) *Manager {
+	return &Manager{
+		store: store,
+		stopC: make(chan struct{}),
+		doneC: make(chan struct{}),
+	}
+}
+
+// Subscribe 注册订阅者。第一次加载后以及之后任一设置变化时，订阅者收到所有已设置的值
+func (m *Manager) Subscribe(fn func(Values)) {
+	m.mu.Lock()
+	m.subs = append(m.subs, fn)
+	loaded := m.values
+	m.mu.Unlock()
+	if loaded != nil {
+		fn(maps.Clone(loaded))
+	}
+}
+
+// Start 在后台定期加载设置
+func (m *Manager) Start() {
+	go m.refreshLoop()
+}
+
+// Close 停止加载设置
+func (m *Manager) Close() {
+	close(m.stopC)
+	<-m.doneC
+}
+
+// Values 返回最近一次加载的设置
+func (m *Manager) Values() Values {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.values)
+}
+
+// Enabled 返回功能开关 feature.<name> 是否打开，没有设置时返回 def
+func (m *Manager) Enabled(name string, def bool) bool {
+	return m.Values().Bool(FeaturePrefix+strings.TrimPrefix(name, FeaturePrefix), def)
+}
+
+func (m *Manager) refreshLoop() {
+	defer close(m.doneC)
+
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.Refresh(); err != nil {
+			log.Warn("Failed to load cluster settings",
+				zap.Error(err),
+				zap.String("component", "settings"))
+		}
+		select {
+		case <-ticker.C:
+		case <-m.stopC:
+			return
+		}
+	}
+}
+
+// Refresh 重新加载设置，有变化时通知订阅者。失败时保留上一次的结果，不合法的值
+// （例如绕过管理接口直接写入）会被跳过并记录日志
+func (m *Manager) Refresh() error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), RefreshInterval)
+	defer cancel()
+	list, err := List(ctx, m.store)
+	if err != nil {
+		return err
+	}
+	values := make(Values, len(list))
+	versions := make(map[string]int64, len(list))
+	for _, s := range list {
+		if err := Validate(s.Name, s.Value); err != nil {
+			log.Warn("Skipping invalid cluster setting",
+				zap.String("name", s.Name),
+				zap.Error(err),
+				zap.String("component", "settings"))
+			continue
+		}
+		values[s.Name] = s.Value
+		versions[s.Name] = s.Version
+	}
+
+	m.mu.Lock()
+	changed := m.values == nil || !maps.Equal(m.versions, versions)
+	if changed {
+		m.values = values
+		m.versions = versions
+	}
+	subs := append([]func(Values){}, m.subs...)
+	m.mu.Unlock()
+
+	if changed {
+		log.Info("Applying cluster settings",
+			zap.Any("settings", values),
+			zap.String("component", "settings"))
+		for _, fn := range subs {
+			fn(maps.Clone(values))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package settings 实现集群范围的运行时设置
+//
+// 设置保存在 __metastore/settings/<name> 下，随 Raft 复制到所有节点，设置的版本即 key
+// 的 ModRevision。各节点的 Manager 定期加载设置并通知订阅者，调整参数不需要滚动重启，
+// 也不会因为各节点 YAML 不一致而行为不同。没有设置的项使用节点配置文件中的值
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"metaStore/internal/kvstore"
+)
+
+// keyPrefix 设置的 key 前缀：<keyPrefix><name>
+const keyPrefix = kvstore.SystemKeyPrefix + "settings/"
+
+// FeaturePrefix 功能开关的名称前缀，feature.<name> 可以是任意名称，值为 true 或 false
+const FeaturePrefix = "feature."
+
+var (
+	// ErrUnknown 没有这个设置
+	ErrUnknown = errors.New("settings: unknown setting")
+	// ErrInvalid 设置的值不合法
+	ErrInvalid = errors.New("settings: invalid value")
+	// ErrNotSet 设置没有在集群中设置，使用节点配置文件中的值
+	ErrNotSet = errors.New("settings: not set")
+	// ErrVersionMismatch 设置已被其他请求修改
+	ErrVersionMismatch = errors.New("settings: version mismatch")
+
+	// RefreshInterval 各节点重新加载设置的间隔，修改后最多经过一个周期在所有节点生效
+	RefreshInterval = time.Second
+)
+
+// Kind 设置值的类型
+type Kind string
+
+const (
+	KindBool     Kind = "bool"
+	KindInt      Kind = "int"
+	KindFloat    Kind = "float"
+	KindDuration Kind = "duration"
+)
+
+// Definition 一个可以在运行时修改的设置
+type Definition struct {
+	Name        string `json:"name"`
+	Kind        Kind   `json:"kind"`
+	Description string `json:"description"`
+
+	check func(string) error // 类型之外的取值范围检查
+}
+
+// definitions 内置的设置，按名称排序
+var definitions = []Definition{
+	{
+		Name:        "compaction.retention",
+		Kind:        KindInt,
+		Description: "revisions kept by periodic auto compaction, 0 disables it",
+		check:       atLeast(0),
+	},
+	{
+		Name:        "limits.max_pending_proposals",
+		Kind:        KindInt,
+		Description: "writes are rejected when this many proposals wait to be applied, 0 means no limit",
+		check:       atLeast(0),
+	},
+	{
+		Name:        "limits.propose_queue_threshold",
+		Kind:        KindFloat,
+		Description: "writes are rejected when this fraction of the propose queue is in use",
+		check: func(v string) error {
+			f, _ := strconv.ParseFloat(v, 64)
+			if f <= 0 || f > 1 {
+				return fmt.Errorf("must be in (0, 1]")
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "limits.request_timeout",
+		Kind:        KindDuration,
+		Description: "write timeout when the client sets no deadline",
+		check:       positiveDuration,
+	},
+	{
+		Name:        "limits.retry_after",
+		Kind:        KindDuration,
+		Description: "retry hint returned with rejected writes",
+		check: func(v string) error {
+			if d, _ := time.ParseDuration(v); d < 0 {
+				return fmt.Errorf("must not be negative")
+			}
+			return nil
+		},
+	},
+}
+
+// Definitions 返回所有内置设置
+func Definitions() []Definition {
+	return append([]Definition(nil), definitions...)
+}
+
+// Lookup 返回 name 对应的设置定义，feature.<name> 都是 bool 类型的功能开关
+func Lookup(name string) (Definition, bool) {
+	for _, d := range definitions {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	if strings.HasPrefix(name, FeaturePrefix) && len(name) > len(FeaturePrefix) {
+		return Definition{Name: name, Kind: KindBool, Description: "feature flag"}, true
+	}
+	return Definition{}, false
+}
+
+// Validate 检查 value 是否是 name 的合法取值
+func Validate(name, value string) error {
+	def, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknown, name)
+	}
+	var err error
+	switch def.Kind {
+	case KindBool:
+		_, err = strconv.ParseBool(value)
+	case KindInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case KindFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case KindDuration:
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s must be a %s: %q", ErrInvalid, name, def.Kind, value)
+	}
+	if def.check != nil {
+		if err := def.check(value); err != nil {
+			return fmt.Errorf("%w: %s %v", ErrInvalid, name, err)
+		}
+	}
+	return nil
+}
+
+func atLeast(min int64) func(string) error {
+	return func(v string) error {
+		if n, _ := strconv.ParseInt(v, 10, 64); n < min {
+			return fmt.Errorf("must be >= %d", min)
+		}
+		return nil
+	}
+}
+
+func positiveDuration(v string) error {
+	if d, _ := time.ParseDuration(v); d <= 0 {
+		return fmt.Errorf("must be > 0")
+	}
+	return nil
+}
+
+// Setting 集群中设置的一个值
+type Setting struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Version int64  `json:"version"` // 最后一次修改的 revision
+}
+
+func settingKey(name string) string {
+	return keyPrefix + name
+}
+
+// List 返回集群中所有已设置的值，按名称排序
+func List(ctx context.Context, store kvstore.Store) ([]Setting, error) {
+	start, end := kvstore.PrefixRange(keyPrefix)
+	resp, err := store.Range(ctx, start, end, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Setting, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		list = append(list, Setting{
+			Name:    strings.TrimPrefix(string(kv.Key), keyPrefix),
+			Value:   string(kv.Value),
+			Version: kv.ModRevision,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Get 返回 name 在集群中设置的值
+func Get(ctx context.Context, store kvstore.Store, name string) (*Setting, error) {
+	if _, ok := Lookup(name); !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknown, name)
+	}
+	resp, err := store.Range(ctx, settingKey(name), "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrNotSet
+	}
+	kv := resp.Kvs[0]
+	return &Setting{Name: name, Value: string(kv.Value), Version: kv.ModRevision}, nil
+}
+
+// Set 修改设置。version 大于等于 0 时只有当前版本等于 version 才修改（0 表示尚未设置），
+// 否则返回 ErrVersionMismatch；version 小于 0 时无条件修改
+func Set(ctx context.Context, store kvstore.Store, name, value string, version int64) (*Setting, error) {
+	if err := Validate(name, value); err != nil {
+		return nil, err
+	}
+	key := settingKey(name)
+	put := kvstore.Op{Type: kvstore.OpPut, Key: []byte(key), Value: []byte(value)}
+	resp, err := store.Txn(ctx, versionCompare(key, version), []kvstore.Op{put}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Succeeded {
+		return nil, fmt.Errorf("%w: %s", ErrVersionMismatch, name)
+	}
+	return &Setting{Name: name, Value: value, Version: resp.Revision}, nil
+}
+
+// Reset 删除集群中的设置，各节点恢复使用配置文件中的值。version 的含义与 Set 相同
+func Reset(ctx context.Context, store kvstore.Store, name string, version int64) error {
+	if _, ok := Lookup(name); !ok {
+		return fmt.Errorf("%w: %q", ErrUnknown, name)
+	}
+	key := settingKey(name)
+	del := kvstore.Op{Type: kvstore.OpDelete, Key: []byte(key)}
+	resp, err := store.Txn(ctx, versionCompare(key, version), []kvstore.Op{del}, nil)
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("%w: %s", ErrVersionMismatch, name)
+	}
+	if len(resp.Responses) > 0 && resp.Responses[0].DeleteResp != nil && resp.Responses[0].DeleteResp.Deleted == 0 {
+		return ErrNotSet
+	}
+	return nil
+}
+
+func versionCompare(key string, version int64) []kvstore.Compare {
+	if version < 0 {
+		return nil
+	}
+	return []kvstore.Compare{{
+		Target:      kvstore.CompareMod,
+		Result:      kvstore.CompareEqual,
+		Key:         []byte(key),
+		TargetUnion: kvstore.CompareUnion{ModRevision: version},
+	}}
+}
+
+// Status 一个设置的定义及其在集群中的值，用于管理接口
+type Status struct {
+	Definition
+	Value   string `json:"value,omitempty"`
+	Version int64  `json:"version,omitempty"` // 0 表示没有设置，各节点使用配置文件中的值
+}
+
+// Statuses 合并内置设置和 list 中已设置的值，已设置的功能开关也包含在内
+func Statuses(list []Setting) []Status {
+	byName := make(map[string]Setting, len(list))
+	for _, s := range list {
+		byName[s.Name] = s
+	}
+	statuses := make([]Status, 0, len(definitions)+len(list))
+	for _, d := range definitions {
+		st := Status{Definition: d}
+		if s, ok := byName[d.Name]; ok {
+			st.Value, st.Version = s.Value, s.Version
+		}
+		statuses = append(statuses, st)
+	}
+	for _, s := range list {
+		if strings.HasPrefix(s.Name, FeaturePrefix) {
+			def, _ := Lookup(s.Name)
+			statuses = append(statuses, Status{Definition: def, Value: s.Value, Version: s.Version})
+		}
+	}
+	return statuses
+}
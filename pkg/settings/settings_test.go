@@ -0,0 +1,106 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("limits.retry_after", "2s"))
+	assert.NoError(t, Validate("feature.fast_path", "true"))
+	assert.ErrorIs(t, Validate("limits.retry_after", "2"), ErrInvalid)
+	assert.ErrorIs(t, Validate("limits.propose_queue_threshold", "1.5"), ErrInvalid)
+	assert.ErrorIs(t, Validate("compaction.retention", "-1"), ErrInvalid)
+	assert.ErrorIs(t, Validate("feature.fast_path", "maybe"), ErrInvalid)
+	assert.ErrorIs(t, Validate("no.such", "1"), ErrUnknown)
+	assert.ErrorIs(t, Validate("feature.", "true"), ErrUnknown)
+}
+
+func TestSetVersioned(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+
+	_, err := Get(ctx, store, "compaction.retention")
+	assert.ErrorIs(t, err, ErrNotSet)
+
+	// version 0：只在尚未设置时写入
+	s1, err := Set(ctx, store, "compaction.retention", "100", 0)
+	require.NoError(t, err)
+	_, err = Set(ctx, store, "compaction.retention", "200", 0)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+
+	s2, err := Set(ctx, store, "compaction.retention", "200", s1.Version)
+	require.NoError(t, err)
+	assert.Greater(t, s2.Version, s1.Version)
+	got, err := Get(ctx, store, "compaction.retention")
+	require.NoError(t, err)
+	assert.Equal(t, *s2, *got)
+
+	_, err = Set(ctx, store, "feature.fast_path", "true", -1)
+	require.NoError(t, err)
+	list, err := List(ctx, store)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	statuses := Statuses(list)
+	assert.Len(t, statuses, len(definitions)+1)
+	assert.Equal(t, "200", statuses[0].Value)
+
+	assert.ErrorIs(t, Reset(ctx, store, "compaction.retention", s1.Version), ErrVersionMismatch)
+	assert.NoError(t, Reset(ctx, store, "compaction.retention", s2.Version))
+	assert.ErrorIs(t, Reset(ctx, store, "compaction.retention", -1), ErrNotSet)
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	_, err := Set(ctx, store, "limits.retry_after", "3s", -1)
+	require.NoError(t, err)
+	// 绕过校验写入的值被跳过
+	_, _, err = store.PutWithLease(ctx, keyPrefix+"limits.request_timeout", "soon", 0)
+	require.NoError(t, err)
+
+	m := NewManager(store)
+	var got []Values
+	m.Subscribe(func(v Values) { got = append(got, v) })
+
+	require.NoError(t, m.Refresh())
+	require.Len(t, got, 1)
+	assert.Equal(t, 3*time.Second, got[0].Duration("limits.retry_after", time.Second))
+	assert.Equal(t, 30*time.Second, got[0].Duration("limits.request_timeout", 30*time.Second))
+
+	// 没有变化时不通知
+	require.NoError(t, m.Refresh())
+	assert.Len(t, got, 1)
+
+	_, err = Set(ctx, store, "feature.fast_path", "true", -1)
+	require.NoError(t, err)
+	require.NoError(t, m.Refresh())
+	assert.Len(t, got, 2)
+	assert.True(t, m.Enabled("fast_path", false))
+	assert.False(t, m.Enabled("other", false))
+
+	// 晚注册的订阅者立即收到当前的值
+	var late Values
+	m.Subscribe(func(v Values) { late = v })
+	assert.Equal(t, "true", late["feature.fast_path"])
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlindex
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ValueFilter 按 value 过滤，用于 watch 在服务端丢弃不关心的事件
+// 所有非空条件同时满足才匹配，没有条件时总是匹配
+type ValueFilter struct {
+	Prefix    string // value 以该前缀开头
+	JSONPath  string // value 中该 JSON 路径的值等于 JSONValue
+	JSONValue string // 按 Term 的规范文本比较，因此 5 与 "5" 相等
+}
+
+// Empty 判断是否没有任何过滤条件
+func (f ValueFilter) Empty() bool {
+	return f.Prefix == "" && f.JSONPath == ""
+}
+
+// Validate 校验 JSON 路径
+func (f ValueFilter) Validate() error {
+	if f.JSONPath == "" {
+		if f.JSONValue != "" {
+			return errors.New("sqlindex: JSON value filter requires a JSON path")
+		}
+		return nil
+	}
+	_, err := parsePath(f.JSONPath)
+	return err
+}
+
+// Match 判断 value 是否满足全部条件
+func (f ValueFilter) Match(value []byte) bool {
+	if !bytes.HasPrefix(value, []byte(f.Prefix)) {
+		return false
+	}
+	if f.JSONPath == "" {
+		return true
+	}
+	v, ok := Extract(value, f.JSONPath)
+	if !ok {
+		return false
+	}
+	term, ok := Term(v)
+	return ok && term == f.JSONValue
+}
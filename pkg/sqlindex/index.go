@@ -0,0 +1,548 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlindex 实现 MySQL 前端使用的 value 二级索引
+//
+// 索引定义和索引条目都保存在系统前缀下，随 Raft 复制。索引可以建在整个 value 上
+// （支持 value = 'x'、value LIKE 'x%' 和基于三元组的 value LIKE '%x%'），也可以建在
+// value 中的 JSON 路径上（支持 JSON_EXTRACT(value, '$.name') = 'x'）。
+//
+// 每次 PUT/DELETE 由 Store 包装在同一个事务中写入数据和索引条目。索引只用于缩小候选
+// key 集合，查询方必须重新读取 value 并校验条件，因此残留的过期条目（例如 lease 过期
+// 删除的 key）只影响性能，不影响结果
+package sqlindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"metaStore/internal/kvstore"
+)
+
+const (
+	// defPrefix 索引定义的 key 前缀
+	defPrefix = kvstore.SystemKeyPrefix + "sqlindex/def/"
+	// dataPrefix 索引条目的 key 前缀：<dataPrefix><name>/<kind>/<escaped term>\x00\x01<key>
+	dataPrefix = kvstore.SystemKeyPrefix + "sqlindex/data/"
+
+	// maxIndexedValue 超过该长度的 value 不展开为等值和三元组条目，只记录一个 long 条目，
+	// 查询时总是作为候选，避免单次写入产生过多条目
+	maxIndexedValue = 1024
+	// backfillBatch 回填时每个事务处理的 key 数量
+	backfillBatch = 256
+)
+
+// 条目类型
+const (
+	kindEqual   = "e" // 完整的 value 或 JSON 路径上的值
+	kindTrigram = "t" // value 的三元组
+	kindLong    = "l" // 超长 value
+)
+
+// termEnd 条目中 term 的结束标记，term 内部的 \x00 转义为 \x00\xff，保证前缀扫描有序
+const termEnd = "\x00\x01"
+
+var (
+	// ErrExists 索引已存在
+	ErrExists = errors.New("sqlindex: index already exists")
+	// ErrNotFound 索引不存在
+	ErrNotFound = errors.New("sqlindex: index not found")
+
+	nameRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+	// RefreshInterval 各节点重新加载索引定义的间隔，CREATE INDEX 会等待三个周期再回填
+	RefreshInterval = time.Second
+)
+
+// State 索引状态
+type State string
+
+const (
+	// StateWriteOnly 写入时维护，查询不使用（等待所有节点加载定义并回填）
+	StateWriteOnly State = "write_only"
+	// StatePublic 回填完成，查询可以使用
+	StatePublic State = "public"
+)
+
+// Definition 二级索引定义
+type Definition struct {
+	Name     string `json:"name"`
+	JSONPath string `json:"json_path,omitempty"` // 为空表示索引整个 value
+	State    State  `json:"state"`
+	Revision int64  `json:"-"` // 定义 key 的 ModRevision
+}
+
+// Expression 返回索引表达式的 SQL 形式
+func (d *Definition) Expression() string {
+	if d.JSONPath == "" {
+		return "value"
+	}
+	return fmt.Sprintf("JSON_EXTRACT(value, '%s')", d.JSONPath)
+}
+
+func defKey(name string) string {
+	return defPrefix + name
+}
+
+func (d *Definition) entryPrefix(kind string) string {
+	return dataPrefix + d.Name + "/" + kind + "/"
+}
+
+// entryKey 返回 key 在 term 上的索引条目
+func (d *Definition) entryKey(kind, term, key string) string {
+	return d.entryPrefix(kind) + escapeTerm(term) + termEnd + key
+}
+
+func escapeTerm(term string) string {
+	return strings.ReplaceAll(term, "\x00", "\x00\xff")
+}
+
+// entries 返回 key=value 在索引上的全部条目
+func (d *Definition) entries(key string, value []byte) []string {
+	if d.JSONPath != "" {
+		v, ok := Extract(value, d.JSONPath)
+		if !ok {
+			return nil
+		}
+		term, ok := Term(v)
+		if !ok || len(term) > maxIndexedValue {
+			return nil
+		}
+		return []string{d.entryKey(kindEqual, term, key)}
+	}
+
+	if len(value) > maxIndexedValue {
+		return []string{d.entryKey(kindLong, "", key)}
+	}
+	entries := []string{d.entryKey(kindEqual, string(value), key)}
+	for _, t := range trigrams(string(value)) {
+		entries = append(entries, d.entryKey(kindTrigram, t, key))
+	}
+	return entries
+}
+
+// trigrams 返回 s 中去重后的全部三字节子串
+func trigrams(s string) []string {
+	if len(s) < 3 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(s))
+	var out []string
+	for i := 0; i+3 <= len(s); i++ {
+		t := s[i : i+3]
+		if _, ok := seen[t]; !ok {
+			seen[t] = struct{}{}
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Validate 校验索引名和 JSON 路径
+func (d *Definition) Validate() error {
+	if !nameRe.MatchString(d.Name) {
+		return fmt.Errorf("sqlindex: invalid index name %q", d.Name)
+	}
+	if d.JSONPath != "" {
+		if _, err := parsePath(d.JSONPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List 返回 store 中的全部索引定义，按名称排序
+func List(ctx context.Context, store kvstore.Store) ([]*Definition, error) {
+	start, end := kvstore.PrefixRange(defPrefix)
+	resp, err := store.Range(ctx, start, end, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]*Definition, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var d Definition
+		if err := json.Unmarshal(kv.Value, &d); err != nil {
+			return nil, fmt.Errorf("sqlindex: corrupt definition %q: %w", kv.Key, err)
+		}
+		d.Revision = kv.ModRevision
+		defs = append(defs, &d)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs, nil
+}
+
+// Create 创建索引并阻塞到回填完成
+//
+// 定义先以 write_only 状态写入，等待所有节点的 Store 加载到该定义（三个刷新周期）后，
+// 此后的写入都会维护索引，再回填已有的 key，最后切换为 public 供查询使用。
+// 中途失败时索引停留在 write_only 状态，可以 DROP 后重建
+func Create(ctx context.Context, store kvstore.Store, def Definition) (*Definition, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	def.State = StateWriteOnly
+	data, err := json.Marshal(&def)
+	if err != nil {
+		return nil, err
+	}
+
+	key := defKey(def.Name)
+	resp, err := store.Txn(ctx,
+		[]kvstore.Compare{{Target: kvstore.CompareVersion, Result: kvstore.CompareEqual, Key: []byte(key)}},
+		[]kvstore.Op{{Type: kvstore.OpPut, Key: []byte(key), Value: data}},
+		nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Succeeded {
+		return nil, ErrExists
+	}
+	def.Revision = resp.Responses[0].PutResp.Revision
+
+	select {
+	case <-time.After(3 * RefreshInterval):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if err := backfill(ctx, store, &def); err != nil {
+		return nil, fmt.Errorf("sqlindex: backfill %s: %w", def.Name, err)
+	}
+
+	def.State = StatePublic
+	if data, err = json.Marshal(&def); err != nil {
+		return nil, err
+	}
+	resp, err = store.Txn(ctx,
+		[]kvstore.Compare{{Target: kvstore.CompareMod, Result: kvstore.CompareEqual, Key: []byte(key),
+			TargetUnion: kvstore.CompareUnion{ModRevision: def.Revision}}},
+		[]kvstore.Op{{Type: kvstore.OpPut, Key: []byte(key), Value: data}},
+		nil)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Succeeded {
+		return nil, fmt.Errorf("sqlindex: index %s was changed during backfill", def.Name)
+	}
+	def.Revision = resp.Responses[0].PutResp.Revision
+	return &def, nil
+}
+
+// backfill 为已有的 key 写入索引条目
+//
+// 与并发写入交错时可能写入过期条目，但不会遗漏：并发写入方已经加载了定义，会自己维护条目
+func backfill(ctx context.Context, store kvstore.Store, def *Definition) error {
+	start, end := kvstore.PrefixRange("")
+	for {
+		resp, err := store.Range(ctx, start, end, backfillBatch, 0)
+		if err != nil {
+			return err
+		}
+
+		var ops []kvstore.Op
+		for _, kv := range resp.Kvs {
+			if kvstore.IsSystemKey(string(kv.Key)) {
+				continue
+			}
+			for _, entry := range def.entries(string(kv.Key), kv.Value) {
+				ops = append(ops, kvstore.Op{Type: kvstore.OpPut, Key: []byte(entry)})
+			}
+		}
+		if len(ops) > 0 {
+			if _, err := store.Txn(ctx, nil, ops, nil); err != nil {
+				return err
+			}
+		}
+
+		if !resp.More || len(resp.Kvs) == 0 {
+			return nil
+		}
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+		if kvstore.IsSystemKey(start) {
+			// 跳过系统前缀（包括索引条目本身）
+			_, start = kvstore.PrefixRange(kvstore.SystemKeyPrefix)
+		}
+	}
+}
+
+// Drop 删除索引定义及其全部条目
+func Drop(ctx context.Context, store kvstore.Store, name string) error {
+	deleted, _, _, err := store.DeleteRange(ctx, defKey(name), "")
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	start, end := kvstore.PrefixRange(dataPrefix + name + "/")
+	_, _, _, err = store.DeleteRange(ctx, start, end)
+	return err
+}
+
+// Equal 返回 term 完全匹配的候选 key
+func (d *Definition) Equal(ctx context.Context, store kvstore.Store, term string) ([]string, error) {
+	return d.scan(ctx, store, kindEqual, escapeTerm(term)+termEnd)
+}
+
+// Like 返回可能匹配 LIKE 模式的候选 key，ok=false 表示索引无法缩小范围，需要全量扫描
+func (d *Definition) Like(ctx context.Context, store kvstore.Store, pattern string) (keys []string, ok bool, err error) {
+	// 无前导通配符：在等值条目上做前缀扫描
+	if prefix := literalPrefix(pattern); prefix != "" {
+		keys, err = d.scan(ctx, store, kindEqual, escapeTerm(prefix))
+		return keys, err == nil, err
+	}
+	if d.JSONPath != "" {
+		return nil, false, nil
+	}
+
+	// 前导通配符：取模式中所有字面片段的三元组求交集
+	var grams []string
+	for _, segment := range literalSegments(pattern) {
+		grams = append(grams, trigrams(segment)...)
+	}
+	if len(grams) == 0 {
+		return nil, false, nil
+	}
+	var candidates map[string]struct{}
+	for _, g := range grams {
+		matched, err := d.scan(ctx, store, kindTrigram, escapeTerm(g)+termEnd)
+		if err != nil {
+			return nil, false, err
+		}
+		next := make(map[string]struct{}, len(matched))
+		for _, k := range matched {
+			if _, ok := candidates[k]; candidates == nil || ok {
+				next[k] = struct{}{}
+			}
+		}
+		candidates = next
+		if len(candidates) == 0 {
+			break
+		}
+	}
+	for k := range candidates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, true, nil
+}
+
+// scan 返回 kind 条目中 term 以 prefix 开头的 key，并合并超长 value 的条目
+func (d *Definition) scan(ctx context.Context, store kvstore.Store, kind, prefix string) ([]string, error) {
+	seen := make(map[string]struct{})
+	var keys []string
+	collect := func(kind, prefix string) error {
+		base := d.entryPrefix(kind)
+		start, end := kvstore.PrefixRange(base + prefix)
+		resp, err := store.Range(ctx, start, end, 0, 0)
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			entry := kv.Key[len(base):]
+			i := bytes.Index(entry, []byte(termEnd))
+			if i < 0 {
+				continue
+			}
+			key := string(entry[i+len(termEnd):])
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	}
+
+	if err := collect(kind, prefix); err != nil {
+		return nil, err
+	}
+	if d.JSONPath == "" {
+		if err := collect(kindLong, ""); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// literalPrefix 返回 LIKE 模式第一个通配符之前的字面前缀
+func literalPrefix(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%', '_':
+			return b.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteByte(pattern[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// literalSegments 按通配符切分 LIKE 模式，返回字面片段
+func literalSegments(pattern string) []string {
+	var segments []string
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '%', '_':
+			if b.Len() > 0 {
+				segments = append(segments, b.String())
+				b.Reset()
+			}
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteByte(pattern[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if b.Len() > 0 {
+		segments = append(segments, b.String())
+	}
+	return segments
+}
+
+// pathStep JSON 路径的一级：对象成员或数组下标
+type pathStep struct {
+	member string
+	index  int // member 为空时使用
+}
+
+// parsePath 解析 $.a.b[0]."c d" 形式的 JSON 路径
+func parsePath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("sqlindex: invalid JSON path %q", path)
+	}
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.Index(rest[1:], `"`)
+				if end < 0 {
+					return nil, fmt.Errorf("sqlindex: invalid JSON path %q", path)
+				}
+				steps = append(steps, pathStep{member: rest[1 : end+1]})
+				rest = rest[end+2:]
+				continue
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("sqlindex: invalid JSON path %q", path)
+			}
+			steps = append(steps, pathStep{member: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("sqlindex: invalid JSON path %q", path)
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("sqlindex: invalid JSON path %q", path)
+			}
+			steps = append(steps, pathStep{index: n})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("sqlindex: invalid JSON path %q", path)
+		}
+	}
+	return steps, nil
+}
+
+// Extract 按 JSON_EXTRACT 的语义取出 value 中 path 处的值，value 不是 JSON 或路径不存在时 ok=false
+func Extract(value []byte, path string) (interface{}, bool) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+	for _, step := range steps {
+		if step.member != "" {
+			obj, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[step.member]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		arr, ok := doc.([]interface{})
+		if !ok || step.index >= len(arr) {
+			return nil, false
+		}
+		doc = arr[step.index]
+	}
+	return doc, true
+}
+
+// Term 返回 JSON 值或 SQL 字面量用于索引和比较的规范文本，null 返回 ok=false
+//
+// 字符串取原文，数字统一为最短十进制形式，因此 JSON 数字 5 与字面量 5、'5' 相等
+func Term(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case json.Number:
+		if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+			return strconv.FormatFloat(f, 'g', -1, 64), true
+		}
+		return string(v), true
+	case int64:
+		return strconv.FormatFloat(float64(v), 'g', -1, 64), true
+	case uint64:
+		return strconv.FormatFloat(float64(v), 'g', -1, 64), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
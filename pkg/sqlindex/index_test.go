@@ -0,0 +1,200 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlindex
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	RefreshInterval = 20 * time.Millisecond
+	m.Run()
+}
+
+// newIndexedStore 返回已加载 defs 的包装存储
+func newIndexedStore(t *testing.T, defs ...Definition) (*Store, *memory.MemoryEtcd) {
+	ctx := context.Background()
+	base := memory.NewMemoryEtcd()
+	for _, def := range defs {
+		_, err := Create(ctx, base, def)
+		require.NoError(t, err)
+	}
+	s := Wrap(base)
+	t.Cleanup(s.Close)
+	require.NoError(t, s.refresh())
+	return s, base
+}
+
+func TestStoreMaintainsEntries(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newIndexedStore(t, Definition{Name: "by_value"}, Definition{Name: "by_name", JSONPath: "$.name"})
+	defs := s.Definitions()
+	require.Len(t, defs, 2)
+	byName, byValue := defs[0], defs[1]
+
+	_, _, err := s.PutWithLease(ctx, "users/1", `{"name":"alice","age":30}`, 0)
+	require.NoError(t, err)
+	_, _, err = s.PutWithLease(ctx, "users/2", `{"name":"bob"}`, 0)
+	require.NoError(t, err)
+
+	keys, err := byName.Equal(ctx, s, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users/1"}, keys)
+
+	keys, ok, err := byValue.Like(ctx, s, `%"bob"%`)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"users/2"}, keys)
+
+	// 覆盖写入删除旧条目
+	prevRev, prevKv, err := s.PutWithLease(ctx, "users/1", `{"name":"carol"}`, 0)
+	require.NoError(t, err)
+	assert.NotZero(t, prevRev)
+	assert.Equal(t, `{"name":"alice","age":30}`, string(prevKv.Value))
+	keys, err = byName.Equal(ctx, s, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// 事务写入同样维护索引，响应只包含调用方自己的操作
+	resp, err := s.Txn(ctx, nil, []kvstore.Op{
+		{Type: kvstore.OpPut, Key: []byte("users/3"), Value: []byte(`{"name":"alice"}`)},
+		{Type: kvstore.OpDelete, Key: []byte("users/2")},
+	}, nil)
+	require.NoError(t, err)
+	assert.Len(t, resp.Responses, 2)
+	keys, err = byName.Equal(ctx, s, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users/3"}, keys)
+	keys, err = byName.Equal(ctx, s, "bob")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// 范围删除清理所有条目
+	deleted, _, _, err := s.DeleteRange(ctx, "users/", "users0")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+	start, end := kvstore.PrefixRange(dataPrefix)
+	entries, err := s.Range(ctx, start, end, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, entries.Kvs)
+}
+
+func TestCreateBackfills(t *testing.T) {
+	ctx := context.Background()
+	base := memory.NewMemoryEtcd()
+	_, _, err := base.PutWithLease(ctx, "a", "hello world", 0)
+	require.NoError(t, err)
+	_, _, err = base.PutWithLease(ctx, "b", strings.Repeat("x", maxIndexedValue+1), 0)
+	require.NoError(t, err)
+
+	s := Wrap(base)
+	defer s.Close()
+
+	def, err := Create(ctx, s, Definition{Name: "v"})
+	require.NoError(t, err)
+	assert.Equal(t, StatePublic, def.State)
+
+	_, err = Create(ctx, s, Definition{Name: "v"})
+	assert.ErrorIs(t, err, ErrExists)
+
+	// 超长 value 总是作为候选
+	keys, ok, err := def.Like(ctx, s, "%o w%")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	keys, err = def.Equal(ctx, s, "hello world")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	// 片段都短于三个字节时无法使用三元组
+	_, ok, err = def.Like(ctx, s, "%lo%")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, Drop(ctx, s, "v"))
+	assert.ErrorIs(t, Drop(ctx, s, "v"), ErrNotFound)
+	defs, err := List(ctx, s)
+	require.NoError(t, err)
+	assert.Empty(t, defs)
+}
+
+func TestExtract(t *testing.T) {
+	doc := []byte(`{"name":"alice","tags":["a","b"],"n":1.50,"ok":true,"o":{"k v":null}}`)
+
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"$.name", "alice", true},
+		{"$.tags[1]", "b", true},
+		{"$.tags", `["a","b"]`, true},
+		{"$.n", "1.5", true},
+		{"$.ok", "true", true},
+		{`$.o."k v"`, "", false},
+		{"$.missing", "", false},
+		{"$.tags[5]", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := Extract(doc, tt.path)
+		term, termOK := Term(v)
+		if tt.ok {
+			assert.True(t, ok && termOK, tt.path)
+			assert.Equal(t, tt.want, term, tt.path)
+		} else {
+			assert.False(t, ok && termOK, tt.path)
+		}
+	}
+
+	_, ok := Extract([]byte("not json"), "$.name")
+	assert.False(t, ok)
+	assert.Error(t, (&Definition{Name: "x", JSONPath: "name"}).Validate())
+	assert.Error(t, (&Definition{Name: "bad name"}).Validate())
+}
+
+func TestValueFilter(t *testing.T) {
+	tests := []struct {
+		filter ValueFilter
+		value  string
+		want   bool
+	}{
+		{ValueFilter{}, "anything", true},
+		{ValueFilter{Prefix: "v1:"}, "v1:ready", true},
+		{ValueFilter{Prefix: "v1:"}, "v2:ready", false},
+		{ValueFilter{JSONPath: "$.status", JSONValue: "ready"}, `{"status":"ready"}`, true},
+		{ValueFilter{JSONPath: "$.status", JSONValue: "ready"}, `{"status":"failed"}`, false},
+		{ValueFilter{JSONPath: "$.replicas", JSONValue: "3"}, `{"replicas":3.0}`, true},
+		{ValueFilter{JSONPath: "$.status", JSONValue: "ready"}, "not json", false},
+		{ValueFilter{Prefix: "{", JSONPath: "$.a", JSONValue: "1"}, `{"a":2}`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.filter.Match([]byte(tt.value)), "%+v %s", tt.filter, tt.value)
+	}
+
+	assert.True(t, ValueFilter{}.Empty())
+	assert.NoError(t, ValueFilter{JSONPath: "$.a"}.Validate())
+	assert.Error(t, ValueFilter{JSONPath: "a"}.Validate())
+	assert.Error(t, ValueFilter{JSONValue: "x"}.Validate())
+}
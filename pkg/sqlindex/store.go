@@ -0,0 +1,289 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// Store 在每次写入时维护二级索引
+//
+// 没有索引定义时写入直接透传；有定义时先读出被修改 key 的当前值，再把数据写入和
+// 索引条目的增删放进同一个事务。预读与事务之间的并发修改最多留下过期条目，
+// 最后提交的写入总会写入自己的全部条目，因此不会遗漏
+type Store struct {
+	kvstore.Store
+
+	mu   sync.RWMutex
+	defs []*Definition
+
+	stopC chan struct{}
+	doneC chan struct{}
+}
+
+// Wrap 返回维护二级索引的存储，并在后台定期加载索引定义
+func Wrap(store kvstore.Store) *Store {
+	s := &Store{
+		Store: store,
+		stopC: make(chan struct{}),
+		doneC: make(chan struct{}),
+	}
+	go s.refreshLoop()
+	return s
+}
+
+// Unwrap 实现 kvstore.Wrapper，供调用方查找底层存储的其他能力
+func (s *Store) Unwrap() kvstore.Store {
+	return s.Store
+}
+
+// RangeFunc 读取不涉及索引维护，转发给底层存储以保留流式读取
+func (s *Store) RangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.RangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// ReverseRangeFunc 同样转发给底层存储
+func (s *Store) ReverseRangeFunc(ctx context.Context, key, rangeEnd string, revision int64, fn func(kv *kvstore.KeyValue) bool) (int64, error) {
+	return kvstore.ReverseRangeFunc(ctx, s.Store, key, rangeEnd, revision, fn)
+}
+
+// Close 停止加载索引定义
+func (s *Store) Close() {
+	close(s.stopC)
+	<-s.doneC
+}
+
+func (s *Store) refreshLoop() {
+	defer close(s.doneC)
+
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.refresh(); err != nil {
+			log.Warn("Failed to load secondary index definitions",
+				zap.Error(err),
+				zap.String("component", "sqlindex"))
+		}
+		select {
+		case <-ticker.C:
+		case <-s.stopC:
+			return
+		}
+	}
+}
+
+// refresh 重新加载索引定义，失败时保留上一次的结果
+func (s *Store) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), RefreshInterval)
+	defer cancel()
+	defs, err := List(ctx, s.Store)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.defs = defs
+	s.mu.Unlock()
+	return nil
+}
+
+// Maintainer 由在写入时维护二级索引的存储实现，存储包装需要转发
+type Maintainer interface {
+	Definitions() []*Definition
+}
+
+// Definitions 返回本节点当前加载的索引定义
+func (s *Store) Definitions() []*Definition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defs
+}
+
+func (s *Store) PutWithLease(ctx context.Context, key, value string, leaseID int64) (int64, *kvstore.KeyValue, error) {
+	defs := s.Definitions()
+	if len(defs) == 0 || kvstore.IsSystemKey(key) {
+		return s.Store.PutWithLease(ctx, key, value, leaseID)
+	}
+
+	ops := []kvstore.Op{{Type: kvstore.OpPut, Key: []byte(key), Value: []byte(value), LeaseID: leaseID}}
+	before, err := s.read(ctx, ops)
+	if err != nil {
+		return 0, nil, err
+	}
+	ops = append(ops, indexOps(defs, before, ops)...)
+
+	resp, err := s.Store.Txn(ctx, nil, ops, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	put := resp.Responses[0].PutResp
+	return put.Revision, put.PrevKv, nil
+}
+
+func (s *Store) DeleteRange(ctx context.Context, key, rangeEnd string) (int64, []*kvstore.KeyValue, int64, error) {
+	defs := s.Definitions()
+	if len(defs) == 0 || (rangeEnd == "" && kvstore.IsSystemKey(key)) {
+		return s.Store.DeleteRange(ctx, key, rangeEnd)
+	}
+
+	ops := []kvstore.Op{{Type: kvstore.OpDelete, Key: []byte(key), RangeEnd: []byte(rangeEnd)}}
+	before, err := s.read(ctx, ops)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	ops = append(ops, indexOps(defs, before, ops)...)
+
+	resp, err := s.Store.Txn(ctx, nil, ops, nil)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	del := resp.Responses[0].DeleteResp
+	return del.Deleted, del.PrevKvs, del.Revision, nil
+}
+
+func (s *Store) Txn(ctx context.Context, cmps []kvstore.Compare, thenOps []kvstore.Op, elseOps []kvstore.Op) (*kvstore.TxnResponse, error) {
+	defs := s.Definitions()
+	writes := append(writeOps(thenOps), writeOps(elseOps)...)
+	if len(defs) == 0 || len(writes) == 0 {
+		return s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	}
+
+	before, err := s.read(ctx, writes)
+	if err != nil {
+		return nil, err
+	}
+	thenIdx := indexOps(defs, before, thenOps)
+	elseIdx := indexOps(defs, before, elseOps)
+	if len(thenIdx) == 0 && len(elseIdx) == 0 {
+		return s.Store.Txn(ctx, cmps, thenOps, elseOps)
+	}
+
+	resp, err := s.Store.Txn(ctx, cmps,
+		append(thenOps[:len(thenOps):len(thenOps)], thenIdx...),
+		append(elseOps[:len(elseOps):len(elseOps)], elseIdx...))
+	if err != nil {
+		return nil, err
+	}
+	// 去掉索引操作的响应，调用方看到的响应与自己的操作一一对应
+	n := len(elseOps)
+	if resp.Succeeded {
+		n = len(thenOps)
+	}
+	if len(resp.Responses) > n {
+		resp.Responses = resp.Responses[:n]
+	}
+	return resp, nil
+}
+
+// writeOps 返回可能影响索引的写操作，单个系统 key 的写入（包括索引条目本身）不需要预读
+func writeOps(ops []kvstore.Op) []kvstore.Op {
+	var writes []kvstore.Op
+	for _, op := range ops {
+		if len(op.RangeEnd) == 0 && kvstore.IsSystemKey(string(op.Key)) {
+			continue
+		}
+		if op.Type == kvstore.OpPut || op.Type == kvstore.OpDelete {
+			writes = append(writes, op)
+		}
+	}
+	return writes
+}
+
+// read 读出写操作涉及的 key 的当前值
+func (s *Store) read(ctx context.Context, ops []kvstore.Op) (map[string][]byte, error) {
+	before := make(map[string][]byte)
+	for _, op := range ops {
+		resp, err := s.Store.Range(ctx, string(op.Key), string(op.RangeEnd), 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("sqlindex: read %q: %w", op.Key, err)
+		}
+		for _, kv := range resp.Kvs {
+			before[string(kv.Key)] = kv.Value
+		}
+	}
+	return before, nil
+}
+
+// indexOps 按顺序模拟 ops 的效果，返回删除旧条目、写入新条目的操作
+func indexOps(defs []*Definition, before map[string][]byte, ops []kvstore.Op) []kvstore.Op {
+	after := make(map[string][]byte, len(before))
+	for k, v := range before {
+		after[k] = v
+	}
+	touched := make(map[string]struct{})
+	for _, op := range ops {
+		switch op.Type {
+		case kvstore.OpPut:
+			after[string(op.Key)] = op.Value
+			touched[string(op.Key)] = struct{}{}
+		case kvstore.OpDelete:
+			for k := range after {
+				if inRange(k, string(op.Key), string(op.RangeEnd)) {
+					delete(after, k)
+					touched[k] = struct{}{}
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(touched))
+	for k := range touched {
+		if !kvstore.IsSystemKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var deletes, puts []kvstore.Op
+	for _, key := range keys {
+		for _, def := range defs {
+			next := make(map[string]struct{})
+			if value, ok := after[key]; ok {
+				for _, entry := range def.entries(key, value) {
+					next[entry] = struct{}{}
+					puts = append(puts, kvstore.Op{Type: kvstore.OpPut, Key: []byte(entry)})
+				}
+			}
+			if value, ok := before[key]; ok {
+				for _, entry := range def.entries(key, value) {
+					if _, keep := next[entry]; !keep {
+						deletes = append(deletes, kvstore.Op{Type: kvstore.OpDelete, Key: []byte(entry)})
+					}
+				}
+			}
+		}
+	}
+	return append(deletes, puts...)
+}
+
+// inRange 判断 key 是否落在 etcd 语义的 [start, end) 中
+func inRange(key, start, end string) bool {
+	switch end {
+	case "":
+		return key == start
+	case "\x00":
+		return key >= start
+	default:
+		return key >= start && key < end
+	}
+}
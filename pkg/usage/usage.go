@@ -0,0 +1,384 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usage 按前缀统计 key 数量、字节数、写入速率和 watch 数量
+//
+// key 数量和字节数来自定期对本地数据的流式全量扫描（serializable 读取，不经过 ReadIndex），
+// 写入次数来自订阅本地 watch 流，每个节点都会 apply 集群的全部写入，因此各节点的统计基本一致；
+// watch 数量是每次扫描时本节点上活跃的客户端 watch。
+//
+// 前缀为 key 中第 depth 个 "/" 及之前的部分，例如 depth 为 2 时 /tenants/a/x 的前缀为 /tenants/，
+// "/" 不足 depth 个的 key 取到最后一个 "/" 为止，没有 "/" 的 key 前缀为空
+package usage
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/pkg/config"
+	"metaStore/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// watchIDBase 内部 watch 使用负数 ID，与 mirror（-1<<40 起）和 CDC（-2<<40 起）错开
+	watchIDBase int64 = -3 << 40
+
+	// OtherPrefix 超出 max_prefixes 的前缀合并后的名称
+	OtherPrefix = "(other)"
+)
+
+// PrefixStats 单个前缀的用量
+type PrefixStats struct {
+	Prefix    string  `json:"prefix"`
+	Keys      int64   `json:"keys"`       // 最近一次扫描时的 key 数量
+	Bytes     int64   `json:"bytes"`      // 最近一次扫描时 key 和 value 的总字节数
+	Writes    int64   `json:"writes"`     // 启动以来的写入次数（put 和删除）
+	WriteRate float64 `json:"write_rate"` // 最近一个扫描周期内每秒的写入次数
+	Watches   int64   `json:"watches"`    // 最近一次扫描时本节点上的 watch 数量
+}
+
+// KeyStats value 最大的 key
+type KeyStats struct {
+	Key         string `json:"key"`
+	Bytes       int64  `json:"bytes"`
+	ModRevision int64  `json:"mod_revision"`
+}
+
+// Report 最近一次扫描的结果
+type Report struct {
+	Revision  int64         `json:"revision"`   // 扫描开始时的 revision
+	ScannedAt time.Time     `json:"scanned_at"` // 零值表示尚未完成扫描
+	Duration  time.Duration `json:"duration"`   // 扫描耗时
+	Prefixes  []PrefixStats `json:"prefixes"`   // 按字节数降序
+	TopKeys   []KeyStats    `json:"top_keys"`   // 按字节数降序
+}
+
+// watchLister 可以列出活跃 watch 的 store
+type watchLister interface {
+	Watches() []kvstore.WatchInfo
+}
+
+// Tracker 定期统计各前缀的用量
+type Tracker struct {
+	store   kvstore.Store
+	cfg     config.UsageConfig
+	watchID int64
+
+	mu       sync.Mutex
+	writes   map[string]int64 // 启动以来各前缀的写入次数
+	lastSeen map[string]int64 // 上一次扫描时的 writes，用于计算速率
+	lastScan time.Time
+	report   Report
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTracker 创建用量统计，调用 Start 后开始运行
+func NewTracker(store kvstore.Store, cfg config.UsageConfig) *Tracker {
+	return &Tracker{
+		store:    store,
+		cfg:      cfg,
+		watchID:  watchIDBase,
+		writes:   make(map[string]int64),
+		lastSeen: make(map[string]int64),
+	}
+}
+
+// Start 订阅写入并开始定期扫描，未启用时不做任何事
+func (t *Tracker) Start() {
+	if !t.cfg.Enable {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.loop(ctx)
+	}()
+	log.Info("Usage accounting started",
+		zap.Int("prefix_depth", t.cfg.PrefixDepth),
+		zap.Duration("scan_interval", t.cfg.ScanInterval),
+		zap.String("component", "usage"))
+}
+
+// Close 停止统计
+func (t *Tracker) Close() {
+	if t.cancel == nil {
+		return
+	}
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Enabled 返回是否启用了统计
+func (t *Tracker) Enabled() bool {
+	return t.cfg.Enable
+}
+
+// Report 返回最近一次扫描的结果，写入次数是当前值
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r := t.report
+	r.Prefixes = make([]PrefixStats, len(t.report.Prefixes))
+	copy(r.Prefixes, t.report.Prefixes)
+	r.TopKeys = append([]KeyStats(nil), t.report.TopKeys...)
+
+	// 写入次数在两次扫描之间持续增长，报告当前值
+	reported := make(map[string]bool, len(r.Prefixes))
+	for _, p := range r.Prefixes {
+		reported[p.Prefix] = true
+	}
+	var other int64
+	for prefix, w := range t.writes {
+		if prefix == OtherPrefix || !reported[prefix] {
+			other += w
+		}
+	}
+	for i := range r.Prefixes {
+		if r.Prefixes[i].Prefix == OtherPrefix {
+			r.Prefixes[i].Writes = other
+		} else {
+			r.Prefixes[i].Writes = t.writes[r.Prefixes[i].Prefix]
+		}
+	}
+	return r
+}
+
+// PrefixOf 返回 key 所属的前缀
+func PrefixOf(key string, depth int) string {
+	end := 0
+	for i := 0; i < len(key) && depth > 0; i++ {
+		if key[i] == '/' {
+			end = i + 1
+			depth--
+		}
+	}
+	return key[:end]
+}
+
+// loop 持续计数写入，并按周期扫描
+func (t *Tracker) loop(ctx context.Context) {
+	events := t.watch()
+	defer t.store.CancelWatch(t.watchID)
+
+	t.scan(ctx)
+	ticker := time.NewTicker(t.cfg.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case ev, ok := <-events:
+			if !ok {
+				// watch 被关闭（例如缓冲溢出），下一个周期重新订阅，期间的写入不计数
+				events = nil
+				continue
+			}
+			if ev.Kv != nil {
+				t.countWrite(string(ev.Kv.Key))
+			}
+
+		case <-ticker.C:
+			if events == nil {
+				t.store.CancelWatch(t.watchID)
+				events = t.watch()
+			}
+			t.scan(ctx)
+		}
+	}
+}
+
+// watch 订阅全部 key 的写入，失败时返回 nil，由下一个周期重试
+func (t *Tracker) watch() <-chan kvstore.WatchEvent {
+	key, rangeEnd := kvstore.PrefixRange("")
+	events, err := t.store.Watch(context.Background(), key, rangeEnd, 0, t.watchID)
+	if err != nil {
+		log.Warn("Failed to watch writes for usage accounting",
+			zap.Error(err),
+			zap.String("component", "usage"))
+		return nil
+	}
+	return events
+}
+
+func (t *Tracker) countWrite(key string) {
+	prefix := PrefixOf(key, t.cfg.PrefixDepth)
+	t.mu.Lock()
+	t.writes[prefix]++
+	t.mu.Unlock()
+}
+
+// scan 扫描本地数据并更新报告，失败时保留上一次的结果
+func (t *Tracker) scan(ctx context.Context) {
+	if err := t.Scan(ctx); err != nil && ctx.Err() == nil {
+		log.Warn("Usage scan failed",
+			zap.Error(err),
+			zap.String("component", "usage"))
+	}
+}
+
+// Scan 立即扫描一次本地数据
+func (t *Tracker) Scan(ctx context.Context) error {
+	start := time.Now()
+	stats := make(map[string]*PrefixStats)
+	top := &keyHeap{}
+
+	key, rangeEnd := kvstore.PrefixRange("")
+	rev, err := kvstore.RangeFunc(kvstore.WithSerializable(ctx), t.store, key, rangeEnd, 0, func(kv *kvstore.KeyValue) bool {
+		k := string(kv.Key)
+		size := int64(len(kv.Key) + len(kv.Value))
+
+		prefix := PrefixOf(k, t.cfg.PrefixDepth)
+		ps := stats[prefix]
+		if ps == nil {
+			ps = &PrefixStats{Prefix: prefix}
+			stats[prefix] = ps
+		}
+		ps.Keys++
+		ps.Bytes += size
+
+		if t.cfg.TopKeys > 0 && (top.Len() < t.cfg.TopKeys || size > (*top)[0].Bytes) {
+			heap.Push(top, KeyStats{Key: k, Bytes: size, ModRevision: kv.ModRevision})
+			if top.Len() > t.cfg.TopKeys {
+				heap.Pop(top)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// 只有 watch 的前缀也要报告
+	if wl, ok := kvstore.As[watchLister](t.store); ok {
+		for _, w := range wl.Watches() {
+			// 内部子系统的 watch 使用负数 ID，不计入
+			if w.ID < 0 {
+				continue
+			}
+			prefix := PrefixOf(w.Key, t.cfg.PrefixDepth)
+			ps := stats[prefix]
+			if ps == nil {
+				ps = &PrefixStats{Prefix: prefix}
+				stats[prefix] = ps
+			}
+			ps.Watches++
+		}
+	}
+
+	topKeys := make([]KeyStats, top.Len())
+	for i := len(topKeys) - 1; i >= 0; i-- {
+		topKeys[i] = heap.Pop(top).(KeyStats)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(t.lastScan).Seconds()
+	for prefix, w := range t.writes {
+		if prefix == OtherPrefix {
+			continue
+		}
+		ps := stats[prefix]
+		if ps == nil {
+			// 写入后被删除的前缀仍然报告写入
+			ps = &PrefixStats{Prefix: prefix}
+			stats[prefix] = ps
+		}
+		ps.Writes = w
+		if !t.lastScan.IsZero() && elapsed > 0 {
+			ps.WriteRate = float64(w-t.lastSeen[prefix]) / elapsed
+		}
+	}
+
+	prefixes := make([]PrefixStats, 0, len(stats))
+	for _, ps := range stats {
+		prefixes = append(prefixes, *ps)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if prefixes[i].Bytes != prefixes[j].Bytes {
+			return prefixes[i].Bytes > prefixes[j].Bytes
+		}
+		if prefixes[i].WriteRate != prefixes[j].WriteRate {
+			return prefixes[i].WriteRate > prefixes[j].WriteRate
+		}
+		return prefixes[i].Prefix < prefixes[j].Prefix
+	})
+	prefixes = t.fold(prefixes)
+
+	t.lastSeen = make(map[string]int64, len(t.writes))
+	for prefix, w := range t.writes {
+		t.lastSeen[prefix] = w
+	}
+	t.lastScan = now
+	t.report = Report{
+		Revision:  rev,
+		ScannedAt: now,
+		Duration:  now.Sub(start),
+		Prefixes:  prefixes,
+		TopKeys:   topKeys,
+	}
+	return nil
+}
+
+// fold 把超出 max_prefixes 的前缀合并为一行 OtherPrefix，prefixes 已按字节数降序
+// 被合并的前缀的写入计数也移入 OtherPrefix，避免计数的 map 无限增长。调用方持有 t.mu
+func (t *Tracker) fold(prefixes []PrefixStats) []PrefixStats {
+	other := PrefixStats{Prefix: OtherPrefix, Writes: t.writes[OtherPrefix]}
+	if len(prefixes) > t.cfg.MaxPrefixes {
+		for _, ps := range prefixes[t.cfg.MaxPrefixes:] {
+			other.Keys += ps.Keys
+			other.Bytes += ps.Bytes
+			other.Writes += ps.Writes
+			other.WriteRate += ps.WriteRate
+			other.Watches += ps.Watches
+			if w, ok := t.writes[ps.Prefix]; ok {
+				delete(t.writes, ps.Prefix)
+				t.writes[OtherPrefix] += w
+			}
+		}
+		prefixes = prefixes[:t.cfg.MaxPrefixes]
+	}
+	if other.Keys == 0 && other.Writes == 0 && other.Watches == 0 {
+		return prefixes
+	}
+	return append(prefixes, other)
+}
+
+// keyHeap 按字节数排列的小顶堆，用于保留最大的 N 个 key
+type keyHeap []KeyStats
+
+func (h keyHeap) Len() int            { return len(h) }
+func (h keyHeap) Less(i, j int) bool  { return h[i].Bytes < h[j].Bytes }
+func (h keyHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x interface{}) { *h = append(*h, x.(KeyStats)) }
+func (h *keyHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
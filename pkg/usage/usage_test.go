@@ -0,0 +1,98 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"metaStore/internal/memory"
+	"metaStore/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixOf(t *testing.T) {
+	assert.Equal(t, "/tenants/", PrefixOf("/tenants/a/x", 2))
+	assert.Equal(t, "/tenants/a/", PrefixOf("/tenants/a/x", 3))
+	assert.Equal(t, "app/x/", PrefixOf("app/x/y", 2))
+	assert.Equal(t, "app/", PrefixOf("app/x", 2))
+	assert.Equal(t, "", PrefixOf("plain", 2))
+}
+
+func TestTrackerScan(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	put := func(key, value string) {
+		_, _, err := store.PutWithLease(ctx, key, value, 0)
+		require.NoError(t, err)
+	}
+	put("/a/1", strings.Repeat("x", 100))
+	put("/a/2", "y")
+	put("/b/1", "z")
+
+	_, err := store.Watch(ctx, "/b/", "/b0", 0, 7)
+	require.NoError(t, err)
+	defer store.CancelWatch(7)
+
+	tr := NewTracker(store, config.UsageConfig{Enable: true, PrefixDepth: 2, ScanInterval: time.Hour, MaxPrefixes: 10, TopKeys: 1})
+	tr.Start()
+	defer tr.Close()
+
+	// 启动时扫描一次，随后的写入由 watch 计数
+	require.Eventually(t, func() bool { return !tr.Report().ScannedAt.IsZero() }, time.Second, 10*time.Millisecond)
+	put("/b/2", "v")
+	put("/b/2", "w")
+	require.Eventually(t, func() bool { return tr.Report().Prefixes[1].Writes == 2 }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, tr.Scan(ctx))
+	r := tr.Report()
+	require.Len(t, r.Prefixes, 2)
+	assert.Equal(t, PrefixStats{Prefix: "/a/", Keys: 2, Bytes: 109}, r.Prefixes[0])
+	b := r.Prefixes[1]
+	assert.Equal(t, "/b/", b.Prefix)
+	assert.Equal(t, int64(2), b.Keys)
+	assert.Equal(t, int64(2), b.Writes)
+	assert.Equal(t, int64(1), b.Watches)
+	assert.Greater(t, b.WriteRate, 0.0)
+	assert.Equal(t, []KeyStats{{Key: "/a/1", Bytes: 104, ModRevision: 1}}, r.TopKeys)
+}
+
+func TestTrackerFoldsPrefixes(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryEtcd()
+	for _, key := range []string{"/a/1", "/a/2", "/a/3", "/b/1", "/b/2", "/c/1"} {
+		_, _, err := store.PutWithLease(ctx, key, "v", 0)
+		require.NoError(t, err)
+	}
+
+	tr := NewTracker(store, config.UsageConfig{PrefixDepth: 2, MaxPrefixes: 1})
+	tr.countWrite("/c/1")
+	require.NoError(t, tr.Scan(ctx))
+
+	r := tr.Report()
+	require.Len(t, r.Prefixes, 2)
+	assert.Equal(t, "/a/", r.Prefixes[0].Prefix)
+	assert.Equal(t, OtherPrefix, r.Prefixes[1].Prefix)
+	assert.Equal(t, int64(3), r.Prefixes[1].Keys)
+	assert.Equal(t, int64(1), r.Prefixes[1].Writes)
+
+	// 已合并的前缀的后续写入仍计入 OtherPrefix
+	tr.countWrite("/b/1")
+	assert.Equal(t, int64(2), tr.Report().Prefixes[1].Writes)
+}
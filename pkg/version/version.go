@@ -0,0 +1,128 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version 定义二进制版本和按集群版本开启的线上功能（capability）
+//
+// 集群版本是所有成员二进制版本中最低的一个。滚动升级期间集群版本停留在旧版本，
+// 新版本引入的、需要所有成员理解的功能（新的 raft 提案格式、新的 watch 恢复方式等）
+// 在最后一个成员升级、集群版本提升后才开启
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version 本二进制的版本
+const Version = "2.1.0"
+
+// Baseline 没有登记版本的成员（引入集群版本之前的二进制）视为的版本
+const Baseline = "2.0.0"
+
+// Capability 需要集群中所有成员支持才能开启的功能
+type Capability string
+
+const (
+	// ProtobufProposals raft 提案使用 Protobuf 编码
+	ProtobufProposals Capability = "ProtobufProposals"
+	// WatchResume HTTP watch 的 SSE id 带有事件在 revision 中的序号，可以从 revision 中间恢复
+	WatchResume Capability = "WatchResume"
+)
+
+// capabilities 每个功能需要的最低集群版本
+var capabilities = map[Capability]string{
+	ProtobufProposals: "2.0.0",
+	WatchResume:       "2.1.0",
+}
+
+// Compare 比较两个 major.minor.patch 形式的版本，a 更低时返回 -1，相同返回 0，更高返回 1
+// "-" 之后的预发布后缀被忽略，无法解析的版本视为最低
+func Compare(a, b string) int {
+	va, errA := parse(a)
+	vb, errB := parse(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Validate 检查版本是否为 major.minor.patch 形式
+func Validate(v string) error {
+	_, err := parse(v)
+	return err
+}
+
+// Min 返回最低的版本，没有版本时返回 Baseline
+func Min(versions []string) string {
+	if len(versions) == 0 {
+		return Baseline
+	}
+	lowest := versions[0]
+	for _, v := range versions[1:] {
+		if Compare(v, lowest) < 0 {
+			lowest = v
+		}
+	}
+	return lowest
+}
+
+// Supports 集群版本为 clusterVersion 时是否可以使用功能 c
+func Supports(clusterVersion string, c Capability) bool {
+	required, ok := capabilities[c]
+	return ok && Compare(clusterVersion, required) >= 0
+}
+
+// Capabilities 返回集群版本为 clusterVersion 时开启的全部功能，按名称排序
+func Capabilities(clusterVersion string) []Capability {
+	var caps []Capability
+	for c := range capabilities {
+		if Supports(clusterVersion, c) {
+			caps = append(caps, c)
+		}
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+	return caps
+}
+
+func parse(v string) ([3]int, error) {
+	var parsed [3]int
+	core, _, _ := strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return parsed, fmt.Errorf("invalid version %q, must be major.minor.patch", v)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("invalid version %q, must be major.minor.patch", v)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	assert.Equal(t, 0, Compare("2.1.0", "2.1.0"))
+	assert.Equal(t, -1, Compare("2.0.9", "2.1.0"))
+	assert.Equal(t, 1, Compare("10.0.0", "9.9.9"))
+	assert.Equal(t, 0, Compare("v2.1.0-rc.1", "2.1.0"))
+	assert.Equal(t, -1, Compare("dev", "2.0.0"))
+	assert.Error(t, Validate("2.1"))
+	assert.NoError(t, Validate(Version))
+}
+
+func TestMin(t *testing.T) {
+	assert.Equal(t, "2.0.0", Min([]string{"2.1.0", "2.0.0", "2.1.3"}))
+	assert.Equal(t, Baseline, Min(nil))
+}
+
+func TestCapabilities(t *testing.T) {
+	assert.Equal(t, []Capability{ProtobufProposals}, Capabilities(Baseline))
+	assert.Equal(t, []Capability{ProtobufProposals, WatchResume}, Capabilities(Version))
+	assert.False(t, Supports(Baseline, WatchResume))
+	assert.True(t, Supports(Version, WatchResume))
+	assert.False(t, Supports(Version, Capability("Unknown")))
+	// 本二进制必须支持自己的所有功能
+	for c, required := range capabilities {
+		assert.LessOrEqual(t, Compare(required, Version), 0, c)
+	}
+}
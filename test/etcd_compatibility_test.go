@@ -288,6 +288,89 @@ func TestWatch(t *testing.T) {
 	}
 }
 
+// TestWatchPrefix 测试 Watch 范围监听
+func TestWatchPrefix(t *testing.T) {
+	_, cli := startTestServer(t)
+
+	ctx := context.Background()
+
+	// 创建前缀 watch
+	watchCh := cli.Watch(ctx, "prefix/", clientv3.WithPrefix())
+
+	// 等待 watch 建立
+	time.Sleep(100 * time.Millisecond)
+
+	// 触发多个事件
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cli.Put(context.Background(), "prefix/key1", "value1")
+		time.Sleep(100 * time.Millisecond)
+		cli.Put(context.Background(), "prefix/key2", "value2")
+		time.Sleep(100 * time.Millisecond)
+		cli.Delete(context.Background(), "prefix/key1")
+	}()
+
+	// 接收 3 个事件
+	receivedEvents := 0
+	timeout := time.After(5 * time.Second)
+
+	for receivedEvents < 3 {
+		select {
+		case wresp := <-watchCh:
+			require.NotNil(t, wresp)
+			require.Len(t, wresp.Events, 1)
+			event := wresp.Events[0]
+
+			// 验证事件
+			key := string(event.Kv.Key)
+			if event.PrevKv != nil {
+				key = string(event.PrevKv.Key)
+			}
+			assert.True(t, strings.HasPrefix(key, "prefix/"))
+
+			receivedEvents++
+		case <-timeout:
+			t.Fatalf("Watch timeout, received %d/3 events", receivedEvents)
+		}
+	}
+
+	assert.Equal(t, 3, receivedEvents)
+}
+
+// TestWatchCancel 测试 Watch 取消
+func TestWatchCancel(t *testing.T) {
+	_, cli := startTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// 创建 watch
+	watchCh := cli.Watch(ctx, "cancel-key")
+
+	// 等待 watch 建立
+	time.Sleep(100 * time.Millisecond)
+
+	// 取消 watch
+	cancel()
+
+	// 等待取消生效
+	time.Sleep(200 * time.Millisecond)
+
+	// 触发事件（不应该收到）
+	cli.Put(context.Background(), "cancel-key", "value")
+
+	// 验证 channel 已关闭或者不会收到事件
+	select {
+	case wresp, ok := <-watchCh:
+		if ok {
+			// 如果收到响应，应该是取消响应
+			assert.True(t, wresp.Canceled, "Watch should be canceled")
+		}
+		// 否则 channel 已关闭，符合预期
+	case <-time.After(500 * time.Millisecond):
+		// 超时也符合预期，说明没有收到事件
+	}
+}
+
 // TestLease 测试 Lease 功能
 func TestLease(t *testing.T) {
 	_, cli := startTestServer(t)
@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"sync"
 	"testing"
-	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 
@@ -56,7 +55,7 @@ func TestEtcdMemoryClusterDataConsistencyAfterWrites(t *testing.T) {
 	}
 
 	// Wait for full replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes have identical data
 	t.Run("VerifyNode0", func(t *testing.T) {
@@ -107,7 +106,7 @@ func TestEtcdMemoryClusterSequentialWrites(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all writes are visible on all nodes
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -147,7 +146,7 @@ func TestEtcdMemoryClusterRangeQueryConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Query range from each node and verify results match
 	var allResults [][]string
@@ -185,7 +184,7 @@ func TestEtcdMemoryClusterDeleteConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Delete keys from different nodes
 	for i, key := range keys {
@@ -195,7 +194,7 @@ func TestEtcdMemoryClusterDeleteConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all keys are deleted on all nodes
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -220,7 +219,7 @@ func TestEtcdMemoryClusterTransactionConsistency(t *testing.T) {
 	_, err := clus.clients[0].Put(ctx, "txn-key", "initial-value")
 	require.NoError(t, err)
 
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Execute transaction on node 1
 	txn := clus.clients[1].Txn(ctx).
@@ -233,7 +232,7 @@ func TestEtcdMemoryClusterTransactionConsistency(t *testing.T) {
 	assert.True(t, txnResp.Succeeded, "Transaction should succeed")
 
 	// Wait for replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes see the updated value
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -245,7 +244,6 @@ func TestEtcdMemoryClusterTransactionConsistency(t *testing.T) {
 	}
 }
 
-
 // TestEtcdMemoryClusterConcurrentMixedOperations tests mixed concurrent operations
 func TestEtcdMemoryClusterConcurrentMixedOperations(t *testing.T) {
 	const numNodes = 3
@@ -297,7 +295,7 @@ func TestEtcdMemoryClusterConcurrentMixedOperations(t *testing.T) {
 	}
 
 	// Wait for final state to replicate
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify final consistency - all nodes should agree on what keys exist
 	for i := 0; i < numOps/3; i++ {
@@ -335,7 +333,7 @@ func TestEtcdMemoryClusterRevisionConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Get revision from each node
 	var revisions []int64
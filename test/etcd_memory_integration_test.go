@@ -17,159 +17,36 @@ package test
 import (
 	"context"
 	"fmt"
-	"net"
-	"os"
 	"testing"
-	"time"
 
-	"metaStore/internal/kvstore"
-	"metaStore/internal/memory"
-	"metaStore/internal/raft"
-	etcdapi "metaStore/api/etcd"
+	"metaStore/test/testutil"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
-	"go.etcd.io/etcd/server/v3/etcdserver/api/snap"
-	"go.etcd.io/raft/v3/raftpb"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// etcdCluster represents a cluster of etcd-compatible nodes for testing
+// etcdCluster is an in-process memory cluster with one etcd client per member
 type etcdCluster struct {
-	peers              []string
-	commitC            []<-chan *kvstore.Commit
-	errorC             []<-chan error
-	proposeC           []chan string
-	confChangeC        []chan raftpb.ConfChange
-	snapshotterReady   []<-chan *snap.Snapshotter
-	kvStores           []*memory.Memory
-	servers            []*etcdapi.Server
-	clients            []*clientv3.Client
+	*testutil.Cluster
+	clients []*clientv3.Client
 }
 
-// newEtcdCluster creates a cluster of n etcd-compatible nodes
+// newEtcdCluster starts an n-node memory cluster on the shared test harness and
+// blocks until a leader is elected; data lives in the test's temp dir
 func newEtcdCluster(t *testing.T, n int) *etcdCluster {
-	// Allocate dynamic ports to avoid conflicts when running tests in parallel
-	peers, listeners := allocatePorts(n)
-	// Release the listeners so Raft can bind to these ports
-	releaseListeners(listeners)
-
-	clus := &etcdCluster{
-		peers:            peers,
-		commitC:          make([]<-chan *kvstore.Commit, len(peers)),
-		errorC:           make([]<-chan error, len(peers)),
-		proposeC:         make([]chan string, len(peers)),
-		confChangeC:      make([]chan raftpb.ConfChange, len(peers)),
-		snapshotterReady: make([]<-chan *snap.Snapshotter, len(peers)),
-		kvStores:         make([]*memory.Memory, len(peers)),
-		servers:          make([]*etcdapi.Server, len(peers)),
-		clients:          make([]*clientv3.Client, len(peers)),
+	c := testutil.StartCluster(t, n, testutil.EngineMemory)
+	clus := &etcdCluster{Cluster: c, clients: make([]*clientv3.Client, n)}
+	for i := range clus.clients {
+		clus.clients[i] = c.Client(i + 1)
 	}
-
-	// Create Raft nodes
-	for i := range clus.peers {
-		// Clean up data directory (raft.NewNode uses "data/memory/{id}" path)
-		os.RemoveAll(fmt.Sprintf("data/memory/%d", i+1))
-		clus.proposeC[i] = make(chan string, 1)
-		clus.confChangeC[i] = make(chan raftpb.ConfChange, 1)
-
-		// Create test config for this node
-		cfg := NewTestConfig(uint64(i+1), 1, fmt.Sprintf(":920%d", i+1))
-
-		var kvs *memory.Memory
-		getSnapshot := func() ([]byte, error) {
-			if kvs == nil {
-				return nil, nil
-			}
-			return kvs.GetSnapshot()
-		}
-		clus.commitC[i], clus.errorC[i], clus.snapshotterReady[i], _ = raft.NewNode(
-			i+1, clus.peers, false, getSnapshot, clus.proposeC[i], clus.confChangeC[i], "memory", cfg,
-		)
-	}
-
-	// Create KV stores and etcd servers
-	for i := range clus.peers {
-		kvs := memory.NewMemory(
-			<-clus.snapshotterReady[i],
-			clus.proposeC[i],
-			clus.commitC[i],
-			clus.errorC[i],
-		)
-		clus.kvStores[i] = kvs
-
-		// Find available port
-		listener, err := net.Listen("tcp", "127.0.0.1:0")
-		require.NoError(t, err)
-		addr := listener.Addr().String()
-		listener.Close()
-
-		server, err := etcdapi.NewServer(etcdapi.ServerConfig{
-			Store:     kvs,
-			Address:   addr,
-			ClusterID: 1000,
-			MemberID:  uint64(i + 1),
-		})
-		require.NoError(t, err)
-		clus.servers[i] = server
-
-		// Start server in background
-		go func(srv *etcdapi.Server) {
-			if err := srv.Start(); err != nil {
-				t.Logf("Server start error: %v", err)
-			}
-		}(server)
-
-		// Create client
-		cli, err := clientv3.New(clientv3.Config{
-			Endpoints:   []string{addr},
-			DialTimeout: 5 * time.Second,
-		})
-		require.NoError(t, err)
-		clus.clients[i] = cli
-	}
-
-	// Wait for cluster to stabilize
-	time.Sleep(3 * time.Second)
-
 	return clus
 }
 
-// Close closes all cluster resources
+// Close stops all members; t.Cleanup also closes the cluster if a test fails early
 func (clus *etcdCluster) Close(t *testing.T) {
-	t.Log("closing etcd cluster...")
-
-	// Close clients first
-	for _, cli := range clus.clients {
-		if cli != nil {
-			cli.Close()
-		}
-	}
-
-	// Stop servers
-	for _, srv := range clus.servers {
-		if srv != nil {
-			srv.Stop()
-		}
-	}
-
-	// Close Raft nodes
-	for i := range clus.peers {
-		// Drain pending commits
-		go func(i int) {
-			for range clus.commitC[i] {
-				// drain
-			}
-		}(i)
-		close(clus.proposeC[i])
-		// Wait for channel to close
-		<-clus.errorC[i]
-		// Clean data (raft.NewNode uses "data/memory/{id}" path)
-		os.RemoveAll(fmt.Sprintf("data/memory/%d", i+1))
-	}
-
-	t.Log("closing etcd cluster [done]")
+	clus.Cluster.Close()
 }
 
 // TestEtcdMemorySingleNodeOperations tests basic single-node operations
@@ -237,7 +114,7 @@ func TestEtcdMemoryClusterBasicConsistency(t *testing.T) {
 		require.NoError(t, err)
 
 		// Wait for replication
-		time.Sleep(2 * time.Second)
+		clus.WaitForReplication()
 
 		// Read from all nodes and verify consistency
 		for i := 0; i < numNodes; i++ {
@@ -267,7 +144,7 @@ func TestEtcdMemoryClusterBasicConsistency(t *testing.T) {
 		}
 
 		// Wait for replication
-		time.Sleep(2 * time.Second)
+		clus.WaitForReplication()
 
 		// Verify all nodes have all keys with correct values
 		for _, td := range testData {
@@ -315,7 +192,7 @@ func TestEtcdMemoryClusterConcurrentOperations(t *testing.T) {
 		}
 
 		// Wait for replication
-		time.Sleep(3 * time.Second)
+		clus.WaitForReplication()
 
 		// Verify all nodes have all keys
 		for i := 0; i < numWrites; i++ {
@@ -352,7 +229,7 @@ func TestEtcdMemoryClusterUpdateOperations(t *testing.T) {
 			require.NoError(t, err)
 
 			// Wait for replication
-			time.Sleep(2 * time.Second)
+			clus.WaitForReplication()
 
 			// Verify all nodes have the latest value
 			for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"sync"
 	"testing"
-	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 
@@ -52,7 +51,7 @@ func TestEtcdRocksDBClusterDataConsistencyAfterWrites(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes have the same data
 	for i := 0; i < numNodes; i++ {
@@ -89,7 +88,7 @@ func TestEtcdRocksDBClusterSequentialWrites(t *testing.T) {
 	}
 
 	// Wait for all writes to replicate
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes see all writes
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -129,7 +128,7 @@ func TestEtcdRocksDBClusterRangeQueryConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Query with prefix from each node and verify results are consistent
 	var results [][]string
@@ -170,7 +169,7 @@ func TestEtcdRocksDBClusterDeleteConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes have the keys
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -190,7 +189,7 @@ func TestEtcdRocksDBClusterDeleteConsistency(t *testing.T) {
 	}
 
 	// Wait for delete replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes no longer have the keys
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
@@ -261,7 +260,7 @@ func TestEtcdRocksDBClusterConcurrentMixedOperations(t *testing.T) {
 	}
 
 	// Wait for operations to propagate
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Basic sanity check: verify cluster is still consistent
 	// Write a test key to one node and verify all nodes can read it
@@ -270,7 +269,7 @@ func TestEtcdRocksDBClusterConcurrentMixedOperations(t *testing.T) {
 	_, err := clus.clients[0].Put(ctx, testKey, testValue)
 	require.NoError(t, err)
 
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
 		resp, err := clus.clients[nodeIdx].Get(ctx, testKey)
@@ -301,7 +300,7 @@ func TestEtcdRocksDBClusterRevisionConsistency(t *testing.T) {
 	}
 
 	// Wait for replication
-	time.Sleep(3 * time.Second)
+	clus.WaitForReplication()
 
 	// Get revision from each node
 	var revisions []int64
@@ -349,7 +348,7 @@ func TestEtcdRocksDBClusterTransactionConsistency(t *testing.T) {
 	require.NoError(t, err)
 
 	// Wait for replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Execute transaction on node 1
 	txn := clus.clients[1].Txn(ctx).
@@ -362,7 +361,7 @@ func TestEtcdRocksDBClusterTransactionConsistency(t *testing.T) {
 	assert.True(t, txnResp.Succeeded, "Transaction should succeed")
 
 	// Wait for replication
-	time.Sleep(2 * time.Second)
+	clus.WaitForReplication()
 
 	// Verify all nodes see the updated value
 	for nodeIdx := 0; nodeIdx < numNodes; nodeIdx++ {
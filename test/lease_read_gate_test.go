@@ -0,0 +1,66 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/pkg/config"
+	"metaStore/pkg/featuregate"
+	"metaStore/test/testutil"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLeaseReadGateOffUsesReadIndex 关闭 LeaseRead 功能开关后，follower 上的
+// 线性一致读仍然经过 ReadIndex，而不是直接读取本地可能过期的状态
+func TestLeaseReadGateOffUsesReadIndex(t *testing.T) {
+	gate, err := featuregate.New(map[string]bool{string(featuregate.LeaseRead): false})
+	require.NoError(t, err)
+
+	c := testutil.StartCluster(t, 3, testutil.EngineMemory, func(cfg *config.Config) {
+		cfg.ApplyFeatureGates(gate)
+	})
+	leader := c.WaitForLeader()
+	follower := c.Nodes[leader.ID%len(c.Nodes)]
+
+	require.Nil(t, follower.Raft().LeaseManager(), "lease manager must not start with the gate off")
+	rim := follower.Raft().ReadIndexManager()
+	require.NotNil(t, rim, "ReadIndex must stay available with the gate off")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = c.Client(leader.ID).Put(ctx, "gate-key", "v1")
+	require.NoError(t, err)
+
+	before := rim.Stats().SlowPathReads
+	resp, err := c.Client(follower.ID).Get(ctx, "gate-key")
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "v1", string(resp.Kvs[0].Value))
+	assert.Greater(t, rim.Stats().SlowPathReads, before, "follower read must go through ReadIndex")
+
+	// serializable 读取直接访问本地状态
+	before = rim.Stats().SlowPathReads
+	_, err = c.Client(follower.ID).Get(ctx, "gate-key", clientv3.WithSerializable())
+	require.NoError(t, err)
+	assert.Equal(t, before, rim.Stats().SlowPathReads)
+}
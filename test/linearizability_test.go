@@ -0,0 +1,137 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"metaStore/pkg/history"
+	"metaStore/test/testutil"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLinearizabilityMemory runs concurrent clients against every member of a
+// 3-node cluster and checks the recorded history for linearizability.
+// Set METASTORE_HISTORY_FILE to keep the history for external checkers.
+func TestLinearizabilityMemory(t *testing.T) {
+	testLinearizability(t, testutil.EngineMemory)
+}
+
+func TestLinearizabilityRocksDB(t *testing.T) {
+	testLinearizability(t, testutil.EngineRocksDB)
+}
+
+func testLinearizability(t *testing.T, engine testutil.Engine) {
+	if testing.Short() {
+		t.Skip("skipping linearizability test in short mode")
+	}
+
+	const (
+		numWorkers       = 6
+		opsPerWorker     = 60
+		numKeys          = 3
+		operationTimeout = 2 * time.Second
+	)
+
+	c := testutil.StartCluster(t, 3, engine)
+
+	path := os.Getenv("METASTORE_HISTORY_FILE")
+	if path == "" {
+		path = filepath.Join(t.TempDir(), "history.jsonl")
+	}
+	rec, err := history.NewRecorder(path)
+	require.NoError(t, err)
+
+	clients := make([]*clientv3.Client, len(c.Nodes))
+	for i := range clients {
+		clients[i] = c.Client(i + 1)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			// 客户端均匀分布到所有节点，读写同时经过 leader 和 follower
+			nodeID := w%len(c.Nodes) + 1
+			cli := clients[nodeID-1]
+			node := fmt.Sprintf("client%d@%d", w, nodeID)
+			rnd := rand.New(rand.NewSource(int64(w)))
+			last := ""
+
+			for i := 0; i < opsPerWorker; i++ {
+				key := fmt.Sprintf("lin-key-%d", rnd.Intn(numKeys))
+				ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+
+				switch p := rnd.Intn(10); {
+				case p < 4:
+					op := rec.Invoke(node, history.FuncRead, key, nil)
+					resp, err := cli.Get(ctx, key)
+					var value interface{}
+					if err == nil && len(resp.Kvs) > 0 {
+						value = string(resp.Kvs[0].Value)
+						last = value.(string)
+					}
+					op.Complete(value, err)
+
+				case p < 7:
+					value := fmt.Sprintf("w%d-%d", w, i)
+					op := rec.Invoke(node, history.FuncWrite, key, value)
+					_, err := cli.Put(ctx, key, value)
+					op.Complete(value, err)
+					last = value
+
+				case p < 8:
+					op := rec.Invoke(node, history.FuncDelete, key, nil)
+					_, err := cli.Delete(ctx, key)
+					op.Complete(nil, err)
+
+				default:
+					cas := []string{last, fmt.Sprintf("c%d-%d", w, i)}
+					op := rec.Invoke(node, history.FuncCAS, key, cas)
+					resp, err := cli.Txn(ctx).
+						If(clientv3.Compare(clientv3.Value(key), "=", cas[0])).
+						Then(clientv3.OpPut(key, cas[1])).
+						Commit()
+					if err == nil && !resp.Succeeded {
+						op.Fail(nil)
+					} else {
+						op.Complete(cas, err)
+					}
+				}
+				cancel()
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.NoError(t, rec.Close())
+
+	events, err := history.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 2*numWorkers*opsPerWorker)
+
+	require.NoError(t, history.CheckLinearizable(events), "history written to %s", path)
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	rocksdbstore "metaStore/internal/rocksdb"
+
+	"github.com/stretchr/testify/require"
+)
+
+// legacyGobProposal 最早的版本提交的 gob 编码 KV
+func legacyGobProposal(t *testing.T, key, value string) string {
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(kvstore.KV{Key: key, Val: value}))
+	return buf.String()
+}
+
+// jsonProposal 以 JSON 编码提案，旧版本（或 enable_protobuf 关闭的成员）的格式
+func jsonProposal(t *testing.T, op interface{}) string {
+	data, err := json.Marshal(op)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// waitForValues 等待所有成员都应用了 expected 中的全部 key
+func waitForValues(t *testing.T, stores []kvstore.Store, expected map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i, store := range stores {
+		for key, value := range expected {
+			for {
+				resp, err := store.Range(ctx, key, "", 0, 0)
+				require.NoError(t, err, "member %d", i+1)
+				if len(resp.Kvs) == 1 {
+					require.Equal(t, value, string(resp.Kvs[0].Value), "member %d key %s", i+1, key)
+					break
+				}
+				select {
+				case <-ctx.Done():
+					t.Fatalf("member %d did not apply %s", i+1, key)
+				case <-time.After(50 * time.Millisecond):
+				}
+			}
+		}
+	}
+}
+
+// TestRollingUpgradeMemoryMixedCluster 3 成员的 memory 集群，成员 3 仍运行旧版本：
+// 它的 JSON 和 gob 提案与新版本成员的 Protobuf 提案在所有成员上以相同的结果应用
+func TestRollingUpgradeMemoryMixedCluster(t *testing.T) {
+	const numNodes = 3
+	clus := newCluster(numNodes)
+	defer clus.closeNoErrors(t)
+
+	stores := make([]kvstore.Store, numNodes)
+	for i := range stores {
+		stores[i] = memory.NewMemory(<-clus.snapshotterReady[i], clus.proposeC[i], clus.commitC[i], clus.errorC[i])
+	}
+	time.Sleep(3 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	expected := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		key, value := fmt.Sprintf("upgrade/new-%d", i), fmt.Sprintf("pb-%d", i)
+		_, _, err := stores[i].PutWithLease(ctx, key, value, 0)
+		require.NoError(t, err)
+		expected[key] = value
+	}
+
+	clus.proposeC[2] <- jsonProposal(t, memory.RaftOperation{Type: "PUT", Key: "upgrade/old-json", Value: "json"})
+	clus.proposeC[2] <- legacyGobProposal(t, "upgrade/old-gob", "gob")
+	expected["upgrade/old-json"] = "json"
+	expected["upgrade/old-gob"] = "gob"
+
+	waitForValues(t, stores, expected)
+	for i := 1; i < numNodes; i++ {
+		require.Equal(t, stores[0].CurrentRevision(), stores[i].CurrentRevision(), "member %d revision", i+1)
+	}
+}
+
+// TestRollingUpgradeRocksDBMixedCluster 3 成员的 rocksdb 集群，成员 3 仍以 JSON 提案
+func TestRollingUpgradeRocksDBMixedCluster(t *testing.T) {
+	const numNodes = 3
+	clus := newRocksDBCluster(numNodes)
+	defer clus.closeNoErrors(t)
+
+	stores := make([]kvstore.Store, numNodes)
+	for i := range stores {
+		stores[i] = rocksdbstore.NewRocksDB(clus.dbs[i], <-clus.snapshotterReady[i], clus.proposeC[i], clus.commitC[i], clus.errorC[i])
+	}
+	time.Sleep(3 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	expected := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		key, value := fmt.Sprintf("upgrade/new-%d", i), fmt.Sprintf("pb-%d", i)
+		_, _, err := stores[i].PutWithLease(ctx, key, value, 0)
+		require.NoError(t, err)
+		expected[key] = value
+	}
+
+	clus.proposeC[2] <- jsonProposal(t, rocksdbstore.RaftOperation{Type: "PUT", Key: "upgrade/old-json", Value: "json"})
+	clus.proposeC[2] <- jsonProposal(t, rocksdbstore.RaftOperation{Type: "DELETE", Key: "upgrade/new-0"})
+	clus.proposeC[2] <- legacyGobProposal(t, "upgrade/old-gob", "gob")
+	delete(expected, "upgrade/new-0")
+	expected["upgrade/old-json"] = "json"
+	expected["upgrade/old-gob"] = "gob"
+
+	waitForValues(t, stores, expected)
+	for i, store := range stores {
+		resp, err := store.Range(ctx, "upgrade/new-0", "", 0, 0)
+		require.NoError(t, err)
+		require.Empty(t, resp.Kvs, "member %d applied the JSON delete", i+1)
+		require.Equal(t, stores[0].CurrentRevision(), store.CurrentRevision(), "member %d revision", i+1)
+	}
+}
@@ -0,0 +1,449 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides a reusable in-process cluster harness for
+// integration tests. Readiness is signalled by raft state and listener
+// binding rather than fixed sleeps, so tests stay fast and deterministic.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	etcdapi "metaStore/api/etcd"
+	"metaStore/internal/kvstore"
+	"metaStore/internal/memory"
+	"metaStore/internal/raft"
+	"metaStore/internal/rocksdb"
+	"metaStore/pkg/config"
+
+	"github.com/linxGnu/grocksdb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	goraft "go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+)
+
+// Engine 存储引擎类型
+type Engine string
+
+const (
+	EngineMemory  Engine = "memory"
+	EngineRocksDB Engine = "rocksdb"
+)
+
+// DefaultTimeout is used by helpers that wait for cluster state changes
+var DefaultTimeout = 10 * time.Second
+
+// Node is a single cluster member with its raft node, store and etcd server
+type Node struct {
+	ID         int
+	Store      kvstore.Store
+	Server     *etcdapi.Server
+	ClientAddr string
+
+	cluster     *Cluster
+	raftNode    raft.PartitionableNode
+	proposeC    chan string
+	confChangeC chan raftpb.ConfChange
+	errorC      <-chan error
+	db          *grocksdb.DB
+	rocksStore  *rocksdb.RocksDB
+	client      *clientv3.Client
+	running     bool
+	partitioned bool
+}
+
+// Cluster is an in-process cluster of n nodes sharing one storage engine
+type Cluster struct {
+	Engine Engine
+	Peers  []string
+	Nodes  []*Node
+
+	t       testing.TB
+	dataDir string // 数据根目录（t.TempDir()），每个节点使用 <dataDir>/<id>
+	opts    []func(*config.Config)
+}
+
+// StartCluster starts an n-node cluster on the given engine and blocks until
+// a leader is elected. The cluster is closed automatically via t.Cleanup.
+// opts are applied to every node's config (e.g. lowering tick intervals).
+func StartCluster(t testing.TB, n int, engine Engine, opts ...func(*config.Config)) *Cluster {
+	t.Helper()
+
+	if engine != EngineMemory && engine != EngineRocksDB {
+		t.Fatalf("testutil: unknown engine %q", engine)
+	}
+
+	c := &Cluster{
+		Engine:  engine,
+		Peers:   allocateURLs(t, n),
+		Nodes:   make([]*Node, n),
+		t:       t,
+		dataDir: t.TempDir(),
+		opts:    opts,
+	}
+
+	for i := range c.Nodes {
+		c.Nodes[i] = &Node{
+			ID:         i + 1,
+			ClientAddr: allocateAddr(t),
+			cluster:    c,
+		}
+	}
+	for _, node := range c.Nodes {
+		node.start()
+	}
+
+	t.Cleanup(c.Close)
+
+	c.WaitForLeader()
+	return c
+}
+
+// Node returns the member with the given 1-based ID
+func (c *Cluster) Node(id int) *Node {
+	c.t.Helper()
+	if id < 1 || id > len(c.Nodes) {
+		c.t.Fatalf("testutil: node %d out of range [1, %d]", id, len(c.Nodes))
+	}
+	return c.Nodes[id-1]
+}
+
+// Raft returns the member's raft node, e.g. to inspect lease and ReadIndex state
+func (n *Node) Raft() raft.TestableNode {
+	return n.raftNode
+}
+
+// WaitForLeader blocks until every running, non-partitioned node agrees on the
+// same leader and returns that leader
+func (c *Cluster) WaitForLeader() *Node {
+	c.t.Helper()
+
+	var leader *Node
+	ok := c.waitFor(DefaultTimeout, func() bool {
+		leader = c.leader()
+		return leader != nil
+	})
+	if !ok {
+		c.t.Fatalf("testutil: timeout waiting for leader election")
+	}
+	return leader
+}
+
+// WaitForApplied blocks until every running node has applied at least index
+func (c *Cluster) WaitForApplied(index uint64) {
+	c.t.Helper()
+
+	ok := c.waitFor(DefaultTimeout, func() bool {
+		for _, node := range c.Nodes {
+			if node.running && node.raftNode.Status().Applied < index {
+				return false
+			}
+		}
+		return true
+	})
+	if !ok {
+		c.t.Fatalf("testutil: timeout waiting for applied index %d", index)
+	}
+}
+
+// WaitForReplication blocks until every running, non-partitioned node's state
+// machine has applied all writes completed on any of them, so a read served
+// locally by a follower observes those writes
+func (c *Cluster) WaitForReplication() {
+	c.t.Helper()
+
+	var index uint64
+	var stores []kvstore.ReadAfterWriter
+	for _, node := range c.Nodes {
+		if !node.running || node.partitioned {
+			continue
+		}
+		raw, ok := kvstore.As[kvstore.ReadAfterWriter](node.Store)
+		if !ok {
+			c.t.Fatalf("testutil: node %d store does not track its applied index", node.ID)
+		}
+		index = max(index, raw.WriteIndex())
+		stores = append(stores, raw)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	for _, raw := range stores {
+		if err := raw.WaitApplied(ctx, index); err != nil {
+			c.t.Fatalf("testutil: timeout waiting for applied index %d: %v", index, err)
+		}
+	}
+}
+
+// StopNode stops a member but keeps its data so it can be restarted
+func (c *Cluster) StopNode(id int) {
+	c.t.Helper()
+	c.Node(id).stop()
+}
+
+// RestartNode stops a member (if running) and starts it again from its
+// existing data, then waits for the cluster to agree on a leader
+func (c *Cluster) RestartNode(id int) {
+	c.t.Helper()
+
+	node := c.Node(id)
+	node.stop()
+	node.start()
+	c.WaitForLeader()
+}
+
+// PartitionNode isolates a member from all peers by pausing its raft transport
+func (c *Cluster) PartitionNode(id int) {
+	c.t.Helper()
+
+	node := c.Node(id)
+	if !node.running {
+		c.t.Fatalf("testutil: cannot partition stopped node %d", id)
+	}
+	node.raftNode.PauseTransport()
+	node.partitioned = true
+}
+
+// HealPartition reconnects a previously partitioned member
+func (c *Cluster) HealPartition(id int) {
+	c.t.Helper()
+
+	node := c.Node(id)
+	if node.running && node.partitioned {
+		node.raftNode.ResumeTransport()
+	}
+	node.partitioned = false
+}
+
+// Client returns an etcd client connected to the given member
+func (c *Cluster) Client(id int) *clientv3.Client {
+	c.t.Helper()
+
+	node := c.Node(id)
+	if node.client == nil {
+		cli, err := clientv3.New(clientv3.Config{
+			Endpoints:   []string{node.ClientAddr},
+			DialTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			c.t.Fatalf("testutil: failed to create client for node %d: %v", id, err)
+		}
+		node.client = cli
+	}
+	return node.client
+}
+
+// Close stops all members; their data is removed with the test's temp dir
+func (c *Cluster) Close() {
+	for _, node := range c.Nodes {
+		if node == nil {
+			continue
+		}
+		if node.client != nil {
+			node.client.Close()
+			node.client = nil
+		}
+		node.stop()
+	}
+}
+
+// leader returns the leader if all running nodes agree on it
+func (c *Cluster) leader() *Node {
+	var leaderID uint64
+	for _, node := range c.Nodes {
+		if !node.running || node.partitioned {
+			continue
+		}
+		status := node.raftNode.Status()
+		if status.LeaderID == 0 {
+			return nil
+		}
+		if leaderID != 0 && status.LeaderID != leaderID {
+			return nil
+		}
+		leaderID = status.LeaderID
+	}
+	if leaderID == 0 || int(leaderID) > len(c.Nodes) {
+		return nil
+	}
+
+	leader := c.Nodes[leaderID-1]
+	if !leader.running || leader.partitioned || leader.raftNode.Status().State != goraft.StateLeader.String() {
+		return nil
+	}
+	return leader
+}
+
+// waitFor polls cond until it returns true or timeout expires
+func (c *Cluster) waitFor(timeout time.Duration, cond func() bool) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if cond() {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// start creates the raft node, store and etcd server for this member
+func (n *Node) start() {
+	c := n.cluster
+	c.t.Helper()
+
+	cfg := config.DefaultConfig(1, uint64(n.ID), n.ClientAddr)
+	cfg.Server.Monitoring.EnablePrometheus = false
+	cfg.Server.Auth.BcryptCost = 4
+	cfg.Server.Reliability.DrainTimeout = 100 * time.Millisecond
+	for _, opt := range c.opts {
+		opt(cfg)
+	}
+
+	n.proposeC = make(chan string, 1)
+	n.confChangeC = make(chan raftpb.ConfChange, 1)
+
+	switch c.Engine {
+	case EngineMemory:
+		var kvs *memory.Memory
+		getSnapshot := func() ([]byte, error) {
+			if kvs == nil {
+				return nil, nil
+			}
+			return kvs.GetSnapshot()
+		}
+		commitC, errorC, snapshotterReady, raftNode := raft.NewNode(
+			n.ID, c.Peers, false, getSnapshot, n.proposeC, n.confChangeC, c.dataDir, cfg,
+		)
+		kvs = memory.NewMemory(<-snapshotterReady, n.proposeC, commitC, errorC)
+		kvs.SetRaftNode(raftNode, uint64(n.ID))
+
+		n.Store = kvs
+		n.raftNode = raftNode
+		n.errorC = errorC
+
+	case EngineRocksDB:
+		dataDir := filepath.Join(c.dataDir, fmt.Sprintf("%d", n.ID))
+		dbPath := filepath.Join(dataDir, "kv")
+		if err := os.MkdirAll(dbPath, 0o755); err != nil {
+			c.t.Fatalf("testutil: failed to create RocksDB directory: %v", err)
+		}
+		db, err := rocksdb.Open(dbPath, &cfg.Server.RocksDB)
+		if err != nil {
+			c.t.Fatalf("testutil: failed to open RocksDB: %v", err)
+		}
+
+		var kvs *rocksdb.RocksDB
+		getSnapshot := func() ([]byte, error) {
+			if kvs == nil {
+				return nil, nil
+			}
+			return kvs.GetSnapshot()
+		}
+		commitC, errorC, snapshotterReady, raftNode := raft.NewNodeRocksDB(
+			n.ID, c.Peers, false, getSnapshot, n.proposeC, n.confChangeC, db, dataDir, cfg,
+		)
+		kvs = rocksdb.NewRocksDB(db, <-snapshotterReady, n.proposeC, commitC, errorC)
+		kvs.SetRaftNode(raftNode, uint64(n.ID))
+
+		n.Store = kvs
+		n.raftNode = raftNode
+		n.errorC = errorC
+		n.db = db
+		n.rocksStore = kvs
+	}
+
+	// NewServer binds the listener synchronously, so the client address is
+	// reachable as soon as it returns
+	server, err := etcdapi.NewServer(etcdapi.ServerConfig{
+		Store:        n.Store,
+		Address:      n.ClientAddr,
+		ClusterID:    cfg.Server.ClusterID,
+		MemberID:     uint64(n.ID),
+		ClusterPeers: c.Peers,
+		ConfChangeC:  n.confChangeC,
+		Config:       cfg,
+	})
+	if err != nil {
+		c.t.Fatalf("testutil: failed to create etcd server for node %d: %v", n.ID, err)
+	}
+	n.Server = server
+	go server.Start()
+
+	n.running = true
+}
+
+// stop shuts down the etcd server and raft node, keeping the data directory
+func (n *Node) stop() {
+	if !n.running {
+		return
+	}
+	n.running = false
+	n.partitioned = false
+
+	if n.client != nil {
+		n.client.Close()
+		n.client = nil
+	}
+	n.Server.Stop()
+
+	// Closing proposeC stops raft, which closes errorC once the WAL is released
+	close(n.proposeC)
+	select {
+	case <-n.errorC:
+	case <-time.After(DefaultTimeout):
+		n.cluster.t.Errorf("testutil: timeout waiting for node %d to stop", n.ID)
+	}
+
+	if n.rocksStore != nil {
+		n.rocksStore.Close()
+		n.rocksStore = nil
+	}
+	if n.db != nil {
+		n.db.Close()
+		n.db = nil
+	}
+}
+
+// allocateURLs reserves n loopback ports and returns them as raft peer URLs
+func allocateURLs(t testing.TB, n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = "http://" + allocateAddr(t)
+	}
+	return urls
+}
+
+// allocateAddr returns a free loopback address
+func allocateAddr(t testing.TB) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("testutil: failed to allocate port: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 The axfor Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"metaStore/internal/kvstore"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterPartitionAndRestart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping cluster test in short mode")
+	}
+
+	c := StartCluster(t, 3, EngineMemory)
+	leader := c.WaitForLeader()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := c.Client(leader.ID).Put(ctx, "testutil-key", "v1")
+	require.NoError(t, err)
+
+	// Isolating the leader forces the remaining majority to elect a new one
+	c.PartitionNode(leader.ID)
+	newLeader := c.WaitForLeader()
+	require.NotEqual(t, leader.ID, newLeader.ID)
+
+	c.HealPartition(leader.ID)
+	c.WaitForLeader()
+
+	// A restarted follower replays its WAL and catches up
+	follower := c.Node(leader.ID%3 + 1)
+	c.RestartNode(follower.ID)
+	require.Eventually(t, func() bool {
+		resp, err := follower.Store.Range(ctx, "testutil-key", "", 0, 0)
+		return err == nil && len(resp.Kvs) == 1 && string(resp.Kvs[0].Value) == "v1"
+	}, DefaultTimeout, 20*time.Millisecond)
+}
+
+func TestClusterWaitForReplication(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping cluster test in short mode")
+	}
+
+	c := StartCluster(t, 3, EngineMemory)
+	leader := c.WaitForLeader()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := c.Client(leader.ID).Put(ctx, "testutil-replicated", "v1")
+	require.NoError(t, err)
+
+	// Once replicated, a serializable read on every member sees the write
+	c.WaitForReplication()
+	for _, node := range c.Nodes {
+		resp, err := node.Store.Range(kvstore.WithSerializable(ctx), "testutil-replicated", "", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, resp.Kvs, 1, "node %d", node.ID)
+		require.Equal(t, "v1", string(resp.Kvs[0].Value))
+	}
+}